@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"crypto/tls"
+	"net"
+	"slices"
+
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/internal/url"
+)
+
+// defaultAgentPort is the port of the agents when their URL does not specify one
+const defaultAgentPort = "9001"
+
+// GetPublicKeyPin returns the pin of the public key presented by the agent listening on the URL
+func GetPublicKeyPin(endpointURL string, tlsConfig *tls.Config) (string, error) {
+	parsedURL, err := url.ParseURL(endpointURL)
+	if err != nil {
+		return "", err
+	}
+
+	port := parsedURL.Port()
+	if port == "" {
+		port = defaultAgentPort
+	}
+
+	return crypto.FetchPublicKeyPin(net.JoinHostPort(parsedURL.Hostname(), port), tlsConfig)
+}
+
+// GetPublicKeyPins returns the pins of the public keys presented by the agents listening on the URLs, the agents
+// that cannot be reached being skipped. It fails only when none of them can be reached.
+func GetPublicKeyPins(endpointURLs []string, tlsConfig *tls.Config) ([]string, error) {
+	var pins []string
+	var lastErr error
+
+	for _, endpointURL := range endpointURLs {
+		pin, err := GetPublicKeyPin(endpointURL, tlsConfig)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if !slices.Contains(pins, pin) {
+			pins = append(pins, pin)
+		}
+	}
+
+	if len(pins) == 0 {
+		return nil, lastErr
+	}
+
+	return pins, nil
+}
@@ -7,10 +7,10 @@ import (
 	"io"
 	"net/http"
 	"strconv"
-	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/internal/url"
+	"github.com/portainer/portainer/api/timeouts"
 )
 
 // GetAgentVersionAndPlatform returns the agent version and platform
@@ -18,7 +18,7 @@ import (
 // it sends a ping to the agent and parses the version and platform from the headers
 func GetAgentVersionAndPlatform(endpointUrl string, tlsConfig *tls.Config) (portainer.AgentPlatform, string, error) {
 	httpCli := &http.Client{
-		Timeout: 3 * time.Second,
+		Timeout: timeouts.Current().EndpointPing,
 	}
 
 	if tlsConfig != nil {
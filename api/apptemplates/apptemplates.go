@@ -0,0 +1,245 @@
+package apptemplates
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/contenttrust"
+	"github.com/portainer/portainer/api/http/client"
+	"github.com/portainer/portainer/api/timeouts"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultSourceName is the name of the source of the templates of the templates URL
+const DefaultSourceName = "default"
+
+var errNotFound = errors.New("not found")
+
+// List represents the app templates merged from their sources
+type List struct {
+	Version   string               `json:"version"`
+	Templates []portainer.Template `json:"templates"`
+}
+
+// ValidateSettings checks the additional sources of the app templates and the pinned templates
+func ValidateSettings(settings portainer.AppTemplatesSettings) error {
+	names := map[string]bool{DefaultSourceName: true}
+
+	for _, source := range settings.Sources {
+		if source.Name == "" {
+			return errors.New("invalid template source, the name is required")
+		}
+
+		if names[source.Name] {
+			return fmt.Errorf("invalid template source, the name %q is already used", source.Name)
+		}
+		names[source.Name] = true
+
+		u, err := url.Parse(source.URL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid URL of the template source %q", source.Name)
+		}
+
+		switch source.Type {
+		case portainer.TemplateSourceURL:
+			if u.Scheme != "http" && u.Scheme != "https" {
+				return fmt.Errorf("invalid URL of the template source %q, an absolute http or https URL is expected", source.Name)
+			}
+		case portainer.TemplateSourceGit:
+			if source.FilePath == "" {
+				return fmt.Errorf("invalid template source %q, the path to the templates file is required", source.Name)
+			}
+		default:
+			return fmt.Errorf("invalid type of the template source %q, it must be 1 (URL) or 2 (git)", source.Name)
+		}
+	}
+
+	for _, pinned := range settings.PinnedTemplates {
+		if pinned.Source == "" || pinned.Title == "" {
+			return errors.New("invalid pinned template, the source and the title are required")
+		}
+	}
+
+	return nil
+}
+
+// Fetcher retrieves the app templates from their sources
+type Fetcher struct {
+	httpClient  *http.Client
+	gitService  portainer.GitService
+	fileService portainer.FileService
+}
+
+// NewFetcher returns a fetcher of the app templates, the git service and the file service are used to retrieve the
+// templates hosted in git repositories
+func NewFetcher(gitService portainer.GitService, fileService portainer.FileService) *Fetcher {
+	return &Fetcher{
+		httpClient:  client.NewExternalClient(timeouts.Current().TemplateFetch),
+		gitService:  gitService,
+		fileService: fileService,
+	}
+}
+
+// List returns the templates of the templates URL and of the enabled sources. A template whose title and type are
+// already used by a source with a higher precedence is skipped. The pinned templates are listed first, and the
+// templates of the sources requiring an approval are only listed to the non-administrator users once pinned.
+// The unreachable sources and the sources whose signature cannot be verified are skipped as long as one of the sources
+// is reachable.
+func (fetcher *Fetcher) List(settings *portainer.Settings, isAdmin bool) (*List, error) {
+	sources := make([]portainer.TemplateSource, 0, len(settings.AppTemplates.Sources)+1)
+	if settings.TemplatesURL != "" {
+		sources = append(sources, portainer.TemplateSource{
+			Name:    DefaultSourceName,
+			Type:    portainer.TemplateSourceURL,
+			URL:     settings.TemplatesURL,
+			Enabled: true,
+		})
+	}
+	sources = append(sources, settings.AppTemplates.Sources...)
+
+	list := &List{Templates: []portainer.Template{}}
+
+	fetched := false
+	var lastErr error
+
+	for _, source := range sources {
+		if !source.Enabled {
+			continue
+		}
+
+		sourceList, err := fetcher.fetch(source, settings.ContentTrust)
+		if err != nil {
+			log.Warn().Err(err).Str("source", source.Name).Msg("unable to retrieve the app templates of the source")
+			lastErr = err
+
+			continue
+		}
+
+		if !fetched {
+			list.Version = sourceList.Version
+			fetched = true
+		}
+
+		for _, template := range sourceList.Templates {
+			if slices.ContainsFunc(list.Templates, func(t portainer.Template) bool {
+				return t.Title == template.Title && t.Type == template.Type
+			}) {
+				continue
+			}
+
+			template.Source = source.Name
+			template.Pinned = slices.Contains(settings.AppTemplates.PinnedTemplates, portainer.TemplateReference{Source: source.Name, Title: template.Title})
+
+			if source.RequireApproval && !template.Pinned && !isAdmin {
+				continue
+			}
+
+			list.Templates = append(list.Templates, template)
+		}
+	}
+
+	if !fetched && lastErr != nil {
+		return nil, lastErr
+	}
+
+	slices.SortStableFunc(list.Templates, func(a, b portainer.Template) int {
+		switch {
+		case a.Pinned == b.Pinned:
+			return 0
+		case a.Pinned:
+			return -1
+		default:
+			return 1
+		}
+	})
+
+	// the identifiers of the templates are only unique within their source
+	for i := range list.Templates {
+		list.Templates[i].ID = portainer.TemplateID(i + 1)
+	}
+
+	return list, nil
+}
+
+func (fetcher *Fetcher) fetch(source portainer.TemplateSource, contentTrust portainer.ContentTrustSettings) (*List, error) {
+	var content []byte
+
+	switch source.Type {
+	case portainer.TemplateSourceGit:
+		projectPath, err := fetcher.fileService.GetTemporaryPath()
+		if err != nil {
+			return nil, err
+		}
+		defer fetcher.fileService.RemoveDirectory(projectPath)
+
+		if err := fetcher.gitService.CloneRepository(projectPath, source.URL, source.ReferenceName, "", "", false); err != nil {
+			return nil, errors.WithMessage(err, "unable to clone the git repository")
+		}
+
+		content, err = fetcher.fileService.GetFileContent(projectPath, source.FilePath)
+		if err != nil {
+			return nil, errors.WithMessage(err, "unable to read the templates file")
+		}
+
+		if err := contenttrust.VerifyFile(contentTrust, projectPath, source.FilePath); err != nil {
+			return nil, err
+		}
+	default:
+		var err error
+		content, err = fetcher.get(source.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		if contentTrust.Enforce || len(contentTrust.TrustedKeys) > 0 {
+			signatureURL, err := url.Parse(source.URL)
+			if err != nil {
+				return nil, err
+			}
+			signatureURL.Path += contenttrust.SignatureExtension
+
+			signature, err := fetcher.get(signatureURL.String())
+			if errors.Is(err, errNotFound) {
+				signature = nil
+			} else if err != nil {
+				return nil, errors.WithMessage(err, "unable to retrieve the signature of the templates")
+			}
+
+			if err := contenttrust.Verify(contentTrust, content, signature); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	list := &List{}
+	if err := json.Unmarshal(content, list); err != nil {
+		return nil, errors.WithMessage(err, "unable to parse the templates file")
+	}
+
+	return list, nil
+}
+
+// get returns the content served at a URL
+func (fetcher *Fetcher) get(rawURL string) ([]byte, error) {
+	resp, err := fetcher.httpClient.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, errNotFound
+	default:
+		return nil, fmt.Errorf("unexpected status code %d when retrieving %s", resp.StatusCode, rawURL)
+	}
+}
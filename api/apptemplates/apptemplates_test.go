@@ -0,0 +1,126 @@
+package apptemplates
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cloningGitService writes the templates file on clone
+type cloningGitService struct {
+	portainer.GitService
+	content string
+}
+
+func (service *cloningGitService) CloneRepository(destination, repositoryURL, referenceName, username, password string, tlsSkipVerify bool) error {
+	if err := os.MkdirAll(destination, 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(destination, "templates.json"), []byte(service.content), 0644)
+}
+
+func newTemplatesServer(t *testing.T, content string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(content))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func titles(templates []portainer.Template) []string {
+	result := make([]string, 0, len(templates))
+	for _, template := range templates {
+		result = append(result, template.Source+"/"+template.Title)
+	}
+
+	return result
+}
+
+func TestValidateSettings(t *testing.T) {
+	valid := portainer.AppTemplatesSettings{
+		Sources: []portainer.TemplateSource{
+			{Name: "internal", Type: portainer.TemplateSourceURL, URL: "https://templates.local/templates.json"},
+			{Name: "git", Type: portainer.TemplateSourceGit, URL: "https://github.com/org/templates", FilePath: "templates.json"},
+		},
+		PinnedTemplates: []portainer.TemplateReference{{Source: "internal", Title: "Nginx"}},
+	}
+	assert.NoError(t, ValidateSettings(valid))
+
+	for _, settings := range []portainer.AppTemplatesSettings{
+		{Sources: []portainer.TemplateSource{{Type: portainer.TemplateSourceURL, URL: "https://templates.local"}}},
+		{Sources: []portainer.TemplateSource{{Name: DefaultSourceName, Type: portainer.TemplateSourceURL, URL: "https://templates.local"}}},
+		{Sources: []portainer.TemplateSource{
+			{Name: "internal", Type: portainer.TemplateSourceURL, URL: "https://templates.local"},
+			{Name: "internal", Type: portainer.TemplateSourceURL, URL: "https://other.local"},
+		}},
+		{Sources: []portainer.TemplateSource{{Name: "internal", Type: portainer.TemplateSourceURL, URL: "ftp://templates.local"}}},
+		{Sources: []portainer.TemplateSource{{Name: "git", Type: portainer.TemplateSourceGit, URL: "https://github.com/org/templates"}}},
+		{Sources: []portainer.TemplateSource{{Name: "other", Type: 3, URL: "https://templates.local"}}},
+		{PinnedTemplates: []portainer.TemplateReference{{Title: "Nginx"}}},
+	} {
+		assert.Error(t, ValidateSettings(settings), "%+v", settings)
+	}
+}
+
+func TestFetcherList(t *testing.T) {
+	defaultSource := newTemplatesServer(t, `{"version": "2", "templates": [
+		{"id": 1, "type": 1, "title": "Nginx", "image": "nginx:latest"},
+		{"id": 2, "type": 1, "title": "Redis", "image": "redis:latest"}
+	]}`)
+	internalSource := newTemplatesServer(t, `{"version": "2", "templates": [
+		{"id": 1, "type": 1, "title": "Nginx", "image": "registry.local/nginx"},
+		{"id": 2, "type": 3, "title": "Billing", "repository": {"url": "https://git.local/billing", "stackfile": "docker-compose.yml"}}
+	]}`)
+	unreachableSource := newTemplatesServer(t, "")
+	unreachableSource.Close()
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	assert.NoError(t, err)
+
+	gitService := &cloningGitService{
+		GitService: testhelpers.NewGitService(nil, ""),
+		content:    `{"version": "2", "templates": [{"type": 1, "title": "Postgres"}, {"type": 1, "title": "MySQL"}]}`,
+	}
+
+	settings := &portainer.Settings{
+		TemplatesURL: defaultSource.URL,
+		AppTemplates: portainer.AppTemplatesSettings{
+			Sources: []portainer.TemplateSource{
+				{Name: "internal", Type: portainer.TemplateSourceURL, URL: internalSource.URL, Enabled: true},
+				{Name: "databases", Type: portainer.TemplateSourceGit, URL: "https://git.local/templates", FilePath: "templates.json", Enabled: true, RequireApproval: true},
+				{Name: "unreachable", Type: portainer.TemplateSourceURL, URL: unreachableSource.URL, Enabled: true},
+				{Name: "disabled", Type: portainer.TemplateSourceURL, URL: internalSource.URL},
+			},
+			PinnedTemplates: []portainer.TemplateReference{{Source: "databases", Title: "MySQL"}},
+		},
+	}
+
+	fetcher := NewFetcher(gitService, fileService)
+
+	list, err := fetcher.List(settings, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", list.Version)
+	assert.Equal(t, []string{"databases/MySQL", "default/Nginx", "default/Redis", "internal/Billing", "databases/Postgres"}, titles(list.Templates))
+	assert.Equal(t, "nginx:latest", list.Templates[1].Image, "the template of the source with the highest precedence should be kept")
+
+	for i, template := range list.Templates {
+		assert.Equal(t, portainer.TemplateID(i+1), template.ID)
+	}
+
+	list, err = fetcher.List(settings, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"databases/MySQL", "default/Nginx", "default/Redis", "internal/Billing"}, titles(list.Templates), "the templates requiring an approval should be hidden unless pinned")
+
+	_, err = fetcher.List(&portainer.Settings{TemplatesURL: unreachableSource.URL}, true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,172 @@
+// Package s3 is a minimal client of the object API of S3 and the S3 compatible storages, the requests are signed
+// with the AWS signature version 4
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/portainer/portainer/api/http/client"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/pkg/errors"
+)
+
+// unsignedPayload skips the signature of the body, which allows to stream the uploaded objects
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+type (
+	// Options represents the bucket addressed by the client
+	Options struct {
+		// URL of an S3 compatible storage, the AWS endpoint of the region is used when empty
+		Endpoint        string
+		Region          string
+		Bucket          string
+		AccessKeyID     string
+		SecretAccessKey string
+		// Whether the bucket is addressed in the path of the URLs rather than in the host name
+		ForcePathStyle bool
+	}
+
+	Client struct {
+		options     Options
+		credentials aws.CredentialsProvider
+		signer      *v4.Signer
+		client      *http.Client
+	}
+)
+
+func NewClient(options Options) *Client {
+	return &Client{
+		options:     options,
+		credentials: aws.NewCredentialsCache(credentials.NewStaticCredentialsProvider(options.AccessKeyID, options.SecretAccessKey, "")),
+		signer: v4.NewSigner(func(o *v4.SignerOptions) {
+			o.DisableURIPathEscaping = true
+		}),
+		client: client.NewExternalClient(0),
+	}
+}
+
+// PutObject uploads an object of a known size
+func (c *Client) PutObject(ctx context.Context, key string, body io.Reader, size int64) error {
+	req, err := c.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// GetObject downloads an object, the body must be closed by the caller
+func (c *Client) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// DeleteObject removes an object
+func (c *Client) DeleteObject(ctx context.Context, key string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+func (c *Client) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	objectURL, err := c.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, objectURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	creds, err := c.credentials.Retrieve(req.Context())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve the S3 credentials")
+	}
+
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	err = c.signer.SignHTTP(req.Context(), creds, req, unsignedPayload, "s3", c.options.Region, time.Now().UTC())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to sign the S3 request")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return nil, fmt.Errorf("S3 request %s %s failed with status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	return resp, nil
+}
+
+// objectURL addresses the bucket in the host name, unless the path style is forced
+func (c *Client) objectURL(key string) (string, error) {
+	endpoint := c.options.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", c.options.Region)
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid S3 endpoint %q", endpoint)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+
+	escapedKey := (&url.URL{Path: strings.TrimPrefix(key, "/")}).EscapedPath()
+
+	if c.options.ForcePathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + c.options.Bucket + "/" + escapedKey
+	} else {
+		u.Host = c.options.Bucket + "." + u.Host
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + escapedKey
+	}
+
+	return u.Scheme + "://" + u.Host + u.Path, nil
+}
@@ -0,0 +1,25 @@
+package s3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjectURL(t *testing.T) {
+	is := assert.New(t)
+
+	client := NewClient(Options{Region: "eu-west-1", Bucket: "backups"})
+	u, err := client.objectURL("portainer/data-1.tar.gz")
+	is.NoError(err)
+	is.Equal("https://backups.s3.eu-west-1.amazonaws.com/portainer/data-1.tar.gz", u)
+
+	client = NewClient(Options{Endpoint: "http://minio:9000/", Region: "us-east-1", Bucket: "backups", ForcePathStyle: true})
+	u, err = client.objectURL("/data 1.tar.gz")
+	is.NoError(err)
+	is.Equal("http://minio:9000/backups/data%201.tar.gz", u)
+
+	client = NewClient(Options{Endpoint: "minio:9000", Bucket: "backups"})
+	_, err = client.objectURL("data.tar.gz")
+	is.Error(err, "the endpoint must be a URL")
+}
@@ -2,7 +2,7 @@ package chisel
 
 import (
 	"encoding/base64"
-	"fmt"
+	"net"
 	"strconv"
 	"strings"
 )
@@ -14,7 +14,7 @@ import (
 func (service *Service) GenerateEdgeKey(url, host string, endpointIdentifier int) string {
 	keyInformation := []string{
 		url,
-		fmt.Sprintf("%s:%s", host, service.serverPort),
+		net.JoinHostPort(host, service.serverPort),
 		service.serverFingerprint,
 		strconv.Itoa(endpointIdentifier),
 	}
@@ -0,0 +1,22 @@
+package chisel
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateEdgeKey(t *testing.T) {
+	service := &Service{serverPort: "8000", serverFingerprint: "fingerprint"}
+
+	for host, tunnelAddr := range map[string]string{
+		"10.0.0.12":   "10.0.0.12:8000",
+		"portainer":   "portainer:8000",
+		"2001:db8::1": "[2001:db8::1]:8000",
+	} {
+		key, err := base64.RawStdEncoding.DecodeString(service.GenerateEdgeKey("https://portainer:9443", host, 1))
+		assert.NoError(t, err)
+		assert.Equal(t, "https://portainer:9443|"+tunnelAddr+"|fingerprint|1", string(key), "host %q", host)
+	}
+}
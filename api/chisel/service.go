@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httputil"
+	"strings"
 	"sync"
 	"time"
 
@@ -30,9 +33,11 @@ type Service struct {
 	serverFingerprint string
 	serverPort        string
 	tunnelDetailsMap  map[portainer.EndpointID]*portainer.TunnelDetails
+	tunnelCredentials map[portainer.EndpointID]string
 	dataStore         dataservices.DataStore
 	snapshotService   portainer.SnapshotService
 	chiselServer      *chserver.Server
+	tunnelProxy       *httputil.ReverseProxy
 	shutdownCtx       context.Context
 	ProxyManager      *proxy.Manager
 	mu                sync.Mutex
@@ -42,10 +47,11 @@ type Service struct {
 // NewService returns a pointer to a new instance of Service
 func NewService(dataStore dataservices.DataStore, shutdownCtx context.Context, fileService portainer.FileService) *Service {
 	return &Service{
-		tunnelDetailsMap: make(map[portainer.EndpointID]*portainer.TunnelDetails),
-		dataStore:        dataStore,
-		shutdownCtx:      shutdownCtx,
-		fileService:      fileService,
+		tunnelDetailsMap:  make(map[portainer.EndpointID]*portainer.TunnelDetails),
+		tunnelCredentials: make(map[portainer.EndpointID]string),
+		dataStore:         dataStore,
+		shutdownCtx:       shutdownCtx,
+		fileService:       fileService,
 	}
 }
 
@@ -138,11 +144,19 @@ func (service *Service) StartTunnelServer(addr, port string, snapshotService por
 	service.serverFingerprint = chiselServer.GetFingerprint()
 	service.serverPort = port
 
-	err = chiselServer.Start(addr, port)
+	// chisel joins the host and the port itself, the IPv6 literals must therefore be bracketed
+	addr = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+	host := addr
+	if ip := net.ParseIP(addr); ip != nil && ip.To4() == nil {
+		host = "[" + addr + "]"
+	}
+
+	err = chiselServer.Start(host, port)
 	if err != nil {
 		return err
 	}
 	service.chiselServer = chiselServer
+	service.tunnelProxy = newTunnelProxy(addr, port)
 
 	// TODO: work-around Chisel default behavior.
 	// By default, Chisel will allow anyone to connect if no user exists.
@@ -152,14 +166,23 @@ func (service *Service) StartTunnelServer(addr, port string, snapshotService por
 		return err
 	}
 
+	if err := service.restoreTunnels(); err != nil {
+		log.Warn().Err(err).Msg("unable to restore the tunnels")
+	}
+
 	service.snapshotService = snapshotService
 	go service.startTunnelVerificationLoop()
 
 	return nil
 }
 
-// StopTunnelServer stops tunnel http server
+// StopTunnelServer persists the state of the open tunnels, so that they can be restored on the next start,
+// then stops tunnel http server
 func (service *Service) StopTunnelServer() error {
+	if err := service.persistTunnels(); err != nil {
+		log.Warn().Err(err).Msg("unable to persist the state of the tunnels")
+	}
+
 	return service.chiselServer.Close()
 }
 
@@ -212,12 +235,9 @@ func (service *Service) startTunnelVerificationLoop() {
 		case <-ticker.C:
 			service.checkTunnels()
 		case <-service.shutdownCtx.Done():
+			// the tunnel server itself is stopped once the HTTP server has drained the in-flight requests
 			log.Debug().Msg("shutting down tunnel service")
 
-			if err := service.StopTunnelServer(); err != nil {
-				log.Debug().Err(err).Msg("stopped tunnel service")
-			}
-
 			ticker.Stop()
 			return
 		}
@@ -0,0 +1,146 @@
+package chisel
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/pkg/libcrypto"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// tunnelState is the state of an open tunnel, persisted on shutdown so that the Edge agents can reconnect
+// to the same port with the same credentials once the server is restarted
+type tunnelState struct {
+	EndpointID   portainer.EndpointID
+	Status       string
+	Port         int
+	LastActivity time.Time
+	// Credentials are encrypted using the Edge ID associated to the environment
+	Credentials string
+}
+
+// persistTunnels saves the state of the open tunnels on disk
+func (service *Service) persistTunnels() error {
+	service.mu.Lock()
+	states := make([]tunnelState, 0, len(service.tunnelCredentials))
+	for endpointID, credentials := range service.tunnelCredentials {
+		tunnel, ok := service.tunnelDetailsMap[endpointID]
+		if !ok || tunnel.Port == 0 || tunnel.Status == portainer.EdgeAgentIdle {
+			continue
+		}
+
+		states = append(states, tunnelState{
+			EndpointID:   endpointID,
+			Status:       tunnel.Status,
+			Port:         tunnel.Port,
+			LastActivity: tunnel.LastActivity,
+			Credentials:  credentials,
+		})
+	}
+	service.mu.Unlock()
+
+	if len(states) == 0 {
+		return nil
+	}
+
+	log.Debug().Int("tunnels", len(states)).Msg("persisting the state of the tunnels")
+
+	return service.fileService.WriteJSONToFile(service.fileService.GetDefaultChiselTunnelStatePath(), states)
+}
+
+// restoreTunnels reopens the tunnels persisted on shutdown. The state file is removed once loaded so that
+// the tunnels are only restored once.
+func (service *Service) restoreTunnels() error {
+	statePath := service.fileService.GetDefaultChiselTunnelStatePath()
+
+	content, err := os.ReadFile(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "unable to read the tunnels state")
+	}
+	defer os.Remove(statePath)
+
+	var states []tunnelState
+	if err := json.Unmarshal(content, &states); err != nil {
+		return errors.Wrap(err, "unable to parse the tunnels state")
+	}
+
+	for _, state := range states {
+		if err := service.restoreTunnel(state); err != nil {
+			log.Warn().
+				Int("endpoint_id", int(state.EndpointID)).
+				Err(err).
+				Msg("unable to restore the tunnel")
+		}
+	}
+
+	return nil
+}
+
+func (service *Service) restoreTunnel(state tunnelState) error {
+	endpoint, err := service.dataStore.Endpoint().Endpoint(state.EndpointID)
+	if err != nil {
+		return err
+	}
+
+	username, password, err := decryptCredentials(state.Credentials, endpoint.EdgeID)
+	if err != nil {
+		return err
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	for endpointID, tunnel := range service.tunnelDetailsMap {
+		if endpointID != state.EndpointID && tunnel.Port == state.Port {
+			return fmt.Errorf("port %d is already associated to another tunnel", state.Port)
+		}
+	}
+
+	if service.chiselServer != nil {
+		authorizedRemote := fmt.Sprintf("^R:0.0.0.0:%d$", state.Port)
+		if err := service.chiselServer.AddUser(username, password, authorizedRemote); err != nil {
+			return err
+		}
+	}
+
+	tunnel := service.getTunnelDetails(state.EndpointID)
+	tunnel.Status = state.Status
+	tunnel.Port = state.Port
+	// the activity is reset so that the agents are given time to reconnect before the tunnel times out
+	tunnel.LastActivity = time.Now()
+	if state.Status == portainer.EdgeAgentManagementRequired {
+		tunnel.Credentials = state.Credentials
+	}
+
+	service.tunnelCredentials[state.EndpointID] = state.Credentials
+
+	return nil
+}
+
+func decryptCredentials(credentials, key string) (string, string, error) {
+	encryptedCredentials, err := base64.RawStdEncoding.DecodeString(credentials)
+	if err != nil {
+		return "", "", err
+	}
+
+	decryptedCredentials, err := libcrypto.Decrypt(encryptedCredentials, []byte(key))
+	if err != nil {
+		return "", "", err
+	}
+
+	username, password, ok := strings.Cut(string(decryptedCredentials), ":")
+	if !ok {
+		return "", "", errors.New("invalid tunnel credentials")
+	}
+
+	return username, password, nil
+}
@@ -0,0 +1,56 @@
+package chisel
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/filesystem"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTunnelState_PersistAndRestore(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	assert.NoError(t, err)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(fileService.GetDefaultChiselTunnelStatePath()), 0700))
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "edge", Type: portainer.EdgeAgentOnDockerEnvironment, EdgeID: "edge-id"}
+	assert.NoError(t, store.Endpoint().Create(endpoint))
+
+	service := NewService(store, context.Background(), fileService)
+	assert.NoError(t, service.SetTunnelStatusToRequired(endpoint.ID))
+
+	tunnel := service.GetTunnelDetails(endpoint.ID)
+	assert.NoError(t, service.persistTunnels())
+
+	restored := NewService(store, context.Background(), fileService)
+	assert.NoError(t, restored.restoreTunnels())
+
+	restoredTunnel := restored.GetTunnelDetails(endpoint.ID)
+	assert.Equal(t, portainer.EdgeAgentManagementRequired, restoredTunnel.Status)
+	assert.Equal(t, tunnel.Port, restoredTunnel.Port)
+	assert.Equal(t, tunnel.Credentials, restoredTunnel.Credentials)
+
+	exists, err := fileService.FileExists(fileService.GetDefaultChiselTunnelStatePath())
+	assert.NoError(t, err)
+	assert.False(t, exists, "the state should only be restored once")
+}
+
+func TestDecryptCredentials(t *testing.T) {
+	credentials, err := encryptCredentials("user", "secret", "edge-id")
+	assert.NoError(t, err)
+
+	username, password, err := decryptCredentials(credentials, "edge-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "secret", password)
+
+	_, _, err = decryptCredentials(credentials, "another-edge-id")
+	assert.Error(t, err)
+}
@@ -122,6 +122,7 @@ func (service *Service) SetTunnelStatusToIdle(endpointID portainer.EndpointID) {
 	tunnel.Status = portainer.EdgeAgentIdle
 	tunnel.Port = 0
 	tunnel.LastActivity = time.Now()
+	delete(service.tunnelCredentials, endpointID)
 
 	credentials := tunnel.Credentials
 	if credentials != "" {
@@ -177,6 +178,7 @@ func (service *Service) SetTunnelStatusToRequired(endpointID portainer.EndpointI
 			return err
 		}
 		tunnel.Credentials = credentials
+		service.tunnelCredentials[endpointID] = credentials
 	}
 
 	return nil
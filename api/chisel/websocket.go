@@ -0,0 +1,56 @@
+package chisel
+
+import (
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+// chisel clients advertise their protocol version through the WebSocket sub-protocol header,
+// e.g. chisel-v3
+const tunnelWebSocketProtocolPrefix = "chisel-"
+
+// TunnelHandler returns an HTTP handler accepting reverse tunnel connections over a WebSocket.
+// It is exposed on the main HTTPS listener so that Edge agents located in networks that only
+// allow outbound HTTPS traffic can still establish a tunnel. Each connection is forwarded to
+// the local tunnel server which remains in charge of the authentication of the agent.
+func (service *Service) TunnelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !websocket.IsWebSocketUpgrade(r) || !strings.HasPrefix(r.Header.Get("Sec-WebSocket-Protocol"), tunnelWebSocketProtocolPrefix) {
+			http.Error(w, "Invalid tunnel connection request", http.StatusBadRequest)
+			return
+		}
+
+		if service.tunnelProxy == nil {
+			http.Error(w, "Tunnel server is not running", http.StatusServiceUnavailable)
+			return
+		}
+
+		log.Debug().
+			Str("remote_addr", r.RemoteAddr).
+			Msg("forwarding WebSocket tunnel connection to the tunnel server")
+
+		service.tunnelProxy.ServeHTTP(w, r)
+	})
+}
+
+// newTunnelProxy creates a reverse proxy targeting the tunnel server listening on addr and port.
+// The tunnel server can be bound to all the interfaces, in which case the loopback address is used.
+func newTunnelProxy(addr, port string) *httputil.ReverseProxy {
+	host := addr
+
+	ip := net.ParseIP(addr)
+	if addr == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+
+	return httputil.NewSingleHostReverseProxy(&url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(host, port),
+	})
+}
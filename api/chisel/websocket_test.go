@@ -0,0 +1,39 @@
+package chisel
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTunnelHandler_RejectsNonTunnelRequests(t *testing.T) {
+	service := &Service{}
+
+	req := httptest.NewRequest(http.MethodGet, "/websocket/tunnel", nil)
+	rr := httptest.NewRecorder()
+	service.TunnelHandler().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/websocket/tunnel", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Protocol", "chisel-v3")
+	rr = httptest.NewRecorder()
+	service.TunnelHandler().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
+func TestNewTunnelProxy_UsesLoopbackForUnspecifiedAddress(t *testing.T) {
+	for addr, expected := range map[string]string{
+		"":          "127.0.0.1:8000",
+		"0.0.0.0":   "127.0.0.1:8000",
+		"::":        "127.0.0.1:8000",
+		"10.0.0.12": "10.0.0.12:8000",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/websocket/tunnel", nil)
+		newTunnelProxy(addr, "8000").Director(req)
+		assert.Equal(t, expected, req.URL.Host, "addr %q", addr)
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/cors"
+	"github.com/portainer/portainer/api/internal/sockets"
+	"github.com/portainer/portainer/api/masterkey"
 
 	"github.com/rs/zerolog/log"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -21,6 +24,12 @@ var (
 	errSocketOrNamedPipeNotFound     = errors.New("Unable to locate Unix socket or named pipe")
 	errInvalidSnapshotInterval       = errors.New("Invalid snapshot interval")
 	errAdminPassExcludeAdminPassFile = errors.New("Cannot use --admin-password with --admin-password-file")
+	errInvalidAgentDuration          = errors.New("Invalid agent connection duration, it must not be negative")
+	errInvalidAgentMaxIdleConns      = errors.New("Invalid number of idle agent connections, it must not be negative")
+	errInvalidWebSocketDuration      = errors.New("Invalid websocket session duration, it must not be negative")
+	errRotateMasterKeyWithoutURI     = errors.New("Cannot use --rotate-master-key without --master-key-uri")
+	errInvalidTimeout                = errors.New("Invalid timeout, it must not be negative")
+	errInvalidTransientRetries       = errors.New("Invalid transient retries, the number of retries and their backoff must not be negative")
 )
 
 // ParseFlags parse the CLI flags and return a portainer.Flags struct
@@ -28,8 +37,13 @@ func (*Service) ParseFlags(version string) (*portainer.CLIFlags, error) {
 	kingpin.Version(version)
 
 	flags := &portainer.CLIFlags{
-		Addr:                      kingpin.Flag("bind", "Address and port to serve Portainer").Default(defaultBindAddress).Short('p').String(),
+		Addr:                      kingpin.Flag("bind", "Address and port to serve Portainer, unix:///path/to/portainer.sock to serve it on a unix socket or fd:// to use a socket activated by systemd").Default(defaultBindAddress).Short('p').String(),
 		AddrHTTPS:                 kingpin.Flag("bind-https", "Address and port to serve Portainer via https").Default(defaultHTTPSBindAddress).String(),
+		GRPCAddr:                  kingpin.Flag("grpc-addr", "Address and port to serve the gRPC API via https, with the certificate of the HTTPS server. The gRPC API is disabled when not specified").String(),
+		IPFamily:                  kingpin.Flag("ip-family", "IP family of the HTTP, HTTPS and gRPC listeners. dual-stack accepts both IPv4 and IPv6 connections on the wildcard addresses, ipv4 and ipv6 restrict them to one family").Default("dual-stack").Enum("dual-stack", "ipv4", "ipv6"),
+		SocketOwner:               kingpin.Flag("socket-owner", "Name or identifier of the user owning the unix sockets Portainer is served on").String(),
+		SocketGroup:               kingpin.Flag("socket-group", "Name or identifier of the group owning the unix sockets Portainer is served on, so that the members of the group can reach Portainer").String(),
+		SocketMode:                kingpin.Flag("socket-mode", "Octal permissions of the unix sockets Portainer is served on").Default(defaultSocketMode).String(),
 		TunnelAddr:                kingpin.Flag("tunnel-addr", "Address to serve the tunnel server").Default(defaultTunnelServerAddress).String(),
 		TunnelPort:                kingpin.Flag("tunnel-port", "Port to serve the tunnel server").Default(defaultTunnelServerPort).String(),
 		Assets:                    kingpin.Flag("assets", "Path to the assets").Default(defaultAssetsDirectory).Short('a').String(),
@@ -61,12 +75,45 @@ func (*Service) ParseFlags(version string) (*portainer.CLIFlags, error) {
 		MaxBatchSize:              kingpin.Flag("max-batch-size", "Maximum size of a batch").Int(),
 		MaxBatchDelay:             kingpin.Flag("max-batch-delay", "Maximum delay before a batch starts").Duration(),
 		SecretKeyName:             kingpin.Flag("secret-key-name", "Secret key name for encryption and will be used as /run/secrets/<secret-key-name>.").Default(defaultSecretKeyName).String(),
+		SecretsKeyFile:            kingpin.Flag("secrets-key-file", "Path to the key encrypting the integration credentials in the database, generated in the data folder when not specified").String(),
+		MasterKeyURI:              kingpin.Flag("master-key-uri", "Master key wrapping the generated secrets key file, such as awskms://<key ARN>, gcpkms://projects/<project>/locations/<location>/keyRings/<key ring>/cryptoKeys/<key> pkcs11://<token label>/<key label>?module=<library path> or vault://<address>/<mount path>/<key name>").String(),
+		RotateMasterKey:           kingpin.Flag("rotate-master-key", "Wrap the encryption and secrets key files with the master key specified by --master-key-uri, then exit").Bool(),
 		LogLevel:                  kingpin.Flag("log-level", "Set the minimum logging level to show").Default("INFO").Enum("DEBUG", "INFO", "WARN", "ERROR"),
 		LogMode:                   kingpin.Flag("log-mode", "Set the logging output mode").Default("PRETTY").Enum("PRETTY", "JSON"),
+		ShutdownTimeout:           kingpin.Flag("shutdown-timeout", "Maximum duration to wait for in-flight requests to complete when shutting down").Default(defaultShutdownTimeout).Duration(),
+		RestartHandoff:            kingpin.Flag("restart-handoff", "Hand the listening sockets over to a new Portainer process when receiving SIGHUP, so that it can be restarted without refusing connections. In a container, Portainer must be run by an init process, e.g. with docker run --init, as the new process is stopped along with the container when its init process exits").Bool(),
+		Failover:                  kingpin.Flag("failover", "Run as the active or a standby replica of an active/standby pair sharing the data volume, the replica holding a Kubernetes lease opening the database and serving the API. This does not run several active replicas").Bool(),
+		FailoverLeaseName:         kingpin.Flag("failover-lease-name", "Name of the Kubernetes lease held by the active replica").Default(defaultFailoverLeaseName).String(),
+		FailoverLeaseNamespace:    kingpin.Flag("failover-lease-namespace", "Namespace of the Kubernetes lease held by the active replica").Default(defaultFailoverLeaseNamespace).String(),
+		CORSAllowedOrigins:        kingpin.Flag("cors-allowed-origins", "Origin allowed to call the API from a browser, \"*\" allowing any origin. Can be repeated, overrides the CORS settings").Strings(),
+		CORSAllowedMethods:        kingpin.Flag("cors-allowed-methods", "Method allowed in cross-origin requests. Can be repeated, overrides the CORS settings").Strings(),
+		CORSAllowedHeaders:        kingpin.Flag("cors-allowed-headers", "Header allowed in cross-origin requests. Can be repeated, overrides the CORS settings").Strings(),
+		OfflineMode:               kingpin.Flag("offline-mode", "Disable all outbound internet access, such as the templates, the checks for new releases and the message of the day, for air-gapped installations. It cannot be disabled from the settings").Bool(),
+		AgentHTTP2:                kingpin.Flag("agent-http2", "Negotiate HTTP/2 with the agents served over TLS, multiplexing the requests on a single connection. The agents not supporting it are reached over HTTP/1.1").Default(defaultAgentHTTP2).Bool(),
+		AgentH2C:                  kingpin.Flag("agent-h2c", "Use HTTP/2 over cleartext (h2c) with the agents reached without TLS. Only enable it when all these agents support it").Bool(),
+		AgentIdleConnTimeout:      kingpin.Flag("agent-idle-conn-timeout", "Duration an idle connection to an agent is kept open, 0 keeping it open until the agent closes it").Default(defaultAgentIdleConnTimeout).Duration(),
+		AgentKeepAlive:            kingpin.Flag("agent-keep-alive", "Interval of the keep-alive probes and HTTP/2 pings on the connections to the agents, 0 using the system defaults").Default(defaultAgentKeepAlive).Duration(),
+		AgentMaxIdleConns:         kingpin.Flag("agent-max-idle-conns", "Number of idle HTTP/1.1 connections kept open for each agent").Default(defaultAgentMaxIdleConns).Int(),
+		WebSocketIdleTimeout:      kingpin.Flag("websocket-idle-timeout", "Duration after which an exec session without any input or output is closed, 0 never closing it").Default(defaultWebSocketIdleTimeout).Duration(),
+		WebSocketReconnectWindow:  kingpin.Flag("websocket-reconnect-window", "Duration an exec session is kept after its connection dropped so that the client can resume it, 0 disabling the reconnection").Default(defaultWebSocketReconnectWindow).Duration(),
+		PingTimeout:               kingpin.Flag("ping-timeout", "Timeout of the pings checking that a Docker environment or an agent is reachable").Default(defaultPingTimeout).Duration(),
+		SnapshotTimeout:           kingpin.Flag("snapshot-timeout", "Timeout of each request of the Docker snapshots and of the whole Kubernetes snapshots, 0 disabling it").Default(defaultSnapshotTimeout).Duration(),
+		ProxyTimeout:              kingpin.Flag("proxy-timeout", "Maximum duration to wait for the response headers of the requests proxied to the Docker environments, 0 waiting indefinitely").Default(defaultProxyTimeout).Duration(),
+		AzureTokenTimeout:         kingpin.Flag("azure-token-timeout", "Timeout of the requests retrieving the access tokens of the Azure environments").Default(defaultAzureTokenTimeout).Duration(),
+		TemplateFetchTimeout:      kingpin.Flag("template-fetch-timeout", "Timeout of the retrieval of the app templates, of the edge templates and of the manifests deployed from a URL").Default(defaultTemplateFetchTimeout).Duration(),
+		TransientRetries:          kingpin.Flag("transient-retries", "Number of retries of the GET requests proxied to the Docker environments or sent by the snapshots failing with a transient connection error, 0 disabling the retries").Default(defaultTransientRetries).Int(),
+		TransientRetryBackoff:     kingpin.Flag("transient-retry-backoff", "Delay before the first retry of a request failing with a transient connection error, doubled on each retry with a random jitter").Default(defaultTransientRetryBackoff).Duration(),
+		TransientRetryMaxBackoff:  kingpin.Flag("transient-retry-max-backoff", "Maximum delay between two retries of a request failing with a transient connection error").Default(defaultTransientRetryMaxBackoff).Duration(),
+		GeoIPDatabase:             kingpin.Flag("geoip-db", "Path of a CSV database of the countries of the IP address ranges, with start_ip,end_ip,country_code rows such as the DB-IP IP to Country Lite database, used to detect the logins from a new country").String(),
 	}
 
+	// --offline is the former name of --offline-mode
+	offline := kingpin.Flag("offline", "Deprecated alias of --offline-mode").Hidden().Bool()
+
 	kingpin.Parse()
 
+	*flags.OfflineMode = *flags.OfflineMode || *offline
+
 	if !filepath.IsAbs(*flags.Assets) {
 		ex, err := os.Executable()
 		if err != nil {
@@ -97,6 +144,49 @@ func (*Service) ValidateFlags(flags *portainer.CLIFlags) error {
 		return errAdminPassExcludeAdminPassFile
 	}
 
+	err = cors.ValidateSettings(portainer.CORSSettings{
+		AllowedOrigins: *flags.CORSAllowedOrigins,
+		AllowedMethods: *flags.CORSAllowedMethods,
+		AllowedHeaders: *flags.CORSAllowedHeaders,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *flags.AgentIdleConnTimeout < 0 || *flags.AgentKeepAlive < 0 {
+		return errInvalidAgentDuration
+	}
+
+	if *flags.AgentMaxIdleConns < 0 {
+		return errInvalidAgentMaxIdleConns
+	}
+
+	if *flags.WebSocketIdleTimeout < 0 || *flags.WebSocketReconnectWindow < 0 {
+		return errInvalidWebSocketDuration
+	}
+
+	for _, timeout := range []*time.Duration{flags.PingTimeout, flags.SnapshotTimeout, flags.ProxyTimeout, flags.AzureTokenTimeout, flags.TemplateFetchTimeout} {
+		if *timeout < 0 {
+			return errInvalidTimeout
+		}
+	}
+
+	if *flags.TransientRetries < 0 || *flags.TransientRetryBackoff < 0 || *flags.TransientRetryMaxBackoff < 0 {
+		return errInvalidTransientRetries
+	}
+
+	if _, err := sockets.ParseMode(*flags.SocketMode); err != nil {
+		return err
+	}
+
+	if *flags.MasterKeyURI != "" {
+		if err := masterkey.ValidateURI(*flags.MasterKeyURI); err != nil {
+			return err
+		}
+	} else if *flags.RotateMasterKey {
+		return errRotateMasterKeyWithoutURI
+	}
+
 	return nil
 }
 
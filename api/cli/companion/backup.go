@@ -0,0 +1,47 @@
+package companion
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+type backupOptions struct {
+	Password   string
+	OutputPath string
+}
+
+func downloadBackup(c *client, stdout io.Writer, options *backupOptions) error {
+	body, err := json.Marshal(map[string]string{"Password": options.Password})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(http.MethodPost, "/backup", "application/json", body, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	file, err := os.Create(options.OutputPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		os.Remove(options.OutputPath)
+
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "Backup written to %s\n", options.OutputPath)
+
+	return err
+}
@@ -0,0 +1,116 @@
+package companion
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	httpclient "github.com/portainer/portainer/api/http/client"
+)
+
+// client sends the requests of the commands to the API of a Portainer server, authenticated with an access token
+type client struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+func newClient(url, token string, insecure bool) *client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &client{
+		url:        strings.TrimSuffix(url, "/"),
+		token:      token,
+		httpClient: &http.Client{Transport: httpclient.NewExternalTransport(transport), Timeout: 10 * time.Minute},
+	}
+}
+
+// do sends a request to the API and returns the response when successful. When set, the validation of the handler
+// is run on the request first so that an invalid request is reported without reaching the server
+func (c *client) do(method, path, contentType string, body []byte, validate func(r *http.Request) error) (*http.Response, error) {
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequest(method, c.url+"/api"+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		return req, nil
+	}
+
+	if validate != nil {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := validate(req); err != nil {
+			return nil, fmt.Errorf("invalid request: %w", err)
+		}
+	}
+
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-API-KEY", c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		defer resp.Body.Close()
+
+		return nil, responseError(resp)
+	}
+
+	return resp, nil
+}
+
+// doJSON sends a request to the API and decodes the JSON response into the result when set
+func (c *client) doJSON(method, path, contentType string, body []byte, validate func(r *http.Request) error, result any) error {
+	resp, err := c.do(method, path, contentType, body, validate)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if result == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// responseError returns the error reported by the API in a failed response
+func responseError(resp *http.Response) error {
+	var apiError struct {
+		Message string `json:"message"`
+		Details string `json:"details"`
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err := json.Unmarshal(body, &apiError); err != nil || apiError.Message == "" {
+		return fmt.Errorf("the server responded with the status %s", resp.Status)
+	}
+
+	if apiError.Details == "" {
+		return errors.New(apiError.Message)
+	}
+
+	return fmt.Errorf("%s: %s", apiError.Message, apiError.Details)
+}
@@ -0,0 +1,72 @@
+// Package companion implements the `portainer cli` commands, which script a running Portainer server through its
+// API with an access token. The requests are validated by the same code as the handlers before being sent.
+package companion
+
+import (
+	"io"
+
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// Run parses the arguments following `portainer cli` and runs the command, printing its output to stdout
+func Run(args []string, stdout io.Writer) error {
+	app := kingpin.New("portainer cli", "Script a running Portainer server through its API.")
+
+	serverURL := app.Flag("server", "URL of the Portainer server, such as https://portainer.example.com:9443").Envar("PORTAINER_URL").Required().String()
+	token := app.Flag("token", "Access token of the user running the commands").Envar("PORTAINER_API_TOKEN").Required().String()
+	insecure := app.Flag("insecure", "Skip the verification of the certificate of the server").Bool()
+
+	endpoints := app.Command("endpoints", "Manage the environments.")
+
+	endpointList := endpoints.Command("list", "List the environments.")
+	endpointListJSON := endpointList.Flag("json", "Print the environments as JSON").Bool()
+
+	endpointCreate := endpoints.Command("create", "Create an environment.")
+	var createOptions endpointCreateOptions
+	endpointCreate.Flag("name", "Name of the environment").Required().StringVar(&createOptions.Name)
+	endpointCreate.Flag("url", "URL of the Docker API or of the agent, such as tcp://10.0.0.10:2375, ssh://user@10.0.0.10 or 10.0.0.10:9001").StringVar(&createOptions.URL)
+	endpointCreate.Flag("type", "Type of the environment").Default("agent").EnumVar(&createOptions.Type, "docker", "agent", "edge")
+	endpointCreate.Flag("group-id", "Identifier of the group of the environment").IntVar(&createOptions.GroupID)
+	endpointCreate.Flag("tag-id", "Identifier of a tag of the environment").IntsVar(&createOptions.TagIDs)
+	endpointCreate.Flag("tls", "Connect to the environment with TLS").BoolVar(&createOptions.TLS)
+	endpointCreate.Flag("tls-skip-verify", "Skip the verification of the certificate of the environment").BoolVar(&createOptions.TLSSkipVerify)
+	endpointCreate.Flag("tls-ca", "Path to the CA certificate verifying the certificate of the environment").StringVar(&createOptions.TLSCACertPath)
+	endpointCreate.Flag("tls-cert", "Path to the client certificate").StringVar(&createOptions.TLSCertPath)
+	endpointCreate.Flag("tls-key", "Path to the key of the client certificate").StringVar(&createOptions.TLSKeyPath)
+	endpointCreate.Flag("ssh-key", "Path to the unencrypted private key connecting to the Docker hosts reached via SSH").StringVar(&createOptions.SSHKeyPath)
+	endpointCreate.Flag("ssh-host-key", "Public key of the SSH server in the authorized_keys format, the key presented by the server being trusted when not specified").StringVar(&createOptions.SSHHostKey)
+
+	stacks := app.Command("stacks", "Manage the stacks.")
+
+	stackDeploy := stacks.Command("deploy", "Deploy a compose stack.")
+	var deployOptions stackDeployOptions
+	stackDeploy.Flag("endpoint-id", "Identifier of the environment to deploy the stack to").Required().IntVar(&deployOptions.EndpointID)
+	stackDeploy.Flag("name", "Name of the stack").Required().StringVar(&deployOptions.Name)
+	stackDeploy.Flag("file", "Path to the compose file, - to read it from the standard input").Required().StringVar(&deployOptions.FilePath)
+	stackDeploy.Flag("env", "Environment variable of the stack, as NAME=VALUE").StringsVar(&deployOptions.Env)
+
+	backup := app.Command("backup", "Download a backup of the server.")
+	var backupOptions backupOptions
+	backup.Flag("password", "Password encrypting the backup").Envar("PORTAINER_BACKUP_PASSWORD").StringVar(&backupOptions.Password)
+	backup.Flag("output", "Path of the backup archive").Default("portainer-backup.tar.gz").Short('o').StringVar(&backupOptions.OutputPath)
+
+	command, err := app.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	c := newClient(*serverURL, *token, *insecure)
+
+	switch command {
+	case endpointList.FullCommand():
+		return listEndpoints(c, stdout, *endpointListJSON)
+	case endpointCreate.FullCommand():
+		return createEndpoint(c, stdout, &createOptions)
+	case stackDeploy.FullCommand():
+		return deployStack(c, stdout, &deployOptions)
+	case backup.FullCommand():
+		return downloadBackup(c, stdout, &backupOptions)
+	}
+
+	return nil
+}
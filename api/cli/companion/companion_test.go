@@ -0,0 +1,106 @@
+package companion
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/handler/endpoints"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompanionCommands(t *testing.T) {
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		if r.Header.Get("X-API-KEY") != "ptr_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Unauthorized", "details": "invalid API key"})
+			return
+		}
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/endpoints":
+			json.NewEncoder(w).Encode([]portainer.Endpoint{
+				{ID: 1, Name: "local", Type: portainer.DockerEnvironment, Status: portainer.EndpointStatusUp, URL: "unix:///var/run/docker.sock"},
+				{ID: 2, Name: "remote", Type: portainer.AgentOnDockerEnvironment, Status: portainer.EndpointStatusDown, URL: "10.0.0.10:9001"},
+			})
+
+		case "POST /api/endpoints":
+			if err := endpoints.ValidateCreateRequest(r); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			json.NewEncoder(w).Encode(portainer.Endpoint{ID: 3, Name: r.FormValue("Name")})
+
+		case "POST /api/backup":
+			w.Write([]byte("archive"))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	run := func(args ...string) (string, error) {
+		var stdout bytes.Buffer
+		err := Run(append([]string{"--server", server.URL, "--token", "ptr_token"}, args...), &stdout)
+
+		return stdout.String(), err
+	}
+
+	t.Run("the environments are listed", func(t *testing.T) {
+		output, err := run("endpoints", "list")
+		assert.NoError(t, err)
+		assert.Equal(t, "ID  NAME    TYPE    STATUS  URL\n"+
+			"1   local   docker  up      unix:///var/run/docker.sock\n"+
+			"2   remote  agent   down    10.0.0.10:9001\n", output)
+	})
+
+	t.Run("the errors of the server are reported", func(t *testing.T) {
+		var stdout bytes.Buffer
+		err := Run([]string{"--server", server.URL, "--token", "invalid", "endpoints", "list"}, &stdout)
+		assert.EqualError(t, err, "Unauthorized: invalid API key")
+	})
+
+	t.Run("an environment is created with a form accepted by the handler", func(t *testing.T) {
+		output, err := run("endpoints", "create", "--name", "remote", "--url", "10.0.0.10:9001", "--tag-id", "1")
+		assert.NoError(t, err)
+		assert.Equal(t, "Environment remote created with the identifier 3\n", output)
+	})
+
+	t.Run("the invalid requests are not sent", func(t *testing.T) {
+		requests = nil
+
+		_, err := run("endpoints", "create", "--name", "edge", "--type", "edge")
+		assert.ErrorContains(t, err, "URL cannot be empty")
+
+		stackFile := filepath.Join(t.TempDir(), "docker-compose.yml")
+		assert.NoError(t, os.WriteFile(stackFile, nil, 0o600))
+
+		_, err = run("stacks", "deploy", "--endpoint-id", "1", "--name", "web", "--file", stackFile)
+		assert.ErrorContains(t, err, "Invalid stack file content")
+
+		assert.Empty(t, requests)
+	})
+
+	t.Run("the backup is downloaded", func(t *testing.T) {
+		archive := filepath.Join(t.TempDir(), "backup.tar.gz")
+
+		_, err := run("backup", "--output", archive)
+		assert.NoError(t, err)
+
+		content, err := os.ReadFile(archive)
+		assert.NoError(t, err)
+		assert.Equal(t, "archive", string(content))
+	})
+}
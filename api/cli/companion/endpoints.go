@@ -0,0 +1,155 @@
+package companion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/handler/endpoints"
+)
+
+var endpointTypeNames = map[portainer.EndpointType]string{
+	portainer.DockerEnvironment:                "docker",
+	portainer.AgentOnDockerEnvironment:         "agent",
+	portainer.AzureEnvironment:                 "azure",
+	portainer.EdgeAgentOnDockerEnvironment:     "edge",
+	portainer.KubernetesLocalEnvironment:       "kubernetes",
+	portainer.AgentOnKubernetesEnvironment:     "kubernetes-agent",
+	portainer.EdgeAgentOnKubernetesEnvironment: "kubernetes-edge",
+	portainer.DockerSSHEnvironment:             "docker-ssh",
+}
+
+// endpointCreationTypes maps the types of the create command to the creation types of the API
+var endpointCreationTypes = map[string]int{
+	"docker": 1,
+	"agent":  2,
+	"edge":   4,
+}
+
+type endpointCreateOptions struct {
+	Name          string
+	URL           string
+	Type          string
+	GroupID       int
+	TagIDs        []int
+	TLS           bool
+	TLSSkipVerify bool
+	TLSCACertPath string
+	TLSCertPath   string
+	TLSKeyPath    string
+	SSHKeyPath    string
+	SSHHostKey    string
+}
+
+func listEndpoints(c *client, stdout io.Writer, asJSON bool) error {
+	var endpointList []portainer.Endpoint
+	if err := c.doJSON(http.MethodGet, "/endpoints", "", nil, nil, &endpointList); err != nil {
+		return err
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(endpointList)
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tTYPE\tSTATUS\tURL")
+
+	for _, endpoint := range endpointList {
+		status := "up"
+		if endpoint.Status == portainer.EndpointStatusDown {
+			status = "down"
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", endpoint.ID, endpoint.Name, endpointTypeNames[endpoint.Type], status, endpoint.URL)
+	}
+
+	return w.Flush()
+}
+
+// endpointCreateForm builds the multipart form of the environment creation
+func endpointCreateForm(options *endpointCreateOptions) ([]byte, string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+
+	tagIDs, err := json.Marshal(options.TagIDs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fields := map[string]string{
+		"Name":                 options.Name,
+		"URL":                  options.URL,
+		"EndpointCreationType": strconv.Itoa(endpointCreationTypes[options.Type]),
+		"GroupID":              strconv.Itoa(options.GroupID),
+		"TagIds":               string(tagIDs),
+		"TLS":                  strconv.FormatBool(options.TLS),
+		"TLSSkipVerify":        strconv.FormatBool(options.TLSSkipVerify),
+		"TLSSkipClientVerify":  strconv.FormatBool(options.TLSCertPath == ""),
+		"SSHHostKey":           options.SSHHostKey,
+	}
+
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return nil, "", err
+		}
+	}
+
+	files := map[string]string{
+		"TLSCACertFile":     options.TLSCACertPath,
+		"TLSCertFile":       options.TLSCertPath,
+		"TLSKeyFile":        options.TLSKeyPath,
+		"SSHPrivateKeyFile": options.SSHKeyPath,
+	}
+
+	for name, path := range files {
+		if path == "" {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", err
+		}
+
+		part, err := form.CreateFormFile(name, path)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if _, err := part.Write(content); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := form.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return body.Bytes(), form.FormDataContentType(), nil
+}
+
+func createEndpoint(c *client, stdout io.Writer, options *endpointCreateOptions) error {
+	body, contentType, err := endpointCreateForm(options)
+	if err != nil {
+		return err
+	}
+
+	var endpoint portainer.Endpoint
+	if err := c.doJSON(http.MethodPost, "/endpoints", contentType, body, endpoints.ValidateCreateRequest, &endpoint); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "Environment %s created with the identifier %d\n", endpoint.Name, endpoint.ID)
+
+	return err
+}
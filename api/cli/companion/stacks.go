@@ -0,0 +1,66 @@
+package companion
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/handler/stacks"
+)
+
+type stackDeployOptions struct {
+	EndpointID int
+	Name       string
+	FilePath   string
+	Env        []string
+}
+
+func readStackFile(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+
+	return os.ReadFile(path)
+}
+
+func deployStack(c *client, stdout io.Writer, options *stackDeployOptions) error {
+	content, err := readStackFile(options.FilePath)
+	if err != nil {
+		return err
+	}
+
+	env := make([]portainer.Pair, 0, len(options.Env))
+	for _, variable := range options.Env {
+		name, value, ok := strings.Cut(variable, "=")
+		if !ok || name == "" {
+			return errors.New("invalid environment variable " + variable + ", it must be formatted as NAME=VALUE")
+		}
+
+		env = append(env, portainer.Pair{Name: name, Value: value})
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"Name":             options.Name,
+		"StackFileContent": string(content),
+		"Env":              env,
+	})
+	if err != nil {
+		return err
+	}
+
+	var stack portainer.Stack
+	path := "/stacks/create/standalone/string?endpointId=" + strconv.Itoa(options.EndpointID)
+	if err := c.doJSON(http.MethodPost, path, "application/json", body, stacks.ValidateComposeStackFromFileContentRequest, &stack); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "Stack %s deployed with the identifier %d\n", stack.Name, stack.ID)
+
+	return err
+}
@@ -4,20 +4,38 @@
 package cli
 
 const (
-	defaultBindAddress         = ":9000"
-	defaultHTTPSBindAddress    = ":9443"
-	defaultTunnelServerAddress = "0.0.0.0"
-	defaultTunnelServerPort    = "8000"
-	defaultDataDirectory       = "/data"
-	defaultAssetsDirectory     = "./"
-	defaultTLS                 = "false"
-	defaultTLSSkipVerify       = "false"
-	defaultTLSCACertPath       = "/certs/ca.pem"
-	defaultTLSCertPath         = "/certs/cert.pem"
-	defaultTLSKeyPath          = "/certs/key.pem"
-	defaultHTTPDisabled        = "false"
-	defaultHTTPEnabled         = "false"
-	defaultSSL                 = "false"
-	defaultBaseURL             = "/"
-	defaultSecretKeyName       = "portainer"
+	defaultBindAddress              = ":9000"
+	defaultHTTPSBindAddress         = ":9443"
+	defaultTunnelServerAddress      = "0.0.0.0"
+	defaultTunnelServerPort         = "8000"
+	defaultSocketMode               = "0660"
+	defaultDataDirectory            = "/data"
+	defaultAssetsDirectory          = "./"
+	defaultTLS                      = "false"
+	defaultTLSSkipVerify            = "false"
+	defaultTLSCACertPath            = "/certs/ca.pem"
+	defaultTLSCertPath              = "/certs/cert.pem"
+	defaultTLSKeyPath               = "/certs/key.pem"
+	defaultHTTPDisabled             = "false"
+	defaultHTTPEnabled              = "false"
+	defaultSSL                      = "false"
+	defaultBaseURL                  = "/"
+	defaultSecretKeyName            = "portainer"
+	defaultShutdownTimeout          = "30s"
+	defaultFailoverLeaseName        = "portainer-leader"
+	defaultFailoverLeaseNamespace   = "portainer"
+	defaultAgentHTTP2               = "true"
+	defaultAgentIdleConnTimeout     = "90s"
+	defaultAgentKeepAlive           = "30s"
+	defaultAgentMaxIdleConns        = "10"
+	defaultWebSocketIdleTimeout     = "0"
+	defaultWebSocketReconnectWindow = "30s"
+	defaultPingTimeout              = "3s"
+	defaultSnapshotTimeout          = "60s"
+	defaultProxyTimeout             = "0"
+	defaultAzureTokenTimeout        = "5s"
+	defaultTemplateFetchTimeout     = "30s"
+	defaultTransientRetries         = "2"
+	defaultTransientRetryBackoff    = "200ms"
+	defaultTransientRetryMaxBackoff = "2s"
 )
@@ -1,21 +1,39 @@
 package cli
 
 const (
-	defaultBindAddress         = ":9000"
-	defaultHTTPSBindAddress    = ":9443"
-	defaultTunnelServerAddress = "0.0.0.0"
-	defaultTunnelServerPort    = "8000"
-	defaultDataDirectory       = "C:\\data"
-	defaultAssetsDirectory     = "./"
-	defaultTLS                 = "false"
-	defaultTLSSkipVerify       = "false"
-	defaultTLSCACertPath       = "C:\\certs\\ca.pem"
-	defaultTLSCertPath         = "C:\\certs\\cert.pem"
-	defaultTLSKeyPath          = "C:\\certs\\key.pem"
-	defaultHTTPDisabled        = "false"
-	defaultHTTPEnabled         = "false"
-	defaultSSL                 = "false"
-	defaultSnapshotInterval    = "5m"
-	defaultBaseURL             = "/"
-	defaultSecretKeyName       = "portainer"
+	defaultBindAddress              = ":9000"
+	defaultHTTPSBindAddress         = ":9443"
+	defaultTunnelServerAddress      = "0.0.0.0"
+	defaultTunnelServerPort         = "8000"
+	defaultSocketMode               = "0660"
+	defaultDataDirectory            = "C:\\data"
+	defaultAssetsDirectory          = "./"
+	defaultTLS                      = "false"
+	defaultTLSSkipVerify            = "false"
+	defaultTLSCACertPath            = "C:\\certs\\ca.pem"
+	defaultTLSCertPath              = "C:\\certs\\cert.pem"
+	defaultTLSKeyPath               = "C:\\certs\\key.pem"
+	defaultHTTPDisabled             = "false"
+	defaultHTTPEnabled              = "false"
+	defaultSSL                      = "false"
+	defaultSnapshotInterval         = "5m"
+	defaultBaseURL                  = "/"
+	defaultSecretKeyName            = "portainer"
+	defaultShutdownTimeout          = "30s"
+	defaultFailoverLeaseName        = "portainer-leader"
+	defaultFailoverLeaseNamespace   = "portainer"
+	defaultAgentHTTP2               = "true"
+	defaultAgentIdleConnTimeout     = "90s"
+	defaultAgentKeepAlive           = "30s"
+	defaultAgentMaxIdleConns        = "10"
+	defaultWebSocketIdleTimeout     = "0"
+	defaultWebSocketReconnectWindow = "30s"
+	defaultPingTimeout              = "3s"
+	defaultSnapshotTimeout          = "60s"
+	defaultProxyTimeout             = "0"
+	defaultAzureTokenTimeout        = "5s"
+	defaultTemplateFetchTimeout     = "30s"
+	defaultTransientRetries         = "2"
+	defaultTransientRetryBackoff    = "200ms"
+	defaultTransientRetryMaxBackoff = "2s"
 )
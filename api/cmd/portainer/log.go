@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	stdlog "log"
 	"os"
 
@@ -10,6 +11,9 @@ import (
 	"github.com/rs/zerolog/pkgerrors"
 )
 
+// logOutput is the writer of the logging mode, the logs are also written to the forwarders
+var logOutput io.Writer = os.Stderr
+
 func configureLogger() {
 	zerolog.ErrorStackFieldName = "stack_trace"
 	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
@@ -19,6 +23,9 @@ func configureLogger() {
 	stdlog.SetOutput(log.Logger)
 
 	log.Logger = log.Logger.With().Caller().Stack().Logger()
+
+	// log.Ctx falls back to the global logger outside of the API calls, which carry a logger with their request ID
+	zerolog.DefaultContextLogger = &log.Logger
 }
 
 func setLoggingLevel(level string) {
@@ -37,16 +44,23 @@ func setLoggingLevel(level string) {
 func setLoggingMode(mode string) {
 	switch mode {
 	case "PRETTY":
-		log.Logger = log.Output(zerolog.ConsoleWriter{
+		logOutput = zerolog.ConsoleWriter{
 			Out:           os.Stderr,
 			TimeFormat:    "2006/01/02 03:04PM",
 			FormatMessage: formatMessage,
-		})
+		}
+		log.Logger = log.Output(logOutput)
 	case "JSON":
-		log.Logger = log.Output(os.Stderr)
+		logOutput = os.Stderr
+		log.Logger = log.Output(logOutput)
 	}
 }
 
+// forwardLogs writes the logs to the forwarder in addition to the output of the logging mode
+func forwardLogs(forwarder zerolog.LevelWriter) {
+	log.Logger = log.Output(zerolog.MultiLevelWriter(logOutput, forwarder))
+}
+
 func formatMessage(i interface{}) string {
 	if i == nil {
 		return ""
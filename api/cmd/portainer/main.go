@@ -3,10 +3,14 @@ package main
 import (
 	"context"
 	"crypto/sha256"
+	"fmt"
 	"math/rand"
+	"net"
 	"os"
+	"os/signal"
 	"path"
 	"strings"
+	"syscall"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
@@ -14,6 +18,9 @@ import (
 	"github.com/portainer/portainer/api/build"
 	"github.com/portainer/portainer/api/chisel"
 	"github.com/portainer/portainer/api/cli"
+	"github.com/portainer/portainer/api/cli/companion"
+	"github.com/portainer/portainer/api/cmdb"
+	"github.com/portainer/portainer/api/cost"
 	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/database"
 	"github.com/portainer/portainer/api/database/boltdb"
@@ -22,30 +29,51 @@ import (
 	"github.com/portainer/portainer/api/datastore"
 	"github.com/portainer/portainer/api/datastore/migrator"
 	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/discovery"
 	"github.com/portainer/portainer/api/docker"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/docker/images"
+	"github.com/portainer/portainer/api/docker/rollout"
 	"github.com/portainer/portainer/api/exec"
+	"github.com/portainer/portainer/api/failover"
 	"github.com/portainer/portainer/api/filesystem"
 	"github.com/portainer/portainer/api/git"
 	"github.com/portainer/portainer/api/hostmanagement/openamt"
 	"github.com/portainer/portainer/api/http"
+	"github.com/portainer/portainer/api/http/client"
 	"github.com/portainer/portainer/api/http/proxy"
+	"github.com/portainer/portainer/api/http/proxy/factory/agent"
 	kubeproxy "github.com/portainer/portainer/api/http/proxy/factory/kubernetes"
 	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/api/internal/edge"
 	"github.com/portainer/portainer/api/internal/edge/edgestacks"
 	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/handoff"
 	"github.com/portainer/portainer/api/internal/snapshot"
+	"github.com/portainer/portainer/api/internal/sockets"
 	"github.com/portainer/portainer/api/internal/ssl"
 	"github.com/portainer/portainer/api/internal/upgrade"
+	"github.com/portainer/portainer/api/intrusion"
+	"github.com/portainer/portainer/api/jobs"
 	"github.com/portainer/portainer/api/jwt"
 	"github.com/portainer/portainer/api/kubernetes"
 	kubecli "github.com/portainer/portainer/api/kubernetes/cli"
 	"github.com/portainer/portainer/api/ldap"
+	"github.com/portainer/portainer/api/mail"
+	"github.com/portainer/portainer/api/masterkey"
 	"github.com/portainer/portainer/api/oauth"
 	"github.com/portainer/portainer/api/pendingactions"
+	"github.com/portainer/portainer/api/release"
+	"github.com/portainer/portainer/api/retries"
 	"github.com/portainer/portainer/api/scheduler"
+	"github.com/portainer/portainer/api/secrets"
+	"github.com/portainer/portainer/api/secretstore"
+	"github.com/portainer/portainer/api/stacks/bundles"
 	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/drift"
+	"github.com/portainer/portainer/api/syslog"
+	"github.com/portainer/portainer/api/timeouts"
+	"github.com/portainer/portainer/api/usage"
 	"github.com/portainer/portainer/pkg/featureflags"
 	"github.com/portainer/portainer/pkg/libhelm"
 	"github.com/portainer/portainer/pkg/libstack"
@@ -79,7 +107,7 @@ func initFileService(dataStorePath string) portainer.FileService {
 	return fileService
 }
 
-func initDataStore(flags *portainer.CLIFlags, secretKey []byte, fileService portainer.FileService, shutdownCtx context.Context) dataservices.DataStore {
+func initDataStore(flags *portainer.CLIFlags, secretKey []byte, fileService portainer.FileService) dataservices.DataStore {
 	connection, err := database.NewDatabase("boltdb", *flags.Data, secretKey)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed creating database connection")
@@ -89,6 +117,7 @@ func initDataStore(flags *portainer.CLIFlags, secretKey []byte, fileService port
 		bconn.MaxBatchSize = *flags.MaxBatchSize
 		bconn.MaxBatchDelay = *flags.MaxBatchDelay
 		bconn.InitialMmapSize = *flags.InitialMmapSize
+		bconn.FieldCipher = initSecretStore(flags)
 	} else {
 		log.Fatal().Msg("failed creating database connection: expecting a boltdb database type but a different one was received")
 	}
@@ -149,12 +178,6 @@ func initDataStore(flags *portainer.CLIFlags, secretKey []byte, fileService port
 		log.Fatal().Err(err).Msg("failed updating settings from flags")
 	}
 
-	// this is for the db restore functionality - needs more tests.
-	go func() {
-		<-shutdownCtx.Done()
-		defer connection.Close()
-	}()
-
 	return store
 }
 
@@ -168,8 +191,8 @@ func checkDBSchemaServerVersionMatch(dbStore dataservices.DataStore, serverVersi
 	return v.SchemaVersion == serverVersion && v.Edition == serverEdition
 }
 
-func initComposeStackManager(composeDeployer libstack.Deployer, proxyManager *proxy.Manager) portainer.ComposeStackManager {
-	composeWrapper, err := exec.NewComposeStackManager(composeDeployer, proxyManager)
+func initComposeStackManager(composeDeployer libstack.Deployer, proxyManager *proxy.Manager, secretsService *secrets.Service) portainer.ComposeStackManager {
+	composeWrapper, err := exec.NewComposeStackManager(composeDeployer, proxyManager, secretsService)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed creating compose manager")
 	}
@@ -184,8 +207,9 @@ func initSwarmStackManager(
 	fileService portainer.FileService,
 	reverseTunnelService portainer.ReverseTunnelService,
 	dataStore dataservices.DataStore,
+	secretsService *secrets.Service,
 ) (portainer.SwarmStackManager, error) {
-	return exec.NewSwarmStackManager(assetsPath, configPath, signatureService, fileService, reverseTunnelService, dataStore)
+	return exec.NewSwarmStackManager(assetsPath, configPath, signatureService, fileService, reverseTunnelService, dataStore, secretsService)
 }
 
 func initKubernetesDeployer(kubernetesTokenCacheManager *kubeproxy.TokenCacheManager, kubernetesClientFactory *kubecli.ClientFactory, dataStore dataservices.DataStore, reverseTunnelService portainer.ReverseTunnelService, signatureService portainer.DigitalSignatureService, proxyManager *proxy.Manager, assetsPath string) portainer.KubernetesDeployer {
@@ -233,16 +257,35 @@ func initGitService(ctx context.Context) portainer.GitService {
 	return git.NewService(ctx)
 }
 
+// listenNetwork returns the network of the listeners for the IP family. The wildcard addresses of the tcp network
+// are bound to both the IPv4 and the IPv6 addresses wherever the system supports it, while the tcp6 network
+// restricts them to IPv6
+func listenNetwork(ipFamily string) string {
+	switch ipFamily {
+	case "ipv4":
+		return "tcp4"
+	case "ipv6":
+		return "tcp6"
+	default:
+		return "tcp"
+	}
+}
+
 func initSSLService(addr, certPath, keyPath string, fileService portainer.FileService, dataStore dataservices.DataStore, shutdownTrigger context.CancelFunc) (*ssl.Service, error) {
-	slices := strings.Split(addr, ":")
-	host := slices[0]
+	// the certificate of the unix sockets and of the sockets activated by systemd is generated for the wildcard
+	// address, as it is when no host is specified
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil && !sockets.IsSocketAddress(addr) {
+		return nil, fmt.Errorf("invalid HTTPS bind address: %w", err)
+	}
+
 	if host == "" {
 		host = "0.0.0.0"
 	}
 
 	sslService := ssl.NewService(fileService, dataStore, shutdownTrigger)
 
-	err := sslService.Init(host, certPath, keyPath)
+	err = sslService.Init(host, certPath, keyPath)
 	if err != nil {
 		return nil, err
 	}
@@ -273,10 +316,38 @@ func initSnapshotService(
 	if err != nil {
 		return nil, err
 	}
-
 	return snapshotService, nil
 }
 
+// acquireFailoverLease waits until this replica holds the Kubernetes lease of the active replica, as the database
+// can only be opened by one replica at a time. The replica exits when it loses the lease, so that a standby replica
+// can take over.
+func acquireFailoverLease(flags *portainer.CLIFlags, shutdownCtx context.Context) {
+	if !*flags.Failover {
+		return
+	}
+
+	lease, err := failover.NewKubernetesLease(*flags.FailoverLeaseNamespace, *flags.FailoverLeaseName)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed initializing the failover lease")
+	}
+
+	log.Info().Str("lease", *flags.FailoverLeaseName).Msg("waiting for the failover lease before opening the database")
+
+	lost, err := lease.Acquire(shutdownCtx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed acquiring the failover lease")
+	}
+
+	go func() {
+		<-lost
+
+		if shutdownCtx.Err() == nil {
+			log.Fatal().Msg("lost the failover lease, exiting so that a standby replica takes over")
+		}
+	}()
+}
+
 func initStatus(instanceID string) *portainer.Status {
 	return &portainer.Status{
 		Version:    portainer.APIVersion,
@@ -310,6 +381,22 @@ func updateSettingsFromFlags(dataStore dataservices.DataStore, flags *portainer.
 		settings.BlackListedLabels = *flags.Labels
 	}
 
+	if *flags.OfflineMode {
+		settings.OfflineMode = true
+	}
+
+	if len(*flags.CORSAllowedOrigins) > 0 {
+		settings.CORS.AllowedOrigins = *flags.CORSAllowedOrigins
+	}
+
+	if len(*flags.CORSAllowedMethods) > 0 {
+		settings.CORS.AllowedMethods = *flags.CORSAllowedMethods
+	}
+
+	if len(*flags.CORSAllowedHeaders) > 0 {
+		settings.CORS.AllowedHeaders = *flags.CORSAllowedHeaders
+	}
+
 	if agentKey, ok := os.LookupEnv("AGENT_SECRET"); ok {
 		settings.AgentSecret = agentKey
 	} else {
@@ -321,6 +408,8 @@ func updateSettingsFromFlags(dataStore dataservices.DataStore, flags *portainer.
 		return err
 	}
 
+	client.SetOfflineMode(settings.OfflineMode)
+
 	sslSettings, err := dataStore.SSLSettings().Settings()
 	if err != nil {
 		return err
@@ -364,6 +453,30 @@ func initKeyPair(fileService portainer.FileService, signatureService portainer.D
 	return generateAndStoreKeyPair(fileService, signatureService)
 }
 
+func secretStoreKeyFile(flags *portainer.CLIFlags) string {
+	if *flags.SecretsKeyFile != "" {
+		return *flags.SecretsKeyFile
+	}
+
+	return path.Join(*flags.Data, secretstore.DefaultKeyFileName)
+}
+
+func initSecretStore(flags *portainer.CLIFlags) *secretstore.Service {
+	keyFile := secretStoreKeyFile(flags)
+
+	key, err := secretstore.LoadKey(keyFile, *flags.MasterKeyURI)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed loading the key of the secret store")
+	}
+
+	secretStore, err := secretstore.NewService(key)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed creating the secret store")
+	}
+
+	return secretStore
+}
+
 func loadEncryptionSecretKey(keyfilename string) []byte {
 	content, err := os.ReadFile(path.Join("/run/secrets", keyfilename))
 	if err != nil {
@@ -376,25 +489,102 @@ func loadEncryptionSecretKey(keyfilename string) []byte {
 		return nil
 	}
 
+	if masterkey.IsEnvelope(content) {
+		content, err = masterkey.Open(context.Background(), content)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed unwrapping the encryption key")
+		}
+	}
+
 	// return a 32 byte hash of the secret (required for AES)
 	hash := sha256.Sum256(content)
 	return hash[:]
 }
 
-func buildServer(flags *portainer.CLIFlags) portainer.Server {
+// rotateMasterKey wraps the encryption key and the key of the secret store with the master key specified by the
+// flags. The data keys are unchanged, so the data does not need to be re-encrypted.
+func rotateMasterKey(flags *portainer.CLIFlags) {
+	ctx := context.Background()
+
+	keyFile := secretStoreKeyFile(flags)
+	if _, err := os.Stat(keyFile); err == nil {
+		if err := masterkey.RewrapFile(ctx, keyFile, *flags.MasterKeyURI); err != nil {
+			log.Fatal().Err(err).Str("path", keyFile).Msg("failed wrapping the key of the secret store")
+		}
+
+		log.Info().Str("path", keyFile).Msg("wrapped the key of the secret store with the master key")
+	}
+
+	encryptionKeyFile := path.Join("/run/secrets", *flags.SecretKeyName)
+	content, err := os.ReadFile(encryptionKeyFile)
+	if os.IsNotExist(err) {
+		return
+	} else if err != nil {
+		log.Fatal().Err(err).Msg("failed reading the encryption key file")
+	}
+
+	content, err = masterkey.Rewrap(ctx, content, *flags.MasterKeyURI)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed wrapping the encryption key")
+	}
+
+	// the Docker secrets are read-only, the wrapped key is then written to the data folder to replace the secret
+	if err := os.WriteFile(encryptionKeyFile, content, 0o600); err != nil {
+		encryptionKeyFile = path.Join(*flags.Data, *flags.SecretKeyName+".wrapped")
+		if err := os.WriteFile(encryptionKeyFile, content, 0o600); err != nil {
+			log.Fatal().Err(err).Msg("failed writing the wrapped encryption key")
+		}
+
+		log.Warn().Str("path", encryptionKeyFile).Msg("the encryption key file is read-only, replace the secret with the wrapped encryption key")
+
+		return
+	}
+
+	log.Info().Str("path", encryptionKeyFile).Msg("wrapped the encryption key with the master key")
+}
+
+func buildServer(flags *portainer.CLIFlags, socketHandoff *handoff.Handoff) *http.Server {
 	shutdownCtx, shutdownTrigger := context.WithCancel(context.Background())
 
 	if flags.FeatureFlags != nil {
 		featureflags.Parse(*flags.FeatureFlags, portainer.SupportedFeatureFlags)
 	}
 
+	timeouts.Configure(timeouts.Timeouts{
+		EndpointPing:  *flags.PingTimeout,
+		Snapshot:      *flags.SnapshotTimeout,
+		ProxyRequest:  *flags.ProxyTimeout,
+		AzureToken:    *flags.AzureTokenTimeout,
+		TemplateFetch: *flags.TemplateFetchTimeout,
+	})
+
+	retries.Configure(retries.Policy{
+		MaxRetries: *flags.TransientRetries,
+		Backoff:    *flags.TransientRetryBackoff,
+		MaxBackoff: *flags.TransientRetryMaxBackoff,
+	})
+
+	socketMode, err := sockets.ParseMode(*flags.SocketMode)
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+
+	if *flags.RotateMasterKey {
+		rotateMasterKey(flags)
+
+		log.Info().Msg("exiting master key rotation")
+		os.Exit(0)
+	}
+
 	fileService := initFileService(*flags.Data)
 	encryptionKey := loadEncryptionSecretKey(*flags.SecretKeyName)
 	if encryptionKey == nil {
 		log.Info().Msg("proceeding without encryption key")
 	}
 
-	dataStore := initDataStore(flags, encryptionKey, fileService, shutdownCtx)
+	acquireFailoverLease(flags, shutdownCtx)
+
+	dataStore := initDataStore(flags, encryptionKey, fileService)
 
 	if err := dataStore.CheckCurrentEdition(); err != nil {
 		log.Fatal().Err(err).Msg("")
@@ -467,6 +657,14 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 	}
 	snapshotService.Start()
 
+	agent.Configure(agent.HTTPSettings{
+		HTTP2:               *flags.AgentHTTP2,
+		H2C:                 *flags.AgentH2C,
+		IdleConnTimeout:     *flags.AgentIdleConnTimeout,
+		KeepAlive:           *flags.AgentKeepAlive,
+		MaxIdleConnsPerHost: *flags.AgentMaxIdleConns,
+	})
+
 	kubernetesTokenCacheManager := kubeproxy.NewTokenCacheManager()
 
 	kubeClusterAccessService := kubernetes.NewKubeClusterAccessService(*flags.BaseURL, *flags.AddrHTTPS, sslSettings.CertPath)
@@ -482,9 +680,11 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		log.Fatal().Err(err).Msg("failed initializing compose deployer")
 	}
 
-	composeStackManager := initComposeStackManager(composeDeployer, proxyManager)
+	secretsService := secrets.NewService(dataStore)
 
-	swarmStackManager, err := initSwarmStackManager(*flags.Assets, dockerConfigPath, digitalSignatureService, fileService, reverseTunnelService, dataStore)
+	composeStackManager := initComposeStackManager(composeDeployer, proxyManager, secretsService)
+
+	swarmStackManager, err := initSwarmStackManager(*flags.Assets, dockerConfigPath, digitalSignatureService, fileService, reverseTunnelService, dataStore, secretsService)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed initializing swarm stack manager")
 	}
@@ -563,8 +763,60 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 	}
 
 	scheduler := scheduler.NewScheduler(shutdownCtx)
-	stackDeployer := deployments.NewStackDeployer(swarmStackManager, composeStackManager, kubernetesDeployer, dockerClientFactory, dataStore)
-	deployments.StartStackSchedules(scheduler, stackDeployer, dataStore, gitService)
+	stackDeployer := deployments.NewStackDeployer(swarmStackManager, composeStackManager, kubernetesDeployer, dockerClientFactory, dataStore, secretsService)
+
+	jobQueue := jobs.NewQueue(dataStore)
+	snapshot.RegisterJobs(jobQueue, dataStore, snapshotService)
+	deployments.RegisterJobs(jobQueue, stackDeployer, dataStore, gitService)
+	images.RegisterPullJobs(jobQueue, dataStore, dockerClientFactory)
+	rollout.RegisterJobs(jobQueue, dataStore, dockerClientFactory)
+	bundles.RegisterJobs(jobQueue, bundles.NewService(dataStore, stackDeployer, composeStackManager, swarmStackManager, dockerClientFactory))
+	jobQueue.Start(shutdownCtx)
+
+	deployments.StartStackSchedules(scheduler, jobQueue, stackDeployer, dataStore, gitService)
+
+	endpointDeleter := &endpointutils.EndpointDeleter{
+		DataStore:            dataStore,
+		FileService:          fileService,
+		ProxyManager:         proxyManager,
+		AuthorizationService: authorizationService,
+	}
+
+	discoveryService := discovery.NewService(dataStore, snapshotService, endpointDeleter, scheduler)
+	if err := discoveryService.Start(); err != nil {
+		log.Error().Err(err).Msg("failed starting the environments discovery")
+	}
+
+	releaseService := release.NewService(dataStore)
+	releaseService.Start(shutdownCtx)
+
+	usageService := usage.NewService(dataStore, *flags.Data, scheduler)
+	if err := usageService.Start(); err != nil {
+		log.Error().Err(err).Msg("failed starting the usage report export")
+	}
+
+	cmdbService := cmdb.NewService(dataStore, scheduler)
+	cmdbService.Start()
+
+	costService := cost.NewService(dataStore, dockerClientFactory, scheduler)
+	costService.Start()
+
+	driftService := drift.NewService(dataStore, gitService, fileService, dockerClientFactory, scheduler)
+	driftService.Start()
+
+	syslogForwarder := syslog.NewForwarder(settings.Syslog)
+	syslogForwarder.Start(shutdownCtx)
+	forwardLogs(syslogForwarder)
+
+	mailService := mail.NewService(settings.SMTP)
+
+	var geoIPDatabase *intrusion.GeoIPDatabase
+	if *flags.GeoIPDatabase != "" {
+		geoIPDatabase, err = intrusion.LoadGeoIPDatabase(*flags.GeoIPDatabase)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed loading the GeoIP database")
+		}
+	}
 
 	sslDBSettings, err := dataStore.SSLSettings().Settings()
 	if err != nil {
@@ -595,6 +847,9 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		Status:                      applicationStatus,
 		BindAddress:                 *flags.Addr,
 		BindAddressHTTPS:            *flags.AddrHTTPS,
+		BindAddressGRPC:             *flags.GRPCAddr,
+		ListenNetwork:               listenNetwork(*flags.IPFamily),
+		UnixSocketOptions:           sockets.UnixOptions{Owner: *flags.SocketOwner, Group: *flags.SocketGroup, Mode: socketMode},
 		HTTPEnabled:                 sslDBSettings.HTTPEnabled,
 		AssetsPath:                  *flags.Assets,
 		DataStore:                   dataStore,
@@ -622,8 +877,22 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 		Scheduler:                   scheduler,
 		ShutdownCtx:                 shutdownCtx,
 		ShutdownTrigger:             shutdownTrigger,
+		ShutdownTimeout:             *flags.ShutdownTimeout,
+		Handoff:                     socketHandoff,
 		StackDeployer:               stackDeployer,
 		DemoService:                 demoService,
+		DiscoveryService:            discoveryService,
+		UsageService:                usageService,
+		CMDBService:                 cmdbService,
+		DriftService:                driftService,
+		JobQueue:                    jobQueue,
+		SyslogForwarder:             syslogForwarder,
+		MailService:                 mailService,
+		ReleaseService:              releaseService,
+		OfflineModeFlag:             *flags.OfflineMode,
+		GeoIPDatabase:               geoIPDatabase,
+		WebSocketIdleTimeout:        *flags.WebSocketIdleTimeout,
+		WebSocketReconnectWindow:    *flags.WebSocketReconnectWindow,
 		UpgradeService:              upgradeService,
 		AdminCreationDone:           adminCreationDone,
 		PendingActionsService:       pendingActionsService,
@@ -631,6 +900,16 @@ func buildServer(flags *portainer.CLIFlags) portainer.Server {
 }
 
 func main() {
+	// portainer cli scripts a running server instead of serving one
+	if len(os.Args) > 1 && os.Args[1] == "cli" {
+		if err := companion.Run(os.Args[2:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, "portainer cli:", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	rand.Seed(time.Now().UnixNano())
 
 	configureLogger()
@@ -641,8 +920,26 @@ func main() {
 	setLoggingLevel(*flags.LogLevel)
 	setLoggingMode(*flags.LogMode)
 
+	socketHandoff, err := handoff.New()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed inheriting the listening sockets")
+	}
+
+	// the previous process is given the time to drain its requests and to close the database
+	socketHandoff.WaitForParent(*flags.ShutdownTimeout + 30*time.Second)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	if *flags.RestartHandoff {
+		if err := handoff.Supported(); err != nil {
+			log.Warn().Err(err).Msg("Portainer cannot be restarted with SIGHUP")
+		}
+
+		signal.Notify(signals, syscall.SIGHUP)
+	}
+
 	for {
-		server := buildServer(flags)
+		server := buildServer(flags, socketHandoff)
 		log.Info().
 			Str("version", portainer.APIVersion).
 			Str("build_number", build.BuildNumber).
@@ -653,7 +950,41 @@ func main() {
 			Str("go_version", build.GoVersion).
 			Msg("starting Portainer")
 
+		exiting := make(chan struct{})
+		go handleSignals(server, signals, socketHandoff, exiting)
+
 		err := server.Start()
 		log.Info().Err(err).Msg("HTTP server exited")
+
+		select {
+		case <-exiting:
+			return
+		default:
+		}
+	}
+}
+
+// handleSignals gracefully shuts the server down when a termination signal is received. When the socket handoff
+// is enabled, SIGHUP first starts a new Portainer process that takes over the listening sockets.
+func handleSignals(server *http.Server, signals <-chan os.Signal, socketHandoff *handoff.Handoff, exiting chan<- struct{}) {
+	for {
+		select {
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				if err := socketHandoff.Restart(); err != nil {
+					log.Error().Err(err).Msg("unable to restart Portainer")
+					continue
+				}
+			}
+
+			log.Info().Str("signal", sig.String()).Msg("shutting down Portainer")
+
+			close(exiting)
+			server.ShutdownTrigger()
+
+			return
+		case <-server.ShutdownCtx.Done():
+			return
+		}
 	}
 }
@@ -0,0 +1,186 @@
+package cmdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/client"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	requestTimeout = 30 * time.Second
+
+	defaultEndpointTable  = "cmdb_ci_docker_engine"
+	defaultContainerTable = "cmdb_ci_docker_container"
+)
+
+// Connector pushes the changes of the inventory to a CMDB
+type Connector interface {
+	// Create adds the item to the CMDB and returns its identifier in the CMDB
+	Create(ctx context.Context, item Item, record map[string]string) (string, error)
+	// Update replaces the fields of an item previously created in the CMDB
+	Update(ctx context.Context, item Item, remoteID string, record map[string]string) error
+	// Delete removes an item previously created in the CMDB
+	Delete(ctx context.Context, kind, remoteID string) error
+}
+
+// NewConnector creates the connector described by the configuration
+func NewConnector(config portainer.CMDBConnector) (Connector, error) {
+	baseURL := strings.TrimSuffix(config.URL, "/")
+	httpClient := client.NewExternalClient(requestTimeout)
+
+	switch config.Type {
+	case portainer.CMDBConnectorServiceNow:
+		tables := map[string]string{
+			KindEndpoint:  config.EndpointTable,
+			KindContainer: config.ContainerTable,
+		}
+
+		if tables[KindEndpoint] == "" {
+			tables[KindEndpoint] = defaultEndpointTable
+		}
+
+		if tables[KindContainer] == "" {
+			tables[KindContainer] = defaultContainerTable
+		}
+
+		return &serviceNowConnector{url: baseURL, username: config.Username, password: config.Password, tables: tables, client: httpClient}, nil
+	case portainer.CMDBConnectorREST:
+		return &restConnector{url: baseURL, token: config.Token, client: httpClient}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported CMDB connector type %q", config.Type)
+}
+
+// serviceNowConnector pushes the items to the tables of a ServiceNow instance through its Table API, the items being
+// identified by their sys_id
+type serviceNowConnector struct {
+	url      string
+	username string
+	password string
+	tables   map[string]string
+	client   *http.Client
+}
+
+func (connector *serviceNowConnector) Create(ctx context.Context, item Item, record map[string]string) (string, error) {
+	var result struct {
+		Result struct {
+			SysID string `json:"sys_id"`
+		} `json:"result"`
+	}
+
+	err := connector.do(ctx, http.MethodPost, connector.tableURL(item.Kind), record, &result)
+	if err != nil {
+		return "", err
+	}
+
+	if result.Result.SysID == "" {
+		return "", errors.New("the ServiceNow response does not contain the sys_id of the created record")
+	}
+
+	return result.Result.SysID, nil
+}
+
+func (connector *serviceNowConnector) Update(ctx context.Context, item Item, remoteID string, record map[string]string) error {
+	return connector.do(ctx, http.MethodPatch, connector.tableURL(item.Kind)+"/"+url.PathEscape(remoteID), record, nil)
+}
+
+func (connector *serviceNowConnector) Delete(ctx context.Context, kind, remoteID string) error {
+	return connector.do(ctx, http.MethodDelete, connector.tableURL(kind)+"/"+url.PathEscape(remoteID), nil, nil)
+}
+
+func (connector *serviceNowConnector) tableURL(kind string) string {
+	return connector.url + "/api/now/table/" + url.PathEscape(connector.tables[kind])
+}
+
+func (connector *serviceNowConnector) do(ctx context.Context, method, url string, body, result any) error {
+	return doJSON(ctx, connector.client, method, url, body, result, func(req *http.Request) {
+		req.SetBasicAuth(connector.username, connector.password)
+	})
+}
+
+// restConnector pushes the items to a generic REST API, as PUT and DELETE requests on {url}/{kind}s/{key}
+type restConnector struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (connector *restConnector) Create(ctx context.Context, item Item, record map[string]string) (string, error) {
+	return item.Key, connector.Update(ctx, item, item.Key, record)
+}
+
+func (connector *restConnector) Update(ctx context.Context, item Item, remoteID string, record map[string]string) error {
+	return connector.do(ctx, http.MethodPut, connector.itemURL(item.Kind, remoteID), record)
+}
+
+func (connector *restConnector) Delete(ctx context.Context, kind, remoteID string) error {
+	return connector.do(ctx, http.MethodDelete, connector.itemURL(kind, remoteID), nil)
+}
+
+func (connector *restConnector) itemURL(kind, key string) string {
+	return connector.url + "/" + kind + "s/" + url.PathEscape(key)
+}
+
+func (connector *restConnector) do(ctx context.Context, method, url string, body any) error {
+	return doJSON(ctx, connector.client, method, url, body, nil, func(req *http.Request) {
+		if connector.token != "" {
+			req.Header.Set("Authorization", "Bearer "+connector.token)
+		}
+	})
+}
+
+// doJSON sends the body encoded in JSON and decodes the response into the result, when set
+func doJSON(ctx context.Context, client *http.Client, method, url string, body, result any, authenticate func(req *http.Request)) error {
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		reader = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	authenticate(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// a record already removed from the CMDB does not need to be deleted
+	if method == http.MethodDelete && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s returned the status %d", method, req.URL.Path, resp.StatusCode)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return errors.Wrap(json.NewDecoder(resp.Body).Decode(result), "unable to decode the response of the CMDB")
+}
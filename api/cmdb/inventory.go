@@ -0,0 +1,211 @@
+package cmdb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+)
+
+const (
+	// KindEndpoint is the kind of the inventory items describing the environments
+	KindEndpoint = "endpoint"
+	// KindContainer is the kind of the inventory items describing the containers of the Docker environments
+	KindContainer = "container"
+)
+
+// endpointTypeNames are the values of the type field of the environments
+var endpointTypeNames = map[portainer.EndpointType]string{
+	portainer.DockerEnvironment:                "docker",
+	portainer.AgentOnDockerEnvironment:         "agent-docker",
+	portainer.AzureEnvironment:                 "azure",
+	portainer.EdgeAgentOnDockerEnvironment:     "edge-agent-docker",
+	portainer.KubernetesLocalEnvironment:       "kubernetes-local",
+	portainer.AgentOnKubernetesEnvironment:     "agent-kubernetes",
+	portainer.EdgeAgentOnKubernetesEnvironment: "edge-agent-kubernetes",
+	portainer.DockerSSHEnvironment:             "docker-ssh",
+}
+
+// Item is an environment or a container of the inventory
+type Item struct {
+	// Kind of the item, either endpoint or container
+	Kind string
+	// Key identifying the item across the synchronizations, also pushed as the key field
+	Key string
+	// Fields of the item, before their mapping to the fields of the CMDB
+	Fields map[string]string
+}
+
+// Collect lists the environments and, when requested, the containers of the last snapshot of the Docker environments
+func Collect(dataStore dataservices.DataStore, withContainers bool) ([]Item, error) {
+	endpoints, err := dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := dataStore.EndpointGroup().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	groupNames := make(map[portainer.EndpointGroupID]string, len(groups))
+	for _, group := range groups {
+		groupNames[group.ID] = group.Name
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].ID < endpoints[j].ID
+	})
+
+	items := []Item{}
+
+	for _, endpoint := range endpoints {
+		if endpointutils.IsEdgeEndpoint(&endpoint) && !endpoint.UserTrusted {
+			continue
+		}
+
+		status := "up"
+		if endpoint.Status == portainer.EndpointStatusDown {
+			status = "down"
+		}
+
+		item := Item{
+			Kind: KindEndpoint,
+			Key:  fmt.Sprintf("%s:%d", KindEndpoint, endpoint.ID),
+			Fields: map[string]string{
+				"id":     strconv.Itoa(int(endpoint.ID)),
+				"name":   endpoint.Name,
+				"url":    endpoint.URL,
+				"type":   endpointTypeNames[endpoint.Type],
+				"status": status,
+				"group":  groupNames[endpoint.GroupID],
+			},
+		}
+
+		for key, value := range endpoint.Metadata {
+			item.Fields["metadata."+key] = value
+		}
+
+		items = append(items, item)
+
+		if !withContainers || !endpointutils.IsDockerEndpoint(&endpoint) {
+			continue
+		}
+
+		containers, err := containerItems(dataStore, &endpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, containers...)
+	}
+
+	return items, nil
+}
+
+func containerItems(dataStore dataservices.DataStore, endpoint *portainer.Endpoint) ([]Item, error) {
+	snapshot, err := dataStore.Snapshot().Read(endpoint.ID)
+	if dataStore.IsErrObjectNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if snapshot.Docker == nil {
+		return nil, nil
+	}
+
+	items := []Item{}
+
+	for _, container := range snapshot.Docker.SnapshotRaw.Containers {
+		name := ""
+		if len(container.Names) > 0 {
+			name = strings.TrimPrefix(container.Names[0], "/")
+		}
+
+		item := Item{
+			Kind: KindContainer,
+			Key:  fmt.Sprintf("%s:%d:%s", KindContainer, endpoint.ID, container.ID),
+			Fields: map[string]string{
+				"id":           container.ID,
+				"name":         name,
+				"image":        container.Image,
+				"state":        container.State,
+				"status":       container.Status,
+				"endpointId":   strconv.Itoa(int(endpoint.ID)),
+				"endpointName": endpoint.Name,
+			},
+		}
+
+		for key, value := range container.Labels {
+			item.Fields["label."+key] = value
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// DefaultFieldMappings returns the mapping used by the connectors without field mappings
+func DefaultFieldMappings(connectorType portainer.CMDBConnectorType) []portainer.CMDBFieldMapping {
+	if connectorType == portainer.CMDBConnectorServiceNow {
+		return []portainer.CMDBFieldMapping{
+			{Kind: KindEndpoint, Source: "key", Target: "correlation_id"},
+			{Kind: KindEndpoint, Source: "name", Target: "name"},
+			{Kind: KindEndpoint, Source: "url", Target: "url"},
+			{Kind: KindEndpoint, Source: "status", Target: "operational_status"},
+			{Kind: KindContainer, Source: "key", Target: "correlation_id"},
+			{Kind: KindContainer, Source: "name", Target: "name"},
+			{Kind: KindContainer, Source: "image", Target: "image"},
+			{Kind: KindContainer, Source: "state", Target: "operational_status"},
+		}
+	}
+
+	mappings := []portainer.CMDBFieldMapping{}
+	for _, source := range []string{"key", "id", "name", "url", "type", "status", "group"} {
+		mappings = append(mappings, portainer.CMDBFieldMapping{Kind: KindEndpoint, Source: source, Target: source})
+	}
+
+	for _, source := range []string{"key", "id", "name", "image", "state", "status", "endpointId", "endpointName"} {
+		mappings = append(mappings, portainer.CMDBFieldMapping{Kind: KindContainer, Source: source, Target: source})
+	}
+
+	return mappings
+}
+
+// Record maps the fields of the item to the fields of the CMDB, the fields missing from the item being sent empty
+func Record(item Item, mappings []portainer.CMDBFieldMapping) map[string]string {
+	record := make(map[string]string)
+
+	for _, mapping := range mappings {
+		if mapping.Kind != item.Kind {
+			continue
+		}
+
+		if mapping.Source == "key" {
+			record[mapping.Target] = item.Key
+			continue
+		}
+
+		record[mapping.Target] = item.Fields[mapping.Source]
+	}
+
+	return record
+}
+
+// hash returns a digest of the record, used to detect the items modified since the previous synchronization
+func hash(record map[string]string) string {
+	// the keys of the maps are sorted by encoding/json
+	content, _ := json.Marshal(record)
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,250 @@
+// Package cmdb synchronizes the inventory of the environments and of their containers to configuration management
+// databases such as ServiceNow
+package cmdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/rs/zerolog/log"
+)
+
+// schedulingInterval is the interval in which the connectors due for a synchronization are looked up
+const schedulingInterval = time.Minute
+
+// Service synchronizes the inventory to the CMDB connectors on their schedule
+type Service struct {
+	dataStore    dataservices.DataStore
+	scheduler    *scheduler.Scheduler
+	newConnector func(portainer.CMDBConnector) (Connector, error)
+
+	mu sync.Mutex
+}
+
+// NewService creates a new instance of the CMDB synchronization service
+func NewService(dataStore dataservices.DataStore, scheduler *scheduler.Scheduler) *Service {
+	return &Service{
+		dataStore:    dataStore,
+		scheduler:    scheduler,
+		newConnector: NewConnector,
+	}
+}
+
+// Start schedules the synchronization of the enabled connectors
+func (service *Service) Start() {
+	service.scheduler.StartJobEvery(schedulingInterval, func() error {
+		service.syncDue(context.Background())
+		return nil
+	})
+}
+
+// ValidateConnector checks that the connector can be synchronized
+func ValidateConnector(connector portainer.CMDBConnector) error {
+	if connector.Name == "" {
+		return errors.New("CMDB connector name is required")
+	}
+
+	if _, err := NewConnector(connector); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(connector.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("invalid CMDB URL, an absolute http or https URL is expected")
+	}
+
+	if connector.Type == portainer.CMDBConnectorServiceNow && connector.Username == "" {
+		return errors.New("username is required for the ServiceNow connectors")
+	}
+
+	if _, err := parseInterval(connector.Interval); err != nil {
+		return err
+	}
+
+	for _, mapping := range connector.FieldMappings {
+		if mapping.Kind != KindEndpoint && mapping.Kind != KindContainer {
+			return fmt.Errorf("invalid field mapping kind %q, endpoint or container is expected", mapping.Kind)
+		}
+
+		if mapping.Source == "" || mapping.Target == "" {
+			return errors.New("the source and the target of the field mappings are required")
+		}
+	}
+
+	return nil
+}
+
+func parseInterval(interval string) (time.Duration, error) {
+	if interval == "" {
+		interval = portainer.DefaultCMDBSyncInterval
+	}
+
+	duration, err := time.ParseDuration(interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid synchronization interval: %w", err)
+	}
+
+	if duration < schedulingInterval {
+		return 0, errors.New("the synchronization interval must be at least 1m")
+	}
+
+	return duration, nil
+}
+
+// syncDue synchronizes the enabled connectors whose interval elapsed since their last synchronization
+func (service *Service) syncDue(ctx context.Context) {
+	connectors, err := service.dataStore.CMDBConnector().ReadAll()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to retrieve the CMDB connectors")
+		return
+	}
+
+	now := time.Now()
+
+	for _, connector := range connectors {
+		if !connector.Enabled {
+			continue
+		}
+
+		interval, err := parseInterval(connector.Interval)
+		if err != nil {
+			continue
+		}
+
+		if now.Before(time.Unix(connector.Status.LastSync, 0).Add(interval)) {
+			continue
+		}
+
+		status, err := service.Sync(ctx, connector.ID)
+		if err != nil {
+			log.Error().Err(err).Str("connector", connector.Name).Msg("unable to synchronize the CMDB connector")
+		} else if !status.Success {
+			log.Warn().Str("error", status.Error).Str("connector", connector.Name).Msg("the synchronization of the CMDB connector failed")
+		}
+	}
+}
+
+// Sync pushes the changes of the inventory since the previous synchronization to the CMDB of the connector. The outcome
+// of the synchronization is reported in the returned status, an error being returned only when the connector cannot be
+// read or updated.
+func (service *Service) Sync(ctx context.Context, id portainer.CMDBConnectorID) (*portainer.CMDBSyncStatus, error) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	config, err := service.dataStore.CMDBConnector().Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	state := make(map[string]portainer.CMDBItemState, len(config.State))
+	for key, item := range config.State {
+		state[key] = item
+	}
+
+	status := portainer.CMDBSyncStatus{LastSync: time.Now().Unix(), Success: true}
+
+	if err := service.push(ctx, config, state, &status); err != nil {
+		status.Success = false
+		status.Error = err.Error()
+	}
+
+	// the connector is read again as it may have been updated during the synchronization
+	connector, err := service.dataStore.CMDBConnector().Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	connector.State = state
+	connector.Status = status
+
+	if err := service.dataStore.CMDBConnector().Update(id, connector); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// push creates, updates and deletes the items of the CMDB, recording them in the state. It stops at the first error,
+// the items pushed so far being kept in the state so that the next synchronization resumes from there.
+func (service *Service) push(ctx context.Context, config *portainer.CMDBConnector, state map[string]portainer.CMDBItemState, status *portainer.CMDBSyncStatus) error {
+	connector, err := service.newConnector(*config)
+	if err != nil {
+		return err
+	}
+
+	items, err := Collect(service.dataStore, config.SyncContainers)
+	if err != nil {
+		return fmt.Errorf("unable to collect the inventory: %w", err)
+	}
+
+	mappings := config.FieldMappings
+	if len(mappings) == 0 {
+		mappings = DefaultFieldMappings(config.Type)
+	}
+
+	seen := make(map[string]bool, len(items))
+
+	for _, item := range items {
+		seen[item.Key] = true
+
+		record := Record(item, mappings)
+		digest := hash(record)
+
+		previous, ok := state[item.Key]
+		if !ok {
+			remoteID, err := connector.Create(ctx, item, record)
+			if err != nil {
+				return fmt.Errorf("unable to create %s: %w", item.Key, err)
+			}
+
+			state[item.Key] = portainer.CMDBItemState{RemoteID: remoteID, Hash: digest}
+			status.Created++
+
+			continue
+		}
+
+		if previous.Hash == digest {
+			continue
+		}
+
+		if err := connector.Update(ctx, item, previous.RemoteID, record); err != nil {
+			return fmt.Errorf("unable to update %s: %w", item.Key, err)
+		}
+
+		state[item.Key] = portainer.CMDBItemState{RemoteID: previous.RemoteID, Hash: digest}
+		status.Updated++
+	}
+
+	for key, previous := range state {
+		if seen[key] {
+			continue
+		}
+
+		if err := connector.Delete(ctx, itemKind(key), previous.RemoteID); err != nil {
+			return fmt.Errorf("unable to delete %s: %w", key, err)
+		}
+
+		delete(state, key)
+		status.Deleted++
+	}
+
+	return nil
+}
+
+// itemKind returns the kind of an item from its key
+func itemKind(key string) string {
+	if strings.HasPrefix(key, KindContainer+":") {
+		return KindContainer
+	}
+
+	return KindEndpoint
+}
@@ -0,0 +1,192 @@
+package cmdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeServiceNow stores the records created through the Table API of a ServiceNow instance
+type fakeServiceNow struct {
+	mu      sync.Mutex
+	records map[string]map[string]string
+	nextID  int
+}
+
+func (fake *fakeServiceNow) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	if username, password, ok := r.BasicAuth(); !ok || username != "portainer" || password != "s3cr3t" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/now/table/")
+
+	switch r.Method {
+	case http.MethodPost:
+		var record map[string]string
+		json.NewDecoder(r.Body).Decode(&record)
+
+		fake.nextID++
+		sysID := fmt.Sprintf("%s/%d", path, fake.nextID)
+		fake.records[sysID] = record
+
+		json.NewEncoder(w).Encode(map[string]any{"result": map[string]string{"sys_id": fmt.Sprint(fake.nextID)}})
+	case http.MethodPatch:
+		if _, ok := fake.records[path]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var record map[string]string
+		json.NewDecoder(r.Body).Decode(&record)
+		fake.records[path] = record
+	case http.MethodDelete:
+		delete(fake.records, path)
+	}
+}
+
+func TestSync(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fake := &fakeServiceNow{records: make(map[string]map[string]string)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "production", URL: "tcp://10.0.0.1:2375", Type: portainer.DockerEnvironment, GroupID: 1}
+	assert.NoError(t, store.Endpoint().Create(endpoint))
+	assert.NoError(t, store.Endpoint().Create(&portainer.Endpoint{ID: 2, Name: "staging", Type: portainer.AgentOnDockerEnvironment, GroupID: 1}))
+
+	snapshot := &portainer.Snapshot{EndpointID: 1, Docker: &portainer.DockerSnapshot{}}
+	snapshot.Docker.SnapshotRaw.Containers = []portainer.DockerContainerSnapshot{
+		{Container: types.Container{ID: "abc", Names: []string{"/web"}, Image: "nginx:1.25", State: "running"}},
+	}
+	assert.NoError(t, store.Snapshot().Create(snapshot))
+
+	connector := &portainer.CMDBConnector{
+		Name:           "servicenow",
+		Type:           portainer.CMDBConnectorServiceNow,
+		URL:            server.URL,
+		Username:       "portainer",
+		Password:       "s3cr3t",
+		SyncContainers: true,
+	}
+	assert.NoError(t, store.CMDBConnector().Create(connector))
+
+	service := NewService(store, nil)
+
+	status, err := service.Sync(context.Background(), connector.ID)
+	assert.NoError(t, err)
+	assert.True(t, status.Success, status.Error)
+	assert.Equal(t, 3, status.Created)
+
+	assert.Equal(t, map[string]string{
+		"correlation_id":     "endpoint:1",
+		"name":               "production",
+		"url":                "tcp://10.0.0.1:2375",
+		"operational_status": "up",
+	}, fake.records["cmdb_ci_docker_engine/1"])
+	assert.Equal(t, "nginx:1.25", fake.records["cmdb_ci_docker_container/2"]["image"])
+
+	// unchanged items are not sent again
+	status, err = service.Sync(context.Background(), connector.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, portainer.CMDBSyncStatus{LastSync: status.LastSync, Success: true}, *status)
+
+	endpoint.Status = portainer.EndpointStatusDown
+	assert.NoError(t, store.Endpoint().UpdateEndpoint(endpoint.ID, endpoint))
+	assert.NoError(t, store.Endpoint().DeleteEndpoint(2))
+
+	status, err = service.Sync(context.Background(), connector.ID)
+	assert.NoError(t, err)
+	assert.True(t, status.Success, status.Error)
+	assert.Equal(t, 1, status.Updated)
+	assert.Equal(t, 1, status.Deleted)
+	assert.Equal(t, "down", fake.records["cmdb_ci_docker_engine/1"]["operational_status"])
+	assert.Len(t, fake.records, 2)
+
+	stored, err := store.CMDBConnector().Read(connector.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, *status, stored.Status)
+	assert.Len(t, stored.State, 2)
+}
+
+func TestSync_ReportsFailures(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	fake := &fakeServiceNow{records: make(map[string]map[string]string)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	assert.NoError(t, store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "production", Type: portainer.DockerEnvironment, GroupID: 1}))
+
+	connector := &portainer.CMDBConnector{
+		Name:     "servicenow",
+		Type:     portainer.CMDBConnectorServiceNow,
+		URL:      server.URL,
+		Username: "portainer",
+		Password: "wrong",
+	}
+	assert.NoError(t, store.CMDBConnector().Create(connector))
+
+	status, err := NewService(store, nil).Sync(context.Background(), connector.ID)
+	assert.NoError(t, err)
+	assert.False(t, status.Success)
+	assert.Contains(t, status.Error, "returned the status 401")
+
+	stored, err := store.CMDBConnector().Read(connector.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, stored.State)
+}
+
+func TestRecord(t *testing.T) {
+	item := Item{
+		Kind:   KindContainer,
+		Key:    "container:1:abc",
+		Fields: map[string]string{"name": "web", "label.com.example.team": "payments"},
+	}
+
+	record := Record(item, []portainer.CMDBFieldMapping{
+		{Kind: KindContainer, Source: "key", Target: "u_key"},
+		{Kind: KindContainer, Source: "label.com.example.team", Target: "u_team"},
+		{Kind: KindContainer, Source: "image", Target: "u_image"},
+		{Kind: KindEndpoint, Source: "name", Target: "u_endpoint"},
+	})
+
+	assert.Equal(t, map[string]string{"u_key": "container:1:abc", "u_team": "payments", "u_image": ""}, record)
+}
+
+func TestValidateConnector(t *testing.T) {
+	valid := portainer.CMDBConnector{Name: "cmdb", Type: portainer.CMDBConnectorREST, URL: "https://cmdb.example.com/api"}
+	assert.NoError(t, ValidateConnector(valid))
+
+	for name, update := range map[string]func(connector *portainer.CMDBConnector){
+		"unknown type":     func(connector *portainer.CMDBConnector) { connector.Type = "ldap" },
+		"relative URL":     func(connector *portainer.CMDBConnector) { connector.URL = "/api" },
+		"short interval":   func(connector *portainer.CMDBConnector) { connector.Interval = "10s" },
+		"missing username": func(connector *portainer.CMDBConnector) { connector.Type = portainer.CMDBConnectorServiceNow },
+		"invalid kind": func(connector *portainer.CMDBConnector) {
+			connector.FieldMappings = []portainer.CMDBFieldMapping{{Kind: "volume", Source: "name", Target: "name"}}
+		},
+		"missing mapping src": func(connector *portainer.CMDBConnector) {
+			connector.FieldMappings = []portainer.CMDBFieldMapping{{Kind: KindEndpoint, Target: "name"}}
+		},
+	} {
+		connector := valid
+		update(&connector)
+		assert.Error(t, ValidateConnector(connector), name)
+	}
+}
@@ -0,0 +1,239 @@
+// Package contenttrust verifies the detached signatures of the templates feeds and of the stack files hosted in git
+// repositories. The signatures are read from a file named after the signed file with the .sig extension and can be
+// produced by cosign (cosign sign-blob, ECDSA P-256 or Ed25519 keys in PEM format) or by minisign.
+package contenttrust
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+// SignatureExtension is the extension of the signature files, appended to the name of the signed files
+const SignatureExtension = ".sig"
+
+const (
+	minisignCommentPrefix        = "untrusted comment:"
+	minisignTrustedCommentPrefix = "trusted comment: "
+	minisignKeyIDLength          = 8
+)
+
+var (
+	// ErrUnsignedContent is returned when the content is not signed while the signatures are enforced
+	ErrUnsignedContent = errors.New("the content is not signed and the signatures are enforced")
+	// ErrInvalidSignature is returned when the signature does not match the content for any of the trusted keys
+	ErrInvalidSignature = errors.New("the signature of the content is not valid for any of the trusted keys")
+)
+
+// trustedKey is a parsed public key, only one of its keys is set
+type trustedKey struct {
+	name     string
+	ecdsa    *ecdsa.PublicKey
+	ed25519  ed25519.PublicKey
+	minisign *minisignPublicKey
+}
+
+type minisignPublicKey struct {
+	keyID []byte
+	key   ed25519.PublicKey
+}
+
+// ValidateSettings checks the trusted keys of the content trust settings
+func ValidateSettings(settings portainer.ContentTrustSettings) error {
+	names := make(map[string]bool, len(settings.TrustedKeys))
+
+	for _, key := range settings.TrustedKeys {
+		if key.Name == "" {
+			return errors.New("invalid trusted key, the name is required")
+		}
+
+		if names[key.Name] {
+			return fmt.Errorf("invalid trusted key, the name %q is already used", key.Name)
+		}
+		names[key.Name] = true
+
+		if _, err := parsePublicKey(key); err != nil {
+			return err
+		}
+	}
+
+	if settings.Enforce && len(settings.TrustedKeys) == 0 {
+		return errors.New("at least one trusted key is required to enforce the signatures")
+	}
+
+	return nil
+}
+
+// Verify checks the signature of the content against the trusted keys. A nil signature means the content is not
+// signed, which is only refused when the signatures are enforced. The signatures are not checked when no key is
+// trusted.
+func Verify(settings portainer.ContentTrustSettings, content, signature []byte) error {
+	if signature == nil {
+		if settings.Enforce {
+			return ErrUnsignedContent
+		}
+
+		return nil
+	}
+
+	if len(settings.TrustedKeys) == 0 {
+		return nil
+	}
+
+	keys := make([]trustedKey, 0, len(settings.TrustedKeys))
+	for _, key := range settings.TrustedKeys {
+		parsed, err := parsePublicKey(key)
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, parsed)
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte(minisignCommentPrefix)) {
+		return verifyMinisign(keys, content, signature)
+	}
+
+	rawSignature, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	digest := sha256.Sum256(content)
+	for _, key := range keys {
+		switch {
+		case key.ecdsa != nil && ecdsa.VerifyASN1(key.ecdsa, digest[:], rawSignature):
+			return nil
+		case key.ed25519 != nil && ed25519.Verify(key.ed25519, content, rawSignature):
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+// VerifyFile checks the signature of a file stored under a root directory, the signature being read from the file
+// with the same name and the .sig extension
+func VerifyFile(settings portainer.ContentTrustSettings, root, path string) error {
+	if !settings.Enforce && len(settings.TrustedKeys) == 0 {
+		return nil
+	}
+
+	filePath := filepath.Join(root, filepath.Clean("/"+path))
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	signature, err := os.ReadFile(filePath + SignatureExtension)
+	if os.IsNotExist(err) {
+		signature = nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := Verify(settings, content, signature); err != nil {
+		return errors.WithMessagef(err, "unable to verify the signature of %s", path)
+	}
+
+	return nil
+}
+
+func parsePublicKey(key portainer.TrustedKey) (trustedKey, error) {
+	parsed := trustedKey{name: key.Name}
+	content := strings.TrimSpace(key.PublicKey)
+
+	if block, _ := pem.Decode([]byte(content)); block != nil {
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return parsed, errors.WithMessagef(err, "invalid public key of the trusted key %q", key.Name)
+		}
+
+		switch publicKey := publicKey.(type) {
+		case *ecdsa.PublicKey:
+			parsed.ecdsa = publicKey
+		case ed25519.PublicKey:
+			parsed.ed25519 = publicKey
+		default:
+			return parsed, fmt.Errorf("unsupported public key of the trusted key %q, ECDSA and Ed25519 keys are supported", key.Name)
+		}
+
+		return parsed, nil
+	}
+
+	// minisign public key, optionally preceded by its comment
+	lines := strings.Split(content, "\n")
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil || len(decoded) != 2+minisignKeyIDLength+ed25519.PublicKeySize || string(decoded[:2]) != "Ed" {
+		return parsed, fmt.Errorf("invalid public key of the trusted key %q, a PEM or minisign public key is expected", key.Name)
+	}
+
+	parsed.minisign = &minisignPublicKey{
+		keyID: decoded[2 : 2+minisignKeyIDLength],
+		key:   ed25519.PublicKey(decoded[2+minisignKeyIDLength:]),
+	}
+
+	return parsed, nil
+}
+
+// verifyMinisign checks a minisign signature, made of an untrusted comment, the signature, a trusted comment and
+// the global signature of the signature and the trusted comment
+func verifyMinisign(keys []trustedKey, content, signature []byte) error {
+	lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+	if len(lines) < 2 {
+		return ErrInvalidSignature
+	}
+
+	rawSignature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(rawSignature) != 2+minisignKeyIDLength+ed25519.SignatureSize {
+		return ErrInvalidSignature
+	}
+
+	algorithm := string(rawSignature[:2])
+	keyID := rawSignature[2 : 2+minisignKeyIDLength]
+	sig := rawSignature[2+minisignKeyIDLength:]
+
+	message := content
+	switch algorithm {
+	case "Ed":
+	case "ED":
+		digest := blake2b.Sum512(content)
+		message = digest[:]
+	default:
+		return ErrInvalidSignature
+	}
+
+	for _, key := range keys {
+		if key.minisign == nil || !bytes.Equal(key.minisign.keyID, keyID) || !ed25519.Verify(key.minisign.key, message, sig) {
+			continue
+		}
+
+		if len(lines) < 4 {
+			return nil
+		}
+
+		trustedComment, found := strings.CutPrefix(strings.TrimRight(lines[2], "\r"), minisignTrustedCommentPrefix)
+		globalSignature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+		if !found || err != nil || !ed25519.Verify(key.minisign.key, append(append([]byte{}, sig...), trustedComment...), globalSignature) {
+			return ErrInvalidSignature
+		}
+
+		return nil
+	}
+
+	return ErrInvalidSignature
+}
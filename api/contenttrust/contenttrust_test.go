@@ -0,0 +1,182 @@
+package contenttrust
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/blake2b"
+)
+
+var content = []byte("version: '3'\nservices:\n  web:\n    image: nginx\n")
+
+func pemPublicKey(t *testing.T, key any) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	assert.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// minisignKey returns a minisign public key and a function signing a content with the prehashed algorithm
+func minisignKey(t *testing.T) (string, func(content []byte, trustedComment string) []byte) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	publicKey := "untrusted comment: minisign public key 0807060504030201\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), public...))
+
+	sign := func(content []byte, trustedComment string) []byte {
+		digest := blake2b.Sum512(content)
+		sig := ed25519.Sign(private, digest[:])
+		globalSignature := ed25519.Sign(private, append(append([]byte{}, sig...), trustedComment...))
+
+		return []byte("untrusted comment: signature from minisign secret key\n" +
+			base64.StdEncoding.EncodeToString(append(append([]byte("ED"), keyID...), sig...)) + "\n" +
+			"trusted comment: " + trustedComment + "\n" +
+			base64.StdEncoding.EncodeToString(globalSignature) + "\n")
+	}
+
+	return publicKey, sign
+}
+
+func TestValidateSettings(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	minisignPublicKey, _ := minisignKey(t)
+
+	valid := portainer.ContentTrustSettings{
+		Enforce: true,
+		TrustedKeys: []portainer.TrustedKey{
+			{Name: "cosign", PublicKey: pemPublicKey(t, public)},
+			{Name: "minisign", PublicKey: minisignPublicKey},
+		},
+	}
+	assert.NoError(t, ValidateSettings(valid))
+	assert.NoError(t, ValidateSettings(portainer.ContentTrustSettings{}))
+
+	for _, settings := range []portainer.ContentTrustSettings{
+		{Enforce: true},
+		{TrustedKeys: []portainer.TrustedKey{{PublicKey: minisignPublicKey}}},
+		{TrustedKeys: []portainer.TrustedKey{{Name: "invalid", PublicKey: "not a key"}}},
+		{TrustedKeys: []portainer.TrustedKey{{Name: "key", PublicKey: minisignPublicKey}, {Name: "key", PublicKey: minisignPublicKey}}},
+	} {
+		assert.Error(t, ValidateSettings(settings), "%+v", settings)
+	}
+}
+
+func TestVerify_ECDSA(t *testing.T) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	digest := sha256.Sum256(content)
+	rawSignature, err := ecdsa.SignASN1(rand.Reader, private, digest[:])
+	assert.NoError(t, err)
+	signature := []byte(base64.StdEncoding.EncodeToString(rawSignature))
+
+	settings := portainer.ContentTrustSettings{
+		Enforce:     true,
+		TrustedKeys: []portainer.TrustedKey{{Name: "cosign", PublicKey: pemPublicKey(t, &private.PublicKey)}},
+	}
+
+	assert.NoError(t, Verify(settings, content, signature))
+	assert.ErrorIs(t, Verify(settings, append(content, '#'), signature), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify(settings, content, []byte("not a signature")), ErrInvalidSignature)
+}
+
+func TestVerify_Ed25519(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	signature := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(private, content)))
+
+	settings := portainer.ContentTrustSettings{
+		TrustedKeys: []portainer.TrustedKey{
+			{Name: "other", PublicKey: pemPublicKey(t, otherPublic)},
+			{Name: "cosign", PublicKey: pemPublicKey(t, public)},
+		},
+	}
+
+	assert.NoError(t, Verify(settings, content, signature))
+	assert.ErrorIs(t, Verify(settings, append(content, '#'), signature), ErrInvalidSignature)
+
+	settings.TrustedKeys = settings.TrustedKeys[:1]
+	assert.ErrorIs(t, Verify(settings, content, signature), ErrInvalidSignature)
+}
+
+func TestVerify_Minisign(t *testing.T) {
+	publicKey, sign := minisignKey(t)
+
+	settings := portainer.ContentTrustSettings{
+		Enforce:     true,
+		TrustedKeys: []portainer.TrustedKey{{Name: "minisign", PublicKey: publicKey}},
+	}
+
+	signature := sign(content, "timestamp:1700000000\tfile:docker-compose.yml")
+	assert.NoError(t, Verify(settings, content, signature))
+	assert.ErrorIs(t, Verify(settings, append(content, '#'), signature), ErrInvalidSignature)
+
+	// a tampered trusted comment invalidates the global signature
+	lines := strings.Split(string(signature), "\n")
+	lines[2] = "trusted comment: timestamp:1800000000"
+	tampered := []byte(strings.Join(lines, "\n"))
+	assert.ErrorIs(t, Verify(settings, content, tampered), ErrInvalidSignature)
+
+	_, otherSign := minisignKey(t)
+	assert.ErrorIs(t, Verify(settings, content, otherSign(content, "")), ErrInvalidSignature)
+}
+
+func TestVerify_Unsigned(t *testing.T) {
+	publicKey, _ := minisignKey(t)
+
+	assert.NoError(t, Verify(portainer.ContentTrustSettings{}, content, nil))
+	assert.NoError(t, Verify(portainer.ContentTrustSettings{}, content, []byte("ignored")))
+
+	settings := portainer.ContentTrustSettings{
+		TrustedKeys: []portainer.TrustedKey{{Name: "minisign", PublicKey: publicKey}},
+	}
+	assert.NoError(t, Verify(settings, content, nil))
+
+	settings.Enforce = true
+	assert.ErrorIs(t, Verify(settings, content, nil), ErrUnsignedContent)
+}
+
+func TestVerifyFile(t *testing.T) {
+	publicKey, sign := minisignKey(t)
+	root := t.TempDir()
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "stacks"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "stacks", "signed.yml"), content, 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "stacks", "signed.yml"+SignatureExtension), sign(content, "signed"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "stacks", "unsigned.yml"), content, 0o644))
+
+	// nothing is read when the signatures are not checked
+	assert.NoError(t, VerifyFile(portainer.ContentTrustSettings{}, root, "stacks/missing.yml"))
+
+	settings := portainer.ContentTrustSettings{
+		Enforce:     true,
+		TrustedKeys: []portainer.TrustedKey{{Name: "minisign", PublicKey: publicKey}},
+	}
+
+	assert.NoError(t, VerifyFile(settings, root, "stacks/signed.yml"))
+	assert.ErrorIs(t, VerifyFile(settings, root, "stacks/unsigned.yml"), ErrUnsignedContent)
+	assert.Error(t, VerifyFile(settings, root, "stacks/missing.yml"))
+
+	settings.Enforce = false
+	assert.NoError(t, VerifyFile(settings, root, "stacks/unsigned.yml"))
+}
@@ -0,0 +1,282 @@
+// Package cost estimates the cost of the stacks from the costs of their environments and the resources used by their
+// containers
+package cost
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	// GroupByStack groups the costs by stack of each environment
+	GroupByStack = "stack"
+	// GroupByTeam groups the costs by team owning the stacks
+	GroupByTeam = "team"
+	// GroupByEndpoint groups the costs by environment
+	GroupByEndpoint = "endpoint"
+
+	gib = 1 << 30
+)
+
+// Sample represents the resources used by the running containers of a stack at the time of a sample
+type Sample struct {
+	EndpointID portainer.EndpointID
+	StackID    portainer.StackID
+	StackName  string
+	TeamIDs    []portainer.TeamID
+	// Number of running containers
+	Containers int
+	// CPUs used by the containers, 1 being a full CPU
+	CPUs float64
+	// Memory used by the containers, in GiB
+	MemoryGiB float64
+}
+
+// Allocate estimates the cost of the stacks of an environment during the specified number of hours. The cost of the
+// host is split among the stacks according to their share of the CPU and memory used on the host, or of the running
+// containers when no resource usage is known, while the cloud prices are charged for the resources used by each stack.
+func Allocate(settings portainer.CostSettings, samples []Sample, hours float64) []portainer.CostEntry {
+	var totalCPUs, totalMemory float64
+	var totalContainers int
+
+	for _, sample := range samples {
+		totalCPUs += sample.CPUs
+		totalMemory += sample.MemoryGiB
+		totalContainers += sample.Containers
+	}
+
+	entries := make([]portainer.CostEntry, 0, len(samples))
+
+	for _, sample := range samples {
+		var shares []float64
+		if totalCPUs > 0 {
+			shares = append(shares, sample.CPUs/totalCPUs)
+		}
+
+		if totalMemory > 0 {
+			shares = append(shares, sample.MemoryGiB/totalMemory)
+		}
+
+		if len(shares) == 0 && totalContainers > 0 {
+			shares = append(shares, float64(sample.Containers)/float64(totalContainers))
+		}
+
+		var share float64
+		for _, s := range shares {
+			share += s / float64(len(shares))
+		}
+
+		hourlyCost := settings.HourlyCost*share + settings.CPUHourlyCost*sample.CPUs + settings.MemoryHourlyCost*sample.MemoryGiB
+
+		entries = append(entries, portainer.CostEntry{
+			EndpointID:     sample.EndpointID,
+			StackID:        sample.StackID,
+			StackName:      sample.StackName,
+			TeamIDs:        sample.TeamIDs,
+			Hours:          hours,
+			CPUHours:       sample.CPUs * hours,
+			MemoryGiBHours: sample.MemoryGiB * hours,
+			Cost:           hourlyCost * hours,
+		})
+	}
+
+	return entries
+}
+
+// ValidateSettings checks that the costs of an environment are not negative
+func ValidateSettings(settings *portainer.CostSettings) error {
+	if settings == nil {
+		return nil
+	}
+
+	if settings.HourlyCost < 0 || settings.CPUHourlyCost < 0 || settings.MemoryHourlyCost < 0 {
+		return errors.New("invalid costs, the costs cannot be negative")
+	}
+
+	return nil
+}
+
+// ReportID returns the identifier of the cost report of the month of the specified time
+func ReportID(t time.Time) portainer.CostReportID {
+	return portainer.CostReportID(t.Year()*100 + int(t.Month()))
+}
+
+// ParseMonth parses a month formatted as YYYY-MM into the identifier of its cost report
+func ParseMonth(month string) (portainer.CostReportID, error) {
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return 0, fmt.Errorf("invalid month %q, YYYY-MM is expected", month)
+	}
+
+	return ReportID(t), nil
+}
+
+// NewReport creates the empty cost report of the month of the specified time
+func NewReport(t time.Time) *portainer.CostReport {
+	return &portainer.CostReport{
+		ID:      ReportID(t),
+		Month:   t.Format("2006-01"),
+		Entries: []portainer.CostEntry{},
+	}
+}
+
+// Merge adds the entries to the report, accumulating the costs of the stacks already in the report. The teams of the
+// stacks are replaced with the latest ones.
+func Merge(report *portainer.CostReport, entries []portainer.CostEntry) {
+	for _, entry := range entries {
+		i := slices.IndexFunc(report.Entries, func(existing portainer.CostEntry) bool {
+			return existing.EndpointID == entry.EndpointID && existing.StackID == entry.StackID && existing.StackName == entry.StackName
+		})
+
+		if i == -1 {
+			report.Entries = append(report.Entries, entry)
+			continue
+		}
+
+		existing := &report.Entries[i]
+		existing.TeamIDs = entry.TeamIDs
+		existing.Hours += entry.Hours
+		existing.CPUHours += entry.CPUHours
+		existing.MemoryGiBHours += entry.MemoryGiBHours
+		existing.Cost += entry.Cost
+	}
+}
+
+// Item represents the estimated cost of a stack, a team or an environment during a month
+type Item struct {
+	// Environment of the stack, set when the costs are grouped by stack
+	EndpointID portainer.EndpointID `json:"EndpointId,omitempty" example:"1"`
+	// Identifier of the stack, of the team or of the environment, 0 for the containers outside of the stacks and for
+	// the stacks not owned by any team
+	ID   int    `json:"Id" example:"1"`
+	Name string `json:"Name" example:"web"`
+	// CPUs used during the month
+	CPUHours float64 `json:"CPUHours" example:"36"`
+	// GiB of memory used during the month
+	MemoryGiBHours float64 `json:"MemoryGiBHours" example:"360"`
+	// Estimated cost, rounded to the cent
+	Cost float64 `json:"Cost" example:"42.5"`
+}
+
+// Summary represents the estimated costs of a month
+type Summary struct {
+	// Month of the costs, formatted as YYYY-MM
+	Month string `json:"Month" example:"2024-10"`
+	// Grouping of the costs, one of stack, team or endpoint
+	GroupBy string `json:"GroupBy" example:"team"`
+	// Estimated cost of the month, rounded to the cent
+	Total float64 `json:"Total" example:"120.75"`
+	Items []Item  `json:"Items"`
+}
+
+// Names resolves the names of the teams and of the environments of the summaries
+type Names struct {
+	Teams     map[portainer.TeamID]string
+	Endpoints map[portainer.EndpointID]string
+}
+
+// Summarize groups the costs of the report, the cost of the stacks owned by several teams being split evenly among them
+func Summarize(report *portainer.CostReport, groupBy string, names Names) Summary {
+	summary := Summary{Month: report.Month, GroupBy: groupBy, Items: []Item{}}
+
+	add := func(item Item, fraction float64, entry portainer.CostEntry) {
+		i := slices.IndexFunc(summary.Items, func(existing Item) bool {
+			return existing.EndpointID == item.EndpointID && existing.ID == item.ID && existing.Name == item.Name
+		})
+
+		if i == -1 {
+			summary.Items = append(summary.Items, item)
+			i = len(summary.Items) - 1
+		}
+
+		summary.Items[i].CPUHours += entry.CPUHours * fraction
+		summary.Items[i].MemoryGiBHours += entry.MemoryGiBHours * fraction
+		summary.Items[i].Cost += entry.Cost * fraction
+	}
+
+	for _, entry := range report.Entries {
+		summary.Total += entry.Cost
+
+		switch groupBy {
+		case GroupByTeam:
+			if len(entry.TeamIDs) == 0 {
+				add(Item{Name: "Unassigned"}, 1, entry)
+				continue
+			}
+
+			for _, teamID := range entry.TeamIDs {
+				add(Item{ID: int(teamID), Name: names.Teams[teamID]}, 1/float64(len(entry.TeamIDs)), entry)
+			}
+		case GroupByEndpoint:
+			add(Item{ID: int(entry.EndpointID), Name: names.Endpoints[entry.EndpointID]}, 1, entry)
+		default:
+			name := entry.StackName
+			if name == "" {
+				name = "Containers outside of the stacks"
+			}
+
+			add(Item{EndpointID: entry.EndpointID, ID: int(entry.StackID), Name: name}, 1, entry)
+		}
+	}
+
+	for i := range summary.Items {
+		summary.Items[i].CPUHours = round(summary.Items[i].CPUHours)
+		summary.Items[i].MemoryGiBHours = round(summary.Items[i].MemoryGiBHours)
+		summary.Items[i].Cost = round(summary.Items[i].Cost)
+	}
+	summary.Total = round(summary.Total)
+
+	sort.SliceStable(summary.Items, func(i, j int) bool {
+		return summary.Items[i].Cost > summary.Items[j].Cost
+	})
+
+	return summary
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// WriteCSV writes the summary as CSV, one line per item
+func WriteCSV(w io.Writer, summary Summary) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{"month", "group_by", "endpoint_id", "id", "name", "cpu_hours", "memory_gib_hours", "cost"})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range summary.Items {
+		endpointID := ""
+		if item.EndpointID != 0 {
+			endpointID = strconv.Itoa(int(item.EndpointID))
+		}
+
+		err := writer.Write([]string{
+			summary.Month,
+			summary.GroupBy,
+			endpointID,
+			strconv.Itoa(item.ID),
+			item.Name,
+			strconv.FormatFloat(item.CPUHours, 'f', 2, 64),
+			strconv.FormatFloat(item.MemoryGiBHours, 'f', 2, 64),
+			strconv.FormatFloat(item.Cost, 'f', 2, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,81 @@
+package cost
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocate(t *testing.T) {
+	samples := []Sample{
+		{StackID: 1, StackName: "web", Containers: 2, CPUs: 1.5, MemoryGiB: 1},
+		{StackID: 2, StackName: "db", Containers: 1, CPUs: 0.5, MemoryGiB: 3},
+	}
+
+	t.Run("the cost of the host is split according to the resources used", func(t *testing.T) {
+		entries := Allocate(portainer.CostSettings{HourlyCost: 1}, samples, 2)
+
+		// web uses 75% of the CPU and 25% of the memory
+		assert.InDelta(t, 1.0, entries[0].Cost, 1e-9)
+		assert.InDelta(t, 1.0, entries[1].Cost, 1e-9)
+		assert.Equal(t, 3.0, entries[0].CPUHours)
+		assert.Equal(t, 2.0, entries[0].Hours)
+	})
+
+	t.Run("the cloud prices are charged for the resources used", func(t *testing.T) {
+		entries := Allocate(portainer.CostSettings{CPUHourlyCost: 0.1, MemoryHourlyCost: 0.01}, samples, 1)
+
+		assert.InDelta(t, 0.16, entries[0].Cost, 1e-9)
+		assert.InDelta(t, 0.08, entries[1].Cost, 1e-9)
+	})
+
+	t.Run("the running containers are used when no resource usage is known", func(t *testing.T) {
+		entries := Allocate(portainer.CostSettings{HourlyCost: 3}, []Sample{
+			{StackName: "web", Containers: 2},
+			{StackName: "db", Containers: 1},
+		}, 1)
+
+		assert.InDelta(t, 2.0, entries[0].Cost, 1e-9)
+		assert.InDelta(t, 1.0, entries[1].Cost, 1e-9)
+	})
+}
+
+func TestSummarize(t *testing.T) {
+	report := NewReport(time.Date(2024, time.October, 15, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, portainer.CostReportID(202410), report.ID)
+
+	Merge(report, []portainer.CostEntry{
+		{EndpointID: 1, StackID: 1, StackName: "web", TeamIDs: []portainer.TeamID{1, 2}, Hours: 1, Cost: 10},
+		{EndpointID: 1, StackName: "", TeamIDs: []portainer.TeamID{}, Hours: 1, Cost: 1},
+	})
+	Merge(report, []portainer.CostEntry{
+		{EndpointID: 1, StackID: 1, StackName: "web", TeamIDs: []portainer.TeamID{1, 2}, Hours: 1, Cost: 10},
+	})
+
+	assert.Len(t, report.Entries, 2)
+	assert.Equal(t, 2.0, report.Entries[0].Hours)
+
+	names := Names{
+		Teams:     map[portainer.TeamID]string{1: "payments", 2: "platform"},
+		Endpoints: map[portainer.EndpointID]string{1: "production"},
+	}
+
+	summary := Summarize(report, GroupByTeam, names)
+	assert.Equal(t, 21.0, summary.Total)
+	assert.Equal(t, []Item{
+		{ID: 1, Name: "payments", Cost: 10},
+		{ID: 2, Name: "platform", Cost: 10},
+		{ID: 0, Name: "Unassigned", Cost: 1},
+	}, summary.Items)
+
+	summary = Summarize(report, GroupByStack, names)
+	assert.Equal(t, "Containers outside of the stacks", summary.Items[1].Name)
+
+	var b strings.Builder
+	assert.NoError(t, WriteCSV(&b, Summarize(report, GroupByEndpoint, names)))
+	assert.Equal(t, "month,group_by,endpoint_id,id,name,cpu_hours,memory_gib_hours,cost\n2024-10,endpoint,,1,production,0.00,0.00,21.00\n", b.String())
+}
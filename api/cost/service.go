@@ -0,0 +1,172 @@
+package cost
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/scheduler"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// samplingInterval is the interval between the samples of the resources used by the stacks
+	samplingInterval = time.Hour
+
+	inspectTimeout = 2 * time.Minute
+)
+
+// Service samples every hour the resources used by the stacks of the environments with costs, and accumulates their
+// estimated cost in the report of the month. The Edge environments, only reachable through their tunnel, are not
+// sampled.
+type Service struct {
+	dataStore dataservices.DataStore
+	scheduler *scheduler.Scheduler
+	inspect   func(ctx context.Context, endpoint *portainer.Endpoint) (map[string][]docker.StackContainerUsage, error)
+
+	mu sync.Mutex
+}
+
+// NewService creates a new instance of the cost service
+func NewService(dataStore dataservices.DataStore, clientFactory *dockerclient.ClientFactory, scheduler *scheduler.Scheduler) *Service {
+	return &Service{
+		dataStore: dataStore,
+		scheduler: scheduler,
+		inspect: func(ctx context.Context, endpoint *portainer.Endpoint) (map[string][]docker.StackContainerUsage, error) {
+			timeout := inspectTimeout
+
+			cli, err := clientFactory.CreateClient(endpoint, "", &timeout)
+			if err != nil {
+				return nil, err
+			}
+			defer cli.Close()
+
+			return docker.InspectStacksUsage(ctx, cli)
+		},
+	}
+}
+
+// Start schedules the hourly samples
+func (service *Service) Start() {
+	service.scheduler.StartJobEvery(samplingInterval, func() error {
+		return service.Sample(context.Background(), time.Now())
+	})
+}
+
+// Sample estimates the cost of the stacks during the last hour and adds it to the report of the month
+func (service *Service) Sample(ctx context.Context, now time.Time) error {
+	endpoints, err := service.dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return err
+	}
+
+	groups, err := service.dataStore.EndpointGroup().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	stacks, err := service.dataStore.Stack().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	resourceControls, err := service.dataStore.ResourceControl().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	stackTeams := make(map[string][]portainer.TeamID)
+	for _, resourceControl := range resourceControls {
+		if resourceControl.Type != portainer.StackResourceControl {
+			continue
+		}
+
+		teamIDs := []portainer.TeamID{}
+		for _, access := range resourceControl.TeamAccesses {
+			teamIDs = append(teamIDs, access.TeamID)
+		}
+
+		stackTeams[resourceControl.ResourceID] = teamIDs
+	}
+
+	var entries []portainer.CostEntry
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		if !endpointutils.IsDockerEndpoint(endpoint) || endpointutils.IsEdgeEndpoint(endpoint) || endpoint.Status == portainer.EndpointStatusDown {
+			continue
+		}
+
+		var group *portainer.EndpointGroup
+		for j := range groups {
+			if groups[j].ID == endpoint.GroupID {
+				group = &groups[j]
+			}
+		}
+
+		settings := endpointutils.ResolveCost(endpoint, group)
+		if settings == nil {
+			continue
+		}
+
+		usages, err := service.inspect(ctx, endpoint)
+		if err != nil {
+			log.Warn().Err(err).Int("endpoint_id", int(endpoint.ID)).Msg("unable to sample the resources used by the stacks of the environment")
+			continue
+		}
+
+		var samples []Sample
+		for stackName, containers := range usages {
+			sample := Sample{EndpointID: endpoint.ID, StackName: stackName, TeamIDs: []portainer.TeamID{}, Containers: len(containers)}
+
+			for _, stack := range stacks {
+				if stackName != "" && stack.EndpointID == endpoint.ID && stack.Name == stackName {
+					sample.StackID = stack.ID
+					if teamIDs, ok := stackTeams[stackutils.ResourceControlID(endpoint.ID, stack.Name)]; ok {
+						sample.TeamIDs = teamIDs
+					}
+					break
+				}
+			}
+
+			for _, container := range containers {
+				sample.CPUs += container.CPUPercent / 100
+				sample.MemoryGiB += float64(container.MemoryUsage) / gib
+			}
+
+			samples = append(samples, sample)
+		}
+
+		sort.Slice(samples, func(i, j int) bool {
+			return samples[i].StackName < samples[j].StackName
+		})
+
+		entries = append(entries, Allocate(*settings, samples, samplingInterval.Hours())...)
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	report, err := service.dataStore.CostReport().Read(ReportID(now))
+	if service.dataStore.IsErrObjectNotFound(err) {
+		report = NewReport(now)
+	} else if err != nil {
+		return err
+	}
+
+	Merge(report, entries)
+
+	return service.dataStore.CostReport().Update(report.ID, report)
+}
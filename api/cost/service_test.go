@@ -0,0 +1,70 @@
+package cost
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/docker"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSample(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	group := &portainer.EndpointGroup{ID: 2, Name: "priced", Defaults: &portainer.EndpointGroupDefaults{Cost: &portainer.CostSettings{HourlyCost: 2}}}
+	assert.NoError(t, store.EndpointGroup().Create(group))
+
+	endpoints := []*portainer.Endpoint{
+		{ID: 1, Name: "production", Type: portainer.DockerEnvironment, GroupID: 2},
+		{ID: 2, Name: "free", Type: portainer.DockerEnvironment, GroupID: 1},
+	}
+	for _, endpoint := range endpoints {
+		assert.NoError(t, store.Endpoint().Create(endpoint))
+	}
+
+	assert.NoError(t, store.Stack().Create(&portainer.Stack{ID: 1, Name: "web", EndpointID: 1}))
+	assert.NoError(t, store.ResourceControl().Create(&portainer.ResourceControl{
+		ResourceID:   "1_web",
+		Type:         portainer.StackResourceControl,
+		TeamAccesses: []portainer.TeamResourceAccess{{TeamID: 3}},
+	}))
+
+	service := NewService(store, nil, nil)
+
+	var inspected []portainer.EndpointID
+	service.inspect = func(ctx context.Context, endpoint *portainer.Endpoint) (map[string][]docker.StackContainerUsage, error) {
+		inspected = append(inspected, endpoint.ID)
+
+		return map[string][]docker.StackContainerUsage{
+			"web": {{CPUPercent: 150, MemoryUsage: 1 << 30}},
+			"":    {{CPUPercent: 50, MemoryUsage: 1 << 30}},
+		}, nil
+	}
+
+	now := time.Date(2024, time.October, 15, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, service.Sample(context.Background(), now))
+	assert.NoError(t, service.Sample(context.Background(), now.Add(time.Hour)))
+
+	assert.Equal(t, []portainer.EndpointID{1, 1}, inspected, "only the environments with costs should be sampled")
+
+	report, err := store.CostReport().Read(202410)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-10", report.Month)
+
+	if !assert.Len(t, report.Entries, 2) {
+		return
+	}
+
+	assert.Equal(t, "", report.Entries[0].StackName)
+	assert.InDelta(t, 1.5, report.Entries[0].Cost, 1e-9)
+
+	assert.Equal(t, portainer.StackID(1), report.Entries[1].StackID)
+	assert.Equal(t, []portainer.TeamID{3}, report.Entries[1].TeamIDs)
+	assert.Equal(t, 2.0, report.Entries[1].Hours)
+	assert.Equal(t, 3.0, report.Entries[1].CPUHours)
+	assert.InDelta(t, 2.5, report.Entries[1].Cost, 1e-9)
+}
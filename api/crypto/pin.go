@@ -0,0 +1,95 @@
+package crypto
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"net"
+	"slices"
+	"time"
+)
+
+// publicKeyPinPrefix prefixes the pins of the public keys, in the format used by curl --pinnedpubkey
+const publicKeyPinPrefix = "sha256//"
+
+// fetchPublicKeyTimeout bounds the connection retrieving the public key of a server
+const fetchPublicKeyTimeout = 10 * time.Second
+
+// ErrPublicKeyMismatch is returned when a server presents a public key that is not pinned
+var ErrPublicKeyMismatch = errors.New("the server presented a public key that does not match the pinned keys, it must be trusted again by an administrator if it was legitimately changed")
+
+// PublicKeyPin returns the pin of the public key of the certificate, the base64 encoded SHA-256 digest of its
+// SubjectPublicKeyInfo prefixed by sha256//
+func PublicKeyPin(cert *x509.Certificate) string {
+	digest := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+
+	return publicKeyPinPrefix + base64.StdEncoding.EncodeToString(digest[:])
+}
+
+// VerifyPublicKeyPins returns a tls.Config.VerifyConnection function refusing the servers whose certificate does not
+// hold one of the pinned public keys. The pins are retrieved on each handshake, nothing being pinned when they are
+// empty. It applies whether the certificate chain of the server is verified or not.
+func VerifyPublicKeyPins(pins func() []string) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		pinned := pins()
+		if len(pinned) == 0 {
+			return nil
+		}
+
+		if len(state.PeerCertificates) == 0 || !slices.Contains(pinned, PublicKeyPin(state.PeerCertificates[0])) {
+			return ErrPublicKeyMismatch
+		}
+
+		return nil
+	}
+}
+
+// PinPublicKeys makes the connections of the configuration refuse the servers whose certificate does not hold one
+// of the pinned public keys, the configuration is left unchanged when there is no pin
+func PinPublicKeys(config *tls.Config, pins []string) {
+	if config == nil || len(pins) == 0 {
+		return
+	}
+
+	config.VerifyConnection = VerifyPublicKeyPins(func() []string { return pins })
+}
+
+// FetchPublicKeyPin connects to the TLS server listening on the address and returns the pin of the public key it
+// presents. The certificate chain is verified as specified by the configuration but no pin is enforced.
+func FetchPublicKeyPin(address string, config *tls.Config) (string, error) {
+	if config == nil {
+		config = CreateTLSConfiguration()
+		config.InsecureSkipVerify = true
+	} else {
+		config = config.Clone()
+	}
+	config.VerifyConnection = nil
+
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return "", err
+		}
+		config.ServerName = host
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchPublicKeyTimeout)
+	defer cancel()
+
+	dialer := &tls.Dialer{Config: config}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	certificates := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certificates) == 0 {
+		return "", errors.New("the server did not present any certificate")
+	}
+
+	return PublicKeyPin(certificates[0]), nil
+}
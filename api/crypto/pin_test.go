@@ -0,0 +1,55 @@
+package crypto
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchPublicKeyPin(t *testing.T) {
+	is := assert.New(t)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	pin, err := FetchPublicKeyPin(strings.TrimPrefix(server.URL, "https://"), nil)
+	is.NoError(err)
+	is.Equal(PublicKeyPin(server.Certificate()), pin)
+	is.True(strings.HasPrefix(pin, publicKeyPinPrefix))
+}
+
+func TestPinPublicKeys(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	request := func(pins []string) error {
+		config := CreateTLSConfiguration()
+		config.InsecureSkipVerify = true
+		PinPublicKeys(config, pins)
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		return err
+	}
+
+	t.Run("no pin", func(t *testing.T) {
+		assert.NoError(t, request(nil))
+	})
+
+	t.Run("pinned key", func(t *testing.T) {
+		assert.NoError(t, request([]string{"sha256//other", PublicKeyPin(server.Certificate())}))
+	})
+
+	t.Run("different key", func(t *testing.T) {
+		err := request([]string{"sha256//other"})
+		assert.ErrorIs(t, err, ErrPublicKeyMismatch)
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/database/changes"
 	dserrors "github.com/portainer/portainer/api/dataservices/errors"
 
 	"github.com/rs/zerolog/log"
@@ -27,13 +28,23 @@ var (
 	ErrHaveEncryptedWithNoKey      = errors.New("The portainer database is encrypted, but no secret was loaded")
 )
 
+// FieldCipher encrypts the secret fields of the objects before they are stored, and decrypts them once they are read
+type FieldCipher interface {
+	// EncryptFields returns the object to store in place of the object, which must not be modified
+	EncryptFields(object any) (any, error)
+	// DecryptFields decrypts the secret fields of an object in place
+	DecryptFields(object any) error
+}
+
 type DbConnection struct {
 	Path            string
 	MaxBatchSize    int
 	MaxBatchDelay   time.Duration
 	InitialMmapSize int
 	EncryptionKey   []byte
-	isEncrypted     bool
+	// FieldCipher encrypts the secret fields of the objects, when set
+	FieldCipher FieldCipher
+	isEncrypted bool
 
 	*bolt.DB
 }
@@ -248,6 +259,10 @@ func (connection *DbConnection) UpdateObject(bucketName string, key []byte, obje
 // UpdateObjectFunc is a generic function used to update an object safely without race conditions.
 func (connection *DbConnection) UpdateObjectFunc(bucketName string, key []byte, object any, updateFn func()) error {
 	return connection.Batch(func(tx *bolt.Tx) error {
+		tx.OnCommit(func() {
+			changes.Notify(bucketName, keyToString(key), changes.ActionUpdate)
+		})
+
 		bucket := tx.Bucket([]byte(bucketName))
 
 		data := bucket.Get(key)
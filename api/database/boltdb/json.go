@@ -20,6 +20,13 @@ func (connection *DbConnection) MarshalObject(object interface{}) (data []byte,
 	if v, ok := object.(string); ok {
 		data = []byte(v)
 	} else {
+		if connection.FieldCipher != nil {
+			object, err = connection.FieldCipher.EncryptFields(object)
+			if err != nil {
+				return data, err
+			}
+		}
+
 		data, err = json.Marshal(object)
 		if err != nil {
 			return data, err
@@ -50,7 +57,14 @@ func (connection *DbConnection) UnmarshalObject(data []byte, object interface{})
 		}
 
 		*s = string(data)
+
+		return err
 	}
+
+	if connection.FieldCipher != nil {
+		return connection.FieldCipher.DecryptFields(object)
+	}
+
 	return err
 }
 
@@ -76,6 +90,10 @@ func (connection *DbConnection) UnmarshalObjectWithJsoniter(data []byte, object
 		return err
 	}
 
+	if connection.FieldCipher != nil {
+		return connection.FieldCipher.DecryptFields(object)
+	}
+
 	return nil
 }
 
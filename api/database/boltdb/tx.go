@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 
+	"github.com/portainer/portainer/api/database/changes"
 	dserrors "github.com/portainer/portainer/api/dataservices/errors"
 
 	"github.com/rs/zerolog/log"
@@ -15,6 +16,15 @@ type DbTransaction struct {
 	tx   *bolt.Tx
 }
 
+// notifyChange records the modification of the object once the transaction is committed
+func (tx *DbTransaction) notifyChange(bucketName string, key []byte, action changes.Action) {
+	k := keyToString(key)
+
+	tx.tx.OnCommit(func() {
+		changes.Notify(bucketName, k, action)
+	})
+}
+
 func (tx *DbTransaction) SetServiceName(bucketName string) error {
 	_, err := tx.tx.CreateBucketIfNotExists([]byte(bucketName))
 	return err
@@ -37,11 +47,15 @@ func (tx *DbTransaction) UpdateObject(bucketName string, key []byte, object inte
 		return err
 	}
 
+	tx.notifyChange(bucketName, key, changes.ActionUpdate)
+
 	bucket := tx.tx.Bucket([]byte(bucketName))
 	return bucket.Put(key, data)
 }
 
 func (tx *DbTransaction) DeleteObject(bucketName string, key []byte) error {
+	tx.notifyChange(bucketName, key, changes.ActionDelete)
+
 	bucket := tx.tx.Bucket([]byte(bucketName))
 	return bucket.Delete(key)
 }
@@ -64,7 +78,11 @@ func (tx *DbTransaction) DeleteAllObjects(bucketName string, obj interface{}, ma
 	}
 
 	for _, id := range ids {
-		if err := bucket.Delete(tx.conn.ConvertToKey(id)); err != nil {
+		key := tx.conn.ConvertToKey(id)
+
+		tx.notifyChange(bucketName, key, changes.ActionDelete)
+
+		if err := bucket.Delete(key); err != nil {
 			return err
 		}
 	}
@@ -89,25 +107,35 @@ func (tx *DbTransaction) CreateObject(bucketName string, fn func(uint64) (int, i
 	seqId, _ := bucket.NextSequence()
 	id, obj := fn(seqId)
 
+	key := tx.conn.ConvertToKey(id)
+
+	tx.notifyChange(bucketName, key, changes.ActionCreate)
+
 	data, err := tx.conn.MarshalObject(obj)
 	if err != nil {
 		return err
 	}
 
-	return bucket.Put(tx.conn.ConvertToKey(id), data)
+	return bucket.Put(key, data)
 }
 
 func (tx *DbTransaction) CreateObjectWithId(bucketName string, id int, obj interface{}) error {
+	key := tx.conn.ConvertToKey(id)
+
+	tx.notifyChange(bucketName, key, changes.ActionCreate)
+
 	bucket := tx.tx.Bucket([]byte(bucketName))
 	data, err := tx.conn.MarshalObject(obj)
 	if err != nil {
 		return err
 	}
 
-	return bucket.Put(tx.conn.ConvertToKey(id), data)
+	return bucket.Put(key, data)
 }
 
 func (tx *DbTransaction) CreateObjectWithStringId(bucketName string, id []byte, obj interface{}) error {
+	tx.notifyChange(bucketName, id, changes.ActionCreate)
+
 	bucket := tx.tx.Bucket([]byte(bucketName))
 	data, err := tx.conn.MarshalObject(obj)
 	if err != nil {
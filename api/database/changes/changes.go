@@ -0,0 +1,93 @@
+// Package changes counts the modifications committed to each database bucket, so that the data derived from
+// a bucket, such as cached responses, can be invalidated as soon as the bucket is modified. The modifications
+// are also published to the subscribers, e.g. to notify the clients of the API.
+package changes
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Action is the kind of modification applied to an object
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// subscriptionBufferSize is the number of changes buffered for a subscriber, the changes published while the
+// buffer is full are dropped for that subscriber
+const subscriptionBufferSize = 256
+
+// Change describes a modification committed to a bucket
+type Change struct {
+	Bucket string
+	// Key of the modified object, usually its identifier
+	Key    string
+	Action Action
+	Time   time.Time
+}
+
+var (
+	versions sync.Map // bucket name -> *atomic.Uint64
+
+	subscribersMu sync.RWMutex
+	subscribers   = make(map[chan Change]struct{})
+)
+
+func counter(bucketName string) *atomic.Uint64 {
+	if v, ok := versions.Load(bucketName); ok {
+		return v.(*atomic.Uint64)
+	}
+
+	v, _ := versions.LoadOrStore(bucketName, &atomic.Uint64{})
+
+	return v.(*atomic.Uint64)
+}
+
+// Notify records a modification of an object of the bucket and publishes it to the subscribers.
+// It must be called once the modification is committed.
+func Notify(bucketName, key string, action Action) {
+	counter(bucketName).Add(1)
+
+	change := Change{Bucket: bucketName, Key: key, Action: action, Time: time.Now()}
+
+	subscribersMu.RLock()
+	defer subscribersMu.RUnlock()
+
+	for subscriber := range subscribers {
+		select {
+		case subscriber <- change:
+		default:
+		}
+	}
+}
+
+// Version returns the number of modifications of the bucket since the start of the process
+func Version(bucketName string) uint64 {
+	return counter(bucketName).Load()
+}
+
+// Subscribe returns a channel receiving the changes committed from now on, and a function to call to
+// unsubscribe once done
+func Subscribe() (<-chan Change, func()) {
+	subscriber := make(chan Change, subscriptionBufferSize)
+
+	subscribersMu.Lock()
+	subscribers[subscriber] = struct{}{}
+	subscribersMu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			subscribersMu.Lock()
+			delete(subscribers, subscriber)
+			subscribersMu.Unlock()
+		})
+	}
+
+	return subscriber, unsubscribe
+}
@@ -0,0 +1,59 @@
+package automationwebhook
+
+import (
+	"crypto/subtle"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "automation_webhooks"
+
+// Service represents a service for managing automation webhook data.
+type Service struct {
+	dataservices.BaseDataService[portainer.AutomationWebhook, portainer.AutomationWebhookID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.AutomationWebhook, portainer.AutomationWebhookID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create assigns an ID to a new automation webhook and saves it.
+func (service *Service) Create(webhook *portainer.AutomationWebhook) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			webhook.ID = portainer.AutomationWebhookID(id)
+			return int(webhook.ID), webhook
+		},
+	)
+}
+
+// WebhookByToken returns the automation webhook identified by a token.
+func (service *Service) WebhookByToken(token string) (*portainer.AutomationWebhook, error) {
+	webhooks, err := service.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range webhooks {
+		if subtle.ConstantTimeCompare([]byte(webhooks[i].Token), []byte(token)) == 1 {
+			return &webhooks[i], nil
+		}
+	}
+
+	return nil, dserrors.ErrObjectNotFound
+}
@@ -0,0 +1,40 @@
+package backgroundjob
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "background_jobs"
+
+// Service represents a service for managing background job data.
+type Service struct {
+	dataservices.BaseDataService[portainer.BackgroundJob, portainer.BackgroundJobID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.BackgroundJob, portainer.BackgroundJobID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create creates a new background job.
+func (service *Service) Create(job *portainer.BackgroundJob) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			job.ID = portainer.BackgroundJobID(id)
+			return int(job.ID), job
+		},
+	)
+}
@@ -0,0 +1,40 @@
+package changerequest
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "change_requests"
+
+// Service represents a service for managing change request data.
+type Service struct {
+	dataservices.BaseDataService[portainer.ChangeRequest, portainer.ChangeRequestID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.ChangeRequest, portainer.ChangeRequestID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create assigns an ID to a new change request and saves it.
+func (service *Service) Create(changeRequest *portainer.ChangeRequest) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			changeRequest.ID = portainer.ChangeRequestID(id)
+			return int(changeRequest.ID), changeRequest
+		},
+	)
+}
@@ -0,0 +1,40 @@
+package cmdbconnector
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "cmdb_connectors"
+
+// Service represents a service for managing CMDB connector data.
+type Service struct {
+	dataservices.BaseDataService[portainer.CMDBConnector, portainer.CMDBConnectorID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.CMDBConnector, portainer.CMDBConnectorID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create assigns an ID to a new CMDB connector and saves it.
+func (service *Service) Create(connector *portainer.CMDBConnector) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			connector.ID = portainer.CMDBConnectorID(id)
+			return int(connector.ID), connector
+		},
+	)
+}
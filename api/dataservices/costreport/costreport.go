@@ -0,0 +1,34 @@
+package costreport
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "cost_reports"
+
+// Service represents a service for managing cost report data.
+type Service struct {
+	dataservices.BaseDataService[portainer.CostReport, portainer.CostReportID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.CostReport, portainer.CostReportID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create saves a cost report, identified by its month.
+func (service *Service) Create(report *portainer.CostReport) error {
+	return service.Connection.CreateObjectWithId(BucketName, int(report.ID), report)
+}
@@ -0,0 +1,96 @@
+package dockeroperationaudit
+
+import (
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "docker_operation_audits"
+
+	// maxAuditsPerEndpoint is the number of operations kept in the audit of each environment
+	maxAuditsPerEndpoint = 1000
+)
+
+// Service represents a service for managing the audit of the Docker operations.
+type Service struct {
+	connection portainer.Connection
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		connection: connection,
+	}, nil
+}
+
+// Create saves an operation in the audit of its environment and prunes the oldest operations.
+func (service *Service) Create(audit *portainer.DockerOperationAudit) error {
+	return service.connection.UpdateTx(func(tx portainer.Transaction) error {
+		err := tx.CreateObject(
+			BucketName,
+			func(id uint64) (int, interface{}) {
+				audit.ID = portainer.DockerOperationAuditID(id)
+				return int(audit.ID), audit
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		var ids []portainer.DockerOperationAuditID
+		err = tx.GetAll(
+			BucketName,
+			&portainer.DockerOperationAudit{},
+			func(obj interface{}) (interface{}, error) {
+				if a, ok := obj.(*portainer.DockerOperationAudit); ok && a.EndpointID == audit.EndpointID {
+					ids = append(ids, a.ID)
+				}
+
+				return &portainer.DockerOperationAudit{}, nil
+			},
+		)
+		if err != nil || len(ids) <= maxAuditsPerEndpoint {
+			return err
+		}
+
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+
+		for _, id := range ids[:len(ids)-maxAuditsPerEndpoint] {
+			if err := tx.DeleteObject(BucketName, service.connection.ConvertToKey(int(id))); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// AuditsByEndpoint returns the operations performed on an environment, the most recent operations first.
+func (service *Service) AuditsByEndpoint(endpointID portainer.EndpointID) ([]portainer.DockerOperationAudit, error) {
+	var audits = make([]portainer.DockerOperationAudit, 0)
+
+	err := service.connection.GetAll(
+		BucketName,
+		&portainer.DockerOperationAudit{},
+		dataservices.FilterFn(&audits, func(e portainer.DockerOperationAudit) bool {
+			return e.EndpointID == endpointID
+		}),
+	)
+
+	sort.Slice(audits, func(i, j int) bool {
+		return audits[i].ID > audits[j].ID
+	})
+
+	return audits, err
+}
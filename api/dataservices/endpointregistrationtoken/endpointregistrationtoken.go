@@ -0,0 +1,80 @@
+package endpointregistrationtoken
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+
+	"github.com/rs/zerolog/log"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "endpoint_registration_tokens"
+
+// Service represents a service for managing the registration tokens of the agents.
+type Service struct {
+	dataservices.BaseDataService[portainer.EndpointRegistrationToken, portainer.EndpointRegistrationTokenID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.EndpointRegistrationToken, portainer.EndpointRegistrationTokenID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create assigns an ID to a new registration token and saves it.
+func (service *Service) Create(token *portainer.EndpointRegistrationToken) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			token.ID = portainer.EndpointRegistrationTokenID(id)
+			return int(token.ID), token
+		},
+	)
+}
+
+// TokenByDigest returns the registration token matching the digest.
+func (service *Service) TokenByDigest(digest []byte) (*portainer.EndpointRegistrationToken, error) {
+	var t *portainer.EndpointRegistrationToken
+	stop := fmt.Errorf("ok")
+	err := service.Connection.GetAll(
+		BucketName,
+		&portainer.EndpointRegistrationToken{},
+		func(obj interface{}) (interface{}, error) {
+			token, ok := obj.(*portainer.EndpointRegistrationToken)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to EndpointRegistrationToken object")
+				return nil, fmt.Errorf("failed to convert to EndpointRegistrationToken object: %s", obj)
+			}
+
+			if subtle.ConstantTimeCompare(token.Digest, digest) == 1 {
+				t = token
+				return nil, stop
+			}
+
+			return &portainer.EndpointRegistrationToken{}, nil
+		})
+
+	if errors.Is(err, stop) {
+		return t, nil
+	}
+
+	if err == nil {
+		return nil, dserrors.ErrObjectNotFound
+	}
+
+	return nil, err
+}
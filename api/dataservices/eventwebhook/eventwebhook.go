@@ -0,0 +1,178 @@
+package eventwebhook
+
+import (
+	"fmt"
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "event_webhooks"
+	// DeliveriesBucketName represents the name of the bucket where the delivery log is stored.
+	DeliveriesBucketName = "event_webhook_deliveries"
+
+	// maxDeliveries is the number of deliveries kept in the log of each event webhook
+	maxDeliveries = 100
+)
+
+// Service represents a service for managing event webhook data.
+type Service struct {
+	dataservices.BaseDataService[portainer.EventWebhook, portainer.EventWebhookID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = connection.SetServiceName(DeliveriesBucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.EventWebhook, portainer.EventWebhookID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create assigns an ID to a new event webhook and saves it.
+func (service *Service) Create(webhook *portainer.EventWebhook) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			webhook.ID = portainer.EventWebhookID(id)
+			return int(webhook.ID), webhook
+		},
+	)
+}
+
+// Delete deletes an event webhook and its delivery log.
+func (service *Service) Delete(ID portainer.EventWebhookID) error {
+	err := service.BaseDataService.Delete(ID)
+	if err != nil {
+		return err
+	}
+
+	return service.Connection.DeleteAllObjects(
+		DeliveriesBucketName,
+		&portainer.EventWebhookDelivery{},
+		func(obj interface{}) (id int, ok bool) {
+			delivery, ok := obj.(*portainer.EventWebhookDelivery)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to EventWebhookDelivery object")
+				return -1, false
+			}
+
+			if delivery.WebhookID == ID {
+				return int(delivery.ID), true
+			}
+
+			return -1, false
+		})
+}
+
+// Deliveries returns the delivery log of an event webhook, the most recent deliveries first.
+func (service *Service) Deliveries(ID portainer.EventWebhookID) ([]portainer.EventWebhookDelivery, error) {
+	var deliveries = make([]portainer.EventWebhookDelivery, 0)
+
+	err := service.Connection.GetAll(
+		DeliveriesBucketName,
+		&portainer.EventWebhookDelivery{},
+		dataservices.FilterFn(&deliveries, func(e portainer.EventWebhookDelivery) bool {
+			return e.WebhookID == ID
+		}),
+	)
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ID > deliveries[j].ID
+	})
+
+	return deliveries, err
+}
+
+// PendingDeliveries returns the deliveries of all the event webhooks waiting to be completed, the oldest first.
+func (service *Service) PendingDeliveries() ([]portainer.EventWebhookDelivery, error) {
+	var deliveries = make([]portainer.EventWebhookDelivery, 0)
+
+	err := service.Connection.GetAll(
+		DeliveriesBucketName,
+		&portainer.EventWebhookDelivery{},
+		dataservices.FilterFn(&deliveries, func(e portainer.EventWebhookDelivery) bool {
+			return e.Pending
+		}),
+	)
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ID < deliveries[j].ID
+	})
+
+	return deliveries, err
+}
+
+// UpdateDelivery saves the outcome of an attempt of a delivery, the deliveries removed with their event webhook are
+// not recreated.
+func (service *Service) UpdateDelivery(delivery *portainer.EventWebhookDelivery) error {
+	key := service.Connection.ConvertToKey(int(delivery.ID))
+
+	return service.Connection.UpdateTx(func(tx portainer.Transaction) error {
+		if err := tx.GetObject(DeliveriesBucketName, key, &portainer.EventWebhookDelivery{}); err != nil {
+			return err
+		}
+
+		return tx.UpdateObject(DeliveriesBucketName, key, delivery)
+	})
+}
+
+// CreateDelivery saves a delivery in the log of its event webhook and prunes the oldest completed deliveries.
+func (service *Service) CreateDelivery(delivery *portainer.EventWebhookDelivery) error {
+	return service.Connection.UpdateTx(func(tx portainer.Transaction) error {
+		err := tx.CreateObject(
+			DeliveriesBucketName,
+			func(id uint64) (int, interface{}) {
+				delivery.ID = portainer.EventWebhookDeliveryID(id)
+				return int(delivery.ID), delivery
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		var ids []portainer.EventWebhookDeliveryID
+		err = tx.GetAll(
+			DeliveriesBucketName,
+			&portainer.EventWebhookDelivery{},
+			func(obj interface{}) (interface{}, error) {
+				if d, ok := obj.(*portainer.EventWebhookDelivery); ok && d.WebhookID == delivery.WebhookID && !d.Pending {
+					ids = append(ids, d.ID)
+				}
+
+				return &portainer.EventWebhookDelivery{}, nil
+			},
+		)
+		if err != nil || len(ids) <= maxDeliveries {
+			return err
+		}
+
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+
+		for _, id := range ids[:len(ids)-maxDeliveries] {
+			if err := tx.DeleteObject(DeliveriesBucketName, service.Connection.ConvertToKey(int(id))); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
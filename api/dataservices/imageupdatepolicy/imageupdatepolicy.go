@@ -0,0 +1,170 @@
+package imageupdatepolicy
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dserrors "github.com/portainer/portainer/api/dataservices/errors"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "image_update_policies"
+	// HistoryBucketName represents the name of the bucket where the update history is stored.
+	HistoryBucketName = "image_update_history"
+
+	// maxRecords is the number of updates kept in the history of each image update policy
+	maxRecords = 50
+)
+
+// Service represents a service for managing image update policy data.
+type Service struct {
+	dataservices.BaseDataService[portainer.ImageUpdatePolicy, portainer.ImageUpdatePolicyID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = connection.SetServiceName(HistoryBucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.ImageUpdatePolicy, portainer.ImageUpdatePolicyID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create assigns an ID to a new image update policy and saves it.
+func (service *Service) Create(policy *portainer.ImageUpdatePolicy) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			policy.ID = portainer.ImageUpdatePolicyID(id)
+			return int(policy.ID), policy
+		},
+	)
+}
+
+// Delete deletes an image update policy and its history.
+func (service *Service) Delete(ID portainer.ImageUpdatePolicyID) error {
+	err := service.BaseDataService.Delete(ID)
+	if err != nil {
+		return err
+	}
+
+	return service.Connection.DeleteAllObjects(
+		HistoryBucketName,
+		&portainer.ImageUpdateRecord{},
+		func(obj interface{}) (id int, ok bool) {
+			record, ok := obj.(*portainer.ImageUpdateRecord)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to ImageUpdateRecord object")
+				return -1, false
+			}
+
+			if record.PolicyID == ID {
+				return int(record.ID), true
+			}
+
+			return -1, false
+		})
+}
+
+// PolicyByResource returns the image update policy of a resource.
+func (service *Service) PolicyByResource(endpointID portainer.EndpointID, resourceType portainer.ImageUpdateResourceType, resourceID string) (*portainer.ImageUpdatePolicy, error) {
+	var policy portainer.ImageUpdatePolicy
+
+	err := service.Connection.GetAll(
+		BucketName,
+		&portainer.ImageUpdatePolicy{},
+		dataservices.FirstFn(&policy, func(e portainer.ImageUpdatePolicy) bool {
+			return e.EndpointID == endpointID && e.ResourceType == resourceType && e.ResourceID == resourceID
+		}),
+	)
+
+	if errors.Is(err, dataservices.ErrStop) {
+		return &policy, nil
+	}
+
+	if err == nil {
+		return nil, dserrors.ErrObjectNotFound
+	}
+
+	return nil, err
+}
+
+// History returns the updates of an image update policy, the most recent updates first.
+func (service *Service) History(ID portainer.ImageUpdatePolicyID) ([]portainer.ImageUpdateRecord, error) {
+	var records = make([]portainer.ImageUpdateRecord, 0)
+
+	err := service.Connection.GetAll(
+		HistoryBucketName,
+		&portainer.ImageUpdateRecord{},
+		dataservices.FilterFn(&records, func(e portainer.ImageUpdateRecord) bool {
+			return e.PolicyID == ID
+		}),
+	)
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ID > records[j].ID
+	})
+
+	return records, err
+}
+
+// CreateRecord saves an update in the history of its image update policy and prunes the oldest updates.
+func (service *Service) CreateRecord(record *portainer.ImageUpdateRecord) error {
+	return service.Connection.UpdateTx(func(tx portainer.Transaction) error {
+		err := tx.CreateObject(
+			HistoryBucketName,
+			func(id uint64) (int, interface{}) {
+				record.ID = portainer.ImageUpdateRecordID(id)
+				return int(record.ID), record
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		var ids []portainer.ImageUpdateRecordID
+		err = tx.GetAll(
+			HistoryBucketName,
+			&portainer.ImageUpdateRecord{},
+			func(obj interface{}) (interface{}, error) {
+				if r, ok := obj.(*portainer.ImageUpdateRecord); ok && r.PolicyID == record.PolicyID {
+					ids = append(ids, r.ID)
+				}
+
+				return &portainer.ImageUpdateRecord{}, nil
+			},
+		)
+		if err != nil || len(ids) <= maxRecords {
+			return err
+		}
+
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+
+		for _, id := range ids[:len(ids)-maxRecords] {
+			if err := tx.DeleteObject(HistoryBucketName, service.Connection.ConvertToKey(int(id))); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
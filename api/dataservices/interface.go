@@ -11,29 +11,45 @@ import (
 type (
 	DataStoreTx interface {
 		IsErrObjectNotFound(err error) bool
+		AutomationWebhook() AutomationWebhookService
+		BackgroundJob() BackgroundJobService
+		ChangeRequest() ChangeRequestService
+		CMDBConnector() CMDBConnectorService
+		CostReport() CostReportService
 		CustomTemplate() CustomTemplateService
+		DockerOperationAudit() DockerOperationAuditService
 		EdgeGroup() EdgeGroupService
 		EdgeJob() EdgeJobService
 		EdgeStack() EdgeStackService
 		Endpoint() EndpointService
 		EndpointGroup() EndpointGroupService
 		EndpointRelation() EndpointRelationService
+		EndpointRegistrationToken() EndpointRegistrationTokenService
+		EventWebhook() EventWebhookService
 		FDOProfile() FDOProfileService
 		HelmUserRepository() HelmUserRepositoryService
+		ImageUpdatePolicy() ImageUpdatePolicyService
+		MaintenanceWindow() MaintenanceWindowService
+		Note() NoteService
+		OwnershipRule() OwnershipRuleService
 		Registry() RegistryService
 		ResourceControl() ResourceControlService
 		Role() RoleService
 		APIKeyRepository() APIKeyRepository
+		SavedView() SavedViewService
+		SecurityAlert() SecurityAlertService
 		Settings() SettingsService
 		Snapshot() SnapshotService
 		SSLSettings() SSLSettingsService
 		Stack() StackService
+		StackBundle() StackBundleService
 		Tag() TagService
 		TeamMembership() TeamMembershipService
 		Team() TeamService
 		TunnelServer() TunnelServerService
 		User() UserService
 		Version() VersionService
+		VolumeBackupJob() VolumeBackupJobService
 		Webhook() WebhookService
 		PendingActions() PendingActionsService
 	}
@@ -120,6 +136,102 @@ type (
 		BucketName() string
 	}
 
+	// EndpointRegistrationTokenService represents a service to manage the registration tokens of the agents
+	EndpointRegistrationTokenService interface {
+		BaseCRUD[portainer.EndpointRegistrationToken, portainer.EndpointRegistrationTokenID]
+		TokenByDigest(digest []byte) (*portainer.EndpointRegistrationToken, error)
+	}
+
+	// DockerOperationAuditService represents a service to manage the audit of the Docker operations
+	DockerOperationAuditService interface {
+		Create(audit *portainer.DockerOperationAudit) error
+		AuditsByEndpoint(endpointID portainer.EndpointID) ([]portainer.DockerOperationAudit, error)
+	}
+
+	// AutomationWebhookService represents a service to manage the automation webhooks
+	AutomationWebhookService interface {
+		BaseCRUD[portainer.AutomationWebhook, portainer.AutomationWebhookID]
+		WebhookByToken(token string) (*portainer.AutomationWebhook, error)
+	}
+
+	// ChangeRequestService represents a service to manage the change requests of the environments
+	ChangeRequestService interface {
+		BaseCRUD[portainer.ChangeRequest, portainer.ChangeRequestID]
+	}
+
+	// SavedViewService represents a service to manage the saved views
+	SavedViewService interface {
+		BaseCRUD[portainer.SavedView, portainer.SavedViewID]
+		DeleteByOwnerID(userID portainer.UserID) error
+	}
+
+	// EventWebhookService represents a service to manage the event webhooks and their delivery log
+	EventWebhookService interface {
+		BaseCRUD[portainer.EventWebhook, portainer.EventWebhookID]
+		Deliveries(ID portainer.EventWebhookID) ([]portainer.EventWebhookDelivery, error)
+		CreateDelivery(delivery *portainer.EventWebhookDelivery) error
+		UpdateDelivery(delivery *portainer.EventWebhookDelivery) error
+		PendingDeliveries() ([]portainer.EventWebhookDelivery, error)
+	}
+
+	// ImageUpdatePolicyService represents a service to manage the image update policies and their history
+	ImageUpdatePolicyService interface {
+		BaseCRUD[portainer.ImageUpdatePolicy, portainer.ImageUpdatePolicyID]
+		PolicyByResource(endpointID portainer.EndpointID, resourceType portainer.ImageUpdateResourceType, resourceID string) (*portainer.ImageUpdatePolicy, error)
+		History(ID portainer.ImageUpdatePolicyID) ([]portainer.ImageUpdateRecord, error)
+		CreateRecord(record *portainer.ImageUpdateRecord) error
+	}
+
+	// MaintenanceWindowService represents a service to manage the maintenance windows
+	MaintenanceWindowService interface {
+		BaseCRUD[portainer.MaintenanceWindow, portainer.MaintenanceWindowID]
+	}
+
+	// CMDBConnectorService represents a service to manage the connectors synchronizing the inventory to a CMDB
+	CMDBConnectorService interface {
+		BaseCRUD[portainer.CMDBConnector, portainer.CMDBConnectorID]
+	}
+
+	// SecurityAlertService represents a service to manage the security alerts
+	SecurityAlertService interface {
+		BaseCRUD[portainer.SecurityAlert, portainer.SecurityAlertID]
+	}
+
+	// StackBundleService represents a service to manage the stack bundles
+	StackBundleService interface {
+		BaseCRUD[portainer.StackBundle, portainer.StackBundleID]
+	}
+
+	// BackgroundJobService represents a service to manage the jobs of the background job queue
+	BackgroundJobService interface {
+		BaseCRUD[portainer.BackgroundJob, portainer.BackgroundJobID]
+	}
+
+	// CostReportService represents a service to manage the monthly cost reports of the stacks
+	CostReportService interface {
+		BaseCRUD[portainer.CostReport, portainer.CostReportID]
+	}
+
+	// OwnershipRuleService represents a service to manage the ownership rules of the external resources
+	OwnershipRuleService interface {
+		BaseCRUD[portainer.OwnershipRule, portainer.OwnershipRuleID]
+	}
+
+	// NoteService represents a service to manage the notes of the users about the resources
+	NoteService interface {
+		BaseCRUD[portainer.Note, portainer.NoteID]
+		NotesByEndpoint(endpointID portainer.EndpointID) ([]portainer.Note, error)
+	}
+
+	// VolumeBackupJobService represents a service to manage the volume backup jobs and their history
+	VolumeBackupJobService interface {
+		BaseCRUD[portainer.VolumeBackupJob, portainer.VolumeBackupJobID]
+		History(ID portainer.VolumeBackupJobID) ([]portainer.VolumeBackupRecord, error)
+		Record(ID portainer.VolumeBackupRecordID) (*portainer.VolumeBackupRecord, error)
+		CreateRecord(record *portainer.VolumeBackupRecord) error
+		DeleteRecord(ID portainer.VolumeBackupRecordID) error
+	}
+
 	// FDOProfileService represents a service to manage FDO Profiles
 	FDOProfileService interface {
 		BaseCRUD[portainer.FDOProfile, portainer.FDOProfileID]
@@ -168,6 +280,9 @@ type (
 	SettingsService interface {
 		Settings() (*portainer.Settings, error)
 		UpdateSettings(settings *portainer.Settings) error
+		CreateVersion(settings *portainer.Settings) error
+		History() ([]portainer.SettingsVersion, error)
+		Version(ID portainer.SettingsVersionID) (*portainer.SettingsVersion, error)
 		BucketName() string
 	}
 
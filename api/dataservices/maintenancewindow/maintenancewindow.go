@@ -0,0 +1,40 @@
+package maintenancewindow
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "maintenance_windows"
+
+// Service represents a service for managing maintenance window data.
+type Service struct {
+	dataservices.BaseDataService[portainer.MaintenanceWindow, portainer.MaintenanceWindowID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.MaintenanceWindow, portainer.MaintenanceWindowID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create assigns an ID to a new maintenance window and saves it.
+func (service *Service) Create(window *portainer.MaintenanceWindow) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			window.ID = portainer.MaintenanceWindowID(id)
+			return int(window.ID), window
+		},
+	)
+}
@@ -0,0 +1,53 @@
+package note
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "notes"
+
+// Service represents a service for managing note data.
+type Service struct {
+	dataservices.BaseDataService[portainer.Note, portainer.NoteID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.Note, portainer.NoteID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create assigns an ID to a new note and saves it.
+func (service *Service) Create(note *portainer.Note) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			note.ID = portainer.NoteID(id)
+			return int(note.ID), note
+		},
+	)
+}
+
+// NotesByEndpoint returns the notes about an environment and about its stacks and containers.
+func (service *Service) NotesByEndpoint(endpointID portainer.EndpointID) ([]portainer.Note, error) {
+	var notes = make([]portainer.Note, 0)
+
+	return notes, service.Connection.GetAll(
+		BucketName,
+		&portainer.Note{},
+		dataservices.FilterFn(&notes, func(e portainer.Note) bool {
+			return e.EndpointID == endpointID
+		}),
+	)
+}
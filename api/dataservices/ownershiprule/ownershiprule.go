@@ -0,0 +1,40 @@
+package ownershiprule
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "ownership_rules"
+
+// Service represents a service for managing ownership rule data.
+type Service struct {
+	dataservices.BaseDataService[portainer.OwnershipRule, portainer.OwnershipRuleID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.OwnershipRule, portainer.OwnershipRuleID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create assigns an ID to a new ownership rule and saves it.
+func (service *Service) Create(rule *portainer.OwnershipRule) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			rule.ID = portainer.OwnershipRuleID(id)
+			return int(rule.ID), rule
+		},
+	)
+}
@@ -0,0 +1,60 @@
+package savedview
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "saved_views"
+
+// Service represents a service for managing saved view data.
+type Service struct {
+	dataservices.BaseDataService[portainer.SavedView, portainer.SavedViewID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.SavedView, portainer.SavedViewID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create assigns an ID to a new saved view and saves it.
+func (service *Service) Create(view *portainer.SavedView) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			view.ID = portainer.SavedViewID(id)
+			return int(view.ID), view
+		},
+	)
+}
+
+// DeleteByOwnerID removes the saved views owned by a user.
+func (service *Service) DeleteByOwnerID(userID portainer.UserID) error {
+	views, err := service.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for _, view := range views {
+		if view.OwnerID != userID {
+			continue
+		}
+
+		if err := service.Delete(view.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
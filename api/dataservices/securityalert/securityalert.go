@@ -0,0 +1,80 @@
+package securityalert
+
+import (
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "security_alerts"
+
+	// maxAlerts is the number of security alerts kept, the oldest ones being removed first
+	maxAlerts = 5000
+)
+
+// Service represents a service for managing security alert data.
+type Service struct {
+	dataservices.BaseDataService[portainer.SecurityAlert, portainer.SecurityAlertID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.SecurityAlert, portainer.SecurityAlertID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create assigns an ID to a new security alert, saves it and prunes the oldest alerts.
+func (service *Service) Create(alert *portainer.SecurityAlert) error {
+	return service.Connection.UpdateTx(func(tx portainer.Transaction) error {
+		err := tx.CreateObject(
+			BucketName,
+			func(id uint64) (int, interface{}) {
+				alert.ID = portainer.SecurityAlertID(id)
+				return int(alert.ID), alert
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		var ids []portainer.SecurityAlertID
+		err = tx.GetAll(
+			BucketName,
+			&portainer.SecurityAlert{},
+			func(obj interface{}) (interface{}, error) {
+				if a, ok := obj.(*portainer.SecurityAlert); ok {
+					ids = append(ids, a.ID)
+				}
+
+				return &portainer.SecurityAlert{}, nil
+			},
+		)
+		if err != nil || len(ids) <= maxAlerts {
+			return err
+		}
+
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+
+		for _, id := range ids[:len(ids)-maxAlerts] {
+			if err := tx.DeleteObject(BucketName, service.Connection.ConvertToKey(int(id))); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
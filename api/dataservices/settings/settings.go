@@ -8,6 +8,8 @@ const (
 	// BucketName represents the name of the bucket where this service stores data.
 	BucketName  = "settings"
 	settingsKey = "SETTINGS"
+	// HistoryBucketName represents the name of the bucket where the previous versions of the settings are stored.
+	HistoryBucketName = "settings_history"
 )
 
 // Service represents a service for managing environment(endpoint) data.
@@ -26,6 +28,11 @@ func NewService(connection portainer.Connection) (*Service, error) {
 		return nil, err
 	}
 
+	err = connection.SetServiceName(HistoryBucketName)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Service{
 		connection: connection,
 	}, nil
@@ -54,3 +61,40 @@ func (service *Service) Settings() (*portainer.Settings, error) {
 func (service *Service) UpdateSettings(settings *portainer.Settings) error {
 	return service.connection.UpdateObject(BucketName, []byte(settingsKey), settings)
 }
+
+// CreateVersion records the settings in the history.
+func (service *Service) CreateVersion(settings *portainer.Settings) error {
+	return service.connection.UpdateTx(func(tx portainer.Transaction) error {
+		return service.Tx(tx).CreateVersion(settings)
+	})
+}
+
+// UpdateVersion persists a version of the settings.
+func (service *Service) UpdateVersion(version *portainer.SettingsVersion) error {
+	return service.connection.UpdateObject(HistoryBucketName, service.connection.ConvertToKey(int(version.ID)), version)
+}
+
+// History returns the versions of the settings, the most recent first.
+func (service *Service) History() ([]portainer.SettingsVersion, error) {
+	var versions []portainer.SettingsVersion
+
+	err := service.connection.ViewTx(func(tx portainer.Transaction) error {
+		var err error
+		versions, err = service.Tx(tx).History()
+		return err
+	})
+
+	return versions, err
+}
+
+// Version returns a version of the settings.
+func (service *Service) Version(ID portainer.SettingsVersionID) (*portainer.SettingsVersion, error) {
+	var version portainer.SettingsVersion
+
+	err := service.connection.GetObject(HistoryBucketName, service.connection.ConvertToKey(int(ID)), &version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
@@ -1,9 +1,17 @@
 package settings
 
 import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+
 	portainer "github.com/portainer/portainer/api"
 )
 
+// maxVersions is the number of versions kept in the history of the settings
+const maxVersions = 50
+
 type ServiceTx struct {
 	service *Service
 	tx      portainer.Transaction
@@ -29,3 +37,85 @@ func (service ServiceTx) Settings() (*portainer.Settings, error) {
 func (service ServiceTx) UpdateSettings(settings *portainer.Settings) error {
 	return service.tx.UpdateObject(BucketName, []byte(settingsKey), settings)
 }
+
+// History returns the versions of the settings, the most recent first.
+func (service ServiceTx) History() ([]portainer.SettingsVersion, error) {
+	var versions = make([]portainer.SettingsVersion, 0)
+
+	err := service.tx.GetAll(
+		HistoryBucketName,
+		&portainer.SettingsVersion{},
+		func(obj interface{}) (interface{}, error) {
+			if version, ok := obj.(*portainer.SettingsVersion); ok {
+				versions = append(versions, *version)
+			}
+
+			return &portainer.SettingsVersion{}, nil
+		},
+	)
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].ID > versions[j].ID
+	})
+
+	return versions, err
+}
+
+// Version returns a version of the settings.
+func (service ServiceTx) Version(ID portainer.SettingsVersionID) (*portainer.SettingsVersion, error) {
+	var version portainer.SettingsVersion
+
+	err := service.tx.GetObject(HistoryBucketName, service.service.connection.ConvertToKey(int(ID)), &version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// CreateVersion records the settings in the history unless they are identical to the latest version, and prunes the
+// oldest versions
+func (service ServiceTx) CreateVersion(settings *portainer.Settings) error {
+	versions, err := service.History()
+	if err != nil {
+		return err
+	}
+
+	if len(versions) > 0 {
+		latest, err := json.Marshal(versions[0].Settings)
+		if err != nil {
+			return err
+		}
+
+		current, err := json.Marshal(settings)
+		if err != nil {
+			return err
+		}
+
+		if bytes.Equal(latest, current) {
+			return nil
+		}
+	}
+
+	err = service.tx.CreateObject(
+		HistoryBucketName,
+		func(id uint64) (int, interface{}) {
+			return int(id), &portainer.SettingsVersion{
+				ID:        portainer.SettingsVersionID(id),
+				Timestamp: time.Now().Unix(),
+				Settings:  *settings,
+			}
+		},
+	)
+	if err != nil || len(versions) < maxVersions {
+		return err
+	}
+
+	for _, version := range versions[maxVersions-1:] {
+		if err := service.tx.DeleteObject(HistoryBucketName, service.service.connection.ConvertToKey(int(version.ID))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package stackbundle
+
+import (
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// BucketName represents the name of the bucket where this service stores data.
+const BucketName = "stack_bundles"
+
+// Service represents a service for managing stack bundle data.
+type Service struct {
+	dataservices.BaseDataService[portainer.StackBundle, portainer.StackBundleID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.StackBundle, portainer.StackBundleID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create assigns an ID to a new stack bundle and saves it.
+func (service *Service) Create(bundle *portainer.StackBundle) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			bundle.ID = portainer.StackBundleID(id)
+			return int(bundle.ID), bundle
+		},
+	)
+}
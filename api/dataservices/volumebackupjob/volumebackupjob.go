@@ -0,0 +1,163 @@
+package volumebackupjob
+
+import (
+	"fmt"
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BucketName represents the name of the bucket where this service stores data.
+	BucketName = "volume_backup_jobs"
+	// HistoryBucketName represents the name of the bucket where the backup history is stored.
+	HistoryBucketName = "volume_backup_history"
+
+	// maxFailedRecords is the number of failed backups kept in the history of each volume backup job, the successful
+	// backups are pruned with their archives according to the retention of the job
+	maxFailedRecords = 20
+)
+
+// Service represents a service for managing volume backup job data.
+type Service struct {
+	dataservices.BaseDataService[portainer.VolumeBackupJob, portainer.VolumeBackupJobID]
+}
+
+// NewService creates a new instance of a service.
+func NewService(connection portainer.Connection) (*Service, error) {
+	err := connection.SetServiceName(BucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = connection.SetServiceName(HistoryBucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		BaseDataService: dataservices.BaseDataService[portainer.VolumeBackupJob, portainer.VolumeBackupJobID]{
+			Bucket:     BucketName,
+			Connection: connection,
+		},
+	}, nil
+}
+
+// Create assigns an ID to a new volume backup job and saves it.
+func (service *Service) Create(job *portainer.VolumeBackupJob) error {
+	return service.Connection.CreateObject(
+		BucketName,
+		func(id uint64) (int, interface{}) {
+			job.ID = portainer.VolumeBackupJobID(id)
+			return int(job.ID), job
+		},
+	)
+}
+
+// Delete deletes a volume backup job and its history.
+func (service *Service) Delete(ID portainer.VolumeBackupJobID) error {
+	err := service.BaseDataService.Delete(ID)
+	if err != nil {
+		return err
+	}
+
+	return service.Connection.DeleteAllObjects(
+		HistoryBucketName,
+		&portainer.VolumeBackupRecord{},
+		func(obj interface{}) (id int, ok bool) {
+			record, ok := obj.(*portainer.VolumeBackupRecord)
+			if !ok {
+				log.Debug().Str("obj", fmt.Sprintf("%#v", obj)).Msg("failed to convert to VolumeBackupRecord object")
+				return -1, false
+			}
+
+			if record.JobID == ID {
+				return int(record.ID), true
+			}
+
+			return -1, false
+		})
+}
+
+// History returns the backups of a volume backup job, the most recent backups first.
+func (service *Service) History(ID portainer.VolumeBackupJobID) ([]portainer.VolumeBackupRecord, error) {
+	var records = make([]portainer.VolumeBackupRecord, 0)
+
+	err := service.Connection.GetAll(
+		HistoryBucketName,
+		&portainer.VolumeBackupRecord{},
+		dataservices.FilterFn(&records, func(e portainer.VolumeBackupRecord) bool {
+			return e.JobID == ID
+		}),
+	)
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ID > records[j].ID
+	})
+
+	return records, err
+}
+
+// Record returns a backup of the history.
+func (service *Service) Record(ID portainer.VolumeBackupRecordID) (*portainer.VolumeBackupRecord, error) {
+	var record portainer.VolumeBackupRecord
+
+	err := service.Connection.GetObject(HistoryBucketName, service.Connection.ConvertToKey(int(ID)), &record)
+	if err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// CreateRecord saves a backup in the history of its volume backup job and prunes the oldest failed backups.
+func (service *Service) CreateRecord(record *portainer.VolumeBackupRecord) error {
+	return service.Connection.UpdateTx(func(tx portainer.Transaction) error {
+		err := tx.CreateObject(
+			HistoryBucketName,
+			func(id uint64) (int, interface{}) {
+				record.ID = portainer.VolumeBackupRecordID(id)
+				return int(record.ID), record
+			},
+		)
+		if err != nil {
+			return err
+		}
+
+		var ids []portainer.VolumeBackupRecordID
+		err = tx.GetAll(
+			HistoryBucketName,
+			&portainer.VolumeBackupRecord{},
+			func(obj interface{}) (interface{}, error) {
+				if r, ok := obj.(*portainer.VolumeBackupRecord); ok && r.JobID == record.JobID && !r.Success {
+					ids = append(ids, r.ID)
+				}
+
+				return &portainer.VolumeBackupRecord{}, nil
+			},
+		)
+		if err != nil || len(ids) <= maxFailedRecords {
+			return err
+		}
+
+		sort.Slice(ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+
+		for _, id := range ids[:len(ids)-maxFailedRecords] {
+			if err := tx.DeleteObject(HistoryBucketName, service.Connection.ConvertToKey(int(id))); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeleteRecord removes a backup from the history.
+func (service *Service) DeleteRecord(ID portainer.VolumeBackupRecordID) error {
+	return service.Connection.DeleteObject(HistoryBucketName, service.Connection.ConvertToKey(int(ID)))
+}
@@ -123,6 +123,7 @@ func setEndpointAuthorizations(endpoint *portainer.Endpoint) {
 		AllowContainerCapabilitiesForRegularUsers: true,
 		AllowDeviceMappingForRegularUsers:         true,
 		AllowStackManagementForRegularUsers:       true,
+		AllowSwarmManagementForRegularUsers:       true,
 	}
 }
 
@@ -71,6 +71,7 @@ func (store *Store) newMigratorParameters(version *models.Version) *migrator.Mig
 		EndpointRelationService: store.EndpointRelationService,
 		ExtensionService:        store.ExtensionService,
 		RegistryService:         store.RegistryService,
+		EventWebhookService:     store.EventWebhookService,
 		ResourceControlService:  store.ResourceControlService,
 		RoleService:             store.RoleService,
 		ScheduleService:         store.ScheduleService,
@@ -0,0 +1,119 @@
+package migrator
+
+import (
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (m *Migrator) updateSwarmManagementSecuritySettingsForDB110() error {
+	log.Info().Msg("allowing the regular users to manage the swarm resources of the existing environments")
+
+	endpoints, err := m.endpointService.Endpoints()
+	if err != nil {
+		return err
+	}
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		endpoint.SecuritySettings.AllowSwarmManagementForRegularUsers = true
+
+		if err := m.endpointService.UpdateEndpoint(endpoint.ID, endpoint); err != nil {
+			return err
+		}
+	}
+
+	groups, err := m.endpointGroupService.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for i := range groups {
+		group := &groups[i]
+		if group.Defaults == nil || group.Defaults.SecuritySettings == nil {
+			continue
+		}
+
+		group.Defaults.SecuritySettings.AllowSwarmManagementForRegularUsers = true
+
+		if err := m.endpointGroupService.Update(group.ID, group); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encryptSecretsForDB110 writes back the objects holding integration credentials, which encrypts the credentials
+// stored in plain text with the key of the secret store
+func (m *Migrator) encryptSecretsForDB110() error {
+	log.Info().Msg("encrypting the integration credentials")
+
+	registries, err := m.registryService.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for i := range registries {
+		if err := m.registryService.Update(registries[i].ID, &registries[i]); err != nil {
+			return err
+		}
+	}
+
+	dockerhub, err := m.dockerhubService.DockerHub()
+	if err == nil {
+		if err := m.dockerhubService.UpdateDockerHub(dockerhub); err != nil {
+			return err
+		}
+	} else if !dataservices.IsErrObjectNotFound(err) {
+		return err
+	}
+
+	settings, err := m.settingsService.Settings()
+	if err != nil {
+		return err
+	}
+
+	if err := m.settingsService.UpdateSettings(settings); err != nil {
+		return err
+	}
+
+	versions, err := m.settingsService.History()
+	if err != nil {
+		return err
+	}
+
+	for i := range versions {
+		if err := m.settingsService.UpdateVersion(&versions[i]); err != nil {
+			return err
+		}
+	}
+
+	endpoints, err := m.endpointService.Endpoints()
+	if err != nil {
+		return err
+	}
+
+	for i := range endpoints {
+		if endpoints[i].AzureCredentials.AuthenticationKey == "" {
+			continue
+		}
+
+		if err := m.endpointService.UpdateEndpoint(endpoints[i].ID, &endpoints[i]); err != nil {
+			return err
+		}
+	}
+
+	webhooks, err := m.eventWebhookService.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for i := range webhooks {
+		if err := m.eventWebhookService.Update(webhooks[i].ID, &webhooks[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
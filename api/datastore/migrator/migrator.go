@@ -12,6 +12,7 @@ import (
 	"github.com/portainer/portainer/api/dataservices/endpoint"
 	"github.com/portainer/portainer/api/dataservices/endpointgroup"
 	"github.com/portainer/portainer/api/dataservices/endpointrelation"
+	"github.com/portainer/portainer/api/dataservices/eventwebhook"
 	"github.com/portainer/portainer/api/dataservices/extension"
 	"github.com/portainer/portainer/api/dataservices/fdoprofile"
 	"github.com/portainer/portainer/api/dataservices/registry"
@@ -42,6 +43,7 @@ type (
 		extensionService        *extension.Service
 		fdoProfilesService      *fdoprofile.Service
 		registryService         *registry.Service
+		eventWebhookService     *eventwebhook.Service
 		resourceControlService  *resourcecontrol.Service
 		roleService             *role.Service
 		scheduleService         *schedule.Service
@@ -69,6 +71,7 @@ type (
 		ExtensionService        *extension.Service
 		FDOProfilesService      *fdoprofile.Service
 		RegistryService         *registry.Service
+		EventWebhookService     *eventwebhook.Service
 		ResourceControlService  *resourcecontrol.Service
 		RoleService             *role.Service
 		ScheduleService         *schedule.Service
@@ -98,6 +101,7 @@ func NewMigrator(parameters *MigratorParameters) *Migrator {
 		extensionService:        parameters.ExtensionService,
 		fdoProfilesService:      parameters.FDOProfilesService,
 		registryService:         parameters.RegistryService,
+		eventWebhookService:     parameters.EventWebhookService,
 		resourceControlService:  parameters.ResourceControlService,
 		roleService:             parameters.RoleService,
 		scheduleService:         parameters.ScheduleService,
@@ -229,6 +233,11 @@ func (m *Migrator) initMigrations() {
 		m.updateEdgeStackStatusForDB100,
 	)
 
+	m.addMigrations("2.20",
+		m.updateSwarmManagementSecuritySettingsForDB110,
+		m.encryptSecretsForDB110,
+	)
+
 	// Add new migrations below...
 	// One function per migration, each versions migration funcs in the same file.
 }
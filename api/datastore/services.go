@@ -9,32 +9,48 @@ import (
 	"github.com/portainer/portainer/api/database/models"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/dataservices/apikeyrepository"
+	"github.com/portainer/portainer/api/dataservices/automationwebhook"
+	"github.com/portainer/portainer/api/dataservices/backgroundjob"
+	"github.com/portainer/portainer/api/dataservices/changerequest"
+	"github.com/portainer/portainer/api/dataservices/cmdbconnector"
+	"github.com/portainer/portainer/api/dataservices/costreport"
 	"github.com/portainer/portainer/api/dataservices/customtemplate"
 	"github.com/portainer/portainer/api/dataservices/dockerhub"
+	"github.com/portainer/portainer/api/dataservices/dockeroperationaudit"
 	"github.com/portainer/portainer/api/dataservices/edgegroup"
 	"github.com/portainer/portainer/api/dataservices/edgejob"
 	"github.com/portainer/portainer/api/dataservices/edgestack"
 	"github.com/portainer/portainer/api/dataservices/endpoint"
 	"github.com/portainer/portainer/api/dataservices/endpointgroup"
+	"github.com/portainer/portainer/api/dataservices/endpointregistrationtoken"
 	"github.com/portainer/portainer/api/dataservices/endpointrelation"
+	"github.com/portainer/portainer/api/dataservices/eventwebhook"
 	"github.com/portainer/portainer/api/dataservices/extension"
 	"github.com/portainer/portainer/api/dataservices/fdoprofile"
 	"github.com/portainer/portainer/api/dataservices/helmuserrepository"
+	"github.com/portainer/portainer/api/dataservices/imageupdatepolicy"
+	"github.com/portainer/portainer/api/dataservices/maintenancewindow"
+	"github.com/portainer/portainer/api/dataservices/note"
+	"github.com/portainer/portainer/api/dataservices/ownershiprule"
 	"github.com/portainer/portainer/api/dataservices/pendingactions"
 	"github.com/portainer/portainer/api/dataservices/registry"
 	"github.com/portainer/portainer/api/dataservices/resourcecontrol"
 	"github.com/portainer/portainer/api/dataservices/role"
+	"github.com/portainer/portainer/api/dataservices/savedview"
 	"github.com/portainer/portainer/api/dataservices/schedule"
+	"github.com/portainer/portainer/api/dataservices/securityalert"
 	"github.com/portainer/portainer/api/dataservices/settings"
 	"github.com/portainer/portainer/api/dataservices/snapshot"
 	"github.com/portainer/portainer/api/dataservices/ssl"
 	"github.com/portainer/portainer/api/dataservices/stack"
+	"github.com/portainer/portainer/api/dataservices/stackbundle"
 	"github.com/portainer/portainer/api/dataservices/tag"
 	"github.com/portainer/portainer/api/dataservices/team"
 	"github.com/portainer/portainer/api/dataservices/teammembership"
 	"github.com/portainer/portainer/api/dataservices/tunnelserver"
 	"github.com/portainer/portainer/api/dataservices/user"
 	"github.com/portainer/portainer/api/dataservices/version"
+	"github.com/portainer/portainer/api/dataservices/volumebackupjob"
 	"github.com/portainer/portainer/api/dataservices/webhook"
 
 	"github.com/rs/zerolog/log"
@@ -45,35 +61,51 @@ import (
 type Store struct {
 	connection portainer.Connection
 
-	fileService               portainer.FileService
-	CustomTemplateService     *customtemplate.Service
-	DockerHubService          *dockerhub.Service
-	EdgeGroupService          *edgegroup.Service
-	EdgeJobService            *edgejob.Service
-	EdgeStackService          *edgestack.Service
-	EndpointGroupService      *endpointgroup.Service
-	EndpointService           *endpoint.Service
-	EndpointRelationService   *endpointrelation.Service
-	ExtensionService          *extension.Service
-	FDOProfilesService        *fdoprofile.Service
-	HelmUserRepositoryService *helmuserrepository.Service
-	RegistryService           *registry.Service
-	ResourceControlService    *resourcecontrol.Service
-	RoleService               *role.Service
-	APIKeyRepositoryService   *apikeyrepository.Service
-	ScheduleService           *schedule.Service
-	SettingsService           *settings.Service
-	SnapshotService           *snapshot.Service
-	SSLSettingsService        *ssl.Service
-	StackService              *stack.Service
-	TagService                *tag.Service
-	TeamMembershipService     *teammembership.Service
-	TeamService               *team.Service
-	TunnelServerService       *tunnelserver.Service
-	UserService               *user.Service
-	VersionService            *version.Service
-	WebhookService            *webhook.Service
-	PendingActionsService     *pendingactions.Service
+	fileService                      portainer.FileService
+	AutomationWebhookService         *automationwebhook.Service
+	BackgroundJobService             *backgroundjob.Service
+	ChangeRequestService             *changerequest.Service
+	CMDBConnectorService             *cmdbconnector.Service
+	CostReportService                *costreport.Service
+	CustomTemplateService            *customtemplate.Service
+	DockerHubService                 *dockerhub.Service
+	DockerOperationAuditService      *dockeroperationaudit.Service
+	EdgeGroupService                 *edgegroup.Service
+	EdgeJobService                   *edgejob.Service
+	EdgeStackService                 *edgestack.Service
+	EndpointGroupService             *endpointgroup.Service
+	EndpointService                  *endpoint.Service
+	EndpointRelationService          *endpointrelation.Service
+	EndpointRegistrationTokenService *endpointregistrationtoken.Service
+	EventWebhookService              *eventwebhook.Service
+	ExtensionService                 *extension.Service
+	FDOProfilesService               *fdoprofile.Service
+	HelmUserRepositoryService        *helmuserrepository.Service
+	ImageUpdatePolicyService         *imageupdatepolicy.Service
+	MaintenanceWindowService         *maintenancewindow.Service
+	NoteService                      *note.Service
+	OwnershipRuleService             *ownershiprule.Service
+	RegistryService                  *registry.Service
+	ResourceControlService           *resourcecontrol.Service
+	RoleService                      *role.Service
+	APIKeyRepositoryService          *apikeyrepository.Service
+	SavedViewService                 *savedview.Service
+	ScheduleService                  *schedule.Service
+	SettingsService                  *settings.Service
+	SnapshotService                  *snapshot.Service
+	SSLSettingsService               *ssl.Service
+	StackService                     *stack.Service
+	SecurityAlertService             *securityalert.Service
+	StackBundleService               *stackbundle.Service
+	TagService                       *tag.Service
+	TeamMembershipService            *teammembership.Service
+	TeamService                      *team.Service
+	TunnelServerService              *tunnelserver.Service
+	UserService                      *user.Service
+	VersionService                   *version.Service
+	VolumeBackupJobService           *volumebackupjob.Service
+	WebhookService                   *webhook.Service
+	PendingActionsService            *pendingactions.Service
 }
 
 func (store *Store) initServices() error {
@@ -83,6 +115,36 @@ func (store *Store) initServices() error {
 	}
 	store.RoleService = authorizationsetService
 
+	automationWebhookService, err := automationwebhook.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.AutomationWebhookService = automationWebhookService
+
+	changeRequestService, err := changerequest.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.ChangeRequestService = changeRequestService
+
+	cmdbConnectorService, err := cmdbconnector.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.CMDBConnectorService = cmdbConnectorService
+
+	backgroundJobService, err := backgroundjob.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.BackgroundJobService = backgroundJobService
+
+	costReportService, err := costreport.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.CostReportService = costReportService
+
 	customTemplateService, err := customtemplate.NewService(store.connection)
 	if err != nil {
 		return err
@@ -95,6 +157,12 @@ func (store *Store) initServices() error {
 	}
 	store.DockerHubService = dockerhubService
 
+	dockerOperationAuditService, err := dockeroperationaudit.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.DockerOperationAuditService = dockerOperationAuditService
+
 	endpointRelationService, err := endpointrelation.NewService(store.connection)
 	if err != nil {
 		return err
@@ -144,12 +212,48 @@ func (store *Store) initServices() error {
 	}
 	store.FDOProfilesService = fdoProfilesService
 
+	endpointRegistrationTokenService, err := endpointregistrationtoken.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.EndpointRegistrationTokenService = endpointRegistrationTokenService
+
+	eventWebhookService, err := eventwebhook.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.EventWebhookService = eventWebhookService
+
 	helmUserRepositoryService, err := helmuserrepository.NewService(store.connection)
 	if err != nil {
 		return err
 	}
 	store.HelmUserRepositoryService = helmUserRepositoryService
 
+	imageUpdatePolicyService, err := imageupdatepolicy.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.ImageUpdatePolicyService = imageUpdatePolicyService
+
+	maintenanceWindowService, err := maintenancewindow.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.MaintenanceWindowService = maintenanceWindowService
+
+	noteService, err := note.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.NoteService = noteService
+
+	ownershipRuleService, err := ownershiprule.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.OwnershipRuleService = ownershipRuleService
+
 	registryService, err := registry.NewService(store.connection)
 	if err != nil {
 		return err
@@ -186,6 +290,18 @@ func (store *Store) initServices() error {
 	}
 	store.StackService = stackService
 
+	securityAlertService, err := securityalert.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.SecurityAlertService = securityAlertService
+
+	stackBundleService, err := stackbundle.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.StackBundleService = stackBundleService
+
 	tagService, err := tag.NewService(store.connection)
 	if err != nil {
 		return err
@@ -228,12 +344,24 @@ func (store *Store) initServices() error {
 	}
 	store.VersionService = versionService
 
+	volumeBackupJobService, err := volumebackupjob.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.VolumeBackupJobService = volumeBackupJobService
+
 	webhookService, err := webhook.NewService(store.connection)
 	if err != nil {
 		return err
 	}
 	store.WebhookService = webhookService
 
+	savedViewService, err := savedview.NewService(store.connection)
+	if err != nil {
+		return err
+	}
+	store.SavedViewService = savedViewService
+
 	scheduleService, err := schedule.NewService(store.connection)
 	if err != nil {
 		return err
@@ -254,6 +382,16 @@ func (store *Store) PendingActions() dataservices.PendingActionsService {
 	return store.PendingActionsService
 }
 
+// AutomationWebhook gives access to the AutomationWebhook data management layer
+func (store *Store) AutomationWebhook() dataservices.AutomationWebhookService {
+	return store.AutomationWebhookService
+}
+
+// ChangeRequest gives access to the ChangeRequest data management layer
+func (store *Store) ChangeRequest() dataservices.ChangeRequestService {
+	return store.ChangeRequestService
+}
+
 // CustomTemplate gives access to the CustomTemplate data management layer
 func (store *Store) CustomTemplate() dataservices.CustomTemplateService {
 	return store.CustomTemplateService
@@ -289,6 +427,21 @@ func (store *Store) EndpointRelation() dataservices.EndpointRelationService {
 	return store.EndpointRelationService
 }
 
+// EndpointRegistrationToken gives access to the EndpointRegistrationToken data management layer
+func (store *Store) EndpointRegistrationToken() dataservices.EndpointRegistrationTokenService {
+	return store.EndpointRegistrationTokenService
+}
+
+// DockerOperationAudit gives access to the DockerOperationAudit data management layer
+func (store *Store) DockerOperationAudit() dataservices.DockerOperationAuditService {
+	return store.DockerOperationAuditService
+}
+
+// EventWebhook gives access to the EventWebhook data management layer
+func (store *Store) EventWebhook() dataservices.EventWebhookService {
+	return store.EventWebhookService
+}
+
 // FDOProfile gives access to the FDOProfile data management layer
 func (store *Store) FDOProfile() dataservices.FDOProfileService {
 	return store.FDOProfilesService
@@ -299,6 +452,41 @@ func (store *Store) HelmUserRepository() dataservices.HelmUserRepositoryService
 	return store.HelmUserRepositoryService
 }
 
+// ImageUpdatePolicy gives access to the ImageUpdatePolicy data management layer
+func (store *Store) ImageUpdatePolicy() dataservices.ImageUpdatePolicyService {
+	return store.ImageUpdatePolicyService
+}
+
+// MaintenanceWindow gives access to the MaintenanceWindow data management layer
+func (store *Store) MaintenanceWindow() dataservices.MaintenanceWindowService {
+	return store.MaintenanceWindowService
+}
+
+// Note gives access to the Note data management layer
+func (store *Store) Note() dataservices.NoteService {
+	return store.NoteService
+}
+
+// CMDBConnector gives access to the CMDBConnector data management layer
+func (store *Store) CMDBConnector() dataservices.CMDBConnectorService {
+	return store.CMDBConnectorService
+}
+
+// CostReport gives access to the CostReport data management layer
+func (store *Store) CostReport() dataservices.CostReportService {
+	return store.CostReportService
+}
+
+// BackgroundJob gives access to the BackgroundJob data management layer
+func (store *Store) BackgroundJob() dataservices.BackgroundJobService {
+	return store.BackgroundJobService
+}
+
+// OwnershipRule gives access to the OwnershipRule data management layer
+func (store *Store) OwnershipRule() dataservices.OwnershipRuleService {
+	return store.OwnershipRuleService
+}
+
 // Registry gives access to the Registry data management layer
 func (store *Store) Registry() dataservices.RegistryService {
 	return store.RegistryService
@@ -314,6 +502,11 @@ func (store *Store) Role() dataservices.RoleService {
 	return store.RoleService
 }
 
+// SavedView gives access to the SavedView data management layer
+func (store *Store) SavedView() dataservices.SavedViewService {
+	return store.SavedViewService
+}
+
 // APIKeyRepository gives access to the api-key data management layer
 func (store *Store) APIKeyRepository() dataservices.APIKeyRepository {
 	return store.APIKeyRepositoryService
@@ -338,6 +531,16 @@ func (store *Store) Stack() dataservices.StackService {
 	return store.StackService
 }
 
+// SecurityAlert gives access to the SecurityAlert data management layer
+func (store *Store) SecurityAlert() dataservices.SecurityAlertService {
+	return store.SecurityAlertService
+}
+
+// StackBundle gives access to the StackBundle data management layer
+func (store *Store) StackBundle() dataservices.StackBundleService {
+	return store.StackBundleService
+}
+
 // Tag gives access to the Tag data management layer
 func (store *Store) Tag() dataservices.TagService {
 	return store.TagService
@@ -368,6 +571,11 @@ func (store *Store) Version() dataservices.VersionService {
 	return store.VersionService
 }
 
+// VolumeBackupJob gives access to the VolumeBackupJob data management layer
+func (store *Store) VolumeBackupJob() dataservices.VolumeBackupJobService {
+	return store.VolumeBackupJobService
+}
+
 // Webhook gives access to the Webhook data management layer
 func (store *Store) Webhook() dataservices.WebhookService {
 	return store.WebhookService
@@ -14,6 +14,10 @@ func (tx *StoreTx) IsErrObjectNotFound(err error) bool {
 	return tx.store.IsErrObjectNotFound(err)
 }
 
+func (tx *StoreTx) AutomationWebhook() dataservices.AutomationWebhookService { return nil }
+
+func (tx *StoreTx) ChangeRequest() dataservices.ChangeRequestService { return nil }
+
 func (tx *StoreTx) CustomTemplate() dataservices.CustomTemplateService { return nil }
 
 func (tx *StoreTx) PendingActions() dataservices.PendingActionsService { return nil }
@@ -42,8 +46,24 @@ func (tx *StoreTx) EndpointRelation() dataservices.EndpointRelationService {
 	return tx.store.EndpointRelationService.Tx(tx.tx)
 }
 
+func (tx *StoreTx) EndpointRegistrationToken() dataservices.EndpointRegistrationTokenService {
+	return nil
+}
+
+func (tx *StoreTx) DockerOperationAudit() dataservices.DockerOperationAuditService {
+	return nil
+}
+
+func (tx *StoreTx) CMDBConnector() dataservices.CMDBConnectorService           { return nil }
+func (tx *StoreTx) BackgroundJob() dataservices.BackgroundJobService           { return nil }
+func (tx *StoreTx) CostReport() dataservices.CostReportService                 { return nil }
+func (tx *StoreTx) EventWebhook() dataservices.EventWebhookService             { return nil }
 func (tx *StoreTx) FDOProfile() dataservices.FDOProfileService                 { return nil }
 func (tx *StoreTx) HelmUserRepository() dataservices.HelmUserRepositoryService { return nil }
+func (tx *StoreTx) ImageUpdatePolicy() dataservices.ImageUpdatePolicyService   { return nil }
+func (tx *StoreTx) MaintenanceWindow() dataservices.MaintenanceWindowService   { return nil }
+func (tx *StoreTx) Note() dataservices.NoteService                             { return nil }
+func (tx *StoreTx) OwnershipRule() dataservices.OwnershipRuleService           { return nil }
 
 func (tx *StoreTx) Registry() dataservices.RegistryService {
 	return tx.store.RegistryService.Tx(tx.tx)
@@ -59,6 +79,10 @@ func (tx *StoreTx) Role() dataservices.RoleService {
 
 func (tx *StoreTx) APIKeyRepository() dataservices.APIKeyRepository { return nil }
 
+func (tx *StoreTx) SavedView() dataservices.SavedViewService { return nil }
+
+func (tx *StoreTx) SecurityAlert() dataservices.SecurityAlertService { return nil }
+
 func (tx *StoreTx) Settings() dataservices.SettingsService {
 	return tx.store.SettingsService.Tx(tx.tx)
 }
@@ -69,6 +93,7 @@ func (tx *StoreTx) Snapshot() dataservices.SnapshotService {
 
 func (tx *StoreTx) SSLSettings() dataservices.SSLSettingsService { return nil }
 func (tx *StoreTx) Stack() dataservices.StackService             { return nil }
+func (tx *StoreTx) StackBundle() dataservices.StackBundleService { return nil }
 
 func (tx *StoreTx) Tag() dataservices.TagService {
 	return tx.store.TagService.Tx(tx.tx)
@@ -86,4 +111,7 @@ func (tx *StoreTx) User() dataservices.UserService {
 }
 
 func (tx *StoreTx) Version() dataservices.VersionService { return nil }
+func (tx *StoreTx) VolumeBackupJob() dataservices.VolumeBackupJobService {
+	return nil
+}
 func (tx *StoreTx) Webhook() dataservices.WebhookService { return nil }
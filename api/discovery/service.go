@@ -0,0 +1,344 @@
+package discovery
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/agent"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// TagPrefix is the prefix of the tag applied to the environments registered from a discovery source
+const TagPrefix = "discovery:"
+
+// Service periodically queries the discovery sources and keeps the registered environments in sync with them
+type Service struct {
+	dataStore       dataservices.DataStore
+	snapshotService portainer.SnapshotService
+	endpointDeleter *endpointutils.EndpointDeleter
+	job             *scheduler.PeriodicJob
+	newSource       func(portainer.DiscoverySource) (Source, error)
+	agentPlatform   func(url string, tlsConfig *tls.Config) (portainer.AgentPlatform, string, error)
+}
+
+// NewService creates a new instance of the discovery service, the environments which disappeared from their source
+// being removed by endpointDeleter like the environments removed by the administrators
+func NewService(dataStore dataservices.DataStore, snapshotService portainer.SnapshotService, endpointDeleter *endpointutils.EndpointDeleter, scheduler *scheduler.Scheduler) *Service {
+	return &Service{
+		dataStore:       dataStore,
+		snapshotService: snapshotService,
+		endpointDeleter: endpointDeleter,
+		job:             scheduler.NewPeriodicJob(),
+		newSource:       NewSource,
+		agentPlatform:   agent.GetAgentVersionAndPlatform,
+	}
+}
+
+// Start schedules the discovery according to the settings stored in the database
+func (service *Service) Start() error {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	return service.Configure(settings.Discovery)
+}
+
+// Configure replaces the scheduled discovery with one matching the settings
+func (service *Service) Configure(settings portainer.DiscoverySettings) error {
+	if err := ValidateSettings(settings); err != nil {
+		return err
+	}
+
+	if !settings.Enabled {
+		return service.job.Stop()
+	}
+
+	interval, err := parseInterval(settings.Interval)
+	if err != nil {
+		return err
+	}
+
+	sources := settings.Sources
+
+	return service.job.Schedule(interval, func() error {
+		service.Discover(context.Background(), sources)
+		return nil
+	})
+}
+
+// ValidateSettings checks that the discovery settings can be scheduled
+func ValidateSettings(settings portainer.DiscoverySettings) error {
+	if _, err := parseInterval(settings.Interval); err != nil {
+		return err
+	}
+
+	names := make(map[string]bool)
+	for _, source := range settings.Sources {
+		if source.Name == "" {
+			return errors.New("discovery source name is required")
+		}
+
+		if names[source.Name] {
+			return fmt.Errorf("duplicate discovery source name %q", source.Name)
+		}
+		names[source.Name] = true
+
+		if _, err := NewSource(source); err != nil {
+			return err
+		}
+
+		if source.Type != portainer.DiscoverySourceInventory && source.Service == "" {
+			return fmt.Errorf("service is required for the discovery source %s", source.Name)
+		}
+
+		if source.Type != portainer.DiscoverySourceDNS && source.URL == "" {
+			return fmt.Errorf("URL is required for the discovery source %s", source.Name)
+		}
+
+		for _, rule := range source.Rules {
+			if _, err := regexp.Compile(rule.NamePattern); err != nil {
+				return errors.Wrapf(err, "invalid name pattern for the discovery source %s", source.Name)
+			}
+
+			if rule.EndpointType != portainer.DockerEnvironment && rule.EndpointType != portainer.AgentOnDockerEnvironment {
+				return fmt.Errorf("invalid environment type %d for the discovery source %s", rule.EndpointType, source.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseInterval(interval string) (time.Duration, error) {
+	return scheduler.ParseInterval(interval, portainer.DefaultDiscoveryInterval, "discovery")
+}
+
+// Discover queries each source and synchronizes the environments registered from it.
+// A source that cannot be queried is skipped so that its environments are not deregistered
+// because of a transient failure.
+func (service *Service) Discover(ctx context.Context, sources []portainer.DiscoverySource) {
+	for _, config := range sources {
+		source, err := service.newSource(config)
+		if err != nil {
+			log.Error().Err(err).Str("source", config.Name).Msg("unable to create the discovery source")
+			continue
+		}
+
+		targets, err := source.Discover(ctx)
+		if err != nil {
+			log.Warn().Err(err).Str("source", config.Name).Msg("unable to query the discovery source")
+			continue
+		}
+
+		if err := service.sync(config, targets); err != nil {
+			log.Error().Err(err).Str("source", config.Name).Msg("unable to synchronize the discovered environments")
+		}
+	}
+}
+
+type matchedTarget struct {
+	Target
+	rule portainer.DiscoveryRule
+}
+
+func matchTargets(config portainer.DiscoverySource, targets []Target) map[string]matchedTarget {
+	matched := make(map[string]matchedTarget)
+
+	for _, target := range targets {
+		for _, rule := range config.Rules {
+			pattern, err := regexp.Compile(rule.NamePattern)
+			if err != nil || !pattern.MatchString(target.Name) {
+				continue
+			}
+
+			matched[target.Address] = matchedTarget{Target: target, rule: rule}
+			break
+		}
+	}
+
+	return matched
+}
+
+func (service *Service) sync(config portainer.DiscoverySource, targets []Target) error {
+	matched := matchTargets(config, targets)
+
+	tag, err := service.sourceTag(config.Name)
+	if err != nil {
+		return err
+	}
+
+	endpoints, err := service.dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]bool)
+	registered := make(map[string]bool)
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		names[endpoint.Name] = true
+
+		if !tag.Endpoints[endpoint.ID] {
+			continue
+		}
+
+		address := endpointAddress(endpoint)
+		if _, ok := matched[address]; ok {
+			registered[address] = true
+			continue
+		}
+
+		if err := service.deregister(endpoint); err != nil {
+			log.Error().Err(err).Str("source", config.Name).Str("endpoint", endpoint.Name).Msg("unable to deregister the environment")
+			continue
+		}
+
+		log.Info().Str("source", config.Name).Str("endpoint", endpoint.Name).Msg("deregistered an environment which disappeared from its discovery source")
+	}
+
+	for address, target := range matched {
+		if registered[address] {
+			continue
+		}
+
+		if names[target.Name] {
+			log.Warn().Str("source", config.Name).Str("endpoint", target.Name).Msg("an environment with the same name already exists, skipping the discovered target")
+			continue
+		}
+
+		if err := service.register(target, tag.ID); err != nil {
+			log.Warn().Err(err).Str("source", config.Name).Str("endpoint", target.Name).Msg("unable to register the discovered environment")
+			continue
+		}
+
+		names[target.Name] = true
+
+		log.Info().Str("source", config.Name).Str("endpoint", target.Name).Msg("registered a discovered environment")
+	}
+
+	return nil
+}
+
+func endpointAddress(endpoint *portainer.Endpoint) string {
+	return strings.TrimPrefix(endpoint.URL, "tcp://")
+}
+
+// sourceTag returns the tag applied to the environments of the source, creating it if needed
+func (service *Service) sourceTag(sourceName string) (*portainer.Tag, error) {
+	name := TagPrefix + sourceName
+
+	tags, err := service.dataStore.Tag().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tags {
+		if tags[i].Name == name {
+			return &tags[i], nil
+		}
+	}
+
+	tag := &portainer.Tag{
+		Name:           name,
+		EndpointGroups: map[portainer.EndpointGroupID]bool{},
+		Endpoints:      map[portainer.EndpointID]bool{},
+	}
+
+	return tag, service.dataStore.Tag().Create(tag)
+}
+
+func (service *Service) register(target matchedTarget, tagID portainer.TagID) error {
+	groupID := target.rule.GroupID
+	if groupID == 0 {
+		groupID = 1
+	}
+
+	endpoint := &portainer.Endpoint{
+		Name:               target.Name,
+		URL:                "tcp://" + target.Address,
+		Type:               portainer.DockerEnvironment,
+		GroupID:            groupID,
+		UserAccessPolicies: portainer.UserAccessPolicies{},
+		TeamAccessPolicies: portainer.TeamAccessPolicies{},
+		TagIDs:             []portainer.TagID{tagID},
+		Status:             portainer.EndpointStatusUp,
+		Snapshots:          []portainer.DockerSnapshot{},
+		Kubernetes:         portainer.KubernetesDefault(),
+		SecuritySettings: portainer.EndpointSecuritySettings{
+			AllowSysctlSettingForRegularUsers:         true,
+			AllowBindMountsForRegularUsers:            true,
+			AllowPrivilegedModeForRegularUsers:        true,
+			AllowHostNamespaceForRegularUsers:         true,
+			AllowContainerCapabilitiesForRegularUsers: true,
+			AllowDeviceMappingForRegularUsers:         true,
+			AllowStackManagementForRegularUsers:       true,
+			AllowSwarmManagementForRegularUsers:       true,
+		},
+	}
+
+	if target.rule.EndpointType == portainer.AgentOnDockerEnvironment {
+		endpoint.TLSConfig = portainer.TLSConfiguration{TLS: true, TLSSkipVerify: true}
+
+		platform, version, err := service.agentPlatform(endpoint.URL, &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			return errors.Wrap(err, "unable to retrieve the agent platform")
+		}
+
+		endpoint.Agent.Version = version
+		endpoint.Type = portainer.AgentOnDockerEnvironment
+		if platform == portainer.AgentPlatformKubernetes {
+			endpoint.Type = portainer.AgentOnKubernetesEnvironment
+			endpoint.URL = target.Address
+		}
+	}
+
+	if err := service.snapshotService.SnapshotEndpoint(endpoint); err != nil {
+		return errors.Wrap(err, "unable to initiate communications with the environment")
+	}
+
+	return service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		endpoint.ID = portainer.EndpointID(tx.Endpoint().GetNextIdentifier())
+		if err := tx.Endpoint().Create(endpoint); err != nil {
+			return err
+		}
+
+		tag, err := tx.Tag().Read(tagID)
+		if err != nil {
+			return err
+		}
+
+		tag.Endpoints[endpoint.ID] = true
+		if err := tx.Tag().Update(tagID, tag); err != nil {
+			return err
+		}
+
+		return tx.EndpointRelation().Create(&portainer.EndpointRelation{
+			EndpointID: endpoint.ID,
+			EdgeStacks: map[portainer.EdgeStackID]bool{},
+		})
+	})
+}
+
+func (service *Service) deregister(endpoint *portainer.Endpoint) error {
+	err := service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		return service.endpointDeleter.DeleteTx(tx, endpoint)
+	})
+	if err != nil {
+		return err
+	}
+
+	service.endpointDeleter.DeleteNotes(endpoint.ID)
+
+	return nil
+}
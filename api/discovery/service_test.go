@@ -0,0 +1,166 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type staticSource struct {
+	targets []Target
+}
+
+func (source *staticSource) Discover(ctx context.Context) ([]Target, error) {
+	return source.targets, nil
+}
+
+type snapshotServiceMock struct {
+	portainer.SnapshotService
+}
+
+func (service snapshotServiceMock) SnapshotEndpoint(endpoint *portainer.Endpoint) error {
+	return nil
+}
+
+type proxyManagerMock struct {
+	deleted []portainer.EndpointID
+}
+
+func (manager *proxyManagerMock) DeleteEndpointProxy(endpointID portainer.EndpointID) {
+	manager.deleted = append(manager.deleted, endpointID)
+}
+
+func TestDiscover(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	source := &staticSource{targets: []Target{
+		{Name: "worker-1", Address: "10.0.0.1:2375"},
+		{Name: "worker-2", Address: "10.0.0.2:2375"},
+		{Name: "manager-1", Address: "10.0.0.3:2375"},
+	}}
+
+	proxyManager := &proxyManagerMock{}
+	service := NewService(store, snapshotServiceMock{}, &endpointutils.EndpointDeleter{DataStore: store, ProxyManager: proxyManager}, nil)
+	service.newSource = func(portainer.DiscoverySource) (Source, error) {
+		return source, nil
+	}
+
+	config := portainer.DiscoverySource{
+		Name:    "swarm",
+		Type:    portainer.DiscoverySourceDNS,
+		Service: "_docker._tcp.swarm",
+		Rules:   []portainer.DiscoveryRule{{NamePattern: "^worker-", EndpointType: portainer.DockerEnvironment}},
+	}
+
+	service.Discover(context.Background(), []portainer.DiscoverySource{config})
+
+	endpoints, err := store.Endpoint().Endpoints()
+	assert.NoError(t, err)
+	if !assert.Len(t, endpoints, 2) {
+		return
+	}
+
+	tags, err := store.Tag().ReadAll()
+	assert.NoError(t, err)
+	if !assert.Len(t, tags, 1) {
+		return
+	}
+	assert.Equal(t, TagPrefix+"swarm", tags[0].Name)
+	assert.Len(t, tags[0].Endpoints, 2)
+
+	for _, endpoint := range endpoints {
+		assert.Equal(t, []portainer.TagID{tags[0].ID}, endpoint.TagIDs)
+		assert.Equal(t, portainer.EndpointGroupID(1), endpoint.GroupID)
+	}
+
+	// the environments are removed along with their relations, as when the administrators remove them
+	for _, endpoint := range endpoints {
+		if endpoint.Name == "worker-1" {
+			assert.NoError(t, store.Note().Create(&portainer.Note{ResourceType: portainer.NoteEndpoint, EndpointID: endpoint.ID, Content: "rack 4"}))
+		}
+	}
+
+	// worker-1 disappears, worker-3 appears
+	source.targets = []Target{
+		{Name: "worker-2", Address: "10.0.0.2:2375"},
+		{Name: "worker-3", Address: "10.0.0.4:2375"},
+	}
+
+	service.Discover(context.Background(), []portainer.DiscoverySource{config})
+
+	endpoints, err = store.Endpoint().Endpoints()
+	assert.NoError(t, err)
+
+	names := []string{}
+	for _, endpoint := range endpoints {
+		names = append(names, endpoint.Name)
+	}
+	assert.ElementsMatch(t, []string{"worker-2", "worker-3"}, names)
+	assert.Len(t, proxyManager.deleted, 1)
+
+	notes, err := store.Note().ReadAll()
+	assert.NoError(t, err)
+	assert.Empty(t, notes)
+
+	tag, err := store.Tag().Read(tags[0].ID)
+	assert.NoError(t, err)
+	assert.Len(t, tag.Endpoints, 2)
+}
+
+func TestValidateSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings portainer.DiscoverySettings
+		wantErr  bool
+	}{
+		{name: "default interval", settings: portainer.DiscoverySettings{Enabled: true}},
+		{name: "invalid interval", settings: portainer.DiscoverySettings{Interval: "often"}, wantErr: true},
+		{
+			name: "unsupported source type",
+			settings: portainer.DiscoverySettings{Sources: []portainer.DiscoverySource{
+				{Name: "cloud", Type: "ec2"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate source name",
+			settings: portainer.DiscoverySettings{Sources: []portainer.DiscoverySource{
+				{Name: "dns", Type: portainer.DiscoverySourceDNS, Service: "_docker._tcp.a"},
+				{Name: "dns", Type: portainer.DiscoverySourceDNS, Service: "_docker._tcp.b"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "missing consul URL",
+			settings: portainer.DiscoverySettings{Sources: []portainer.DiscoverySource{
+				{Name: "consul", Type: portainer.DiscoverySourceConsul, Service: "docker"},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "invalid rule",
+			settings: portainer.DiscoverySettings{Sources: []portainer.DiscoverySource{
+				{Name: "inventory", Type: portainer.DiscoverySourceInventory, URL: "http://inventory", Rules: []portainer.DiscoveryRule{
+					{NamePattern: "(", EndpointType: portainer.DockerEnvironment},
+				}},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSettings(tt.settings)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/client"
+
+	"github.com/pkg/errors"
+)
+
+const sourceRequestTimeout = 30 * time.Second
+
+// Target is a host discovered from a source
+type Target struct {
+	// Name of the host, matched against the rules of the source
+	Name string `json:"Name"`
+	// Address of the host, formatted as host:port
+	Address string `json:"Address"`
+}
+
+// Source lists the hosts currently available from a discovery source
+type Source interface {
+	Discover(ctx context.Context) ([]Target, error)
+}
+
+// NewSource creates the source described by the configuration
+func NewSource(config portainer.DiscoverySource) (Source, error) {
+	switch config.Type {
+	case portainer.DiscoverySourceDNS:
+		return &dnsSource{record: config.Service, resolver: net.DefaultResolver}, nil
+	case portainer.DiscoverySourceConsul:
+		return &consulSource{url: strings.TrimSuffix(config.URL, "/"), service: config.Service, client: newHTTPClient()}, nil
+	case portainer.DiscoverySourceInventory:
+		return &inventorySource{url: config.URL, client: newHTTPClient()}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported discovery source type %q", config.Type)
+}
+
+func newHTTPClient() *http.Client {
+	return client.NewExternalClient(sourceRequestTimeout)
+}
+
+// dnsSource discovers the targets of a DNS SRV record, e.g. the tasks of a Swarm service
+type dnsSource struct {
+	record   string
+	resolver *net.Resolver
+}
+
+func (source *dnsSource) Discover(ctx context.Context) ([]Target, error) {
+	_, records, err := source.resolver.LookupSRV(ctx, "", "", source.record)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to resolve the SRV record %s", source.record)
+	}
+
+	targets := make([]Target, 0, len(records))
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+
+		targets = append(targets, Target{
+			Name:    host,
+			Address: net.JoinHostPort(host, strconv.Itoa(int(record.Port))),
+		})
+	}
+
+	return targets, nil
+}
+
+// consulSource discovers the instances of a service registered in the Consul catalog
+type consulSource struct {
+	url     string
+	service string
+	client  *http.Client
+}
+
+type consulCatalogService struct {
+	Node           string
+	Address        string
+	ServiceAddress string
+	ServicePort    int
+}
+
+func (source *consulSource) Discover(ctx context.Context) ([]Target, error) {
+	var services []consulCatalogService
+	if err := getJSON(ctx, source.client, source.url+"/v1/catalog/service/"+url.PathEscape(source.service), &services); err != nil {
+		return nil, err
+	}
+
+	targets := make([]Target, 0, len(services))
+	for _, service := range services {
+		host := service.ServiceAddress
+		if host == "" {
+			host = service.Address
+		}
+
+		targets = append(targets, Target{
+			Name:    service.Node,
+			Address: net.JoinHostPort(host, strconv.Itoa(service.ServicePort)),
+		})
+	}
+
+	return targets, nil
+}
+
+// inventorySource discovers the hosts listed by an inventory API, typically exposed on top of a cloud provider
+// API, which is expected to return a JSON array of targets
+type inventorySource struct {
+	url    string
+	client *http.Client
+}
+
+func (source *inventorySource) Discover(ctx context.Context) ([]Target, error) {
+	var targets []Target
+	if err := getJSON(ctx, source.client, source.url, &targets); err != nil {
+		return nil, err
+	}
+
+	for _, target := range targets {
+		if _, _, err := net.SplitHostPort(target.Address); err != nil {
+			return nil, errors.Wrapf(err, "invalid address for the target %s", target.Name)
+		}
+	}
+
+	return targets, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "unable to query %s", url)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
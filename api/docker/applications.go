@@ -0,0 +1,226 @@
+package docker
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/portainer/portainer/api/docker/consts"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	pkgerrors "github.com/pkg/errors"
+)
+
+// DefaultApplicationLabel is the label grouping the containers into applications when none is specified
+const DefaultApplicationLabel = consts.ComposeStackNameLabel
+
+const (
+	// ApplicationStatusHealthy is the status of the applications whose containers and services are all running and
+	// healthy
+	ApplicationStatusHealthy = "healthy"
+	// ApplicationStatusDegraded is the status of the applications running partially or with unhealthy containers
+	ApplicationStatusDegraded = "degraded"
+	// ApplicationStatusDown is the status of the applications without any running container or task
+	ApplicationStatusDown = "down"
+)
+
+type (
+	// Applications represents the containers and the services of an environment grouped by the value of a label
+	Applications struct {
+		// Label grouping the containers and the services
+		Label        string        `json:"Label" example:"com.docker.compose.project"`
+		Applications []Application `json:"Applications"`
+		// Number of containers without the label
+		UngroupedContainerCount int `json:"UngroupedContainerCount" example:"2"`
+	}
+
+	// Application represents the containers and the services sharing the same value of a label
+	Application struct {
+		// Value of the label
+		Name string `json:"Name" example:"web"`
+		// One of healthy, degraded or down
+		Status                  string                 `json:"Status" example:"healthy"`
+		ContainerCount          int                    `json:"ContainerCount" example:"3"`
+		RunningContainerCount   int                    `json:"RunningContainerCount" example:"3"`
+		StoppedContainerCount   int                    `json:"StoppedContainerCount" example:"0"`
+		HealthyContainerCount   int                    `json:"HealthyContainerCount" example:"2"`
+		UnhealthyContainerCount int                    `json:"UnhealthyContainerCount" example:"0"`
+		Images                  []string               `json:"Images"`
+		Containers              []ApplicationContainer `json:"Containers"`
+		Services                []ApplicationService   `json:"Services"`
+	}
+
+	// ApplicationContainer represents a container of an application
+	ApplicationContainer struct {
+		ID     string `json:"Id" example:"3a8b3e1c5f2d"`
+		Name   string `json:"Name" example:"web-nginx-1"`
+		Image  string `json:"Image" example:"nginx:1.25"`
+		State  string `json:"State" example:"running"`
+		Status string `json:"Status" example:"Up 2 hours (healthy)"`
+	}
+
+	// ApplicationService represents a Swarm service of an application
+	ApplicationService struct {
+		ID           string `json:"Id" example:"9mnpnzenvg8p"`
+		Name         string `json:"Name" example:"web_nginx"`
+		Image        string `json:"Image" example:"nginx:1.25"`
+		RunningTasks uint64 `json:"RunningTasks" example:"2"`
+		DesiredTasks uint64 `json:"DesiredTasks" example:"2"`
+	}
+)
+
+// ListApplicationResources lists the containers and, on a Swarm manager, the services holding the label
+func ListApplicationResources(ctx context.Context, cli *client.Client, label string) ([]types.Container, []swarm.Service, error) {
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, nil, pkgerrors.Wrap(err, "unable to list the containers")
+	}
+
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return nil, nil, pkgerrors.Wrap(err, "unable to retrieve the information of the environment")
+	}
+
+	if !info.Swarm.ControlAvailable {
+		return containers, nil, nil
+	}
+
+	services, err := cli.ServiceList(ctx, types.ServiceListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", label)),
+		Status:  true,
+	})
+	if err != nil {
+		return nil, nil, pkgerrors.Wrap(err, "unable to list the services")
+	}
+
+	return containers, services, nil
+}
+
+// GroupApplications groups the containers and the services by the value of the label
+func GroupApplications(label string, containers []types.Container, services []swarm.Service) *Applications {
+	result := &Applications{Label: label, Applications: []Application{}}
+	applications := make(map[string]*Application)
+
+	application := func(name string) *Application {
+		app, ok := applications[name]
+		if !ok {
+			app = &Application{Name: name, Images: []string{}, Containers: []ApplicationContainer{}, Services: []ApplicationService{}}
+			applications[name] = app
+		}
+
+		return app
+	}
+
+	for _, container := range containers {
+		name := container.Labels[label]
+		if name == "" {
+			result.UngroupedContainerCount++
+			continue
+		}
+
+		app := application(name)
+		app.ContainerCount++
+
+		switch container.State {
+		case "running":
+			app.RunningContainerCount++
+		case "exited", "stopped", "created", "dead":
+			app.StoppedContainerCount++
+		}
+
+		if strings.Contains(container.Status, "(healthy)") {
+			app.HealthyContainerCount++
+		} else if strings.Contains(container.Status, "(unhealthy)") {
+			app.UnhealthyContainerCount++
+		}
+
+		addImage(app, container.Image)
+
+		containerName := ""
+		if len(container.Names) > 0 {
+			containerName = strings.TrimPrefix(container.Names[0], "/")
+		}
+
+		app.Containers = append(app.Containers, ApplicationContainer{
+			ID:     container.ID,
+			Name:   containerName,
+			Image:  container.Image,
+			State:  container.State,
+			Status: container.Status,
+		})
+	}
+
+	for _, service := range services {
+		name := service.Spec.Labels[label]
+		if name == "" {
+			continue
+		}
+
+		app := application(name)
+
+		image := ""
+		if service.Spec.TaskTemplate.ContainerSpec != nil {
+			// the digest pinned by the Swarm manager is of no use in a summary
+			image, _, _ = strings.Cut(service.Spec.TaskTemplate.ContainerSpec.Image, "@")
+			addImage(app, image)
+		}
+
+		serviceUsage := ApplicationService{ID: service.ID, Name: service.Spec.Name, Image: image}
+		if service.ServiceStatus != nil {
+			serviceUsage.RunningTasks = service.ServiceStatus.RunningTasks
+			serviceUsage.DesiredTasks = service.ServiceStatus.DesiredTasks
+		}
+
+		app.Services = append(app.Services, serviceUsage)
+	}
+
+	for _, app := range applications {
+		app.Status = applicationStatus(app)
+
+		sort.Strings(app.Images)
+		sort.Slice(app.Containers, func(i, j int) bool { return app.Containers[i].Name < app.Containers[j].Name })
+		sort.Slice(app.Services, func(i, j int) bool { return app.Services[i].Name < app.Services[j].Name })
+
+		result.Applications = append(result.Applications, *app)
+	}
+
+	sort.Slice(result.Applications, func(i, j int) bool {
+		return result.Applications[i].Name < result.Applications[j].Name
+	})
+
+	return result
+}
+
+func addImage(app *Application, image string) {
+	if image != "" && !slices.Contains(app.Images, image) {
+		app.Images = append(app.Images, image)
+	}
+}
+
+func applicationStatus(app *Application) string {
+	running := app.RunningContainerCount > 0
+	complete := app.UnhealthyContainerCount == 0
+
+	// the containers of the previous tasks of the services remain stopped, the services report the expected tasks
+	if len(app.Services) == 0 {
+		complete = complete && app.RunningContainerCount == app.ContainerCount
+	}
+
+	for _, service := range app.Services {
+		running = running || service.RunningTasks > 0
+		complete = complete && service.RunningTasks >= service.DesiredTasks
+	}
+
+	switch {
+	case !running:
+		return ApplicationStatusDown
+	case !complete:
+		return ApplicationStatusDegraded
+	}
+
+	return ApplicationStatusHealthy
+}
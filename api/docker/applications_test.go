@@ -0,0 +1,65 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupApplications(t *testing.T) {
+	is := assert.New(t)
+
+	const label = "app"
+
+	containers := []types.Container{
+		{ID: "c1", Names: []string{"/shop-web-1"}, Image: "nginx:1.25", State: "running", Status: "Up 1 hour (healthy)", Labels: map[string]string{label: "shop"}},
+		{ID: "c2", Names: []string{"/shop-db-1"}, Image: "postgres:16", State: "running", Status: "Up 1 hour", Labels: map[string]string{label: "shop"}},
+		{ID: "c3", Names: []string{"/blog-web-1"}, Image: "ghost:5", State: "exited", Status: "Exited (1) 2 minutes ago", Labels: map[string]string{label: "blog"}},
+		{ID: "c4", Names: []string{"/api.1.x"}, Image: "api:2", State: "exited", Status: "Exited (0) 1 hour ago", Labels: map[string]string{label: "api"}},
+		{ID: "c5", Names: []string{"/standalone"}, Image: "busybox", State: "running"},
+	}
+
+	services := []swarm.Service{{
+		ID:            "s1",
+		Spec:          swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "api_api", Labels: map[string]string{label: "api"}}, TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "api:2@sha256:abc"}}},
+		ServiceStatus: &swarm.ServiceStatus{RunningTasks: 2, DesiredTasks: 2},
+	}}
+
+	result := GroupApplications(label, containers, services)
+
+	is.Equal(label, result.Label)
+	is.Equal(1, result.UngroupedContainerCount)
+	if !is.Len(result.Applications, 3) {
+		return
+	}
+
+	api, blog, shop := result.Applications[0], result.Applications[1], result.Applications[2]
+
+	// the containers of the previous tasks do not degrade the services running all their tasks
+	is.Equal("api", api.Name)
+	is.Equal(ApplicationStatusHealthy, api.Status)
+	is.Equal([]string{"api:2"}, api.Images)
+	is.Equal([]ApplicationService{{ID: "s1", Name: "api_api", Image: "api:2", RunningTasks: 2, DesiredTasks: 2}}, api.Services)
+
+	is.Equal("blog", blog.Name)
+	is.Equal(ApplicationStatusDown, blog.Status)
+	is.Equal(1, blog.StoppedContainerCount)
+
+	is.Equal("shop", shop.Name)
+	is.Equal(ApplicationStatusHealthy, shop.Status)
+	is.Equal(2, shop.RunningContainerCount)
+	is.Equal(1, shop.HealthyContainerCount)
+	is.Equal([]string{"nginx:1.25", "postgres:16"}, shop.Images)
+	is.Equal("shop-db-1", shop.Containers[0].Name)
+}
+
+func TestApplicationStatus(t *testing.T) {
+	is := assert.New(t)
+
+	is.Equal(ApplicationStatusDegraded, applicationStatus(&Application{ContainerCount: 2, RunningContainerCount: 1}))
+	is.Equal(ApplicationStatusDegraded, applicationStatus(&Application{ContainerCount: 1, RunningContainerCount: 1, UnhealthyContainerCount: 1}))
+	is.Equal(ApplicationStatusDegraded, applicationStatus(&Application{Services: []ApplicationService{{RunningTasks: 1, DesiredTasks: 3}}}))
+	is.Equal(ApplicationStatusDown, applicationStatus(&Application{Services: []ApplicationService{{DesiredTasks: 3}}}))
+}
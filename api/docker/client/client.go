@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/docker/docker/client"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/http/proxy/factory/agent"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/retries"
 )
 
 var errUnsupportedEnvironmentType = errors.New("Environment not supported")
@@ -23,6 +27,8 @@ const (
 type ClientFactory struct {
 	signatureService     portainer.DigitalSignatureService
 	reverseTunnelService portainer.ReverseTunnelService
+	sshDialers           map[portainer.EndpointID]*sshDialer
+	sshDialersMu         sync.Mutex
 }
 
 // NewClientFactory returns a new instance of a ClientFactory
@@ -30,6 +36,7 @@ func NewClientFactory(signatureService portainer.DigitalSignatureService, revers
 	return &ClientFactory{
 		signatureService:     signatureService,
 		reverseTunnelService: reverseTunnelService,
+		sshDialers:           make(map[portainer.EndpointID]*sshDialer),
 	}
 }
 
@@ -38,6 +45,10 @@ func NewClientFactory(signatureService portainer.DigitalSignatureService, revers
 // with an agent enabled environment(endpoint) to target a specific node in an agent cluster.
 // The underlying http client timeout may be specified, a default value is used otherwise.
 func (factory *ClientFactory) CreateClient(endpoint *portainer.Endpoint, nodeName string, timeout *time.Duration) (*client.Client, error) {
+	if endpointutils.IsDockerSSHEndpoint(endpoint) {
+		return factory.createSSHClient(endpoint, timeout)
+	}
+
 	switch endpoint.Type {
 	case portainer.AzureEnvironment:
 		return nil, errUnsupportedEnvironmentType
@@ -128,6 +139,11 @@ func createAgentClient(endpoint *portainer.Endpoint, signatureService portainer.
 		return nil, err
 	}
 
+	httpCli.Transport, err = agent.NewClusterTransport(httpCli.Transport, endpoint, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	signature, err := signatureService.CreateSignature(portainer.PortainerAgentSignatureMessage)
 	if err != nil {
 		return nil, err
@@ -158,6 +174,7 @@ func httpClient(endpoint *portainer.Endpoint, timeout *time.Duration) (*http.Cli
 		if err != nil {
 			return nil, err
 		}
+		crypto.PinPublicKeys(tlsConfig, endpoint.AgentPublicKeyPins)
 		transport.TLSClientConfig = tlsConfig
 	}
 
@@ -171,3 +188,18 @@ func httpClient(endpoint *portainer.Endpoint, timeout *time.Duration) (*http.Cli
 		Timeout:   clientTimeout,
 	}, nil
 }
+
+// EnableRetries retries the idempotent requests of the client failing with a transient connection error, the
+// retries being counted in the metrics of the subsystem
+func EnableRetries(cli *client.Client, subsystem string) error {
+	httpCli := cli.HTTPClient()
+
+	transport := httpCli.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	httpCli.Transport = retries.NewTransport(transport, subsystem)
+
+	return client.WithHTTPClient(httpCli)(cli)
+}
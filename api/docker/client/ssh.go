@@ -0,0 +1,286 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+	portainer "github.com/portainer/portainer/api"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	defaultSSHPort          = "22"
+	defaultDockerSocketPath = "/var/run/docker.sock"
+	sshConnectionTimeout    = 10 * time.Second
+	sshConnectionKeepAlive  = 30 * time.Second
+	sshDockerClientHost     = "http://docker.ssh"
+)
+
+var (
+	errInvalidSSHURL        = errors.New("invalid SSH URL, it must be formatted as ssh://user@host[:port]")
+	errUndefinedSSHConfig   = errors.New("the SSH configuration of the environment is not defined")
+	errUndefinedSSHHostKey  = errors.New("the host key of the SSH server is not defined")
+	errSSHHostKeyRetrieved  = errors.New("host key retrieved")
+	errSSHHostKeyNotPresent = errors.New("the SSH server did not present a host key")
+)
+
+// DialContextFunc opens a connection, as used by the HTTP transports
+type DialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// ParseSSHURL returns the user and the address of the SSH server of an environment(endpoint) URL, formatted as
+// ssh://user@host[:port]
+func ParseSSHURL(rawURL string) (string, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "ssh" || u.Hostname() == "" || u.User == nil || u.User.Username() == "" {
+		return "", "", errInvalidSSHURL
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = defaultSSHPort
+	}
+
+	return u.User.Username(), net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// FetchSSHHostKey returns the public key presented by the SSH server of an environment(endpoint) URL, in the
+// authorized_keys format. It is used to trust the server on the creation of the environment(endpoint).
+func FetchSSHHostKey(rawURL string) (string, error) {
+	user, address, err := ParseSSHURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	var hostKey ssh.PublicKey
+	config := &ssh.ClientConfig{
+		User: user,
+		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+
+			// The handshake is interrupted as the key is all that is needed
+			return errSSHHostKeyRetrieved
+		},
+		Timeout: sshConnectionTimeout,
+	}
+
+	conn, err := net.DialTimeout("tcp", address, sshConnectionTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	_, _, _, err = ssh.NewClientConn(conn, address, config)
+	if hostKey == nil {
+		if err == nil {
+			err = errSSHHostKeyNotPresent
+		}
+
+		return "", err
+	}
+
+	return string(bytes.TrimSpace(ssh.MarshalAuthorizedKey(hostKey))), nil
+}
+
+// SSHDialContext returns the function opening connections to the Docker socket of an environment(endpoint)
+// connected via SSH. The SSH connection is established on demand and shared by the connections to the
+// environment(endpoint), it is replaced when the configuration of the environment(endpoint) changes.
+func (factory *ClientFactory) SSHDialContext(endpoint *portainer.Endpoint) (DialContextFunc, error) {
+	dialer, err := newSSHDialer(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	factory.sshDialersMu.Lock()
+	defer factory.sshDialersMu.Unlock()
+
+	if existing, ok := factory.sshDialers[endpoint.ID]; ok {
+		if existing.signature == dialer.signature {
+			return existing.DialContext, nil
+		}
+
+		existing.Close()
+	}
+
+	factory.sshDialers[endpoint.ID] = dialer
+
+	return dialer.DialContext, nil
+}
+
+// CloseSSHConnection closes the SSH connection to an environment(endpoint), if any
+func (factory *ClientFactory) CloseSSHConnection(endpointID portainer.EndpointID) {
+	factory.sshDialersMu.Lock()
+	dialer, ok := factory.sshDialers[endpointID]
+	delete(factory.sshDialers, endpointID)
+	factory.sshDialersMu.Unlock()
+
+	if ok {
+		dialer.Close()
+	}
+}
+
+func (factory *ClientFactory) createSSHClient(endpoint *portainer.Endpoint, timeout *time.Duration) (*client.Client, error) {
+	dialContext, err := factory.SSHDialContext(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	clientTimeout := defaultDockerRequestTimeout
+	if timeout != nil {
+		clientTimeout = *timeout
+	}
+
+	return client.NewClientWithOpts(
+		client.WithHost(sshDockerClientHost),
+		client.WithAPIVersionNegotiation(),
+		client.WithHTTPClient(&http.Client{
+			Transport: &http.Transport{DialContext: dialContext},
+			Timeout:   clientTimeout,
+		}),
+	)
+}
+
+// sshDialer opens connections to the Docker socket of a host through a single SSH connection
+type sshDialer struct {
+	address    string
+	socketPath string
+	config     *ssh.ClientConfig
+	// signature identifies the configuration of the environment(endpoint) the dialer was created from
+	signature string
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+func newSSHDialer(endpoint *portainer.Endpoint) (*sshDialer, error) {
+	if endpoint.SSHConfig == nil {
+		return nil, errUndefinedSSHConfig
+	}
+
+	user, address, err := ParseSSHURL(endpoint.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint.SSHConfig.HostKey == "" {
+		return nil, errUndefinedSSHHostKey
+	}
+
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(endpoint.SSHConfig.HostKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid host key of the SSH server: %w", err)
+	}
+
+	privateKey, err := os.ReadFile(endpoint.SSHConfig.PrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ssh.ParsePrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SSH private key: %w", err)
+	}
+
+	socketPath := endpoint.SSHConfig.SocketPath
+	if socketPath == "" {
+		socketPath = defaultDockerSocketPath
+	}
+
+	return &sshDialer{
+		address:    address,
+		socketPath: socketPath,
+		config: &ssh.ClientConfig{
+			User:            user,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: ssh.FixedHostKey(hostKey),
+			Timeout:         sshConnectionTimeout,
+		},
+		signature: fmt.Sprintf("%s\n%s\n%s\n%s", endpoint.URL, endpoint.SSHConfig.HostKey, socketPath, privateKey),
+	}, nil
+}
+
+// DialContext opens a connection to the Docker socket, the network and address are the ones of the SSH server
+func (dialer *sshDialer) DialContext(ctx context.Context, _, _ string) (net.Conn, error) {
+	sshClient, err := dialer.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sshClient.Dial("unix", dialer.socketPath)
+	var openChannelErr *ssh.OpenChannelError
+	if err == nil || errors.As(err, &openChannelErr) {
+		return conn, err
+	}
+
+	// The SSH connection may have dropped without being noticed yet, it is established again once
+	dialer.disconnect(sshClient)
+
+	sshClient, err = dialer.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return sshClient.Dial("unix", dialer.socketPath)
+}
+
+func (dialer *sshDialer) connect(ctx context.Context) (*ssh.Client, error) {
+	dialer.mu.Lock()
+	defer dialer.mu.Unlock()
+
+	if dialer.client != nil {
+		return dialer.client, nil
+	}
+
+	netDialer := net.Dialer{Timeout: sshConnectionTimeout, KeepAlive: sshConnectionKeepAlive}
+	conn, err := netDialer.DialContext(ctx, "tcp", dialer.address)
+	if err != nil {
+		return nil, err
+	}
+
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, dialer.address, dialer.config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	sshClient := ssh.NewClient(sshConn, channels, requests)
+	dialer.client = sshClient
+
+	go func() {
+		sshClient.Wait()
+		dialer.disconnect(sshClient)
+	}()
+
+	return sshClient, nil
+}
+
+func (dialer *sshDialer) disconnect(sshClient *ssh.Client) {
+	dialer.mu.Lock()
+	if dialer.client == sshClient {
+		dialer.client = nil
+	}
+	dialer.mu.Unlock()
+
+	sshClient.Close()
+}
+
+// Close closes the SSH connection, the connections opened through it are closed as well
+func (dialer *sshDialer) Close() {
+	dialer.mu.Lock()
+	sshClient := dialer.client
+	dialer.client = nil
+	dialer.mu.Unlock()
+
+	if sshClient != nil {
+		sshClient.Close()
+	}
+}
@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+// testSSHServer is an SSH server forwarding the Unix socket connections, as done by OpenSSH
+type testSSHServer struct {
+	address     string
+	hostKey     string
+	connections atomic.Int32
+}
+
+func newTestSSHServer(t *testing.T, authorizedKey ssh.PublicKey) *testSSHServer {
+	_, hostPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	hostSigner, err := ssh.NewSignerFromKey(hostPrivateKey)
+	assert.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(authorizedKey.Marshal()) {
+				return nil, ssh.ErrNoAuth
+			}
+
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	server := &testSSHServer{
+		address: listener.Addr().String(),
+		hostKey: strings.TrimSpace(string(ssh.MarshalAuthorizedKey(hostSigner.PublicKey()))),
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go server.serve(conn, config)
+		}
+	}()
+
+	return server
+}
+
+func (server *testSSHServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	sshConn, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	defer sshConn.Close()
+
+	server.connections.Add(1)
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		var msg struct {
+			SocketPath string
+			Reserved0  string
+			Reserved1  uint32
+		}
+
+		if newChannel.ChannelType() != "direct-streamlocal@openssh.com" || ssh.Unmarshal(newChannel.ExtraData(), &msg) != nil {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel")
+			continue
+		}
+
+		socketConn, err := net.Dial("unix", msg.SocketPath)
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			socketConn.Close()
+			continue
+		}
+		go ssh.DiscardRequests(channelRequests)
+
+		go func() {
+			io.Copy(channel, socketConn)
+			channel.Close()
+		}()
+
+		go func() {
+			io.Copy(socketConn, channel)
+			socketConn.Close()
+		}()
+	}
+}
+
+// newTestDockerSocket serves a minimal Docker API on a Unix socket
+func newTestDockerSocket(t *testing.T) string {
+	socketPath := filepath.Join(t.TempDir(), "docker.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.41")
+		w.Write([]byte("OK"))
+	}))
+
+	return socketPath
+}
+
+func newTestSSHKey(t *testing.T) (string, ssh.PublicKey) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	assert.NoError(t, err)
+
+	keyPath := filepath.Join(t.TempDir(), "id_key")
+	err = os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	assert.NoError(t, err)
+
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	assert.NoError(t, err)
+
+	return keyPath, signer.PublicKey()
+}
+
+func TestParseSSHURL(t *testing.T) {
+	user, address, err := ParseSSHURL("ssh://docker@host.local")
+	assert.NoError(t, err)
+	assert.Equal(t, "docker", user)
+	assert.Equal(t, "host.local:22", address)
+
+	_, address, err = ParseSSHURL("ssh://docker@10.0.0.1:2222")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:2222", address)
+
+	for _, rawURL := range []string{"tcp://host.local:2375", "ssh://host.local", "ssh://docker@", "host.local"} {
+		_, _, err := ParseSSHURL(rawURL)
+		assert.Error(t, err, rawURL)
+	}
+}
+
+func TestFetchSSHHostKey(t *testing.T) {
+	_, publicKey := newTestSSHKey(t)
+	server := newTestSSHServer(t, publicKey)
+
+	hostKey, err := FetchSSHHostKey("ssh://docker@" + server.address)
+	assert.NoError(t, err)
+	assert.Equal(t, server.hostKey, hostKey)
+	assert.Zero(t, server.connections.Load(), "the handshake should be interrupted before the authentication")
+}
+
+func TestCreateClient_SSH(t *testing.T) {
+	keyPath, publicKey := newTestSSHKey(t)
+	server := newTestSSHServer(t, publicKey)
+
+	endpoint := &portainer.Endpoint{
+		ID:   1,
+		Type: portainer.DockerSSHEnvironment,
+		URL:  "ssh://docker@" + server.address,
+		SSHConfig: &portainer.SSHConfiguration{
+			PrivateKeyPath: keyPath,
+			HostKey:        server.hostKey,
+			SocketPath:     newTestDockerSocket(t),
+		},
+	}
+
+	factory := NewClientFactory(nil, nil)
+	t.Cleanup(func() { factory.CloseSSHConnection(endpoint.ID) })
+
+	for i := 0; i < 2; i++ {
+		cli, err := factory.CreateClient(endpoint, "", nil)
+		assert.NoError(t, err)
+
+		ping, err := cli.Ping(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "1.41", ping.APIVersion)
+	}
+
+	assert.Equal(t, int32(1), server.connections.Load(), "the SSH connection should be shared")
+
+	// The SSH connection is established again after being closed
+	factory.CloseSSHConnection(endpoint.ID)
+
+	cli, err := factory.CreateClient(endpoint, "", nil)
+	assert.NoError(t, err)
+
+	_, err = cli.Ping(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), server.connections.Load())
+}
+
+func TestCreateClient_SSHUnknownHostKey(t *testing.T) {
+	keyPath, publicKey := newTestSSHKey(t)
+	server := newTestSSHServer(t, publicKey)
+	otherServer := newTestSSHServer(t, publicKey)
+
+	endpoint := &portainer.Endpoint{
+		ID:   1,
+		Type: portainer.DockerSSHEnvironment,
+		URL:  "ssh://docker@" + server.address,
+		SSHConfig: &portainer.SSHConfiguration{
+			PrivateKeyPath: keyPath,
+			HostKey:        otherServer.hostKey,
+			SocketPath:     newTestDockerSocket(t),
+		},
+	}
+
+	factory := NewClientFactory(nil, nil)
+
+	cli, err := factory.CreateClient(endpoint, "", nil)
+	assert.NoError(t, err)
+
+	_, err = cli.Ping(context.Background())
+	assert.Error(t, err)
+	assert.Zero(t, server.connections.Load())
+}
@@ -6,3 +6,9 @@ const (
 	SwarmServiceIdLabel   = "com.docker.swarm.service.id"
 	SwarmNodeIdLabel      = "com.docker.swarm.node.id"
 )
+
+const (
+	AccessControlPublicLabel = "io.portainer.accesscontrol.public"
+	AccessControlTeamsLabel  = "io.portainer.accesscontrol.teams"
+	AccessControlUsersLabel  = "io.portainer.accesscontrol.users"
+)
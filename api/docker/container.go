@@ -13,6 +13,7 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
 	"github.com/portainer/portainer/api/docker/images"
+	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/rs/zerolog/log"
 )
 
@@ -30,8 +31,9 @@ func NewContainerService(factory *dockerclient.ClientFactory, dataStore dataserv
 	}
 }
 
-// Recreate a container
-func (c *ContainerService) Recreate(ctx context.Context, endpoint *portainer.Endpoint, containerId string, forcePullImage bool, imageTag, nodeName string) (*types.ContainerJSON, error) {
+// Recreate a container with the changes of the patch applied to its configuration, the networks and volumes of the
+// container are kept
+func (c *ContainerService) Recreate(ctx context.Context, endpoint *portainer.Endpoint, containerId string, forcePullImage bool, patch ContainerPatch, nodeName string) (*types.ContainerJSON, error) {
 	cli, err := c.factory.CreateClient(endpoint, nodeName, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "create client error")
@@ -56,10 +58,10 @@ func (c *ContainerService) Recreate(ctx context.Context, endpoint *portainer.End
 		return nil, errors.Wrap(err, "parse image error")
 	}
 
-	if imageTag != "" {
-		err = img.WithTag(imageTag)
+	if patch.ImageTag != "" {
+		err = img.WithTag(patch.ImageTag)
 		if err != nil {
-			return nil, errors.Wrapf(err, "set image tag error %s", imageTag)
+			return nil, errors.Wrapf(err, "set image tag error %s", patch.ImageTag)
 		}
 
 		log.Debug().Str("image", container.Config.Image).Msg("new image with tag")
@@ -67,6 +69,8 @@ func (c *ContainerService) Recreate(ctx context.Context, endpoint *portainer.End
 		container.Config.Image = img.FullName()
 	}
 
+	applyContainerPatch(&container, patch)
+
 	// 1. pull image if you need force pull
 	if forcePullImage {
 		puller := images.NewPuller(cli, images.NewRegistryClient(c.dataStore), c.dataStore)
@@ -183,6 +187,77 @@ func (c *ContainerService) Recreate(ctx context.Context, endpoint *portainer.End
 	return &newContainer, nil
 }
 
+// UpdateContainerReferences moves the webhook and the resource control of a recreated container to the new container
+func (c *ContainerService) UpdateContainerReferences(oldContainerID, newContainerID string) {
+	c.updateWebhook(oldContainerID, newContainerID)
+	c.createResourceControl(oldContainerID, newContainerID)
+}
+
+func (c *ContainerService) createResourceControl(oldContainerId string, newContainerId string) {
+	resourceControls, err := c.dataStore.ResourceControl().ReadAll()
+	if err != nil {
+		log.Error().Err(err).Msg("Exporting Resource Controls")
+		return
+	}
+
+	resourceControl := authorization.GetResourceControlByResourceIDAndType(oldContainerId, portainer.ContainerResourceControl, resourceControls)
+	if resourceControl == nil {
+		return
+	}
+	resourceControl.ResourceID = newContainerId
+	err = c.dataStore.ResourceControl().Create(resourceControl)
+	if err != nil {
+		log.Error().Err(err).Str("containerId", newContainerId).Msg("Failed to create new resource control for container")
+		return
+	}
+}
+
+// SyncResourceControlWithLabels replaces the resource control of the container by the one described by its ownership
+// labels. The resource control of the container is removed when its labels no longer set its ownership.
+func (c *ContainerService) SyncResourceControlWithLabels(containerID string, labels map[string]string) error {
+	resourceControls, err := c.dataStore.ResourceControl().ReadAll()
+	if err != nil {
+		return errors.Wrap(err, "unable to retrieve the resource controls")
+	}
+
+	var current *portainer.ResourceControl
+	for i := range resourceControls {
+		if resourceControls[i].ResourceID == containerID && resourceControls[i].Type == portainer.ContainerResourceControl {
+			current = &resourceControls[i]
+			break
+		}
+	}
+
+	resourceControl := authorization.ResourceControlFromLabels(c.dataStore, labels, containerID, portainer.ContainerResourceControl)
+
+	switch {
+	case current == nil && resourceControl == nil:
+		return nil
+	case current == nil:
+		return c.dataStore.ResourceControl().Create(resourceControl)
+	case resourceControl == nil:
+		return c.dataStore.ResourceControl().Delete(current.ID)
+	}
+
+	resourceControl.ID = current.ID
+
+	return c.dataStore.ResourceControl().Update(current.ID, resourceControl)
+}
+
+func (c *ContainerService) updateWebhook(oldContainerId string, newContainerId string) {
+	webhook, err := c.dataStore.Webhook().WebhookByResourceID(oldContainerId)
+	if err != nil {
+		log.Error().Err(err).Str("containerId", oldContainerId).Msg("cannot find webhook by containerId")
+		return
+	}
+
+	webhook.ResourceID = newContainerId
+	err = c.dataStore.Webhook().Update(webhook.ID, webhook)
+	if err != nil {
+		log.Error().Err(err).Int("webhookId", int(webhook.ID)).Msg("cannot update webhook")
+	}
+}
+
 type serviceRestore struct {
 	restoreC chan struct{}
 	fs       []func()
@@ -0,0 +1,63 @@
+package docker
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncResourceControlWithLabels(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	team := &portainer.Team{Name: "web"}
+	is.NoError(store.Team().Create(team))
+	user := &portainer.User{Username: "alice", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	is.NoError(store.ResourceControl().Create(&portainer.ResourceControl{ResourceID: "c1", Type: portainer.ContainerResourceControl,
+		UserAccesses: []portainer.UserResourceAccess{{UserID: 5, AccessLevel: portainer.ReadWriteAccessLevel}}}))
+
+	service := NewContainerService(nil, store)
+
+	containerResourceControl := func(containerID string) *portainer.ResourceControl {
+		resourceControls, err := store.ResourceControl().ReadAll()
+		is.NoError(err)
+
+		for i := range resourceControls {
+			if resourceControls[i].ResourceID == containerID {
+				return &resourceControls[i]
+			}
+		}
+
+		return nil
+	}
+
+	is.NoError(service.SyncResourceControlWithLabels("c1", map[string]string{
+		"io.portainer.accesscontrol.users": "alice, unknown",
+		"io.portainer.accesscontrol.teams": "web",
+	}))
+
+	resourceControl := containerResourceControl("c1")
+	if is.NotNil(resourceControl) {
+		is.Equal([]portainer.UserResourceAccess{{UserID: user.ID, AccessLevel: portainer.ReadWriteAccessLevel}}, resourceControl.UserAccesses)
+		is.Equal([]portainer.TeamResourceAccess{{TeamID: team.ID, AccessLevel: portainer.ReadWriteAccessLevel}}, resourceControl.TeamAccesses)
+	}
+
+	is.NoError(service.SyncResourceControlWithLabels("c1", map[string]string{"io.portainer.accesscontrol.public": ""}))
+	resourceControl = containerResourceControl("c1")
+	if is.NotNil(resourceControl) {
+		is.True(resourceControl.Public)
+		is.Empty(resourceControl.UserAccesses)
+	}
+
+	is.NoError(service.SyncResourceControlWithLabels("c1", map[string]string{"env": "prod"}))
+	is.Nil(containerResourceControl("c1"), "the resource control is removed with the ownership labels")
+
+	is.NoError(service.SyncResourceControlWithLabels("c2", map[string]string{"io.portainer.accesscontrol.teams": "web"}))
+	is.NotNil(containerResourceControl("c2"))
+}
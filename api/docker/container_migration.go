@@ -0,0 +1,339 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/images"
+
+	"github.com/docker/docker/api/types"
+	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// MigrationImageRegistry pulls the image of the migrated container from its registry on the target environment
+	MigrationImageRegistry = "registry"
+	// MigrationImageStream exports the image from the source environment and loads it on the target environment
+	// through the server, for the images which are not available in a registry
+	MigrationImageStream = "stream"
+)
+
+// ContainerMigration represents the migration of a container to another environment
+type ContainerMigration struct {
+	// Name of the new container, defaults to the name of the migrated container
+	Name string `json:"Name" example:"web"`
+	// Named volumes of the container whose contents are copied to the target environment, the other volumes are
+	// created empty
+	Volumes []string `json:"Volumes" example:"web-data"`
+	// Transfer of the image, registry or stream. Defaults to registry
+	ImageTransfer string `json:"ImageTransfer" example:"registry" enums:"registry,stream"`
+	// Whether the migrated container is stopped before its volumes are copied, so that their contents are consistent
+	StopSource bool `json:"StopSource" example:"true"`
+	// Whether the migrated container is removed once migrated, its volumes are kept
+	RemoveSource bool `json:"RemoveSource" example:"false"`
+	// Image of the helper containers mounting the volumes, defaults to alpine:latest
+	HelperImage string `json:"HelperImage" example:"alpine:latest"`
+	// Name of the Swarm node of the migrated container, for the agent environments
+	SourceNodeName string `json:"SourceNodeName" example:"node-1"`
+	// Name of the Swarm node of the new container, for the agent environments
+	TargetNodeName string `json:"TargetNodeName" example:"node-2"`
+}
+
+// Migrate recreates a container on another environment with its image, configuration and networks, and copies the
+// contents of the selected volumes. The image and the volumes are streamed through the server. The new container
+// is started when the migrated container was running, and is removed when the migration fails.
+func (c *ContainerService) Migrate(ctx context.Context, source, target *portainer.Endpoint, containerID string, migration ContainerMigration) (*types.ContainerJSON, error) {
+	srcCli, err := c.factory.CreateClient(source, migration.SourceNodeName, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to connect to the source environment")
+	}
+	defer srcCli.Close()
+
+	dstCli, err := c.factory.CreateClient(target, migration.TargetNodeName, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to connect to the target environment")
+	}
+	defer dstCli.Close()
+
+	container, _, err := srcCli.ContainerInspectWithRaw(ctx, containerID, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to inspect the container")
+	}
+
+	name := migration.Name
+	if name == "" {
+		name = strings.TrimPrefix(container.Name, "/")
+	}
+
+	if err := checkMigratedVolumes(&container, migration.Volumes); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("container_id", container.ID).
+		Int("source_endpoint_id", int(source.ID)).
+		Int("target_endpoint_id", int(target.ID)).
+		Msg("migrating the container")
+
+	if err := c.transferImage(ctx, srcCli, dstCli, container.Config.Image, migration.ImageTransfer); err != nil {
+		return nil, err
+	}
+
+	wasRunning := container.State != nil && container.State.Running
+	if wasRunning && (migration.StopSource || migration.RemoveSource) {
+		if err := srcCli.ContainerStop(ctx, container.ID, dockercontainer.StopOptions{}); err != nil {
+			return nil, errors.Wrap(err, "unable to stop the container")
+		}
+
+		defer func() {
+			if err != nil {
+				log.Debug().Str("container_id", container.ID).Msg("restarting the migrated container")
+				srcCli.ContainerStart(context.WithoutCancel(ctx), container.ID, types.ContainerStartOptions{})
+			}
+		}()
+	}
+
+	for _, volumeName := range migration.Volumes {
+		if err = copyVolume(ctx, srcCli, dstCli, volumeName, migration.HelperImage); err != nil {
+			return nil, err
+		}
+	}
+
+	endpointsConfig, err := migrateNetworks(ctx, srcCli, dstCli, &container)
+	if err != nil {
+		return nil, err
+	}
+
+	preserveAnonymousVolumes(&container)
+
+	// the host name defaults to the identifier of the container, which changes
+	if container.Config.Hostname == shortContainerID(container.ID) {
+		container.Config.Hostname = ""
+	}
+
+	networkingConfig := &network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{}}
+	for name, settings := range endpointsConfig {
+		// docker can connect to only one network at creation, see https://github.com/moby/moby/issues/17750
+		networkingConfig.EndpointsConfig[name] = settings
+		break
+	}
+
+	created, err := dstCli.ContainerCreate(ctx, container.Config, container.HostConfig, networkingConfig, nil, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the container on the target environment")
+	}
+
+	defer func() {
+		if err != nil {
+			log.Debug().Str("container_id", created.ID).Msg("removing the new container")
+			dstCli.ContainerRemove(context.WithoutCancel(ctx), created.ID, types.ContainerRemoveOptions{Force: true})
+		}
+	}()
+
+	for name, settings := range endpointsConfig {
+		if _, ok := networkingConfig.EndpointsConfig[name]; ok {
+			continue
+		}
+
+		if err = dstCli.NetworkConnect(ctx, name, created.ID, settings); err != nil {
+			return nil, errors.Wrapf(err, "unable to connect the container to the network %s", name)
+		}
+	}
+
+	if wasRunning {
+		if err = dstCli.ContainerStart(ctx, created.ID, types.ContainerStartOptions{}); err != nil {
+			return nil, errors.Wrap(err, "unable to start the container on the target environment")
+		}
+	}
+
+	newContainer, _, err := dstCli.ContainerInspectWithRaw(ctx, created.ID, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to inspect the new container")
+	}
+
+	c.createResourceControl(container.ID, newContainer.ID)
+
+	if migration.RemoveSource {
+		if err := srcCli.ContainerRemove(ctx, container.ID, types.ContainerRemoveOptions{}); err != nil {
+			log.Warn().Err(err).Str("container_id", container.ID).Msg("unable to remove the migrated container")
+		}
+	}
+
+	return &newContainer, nil
+}
+
+// checkMigratedVolumes ensures that the copied volumes are mounted by the container
+func checkMigratedVolumes(container *types.ContainerJSON, volumes []string) error {
+	for _, name := range volumes {
+		mounted := false
+		for _, m := range container.Mounts {
+			if m.Type == mount.TypeVolume && m.Name == name {
+				mounted = true
+				break
+			}
+		}
+
+		if !mounted {
+			return fmt.Errorf("the volume %s is not mounted by the container", name)
+		}
+	}
+
+	return nil
+}
+
+func (c *ContainerService) transferImage(ctx context.Context, srcCli, dstCli *client.Client, image, transfer string) error {
+	if transfer == MigrationImageStream {
+		content, err := srcCli.ImageSave(ctx, []string{image})
+		if err != nil {
+			return errors.Wrapf(err, "unable to export the image %s", image)
+		}
+		defer content.Close()
+
+		resp, err := dstCli.ImageLoad(ctx, content, true)
+		if err != nil {
+			return errors.Wrapf(err, "unable to load the image %s", image)
+		}
+		defer resp.Body.Close()
+
+		_, err = io.Copy(io.Discard, resp.Body)
+
+		return errors.Wrapf(err, "unable to load the image %s", image)
+	}
+
+	img, err := images.ParseImage(images.ParseImageOptions{Name: image})
+	if err != nil {
+		return errors.Wrapf(err, "unable to parse the image %s", image)
+	}
+
+	puller := images.NewPuller(dstCli, images.NewRegistryClient(c.dataStore), c.dataStore)
+	if err := puller.Pull(ctx, img); err != nil {
+		return errors.Wrapf(err, "unable to pull the image %s on the target environment", image)
+	}
+
+	return nil
+}
+
+// copyVolume streams the contents of a volume to a new volume of the target environment, created with the same
+// driver and labels. The volumes existing on the target environment are not overwritten.
+func copyVolume(ctx context.Context, srcCli, dstCli *client.Client, volumeName, helperImage string) error {
+	source, err := srcCli.VolumeInspect(ctx, volumeName)
+	if err != nil {
+		return errors.Wrapf(err, "unable to inspect the volume %s", volumeName)
+	}
+
+	_, err = dstCli.VolumeInspect(ctx, volumeName)
+	if err == nil {
+		return fmt.Errorf("the volume %s already exists on the target environment", volumeName)
+	} else if !client.IsErrNotFound(err) {
+		return errors.Wrapf(err, "unable to inspect the volume %s on the target environment", volumeName)
+	}
+
+	reader, writer := io.Pipe()
+	exported := make(chan error, 1)
+
+	go func() {
+		err := ExportVolume(ctx, srcCli, volumeName, helperImage, writer)
+		writer.CloseWithError(err)
+		exported <- err
+	}()
+
+	err = ImportVolume(ctx, dstCli, volume.CreateOptions{
+		Name:       volumeName,
+		Driver:     source.Driver,
+		DriverOpts: source.Options,
+		Labels:     source.Labels,
+	}, helperImage, reader)
+	reader.CloseWithError(err)
+
+	if exportErr := <-exported; exportErr != nil {
+		return exportErr
+	}
+
+	return err
+}
+
+// migrateNetworks creates the user-defined networks of the container missing on the target environment and returns
+// the settings connecting the new container to its networks, the addresses being kept only when static
+func migrateNetworks(ctx context.Context, srcCli, dstCli *client.Client, container *types.ContainerJSON) (map[string]*network.EndpointSettings, error) {
+	endpointsConfig := map[string]*network.EndpointSettings{}
+
+	for name, settings := range container.NetworkSettings.Networks {
+		if !isPredefinedNetwork(name) {
+			if err := ensureNetwork(ctx, srcCli, dstCli, name); err != nil {
+				return nil, err
+			}
+		}
+
+		aliases := make([]string, 0, len(settings.Aliases))
+		for _, alias := range settings.Aliases {
+			if alias != shortContainerID(container.ID) {
+				aliases = append(aliases, alias)
+			}
+		}
+
+		endpointsConfig[name] = &network.EndpointSettings{
+			IPAMConfig: settings.IPAMConfig,
+			Links:      settings.Links,
+			Aliases:    aliases,
+			DriverOpts: settings.DriverOpts,
+		}
+	}
+
+	return endpointsConfig, nil
+}
+
+func ensureNetwork(ctx context.Context, srcCli, dstCli *client.Client, name string) error {
+	_, err := dstCli.NetworkInspect(ctx, name, types.NetworkInspectOptions{})
+	if err == nil {
+		return nil
+	} else if !client.IsErrNotFound(err) {
+		return errors.Wrapf(err, "unable to inspect the network %s on the target environment", name)
+	}
+
+	source, err := srcCli.NetworkInspect(ctx, name, types.NetworkInspectOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "unable to inspect the network %s", name)
+	}
+
+	ipam := source.IPAM
+	_, err = dstCli.NetworkCreate(ctx, name, types.NetworkCreate{
+		CheckDuplicate: true,
+		Driver:         source.Driver,
+		Scope:          source.Scope,
+		EnableIPv6:     source.EnableIPv6,
+		IPAM:           &ipam,
+		Internal:       source.Internal,
+		Attachable:     source.Attachable,
+		Options:        source.Options,
+		Labels:         source.Labels,
+	})
+
+	return errors.Wrapf(err, "unable to create the network %s on the target environment", name)
+}
+
+// isPredefinedNetwork returns whether the network is created by the Docker daemon
+func isPredefinedNetwork(name string) bool {
+	switch name {
+	case "bridge", "host", "none", "ingress", "docker_gwbridge":
+		return true
+	}
+
+	return false
+}
+
+func shortContainerID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+
+	return id
+}
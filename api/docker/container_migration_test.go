@@ -0,0 +1,36 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckMigratedVolumes(t *testing.T) {
+	container := &types.ContainerJSON{
+		Mounts: []types.MountPoint{
+			{Type: mount.TypeVolume, Name: "data"},
+			{Type: mount.TypeBind, Source: "/srv/config"},
+		},
+	}
+
+	assert.NoError(t, checkMigratedVolumes(container, nil))
+	assert.NoError(t, checkMigratedVolumes(container, []string{"data"}))
+	assert.Error(t, checkMigratedVolumes(container, []string{"data", "logs"}))
+	assert.Error(t, checkMigratedVolumes(container, []string{"/srv/config"}))
+}
+
+func TestIsPredefinedNetwork(t *testing.T) {
+	for _, name := range []string{"bridge", "host", "none", "ingress", "docker_gwbridge"} {
+		assert.True(t, isPredefinedNetwork(name), name)
+	}
+
+	assert.False(t, isPredefinedNetwork("frontend"))
+}
+
+func TestShortContainerID(t *testing.T) {
+	assert.Equal(t, "4e4f7d9c8f2a", shortContainerID("4e4f7d9c8f2a9b1c3d5e7f"))
+	assert.Equal(t, "abc", shortContainerID("abc"))
+}
@@ -0,0 +1,160 @@
+package docker
+
+import (
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+)
+
+// ContainerPatch represents the changes applied to the configuration of a container when it is recreated
+type ContainerPatch struct {
+	// Tag of the image of the new container, the current tag is kept when empty
+	ImageTag string `json:"ImageTag" example:"1.25"`
+	// Environment variables set on the new container, replacing the variables with the same name
+	Env map[string]string `json:"Env"`
+	// Names of the environment variables removed from the new container
+	RemoveEnv []string `json:"RemoveEnv" example:"DEBUG"`
+	// Labels set on the new container, replacing the labels with the same name
+	Labels map[string]string `json:"Labels"`
+	// Names of the labels removed from the new container
+	RemoveLabels []string `json:"RemoveLabels" example:"com.example.version"`
+	// Mounts added to the new container, replacing the mounts with the same target
+	Mounts []mount.Mount `json:"Mounts"`
+	// Targets of the mounts removed from the new container
+	RemoveMounts []string `json:"RemoveMounts" example:"/var/cache"`
+	// Restart policy of the new container, the current policy is kept when empty
+	RestartPolicy *container.RestartPolicy `json:"RestartPolicy"`
+}
+
+// applyContainerPatch changes the configuration of an inspected container before it is recreated. The anonymous
+// volumes of the container are mounted by name, so that the new container keeps their data.
+func applyContainerPatch(container *types.ContainerJSON, patch ContainerPatch) {
+	preserveAnonymousVolumes(container)
+
+	if len(patch.Env) > 0 || len(patch.RemoveEnv) > 0 {
+		container.Config.Env = patchEnv(container.Config.Env, patch.Env, patch.RemoveEnv)
+	}
+
+	if len(patch.Labels) > 0 || len(patch.RemoveLabels) > 0 {
+		if container.Config.Labels == nil {
+			container.Config.Labels = map[string]string{}
+		}
+
+		for _, name := range patch.RemoveLabels {
+			delete(container.Config.Labels, name)
+		}
+
+		for name, value := range patch.Labels {
+			container.Config.Labels[name] = value
+		}
+	}
+
+	removedTargets := append([]string{}, patch.RemoveMounts...)
+	for _, m := range patch.Mounts {
+		removedTargets = append(removedTargets, m.Target)
+	}
+
+	if len(removedTargets) > 0 {
+		removeMounts(container, removedTargets)
+		container.HostConfig.Mounts = append(container.HostConfig.Mounts, patch.Mounts...)
+	}
+
+	if patch.RestartPolicy != nil {
+		container.HostConfig.RestartPolicy = *patch.RestartPolicy
+	}
+}
+
+func patchEnv(env []string, set map[string]string, remove []string) []string {
+	patched := make([]string, 0, len(env)+len(set))
+
+	for _, variable := range env {
+		name, _, _ := strings.Cut(variable, "=")
+		if _, ok := set[name]; ok || contains(remove, name) {
+			continue
+		}
+
+		patched = append(patched, variable)
+	}
+
+	for name, value := range set {
+		patched = append(patched, name+"="+value)
+	}
+
+	return patched
+}
+
+// preserveAnonymousVolumes mounts the volumes created for the image volumes by name
+func preserveAnonymousVolumes(container *types.ContainerJSON) {
+	for _, mountPoint := range container.Mounts {
+		if mountPoint.Type != mount.TypeVolume || mountPoint.Name == "" || isMounted(container, mountPoint.Destination) {
+			continue
+		}
+
+		container.HostConfig.Mounts = append(container.HostConfig.Mounts, mount.Mount{
+			Type:     mount.TypeVolume,
+			Source:   mountPoint.Name,
+			Target:   mountPoint.Destination,
+			ReadOnly: !mountPoint.RW,
+		})
+	}
+}
+
+func isMounted(container *types.ContainerJSON, target string) bool {
+	for _, bind := range container.HostConfig.Binds {
+		if bindTarget(bind) == target {
+			return true
+		}
+	}
+
+	for _, m := range container.HostConfig.Mounts {
+		if m.Target == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+func removeMounts(container *types.ContainerJSON, targets []string) {
+	binds := make([]string, 0, len(container.HostConfig.Binds))
+	for _, bind := range container.HostConfig.Binds {
+		if !contains(targets, bindTarget(bind)) {
+			binds = append(binds, bind)
+		}
+	}
+	container.HostConfig.Binds = binds
+
+	mounts := make([]mount.Mount, 0, len(container.HostConfig.Mounts))
+	for _, m := range container.HostConfig.Mounts {
+		if !contains(targets, m.Target) {
+			mounts = append(mounts, m)
+		}
+	}
+	container.HostConfig.Mounts = mounts
+
+	for _, target := range targets {
+		delete(container.Config.Volumes, target)
+	}
+}
+
+// bindTarget returns the path in the container of a bind, formatted as source:target[:options]
+func bindTarget(bind string) string {
+	parts := strings.Split(bind, ":")
+	if len(parts) < 2 {
+		return bind
+	}
+
+	return parts[1]
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
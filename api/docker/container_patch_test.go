@@ -0,0 +1,72 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newInspectedContainer() *types.ContainerJSON {
+	return &types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			HostConfig: &container.HostConfig{
+				Binds: []string{"/srv/config:/etc/app:ro", "logs:/var/log/app"},
+			},
+		},
+		Mounts: []types.MountPoint{
+			{Type: mount.TypeBind, Source: "/srv/config", Destination: "/etc/app"},
+			{Type: mount.TypeVolume, Name: "logs", Destination: "/var/log/app", RW: true},
+			{Type: mount.TypeVolume, Name: "3f5c0a1b", Destination: "/var/lib/app", RW: true},
+			{Type: mount.TypeVolume, Name: "7d2e9c4f", Destination: "/var/cache", RW: true},
+		},
+		Config: &container.Config{
+			Env:     []string{"PATH=/usr/bin", "DEBUG=1", "MODE=dev"},
+			Labels:  map[string]string{"com.example.team": "web", "com.example.version": "1"},
+			Volumes: map[string]struct{}{"/var/lib/app": {}, "/var/cache": {}},
+		},
+	}
+}
+
+func Test_applyContainerPatch(t *testing.T) {
+	is := assert.New(t)
+
+	c := newInspectedContainer()
+	applyContainerPatch(c, ContainerPatch{
+		Env:           map[string]string{"MODE": "prod", "WORKERS": "4"},
+		RemoveEnv:     []string{"DEBUG"},
+		Labels:        map[string]string{"com.example.team": "platform"},
+		RemoveLabels:  []string{"com.example.version"},
+		Mounts:        []mount.Mount{{Type: mount.TypeBind, Source: "/srv/config-v2", Target: "/etc/app", ReadOnly: true}},
+		RemoveMounts:  []string{"/var/cache"},
+		RestartPolicy: &container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3},
+	})
+
+	is.ElementsMatch([]string{"PATH=/usr/bin", "MODE=prod", "WORKERS=4"}, c.Config.Env)
+	is.Equal(map[string]string{"com.example.team": "platform"}, c.Config.Labels)
+	is.Equal([]string{"logs:/var/log/app"}, c.HostConfig.Binds)
+	is.Equal([]mount.Mount{
+		{Type: mount.TypeVolume, Source: "3f5c0a1b", Target: "/var/lib/app"},
+		{Type: mount.TypeBind, Source: "/srv/config-v2", Target: "/etc/app", ReadOnly: true},
+	}, c.HostConfig.Mounts)
+	is.Equal(map[string]struct{}{"/var/lib/app": {}}, c.Config.Volumes)
+	is.Equal(container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3}, c.HostConfig.RestartPolicy)
+}
+
+func Test_applyContainerPatch_Empty(t *testing.T) {
+	is := assert.New(t)
+
+	c := newInspectedContainer()
+	applyContainerPatch(c, ContainerPatch{})
+
+	is.Equal([]string{"PATH=/usr/bin", "DEBUG=1", "MODE=dev"}, c.Config.Env)
+	is.Len(c.Config.Labels, 2)
+	is.Equal([]string{"/srv/config:/etc/app:ro", "logs:/var/log/app"}, c.HostConfig.Binds)
+	is.Equal([]mount.Mount{
+		{Type: mount.TypeVolume, Source: "3f5c0a1b", Target: "/var/lib/app"},
+		{Type: mount.TypeVolume, Source: "7d2e9c4f", Target: "/var/cache"},
+	}, c.HostConfig.Mounts, "the anonymous volumes are kept")
+}
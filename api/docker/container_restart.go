@@ -0,0 +1,24 @@
+package docker
+
+import (
+	"context"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/timeouts"
+
+	dockercontainer "github.com/docker/docker/api/types/container"
+)
+
+// RestartContainer restarts a container of a specific Docker environment(endpoint), used to recover the containers
+// whose health check keeps failing
+func (snapshotter *Snapshotter) RestartContainer(endpoint *portainer.Endpoint, containerID string) error {
+	timeout := timeouts.Current().Snapshot
+
+	cli, err := snapshotter.clientFactory.CreateClient(endpoint, "", &timeout)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	return cli.ContainerRestart(context.Background(), containerID, dockercontainer.StopOptions{})
+}
@@ -0,0 +1,137 @@
+package docker
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// contextMetaFile is the metadata of the context at the root of the archives of docker context export
+	contextMetaFile = "meta.json"
+	// contextTLSDirectory holds the TLS material of the Docker endpoint of the context
+	contextTLSDirectory = "tls/docker"
+	// maxContextFileSize limits the size of the files read from the archives
+	maxContextFileSize = 1 << 20
+)
+
+type (
+	// DockerContext represents the Docker endpoint of a context exported with docker context export
+	DockerContext struct {
+		Name        string
+		Description string
+		// Address of the Docker daemon, tcp://, unix:// or npipe://
+		Host          string
+		SkipTLSVerify bool
+		TLSCACert     []byte
+		TLSCert       []byte
+		TLSKey        []byte
+	}
+
+	contextMetadata struct {
+		Name     string
+		Metadata struct {
+			Description string
+		}
+		Endpoints map[string]struct {
+			Host          string
+			SkipTLSVerify bool
+		}
+	}
+)
+
+// ParseContextExport reads the archive produced by docker context export, made of the meta.json metadata of the
+// context and the TLS material of its endpoints
+func ParseContextExport(r io.Reader) (*DockerContext, error) {
+	var meta []byte
+	files := map[string][]byte{}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "invalid context archive")
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if header.Size > maxContextFileSize {
+			return nil, fmt.Errorf("the file %s of the context archive is too large", header.Name)
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read the file %s of the context archive", header.Name)
+		}
+
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if name == contextMetaFile {
+			meta = content
+		} else if path.Dir(name) == contextTLSDirectory {
+			files[path.Base(name)] = content
+		}
+	}
+
+	if meta == nil {
+		return nil, errors.New("the context archive has no meta.json file")
+	}
+
+	return parseContextMetadata(meta, files)
+}
+
+func parseContextMetadata(meta []byte, tlsFiles map[string][]byte) (*DockerContext, error) {
+	var metadata contextMetadata
+	if err := json.Unmarshal(meta, &metadata); err != nil {
+		return nil, errors.Wrap(err, "invalid meta.json file")
+	}
+
+	endpoint, ok := metadata.Endpoints["docker"]
+	if !ok || endpoint.Host == "" {
+		return nil, fmt.Errorf("the context %s has no Docker endpoint", metadata.Name)
+	}
+
+	if metadata.Name == "" {
+		return nil, errors.New("the context has no name")
+	}
+
+	scheme, _, _ := strings.Cut(endpoint.Host, "://")
+	switch scheme {
+	case "tcp", "unix", "npipe":
+	default:
+		return nil, fmt.Errorf("the host %s of the context %s is not supported, tcp://, unix:// or npipe:// is expected", endpoint.Host, metadata.Name)
+	}
+
+	dockerContext := &DockerContext{
+		Name:          metadata.Name,
+		Description:   metadata.Metadata.Description,
+		Host:          endpoint.Host,
+		SkipTLSVerify: endpoint.SkipTLSVerify,
+		TLSCACert:     tlsFiles["ca.pem"],
+		TLSCert:       tlsFiles["cert.pem"],
+		TLSKey:        tlsFiles["key.pem"],
+	}
+
+	if (dockerContext.TLSCert == nil) != (dockerContext.TLSKey == nil) {
+		return nil, fmt.Errorf("the context %s must include both the client certificate and its key", metadata.Name)
+	}
+
+	if dockerContext.TLS() && !dockerContext.SkipTLSVerify && dockerContext.TLSCACert == nil {
+		return nil, fmt.Errorf("the context %s has no CA certificate to verify the host", metadata.Name)
+	}
+
+	return dockerContext, nil
+}
+
+// TLS returns whether the connections to the host of the context are secured
+func (c *DockerContext) TLS() bool {
+	return c.SkipTLSVerify || c.TLSCACert != nil || c.TLSCert != nil
+}
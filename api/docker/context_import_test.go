@@ -0,0 +1,80 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func contextArchive(t *testing.T, files map[string]string) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+
+	for name, content := range files {
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		assert.NoError(t, err)
+
+		_, err = tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, tw.Close())
+
+	return buf
+}
+
+func TestParseContextExport(t *testing.T) {
+	archive := contextArchive(t, map[string]string{
+		"meta.json":           `{"Name":"remote","Metadata":{"Description":"staging"},"Endpoints":{"docker":{"Host":"tcp://10.0.0.5:2376","SkipTLSVerify":false}}}`,
+		"tls/docker/ca.pem":   "ca",
+		"tls/docker/cert.pem": "cert",
+		"tls/docker/key.pem":  "key",
+	})
+
+	dockerContext, err := ParseContextExport(archive)
+	assert.NoError(t, err)
+	assert.Equal(t, "remote", dockerContext.Name)
+	assert.Equal(t, "staging", dockerContext.Description)
+	assert.Equal(t, "tcp://10.0.0.5:2376", dockerContext.Host)
+	assert.True(t, dockerContext.TLS())
+	assert.Equal(t, []byte("ca"), dockerContext.TLSCACert)
+	assert.Equal(t, []byte("cert"), dockerContext.TLSCert)
+	assert.Equal(t, []byte("key"), dockerContext.TLSKey)
+}
+
+func TestParseContextExport_Unsecured(t *testing.T) {
+	archive := contextArchive(t, map[string]string{
+		"meta.json": `{"Name":"local","Endpoints":{"docker":{"Host":"unix:///var/run/docker.sock"}}}`,
+	})
+
+	dockerContext, err := ParseContextExport(archive)
+	assert.NoError(t, err)
+	assert.False(t, dockerContext.TLS())
+}
+
+func TestParseContextExport_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"no metadata", map[string]string{"tls/docker/ca.pem": "ca"}},
+		{"no docker endpoint", map[string]string{"meta.json": `{"Name":"k8s","Endpoints":{"kubernetes":{}}}`}},
+		{"ssh host", map[string]string{"meta.json": `{"Name":"ssh","Endpoints":{"docker":{"Host":"ssh://user@host"}}}`}},
+		{"no CA certificate", map[string]string{
+			"meta.json":           `{"Name":"remote","Endpoints":{"docker":{"Host":"tcp://10.0.0.5:2376"}}}`,
+			"tls/docker/cert.pem": "cert",
+			"tls/docker/key.pem":  "key",
+		}},
+		{"no client key", map[string]string{
+			"meta.json":           `{"Name":"remote","Endpoints":{"docker":{"Host":"tcp://10.0.0.5:2376","SkipTLSVerify":true}}}`,
+			"tls/docker/cert.pem": "cert",
+		}},
+	}
+
+	for _, test := range tests {
+		_, err := ParseContextExport(contextArchive(t, test.files))
+		assert.Error(t, err, test.name)
+	}
+}
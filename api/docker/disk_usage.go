@@ -0,0 +1,74 @@
+package docker
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/timeouts"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+// CreateDiskUsage collects the disk space used by the volumes and by the writable layers of the containers of a
+// specific Docker environment(endpoint). It is expensive for the engine, which has to walk the volumes and the
+// layers, and is therefore collected less often than the snapshots.
+func (snapshotter *Snapshotter) CreateDiskUsage(endpoint *portainer.Endpoint) (*portainer.DockerDiskUsage, error) {
+	timeout := timeouts.Current().Snapshot
+
+	cli, err := snapshotter.clientFactory.CreateClient(endpoint, "", &timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	return diskUsage(cli)
+}
+
+func diskUsage(cli *client.Client) (*portainer.DockerDiskUsage, error) {
+	df, err := cli.DiskUsage(context.Background(), types.DiskUsageOptions{
+		Types: []types.DiskUsageObject{types.VolumeObject, types.ContainerObject},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &portainer.DockerDiskUsage{
+		Time:       time.Now().Unix(),
+		Volumes:    make([]portainer.DockerVolumeUsage, 0, len(df.Volumes)),
+		Containers: make([]portainer.DockerContainerUsage, 0, len(df.Containers)),
+	}
+
+	for _, volume := range df.Volumes {
+		volumeUsage := portainer.DockerVolumeUsage{Name: volume.Name, Driver: volume.Driver, Size: -1, RefCount: -1}
+		if volume.UsageData != nil {
+			volumeUsage.Size = volume.UsageData.Size
+			volumeUsage.RefCount = volume.UsageData.RefCount
+		}
+
+		if volumeUsage.Size > 0 {
+			usage.VolumesSize += volumeUsage.Size
+		}
+
+		usage.Volumes = append(usage.Volumes, volumeUsage)
+	}
+
+	for _, container := range df.Containers {
+		name := ""
+		if len(container.Names) > 0 {
+			name = strings.TrimPrefix(container.Names[0], "/")
+		}
+
+		usage.ContainersSize += container.SizeRw
+		usage.Containers = append(usage.Containers, portainer.DockerContainerUsage{
+			ID:         container.ID,
+			Name:       name,
+			SizeRw:     container.SizeRw,
+			SizeRootFs: container.SizeRootFs,
+		})
+	}
+
+	return usage, nil
+}
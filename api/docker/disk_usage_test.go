@@ -0,0 +1,39 @@
+package docker
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_diskUsage(t *testing.T) {
+	is := assert.New(t)
+
+	engine := &fakeEngine{}
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(server.URL, "http://")), client.WithVersion("1.41"))
+	is.NoError(err)
+	defer cli.Close()
+
+	usage, err := diskUsage(cli)
+	if !is.NoError(err) {
+		return
+	}
+
+	is.Equal(int64(2048), usage.VolumesSize)
+	is.Equal(int64(512), usage.ContainersSize)
+	is.Equal([]portainer.DockerVolumeUsage{
+		{Name: "data", Driver: "local", Size: 2048, RefCount: 1},
+		{Name: "nfs", Driver: "nfs", Size: -1, RefCount: -1},
+	}, usage.Volumes)
+	is.Equal([]portainer.DockerContainerUsage{
+		{ID: "c1", Name: "web", SizeRw: 512, SizeRootFs: 4096},
+	}, usage.Containers)
+}
@@ -0,0 +1,319 @@
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/containers/image/v5/docker/reference"
+	containersimage "github.com/containers/image/v5/image"
+	"github.com/containers/image/v5/manifest"
+	"github.com/containers/image/v5/pkg/blobinfocache/none"
+	imagetypes "github.com/containers/image/v5/types"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/opencontainers/go-digest"
+	pkgerrors "github.com/pkg/errors"
+)
+
+// progressInterval is the minimum interval between two progress reports of a blob transfer
+const progressInterval = 500 * time.Millisecond
+
+// ProgressFunc receives the progress of an image copy, formatted as the Docker JSON messages
+type ProgressFunc func(message jsonmessage.JSONMessage)
+
+// RegistryImage represents an image stored in a registry configured in Portainer
+type RegistryImage struct {
+	Registry *portainer.Registry
+	// Repository and tag or digest of the image in the registry, such as app/api:1.4.2
+	Name string
+}
+
+// Reference returns the fully qualified name of the image, prefixed with the address of its registry
+func (image RegistryImage) Reference() string {
+	address := strings.TrimPrefix(strings.TrimPrefix(image.Registry.URL, "https://"), "http://")
+
+	return strings.TrimSuffix(address, "/") + "/" + strings.TrimPrefix(image.Name, "/")
+}
+
+// ValidateImageName checks the name of an image in a registry. The pushed images must be tagged, while the source
+// images can also be referenced by digest.
+func ValidateImageName(name string, requireTag bool) error {
+	named, err := reference.ParseNormalizedNamed("registry.local/" + strings.TrimPrefix(name, "/"))
+	if err != nil {
+		return pkgerrors.Wrapf(err, "invalid image name %q", name)
+	}
+
+	if _, ok := named.(reference.Tagged); ok {
+		return nil
+	}
+
+	if _, ok := named.(reference.Digested); ok && !requireTag {
+		return nil
+	}
+
+	return fmt.Errorf("invalid image name %q, a tag is required", name)
+}
+
+// Copier copies the images between the registries, either directly from registry to registry or through the Docker
+// daemon of an environment
+type Copier struct {
+	registryClient *RegistryClient
+}
+
+// NewCopier creates an image copier authenticating with the credentials of the registries
+func NewCopier(registryClient *RegistryClient) *Copier {
+	return &Copier{registryClient: registryClient}
+}
+
+// CopyBetweenRegistries transfers the manifests and the blobs of the image from the source registry to the target
+// registry, the blobs already present in the target registry are not transferred
+func (copier *Copier) CopyBetweenRegistries(ctx context.Context, source, target RegistryImage, progress ProgressFunc) error {
+	srcRef, err := ParseReference(source.Reference())
+	if err != nil {
+		return pkgerrors.Wrap(err, "cannot parse the source image reference")
+	}
+
+	destRef, err := ParseReference(target.Reference())
+	if err != nil {
+		return pkgerrors.Wrap(err, "cannot parse the target image reference")
+	}
+
+	src, err := srcRef.NewImageSource(ctx, copier.systemContext(source.Registry))
+	if err != nil {
+		return pkgerrors.Wrapf(err, "unable to read the image %s", source.Reference())
+	}
+	defer src.Close()
+
+	dest, err := destRef.NewImageDestination(ctx, copier.systemContext(target.Registry))
+	if err != nil {
+		return pkgerrors.Wrapf(err, "unable to write the image %s", target.Reference())
+	}
+	defer dest.Close()
+
+	manifestBlob, mimeType, err := src.GetManifest(ctx, nil)
+	if err != nil {
+		return pkgerrors.Wrapf(err, "unable to read the manifest of the image %s", source.Reference())
+	}
+
+	if manifest.MIMETypeIsMultiImage(mimeType) {
+		list, err := manifest.ListFromBlob(manifestBlob, mimeType)
+		if err != nil {
+			return pkgerrors.Wrap(err, "unable to parse the manifest list")
+		}
+
+		for _, instance := range list.Instances() {
+			instance := instance
+
+			instanceBlob, instanceType, err := src.GetManifest(ctx, &instance)
+			if err != nil {
+				return pkgerrors.Wrapf(err, "unable to read the manifest %s", instance)
+			}
+
+			if err := copyBlobs(ctx, src, dest, instanceBlob, instanceType, progress); err != nil {
+				return err
+			}
+
+			if err := dest.PutManifest(ctx, instanceBlob, &instance); err != nil {
+				return pkgerrors.Wrapf(err, "unable to write the manifest %s", instance)
+			}
+		}
+	} else if err := copyBlobs(ctx, src, dest, manifestBlob, mimeType, progress); err != nil {
+		return err
+	}
+
+	if err := dest.PutManifest(ctx, manifestBlob, nil); err != nil {
+		return pkgerrors.Wrapf(err, "unable to write the manifest of the image %s", target.Reference())
+	}
+
+	if err := dest.Commit(ctx, containersimage.UnparsedInstance(src, nil)); err != nil {
+		return err
+	}
+
+	manifestDigest, err := manifest.Digest(manifestBlob)
+	if err == nil {
+		progress(jsonmessage.JSONMessage{Status: fmt.Sprintf("%s: digest: %s", target.Reference(), manifestDigest)})
+	}
+
+	return nil
+}
+
+func copyBlobs(ctx context.Context, src imagetypes.ImageSource, dest imagetypes.ImageDestination, manifestBlob []byte, mimeType string, progress ProgressFunc) error {
+	m, err := manifest.FromBlob(manifestBlob, mimeType)
+	if err != nil {
+		return pkgerrors.Wrap(err, "unable to parse the image manifest")
+	}
+
+	copied := map[digest.Digest]bool{}
+
+	if config := m.ConfigInfo(); config.Digest != "" {
+		if err := copyBlob(ctx, src, dest, config, true, progress); err != nil {
+			return err
+		}
+
+		copied[config.Digest] = true
+	}
+
+	for _, layer := range m.LayerInfos() {
+		if copied[layer.Digest] {
+			continue
+		}
+
+		if err := copyBlob(ctx, src, dest, layer.BlobInfo, false, progress); err != nil {
+			return err
+		}
+
+		copied[layer.Digest] = true
+	}
+
+	return nil
+}
+
+func copyBlob(ctx context.Context, src imagetypes.ImageSource, dest imagetypes.ImageDestination, info imagetypes.BlobInfo, isConfig bool, progress ProgressFunc) error {
+	id := shortID(info.Digest)
+
+	reused, _, err := dest.TryReusingBlob(ctx, info, none.NoCache, false)
+	if err != nil {
+		return pkgerrors.Wrapf(err, "unable to check the blob %s", info.Digest)
+	}
+
+	if reused {
+		progress(jsonmessage.JSONMessage{ID: id, Status: "Layer already exists"})
+		return nil
+	}
+
+	stream, size, err := src.GetBlob(ctx, info, none.NoCache)
+	if err != nil {
+		return pkgerrors.Wrapf(err, "unable to read the blob %s", info.Digest)
+	}
+	defer stream.Close()
+
+	if info.Size <= 0 {
+		info.Size = size
+	}
+
+	reader := &progressReader{reader: stream, id: id, total: info.Size, progress: progress}
+	if _, err := dest.PutBlob(ctx, reader, info, none.NoCache, isConfig); err != nil {
+		return pkgerrors.Wrapf(err, "unable to write the blob %s", info.Digest)
+	}
+
+	progress(jsonmessage.JSONMessage{ID: id, Status: "Pushed"})
+
+	return nil
+}
+
+// progressReader reports the bytes read from a blob, at most once per progress interval
+type progressReader struct {
+	reader     io.Reader
+	id         string
+	current    int64
+	total      int64
+	lastReport time.Time
+	progress   ProgressFunc
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.current += int64(n)
+
+	if time.Since(r.lastReport) >= progressInterval || errors.Is(err, io.EOF) {
+		r.lastReport = time.Now()
+		r.progress(jsonmessage.JSONMessage{
+			ID:       r.id,
+			Status:   "Pushing",
+			Progress: &jsonmessage.JSONProgress{Current: r.current, Total: r.total},
+		})
+	}
+
+	return n, err
+}
+
+// CopyThroughDaemon pulls the image with the Docker daemon of an environment, tags it with the target name and
+// pushes it to the target registry. The progress reported by the daemon is forwarded.
+func (copier *Copier) CopyThroughDaemon(ctx context.Context, cli *client.Client, source, target RegistryImage, progress ProgressFunc) error {
+	sourceAuth, err := copier.registryClient.EncodedCertainRegistryAuth(source.Registry)
+	if err != nil {
+		return pkgerrors.Wrap(err, "unable to retrieve the credentials of the source registry")
+	}
+
+	out, err := cli.ImagePull(ctx, source.Reference(), types.ImagePullOptions{RegistryAuth: sourceAuth})
+	if err != nil {
+		return pkgerrors.Wrapf(err, "unable to pull the image %s", source.Reference())
+	}
+
+	err = forwardProgress(out, progress)
+	out.Close()
+	if err != nil {
+		return pkgerrors.Wrapf(err, "unable to pull the image %s", source.Reference())
+	}
+
+	if err := cli.ImageTag(ctx, source.Reference(), target.Reference()); err != nil {
+		return pkgerrors.Wrapf(err, "unable to tag the image %s", target.Reference())
+	}
+
+	// the target tag is only needed to push the image
+	defer cli.ImageRemove(context.WithoutCancel(ctx), target.Reference(), types.ImageRemoveOptions{})
+
+	targetAuth, err := copier.registryClient.EncodedCertainRegistryAuth(target.Registry)
+	if err != nil {
+		return pkgerrors.Wrap(err, "unable to retrieve the credentials of the target registry")
+	}
+
+	out, err = cli.ImagePush(ctx, target.Reference(), types.ImagePushOptions{RegistryAuth: targetAuth})
+	if err != nil {
+		return pkgerrors.Wrapf(err, "unable to push the image %s", target.Reference())
+	}
+	defer out.Close()
+
+	return pkgerrors.Wrapf(forwardProgress(out, progress), "unable to push the image %s", target.Reference())
+}
+
+// forwardProgress decodes the JSON messages streamed by the Docker daemon, a message holding an error stops the copy
+func forwardProgress(stream io.Reader, progress ProgressFunc) error {
+	decoder := json.NewDecoder(stream)
+
+	for {
+		var message jsonmessage.JSONMessage
+		if err := decoder.Decode(&message); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if message.Error != nil {
+			return message.Error
+		}
+
+		progress(message)
+	}
+}
+
+func (copier *Copier) systemContext(registry *portainer.Registry) *imagetypes.SystemContext {
+	username, password, err := copier.registryClient.CertainRegistryAuth(registry)
+	if err != nil {
+		return nil
+	}
+
+	return &imagetypes.SystemContext{
+		DockerAuthConfig: &imagetypes.DockerAuthConfig{
+			Username: username,
+			Password: password,
+		},
+	}
+}
+
+func shortID(d digest.Digest) string {
+	encoded := d.Encoded()
+	if len(encoded) > 12 {
+		return encoded[:12]
+	}
+
+	return encoded
+}
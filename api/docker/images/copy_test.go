@@ -0,0 +1,30 @@
+package images
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryImageReference(t *testing.T) {
+	is := assert.New(t)
+
+	image := RegistryImage{Registry: &portainer.Registry{URL: "https://registry.example.com/"}, Name: "app/api:1.4.2"}
+	is.Equal("registry.example.com/app/api:1.4.2", image.Reference())
+
+	image = RegistryImage{Registry: &portainer.Registry{URL: "registry.example.com:5000"}, Name: "/app/api:1.4.2"}
+	is.Equal("registry.example.com:5000/app/api:1.4.2", image.Reference())
+}
+
+func TestValidateImageName(t *testing.T) {
+	is := assert.New(t)
+
+	digested := "app/api@sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	is.NoError(ValidateImageName("app/api:1.4.2", true))
+	is.NoError(ValidateImageName(digested, false))
+	is.Error(ValidateImageName(digested, true), "a pushed image must be tagged")
+	is.Error(ValidateImageName("app/api", false), "a tag or a digest is required")
+	is.Error(ValidateImageName("App/API:1.4.2", false))
+}
@@ -0,0 +1,80 @@
+package images
+
+import (
+	"context"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/jobs"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	pkgerrors "github.com/pkg/errors"
+)
+
+// PullJobType is the type of the background jobs pulling an image on an environment ahead of its deployment
+const PullJobType = "image.pull"
+
+// PullJobPayload represents the image pulled by a background job
+type PullJobPayload struct {
+	EndpointID portainer.EndpointID
+	// Node of the Swarm cluster pulling the image, the node of the environment when empty
+	NodeName string
+	Image    string
+}
+
+// RegisterPullJobs registers the handler of the image pull jobs in the job queue, the images being pulled with the
+// credentials of the matching registry
+func RegisterPullJobs(queue *jobs.Queue, dataStore dataservices.DataStore, clientFactory *dockerclient.ClientFactory) {
+	queue.Register(PullJobType, jobs.Options{Concurrency: 3, Backoff: time.Minute}, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		var payload PullJobPayload
+		if err := jobs.DecodePayload(job, &payload); err != nil {
+			return scheduler.NewPermanentError(err)
+		}
+
+		image, err := ParseImage(ParseImageOptions{Name: payload.Image})
+		if err != nil {
+			return scheduler.NewPermanentError(err)
+		}
+
+		endpoint, err := dataStore.Endpoint().Endpoint(payload.EndpointID)
+		if err != nil {
+			return scheduler.NewPermanentError(pkgerrors.WithMessagef(err, "failed to find the environment %d", payload.EndpointID))
+		}
+
+		cli, err := clientFactory.CreateClient(endpoint, payload.NodeName, nil)
+		if err != nil {
+			return pkgerrors.WithMessage(err, "unable to connect to the Docker environment")
+		}
+		defer cli.Close()
+
+		return NewPuller(cli, NewRegistryClient(dataStore), dataStore).PullWithProgress(ctx, image, pullProgress(ctx))
+	})
+}
+
+// pullProgress reports the progress of an image pull as the progress of the job, the bytes of the layers being
+// downloaded being summed up
+func pullProgress(ctx context.Context) ProgressFunc {
+	layers := map[string]jsonmessage.JSONProgress{}
+
+	return func(message jsonmessage.JSONMessage) {
+		if message.ID != "" && message.Progress != nil && message.Status == "Downloading" {
+			layers[message.ID] = *message.Progress
+		}
+
+		var current, total int64
+		for _, layer := range layers {
+			current += layer.Current
+			total += layer.Total
+		}
+
+		text := message.Status
+		if message.ID != "" {
+			text = message.ID + ": " + message.Status
+		}
+
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "pull", Message: text, Current: current, Total: total})
+	}
+}
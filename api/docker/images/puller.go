@@ -26,6 +26,30 @@ func NewPuller(client *client.Client, registryClient *RegistryClient, dataStore
 }
 
 func (puller *Puller) Pull(ctx context.Context, image Image) error {
+	out, err := puller.pull(ctx, image)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.ReadAll(out)
+
+	return err
+}
+
+// PullWithProgress pulls the image and forwards the progress reported by the Docker daemon, the errors reported in
+// the progress stopping the pull
+func (puller *Puller) PullWithProgress(ctx context.Context, image Image, progress ProgressFunc) error {
+	out, err := puller.pull(ctx, image)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return forwardProgress(out, progress)
+}
+
+func (puller *Puller) pull(ctx context.Context, image Image) (io.ReadCloser, error) {
 	log.Debug().Str("image", image.FullName()).Msg("starting to pull the image")
 
 	registryAuth, err := puller.registryClient.EncodedRegistryAuth(image)
@@ -36,15 +60,7 @@ func (puller *Puller) Pull(ctx context.Context, image Image) error {
 			Msg("failed to get an encoded registry auth via image, try to pull image without registry auth")
 	}
 
-	out, err := puller.client.ImagePull(ctx, image.FullName(), types.ImagePullOptions{
+	return puller.client.ImagePull(ctx, image.FullName(), types.ImagePullOptions{
 		RegistryAuth: registryAuth,
 	})
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	_, err = io.ReadAll(out)
-
-	return err
 }
@@ -0,0 +1,237 @@
+package docker
+
+import (
+	"context"
+	"math"
+	"net/netip"
+	"sort"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	pkgerrors "github.com/pkg/errors"
+)
+
+type (
+	// NetworkOverview represents the IP address management of the networks of an environment
+	NetworkOverview struct {
+		// Whether the networks of the whole Swarm cluster are included
+		Swarm    bool             `json:"Swarm" example:"false"`
+		Networks []NetworkSummary `json:"Networks"`
+		// Subnets of the environment overlapping the subnets of the other environments
+		Conflicts []SubnetConflict `json:"Conflicts"`
+	}
+
+	// NetworkSummary represents the subnets of a network and the addresses used by its containers
+	NetworkSummary struct {
+		ID         string             `json:"Id" example:"4e4f7d9c8f2a"`
+		Name       string             `json:"Name" example:"frontend"`
+		Driver     string             `json:"Driver" example:"overlay"`
+		Scope      string             `json:"Scope" example:"swarm"`
+		Subnets    []SubnetUsage      `json:"Subnets"`
+		Containers []NetworkContainer `json:"Containers"`
+	}
+
+	// SubnetUsage represents the addresses of a subnet used by the containers
+	SubnetUsage struct {
+		Subnet  string `json:"Subnet" example:"10.0.1.0/24"`
+		Gateway string `json:"Gateway" example:"10.0.1.1"`
+		// Number of addresses of the subnet, capped to the maximum uint64 value for the large IPv6 subnets
+		Size uint64 `json:"Size" example:"256"`
+		// Addresses of the subnet used by the containers
+		UsedIPs []string `json:"UsedIPs"`
+	}
+
+	// NetworkContainer represents a container, or a task of a Swarm service, connected to a network
+	NetworkContainer struct {
+		Name        string `json:"Name" example:"web.1.xq2sl7j3fdm4"`
+		IPv4Address string `json:"IPv4Address" example:"10.0.1.5"`
+		IPv6Address string `json:"IPv6Address,omitempty"`
+		// Address of the Swarm node running the task
+		Node string `json:"Node,omitempty" example:"192.168.1.12"`
+	}
+
+	// SubnetConflict represents a subnet overlapping the subnet of a network of another environment
+	SubnetConflict struct {
+		NetworkName       string               `json:"NetworkName" example:"frontend"`
+		Subnet            string               `json:"Subnet" example:"10.0.1.0/24"`
+		OtherEndpointID   portainer.EndpointID `json:"OtherEndpointId" example:"3"`
+		OtherEndpointName string               `json:"OtherEndpointName" example:"production"`
+		OtherNetworkName  string               `json:"OtherNetworkName" example:"backend"`
+		OtherSubnet       string               `json:"OtherSubnet" example:"10.0.0.0/16"`
+	}
+)
+
+// OtherEndpointNetworks holds the networks recorded in the snapshot of another environment
+type OtherEndpointNetworks struct {
+	EndpointID   portainer.EndpointID
+	EndpointName string
+	Networks     []types.NetworkResource
+}
+
+// InspectNetworks builds the overview of the networks of an environment. On a Swarm manager, the tasks connected to
+// the Swarm networks are inspected on every node of the cluster.
+func InspectNetworks(ctx context.Context, cli *client.Client) (*NetworkOverview, error) {
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "unable to retrieve the information of the environment")
+	}
+
+	networks, err := cli.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "unable to list the networks")
+	}
+
+	swarm := info.Swarm.ControlAvailable
+	overview := &NetworkOverview{Swarm: swarm, Networks: []NetworkSummary{}, Conflicts: []SubnetConflict{}}
+
+	for _, network := range networks {
+		verbose := swarm && network.Scope == "swarm"
+
+		resource, err := cli.NetworkInspect(ctx, network.ID, types.NetworkInspectOptions{Verbose: verbose})
+		if err != nil {
+			return nil, pkgerrors.Wrapf(err, "unable to inspect the network %s", network.Name)
+		}
+
+		overview.Networks = append(overview.Networks, summarizeNetwork(resource))
+	}
+
+	sort.Slice(overview.Networks, func(i, j int) bool {
+		return overview.Networks[i].Name < overview.Networks[j].Name
+	})
+
+	return overview, nil
+}
+
+func summarizeNetwork(network types.NetworkResource) NetworkSummary {
+	summary := NetworkSummary{
+		ID:         network.ID,
+		Name:       network.Name,
+		Driver:     network.Driver,
+		Scope:      network.Scope,
+		Subnets:    []SubnetUsage{},
+		Containers: []NetworkContainer{},
+	}
+
+	for _, c := range network.Containers {
+		summary.Containers = append(summary.Containers, NetworkContainer{
+			Name:        c.Name,
+			IPv4Address: addressOf(c.IPv4Address),
+			IPv6Address: addressOf(c.IPv6Address),
+		})
+	}
+
+	// the tasks of the other nodes are only listed in the services of a verbose inspection
+	for _, service := range network.Services {
+		for _, task := range service.Tasks {
+			if task.Name == "" || isListed(summary.Containers, task.Name) {
+				continue
+			}
+
+			summary.Containers = append(summary.Containers, NetworkContainer{
+				Name:        task.Name,
+				IPv4Address: addressOf(task.EndpointIP),
+				Node:        task.Info["Host IP"],
+			})
+		}
+	}
+
+	sort.Slice(summary.Containers, func(i, j int) bool {
+		return summary.Containers[i].Name < summary.Containers[j].Name
+	})
+
+	for _, config := range network.IPAM.Config {
+		prefix, err := netip.ParsePrefix(config.Subnet)
+		if err != nil {
+			continue
+		}
+
+		usage := SubnetUsage{
+			Subnet:  prefix.String(),
+			Gateway: config.Gateway,
+			Size:    prefixSize(prefix),
+			UsedIPs: []string{},
+		}
+
+		for _, c := range summary.Containers {
+			for _, address := range []string{c.IPv4Address, c.IPv6Address} {
+				if ip, err := netip.ParseAddr(address); err == nil && prefix.Contains(ip) {
+					usage.UsedIPs = append(usage.UsedIPs, address)
+				}
+			}
+		}
+
+		summary.Subnets = append(summary.Subnets, usage)
+	}
+
+	return summary
+}
+
+// FindSubnetConflicts returns the subnets of the networks overlapping the subnets of the networks of other
+// environments. The overlaps between two local networks are ignored as they are isolated on their own host, as well
+// as the Swarm networks shared by the environments of the same cluster.
+func FindSubnetConflicts(networks []NetworkSummary, others []OtherEndpointNetworks) []SubnetConflict {
+	conflicts := []SubnetConflict{}
+
+	for _, network := range networks {
+		for _, subnet := range network.Subnets {
+			prefix, err := netip.ParsePrefix(subnet.Subnet)
+			if err != nil {
+				continue
+			}
+
+			for _, other := range others {
+				for _, otherNetwork := range other.Networks {
+					if otherNetwork.ID == network.ID || (network.Scope == "local" && otherNetwork.Scope == "local") {
+						continue
+					}
+
+					for _, config := range otherNetwork.IPAM.Config {
+						otherPrefix, err := netip.ParsePrefix(config.Subnet)
+						if err != nil || !prefix.Overlaps(otherPrefix) {
+							continue
+						}
+
+						conflicts = append(conflicts, SubnetConflict{
+							NetworkName:       network.Name,
+							Subnet:            subnet.Subnet,
+							OtherEndpointID:   other.EndpointID,
+							OtherEndpointName: other.EndpointName,
+							OtherNetworkName:  otherNetwork.Name,
+							OtherSubnet:       otherPrefix.String(),
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// addressOf strips the prefix length of an address formatted as 10.0.1.5/24
+func addressOf(address string) string {
+	ip, _, _ := strings.Cut(address, "/")
+	return ip
+}
+
+func prefixSize(prefix netip.Prefix) uint64 {
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits >= 64 {
+		return math.MaxUint64
+	}
+
+	return 1 << hostBits
+}
+
+func isListed(containers []NetworkContainer, name string) bool {
+	for _, c := range containers {
+		if c.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
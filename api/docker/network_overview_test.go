@@ -0,0 +1,68 @@
+package docker
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_summarizeNetwork(t *testing.T) {
+	is := assert.New(t)
+
+	summary := summarizeNetwork(types.NetworkResource{
+		ID:     "n1",
+		Name:   "frontend",
+		Driver: "overlay",
+		Scope:  "swarm",
+		IPAM:   network.IPAM{Config: []network.IPAMConfig{{Subnet: "10.0.1.0/24", Gateway: "10.0.1.1"}}},
+		Containers: map[string]types.EndpointResource{
+			"c1": {Name: "web.1.abc", IPv4Address: "10.0.1.5/24"},
+		},
+		Services: map[string]network.ServiceInfo{
+			"web": {Tasks: []network.Task{
+				{Name: "web.1.abc", EndpointIP: "10.0.1.5"},
+				{Name: "web.2.def", EndpointIP: "10.0.1.6", Info: map[string]string{"Host IP": "192.168.1.12"}},
+			}},
+		},
+	})
+
+	is.Equal([]NetworkContainer{
+		{Name: "web.1.abc", IPv4Address: "10.0.1.5"},
+		{Name: "web.2.def", IPv4Address: "10.0.1.6", Node: "192.168.1.12"},
+	}, summary.Containers)
+	is.Equal([]SubnetUsage{
+		{Subnet: "10.0.1.0/24", Gateway: "10.0.1.1", Size: 256, UsedIPs: []string{"10.0.1.5", "10.0.1.6"}},
+	}, summary.Subnets)
+}
+
+func TestFindSubnetConflicts(t *testing.T) {
+	is := assert.New(t)
+
+	networks := []NetworkSummary{
+		{ID: "n1", Name: "frontend", Scope: "swarm", Subnets: []SubnetUsage{{Subnet: "10.0.1.0/24"}}},
+		{ID: "n2", Name: "bridge", Scope: "local", Subnets: []SubnetUsage{{Subnet: "172.17.0.0/16"}}},
+	}
+
+	others := []OtherEndpointNetworks{{
+		EndpointID:   3,
+		EndpointName: "production",
+		Networks: []types.NetworkResource{
+			{ID: "n1", Name: "frontend", Scope: "swarm", IPAM: network.IPAM{Config: []network.IPAMConfig{{Subnet: "10.0.1.0/24"}}}},
+			{ID: "n3", Name: "backend", Scope: "swarm", IPAM: network.IPAM{Config: []network.IPAMConfig{{Subnet: "10.0.0.0/16"}}}},
+			{ID: "n4", Name: "bridge", Scope: "local", IPAM: network.IPAM{Config: []network.IPAMConfig{{Subnet: "172.17.0.0/16"}}}},
+		},
+	}}
+
+	is.Equal([]SubnetConflict{{
+		NetworkName:       "frontend",
+		Subnet:            "10.0.1.0/24",
+		OtherEndpointID:   portainer.EndpointID(3),
+		OtherEndpointName: "production",
+		OtherNetworkName:  "backend",
+		OtherSubnet:       "10.0.0.0/16",
+	}}, FindSubnetConflicts(networks, others), "the shared Swarm networks and the local networks are ignored")
+}
@@ -0,0 +1,271 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/portainer/portainer/api/docker/consts"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	pkgerrors "github.com/pkg/errors"
+)
+
+type (
+	// RestartPolicies represents the restart policies of the containers and the Swarm services of an environment
+	RestartPolicies struct {
+		Containers []ContainerRestartPolicy `json:"Containers"`
+		Services   []ServiceRestartPolicy   `json:"Services"`
+		// Number of containers by restart policy
+		ContainerPolicyCounts map[string]int `json:"ContainerPolicyCounts"`
+		// Number of services by restart condition
+		ServiceConditionCounts map[string]int `json:"ServiceConditionCounts"`
+		// Number of containers and services which are not started again after a reboot of the host
+		NotRestartedCount int `json:"NotRestartedCount" example:"2"`
+		// Stacks whose containers or services do not share the same restart policy
+		InconsistentStacks []string `json:"InconsistentStacks"`
+	}
+
+	// ContainerRestartPolicy represents the restart policy of a container
+	ContainerRestartPolicy struct {
+		ID    string `json:"Id" example:"3a8b3e1c5f2d"`
+		Name  string `json:"Name" example:"web-nginx-1"`
+		Image string `json:"Image" example:"nginx:1.25"`
+		State string `json:"State" example:"running"`
+		// Compose project of the container
+		Stack string `json:"Stack,omitempty" example:"web"`
+		// One of no, always, unless-stopped or on-failure
+		Policy            string `json:"Policy" example:"unless-stopped"`
+		MaximumRetryCount int    `json:"MaximumRetryCount" example:"0"`
+		// Whether the container is started again after a reboot of the host
+		RestartedOnReboot bool `json:"RestartedOnReboot" example:"true"`
+	}
+
+	// ServiceRestartPolicy represents the restart policy of the tasks of a Swarm service
+	ServiceRestartPolicy struct {
+		ID   string `json:"Id" example:"9mnpnzenvg8p"`
+		Name string `json:"Name" example:"web_nginx"`
+		// Stack of the service
+		Stack string `json:"Stack,omitempty" example:"web"`
+		// One of none, on-failure or any
+		Condition string `json:"Condition" example:"any"`
+		// Maximum number of restarts of a task, 0 when unlimited
+		MaxAttempts uint64 `json:"MaxAttempts" example:"0"`
+		// Whether the tasks are started again after a reboot of their node
+		RestartedOnReboot bool `json:"RestartedOnReboot" example:"true"`
+	}
+)
+
+// ListRestartPolicyResources lists the containers, except the tasks of the Swarm services which are restarted by
+// their service, and on a Swarm manager the services
+func ListRestartPolicyResources(ctx context.Context, cli *client.Client) ([]types.Container, []swarm.Service, error) {
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, nil, pkgerrors.Wrap(err, "unable to list the containers")
+	}
+
+	containers = slices.DeleteFunc(containers, func(container types.Container) bool {
+		return container.Labels[consts.SwarmServiceIdLabel] != ""
+	})
+
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return nil, nil, pkgerrors.Wrap(err, "unable to retrieve the information of the environment")
+	}
+
+	if !info.Swarm.ControlAvailable {
+		return containers, nil, nil
+	}
+
+	services, err := cli.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return nil, nil, pkgerrors.Wrap(err, "unable to list the services")
+	}
+
+	return containers, services, nil
+}
+
+// InspectRestartPolicies returns the restart policies of the containers, which are inspected as the list of the
+// containers does not hold them, and of the services
+func InspectRestartPolicies(ctx context.Context, cli *client.Client, containers []types.Container, services []swarm.Service) (*RestartPolicies, error) {
+	policies := make([]ContainerRestartPolicy, 0, len(containers))
+
+	for _, c := range containers {
+		inspected, err := cli.ContainerInspect(ctx, c.ID)
+		if client.IsErrNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, pkgerrors.Wrapf(err, "unable to inspect the container %s", c.ID)
+		}
+
+		policies = append(policies, containerRestartPolicy(c, inspected.HostConfig.RestartPolicy))
+	}
+
+	servicePolicies := make([]ServiceRestartPolicy, 0, len(services))
+	for _, service := range services {
+		servicePolicies = append(servicePolicies, serviceRestartPolicy(service))
+	}
+
+	return SummarizeRestartPolicies(policies, servicePolicies), nil
+}
+
+// SummarizeRestartPolicies counts the restart policies of the containers and the services, and finds the stacks whose
+// containers or services have different restart policies
+func SummarizeRestartPolicies(containers []ContainerRestartPolicy, services []ServiceRestartPolicy) *RestartPolicies {
+	result := &RestartPolicies{
+		Containers:             containers,
+		Services:               services,
+		ContainerPolicyCounts:  map[string]int{},
+		ServiceConditionCounts: map[string]int{},
+		InconsistentStacks:     []string{},
+	}
+
+	stackPolicies := map[string]map[string]bool{}
+	addStackPolicy := func(stack, policy string) {
+		if stack == "" {
+			return
+		}
+
+		if stackPolicies[stack] == nil {
+			stackPolicies[stack] = map[string]bool{}
+		}
+
+		stackPolicies[stack][policy] = true
+	}
+
+	for _, c := range containers {
+		result.ContainerPolicyCounts[c.Policy]++
+		if !c.RestartedOnReboot {
+			result.NotRestartedCount++
+		}
+
+		addStackPolicy(c.Stack, fmt.Sprintf("%s:%d", c.Policy, c.MaximumRetryCount))
+	}
+
+	for _, service := range services {
+		result.ServiceConditionCounts[service.Condition]++
+		if !service.RestartedOnReboot {
+			result.NotRestartedCount++
+		}
+
+		addStackPolicy(service.Stack, fmt.Sprintf("%s:%d", service.Condition, service.MaxAttempts))
+	}
+
+	for stack, policies := range stackPolicies {
+		if len(policies) > 1 {
+			result.InconsistentStacks = append(result.InconsistentStacks, stack)
+		}
+	}
+
+	sort.Strings(result.InconsistentStacks)
+	sort.Slice(result.Containers, func(i, j int) bool { return result.Containers[i].Name < result.Containers[j].Name })
+	sort.Slice(result.Services, func(i, j int) bool { return result.Services[i].Name < result.Services[j].Name })
+
+	return result
+}
+
+func containerRestartPolicy(c types.Container, policy container.RestartPolicy) ContainerRestartPolicy {
+	name := ""
+	if len(c.Names) > 0 {
+		name = strings.TrimPrefix(c.Names[0], "/")
+	}
+
+	policyName := policy.Name
+	if policyName == "" {
+		policyName = "no"
+	}
+
+	return ContainerRestartPolicy{
+		ID:                c.ID,
+		Name:              name,
+		Image:             c.Image,
+		State:             c.State,
+		Stack:             c.Labels[consts.ComposeStackNameLabel],
+		Policy:            policyName,
+		MaximumRetryCount: policy.MaximumRetryCount,
+		// the containers stopped by their users are not started with the unless-stopped policy, and the on-failure
+		// policy does not apply to the containers stopped by the shutdown of the host
+		RestartedOnReboot: policyName == "always" || (policyName == "unless-stopped" && c.State == "running"),
+	}
+}
+
+func serviceRestartPolicy(service swarm.Service) ServiceRestartPolicy {
+	// the tasks of the services are restarted on any condition when the service does not set a policy
+	condition := swarm.RestartPolicyConditionAny
+
+	var maxAttempts uint64
+	if policy := service.Spec.TaskTemplate.RestartPolicy; policy != nil {
+		if policy.Condition != "" {
+			condition = policy.Condition
+		}
+
+		if policy.MaxAttempts != nil {
+			maxAttempts = *policy.MaxAttempts
+		}
+	}
+
+	return ServiceRestartPolicy{
+		ID:                service.ID,
+		Name:              service.Spec.Name,
+		Stack:             service.Spec.Labels[consts.SwarmStackNameLabel],
+		Condition:         string(condition),
+		MaxAttempts:       maxAttempts,
+		RestartedOnReboot: condition != swarm.RestartPolicyConditionNone,
+	}
+}
+
+// ServiceRestartPolicyOf returns the restart policy of the tasks of a service equivalent to the restart policy of a
+// container: no maps to none, always and unless-stopped to any and on-failure to on-failure. The delay and the window
+// of the current policy are kept.
+func ServiceRestartPolicyOf(policy container.RestartPolicy, current *swarm.RestartPolicy) *swarm.RestartPolicy {
+	updated := &swarm.RestartPolicy{}
+	if current != nil {
+		*updated = *current
+	}
+
+	updated.MaxAttempts = nil
+
+	switch policy.Name {
+	case "", "no":
+		updated.Condition = swarm.RestartPolicyConditionNone
+	case "on-failure":
+		updated.Condition = swarm.RestartPolicyConditionOnFailure
+
+		if policy.MaximumRetryCount > 0 {
+			maxAttempts := uint64(policy.MaximumRetryCount)
+			updated.MaxAttempts = &maxAttempts
+		}
+	default:
+		updated.Condition = swarm.RestartPolicyConditionAny
+	}
+
+	return updated
+}
+
+// UpdateServiceRestartPolicy changes the restart policy of the tasks of a service, which restarts its tasks. It
+// returns false when the service already has the policy.
+func UpdateServiceRestartPolicy(ctx context.Context, cli *client.Client, serviceID string, policy container.RestartPolicy) (bool, error) {
+	service, _, err := cli.ServiceInspectWithRaw(ctx, serviceID, types.ServiceInspectOptions{})
+	if err != nil {
+		return false, pkgerrors.Wrapf(err, "unable to inspect the service %s", serviceID)
+	}
+
+	updated := service
+	updated.Spec.TaskTemplate.RestartPolicy = ServiceRestartPolicyOf(policy, service.Spec.TaskTemplate.RestartPolicy)
+
+	if serviceRestartPolicy(updated) == serviceRestartPolicy(service) {
+		return false, nil
+	}
+
+	_, err = cli.ServiceUpdate(ctx, service.ID, service.Version, updated.Spec, types.ServiceUpdateOptions{})
+	if err != nil {
+		return false, pkgerrors.Wrapf(err, "unable to update the service %s", service.Spec.Name)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,105 @@
+package docker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/portainer/portainer/api/docker/consts"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/swarm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_containerRestartPolicy(t *testing.T) {
+	is := assert.New(t)
+
+	c := types.Container{
+		ID:     "3a8b3e1c5f2d",
+		Names:  []string{"/web-nginx-1"},
+		Image:  "nginx:1.25",
+		State:  "running",
+		Labels: map[string]string{consts.ComposeStackNameLabel: "web"},
+	}
+
+	policy := containerRestartPolicy(c, container.RestartPolicy{})
+	is.Equal("web-nginx-1", policy.Name)
+	is.Equal("web", policy.Stack)
+	is.Equal("no", policy.Policy)
+	is.False(policy.RestartedOnReboot)
+
+	is.True(containerRestartPolicy(c, container.RestartPolicy{Name: "always"}).RestartedOnReboot)
+	is.True(containerRestartPolicy(c, container.RestartPolicy{Name: "unless-stopped"}).RestartedOnReboot)
+	is.False(containerRestartPolicy(c, container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3}).RestartedOnReboot)
+
+	c.State = "exited"
+	is.False(containerRestartPolicy(c, container.RestartPolicy{Name: "unless-stopped"}).RestartedOnReboot, "a stopped container is not started with the unless-stopped policy")
+	is.True(containerRestartPolicy(c, container.RestartPolicy{Name: "always"}).RestartedOnReboot)
+}
+
+func Test_serviceRestartPolicy(t *testing.T) {
+	is := assert.New(t)
+
+	service := swarm.Service{ID: "9mnpnzenvg8p", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "web_nginx", Labels: map[string]string{consts.SwarmStackNameLabel: "web"}}}}
+
+	policy := serviceRestartPolicy(service)
+	is.Equal("any", policy.Condition, "the services restart on any condition by default")
+	is.Equal("web", policy.Stack)
+	is.True(policy.RestartedOnReboot)
+
+	maxAttempts := uint64(5)
+	service.Spec.TaskTemplate.RestartPolicy = &swarm.RestartPolicy{Condition: swarm.RestartPolicyConditionOnFailure, MaxAttempts: &maxAttempts}
+	policy = serviceRestartPolicy(service)
+	is.Equal("on-failure", policy.Condition)
+	is.Equal(uint64(5), policy.MaxAttempts)
+
+	service.Spec.TaskTemplate.RestartPolicy = &swarm.RestartPolicy{Condition: swarm.RestartPolicyConditionNone}
+	is.False(serviceRestartPolicy(service).RestartedOnReboot)
+}
+
+func TestSummarizeRestartPolicies(t *testing.T) {
+	is := assert.New(t)
+
+	containers := []ContainerRestartPolicy{
+		{Name: "web-nginx-1", Stack: "web", Policy: "always", RestartedOnReboot: true},
+		{Name: "web-db-1", Stack: "web", Policy: "no"},
+		{Name: "api-app-1", Stack: "api", Policy: "unless-stopped", RestartedOnReboot: true},
+		{Name: "api-worker-1", Stack: "api", Policy: "unless-stopped", RestartedOnReboot: true},
+		{Name: "cron", Policy: "on-failure", MaximumRetryCount: 3},
+	}
+	services := []ServiceRestartPolicy{
+		{Name: "shop_front", Stack: "shop", Condition: "any", RestartedOnReboot: true},
+		{Name: "shop_jobs", Stack: "shop", Condition: "none"},
+	}
+
+	summary := SummarizeRestartPolicies(containers, services)
+
+	is.Equal(map[string]int{"always": 1, "no": 1, "unless-stopped": 2, "on-failure": 1}, summary.ContainerPolicyCounts)
+	is.Equal(map[string]int{"any": 1, "none": 1}, summary.ServiceConditionCounts)
+	is.Equal(3, summary.NotRestartedCount)
+	is.Equal([]string{"shop", "web"}, summary.InconsistentStacks)
+	is.Equal("api-app-1", summary.Containers[0].Name)
+	is.Equal("shop_front", summary.Services[0].Name)
+}
+
+func TestServiceRestartPolicyOf(t *testing.T) {
+	is := assert.New(t)
+
+	delay := 5 * time.Second
+	current := &swarm.RestartPolicy{Condition: swarm.RestartPolicyConditionAny, Delay: &delay}
+
+	policy := ServiceRestartPolicyOf(container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3}, current)
+	is.Equal(swarm.RestartPolicyConditionOnFailure, policy.Condition)
+	is.Equal(uint64(3), *policy.MaxAttempts)
+	is.Equal(&delay, policy.Delay, "the delay should be kept")
+	is.Equal(swarm.RestartPolicyConditionAny, current.Condition, "the current policy should not be changed")
+
+	is.Equal(swarm.RestartPolicyConditionNone, ServiceRestartPolicyOf(container.RestartPolicy{Name: "no"}, nil).Condition)
+	is.Equal(swarm.RestartPolicyConditionAny, ServiceRestartPolicyOf(container.RestartPolicy{Name: "always"}, nil).Condition)
+
+	policy = ServiceRestartPolicyOf(container.RestartPolicy{Name: "unless-stopped"}, policy)
+	is.Equal(swarm.RestartPolicyConditionAny, policy.Condition)
+	is.Nil(policy.MaxAttempts)
+}
@@ -0,0 +1,220 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/portainer/portainer/api/jobs"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	blue  = "blue"
+	green = "green"
+)
+
+// BlueGreenOptions represents a blue/green deployment of a service
+type BlueGreenOptions struct {
+	ServiceID string
+	// Image run by the new service
+	Image string
+	// Labels moved from the previous service to the new one along with the published ports, such as the labels
+	// routing the requests of a reverse proxy to the service
+	RoutingLabels []string
+	// Whether the previous service is scaled down to 0 instead of being removed, to be able to roll back
+	KeepPrevious bool
+	// Time in seconds waited for the new service to be healthy, 120 seconds when 0
+	HealthTimeout int
+}
+
+// BlueGreen deploys a new service running the image next to the current one, named after the service with the next
+// color. Once the tasks of the new service are healthy, the published ports and the routing labels are moved from the
+// current service to the new one, and the current service is retired. The new service is removed and the current one is
+// left as it was when the new service fails to be healthy. The name of the new service is returned.
+func BlueGreen(ctx context.Context, cli ServiceClient, registryAuth string, options BlueGreenOptions) (string, error) {
+	r := &rollout{cli: cli, registryAuth: registryAuth, pollInterval: healthPollInterval}
+
+	return r.blueGreen(ctx, options)
+}
+
+func (r *rollout) blueGreen(ctx context.Context, options BlueGreenOptions) (string, error) {
+	current, _, err := r.cli.ServiceInspectWithRaw(ctx, options.ServiceID, types.ServiceInspectOptions{})
+	if err != nil {
+		return "", fmt.Errorf("unable to inspect the service %s: %w", options.ServiceID, err)
+	}
+
+	if err := CheckService(current); err != nil {
+		return "", err
+	}
+
+	if options.KeepPrevious && current.Spec.Mode.Replicated == nil {
+		return "", errors.New("only the replicated services can be kept scaled down")
+	}
+
+	spec, err := copySpec(current.Spec, options.Image)
+	if err != nil {
+		return "", err
+	}
+
+	baseName := current.Spec.Labels[baseNameLabel]
+	if baseName == "" {
+		baseName = current.Spec.Name
+	}
+
+	color := green
+	if current.Spec.Labels[ColorLabel] == green {
+		color = blue
+	}
+
+	spec.Name = baseName + "-" + color
+	spec.Labels[baseNameLabel] = baseName
+	spec.Labels[ColorLabel] = color
+
+	routingLabels := map[string]string{}
+	for _, label := range options.RoutingLabels {
+		if value, ok := current.Spec.Labels[label]; ok {
+			routingLabels[label] = value
+			delete(spec.Labels, label)
+		}
+	}
+
+	if err := r.removeRetired(ctx, spec.Name, baseName); err != nil {
+		return "", err
+	}
+
+	jobs.ReportProgress(ctx, jobs.Progress{Stage: "create", Message: "Creating the service " + spec.Name})
+
+	newID, err := r.create(ctx, spec)
+	if err != nil {
+		return "", err
+	}
+
+	jobs.ReportProgress(ctx, jobs.Progress{Stage: "health", Message: "Waiting for the service " + spec.Name + " to be healthy"})
+
+	if err := r.waitHealthy(ctx, newID, healthTimeout(options.HealthTimeout)); err != nil {
+		r.remove(ctx, newID)
+
+		return "", err
+	}
+
+	jobs.ReportProgress(ctx, jobs.Progress{Stage: "flip", Message: "Moving the published ports from " + current.Spec.Name + " to " + spec.Name})
+
+	var ports []swarm.PortConfig
+	if current.Spec.EndpointSpec != nil {
+		ports = current.Spec.EndpointSpec.Ports
+	}
+
+	// the ports are released by the current service before being published by the new one
+	err = r.update(ctx, current.ID, func(spec *swarm.ServiceSpec) {
+		if spec.EndpointSpec != nil {
+			spec.EndpointSpec.Ports = nil
+		}
+
+		for label := range routingLabels {
+			delete(spec.Labels, label)
+		}
+	})
+	if err != nil {
+		r.remove(ctx, newID)
+
+		return "", err
+	}
+
+	err = r.update(ctx, newID, func(spec *swarm.ServiceSpec) {
+		if len(ports) > 0 {
+			if spec.EndpointSpec == nil {
+				spec.EndpointSpec = &swarm.EndpointSpec{}
+			}
+
+			spec.EndpointSpec.Ports = ports
+		}
+
+		for label, value := range routingLabels {
+			spec.Labels[label] = value
+		}
+	})
+	if err != nil {
+		r.restore(ctx, current.ID, ports, routingLabels)
+		r.remove(ctx, newID)
+
+		return "", err
+	}
+
+	jobs.ReportProgress(ctx, jobs.Progress{Stage: "retire", Message: "Retiring the service " + current.Spec.Name})
+
+	if options.KeepPrevious {
+		err = r.update(ctx, current.ID, func(spec *swarm.ServiceSpec) {
+			setReplicas(spec, 0)
+		})
+	} else {
+		err = r.cli.ServiceRemove(ctx, current.ID)
+	}
+
+	if err != nil {
+		return spec.Name, fmt.Errorf("the service %s is deployed but the service %s could not be retired: %w", spec.Name, current.Spec.Name, err)
+	}
+
+	return spec.Name, nil
+}
+
+// removeRetired removes the service kept scaled down by a previous deployment whose name is reused by the new service
+func (r *rollout) removeRetired(ctx context.Context, name, baseName string) error {
+	services, err := r.cli.ServiceList(ctx, types.ServiceListOptions{Filters: filters.NewArgs(filters.Arg("name", name))})
+	if err != nil {
+		return fmt.Errorf("unable to list the services: %w", err)
+	}
+
+	for _, service := range services {
+		// the name filter matches the prefixes of the names
+		if service.Spec.Name != name {
+			continue
+		}
+
+		if service.Spec.Labels[baseNameLabel] != baseName || replicas(service.Spec) != 0 {
+			return fmt.Errorf("the service %s already exists", name)
+		}
+
+		if err := r.cli.ServiceRemove(ctx, service.ID); err != nil {
+			return fmt.Errorf("unable to remove the retired service %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// restore publishes the ports and sets the routing labels of the current service again after a failed flip
+func (r *rollout) restore(ctx context.Context, serviceID string, ports []swarm.PortConfig, routingLabels map[string]string) {
+	// the service is restored even when the deployment was canceled
+	err := r.update(context.WithoutCancel(ctx), serviceID, func(spec *swarm.ServiceSpec) {
+		if len(ports) > 0 {
+			if spec.EndpointSpec == nil {
+				spec.EndpointSpec = &swarm.EndpointSpec{}
+			}
+
+			spec.EndpointSpec.Ports = ports
+		}
+
+		if spec.Labels == nil {
+			spec.Labels = map[string]string{}
+		}
+
+		for label, value := range routingLabels {
+			spec.Labels[label] = value
+		}
+	})
+	if err != nil {
+		log.Error().Err(err).Str("service_id", serviceID).Msg("unable to restore the published ports of the service")
+	}
+}
+
+func (r *rollout) remove(ctx context.Context, serviceID string) {
+	// the service is removed even when the deployment was canceled
+	if err := r.cli.ServiceRemove(context.WithoutCancel(ctx), serviceID); err != nil {
+		log.Error().Err(err).Str("service_id", serviceID).Msg("unable to remove the service")
+	}
+}
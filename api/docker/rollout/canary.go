@@ -0,0 +1,219 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/portainer/portainer/api/jobs"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// CanaryOptions represents a canary update of a service
+type CanaryOptions struct {
+	ServiceID string
+	// Image run by the canary, the image of the current canary when empty
+	Image string
+	// Share of the replicas run by the canary: 0 removes the canary and 100 promotes its image to the service
+	Percentage int
+	// Time in seconds waited for the replicas to be healthy, 120 seconds when 0
+	HealthTimeout int
+}
+
+// CanaryReplicas returns the number of replicas of the canary running the percentage of the replicas of a service,
+// rounded up and leaving at least one replica to the service
+func CanaryReplicas(total uint64, percentage int) (uint64, error) {
+	if percentage <= 0 || percentage >= 100 {
+		return 0, fmt.Errorf("the percentage of a canary must be between 1 and 99, got %d", percentage)
+	}
+
+	if total < 2 {
+		return 0, errors.New("a service requires at least 2 replicas to be shared with a canary")
+	}
+
+	canary := (total*uint64(percentage) + 99) / 100
+
+	return min(max(canary, 1), total-1), nil
+}
+
+// Canary shares the replicas of a service with a canary service running another image, the total number of replicas
+// being kept. The canary copies the service, its networks and its labels, and is reachable under the name of the service
+// on its networks, but it does not publish the ports of the service. The replicas are added before being removed, the
+// canary being scaled up and healthy before the service is scaled down, and the other way around.
+//
+// A percentage of 0 scales the service back to all the replicas and removes the canary, a percentage of 100 updates
+// the service to the image of the canary and removes the canary.
+func Canary(ctx context.Context, cli ServiceClient, registryAuth string, options CanaryOptions) error {
+	r := &rollout{cli: cli, registryAuth: registryAuth, pollInterval: healthPollInterval}
+
+	return r.canary(ctx, options)
+}
+
+func (r *rollout) canary(ctx context.Context, options CanaryOptions) error {
+	stable, _, err := r.cli.ServiceInspectWithRaw(ctx, options.ServiceID, types.ServiceInspectOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to inspect the service %s: %w", options.ServiceID, err)
+	}
+
+	if err := CheckService(stable); err != nil {
+		return err
+	}
+
+	if stable.Spec.Mode.Replicated == nil {
+		return errors.New("only the replicated services can be shared with a canary")
+	}
+
+	canary, found, err := r.findCanary(ctx, stable.Spec.Name)
+	if err != nil {
+		return err
+	}
+
+	image := options.Image
+	if image == "" && found {
+		image = canary.Spec.TaskTemplate.ContainerSpec.Image
+	}
+
+	timeout := healthTimeout(options.HealthTimeout)
+	total := replicas(stable.Spec) + replicas(canary.Spec)
+
+	switch {
+	case options.Percentage == 0:
+		if !found {
+			return nil
+		}
+
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "scale", Message: "Scaling the service " + stable.Spec.Name + " back to all the replicas"})
+
+		if err := r.scale(ctx, stable.ID, total, "", timeout); err != nil {
+			return err
+		}
+
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "retire", Message: "Removing the canary " + canary.Spec.Name})
+
+		return r.cli.ServiceRemove(ctx, canary.ID)
+	case options.Percentage == 100:
+		if image == "" {
+			return errors.New("the image of the service is required")
+		}
+
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "promote", Message: "Updating the service " + stable.Spec.Name + " to the image " + image})
+
+		if err := r.scale(ctx, stable.ID, total, image, timeout); err != nil {
+			return err
+		}
+
+		if !found {
+			return nil
+		}
+
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "retire", Message: "Removing the canary " + canary.Spec.Name})
+
+		return r.cli.ServiceRemove(ctx, canary.ID)
+	}
+
+	canaryReplicas, err := CanaryReplicas(total, options.Percentage)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		if image == "" {
+			return errors.New("the image of the canary is required")
+		}
+
+		spec, err := canarySpec(stable.Spec, image, canaryReplicas)
+		if err != nil {
+			return err
+		}
+
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "create", Message: fmt.Sprintf("Creating the canary %s with %d replicas", spec.Name, canaryReplicas)})
+
+		canaryID, err := r.create(ctx, spec)
+		if err != nil {
+			return err
+		}
+
+		if err := r.waitHealthy(ctx, canaryID, timeout); err != nil {
+			r.remove(ctx, canaryID)
+
+			return err
+		}
+
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "scale", Message: fmt.Sprintf("Scaling the service %s down to %d replicas", stable.Spec.Name, total-canaryReplicas)})
+
+		return r.scale(ctx, stable.ID, total-canaryReplicas, "", 0)
+	}
+
+	jobs.ReportProgress(ctx, jobs.Progress{Stage: "scale", Message: fmt.Sprintf("Running %d replicas on the canary %s and %d on the service %s", canaryReplicas, canary.Spec.Name, total-canaryReplicas, stable.Spec.Name)})
+
+	if canaryReplicas >= replicas(canary.Spec) {
+		if err := r.scale(ctx, canary.ID, canaryReplicas, options.Image, timeout); err != nil {
+			return err
+		}
+
+		return r.scale(ctx, stable.ID, total-canaryReplicas, "", 0)
+	}
+
+	if err := r.scale(ctx, stable.ID, total-canaryReplicas, "", timeout); err != nil {
+		return err
+	}
+
+	return r.scale(ctx, canary.ID, canaryReplicas, options.Image, timeout)
+}
+
+// findCanary returns the canary of a service
+func (r *rollout) findCanary(ctx context.Context, serviceName string) (swarm.Service, bool, error) {
+	services, err := r.cli.ServiceList(ctx, types.ServiceListOptions{Filters: filters.NewArgs(filters.Arg("label", CanaryOfLabel+"="+serviceName))})
+	if err != nil {
+		return swarm.Service{}, false, fmt.Errorf("unable to list the services: %w", err)
+	}
+
+	if len(services) == 0 {
+		return swarm.Service{}, false, nil
+	}
+
+	return services[0], true, nil
+}
+
+// scale updates the replicas of a service, and its image when set, then waits for it to be healthy unless the timeout
+// is 0
+func (r *rollout) scale(ctx context.Context, serviceID string, replicas uint64, image string, timeout time.Duration) error {
+	err := r.update(ctx, serviceID, func(spec *swarm.ServiceSpec) {
+		setReplicas(spec, replicas)
+
+		if image != "" {
+			spec.TaskTemplate.ContainerSpec.Image = image
+		}
+	})
+	if err != nil || timeout == 0 {
+		return err
+	}
+
+	return r.waitHealthy(ctx, serviceID, timeout)
+}
+
+// canarySpec returns the spec of the canary of a service, reachable under the name of the service on its networks
+func canarySpec(stable swarm.ServiceSpec, image string, replicas uint64) (swarm.ServiceSpec, error) {
+	spec, err := copySpec(stable, image)
+	if err != nil {
+		return swarm.ServiceSpec{}, err
+	}
+
+	spec.Name = stable.Name + "-canary"
+	spec.Labels[CanaryOfLabel] = stable.Name
+	setReplicas(&spec, replicas)
+
+	for i := range spec.TaskTemplate.Networks {
+		network := &spec.TaskTemplate.Networks[i]
+		if !slices.Contains(network.Aliases, stable.Name) {
+			network.Aliases = append(network.Aliases, stable.Name)
+		}
+	}
+
+	return spec, nil
+}
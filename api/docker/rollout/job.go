@@ -0,0 +1,113 @@
+package rollout
+
+import (
+	"context"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/docker/images"
+	"github.com/portainer/portainer/api/jobs"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/docker/docker/client"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// BlueGreenJobType is the type of the background jobs running a blue/green deployment of a Swarm service
+	BlueGreenJobType = "docker.service.bluegreen"
+	// CanaryJobType is the type of the background jobs running a canary update of a Swarm service
+	CanaryJobType = "docker.service.canary"
+)
+
+// BlueGreenJobPayload represents the blue/green deployment run by a background job
+type BlueGreenJobPayload struct {
+	EndpointID portainer.EndpointID
+	BlueGreenOptions
+}
+
+// CanaryJobPayload represents the canary update run by a background job
+type CanaryJobPayload struct {
+	EndpointID portainer.EndpointID
+	CanaryOptions
+}
+
+// RegisterJobs registers the handlers of the rollout jobs in the job queue, the images being pulled with the
+// credentials of the matching registry. A failed rollout is not retried, the services being left as they were before
+// the failed step.
+func RegisterJobs(queue *jobs.Queue, dataStore dataservices.DataStore, clientFactory *dockerclient.ClientFactory) {
+	options := jobs.Options{MaxAttempts: 1}
+
+	queue.Register(BlueGreenJobType, options, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		var payload BlueGreenJobPayload
+		if err := jobs.DecodePayload(job, &payload); err != nil {
+			return scheduler.NewPermanentError(err)
+		}
+
+		cli, err := swarmClient(dataStore, clientFactory, payload.EndpointID)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		name, err := BlueGreen(ctx, cli, registryAuth(dataStore, payload.Image), payload.BlueGreenOptions)
+		if err != nil {
+			return err
+		}
+
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "done", Message: "The service " + name + " is deployed"})
+
+		return nil
+	})
+
+	queue.Register(CanaryJobType, options, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		var payload CanaryJobPayload
+		if err := jobs.DecodePayload(job, &payload); err != nil {
+			return scheduler.NewPermanentError(err)
+		}
+
+		cli, err := swarmClient(dataStore, clientFactory, payload.EndpointID)
+		if err != nil {
+			return err
+		}
+		defer cli.Close()
+
+		return Canary(ctx, cli, registryAuth(dataStore, payload.Image), payload.CanaryOptions)
+	})
+}
+
+func swarmClient(dataStore dataservices.DataStore, clientFactory *dockerclient.ClientFactory, endpointID portainer.EndpointID) (*client.Client, error) {
+	endpoint, err := dataStore.Endpoint().Endpoint(endpointID)
+	if err != nil {
+		return nil, scheduler.NewPermanentError(pkgerrors.WithMessagef(err, "failed to find the environment %d", endpointID))
+	}
+
+	cli, err := clientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return nil, pkgerrors.WithMessage(err, "unable to connect to the Docker environment")
+	}
+
+	return cli, nil
+}
+
+// registryAuth returns the credentials of the registry of an image, the image being pulled anonymously when no
+// registry matches
+func registryAuth(dataStore dataservices.DataStore, name string) string {
+	if name == "" {
+		return ""
+	}
+
+	image, err := images.ParseImage(images.ParseImageOptions{Name: name})
+	if err != nil {
+		return ""
+	}
+
+	auth, err := images.NewRegistryClient(dataStore).EncodedRegistryAuth(image)
+	if err != nil {
+		log.Debug().Str("image", name).Err(err).Msg("failed to get an encoded registry auth via image, try to pull image without registry auth")
+	}
+
+	return auth
+}
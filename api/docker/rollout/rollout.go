@@ -0,0 +1,189 @@
+// Package rollout deploys a new image of a Swarm service either side by side with a blue/green deployment, the
+// published ports being moved to the new service once it is healthy, or progressively with a canary service running a
+// share of the replicas
+package rollout
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/portainer/portainer/api/docker/consts"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+const (
+	// ColorLabel is the label holding the color of the services deployed by a blue/green deployment
+	ColorLabel = "io.portainer.rollout.color"
+	// CanaryOfLabel is the label holding the name of the service of which a service is the canary
+	CanaryOfLabel = "io.portainer.rollout.canary-of"
+
+	// baseNameLabel is the label holding the name of the service before its first blue/green deployment
+	baseNameLabel = "io.portainer.rollout.name"
+
+	defaultHealthTimeout = 2 * time.Minute
+	healthPollInterval   = 2 * time.Second
+)
+
+// ServiceClient is the part of the Docker client managing the Swarm services
+type ServiceClient interface {
+	ServiceCreate(ctx context.Context, service swarm.ServiceSpec, options types.ServiceCreateOptions) (types.ServiceCreateResponse, error)
+	ServiceInspectWithRaw(ctx context.Context, serviceID string, options types.ServiceInspectOptions) (swarm.Service, []byte, error)
+	ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error)
+	ServiceRemove(ctx context.Context, serviceID string) error
+	ServiceUpdate(ctx context.Context, serviceID string, version swarm.Version, service swarm.ServiceSpec, options types.ServiceUpdateOptions) (types.ServiceUpdateResponse, error)
+}
+
+// CheckService returns an error when a service cannot be rolled out: the services of the stacks are updated along with
+// their stack, which would bring back the previous service, and the canaries are rolled out through their service
+func CheckService(service swarm.Service) error {
+	if stack := service.Spec.Labels[consts.SwarmStackNameLabel]; stack != "" {
+		return fmt.Errorf("the service %s is managed by the stack %s", service.Spec.Name, stack)
+	}
+
+	if stable := service.Spec.Labels[CanaryOfLabel]; stable != "" {
+		return fmt.Errorf("the service %s is the canary of the service %s", service.Spec.Name, stable)
+	}
+
+	if service.Spec.Mode.Replicated == nil && service.Spec.Mode.Global == nil {
+		return fmt.Errorf("the service %s is neither replicated nor global", service.Spec.Name)
+	}
+
+	return nil
+}
+
+// rollout runs the changes of a rollout against the Swarm cluster
+type rollout struct {
+	cli          ServiceClient
+	registryAuth string
+	pollInterval time.Duration
+}
+
+func (r *rollout) create(ctx context.Context, spec swarm.ServiceSpec) (string, error) {
+	response, err := r.cli.ServiceCreate(ctx, spec, types.ServiceCreateOptions{EncodedRegistryAuth: r.registryAuth, QueryRegistry: true})
+	if err != nil {
+		return "", fmt.Errorf("unable to create the service %s: %w", spec.Name, err)
+	}
+
+	return response.ID, nil
+}
+
+// update inspects a service and updates it with the changes applied to its current spec
+func (r *rollout) update(ctx context.Context, serviceID string, change func(spec *swarm.ServiceSpec)) error {
+	service, _, err := r.cli.ServiceInspectWithRaw(ctx, serviceID, types.ServiceInspectOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to inspect the service %s: %w", serviceID, err)
+	}
+
+	change(&service.Spec)
+
+	_, err = r.cli.ServiceUpdate(ctx, service.ID, service.Version, service.Spec, types.ServiceUpdateOptions{EncodedRegistryAuth: r.registryAuth, QueryRegistry: true})
+	if err != nil {
+		return fmt.Errorf("unable to update the service %s: %w", service.Spec.Name, err)
+	}
+
+	return nil
+}
+
+// waitHealthy waits for the tasks of a service to be running, which swarm only reports once the health check of their
+// container passed, and for its last update to be completed
+func (r *rollout) waitHealthy(ctx context.Context, serviceID string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		services, err := r.cli.ServiceList(ctx, types.ServiceListOptions{Filters: filters.NewArgs(filters.Arg("id", serviceID)), Status: true})
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("unable to list the tasks of the service %s: %w", serviceID, err)
+		}
+
+		if len(services) > 0 {
+			healthy, err := serviceHealthy(services[0])
+			if err != nil {
+				return err
+			}
+
+			if healthy {
+				return nil
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("the service %s is not healthy after %s", serviceID, timeout)
+		}
+	}
+}
+
+func serviceHealthy(service swarm.Service) (bool, error) {
+	if service.UpdateStatus != nil {
+		switch service.UpdateStatus.State {
+		case swarm.UpdateStateUpdating:
+			return false, nil
+		case swarm.UpdateStatePaused, swarm.UpdateStateRollbackStarted, swarm.UpdateStateRollbackPaused, swarm.UpdateStateRollbackCompleted:
+			return false, fmt.Errorf("the update of the service %s failed: %s", service.Spec.Name, service.UpdateStatus.Message)
+		}
+	}
+
+	return service.ServiceStatus != nil && service.ServiceStatus.RunningTasks >= service.ServiceStatus.DesiredTasks, nil
+}
+
+// copySpec returns a deep copy of the spec of a service running another image, without its published ports which
+// cannot be shared by two services
+func copySpec(spec swarm.ServiceSpec, image string) (swarm.ServiceSpec, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return swarm.ServiceSpec{}, err
+	}
+
+	var copied swarm.ServiceSpec
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return swarm.ServiceSpec{}, err
+	}
+
+	if copied.TaskTemplate.ContainerSpec == nil {
+		return swarm.ServiceSpec{}, errors.New("only the services running containers can be rolled out")
+	}
+
+	copied.TaskTemplate.ContainerSpec.Image = image
+	copied.TaskTemplate.ForceUpdate = 0
+
+	if copied.EndpointSpec != nil {
+		copied.EndpointSpec.Ports = nil
+	}
+
+	if copied.Labels == nil {
+		copied.Labels = map[string]string{}
+	}
+
+	return copied, nil
+}
+
+func replicas(spec swarm.ServiceSpec) uint64 {
+	if spec.Mode.Replicated == nil || spec.Mode.Replicated.Replicas == nil {
+		return 0
+	}
+
+	return *spec.Mode.Replicated.Replicas
+}
+
+func setReplicas(spec *swarm.ServiceSpec, replicas uint64) {
+	spec.Mode.Replicated = &swarm.ReplicatedService{Replicas: &replicas}
+}
+
+func healthTimeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		return defaultHealthTimeout
+	}
+
+	return time.Duration(seconds) * time.Second
+}
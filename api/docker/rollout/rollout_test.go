@@ -0,0 +1,269 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/portainer/portainer/api/docker/consts"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSwarm manages the services in memory, their tasks being running as soon as they are created unless their name
+// is unhealthy
+type fakeSwarm struct {
+	services  map[string]swarm.Service
+	nextID    int
+	unhealthy string
+}
+
+func newFakeSwarm(specs ...swarm.ServiceSpec) *fakeSwarm {
+	cli := &fakeSwarm{services: map[string]swarm.Service{}}
+	for _, spec := range specs {
+		cli.ServiceCreate(context.Background(), spec, types.ServiceCreateOptions{})
+	}
+
+	return cli
+}
+
+func (cli *fakeSwarm) ServiceCreate(ctx context.Context, spec swarm.ServiceSpec, options types.ServiceCreateOptions) (types.ServiceCreateResponse, error) {
+	for _, service := range cli.services {
+		if service.Spec.Name == spec.Name {
+			return types.ServiceCreateResponse{}, errors.New("name conflicts with an existing object")
+		}
+	}
+
+	cli.nextID++
+	id := fmt.Sprintf("service-%d", cli.nextID)
+	cli.services[id] = swarm.Service{ID: id, Spec: spec}
+
+	return types.ServiceCreateResponse{ID: id}, nil
+}
+
+func (cli *fakeSwarm) ServiceInspectWithRaw(ctx context.Context, serviceID string, options types.ServiceInspectOptions) (swarm.Service, []byte, error) {
+	service, ok := cli.services[serviceID]
+	if !ok {
+		return swarm.Service{}, nil, errors.New("service not found")
+	}
+
+	return service, nil, nil
+}
+
+func (cli *fakeSwarm) ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	services := []swarm.Service{}
+
+	for _, service := range cli.services {
+		if options.Filters.Contains("id") && !options.Filters.ExactMatch("id", service.ID) {
+			continue
+		}
+
+		if options.Filters.Contains("name") && !strings.HasPrefix(service.Spec.Name, options.Filters.Get("name")[0]) {
+			continue
+		}
+
+		if options.Filters.Contains("label") && !options.Filters.MatchKVList("label", service.Spec.Labels) {
+			continue
+		}
+
+		if options.Status {
+			desired := replicas(service.Spec)
+			running := desired
+			if service.Spec.Name == cli.unhealthy {
+				running = 0
+			}
+
+			service.ServiceStatus = &swarm.ServiceStatus{RunningTasks: running, DesiredTasks: desired}
+		}
+
+		services = append(services, service)
+	}
+
+	return services, nil
+}
+
+func (cli *fakeSwarm) ServiceRemove(ctx context.Context, serviceID string) error {
+	delete(cli.services, serviceID)
+
+	return nil
+}
+
+func (cli *fakeSwarm) ServiceUpdate(ctx context.Context, serviceID string, version swarm.Version, spec swarm.ServiceSpec, options types.ServiceUpdateOptions) (types.ServiceUpdateResponse, error) {
+	service := cli.services[serviceID]
+	service.Spec = spec
+	service.Version.Index++
+	cli.services[serviceID] = service
+
+	return types.ServiceUpdateResponse{}, nil
+}
+
+func (cli *fakeSwarm) byName(name string) (swarm.Service, bool) {
+	for _, service := range cli.services {
+		if service.Spec.Name == name {
+			return service, true
+		}
+	}
+
+	return swarm.Service{}, false
+}
+
+func serviceSpec(name, image string, replicas uint64, labels map[string]string, ports ...swarm.PortConfig) swarm.ServiceSpec {
+	spec := swarm.ServiceSpec{
+		Annotations: swarm.Annotations{Name: name, Labels: labels},
+		TaskTemplate: swarm.TaskSpec{
+			ContainerSpec: &swarm.ContainerSpec{Image: image},
+			Networks:      []swarm.NetworkAttachmentConfig{{Target: "backend"}},
+		},
+		EndpointSpec: &swarm.EndpointSpec{Ports: ports},
+	}
+	setReplicas(&spec, replicas)
+
+	return spec
+}
+
+func TestCanaryReplicas(t *testing.T) {
+	is := assert.New(t)
+
+	for _, tc := range []struct {
+		total      uint64
+		percentage int
+		expected   uint64
+	}{
+		{total: 10, percentage: 10, expected: 1},
+		{total: 10, percentage: 25, expected: 3},
+		{total: 4, percentage: 50, expected: 2},
+		{total: 3, percentage: 1, expected: 1},
+		{total: 2, percentage: 99, expected: 1},
+	} {
+		replicas, err := CanaryReplicas(tc.total, tc.percentage)
+		is.NoError(err)
+		is.Equal(tc.expected, replicas, "%d%% of %d replicas", tc.percentage, tc.total)
+	}
+
+	_, err := CanaryReplicas(1, 50)
+	is.Error(err)
+
+	_, err = CanaryReplicas(4, 100)
+	is.Error(err)
+}
+
+func TestCheckService(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(CheckService(swarm.Service{Spec: serviceSpec("web", "nginx:1.25", 1, nil)}))
+	is.Error(CheckService(swarm.Service{Spec: serviceSpec("shop_web", "nginx:1.25", 1, map[string]string{consts.SwarmStackNameLabel: "shop"})}))
+	is.Error(CheckService(swarm.Service{Spec: serviceSpec("web-canary", "nginx:1.26", 1, map[string]string{CanaryOfLabel: "web"})}))
+}
+
+func TestBlueGreen(t *testing.T) {
+	is := assert.New(t)
+
+	port := swarm.PortConfig{Protocol: swarm.PortConfigProtocolTCP, TargetPort: 80, PublishedPort: 8080}
+	cli := newFakeSwarm(serviceSpec("web", "nginx:1.24", 2, map[string]string{"traefik.enable": "true", "team": "front"}, port))
+	r := &rollout{cli: cli, pollInterval: 10 * time.Millisecond}
+
+	name, err := r.blueGreen(context.Background(), BlueGreenOptions{ServiceID: "service-1", Image: "nginx:1.25", RoutingLabels: []string{"traefik.enable"}})
+	is.NoError(err)
+	is.Equal("web-green", name)
+
+	_, found := cli.byName("web")
+	is.False(found, "the previous service should be removed")
+
+	green, found := cli.byName("web-green")
+	is.True(found)
+	is.Equal("nginx:1.25", green.Spec.TaskTemplate.ContainerSpec.Image)
+	is.Equal([]swarm.PortConfig{port}, green.Spec.EndpointSpec.Ports)
+	is.Equal(uint64(2), replicas(green.Spec))
+	is.Equal(map[string]string{"traefik.enable": "true", "team": "front", ColorLabel: "green", baseNameLabel: "web"}, green.Spec.Labels)
+
+	name, err = r.blueGreen(context.Background(), BlueGreenOptions{ServiceID: green.ID, Image: "nginx:1.26", RoutingLabels: []string{"traefik.enable"}, KeepPrevious: true})
+	is.NoError(err)
+	is.Equal("web-blue", name)
+
+	previous, found := cli.byName("web-green")
+	is.True(found, "the previous service should be kept")
+	is.Equal(uint64(0), replicas(previous.Spec))
+	is.Empty(previous.Spec.EndpointSpec.Ports)
+	is.NotContains(previous.Spec.Labels, "traefik.enable")
+
+	blueService, _ := cli.byName("web-blue")
+	is.Equal([]swarm.PortConfig{port}, blueService.Spec.EndpointSpec.Ports)
+
+	name, err = r.blueGreen(context.Background(), BlueGreenOptions{ServiceID: blueService.ID, Image: "nginx:1.27"})
+	is.NoError(err)
+	is.Equal("web-green", name, "the retired service should be replaced")
+	is.Len(cli.services, 1)
+
+	current, _ := cli.byName("web-green")
+	cli.unhealthy = "web-blue"
+
+	_, err = r.blueGreen(context.Background(), BlueGreenOptions{ServiceID: current.ID, Image: "nginx:broken", HealthTimeout: 1})
+	is.ErrorContains(err, "is not healthy after 1s")
+
+	_, found = cli.byName("web-blue")
+	is.False(found, "the unhealthy service should be removed")
+
+	current, _ = cli.byName("web-green")
+	is.Equal([]swarm.PortConfig{port}, current.Spec.EndpointSpec.Ports, "the current service should keep its ports")
+	is.Equal("nginx:1.27", current.Spec.TaskTemplate.ContainerSpec.Image)
+}
+
+func TestCanary(t *testing.T) {
+	is := assert.New(t)
+
+	port := swarm.PortConfig{Protocol: swarm.PortConfigProtocolTCP, TargetPort: 80, PublishedPort: 8080}
+	cli := newFakeSwarm(serviceSpec("api", "api:1", 4, nil, port))
+	r := &rollout{cli: cli, pollInterval: 10 * time.Millisecond}
+
+	replicasOf := func(name string) uint64 {
+		service, _ := cli.byName(name)
+		return replicas(service.Spec)
+	}
+
+	is.NoError(r.canary(context.Background(), CanaryOptions{ServiceID: "service-1", Image: "api:2", Percentage: 25}))
+
+	canary, found := cli.byName("api-canary")
+	is.True(found)
+	is.Equal("api:2", canary.Spec.TaskTemplate.ContainerSpec.Image)
+	is.Equal("api", canary.Spec.Labels[CanaryOfLabel])
+	is.Equal([]string{"api"}, canary.Spec.TaskTemplate.Networks[0].Aliases)
+	is.Empty(canary.Spec.EndpointSpec.Ports)
+	is.Equal(uint64(1), replicasOf("api-canary"))
+	is.Equal(uint64(3), replicasOf("api"))
+
+	is.NoError(r.canary(context.Background(), CanaryOptions{ServiceID: "service-1", Percentage: 50}))
+	is.Equal(uint64(2), replicasOf("api-canary"))
+	is.Equal(uint64(2), replicasOf("api"))
+
+	is.NoError(r.canary(context.Background(), CanaryOptions{ServiceID: "service-1", Percentage: 0}))
+	_, found = cli.byName("api-canary")
+	is.False(found, "the canary should be removed")
+	is.Equal(uint64(4), replicasOf("api"))
+
+	is.NoError(r.canary(context.Background(), CanaryOptions{ServiceID: "service-1", Image: "api:3", Percentage: 75}))
+	is.Equal(uint64(3), replicasOf("api-canary"))
+	is.Equal(uint64(1), replicasOf("api"))
+
+	is.NoError(r.canary(context.Background(), CanaryOptions{ServiceID: "service-1", Percentage: 100}))
+	_, found = cli.byName("api-canary")
+	is.False(found, "the promoted canary should be removed")
+
+	stable, _ := cli.byName("api")
+	is.Equal("api:3", stable.Spec.TaskTemplate.ContainerSpec.Image)
+	is.Equal(uint64(4), replicas(stable.Spec))
+	is.Equal([]swarm.PortConfig{port}, stable.Spec.EndpointSpec.Ports)
+
+	cli.unhealthy = "api-canary"
+
+	err := r.canary(context.Background(), CanaryOptions{ServiceID: "service-1", Image: "api:broken", Percentage: 50, HealthTimeout: 1})
+	is.ErrorContains(err, "is not healthy after 1s")
+
+	_, found = cli.byName("api-canary")
+	is.False(found, "the unhealthy canary should be removed")
+	is.Equal(uint64(4), replicasOf("api"), "the service should keep its replicas")
+}
@@ -12,6 +12,8 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
 	"github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/retries"
+	"github.com/portainer/portainer/api/timeouts"
 	"github.com/rs/zerolog/log"
 )
 
@@ -27,18 +29,24 @@ func NewSnapshotter(clientFactory *dockerclient.ClientFactory) *Snapshotter {
 	}
 }
 
-// CreateSnapshot creates a snapshot of a specific Docker environment(endpoint)
-func (snapshotter *Snapshotter) CreateSnapshot(endpoint *portainer.Endpoint) (*portainer.DockerSnapshot, error) {
-	cli, err := snapshotter.clientFactory.CreateClient(endpoint, "", nil)
+// CreateSnapshot creates a snapshot of a specific Docker environment(endpoint), skipping the excluded sections
+func (snapshotter *Snapshotter) CreateSnapshot(endpoint *portainer.Endpoint, content portainer.DockerSnapshotContent) (*portainer.DockerSnapshot, error) {
+	timeout := timeouts.Current().Snapshot
+
+	cli, err := snapshotter.clientFactory.CreateClient(endpoint, "", &timeout)
 	if err != nil {
 		return nil, err
 	}
 	defer cli.Close()
 
-	return snapshot(cli, endpoint)
+	if err := dockerclient.EnableRetries(cli, retries.SubsystemSnapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot(cli, endpoint, content)
 }
 
-func snapshot(cli *client.Client, endpoint *portainer.Endpoint) (*portainer.DockerSnapshot, error) {
+func snapshot(cli *client.Client, endpoint *portainer.Endpoint, content portainer.DockerSnapshotContent) (*portainer.DockerSnapshot, error) {
 	_, err := cli.Ping(context.Background())
 	if err != nil {
 		return nil, err
@@ -54,7 +62,7 @@ func snapshot(cli *client.Client, endpoint *portainer.Endpoint) (*portainer.Dock
 	}
 
 	if snapshot.Swarm {
-		err = snapshotSwarmServices(snapshot, cli)
+		err = snapshotSwarmServices(snapshot, cli, content)
 		if err != nil {
 			log.Warn().Str("environment", endpoint.Name).Err(err).Msg("unable to snapshot Swarm services")
 		}
@@ -65,24 +73,30 @@ func snapshot(cli *client.Client, endpoint *portainer.Endpoint) (*portainer.Dock
 		}
 	}
 
-	err = snapshotContainers(snapshot, cli)
+	err = snapshotContainers(snapshot, cli, content)
 	if err != nil {
 		log.Warn().Str("environment", endpoint.Name).Err(err).Msg("unable to snapshot containers")
 	}
 
-	err = snapshotImages(snapshot, cli)
-	if err != nil {
-		log.Warn().Str("environment", endpoint.Name).Err(err).Msg("unable to snapshot images")
+	if !content.ExcludeImages {
+		err = snapshotImages(snapshot, cli)
+		if err != nil {
+			log.Warn().Str("environment", endpoint.Name).Err(err).Msg("unable to snapshot images")
+		}
 	}
 
-	err = snapshotVolumes(snapshot, cli)
-	if err != nil {
-		log.Warn().Str("environment", endpoint.Name).Err(err).Msg("unable to snapshot volumes")
+	if !content.ExcludeVolumes {
+		err = snapshotVolumes(snapshot, cli)
+		if err != nil {
+			log.Warn().Str("environment", endpoint.Name).Err(err).Msg("unable to snapshot volumes")
+		}
 	}
 
-	err = snapshotNetworks(snapshot, cli)
-	if err != nil {
-		log.Warn().Str("environment", endpoint.Name).Err(err).Msg("unable to snapshot networks")
+	if !content.ExcludeNetworks {
+		err = snapshotNetworks(snapshot, cli)
+		if err != nil {
+			log.Warn().Str("environment", endpoint.Name).Err(err).Msg("unable to snapshot networks")
+		}
 	}
 
 	err = snapshotVersion(snapshot, cli)
@@ -125,7 +139,7 @@ func snapshotNodes(snapshot *portainer.DockerSnapshot, cli *client.Client) error
 	return nil
 }
 
-func snapshotSwarmServices(snapshot *portainer.DockerSnapshot, cli *client.Client) error {
+func snapshotSwarmServices(snapshot *portainer.DockerSnapshot, cli *client.Client, content portainer.DockerSnapshotContent) error {
 	stacks := make(map[string]struct{})
 
 	services, err := cli.ServiceList(context.Background(), types.ServiceListOptions{})
@@ -142,11 +156,13 @@ func snapshotSwarmServices(snapshot *portainer.DockerSnapshot, cli *client.Clien
 	}
 
 	snapshot.ServiceCount = len(services)
-	snapshot.StackCount += len(stacks)
+	if !content.ExcludeStacks {
+		snapshot.StackCount += len(stacks)
+	}
 	return nil
 }
 
-func snapshotContainers(snapshot *portainer.DockerSnapshot, cli *client.Client) error {
+func snapshotContainers(snapshot *portainer.DockerSnapshot, cli *client.Client, content portainer.DockerSnapshotContent) error {
 	containers, err := cli.ContainerList(context.Background(), types.ContainerListOptions{All: true})
 	if err != nil {
 		return err
@@ -165,32 +181,35 @@ func snapshotContainers(snapshot *portainer.DockerSnapshot, cli *client.Client)
 		} else if container.State == "running" {
 			runningContainers++
 
-			// snapshot GPUs
-			response, err := cli.ContainerInspect(context.Background(), container.ID)
-			if err != nil {
-				// Inspect a container will fail when the container runs on a different
-				// Swarm node, so it is better to log the error instead of return error
-				// when the Swarm mode is enabled
-				if !snapshot.Swarm {
-					return err
+			// the inspection of each running container is the heaviest part of the snapshot on large engines
+			if !content.ExcludeContainerDetails {
+				// snapshot GPUs
+				response, err := cli.ContainerInspect(context.Background(), container.ID)
+				if err != nil {
+					// Inspect a container will fail when the container runs on a different
+					// Swarm node, so it is better to log the error instead of return error
+					// when the Swarm mode is enabled
+					if !snapshot.Swarm {
+						return err
+					} else {
+						log.Info().Str("container", container.ID).Err(err).Msg("unable to inspect container in other Swarm nodes")
+					}
 				} else {
-					log.Info().Str("container", container.ID).Err(err).Msg("unable to inspect container in other Swarm nodes")
-				}
-			} else {
-				var gpuOptions *_container.DeviceRequest = nil
-				for _, deviceRequest := range response.HostConfig.Resources.DeviceRequests {
-					deviceRequest := deviceRequest
-					if deviceRequest.Driver == "nvidia" || deviceRequest.Capabilities[0][0] == "gpu" {
-						gpuOptions = &deviceRequest
+					var gpuOptions *_container.DeviceRequest = nil
+					for _, deviceRequest := range response.HostConfig.Resources.DeviceRequests {
+						deviceRequest := deviceRequest
+						if deviceRequest.Driver == "nvidia" || deviceRequest.Capabilities[0][0] == "gpu" {
+							gpuOptions = &deviceRequest
+						}
 					}
-				}
 
-				if gpuOptions != nil {
-					if gpuOptions.Count == -1 {
-						gpuUseAll = true
-					}
-					for _, id := range gpuOptions.DeviceIDs {
-						gpuUseSet[id] = struct{}{}
+					if gpuOptions != nil {
+						if gpuOptions.Count == -1 {
+							gpuUseAll = true
+						}
+						for _, id := range gpuOptions.DeviceIDs {
+							gpuUseSet[id] = struct{}{}
+						}
 					}
 				}
 			}
@@ -221,7 +240,9 @@ func snapshotContainers(snapshot *portainer.DockerSnapshot, cli *client.Client)
 	snapshot.StoppedContainerCount = stoppedContainers
 	snapshot.HealthyContainerCount = healthyContainers
 	snapshot.UnhealthyContainerCount = unhealthyContainers
-	snapshot.StackCount += len(stacks)
+	if !content.ExcludeStacks {
+		snapshot.StackCount += len(stacks)
+	}
 	for _, container := range containers {
 		snapshot.SnapshotRaw.Containers = append(snapshot.SnapshotRaw.Containers, portainer.DockerContainerSnapshot{Container: container})
 	}
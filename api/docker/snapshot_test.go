@@ -0,0 +1,114 @@
+package docker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEngine answers the calls of the snapshots with empty resources and a single running container, recording the
+// paths it was requested
+type fakeEngine struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (engine *fakeEngine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if i := strings.Index(path[1:], "/"); strings.HasPrefix(path, "/v") && i > 0 {
+		path = path[i+1:]
+	}
+
+	engine.mu.Lock()
+	engine.paths = append(engine.paths, path)
+	engine.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	switch path {
+	case "/_ping":
+		w.Write([]byte("OK"))
+	case "/containers/json":
+		w.Write([]byte(`[{"Id":"c1","Names":["/web-nginx-1"],"State":"running","SizeRw":512,"Labels":{"com.docker.compose.project":"web","com.docker.compose.service":"nginx"}}]`))
+	case "/containers/c1/json":
+		w.Write([]byte(`{"Id":"c1","HostConfig":{}}`))
+	case "/containers/c1/stats":
+		w.Write([]byte(`{
+			"cpu_stats":{"cpu_usage":{"total_usage":300},"system_cpu_usage":2000,"online_cpus":2},
+			"precpu_stats":{"cpu_usage":{"total_usage":200},"system_cpu_usage":1000},
+			"memory_stats":{"usage":1000,"limit":4000,"stats":{"inactive_file":200}}
+		}`))
+	case "/system/df":
+		w.Write([]byte(`{
+			"Volumes":[{"Name":"data","Driver":"local","UsageData":{"Size":2048,"RefCount":1}},{"Name":"nfs","Driver":"nfs"}],
+			"Containers":[{"Id":"c1","Names":["/web"],"SizeRw":512,"SizeRootFs":4096}]
+		}`))
+	case "/images/json", "/networks":
+		w.Write([]byte(`[]`))
+	default:
+		w.Write([]byte(`{}`))
+	}
+}
+
+func (engine *fakeEngine) requested(path string) bool {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	return slices.Contains(engine.paths, path)
+}
+
+func TestSnapshot_Content(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    portainer.DockerSnapshotContent
+		skipped    []string
+		stackCount int
+	}{
+		{
+			name:       "full snapshot",
+			stackCount: 1,
+		},
+		{
+			name: "excluded sections",
+			content: portainer.DockerSnapshotContent{
+				ExcludeImages:           true,
+				ExcludeVolumes:          true,
+				ExcludeNetworks:         true,
+				ExcludeContainerDetails: true,
+				ExcludeStacks:           true,
+			},
+			skipped: []string{"/images/json", "/volumes", "/networks", "/containers/c1/json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := assert.New(t)
+
+			engine := &fakeEngine{}
+			server := httptest.NewServer(engine)
+			defer server.Close()
+
+			cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(server.URL, "http://")), client.WithVersion("1.41"))
+			is.NoError(err)
+			defer cli.Close()
+
+			snapshot, err := snapshot(cli, &portainer.Endpoint{Name: "docker"}, tt.content)
+			is.NoError(err)
+			is.Equal(1, snapshot.RunningContainerCount)
+			is.Equal(tt.stackCount, snapshot.StackCount)
+
+			for _, path := range []string{"/images/json", "/volumes", "/networks", "/containers/c1/json"} {
+				is.Equal(!slices.Contains(tt.skipped, path), engine.requested(path), path)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/consts"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	pkgerrors "github.com/pkg/errors"
+)
+
+const (
+	// StackUsageSourceLive designates the usage computed from the statistics of the running containers
+	StackUsageSourceLive = "live"
+	// StackUsageSourceSnapshot designates the usage computed from the latest snapshot of the environment, which does
+	// not hold the CPU and memory usage
+	StackUsageSourceSnapshot = "snapshot"
+
+	// statsConcurrency bounds the number of containers whose statistics are retrieved at the same time, each request
+	// taking about a second as the engine samples the CPU usage twice
+	statsConcurrency = 8
+
+	composeServiceLabel = "com.docker.compose.service"
+	swarmServiceLabel   = "com.docker.swarm.service.name"
+)
+
+type (
+	// StackUsage represents the resources used by the containers of a stack
+	StackUsage struct {
+		StackID   portainer.StackID `json:"StackId" example:"1"`
+		StackName string            `json:"StackName" example:"web"`
+		// Source of the usage, live or snapshot
+		Source string `json:"Source" example:"live"`
+		// Unix timestamp of the computation, or of the snapshot
+		Time int64 `json:"Time" example:"1700000000"`
+		// Number of containers of the stack, and of the running ones
+		ContainerCount        int `json:"ContainerCount" example:"3"`
+		RunningContainerCount int `json:"RunningContainerCount" example:"2"`
+		// Sum of the CPU usage of the containers, 100 being a full CPU
+		CPUPercent float64 `json:"CPUPercent" example:"12.5"`
+		// Sum of the memory used by the containers, in bytes
+		MemoryUsage int64 `json:"MemoryUsage" example:"104857600"`
+		// Sum of the size of the writable layers of the containers, in bytes
+		DiskUsage  int64                 `json:"DiskUsage" example:"4096"`
+		Containers []StackContainerUsage `json:"Containers"`
+	}
+
+	// StackContainerUsage represents the resources used by a container of a stack
+	StackContainerUsage struct {
+		ID      string `json:"Id" example:"3a8b3e1c5f2d"`
+		Name    string `json:"Name" example:"web-nginx-1"`
+		Service string `json:"Service" example:"nginx"`
+		State   string `json:"State" example:"running"`
+		// CPU usage of the container, 100 being a full CPU
+		CPUPercent float64 `json:"CPUPercent" example:"6.25"`
+		// Memory used by the container, excluding the page cache, in bytes
+		MemoryUsage int64 `json:"MemoryUsage" example:"52428800"`
+		// Memory limit of the container, in bytes
+		MemoryLimit int64 `json:"MemoryLimit" example:"2147483648"`
+		// Size of the writable layer of the container, in bytes
+		DiskUsage int64 `json:"DiskUsage" example:"2048"`
+	}
+)
+
+// StackLabel returns the label identifying the containers of a stack
+func StackLabel(stack *portainer.Stack) string {
+	if stack.Type == portainer.DockerSwarmStack {
+		return consts.SwarmStackNameLabel
+	}
+
+	return consts.ComposeStackNameLabel
+}
+
+// InspectStackUsage computes the resources used by the containers of a stack from their statistics. The containers
+// whose statistics cannot be retrieved, such as the tasks running on the other nodes of a Swarm cluster when the
+// environment is not managed through an agent, are reported without CPU and memory usage.
+func InspectStackUsage(ctx context.Context, cli *client.Client, stack *portainer.Stack) (*StackUsage, error) {
+	label := StackLabel(stack)
+
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Size:    true,
+		Filters: filters.NewArgs(filters.Arg("label", label+"="+stack.Name)),
+	})
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "unable to list the containers of the stack")
+	}
+
+	return rollupStackUsage(stack, StackUsageSourceLive, time.Now().Unix(), inspectContainersUsage(ctx, cli, containers)), nil
+}
+
+// InspectStacksUsage computes the resources used by the running containers of an environment from their statistics,
+// grouped by the name of the stack they belong to, the containers outside of the stacks being grouped under an empty
+// name
+func InspectStacksUsage(ctx context.Context, cli *client.Client) (map[string][]StackContainerUsage, error) {
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{})
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "unable to list the containers")
+	}
+
+	usages := make(map[string][]StackContainerUsage)
+	for i, usage := range inspectContainersUsage(ctx, cli, containers) {
+		stackName := containers[i].Labels[consts.ComposeStackNameLabel]
+		if stackName == "" {
+			stackName = containers[i].Labels[consts.SwarmStackNameLabel]
+		}
+
+		usages[stackName] = append(usages[stackName], usage)
+	}
+
+	return usages, nil
+}
+
+// inspectContainersUsage retrieves the statistics of the running containers, in the order of the containers
+func inspectContainersUsage(ctx context.Context, cli *client.Client, containers []types.Container) []StackContainerUsage {
+	usages := make([]StackContainerUsage, len(containers))
+	slots := make(chan struct{}, statsConcurrency)
+
+	var wg sync.WaitGroup
+	for i, container := range containers {
+		usages[i] = containerUsage(container)
+		if container.State != "running" {
+			continue
+		}
+
+		wg.Add(1)
+		go func(usage *StackContainerUsage) {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			stats, err := containerStats(ctx, cli, usage.ID)
+			if err != nil {
+				return
+			}
+
+			usage.CPUPercent = cpuPercent(stats)
+			usage.MemoryUsage = memoryUsage(stats)
+			usage.MemoryLimit = int64(stats.MemoryStats.Limit)
+		}(&usages[i])
+	}
+	wg.Wait()
+
+	return usages
+}
+
+// SnapshotStackUsage computes the resources used by the containers of a stack from the latest snapshot of its
+// environment, the disk usage being known when it was collected
+func SnapshotStackUsage(stack *portainer.Stack, snapshot *portainer.Snapshot) *StackUsage {
+	label := StackLabel(stack)
+
+	sizes := make(map[string]int64)
+	if snapshot.DockerDiskUsage != nil {
+		for _, container := range snapshot.DockerDiskUsage.Containers {
+			sizes[container.ID] = container.SizeRw
+		}
+	}
+
+	usages := []StackContainerUsage{}
+	for _, container := range snapshot.Docker.SnapshotRaw.Containers {
+		if container.Labels[label] != stack.Name {
+			continue
+		}
+
+		usage := containerUsage(container.Container)
+		usage.DiskUsage = sizes[container.ID]
+		usages = append(usages, usage)
+	}
+
+	return rollupStackUsage(stack, StackUsageSourceSnapshot, snapshot.Docker.Time, usages)
+}
+
+func containerUsage(container types.Container) StackContainerUsage {
+	name := ""
+	if len(container.Names) > 0 {
+		name = strings.TrimPrefix(container.Names[0], "/")
+	}
+
+	service := container.Labels[composeServiceLabel]
+	if service == "" {
+		service = container.Labels[swarmServiceLabel]
+	}
+
+	return StackContainerUsage{
+		ID:        container.ID,
+		Name:      name,
+		Service:   service,
+		State:     container.State,
+		DiskUsage: container.SizeRw,
+	}
+}
+
+func rollupStackUsage(stack *portainer.Stack, source string, timestamp int64, containers []StackContainerUsage) *StackUsage {
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+
+	usage := &StackUsage{
+		StackID:        stack.ID,
+		StackName:      stack.Name,
+		Source:         source,
+		Time:           timestamp,
+		ContainerCount: len(containers),
+		Containers:     containers,
+	}
+
+	for _, container := range containers {
+		if container.State == "running" {
+			usage.RunningContainerCount++
+		}
+
+		usage.CPUPercent += container.CPUPercent
+		usage.MemoryUsage += container.MemoryUsage
+		usage.DiskUsage += container.DiskUsage
+	}
+
+	return usage
+}
+
+func containerStats(ctx context.Context, cli *client.Client, containerID string) (*types.StatsJSON, error) {
+	response, err := cli.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var stats types.StatsJSON
+	if err := json.NewDecoder(response.Body).Decode(&stats); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// cpuPercent computes the CPU usage of a container between the two samples of its statistics, as the docker stats
+// command does
+func cpuPercent(stats *types.StatsJSON) float64 {
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+
+	onlineCPUs := float64(stats.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+
+	return cpuDelta / systemDelta * onlineCPUs * 100
+}
+
+// memoryUsage returns the memory used by a container without its inactive page cache, as the docker stats command
+// does
+func memoryUsage(stats *types.StatsJSON) int64 {
+	usage := stats.MemoryStats.Usage
+
+	// cgroup v1 reports total_inactive_file and cgroup v2 inactive_file
+	cache, ok := stats.MemoryStats.Stats["total_inactive_file"]
+	if !ok {
+		cache = stats.MemoryStats.Stats["inactive_file"]
+	}
+
+	if cache < usage {
+		usage -= cache
+	}
+
+	return int64(usage)
+}
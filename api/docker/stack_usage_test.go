@@ -0,0 +1,92 @@
+package docker
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspectStackUsage(t *testing.T) {
+	is := assert.New(t)
+
+	engine := &fakeEngine{}
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+strings.TrimPrefix(server.URL, "http://")), client.WithVersion("1.41"))
+	is.NoError(err)
+	defer cli.Close()
+
+	stack := &portainer.Stack{ID: 1, Name: "web", Type: portainer.DockerComposeStack}
+
+	usage, err := InspectStackUsage(context.Background(), cli, stack)
+	if !is.NoError(err) {
+		return
+	}
+
+	is.Equal(StackUsageSourceLive, usage.Source)
+	is.Equal(1, usage.ContainerCount)
+	is.Equal(1, usage.RunningContainerCount)
+	is.InDelta(20.0, usage.CPUPercent, 0.001)
+	is.Equal(int64(800), usage.MemoryUsage)
+	is.Equal(int64(512), usage.DiskUsage)
+	is.Equal([]StackContainerUsage{{
+		ID:          "c1",
+		Name:        "web-nginx-1",
+		Service:     "nginx",
+		State:       "running",
+		CPUPercent:  usage.CPUPercent,
+		MemoryUsage: 800,
+		MemoryLimit: 4000,
+		DiskUsage:   512,
+	}}, usage.Containers)
+}
+
+func TestSnapshotStackUsage(t *testing.T) {
+	is := assert.New(t)
+
+	container := func(id, state string, labels map[string]string) portainer.DockerContainerSnapshot {
+		return portainer.DockerContainerSnapshot{Container: types.Container{ID: id, Names: []string{"/" + id}, State: state, Labels: labels}}
+	}
+
+	snapshot := &portainer.Snapshot{
+		Docker: &portainer.DockerSnapshot{Time: 42},
+		DockerDiskUsage: &portainer.DockerDiskUsage{Containers: []portainer.DockerContainerUsage{
+			{ID: "web.1", SizeRw: 100},
+			{ID: "db.1", SizeRw: 1000},
+		}},
+	}
+	snapshot.Docker.SnapshotRaw.Containers = []portainer.DockerContainerSnapshot{
+		container("web.1", "running", map[string]string{"com.docker.stack.namespace": "shop", "com.docker.swarm.service.name": "shop_web"}),
+		container("web.2", "exited", map[string]string{"com.docker.stack.namespace": "shop", "com.docker.swarm.service.name": "shop_web"}),
+		container("db.1", "running", map[string]string{"com.docker.stack.namespace": "other"}),
+	}
+
+	usage := SnapshotStackUsage(&portainer.Stack{ID: 2, Name: "shop", Type: portainer.DockerSwarmStack}, snapshot)
+
+	is.Equal(StackUsageSourceSnapshot, usage.Source)
+	is.Equal(int64(42), usage.Time)
+	is.Equal(2, usage.ContainerCount)
+	is.Equal(1, usage.RunningContainerCount)
+	is.Equal(int64(100), usage.DiskUsage)
+	is.Equal("shop_web", usage.Containers[0].Service)
+}
+
+func Test_memoryUsage(t *testing.T) {
+	is := assert.New(t)
+
+	stats := &types.StatsJSON{}
+	stats.MemoryStats.Usage = 1000
+	stats.MemoryStats.Stats = map[string]uint64{"total_inactive_file": 300, "inactive_file": 100}
+	is.Equal(int64(700), memoryUsage(stats))
+
+	stats.MemoryStats.Stats = nil
+	is.Equal(int64(1000), memoryUsage(stats))
+}
@@ -0,0 +1,127 @@
+package docker
+
+import (
+	"context"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultHelperImage is the image of the helper containers mounting the volumes when none is specified
+	DefaultHelperImage = "alpine:latest"
+	// volumeMountPath is the mount point of the volume in the helper container, the entries of the archives are
+	// prefixed with its base name
+	volumeMountPath = "/volume"
+	// helperLabel identifies the helper containers
+	helperLabel = "io.portainer.volume-helper"
+)
+
+// ExportVolume writes a tar archive of the contents of a volume. The volume is mounted read-only in a helper
+// container which is never started.
+func ExportVolume(ctx context.Context, cli *client.Client, volumeName, helperImage string, w io.Writer) error {
+	if _, err := cli.VolumeInspect(ctx, volumeName); err != nil {
+		return errors.Wrapf(err, "unable to find the volume %s", volumeName)
+	}
+
+	containerID, err := createHelperContainer(ctx, cli, volumeName, helperImage, true)
+	if err != nil {
+		return err
+	}
+	defer removeHelperContainer(ctx, cli, containerID)
+
+	content, _, err := cli.CopyFromContainer(ctx, containerID, volumeMountPath)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read the contents of the volume %s", volumeName)
+	}
+	defer content.Close()
+
+	_, err = io.Copy(w, content)
+
+	return errors.Wrapf(err, "unable to export the contents of the volume %s", volumeName)
+}
+
+// ImportVolume extracts a tar archive created by ExportVolume into a volume, which is created with the options when
+// it does not exist. The existing files of the volume are overwritten.
+func ImportVolume(ctx context.Context, cli *client.Client, options volume.CreateOptions, helperImage string, archive io.Reader) error {
+	_, err := cli.VolumeInspect(ctx, options.Name)
+	if client.IsErrNotFound(err) {
+		_, err = cli.VolumeCreate(ctx, options)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "unable to prepare the volume %s", options.Name)
+	}
+
+	containerID, err := createHelperContainer(ctx, cli, options.Name, helperImage, false)
+	if err != nil {
+		return err
+	}
+	defer removeHelperContainer(ctx, cli, containerID)
+
+	err = cli.CopyToContainer(ctx, containerID, "/", archive, types.CopyToContainerOptions{})
+
+	return errors.Wrapf(err, "unable to import the contents of the volume %s", options.Name)
+}
+
+func createHelperContainer(ctx context.Context, cli *client.Client, volumeName, helperImage string, readOnly bool) (string, error) {
+	if helperImage == "" {
+		helperImage = DefaultHelperImage
+	}
+
+	if err := ensureImage(ctx, cli, helperImage); err != nil {
+		return "", err
+	}
+
+	created, err := cli.ContainerCreate(ctx,
+		&container.Config{
+			Image:  helperImage,
+			Cmd:    []string{"true"},
+			Labels: map[string]string{helperLabel: volumeName},
+		},
+		&container.HostConfig{
+			Mounts: []mount.Mount{{
+				Type:     mount.TypeVolume,
+				Source:   volumeName,
+				Target:   volumeMountPath,
+				ReadOnly: readOnly,
+			}},
+		},
+		nil, nil, "")
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to create the helper container mounting the volume %s", volumeName)
+	}
+
+	return created.ID, nil
+}
+
+func removeHelperContainer(ctx context.Context, cli *client.Client, containerID string) {
+	err := cli.ContainerRemove(context.WithoutCancel(ctx), containerID, types.ContainerRemoveOptions{Force: true})
+	if err != nil {
+		log.Warn().Err(err).Str("container_id", containerID).Msg("unable to remove the volume helper container")
+	}
+}
+
+func ensureImage(ctx context.Context, cli *client.Client, image string) error {
+	_, _, err := cli.ImageInspectWithRaw(ctx, image)
+	if err == nil {
+		return nil
+	} else if !client.IsErrNotFound(err) {
+		return errors.Wrapf(err, "unable to inspect the helper image %s", image)
+	}
+
+	out, err := cli.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "unable to pull the helper image %s", image)
+	}
+	defer out.Close()
+
+	_, err = io.Copy(io.Discard, out)
+
+	return errors.Wrapf(err, "unable to pull the helper image %s", image)
+}
@@ -11,6 +11,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/proxy"
 	"github.com/portainer/portainer/api/http/proxy/factory"
+	"github.com/portainer/portainer/api/secrets"
 	"github.com/portainer/portainer/api/stacks/stackutils"
 	"github.com/portainer/portainer/pkg/libstack"
 
@@ -19,16 +20,18 @@ import (
 
 // ComposeStackManager is a wrapper for docker-compose binary
 type ComposeStackManager struct {
-	deployer     libstack.Deployer
-	proxyManager *proxy.Manager
+	deployer       libstack.Deployer
+	proxyManager   *proxy.Manager
+	secretsService *secrets.Service
 }
 
 // NewComposeStackManager returns a docker-compose wrapper if corresponding binary present, otherwise nil
-func NewComposeStackManager(deployer libstack.Deployer, proxyManager *proxy.Manager) (*ComposeStackManager, error) {
+func NewComposeStackManager(deployer libstack.Deployer, proxyManager *proxy.Manager, secretsService *secrets.Service) (*ComposeStackManager, error) {
 
 	return &ComposeStackManager{
-		deployer:     deployer,
-		proxyManager: proxyManager,
+		deployer:       deployer,
+		proxyManager:   proxyManager,
+		secretsService: secretsService,
 	}, nil
 }
 
@@ -48,9 +51,9 @@ func (manager *ComposeStackManager) Up(ctx context.Context, stack *portainer.Sta
 		defer proxy.Close()
 	}
 
-	envFilePath, err := createEnvFile(stack)
+	envFilePath, env, err := manager.prepareEnv(ctx, stack)
 	if err != nil {
-		return errors.Wrap(err, "failed to create env file")
+		return err
 	}
 
 	filePaths := stackutils.GetStackFilePaths(stack, true)
@@ -58,6 +61,7 @@ func (manager *ComposeStackManager) Up(ctx context.Context, stack *portainer.Sta
 		Options: libstack.Options{
 			WorkingDir:  stack.ProjectPath,
 			EnvFilePath: envFilePath,
+			Env:         env,
 			Host:        url,
 			ProjectName: stack.Name,
 		},
@@ -101,15 +105,16 @@ func (manager *ComposeStackManager) Pull(ctx context.Context, stack *portainer.S
 		defer proxy.Close()
 	}
 
-	envFilePath, err := createEnvFile(stack)
+	envFilePath, env, err := manager.prepareEnv(ctx, stack)
 	if err != nil {
-		return errors.Wrap(err, "failed to create env file")
+		return err
 	}
 
 	filePaths := stackutils.GetStackFilePaths(stack, true)
 	err = manager.deployer.Pull(ctx, filePaths, libstack.Options{
 		WorkingDir:  stack.ProjectPath,
 		EnvFilePath: envFilePath,
+		Env:         env,
 		Host:        url,
 		ProjectName: stack.Name,
 	})
@@ -134,6 +139,39 @@ func (manager *ComposeStackManager) fetchEndpointProxy(endpoint *portainer.Endpo
 	return fmt.Sprintf("tcp://127.0.0.1:%d", proxy.Port), proxy, nil
 }
 
+// prepareEnv creates the env file of the stack, and resolves the environment variables referencing secrets. The
+// secrets are passed to the command environment only, so that they are not written in the env file.
+func (manager *ComposeStackManager) prepareEnv(ctx context.Context, stack *portainer.Stack) (string, []string, error) {
+	if manager.secretsService == nil || !manager.secretsService.HasReferences(stack.Env) {
+		envFilePath, err := createEnvFile(stack)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "failed to create env file")
+		}
+
+		return envFilePath, nil, nil
+	}
+
+	plain, resolved, err := manager.secretsService.ResolveEnv(ctx, stack.Env)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to resolve the stack secrets")
+	}
+
+	plainStack := *stack
+	plainStack.Env = plain
+
+	envFilePath, err := createEnvFile(&plainStack)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to create env file")
+	}
+
+	env := make([]string, 0, len(resolved))
+	for _, pair := range resolved {
+		env = append(env, pair.Name+"="+pair.Value)
+	}
+
+	return envFilePath, env, nil
+}
+
 // createEnvFile creates a file that would hold both "in-place" and default environment variables.
 // It will return the name of the file if the stack has "in-place" env vars, otherwise empty string.
 func createEnvFile(stack *portainer.Stack) (string, error) {
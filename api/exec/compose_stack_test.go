@@ -1,12 +1,15 @@
 package exec
 
 import (
+	"context"
 	"io"
 	"os"
 	"path"
 	"testing"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/secrets"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -84,3 +87,38 @@ func Test_createEnvFile_mergesDefultAndInplaceEnvVars(t *testing.T) {
 
 	assert.Equal(t, []byte("VAR1=VAL1\nVAR2=VAL2\n\nVAR1=NEW_VAL1\nVAR3=VAL3\n"), content)
 }
+
+type staticSecretsProvider map[string]string
+
+func (provider staticSecretsProvider) Secret(ctx context.Context, path, key string) (string, error) {
+	return provider[path+"#"+key], nil
+}
+
+func Test_prepareEnv_keepsSecretsOutOfEnvFile(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	secretsService := secrets.NewService(store)
+	secretsService.Register("test", func(settings portainer.SecretsSettings) (secrets.Provider, error) {
+		return staticSecretsProvider{"app#password": "s3cr3t"}, nil
+	})
+
+	manager := &ComposeStackManager{secretsService: secretsService}
+
+	dir := t.TempDir()
+	stack := &portainer.Stack{
+		ProjectPath: dir,
+		Env: []portainer.Pair{
+			{Name: "HOST", Value: "db"},
+			{Name: "PASSWORD", Value: "test:app#password"},
+		},
+	}
+
+	envFilePath, env, err := manager.prepareEnv(context.Background(), stack)
+	assert.NoError(t, err)
+	assert.Equal(t, "stack.env", envFilePath)
+	assert.Equal(t, []string{"PASSWORD=s3cr3t"}, env)
+
+	content, err := os.ReadFile(path.Join(dir, "stack.env"))
+	assert.NoError(t, err)
+	assert.Equal(t, "HOST=db\n", string(content))
+}
@@ -19,6 +19,10 @@ func (deployer *kubernetesMockDeployer) Remove(userID portainer.UserID, endpoint
 	return "", nil
 }
 
+func (deployer *kubernetesMockDeployer) Validate(userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) (string, error) {
+	return "", nil
+}
+
 func (deployer *kubernetesMockDeployer) ConvertCompose(data []byte) ([]byte, error) {
 	return nil, nil
 }
@@ -87,7 +87,13 @@ func (deployer *KubernetesDeployer) Remove(userID portainer.UserID, endpoint *po
 	return deployer.command("delete", userID, endpoint, manifestFiles, namespace)
 }
 
-func (deployer *KubernetesDeployer) command(operation string, userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) (string, error) {
+// Validate submits the Kubernetes resources defined in manifest(s) to the API server without persisting them.
+// The resources are validated against the schema known by the API server as well as its admission chain.
+func (deployer *KubernetesDeployer) Validate(userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string) (string, error) {
+	return deployer.command("apply", userID, endpoint, manifestFiles, namespace, "--dry-run=server", "--validate=strict")
+}
+
+func (deployer *KubernetesDeployer) command(operation string, userID portainer.UserID, endpoint *portainer.Endpoint, manifestFiles []string, namespace string, flags ...string) (string, error) {
 	token, err := deployer.getToken(userID, endpoint, endpoint.Type == portainer.KubernetesLocalEnvironment)
 	if err != nil {
 		return "", errors.Wrap(err, "failed generating a user token")
@@ -119,6 +125,7 @@ func (deployer *KubernetesDeployer) command(operation string, userID portainer.U
 	}
 
 	args = append(args, operation)
+	args = append(args, flags...)
 	for _, path := range manifestFiles {
 		args = append(args, "-f", strings.TrimSpace(path))
 	}
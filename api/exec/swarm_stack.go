@@ -2,6 +2,7 @@ package exec
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,7 +14,9 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/internal/registryutils"
+	"github.com/portainer/portainer/api/secrets"
 	"github.com/portainer/portainer/api/stacks/stackutils"
 	"github.com/rs/zerolog/log"
 )
@@ -26,6 +29,7 @@ type SwarmStackManager struct {
 	fileService          portainer.FileService
 	reverseTunnelService portainer.ReverseTunnelService
 	dataStore            dataservices.DataStore
+	secretsService       *secrets.Service
 }
 
 // NewSwarmStackManager initializes a new SwarmStackManager service.
@@ -36,6 +40,7 @@ func NewSwarmStackManager(
 	fileService portainer.FileService,
 	reverseTunnelService portainer.ReverseTunnelService,
 	datastore dataservices.DataStore,
+	secretsService *secrets.Service,
 ) (*SwarmStackManager, error) {
 	manager := &SwarmStackManager{
 		binaryPath:           binaryPath,
@@ -44,6 +49,7 @@ func NewSwarmStackManager(
 		fileService:          fileService,
 		reverseTunnelService: reverseTunnelService,
 		dataStore:            datastore,
+		secretsService:       secretsService,
 	}
 
 	err := manager.updateDockerCLIConfiguration(manager.configPath)
@@ -133,8 +139,16 @@ func (manager *SwarmStackManager) Deploy(stack *portainer.Stack, prune bool, pul
 	args = configureFilePaths(args, filePaths)
 	args = append(args, stack.Name)
 
+	stackEnv := stack.Env
+	if manager.secretsService != nil {
+		stackEnv, err = manager.secretsService.ResolveEnvValues(context.TODO(), stack.Env)
+		if err != nil {
+			return err
+		}
+	}
+
 	env := make([]string, 0)
-	for _, envvar := range stack.Env {
+	for _, envvar := range stackEnv {
 		env = append(env, envvar.Name+"="+envvar.Value)
 	}
 
@@ -180,6 +194,10 @@ func (manager *SwarmStackManager) prepareDockerCommandAndArgs(binaryPath, config
 		command = path.Join(binaryPath, "docker.exe")
 	}
 
+	if endpointutils.IsDockerSSHEndpoint(endpoint) {
+		return "", nil, errors.New("swarm stacks are not supported on the environments connected via SSH")
+	}
+
 	args := make([]string, 0)
 	args = append(args, "--config", configPath)
 
@@ -0,0 +1,111 @@
+// Package failover runs Portainer as the active instance of an active/standby pair of replicas sharing the data
+// volume. The embedded database holds an exclusive lock on its file, so only one replica can open it: the replica
+// holding a Kubernetes Lease is the active one, and the standby replicas wait for the lease before opening the
+// database. This does not make several replicas serve the API at the same time.
+package failover
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// Lease is the Kubernetes Lease held by the active replica, which is acquired by one replica at a time and renewed
+// for as long as it is running
+type Lease struct {
+	elector  *leaderelection.LeaderElector
+	acquired chan struct{}
+	lost     chan struct{}
+}
+
+// NewKubernetesLease creates a lease competing for the specified Lease object, using the hostname of the replica,
+// i.e. the pod name, as its identity
+func NewKubernetesLease(namespace, name string) (*Lease, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve the in-cluster configuration")
+	}
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the Kubernetes client")
+	}
+
+	identity, err := os.Hostname()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve the replica identity")
+	}
+
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, namespace, name, client.CoreV1(), client.CoordinationV1(), resourcelock.ResourceLockConfig{
+		Identity: identity,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create the lease lock")
+	}
+
+	return newLease(lock)
+}
+
+func newLease(lock resourcelock.Interface) (*Lease, error) {
+	lease := &Lease{
+		acquired: make(chan struct{}),
+		lost:     make(chan struct{}),
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   renewDeadline,
+		RetryPeriod:     retryPeriod,
+		ReleaseOnCancel: true,
+		Name:            lock.Describe(),
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				log.Info().Str("identity", lock.Identity()).Msg("acquired the lease, running as the active replica")
+				close(lease.acquired)
+			},
+			OnStoppedLeading: func() {
+				close(lease.lost)
+			},
+			OnNewLeader: func(identity string) {
+				log.Debug().Str("leader", identity).Msg("new active replica")
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lease.elector = elector
+
+	return lease, nil
+}
+
+// Acquire waits until the replica holds the lease, which it keeps renewing until the context is done. The lease is
+// only acquired once: the returned channel is closed when it is lost, after which the replica must stop using the
+// database and exit so that a standby replica can take over.
+func (lease *Lease) Acquire(ctx context.Context) (<-chan struct{}, error) {
+	go lease.elector.Run(ctx)
+
+	select {
+	case <-lease.acquired:
+		return lease.lost, nil
+	case <-lease.lost:
+		return nil, errors.New("unable to acquire the lease")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
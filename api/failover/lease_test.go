@@ -0,0 +1,55 @@
+package failover
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func TestLease_Acquire(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	newReplicaLease := func(identity string) *Lease {
+		lease, err := newLease(&resourcelock.LeaseLock{
+			LeaseMeta:  metav1.ObjectMeta{Namespace: "portainer", Name: "portainer-leader"},
+			Client:     client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+		})
+		assert.NoError(t, err)
+
+		return lease
+	}
+
+	activeCtx, stopActive := context.WithCancel(context.Background())
+	defer stopActive()
+
+	lost, err := newReplicaLease("portainer-0").Acquire(activeCtx)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	standbyCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err = newReplicaLease("portainer-1").Acquire(standbyCtx)
+	assert.Error(t, err, "the standby replica should wait while the lease is held")
+
+	stopActive()
+
+	select {
+	case <-lost:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the lease should be lost once the active replica stops")
+	}
+
+	takeoverCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = newReplicaLease("portainer-2").Acquire(takeoverCtx)
+	assert.NoError(t, err, "a standby replica should take over the released lease")
+}
@@ -28,6 +28,10 @@ const (
 	TLSCertFile = "cert.pem"
 	// TLSKeyFile represents the name on disk for a TLS key file.
 	TLSKeyFile = "key.pem"
+	// SSHStorePath represents the subfolder where SSH files are stored in the file store folder.
+	SSHStorePath = "ssh"
+	// SSHPrivateKeyFile represents the name on disk of the file containing an SSH private key.
+	SSHPrivateKeyFile = "id_key"
 	// ComposeStorePath represents the subfolder where compose files are stored in the file store folder.
 	ComposeStorePath = "compose"
 	// ComposeFileDefaultName represents the default name of a compose file.
@@ -72,6 +76,12 @@ const (
 	ChiselPath = "chisel"
 	// ChiselPrivateKeyFilename represents the chisel private key file name
 	ChiselPrivateKeyFilename = "private-key.pem"
+	// ChiselTunnelStateFilename represents the file name of the tunnels state persisted on shutdown
+	ChiselTunnelStateFilename = "tunnels.json"
+	// BrandingStorePath represents the subfolder where the custom branding files are stored
+	BrandingStorePath = "branding"
+	// LogoFilename represents the file name of the custom logo
+	LogoFilename = "logo"
 )
 
 // ErrUndefinedTLSFileType represents an error returned on undefined TLS file type
@@ -550,6 +560,30 @@ func (service *Service) DeleteTLSFile(folder string, fileType portainer.TLSFileT
 	return os.Remove(filePath)
 }
 
+// StoreSSHPrivateKeyFromBytes creates a folder in the SSHStorePath and stores an SSH private key from bytes.
+// It returns the path to the newly created file.
+func (service *Service) StoreSSHPrivateKeyFromBytes(folder string, data []byte) (string, error) {
+	storePath := JoinPaths(SSHStorePath, folder)
+	err := service.createDirectoryInStore(storePath)
+	if err != nil {
+		return "", err
+	}
+
+	keyFilePath := JoinPaths(storePath, SSHPrivateKeyFile)
+	err = service.createFileInStore(keyFilePath, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	return service.wrapFileStore(keyFilePath), nil
+}
+
+// DeleteSSHFiles deletes a folder in the SSH store path.
+func (service *Service) DeleteSSHFiles(folder string) error {
+	storePath := JoinPaths(service.wrapFileStore(SSHStorePath), folder)
+	return os.RemoveAll(storePath)
+}
+
 // GetFileContent returns the content of a file as bytes.
 func (service *Service) GetFileContent(trustedRoot, filePath string) ([]byte, error) {
 	content, err := os.ReadFile(JoinPaths(trustedRoot, filePath))
@@ -827,6 +861,11 @@ func defaultChiselPrivateKeyPathUnderFileStore() string {
 	return JoinPaths(ChiselPath, ChiselPrivateKeyFilename)
 }
 
+// GetDefaultChiselTunnelStatePath returns the path of the tunnels state persisted on shutdown
+func (service *Service) GetDefaultChiselTunnelStatePath() string {
+	return service.wrapFileStore(JoinPaths(ChiselPath, ChiselTunnelStateFilename))
+}
+
 // StoreChiselPrivateKey store the specified chisel private key content on disk.
 func (service *Service) StoreChiselPrivateKey(privateKey []byte) error {
 	err := service.createDirectoryInStore(ChiselPath)
@@ -839,6 +878,37 @@ func (service *Service) StoreChiselPrivateKey(privateKey []byte) error {
 	return service.createFileInStore(privateKeyPath, r)
 }
 
+// GetLogoFilePath returns the path of the custom logo.
+func (service *Service) GetLogoFilePath() string {
+	return service.wrapFileStore(JoinPaths(BrandingStorePath, LogoFilename))
+}
+
+// StoreLogoFile stores the custom logo on disk, replacing the previous one.
+func (service *Service) StoreLogoFile(data []byte) (string, error) {
+	err := service.createDirectoryInStore(BrandingStorePath)
+	if err != nil {
+		return "", err
+	}
+
+	filePath := JoinPaths(BrandingStorePath, LogoFilename)
+	err = service.createFileInStore(filePath, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+
+	return service.wrapFileStore(filePath), nil
+}
+
+// RemoveLogoFile removes the custom logo from disk.
+func (service *Service) RemoveLogoFile() error {
+	err := os.Remove(service.GetLogoFilePath())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
 // StoreSSLCertPair stores a ssl certificate pair
 func (service *Service) StoreSSLCertPair(cert, key []byte) (string, string, error) {
 	certPath, keyPath := defaultCertPathUnderFileStore()
@@ -0,0 +1,170 @@
+package grpcapi
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/portainer/portainer/api/http/security"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// callPathPrefix is the path prefix of the requests representing the gRPC calls for the policies of the HTTP API
+const callPathPrefix = "/api/grpc"
+
+type restrictedContextKey struct{}
+
+// statusRecorder records the status written by the bouncer when it rejects a request
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (recorder *statusRecorder) Header() http.Header {
+	return recorder.header
+}
+
+func (recorder *statusRecorder) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	recorder.status = status
+}
+
+// authenticate runs the call through the policies of the HTTP API, i.e. the authorization hook, the rate limiting and
+// the tracking of the API usage, then through its request bouncer so that the JWTs and the access tokens are verified
+// the same way. The calls are seen by the policies as POST requests to /api/grpc/<service>/<method>. It returns a
+// context holding the restricted request context of the user.
+func (server *Server) authenticate(ctx context.Context, method string) (context.Context, error) {
+	return server.authorize(ctx, method, server.policies)
+}
+
+// reauthenticate verifies that the credentials of a call are still valid, e.g. that its token was not revoked since
+// the call started. The policies are not applied again, so that a long-lived stream counts as one request.
+func (server *Server) reauthenticate(ctx context.Context, method string) error {
+	_, err := server.authorize(ctx, method, nil)
+
+	return err
+}
+
+func (server *Server) authorize(ctx context.Context, method string, policies func(http.Handler) http.Handler) (context.Context, error) {
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, path.Join(callPathPrefix, method), nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		r.RemoteAddr = p.Addr.String()
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		r.Header.Set("Authorization", values[0])
+	}
+
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		r.Header.Set("X-API-KEY", values[0])
+	}
+
+	var requestContext *security.RestrictedRequestContext
+
+	var handler http.Handler = server.bouncer.RestrictedAccess(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestContext, _ = security.RetrieveRestrictedRequestContext(r)
+	}))
+
+	if policies != nil {
+		handler = policies(handler)
+	}
+
+	recorder := &statusRecorder{header: http.Header{}}
+	handler.ServeHTTP(recorder, r)
+
+	if requestContext == nil {
+		switch recorder.status {
+		case http.StatusForbidden:
+			return nil, status.Error(codes.PermissionDenied, "access denied")
+		case http.StatusUnauthorized:
+			return nil, status.Error(codes.Unauthenticated, "a valid authorisation token is missing")
+		case http.StatusTooManyRequests:
+			return nil, status.Errorf(codes.ResourceExhausted, "too many requests, retry after %s seconds", recorder.header.Get("Retry-After"))
+		}
+
+		return nil, status.Error(codes.Internal, "unable to authenticate the call")
+	}
+
+	return context.WithValue(ctx, restrictedContextKey{}, requestContext), nil
+}
+
+// requestContext returns the restricted request context of the user making the call
+func requestContext(ctx context.Context) *security.RestrictedRequestContext {
+	requestContext, _ := ctx.Value(restrictedContextKey{}).(*security.RestrictedRequestContext)
+
+	return requestContext
+}
+
+func (server *Server) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := server.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// authenticatedStream overrides the context of a stream with the authenticated one
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *authenticatedStream) Context() context.Context {
+	return stream.ctx
+}
+
+// streamInterceptor authenticates the streams when they start, then reauthenticates them periodically while they are
+// running, the streams whose credentials were revoked being ended with the authentication error
+func (server *Server) streamInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := server.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	go server.watchCredentials(ctx, cancel, info.FullMethod)
+
+	err = handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+
+	if cause := context.Cause(ctx); status.Code(cause) != codes.OK && status.Code(cause) != codes.Unknown {
+		return cause
+	}
+
+	return err
+}
+
+// watchCredentials reauthenticates a stream at the reauthentication interval, and cancels it with the authentication
+// error once its credentials are no longer valid
+func (server *Server) watchCredentials(ctx context.Context, cancel context.CancelCauseFunc, method string) {
+	ticker := time.NewTicker(server.reauthenticationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := server.reauthenticate(ctx, method); err != nil {
+				cancel(err)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,388 @@
+// Automation service of the gRPC API, served when Portainer is started with --grpc-addr.
+//
+// The calls are authenticated like the HTTP API, with a JWT in the "authorization" metadata ("Bearer <token>") or
+// an access token in the "x-api-key" metadata.
+//
+// Regenerate the Go code with:
+//   protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative automation.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.24.4
+// source: automation.proto
+
+package automationpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Automation_ListEndpoints_FullMethodName  = "/portainer.automation.v1.Automation/ListEndpoints"
+	Automation_WatchEndpoints_FullMethodName = "/portainer.automation.v1.Automation/WatchEndpoints"
+	Automation_GetSnapshot_FullMethodName    = "/portainer.automation.v1.Automation/GetSnapshot"
+	Automation_GetSnapshots_FullMethodName   = "/portainer.automation.v1.Automation/GetSnapshots"
+	Automation_BatchAction_FullMethodName    = "/portainer.automation.v1.Automation/BatchAction"
+)
+
+// AutomationClient is the client API for Automation service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AutomationClient interface {
+	// Streams the environments the user has access to.
+	ListEndpoints(ctx context.Context, in *ListEndpointsRequest, opts ...grpc.CallOption) (Automation_ListEndpointsClient, error)
+	// Streams the environments the user has access to, then their changes: the status, the check-in date and the
+	// time of the snapshot. The removed environments are sent with the removed flag set.
+	WatchEndpoints(ctx context.Context, in *WatchEndpointsRequest, opts ...grpc.CallOption) (Automation_WatchEndpointsClient, error)
+	// Returns the latest snapshot of an environment.
+	GetSnapshot(ctx context.Context, in *GetSnapshotRequest, opts ...grpc.CallOption) (*Snapshot, error)
+	// Streams the latest snapshots of several environments, all the environments the user has access to when none is
+	// specified. The environments without a snapshot are skipped.
+	GetSnapshots(ctx context.Context, in *GetSnapshotsRequest, opts ...grpc.CallOption) (Automation_GetSnapshotsClient, error)
+	// Runs an action on several environments and streams the result for each of them. Administrators only.
+	BatchAction(ctx context.Context, in *BatchActionRequest, opts ...grpc.CallOption) (Automation_BatchActionClient, error)
+}
+
+type automationClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAutomationClient(cc grpc.ClientConnInterface) AutomationClient {
+	return &automationClient{cc}
+}
+
+func (c *automationClient) ListEndpoints(ctx context.Context, in *ListEndpointsRequest, opts ...grpc.CallOption) (Automation_ListEndpointsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Automation_ServiceDesc.Streams[0], Automation_ListEndpoints_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &automationListEndpointsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Automation_ListEndpointsClient interface {
+	Recv() (*Endpoint, error)
+	grpc.ClientStream
+}
+
+type automationListEndpointsClient struct {
+	grpc.ClientStream
+}
+
+func (x *automationListEndpointsClient) Recv() (*Endpoint, error) {
+	m := new(Endpoint)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *automationClient) WatchEndpoints(ctx context.Context, in *WatchEndpointsRequest, opts ...grpc.CallOption) (Automation_WatchEndpointsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Automation_ServiceDesc.Streams[1], Automation_WatchEndpoints_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &automationWatchEndpointsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Automation_WatchEndpointsClient interface {
+	Recv() (*Endpoint, error)
+	grpc.ClientStream
+}
+
+type automationWatchEndpointsClient struct {
+	grpc.ClientStream
+}
+
+func (x *automationWatchEndpointsClient) Recv() (*Endpoint, error) {
+	m := new(Endpoint)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *automationClient) GetSnapshot(ctx context.Context, in *GetSnapshotRequest, opts ...grpc.CallOption) (*Snapshot, error) {
+	out := new(Snapshot)
+	err := c.cc.Invoke(ctx, Automation_GetSnapshot_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *automationClient) GetSnapshots(ctx context.Context, in *GetSnapshotsRequest, opts ...grpc.CallOption) (Automation_GetSnapshotsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Automation_ServiceDesc.Streams[2], Automation_GetSnapshots_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &automationGetSnapshotsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Automation_GetSnapshotsClient interface {
+	Recv() (*Snapshot, error)
+	grpc.ClientStream
+}
+
+type automationGetSnapshotsClient struct {
+	grpc.ClientStream
+}
+
+func (x *automationGetSnapshotsClient) Recv() (*Snapshot, error) {
+	m := new(Snapshot)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *automationClient) BatchAction(ctx context.Context, in *BatchActionRequest, opts ...grpc.CallOption) (Automation_BatchActionClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Automation_ServiceDesc.Streams[3], Automation_BatchAction_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &automationBatchActionClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Automation_BatchActionClient interface {
+	Recv() (*BatchActionResult, error)
+	grpc.ClientStream
+}
+
+type automationBatchActionClient struct {
+	grpc.ClientStream
+}
+
+func (x *automationBatchActionClient) Recv() (*BatchActionResult, error) {
+	m := new(BatchActionResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AutomationServer is the server API for Automation service.
+// All implementations must embed UnimplementedAutomationServer
+// for forward compatibility
+type AutomationServer interface {
+	// Streams the environments the user has access to.
+	ListEndpoints(*ListEndpointsRequest, Automation_ListEndpointsServer) error
+	// Streams the environments the user has access to, then their changes: the status, the check-in date and the
+	// time of the snapshot. The removed environments are sent with the removed flag set.
+	WatchEndpoints(*WatchEndpointsRequest, Automation_WatchEndpointsServer) error
+	// Returns the latest snapshot of an environment.
+	GetSnapshot(context.Context, *GetSnapshotRequest) (*Snapshot, error)
+	// Streams the latest snapshots of several environments, all the environments the user has access to when none is
+	// specified. The environments without a snapshot are skipped.
+	GetSnapshots(*GetSnapshotsRequest, Automation_GetSnapshotsServer) error
+	// Runs an action on several environments and streams the result for each of them. Administrators only.
+	BatchAction(*BatchActionRequest, Automation_BatchActionServer) error
+	mustEmbedUnimplementedAutomationServer()
+}
+
+// UnimplementedAutomationServer must be embedded to have forward compatible implementations.
+type UnimplementedAutomationServer struct {
+}
+
+func (UnimplementedAutomationServer) ListEndpoints(*ListEndpointsRequest, Automation_ListEndpointsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListEndpoints not implemented")
+}
+func (UnimplementedAutomationServer) WatchEndpoints(*WatchEndpointsRequest, Automation_WatchEndpointsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEndpoints not implemented")
+}
+func (UnimplementedAutomationServer) GetSnapshot(context.Context, *GetSnapshotRequest) (*Snapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSnapshot not implemented")
+}
+func (UnimplementedAutomationServer) GetSnapshots(*GetSnapshotsRequest, Automation_GetSnapshotsServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetSnapshots not implemented")
+}
+func (UnimplementedAutomationServer) BatchAction(*BatchActionRequest, Automation_BatchActionServer) error {
+	return status.Errorf(codes.Unimplemented, "method BatchAction not implemented")
+}
+func (UnimplementedAutomationServer) mustEmbedUnimplementedAutomationServer() {}
+
+// UnsafeAutomationServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AutomationServer will
+// result in compilation errors.
+type UnsafeAutomationServer interface {
+	mustEmbedUnimplementedAutomationServer()
+}
+
+func RegisterAutomationServer(s grpc.ServiceRegistrar, srv AutomationServer) {
+	s.RegisterService(&Automation_ServiceDesc, srv)
+}
+
+func _Automation_ListEndpoints_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListEndpointsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AutomationServer).ListEndpoints(m, &automationListEndpointsServer{stream})
+}
+
+type Automation_ListEndpointsServer interface {
+	Send(*Endpoint) error
+	grpc.ServerStream
+}
+
+type automationListEndpointsServer struct {
+	grpc.ServerStream
+}
+
+func (x *automationListEndpointsServer) Send(m *Endpoint) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Automation_WatchEndpoints_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEndpointsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AutomationServer).WatchEndpoints(m, &automationWatchEndpointsServer{stream})
+}
+
+type Automation_WatchEndpointsServer interface {
+	Send(*Endpoint) error
+	grpc.ServerStream
+}
+
+type automationWatchEndpointsServer struct {
+	grpc.ServerStream
+}
+
+func (x *automationWatchEndpointsServer) Send(m *Endpoint) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Automation_GetSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AutomationServer).GetSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Automation_GetSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AutomationServer).GetSnapshot(ctx, req.(*GetSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Automation_GetSnapshots_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetSnapshotsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AutomationServer).GetSnapshots(m, &automationGetSnapshotsServer{stream})
+}
+
+type Automation_GetSnapshotsServer interface {
+	Send(*Snapshot) error
+	grpc.ServerStream
+}
+
+type automationGetSnapshotsServer struct {
+	grpc.ServerStream
+}
+
+func (x *automationGetSnapshotsServer) Send(m *Snapshot) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Automation_BatchAction_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BatchActionRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AutomationServer).BatchAction(m, &automationBatchActionServer{stream})
+}
+
+type Automation_BatchActionServer interface {
+	Send(*BatchActionResult) error
+	grpc.ServerStream
+}
+
+type automationBatchActionServer struct {
+	grpc.ServerStream
+}
+
+func (x *automationBatchActionServer) Send(m *BatchActionResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Automation_ServiceDesc is the grpc.ServiceDesc for Automation service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Automation_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "portainer.automation.v1.Automation",
+	HandlerType: (*AutomationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSnapshot",
+			Handler:    _Automation_GetSnapshot_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListEndpoints",
+			Handler:       _Automation_ListEndpoints_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchEndpoints",
+			Handler:       _Automation_WatchEndpoints_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetSnapshots",
+			Handler:       _Automation_GetSnapshots_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "BatchAction",
+			Handler:       _Automation_BatchAction_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "automation.proto",
+}
@@ -0,0 +1,168 @@
+package grpcapi
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/grpcapi/automationpb"
+	"github.com/portainer/portainer/api/internal/snapshot"
+	"github.com/portainer/portainer/api/jobs"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) BatchAction(req *automationpb.BatchActionRequest, stream automationpb.Automation_BatchActionServer) error {
+	if !requestContext(stream.Context()).IsAdmin {
+		return status.Error(codes.PermissionDenied, "the batch actions are restricted to the administrators")
+	}
+
+	var action func(endpointID portainer.EndpointID) error
+
+	switch req.GetAction() {
+	case automationpb.BatchAction_BATCH_ACTION_SNAPSHOT:
+		return server.batchSnapshot(req.GetEndpointIds(), stream)
+
+	case automationpb.BatchAction_BATCH_ACTION_ADD_TAG, automationpb.BatchAction_BATCH_ACTION_REMOVE_TAG:
+		tagID := portainer.TagID(req.GetTagId())
+		if _, err := server.dataStore.Tag().Read(tagID); err != nil {
+			return status.Error(codes.InvalidArgument, "unable to find a tag with the specified identifier")
+		}
+
+		add := req.GetAction() == automationpb.BatchAction_BATCH_ACTION_ADD_TAG
+		action = func(endpointID portainer.EndpointID) error {
+			return server.updateEndpointTag(endpointID, tagID, add)
+		}
+
+	default:
+		return status.Error(codes.InvalidArgument, "invalid batch action")
+	}
+
+	for _, endpointID := range req.GetEndpointIds() {
+		result := &automationpb.BatchActionResult{EndpointId: endpointID, Success: true}
+
+		if err := action(portainer.EndpointID(endpointID)); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+		}
+
+		if err := stream.Send(result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// batchSnapshot snapshots the environments through the job queue, several environments being snapshotted at the
+// same time while the results are streamed in the order of the request. The snapshots not reported yet are canceled
+// when the client goes away.
+func (server *Server) batchSnapshot(endpointIDs []int32, stream automationpb.Automation_BatchActionServer) error {
+	ctx := stream.Context()
+	userID := requestContext(ctx).UserID
+
+	jobIDs := make([]portainer.BackgroundJobID, len(endpointIDs))
+	errs := make([]error, len(endpointIDs))
+
+	for i, endpointID := range endpointIDs {
+		job, err := server.jobQueue.Enqueue(snapshot.JobType, snapshot.JobPayload{EndpointID: portainer.EndpointID(endpointID)}, userID, fmt.Sprintf("Snapshot of the environment %d", endpointID))
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		jobIDs[i] = job.ID
+	}
+
+	reported := 0
+	defer func() {
+		server.cancelJobs(jobIDs[reported:])
+	}()
+
+	for i, endpointID := range endpointIDs {
+		result := &automationpb.BatchActionResult{EndpointId: endpointID, Success: true}
+
+		err := errs[i]
+		if err == nil {
+			job, waitErr := server.jobQueue.Wait(ctx, jobIDs[i])
+			if waitErr != nil {
+				return waitErr
+			}
+
+			if job.Status == portainer.BackgroundJobCanceled {
+				err = errors.New("the snapshot was canceled")
+			} else if job.Status != portainer.BackgroundJobSucceeded {
+				err = errors.New(job.Error)
+			}
+		}
+
+		if err != nil {
+			result.Success = false
+			result.Error = err.Error()
+		}
+
+		if err := stream.Send(result); err != nil {
+			return err
+		}
+
+		reported++
+	}
+
+	return nil
+}
+
+// cancelJobs cancels the jobs which are not finished yet
+func (server *Server) cancelJobs(jobIDs []portainer.BackgroundJobID) {
+	for _, jobID := range jobIDs {
+		if jobID == 0 {
+			continue
+		}
+
+		if _, err := server.jobQueue.Cancel(jobID); err != nil && !errors.Is(err, jobs.ErrJobFinished) {
+			log.Debug().Err(err).Int("job_id", int(jobID)).Msg("unable to cancel the snapshot of an abandoned batch action")
+		}
+	}
+}
+
+// updateEndpointTag adds or removes a tag of an environment, updating both the environment and the tag
+func (server *Server) updateEndpointTag(endpointID portainer.EndpointID, tagID portainer.TagID, add bool) error {
+	return server.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		endpoint, err := tx.Endpoint().Endpoint(endpointID)
+		if err != nil {
+			return errors.New("unable to find an environment with the specified identifier")
+		}
+
+		tag, err := tx.Tag().Read(tagID)
+		if err != nil {
+			return errors.New("unable to find a tag with the specified identifier")
+		}
+
+		if tag.Endpoints == nil {
+			tag.Endpoints = make(map[portainer.EndpointID]bool)
+		}
+
+		if add {
+			if !slices.Contains(endpoint.TagIDs, tagID) {
+				endpoint.TagIDs = append(endpoint.TagIDs, tagID)
+			}
+
+			tag.Endpoints[endpointID] = true
+		} else {
+			endpoint.TagIDs = slices.DeleteFunc(endpoint.TagIDs, func(id portainer.TagID) bool {
+				return id == tagID
+			})
+
+			delete(tag.Endpoints, endpointID)
+		}
+
+		if err := tx.Endpoint().UpdateEndpoint(endpoint.ID, endpoint); err != nil {
+			return err
+		}
+
+		return tx.Tag().Update(tag.ID, tag)
+	})
+}
@@ -0,0 +1,182 @@
+package grpcapi
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/grpcapi/automationpb"
+	"github.com/portainer/portainer/api/http/security"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultWatchInterval is the interval of the checks for changes of WatchEndpoints when none is requested
+const defaultWatchInterval = 5 * time.Second
+
+// accessibleEndpoints returns the environments the user has access to, along with the time of their latest snapshot
+func (server *Server) accessibleEndpoints(ctx context.Context) ([]portainer.Endpoint, map[portainer.EndpointID]int64, error) {
+	endpoints, err := server.dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return nil, nil, status.Error(codes.Internal, "unable to retrieve the environments from the database")
+	}
+
+	groups, err := server.dataStore.EndpointGroup().ReadAll()
+	if err != nil {
+		return nil, nil, status.Error(codes.Internal, "unable to retrieve the environment groups from the database")
+	}
+
+	snapshots, err := server.dataStore.Snapshot().ReadAll()
+	if err != nil {
+		return nil, nil, status.Error(codes.Internal, "unable to retrieve the snapshots from the database")
+	}
+
+	snapshotTimes := make(map[portainer.EndpointID]int64, len(snapshots))
+	for _, snapshot := range snapshots {
+		if snapshot.Docker != nil {
+			snapshotTimes[snapshot.EndpointID] = snapshot.Docker.Time
+		} else if snapshot.Kubernetes != nil {
+			snapshotTimes[snapshot.EndpointID] = snapshot.Kubernetes.Time
+		}
+	}
+
+	return security.FilterEndpoints(endpoints, groups, requestContext(ctx)), snapshotTimes, nil
+}
+
+// accessibleEndpoint returns an environment the user has access to
+func (server *Server) accessibleEndpoint(ctx context.Context, endpointID portainer.EndpointID) (*portainer.Endpoint, error) {
+	endpoint, err := server.dataStore.Endpoint().Endpoint(endpointID)
+	if server.dataStore.IsErrObjectNotFound(err) {
+		return nil, status.Error(codes.NotFound, "unable to find an environment with the specified identifier")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "unable to find an environment with the specified identifier")
+	}
+
+	requestContext := requestContext(ctx)
+	if requestContext.IsAdmin {
+		return endpoint, nil
+	}
+
+	group, err := server.dataStore.EndpointGroup().Read(endpoint.GroupID)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to retrieve the environment group from the database")
+	}
+
+	if !security.AuthorizedEndpointAccess(endpoint, group, requestContext.UserID, requestContext.UserMemberships) {
+		return nil, status.Error(codes.PermissionDenied, "access denied to the environment")
+	}
+
+	return endpoint, nil
+}
+
+func toEndpoint(endpoint *portainer.Endpoint, snapshotTime int64) *automationpb.Endpoint {
+	tagIDs := make([]int32, 0, len(endpoint.TagIDs))
+	for _, tagID := range endpoint.TagIDs {
+		tagIDs = append(tagIDs, int32(tagID))
+	}
+
+	return &automationpb.Endpoint{
+		Id:              int32(endpoint.ID),
+		Name:            endpoint.Name,
+		Type:            int32(endpoint.Type),
+		Status:          int32(endpoint.Status),
+		GroupId:         int32(endpoint.GroupID),
+		Url:             endpoint.URL,
+		TagIds:          tagIDs,
+		LastCheckInDate: endpoint.LastCheckInDate,
+		SnapshotTime:    snapshotTime,
+	}
+}
+
+func matchEndpoint(req *automationpb.ListEndpointsRequest, endpoint *portainer.Endpoint) bool {
+	if len(req.GetGroupIds()) > 0 && !slices.Contains(req.GetGroupIds(), int32(endpoint.GroupID)) {
+		return false
+	}
+
+	if len(req.GetStatuses()) > 0 && !slices.Contains(req.GetStatuses(), int32(endpoint.Status)) {
+		return false
+	}
+
+	for _, tagID := range req.GetTagIds() {
+		if !slices.Contains(endpoint.TagIDs, portainer.TagID(tagID)) {
+			return false
+		}
+	}
+
+	return req.GetName() == "" || endpoint.Name == req.GetName()
+}
+
+func (server *Server) ListEndpoints(req *automationpb.ListEndpointsRequest, stream automationpb.Automation_ListEndpointsServer) error {
+	endpoints, snapshotTimes, err := server.accessibleEndpoints(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	for i := range endpoints {
+		if !matchEndpoint(req, &endpoints[i]) {
+			continue
+		}
+
+		if err := stream.Send(toEndpoint(&endpoints[i], snapshotTimes[endpoints[i].ID])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (server *Server) WatchEndpoints(req *automationpb.WatchEndpointsRequest, stream automationpb.Automation_WatchEndpointsServer) error {
+	interval := defaultWatchInterval
+	if req.GetIntervalSeconds() > 0 {
+		interval = time.Duration(req.GetIntervalSeconds()) * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	sent := make(map[int32]*automationpb.Endpoint)
+
+	for {
+		endpoints, snapshotTimes, err := server.accessibleEndpoints(stream.Context())
+		if err != nil {
+			return err
+		}
+
+		current := make(map[int32]bool, len(endpoints))
+		for i := range endpoints {
+			endpoint := toEndpoint(&endpoints[i], snapshotTimes[endpoints[i].ID])
+			current[endpoint.Id] = true
+
+			if previous, ok := sent[endpoint.Id]; ok && proto.Equal(previous, endpoint) {
+				continue
+			}
+
+			if err := stream.Send(endpoint); err != nil {
+				return err
+			}
+
+			sent[endpoint.Id] = endpoint
+		}
+
+		for id := range sent {
+			if current[id] {
+				continue
+			}
+
+			if err := stream.Send(&automationpb.Endpoint{Id: id, Removed: true}); err != nil {
+				return err
+			}
+
+			delete(sent, id)
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Package grpcapi serves the gRPC API of Portainer, an alternative to the HTTP API for the automation tools listing
+// the environments, retrieving their snapshots and running batch actions at a high volume. The results are streamed
+// instead of being polled, and the calls are authenticated with the same JWTs and access tokens as the HTTP API. The
+// calls are subject to the authorization hook, the rate limiting and the API usage tracking of the HTTP API, and the
+// long-lived streams are ended once their credentials are revoked.
+package grpcapi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/grpcapi/automationpb"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jobs"
+
+	"google.golang.org/grpc"
+)
+
+// defaultReauthenticationInterval is the interval at which the credentials of the running streams are verified again,
+// so that the streams of the users whose tokens were revoked are ended
+const defaultReauthenticationInterval = 30 * time.Second
+
+// Server implements the services of the gRPC API
+type Server struct {
+	automationpb.UnimplementedAutomationServer
+
+	bouncer   security.BouncerService
+	policies  func(http.Handler) http.Handler
+	dataStore dataservices.DataStore
+	jobQueue  *jobs.Queue
+
+	reauthenticationInterval time.Duration
+}
+
+// NewServer creates the services of the gRPC API. The calls are run through the policies applied to the requests of
+// the HTTP API, such as the rate limiting, before being authenticated by the request bouncer.
+func NewServer(bouncer security.BouncerService, policies func(http.Handler) http.Handler, dataStore dataservices.DataStore, jobQueue *jobs.Queue) *Server {
+	return &Server{
+		bouncer:                  bouncer,
+		policies:                 policies,
+		dataStore:                dataStore,
+		jobQueue:                 jobQueue,
+		reauthenticationInterval: defaultReauthenticationInterval,
+	}
+}
+
+// GRPCServer returns a gRPC server serving the services, the calls being authenticated by the request bouncer
+func (server *Server) GRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.UnaryInterceptor(server.unaryInterceptor),
+		grpc.StreamInterceptor(server.streamInterceptor),
+	)
+
+	grpcServer := grpc.NewServer(opts...)
+
+	automationpb.RegisterAutomationServer(grpcServer, server)
+
+	return grpcServer
+}
@@ -0,0 +1,203 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/grpcapi/automationpb"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestAutomationServer(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	admin := &portainer.User{Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(admin))
+
+	user := &portainer.User{Username: "user", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "authorized", GroupID: 1, Status: portainer.EndpointStatusUp,
+		UserAccessPolicies: portainer.UserAccessPolicies{user.ID: {}}}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 2, Name: "unauthorized", GroupID: 1, Status: portainer.EndpointStatusDown}))
+
+	tag := &portainer.Tag{Name: "prod"}
+	is.NoError(store.Tag().Create(tag))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err)
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+
+	// the policies of the HTTP API throttle the calls of the users once enabled
+	var throttled atomic.Bool
+	var calls atomic.Int32
+	policies := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+
+			if throttled.Load() && strings.HasPrefix(r.URL.Path, "/api/grpc/portainer.automation.v1.Automation/") {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	server := NewServer(security.NewRequestBouncer(store, jwtService, apiKeyService), policies, store, nil)
+	server.reauthenticationInterval = 20 * time.Millisecond
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := server.GRPCServer()
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	is.NoError(err)
+	defer conn.Close()
+
+	client := automationpb.NewAutomationClient(conn)
+
+	authenticated := func(u *portainer.User) context.Context {
+		token, err := jwtService.GenerateToken(&portainer.TokenData{ID: u.ID, Username: u.Username, Role: u.Role})
+		is.NoError(err)
+
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+
+	listEndpoints := func(ctx context.Context, req *automationpb.ListEndpointsRequest) ([]string, error) {
+		stream, err := client.ListEndpoints(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		var names []string
+		for {
+			endpoint, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return names, nil
+			} else if err != nil {
+				return nil, err
+			}
+
+			names = append(names, endpoint.Name)
+		}
+	}
+
+	t.Run("the calls without credentials are rejected", func(t *testing.T) {
+		_, err := listEndpoints(context.Background(), &automationpb.ListEndpointsRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("the environments are filtered by the access of the user", func(t *testing.T) {
+		names, err := listEndpoints(authenticated(user), &automationpb.ListEndpointsRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"authorized"}, names)
+
+		names, err = listEndpoints(authenticated(admin), &automationpb.ListEndpointsRequest{Statuses: []int32{int32(portainer.EndpointStatusDown)}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"unauthorized"}, names)
+	})
+
+	t.Run("the snapshots of the inaccessible environments cannot be retrieved", func(t *testing.T) {
+		_, err := client.GetSnapshot(authenticated(user), &automationpb.GetSnapshotRequest{EndpointId: 2})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+		_, err = client.GetSnapshot(authenticated(user), &automationpb.GetSnapshotRequest{EndpointId: 1})
+		assert.Equal(t, codes.NotFound, status.Code(err), "the environment has no snapshot")
+	})
+
+	t.Run("the batch actions are restricted to the administrators", func(t *testing.T) {
+		stream, err := client.BatchAction(authenticated(user), &automationpb.BatchActionRequest{
+			Action:      automationpb.BatchAction_BATCH_ACTION_ADD_TAG,
+			EndpointIds: []int32{1},
+			TagId:       int32(tag.ID),
+		})
+		assert.NoError(t, err)
+
+		_, err = stream.Recv()
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("a tag is added to the environments", func(t *testing.T) {
+		stream, err := client.BatchAction(authenticated(admin), &automationpb.BatchActionRequest{
+			Action:      automationpb.BatchAction_BATCH_ACTION_ADD_TAG,
+			EndpointIds: []int32{1, 42},
+			TagId:       int32(tag.ID),
+		})
+		assert.NoError(t, err)
+
+		result, err := stream.Recv()
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+
+		result, err = stream.Recv()
+		assert.NoError(t, err)
+		assert.False(t, result.Success, "the environment does not exist")
+
+		names, err := listEndpoints(authenticated(admin), &automationpb.ListEndpointsRequest{TagIds: []int32{int32(tag.ID)}})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"authorized"}, names)
+
+		updatedTag, err := store.Tag().Read(tag.ID)
+		assert.NoError(t, err)
+		assert.True(t, updatedTag.Endpoints[1])
+	})
+
+	t.Run("the calls are run through the policies of the HTTP API", func(t *testing.T) {
+		throttled.Store(true)
+		defer throttled.Store(false)
+
+		_, err := client.GetSnapshot(authenticated(admin), &automationpb.GetSnapshotRequest{EndpointId: 1})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("the streams are ended once their token is revoked", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(authenticated(user), 5*time.Second)
+		defer cancel()
+
+		stream, err := client.WatchEndpoints(ctx, &automationpb.WatchEndpointsRequest{})
+		assert.NoError(t, err)
+
+		endpoint, err := stream.Recv()
+		assert.NoError(t, err)
+		assert.Equal(t, "authorized", endpoint.GetName())
+
+		// the stream is only counted once by the policies
+		before := calls.Load()
+
+		// the sessions of the user are revoked, e.g. on a password change
+		revoked, err := store.User().Read(user.ID)
+		assert.NoError(t, err)
+		revoked.TokenIssueAt = time.Now().Add(time.Minute).Unix()
+		assert.NoError(t, store.User().Update(revoked.ID, revoked))
+
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		assert.Equal(t, before, calls.Load())
+	})
+}
@@ -0,0 +1,109 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/grpcapi/automationpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func toSnapshot(snapshot *portainer.Snapshot, includeRaw bool) (*automationpb.Snapshot, error) {
+	result := &automationpb.Snapshot{EndpointId: int32(snapshot.EndpointID)}
+
+	if docker := snapshot.Docker; docker != nil {
+		result.Docker = &automationpb.DockerSnapshot{
+			Time:                    docker.Time,
+			DockerVersion:           docker.DockerVersion,
+			Swarm:                   docker.Swarm,
+			TotalCpu:                int32(docker.TotalCPU),
+			TotalMemory:             docker.TotalMemory,
+			RunningContainerCount:   int32(docker.RunningContainerCount),
+			StoppedContainerCount:   int32(docker.StoppedContainerCount),
+			HealthyContainerCount:   int32(docker.HealthyContainerCount),
+			UnhealthyContainerCount: int32(docker.UnhealthyContainerCount),
+			VolumeCount:             int32(docker.VolumeCount),
+			ImageCount:              int32(docker.ImageCount),
+			ServiceCount:            int32(docker.ServiceCount),
+			StackCount:              int32(docker.StackCount),
+			NodeCount:               int32(docker.NodeCount),
+		}
+
+		if includeRaw {
+			raw, err := json.Marshal(docker.SnapshotRaw)
+			if err != nil {
+				return nil, err
+			}
+
+			result.Docker.RawJson = raw
+		}
+	}
+
+	if kubernetes := snapshot.Kubernetes; kubernetes != nil {
+		result.Kubernetes = &automationpb.KubernetesSnapshot{
+			Time:              kubernetes.Time,
+			KubernetesVersion: kubernetes.KubernetesVersion,
+			NodeCount:         int32(kubernetes.NodeCount),
+			TotalCpu:          kubernetes.TotalCPU,
+			TotalMemory:       kubernetes.TotalMemory,
+		}
+	}
+
+	return result, nil
+}
+
+func (server *Server) GetSnapshot(ctx context.Context, req *automationpb.GetSnapshotRequest) (*automationpb.Snapshot, error) {
+	endpoint, err := server.accessibleEndpoint(ctx, portainer.EndpointID(req.GetEndpointId()))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := server.dataStore.Snapshot().Read(endpoint.ID)
+	if server.dataStore.IsErrObjectNotFound(err) {
+		return nil, status.Error(codes.NotFound, "the environment has no snapshot")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "unable to retrieve the snapshot from the database")
+	}
+
+	result, err := toSnapshot(snapshot, req.GetIncludeRaw())
+	if err != nil {
+		return nil, status.Error(codes.Internal, "unable to encode the snapshot")
+	}
+
+	return result, nil
+}
+
+func (server *Server) GetSnapshots(req *automationpb.GetSnapshotsRequest, stream automationpb.Automation_GetSnapshotsServer) error {
+	endpointIDs := req.GetEndpointIds()
+	if len(endpointIDs) == 0 {
+		endpoints, _, err := server.accessibleEndpoints(stream.Context())
+		if err != nil {
+			return err
+		}
+
+		for _, endpoint := range endpoints {
+			endpointIDs = append(endpointIDs, int32(endpoint.ID))
+		}
+	}
+
+	for _, endpointID := range endpointIDs {
+		snapshot, err := server.GetSnapshot(stream.Context(), &automationpb.GetSnapshotRequest{
+			EndpointId: endpointID,
+			IncludeRaw: req.GetIncludeRaw(),
+		})
+		if status.Code(err) == codes.NotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if err := stream.Send(snapshot); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,388 @@
+// Package apiusage counts the API requests of each user, API key and unauthenticated client over the last hour, so
+// that the administrators can find the clients overloading the server, and alerts on the clients sending many more
+// requests than usual
+package apiusage
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/requestutils"
+	"github.com/portainer/portainer/api/lifecycle"
+)
+
+const (
+	// window is the number of minutes of request counts kept for the clients and the whole instance
+	window = 60
+	// alertCooldown is the minimum interval between two anomaly alerts of the same client
+	alertCooldown = time.Hour
+	// maxAnonymousClients bounds the number of unauthenticated clients tracked, the idle ones being removed first
+	maxAnonymousClients = 1000
+
+	defaultMinRequestsPerMinute = 600
+	defaultSpikeFactor          = 10
+)
+
+// TokenLookup returns the user authenticated by the request and the API key it uses, if any
+type TokenLookup func(r *http.Request) (*portainer.TokenData, *portainer.APIKey)
+
+// EventPublisher publishes the lifecycle events of the anomalous usage of the API
+type EventPublisher interface {
+	Publish(event lifecycle.Event)
+}
+
+// minuteRing counts the requests of the last minutes, each slot holding the count of one minute
+type minuteRing struct {
+	counts  [window]int
+	minutes [window]int64
+}
+
+// add counts a request in the minute, since the Unix epoch, and returns the count of the minute
+func (ring *minuteRing) add(minute int64) int {
+	i := minute % window
+	if ring.minutes[i] != minute {
+		ring.minutes[i] = minute
+		ring.counts[i] = 0
+	}
+
+	ring.counts[i]++
+
+	return ring.counts[i]
+}
+
+func (ring *minuteRing) count(minute int64) int {
+	i := minute % window
+	if ring.minutes[i] != minute {
+		return 0
+	}
+
+	return ring.counts[i]
+}
+
+// last returns the counts of the minutes of the window ending with the minute, the oldest first
+func (ring *minuteRing) last(minute int64) []int {
+	counts := make([]int, window)
+	for i := range counts {
+		counts[i] = ring.count(minute - window + 1 + int64(i))
+	}
+
+	return counts
+}
+
+// sum returns the number of requests of the minutes of the window ending with the minute
+func (ring *minuteRing) sum(minute int64) int {
+	total := 0
+	for _, count := range ring.last(minute) {
+		total += count
+	}
+
+	return total
+}
+
+type client struct {
+	usage     ClientUsage
+	minutes   minuteRing
+	lastAlert time.Time
+}
+
+// Tracker counts the API requests by client
+type Tracker struct {
+	lookupToken TokenLookup
+	publisher   EventPublisher
+	settings    atomic.Pointer[portainer.APIUsageSettings]
+	now         func() time.Time
+
+	mu      sync.Mutex
+	since   time.Time
+	total   int64
+	minutes minuteRing
+	clients map[string]*client
+}
+
+// NewTracker creates a tracker identifying the clients with lookupToken, the anomaly alerts being published with
+// publisher when they are enabled by the settings
+func NewTracker(settings portainer.APIUsageSettings, lookupToken TokenLookup, publisher EventPublisher) *Tracker {
+	tracker := &Tracker{
+		lookupToken: lookupToken,
+		publisher:   publisher,
+		now:         time.Now,
+		since:       time.Now(),
+		clients:     make(map[string]*client),
+	}
+	tracker.Update(settings)
+
+	return tracker
+}
+
+// Update replaces the settings of the anomaly alerts, which must have been validated
+func (tracker *Tracker) Update(settings portainer.APIUsageSettings) {
+	tracker.settings.Store(&settings)
+}
+
+// Middleware counts the API requests and their failed responses. The requests are counted when they are received,
+// so that a client is spotted while it floods the server.
+func (tracker *Tracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity := tracker.identify(r)
+		tracker.record(identity, r)
+
+		writer := &statusWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(writer, r)
+
+		if writer.statusCode >= http.StatusBadRequest {
+			tracker.recordError(identity.key)
+		}
+	})
+}
+
+// identity identifies the client of a request
+type identity struct {
+	key   string
+	usage ClientUsage
+}
+
+func (tracker *Tracker) identify(r *http.Request) identity {
+	address := requestutils.RemoteIP(r)
+
+	var tokenData *portainer.TokenData
+	var apiKey *portainer.APIKey
+	if tracker.lookupToken != nil {
+		tokenData, apiKey = tracker.lookupToken(r)
+	}
+
+	switch {
+	case tokenData != nil && apiKey != nil:
+		return identity{
+			key:   "apikey:" + strconv.Itoa(int(apiKey.ID)),
+			usage: ClientUsage{UserID: tokenData.ID, Username: tokenData.Username, APIKeyID: apiKey.ID, APIKeyDescription: apiKey.Description, Address: address},
+		}
+	case tokenData != nil:
+		return identity{
+			key:   "user:" + strconv.Itoa(int(tokenData.ID)),
+			usage: ClientUsage{UserID: tokenData.ID, Username: tokenData.Username, Address: address},
+		}
+	}
+
+	return identity{key: "ip:" + address, usage: ClientUsage{Address: address}}
+}
+
+// record counts a request of a client, and publishes an anomaly alert when the client sends many more requests than
+// usual
+func (tracker *Tracker) record(identity identity, r *http.Request) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	now := tracker.now()
+	minute := now.Unix() / 60
+
+	tracker.total++
+	tracker.minutes.add(minute)
+
+	c := tracker.clients[identity.key]
+	if c == nil {
+		if identity.usage.UserID == 0 {
+			tracker.evictAnonymous(minute)
+		}
+
+		c = &client{usage: identity.usage}
+		tracker.clients[identity.key] = c
+	}
+
+	c.usage.Username = identity.usage.Username
+	c.usage.Address = identity.usage.Address
+	c.usage.UserAgent = r.UserAgent()
+	c.usage.LastMethod = r.Method
+	c.usage.LastPath = r.URL.Path
+	c.usage.LastSeen = now
+	c.usage.TotalRequests++
+
+	count := c.minutes.add(minute)
+	c.usage.PeakRequestsPerMinute = max(c.usage.PeakRequestsPerMinute, count)
+
+	settings := tracker.settings.Load()
+	if !settings.AnomalyAlerts || tracker.publisher == nil || now.Sub(c.lastAlert) < alertCooldown {
+		return
+	}
+
+	// the usual rate is the average of the previous minutes of the window, the minutes before the first request of
+	// the client counting as idle
+	baseline := float64(c.minutes.sum(minute-1)) / (window - 1)
+	if !IsAnomalous(*settings, count, baseline) {
+		return
+	}
+
+	c.lastAlert = now
+	tracker.publisher.Publish(lifecycle.NewEvent(lifecycle.APIUsageAnomaly, identity.key, anomalyData(c.usage, count, baseline)))
+}
+
+func (tracker *Tracker) recordError(key string) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if c := tracker.clients[key]; c != nil {
+		c.usage.ErrorCount++
+	}
+}
+
+// evictAnonymous removes the unauthenticated clients idle for the whole window, and the least recently seen one when
+// there are still too many of them. The lock must be held.
+func (tracker *Tracker) evictAnonymous(minute int64) {
+	var anonymous []string
+	for key, c := range tracker.clients {
+		if c.usage.UserID != 0 {
+			continue
+		}
+
+		if c.minutes.sum(minute) == 0 {
+			delete(tracker.clients, key)
+			continue
+		}
+
+		anonymous = append(anonymous, key)
+	}
+
+	if len(anonymous) < maxAnonymousClients {
+		return
+	}
+
+	oldest := anonymous[0]
+	for _, key := range anonymous[1:] {
+		if tracker.clients[key].usage.LastSeen.Before(tracker.clients[oldest].usage.LastSeen) {
+			oldest = key
+		}
+	}
+
+	delete(tracker.clients, oldest)
+}
+
+// IsAnomalous returns whether a client sending count requests in the current minute, and baseline requests per minute
+// on average before it, sends many more requests than usual
+func IsAnomalous(settings portainer.APIUsageSettings, count int, baseline float64) bool {
+	minRequests := settings.MinRequestsPerMinute
+	if minRequests <= 0 {
+		minRequests = defaultMinRequestsPerMinute
+	}
+
+	factor := settings.SpikeFactor
+	if factor <= 0 {
+		factor = defaultSpikeFactor
+	}
+
+	return count >= minRequests && float64(count) > factor*baseline
+}
+
+func anomalyData(usage ClientUsage, count int, baseline float64) map[string]string {
+	data := map[string]string{
+		"address":           usage.Address,
+		"userAgent":         usage.UserAgent,
+		"requestsPerMinute": strconv.Itoa(count),
+		"baseline":          strconv.FormatFloat(baseline, 'f', 1, 64),
+	}
+
+	if usage.UserID != 0 {
+		data["userId"] = strconv.Itoa(int(usage.UserID))
+		data["username"] = usage.Username
+	}
+
+	if usage.APIKeyID != 0 {
+		data["apiKeyId"] = strconv.Itoa(int(usage.APIKeyID))
+		data["apiKeyDescription"] = usage.APIKeyDescription
+	}
+
+	return data
+}
+
+// Report returns the usage of the API since the start of the tracker, the clients sending the most requests over the
+// last hour first
+func (tracker *Tracker) Report() *Report {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	minute := tracker.now().Unix() / 60
+
+	report := &Report{
+		Since:              tracker.since,
+		TotalRequests:      tracker.total,
+		RequestsLastMinute: tracker.minutes.count(minute),
+		RequestsLastHour:   tracker.minutes.sum(minute),
+		RequestsPerMinute:  tracker.minutes.last(minute),
+		Clients:            make([]ClientUsage, 0, len(tracker.clients)),
+	}
+
+	for _, c := range tracker.clients {
+		usage := c.usage
+		usage.RequestsLastMinute = c.minutes.count(minute)
+		usage.RequestsLastHour = c.minutes.sum(minute)
+		usage.RequestsPerMinute = c.minutes.last(minute)
+
+		report.Clients = append(report.Clients, usage)
+	}
+
+	sort.Slice(report.Clients, func(i, j int) bool {
+		if report.Clients[i].RequestsLastHour != report.Clients[j].RequestsLastHour {
+			return report.Clients[i].RequestsLastHour > report.Clients[j].RequestsLastHour
+		}
+
+		return report.Clients[i].TotalRequests > report.Clients[j].TotalRequests
+	})
+
+	return report
+}
+
+// statusWriter keeps the status code of the response, while letting the streamed and hijacked responses through
+type statusWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.statusCode = statusCode
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+
+	return hijacker.Hijack()
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ValidateSettings checks that the thresholds of the anomaly alerts are not negative
+func ValidateSettings(settings portainer.APIUsageSettings) error {
+	if settings.MinRequestsPerMinute < 0 || settings.SpikeFactor < 0 {
+		return errors.New("invalid API usage alert, the minimum requests per minute and the spike factor must not be negative")
+	}
+
+	return nil
+}
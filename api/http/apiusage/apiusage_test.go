@@ -0,0 +1,172 @@
+package apiusage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/lifecycle"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type eventRecorder struct {
+	events []lifecycle.Event
+}
+
+func (recorder *eventRecorder) Publish(event lifecycle.Event) {
+	recorder.events = append(recorder.events, event)
+}
+
+func TestTracker(t *testing.T) {
+	is := assert.New(t)
+
+	recorder := &eventRecorder{}
+	tracker := NewTracker(portainer.APIUsageSettings{}, func(r *http.Request) (*portainer.TokenData, *portainer.APIKey) {
+		switch r.Header.Get("X-User") {
+		case "admin":
+			return &portainer.TokenData{ID: 1, Username: "admin"}, nil
+		case "bot":
+			return &portainer.TokenData{ID: 2, Username: "bot"}, &portainer.APIKey{ID: 5, Description: "nightly-cleanup"}
+		}
+
+		return nil, nil
+	}, recorder)
+
+	now := time.Unix(1700000000, 0)
+	tracker.now = func() time.Time { return now }
+
+	handler := tracker.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	do := func(path, user string, count int) {
+		for i := 0; i < count; i++ {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			req.Header.Set("User-Agent", "python-requests/2.31.0")
+			if user != "" {
+				req.Header.Set("X-User", user)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	}
+
+	do("/api/endpoints", "admin", 3)
+	do("/api/missing", "admin", 1)
+	do("/api/stacks", "bot", 10)
+	do("/api/status", "", 2)
+	do("/index.html", "", 5)
+
+	now = now.Add(2 * time.Minute)
+	do("/api/stacks", "bot", 4)
+
+	report := tracker.Report()
+	is.Equal(int64(20), report.TotalRequests, "only the API requests should be counted")
+	is.Equal(4, report.RequestsLastMinute)
+	is.Equal(20, report.RequestsLastHour)
+	is.Len(report.RequestsPerMinute, window)
+	is.Equal(4, report.RequestsPerMinute[window-1])
+	is.Equal(16, report.RequestsPerMinute[window-3])
+	is.Len(report.Clients, 3)
+
+	bot := report.Clients[0]
+	is.Equal(portainer.APIKeyID(5), bot.APIKeyID, "the clients sending the most requests should be first")
+	is.Equal("bot", bot.Username)
+	is.Equal("nightly-cleanup", bot.APIKeyDescription)
+	is.Equal(int64(14), bot.TotalRequests)
+	is.Equal(10, bot.PeakRequestsPerMinute)
+	is.Equal(4, bot.RequestsLastMinute)
+	is.Equal("python-requests/2.31.0", bot.UserAgent)
+
+	admin := report.Clients[1]
+	is.Equal(portainer.UserID(1), admin.UserID)
+	is.Equal(int64(4), admin.TotalRequests)
+	is.Equal(int64(1), admin.ErrorCount)
+	is.Equal("/api/missing", admin.LastPath)
+
+	anonymous := report.Clients[2]
+	is.Equal(portainer.UserID(0), anonymous.UserID)
+	is.Equal("10.0.0.1", anonymous.Address)
+
+	now = now.Add(2 * time.Hour)
+	report = tracker.Report()
+	is.Equal(0, report.RequestsLastHour, "the counts should leave the window")
+	is.Equal(int64(20), report.TotalRequests)
+	is.Empty(recorder.events, "no alert should be published by default")
+}
+
+func TestTracker_AnomalyAlerts(t *testing.T) {
+	is := assert.New(t)
+
+	recorder := &eventRecorder{}
+	tracker := NewTracker(portainer.APIUsageSettings{AnomalyAlerts: true, MinRequestsPerMinute: 100, SpikeFactor: 5}, nil, recorder)
+
+	now := time.Unix(1700000000, 0)
+	tracker.now = func() time.Time { return now }
+
+	handler := tracker.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(count int) {
+		for i := 0; i < count; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/api/endpoints", nil)
+			req.RemoteAddr = "10.0.0.2:1234"
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	}
+
+	do(99)
+	is.Empty(recorder.events, "the usage below the minimum should not be anomalous")
+
+	do(1)
+	if is.Len(recorder.events, 1) {
+		event := recorder.events[0]
+		is.Equal(lifecycle.APIUsageAnomaly, event.Type)
+		is.Equal("ip:10.0.0.2", event.ResourceID)
+		is.Equal("100", event.Data["requestsPerMinute"])
+		is.Equal("10.0.0.2", event.Data["address"])
+	}
+
+	do(500)
+	is.Len(recorder.events, 1, "a client should not be alerted on again before the cooldown")
+
+	for i := 0; i < 59; i++ {
+		now = now.Add(time.Minute)
+		do(100)
+	}
+
+	now = now.Add(time.Hour)
+	do(100)
+	is.Len(recorder.events, 2)
+
+	for i := 0; i < 60; i++ {
+		now = now.Add(time.Minute)
+		do(100)
+	}
+
+	now = now.Add(time.Minute)
+	do(200)
+	is.Len(recorder.events, 2, "a steady usage should not be anomalous")
+}
+
+func TestIsAnomalous(t *testing.T) {
+	is := assert.New(t)
+
+	is.False(IsAnomalous(portainer.APIUsageSettings{}, 599, 0), "the default minimum should apply")
+	is.True(IsAnomalous(portainer.APIUsageSettings{}, 600, 10))
+	is.False(IsAnomalous(portainer.APIUsageSettings{}, 600, 60), "the default spike factor should apply")
+	is.True(IsAnomalous(portainer.APIUsageSettings{MinRequestsPerMinute: 50, SpikeFactor: 2}, 50, 20))
+}
+
+func TestValidateSettings(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateSettings(portainer.APIUsageSettings{AnomalyAlerts: true}))
+	is.Error(ValidateSettings(portainer.APIUsageSettings{MinRequestsPerMinute: -1}))
+	is.Error(ValidateSettings(portainer.APIUsageSettings{SpikeFactor: -2}))
+}
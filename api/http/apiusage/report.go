@@ -0,0 +1,50 @@
+package apiusage
+
+import (
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// Report is the usage of the API since Portainer started
+type Report struct {
+	// Time at which the requests started to be counted
+	Since time.Time `json:"Since"`
+	// Number of API requests since Portainer started
+	TotalRequests int64 `json:"TotalRequests" example:"152340"`
+	// Number of API requests of the current minute
+	RequestsLastMinute int `json:"RequestsLastMinute" example:"84"`
+	// Number of API requests of the last hour
+	RequestsLastHour int `json:"RequestsLastHour" example:"5210"`
+	// Number of API requests of each minute of the last hour, the oldest first
+	RequestsPerMinute []int `json:"RequestsPerMinute"`
+	// Usage of each client, the clients sending the most requests over the last hour first
+	Clients []ClientUsage `json:"Clients"`
+}
+
+// ClientUsage is the usage of the API by a user, an API key or an unauthenticated IP address
+type ClientUsage struct {
+	// User of the requests, 0 when they are not authenticated
+	UserID   portainer.UserID `json:"UserId" example:"3"`
+	Username string           `json:"Username,omitempty" example:"ci-bot"`
+	// API key of the requests, 0 when they are authenticated with a session token or not authenticated
+	APIKeyID          portainer.APIKeyID `json:"ApiKeyId" example:"7"`
+	APIKeyDescription string             `json:"ApiKeyDescription,omitempty" example:"nightly-cleanup"`
+	// IP address and user agent of the last request
+	Address   string `json:"Address" example:"10.0.4.12"`
+	UserAgent string `json:"UserAgent" example:"python-requests/2.31.0"`
+	// Method and path of the last request
+	LastMethod string    `json:"LastMethod" example:"GET"`
+	LastPath   string    `json:"LastPath" example:"/api/endpoints/1/docker/containers/json"`
+	LastSeen   time.Time `json:"LastSeen"`
+	// Number of requests since Portainer started
+	TotalRequests int64 `json:"TotalRequests" example:"48210"`
+	// Number of requests answered with an error status since Portainer started
+	ErrorCount int64 `json:"ErrorCount" example:"12"`
+	// Highest number of requests sent in a minute since Portainer started
+	PeakRequestsPerMinute int `json:"PeakRequestsPerMinute" example:"900"`
+	RequestsLastMinute    int `json:"RequestsLastMinute" example:"60"`
+	RequestsLastHour      int `json:"RequestsLastHour" example:"3600"`
+	// Number of requests of each minute of the last hour, the oldest first
+	RequestsPerMinute []int `json:"RequestsPerMinute"`
+}
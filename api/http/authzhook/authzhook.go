@@ -0,0 +1,441 @@
+// Package authzhook authorizes the API operations with an external policy service, such as an Open Policy Agent
+// server. The principal, the environment and the operation of the selected requests are posted to the service, which
+// allows or denies them.
+package authzhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/client"
+	"github.com/portainer/portainer/api/internal/requestutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/rs/zerolog/log"
+)
+
+const requestTimeout = 5 * time.Second
+
+// alwaysExemptPaths are the paths never authorized by the policy service: the authentication, and the settings so
+// that an administrator can always disable an unreachable policy service
+var alwaysExemptPaths = []string{"/api/auth/**", "/api/settings/**"}
+
+var methodRe = regexp.MustCompile(`^[A-Z]+$`)
+
+var (
+	// ErrOperationDenied is returned when the policy service denies an operation
+	ErrOperationDenied = errors.New("operation denied by the authorization policy")
+	// ErrPolicyUnavailable is returned when the policy service cannot be reached while it fails closed
+	ErrPolicyUnavailable = errors.New("unable to evaluate the authorization policy")
+)
+
+// UserLookup returns the user authenticated by the request, if any
+type UserLookup func(r *http.Request) (portainer.UserID, bool)
+
+// operationPattern is a method and a path pattern, an empty method matching all the methods
+type operationPattern struct {
+	method string
+	path   []string
+}
+
+// hook is the normalized form of the authorization hook settings
+type hook struct {
+	enabled       bool
+	url           string
+	operations    []operationPattern
+	failOpen      bool
+	cacheDuration time.Duration
+}
+
+type decision struct {
+	allowed bool
+	reason  string
+	expires time.Time
+}
+
+// input is the document posted to the policy service, wrapped in an "input" field as expected by OPA
+type input struct {
+	Principal principal `json:"principal"`
+	Endpoint  *endpoint `json:"endpoint,omitempty"`
+	Operation operation `json:"operation"`
+}
+
+type principal struct {
+	ID       portainer.UserID `json:"id"`
+	Username string           `json:"username"`
+	Role     string           `json:"role"`
+	Teams    []string         `json:"teams"`
+}
+
+type endpoint struct {
+	ID      portainer.EndpointID      `json:"id"`
+	Name    string                    `json:"name"`
+	Type    portainer.EndpointType    `json:"type"`
+	GroupID portainer.EndpointGroupID `json:"groupId"`
+}
+
+type operation struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// Hook authorizes the API operations with the policy service of the settings, nothing being authorized by default
+type Hook struct {
+	dataStore  dataservices.DataStore
+	lookupUser UserLookup
+	client     *http.Client
+	current    atomic.Pointer[hook]
+	now        func() time.Time
+
+	mu        sync.Mutex
+	decisions map[string]decision
+	lastSweep time.Time
+}
+
+// NewHook creates a hook from the settings, the principal of the requests being the user found by lookupUser
+func NewHook(settings portainer.AuthorizationHookSettings, dataStore dataservices.DataStore, lookupUser UserLookup) *Hook {
+	h := &Hook{
+		dataStore:  dataStore,
+		lookupUser: lookupUser,
+		client:     client.NewExternalClient(requestTimeout),
+		now:        time.Now,
+	}
+	h.Update(settings)
+
+	return h
+}
+
+// Update replaces the hook with the settings, which must have been validated, and clears the cached decisions
+func (h *Hook) Update(settings portainer.AuthorizationHookSettings) {
+	current := &hook{
+		enabled:  settings.Enabled,
+		url:      settings.URL,
+		failOpen: settings.FailOpen,
+	}
+
+	for _, pattern := range settings.Operations {
+		if op, err := parseOperation(pattern); err == nil {
+			current.operations = append(current.operations, op)
+		}
+	}
+
+	if settings.CacheDuration != "" {
+		current.cacheDuration, _ = time.ParseDuration(settings.CacheDuration)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.current.Store(current)
+	h.decisions = make(map[string]decision)
+}
+
+// Middleware rejects the selected API operations denied by the policy service with a 403 response. The requests which
+// are not authenticated are left to the authentication of the API.
+func (h *Hook) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := h.current.Load()
+		if !current.enabled || !current.selects(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID, ok := h.lookupUser(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		in, err := h.newInput(r, userID)
+		if h.dataStore.IsErrObjectNotFound(err) {
+			next.ServeHTTP(w, r)
+			return
+		} else if err != nil {
+			httperror.WriteError(w, http.StatusInternalServerError, "Unable to retrieve the principal of the request", err)
+			return
+		}
+
+		d, err := h.authorize(current, in)
+		if err != nil {
+			if !current.failOpen {
+				httperror.WriteError(w, http.StatusForbidden, "Access denied", fmt.Errorf("%w: %w", ErrPolicyUnavailable, err))
+				return
+			}
+
+			log.Warn().Err(err).Str("method", r.Method).Str("path", r.URL.Path).Msg("unable to evaluate the authorization policy, the operation is allowed")
+			d = decision{allowed: true}
+		}
+
+		if !d.allowed {
+			err := ErrOperationDenied
+			if d.reason != "" {
+				err = fmt.Errorf("%w: %s", ErrOperationDenied, d.reason)
+			}
+
+			httperror.WriteError(w, http.StatusForbidden, "Access denied", err)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// selects returns whether the request is authorized by the policy service
+func (current *hook) selects(r *http.Request) bool {
+	if !strings.HasPrefix(r.URL.Path, "/api/") || r.Method == http.MethodOptions {
+		return false
+	}
+
+	segments := requestutils.PathSegments(r.URL.Path)
+	for _, pattern := range alwaysExemptPaths {
+		if requestutils.MatchPath(requestutils.PathSegments(pattern), segments) {
+			return false
+		}
+	}
+
+	if len(current.operations) == 0 {
+		return r.Method != http.MethodGet && r.Method != http.MethodHead
+	}
+
+	for _, op := range current.operations {
+		if (op.method == "" || op.method == r.Method) && requestutils.MatchPath(op.path, segments) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newInput describes the user, the environment and the operation of the request
+func (h *Hook) newInput(r *http.Request, userID portainer.UserID) (*input, error) {
+	user, err := h.dataStore.User().Read(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	in := &input{
+		Principal: principal{
+			ID:       user.ID,
+			Username: user.Username,
+			Role:     "user",
+			Teams:    []string{},
+		},
+		Operation: operation{Method: r.Method, Path: r.URL.Path},
+	}
+
+	if user.Role == portainer.AdministratorRole {
+		in.Principal.Role = "administrator"
+	}
+
+	memberships, err := h.dataStore.TeamMembership().TeamMembershipsByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, membership := range memberships {
+		team, err := h.dataStore.Team().Read(membership.TeamID)
+		if h.dataStore.IsErrObjectNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		in.Principal.Teams = append(in.Principal.Teams, team.Name)
+	}
+
+	if endpointID, ok := requestEndpointID(r); ok {
+		e, err := h.dataStore.Endpoint().Endpoint(endpointID)
+		if err == nil {
+			in.Endpoint = &endpoint{ID: e.ID, Name: e.Name, Type: e.Type, GroupID: e.GroupID}
+		} else if !h.dataStore.IsErrObjectNotFound(err) {
+			return nil, err
+		}
+	}
+
+	return in, nil
+}
+
+// requestEndpointID returns the environment targeted by the request, from its /api/endpoints/{id} path or from its
+// endpointId query parameter
+func requestEndpointID(r *http.Request) (portainer.EndpointID, bool) {
+	segments := requestutils.PathSegments(r.URL.Path)
+
+	rawID := r.URL.Query().Get("endpointId")
+	if len(segments) >= 3 && segments[1] == "endpoints" {
+		rawID = segments[2]
+	}
+
+	id, err := strconv.Atoi(rawID)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+
+	return portainer.EndpointID(id), true
+}
+
+// authorize returns the decision of the policy service for the input, from the cache when it is still valid
+func (h *Hook) authorize(current *hook, in *input) (decision, error) {
+	key := fmt.Sprintf("%d %s %s", in.Principal.ID, in.Operation.Method, in.Operation.Path)
+	if in.Endpoint != nil {
+		key += fmt.Sprintf(" %d", in.Endpoint.ID)
+	}
+
+	if current.cacheDuration > 0 {
+		h.mu.Lock()
+		d, ok := h.decisions[key]
+		h.mu.Unlock()
+
+		if ok && h.now().Before(d.expires) {
+			return d, nil
+		}
+	}
+
+	d, err := h.query(current, in)
+	if err != nil {
+		return d, err
+	}
+
+	if current.cacheDuration > 0 {
+		now := h.now()
+		d.expires = now.Add(current.cacheDuration)
+
+		h.mu.Lock()
+		// the decisions cached with previous settings are discarded
+		if h.current.Load() == current {
+			h.sweep(now, current.cacheDuration)
+			h.decisions[key] = d
+		}
+		h.mu.Unlock()
+	}
+
+	return d, nil
+}
+
+// sweep removes the expired decisions
+func (h *Hook) sweep(now time.Time, interval time.Duration) {
+	if now.Sub(h.lastSweep) < interval {
+		return
+	}
+
+	h.lastSweep = now
+
+	for key, d := range h.decisions {
+		if !now.Before(d.expires) {
+			delete(h.decisions, key)
+		}
+	}
+}
+
+// query posts the input to the policy service. The response can be an OPA decision, {"result": true} or
+// {"result": {"allow": true, "reason": "..."}}, or {"allow": true, "reason": "..."}. An undefined decision denies the
+// operation.
+func (h *Hook) query(current *hook, in *input) (decision, error) {
+	body, err := json.Marshal(map[string]*input{"input": in})
+	if err != nil {
+		return decision{}, err
+	}
+
+	resp, err := h.client.Post(current.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return decision{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return decision{}, fmt.Errorf("unexpected status code %d from the policy service", resp.StatusCode)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Allow  *bool           `json:"allow"`
+		Reason string          `json:"reason"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return decision{}, fmt.Errorf("invalid response from the policy service: %w", err)
+	}
+
+	if len(response.Result) > 0 {
+		var allowed bool
+		if err := json.Unmarshal(response.Result, &allowed); err == nil {
+			return decision{allowed: allowed}, nil
+		}
+
+		var result struct {
+			Allow  bool   `json:"allow"`
+			Reason string `json:"reason"`
+		}
+		if err := json.Unmarshal(response.Result, &result); err != nil {
+			return decision{}, fmt.Errorf("invalid result from the policy service: %w", err)
+		}
+
+		return decision{allowed: result.Allow, reason: result.Reason}, nil
+	}
+
+	if response.Allow != nil {
+		return decision{allowed: *response.Allow, reason: response.Reason}, nil
+	}
+
+	return decision{reason: "the policy is undefined"}, nil
+}
+
+func parseOperation(pattern string) (operationPattern, error) {
+	op := operationPattern{}
+
+	method, path, found := strings.Cut(strings.TrimSpace(pattern), " ")
+	if found {
+		if !methodRe.MatchString(method) {
+			return op, fmt.Errorf("invalid method %q", method)
+		}
+
+		op.method = method
+	} else {
+		path = method
+	}
+
+	path = strings.TrimSpace(path)
+	if !strings.HasPrefix(path, "/") {
+		return op, errors.New("an absolute path is expected")
+	}
+
+	op.path = requestutils.PathSegments(path)
+
+	return op, nil
+}
+
+// ValidateSettings checks that the URL of the policy service is set when the hook is enabled, and that the operations
+// and the cache duration are valid
+func ValidateSettings(settings portainer.AuthorizationHookSettings) error {
+	if settings.Enabled || settings.URL != "" {
+		u, err := url.Parse(settings.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("invalid authorization hook URL, an absolute http or https URL is expected")
+		}
+	}
+
+	for _, pattern := range settings.Operations {
+		if _, err := parseOperation(pattern); err != nil {
+			return fmt.Errorf("invalid authorization hook operation %q, a path such as /api/stacks/** optionally preceded by a method is expected", pattern)
+		}
+	}
+
+	if settings.CacheDuration != "" {
+		duration, err := time.ParseDuration(settings.CacheDuration)
+		if err != nil || duration < 0 {
+			return fmt.Errorf("invalid authorization hook cache duration %q", settings.CacheDuration)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package authzhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHook(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	user := &portainer.User{Username: "alice", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+	team := &portainer.Team{Name: "dev"}
+	is.NoError(store.Team().Create(team))
+	is.NoError(store.TeamMembership().Create(&portainer.TeamMembership{UserID: user.ID, TeamID: team.ID}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 3, Name: "production", Type: portainer.DockerEnvironment, GroupID: 1}))
+
+	var queries atomic.Int32
+	var lastInput map[string]input
+	var response atomic.Value
+	response.Store(`{"result": {"allow": false, "reason": "production is frozen"}}`)
+
+	policyService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries.Add(1)
+
+		lastInput = nil
+		if err := json.NewDecoder(r.Body).Decode(&lastInput); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Write([]byte(response.Load().(string)))
+	}))
+	defer policyService.Close()
+
+	hook := NewHook(portainer.AuthorizationHookSettings{}, store, func(r *http.Request) (portainer.UserID, bool) {
+		if r.Header.Get("X-User") == "" {
+			return 0, false
+		}
+
+		return user.ID, true
+	})
+
+	now := time.Unix(1700000000, 0)
+	hook.now = func() time.Time { return now }
+
+	handler := hook.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(method, path string, authenticated bool) int {
+		req := httptest.NewRequest(method, path, nil)
+		if authenticated {
+			req.Header.Set("X-User", "alice")
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	t.Run("nothing is authorized by default", func(t *testing.T) {
+		is.Equal(http.StatusOK, do(http.MethodPost, "/api/endpoints/3/docker/containers/create", true))
+		is.Zero(queries.Load())
+	})
+
+	hook.Update(portainer.AuthorizationHookSettings{Enabled: true, URL: policyService.URL})
+
+	t.Run("the write operations are authorized by default", func(t *testing.T) {
+		is.Equal(http.StatusForbidden, do(http.MethodPost, "/api/endpoints/3/docker/containers/create", true))
+		is.Equal(int32(1), queries.Load())
+
+		in := lastInput["input"]
+		is.Equal(principal{ID: user.ID, Username: "alice", Role: "user", Teams: []string{"dev"}}, in.Principal)
+		is.Equal(&endpoint{ID: 3, Name: "production", Type: portainer.DockerEnvironment, GroupID: 1}, in.Endpoint)
+		is.Equal(operation{Method: http.MethodPost, Path: "/api/endpoints/3/docker/containers/create"}, in.Operation)
+
+		is.Equal(http.StatusOK, do(http.MethodGet, "/api/endpoints/3/docker/containers/json", true))
+		is.Equal(http.StatusOK, do(http.MethodPut, "/api/settings", true))
+		is.Equal(http.StatusOK, do(http.MethodPost, "/api/stacks", false))
+		is.Equal(int32(1), queries.Load())
+	})
+
+	t.Run("the environment is read from the query", func(t *testing.T) {
+		response.Store(`{"result": true}`)
+
+		is.Equal(http.StatusOK, do(http.MethodPost, "/api/stacks/create/standalone/string?endpointId=3", true))
+		is.Equal(portainer.EndpointID(3), lastInput["input"].Endpoint.ID)
+	})
+
+	hook.Update(portainer.AuthorizationHookSettings{
+		Enabled:       true,
+		URL:           policyService.URL,
+		Operations:    []string{"DELETE /api/stacks/*", "/api/endpoints/*/docker/**"},
+		CacheDuration: "1m",
+	})
+
+	t.Run("the selected operations are authorized and the decisions are cached", func(t *testing.T) {
+		queries.Store(0)
+		response.Store(`{"allow": false}`)
+
+		is.Equal(http.StatusOK, do(http.MethodPost, "/api/stacks/1/start", true))
+		is.Equal(http.StatusForbidden, do(http.MethodDelete, "/api/stacks/1", true))
+		is.Equal(http.StatusForbidden, do(http.MethodGet, "/api/endpoints/3/docker/containers/json", true))
+		is.Equal(int32(2), queries.Load())
+
+		response.Store(`{"allow": true}`)
+		is.Equal(http.StatusForbidden, do(http.MethodDelete, "/api/stacks/1", true))
+		is.Equal(int32(2), queries.Load())
+
+		now = now.Add(time.Minute)
+		is.Equal(http.StatusOK, do(http.MethodDelete, "/api/stacks/1", true))
+		is.Equal(int32(3), queries.Load())
+	})
+
+	t.Run("an undefined decision denies the operation", func(t *testing.T) {
+		response.Store(`{}`)
+		is.Equal(http.StatusForbidden, do(http.MethodDelete, "/api/stacks/2", true))
+	})
+
+	t.Run("an unreachable policy service fails closed or open", func(t *testing.T) {
+		settings := portainer.AuthorizationHookSettings{Enabled: true, URL: "http://127.0.0.1:1"}
+
+		hook.Update(settings)
+		is.Equal(http.StatusForbidden, do(http.MethodDelete, "/api/stacks/1", true))
+
+		settings.FailOpen = true
+		hook.Update(settings)
+		is.Equal(http.StatusOK, do(http.MethodDelete, "/api/stacks/1", true))
+	})
+}
+
+func TestValidateSettings(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateSettings(portainer.AuthorizationHookSettings{}))
+	is.NoError(ValidateSettings(portainer.AuthorizationHookSettings{
+		Enabled:       true,
+		URL:           "https://opa.example.com/v1/data/portainer/allow",
+		Operations:    []string{"POST /api/stacks/**", "/api/endpoints/*/docker/**"},
+		CacheDuration: "30s",
+	}))
+
+	for _, settings := range []portainer.AuthorizationHookSettings{
+		{Enabled: true},
+		{Enabled: true, URL: "opa.example.com"},
+		{URL: "ftp://opa.example.com"},
+		{Operations: []string{"api/stacks"}},
+		{Operations: []string{"post /api/stacks"}},
+		{CacheDuration: "a minute"},
+		{CacheDuration: "-1m"},
+	} {
+		is.Error(ValidateSettings(settings), "%+v", settings)
+	}
+}
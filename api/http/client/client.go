@@ -12,13 +12,14 @@ import (
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/timeouts"
 
 	"github.com/rs/zerolog/log"
 )
 
 var errInvalidResponseStatus = errors.New("invalid response status (expecting 200)")
 
-const defaultHTTPTimeout = 5
+const defaultHTTPTimeout = 5 * time.Second
 
 // HTTPClient represents a client to send HTTP requests.
 type HTTPClient struct {
@@ -29,7 +30,7 @@ type HTTPClient struct {
 func NewHTTPClient() *HTTPClient {
 	return &HTTPClient{
 		&http.Client{
-			Timeout: time.Second * time.Duration(defaultHTTPTimeout),
+			Timeout: defaultHTTPTimeout,
 		},
 	}
 }
@@ -72,15 +73,13 @@ func (client *HTTPClient) ExecuteAzureAuthenticationRequest(credentials *portain
 
 // Get executes a simple HTTP GET to the specified URL and returns
 // the content of the response body. Timeout can be specified via the timeout parameter,
-// will default to defaultHTTPTimeout if set to 0.
-func Get(url string, timeout int) ([]byte, error) {
+// will default to defaultHTTPTimeout if set to 0. It fails while the offline mode is enabled.
+func Get(url string, timeout time.Duration) ([]byte, error) {
 	if timeout == 0 {
 		timeout = defaultHTTPTimeout
 	}
 
-	client := &http.Client{
-		Timeout: time.Second * time.Duration(timeout),
-	}
+	client := NewExternalClient(timeout)
 
 	response, err := client.Get(url)
 	if err != nil {
@@ -115,7 +114,7 @@ func ExecutePingOperation(host string, tlsConfig *tls.Config) (bool, error) {
 	}
 
 	client := &http.Client{
-		Timeout:   time.Second * 3,
+		Timeout:   timeouts.Current().EndpointPing,
 		Transport: transport,
 	}
 
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ErrOfflineMode is returned for the requests sent by the external clients while the offline mode is enabled
+var ErrOfflineMode = errors.New("outbound internet access is disabled by the offline mode")
+
+var offlineMode atomic.Bool
+
+// SetOfflineMode enables or disables the outbound internet access of the external clients
+func SetOfflineMode(enabled bool) {
+	offlineMode.Store(enabled)
+}
+
+// OfflineMode returns whether the outbound internet access is disabled
+func OfflineMode() bool {
+	return offlineMode.Load()
+}
+
+// offlineTransport refuses the requests while the offline mode is enabled
+type offlineTransport struct {
+	next http.RoundTripper
+}
+
+func (transport *offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if OfflineMode() {
+		return nil, ErrOfflineMode
+	}
+
+	return transport.next.RoundTrip(req)
+}
+
+// NewExternalTransport wraps the transport of a client reaching the internet so that it enforces the offline mode,
+// for the clients needing their own transport such as a TLS configuration
+func NewExternalTransport(next http.RoundTripper) http.RoundTripper {
+	return &offlineTransport{next: next}
+}
+
+// NewExternalClient returns a client to reach the internet, such as the templates or the Portainer release
+// information. All the requests made to the internet must use such a client so that the offline mode is enforced
+// in a single place. A zero timeout means no timeout.
+func NewExternalClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: NewExternalTransport(http.DefaultTransport),
+	}
+}
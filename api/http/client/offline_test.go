@@ -0,0 +1,35 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalClientOfflineMode(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	SetOfflineMode(true)
+
+	_, err := Get(server.URL, 0)
+	assert.ErrorIs(t, err, ErrOfflineMode)
+
+	_, err = NewExternalClient(0).Get(server.URL)
+	assert.ErrorIs(t, err, ErrOfflineMode)
+
+	_, err = (&http.Client{Transport: NewExternalTransport(http.DefaultTransport)}).Get(server.URL)
+	assert.ErrorIs(t, err, ErrOfflineMode)
+	assert.Zero(t, requests)
+
+	SetOfflineMode(false)
+
+	_, err = Get(server.URL, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+}
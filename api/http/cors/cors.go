@@ -0,0 +1,178 @@
+// Package cors applies the cross-origin resource sharing policy of the API
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// preflightMaxAge is the number of seconds a browser can cache the result of a preflight request
+const preflightMaxAge = 600
+
+var (
+	defaultAllowedMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	defaultAllowedHeaders = []string{"Authorization", "Content-Type", "X-API-Key"}
+	// exposedHeaders are the response headers readable by the cross-origin callers
+	exposedHeaders = []string{"ETag", "X-Total-Count", "X-Total-Available"}
+
+	validMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+)
+
+// policy is the normalized form of the CORS settings
+type policy struct {
+	anyOrigin bool
+	origins   []string
+	methods   []string
+	headers   []string
+}
+
+// Policy applies the CORS settings to the API requests, no origin being allowed by default
+type Policy struct {
+	current atomic.Pointer[policy]
+}
+
+// NewPolicy creates a policy from the settings
+func NewPolicy(settings portainer.CORSSettings) *Policy {
+	p := &Policy{}
+	p.Update(settings)
+
+	return p
+}
+
+// Update replaces the policy with the settings, which must have been validated
+func (p *Policy) Update(settings portainer.CORSSettings) {
+	current := &policy{
+		methods: defaultAllowedMethods,
+		headers: defaultAllowedHeaders,
+	}
+
+	for _, origin := range settings.AllowedOrigins {
+		if origin == "*" {
+			current.anyOrigin = true
+			continue
+		}
+
+		current.origins = append(current.origins, strings.ToLower(strings.TrimSuffix(origin, "/")))
+	}
+
+	if len(settings.AllowedMethods) > 0 {
+		current.methods = nil
+		for _, method := range settings.AllowedMethods {
+			current.methods = append(current.methods, strings.ToUpper(method))
+		}
+	}
+
+	if len(settings.AllowedHeaders) > 0 {
+		current.headers = nil
+		for _, header := range settings.AllowedHeaders {
+			current.headers = append(current.headers, http.CanonicalHeaderKey(header))
+		}
+	}
+
+	p.current.Store(current)
+}
+
+// Middleware adds the CORS headers to the responses of the API and answers the preflight requests
+func (p *Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		current := p.current.Load()
+		w.Header().Add("Vary", "Origin")
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !current.allowsOrigin(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			// the browser blocks the response without the CORS headers
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+
+		if !preflight {
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(exposedHeaders, ", "))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+		if !current.allowsRequest(r) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(current.methods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(current.headers, ", "))
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(preflightMaxAge))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (current *policy) allowsOrigin(origin string) bool {
+	return current.anyOrigin || slices.Contains(current.origins, strings.ToLower(origin))
+}
+
+// allowsRequest checks the method and the headers of a preflight request
+func (current *policy) allowsRequest(r *http.Request) bool {
+	if !slices.Contains(current.methods, strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))) {
+		return false
+	}
+
+	for _, header := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !slices.Contains(current.headers, http.CanonicalHeaderKey(header)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidateSettings checks that the origins are "*" or an http or https origin without path, and that the
+// methods and headers are valid
+func ValidateSettings(settings portainer.CORSSettings) error {
+	for _, origin := range settings.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+
+		u, err := url.Parse(strings.TrimSuffix(origin, "/"))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.User != nil {
+			return fmt.Errorf("invalid CORS origin %q, an origin such as https://dashboard.example.com is expected", origin)
+		}
+	}
+
+	for _, method := range settings.AllowedMethods {
+		if !slices.Contains(validMethods, strings.ToUpper(method)) {
+			return fmt.Errorf("invalid CORS method %q", method)
+		}
+	}
+
+	for _, header := range settings.AllowedHeaders {
+		if header == "" || strings.ContainsFunc(header, func(c rune) bool {
+			return !(c == '-' || c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'))
+		}) {
+			return fmt.Errorf("invalid CORS header %q", header)
+		}
+	}
+
+	return nil
+}
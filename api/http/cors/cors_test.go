@@ -0,0 +1,108 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(t *testing.T) {
+	is := assert.New(t)
+
+	policy := NewPolicy(portainer.CORSSettings{})
+
+	var served int
+	handler := policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+	}))
+
+	do := func(method, path, origin string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	preflight := map[string]string{"Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "authorization"}
+
+	t.Run("no origin is allowed by default", func(t *testing.T) {
+		rr := do(http.MethodGet, "/api/endpoints", "https://dashboard.example.com", nil)
+		is.Empty(rr.Header().Get("Access-Control-Allow-Origin"))
+
+		rr = do(http.MethodOptions, "/api/endpoints", "https://dashboard.example.com", preflight)
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+
+	policy.Update(portainer.CORSSettings{AllowedOrigins: []string{"https://Dashboard.example.com/"}})
+
+	t.Run("allowed origin", func(t *testing.T) {
+		rr := do(http.MethodGet, "/api/endpoints", "https://dashboard.example.com", nil)
+		is.Equal("https://dashboard.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+		is.Contains(rr.Header().Get("Access-Control-Expose-Headers"), "X-Total-Count")
+
+		rr = do(http.MethodOptions, "/api/endpoints", "https://dashboard.example.com", preflight)
+		is.Equal(http.StatusNoContent, rr.Code)
+		is.Equal("https://dashboard.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+		is.Contains(rr.Header().Get("Access-Control-Allow-Headers"), "Authorization")
+		is.Contains(rr.Header().Get("Access-Control-Allow-Methods"), "DELETE")
+	})
+
+	t.Run("other origin", func(t *testing.T) {
+		rr := do(http.MethodGet, "/api/endpoints", "https://evil.example.com", nil)
+		is.Empty(rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("preflight with a header that is not allowed", func(t *testing.T) {
+		rr := do(http.MethodOptions, "/api/endpoints", "https://dashboard.example.com", map[string]string{
+			"Access-Control-Request-Method":  "GET",
+			"Access-Control-Request-Headers": "X-Custom",
+		})
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+
+	policy.Update(portainer.CORSSettings{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"get"}})
+
+	t.Run("any origin with restricted methods", func(t *testing.T) {
+		rr := do(http.MethodOptions, "/api/endpoints", "https://other.example.com", preflight)
+		is.Equal(http.StatusNoContent, rr.Code)
+		is.Equal("GET", rr.Header().Get("Access-Control-Allow-Methods"))
+
+		rr = do(http.MethodOptions, "/api/endpoints", "https://other.example.com", map[string]string{"Access-Control-Request-Method": "DELETE"})
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("the UI is not affected", func(t *testing.T) {
+		rr := do(http.MethodGet, "/index.html", "https://other.example.com", nil)
+		is.Empty(rr.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	is.Equal(4, served, "the preflight requests must not reach the API")
+}
+
+func TestValidateSettings(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateSettings(portainer.CORSSettings{}))
+	is.NoError(ValidateSettings(portainer.CORSSettings{
+		AllowedOrigins: []string{"*", "https://dashboard.example.com", "http://localhost:3000"},
+		AllowedMethods: []string{"get", "POST"},
+		AllowedHeaders: []string{"X-API-Key", "Content-Type"},
+	}))
+
+	is.Error(ValidateSettings(portainer.CORSSettings{AllowedOrigins: []string{"dashboard.example.com"}}))
+	is.Error(ValidateSettings(portainer.CORSSettings{AllowedOrigins: []string{"https://dashboard.example.com/path"}}))
+	is.Error(ValidateSettings(portainer.CORSSettings{AllowedMethods: []string{"CONNECT"}}))
+	is.Error(ValidateSettings(portainer.CORSSettings{AllowedHeaders: []string{"X-API-Key: x"}}))
+}
@@ -11,4 +11,10 @@ var (
 	ErrResourceAccessDenied = errors.New("Access denied to resource")
 	// ErrNotAvailableInDemo feature is not allowed in demo
 	ErrNotAvailableInDemo = errors.New("This feature is not available in the demo version of Portainer")
+	// ErrLoginBannerNotAcknowledged the login banner must be acknowledged before logging in
+	ErrLoginBannerNotAcknowledged = errors.New("The login banner must be acknowledged")
+	// ErrPasswordChangeRequired the password must be changed before logging in
+	ErrPasswordChangeRequired = errors.New("The password must be changed")
+	// ErrAccountPendingApproval the account was requested through the signup and is not approved yet
+	ErrAccountPendingApproval = errors.New("The account is pending approval")
 )
@@ -2,11 +2,14 @@ package auth
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/lifecycle"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -21,6 +24,10 @@ type authenticatePayload struct {
 	Username string `example:"admin" validate:"required"`
 	// Password
 	Password string `example:"mypassword" validate:"required"`
+	// Whether the user acknowledged the login banner, required when the banner is enabled
+	AcknowledgeLoginBanner bool `example:"true"`
+	// New password, required when the user must change the password at the next login
+	NewPassword string `example:"mynewpassword"`
 }
 
 type authenticateResponse struct {
@@ -50,6 +57,7 @@ func (payload *authenticatePayload) Validate(r *http.Request) error {
 // @param body body authenticatePayload true "Credentials used for authentication"
 // @success 200 {object} authenticateResponse "Success"
 // @failure 400 "Invalid request"
+// @failure 403 "Login banner not acknowledged, password change required or account pending approval"
 // @failure 422 "Invalid Credentials"
 // @failure 500 "Server error"
 // @router /auth [post]
@@ -65,6 +73,10 @@ func (handler *Handler) authenticate(rw http.ResponseWriter, r *http.Request) *h
 		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
 	}
 
+	if settings.LoginBanner.Enabled && !payload.AcknowledgeLoginBanner {
+		return httperror.Forbidden(httperrors.ErrLoginBannerNotAcknowledged.Error(), httperrors.ErrLoginBannerNotAcknowledged)
+	}
+
 	user, err := handler.DataStore.User().UserByUsername(payload.Username)
 	if err != nil {
 		if !handler.DataStore.IsErrObjectNotFound(err) {
@@ -74,12 +86,14 @@ func (handler *Handler) authenticate(rw http.ResponseWriter, r *http.Request) *h
 		if settings.AuthenticationMethod == portainer.AuthenticationInternal ||
 			settings.AuthenticationMethod == portainer.AuthenticationOAuth ||
 			(settings.AuthenticationMethod == portainer.AuthenticationLDAP && !settings.LDAPSettings.AutoCreateUsers) {
+			handler.recordFailedLogin(r, payload.Username)
+
 			return &httperror.HandlerError{StatusCode: http.StatusUnprocessableEntity, Message: "Invalid credentials", Err: httperrors.ErrUnauthorized}
 		}
 	}
 
 	if user != nil && isUserInitialAdmin(user) || settings.AuthenticationMethod == portainer.AuthenticationInternal {
-		return handler.authenticateInternal(rw, user, payload.Password)
+		return handler.authenticateInternal(rw, r, user, payload.Password, payload.NewPassword)
 	}
 
 	if settings.AuthenticationMethod == portainer.AuthenticationOAuth {
@@ -87,7 +101,7 @@ func (handler *Handler) authenticate(rw http.ResponseWriter, r *http.Request) *h
 	}
 
 	if settings.AuthenticationMethod == portainer.AuthenticationLDAP {
-		return handler.authenticateLDAP(rw, user, payload.Username, payload.Password, &settings.LDAPSettings)
+		return handler.authenticateLDAP(rw, r, user, payload.Username, payload.Password, &settings.LDAPSettings)
 	}
 
 	return &httperror.HandlerError{StatusCode: http.StatusUnprocessableEntity, Message: "Login method is not supported", Err: httperrors.ErrUnauthorized}
@@ -97,23 +111,75 @@ func isUserInitialAdmin(user *portainer.User) bool {
 	return int(user.ID) == 1
 }
 
-func (handler *Handler) authenticateInternal(w http.ResponseWriter, user *portainer.User, password string) *httperror.HandlerError {
+func (handler *Handler) authenticateInternal(w http.ResponseWriter, r *http.Request, user *portainer.User, password, newPassword string) *httperror.HandlerError {
 	err := handler.CryptoService.CompareHashAndData(user.Password, password)
 	if err != nil {
+		handler.recordFailedLogin(r, user.Username)
+
 		return &httperror.HandlerError{StatusCode: http.StatusUnprocessableEntity, Message: "Invalid credentials", Err: httperrors.ErrUnauthorized}
 	}
 
+	// the pending accounts are only revealed to the users knowing their password
+	if user.Pending {
+		return httperror.Forbidden(httperrors.ErrAccountPendingApproval.Error(), httperrors.ErrAccountPendingApproval)
+	}
+
+	if user.ForcePasswordChange {
+		if httpErr := handler.changeForcedPassword(user, password, newPassword); httpErr != nil {
+			return httpErr
+		}
+
+		password = newPassword
+	}
+
 	forceChangePassword := !handler.passwordStrengthChecker.Check(password)
 
-	return handler.writeToken(w, user, forceChangePassword)
+	return handler.writeToken(w, r, user, forceChangePassword)
 }
 
-func (handler *Handler) authenticateLDAP(w http.ResponseWriter, user *portainer.User, username, password string, ldapSettings *portainer.LDAPSettings) *httperror.HandlerError {
+// changeForcedPassword replaces the password of a user flagged for a password change at the next login,
+// previously issued tokens are revoked
+func (handler *Handler) changeForcedPassword(user *portainer.User, password, newPassword string) *httperror.HandlerError {
+	if newPassword == "" {
+		return httperror.Forbidden(httperrors.ErrPasswordChangeRequired.Error(), httperrors.ErrPasswordChangeRequired)
+	}
+
+	if newPassword == password {
+		return httperror.BadRequest("The new password must be different from the current password", errors.New("password unchanged"))
+	}
+
+	if !handler.passwordStrengthChecker.Check(newPassword) {
+		return httperror.BadRequest("Password does not meet the requirements", nil)
+	}
+
+	hash, err := handler.CryptoService.Hash(newPassword)
+	if err != nil {
+		return httperror.InternalServerError("Unable to hash user password", errors.New("Unable to hash user password"))
+	}
+
+	user.Password = hash
+	user.ForcePasswordChange = false
+	user.TokenIssueAt = time.Now().Unix()
+
+	if err := handler.DataStore.User().Update(user.ID, user); err != nil {
+		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+	}
+
+	return nil
+}
+
+func (handler *Handler) authenticateLDAP(w http.ResponseWriter, r *http.Request, user *portainer.User, username, password string, ldapSettings *portainer.LDAPSettings) *httperror.HandlerError {
 	err := handler.LDAPService.AuthenticateUser(username, password, ldapSettings)
 	if err != nil {
+		handler.recordFailedLogin(r, username)
+
 		return httperror.Forbidden("Only initial admin is allowed to login without oauth", err)
 	}
 
+	if user != nil && user.Pending {
+		return httperror.Forbidden(httperrors.ErrAccountPendingApproval.Error(), httperrors.ErrAccountPendingApproval)
+	}
+
 	if user == nil {
 		user = &portainer.User{
 			Username:                username,
@@ -132,13 +198,52 @@ func (handler *Handler) authenticateLDAP(w http.ResponseWriter, user *portainer.
 		log.Warn().Err(err).Msg("unable to automatically sync user teams with ldap")
 	}
 
-	return handler.writeToken(w, user, false)
+	return handler.writeToken(w, r, user, false)
 }
 
-func (handler *Handler) writeToken(w http.ResponseWriter, user *portainer.User, forceChangePassword bool) *httperror.HandlerError {
+func (handler *Handler) writeToken(w http.ResponseWriter, r *http.Request, user *portainer.User, forceChangePassword bool) *httperror.HandlerError {
 	tokenData := composeTokenData(user, forceChangePassword)
 
-	return handler.persistAndWriteToken(w, tokenData)
+	if err := handler.recordLoginBannerAcknowledgment(user); err != nil {
+		return httperror.InternalServerError("Unable to record the login banner acknowledgment", err)
+	}
+
+	if httpErr := handler.persistAndWriteToken(w, tokenData); httpErr != nil {
+		return httpErr
+	}
+
+	if handler.EventDispatcher != nil {
+		handler.EventDispatcher.Publish(lifecycle.NewEvent(lifecycle.UserLoggedIn, strconv.Itoa(int(user.ID)), map[string]string{
+			"username": user.Username,
+		}))
+	}
+
+	if handler.IntrusionDetector != nil {
+		handler.IntrusionDetector.LoginSucceeded(r, user)
+	}
+
+	return nil
+}
+
+// recordFailedLogin reports a failed login to the intrusion detector, which alerts on a successful login following
+// many failed ones
+func (handler *Handler) recordFailedLogin(r *http.Request, username string) {
+	if handler.IntrusionDetector != nil {
+		handler.IntrusionDetector.LoginFailed(r, username)
+	}
+}
+
+// recordLoginBannerAcknowledgment records that the user acknowledged the login banner, which is enforced by the
+// authentication handlers before the credentials are checked
+func (handler *Handler) recordLoginBannerAcknowledgment(user *portainer.User) error {
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil || !settings.LoginBanner.Enabled {
+		return err
+	}
+
+	user.LoginBannerAcknowledgedAt = time.Now().Unix()
+
+	return handler.DataStore.User().Update(user.ID, user)
 }
 
 func (handler *Handler) persistAndWriteToken(w http.ResponseWriter, tokenData *portainer.TokenData) *httperror.HandlerError {
@@ -16,6 +16,8 @@ import (
 type oauthPayload struct {
 	// OAuth code returned from OAuth Provided
 	Code string
+	// Whether the user acknowledged the login banner, required when the banner is enabled
+	AcknowledgeLoginBanner bool `example:"true"`
 }
 
 func (payload *oauthPayload) Validate(r *http.Request) error {
@@ -52,6 +54,7 @@ func (handler *Handler) authenticateOAuth(code string, settings *portainer.OAuth
 // @param body body oauthPayload true "OAuth Credentials used for authentication"
 // @success 200 {object} authenticateResponse "Success"
 // @failure 400 "Invalid request"
+// @failure 403 "Login banner not acknowledged"
 // @failure 422 "Invalid Credentials"
 // @failure 500 "Server error"
 // @router /auth/oauth/validate [post]
@@ -71,6 +74,10 @@ func (handler *Handler) validateOAuth(w http.ResponseWriter, r *http.Request) *h
 		return httperror.Forbidden("OAuth authentication is not enabled", errors.New("OAuth authentication is not enabled"))
 	}
 
+	if settings.LoginBanner.Enabled && !payload.AcknowledgeLoginBanner {
+		return httperror.Forbidden(httperrors.ErrLoginBannerNotAcknowledged.Error(), httperrors.ErrLoginBannerNotAcknowledged)
+	}
+
 	username, err := handler.authenticateOAuth(payload.Code, &settings.OAuthSettings)
 	if err != nil {
 		log.Debug().Err(err).Msg("OAuth authentication error")
@@ -113,5 +120,5 @@ func (handler *Handler) validateOAuth(w http.ResponseWriter, r *http.Request) *h
 
 	}
 
-	return handler.writeToken(w, user, false)
+	return handler.writeToken(w, r, user, false)
 }
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_authenticate_LoginBanner(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	cryptoService := &crypto.Service{}
+	hash, err := cryptoService.Hash("password")
+	is.NoError(err)
+
+	user := &portainer.User{ID: 1, Username: "admin", Password: hash, Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(user))
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.LoginBanner = portainer.LoginBannerSettings{Enabled: true, Content: "authorized use only"}
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, passwordChecker)
+	h.DataStore = store
+	h.CryptoService = cryptoService
+	h.JWTService = jwtService
+
+	authenticate := func(acknowledge bool) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(authenticatePayload{Username: "admin", Password: "password", AcknowledgeLoginBanner: acknowledge})
+
+		req := httptest.NewRequest(http.MethodPost, "/auth", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("login is rejected when the banner is not acknowledged", func(t *testing.T) {
+		rr := authenticate(false)
+		is.Equal(http.StatusForbidden, rr.Code)
+
+		user, err := store.User().Read(1)
+		is.NoError(err)
+		is.Zero(user.LoginBannerAcknowledgedAt)
+	})
+
+	t.Run("acknowledgment is recorded on login", func(t *testing.T) {
+		rr := authenticate(true)
+		is.Equal(http.StatusOK, rr.Code)
+
+		user, err := store.User().Read(1)
+		is.NoError(err)
+		is.NotZero(user.LoginBannerAcknowledgedAt)
+	})
+}
+
+func Test_authenticate_ForcePasswordChange(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	cryptoService := &crypto.Service{}
+	hash, err := cryptoService.Hash("current-password")
+	is.NoError(err)
+
+	user := &portainer.User{ID: 1, Username: "admin", Password: hash, Role: portainer.AdministratorRole, ForcePasswordChange: true}
+	is.NoError(store.User().Create(user))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, passwordChecker)
+	h.DataStore = store
+	h.CryptoService = cryptoService
+	h.JWTService = jwtService
+
+	authenticate := func(password, newPassword string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(authenticatePayload{Username: "admin", Password: password, NewPassword: newPassword})
+
+		req := httptest.NewRequest(http.MethodPost, "/auth", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("login is rejected without a new password", func(t *testing.T) {
+		rr := authenticate("current-password", "")
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("the new password must differ from the current one", func(t *testing.T) {
+		rr := authenticate("current-password", "current-password")
+		is.Equal(http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("a weak new password is rejected", func(t *testing.T) {
+		rr := authenticate("current-password", "short")
+		is.Equal(http.StatusBadRequest, rr.Code)
+
+		user, err := store.User().Read(1)
+		is.NoError(err)
+		is.True(user.ForcePasswordChange)
+	})
+
+	t.Run("the password is changed on login", func(t *testing.T) {
+		rr := authenticate("current-password", "the-new-password")
+		is.Equal(http.StatusOK, rr.Code)
+
+		user, err := store.User().Read(1)
+		is.NoError(err)
+		is.False(user.ForcePasswordChange)
+		is.NoError(cryptoService.CompareHashAndData(user.Password, "the-new-password"))
+
+		rr = authenticate("the-new-password", "")
+		is.Equal(http.StatusOK, rr.Code)
+	})
+}
+
+func Test_authenticate_PendingUser(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	cryptoService := &crypto.Service{}
+	hash, err := cryptoService.Hash("a-strong-password")
+	is.NoError(err)
+
+	user := &portainer.User{ID: 2, Username: "bob", Password: hash, Role: portainer.StandardUserRole, Pending: true}
+	is.NoError(store.User().Create(user))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, passwordChecker)
+	h.DataStore = store
+	h.CryptoService = cryptoService
+	h.JWTService = jwtService
+
+	authenticate := func(password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(authenticatePayload{Username: "bob", Password: password})
+
+		req := httptest.NewRequest(http.MethodPost, "/auth", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	is.Equal(http.StatusForbidden, authenticate("a-strong-password").Code)
+	is.Equal(http.StatusUnprocessableEntity, authenticate("a-wrong-password").Code, "a pending account must not be revealed without its password")
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/portainer/portainer/api/http/proxy"
 	"github.com/portainer/portainer/api/http/proxy/factory/kubernetes"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/intrusion"
+	"github.com/portainer/portainer/api/lifecycle"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -23,6 +25,8 @@ type Handler struct {
 	OAuthService                portainer.OAuthService
 	ProxyManager                *proxy.Manager
 	KubernetesTokenCacheManager *kubernetes.TokenCacheManager
+	EventDispatcher             *lifecycle.Dispatcher
+	IntrusionDetector           *intrusion.Detector
 	passwordStrengthChecker     security.PasswordStrengthChecker
 }
 
@@ -0,0 +1,97 @@
+package automationwebhooks
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type automationWebhookCreatePayload struct {
+	// Name of the automation webhook
+	Name string `validate:"required" example:"deploy-monitoring"`
+	// Operation triggered by the webhook
+	Action portainer.AutomationWebhookAction `validate:"required" example:"deploy_template" enums:"deploy_template,run_volume_backup,check_image_update"`
+	// Environment where the custom template is deployed, for the deploy_template action
+	EndpointID portainer.EndpointID `example:"1"`
+	// Custom template deployed, for the deploy_template action
+	CustomTemplateID portainer.CustomTemplateID `example:"1"`
+	// Name of the deployed stack, the stack is redeployed when it exists, for the deploy_template action
+	StackName string `example:"monitoring"`
+	// Values of the variables of the custom template, for the deploy_template action
+	Variables map[string]string
+	// Variables of the custom template whose values can be provided by the invocations, for the deploy_template action
+	AllowedVariables []string `example:"version"`
+	// Volume backup job run, for the run_volume_backup action
+	VolumeBackupJobID portainer.VolumeBackupJobID `example:"1"`
+	// Image update policy checked, for the check_image_update action
+	ImageUpdatePolicyID portainer.ImageUpdatePolicyID `example:"1"`
+	// Whether the webhook can be invoked
+	Enabled bool `example:"true"`
+}
+
+func (payload *automationWebhookCreatePayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id AutomationWebhookCreate
+// @summary Create an automation webhook
+// @description Create an incoming webhook triggering an operation when a POST request is sent to /api/automation_webhooks/invoke/{token},
+// @description the token being generated by Portainer. The operations are run on behalf of the administrator creating the webhook.
+// @description **Access policy**: administrator
+// @tags automation_webhooks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body automationWebhookCreatePayload true "Automation webhook details"
+// @success 200 {object} portainer.AutomationWebhook "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /automation_webhooks [post]
+func (handler *Handler) automationWebhookCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload automationWebhookCreatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate the token of the automation webhook", err)
+	}
+
+	webhook := &portainer.AutomationWebhook{
+		Name:                payload.Name,
+		Token:               token,
+		Action:              payload.Action,
+		EndpointID:          payload.EndpointID,
+		CustomTemplateID:    payload.CustomTemplateID,
+		StackName:           payload.StackName,
+		Variables:           payload.Variables,
+		AllowedVariables:    payload.AllowedVariables,
+		VolumeBackupJobID:   payload.VolumeBackupJobID,
+		ImageUpdatePolicyID: payload.ImageUpdatePolicyID,
+		Enabled:             payload.Enabled,
+		CreatedByUserID:     tokenData.ID,
+	}
+
+	if err := handler.validateWebhook(webhook); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	err = handler.DataStore.AutomationWebhook().Create(webhook)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the automation webhook inside the database", err)
+	}
+
+	return response.JSON(w, webhook)
+}
@@ -0,0 +1,34 @@
+package automationwebhooks
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id AutomationWebhookDelete
+// @summary Remove an automation webhook
+// @description **Access policy**: administrator
+// @tags automation_webhooks
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Automation webhook identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Automation webhook not found"
+// @failure 500 "Server error"
+// @router /automation_webhooks/{id} [delete]
+func (handler *Handler) automationWebhookDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	webhook, httpErr := handler.webhookFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err := handler.DataStore.AutomationWebhook().Delete(webhook.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the automation webhook from the database", err)
+	}
+
+	return response.Empty(w)
+}
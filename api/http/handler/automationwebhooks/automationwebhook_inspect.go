@@ -0,0 +1,30 @@
+package automationwebhooks
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id AutomationWebhookInspect
+// @summary Inspect an automation webhook
+// @description **Access policy**: administrator
+// @tags automation_webhooks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Automation webhook identifier"
+// @success 200 {object} portainer.AutomationWebhook "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Automation webhook not found"
+// @failure 500 "Server error"
+// @router /automation_webhooks/{id} [get]
+func (handler *Handler) automationWebhookInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	webhook, httpErr := handler.webhookFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, webhook)
+}
@@ -0,0 +1,138 @@
+package automationwebhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/volumebackups"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+type automationWebhookInvokePayload struct {
+	// Values of the allowed variables of the custom template, overriding the values of the webhook
+	Variables map[string]string
+}
+
+// @id AutomationWebhookInvoke
+// @summary Invoke an automation webhook
+// @description Trigger the operation of an automation webhook, on behalf of the administrator who created it.
+// @description The body is optional, it can provide the values of the allowed variables of the deployed custom template.
+// @description The values must not contain line breaks or control characters.
+// @description The deployed stack, the volume backup or the image update is returned, no content is returned when the images are up to date.
+// @description **Access policy**: public
+// @tags automation_webhooks
+// @accept json
+// @produce json
+// @param token path string true "Automation webhook token"
+// @param body body automationWebhookInvokePayload false "Invocation parameters"
+// @success 200 "Success"
+// @success 204 "Up to date"
+// @failure 400 "Invalid request"
+// @failure 403 "The webhook is disabled or its author is no longer an administrator"
+// @failure 404 "Automation webhook not found"
+// @failure 409 "The operation is already in progress or conflicts with an existing stack"
+// @failure 500 "Server error"
+// @router /automation_webhooks/invoke/{token} [post]
+func (handler *Handler) automationWebhookInvoke(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	token, err := request.RetrieveRouteVariableValue(r, "token")
+	if err != nil {
+		return httperror.BadRequest("Invalid automation webhook token route variable", err)
+	}
+
+	webhook, err := handler.DataStore.AutomationWebhook().WebhookByToken(token)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an automation webhook with the specified token", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an automation webhook with the specified token", err)
+	}
+
+	if !webhook.Enabled {
+		return httperror.Forbidden("The automation webhook is disabled", errors.New("automation webhook disabled"))
+	}
+
+	user, err := handler.DataStore.User().Read(webhook.CreatedByUserID)
+	if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.InternalServerError("Unable to retrieve the author of the automation webhook", err)
+	} else if err != nil || user.Role != portainer.AdministratorRole {
+		return httperror.Forbidden("The author of the automation webhook is no longer an administrator", errors.New("automation webhook author is not an administrator"))
+	}
+
+	var payload automationWebhookInvokePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil && !errors.Is(err, io.EOF) {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	variables := maps.Clone(webhook.Variables)
+	if variables == nil {
+		variables = map[string]string{}
+	}
+
+	for name, value := range payload.Variables {
+		if !slices.Contains(webhook.AllowedVariables, name) {
+			return httperror.BadRequest("Invalid request payload", errors.New("the variable "+name+" cannot be provided by the invocations"))
+		}
+
+		if !isSafeVariableValue(value) {
+			return httperror.BadRequest("Invalid request payload", errors.New("the value of the variable "+name+" must not contain line breaks or control characters"))
+		}
+
+		variables[name] = value
+	}
+
+	webhook.LastInvocation = time.Now().Unix()
+	if err := handler.DataStore.AutomationWebhook().Update(webhook.ID, webhook); err != nil {
+		log.Warn().Err(err).Int("webhook_id", int(webhook.ID)).Msg("unable to record the invocation of the automation webhook")
+	}
+
+	switch webhook.Action {
+	case portainer.AutomationDeployTemplate:
+		stack, httpErr := handler.deployTemplate(r.Context(), webhook, user, variables)
+		if httpErr != nil {
+			return httpErr
+		}
+
+		return response.JSON(w, stack)
+
+	case portainer.AutomationRunVolumeBackup:
+		record, err := handler.VolumeBackupService.Backup(r.Context(), webhook.VolumeBackupJobID)
+		if errors.Is(err, volumebackups.ErrBackupInProgress) {
+			return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "A backup of the volume is already in progress", Err: err}
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to back up the volume", err)
+		}
+
+		return response.JSON(w, record)
+
+	case portainer.AutomationCheckImageUpdate:
+		record, err := handler.ImageUpdateService.Check(r.Context(), webhook.ImageUpdatePolicyID)
+		if err != nil {
+			return httperror.InternalServerError("Unable to check the images of the resource", err)
+		}
+
+		if record == nil {
+			return response.Empty(w)
+		}
+
+		return response.JSON(w, record)
+	}
+
+	return httperror.InternalServerError("Invalid automation webhook action", errors.New("unsupported action "+string(webhook.Action)))
+}
+
+// isSafeVariableValue returns true when a value provided by an invocation cannot change the structure of the rendered
+// template, the values being pasted as they are in the YAML file deployed on behalf of the administrator
+func isSafeVariableValue(value string) bool {
+	return !strings.ContainsFunc(value, unicode.IsControl)
+}
@@ -0,0 +1,157 @@
+package automationwebhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_automationWebhookCreate(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	admin := &portainer.User{Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(admin))
+
+	policy := &portainer.ImageUpdatePolicy{EndpointID: 1, ResourceType: portainer.ImageUpdateContainer, ResourceID: "web", Interval: "1h"}
+	is.NoError(store.ImageUpdatePolicy().Create(policy))
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer())
+	h.DataStore = store
+
+	create := func(payload map[string]any) *httptest.ResponseRecorder {
+		body, err := json.Marshal(payload)
+		is.NoError(err)
+
+		r := httptest.NewRequest(http.MethodPost, "/automation_webhooks", bytes.NewReader(body))
+		r = r.WithContext(security.StoreTokenData(r, &portainer.TokenData{ID: admin.ID, Role: portainer.AdministratorRole}))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		return w
+	}
+
+	t.Run("the action must be supported", func(t *testing.T) {
+		w := create(map[string]any{"Name": "hook", "Action": "restart_everything"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("the targeted resource must exist", func(t *testing.T) {
+		w := create(map[string]any{"Name": "hook", "Action": "check_image_update", "ImageUpdatePolicyId": 42})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("a token is generated and the unused parameters are cleared", func(t *testing.T) {
+		w := create(map[string]any{
+			"Name":                "hook",
+			"Action":              "check_image_update",
+			"ImageUpdatePolicyId": policy.ID,
+			"StackName":           "unused",
+			"Enabled":             true,
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var webhook portainer.AutomationWebhook
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&webhook))
+		assert.NotEmpty(t, webhook.Token)
+		assert.Empty(t, webhook.StackName)
+		assert.Equal(t, admin.ID, webhook.CreatedByUserID)
+		assert.Equal(t, policy.ID, webhook.ImageUpdatePolicyID)
+	})
+}
+
+func TestHandler_automationWebhookInvoke(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	admin := &portainer.User{Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(admin))
+
+	user := &portainer.User{Username: "user", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	webhooks := map[string]*portainer.AutomationWebhook{
+		"disabled": {
+			Name:            "disabled",
+			Token:           "disabled-token",
+			Action:          portainer.AutomationDeployTemplate,
+			CreatedByUserID: admin.ID,
+		},
+		"demoted": {
+			Name:            "demoted",
+			Token:           "demoted-token",
+			Action:          portainer.AutomationDeployTemplate,
+			Enabled:         true,
+			CreatedByUserID: user.ID,
+		},
+		"deploy": {
+			Name:             "deploy",
+			Token:            "deploy-token",
+			Action:           portainer.AutomationDeployTemplate,
+			Variables:        map[string]string{"version": "1.0"},
+			AllowedVariables: []string{"version"},
+			Enabled:          true,
+			CreatedByUserID:  admin.ID,
+		},
+	}
+
+	for _, webhook := range webhooks {
+		is.NoError(store.AutomationWebhook().Create(webhook))
+	}
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer())
+	h.DataStore = store
+
+	invoke := func(token, body string) int {
+		r := httptest.NewRequest(http.MethodPost, "/automation_webhooks/invoke/"+token, strings.NewReader(body))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	t.Run("an unknown token is not found", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, invoke("unknown-token", ""))
+	})
+
+	t.Run("a disabled webhook cannot be invoked", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, invoke("disabled-token", ""))
+	})
+
+	t.Run("a webhook whose author is no longer an administrator cannot be invoked", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, invoke("demoted-token", ""))
+	})
+
+	t.Run("only the allowed variables can be provided", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, invoke("deploy-token", `{"Variables":{"image":"evil"}}`))
+	})
+
+	t.Run("the values cannot inject lines in the template", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, invoke("deploy-token", `{"Variables":{"version":"2.0\n    privileged: true"}}`))
+		assert.Equal(t, http.StatusBadRequest, invoke("deploy-token", `{"Variables":{"version":"2.0\r    privileged: true"}}`))
+	})
+
+	t.Run("the invocation is recorded", func(t *testing.T) {
+		// the environment of the webhook does not exist
+		assert.Equal(t, http.StatusNotFound, invoke("deploy-token", `{"Variables":{"version":"2.0"}}`))
+
+		webhook, err := store.AutomationWebhook().Read(webhooks["deploy"].ID)
+		assert.NoError(t, err)
+		assert.NotZero(t, webhook.LastInvocation)
+		assert.Equal(t, "1.0", webhook.Variables["version"], "the invocation values should not be saved")
+	})
+}
@@ -0,0 +1,27 @@
+package automationwebhooks
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id AutomationWebhookList
+// @summary List the automation webhooks
+// @description **Access policy**: administrator
+// @tags automation_webhooks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.AutomationWebhook "Success"
+// @failure 500 "Server error"
+// @router /automation_webhooks [get]
+func (handler *Handler) automationWebhookList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	webhooks, err := handler.DataStore.AutomationWebhook().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the automation webhooks from the database", err)
+	}
+
+	return response.JSON(w, webhooks)
+}
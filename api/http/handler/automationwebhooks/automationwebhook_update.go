@@ -0,0 +1,125 @@
+package automationwebhooks
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type automationWebhookUpdatePayload struct {
+	// Name of the automation webhook
+	Name *string `example:"deploy-monitoring"`
+	// Operation triggered by the webhook
+	Action *portainer.AutomationWebhookAction `example:"deploy_template" enums:"deploy_template,run_volume_backup,check_image_update"`
+	// Environment where the custom template is deployed, for the deploy_template action
+	EndpointID *portainer.EndpointID `example:"1"`
+	// Custom template deployed, for the deploy_template action
+	CustomTemplateID *portainer.CustomTemplateID `example:"1"`
+	// Name of the deployed stack, the stack is redeployed when it exists, for the deploy_template action
+	StackName *string `example:"monitoring"`
+	// Values of the variables of the custom template, for the deploy_template action
+	Variables map[string]string
+	// Variables of the custom template whose values can be provided by the invocations, for the deploy_template action
+	AllowedVariables []string `example:"version"`
+	// Volume backup job run, for the run_volume_backup action
+	VolumeBackupJobID *portainer.VolumeBackupJobID `example:"1"`
+	// Image update policy checked, for the check_image_update action
+	ImageUpdatePolicyID *portainer.ImageUpdatePolicyID `example:"1"`
+	// Whether the webhook can be invoked
+	Enabled *bool `example:"true"`
+	// Replace the token of the webhook, invalidating its current URL
+	RegenerateToken bool `example:"false"`
+}
+
+func (payload *automationWebhookUpdatePayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id AutomationWebhookUpdate
+// @summary Update an automation webhook
+// @description **Access policy**: administrator
+// @tags automation_webhooks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Automation webhook identifier"
+// @param body body automationWebhookUpdatePayload true "Automation webhook details"
+// @success 200 {object} portainer.AutomationWebhook "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Automation webhook not found"
+// @failure 500 "Server error"
+// @router /automation_webhooks/{id} [put]
+func (handler *Handler) automationWebhookUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	webhook, httpErr := handler.webhookFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var payload automationWebhookUpdatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	if payload.Name != nil {
+		webhook.Name = *payload.Name
+	}
+
+	if payload.Action != nil {
+		webhook.Action = *payload.Action
+	}
+
+	if payload.EndpointID != nil {
+		webhook.EndpointID = *payload.EndpointID
+	}
+
+	if payload.CustomTemplateID != nil {
+		webhook.CustomTemplateID = *payload.CustomTemplateID
+	}
+
+	if payload.StackName != nil {
+		webhook.StackName = *payload.StackName
+	}
+
+	if payload.Variables != nil {
+		webhook.Variables = payload.Variables
+	}
+
+	if payload.AllowedVariables != nil {
+		webhook.AllowedVariables = payload.AllowedVariables
+	}
+
+	if payload.VolumeBackupJobID != nil {
+		webhook.VolumeBackupJobID = *payload.VolumeBackupJobID
+	}
+
+	if payload.ImageUpdatePolicyID != nil {
+		webhook.ImageUpdatePolicyID = *payload.ImageUpdatePolicyID
+	}
+
+	if payload.Enabled != nil {
+		webhook.Enabled = *payload.Enabled
+	}
+
+	if payload.RegenerateToken {
+		webhook.Token, err = generateToken()
+		if err != nil {
+			return httperror.InternalServerError("Unable to generate the token of the automation webhook", err)
+		}
+	}
+
+	if err := handler.validateWebhook(webhook); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	err = handler.DataStore.AutomationWebhook().Update(webhook.ID, webhook)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the automation webhook changes inside the database", err)
+	}
+
+	return response.JSON(w, webhook)
+}
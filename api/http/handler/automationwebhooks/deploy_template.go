@@ -0,0 +1,177 @@
+package automationwebhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/internal/customtemplateutils"
+	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/stackbuilders"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/rs/zerolog/log"
+)
+
+// deployTemplate deploys the custom template of the webhook as a stack of its environment, the stack being
+// redeployed with the new content of the template when it already exists
+func (handler *Handler) deployTemplate(ctx context.Context, webhook *portainer.AutomationWebhook, user *portainer.User, variables map[string]string) (*portainer.Stack, *httperror.HandlerError) {
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(webhook.EndpointID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find the environment of the automation webhook", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find the environment of the automation webhook", err)
+	}
+
+	customTemplate, err := handler.DataStore.CustomTemplate().Read(webhook.CustomTemplateID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find the custom template of the automation webhook", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find the custom template of the automation webhook", err)
+	}
+
+	if customTemplate.Type != portainer.DockerComposeStack && customTemplate.Type != portainer.DockerSwarmStack {
+		return nil, httperror.BadRequest("Invalid custom template", errors.New("only the Docker templates can be deployed by an automation webhook"))
+	}
+
+	entryPath := customTemplate.EntryPoint
+	if customTemplate.GitConfig != nil {
+		entryPath = customTemplate.GitConfig.ConfigFilePath
+	}
+
+	fileContent, err := handler.FileService.GetFileContent(customTemplate.ProjectPath, entryPath)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve custom template file from disk", err)
+	}
+
+	content, err := customtemplateutils.Render(string(fileContent), customTemplate.Variables, variables)
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid custom template variables", err)
+	}
+
+	stackName := webhook.StackName
+	if customTemplate.Type == portainer.DockerComposeStack {
+		stackName = handler.ComposeStackManager.NormalizeStackName(stackName)
+	}
+
+	securityContext := &security.RestrictedRequestContext{IsAdmin: true, UserID: user.ID}
+
+	stacks, err := handler.DataStore.Stack().StacksByName(stackName)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve the stacks from the database", err)
+	}
+
+	for i := range stacks {
+		if stacks[i].EndpointID == endpoint.ID {
+			return handler.redeployStack(securityContext, &stacks[i], endpoint, user, customTemplate.Type, content)
+		}
+	}
+
+	payload := stackbuilders.StackPayload{
+		Name:             stackName,
+		StackFileContent: content,
+	}
+
+	var builder stackbuilders.FileContentMethodStackBuildProcess
+	if customTemplate.Type == portainer.DockerSwarmStack {
+		payload.SwarmID, err = handler.swarmID(ctx, endpoint)
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to retrieve the swarm cluster of the environment", err)
+		}
+
+		builder = stackbuilders.CreateSwarmStackFileContentBuilder(securityContext, handler.DataStore, handler.FileService, handler.StackDeployer)
+	} else {
+		builder = stackbuilders.CreateComposeStackFileContentBuilder(securityContext, handler.DataStore, handler.FileService, handler.StackDeployer)
+	}
+
+	stack, httpErr := stackbuilders.NewStackBuilderDirector(builder).Build(&payload, endpoint)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	resourceControl := authorization.NewAdministratorsOnlyResourceControl(stackutils.ResourceControlID(stack.EndpointID, stack.Name), portainer.StackResourceControl)
+	if err := handler.DataStore.ResourceControl().Create(resourceControl); err != nil {
+		return nil, httperror.InternalServerError("Unable to persist resource control inside the database", err)
+	}
+
+	stack.ResourceControl = resourceControl
+
+	return stack, nil
+}
+
+// redeployStack replaces the file of an existing stack with the rendered template and deploys it again
+func (handler *Handler) redeployStack(securityContext *security.RestrictedRequestContext, stack *portainer.Stack, endpoint *portainer.Endpoint, user *portainer.User, stackType portainer.StackType, content string) (*portainer.Stack, *httperror.HandlerError) {
+	if stack.Type != stackType {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "A stack of another type with the same name already exists in the environment", Err: errors.New("stack type mismatch")}
+	}
+
+	if stack.GitConfig != nil {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "A stack deployed from a Git repository with the same name already exists in the environment", Err: errors.New("git stack")}
+	}
+
+	stackFolder := strconv.Itoa(int(stack.ID))
+	if _, err := handler.FileService.UpdateStoreStackFileFromBytes(stackFolder, stack.EntryPoint, []byte(content)); err != nil {
+		if rollbackErr := handler.FileService.RollbackStackFile(stackFolder, stack.EntryPoint); rollbackErr != nil {
+			log.Warn().Err(rollbackErr).Msg("rollback stack file error")
+		}
+
+		return nil, httperror.InternalServerError("Unable to persist updated stack file on disk", err)
+	}
+
+	var deploymentConfig deployments.StackDeploymentConfiger
+	var err error
+	if stack.Type == portainer.DockerSwarmStack {
+		deploymentConfig, err = deployments.CreateSwarmStackDeploymentConfig(securityContext, stack, endpoint, handler.DataStore, handler.FileService, handler.StackDeployer, false, true)
+	} else {
+		deploymentConfig, err = deployments.CreateComposeStackDeploymentConfig(securityContext, stack, endpoint, handler.DataStore, handler.FileService, handler.StackDeployer, true, false)
+	}
+
+	if err == nil {
+		err = deploymentConfig.Deploy()
+	}
+
+	if err != nil {
+		if rollbackErr := handler.FileService.RollbackStackFile(stackFolder, stack.EntryPoint); rollbackErr != nil {
+			log.Warn().Err(rollbackErr).Msg("rollback stack file error")
+		}
+
+		return nil, stackutils.DeploymentError(err)
+	}
+
+	handler.FileService.RemoveStackFileBackup(stackFolder, stack.EntryPoint)
+
+	stack.UpdatedBy = user.Username
+	stack.UpdateDate = time.Now().Unix()
+	stack.Status = portainer.StackStatusActive
+
+	if err := handler.DataStore.Stack().Update(stack.ID, stack); err != nil {
+		return nil, httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
+	}
+
+	return stack, nil
+}
+
+func (handler *Handler) swarmID(ctx context.Context, endpoint *portainer.Endpoint) (string, error) {
+	cli, err := handler.DockerClientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return "", err
+	}
+	defer cli.Close()
+
+	info, err := cli.Info(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if info.Swarm.Cluster == nil {
+		return "", errors.New("the environment is not a swarm cluster")
+	}
+
+	return info.Swarm.Cluster.ID, nil
+}
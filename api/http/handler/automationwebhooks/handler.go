@@ -0,0 +1,153 @@
+package automationwebhooks
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/imageupdates"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/volumebackups"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gofrs/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to handle automation webhook operations.
+type Handler struct {
+	*mux.Router
+	DataStore           dataservices.DataStore
+	FileService         portainer.FileService
+	StackDeployer       deployments.StackDeployer
+	ComposeStackManager portainer.ComposeStackManager
+	DockerClientFactory *dockerclient.ClientFactory
+	VolumeBackupService *volumebackups.Service
+	ImageUpdateService  *imageupdates.Service
+}
+
+// NewHandler creates a handler to manage automation webhook operations.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+
+	adminRouter := h.NewRoute().Subrouter()
+	adminRouter.Use(bouncer.AdminAccess)
+
+	adminRouter.Handle("/automation_webhooks", httperror.LoggerHandler(h.automationWebhookCreate)).Methods(http.MethodPost)
+	adminRouter.Handle("/automation_webhooks", httperror.LoggerHandler(h.automationWebhookList)).Methods(http.MethodGet)
+	adminRouter.Handle("/automation_webhooks/{id}", httperror.LoggerHandler(h.automationWebhookInspect)).Methods(http.MethodGet)
+	adminRouter.Handle("/automation_webhooks/{id}", httperror.LoggerHandler(h.automationWebhookUpdate)).Methods(http.MethodPut)
+	adminRouter.Handle("/automation_webhooks/{id}", httperror.LoggerHandler(h.automationWebhookDelete)).Methods(http.MethodDelete)
+
+	h.Handle("/automation_webhooks/invoke/{token}",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.automationWebhookInvoke))).Methods(http.MethodPost)
+
+	return h
+}
+
+func (handler *Handler) webhookFromRequest(r *http.Request) (*portainer.AutomationWebhook, *httperror.HandlerError) {
+	webhookID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid automation webhook identifier route variable", err)
+	}
+
+	webhook, err := handler.DataStore.AutomationWebhook().Read(portainer.AutomationWebhookID(webhookID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find an automation webhook with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find an automation webhook with the specified identifier inside the database", err)
+	}
+
+	return webhook, nil
+}
+
+func generateToken() (string, error) {
+	token, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+
+	return token.String(), nil
+}
+
+// validateWebhook checks that the resources targeted by the action of the webhook exist and clears the parameters
+// which are not used by the action
+func (handler *Handler) validateWebhook(webhook *portainer.AutomationWebhook) error {
+	if webhook.Name == "" {
+		return errors.New("invalid automation webhook name")
+	}
+
+	if webhook.Action != portainer.AutomationDeployTemplate {
+		webhook.EndpointID = 0
+		webhook.CustomTemplateID = 0
+		webhook.StackName = ""
+		webhook.Variables = nil
+		webhook.AllowedVariables = nil
+	}
+
+	if webhook.Action != portainer.AutomationRunVolumeBackup {
+		webhook.VolumeBackupJobID = 0
+	}
+
+	if webhook.Action != portainer.AutomationCheckImageUpdate {
+		webhook.ImageUpdatePolicyID = 0
+	}
+
+	switch webhook.Action {
+	case portainer.AutomationDeployTemplate:
+		return handler.validateDeployTemplate(webhook)
+
+	case portainer.AutomationRunVolumeBackup:
+		if _, err := handler.DataStore.VolumeBackupJob().Read(webhook.VolumeBackupJobID); err != nil {
+			return errors.New("invalid volume backup job")
+		}
+
+	case portainer.AutomationCheckImageUpdate:
+		if _, err := handler.DataStore.ImageUpdatePolicy().Read(webhook.ImageUpdatePolicyID); err != nil {
+			return errors.New("invalid image update policy")
+		}
+
+	default:
+		return errors.New("invalid action, deploy_template, run_volume_backup or check_image_update is expected")
+	}
+
+	return nil
+}
+
+func (handler *Handler) validateDeployTemplate(webhook *portainer.AutomationWebhook) error {
+	if webhook.StackName == "" {
+		return errors.New("invalid stack name")
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(webhook.EndpointID)
+	if err != nil || !endpointutils.IsDockerEndpoint(endpoint) {
+		return errors.New("invalid environment, a Docker environment is expected")
+	}
+
+	customTemplate, err := handler.DataStore.CustomTemplate().Read(webhook.CustomTemplateID)
+	if err != nil {
+		return errors.New("invalid custom template")
+	}
+
+	if customTemplate.Type == portainer.KubernetesStack {
+		return errors.New("invalid custom template, a Kubernetes template cannot be deployed as a Docker stack")
+	}
+
+	for _, name := range webhook.AllowedVariables {
+		if !slices.ContainsFunc(customTemplate.Variables, func(variable portainer.CustomTemplateVariableDefinition) bool {
+			return variable.Name == name
+		}) {
+			return errors.New("invalid allowed variable, the custom template has no variable " + name)
+		}
+	}
+
+	return nil
+}
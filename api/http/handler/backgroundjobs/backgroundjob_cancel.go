@@ -0,0 +1,42 @@
+package backgroundjobs
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/portainer/portainer/api/jobs"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id BackgroundJobCancel
+// @summary Cancel a background job
+// @description Cancel a queued or running job, a running job being interrupted.
+// @description **Access policy**: authenticated, the users other than the administrators only accessing their jobs
+// @tags background_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Background job identifier"
+// @success 200 {object} portainer.BackgroundJob "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Background job not found"
+// @failure 409 "The job is already finished"
+// @failure 500 "Server error"
+// @router /background_jobs/{id}/cancel [post]
+func (handler *Handler) backgroundJobCancel(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	job, httpErr := handler.jobFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	job, err := handler.JobQueue.Cancel(job.ID)
+	if errors.Is(err, jobs.ErrJobFinished) {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "The background job is already finished", Err: err}
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to cancel the background job", err)
+	}
+
+	return response.JSON(w, job)
+}
@@ -0,0 +1,141 @@
+package backgroundjobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/portainer/portainer/api/jobs"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+)
+
+const (
+	// eventsKeepAliveInterval is the interval between the comments sent to keep the stream open through the proxies
+	eventsKeepAliveInterval = 30 * time.Second
+	// eventsPollInterval is the interval between two reads of the status of the job, which catch the changes of the
+	// jobs run by another replica
+	eventsPollInterval = 5 * time.Second
+)
+
+// @id BackgroundJobEvents
+// @summary Stream the progress of a background job
+// @description Open a server-sent events stream reporting the status changes and the progress of a job, until the job
+// @description is finished. The current status of the job is sent first, followed by its last progress when it is running.
+// @description The progress events carry the stage of the job, such as pull, archive, upload or deploy, a message and
+// @description the units processed so far along with their total when known.
+// @description **Access policy**: authenticated, the users other than the administrators only accessing their jobs
+// @tags background_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @produce text/event-stream
+// @param id path int true "Background job identifier"
+// @success 200 {object} jobs.Event "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Background job not found"
+// @failure 500 "Server error"
+// @router /background_jobs/{id}/events [get]
+func (handler *Handler) backgroundJobEvents(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	job, httpErr := handler.jobFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	events, unsubscribe := handler.JobQueue.Subscribe(job.ID)
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	status := jobs.StatusEvent(job)
+	if err := writeEvent(w, status); err != nil {
+		return nil
+	}
+
+	if err := rc.Flush(); err != nil {
+		return httperror.InternalServerError("Streaming is not supported", err)
+	}
+
+	if jobs.IsFinished(job.Status) {
+		return nil
+	}
+
+	shutdownCtx := handler.ShutdownCtx
+	if shutdownCtx == nil {
+		shutdownCtx = context.Background()
+	}
+
+	keepAlive := time.NewTicker(eventsKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	poll := time.NewTicker(eventsPollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+
+		case <-shutdownCtx.Done():
+			return nil
+
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+
+		case <-poll.C:
+			latest, err := handler.DataStore.BackgroundJob().Read(job.ID)
+			if err != nil {
+				return nil
+			}
+
+			if latest.Status != status.Status || latest.Attempts != status.Attempt {
+				status = jobs.StatusEvent(latest)
+				if err := writeEvent(w, status); err != nil {
+					return nil
+				}
+			}
+
+			if jobs.IsFinished(latest.Status) {
+				rc.Flush()
+				return nil
+			}
+
+		case event, ok := <-events:
+			if !ok {
+				// the job is finished
+				return nil
+			}
+
+			if event.Type == jobs.EventStatus {
+				status = event
+			}
+
+			if err := writeEvent(w, event); err != nil {
+				return nil
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return nil
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event jobs.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+
+	return err
+}
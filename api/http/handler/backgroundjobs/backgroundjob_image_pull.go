@@ -0,0 +1,96 @@
+package backgroundjobs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/images"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type imagePullPayload struct {
+	// Image to pull, such as nginx:1.27 or registry.example.com/app/api:1.4.2
+	Image string `example:"nginx:1.27" validate:"required"`
+	// Environments pulling the image
+	EndpointIDs []portainer.EndpointID `example:"1,2" validate:"required"`
+	// Node of the Swarm cluster pulling the image, the node of the environment when empty
+	NodeName string `example:"node-1"`
+}
+
+func (payload *imagePullPayload) Validate(r *http.Request) error {
+	if payload.Image == "" {
+		return errors.New("invalid image, the image is required")
+	}
+
+	if _, err := images.ParseImage(images.ParseImageOptions{Name: payload.Image}); err != nil {
+		return fmt.Errorf("invalid image %q", payload.Image)
+	}
+
+	if len(payload.EndpointIDs) == 0 {
+		return errors.New("invalid environments, at least one environment is required")
+	}
+
+	return nil
+}
+
+// @id BackgroundJobImagePull
+// @summary Pre-pull an image on environments
+// @description Queue a background job per environment pulling the image ahead of its deployment, with the
+// @description credentials of the matching registry. The queued jobs are returned.
+// @description **Access policy**: administrator
+// @tags background_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body imagePullPayload true "Image to pull"
+// @success 200 {array} portainer.BackgroundJob "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment not found"
+// @failure 500 "Server error"
+// @router /background_jobs/image_pulls [post]
+func (handler *Handler) backgroundJobImagePull(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload imagePullPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	for _, endpointID := range payload.EndpointIDs {
+		endpoint, err := handler.DataStore.Endpoint().Endpoint(endpointID)
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+		}
+
+		if !endpointutils.IsDockerEndpoint(endpoint) || endpointutils.IsEdgeEndpoint(endpoint) {
+			return httperror.BadRequest("The images can only be pulled on the Docker environments reachable by Portainer", fmt.Errorf("unsupported environment %d", endpointID))
+		}
+	}
+
+	queued := []*portainer.BackgroundJob{}
+
+	for _, endpointID := range payload.EndpointIDs {
+		jobPayload := images.PullJobPayload{EndpointID: endpointID, NodeName: payload.NodeName, Image: payload.Image}
+
+		job, err := handler.JobQueue.EnqueueOnce(images.PullJobType, jobPayload, tokenData.ID, fmt.Sprintf("Pull of the image %s on the environment %d", payload.Image, endpointID))
+		if err != nil {
+			return httperror.InternalServerError("Unable to queue the image pull", err)
+		}
+
+		queued = append(queued, job)
+	}
+
+	return response.JSON(w, queued)
+}
@@ -0,0 +1,31 @@
+package backgroundjobs
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id BackgroundJobInspect
+// @summary Inspect a background job
+// @description **Access policy**: authenticated, the users other than the administrators only accessing their jobs
+// @tags background_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Background job identifier"
+// @success 200 {object} portainer.BackgroundJob "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Background job not found"
+// @failure 500 "Server error"
+// @router /background_jobs/{id} [get]
+func (handler *Handler) backgroundJobInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	job, httpErr := handler.jobFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, job)
+}
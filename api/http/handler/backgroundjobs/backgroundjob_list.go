@@ -0,0 +1,56 @@
+package backgroundjobs
+
+import (
+	"net/http"
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id BackgroundJobList
+// @summary List the background jobs
+// @description List the jobs of the background job queue, the most recent first. The users other than the
+// @description administrators only see the jobs they requested.
+// @description **Access policy**: authenticated
+// @tags background_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param type query string false "Only list the jobs of this type, such as endpoint.snapshot"
+// @param status query string false "Only list the jobs with this status" Enums(queued, running, succeeded, failed, canceled)
+// @success 200 {array} portainer.BackgroundJob "Success"
+// @failure 500 "Server error"
+// @router /background_jobs [get]
+func (handler *Handler) backgroundJobList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	jobType, _ := request.RetrieveQueryParameter(r, "type", true)
+	status, _ := request.RetrieveQueryParameter(r, "status", true)
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	allJobs, err := handler.DataStore.BackgroundJob().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the background jobs from the database", err)
+	}
+
+	jobs := []portainer.BackgroundJob{}
+	for _, job := range allJobs {
+		if (securityContext.IsAdmin || job.CreatedBy == securityContext.UserID) &&
+			(jobType == "" || job.Type == jobType) &&
+			(status == "" || job.Status == portainer.BackgroundJobStatus(status)) {
+			jobs = append(jobs, job)
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID > jobs[j].ID
+	})
+
+	return response.JSON(w, jobs)
+}
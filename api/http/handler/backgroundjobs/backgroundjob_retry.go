@@ -0,0 +1,42 @@
+package backgroundjobs
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/portainer/portainer/api/jobs"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id BackgroundJobRetry
+// @summary Retry a background job
+// @description Queue again a failed or canceled job, its attempts being reset.
+// @description **Access policy**: authenticated, the users other than the administrators only accessing their jobs
+// @tags background_jobs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Background job identifier"
+// @success 200 {object} portainer.BackgroundJob "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Background job not found"
+// @failure 409 "The job did not fail and was not canceled"
+// @failure 500 "Server error"
+// @router /background_jobs/{id}/retry [post]
+func (handler *Handler) backgroundJobRetry(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	job, httpErr := handler.jobFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	job, err := handler.JobQueue.Retry(job.ID)
+	if errors.Is(err, jobs.ErrJobNotRetryable) {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "Only the failed and canceled background jobs can be retried", Err: err}
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to retry the background job", err)
+	}
+
+	return response.JSON(w, job)
+}
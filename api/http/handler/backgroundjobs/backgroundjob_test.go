@@ -0,0 +1,93 @@
+package backgroundjobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/portainer/portainer/api/jobs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackgroundJobs(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	admin := &security.RestrictedRequestContext{IsAdmin: true, UserID: 1}
+	owner := &security.RestrictedRequestContext{UserID: 2}
+	outsider := &security.RestrictedRequestContext{UserID: 3}
+
+	queued := &portainer.BackgroundJob{Type: "image.pull", Status: portainer.BackgroundJobQueued, CreatedBy: owner.UserID}
+	is.NoError(store.BackgroundJob().Create(queued))
+	failed := &portainer.BackgroundJob{Type: "endpoint.snapshot", Status: portainer.BackgroundJobFailed, Attempts: 3, Error: "unreachable"}
+	is.NoError(store.BackgroundJob().Create(failed))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer())
+	handler.DataStore = store
+	handler.JobQueue = jobs.NewQueue(store)
+
+	do := func(securityContext *security.RestrictedRequestContext, method, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r = r.WithContext(security.StoreRestrictedRequestContext(r, securityContext))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	list := func(securityContext *security.RestrictedRequestContext, query string) []portainer.BackgroundJob {
+		w := do(securityContext, http.MethodGet, "/background_jobs"+query)
+		is.Equal(http.StatusOK, w.Code)
+
+		var jobs []portainer.BackgroundJob
+		is.NoError(json.NewDecoder(w.Body).Decode(&jobs))
+
+		return jobs
+	}
+
+	is.Len(list(admin, ""), 2)
+	is.Len(list(owner, ""), 1)
+	is.Empty(list(outsider, ""))
+	is.Len(list(admin, "?status=failed"), 1)
+	is.Len(list(admin, "?type=image.pull"), 1)
+
+	is.Equal(http.StatusForbidden, do(outsider, http.MethodGet, fmt.Sprintf("/background_jobs/%d", queued.ID)).Code)
+	is.Equal(http.StatusForbidden, do(outsider, http.MethodPost, fmt.Sprintf("/background_jobs/%d/cancel", queued.ID)).Code)
+
+	w := do(owner, http.MethodPost, fmt.Sprintf("/background_jobs/%d/cancel", queued.ID))
+	is.Equal(http.StatusOK, w.Code)
+
+	var job portainer.BackgroundJob
+	is.NoError(json.NewDecoder(w.Body).Decode(&job))
+	is.Equal(portainer.BackgroundJobCanceled, job.Status)
+
+	is.Equal(http.StatusConflict, do(owner, http.MethodPost, fmt.Sprintf("/background_jobs/%d/cancel", queued.ID)).Code)
+
+	w = do(admin, http.MethodPost, fmt.Sprintf("/background_jobs/%d/retry", failed.ID))
+	is.Equal(http.StatusOK, w.Code)
+	is.NoError(json.NewDecoder(w.Body).Decode(&job))
+	is.Equal(portainer.BackgroundJobQueued, job.Status)
+	is.Equal(0, job.Attempts)
+
+	is.Equal(http.StatusConflict, do(admin, http.MethodPost, fmt.Sprintf("/background_jobs/%d/retry", failed.ID)).Code)
+	is.Equal(http.StatusNotFound, do(admin, http.MethodGet, "/background_jobs/42").Code)
+
+	// the stream of a finished job only carries its status
+	w = do(owner, http.MethodGet, fmt.Sprintf("/background_jobs/%d/events", queued.ID))
+	is.Equal(http.StatusOK, w.Code)
+	is.Equal("text/event-stream", w.Header().Get("Content-Type"))
+	is.True(strings.HasPrefix(w.Body.String(), "event: status\ndata: "))
+	is.Contains(w.Body.String(), `"status":"canceled"`)
+
+	is.Equal(http.StatusForbidden, do(outsider, http.MethodGet, fmt.Sprintf("/background_jobs/%d/events", queued.ID)).Code)
+}
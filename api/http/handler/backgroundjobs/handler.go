@@ -0,0 +1,74 @@
+package backgroundjobs
+
+import (
+	"context"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jobs"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to handle the background job operations.
+type Handler struct {
+	*mux.Router
+	DataStore   dataservices.DataStore
+	JobQueue    *jobs.Queue
+	ShutdownCtx context.Context
+}
+
+// NewHandler creates a handler to manage the background job operations. The users can follow and cancel the jobs they
+// requested, while the administrators can manage all the jobs.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+
+	authenticatedRouter := h.NewRoute().Subrouter()
+	authenticatedRouter.Use(bouncer.AuthenticatedAccess)
+
+	authenticatedRouter.Handle("/background_jobs", httperror.LoggerHandler(h.backgroundJobList)).Methods(http.MethodGet)
+	authenticatedRouter.Handle("/background_jobs/{id}", httperror.LoggerHandler(h.backgroundJobInspect)).Methods(http.MethodGet)
+	authenticatedRouter.Handle("/background_jobs/{id}/events", httperror.LoggerHandler(h.backgroundJobEvents)).Methods(http.MethodGet)
+	authenticatedRouter.Handle("/background_jobs/{id}/cancel", httperror.LoggerHandler(h.backgroundJobCancel)).Methods(http.MethodPost)
+	authenticatedRouter.Handle("/background_jobs/{id}/retry", httperror.LoggerHandler(h.backgroundJobRetry)).Methods(http.MethodPost)
+
+	adminRouter := h.NewRoute().Subrouter()
+	adminRouter.Use(bouncer.AdminAccess)
+
+	adminRouter.Handle("/background_jobs/image_pulls", httperror.LoggerHandler(h.backgroundJobImagePull)).Methods(http.MethodPost)
+
+	return h
+}
+
+// jobFromRequest returns the job of the request, the users other than the administrators only accessing their jobs
+func (handler *Handler) jobFromRequest(r *http.Request) (*portainer.BackgroundJob, *httperror.HandlerError) {
+	jobID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid background job identifier route variable", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	job, err := handler.DataStore.BackgroundJob().Read(portainer.BackgroundJobID(jobID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a background job with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a background job with the specified identifier inside the database", err)
+	}
+
+	if !securityContext.IsAdmin && job.CreatedBy != securityContext.UserID {
+		return nil, httperror.Forbidden("Access denied to the background job", httperrors.ErrResourceAccessDenied)
+	}
+
+	return job, nil
+}
@@ -0,0 +1,52 @@
+package changerequests
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ChangeRequestCancel
+// @summary Cancel a change request
+// @description Withdraw a pending change request. Only the requester can cancel their change requests.
+// @description **Access policy**: restricted
+// @tags change_requests
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Change request identifier"
+// @success 200 {object} portainer.ChangeRequest "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Change request not found"
+// @failure 409 "The change request is not pending"
+// @failure 500 "Server error"
+// @router /change_requests/{id}/cancel [post]
+func (handler *Handler) changeRequestCancel(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	changeRequest, _, httpErr := handler.changeRequestFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	if changeRequest.RequesterID != securityContext.UserID {
+		return httperror.Forbidden("Only the requester can cancel the change request", httperrors.ErrResourceAccessDenied)
+	}
+
+	changeRequest, httpErr = handler.closePending(changeRequest.ID, func(changeRequest *portainer.ChangeRequest) {
+		changeRequest.Status = portainer.ChangeRequestCancelled
+	})
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, changeRequest)
+}
@@ -0,0 +1,32 @@
+package changerequests
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ChangeRequestInspect
+// @summary Inspect a change request
+// @description The change request must be submitted by the user or be reviewable by them.
+// @description **Access policy**: restricted
+// @tags change_requests
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Change request identifier"
+// @success 200 {object} portainer.ChangeRequest "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Change request not found"
+// @failure 500 "Server error"
+// @router /change_requests/{id} [get]
+func (handler *Handler) changeRequestInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	changeRequest, _, httpErr := handler.changeRequestFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, changeRequest)
+}
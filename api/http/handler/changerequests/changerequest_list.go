@@ -0,0 +1,78 @@
+package changerequests
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ChangeRequestList
+// @summary List the change requests
+// @description List the change requests submitted by the user and the ones they can review. The administrators see
+// @description all the change requests.
+// @description **Access policy**: restricted
+// @tags change_requests
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param status query string false "Only the change requests with this status" Enums(pending, approved, rejected, cancelled, executed, failed)
+// @param endpointId query int false "Only the change requests of this environment"
+// @success 200 {array} portainer.ChangeRequest "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /change_requests [get]
+func (handler *Handler) changeRequestList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	status, _ := request.RetrieveQueryParameter(r, "status", true)
+
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: endpointId", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	changeRequests, err := handler.DataStore.ChangeRequest().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the change requests from the database", err)
+	}
+
+	endpoints := make(map[portainer.EndpointID]*portainer.Endpoint)
+
+	filtered := []portainer.ChangeRequest{}
+	for i := range changeRequests {
+		changeRequest := &changeRequests[i]
+
+		if status != "" && string(changeRequest.Status) != status {
+			continue
+		}
+
+		if endpointID != 0 && changeRequest.EndpointID != portainer.EndpointID(endpointID) {
+			continue
+		}
+
+		endpoint, ok := endpoints[changeRequest.EndpointID]
+		if !ok {
+			endpoint, err = handler.DataStore.Endpoint().Endpoint(changeRequest.EndpointID)
+			if handler.DataStore.IsErrObjectNotFound(err) {
+				endpoint = nil
+			} else if err != nil {
+				return httperror.InternalServerError("Unable to find the environment of the change request inside the database", err)
+			}
+
+			endpoints[changeRequest.EndpointID] = endpoint
+		}
+
+		if canReadChangeRequest(changeRequest, endpoint, securityContext) {
+			filtered = append(filtered, *changeRequest)
+		}
+	}
+
+	return response.JSON(w, filtered)
+}
@@ -0,0 +1,171 @@
+package changerequests
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/lifecycle"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+type changeRequestReviewPayload struct {
+	// Comment of the reviewer, required to reject a change request
+	Comment string `example:"Approved for the release"`
+}
+
+func (payload *changeRequestReviewPayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// review is the review of a change request by one of the approvers of its environment
+type review struct {
+	changeRequest *portainer.ChangeRequest
+	endpoint      *portainer.Endpoint
+	reviewerID    portainer.UserID
+	comment       string
+}
+
+// @id ChangeRequestApprove
+// @summary Approve a change request
+// @description Approve a pending change request, which is then run on the environment on behalf of its requester.
+// @description The change request is executed when the Docker API accepts it and failed otherwise, the response of
+// @description the Docker API being recorded in both cases.
+// @description Only the approvers of the environment and the administrators can approve the change requests, the
+// @description requesters never approving their own change requests.
+// @description **Access policy**: restricted
+// @tags change_requests
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Change request identifier"
+// @param body body changeRequestReviewPayload true "Review details"
+// @success 200 {object} portainer.ChangeRequest "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Change request not found"
+// @failure 409 "The change request is not pending"
+// @failure 500 "Server error"
+// @router /change_requests/{id}/approve [post]
+func (handler *Handler) changeRequestApprove(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	review, httpErr := handler.reviewFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	changeRequest, httpErr := handler.closeReview(review, portainer.ChangeRequestApproved)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	handler.publishChangeRequestEvent(lifecycle.ChangeApproved, changeRequest)
+
+	if err := handler.execute(changeRequest, review.endpoint); err != nil {
+		log.Warn().Err(err).Int("change_request", int(changeRequest.ID)).Msg("unable to execute the change request")
+
+		changeRequest.ResponseBody = err.Error()
+	}
+
+	changeRequest.Status = portainer.ChangeRequestExecuted
+	if changeRequest.ResponseStatus == 0 || changeRequest.ResponseStatus >= http.StatusBadRequest {
+		changeRequest.Status = portainer.ChangeRequestFailed
+
+		handler.publishChangeRequestEvent(lifecycle.ChangeFailed, changeRequest)
+	}
+
+	if err := handler.DataStore.ChangeRequest().Update(changeRequest.ID, changeRequest); err != nil {
+		return httperror.InternalServerError("Unable to persist the change request changes inside the database", err)
+	}
+
+	return response.JSON(w, changeRequest)
+}
+
+// @id ChangeRequestReject
+// @summary Reject a change request
+// @description Reject a pending change request, which is never run. The comment explaining the rejection is required.
+// @description Only the approvers of the environment and the administrators can reject the change requests.
+// @description **Access policy**: restricted
+// @tags change_requests
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Change request identifier"
+// @param body body changeRequestReviewPayload true "Review details"
+// @success 200 {object} portainer.ChangeRequest "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Change request not found"
+// @failure 409 "The change request is not pending"
+// @failure 500 "Server error"
+// @router /change_requests/{id}/reject [post]
+func (handler *Handler) changeRequestReject(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	review, httpErr := handler.reviewFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if review.comment == "" {
+		return httperror.BadRequest("Invalid request payload", errors.New("the comment is required to reject a change request"))
+	}
+
+	changeRequest, httpErr := handler.closeReview(review, portainer.ChangeRequestRejected)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	handler.publishChangeRequestEvent(lifecycle.ChangeRejected, changeRequest)
+
+	return response.JSON(w, changeRequest)
+}
+
+// reviewFromRequest returns the review of the change request of the request, when the user can review it
+func (handler *Handler) reviewFromRequest(r *http.Request) (*review, *httperror.HandlerError) {
+	var payload changeRequestReviewPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return nil, httperror.BadRequest("Invalid request payload", err)
+	}
+
+	changeRequest, endpoint, httpErr := handler.changeRequestFromRequest(r)
+	if httpErr != nil {
+		return nil, httpErr
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	if endpoint == nil {
+		return nil, httperror.NotFound("Unable to find the environment of the change request inside the database", errors.New("the environment of the change request no longer exists"))
+	}
+
+	if !canReviewChangeRequest(changeRequest, endpoint, securityContext) {
+		return nil, httperror.Forbidden("Only the approvers of the environment can review the change request", httperrors.ErrResourceAccessDenied)
+	}
+
+	return &review{
+		changeRequest: changeRequest,
+		endpoint:      endpoint,
+		reviewerID:    securityContext.UserID,
+		comment:       payload.Comment,
+	}, nil
+}
+
+// closeReview records the review on the change request, which must still be pending
+func (handler *Handler) closeReview(review *review, status portainer.ChangeRequestStatus) (*portainer.ChangeRequest, *httperror.HandlerError) {
+	return handler.closePending(review.changeRequest.ID, func(changeRequest *portainer.ChangeRequest) {
+		changeRequest.Status = status
+		changeRequest.ReviewerID = review.reviewerID
+		changeRequest.ReviewedAt = time.Now().Unix()
+		changeRequest.ReviewComment = review.comment
+	})
+}
@@ -0,0 +1,173 @@
+package changerequests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeRequestReview(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	team := &portainer.Team{Name: "release"}
+	is.NoError(store.Team().Create(team))
+
+	developer := &portainer.User{Username: "developer", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(developer))
+	releaser := &portainer.User{Username: "releaser", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(releaser))
+
+	requester := &security.RestrictedRequestContext{UserID: developer.ID}
+	approver := &security.RestrictedRequestContext{UserID: releaser.ID, UserMemberships: []portainer.TeamMembership{{UserID: releaser.ID, TeamID: team.ID}}}
+	outsider := &security.RestrictedRequestContext{UserID: 99}
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "production", Type: portainer.DockerEnvironment, GroupID: 1,
+		UserAccessPolicies: portainer.UserAccessPolicies{developer.ID: {}},
+		ChangeApproval:     &portainer.EndpointChangeApproval{Enabled: true, ApproverTeamIDs: []portainer.TeamID{team.ID}}}))
+
+	newChangeRequest := func() *portainer.ChangeRequest {
+		changeRequest := &portainer.ChangeRequest{EndpointID: 1, RequesterID: developer.ID, Method: http.MethodPost, Path: "/containers/create",
+			Query: "name=web", ContentType: "application/json", Body: []byte(`{"Image":"nginx"}`), Status: portainer.ChangeRequestPending}
+		is.NoError(store.ChangeRequest().Create(changeRequest))
+
+		return changeRequest
+	}
+
+	var sent *http.Request
+	var sentBody []byte
+	var sentTokenData *portainer.TokenData
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer())
+	handler.DataStore = store
+	handler.dockerProxy = func(endpoint *portainer.Endpoint) (http.Handler, error) {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sent = r
+			sentBody, _ = io.ReadAll(r.Body)
+			sentTokenData, _ = security.RetrieveTokenData(r)
+
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"Id":"c1"}`))
+		}), nil
+	}
+
+	do := func(securityContext *security.RestrictedRequestContext, method, path string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			is.NoError(json.NewEncoder(&body).Encode(payload))
+		}
+
+		r := httptest.NewRequest(method, path, &body)
+		r = r.WithContext(security.StoreRestrictedRequestContext(r, securityContext))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	t.Run("the change requests are listed for their requester and the approvers only", func(t *testing.T) {
+		newChangeRequest()
+
+		for securityContext, count := range map[*security.RestrictedRequestContext]int{requester: 1, approver: 1, outsider: 0} {
+			w := do(securityContext, http.MethodGet, "/change_requests?status=pending", nil)
+			is.Equal(http.StatusOK, w.Code)
+
+			var changeRequests []portainer.ChangeRequest
+			is.NoError(json.NewDecoder(w.Body).Decode(&changeRequests))
+			is.Len(changeRequests, count)
+		}
+	})
+
+	t.Run("the requester cannot approve their own change request", func(t *testing.T) {
+		changeRequest := newChangeRequest()
+
+		w := do(requester, http.MethodPost, fmt.Sprintf("/change_requests/%d/approve", changeRequest.ID), changeRequestReviewPayload{})
+		is.Equal(http.StatusForbidden, w.Code)
+
+		w = do(outsider, http.MethodPost, fmt.Sprintf("/change_requests/%d/approve", changeRequest.ID), changeRequestReviewPayload{})
+		is.Equal(http.StatusForbidden, w.Code)
+	})
+
+	t.Run("an approved change request is run on behalf of the requester", func(t *testing.T) {
+		changeRequest := newChangeRequest()
+
+		w := do(approver, http.MethodPost, fmt.Sprintf("/change_requests/%d/approve", changeRequest.ID), changeRequestReviewPayload{Comment: "ok"})
+		is.Equal(http.StatusOK, w.Code)
+
+		is.NoError(json.NewDecoder(w.Body).Decode(changeRequest))
+		is.Equal(portainer.ChangeRequestExecuted, changeRequest.Status)
+		is.Equal(releaser.ID, changeRequest.ReviewerID)
+		is.Equal(http.StatusCreated, changeRequest.ResponseStatus)
+		is.Equal(`{"Id":"c1"}`, changeRequest.ResponseBody)
+
+		if is.NotNil(sent) {
+			is.Equal("/containers/create", sent.URL.Path)
+			is.Equal("name=web", sent.URL.RawQuery)
+			is.Equal("application/json", sent.Header.Get("Content-Type"))
+			is.Equal(`{"Image":"nginx"}`, string(sentBody))
+			is.Equal(&portainer.TokenData{ID: developer.ID, Username: "developer", Role: portainer.StandardUserRole}, sentTokenData)
+		}
+
+		w = do(approver, http.MethodPost, fmt.Sprintf("/change_requests/%d/approve", changeRequest.ID), changeRequestReviewPayload{})
+		is.Equal(http.StatusConflict, w.Code, "a change request is run once")
+	})
+
+	t.Run("a rejected change request is never run", func(t *testing.T) {
+		changeRequest := newChangeRequest()
+		sent = nil
+
+		w := do(approver, http.MethodPost, fmt.Sprintf("/change_requests/%d/reject", changeRequest.ID), changeRequestReviewPayload{})
+		is.Equal(http.StatusBadRequest, w.Code, "the rejections must be explained")
+
+		w = do(approver, http.MethodPost, fmt.Sprintf("/change_requests/%d/reject", changeRequest.ID), changeRequestReviewPayload{Comment: "not during the freeze"})
+		is.Equal(http.StatusOK, w.Code)
+
+		changeRequest, err := store.ChangeRequest().Read(changeRequest.ID)
+		is.NoError(err)
+		is.Equal(portainer.ChangeRequestRejected, changeRequest.Status)
+		is.Equal("not during the freeze", changeRequest.ReviewComment)
+		is.Nil(sent)
+	})
+
+	t.Run("only the requester cancels their change request", func(t *testing.T) {
+		changeRequest := newChangeRequest()
+
+		w := do(approver, http.MethodPost, fmt.Sprintf("/change_requests/%d/cancel", changeRequest.ID), nil)
+		is.Equal(http.StatusForbidden, w.Code)
+
+		w = do(requester, http.MethodPost, fmt.Sprintf("/change_requests/%d/cancel", changeRequest.ID), nil)
+		is.Equal(http.StatusOK, w.Code)
+
+		w = do(approver, http.MethodPost, fmt.Sprintf("/change_requests/%d/approve", changeRequest.ID), changeRequestReviewPayload{})
+		is.Equal(http.StatusConflict, w.Code)
+	})
+
+	t.Run("the change request fails when the requester lost the access to the environment", func(t *testing.T) {
+		changeRequest := newChangeRequest()
+
+		endpoint, err := store.Endpoint().Endpoint(1)
+		is.NoError(err)
+		endpoint.UserAccessPolicies = portainer.UserAccessPolicies{}
+		is.NoError(store.Endpoint().UpdateEndpoint(1, endpoint))
+
+		w := do(approver, http.MethodPost, fmt.Sprintf("/change_requests/%d/approve", changeRequest.ID), changeRequestReviewPayload{})
+		is.Equal(http.StatusOK, w.Code)
+
+		is.NoError(json.NewDecoder(w.Body).Decode(changeRequest))
+		is.Equal(portainer.ChangeRequestFailed, changeRequest.Status)
+		is.Equal(errRequesterAccessRevoked.Error(), changeRequest.ResponseBody)
+	})
+}
@@ -0,0 +1,128 @@
+package changerequests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+)
+
+// maxResponseBodySize is the size of the beginning of the Docker API responses recorded on the change requests
+const maxResponseBodySize = 64 << 10
+
+var errRequesterAccessRevoked = errors.New("the requester can no longer access the environment")
+
+// execute sends the Docker request of an approved change request to its environment on behalf of the requester, so
+// that the resource controls and the audit of the Docker operations apply as if the requester sent it. The status
+// and the beginning of the body of the Docker response are recorded on the change request.
+func (handler *Handler) execute(changeRequest *portainer.ChangeRequest, endpoint *portainer.Endpoint) error {
+	requester, err := handler.DataStore.User().Read(changeRequest.RequesterID)
+	if err != nil {
+		return fmt.Errorf("unable to find the requester of the change request: %w", err)
+	}
+
+	if requester.Role != portainer.AdministratorRole {
+		memberships, err := handler.DataStore.TeamMembership().TeamMembershipsByUserID(requester.ID)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve the teams of the requester: %w", err)
+		}
+
+		group, err := handler.DataStore.EndpointGroup().Read(endpoint.GroupID)
+		if err != nil {
+			return fmt.Errorf("unable to find the group of the environment: %w", err)
+		}
+
+		if !security.AuthorizedEndpointAccess(endpoint, group, requester.ID, memberships) {
+			return errRequesterAccessRevoked
+		}
+	}
+
+	proxy, err := handler.dockerProxy(endpoint)
+	if err != nil {
+		return err
+	}
+
+	target := changeRequest.Path
+	if changeRequest.Query != "" {
+		target += "?" + changeRequest.Query
+	}
+
+	// the request is not bound to the one of the reviewer, so that a long operation such as the pull of an image
+	// completes even when the reviewer leaves
+	r, err := http.NewRequestWithContext(context.Background(), changeRequest.Method, target, bytes.NewReader(changeRequest.Body))
+	if err != nil {
+		return fmt.Errorf("unable to build the Docker request of the change request: %w", err)
+	}
+
+	if changeRequest.ContentType != "" {
+		r.Header.Set("Content-Type", changeRequest.ContentType)
+	}
+
+	if changeRequest.AgentTarget != "" {
+		r.Header.Set(portainer.PortainerAgentTargetHeader, changeRequest.AgentTarget)
+	}
+
+	r = r.WithContext(security.StoreTokenData(r, &portainer.TokenData{
+		ID:       requester.ID,
+		Username: requester.Username,
+		Role:     requester.Role,
+	}))
+
+	recorder := &responseRecorder{header: make(http.Header)}
+	proxy.ServeHTTP(recorder, r)
+
+	changeRequest.ResponseStatus = recorder.status
+	changeRequest.ResponseBody = recorder.body.String()
+
+	return nil
+}
+
+// endpointDockerProxy returns the proxy of the Docker API of the environment, created when it does not exist yet
+func (handler *Handler) endpointDockerProxy(endpoint *portainer.Endpoint) (http.Handler, error) {
+	if endpoint.Type == portainer.EdgeAgentOnDockerEnvironment {
+		if endpoint.EdgeID == "" {
+			return nil, errors.New("no Edge agent registered with the environment")
+		}
+
+		if _, err := handler.ReverseTunnelService.GetActiveTunnel(endpoint); err != nil {
+			return nil, fmt.Errorf("unable to get the active tunnel: %w", err)
+		}
+	}
+
+	if proxy := handler.ProxyManager.GetEndpointProxy(endpoint); proxy != nil {
+		return proxy, nil
+	}
+
+	return handler.ProxyManager.CreateAndRegisterEndpointProxy(endpoint)
+}
+
+// responseRecorder records the status and the beginning of the body of a Docker response
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (recorder *responseRecorder) Header() http.Header {
+	return recorder.header
+}
+
+func (recorder *responseRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	recorder.WriteHeader(http.StatusOK)
+
+	if remaining := maxResponseBodySize - recorder.body.Len(); remaining > 0 {
+		recorder.body.Write(data[:min(len(data), remaining)])
+	}
+
+	return len(data), nil
+}
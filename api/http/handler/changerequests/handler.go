@@ -0,0 +1,139 @@
+package changerequests
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/proxy"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/lifecycle"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+var errChangeRequestNotPending = errors.New("the change request is not pending")
+
+// Handler is the HTTP handler used to handle change request operations.
+type Handler struct {
+	*mux.Router
+	DataStore            dataservices.DataStore
+	ProxyManager         *proxy.Manager
+	ReverseTunnelService portainer.ReverseTunnelService
+	EventDispatcher      *lifecycle.Dispatcher
+	// dockerProxy returns the proxy of the Docker API of an environment, running the approved change requests
+	dockerProxy func(endpoint *portainer.Endpoint) (http.Handler, error)
+	// reviewMu serializes the reviews, so that a change request is reviewed once
+	reviewMu sync.Mutex
+}
+
+// NewHandler creates a handler to manage change request operations.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+	h.dockerProxy = h.endpointDockerProxy
+
+	restrictedRouter := h.NewRoute().Subrouter()
+	restrictedRouter.Use(bouncer.RestrictedAccess)
+
+	restrictedRouter.Handle("/change_requests", httperror.LoggerHandler(h.changeRequestList)).Methods(http.MethodGet)
+	restrictedRouter.Handle("/change_requests/{id}", httperror.LoggerHandler(h.changeRequestInspect)).Methods(http.MethodGet)
+	restrictedRouter.Handle("/change_requests/{id}/approve", httperror.LoggerHandler(h.changeRequestApprove)).Methods(http.MethodPost)
+	restrictedRouter.Handle("/change_requests/{id}/reject", httperror.LoggerHandler(h.changeRequestReject)).Methods(http.MethodPost)
+	restrictedRouter.Handle("/change_requests/{id}/cancel", httperror.LoggerHandler(h.changeRequestCancel)).Methods(http.MethodPost)
+
+	return h
+}
+
+// changeRequestFromRequest returns the change request of the request along with its environment, when the user
+// submitted it or can review it
+func (handler *Handler) changeRequestFromRequest(r *http.Request) (*portainer.ChangeRequest, *portainer.Endpoint, *httperror.HandlerError) {
+	changeRequestID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, nil, httperror.BadRequest("Invalid change request identifier route variable", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	changeRequest, err := handler.DataStore.ChangeRequest().Read(portainer.ChangeRequestID(changeRequestID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, nil, httperror.NotFound("Unable to find a change request with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to find a change request with the specified identifier inside the database", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(changeRequest.EndpointID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		endpoint = nil
+	} else if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to find the environment of the change request inside the database", err)
+	}
+
+	if !canReadChangeRequest(changeRequest, endpoint, securityContext) {
+		return nil, nil, httperror.Forbidden("Permission denied to access the change request", httperrors.ErrResourceAccessDenied)
+	}
+
+	return changeRequest, endpoint, nil
+}
+
+// canReadChangeRequest returns true when the user submitted the change request or can review it
+func canReadChangeRequest(changeRequest *portainer.ChangeRequest, endpoint *portainer.Endpoint, securityContext *security.RestrictedRequestContext) bool {
+	if securityContext.IsAdmin || changeRequest.RequesterID == securityContext.UserID {
+		return true
+	}
+
+	return endpoint != nil && endpointutils.IsChangeApprover(endpoint, securityContext.UserID, securityContext.UserMemberships)
+}
+
+// canReviewChangeRequest returns true when the user is an approver of the environment of the change request or an
+// administrator, the requesters never reviewing their own change requests
+func canReviewChangeRequest(changeRequest *portainer.ChangeRequest, endpoint *portainer.Endpoint, securityContext *security.RestrictedRequestContext) bool {
+	if changeRequest.RequesterID == securityContext.UserID {
+		return false
+	}
+
+	return securityContext.IsAdmin || endpointutils.IsChangeApprover(endpoint, securityContext.UserID, securityContext.UserMemberships)
+}
+
+// closePending moves a pending change request to another status, failing when it was reviewed or cancelled
+// meanwhile
+func (handler *Handler) closePending(changeRequestID portainer.ChangeRequestID, update func(changeRequest *portainer.ChangeRequest)) (*portainer.ChangeRequest, *httperror.HandlerError) {
+	handler.reviewMu.Lock()
+	defer handler.reviewMu.Unlock()
+
+	changeRequest, err := handler.DataStore.ChangeRequest().Read(changeRequestID)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to find the change request inside the database", err)
+	}
+
+	if changeRequest.Status != portainer.ChangeRequestPending {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "The change request is not pending", Err: errChangeRequestNotPending}
+	}
+
+	update(changeRequest)
+
+	if err := handler.DataStore.ChangeRequest().Update(changeRequest.ID, changeRequest); err != nil {
+		return nil, httperror.InternalServerError("Unable to persist the change request changes inside the database", err)
+	}
+
+	return changeRequest, nil
+}
+
+// publishChangeRequestEvent notifies the event webhooks of a change request, e.g. so that the approvers are alerted
+func (handler *Handler) publishChangeRequestEvent(eventType string, changeRequest *portainer.ChangeRequest) {
+	if handler.EventDispatcher == nil {
+		return
+	}
+
+	handler.EventDispatcher.Publish(lifecycle.NewChangeRequestEvent(eventType, changeRequest))
+}
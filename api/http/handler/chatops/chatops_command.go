@@ -0,0 +1,163 @@
+package chatops
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+const (
+	// maxCommandSize is the maximum size of the slash command payloads
+	maxCommandSize = 64 * 1024
+	// maxRequestAge is the maximum age of the Slack requests, rejecting the replayed requests
+	maxRequestAge = 5 * time.Minute
+)
+
+// commandResponse is the message replied to a slash command, only visible to the user who sent it
+type commandResponse struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// @id ChatOpsSlack
+// @summary Run a Slack slash command
+// @description Run a slash command sent by Slack, the request being verified with the signing secret of the Slack app.
+// @description The command is run on behalf of the Portainer user linked to the Slack account.
+// @description **Access policy**: public
+// @tags chatops
+// @accept x-www-form-urlencoded
+// @produce json
+// @success 200 "Success"
+// @failure 401 "Invalid signature"
+// @failure 403 "The slash commands are disabled"
+// @failure 500 "Server error"
+// @router /chatops/slack [post]
+func (handler *Handler) chatOpsSlack(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, httpErr := handler.chatOpsSettings()
+	if httpErr != nil {
+		return httpErr
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCommandSize))
+	if err != nil {
+		return httperror.BadRequest("Unable to read the request body", err)
+	}
+
+	if err := verifySlackSignature(settings.SlackSigningSecret, r.Header, body, handler.now()); err != nil {
+		return httperror.Unauthorized("Invalid Slack request signature", err)
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	return handler.runCommand(w, platformSlack, values)
+}
+
+// @id ChatOpsMattermost
+// @summary Run a Mattermost slash command
+// @description Run a slash command sent by Mattermost, the request being verified with the token of the slash command.
+// @description The command is run on behalf of the Portainer user linked to the Mattermost account.
+// @description **Access policy**: public
+// @tags chatops
+// @accept x-www-form-urlencoded
+// @produce json
+// @success 200 "Success"
+// @failure 401 "Invalid token"
+// @failure 403 "The slash commands are disabled"
+// @failure 500 "Server error"
+// @router /chatops/mattermost [post]
+func (handler *Handler) chatOpsMattermost(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, httpErr := handler.chatOpsSettings()
+	if httpErr != nil {
+		return httpErr
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCommandSize))
+	if err != nil {
+		return httperror.BadRequest("Unable to read the request body", err)
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	if err := verifyMattermostToken(settings.MattermostToken, values.Get("token")); err != nil {
+		return httperror.Unauthorized("Invalid Mattermost token", err)
+	}
+
+	return handler.runCommand(w, platformMattermost, values)
+}
+
+func (handler *Handler) runCommand(w http.ResponseWriter, platform string, values url.Values) *httperror.HandlerError {
+	sender := chatUser{
+		Platform: platform,
+		TeamID:   values.Get("team_id"),
+		UserID:   values.Get("user_id"),
+	}
+
+	if sender.UserID == "" {
+		return httperror.BadRequest("Invalid request payload", errors.New("missing user_id"))
+	}
+
+	text, err := handler.command(sender, values.Get("text"))
+	if err != nil {
+		return httperror.InternalServerError("Unable to run the slash command", err)
+	}
+
+	return response.JSON(w, commandResponse{ResponseType: "ephemeral", Text: text})
+}
+
+// verifySlackSignature checks the signature of a Slack request, computed over its timestamp and its body
+func verifySlackSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	if secret == "" {
+		return errors.New("the Slack signing secret is not configured")
+	}
+
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid request timestamp")
+	}
+
+	if age := now.Sub(time.Unix(sent, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return errors.New("the request timestamp is too far from the current time")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return errors.New("signature mismatch")
+	}
+
+	return nil
+}
+
+// verifyMattermostToken checks the token sent by Mattermost along with the slash command
+func verifyMattermostToken(expected, token string) error {
+	if expected == "" {
+		return errors.New("the Mattermost token is not configured")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(token)) != 1 {
+		return errors.New("token mismatch")
+	}
+
+	return nil
+}
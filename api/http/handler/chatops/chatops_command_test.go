@@ -0,0 +1,161 @@
+package chatops
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func signSlackRequest(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySlackSignature(t *testing.T) {
+	is := assert.New(t)
+
+	now := time.Unix(1700000000, 0)
+	body := []byte("team_id=T1&user_id=U1&text=endpoints")
+
+	header := func(timestamp, signature string) http.Header {
+		h := http.Header{}
+		h.Set("X-Slack-Request-Timestamp", timestamp)
+		h.Set("X-Slack-Signature", signature)
+
+		return h
+	}
+
+	is.NoError(verifySlackSignature("secret", header("1700000000", signSlackRequest("secret", "1700000000", body)), body, now))
+	is.Error(verifySlackSignature("secret", header("1700000000", signSlackRequest("other", "1700000000", body)), body, now), "the signing secret should match")
+	is.Error(verifySlackSignature("secret", header("1700000000", signSlackRequest("secret", "1700000000", body)), []byte("text=restart"), now), "the body should be signed")
+	is.Error(verifySlackSignature("secret", header("1699999000", signSlackRequest("secret", "1699999000", body)), body, now), "the old requests should be rejected")
+	is.Error(verifySlackSignature("", header("1700000000", signSlackRequest("", "1700000000", body)), body, now), "a signing secret should be configured")
+}
+
+func TestVerifyMattermostToken(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(verifyMattermostToken("token", "token"))
+	is.Error(verifyMattermostToken("token", "other"))
+	is.Error(verifyMattermostToken("", ""), "a token should be configured")
+}
+
+func TestHandler_chatOps(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.ChatOps = portainer.ChatOpsSettings{Enabled: true, SlackSigningSecret: "secret", MattermostToken: "token"}
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	user := &portainer.User{Username: "user", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "authorized", Type: portainer.DockerEnvironment, GroupID: 1,
+		UserAccessPolicies: portainer.UserAccessPolicies{user.ID: {}}, Status: portainer.EndpointStatusUp}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 2, Name: "unauthorized", Type: portainer.DockerEnvironment, GroupID: 1}))
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer())
+	h.DataStore = store
+
+	slack := func(text string) (int, string) {
+		body := []byte(url.Values{"team_id": {"T1"}, "user_id": {"U1"}, "text": {text}}.Encode())
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+		r := httptest.NewRequest(http.MethodPost, "/chatops/slack", bytes.NewReader(body))
+		r.Header.Set("X-Slack-Request-Timestamp", timestamp)
+		r.Header.Set("X-Slack-Signature", signSlackRequest("secret", timestamp, body))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		var reply commandResponse
+		json.NewDecoder(w.Body).Decode(&reply)
+
+		return w.Code, reply.Text
+	}
+
+	t.Run("the unsigned requests are rejected", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/chatops/slack", strings.NewReader("user_id=U1&text=endpoints"))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("the requests with an invalid token are rejected", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/chatops/mattermost", strings.NewReader("token=other&user_id=U1&text=endpoints"))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("the commands of an account which is not linked are refused", func(t *testing.T) {
+		code, text := slack("endpoints")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Contains(t, text, "not linked")
+	})
+
+	t.Run("the linked account lists the environments of the user", func(t *testing.T) {
+		code, text := slack("link")
+		assert.Equal(t, http.StatusOK, code)
+
+		linkCode := regexp.MustCompile("`([0-9A-F]{8})`").FindStringSubmatch(text)
+		if !assert.Len(t, linkCode, 2) {
+			return
+		}
+
+		link := func() int {
+			r := httptest.NewRequest(http.MethodPost, "/chatops/link", strings.NewReader(`{"Code":"`+linkCode[1]+`"}`))
+			r = r.WithContext(security.StoreTokenData(r, &portainer.TokenData{ID: user.ID, Role: portainer.StandardUserRole}))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			return w.Code
+		}
+
+		assert.Equal(t, http.StatusNoContent, link())
+		assert.Equal(t, http.StatusBadRequest, link(), "the link code should only be used once")
+
+		_, text = slack("endpoints")
+		assert.Contains(t, text, "authorized: up")
+		assert.NotContains(t, text, "unauthorized")
+
+		_, text = slack("containers unauthorized")
+		assert.Contains(t, text, "No Docker environment")
+	})
+
+	t.Run("a restart can only be confirmed by the chat user who requested it", func(t *testing.T) {
+		code, err := h.confirmations.add(restartRequest{ChatUser: chatUser{Platform: platformSlack, TeamID: "T1", UserID: "U2"}, EndpointID: 1, ContainerID: "id"}, time.Now())
+		assert.NoError(t, err)
+
+		_, text := slack("confirm " + code)
+		assert.Contains(t, text, "Invalid or expired")
+	})
+}
@@ -0,0 +1,129 @@
+package chatops
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type chatOpsLinkPayload struct {
+	// Code returned by the link slash command
+	Code string `example:"4F2A91C0"`
+}
+
+func (payload *chatOpsLinkPayload) Validate(r *http.Request) error {
+	if payload.Code == "" {
+		return errors.New("invalid link code")
+	}
+
+	return nil
+}
+
+// @id ChatOpsLink
+// @summary Link a chat account
+// @description Link the Slack or Mattermost account which ran the link slash command to the current user.
+// @description The account is unlinked from any other user.
+// @description **Access policy**: authenticated
+// @tags chatops
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @param body body chatOpsLinkPayload true "Link code"
+// @success 204 "Success"
+// @failure 400 "Invalid or expired link code"
+// @failure 500 "Server error"
+// @router /chatops/link [post]
+func (handler *Handler) chatOpsLink(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload chatOpsLinkPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	sender, ok := handler.links.take(payload.Code, handler.now())
+	if !ok {
+		return httperror.BadRequest("Invalid or expired link code", errors.New("invalid link code"))
+	}
+
+	account := sender.account()
+
+	err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		users, err := tx.User().ReadAll()
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			accounts := slices.DeleteFunc(slices.Clone(user.ChatAccounts), func(a portainer.ChatAccount) bool {
+				return a == account
+			})
+
+			if user.ID == tokenData.ID {
+				accounts = append(accounts, account)
+			} else if len(accounts) == len(user.ChatAccounts) {
+				continue
+			}
+
+			user.ChatAccounts = accounts
+			if err := tx.User().Update(user.ID, &user); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return httperror.InternalServerError("Unable to link the chat account", err)
+	}
+
+	return response.Empty(w)
+}
+
+// @id ChatOpsUnlink
+// @summary Unlink the chat accounts
+// @description Unlink the Slack and Mattermost accounts of the current user, or only those of a platform.
+// @description **Access policy**: authenticated
+// @tags chatops
+// @security ApiKeyAuth
+// @security jwt
+// @param platform query string false "Chat platform, slack or mattermost"
+// @success 204 "Success"
+// @failure 500 "Server error"
+// @router /chatops/link [delete]
+func (handler *Handler) chatOpsUnlink(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	platform, _ := request.RetrieveQueryParameter(r, "platform", true)
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		user, err := tx.User().Read(tokenData.ID)
+		if err != nil {
+			return err
+		}
+
+		user.ChatAccounts = slices.DeleteFunc(user.ChatAccounts, func(a portainer.ChatAccount) bool {
+			return platform == "" || a.Platform == platform
+		})
+
+		return tx.User().Update(user.ID, user)
+	})
+	if err != nil {
+		return httperror.InternalServerError("Unable to unlink the chat accounts", err)
+	}
+
+	return response.Empty(w)
+}
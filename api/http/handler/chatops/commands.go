@@ -0,0 +1,351 @@
+package chatops
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/docker/docker/api/types"
+	dockercontainer "github.com/docker/docker/api/types/container"
+	"github.com/rs/zerolog/log"
+)
+
+// dockerTimeout bounds the Docker requests of the commands, the chat platforms expecting a quick reply
+const dockerTimeout = 10 * time.Second
+
+const usage = "Available commands:\n" +
+	"• `link` links this account to your Portainer user\n" +
+	"• `endpoints` lists the environments and their status\n" +
+	"• `containers <environment>` lists the containers of an environment\n" +
+	"• `restart <environment> <container>` restarts a container after a confirmation"
+
+// command runs a slash command on behalf of the Portainer user linked to the chat account and returns the reply,
+// an error being returned only when the command cannot be run
+func (handler *Handler) command(sender chatUser, text string) (string, error) {
+	args := strings.Fields(text)
+	if len(args) == 0 || args[0] == "help" {
+		return usage, nil
+	}
+
+	if args[0] == "link" {
+		code, err := handler.links.add(sender, handler.now())
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("Your link code is `%s`, enter it in Portainer within %s to link this account to your Portainer user.",
+			code, linkCodeDuration), nil
+	}
+
+	user, err := handler.linkedUser(sender)
+	if err != nil {
+		return "", err
+	} else if user == nil {
+		return "This account is not linked to a Portainer user, run `link` to link it.", nil
+	}
+
+	requestContext, err := handler.requestContext(user)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case args[0] == "endpoints" && len(args) == 1:
+		return handler.listEndpoints(requestContext)
+	case args[0] == "containers" && len(args) == 2:
+		return handler.listContainers(requestContext, args[1])
+	case args[0] == "restart" && len(args) == 3:
+		return handler.requestRestart(requestContext, sender, args[1], args[2])
+	case args[0] == "confirm" && len(args) == 2:
+		return handler.confirmRestart(requestContext, sender, args[1])
+	}
+
+	return usage, nil
+}
+
+// linkedUser returns the Portainer user linked to a chat account, nil being returned when the account is not linked
+func (handler *Handler) linkedUser(sender chatUser) (*portainer.User, error) {
+	users, err := handler.DataStore.User().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range users {
+		if slices.Contains(users[i].ChatAccounts, sender.account()) && !users[i].Pending {
+			return &users[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (handler *Handler) requestContext(user *portainer.User) (*security.RestrictedRequestContext, error) {
+	memberships, err := handler.DataStore.TeamMembership().TeamMembershipsByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &security.RestrictedRequestContext{
+		IsAdmin:         user.Role == portainer.AdministratorRole,
+		UserID:          user.ID,
+		UserMemberships: memberships,
+	}, nil
+}
+
+func (handler *Handler) listEndpoints(requestContext *security.RestrictedRequestContext) (string, error) {
+	endpoints, err := handler.DataStore.Endpoint().Endpoints()
+	if err != nil {
+		return "", err
+	}
+
+	groups, err := handler.DataStore.EndpointGroup().ReadAll()
+	if err != nil {
+		return "", err
+	}
+
+	endpoints = security.FilterEndpoints(endpoints, groups, requestContext)
+	if len(endpoints) == 0 {
+		return "No environment is available.", nil
+	}
+
+	var reply strings.Builder
+	for _, endpoint := range endpoints {
+		status := "up"
+		if endpoint.Status == portainer.EndpointStatusDown {
+			status = "down"
+		}
+
+		fmt.Fprintf(&reply, "• `%d` %s: %s\n", endpoint.ID, endpoint.Name, status)
+	}
+
+	return reply.String(), nil
+}
+
+// findEndpoint returns the Docker environment matching an identifier or a name, nil being returned when the user has
+// no access to it
+func (handler *Handler) findEndpoint(requestContext *security.RestrictedRequestContext, reference string) (*portainer.Endpoint, error) {
+	endpoints, err := handler.DataStore.Endpoint().Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		if strconv.Itoa(int(endpoint.ID)) != reference && endpoint.Name != reference {
+			continue
+		}
+
+		if !endpointutils.IsDockerEndpoint(endpoint) {
+			return nil, nil
+		}
+
+		if requestContext.IsAdmin {
+			return endpoint, nil
+		}
+
+		group, err := handler.DataStore.EndpointGroup().Read(endpoint.GroupID)
+		if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+			return nil, err
+		}
+
+		if security.AuthorizedEndpointAccess(endpoint, group, requestContext.UserID, requestContext.UserMemberships) {
+			return endpoint, nil
+		}
+
+		return nil, nil
+	}
+
+	return nil, nil
+}
+
+// authorizedContainers returns the containers of an environment the user has access to, the containers without a
+// resource control being only available to the administrators
+func (handler *Handler) authorizedContainers(requestContext *security.RestrictedRequestContext, endpoint *portainer.Endpoint) ([]types.Container, error) {
+	cli, err := handler.DockerClientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	if requestContext.IsAdmin {
+		return containers, nil
+	}
+
+	resourceControls, err := handler.DataStore.ResourceControl().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	n := 0
+	for _, container := range containers {
+		resourceControl := authorization.GetResourceControlByResourceIDAndType(container.ID, portainer.ContainerResourceControl, resourceControls)
+
+		if resourceControl == nil {
+			stackName := container.Labels[consts.ComposeStackNameLabel]
+			if stackName == "" {
+				stackName = container.Labels[consts.SwarmStackNameLabel]
+			}
+
+			if stackName != "" {
+				resourceControl = authorization.GetResourceControlByResourceIDAndType(stackutils.ResourceControlID(endpoint.ID, stackName), portainer.StackResourceControl, resourceControls)
+			}
+		}
+
+		if resourceControl != nil && security.AuthorizedResourceControlAccess(resourceControl, requestContext) {
+			containers[n] = container
+			n++
+		}
+	}
+
+	return containers[:n], nil
+}
+
+// findContainer returns the container matching a name or an identifier prefix, nil being returned when the user has
+// no access to it
+func (handler *Handler) findContainer(requestContext *security.RestrictedRequestContext, endpoint *portainer.Endpoint, reference string) (*types.Container, error) {
+	containers, err := handler.authorizedContainers(requestContext, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range containers {
+		if slices.Contains(containers[i].Names, "/"+reference) || (len(reference) >= 12 && strings.HasPrefix(containers[i].ID, reference)) {
+			return &containers[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (handler *Handler) listContainers(requestContext *security.RestrictedRequestContext, endpointReference string) (string, error) {
+	endpoint, err := handler.findEndpoint(requestContext, endpointReference)
+	if err != nil {
+		return "", err
+	} else if endpoint == nil {
+		return fmt.Sprintf("No Docker environment named `%s` is available.", endpointReference), nil
+	}
+
+	containers, err := handler.authorizedContainers(requestContext, endpoint)
+	if err != nil {
+		log.Warn().Err(err).Int("endpoint_id", int(endpoint.ID)).Msg("unable to list the containers of the environment")
+
+		return fmt.Sprintf("Unable to list the containers of the environment %s.", endpoint.Name), nil
+	}
+
+	if len(containers) == 0 {
+		return fmt.Sprintf("No container is available in the environment %s.", endpoint.Name), nil
+	}
+
+	var reply strings.Builder
+	for _, container := range containers {
+		fmt.Fprintf(&reply, "• `%s` %s: %s\n", containerName(container), container.Image, container.Status)
+	}
+
+	return reply.String(), nil
+}
+
+func (handler *Handler) requestRestart(requestContext *security.RestrictedRequestContext, sender chatUser, endpointReference, containerReference string) (string, error) {
+	endpoint, err := handler.findEndpoint(requestContext, endpointReference)
+	if err != nil {
+		return "", err
+	} else if endpoint == nil {
+		return fmt.Sprintf("No Docker environment named `%s` is available.", endpointReference), nil
+	}
+
+	container, err := handler.findContainer(requestContext, endpoint, containerReference)
+	if err != nil {
+		log.Warn().Err(err).Int("endpoint_id", int(endpoint.ID)).Msg("unable to list the containers of the environment")
+
+		return fmt.Sprintf("Unable to list the containers of the environment %s.", endpoint.Name), nil
+	} else if container == nil {
+		return fmt.Sprintf("No container named `%s` is available in the environment %s.", containerReference, endpoint.Name), nil
+	}
+
+	code, err := handler.confirmations.add(restartRequest{
+		ChatUser:    sender,
+		EndpointID:  endpoint.ID,
+		ContainerID: container.ID,
+	}, handler.now())
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Run `confirm %s` within %s to restart the container %s of the environment %s.",
+		code, confirmationDuration, containerName(*container), endpoint.Name), nil
+}
+
+// confirmRestart restarts the container of a confirmed restart request, the access of the user being checked again
+func (handler *Handler) confirmRestart(requestContext *security.RestrictedRequestContext, sender chatUser, code string) (string, error) {
+	request, ok := handler.confirmations.take(code, handler.now())
+	if !ok || request.ChatUser != sender {
+		return "Invalid or expired confirmation code.", nil
+	}
+
+	endpoint, err := handler.findEndpoint(requestContext, strconv.Itoa(int(request.EndpointID)))
+	if err != nil {
+		return "", err
+	} else if endpoint == nil {
+		return "The environment is no longer available.", nil
+	}
+
+	container, err := handler.findContainer(requestContext, endpoint, request.ContainerID)
+	if err != nil || container == nil {
+		return "The container is no longer available.", nil
+	}
+
+	cli, err := handler.DockerClientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return "", err
+	}
+
+	name := containerName(*container)
+
+	// the restart waits for the container to stop, which can take longer than the chat platforms wait for the reply
+	go func() {
+		defer cli.Close()
+
+		if err := cli.ContainerRestart(context.Background(), container.ID, dockercontainer.StopOptions{}); err != nil {
+			log.Warn().Err(err).Int("endpoint_id", int(endpoint.ID)).Str("container", name).Msg("unable to restart the container")
+
+			return
+		}
+
+		log.Info().Int("endpoint_id", int(endpoint.ID)).Str("container", name).Int("user_id", int(requestContext.UserID)).Msg("container restarted from a slash command")
+	}()
+
+	return fmt.Sprintf("The container %s of the environment %s is restarting.", name, endpoint.Name), nil
+}
+
+func containerName(container types.Container) string {
+	if len(container.Names) > 0 {
+		return strings.TrimPrefix(container.Names[0], "/")
+	}
+
+	return container.ID[:12]
+}
+
+func (sender chatUser) account() portainer.ChatAccount {
+	return portainer.ChatAccount{
+		Platform: sender.Platform,
+		TeamID:   sender.TeamID,
+		UserID:   sender.UserID,
+	}
+}
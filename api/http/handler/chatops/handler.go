@@ -0,0 +1,86 @@
+package chatops
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	platformSlack      = "slack"
+	platformMattermost = "mattermost"
+
+	// linkCodeDuration is the duration a link code can be entered in Portainer for
+	linkCodeDuration = 10 * time.Minute
+	// confirmationDuration is the duration a restart can be confirmed for
+	confirmationDuration = 2 * time.Minute
+)
+
+// Handler is the HTTP handler used to handle the Slack and Mattermost slash commands.
+type Handler struct {
+	*mux.Router
+	DataStore           dataservices.DataStore
+	DockerClientFactory *dockerclient.ClientFactory
+	links               *pendingCodes[chatUser]
+	confirmations       *pendingCodes[restartRequest]
+	now                 func() time.Time
+}
+
+// chatUser identifies the user sending a slash command
+type chatUser struct {
+	Platform string
+	TeamID   string
+	UserID   string
+}
+
+// restartRequest is a container restart waiting for the confirmation of the chat user
+type restartRequest struct {
+	ChatUser    chatUser
+	EndpointID  portainer.EndpointID
+	ContainerID string
+}
+
+// NewHandler creates a handler to handle the Slack and Mattermost slash commands.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router:        mux.NewRouter(),
+		links:         newPendingCodes[chatUser](linkCodeDuration),
+		confirmations: newPendingCodes[restartRequest](confirmationDuration),
+		now:           time.Now,
+	}
+
+	h.Handle("/chatops/slack",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.chatOpsSlack))).Methods(http.MethodPost)
+	h.Handle("/chatops/mattermost",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.chatOpsMattermost))).Methods(http.MethodPost)
+
+	authenticatedRouter := h.NewRoute().Subrouter()
+	authenticatedRouter.Use(bouncer.AuthenticatedAccess)
+
+	authenticatedRouter.Handle("/chatops/link", httperror.LoggerHandler(h.chatOpsLink)).Methods(http.MethodPost)
+	authenticatedRouter.Handle("/chatops/link", httperror.LoggerHandler(h.chatOpsUnlink)).Methods(http.MethodDelete)
+
+	return h
+}
+
+// chatOpsSettings returns the slash commands settings, an error being returned when they are disabled
+func (handler *Handler) chatOpsSettings() (*portainer.ChatOpsSettings, *httperror.HandlerError) {
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	if !settings.ChatOps.Enabled {
+		return nil, httperror.Forbidden("The slash commands are disabled", errors.New("slash commands disabled"))
+	}
+
+	return &settings.ChatOps, nil
+}
@@ -0,0 +1,70 @@
+package chatops
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pendingCodes keeps the values waiting for a one-time code, such as the account links and the restart
+// confirmations, in memory until the code is used or expires
+type pendingCodes[T any] struct {
+	mu       sync.Mutex
+	duration time.Duration
+	entries  map[string]pendingEntry[T]
+}
+
+type pendingEntry[T any] struct {
+	value     T
+	expiresAt time.Time
+}
+
+func newPendingCodes[T any](duration time.Duration) *pendingCodes[T] {
+	return &pendingCodes[T]{
+		duration: duration,
+		entries:  make(map[string]pendingEntry[T]),
+	}
+}
+
+// add generates the code of a value
+func (codes *pendingCodes[T]) add(value T, now time.Time) (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := strings.ToUpper(hex.EncodeToString(b))
+
+	codes.mu.Lock()
+	defer codes.mu.Unlock()
+
+	for key, entry := range codes.entries {
+		if now.After(entry.expiresAt) {
+			delete(codes.entries, key)
+		}
+	}
+
+	codes.entries[code] = pendingEntry[T]{value: value, expiresAt: now.Add(codes.duration)}
+
+	return code, nil
+}
+
+// take returns the value of a code when it has not expired, the code being used only once
+func (codes *pendingCodes[T]) take(code string, now time.Time) (T, bool) {
+	codes.mu.Lock()
+	defer codes.mu.Unlock()
+
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	entry, ok := codes.entries[code]
+	if !ok || now.After(entry.expiresAt) {
+		var zero T
+		return zero, false
+	}
+
+	delete(codes.entries, code)
+
+	return entry.value, true
+}
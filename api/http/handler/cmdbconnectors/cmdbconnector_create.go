@@ -0,0 +1,105 @@
+package cmdbconnectors
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/cmdb"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type cmdbConnectorCreatePayload struct {
+	// Name of the CMDB connector
+	Name string `validate:"required" example:"servicenow"`
+	// Type of the CMDB. Valid values are: servicenow or rest
+	Type portainer.CMDBConnectorType `validate:"required" example:"servicenow"`
+	// URL of the ServiceNow instance or base URL of the REST API
+	URL string `validate:"required" example:"https://example.service-now.com"`
+	// Username used to authenticate against ServiceNow
+	Username string `example:"portainer"`
+	// Password used to authenticate against ServiceNow
+	Password string `example:"s3cr3t"`
+	// Bearer token used to authenticate against the REST API
+	Token string `example:"s3cr3t"`
+	// Whether the inventory is synchronized on schedule
+	Enabled bool `example:"true"`
+	// The interval in which the inventory is synchronized, defaults to 1h
+	Interval string `example:"1h"`
+	// Whether the containers of the Docker environments are synchronized along with the environments
+	SyncContainers bool `example:"true"`
+	// ServiceNow table of the environments, defaults to cmdb_ci_docker_engine
+	EndpointTable string `example:"cmdb_ci_docker_engine"`
+	// ServiceNow table of the containers, defaults to cmdb_ci_docker_container
+	ContainerTable string `example:"cmdb_ci_docker_container"`
+	// Mapping of the inventory fields to the fields of the CMDB, a default mapping being used when empty
+	FieldMappings []portainer.CMDBFieldMapping
+}
+
+func (payload *cmdbConnectorCreatePayload) Validate(r *http.Request) error {
+	return cmdb.ValidateConnector(payload.connector())
+}
+
+func (payload *cmdbConnectorCreatePayload) connector() portainer.CMDBConnector {
+	connector := portainer.CMDBConnector{
+		Name:           payload.Name,
+		Type:           payload.Type,
+		URL:            payload.URL,
+		Username:       payload.Username,
+		Password:       payload.Password,
+		Token:          payload.Token,
+		Enabled:        payload.Enabled,
+		Interval:       payload.Interval,
+		SyncContainers: payload.SyncContainers,
+		EndpointTable:  payload.EndpointTable,
+		ContainerTable: payload.ContainerTable,
+		FieldMappings:  payload.FieldMappings,
+	}
+
+	if connector.Interval == "" {
+		connector.Interval = portainer.DefaultCMDBSyncInterval
+	}
+
+	if connector.FieldMappings == nil {
+		connector.FieldMappings = []portainer.CMDBFieldMapping{}
+	}
+
+	return connector
+}
+
+// @id CMDBConnectorCreate
+// @summary Create a CMDB connector
+// @description Register a connector pushing the inventory of the environments, and optionally of their containers, to a
+// @description configuration management database on schedule. ServiceNow connectors create the records in the tables of the
+// @description instance through its Table API, REST connectors send PUT and DELETE requests on {url}/endpoints/{key} and
+// @description {url}/containers/{key}. Only the changes since the previous synchronization are sent.
+// @description **Access policy**: administrator
+// @tags cmdb_connectors
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body cmdbConnectorCreatePayload true "CMDB connector details"
+// @success 200 {object} portainer.CMDBConnector "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /cmdb_connectors [post]
+func (handler *Handler) cmdbConnectorCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload cmdbConnectorCreatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	connector := payload.connector()
+
+	err = handler.DataStore.CMDBConnector().Create(&connector)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the CMDB connector inside the database", err)
+	}
+
+	hideFields(&connector)
+
+	return response.JSON(w, connector)
+}
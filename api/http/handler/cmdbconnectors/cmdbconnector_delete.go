@@ -0,0 +1,35 @@
+package cmdbconnectors
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id CMDBConnectorDelete
+// @summary Remove a CMDB connector
+// @description Remove a CMDB connector, the items previously pushed to the CMDB being left in place.
+// @description **Access policy**: administrator
+// @tags cmdb_connectors
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "CMDB connector identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "CMDB connector not found"
+// @failure 500 "Server error"
+// @router /cmdb_connectors/{id} [delete]
+func (handler *Handler) cmdbConnectorDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	connector, httpErr := handler.connectorFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err := handler.DataStore.CMDBConnector().Delete(connector.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the CMDB connector from the database", err)
+	}
+
+	return response.Empty(w)
+}
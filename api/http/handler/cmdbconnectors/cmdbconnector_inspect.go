@@ -0,0 +1,32 @@
+package cmdbconnectors
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id CMDBConnectorInspect
+// @summary Inspect a CMDB connector
+// @description **Access policy**: administrator
+// @tags cmdb_connectors
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "CMDB connector identifier"
+// @success 200 {object} portainer.CMDBConnector "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "CMDB connector not found"
+// @failure 500 "Server error"
+// @router /cmdb_connectors/{id} [get]
+func (handler *Handler) cmdbConnectorInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	connector, httpErr := handler.connectorFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	hideFields(connector)
+
+	return response.JSON(w, connector)
+}
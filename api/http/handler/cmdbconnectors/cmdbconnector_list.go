@@ -0,0 +1,31 @@
+package cmdbconnectors
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id CMDBConnectorList
+// @summary List the CMDB connectors
+// @description **Access policy**: administrator
+// @tags cmdb_connectors
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.CMDBConnector "Success"
+// @failure 500 "Server error"
+// @router /cmdb_connectors [get]
+func (handler *Handler) cmdbConnectorList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	connectors, err := handler.DataStore.CMDBConnector().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the CMDB connectors from the database", err)
+	}
+
+	for i := range connectors {
+		hideFields(&connectors[i])
+	}
+
+	return response.JSON(w, connectors)
+}
@@ -0,0 +1,38 @@
+package cmdbconnectors
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id CMDBConnectorSync
+// @summary Synchronize a CMDB connector
+// @description Push the changes of the inventory since the previous synchronization to the CMDB without waiting for the
+// @description schedule of the connector. The outcome of the synchronization is returned, a failed synchronization being
+// @description reported in the status rather than as an error.
+// @description **Access policy**: administrator
+// @tags cmdb_connectors
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "CMDB connector identifier"
+// @success 200 {object} portainer.CMDBSyncStatus "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "CMDB connector not found"
+// @failure 500 "Server error"
+// @router /cmdb_connectors/{id}/sync [post]
+func (handler *Handler) cmdbConnectorSync(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	connector, httpErr := handler.connectorFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	status, err := handler.CMDBService.Sync(r.Context(), connector.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to synchronize the CMDB connector", err)
+	}
+
+	return response.JSON(w, status)
+}
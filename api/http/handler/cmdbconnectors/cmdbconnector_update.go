@@ -0,0 +1,142 @@
+package cmdbconnectors
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/cmdb"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type cmdbConnectorUpdatePayload struct {
+	// Name of the CMDB connector
+	Name *string `example:"servicenow"`
+	// Type of the CMDB. Valid values are: servicenow or rest
+	Type *portainer.CMDBConnectorType `example:"servicenow"`
+	// URL of the ServiceNow instance or base URL of the REST API
+	URL *string `example:"https://example.service-now.com"`
+	// Username used to authenticate against ServiceNow
+	Username *string `example:"portainer"`
+	// Password used to authenticate against ServiceNow, the current password is kept when empty
+	Password *string `example:"s3cr3t"`
+	// Bearer token used to authenticate against the REST API, the current token is kept when empty
+	Token *string `example:"s3cr3t"`
+	// Whether the inventory is synchronized on schedule
+	Enabled *bool `example:"true"`
+	// The interval in which the inventory is synchronized
+	Interval *string `example:"1h"`
+	// Whether the containers of the Docker environments are synchronized along with the environments
+	SyncContainers *bool `example:"true"`
+	// ServiceNow table of the environments
+	EndpointTable *string `example:"cmdb_ci_docker_engine"`
+	// ServiceNow table of the containers
+	ContainerTable *string `example:"cmdb_ci_docker_container"`
+	// Mapping of the inventory fields to the fields of the CMDB, a default mapping being used when empty
+	FieldMappings []portainer.CMDBFieldMapping
+}
+
+func (payload *cmdbConnectorUpdatePayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id CMDBConnectorUpdate
+// @summary Update a CMDB connector
+// @description Changing the type, the URL or the tables of the connector forgets the items previously pushed to the CMDB,
+// @description the whole inventory being created again on the next synchronization.
+// @description **Access policy**: administrator
+// @tags cmdb_connectors
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "CMDB connector identifier"
+// @param body body cmdbConnectorUpdatePayload true "CMDB connector details"
+// @success 200 {object} portainer.CMDBConnector "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "CMDB connector not found"
+// @failure 500 "Server error"
+// @router /cmdb_connectors/{id} [put]
+func (handler *Handler) cmdbConnectorUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	connector, httpErr := handler.connectorFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var payload cmdbConnectorUpdatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	target := func(connector *portainer.CMDBConnector) [4]string {
+		return [4]string{string(connector.Type), connector.URL, connector.EndpointTable, connector.ContainerTable}
+	}
+	previousTarget := target(connector)
+
+	if payload.Name != nil {
+		connector.Name = *payload.Name
+	}
+
+	if payload.Type != nil {
+		connector.Type = *payload.Type
+	}
+
+	if payload.URL != nil {
+		connector.URL = *payload.URL
+	}
+
+	if payload.Username != nil {
+		connector.Username = *payload.Username
+	}
+
+	if payload.Password != nil && *payload.Password != "" {
+		connector.Password = *payload.Password
+	}
+
+	if payload.Token != nil && *payload.Token != "" {
+		connector.Token = *payload.Token
+	}
+
+	if payload.Enabled != nil {
+		connector.Enabled = *payload.Enabled
+	}
+
+	if payload.Interval != nil {
+		connector.Interval = *payload.Interval
+	}
+
+	if payload.SyncContainers != nil {
+		connector.SyncContainers = *payload.SyncContainers
+	}
+
+	if payload.EndpointTable != nil {
+		connector.EndpointTable = *payload.EndpointTable
+	}
+
+	if payload.ContainerTable != nil {
+		connector.ContainerTable = *payload.ContainerTable
+	}
+
+	if payload.FieldMappings != nil {
+		connector.FieldMappings = payload.FieldMappings
+	}
+
+	if err := cmdb.ValidateConnector(*connector); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	if target(connector) != previousTarget {
+		connector.State = nil
+	}
+
+	err = handler.DataStore.CMDBConnector().Update(connector.ID, connector)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the CMDB connector changes inside the database", err)
+	}
+
+	hideFields(connector)
+
+	return response.JSON(w, connector)
+}
@@ -0,0 +1,67 @@
+package cmdbconnectors
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_cmdbConnectorUpdate(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	connector := &portainer.CMDBConnector{
+		Name:     "servicenow",
+		Type:     portainer.CMDBConnectorServiceNow,
+		URL:      "https://example.service-now.com",
+		Username: "portainer",
+		Password: "s3cr3t",
+		Interval: "1h",
+		State:    map[string]portainer.CMDBItemState{"endpoint:1": {RemoteID: "1", Hash: "abc"}},
+	}
+	is.NoError(store.CMDBConnector().Create(connector))
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer())
+	h.DataStore = store
+
+	update := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	rr := update("/cmdb_connectors/1", `{"Password":"","Interval":"30m"}`)
+	is.Equal(http.StatusOK, rr.Code)
+
+	var response portainer.CMDBConnector
+	is.NoError(json.NewDecoder(rr.Body).Decode(&response))
+	is.Empty(response.Password, "the password should not be returned")
+	is.Nil(response.State, "the synchronization state should not be returned")
+	is.Equal("30m", response.Interval)
+
+	stored, err := store.CMDBConnector().Read(connector.ID)
+	is.NoError(err)
+	is.Equal("s3cr3t", stored.Password, "an empty password should keep the current one")
+	is.Len(stored.State, 1)
+
+	rr = update("/cmdb_connectors/1", `{"URL":"https://other.service-now.com"}`)
+	is.Equal(http.StatusOK, rr.Code)
+
+	stored, err = store.CMDBConnector().Read(connector.ID)
+	is.NoError(err)
+	is.Empty(stored.State, "the state should be forgotten when the CMDB changes")
+
+	is.Equal(http.StatusBadRequest, update("/cmdb_connectors/1", `{"Interval":"10s"}`).Code)
+	is.Equal(http.StatusNotFound, update("/cmdb_connectors/2", `{}`).Code)
+}
@@ -0,0 +1,62 @@
+package cmdbconnectors
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/cmdb"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+func hideFields(connector *portainer.CMDBConnector) {
+	connector.Password = ""
+	connector.Token = ""
+	connector.State = nil
+}
+
+// Handler is the HTTP handler used to handle CMDB connector operations.
+type Handler struct {
+	*mux.Router
+	DataStore   dataservices.DataStore
+	CMDBService *cmdb.Service
+}
+
+// NewHandler creates a handler to manage CMDB connector operations.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+
+	adminRouter := h.NewRoute().Subrouter()
+	adminRouter.Use(bouncer.AdminAccess)
+
+	adminRouter.Handle("/cmdb_connectors", httperror.LoggerHandler(h.cmdbConnectorCreate)).Methods(http.MethodPost)
+	adminRouter.Handle("/cmdb_connectors", httperror.LoggerHandler(h.cmdbConnectorList)).Methods(http.MethodGet)
+	adminRouter.Handle("/cmdb_connectors/{id}", httperror.LoggerHandler(h.cmdbConnectorInspect)).Methods(http.MethodGet)
+	adminRouter.Handle("/cmdb_connectors/{id}", httperror.LoggerHandler(h.cmdbConnectorUpdate)).Methods(http.MethodPut)
+	adminRouter.Handle("/cmdb_connectors/{id}", httperror.LoggerHandler(h.cmdbConnectorDelete)).Methods(http.MethodDelete)
+	adminRouter.Handle("/cmdb_connectors/{id}/sync", httperror.LoggerHandler(h.cmdbConnectorSync)).Methods(http.MethodPost)
+
+	return h
+}
+
+func (handler *Handler) connectorFromRequest(r *http.Request) (*portainer.CMDBConnector, *httperror.HandlerError) {
+	connectorID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid CMDB connector identifier route variable", err)
+	}
+
+	connector, err := handler.DataStore.CMDBConnector().Read(portainer.CMDBConnectorID(connectorID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a CMDB connector with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a CMDB connector with the specified identifier inside the database", err)
+	}
+
+	return connector, nil
+}
@@ -0,0 +1,40 @@
+package costs
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/portainer/portainer/api/cost"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+)
+
+// @id CostExport
+// @summary Export the costs of a month
+// @description Export the estimated costs of a month as CSV, one line per stack, team or environment, with the columns
+// @description month, group_by, endpoint_id, id, name, cpu_hours, memory_gib_hours and cost.
+// @description **Access policy**: administrator
+// @tags costs
+// @security ApiKeyAuth
+// @security jwt
+// @produce text/csv
+// @param month query string false "Month of the costs, formatted as YYYY-MM, defaults to the current month"
+// @param groupBy query string false "Grouping of the costs" Enums(stack, team, endpoint)
+// @success 200 {file} file "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /costs/export [get]
+func (handler *Handler) costExport(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	summary, httpErr := handler.summaryFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=portainer-costs-%s-%s.csv", summary.Month, summary.GroupBy))
+
+	if err := cost.WriteCSV(w, *summary); err != nil {
+		return httperror.InternalServerError("Unable to write the costs", err)
+	}
+
+	return nil
+}
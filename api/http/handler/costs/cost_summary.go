@@ -0,0 +1,36 @@
+package costs
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id CostSummary
+// @summary Estimate the costs of a month
+// @description Estimate the costs of the stacks during a month, grouped by stack, team or environment. The resources used
+// @description by the stacks are sampled every hour on the Docker environments with costs, set on the environments or
+// @description inherited from the defaults of their group. The cost of a host is split among its stacks according to
+// @description their share of the CPU and memory used on the host, and the cloud prices are charged for the resources
+// @description they use. The cost of the stacks owned by several teams is split evenly among them. The costs are
+// @description expressed in the currency used to set the costs of the environments.
+// @description **Access policy**: administrator
+// @tags costs
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param month query string false "Month of the costs, formatted as YYYY-MM, defaults to the current month"
+// @param groupBy query string false "Grouping of the costs" Enums(stack, team, endpoint)
+// @success 200 {object} cost.Summary "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /costs [get]
+func (handler *Handler) costSummary(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	summary, httpErr := handler.summaryFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, summary)
+}
@@ -0,0 +1,61 @@
+package costs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/cost"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_costSummary(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.Team().Create(&portainer.Team{ID: 1, Name: "payments"}))
+	is.NoError(store.CostReport().Create(&portainer.CostReport{
+		ID:    202410,
+		Month: "2024-10",
+		Entries: []portainer.CostEntry{
+			{EndpointID: 1, StackID: 1, StackName: "web", TeamIDs: []portainer.TeamID{1}, Hours: 720, Cost: 42.123},
+		},
+	}))
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer())
+	h.DataStore = store
+
+	get := func(url string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, url, nil))
+
+		return rr
+	}
+
+	rr := get("/costs?month=2024-10&groupBy=team")
+	is.Equal(http.StatusOK, rr.Code)
+
+	var summary cost.Summary
+	is.NoError(json.NewDecoder(rr.Body).Decode(&summary))
+	is.Equal(42.12, summary.Total)
+	is.Equal([]cost.Item{{ID: 1, Name: "payments", Cost: 42.12}}, summary.Items)
+
+	rr = get("/costs/export?month=2024-10")
+	is.Equal(http.StatusOK, rr.Code)
+	is.Equal("attachment; filename=portainer-costs-2024-10-stack.csv", rr.Header().Get("Content-Disposition"))
+	is.Contains(rr.Body.String(), "2024-10,stack,1,1,web,0.00,0.00,42.12\n")
+
+	rr = get("/costs?month=2024-09")
+	is.Equal(http.StatusOK, rr.Code)
+	is.NoError(json.NewDecoder(rr.Body).Decode(&summary))
+	is.Empty(summary.Items)
+
+	is.Equal(http.StatusBadRequest, get("/costs?month=october").Code)
+	is.Equal(http.StatusBadRequest, get("/costs?groupBy=user").Code)
+}
@@ -0,0 +1,93 @@
+package costs
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/cost"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to handle cost operations.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+}
+
+// NewHandler creates a handler to estimate the costs of the stacks.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+
+	adminRouter := h.NewRoute().Subrouter()
+	adminRouter.Use(bouncer.AdminAccess)
+
+	adminRouter.Handle("/costs", httperror.LoggerHandler(h.costSummary)).Methods(http.MethodGet)
+	adminRouter.Handle("/costs/export", httperror.LoggerHandler(h.costExport)).Methods(http.MethodGet)
+
+	return h
+}
+
+// summaryFromRequest summarizes the costs of the month of the request, the current month by default
+func (handler *Handler) summaryFromRequest(r *http.Request) (*cost.Summary, *httperror.HandlerError) {
+	month, _ := request.RetrieveQueryParameter(r, "month", true)
+	if month == "" {
+		month = time.Now().Format("2006-01")
+	}
+
+	reportID, err := cost.ParseMonth(month)
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid month query parameter", err)
+	}
+
+	groupBy, _ := request.RetrieveQueryParameter(r, "groupBy", true)
+	switch groupBy {
+	case "":
+		groupBy = cost.GroupByStack
+	case cost.GroupByStack, cost.GroupByTeam, cost.GroupByEndpoint:
+	default:
+		return nil, httperror.BadRequest("Invalid groupBy query parameter", errors.New("stack, team or endpoint is expected"))
+	}
+
+	report, err := handler.DataStore.CostReport().Read(reportID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		report = &portainer.CostReport{Month: month}
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve the cost report from the database", err)
+	}
+
+	names := cost.Names{
+		Teams:     make(map[portainer.TeamID]string),
+		Endpoints: make(map[portainer.EndpointID]string),
+	}
+
+	teams, err := handler.DataStore.Team().ReadAll()
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve the teams from the database", err)
+	}
+
+	for _, team := range teams {
+		names.Teams[team.ID] = team.Name
+	}
+
+	endpoints, err := handler.DataStore.Endpoint().Endpoints()
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve the environments from the database", err)
+	}
+
+	for _, endpoint := range endpoints {
+		names.Endpoints[endpoint.ID] = endpoint.Name
+	}
+
+	summary := cost.Summarize(report, groupBy, names)
+
+	return &summary, nil
+}
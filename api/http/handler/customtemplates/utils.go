@@ -1,19 +1,10 @@
 package customtemplates
 
 import (
-	"errors"
-
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/customtemplateutils"
 )
 
 func validateVariablesDefinitions(variables []portainer.CustomTemplateVariableDefinition) error {
-	for _, variable := range variables {
-		if variable.Name == "" {
-			return errors.New("variable name is required")
-		}
-		if variable.Label == "" {
-			return errors.New("variable label is required")
-		}
-	}
-	return nil
+	return customtemplateutils.ValidateVariableDefinitions(variables)
 }
@@ -0,0 +1,122 @@
+package docker
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// @id dockerApplicationList
+// @summary List the applications of an environment
+// @description Group the containers and the Swarm services of an environment by the value of a label, such as the
+// @description Compose project or a custom application label, and summarize the state and the health of each group.
+// @description The non administrators only see the containers and the services they have access to.
+// @description **Access policy**: restricted
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param label query string false "Label grouping the containers and the services, com.docker.compose.project by default"
+// @success 200 {object} docker.Applications "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment not found"
+// @failure 500 "Internal server error"
+// @router /docker/{environmentId}/applications [get]
+func (handler *Handler) applicationList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	err = handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint)
+	if err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	label, _ := request.RetrieveQueryParameter(r, "label", true)
+	if label == "" {
+		label = docker.DefaultApplicationLabel
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	cli, err := handler.dockerClientFactory.CreateClient(endpoint, r.Header.Get(portainer.PortainerAgentTargetHeader), nil)
+	if err != nil {
+		return httperror.InternalServerError("Unable to connect to the Docker daemon", err)
+	}
+	defer cli.Close()
+
+	containers, services, err := docker.ListApplicationResources(r.Context(), cli, label)
+	if err != nil {
+		return httperror.InternalServerError("Unable to list the resources of the environment", err)
+	}
+
+	if !securityContext.IsAdmin {
+		resourceControls, err := handler.dataStore.ResourceControl().ReadAll()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the resource controls from the database", err)
+		}
+
+		access := resourceAccess{
+			endpointID:       endpoint.ID,
+			userID:           securityContext.UserID,
+			resourceControls: resourceControls,
+		}
+		for _, membership := range securityContext.UserMemberships {
+			access.teamIDs = append(access.teamIDs, membership.TeamID)
+		}
+
+		containers, services = access.filterContainers(containers), access.filterServices(services)
+	}
+
+	return response.JSON(w, docker.GroupApplications(label, containers, services))
+}
+
+// resourceAccess filters the resources a non administrator can access, as the Docker proxy does for the resource lists
+type resourceAccess struct {
+	endpointID       portainer.EndpointID
+	userID           portainer.UserID
+	teamIDs          []portainer.TeamID
+	resourceControls []portainer.ResourceControl
+}
+
+func (access resourceAccess) filterContainers(containers []types.Container) []types.Container {
+	filtered := []types.Container{}
+	for _, container := range containers {
+		if access.canAccess(container.ID, portainer.ContainerResourceControl, container.Labels) {
+			filtered = append(filtered, container)
+		}
+	}
+
+	return filtered
+}
+
+func (access resourceAccess) filterServices(services []swarm.Service) []swarm.Service {
+	filtered := []swarm.Service{}
+	for _, service := range services {
+		if access.canAccess(service.ID, portainer.ServiceResourceControl, service.Spec.Labels) {
+			filtered = append(filtered, service)
+		}
+	}
+
+	return filtered
+}
+
+func (access resourceAccess) canAccess(resourceID string, resourceType portainer.ResourceControlType, labels map[string]string) bool {
+	return authorization.UserCanAccessDockerResource(access.userID, access.teamIDs, access.endpointID, resourceID, resourceType, labels, access.resourceControls)
+}
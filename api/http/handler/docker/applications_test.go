@@ -0,0 +1,48 @@
+package docker
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceAccess(t *testing.T) {
+	is := assert.New(t)
+
+	access := resourceAccess{
+		endpointID: 1,
+		userID:     2,
+		teamIDs:    []portainer.TeamID{3},
+		resourceControls: []portainer.ResourceControl{
+			{ResourceID: "c1", Type: portainer.ContainerResourceControl, UserAccesses: []portainer.UserResourceAccess{{UserID: 2}}},
+			{ResourceID: stackutils.ResourceControlID(1, "shop"), Type: portainer.StackResourceControl, TeamAccesses: []portainer.TeamResourceAccess{{TeamID: 3}}},
+			{ResourceID: stackutils.ResourceControlID(1, "admin"), Type: portainer.StackResourceControl, AdministratorsOnly: true},
+			{ResourceID: "s1", Type: portainer.ServiceResourceControl, Public: true},
+		},
+	}
+
+	containers := access.filterContainers([]types.Container{
+		{ID: "c1"},
+		{ID: "c2", Labels: map[string]string{"com.docker.compose.project": "shop"}},
+		{ID: "c3", Labels: map[string]string{"com.docker.compose.project": "admin"}},
+		{ID: "c4", Labels: map[string]string{"com.docker.swarm.service.id": "s1"}},
+		{ID: "c5"},
+	})
+
+	ids := []string{}
+	for _, container := range containers {
+		ids = append(ids, container.ID)
+	}
+	is.Equal([]string{"c1", "c2", "c4"}, ids)
+
+	services := access.filterServices([]swarm.Service{
+		{ID: "s1"},
+		{ID: "s2", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Labels: map[string]string{"com.docker.stack.namespace": "admin"}}}},
+	})
+	is.Len(services, 1)
+}
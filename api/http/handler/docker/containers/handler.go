@@ -34,6 +34,8 @@ func NewHandler(routePrefix string, bouncer security.BouncerService, dataStore d
 
 	router.Handle("/{containerId}/gpus", httperror.LoggerHandler(h.containerGpusInspect)).Methods(http.MethodGet)
 	router.Handle("/{containerId}/recreate", httperror.LoggerHandler(h.recreate)).Methods(http.MethodPost)
+	router.Handle("/{containerId}/labels", httperror.LoggerHandler(h.labelsUpdate)).Methods(http.MethodPut)
+	router.Handle("/{containerId}/migrate", httperror.LoggerHandler(h.migrate)).Methods(http.MethodPost)
 
 	return h
 }
@@ -0,0 +1,186 @@
+package containers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/docker/docker/api/types"
+)
+
+type labelsUpdatePayload struct {
+	// Labels set on the container, replacing the labels with the same name
+	Labels map[string]string `json:"Labels"`
+	// Names of the labels removed from the container
+	RemoveLabels []string `json:"RemoveLabels" example:"com.example.version"`
+}
+
+func (payload *labelsUpdatePayload) Validate(r *http.Request) error {
+	if len(payload.Labels) == 0 && len(payload.RemoveLabels) == 0 {
+		return errors.New("at least one label must be set or removed")
+	}
+
+	for name := range payload.Labels {
+		if name == "" {
+			return errors.New("invalid label, a name is required")
+		}
+
+		if slices.Contains(payload.RemoveLabels, name) {
+			return fmt.Errorf("invalid label %q, it cannot be both set and removed", name)
+		}
+	}
+
+	if slices.Contains(payload.RemoveLabels, "") {
+		return errors.New("invalid removed label, a name is required")
+	}
+
+	return nil
+}
+
+// changesOwnership returns true when the payload sets or removes one of the ownership labels of the container
+func (payload *labelsUpdatePayload) changesOwnership() bool {
+	for name := range payload.Labels {
+		if authorization.IsAccessControlLabel(name) {
+			return true
+		}
+	}
+
+	return slices.ContainsFunc(payload.RemoveLabels, authorization.IsAccessControlLabel)
+}
+
+// @id ContainerLabelsUpdate
+// @summary Update the labels of a container
+// @description Docker does not allow the labels of a container to be changed, the container is recreated with the new labels,
+// @description keeping the rest of its configuration, its networks and its volumes. The new container is returned.
+// @description When the ownership labels (io.portainer.accesscontrol.public, io.portainer.accesscontrol.users and io.portainer.accesscontrol.teams)
+// @description are changed, the resource control of the container is replaced by the one described by its new labels, and removed when no ownership label remains.
+// @description **Access policy**: authenticated, the ownership labels being restricted to the administrators and the environment administrators
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param containerId path string true "Container identifier"
+// @param body body labelsUpdatePayload true "Changes applied to the labels of the container"
+// @success 200 {object} types.ContainerJSON "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment or container not found"
+// @failure 500 "Server error"
+// @router /docker/{environmentId}/containers/{containerId}/labels [put]
+func (handler *Handler) labelsUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	containerID, err := request.RetrieveRouteVariableValue(r, "containerId")
+	if err != nil {
+		return httperror.BadRequest("Invalid containerId", err)
+	}
+
+	var payload labelsUpdatePayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	if httpErr := handler.checkRecreateAccess(r, endpoint); httpErr != nil {
+		return httpErr
+	}
+
+	agentTargetHeader := r.Header.Get(portainer.PortainerAgentTargetHeader)
+
+	cli, err := handler.dockerClientFactory.CreateClient(endpoint, agentTargetHeader, nil)
+	if err != nil {
+		return httperror.InternalServerError("Unable to connect to the Docker daemon", err)
+	}
+	defer cli.Close()
+
+	container, err := cli.ContainerInspect(r.Context(), containerID)
+	if err != nil {
+		return httperror.NotFound("Unable to find the container", err)
+	}
+
+	if httpErr := handler.checkLabelsUpdateAccess(r, endpoint, &container, payload.changesOwnership()); httpErr != nil {
+		return httpErr
+	}
+
+	patch := docker.ContainerPatch{Labels: payload.Labels, RemoveLabels: payload.RemoveLabels}
+
+	newContainer, err := handler.containerService.Recreate(r.Context(), endpoint, container.ID, false, patch, agentTargetHeader)
+	if err != nil {
+		return httperror.InternalServerError("Error recreating container", err)
+	}
+
+	handler.containerRecreated(container.ID, newContainer)
+
+	if payload.changesOwnership() {
+		err = handler.containerService.SyncResourceControlWithLabels(newContainer.ID, newContainer.Config.Labels)
+		if err != nil {
+			return httperror.InternalServerError("The container was recreated but its resource control could not be updated from its labels", err)
+		}
+	}
+
+	return response.JSON(w, newContainer)
+}
+
+// checkLabelsUpdateAccess ensures that the user can access the container, and that the changes of its ownership labels
+// are made by an administrator of the environment
+func (handler *Handler) checkLabelsUpdateAccess(r *http.Request, endpoint *portainer.Endpoint, container *types.ContainerJSON, changesOwnership bool) *httperror.HandlerError {
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	if securityContext.IsAdmin {
+		return nil
+	}
+
+	user, err := handler.dataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to find the user in the database", err)
+	}
+
+	isEndpointAdmin, err := stackutils.UserIsAdminOrEndpointAdmin(user, endpoint.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify the permissions of the user", err)
+	}
+
+	if isEndpointAdmin {
+		return nil
+	}
+
+	if changesOwnership {
+		return httperror.Forbidden("Permission denied to change the ownership labels of the container", errors.New("the user is not an administrator of the environment"))
+	}
+
+	resourceControls, err := handler.dataStore.ResourceControl().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the resource controls from the database", err)
+	}
+
+	teamIDs := make([]portainer.TeamID, 0, len(securityContext.UserMemberships))
+	for _, membership := range securityContext.UserMemberships {
+		teamIDs = append(teamIDs, membership.TeamID)
+	}
+
+	if !authorization.UserCanAccessDockerResource(user.ID, teamIDs, endpoint.ID, container.ID, portainer.ContainerResourceControl, container.Config.Labels, resourceControls) {
+		return httperror.Forbidden("Permission denied to access the container", httperrors.ErrResourceAccessDenied)
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package containers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelsUpdatePayload(t *testing.T) {
+	is := assert.New(t)
+
+	for _, payload := range []labelsUpdatePayload{
+		{},
+		{Labels: map[string]string{"": "prod"}},
+		{RemoveLabels: []string{""}},
+		{Labels: map[string]string{"env": "prod"}, RemoveLabels: []string{"env"}},
+	} {
+		is.Error(payload.Validate(nil))
+	}
+
+	payload := labelsUpdatePayload{Labels: map[string]string{"env": "prod"}, RemoveLabels: []string{"tier"}}
+	is.NoError(payload.Validate(nil))
+	is.False(payload.changesOwnership())
+
+	payload = labelsUpdatePayload{RemoveLabels: []string{"io.portainer.accesscontrol.public"}}
+	is.True(payload.changesOwnership())
+
+	payload = labelsUpdatePayload{Labels: map[string]string{"io.portainer.accesscontrol.users": "alice"}}
+	is.True(payload.changesOwnership())
+}
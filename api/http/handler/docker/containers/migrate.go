@@ -0,0 +1,164 @@
+package containers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// containerNamePattern matches the names of the Docker containers and volumes
+var containerNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+type MigratePayload struct {
+	// Identifier of the environment the container is migrated to
+	TargetEndpointID portainer.EndpointID `json:"TargetEndpointId" validate:"required" example:"2"`
+
+	docker.ContainerMigration
+}
+
+func (payload *MigratePayload) Validate(r *http.Request) error {
+	if payload.TargetEndpointID == 0 {
+		return errors.New("invalid target environment identifier")
+	}
+
+	if payload.Name != "" && !containerNamePattern.MatchString(payload.Name) {
+		return fmt.Errorf("invalid container name %q", payload.Name)
+	}
+
+	for _, name := range payload.Volumes {
+		if !containerNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid volume name %q", name)
+		}
+	}
+
+	switch payload.ImageTransfer {
+	case "":
+		payload.ImageTransfer = docker.MigrationImageRegistry
+	case docker.MigrationImageRegistry, docker.MigrationImageStream:
+	default:
+		return fmt.Errorf("invalid image transfer %q, registry or stream is expected", payload.ImageTransfer)
+	}
+
+	return nil
+}
+
+// @id ContainerMigrate
+// @summary Migrate a container to another environment
+// @description Recreate a container on another Docker environment with the same image, configuration and networks, and copy the contents of the selected named volumes.
+// @description The image is pulled from its registry by the target environment, or exported from the source environment and streamed through the server.
+// @description The volumes are streamed through the server and must not exist on the target environment, the missing user-defined networks are created.
+// @description The bind mounts are kept as is, their source paths must exist on the target environment.
+// @description The new container is started when the migrated container was running, and is removed when the migration fails.
+// @description **Access policy**: administrator or environment administrator of both environments
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Environment identifier"
+// @param containerId path string true "Container identifier"
+// @param body body MigratePayload true "Migration details"
+// @success 200 {object} types.ContainerJSON "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/docker/containers/{containerId}/migrate [post]
+func (handler *Handler) migrate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	containerID, err := request.RetrieveRouteVariableValue(r, "containerId")
+	if err != nil {
+		return httperror.BadRequest("Invalid containerId", err)
+	}
+
+	var payload MigratePayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	source, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	if payload.TargetEndpointID == source.ID {
+		return httperror.BadRequest("The container can only be migrated to another environment", errors.New("identical source and target environments"))
+	}
+
+	target, err := handler.dataStore.Endpoint().Endpoint(payload.TargetEndpointID)
+	if handler.dataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find the target environment inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find the target environment inside the database", err)
+	}
+
+	if !endpointutils.IsDockerEndpoint(target) {
+		return httperror.BadRequest("The container can only be migrated to a Docker environment", errors.New("target environment is not a docker environment"))
+	}
+
+	for _, endpoint := range []*portainer.Endpoint{source, target} {
+		if httpErr := handler.checkMigrationAccess(r, endpoint); httpErr != nil {
+			return httpErr
+		}
+	}
+
+	if payload.SourceNodeName == "" {
+		payload.SourceNodeName = r.Header.Get(portainer.PortainerAgentTargetHeader)
+	}
+
+	newContainer, err := handler.containerService.Migrate(r.Context(), source, target, containerID, payload.ContainerMigration)
+	if err != nil {
+		return httperror.InternalServerError("Unable to migrate the container", err)
+	}
+
+	return response.JSON(w, newContainer)
+}
+
+// checkMigrationAccess ensures that the user administers the environment, that it is not read-only and that its
+// changes do not require an approval
+func (handler *Handler) checkMigrationAccess(r *http.Request, endpoint *portainer.Endpoint) *httperror.HandlerError {
+	if err := handler.bouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return httperror.Forbidden("Permission denied to access the environment", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	user, err := handler.dataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to find the user in the database", err)
+	}
+
+	isAdmin, err := stackutils.UserIsAdminOrEndpointAdmin(user, endpoint.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify the permissions of the user", err)
+	}
+
+	if !isAdmin {
+		return httperror.Forbidden("Permission denied to migrate containers", errors.New("the user is not an administrator of the environment"))
+	}
+
+	readOnly, err := endpointutils.IsReadOnly(handler.dataStore, endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to resolve the settings of the environment", err)
+	}
+
+	if readOnly {
+		return httperror.Forbidden("The environment is read-only", endpointutils.ErrReadOnlyEndpoint)
+	}
+
+	return nil
+}
@@ -1,28 +1,85 @@
 package containers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker"
 	"github.com/portainer/portainer/api/docker/consts"
 	"github.com/portainer/portainer/api/docker/images"
 	"github.com/portainer/portainer/api/http/middlewares"
-	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/stacks/stackutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
-	"github.com/rs/zerolog/log"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
 )
 
+var imageTagPattern = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+
 type RecreatePayload struct {
 	// PullImage if true will pull the image
 	PullImage bool `json:"PullImage"`
+
+	docker.ContainerPatch
 }
 
 func (r RecreatePayload) Validate(request *http.Request) error {
+	if r.ImageTag != "" && !imageTagPattern.MatchString(r.ImageTag) {
+		return fmt.Errorf("invalid image tag %q", r.ImageTag)
+	}
+
+	for name := range r.Env {
+		if name == "" {
+			return errors.New("invalid environment variable, a name is required")
+		}
+	}
+
+	for _, m := range r.Mounts {
+		if m.Target == "" {
+			return errors.New("invalid mount, a target is required")
+		}
+
+		switch m.Type {
+		case mount.TypeBind, mount.TypeVolume, mount.TypeTmpfs:
+		default:
+			return fmt.Errorf("invalid mount type %q for %s, bind, volume or tmpfs is expected", m.Type, m.Target)
+		}
+
+		if m.Type == mount.TypeBind && m.Source == "" {
+			return fmt.Errorf("invalid bind mount for %s, a source is required", m.Target)
+		}
+	}
+
 	return nil
 }
 
+// @id ContainerRecreate
+// @summary Recreate a container
+// @description Recreate a container with the same networks and volumes, applying changes to its image tag, environment variables, labels and mounts.
+// @description The anonymous volumes of the container are kept. The new container is returned.
+// @description **Access policy**: authenticated
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param containerId path string true "Container identifier"
+// @param body body RecreatePayload true "Changes applied to the container"
+// @success 200 {object} types.ContainerJSON "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment not found"
+// @failure 500 "Server error"
+// @router /docker/{environmentId}/containers/{containerId}/recreate [post]
 func (handler *Handler) recreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
 	containerID, err := request.RetrieveRouteVariableValue(r, "containerId")
 	if err != nil {
@@ -40,58 +97,97 @@ func (handler *Handler) recreate(w http.ResponseWriter, r *http.Request) *httper
 		return httperror.NotFound("Unable to find an environment on request context", err)
 	}
 
-	err = handler.bouncer.AuthorizedEndpointOperation(r, endpoint)
-	if err != nil {
-		return httperror.Forbidden("Permission denied to force update service", err)
+	if httpErr := handler.checkRecreateAccess(r, endpoint); httpErr != nil {
+		return httpErr
+	}
+
+	if httpErr := handler.checkRecreateMounts(r, endpoint, payload.Mounts); httpErr != nil {
+		return httpErr
 	}
 
 	agentTargetHeader := r.Header.Get(portainer.PortainerAgentTargetHeader)
 
-	newContainer, err := handler.containerService.Recreate(r.Context(), endpoint, containerID, payload.PullImage, "", agentTargetHeader)
+	newContainer, err := handler.containerService.Recreate(r.Context(), endpoint, containerID, payload.PullImage, payload.ContainerPatch, agentTargetHeader)
 	if err != nil {
 		return httperror.InternalServerError("Error recreating container", err)
 	}
 
-	handler.updateWebhook(containerID, newContainer.ID)
-	handler.createResourceControl(containerID, newContainer.ID)
+	handler.containerRecreated(containerID, newContainer)
+
+	return response.JSON(w, newContainer)
+}
+
+// checkRecreateAccess ensures that the user can operate the environment, that it is not read-only and that its changes
+// do not require an approval
+func (handler *Handler) checkRecreateAccess(r *http.Request, endpoint *portainer.Endpoint) *httperror.HandlerError {
+	err := handler.bouncer.AuthorizedEndpointOperation(r, endpoint)
+	if err != nil {
+		return httperror.Forbidden("Permission denied to force update service", err)
+	}
+
+	readOnly, err := endpointutils.IsReadOnly(handler.dataStore, endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to resolve the settings of the environment", err)
+	}
+
+	if readOnly {
+		return httperror.Forbidden("The environment is read-only", endpointutils.ErrReadOnlyEndpoint)
+	}
+
+	return nil
+}
+
+// containerRecreated moves the references of the recreated container to the new container
+func (handler *Handler) containerRecreated(containerID string, newContainer *types.ContainerJSON) {
+	handler.containerService.UpdateContainerReferences(containerID, newContainer.ID)
 
 	go func() {
 		images.EvictImageStatus(containerID)
 		images.EvictImageStatus(newContainer.Config.Labels[consts.ComposeStackNameLabel])
 		images.EvictImageStatus(newContainer.Config.Labels[consts.SwarmServiceIdLabel])
 	}()
-	return response.JSON(w, newContainer)
 }
 
-func (handler *Handler) createResourceControl(oldContainerId string, newContainerId string) {
-	resourceControls, err := handler.dataStore.ResourceControl().ReadAll()
+// checkRecreateMounts rejects the bind mounts added by the regular users when the environment forbids them
+func (handler *Handler) checkRecreateMounts(r *http.Request, endpoint *portainer.Endpoint, mounts []mount.Mount) *httperror.HandlerError {
+	hasBindMount := false
+	for _, m := range mounts {
+		if m.Type == mount.TypeBind {
+			hasBindMount = true
+		}
+	}
+
+	if !hasBindMount {
+		return nil
+	}
+
+	securitySettings, err := endpointutils.EffectiveSecuritySettings(handler.dataStore, endpoint)
 	if err != nil {
-		log.Error().Err(err).Msg("Exporting Resource Controls")
-		return
+		return httperror.InternalServerError("Unable to resolve the security settings of the environment", err)
 	}
 
-	resourceControl := authorization.GetResourceControlByResourceIDAndType(oldContainerId, portainer.ContainerResourceControl, resourceControls)
-	if resourceControl == nil {
-		return
+	if securitySettings.AllowBindMountsForRegularUsers {
+		return nil
 	}
-	resourceControl.ResourceID = newContainerId
-	err = handler.dataStore.ResourceControl().Create(resourceControl)
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
 	if err != nil {
-		log.Error().Err(err).Str("containerId", newContainerId).Msg("Failed to create new resource control for container")
-		return
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
 	}
-}
 
-func (handler *Handler) updateWebhook(oldContainerId string, newContainerId string) {
-	webhook, err := handler.dataStore.Webhook().WebhookByResourceID(oldContainerId)
+	user, err := handler.dataStore.User().Read(securityContext.UserID)
 	if err != nil {
-		log.Error().Err(err).Str("containerId", oldContainerId).Msg("cannot find webhook by containerId")
-		return
+		return httperror.InternalServerError("Unable to find the user in the database", err)
 	}
 
-	webhook.ResourceID = newContainerId
-	err = handler.dataStore.Webhook().Update(webhook.ID, webhook)
+	isAdmin, err := stackutils.UserIsAdminOrEndpointAdmin(user, endpoint.ID)
 	if err != nil {
-		log.Error().Err(err).Int("webhookId", int(webhook.ID)).Msg("cannot update webhook")
+		return httperror.InternalServerError("Unable to verify the permissions of the user", err)
+	}
+
+	if !isAdmin {
+		return httperror.Forbidden("Permission denied to use bind mounts", errors.New("bind-mount disabled for non administrator users"))
 	}
+
+	return nil
 }
@@ -8,10 +8,12 @@ import (
 	"github.com/portainer/portainer/api/docker"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
 	"github.com/portainer/portainer/api/http/handler/docker/containers"
+	"github.com/portainer/portainer/api/http/handler/docker/services"
 	"github.com/portainer/portainer/api/http/middlewares"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/jobs"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -28,7 +30,7 @@ type Handler struct {
 }
 
 // NewHandler creates a handler to process non-proxied requests to docker APIs directly.
-func NewHandler(bouncer security.BouncerService, authorizationService *authorization.Service, dataStore dataservices.DataStore, dockerClientFactory *dockerclient.ClientFactory, containerService *docker.ContainerService) *Handler {
+func NewHandler(bouncer security.BouncerService, authorizationService *authorization.Service, dataStore dataservices.DataStore, dockerClientFactory *dockerclient.ClientFactory, containerService *docker.ContainerService, jobQueue *jobs.Queue) *Handler {
 	h := &Handler{
 		Router:               mux.NewRouter(),
 		requestBouncer:       bouncer,
@@ -45,6 +47,15 @@ func NewHandler(bouncer security.BouncerService, authorizationService *authoriza
 
 	containersHandler := containers.NewHandler("/{id}/containers", bouncer, dataStore, dockerClientFactory, containerService)
 	endpointRouter.PathPrefix("/containers").Handler(containersHandler)
+	servicesHandler := services.NewHandler("/{id}/services", bouncer, dataStore, dockerClientFactory, jobQueue)
+	endpointRouter.PathPrefix("/services").Handler(servicesHandler)
+	endpointRouter.Handle("/applications",
+		bouncer.RestrictedAccess(httperror.LoggerHandler(h.applicationList))).Methods(http.MethodGet)
+	endpointRouter.Handle("/restart_policies",
+		bouncer.RestrictedAccess(httperror.LoggerHandler(h.restartPolicyList))).Methods(http.MethodGet)
+	endpointRouter.Handle("/restart_policies",
+		bouncer.RestrictedAccess(httperror.LoggerHandler(h.restartPolicyUpdate))).Methods(http.MethodPut)
+
 	return h
 }
 
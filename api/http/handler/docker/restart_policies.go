@@ -0,0 +1,268 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker"
+	"github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+type restartPolicyUpdatePayload struct {
+	// Identifiers of the containers to update
+	Containers []string `example:"3a8b3e1c5f2d"`
+	// Identifiers of the Swarm services to update
+	Services []string `example:"9mnpnzenvg8p"`
+	// Restart policy, one of no, always, unless-stopped or on-failure
+	Policy string `validate:"required" example:"unless-stopped"`
+	// Maximum number of restarts with the on-failure policy, 0 when unlimited
+	MaximumRetryCount int `example:"0"`
+}
+
+func (payload *restartPolicyUpdatePayload) Validate(r *http.Request) error {
+	if len(payload.Containers) == 0 && len(payload.Services) == 0 {
+		return errors.New("at least one container or service is required")
+	}
+
+	switch payload.Policy {
+	case "no", "always", "unless-stopped", "on-failure":
+	default:
+		return fmt.Errorf("invalid restart policy %q, no, always, unless-stopped or on-failure is expected", payload.Policy)
+	}
+
+	if payload.MaximumRetryCount < 0 {
+		return errors.New("invalid maximum retry count")
+	}
+
+	if payload.MaximumRetryCount > 0 && payload.Policy != "on-failure" {
+		return errors.New("a maximum retry count can only be set with the on-failure policy")
+	}
+
+	return nil
+}
+
+type restartPolicyUpdateResult struct {
+	// Identifier of the container or the service
+	ID string `json:"Id" example:"3a8b3e1c5f2d"`
+	// Identifier of the recreated container
+	NewID string `json:"NewId,omitempty" example:"9e41a7d3b2f0"`
+	// Whether the container or the service already had the restart policy
+	Unchanged bool `json:"Unchanged" example:"false"`
+	// Reason why the restart policy could not be changed
+	Error string `json:"Error,omitempty" example:"the container is a task of a Swarm service"`
+}
+
+type restartPolicyUpdateResponse struct {
+	Containers []restartPolicyUpdateResult `json:"Containers"`
+	Services   []restartPolicyUpdateResult `json:"Services"`
+}
+
+// @id dockerRestartPolicyList
+// @summary Report the restart policies of an environment
+// @description List the restart policies of the containers, except the tasks of the Swarm services, and the restart
+// @description conditions of the Swarm services of an environment. The containers and the services which are not started
+// @description again after a reboot of the host are counted, and the stacks mixing restart policies are reported.
+// @description The non administrators only see the containers and the services they have access to.
+// @description **Access policy**: restricted
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @success 200 {object} docker.RestartPolicies "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment not found"
+// @failure 500 "Internal server error"
+// @router /docker/{environmentId}/restart_policies [get]
+func (handler *Handler) restartPolicyList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	err = handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint)
+	if err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	cli, err := handler.dockerClientFactory.CreateClient(endpoint, r.Header.Get(portainer.PortainerAgentTargetHeader), nil)
+	if err != nil {
+		return httperror.InternalServerError("Unable to connect to the Docker daemon", err)
+	}
+	defer cli.Close()
+
+	containers, services, err := docker.ListRestartPolicyResources(r.Context(), cli)
+	if err != nil {
+		return httperror.InternalServerError("Unable to list the resources of the environment", err)
+	}
+
+	if !securityContext.IsAdmin {
+		resourceControls, err := handler.dataStore.ResourceControl().ReadAll()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the resource controls from the database", err)
+		}
+
+		access := resourceAccess{
+			endpointID:       endpoint.ID,
+			userID:           securityContext.UserID,
+			resourceControls: resourceControls,
+		}
+		for _, membership := range securityContext.UserMemberships {
+			access.teamIDs = append(access.teamIDs, membership.TeamID)
+		}
+
+		containers, services = access.filterContainers(containers), access.filterServices(services)
+	}
+
+	policies, err := docker.InspectRestartPolicies(r.Context(), cli, containers, services)
+	if err != nil {
+		return httperror.InternalServerError("Unable to inspect the restart policies", err)
+	}
+
+	return response.JSON(w, policies)
+}
+
+// @id dockerRestartPolicyUpdate
+// @summary Change the restart policies of containers and services
+// @description Change the restart policy of containers, which are recreated with the same configuration, and the restart
+// @description condition of Swarm services, whose tasks are restarted by the update. The containers and the services which
+// @description already have the policy are left as they are. The policies are mapped to the restart conditions of the services:
+// @description no to none, always and unless-stopped to any, and on-failure to on-failure. The outcome of each change is
+// @description returned, the other changes being made when one fails.
+// @description **Access policy**: environment administrator
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param body body restartPolicyUpdatePayload true "Restart policy and resources to update"
+// @success 200 {object} restartPolicyUpdateResponse "Success"
+// @failure 400 "Bad request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment not found"
+// @failure 500 "Internal server error"
+// @router /docker/{environmentId}/restart_policies [put]
+func (handler *Handler) restartPolicyUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload restartPolicyUpdatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	if httpErr := handler.checkRestartPolicyUpdateAccess(r, endpoint); httpErr != nil {
+		return httpErr
+	}
+
+	nodeName := r.Header.Get(portainer.PortainerAgentTargetHeader)
+
+	cli, err := handler.dockerClientFactory.CreateClient(endpoint, nodeName, nil)
+	if err != nil {
+		return httperror.InternalServerError("Unable to connect to the Docker daemon", err)
+	}
+	defer cli.Close()
+
+	policy := container.RestartPolicy{Name: payload.Policy, MaximumRetryCount: payload.MaximumRetryCount}
+	result := restartPolicyUpdateResponse{
+		Containers: []restartPolicyUpdateResult{},
+		Services:   []restartPolicyUpdateResult{},
+	}
+
+	for _, containerID := range payload.Containers {
+		containerResult := restartPolicyUpdateResult{ID: containerID}
+
+		inspected, err := cli.ContainerInspect(r.Context(), containerID)
+		switch {
+		case err != nil:
+			containerResult.Error = err.Error()
+		case inspected.Config.Labels[consts.SwarmServiceIdLabel] != "":
+			containerResult.Error = "the container is a task of a Swarm service, the restart condition of the service applies"
+		case inspected.HostConfig.RestartPolicy == policy || (inspected.HostConfig.RestartPolicy.Name == "" && policy.Name == "no"):
+			containerResult.Unchanged = true
+		default:
+			newContainer, err := handler.containerService.Recreate(r.Context(), endpoint, containerID, false, docker.ContainerPatch{RestartPolicy: &policy}, nodeName)
+			if err != nil {
+				containerResult.Error = err.Error()
+				break
+			}
+
+			handler.containerService.UpdateContainerReferences(containerID, newContainer.ID)
+			containerResult.NewID = newContainer.ID
+		}
+
+		result.Containers = append(result.Containers, containerResult)
+	}
+
+	for _, serviceID := range payload.Services {
+		serviceResult := restartPolicyUpdateResult{ID: serviceID}
+
+		updated, err := docker.UpdateServiceRestartPolicy(r.Context(), cli, serviceID, policy)
+		if err != nil {
+			serviceResult.Error = err.Error()
+		}
+		serviceResult.Unchanged = err == nil && !updated
+
+		result.Services = append(result.Services, serviceResult)
+	}
+
+	return response.JSON(w, result)
+}
+
+// checkRestartPolicyUpdateAccess ensures that the user administers the environment, that it is not read-only and that
+// its changes do not require an approval
+func (handler *Handler) checkRestartPolicyUpdateAccess(r *http.Request, endpoint *portainer.Endpoint) *httperror.HandlerError {
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return httperror.Forbidden("Permission denied to access the environment", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	user, err := handler.dataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to find the user in the database", err)
+	}
+
+	isAdmin, err := stackutils.UserIsAdminOrEndpointAdmin(user, endpoint.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify the permissions of the user", err)
+	}
+
+	if !isAdmin {
+		return httperror.Forbidden("Permission denied to change the restart policies", errors.New("the user is not an administrator of the environment"))
+	}
+
+	readOnly, err := endpointutils.IsReadOnly(handler.dataStore, endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to resolve the settings of the environment", err)
+	}
+
+	if readOnly {
+		return httperror.Forbidden("The environment is read-only", endpointutils.ErrReadOnlyEndpoint)
+	}
+
+	return nil
+}
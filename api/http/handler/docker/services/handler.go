@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/docker/rollout"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/jobs"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/gorilla/mux"
+)
+
+type Handler struct {
+	*mux.Router
+	dockerClientFactory *dockerclient.ClientFactory
+	dataStore           dataservices.DataStore
+	jobQueue            *jobs.Queue
+	bouncer             security.BouncerService
+}
+
+// NewHandler creates a handler to process non-proxied requests to the Swarm services.
+func NewHandler(routePrefix string, bouncer security.BouncerService, dataStore dataservices.DataStore, dockerClientFactory *dockerclient.ClientFactory, jobQueue *jobs.Queue) *Handler {
+	h := &Handler{
+		Router:              mux.NewRouter(),
+		dataStore:           dataStore,
+		dockerClientFactory: dockerClientFactory,
+		jobQueue:            jobQueue,
+		bouncer:             bouncer,
+	}
+
+	router := h.PathPrefix(routePrefix).Subrouter()
+	router.Use(bouncer.AuthenticatedAccess)
+
+	router.Handle("/{serviceId}/bluegreen", httperror.LoggerHandler(h.blueGreen)).Methods(http.MethodPost)
+	router.Handle("/{serviceId}/canary", httperror.LoggerHandler(h.canary)).Methods(http.MethodPost)
+
+	return h
+}
+
+// rolloutService returns the environment and the service of a rollout request, once the user is checked to administer
+// the environment and the service to be rolled out
+func (handler *Handler) rolloutService(r *http.Request) (*portainer.Endpoint, *swarm.Service, *httperror.HandlerError) {
+	serviceID, err := request.RetrieveRouteVariableValue(r, "serviceId")
+	if err != nil {
+		return nil, nil, httperror.BadRequest("Invalid serviceId", err)
+	}
+
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return nil, nil, httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	if httpErr := handler.checkRolloutAccess(r, endpoint); httpErr != nil {
+		return nil, nil, httpErr
+	}
+
+	cli, err := handler.dockerClientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to connect to the Docker environment", err)
+	}
+	defer cli.Close()
+
+	service, _, err := cli.ServiceInspectWithRaw(r.Context(), serviceID, types.ServiceInspectOptions{})
+	if client.IsErrNotFound(err) {
+		return nil, nil, httperror.NotFound("Unable to find the service", err)
+	} else if err != nil {
+		return nil, nil, httperror.InternalServerError("Unable to inspect the service", err)
+	}
+
+	if err := rollout.CheckService(service); err != nil {
+		return nil, nil, httperror.BadRequest("The service cannot be rolled out", err)
+	}
+
+	return endpoint, &service, nil
+}
+
+// checkRolloutAccess ensures that the user administers the environment, that it is not read-only and that its changes
+// do not require an approval
+func (handler *Handler) checkRolloutAccess(r *http.Request, endpoint *portainer.Endpoint) *httperror.HandlerError {
+	if err := handler.bouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return httperror.Forbidden("Permission denied to access the environment", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	user, err := handler.dataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to find the user in the database", err)
+	}
+
+	isAdmin, err := stackutils.UserIsAdminOrEndpointAdmin(user, endpoint.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify the permissions of the user", err)
+	}
+
+	if !isAdmin {
+		return httperror.Forbidden("Permission denied to roll out services", errors.New("the user is not an administrator of the environment"))
+	}
+
+	if endpointutils.IsEdgeEndpoint(endpoint) {
+		return httperror.BadRequest("The services can only be rolled out on the Docker environments reachable by Portainer", errors.New("unsupported environment"))
+	}
+
+	readOnly, err := endpointutils.IsReadOnly(handler.dataStore, endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to resolve the settings of the environment", err)
+	}
+
+	if readOnly {
+		return httperror.Forbidden("The environment is read-only", endpointutils.ErrReadOnlyEndpoint)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/portainer/portainer/api/docker/rollout"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type blueGreenPayload struct {
+	// Image run by the new service
+	Image string `validate:"required" example:"nginx:1.25"`
+	// Labels moved from the current service to the new one along with the published ports, such as the labels
+	// routing the requests of a reverse proxy to the service
+	RoutingLabels []string `example:"traefik.enable"`
+	// Whether the current service is scaled down to 0 instead of being removed, to be able to roll back
+	KeepPrevious bool `example:"false"`
+	// Time in seconds waited for the new service to be healthy, defaults to 120
+	HealthTimeout int `example:"120"`
+}
+
+func (payload *blueGreenPayload) Validate(r *http.Request) error {
+	if payload.Image == "" {
+		return errors.New("invalid image")
+	}
+
+	if payload.HealthTimeout < 0 {
+		return errors.New("invalid health timeout")
+	}
+
+	return nil
+}
+
+type canaryPayload struct {
+	// Image run by the canary, the image of the current canary when empty
+	Image string `example:"nginx:1.25"`
+	// Share of the replicas run by the canary: 0 removes the canary and 100 promotes its image to the service
+	Percentage int `example:"25"`
+	// Time in seconds waited for the replicas to be healthy, defaults to 120
+	HealthTimeout int `example:"120"`
+}
+
+func (payload *canaryPayload) Validate(r *http.Request) error {
+	if payload.Percentage < 0 || payload.Percentage > 100 {
+		return errors.New("invalid percentage, a value between 0 and 100 is expected")
+	}
+
+	if payload.HealthTimeout < 0 {
+		return errors.New("invalid health timeout")
+	}
+
+	return nil
+}
+
+// @id ServiceBlueGreen
+// @summary Run a blue/green deployment of a Swarm service
+// @description Queue a background job deploying a new service running the image next to the current one, named after the
+// @description service with the next color (-green or -blue). Once the tasks of the new service are running and healthy,
+// @description the published ports and the routing labels are moved from the current service to the new one, and the current
+// @description service is removed or scaled down to 0. The new service is removed when it fails to be healthy, the current
+// @description one being left as it was. The services of the stacks cannot be rolled out.
+// @description **Access policy**: environment administrator
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param serviceId path string true "Service identifier"
+// @param body body blueGreenPayload true "Deployment details"
+// @success 200 {object} portainer.BackgroundJob "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment or service not found"
+// @failure 500 "Server error"
+// @router /docker/{environmentId}/services/{serviceId}/bluegreen [post]
+func (handler *Handler) blueGreen(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload blueGreenPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	endpoint, service, httpErr := handler.rolloutService(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	jobPayload := rollout.BlueGreenJobPayload{
+		EndpointID: endpoint.ID,
+		BlueGreenOptions: rollout.BlueGreenOptions{
+			ServiceID:     service.ID,
+			Image:         payload.Image,
+			RoutingLabels: payload.RoutingLabels,
+			KeepPrevious:  payload.KeepPrevious,
+			HealthTimeout: payload.HealthTimeout,
+		},
+	}
+
+	return handler.enqueue(w, r, rollout.BlueGreenJobType, jobPayload, fmt.Sprintf("Blue/green deployment of the image %s to the service %s", payload.Image, service.Spec.Name))
+}
+
+// @id ServiceCanary
+// @summary Share the replicas of a Swarm service with a canary
+// @description Queue a background job running the percentage of the replicas of the service on a canary service running another
+// @description image, named after the service with the -canary suffix. The total number of replicas is kept, the replicas of the
+// @description canary being rounded up. The canary copies the service and is reachable under the name of the service on its
+// @description networks, but it does not publish its ports: the share of the requests follows the share of the replicas for the
+// @description clients and the reverse proxies balancing over the tasks. The replicas are added and healthy before the others are
+// @description removed. A percentage of 0 removes the canary and 100 updates the service to the image of the canary.
+// @description **Access policy**: environment administrator
+// @tags docker
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param environmentId path int true "Environment identifier"
+// @param serviceId path string true "Service identifier"
+// @param body body canaryPayload true "Canary details"
+// @success 200 {object} portainer.BackgroundJob "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment or service not found"
+// @failure 500 "Server error"
+// @router /docker/{environmentId}/services/{serviceId}/canary [post]
+func (handler *Handler) canary(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload canaryPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	endpoint, service, httpErr := handler.rolloutService(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	jobPayload := rollout.CanaryJobPayload{
+		EndpointID: endpoint.ID,
+		CanaryOptions: rollout.CanaryOptions{
+			ServiceID:     service.ID,
+			Image:         payload.Image,
+			Percentage:    payload.Percentage,
+			HealthTimeout: payload.HealthTimeout,
+		},
+	}
+
+	return handler.enqueue(w, r, rollout.CanaryJobType, jobPayload, fmt.Sprintf("Canary at %d%% of the service %s", payload.Percentage, service.Spec.Name))
+}
+
+func (handler *Handler) enqueue(w http.ResponseWriter, r *http.Request, jobType string, payload any, description string) *httperror.HandlerError {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	job, err := handler.jobQueue.EnqueueOnce(jobType, payload, tokenData.ID, description)
+	if err != nil {
+		return httperror.InternalServerError("Unable to queue the rollout of the service", err)
+	}
+
+	return response.JSON(w, job)
+}
@@ -6,6 +6,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/client"
+	"github.com/portainer/portainer/api/timeouts"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/response"
 )
@@ -38,7 +39,7 @@ func (handler *Handler) edgeTemplateList(w http.ResponseWriter, r *http.Request)
 	}
 
 	var templateData []byte
-	templateData, err = client.Get(url, 10)
+	templateData, err = client.Get(url, timeouts.Current().TemplateFetch)
 	if err != nil {
 		return httperror.InternalServerError("Unable to retrieve external templates", err)
 	}
@@ -6,6 +6,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/endpointutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -22,6 +23,8 @@ type endpointGroupCreatePayload struct {
 	AssociatedEndpoints []portainer.EndpointID `example:"1,3"`
 	// List of tag identifiers to which this environment(endpoint) group is associated
 	TagIDs []portainer.TagID `example:"1,2"`
+	// Settings inherited by the environments(endpoints) of the group unless they override them
+	Defaults *portainer.EndpointGroupDefaults
 }
 
 func (payload *endpointGroupCreatePayload) Validate(r *http.Request) error {
@@ -80,9 +83,15 @@ func (handler *Handler) createEndpointGroup(tx dataservices.DataStoreTx, payload
 		UserAccessPolicies: portainer.UserAccessPolicies{},
 		TeamAccessPolicies: portainer.TeamAccessPolicies{},
 		TagIDs:             payload.TagIDs,
+		Defaults:           payload.Defaults,
 	}
 
-	err := tx.EndpointGroup().Create(endpointGroup)
+	err := endpointutils.ValidateGroupDefaults(tx, endpointGroup.Defaults)
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid environment group defaults", err)
+	}
+
+	err = tx.EndpointGroup().Create(endpointGroup)
 	if err != nil {
 		return nil, httperror.InternalServerError("Unable to persist the environment group inside the database", err)
 	}
@@ -112,6 +121,11 @@ func (handler *Handler) createEndpointGroup(tx dataservices.DataStoreTx, payload
 		}
 	}
 
+	err = endpointutils.GrantGroupRegistryAccess(tx, endpointGroup, payload.AssociatedEndpoints...)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to give the environments access to the registries of the group", err)
+	}
+
 	for _, tagID := range endpointGroup.TagIDs {
 		tag, err := tx.Tag().Read(tagID)
 		if err != nil {
@@ -6,6 +6,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/endpointutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -78,5 +79,10 @@ func (handler *Handler) addEndpoint(tx dataservices.DataStoreTx, endpointGroupID
 		return httperror.InternalServerError("Unable to persist environment relations changes inside the database", err)
 	}
 
+	err = endpointutils.GrantGroupRegistryAccess(tx, endpointGroup, endpoint.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to give the environment access to the registries of the group", err)
+	}
+
 	return nil
 }
@@ -2,9 +2,12 @@ package endpointgroups
 
 import (
 	"net/http"
+	"slices"
 
+	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/security"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
 )
 
@@ -18,6 +21,7 @@ import (
 // @security ApiKeyAuth
 // @security jwt
 // @produce json
+// @param name query string false "Only list the environment(endpoint) group with this exact name"
 // @success 200 {array} portainer.EndpointGroup "Environment(Endpoint) group"
 // @failure 500 "Server error"
 // @router /endpoint_groups [get]
@@ -33,5 +37,12 @@ func (handler *Handler) endpointGroupList(w http.ResponseWriter, r *http.Request
 	}
 
 	endpointGroups = security.FilterEndpointGroups(endpointGroups, securityContext)
+
+	if name, _ := request.RetrieveQueryParameter(r, "name", true); name != "" {
+		endpointGroups = slices.DeleteFunc(endpointGroups, func(endpointGroup portainer.EndpointGroup) bool {
+			return endpointGroup.Name != name
+		})
+	}
+
 	return response.JSON(w, endpointGroups)
 }
@@ -4,10 +4,16 @@ import (
 	"errors"
 	"net/http"
 	"reflect"
+	"strconv"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/cost"
+	"github.com/portainer/portainer/api/database/changes"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/dataservices/endpointgroup"
+	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/internal/tag"
+	"github.com/portainer/portainer/api/lifecycle"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -23,15 +29,22 @@ type endpointGroupUpdatePayload struct {
 	TagIDs             []portainer.TagID `example:"3,4"`
 	UserAccessPolicies portainer.UserAccessPolicies
 	TeamAccessPolicies portainer.TeamAccessPolicies
+	// Settings inherited by the environments(endpoints) of the group unless they override them, replacing the existing ones
+	Defaults *portainer.EndpointGroupDefaults
 }
 
 func (payload *endpointGroupUpdatePayload) Validate(r *http.Request) error {
+	if payload.Defaults != nil {
+		return cost.ValidateSettings(payload.Defaults.Cost)
+	}
+
 	return nil
 }
 
 // @id EndpointGroupUpdate
 // @summary Update an environment(endpoint) group
 // @description Update an environment(endpoint) group.
+// @description The environments of the group are given access to the registries of the defaults.
 // @description **Access policy**: administrator
 // @tags endpoint_groups
 // @security ApiKeyAuth
@@ -58,8 +71,9 @@ func (handler *Handler) endpointGroupUpdate(w http.ResponseWriter, r *http.Reque
 	}
 
 	var endpointGroup *portainer.EndpointGroup
+	var accessChanged bool
 	err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
-		endpointGroup, err = handler.updateEndpointGroup(tx, portainer.EndpointGroupID(endpointGroupID), payload)
+		endpointGroup, accessChanged, err = handler.updateEndpointGroup(tx, portainer.EndpointGroupID(endpointGroupID), payload)
 		return err
 	})
 	if err != nil {
@@ -71,15 +85,19 @@ func (handler *Handler) endpointGroupUpdate(w http.ResponseWriter, r *http.Reque
 		return httperror.InternalServerError("Unexpected error", err)
 	}
 
+	if accessChanged && handler.EventDispatcher != nil {
+		handler.EventDispatcher.Publish(lifecycle.NewAccessChangedEvent(endpointgroup.BucketName, strconv.Itoa(int(endpointGroup.ID)), changes.ActionUpdate))
+	}
+
 	return response.JSON(w, endpointGroup)
 }
 
-func (handler *Handler) updateEndpointGroup(tx dataservices.DataStoreTx, endpointGroupID portainer.EndpointGroupID, payload endpointGroupUpdatePayload) (*portainer.EndpointGroup, error) {
+func (handler *Handler) updateEndpointGroup(tx dataservices.DataStoreTx, endpointGroupID portainer.EndpointGroupID, payload endpointGroupUpdatePayload) (*portainer.EndpointGroup, bool, error) {
 	endpointGroup, err := tx.EndpointGroup().Read(portainer.EndpointGroupID(endpointGroupID))
 	if tx.IsErrObjectNotFound(err) {
-		return nil, httperror.NotFound("Unable to find an environment group with the specified identifier inside the database", err)
+		return nil, false, httperror.NotFound("Unable to find an environment group with the specified identifier inside the database", err)
 	} else if err != nil {
-		return nil, httperror.InternalServerError("Unable to find an environment group with the specified identifier inside the database", err)
+		return nil, false, httperror.InternalServerError("Unable to find an environment group with the specified identifier inside the database", err)
 	}
 
 	if payload.Name != "" {
@@ -104,14 +122,14 @@ func (handler *Handler) updateEndpointGroup(tx dataservices.DataStoreTx, endpoin
 			for tagID := range removeTags {
 				tag, err := tx.Tag().Read(tagID)
 				if err != nil {
-					return nil, httperror.InternalServerError("Unable to find a tag inside the database", err)
+					return nil, false, httperror.InternalServerError("Unable to find a tag inside the database", err)
 				}
 
 				delete(tag.EndpointGroups, endpointGroup.ID)
 
 				err = tx.Tag().Update(tagID, tag)
 				if err != nil {
-					return nil, httperror.InternalServerError("Unable to persist tag changes inside the database", err)
+					return nil, false, httperror.InternalServerError("Unable to persist tag changes inside the database", err)
 				}
 			}
 
@@ -119,19 +137,45 @@ func (handler *Handler) updateEndpointGroup(tx dataservices.DataStoreTx, endpoin
 			for _, tagID := range payload.TagIDs {
 				tag, err := tx.Tag().Read(tagID)
 				if err != nil {
-					return nil, httperror.InternalServerError("Unable to find a tag inside the database", err)
+					return nil, false, httperror.InternalServerError("Unable to find a tag inside the database", err)
 				}
 
 				tag.EndpointGroups[endpointGroup.ID] = true
 
 				err = tx.Tag().Update(tagID, tag)
 				if err != nil {
-					return nil, httperror.InternalServerError("Unable to persist tag changes inside the database", err)
+					return nil, false, httperror.InternalServerError("Unable to persist tag changes inside the database", err)
 				}
 			}
 		}
 	}
 
+	if payload.Defaults != nil {
+		err := endpointutils.ValidateGroupDefaults(tx, payload.Defaults)
+		if err != nil {
+			return nil, false, httperror.BadRequest("Invalid environment group defaults", err)
+		}
+
+		endpointGroup.Defaults = payload.Defaults
+
+		endpoints, err := tx.Endpoint().Endpoints()
+		if err != nil {
+			return nil, false, httperror.InternalServerError("Unable to retrieve environments from the database", err)
+		}
+
+		var endpointIDs []portainer.EndpointID
+		for _, endpoint := range endpoints {
+			if endpoint.GroupID == endpointGroup.ID {
+				endpointIDs = append(endpointIDs, endpoint.ID)
+			}
+		}
+
+		err = endpointutils.GrantGroupRegistryAccess(tx, endpointGroup, endpointIDs...)
+		if err != nil {
+			return nil, false, httperror.InternalServerError("Unable to give the environments access to the registries of the group", err)
+		}
+	}
+
 	updateAuthorizations := false
 	if payload.UserAccessPolicies != nil && !reflect.DeepEqual(payload.UserAccessPolicies, endpointGroup.UserAccessPolicies) {
 		endpointGroup.UserAccessPolicies = payload.UserAccessPolicies
@@ -146,7 +190,7 @@ func (handler *Handler) updateEndpointGroup(tx dataservices.DataStoreTx, endpoin
 	if updateAuthorizations {
 		endpoints, err := tx.Endpoint().Endpoints()
 		if err != nil {
-			return nil, httperror.InternalServerError("Unable to retrieve environments from the database", err)
+			return nil, false, httperror.InternalServerError("Unable to retrieve environments from the database", err)
 		}
 
 		for _, endpoint := range endpoints {
@@ -169,13 +213,13 @@ func (handler *Handler) updateEndpointGroup(tx dataservices.DataStoreTx, endpoin
 
 	err = tx.EndpointGroup().Update(endpointGroup.ID, endpointGroup)
 	if err != nil {
-		return nil, httperror.InternalServerError("Unable to persist environment group changes inside the database", err)
+		return nil, false, httperror.InternalServerError("Unable to persist environment group changes inside the database", err)
 	}
 
 	if tagsChanged {
 		endpoints, err := tx.Endpoint().Endpoints()
 		if err != nil {
-			return nil, httperror.InternalServerError("Unable to retrieve environments from the database", err)
+			return nil, false, httperror.InternalServerError("Unable to retrieve environments from the database", err)
 
 		}
 
@@ -183,11 +227,11 @@ func (handler *Handler) updateEndpointGroup(tx dataservices.DataStoreTx, endpoin
 			if endpoint.GroupID == endpointGroup.ID {
 				err = handler.updateEndpointRelations(tx, &endpoint, endpointGroup)
 				if err != nil {
-					return nil, httperror.InternalServerError("Unable to persist environment relations changes inside the database", err)
+					return nil, false, httperror.InternalServerError("Unable to persist environment relations changes inside the database", err)
 				}
 			}
 		}
 	}
 
-	return endpointGroup, nil
+	return endpointGroup, updateAuthorizations, nil
 }
@@ -4,8 +4,13 @@ import (
 	"net/http"
 
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/dataservices/endpointgroup"
+	"github.com/portainer/portainer/api/dataservices/teammembership"
+	"github.com/portainer/portainer/api/dataservices/user"
+	"github.com/portainer/portainer/api/http/middlewares"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/lifecycle"
 	"github.com/portainer/portainer/api/pendingactions"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
@@ -18,6 +23,7 @@ type Handler struct {
 	AuthorizationService  *authorization.Service
 	DataStore             dataservices.DataStore
 	PendingActionsService *pendingactions.PendingActionsService
+	EventDispatcher       *lifecycle.Dispatcher
 }
 
 // NewHandler creates a handler to manage environment(endpoint) group operations.
@@ -25,10 +31,12 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 	h := &Handler{
 		Router: mux.NewRouter(),
 	}
+	listCache := middlewares.NewResponseCache(middlewares.ResponseCacheTTL)
+
 	h.Handle("/endpoint_groups",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointGroupCreate))).Methods(http.MethodPost)
 	h.Handle("/endpoint_groups",
-		bouncer.RestrictedAccess(httperror.LoggerHandler(h.endpointGroupList))).Methods(http.MethodGet)
+		bouncer.RestrictedAccess(listCache.Handler(httperror.LoggerHandler(h.endpointGroupList), endpointgroup.BucketName, teammembership.BucketName, user.BucketName))).Methods(http.MethodGet)
 	h.Handle("/endpoint_groups/{id}",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointGroupInspect))).Methods(http.MethodGet)
 	h.Handle("/endpoint_groups/{id}",
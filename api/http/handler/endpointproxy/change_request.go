@@ -0,0 +1,88 @@
+package endpointproxy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/lifecycle"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxChangeRequestBodySize is the size of the largest Docker request body that can be submitted for approval
+const maxChangeRequestBodySize = 1 << 20
+
+var errChangeRequestTooLarge = errors.New("the body of the request is too large to be submitted for approval")
+
+// requiresChangeApproval returns true when the request is a change of a non-administrator user on an environment
+// requiring the approval of its changes
+func (handler *Handler) requiresChangeApproval(r *http.Request, endpoint *portainer.Endpoint) (bool, *httperror.HandlerError) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false, nil
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return false, httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	return endpointutils.RequiresChangeApproval(endpoint, tokenData), nil
+}
+
+// submitChangeRequest records the Docker request as a change request pending approval instead of sending it to the
+// environment. The credentials sent along with the request are not recorded, the request being run on behalf of
+// the requester once approved.
+func (handler *Handler) submitChangeRequest(w http.ResponseWriter, r *http.Request, endpoint *portainer.Endpoint, prefix string) *httperror.HandlerError {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxChangeRequestBodySize+1))
+	if err != nil {
+		return httperror.BadRequest("Unable to read the body of the request", err)
+	}
+
+	if len(body) > maxChangeRequestBodySize {
+		return httperror.NewError(http.StatusRequestEntityTooLarge, "The request is too large to be submitted for approval", errChangeRequestTooLarge)
+	}
+
+	changeRequest := &portainer.ChangeRequest{
+		EndpointID:  endpoint.ID,
+		RequesterID: tokenData.ID,
+		Method:      r.Method,
+		Path:        strings.TrimPrefix(r.URL.EscapedPath(), prefix),
+		Query:       r.URL.RawQuery,
+		ContentType: r.Header.Get("Content-Type"),
+		AgentTarget: r.Header.Get(portainer.PortainerAgentTargetHeader),
+		Body:        body,
+		Status:      portainer.ChangeRequestPending,
+		CreatedAt:   time.Now().Unix(),
+	}
+
+	if err := handler.DataStore.ChangeRequest().Create(changeRequest); err != nil {
+		return httperror.InternalServerError("Unable to persist the change request inside the database", err)
+	}
+
+	if handler.EventDispatcher != nil {
+		handler.EventDispatcher.Publish(lifecycle.NewChangeRequestEvent(lifecycle.ChangeRequested, changeRequest))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+
+	if err := json.NewEncoder(w).Encode(changeRequest); err != nil {
+		log.Warn().Err(err).Msg("unable to write the change request")
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package endpointproxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyRequestsToDockerAPI_ChangeApproval(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "production", Type: portainer.DockerEnvironment, GroupID: 1,
+		ChangeApproval: &portainer.EndpointChangeApproval{Enabled: true}}))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer())
+	handler.DataStore = store
+
+	r := httptest.NewRequest(http.MethodPost, "/1/agent/docker/containers/create?name=web", strings.NewReader(`{"Image":"nginx"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(portainer.PortainerAgentTargetHeader, "node-1")
+	r = r.WithContext(security.StoreTokenData(r, &portainer.TokenData{ID: 2, Username: "developer", Role: portainer.StandardUserRole}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	is.Equal(http.StatusAccepted, w.Code)
+
+	var changeRequest portainer.ChangeRequest
+	is.NoError(json.NewDecoder(w.Body).Decode(&changeRequest))
+
+	stored, err := store.ChangeRequest().Read(changeRequest.ID)
+	if is.NoError(err) {
+		is.Equal(portainer.ChangeRequestPending, stored.Status)
+		is.Equal(portainer.UserID(2), stored.RequesterID)
+		is.Equal("/containers/create", stored.Path)
+		is.Equal("name=web", stored.Query)
+		is.Equal("node-1", stored.AgentTarget)
+		is.Equal(`{"Image":"nginx"}`, string(stored.Body))
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/1/docker/build", strings.NewReader(strings.Repeat("x", maxChangeRequestBodySize+1)))
+	r = r.WithContext(security.StoreTokenData(r, &portainer.TokenData{ID: 2, Username: "developer", Role: portainer.StandardUserRole}))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	is.Equal(http.StatusRequestEntityTooLarge, w.Code)
+}
@@ -1,11 +1,17 @@
 package endpointproxy
 
 import (
+	"errors"
+	"net/http"
+
 	"github.com/gorilla/mux"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/proxy"
+	"github.com/portainer/portainer/api/http/ratelimit"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/lifecycle"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 )
 
@@ -16,6 +22,9 @@ type Handler struct {
 	requestBouncer       security.BouncerService
 	ProxyManager         *proxy.Manager
 	ReverseTunnelService portainer.ReverseTunnelService
+	EventDispatcher      *lifecycle.Dispatcher
+	// ConcurrencyLimiter limits the requests proxied at the same time to each environment, nothing is limited when nil
+	ConcurrencyLimiter *ratelimit.ConcurrencyLimiter
 }
 
 // NewHandler creates a handler to proxy requests to external APIs.
@@ -36,3 +45,49 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.proxyRequestsToKubernetesAPI)))
 	return h
 }
+
+// checkReadOnly rejects the requests that are not reads when the environment is read-only
+func (handler *Handler) checkReadOnly(r *http.Request, endpoint *portainer.Endpoint) *httperror.HandlerError {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	readOnly, err := endpointutils.IsReadOnly(handler.DataStore, endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to resolve the settings of the environment", err)
+	}
+
+	if readOnly {
+		return httperror.Forbidden("The environment is read-only", endpointutils.ErrReadOnlyEndpoint)
+	}
+
+	return nil
+}
+
+// acquireSlot waits for a slot of the environment before proxying the request, the long-lived requests not being
+// limited. The returned function releases the slot.
+func (handler *Handler) acquireSlot(w http.ResponseWriter, r *http.Request, endpoint *portainer.Endpoint) (func(), *httperror.HandlerError) {
+	if handler.ConcurrencyLimiter == nil || ratelimit.IsLongLived(r) {
+		return func() {}, nil
+	}
+
+	release, err := handler.ConcurrencyLimiter.Acquire(r.Context(), endpoint.ID, endpointutils.IsEdgeEndpoint(endpoint))
+	if errors.Is(err, ratelimit.ErrConcurrencyLimitExceeded) {
+		w.Header().Set("Retry-After", "1")
+
+		return nil, &httperror.HandlerError{
+			StatusCode: http.StatusTooManyRequests,
+			Message:    "Too many requests in progress on the environment, retry later",
+			Err:        err,
+		}
+	} else if err != nil {
+		return nil, &httperror.HandlerError{
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    "The request was canceled while waiting for the environment",
+			Err:        err,
+		}
+	}
+
+	return release, nil
+}
@@ -27,6 +27,10 @@ func (handler *Handler) proxyRequestsToAzureAPI(w http.ResponseWriter, r *http.R
 		return httperror.Forbidden("Permission denied to access environment", err)
 	}
 
+	if httpErr := handler.checkReadOnly(r, endpoint); httpErr != nil {
+		return httpErr
+	}
+
 	var proxy http.Handler
 	proxy = handler.ProxyManager.GetEndpointProxy(endpoint)
 	if proxy == nil {
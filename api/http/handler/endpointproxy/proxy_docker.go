@@ -24,11 +24,32 @@ func (handler *Handler) proxyRequestsToDockerAPI(w http.ResponseWriter, r *http.
 		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
 	}
 
-	err = handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint)
+	// the changes requiring an approval are submitted for approval below
+	err = handler.requestBouncer.AuthorizedEndpointProxyOperation(r, endpoint)
 	if err != nil {
 		return httperror.Forbidden("Permission denied to access environment", err)
 	}
 
+	if httpErr := handler.checkReadOnly(r, endpoint); httpErr != nil {
+		return httpErr
+	}
+
+	id := strconv.Itoa(endpointID)
+
+	prefix := "/" + id + "/agent/docker"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		prefix = "/" + id + "/docker"
+	}
+
+	requiresApproval, httpErr := handler.requiresChangeApproval(r, endpoint)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if requiresApproval {
+		return handler.submitChangeRequest(w, r, endpoint, prefix)
+	}
+
 	if endpoint.Type == portainer.EdgeAgentOnDockerEnvironment {
 		if endpoint.EdgeID == "" {
 			return httperror.InternalServerError("No Edge agent registered with the environment", errors.New("No agent available"))
@@ -49,12 +70,11 @@ func (handler *Handler) proxyRequestsToDockerAPI(w http.ResponseWriter, r *http.
 		}
 	}
 
-	id := strconv.Itoa(endpointID)
-
-	prefix := "/" + id + "/agent/docker"
-	if !strings.HasPrefix(r.URL.Path, prefix) {
-		prefix = "/" + id + "/docker"
+	release, httpErr := handler.acquireSlot(w, r, endpoint)
+	if httpErr != nil {
+		return httpErr
 	}
+	defer release()
 
 	http.StripPrefix(prefix, proxy).ServeHTTP(w, r)
 	return nil
@@ -29,6 +29,10 @@ func (handler *Handler) proxyRequestsToKubernetesAPI(w http.ResponseWriter, r *h
 		return httperror.Forbidden("Permission denied to access environment", err)
 	}
 
+	if httpErr := handler.checkReadOnly(r, endpoint); httpErr != nil {
+		return httpErr
+	}
+
 	if endpoint.Type == portainer.EdgeAgentOnKubernetesEnvironment {
 		if endpoint.EdgeID == "" {
 			return httperror.InternalServerError("No Edge agent registered with the environment", errors.New("No agent available"))
@@ -61,6 +65,12 @@ func (handler *Handler) proxyRequestsToKubernetesAPI(w http.ResponseWriter, r *h
 		}
 	}
 
+	release, httpErr := handler.acquireSlot(w, r, endpoint)
+	if httpErr != nil {
+		return httpErr
+	}
+	defer release()
+
 	http.StripPrefix(requestPrefix, proxy).ServeHTTP(w, r)
 	return nil
 }
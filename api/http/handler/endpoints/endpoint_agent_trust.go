@@ -0,0 +1,94 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/agent"
+	agentproxy "github.com/portainer/portainer/api/http/proxy/factory/agent"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type endpointAgentTrustPayload struct {
+	// Pin of the public key the agent is expected to present, in the sha256//<base64 digest> format. The keys
+	// presented by the agents are trusted as is when empty.
+	PublicKeyPin string `example:"sha256//47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="`
+}
+
+func (payload *endpointAgentTrustPayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id EndpointAgentTrust
+// @summary Trusts again the public keys of the agents of an environment(endpoint)
+// @description Replaces the pinned public keys of the agents of an environment(endpoint) by the keys they currently
+// @description present, to be used after the certificates of the agents were legitimately renewed.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param body body endpointAgentTrustPayload true "Expected public key"
+// @success 200 {object} portainer.Endpoint "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment(Endpoint) not found"
+// @failure 409 "The agent presented a different public key than the expected one"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/agent/trust [post]
+func (handler *Handler) endpointAgentTrust(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	var payload endpointAgentTrustPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if endpoint.Type != portainer.AgentOnDockerEnvironment && endpoint.Type != portainer.AgentOnKubernetesEnvironment {
+		return httperror.BadRequest("The public keys are only pinned for the agent environments", errors.New("unsupported environment type"))
+	}
+
+	tlsConfig, err := agentTLSConfig(endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to create the TLS configuration of the agent", err)
+	} else if tlsConfig == nil {
+		return httperror.BadRequest("The agent of the environment is not reached over TLS", errors.New("TLS is disabled"))
+	}
+
+	pins, err := agent.GetPublicKeyPins(agentproxy.ClusterURLs(endpoint), tlsConfig)
+	if err != nil {
+		return httperror.BadRequest("Unable to retrieve the public key of the agent", err)
+	}
+
+	if payload.PublicKeyPin != "" && !slices.Contains(pins, payload.PublicKeyPin) {
+		return httperror.NewError(http.StatusConflict, "The agent presented a different public key than the expected one", errors.New("public key mismatch"))
+	}
+
+	endpoint.AgentPublicKeyPins = pins
+
+	if err := handler.DataStore.Endpoint().UpdateEndpoint(endpoint.ID, endpoint); err != nil {
+		return httperror.InternalServerError("Unable to persist environment changes inside the database", err)
+	}
+
+	// the proxy and the clients of the environment hold the previous pins
+	handler.ProxyManager.DeleteEndpointProxy(endpoint.ID)
+
+	hideFields(endpoint)
+
+	return response.JSON(w, endpoint)
+}
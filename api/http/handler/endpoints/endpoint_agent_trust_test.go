@@ -0,0 +1,82 @@
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/proxy"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointAgentTrust(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	agentServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer agentServer.Close()
+
+	agentEndpoint := &portainer.Endpoint{
+		ID:                 1,
+		Name:               "agent",
+		Type:               portainer.AgentOnDockerEnvironment,
+		URL:                "tcp://" + strings.TrimPrefix(agentServer.URL, "https://"),
+		TLSConfig:          portainer.TLSConfiguration{TLS: true, TLSSkipVerify: true},
+		AgentPublicKeyPins: []string{"sha256//previous"},
+	}
+	is.NoError(store.Endpoint().Create(agentEndpoint))
+
+	dockerEndpoint := &portainer.Endpoint{ID: 2, Name: "docker", Type: portainer.DockerEnvironment, URL: "tcp://127.0.0.1:2375"}
+	is.NoError(store.Endpoint().Create(dockerEndpoint))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	handler.DataStore = store
+	handler.ProxyManager = proxy.NewManager(nil, nil, nil, nil, nil, nil, nil)
+
+	trust := func(endpointID portainer.EndpointID, payload endpointAgentTrustPayload) *httptest.ResponseRecorder {
+		body, err := json.Marshal(payload)
+		is.NoError(err)
+
+		req := httptest.NewRequest(http.MethodPost, "/endpoints/"+strconv.Itoa(int(endpointID))+"/agent/trust", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	pin := crypto.PublicKeyPin(agentServer.Certificate())
+
+	t.Run("unsupported environment", func(t *testing.T) {
+		rec := trust(dockerEndpoint.ID, endpointAgentTrustPayload{})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("unexpected key", func(t *testing.T) {
+		rec := trust(agentEndpoint.ID, endpointAgentTrustPayload{PublicKeyPin: "sha256//other"})
+		assert.Equal(t, http.StatusConflict, rec.Code)
+
+		endpoint, err := store.Endpoint().Endpoint(agentEndpoint.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"sha256//previous"}, endpoint.AgentPublicKeyPins)
+	})
+
+	t.Run("expected key", func(t *testing.T) {
+		rec := trust(agentEndpoint.ID, endpointAgentTrustPayload{PublicKeyPin: pin})
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		endpoint, err := store.Endpoint().Endpoint(agentEndpoint.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{pin}, endpoint.AgentPublicKeyPins)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"regexp"
 	"strings"
@@ -84,6 +85,11 @@ func (handler *Handler) updateEdgeKey(edgeKey string) (string, error) {
 }
 
 func getPort(url string) string {
-	items := strings.Split(url, ":")
-	return items[len(items)-1]
+	_, port, err := net.SplitHostPort(url)
+	if err != nil {
+		items := strings.Split(url, ":")
+		return items[len(items)-1]
+	}
+
+	return port
 }
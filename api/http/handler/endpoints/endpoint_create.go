@@ -12,6 +12,7 @@ import (
 	"github.com/portainer/portainer/api/agent"
 	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
 	"github.com/portainer/portainer/api/http/client"
 	"github.com/portainer/portainer/api/internal/edge"
 	"github.com/portainer/portainer/api/internal/endpointutils"
@@ -20,6 +21,8 @@ import (
 	"github.com/portainer/portainer/pkg/libhttp/response"
 
 	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/ssh"
 )
 
 type endpointCreatePayload struct {
@@ -39,7 +42,11 @@ type endpointCreatePayload struct {
 	AzureTenantID          string
 	AzureAuthenticationKey string
 	TagIDs                 []portainer.TagID
+	Metadata               map[string]string
 	EdgeCheckinInterval    int
+	SSHPrivateKeyFile      []byte
+	SSHHostKey             string
+	SSHSocketPath          string
 }
 
 type endpointCreationEnum int
@@ -51,6 +58,7 @@ const (
 	azureEnvironment
 	edgeAgentEnvironment
 	localKubernetesEnvironment
+	sshEnvironment
 )
 
 func (payload *endpointCreatePayload) Validate(r *http.Request) error {
@@ -62,10 +70,18 @@ func (payload *endpointCreatePayload) Validate(r *http.Request) error {
 
 	endpointCreationType, err := request.RetrieveNumericMultiPartFormValue(r, "EndpointCreationType", false)
 	if err != nil || endpointCreationType == 0 {
-		return errors.New("invalid environment type value. Value must be one of: 1 (Docker environment), 2 (Agent environment), 3 (Azure environment), 4 (Edge Agent environment) or 5 (Local Kubernetes environment)")
+		return errors.New("invalid environment type value. Value must be one of: 1 (Docker environment), 2 (Agent environment), 3 (Azure environment), 4 (Edge Agent environment), 5 (Local Kubernetes environment) or 6 (Docker environment via SSH)")
 	}
 	payload.EndpointCreationType = endpointCreationEnum(endpointCreationType)
 
+	// the Docker hosts are reached via SSH when their URL uses the ssh:// scheme, as they are by the Docker CLI
+	if payload.EndpointCreationType == localDockerEnvironment {
+		endpointURL, _ := request.RetrieveMultiPartFormValue(r, "URL", true)
+		if strings.HasPrefix(endpointURL, "ssh://") {
+			payload.EndpointCreationType = sshEnvironment
+		}
+	}
+
 	groupID, _ := request.RetrieveNumericMultiPartFormValue(r, "GroupID", true)
 	if groupID == 0 {
 		groupID = 1
@@ -82,6 +98,16 @@ func (payload *endpointCreatePayload) Validate(r *http.Request) error {
 		payload.TagIDs = make([]portainer.TagID, 0)
 	}
 
+	err = request.RetrieveMultiPartFormJSONValue(r, "Metadata", &payload.Metadata, true)
+	if err != nil {
+		return errors.New("invalid Metadata parameter")
+	}
+
+	err = validateMetadata(payload.Metadata)
+	if err != nil {
+		return err
+	}
+
 	useTLS, _ := request.RetrieveBooleanMultiPartFormValue(r, "TLS", true)
 	payload.TLS = useTLS
 
@@ -134,6 +160,41 @@ func (payload *endpointCreatePayload) Validate(r *http.Request) error {
 		}
 		payload.AzureAuthenticationKey = azureAuthenticationKey
 
+	case sshEnvironment:
+		endpointURL, err := request.RetrieveMultiPartFormValue(r, "URL", false)
+		if err != nil {
+			return errors.New("invalid environment URL")
+		}
+
+		if _, _, err := dockerclient.ParseSSHURL(endpointURL); err != nil {
+			return err
+		}
+		payload.URL = endpointURL
+
+		publicURL, _ := request.RetrieveMultiPartFormValue(r, "PublicURL", true)
+		payload.PublicURL = publicURL
+
+		privateKey, _, err := request.RetrieveMultiPartFormFile(r, "SSHPrivateKeyFile")
+		if err != nil {
+			return errors.New("invalid SSH private key file. Ensure that the file is uploaded correctly")
+		}
+
+		if _, err := ssh.ParsePrivateKey(privateKey); err != nil {
+			return errors.New("invalid SSH private key file, the key must be unencrypted")
+		}
+		payload.SSHPrivateKeyFile = privateKey
+
+		hostKey, _ := request.RetrieveMultiPartFormValue(r, "SSHHostKey", true)
+		if hostKey != "" {
+			if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey)); err != nil {
+				return errors.New("invalid SSH host key, the key must be in the authorized_keys format")
+			}
+		}
+		payload.SSHHostKey = hostKey
+
+		socketPath, _ := request.RetrieveMultiPartFormValue(r, "SSHSocketPath", true)
+		payload.SSHSocketPath = socketPath
+
 	case edgeAgentEnvironment:
 		endpointURL, err := request.RetrieveMultiPartFormValue(r, "URL", false)
 		if err != nil || strings.EqualFold("", strings.Trim(endpointURL, " ")) {
@@ -172,6 +233,14 @@ func (payload *endpointCreatePayload) Validate(r *http.Request) error {
 	return nil
 }
 
+// ValidateCreateRequest runs the validation of the environment creation on a multipart request, so that the clients
+// of the API can report an invalid request before sending it
+func ValidateCreateRequest(r *http.Request) error {
+	var payload endpointCreatePayload
+
+	return payload.Validate(r)
+}
+
 // @id EndpointCreate
 // @summary Create a new environment(endpoint)
 // @description  Create a new environment(endpoint) that will be used to manage an environment(endpoint).
@@ -182,8 +251,8 @@ func (payload *endpointCreatePayload) Validate(r *http.Request) error {
 // @accept multipart/form-data
 // @produce json
 // @param Name formData string true "Name that will be used to identify this environment(endpoint) (example: my-environment)"
-// @param EndpointCreationType formData integer true "Environment(Endpoint) type. Value must be one of: 1 (Local Docker environment), 2 (Agent environment), 3 (Azure environment), 4 (Edge agent environment) or 5 (Local Kubernetes Environment)" Enum(1,2,3,4,5)
-// @param URL formData string false "URL or IP address of a Docker host (example: docker.mydomain.tld:2375). Defaults to local if not specified (Linux: /var/run/docker.sock, Windows: //./pipe/docker_engine). Cannot be empty if EndpointCreationType is set to 4 (Edge agent environment)"
+// @param EndpointCreationType formData integer true "Environment(Endpoint) type. Value must be one of: 1 (Local Docker environment), 2 (Agent environment), 3 (Azure environment), 4 (Edge agent environment), 5 (Local Kubernetes Environment) or 6 (Docker environment via SSH)" Enum(1,2,3,4,5,6)
+// @param URL formData string false "URL or IP address of a Docker host (example: docker.mydomain.tld:2375). Defaults to local if not specified (Linux: /var/run/docker.sock, Windows: //./pipe/docker_engine). Cannot be empty if EndpointCreationType is set to 4 (Edge agent environment). Must be formatted as ssh://user@host[:port] if EndpointCreationType is set to 6 (Docker environment via SSH), the Docker environments with such a URL being connected via SSH"
 // @param PublicURL formData string false "URL or IP address where exposed containers will be reachable. Defaults to URL if not specified (example: docker.mydomain.tld:2375)"
 // @param GroupID formData int false "Environment(Endpoint) group identifier. If not specified will default to 1 (unassigned)."
 // @param TLS formData bool false "Require TLS to connect against this environment(endpoint). Must be true if EndpointCreationType is set to 2 (Agent environment)"
@@ -196,11 +265,18 @@ func (payload *endpointCreatePayload) Validate(r *http.Request) error {
 // @param AzureTenantID formData string false "Azure tenant ID. Required if environment(endpoint) type is set to 3"
 // @param AzureAuthenticationKey formData string false "Azure authentication key. Required if environment(endpoint) type is set to 3"
 // @param TagIds formData []int false "List of tag identifiers to which this environment(endpoint) is associated"
+// @param Metadata formData string false "Key-value metadata of the environment(endpoint) - json stringified object of string values"
 // @param EdgeCheckinInterval formData int false "The check in interval for edge agent (in seconds)"
 // @param EdgeTunnelServerAddress formData string true "URL or IP address that will be used to establish a reverse tunnel"
 // @param Gpus formData string false "List of GPUs - json stringified array of {name, value} structs"
+// @param SSHPrivateKeyFile formData file false "Unencrypted private key used to connect to the SSH server. Required if EndpointCreationType is set to 6 (Docker environment via SSH) or if the URL uses the ssh:// scheme"
+// @param SSHHostKey formData string false "Public key of the SSH server in the authorized_keys format. The key presented by the server on creation is trusted if not specified"
+// @param SSHSocketPath formData string false "Path of the Docker socket on the host reached via SSH. Defaults to /var/run/docker.sock"
+// @param Idempotency-Key header string false "Key identifying the retries of the request, whose response is replayed for 24 hours"
 // @success 200 {object} portainer.Endpoint "Success"
 // @failure 400 "Invalid request"
+// @failure 409 "A request with the same idempotency key is in progress"
+// @failure 422 "The idempotency key was used for a different request"
 // @failure 500 "Server error"
 // @router /endpoints [post]
 func (handler *Handler) endpointCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
@@ -210,33 +286,50 @@ func (handler *Handler) endpointCreate(w http.ResponseWriter, r *http.Request) *
 		return httperror.BadRequest("Invalid request payload", err)
 	}
 
+	endpoint, handlerErr := handler.createEndpointWithRelation(payload)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	hideFields(endpoint)
+
+	return response.JSON(w, endpoint)
+}
+
+// createEndpointWithRelation creates an environment with a unique name and its relation to the Edge stacks
+func (handler *Handler) createEndpointWithRelation(payload *endpointCreatePayload) (*portainer.Endpoint, *httperror.HandlerError) {
 	isUnique, err := handler.isNameUnique(payload.Name, 0)
 	if err != nil {
-		return httperror.InternalServerError("Unable to check if name is unique", err)
+		return nil, httperror.InternalServerError("Unable to check if name is unique", err)
 	}
 
 	if !isUnique {
-		return httperror.NewError(http.StatusConflict, "Name is not unique", nil)
+		return nil, httperror.NewError(http.StatusConflict, "Name is not unique", nil)
 	}
 
 	endpoint, endpointCreationError := handler.createEndpoint(handler.DataStore, payload)
 	if endpointCreationError != nil {
-		return endpointCreationError
+		return nil, endpointCreationError
 	}
 
 	endpointGroup, err := handler.DataStore.EndpointGroup().Read(endpoint.GroupID)
 	if err != nil {
-		return httperror.InternalServerError("Unable to find an environment group inside the database", err)
+		return nil, httperror.InternalServerError("Unable to find an environment group inside the database", err)
+	}
+
+	err = endpointutils.GrantGroupRegistryAccess(handler.DataStore, endpointGroup, endpoint.ID)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to give the environment access to the registries of its group", err)
 	}
 
 	edgeGroups, err := handler.DataStore.EdgeGroup().ReadAll()
 	if err != nil {
-		return httperror.InternalServerError("Unable to retrieve edge groups from the database", err)
+		return nil, httperror.InternalServerError("Unable to retrieve edge groups from the database", err)
 	}
 
 	edgeStacks, err := handler.DataStore.EdgeStack().EdgeStacks()
 	if err != nil {
-		return httperror.InternalServerError("Unable to retrieve edge stacks from the database", err)
+		return nil, httperror.InternalServerError("Unable to retrieve edge stacks from the database", err)
 	}
 
 	relationObject := &portainer.EndpointRelation{
@@ -269,10 +362,10 @@ func (handler *Handler) endpointCreate(w http.ResponseWriter, r *http.Request) *
 
 	err = handler.DataStore.EndpointRelation().Create(relationObject)
 	if err != nil {
-		return httperror.InternalServerError("Unable to persist the relation object inside the database", err)
+		return nil, httperror.InternalServerError("Unable to persist the relation object inside the database", err)
 	}
 
-	return response.JSON(w, endpoint)
+	return endpoint, nil
 }
 
 func (handler *Handler) createEndpoint(tx dataservices.DataStoreTx, payload *endpointCreatePayload) (*portainer.Endpoint, *httperror.HandlerError) {
@@ -286,10 +379,14 @@ func (handler *Handler) createEndpoint(tx dataservices.DataStoreTx, payload *end
 
 	case localKubernetesEnvironment:
 		return handler.createKubernetesEndpoint(tx, payload)
+
+	case sshEnvironment:
+		return handler.createSSHEndpoint(tx, payload)
 	}
 
 	endpointType := portainer.DockerEnvironment
 	var agentVersion string
+	var agentPublicKeyPins []string
 	if payload.EndpointCreationType == agentEnvironment {
 		var tlsConfig *tls.Config
 		if payload.TLS {
@@ -304,6 +401,17 @@ func (handler *Handler) createEndpoint(tx dataservices.DataStoreTx, payload *end
 			return nil, httperror.InternalServerError("Unable to get environment type", err)
 		}
 
+		// the key presented by the agent is trusted on first use, the connections to an agent presenting another
+		// key being refused afterwards
+		if tlsConfig != nil {
+			pin, err := agent.GetPublicKeyPin(payload.URL, tlsConfig)
+			if err != nil {
+				return nil, httperror.InternalServerError("Unable to retrieve the public key of the agent", err)
+			}
+
+			agentPublicKeyPins = []string{pin}
+		}
+
 		agentVersion = version
 		if agentPlatform == portainer.AgentPlatformDocker {
 			endpointType = portainer.AgentOnDockerEnvironment
@@ -314,7 +422,7 @@ func (handler *Handler) createEndpoint(tx dataservices.DataStoreTx, payload *end
 	}
 
 	if payload.TLS {
-		return handler.createTLSSecuredEndpoint(tx, payload, endpointType, agentVersion)
+		return handler.createTLSSecuredEndpoint(tx, payload, endpointType, agentVersion, agentPublicKeyPins)
 	}
 
 	return handler.createUnsecuredEndpoint(tx, payload)
@@ -346,6 +454,7 @@ func (handler *Handler) createAzureEndpoint(tx dataservices.DataStoreTx, payload
 		TeamAccessPolicies: portainer.TeamAccessPolicies{},
 		AzureCredentials:   credentials,
 		TagIDs:             payload.TagIDs,
+		Metadata:           payload.Metadata,
 		Status:             portainer.EndpointStatusUp,
 		Snapshots:          []portainer.DockerSnapshot{},
 		Kubernetes:         portainer.KubernetesDefault(),
@@ -382,6 +491,7 @@ func (handler *Handler) createEdgeAgentEndpoint(tx dataservices.DataStoreTx, pay
 		UserAccessPolicies:  portainer.UserAccessPolicies{},
 		TeamAccessPolicies:  portainer.TeamAccessPolicies{},
 		TagIDs:              payload.TagIDs,
+		Metadata:            payload.Metadata,
 		Status:              portainer.EndpointStatusUp,
 		Snapshots:           []portainer.DockerSnapshot{},
 		EdgeKey:             edgeKey,
@@ -437,6 +547,7 @@ func (handler *Handler) createUnsecuredEndpoint(tx dataservices.DataStoreTx, pay
 		UserAccessPolicies: portainer.UserAccessPolicies{},
 		TeamAccessPolicies: portainer.TeamAccessPolicies{},
 		TagIDs:             payload.TagIDs,
+		Metadata:           payload.Metadata,
 		Status:             portainer.EndpointStatusUp,
 		Snapshots:          []portainer.DockerSnapshot{},
 		Kubernetes:         portainer.KubernetesDefault(),
@@ -450,6 +561,65 @@ func (handler *Handler) createUnsecuredEndpoint(tx dataservices.DataStoreTx, pay
 	return endpoint, nil
 }
 
+// createSSHEndpoint creates an environment connected to the Docker socket of a host via SSH. The host key presented
+// by the SSH server is trusted when none is specified, the following connections must present the same key.
+func (handler *Handler) createSSHEndpoint(tx dataservices.DataStoreTx, payload *endpointCreatePayload) (*portainer.Endpoint, *httperror.HandlerError) {
+	hostKey := payload.SSHHostKey
+	if hostKey == "" {
+		var err error
+		hostKey, err = dockerclient.FetchSSHHostKey(payload.URL)
+		if err != nil {
+			return nil, httperror.InternalServerError("Unable to retrieve the host key of the SSH server", err)
+		}
+
+		log.Info().Str("url", payload.URL).Str("host_key", hostKey).Msg("trusting the host key presented by the SSH server")
+	}
+
+	endpointID := tx.Endpoint().GetNextIdentifier()
+	endpoint := &portainer.Endpoint{
+		ID:        portainer.EndpointID(endpointID),
+		Name:      payload.Name,
+		URL:       payload.URL,
+		Type:      portainer.DockerSSHEnvironment,
+		GroupID:   portainer.EndpointGroupID(payload.GroupID),
+		PublicURL: payload.PublicURL,
+		Gpus:      payload.Gpus,
+		TLSConfig: portainer.TLSConfiguration{
+			TLS: false,
+		},
+		SSHConfig: &portainer.SSHConfiguration{
+			HostKey:    hostKey,
+			SocketPath: payload.SSHSocketPath,
+		},
+		UserAccessPolicies: portainer.UserAccessPolicies{},
+		TeamAccessPolicies: portainer.TeamAccessPolicies{},
+		TagIDs:             payload.TagIDs,
+		Metadata:           payload.Metadata,
+		Status:             portainer.EndpointStatusUp,
+		Snapshots:          []portainer.DockerSnapshot{},
+		Kubernetes:         portainer.KubernetesDefault(),
+	}
+
+	folder := strconv.Itoa(endpointID)
+	privateKeyPath, err := handler.FileService.StoreSSHPrivateKeyFromBytes(folder, payload.SSHPrivateKeyFile)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to persist the SSH private key on disk", err)
+	}
+	endpoint.SSHConfig.PrivateKeyPath = privateKeyPath
+
+	if handlerErr := handler.snapshotAndPersistEndpoint(tx, endpoint); handlerErr != nil {
+		handler.DockerClientFactory.CloseSSHConnection(endpoint.ID)
+
+		if err := handler.FileService.DeleteSSHFiles(folder); err != nil {
+			log.Warn().Err(err).Int("endpoint_id", endpointID).Msg("unable to remove the SSH files of the environment")
+		}
+
+		return nil, handlerErr
+	}
+
+	return endpoint, nil
+}
+
 func (handler *Handler) createKubernetesEndpoint(tx dataservices.DataStoreTx, payload *endpointCreatePayload) (*portainer.Endpoint, *httperror.HandlerError) {
 	if payload.URL == "" {
 		payload.URL = "https://kubernetes.default.svc"
@@ -472,6 +642,7 @@ func (handler *Handler) createKubernetesEndpoint(tx dataservices.DataStoreTx, pa
 		UserAccessPolicies: portainer.UserAccessPolicies{},
 		TeamAccessPolicies: portainer.TeamAccessPolicies{},
 		TagIDs:             payload.TagIDs,
+		Metadata:           payload.Metadata,
 		Status:             portainer.EndpointStatusUp,
 		Snapshots:          []portainer.DockerSnapshot{},
 		Kubernetes:         portainer.KubernetesDefault(),
@@ -485,7 +656,7 @@ func (handler *Handler) createKubernetesEndpoint(tx dataservices.DataStoreTx, pa
 	return endpoint, nil
 }
 
-func (handler *Handler) createTLSSecuredEndpoint(tx dataservices.DataStoreTx, payload *endpointCreatePayload, endpointType portainer.EndpointType, agentVersion string) (*portainer.Endpoint, *httperror.HandlerError) {
+func (handler *Handler) createTLSSecuredEndpoint(tx dataservices.DataStoreTx, payload *endpointCreatePayload, endpointType portainer.EndpointType, agentVersion string, agentPublicKeyPins []string) (*portainer.Endpoint, *httperror.HandlerError) {
 	endpointID := tx.Endpoint().GetNextIdentifier()
 	endpoint := &portainer.Endpoint{
 		ID:        portainer.EndpointID(endpointID),
@@ -502,12 +673,14 @@ func (handler *Handler) createTLSSecuredEndpoint(tx dataservices.DataStoreTx, pa
 		UserAccessPolicies: portainer.UserAccessPolicies{},
 		TeamAccessPolicies: portainer.TeamAccessPolicies{},
 		TagIDs:             payload.TagIDs,
+		Metadata:           payload.Metadata,
 		Status:             portainer.EndpointStatusUp,
 		Snapshots:          []portainer.DockerSnapshot{},
 		Kubernetes:         portainer.KubernetesDefault(),
 	}
 
 	endpoint.Agent.Version = agentVersion
+	endpoint.AgentPublicKeyPins = agentPublicKeyPins
 
 	err := handler.storeTLSFiles(endpoint, payload)
 	if err != nil {
@@ -525,11 +698,7 @@ func (handler *Handler) createTLSSecuredEndpoint(tx dataservices.DataStoreTx, pa
 func (handler *Handler) snapshotAndPersistEndpoint(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint) *httperror.HandlerError {
 	err := handler.SnapshotService.SnapshotEndpoint(endpoint)
 	if err != nil {
-		if (endpoint.Type == portainer.AgentOnDockerEnvironment && strings.Contains(err.Error(), "Invalid request signature")) ||
-			(endpoint.Type == portainer.AgentOnKubernetesEnvironment && strings.Contains(err.Error(), "unknown")) {
-			err = errors.New("agent already paired with another Portainer instance")
-		}
-		return httperror.InternalServerError("Unable to initiate communications with environment", err)
+		return httperror.InternalServerError("Unable to initiate communications with environment", snapshotError(endpoint, err))
 	}
 
 	err = handler.saveEndpointAndUpdateAuthorizations(tx, endpoint)
@@ -552,6 +721,7 @@ func (handler *Handler) saveEndpointAndUpdateAuthorizations(tx dataservices.Data
 		AllowContainerCapabilitiesForRegularUsers: true,
 		AllowDeviceMappingForRegularUsers:         true,
 		AllowStackManagementForRegularUsers:       true,
+		AllowSwarmManagementForRegularUsers:       true,
 	}
 
 	err := tx.Endpoint().Create(endpoint)
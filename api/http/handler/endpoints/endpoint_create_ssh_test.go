@@ -0,0 +1,30 @@
+package endpoints
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointCreate_SSHURL(t *testing.T) {
+	is := assert.New(t)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	is.NoError(form.WriteField("Name", "legacy"))
+	is.NoError(form.WriteField("EndpointCreationType", "1"))
+	is.NoError(form.WriteField("URL", "ssh://admin@10.0.0.10"))
+	is.NoError(form.Close())
+
+	r := httptest.NewRequest(http.MethodPost, "/endpoints", &body)
+	r.Header.Set("Content-Type", form.FormDataContentType())
+
+	var payload endpointCreatePayload
+	err := payload.Validate(r)
+	is.EqualError(err, "invalid SSH private key file. Ensure that the file is uploaded correctly", "the Docker environments with an ssh:// URL require a private key")
+	is.Equal(sshEnvironment, payload.EndpointCreationType)
+}
@@ -0,0 +1,161 @@
+package endpoints
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// dashboardResponse aggregates the latest snapshots of the environments
+type dashboardResponse struct {
+	// Number of environments matching the filters
+	EndpointCount int `json:"EndpointCount" example:"10"`
+	// Number of environments which are up
+	UpEndpointCount int `json:"UpEndpointCount" example:"8"`
+	// Number of environments which are down
+	DownEndpointCount int `json:"DownEndpointCount" example:"2"`
+	// Number of environments running unhealthy containers
+	UnhealthyEndpointCount int `json:"UnhealthyEndpointCount" example:"1"`
+	// Number of environments without snapshot, they are not included in the totals below
+	UnsnapshottedEndpointCount int `json:"UnsnapshottedEndpointCount" example:"0"`
+
+	RunningContainerCount   int `json:"RunningContainerCount" example:"42"`
+	StoppedContainerCount   int `json:"StoppedContainerCount" example:"3"`
+	HealthyContainerCount   int `json:"HealthyContainerCount" example:"30"`
+	UnhealthyContainerCount int `json:"UnhealthyContainerCount" example:"1"`
+	StackCount              int `json:"StackCount" example:"12"`
+	ServiceCount            int `json:"ServiceCount" example:"5"`
+	ImageCount              int `json:"ImageCount" example:"64"`
+	// Total size of the images in bytes
+	ImagesSize  int64 `json:"ImagesSize" example:"2147483648"`
+	VolumeCount int   `json:"VolumeCount" example:"20"`
+	// Number of Docker Swarm and Kubernetes nodes
+	NodeCount int `json:"NodeCount" example:"4"`
+}
+
+// @id EndpointDashboard
+// @summary Aggregate the environments snapshots
+// @description Aggregate the latest snapshots of the environments the user has access to, optionally limited to an
+// @description environment group, to the environments having all the specified tags and to the environments running
+// @description unhealthy containers.
+// @description **Access policy**: restricted
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param groupId query int false "Only aggregate the environments of this group"
+// @param tagIds query []int false "Only aggregate the environments having all these tags"
+// @param unhealthy query bool false "Only aggregate the environments running containers whose health check fails"
+// @success 200 {object} dashboardResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /dashboard [get]
+func (handler *Handler) endpointDashboard(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	groupID, err := request.RetrieveNumericQueryParameter(r, "groupId", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: groupId", err)
+	}
+
+	tagIDs, err := getNumberArrayQueryParameter[portainer.TagID](r, "tagIds")
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: tagIds", err)
+	}
+
+	unhealthy, _ := request.RetrieveBooleanQueryParameter(r, "unhealthy", true)
+
+	endpointGroups, err := handler.DataStore.EndpointGroup().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve environment groups from the database", err)
+	}
+
+	edgeGroups, err := handler.DataStore.EdgeGroup().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve edge groups from the database", err)
+	}
+
+	endpoints, err := handler.DataStore.Endpoint().Endpoints()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve environments from the database", err)
+	}
+
+	snapshots, err := handler.DataStore.Snapshot().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the snapshots from the database", err)
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	query := EnvironmentsQuery{tagIds: tagIDs, unhealthy: unhealthy}
+	if groupID != 0 {
+		query.groupIds = []portainer.EndpointGroupID{portainer.EndpointGroupID(groupID)}
+	}
+
+	filteredEndpoints := security.FilterEndpoints(endpoints, endpointGroups, securityContext)
+
+	filteredEndpoints, _, err = handler.filterEndpointsByQuery(filteredEndpoints, query, endpointGroups, edgeGroups, settings)
+	if err != nil {
+		return httperror.InternalServerError("Unable to filter endpoints", err)
+	}
+
+	return response.JSON(w, aggregateSnapshots(filteredEndpoints, snapshots, settings))
+}
+
+func aggregateSnapshots(endpoints []portainer.Endpoint, snapshots []portainer.Snapshot, settings *portainer.Settings) dashboardResponse {
+	snapshotsByEndpoint := make(map[portainer.EndpointID]*portainer.Snapshot, len(snapshots))
+	for i := range snapshots {
+		snapshotsByEndpoint[snapshots[i].EndpointID] = &snapshots[i]
+	}
+
+	dashboard := dashboardResponse{EndpointCount: len(endpoints)}
+
+	for i := range endpoints {
+		if endpointStatus(&endpoints[i], settings) == portainer.EndpointStatusUp {
+			dashboard.UpEndpointCount++
+		} else {
+			dashboard.DownEndpointCount++
+		}
+
+		snapshot, ok := snapshotsByEndpoint[endpoints[i].ID]
+		if !ok || (snapshot.Docker == nil && snapshot.Kubernetes == nil) {
+			dashboard.UnsnapshottedEndpointCount++
+			continue
+		}
+
+		if docker := snapshot.Docker; docker != nil {
+			dashboard.RunningContainerCount += docker.RunningContainerCount
+			dashboard.StoppedContainerCount += docker.StoppedContainerCount
+			dashboard.HealthyContainerCount += docker.HealthyContainerCount
+			dashboard.UnhealthyContainerCount += docker.UnhealthyContainerCount
+			if docker.UnhealthyContainerCount > 0 {
+				dashboard.UnhealthyEndpointCount++
+			}
+			dashboard.StackCount += docker.StackCount
+			dashboard.ServiceCount += docker.ServiceCount
+			dashboard.ImageCount += docker.ImageCount
+			dashboard.VolumeCount += docker.VolumeCount
+			dashboard.NodeCount += docker.NodeCount
+
+			for _, image := range docker.SnapshotRaw.Images {
+				dashboard.ImagesSize += image.Size
+			}
+		}
+
+		if kubernetes := snapshot.Kubernetes; kubernetes != nil {
+			dashboard.NodeCount += kubernetes.NodeCount
+		}
+	}
+
+	return dashboard
+}
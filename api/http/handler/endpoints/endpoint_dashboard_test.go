@@ -0,0 +1,103 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointDashboard(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	is.NoError(store.EndpointGroup().Create(&portainer.EndpointGroup{ID: 2, Name: "production"}))
+
+	for _, endpoint := range []portainer.Endpoint{
+		{ID: 1, Name: "dev", GroupID: 1, Type: portainer.DockerEnvironment, Status: portainer.EndpointStatusUp, TagIDs: []portainer.TagID{1}},
+		{ID: 2, Name: "prod-1", GroupID: 2, Type: portainer.DockerEnvironment, Status: portainer.EndpointStatusDown},
+		{ID: 3, Name: "prod-2", GroupID: 2, Type: portainer.KubernetesLocalEnvironment, Status: portainer.EndpointStatusUp, TagIDs: []portainer.TagID{1}},
+	} {
+		is.NoError(store.Endpoint().Create(&endpoint))
+	}
+
+	is.NoError(store.Snapshot().Create(&portainer.Snapshot{EndpointID: 1, Docker: &portainer.DockerSnapshot{
+		RunningContainerCount:   3,
+		StoppedContainerCount:   1,
+		UnhealthyContainerCount: 1,
+		StackCount:              2,
+		ImageCount:              2,
+		VolumeCount:             4,
+		SnapshotRaw: portainer.DockerSnapshotRaw{
+			Images: []types.ImageSummary{{Size: 100}, {Size: 50}},
+		},
+	}}))
+	is.NoError(store.Snapshot().Create(&portainer.Snapshot{EndpointID: 3, Kubernetes: &portainer.KubernetesSnapshot{NodeCount: 3}}))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	handler.DataStore = store
+
+	dashboard := func(query string) dashboardResponse {
+		req := httptest.NewRequest(http.MethodGet, "/dashboard"+query, nil)
+		req = req.WithContext(security.StoreRestrictedRequestContext(req, &security.RestrictedRequestContext{UserID: 1, IsAdmin: true}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var response dashboardResponse
+		is.NoError(json.NewDecoder(rr.Body).Decode(&response))
+
+		return response
+	}
+
+	is.Equal(dashboardResponse{
+		EndpointCount:              3,
+		UpEndpointCount:            2,
+		DownEndpointCount:          1,
+		UnhealthyEndpointCount:     1,
+		UnsnapshottedEndpointCount: 1,
+		RunningContainerCount:      3,
+		StoppedContainerCount:      1,
+		UnhealthyContainerCount:    1,
+		StackCount:                 2,
+		ImageCount:                 2,
+		ImagesSize:                 150,
+		VolumeCount:                4,
+		NodeCount:                  3,
+	}, dashboard(""))
+
+	production := dashboard("?groupId=2")
+	is.Equal(2, production.EndpointCount)
+	is.Equal(1, production.DownEndpointCount)
+	is.Zero(production.RunningContainerCount)
+	is.Equal(3, production.NodeCount)
+
+	tagged := dashboard("?tagIds[]=1")
+	is.Equal(2, tagged.EndpointCount)
+	is.Zero(tagged.DownEndpointCount)
+
+	unhealthy := dashboard("?unhealthy=true")
+	is.Equal(1, unhealthy.EndpointCount)
+	is.Equal(1, unhealthy.UnhealthyContainerCount)
+	is.Zero(unhealthy.UnsnapshottedEndpointCount)
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	req = req.WithContext(security.StoreRestrictedRequestContext(req, &security.RestrictedRequestContext{UserID: 2}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var restricted dashboardResponse
+	is.NoError(json.NewDecoder(rr.Body).Decode(&restricted))
+	is.Zero(restricted.EndpointCount, "only the environments the user can access should be aggregated")
+}
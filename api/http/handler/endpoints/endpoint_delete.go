@@ -3,8 +3,6 @@ package endpoints
 import (
 	"errors"
 	"net/http"
-	"slices"
-	"strconv"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
@@ -13,8 +11,6 @@ import (
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
-
-	"github.com/rs/zerolog/log"
 )
 
 // @id EndpointDelete
@@ -59,9 +55,21 @@ func (handler *Handler) endpointDelete(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unexpected error", err)
 	}
 
+	handler.endpointDeleter().DeleteNotes(portainer.EndpointID(endpointID))
+
 	return response.Empty(w)
 }
 
+// endpointDeleter returns the deleter removing the environments along with their relations to the other resources
+func (handler *Handler) endpointDeleter() *endpointutils.EndpointDeleter {
+	return &endpointutils.EndpointDeleter{
+		DataStore:            handler.DataStore,
+		FileService:          handler.FileService,
+		ProxyManager:         handler.ProxyManager,
+		AuthorizationService: handler.AuthorizationService,
+	}
+}
+
 func (handler *Handler) deleteEndpoint(tx dataservices.DataStoreTx, endpointID portainer.EndpointID, deleteCluster bool) error {
 	endpoint, err := tx.Endpoint().Endpoint(portainer.EndpointID(endpointID))
 	if tx.IsErrObjectNotFound(err) {
@@ -70,116 +78,7 @@ func (handler *Handler) deleteEndpoint(tx dataservices.DataStoreTx, endpointID p
 		return httperror.InternalServerError("Unable to read the environment record from the database", err)
 	}
 
-	if endpoint.TLSConfig.TLS {
-		folder := strconv.Itoa(int(endpointID))
-		err = handler.FileService.DeleteTLSFiles(folder)
-		if err != nil {
-			log.Error().Err(err).Msgf("Unable to remove TLS files from disk when deleting endpoint %d", endpointID)
-		}
-	}
-
-	err = tx.Snapshot().Delete(endpointID)
-	if err != nil {
-		log.Warn().Err(err).Msgf("Unable to remove the snapshot from the database")
-	}
-
-	handler.ProxyManager.DeleteEndpointProxy(endpoint.ID)
-
-	if len(endpoint.UserAccessPolicies) > 0 || len(endpoint.TeamAccessPolicies) > 0 {
-		err = handler.AuthorizationService.UpdateUsersAuthorizationsTx(tx)
-		if err != nil {
-			log.Warn().Err(err).Msgf("Unable to update user authorizations")
-		}
-	}
-
-	err = tx.EndpointRelation().DeleteEndpointRelation(endpoint.ID)
-	if err != nil {
-		log.Warn().Err(err).Msgf("Unable to remove environment relation from the database")
-	}
-
-	for _, tagID := range endpoint.TagIDs {
-		var tag *portainer.Tag
-		tag, err = tx.Tag().Read(tagID)
-		if err == nil {
-			delete(tag.Endpoints, endpoint.ID)
-			err = tx.Tag().Update(tagID, tag)
-		}
-
-		if handler.DataStore.IsErrObjectNotFound(err) {
-			log.Warn().Err(err).Msgf("Unable to find tag inside the database")
-		} else if err != nil {
-			log.Warn().Err(err).Msgf("Unable to delete tag relation from the database")
-		}
-	}
-
-	edgeGroups, err := tx.EdgeGroup().ReadAll()
-	if err != nil {
-		log.Warn().Err(err).Msgf("Unable to retrieve edge groups from the database")
-	}
-
-	for _, edgeGroup := range edgeGroups {
-		edgeGroup.Endpoints = slices.DeleteFunc(edgeGroup.Endpoints, func(e portainer.EndpointID) bool {
-			return e == endpoint.ID
-		})
-
-		err = tx.EdgeGroup().Update(edgeGroup.ID, &edgeGroup)
-		if err != nil {
-			log.Warn().Err(err).Msgf("Unable to update edge group")
-		}
-	}
-
-	edgeStacks, err := tx.EdgeStack().EdgeStacks()
-	if err != nil {
-		log.Warn().Err(err).Msgf("Unable to retrieve edge stacks from the database")
-	}
-
-	for idx := range edgeStacks {
-		edgeStack := &edgeStacks[idx]
-		if _, ok := edgeStack.Status[endpoint.ID]; ok {
-			delete(edgeStack.Status, endpoint.ID)
-			err = tx.EdgeStack().UpdateEdgeStack(edgeStack.ID, edgeStack)
-			if err != nil {
-				log.Warn().Err(err).Msgf("Unable to update edge stack")
-			}
-		}
-	}
-
-	registries, err := tx.Registry().ReadAll()
-	if err != nil {
-		log.Warn().Err(err).Msgf("Unable to retrieve registries from the database")
-	}
-
-	for idx := range registries {
-		registry := &registries[idx]
-		if _, ok := registry.RegistryAccesses[endpoint.ID]; ok {
-			delete(registry.RegistryAccesses, endpoint.ID)
-			err = tx.Registry().Update(registry.ID, registry)
-			if err != nil {
-				log.Warn().Err(err).Msgf("Unable to update registry accesses")
-			}
-		}
-	}
-
-	if endpointutils.IsEdgeEndpoint(endpoint) {
-		edgeJobs, err := handler.DataStore.EdgeJob().ReadAll()
-		if err != nil {
-			log.Warn().Err(err).Msgf("Unable to retrieve edge jobs from the database")
-		}
-
-		for idx := range edgeJobs {
-			edgeJob := &edgeJobs[idx]
-			if _, ok := edgeJob.Endpoints[endpoint.ID]; ok {
-				delete(edgeJob.Endpoints, endpoint.ID)
-
-				err = tx.EdgeJob().Update(edgeJob.ID, edgeJob)
-				if err != nil {
-					log.Warn().Err(err).Msgf("Unable to update edge job")
-				}
-			}
-		}
-	}
-
-	err = tx.Endpoint().DeleteEndpoint(portainer.EndpointID(endpointID))
+	err = handler.endpointDeleter().DeleteTx(tx, endpoint)
 	if err != nil {
 		return httperror.InternalServerError("Unable to delete the environment from the database", err)
 	}
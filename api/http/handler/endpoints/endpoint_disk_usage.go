@@ -0,0 +1,58 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id EndpointDiskUsageInspect
+// @summary Inspect the disk usage of an environment(endpoint)
+// @description Retrieve the disk space used by each volume and by the writable layer of each container of a Docker
+// @description environment(endpoint) managed through an agent. The disk usage is collected in the background, at most
+// @description once an hour, after the snapshots of the environment.
+// @description **Access policy**: restricted
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @success 200 {object} portainer.DockerDiskUsage "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied to access environment"
+// @failure 404 "Environment(Endpoint) not found or disk usage not collected yet"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/disk_usage [get]
+func (handler *Handler) endpointDiskUsageInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	err = handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint)
+	if err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	snapshot, err := handler.DataStore.Snapshot().Read(endpoint.ID)
+	if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.InternalServerError("Unable to retrieve the environment snapshot from the database", err)
+	}
+
+	if snapshot == nil || snapshot.DockerDiskUsage == nil {
+		return httperror.NotFound("The disk usage of the environment was not collected yet", errors.New("disk usage not collected"))
+	}
+
+	return response.JSON(w, snapshot.DockerDiskUsage)
+}
@@ -0,0 +1,54 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointDiskUsageInspect(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "agent", Type: portainer.AgentOnDockerEnvironment}
+	is.NoError(store.Endpoint().Create(endpoint))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	handler.DataStore = store
+
+	inspect := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/endpoints/1/disk_usage", nil)
+		req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 1, Role: portainer.AdministratorRole}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	is.Equal(http.StatusNotFound, inspect().Code)
+
+	usage := &portainer.DockerDiskUsage{
+		VolumesSize: 2048,
+		Volumes:     []portainer.DockerVolumeUsage{{Name: "data", Driver: "local", Size: 2048, RefCount: 1}},
+		Containers:  []portainer.DockerContainerUsage{},
+	}
+	is.NoError(store.Snapshot().Create(&portainer.Snapshot{EndpointID: endpoint.ID, Docker: &portainer.DockerSnapshot{}, DockerDiskUsage: usage}))
+
+	rr := inspect()
+	is.Equal(http.StatusOK, rr.Code)
+
+	var result portainer.DockerDiskUsage
+	is.NoError(json.NewDecoder(rr.Body).Decode(&result))
+	is.Equal(*usage, result)
+}
@@ -0,0 +1,68 @@
+package endpoints
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id EndpointDockerOperationList
+// @summary List the audited Docker operations of an environment(endpoint)
+// @description List the mutating Docker operations performed through Portainer on an environment(endpoint), the most
+// @description recent first, with a summary of their payload and the fields changed by the updates.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param operation query string false "Only return the operations of this type, e.g. service.update"
+// @param resourceId query string false "Only return the operations on this Docker resource"
+// @param userId query int false "Only return the operations performed by this user"
+// @param limit query int false "Maximum number of operations returned"
+// @success 200 {array} portainer.DockerOperationAudit "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment(Endpoint) not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/docker_operations [get]
+func (handler *Handler) endpointDockerOperationList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	operation, _ := request.RetrieveQueryParameter(r, "operation", true)
+	resourceID, _ := request.RetrieveQueryParameter(r, "resourceId", true)
+	userID, _ := request.RetrieveNumericQueryParameter(r, "userId", true)
+	limit, _ := request.RetrieveNumericQueryParameter(r, "limit", true)
+
+	if _, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID)); handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	audits, err := handler.DataStore.DockerOperationAudit().AuditsByEndpoint(portainer.EndpointID(endpointID))
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the audited operations from the database", err)
+	}
+
+	filtered := make([]portainer.DockerOperationAudit, 0, len(audits))
+	for _, audit := range audits {
+		if (operation != "" && audit.Operation != operation) ||
+			(resourceID != "" && audit.ResourceID != resourceID) ||
+			(userID != 0 && audit.UserID != portainer.UserID(userID)) {
+			continue
+		}
+
+		filtered = append(filtered, audit)
+		if limit > 0 && len(filtered) == limit {
+			break
+		}
+	}
+
+	return response.JSON(w, filtered)
+}
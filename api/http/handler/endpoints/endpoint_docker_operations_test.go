@@ -0,0 +1,56 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointDockerOperationList(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "env"}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 2, Name: "other"}))
+
+	for _, audit := range []portainer.DockerOperationAudit{
+		{EndpointID: 1, UserID: 1, Operation: "container.create", ResourceID: "abc"},
+		{EndpointID: 1, UserID: 2, Operation: "container.delete", ResourceID: "abc"},
+		{EndpointID: 1, UserID: 2, Operation: "service.update", ResourceID: "svc"},
+		{EndpointID: 2, UserID: 1, Operation: "container.create", ResourceID: "def"},
+	} {
+		is.NoError(store.DockerOperationAudit().Create(&audit))
+	}
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	handler.DataStore = store
+
+	list := func(query string) []portainer.DockerOperationAudit {
+		req := httptest.NewRequest(http.MethodGet, "/endpoints/1/docker_operations"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var audits []portainer.DockerOperationAudit
+		is.NoError(json.NewDecoder(rr.Body).Decode(&audits))
+
+		return audits
+	}
+
+	audits := list("")
+	is.Len(audits, 3)
+	is.Equal("service.update", audits[0].Operation, "the most recent operations must come first")
+
+	is.Len(list("?resourceId=abc"), 2)
+	is.Len(list("?userId=2&operation=container.delete"), 1)
+	is.Len(list("?limit=1"), 1)
+}
@@ -0,0 +1,98 @@
+package endpoints
+
+import (
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id EndpointEffectiveSettingsInspect
+// @summary Inspect the effective settings of an environment(endpoint)
+// @description Retrieve the settings applied to an environment(endpoint) once the defaults of its group are resolved,
+// @description along with the source of each of them: environment, group or global.
+// @description **Access policy**: restricted
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @success 200 {object} endpointutils.EffectiveSettings "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied to access environment"
+// @failure 404 "Environment(Endpoint) not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/effective_settings [get]
+func (handler *Handler) endpointEffectiveSettingsInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	err = handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint)
+	if err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	var effectiveSettings endpointutils.EffectiveSettings
+	err = handler.DataStore.ViewTx(func(tx dataservices.DataStoreTx) error {
+		effectiveSettings, err = resolveEffectiveSettings(tx, endpoint)
+		return err
+	})
+	if err != nil {
+		return httperror.InternalServerError("Unable to resolve the settings of the environment", err)
+	}
+
+	return response.JSON(w, effectiveSettings)
+}
+
+func resolveEffectiveSettings(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint) (endpointutils.EffectiveSettings, error) {
+	group, err := endpointutils.EndpointGroup(tx, endpoint)
+	if err != nil {
+		return endpointutils.EffectiveSettings{}, err
+	}
+
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return endpointutils.EffectiveSettings{}, err
+	}
+
+	snapshotInterval := settings.SnapshotInterval
+	if snapshotInterval == "" {
+		snapshotInterval = portainer.DefaultSnapshotInterval
+	}
+
+	effectiveSettings := endpointutils.ResolveSettings(endpoint, group, snapshotInterval)
+
+	effectiveSettings.SnapshotContent = endpointutils.ResolveSnapshotContent(endpoint, settings.SnapshotContent)
+	effectiveSettings.Sources["SnapshotContent"] = endpointutils.SettingSourceGlobal
+	if endpoint.SnapshotContent != nil {
+		effectiveSettings.Sources["SnapshotContent"] = endpointutils.SettingSourceEnvironment
+	}
+
+	registries, err := tx.Registry().ReadAll()
+	if err != nil {
+		return endpointutils.EffectiveSettings{}, err
+	}
+
+	for _, registry := range registries {
+		if _, ok := registry.RegistryAccesses[endpoint.ID]; ok {
+			effectiveSettings.RegistryIDs = append(effectiveSettings.RegistryIDs, registry.ID)
+		}
+	}
+	slices.Sort(effectiveSettings.RegistryIDs)
+
+	return effectiveSettings, nil
+}
@@ -0,0 +1,60 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointEffectiveSettingsInspect(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	readOnly := true
+	group := &portainer.EndpointGroup{
+		ID:     2,
+		Name:   "production",
+		TagIDs: []portainer.TagID{2},
+		Defaults: &portainer.EndpointGroupDefaults{
+			SnapshotInterval: "15m",
+			RegistryIDs:      []portainer.RegistryID{1},
+			ReadOnly:         &readOnly,
+		},
+	}
+	is.NoError(store.EndpointGroup().Create(group))
+	is.NoError(store.Registry().Create(&portainer.Registry{ID: 1, Name: "registry", RegistryAccesses: portainer.RegistryAccesses{}}))
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "env", GroupID: 2, TagIDs: []portainer.TagID{1}}
+	is.NoError(store.Endpoint().Create(endpoint))
+
+	is.NoError(endpointutils.GrantGroupRegistryAccess(store, group, endpoint.ID))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	handler.DataStore = store
+
+	req := httptest.NewRequest(http.MethodGet, "/endpoints/1/effective_settings", nil)
+	req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 1, Role: portainer.AdministratorRole}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	is.Equal(http.StatusOK, rr.Code)
+
+	var settings endpointutils.EffectiveSettings
+	is.NoError(json.NewDecoder(rr.Body).Decode(&settings))
+	is.Equal([]portainer.TagID{1, 2}, settings.TagIDs)
+	is.Equal("15m", settings.SnapshotInterval)
+	is.Equal([]portainer.RegistryID{1}, settings.RegistryIDs)
+	is.True(settings.ReadOnly)
+	is.Equal(endpointutils.SettingSourceGroup, settings.Sources["ReadOnly"])
+}
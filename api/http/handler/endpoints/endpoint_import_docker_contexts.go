@@ -0,0 +1,148 @@
+package endpoints
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+const (
+	// maxContextImportMemory limits the memory used to parse the uploaded contexts, the larger files are buffered on disk
+	maxContextImportMemory = 32 << 20
+	// dockerContextMetadataKey is the metadata key recording the context an environment was imported from
+	dockerContextMetadataKey = "docker.context"
+)
+
+type dockerContextsImportPayload struct {
+	Contexts []*multipart.FileHeader
+	GroupID  int
+	TagIDs   []portainer.TagID
+}
+
+type dockerContextImportResult struct {
+	// Name of the uploaded file
+	File string `json:"File" example:"remote.dockercontext"`
+	// Name of the context, empty when the archive is invalid
+	ContextName string `json:"ContextName" example:"remote"`
+	// Environment created from the context
+	Endpoint *portainer.Endpoint `json:"Endpoint,omitempty"`
+	// Reason why the environment could not be created
+	Error string `json:"Error,omitempty" example:"Name is not unique"`
+}
+
+func (payload *dockerContextsImportPayload) Validate(r *http.Request) error {
+	if err := r.ParseMultipartForm(maxContextImportMemory); err != nil {
+		return errors.New("invalid multipart form")
+	}
+
+	payload.Contexts = r.MultipartForm.File["Contexts"]
+	if len(payload.Contexts) == 0 {
+		return errors.New("at least one context archive is required")
+	}
+
+	groupID, _ := request.RetrieveNumericMultiPartFormValue(r, "GroupID", true)
+	if groupID == 0 {
+		groupID = 1
+	}
+	payload.GroupID = groupID
+
+	err := request.RetrieveMultiPartFormJSONValue(r, "TagIds", &payload.TagIDs, true)
+	if err != nil {
+		return errors.New("invalid TagIds parameter")
+	}
+	if payload.TagIDs == nil {
+		payload.TagIDs = make([]portainer.TagID, 0)
+	}
+
+	return nil
+}
+
+// @id EndpointImportDockerContexts
+// @summary Create environments(endpoints) from Docker CLI contexts
+// @description Create a Docker environment(endpoint) for each archive produced by "docker context export".
+// @description The environment is named after the context and connects to the host of its Docker endpoint with the TLS material of the archive.
+// @description The ssh:// hosts are not supported. Each context is imported independently, the result reports the environment created or the reason of the failure.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @accept multipart/form-data
+// @produce json
+// @param Contexts formData file true "Archives exported with docker context export, the parameter can be repeated"
+// @param GroupID formData int false "Environment(Endpoint) group identifier of the created environments. If not specified will default to 1 (unassigned)."
+// @param TagIds formData []int false "List of tag identifiers to which the created environments are associated"
+// @success 200 {array} dockerContextImportResult "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /endpoints/import/docker_contexts [post]
+func (handler *Handler) endpointImportDockerContexts(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	payload := &dockerContextsImportPayload{}
+	err := payload.Validate(r)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	results := make([]dockerContextImportResult, 0, len(payload.Contexts))
+	for _, file := range payload.Contexts {
+		results = append(results, handler.importDockerContext(file, payload))
+	}
+
+	return response.JSON(w, results)
+}
+
+func (handler *Handler) importDockerContext(file *multipart.FileHeader, payload *dockerContextsImportPayload) dockerContextImportResult {
+	result := dockerContextImportResult{File: file.Filename}
+
+	dockerContext, err := readDockerContext(file)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.ContextName = dockerContext.Name
+
+	endpointPayload := &endpointCreatePayload{
+		Name:                 dockerContext.Name,
+		URL:                  dockerContext.Host,
+		EndpointCreationType: localDockerEnvironment,
+		GroupID:              payload.GroupID,
+		TagIDs:               payload.TagIDs,
+		Gpus:                 []portainer.Pair{},
+		Metadata:             map[string]string{dockerContextMetadataKey: dockerContext.Name},
+		TLS:                  dockerContext.TLS(),
+		TLSSkipVerify:        dockerContext.SkipTLSVerify,
+		TLSSkipClientVerify:  dockerContext.TLSCert == nil,
+		TLSCACertFile:        dockerContext.TLSCACert,
+		TLSCertFile:          dockerContext.TLSCert,
+		TLSKeyFile:           dockerContext.TLSKey,
+	}
+
+	endpoint, handlerErr := handler.createEndpointWithRelation(endpointPayload)
+	if handlerErr != nil {
+		result.Error = handlerErr.Message
+		if handlerErr.Err != nil {
+			result.Error += ": " + handlerErr.Err.Error()
+		}
+
+		return result
+	}
+
+	result.Endpoint = endpoint
+
+	return result
+}
+
+func readDockerContext(file *multipart.FileHeader) (*docker.DockerContext, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return docker.ParseContextExport(f)
+}
@@ -5,6 +5,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/notes"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -81,6 +82,12 @@ func (handler *Handler) endpointInspect(w http.ResponseWriter, r *http.Request)
 	// Execute endpoint pending actions
 	handler.PendingActionsService.Execute(endpoint.ID)
 
+	endpointNotes, err := handler.DataStore.Note().NotesByEndpoint(endpoint.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the notes of the environment from the database", err)
+	}
+	endpoint.Notes = notes.NewIndex(endpointNotes).Endpoint(endpoint.ID)
+
 	return response.JSON(w, endpoint)
 }
 
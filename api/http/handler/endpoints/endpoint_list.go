@@ -8,6 +8,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/notes"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -45,7 +46,9 @@ const (
 // @param edgeDeviceUntrusted query bool false "if true, show only untrusted edge agents, if false show only trusted edge agents (relevant only for edge agents)"
 // @param edgeCheckInPassedSeconds query number false "if bigger then zero, show only edge agents that checked-in in the last provided seconds (relevant only for edge agents)"
 // @param excludeSnapshots query bool false "if true, the snapshot data won't be retrieved"
+// @param unhealthy query bool false "if true, will return only the Docker environments running containers whose health check fails, according to their latest snapshot"
 // @param name query string false "will return only environments(endpoints) with this name"
+// @param metadata query []string false "will return only environments(endpoints) having all these metadata, each formatted as key or key:value"
 // @param edgeStackId query portainer.EdgeStackID false "will return the environements of the specified edge stack"
 // @param edgeStackStatus query string false "only applied when edgeStackId exists. Filter the returned environments based on their deployment status in the stack (not the environment status!)" Enum("Pending", "Ok", "Error", "Acknowledged", "Remove", "RemoteUpdateSuccess", "ImagesPulled")
 // @success 200 {array} portainer.Endpoint "Endpoints"
@@ -104,7 +107,14 @@ func (handler *Handler) endpointList(w http.ResponseWriter, r *http.Request) *ht
 
 	paginatedEndpoints := paginateEndpoints(filteredEndpoints, start, limit)
 
+	allNotes, err := handler.DataStore.Note().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the notes from the database", err)
+	}
+	noteIndex := notes.NewIndex(allNotes)
+
 	for idx := range paginatedEndpoints {
+		paginatedEndpoints[idx].Notes = noteIndex.Endpoint(paginatedEndpoints[idx].ID)
 		hideFields(&paginatedEndpoints[idx])
 		paginatedEndpoints[idx].ComposeSyntaxMaxVersion = handler.ComposeStackManager.ComposeSyntaxMaxVersion()
 		if paginatedEndpoints[idx].EdgeCheckinInterval == 0 {
@@ -0,0 +1,106 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id EndpointNetworksOverview
+// @summary Inspect the IP address management of the networks of an environment(endpoint)
+// @description Aggregate the subnets of the networks of a Docker environment(endpoint), the containers connected to them and the addresses they use.
+// @description On a Swarm manager, the tasks of the whole cluster are included.
+// @description The subnets overlapping the subnets recorded in the snapshots of the other environments are reported as conflicts,
+// @description except when both networks are local to their host.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @success 200 {object} docker.NetworkOverview "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment(Endpoint) not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/networks/overview [get]
+func (handler *Handler) endpointNetworksOverview(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if !endpointutils.IsDockerEndpoint(endpoint) {
+		return httperror.BadRequest("The networks overview is only available for the Docker environments", errors.New("environment is not a docker environment"))
+	}
+
+	cli, err := handler.DockerClientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return httperror.InternalServerError("Unable to connect to the Docker environment", err)
+	}
+	defer cli.Close()
+
+	overview, err := docker.InspectNetworks(r.Context(), cli)
+	if err != nil {
+		return httperror.InternalServerError("Unable to inspect the networks of the environment", err)
+	}
+
+	others, err := handler.otherEndpointsNetworks(endpoint.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the snapshots of the environments from the database", err)
+	}
+
+	overview.Conflicts = docker.FindSubnetConflicts(overview.Networks, others)
+
+	return response.JSON(w, overview)
+}
+
+// otherEndpointsNetworks returns the networks recorded in the Docker snapshots of the other environments
+func (handler *Handler) otherEndpointsNetworks(endpointID portainer.EndpointID) ([]docker.OtherEndpointNetworks, error) {
+	snapshots, err := handler.DataStore.Snapshot().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints, err := handler.DataStore.Endpoint().Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[portainer.EndpointID]string, len(endpoints))
+	for _, e := range endpoints {
+		names[e.ID] = e.Name
+	}
+
+	others := []docker.OtherEndpointNetworks{}
+	for _, snapshot := range snapshots {
+		if snapshot.EndpointID == endpointID || snapshot.Docker == nil {
+			continue
+		}
+
+		name, ok := names[snapshot.EndpointID]
+		if !ok {
+			continue
+		}
+
+		others = append(others, docker.OtherEndpointNetworks{
+			EndpointID:   snapshot.EndpointID,
+			EndpointName: name,
+			Networks:     snapshot.Docker.SnapshotRaw.Networks,
+		})
+	}
+
+	return others, nil
+}
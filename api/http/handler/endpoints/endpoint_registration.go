@@ -0,0 +1,348 @@
+package endpoints
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+const (
+	// registrationTokenPrefix identifies the registration tokens, e.g. in the secret scanners
+	registrationTokenPrefix = "ptr_"
+
+	defaultRegistrationTokenExpiry = 24 * time.Hour
+	maxRegistrationTokenExpiry     = 30 * 24 * time.Hour
+)
+
+var errInvalidRegistrationToken = errors.New("invalid, expired or already used registration token")
+
+type registrationTokenCreatePayload struct {
+	// Description of the token, such as the host the agent is installed on
+	Description string `example:"docker-prod-01"`
+	// Group of the environment created by the registration, defaults to 1 (unassigned)
+	GroupID portainer.EndpointGroupID `example:"1"`
+	// Tags of the environment created by the registration
+	TagIDs []portainer.TagID `example:"1"`
+	// Validity of the token, defaults to 24h and cannot exceed 720h
+	ExpiresIn string `example:"24h"`
+}
+
+func (payload *registrationTokenCreatePayload) Validate(r *http.Request) error {
+	if payload.ExpiresIn == "" {
+		return nil
+	}
+
+	expiry, err := time.ParseDuration(payload.ExpiresIn)
+	if err != nil || expiry <= 0 || expiry > maxRegistrationTokenExpiry {
+		return errors.New("invalid expiry, a positive duration of at most 720h is expected")
+	}
+
+	return nil
+}
+
+type registrationTokenCreateResponse struct {
+	portainer.EndpointRegistrationToken
+	// Token to pass to the agent, it cannot be retrieved afterwards
+	Token string `json:"Token" example:"ptr_Yk5uQ0Z2eVQ0d0pHa2hUbU1Ya1N5Z2FqU0VQd3J2Tnk"`
+}
+
+// @id EndpointRegistrationTokenCreate
+// @summary Create a registration token
+// @description Create a one-time token allowing an agent to register itself as an environment.
+// @description The token is only returned in this response.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body registrationTokenCreatePayload true "Registration token details"
+// @success 200 {object} registrationTokenCreateResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /endpoints/registration_tokens [post]
+func (handler *Handler) registrationTokenCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload registrationTokenCreatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	if payload.GroupID == 0 {
+		payload.GroupID = 1
+	}
+
+	if _, err := handler.DataStore.EndpointGroup().Read(payload.GroupID); err != nil {
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.BadRequest("Invalid environment group identifier", err)
+		}
+
+		return httperror.InternalServerError("Unable to find an environment group inside the database", err)
+	}
+
+	for _, tagID := range payload.TagIDs {
+		if _, err := handler.DataStore.Tag().Read(tagID); err != nil {
+			if handler.DataStore.IsErrObjectNotFound(err) {
+				return httperror.BadRequest("Invalid tag identifier", err)
+			}
+
+			return httperror.InternalServerError("Unable to find a tag inside the database", err)
+		}
+	}
+
+	expiry := defaultRegistrationTokenExpiry
+	if payload.ExpiresIn != "" {
+		expiry, _ = time.ParseDuration(payload.ExpiresIn)
+	}
+
+	rawToken, digest, err := generateRegistrationToken()
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate the registration token", err)
+	}
+
+	now := time.Now()
+	token := &portainer.EndpointRegistrationToken{
+		Description: payload.Description,
+		Digest:      digest,
+		GroupID:     payload.GroupID,
+		TagIDs:      payload.TagIDs,
+		CreatedBy:   tokenData.ID,
+		CreatedAt:   now.Unix(),
+		ExpiresAt:   now.Add(expiry).Unix(),
+	}
+
+	if token.TagIDs == nil {
+		token.TagIDs = []portainer.TagID{}
+	}
+
+	if err := handler.DataStore.EndpointRegistrationToken().Create(token); err != nil {
+		return httperror.InternalServerError("Unable to persist the registration token inside the database", err)
+	}
+
+	token.Digest = nil
+
+	return response.JSON(w, registrationTokenCreateResponse{EndpointRegistrationToken: *token, Token: rawToken})
+}
+
+// @id EndpointRegistrationTokenList
+// @summary List the registration tokens
+// @description List the registration tokens, including the used and expired ones.
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.EndpointRegistrationToken "Success"
+// @failure 500 "Server error"
+// @router /endpoints/registration_tokens [get]
+func (handler *Handler) registrationTokenList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	tokens, err := handler.DataStore.EndpointRegistrationToken().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the registration tokens from the database", err)
+	}
+
+	for i := range tokens {
+		tokens[i].Digest = nil
+	}
+
+	return response.JSON(w, tokens)
+}
+
+// @id EndpointRegistrationTokenDelete
+// @summary Revoke a registration token
+// @description **Access policy**: administrator
+// @tags endpoints
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Registration token identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Registration token not found"
+// @failure 500 "Server error"
+// @router /endpoints/registration_tokens/{id} [delete]
+func (handler *Handler) registrationTokenDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	tokenID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid registration token identifier route variable", err)
+	}
+
+	id := portainer.EndpointRegistrationTokenID(tokenID)
+	if _, err := handler.DataStore.EndpointRegistrationToken().Read(id); err != nil {
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.NotFound("Unable to find a registration token with the specified identifier inside the database", err)
+		}
+
+		return httperror.InternalServerError("Unable to find a registration token with the specified identifier inside the database", err)
+	}
+
+	if err := handler.DataStore.EndpointRegistrationToken().Delete(id); err != nil {
+		return httperror.InternalServerError("Unable to remove the registration token from the database", err)
+	}
+
+	return response.Empty(w)
+}
+
+type endpointRegisterPayload struct {
+	// Registration token generated by an administrator
+	Token string `example:"ptr_Yk5uQ0Z2eVQ0d0pHa2hUbU1Ya1N5Z2FqU0VQd3J2Tnk" validate:"required"`
+	// Address of the agent reachable by Portainer
+	URL string `example:"10.0.0.10:9001" validate:"required"`
+	// Name of the environment, defaults to the host of the agent address
+	Name string `example:"docker-prod-01"`
+	// URL or IP address where the exposed containers are reachable
+	PublicURL string `example:"docker-prod-01.example.com"`
+	// PEM encoded CA certificate of the TLS certificate of the agent, the certificate is not verified when empty
+	TLSCACert string
+	// GPUs of the host
+	Gpus []portainer.Pair
+}
+
+func (payload *endpointRegisterPayload) Validate(r *http.Request) error {
+	if payload.Token == "" {
+		return errors.New("the registration token is required")
+	}
+
+	if strings.TrimSpace(payload.URL) == "" {
+		return errors.New("the agent address is required")
+	}
+
+	if payload.TLSCACert != "" {
+		if _, err := crypto.CreateTLSConfigurationFromBytes([]byte(payload.TLSCACert), nil, nil, false, true); err != nil {
+			return errors.New("invalid CA certificate, a PEM encoded certificate is expected")
+		}
+	}
+
+	return nil
+}
+
+type endpointRegisterResponse struct {
+	// Identifier of the registered environment
+	EndpointID portainer.EndpointID `json:"EndpointId" example:"1"`
+	// Name of the registered environment
+	Name string `json:"Name" example:"docker-prod-01"`
+	// Public key of Portainer, used by the agent to verify the signature of the requests
+	PublicKey string `json:"PublicKey"`
+}
+
+// @id EndpointRegister
+// @summary Register an agent
+// @description Create an agent environment with a one-time registration token generated by an administrator.
+// @description Portainer connects to the agent to complete the registration, and returns its public key for the
+// @description agent to verify the signature of the requests.
+// @description **Access policy**: public, with a registration token
+// @tags endpoints
+// @accept json
+// @produce json
+// @param body body endpointRegisterPayload true "Agent details"
+// @success 200 {object} endpointRegisterResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 401 "Invalid, expired or already used registration token"
+// @failure 409 "Name is not unique"
+// @failure 500 "Server error"
+// @router /endpoints/register [post]
+func (handler *Handler) endpointRegister(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload endpointRegisterPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	// the registrations are serialized so that a token cannot be used twice
+	handler.registrationMutex.Lock()
+	defer handler.registrationMutex.Unlock()
+
+	digest := sha256.Sum256([]byte(payload.Token))
+
+	token, err := handler.DataStore.EndpointRegistrationToken().TokenByDigest(digest[:])
+	if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.InternalServerError("Unable to retrieve the registration token from the database", err)
+	}
+
+	if token == nil || token.EndpointID != 0 || time.Now().Unix() > token.ExpiresAt {
+		return httperror.Unauthorized("Invalid registration token", errInvalidRegistrationToken)
+	}
+
+	endpointURL := payload.URL
+	if !strings.Contains(endpointURL, "://") {
+		endpointURL = "tcp://" + endpointURL
+	}
+
+	name := payload.Name
+	if name == "" {
+		name = agentHost(endpointURL)
+	}
+
+	createPayload := &endpointCreatePayload{
+		Name:                 name,
+		URL:                  endpointURL,
+		EndpointCreationType: agentEnvironment,
+		PublicURL:            payload.PublicURL,
+		Gpus:                 payload.Gpus,
+		GroupID:              int(token.GroupID),
+		TagIDs:               token.TagIDs,
+		TLS:                  true,
+		TLSSkipVerify:        payload.TLSCACert == "",
+		TLSSkipClientVerify:  true,
+		TLSCACertFile:        []byte(payload.TLSCACert),
+	}
+
+	if createPayload.Gpus == nil {
+		createPayload.Gpus = []portainer.Pair{}
+	}
+
+	endpoint, handlerErr := handler.createEndpointWithRelation(createPayload)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	token.EndpointID = endpoint.ID
+	if err := handler.DataStore.EndpointRegistrationToken().Update(token.ID, token); err != nil {
+		return httperror.InternalServerError("Unable to persist the registration token changes inside the database", err)
+	}
+
+	return response.JSON(w, endpointRegisterResponse{
+		EndpointID: endpoint.ID,
+		Name:       endpoint.Name,
+		PublicKey:  handler.SignatureService.EncodedPublicKey(),
+	})
+}
+
+// generateRegistrationToken returns a random token and its SHA256 digest
+func generateRegistrationToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+
+	token := registrationTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	digest := sha256.Sum256([]byte(token))
+
+	return token, digest[:], nil
+}
+
+// agentHost returns the host of the address of an agent
+func agentHost(endpointURL string) string {
+	address := endpointURL[strings.Index(endpointURL, "://")+3:]
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+
+	return host
+}
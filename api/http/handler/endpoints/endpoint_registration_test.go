@@ -0,0 +1,143 @@
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointRegister(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.Tag().Create(&portainer.Tag{ID: 1, Name: "prod", Endpoints: map[portainer.EndpointID]bool{}}))
+
+	agent := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(portainer.PortainerAgentHeader, "2.19.0")
+		w.Header().Set(portainer.HTTPResponseAgentPlatform, strconv.Itoa(int(portainer.AgentPlatformDocker)))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer agent.Close()
+
+	signatureService := crypto.NewECDSAService("")
+	_, _, err := signatureService.GenerateKeyPair()
+	is.NoError(err)
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	handler.DataStore = store
+	handler.SnapshotService = &engineSnapshotService{dataStore: store, engineID: "engine-a"}
+	handler.SignatureService = signatureService
+
+	do := func(method, path string, body any) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		is.NoError(err)
+
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 1, Role: portainer.AdministratorRole}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	rr := do(http.MethodPost, "/endpoints/registration_tokens", registrationTokenCreatePayload{Description: "docker-prod-01", TagIDs: []portainer.TagID{1}})
+	is.Equal(http.StatusOK, rr.Code)
+
+	var created registrationTokenCreateResponse
+	is.NoError(json.NewDecoder(rr.Body).Decode(&created))
+	is.True(strings.HasPrefix(created.Token, registrationTokenPrefix))
+	is.Empty(created.Digest)
+	is.Equal(portainer.EndpointGroupID(1), created.GroupID)
+
+	agentAddress := strings.TrimPrefix(agent.URL, "https://")
+
+	t.Run("an invalid token is rejected", func(t *testing.T) {
+		rr := do(http.MethodPost, "/endpoints/register", endpointRegisterPayload{Token: "ptr_invalid", URL: agentAddress})
+		is.Equal(http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("the agent is registered", func(t *testing.T) {
+		rr := do(http.MethodPost, "/endpoints/register", endpointRegisterPayload{Token: created.Token, URL: agentAddress})
+		is.Equal(http.StatusOK, rr.Code)
+
+		var registered endpointRegisterResponse
+		is.NoError(json.NewDecoder(rr.Body).Decode(&registered))
+		is.Equal("127.0.0.1", registered.Name)
+		is.Equal(signatureService.EncodedPublicKey(), registered.PublicKey)
+
+		endpoint, err := store.Endpoint().Endpoint(registered.EndpointID)
+		is.NoError(err)
+		is.Equal(portainer.AgentOnDockerEnvironment, endpoint.Type)
+		is.Equal("tcp://"+agentAddress, endpoint.URL)
+		is.Equal([]portainer.TagID{1}, endpoint.TagIDs)
+		is.Equal("2.19.0", endpoint.Agent.Version)
+		is.True(endpoint.TLSConfig.TLS)
+
+		token, err := store.EndpointRegistrationToken().Read(created.ID)
+		is.NoError(err)
+		is.Equal(registered.EndpointID, token.EndpointID)
+	})
+
+	t.Run("a token cannot be used twice", func(t *testing.T) {
+		rr := do(http.MethodPost, "/endpoints/register", endpointRegisterPayload{Token: created.Token, URL: agentAddress, Name: "other"})
+		is.Equal(http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("an expired token is rejected", func(t *testing.T) {
+		rawToken, digest, err := generateRegistrationToken()
+		is.NoError(err)
+		is.NoError(store.EndpointRegistrationToken().Create(&portainer.EndpointRegistrationToken{
+			Digest:    digest,
+			GroupID:   1,
+			ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+		}))
+
+		rr := do(http.MethodPost, "/endpoints/register", endpointRegisterPayload{Token: rawToken, URL: agentAddress, Name: "expired"})
+		is.Equal(http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("the tokens are listed without their digest", func(t *testing.T) {
+		rr := do(http.MethodGet, "/endpoints/registration_tokens", nil)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var tokens []portainer.EndpointRegistrationToken
+		is.NoError(json.NewDecoder(rr.Body).Decode(&tokens))
+		is.Len(tokens, 2)
+		for _, token := range tokens {
+			is.Empty(token.Digest)
+		}
+	})
+
+	t.Run("a token can be revoked", func(t *testing.T) {
+		rr := do(http.MethodDelete, "/endpoints/registration_tokens/"+strconv.Itoa(int(created.ID)), nil)
+		is.Equal(http.StatusNoContent, rr.Code)
+
+		_, err := store.EndpointRegistrationToken().Read(created.ID)
+		is.True(store.IsErrObjectNotFound(err))
+	})
+}
+
+func TestRegistrationTokenCreatePayload(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError((&registrationTokenCreatePayload{}).Validate(nil))
+	is.NoError((&registrationTokenCreatePayload{ExpiresIn: "1h"}).Validate(nil))
+	is.Error((&registrationTokenCreatePayload{ExpiresIn: "-1h"}).Validate(nil))
+	is.Error((&registrationTokenCreatePayload{ExpiresIn: "1000h"}).Validate(nil))
+}
@@ -223,6 +223,7 @@ func (handler *Handler) userNamespaces(endpoint *portainer.Endpoint, user *porta
 
 func hideRegistryFields(registry *portainer.Registry, hideAccesses bool) {
 	registry.Password = ""
+	registry.AccessToken = ""
 	registry.ManagementConfiguration = nil
 	if hideAccesses {
 		registry.RegistryAccesses = nil
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/endpointutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -22,6 +23,8 @@ type endpointSettingsUpdatePayload struct {
 	AllowDeviceMappingForRegularUsers *bool `json:"allowDeviceMappingForRegularUsers" example:"true"`
 	// Whether non-administrator should be able to manage stacks
 	AllowStackManagementForRegularUsers *bool `json:"allowStackManagementForRegularUsers" example:"true"`
+	// Whether non-administrator should be able to manage the services, secrets and configs of a Swarm cluster
+	AllowSwarmManagementForRegularUsers *bool `json:"allowSwarmManagementForRegularUsers" example:"true"`
 	// Whether non-administrator should be able to use container capabilities
 	AllowContainerCapabilitiesForRegularUsers *bool `json:"allowContainerCapabilitiesForRegularUsers" example:"true"`
 	// Whether non-administrator should be able to use sysctl settings
@@ -38,9 +41,23 @@ func (payload *endpointSettingsUpdatePayload) Validate(r *http.Request) error {
 	return nil
 }
 
+func (payload *endpointSettingsUpdatePayload) updatesSecuritySettings() bool {
+	return payload.AllowBindMountsForRegularUsers != nil ||
+		payload.AllowPrivilegedModeForRegularUsers != nil ||
+		payload.AllowVolumeBrowserForRegularUsers != nil ||
+		payload.AllowHostNamespaceForRegularUsers != nil ||
+		payload.AllowDeviceMappingForRegularUsers != nil ||
+		payload.AllowStackManagementForRegularUsers != nil ||
+		payload.AllowSwarmManagementForRegularUsers != nil ||
+		payload.AllowContainerCapabilitiesForRegularUsers != nil ||
+		payload.AllowSysctlSettingForRegularUsers != nil ||
+		payload.EnableHostManagementFeatures != nil
+}
+
 // @id EndpointSettingsUpdate
 // @summary Update settings for an environment(endpoint)
 // @description Update settings for an environment(endpoint).
+// @description Updating the security settings overrides the ones inherited from the environment group.
 // @description **Access policy**: authenticated
 // @security ApiKeyAuth
 // @security jwt
@@ -73,7 +90,11 @@ func (handler *Handler) endpointSettingsUpdate(w http.ResponseWriter, r *http.Re
 		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
 	}
 
-	securitySettings := endpoint.SecuritySettings
+	// the changes apply to the settings in effect, which can be inherited from the group
+	securitySettings, err := endpointutils.EffectiveSecuritySettings(handler.DataStore, endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to resolve the security settings of the environment", err)
+	}
 
 	if payload.AllowBindMountsForRegularUsers != nil {
 		securitySettings.AllowBindMountsForRegularUsers = *payload.AllowBindMountsForRegularUsers
@@ -99,6 +120,10 @@ func (handler *Handler) endpointSettingsUpdate(w http.ResponseWriter, r *http.Re
 		securitySettings.AllowStackManagementForRegularUsers = *payload.AllowStackManagementForRegularUsers
 	}
 
+	if payload.AllowSwarmManagementForRegularUsers != nil {
+		securitySettings.AllowSwarmManagementForRegularUsers = *payload.AllowSwarmManagementForRegularUsers
+	}
+
 	if payload.AllowVolumeBrowserForRegularUsers != nil {
 		securitySettings.AllowVolumeBrowserForRegularUsers = *payload.AllowVolumeBrowserForRegularUsers
 	}
@@ -119,7 +144,10 @@ func (handler *Handler) endpointSettingsUpdate(w http.ResponseWriter, r *http.Re
 		endpoint.Gpus = payload.Gpus
 	}
 
-	endpoint.SecuritySettings = securitySettings
+	if payload.updatesSecuritySettings() {
+		endpoint.SecuritySettings = *securitySettings
+		endpoint.SecuritySettingsOverridden = true
+	}
 
 	err = handler.DataStore.Endpoint().UpdateEndpoint(portainer.EndpointID(endpointID), endpoint)
 	if err != nil {
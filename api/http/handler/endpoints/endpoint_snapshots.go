@@ -1,11 +1,14 @@
 package endpoints
 
 import (
+	"fmt"
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/snapshot"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
 
 	"github.com/rs/zerolog/log"
@@ -13,11 +16,14 @@ import (
 
 // @id EndpointSnapshots
 // @summary Snapshot all environments(endpoints)
-// @description Snapshot all environments(endpoints)
+// @description Snapshot all environments(endpoints). When async is set, the snapshots are run by the background job
+// @description queue and the queued jobs are returned instead of waiting for the snapshots.
 // @description **Access policy**: administrator
 // @tags endpoints
 // @security ApiKeyAuth
 // @security jwt
+// @param async query boolean false "Run the snapshots in the background"
+// @success 200 {array} portainer.BackgroundJob "Queued jobs, when async is set"
 // @success 204 "Success"
 // @failure 500 "Server Error"
 // @router /endpoints/snapshot [post]
@@ -27,6 +33,10 @@ func (handler *Handler) endpointSnapshots(w http.ResponseWriter, r *http.Request
 		return httperror.InternalServerError("Unable to retrieve environments from the database", err)
 	}
 
+	if async, _ := request.RetrieveBooleanQueryParameter(r, "async", true); async {
+		return handler.enqueueSnapshots(w, r, endpoints)
+	}
+
 	for _, endpoint := range endpoints {
 		if !snapshot.SupportDirectSnapshot(&endpoint) {
 			continue
@@ -70,3 +80,29 @@ func (handler *Handler) endpointSnapshots(w http.ResponseWriter, r *http.Request
 
 	return response.Empty(w)
 }
+
+// enqueueSnapshots queues a snapshot job per environment, the environments already being snapshotted keeping their
+// pending job
+func (handler *Handler) enqueueSnapshots(w http.ResponseWriter, r *http.Request, endpoints []portainer.Endpoint) *httperror.HandlerError {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	queued := []*portainer.BackgroundJob{}
+
+	for _, endpoint := range endpoints {
+		if !snapshot.SupportDirectSnapshot(&endpoint) || endpoint.URL == "" {
+			continue
+		}
+
+		job, err := handler.JobQueue.EnqueueOnce(snapshot.JobType, snapshot.JobPayload{EndpointID: endpoint.ID}, tokenData.ID, fmt.Sprintf("Snapshot of the environment %s", endpoint.Name))
+		if err != nil {
+			return httperror.InternalServerError("Unable to queue the snapshot of the environment", err)
+		}
+
+		queued = append(queued, job)
+	}
+
+	return response.JSON(w, queued)
+}
@@ -1,14 +1,22 @@
 package endpoints
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/cost"
+	"github.com/portainer/portainer/api/database/changes"
 	"github.com/portainer/portainer/api/dataservices"
+	endpointbucket "github.com/portainer/portainer/api/dataservices/endpoint"
 	"github.com/portainer/portainer/api/http/client"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/internal/snapshot"
+	"github.com/portainer/portainer/api/lifecycle"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -25,6 +33,8 @@ type endpointUpdatePayload struct {
 	PublicURL *string `example:"docker.mydomain.tld:2375"`
 	// GPUs information
 	Gpus []portainer.Pair
+	// Key-value metadata of the environment(endpoint), replacing the existing one
+	Metadata map[string]string
 	// Group identifier
 	GroupID *int `example:"1"`
 	// Require TLS to connect against this environment(endpoint)
@@ -49,15 +59,59 @@ type endpointUpdatePayload struct {
 	EdgeCheckinInterval *int `example:"5"`
 	// Associated Kubernetes data
 	Kubernetes *portainer.KubernetesData
+	// Accept the new URL or TLS configuration even if it targets a different engine than before
+	AllowEngineChange bool `example:"false"`
+	// Interval between the snapshots of the environment(endpoint), overriding the one of its group
+	SnapshotInterval *string `example:"10m"`
+	// Sections skipped by the snapshots of the environment(endpoint), overriding the global ones
+	SnapshotContent *portainer.DockerSnapshotContent
+	// Whether the environment(endpoint) only accepts read requests, overriding the flag of its group
+	ReadOnly *bool `example:"false"`
+	// Restarts of the unhealthy containers of the Docker environment(endpoint)
+	UnhealthyRestartPolicy *portainer.UnhealthyRestartPolicy
+	// Approval of the Docker changes of the non-administrator users on the environment(endpoint)
+	ChangeApproval *portainer.EndpointChangeApproval
+	// Costs of the environment(endpoint) used to estimate the cost of its stacks, overriding the ones of its group
+	Cost *portainer.CostSettings
+	// Settings inherited again from the group of the environment(endpoint) or from the global settings, among
+	// SnapshotInterval, SnapshotContent, ReadOnly, SecuritySettings and Cost
+	ResetOverrides []string `example:"ReadOnly"`
 }
 
 func (payload *endpointUpdatePayload) Validate(r *http.Request) error {
-	return nil
+	if payload.SnapshotInterval != nil && *payload.SnapshotInterval != "" {
+		err := endpointutils.ValidateSnapshotInterval(*payload.SnapshotInterval)
+		if err != nil {
+			return err
+		}
+	}
+
+	if payload.UnhealthyRestartPolicy != nil && payload.UnhealthyRestartPolicy.MaxRestarts < 0 {
+		return errors.New("invalid unhealthy restart policy, the number of restarts cannot be negative")
+	}
+
+	for _, setting := range payload.ResetOverrides {
+		switch setting {
+		case "SnapshotInterval", "SnapshotContent", "ReadOnly", "SecuritySettings", "Cost":
+		default:
+			return fmt.Errorf("invalid setting to reset: %s. It must be one of SnapshotInterval, SnapshotContent, ReadOnly, SecuritySettings or Cost", setting)
+		}
+	}
+
+	if err := cost.ValidateSettings(payload.Cost); err != nil {
+		return err
+	}
+
+	return validateMetadata(payload.Metadata)
 }
 
 // @id EndpointUpdate
 // @summary Update an environment(endpoint)
 // @description Update an environment(endpoint).
+// @description Changing the URL or the TLS configuration of an environment triggers a connectivity check and a new snapshot.
+// @description The change is rejected when the new target is a different engine than before, unless AllowEngineChange is set.
+// @description Moving an environment to a group gives it access to the registries of the group defaults.
+// @description When the change approval is enabled, the Docker changes of the non-administrator users are recorded as change requests run once approved.
 // @description **Access policy**: authenticated
 // @security ApiKeyAuth
 // @security jwt
@@ -69,6 +123,7 @@ func (payload *endpointUpdatePayload) Validate(r *http.Request) error {
 // @success 200 {object} portainer.Endpoint "Success"
 // @failure 400 "Invalid request"
 // @failure 404 "Environment(Endpoint) not found"
+// @failure 409 "The new URL or TLS configuration targets a different engine"
 // @failure 500 "Server error"
 // @router /endpoints/{id} [put]
 func (handler *Handler) endpointUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
@@ -91,6 +146,7 @@ func (handler *Handler) endpointUpdate(w http.ResponseWriter, r *http.Request) *
 	}
 
 	updateEndpointProxy := shouldReloadTLSConfiguration(endpoint, &payload)
+	verifyConnection := updateEndpointProxy || (payload.TLS != nil && *payload.TLS && payload.TLSSkipVerify != nil && *payload.TLSSkipVerify != endpoint.TLSConfig.TLSSkipVerify)
 
 	if payload.Name != nil {
 		name := *payload.Name
@@ -110,6 +166,7 @@ func (handler *Handler) endpointUpdate(w http.ResponseWriter, r *http.Request) *
 	if payload.URL != nil && *payload.URL != endpoint.URL {
 		endpoint.URL = *payload.URL
 		updateEndpointProxy = true
+		verifyConnection = true
 	}
 
 	if payload.PublicURL != nil {
@@ -120,19 +177,72 @@ func (handler *Handler) endpointUpdate(w http.ResponseWriter, r *http.Request) *
 		endpoint.Gpus = payload.Gpus
 	}
 
+	if payload.Metadata != nil {
+		endpoint.Metadata = payload.Metadata
+	}
+
 	if payload.EdgeCheckinInterval != nil {
 		endpoint.EdgeCheckinInterval = *payload.EdgeCheckinInterval
 	}
 
 	updateRelations := false
 
+	groupChanged := false
 	if payload.GroupID != nil {
 		groupID := portainer.EndpointGroupID(*payload.GroupID)
 
-		updateRelations = updateRelations || groupID != endpoint.GroupID
+		groupChanged = groupID != endpoint.GroupID
+		updateRelations = updateRelations || groupChanged
 		endpoint.GroupID = groupID
 	}
 
+	if payload.SnapshotInterval != nil {
+		endpoint.SnapshotInterval = *payload.SnapshotInterval
+	}
+
+	if payload.SnapshotContent != nil {
+		endpoint.SnapshotContent = payload.SnapshotContent
+	}
+
+	if payload.ReadOnly != nil {
+		endpoint.ReadOnly = payload.ReadOnly
+	}
+
+	if payload.UnhealthyRestartPolicy != nil {
+		endpoint.UnhealthyRestartPolicy = payload.UnhealthyRestartPolicy
+	}
+
+	if payload.ChangeApproval != nil {
+		if payload.ChangeApproval.Enabled && !endpointutils.IsDockerEndpoint(endpoint) {
+			return httperror.BadRequest("Invalid change approval", errors.New("the change approval is only available for the Docker environments"))
+		}
+
+		if err := handler.validateChangeApprovers(payload.ChangeApproval); err != nil {
+			return httperror.BadRequest("Invalid change approval", err)
+		}
+
+		endpoint.ChangeApproval = payload.ChangeApproval
+	}
+
+	if payload.Cost != nil {
+		endpoint.Cost = payload.Cost
+	}
+
+	for _, setting := range payload.ResetOverrides {
+		switch setting {
+		case "SnapshotInterval":
+			endpoint.SnapshotInterval = ""
+		case "SnapshotContent":
+			endpoint.SnapshotContent = nil
+		case "ReadOnly":
+			endpoint.ReadOnly = nil
+		case "SecuritySettings":
+			endpoint.SecuritySettingsOverridden = false
+		case "Cost":
+			endpoint.Cost = nil
+		}
+	}
+
 	if payload.TagIDs != nil {
 		err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
 
@@ -253,6 +363,14 @@ func (handler *Handler) endpointUpdate(w http.ResponseWriter, r *http.Request) *
 		}
 	}
 
+	if verifyConnection && snapshot.SupportDirectSnapshot(endpoint) {
+		if httpErr := handler.verifyEndpointConnection(endpoint, payload.AllowEngineChange); httpErr != nil {
+			return httpErr
+		}
+
+		updateEndpointProxy = true
+	}
+
 	if updateEndpointProxy {
 		handler.ProxyManager.DeleteEndpointProxy(endpoint.ID)
 		_, err = handler.ProxyManager.CreateAndRegisterEndpointProxy(endpoint)
@@ -280,6 +398,24 @@ func (handler *Handler) endpointUpdate(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unable to persist environment changes inside the database", err)
 	}
 
+	if updateAuthorizations && handler.EventDispatcher != nil {
+		handler.EventDispatcher.Publish(lifecycle.NewAccessChangedEvent(endpointbucket.BucketName, strconv.Itoa(int(endpoint.ID)), changes.ActionUpdate))
+	}
+
+	if groupChanged {
+		err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+			group, err := endpointutils.EndpointGroup(tx, endpoint)
+			if err != nil {
+				return err
+			}
+
+			return endpointutils.GrantGroupRegistryAccess(tx, group, endpoint.ID)
+		})
+		if err != nil {
+			return httperror.InternalServerError("Unable to give the environment access to the registries of its group", err)
+		}
+	}
+
 	if updateRelations {
 		err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
 			return handler.updateEdgeRelations(tx, endpoint)
@@ -295,6 +431,8 @@ func (handler *Handler) endpointUpdate(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unable to add snapshot data", err)
 	}
 
+	hideFields(endpoint)
+
 	return response.JSON(w, endpoint)
 }
 
@@ -322,3 +460,20 @@ func shouldReloadTLSConfiguration(endpoint *portainer.Endpoint, payload *endpoin
 	}
 	return false
 }
+
+// validateChangeApprovers checks that the approvers of the changes of an environment exist
+func (handler *Handler) validateChangeApprovers(approval *portainer.EndpointChangeApproval) error {
+	for _, userID := range approval.ApproverUserIDs {
+		if _, err := handler.DataStore.User().Read(userID); err != nil {
+			return fmt.Errorf("invalid approver, the user %d does not exist", userID)
+		}
+	}
+
+	for _, teamID := range approval.ApproverTeamIDs {
+		if _, err := handler.DataStore.Team().Read(teamID); err != nil {
+			return fmt.Errorf("invalid approver, the team %d does not exist", teamID)
+		}
+	}
+
+	return nil
+}
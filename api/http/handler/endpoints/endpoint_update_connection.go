@@ -0,0 +1,128 @@
+package endpoints
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/agent"
+	"github.com/portainer/portainer/api/crypto"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+var errEngineChanged = errors.New("the environment targets a different engine")
+
+// verifyEndpointConnection checks that the environment can be reached with its new connection settings and
+// refreshes its snapshot. It rejects the change when the new target is a different engine than before,
+// unless allowEngineChange is set.
+func (handler *Handler) verifyEndpointConnection(endpoint *portainer.Endpoint, allowEngineChange bool) *httperror.HandlerError {
+	previousSnapshot, err := handler.DataStore.Snapshot().Read(endpoint.ID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		previousSnapshot = nil
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the environment snapshot from the database", err)
+	}
+
+	if endpoint.Type == portainer.AgentOnDockerEnvironment || endpoint.Type == portainer.AgentOnKubernetesEnvironment {
+		tlsConfig, err := agentTLSConfig(endpoint)
+		if err != nil {
+			return httperror.InternalServerError("Unable to create the TLS configuration of the agent", err)
+		}
+
+		// the key of the new target is trusted only when the change of engine is confirmed, the pinned keys are
+		// enforced otherwise
+		if allowEngineChange && tlsConfig != nil {
+			pin, err := agent.GetPublicKeyPin(endpoint.URL, tlsConfig)
+			if err != nil {
+				return httperror.BadRequest("Unable to retrieve the public key of the agent", err)
+			}
+
+			endpoint.AgentPublicKeyPins = []string{pin}
+		}
+
+		endpointType, err := agentEndpointType(endpoint, tlsConfig)
+		if err != nil {
+			return httperror.BadRequest("Unable to reach the agent with the new connection settings", err)
+		}
+
+		if endpointType != endpoint.Type {
+			if !allowEngineChange {
+				return httperror.NewError(http.StatusConflict, "The new connection settings target an agent running on a different platform", errEngineChanged)
+			}
+
+			endpoint.Type = endpointType
+		}
+	}
+
+	if err := handler.SnapshotService.SnapshotEndpoint(endpoint); err != nil {
+		return httperror.BadRequest("Unable to initiate communications with the environment using the new connection settings", snapshotError(endpoint, err))
+	}
+
+	if allowEngineChange || previousSnapshot == nil || previousSnapshot.Docker == nil {
+		return nil
+	}
+
+	currentSnapshot, err := handler.DataStore.Snapshot().Read(endpoint.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the environment snapshot from the database", err)
+	}
+
+	previousEngineID := previousSnapshot.Docker.SnapshotRaw.Info.ID
+	if currentSnapshot.Docker == nil || previousEngineID == "" || currentSnapshot.Docker.SnapshotRaw.Info.ID == previousEngineID {
+		return nil
+	}
+
+	// the previous snapshot still describes the environment as long as the change is not confirmed
+	if err := handler.DataStore.Snapshot().Update(endpoint.ID, previousSnapshot); err != nil {
+		log.Warn().Err(err).Int("endpoint_id", int(endpoint.ID)).Msg("unable to restore the environment snapshot")
+	}
+
+	msg := fmt.Sprintf("The new connection settings target a different Docker engine (%s) than before (%s)", currentSnapshot.Docker.SnapshotRaw.Info.ID, previousEngineID)
+
+	return httperror.NewError(http.StatusConflict, msg, errEngineChanged)
+}
+
+// agentTLSConfig returns the TLS configuration used to reach the agent the environment targets, nil when the agent
+// is reached without TLS
+func agentTLSConfig(endpoint *portainer.Endpoint) (*tls.Config, error) {
+	if !endpoint.TLSConfig.TLS {
+		return nil, nil
+	}
+
+	return crypto.CreateTLSConfigurationFromDisk(endpoint.TLSConfig.TLSCACertPath, endpoint.TLSConfig.TLSCertPath, endpoint.TLSConfig.TLSKeyPath, endpoint.TLSConfig.TLSSkipVerify)
+}
+
+// agentEndpointType returns the environment type matching the platform of the agent the environment targets, the
+// public keys of the agents being enforced when they are pinned
+func agentEndpointType(endpoint *portainer.Endpoint, tlsConfig *tls.Config) (portainer.EndpointType, error) {
+	if tlsConfig != nil {
+		tlsConfig = tlsConfig.Clone()
+		crypto.PinPublicKeys(tlsConfig, endpoint.AgentPublicKeyPins)
+	}
+
+	platform, _, err := agent.GetAgentVersionAndPlatform(endpoint.URL, tlsConfig)
+	if err != nil {
+		return 0, err
+	}
+
+	if platform == portainer.AgentPlatformKubernetes {
+		return portainer.AgentOnKubernetesEnvironment, nil
+	}
+
+	return portainer.AgentOnDockerEnvironment, nil
+}
+
+// snapshotError translates the errors returned when an agent rejects the signature of Portainer
+func snapshotError(endpoint *portainer.Endpoint, err error) error {
+	if (endpoint.Type == portainer.AgentOnDockerEnvironment && strings.Contains(err.Error(), "Invalid request signature")) ||
+		(endpoint.Type == portainer.AgentOnKubernetesEnvironment && strings.Contains(err.Error(), "unknown")) {
+		return errors.New("agent already paired with another Portainer instance")
+	}
+
+	return err
+}
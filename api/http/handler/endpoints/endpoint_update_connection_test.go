@@ -0,0 +1,80 @@
+package endpoints
+
+import (
+	"net/http"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type engineSnapshotService struct {
+	portainer.SnapshotService
+	dataStore dataservices.DataStore
+	engineID  string
+}
+
+func (service *engineSnapshotService) SnapshotEndpoint(endpoint *portainer.Endpoint) error {
+	snapshot := &portainer.DockerSnapshot{}
+	snapshot.SnapshotRaw.Info.ID = service.engineID
+
+	return service.dataStore.Snapshot().Create(&portainer.Snapshot{EndpointID: endpoint.ID, Docker: snapshot})
+}
+
+func engineID(t *testing.T, store dataservices.DataStore, endpointID portainer.EndpointID) string {
+	snapshot, err := store.Snapshot().Read(endpointID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return snapshot.Docker.SnapshotRaw.Info.ID
+}
+
+func TestVerifyEndpointConnection(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "docker", Type: portainer.DockerEnvironment, URL: "tcp://127.0.0.1:2375"}
+	err := store.Endpoint().Create(endpoint)
+	assert.NoError(t, err)
+
+	previous := &portainer.DockerSnapshot{}
+	previous.SnapshotRaw.Info.ID = "engine-a"
+	err = store.Snapshot().Create(&portainer.Snapshot{EndpointID: endpoint.ID, Docker: previous})
+	assert.NoError(t, err)
+
+	snapshotService := &engineSnapshotService{dataStore: store, engineID: "engine-a"}
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer(), demo.NewService())
+	handler.DataStore = store
+	handler.SnapshotService = snapshotService
+
+	t.Run("same engine", func(t *testing.T) {
+		httpErr := handler.verifyEndpointConnection(endpoint, false)
+		assert.Nil(t, httpErr)
+	})
+
+	t.Run("different engine is rejected", func(t *testing.T) {
+		snapshotService.engineID = "engine-b"
+
+		httpErr := handler.verifyEndpointConnection(endpoint, false)
+		if assert.NotNil(t, httpErr) {
+			assert.Equal(t, http.StatusConflict, httpErr.StatusCode)
+		}
+
+		assert.Equal(t, "engine-a", engineID(t, store, endpoint.ID))
+	})
+
+	t.Run("different engine is accepted with the override", func(t *testing.T) {
+		snapshotService.engineID = "engine-b"
+
+		httpErr := handler.verifyEndpointConnection(endpoint, true)
+		assert.Nil(t, httpErr)
+
+		assert.Equal(t, "engine-b", engineID(t, store, endpoint.ID))
+	})
+}
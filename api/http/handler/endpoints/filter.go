@@ -37,6 +37,9 @@ type EnvironmentsQuery struct {
 	edgeStackId              portainer.EdgeStackID
 	edgeStackStatus          *portainer.EdgeStackStatusType
 	excludeIds               []portainer.EndpointID
+	metadata                 []metadataFilter
+	// if true, will only keep the Docker environments running unhealthy containers according to their snapshot
+	unhealthy bool
 }
 
 func parseQuery(r *http.Request) (EnvironmentsQuery, error) {
@@ -79,6 +82,11 @@ func parseQuery(r *http.Request) (EnvironmentsQuery, error) {
 
 	agentVersions := getArrayQueryParameter(r, "agentVersions")
 
+	metadata, err := parseMetadataFilters(getArrayQueryParameter(r, "metadata"))
+	if err != nil {
+		return EnvironmentsQuery{}, err
+	}
+
 	name, _ := request.RetrieveQueryParameter(r, "name", true)
 
 	var edgeAsync *bool
@@ -91,6 +99,8 @@ func parseQuery(r *http.Request) (EnvironmentsQuery, error) {
 
 	excludeSnapshots, _ := request.RetrieveBooleanQueryParameter(r, "excludeSnapshots", true)
 
+	unhealthy, _ := request.RetrieveBooleanQueryParameter(r, "unhealthy", true)
+
 	edgeCheckInPassedSeconds, _ := request.RetrieveNumericQueryParameter(r, "edgeCheckInPassedSeconds", true)
 
 	edgeStackId, _ := request.RetrieveNumericQueryParameter(r, "edgeStackId", true)
@@ -117,6 +127,8 @@ func parseQuery(r *http.Request) (EnvironmentsQuery, error) {
 		edgeCheckInPassedSeconds: edgeCheckInPassedSeconds,
 		edgeStackId:              portainer.EdgeStackID(edgeStackId),
 		edgeStackStatus:          edgeStackStatus,
+		metadata:                 metadata,
+		unhealthy:                unhealthy,
 	}, nil
 }
 
@@ -214,6 +226,20 @@ func (handler *Handler) filterEndpointsByQuery(
 			return !endpointutils.IsAgentEndpoint(&endpoint) || contains(query.agentVersions, endpoint.Agent.Version)
 		})
 	}
+	if len(query.metadata) > 0 {
+		filteredEndpoints = filter(filteredEndpoints, func(endpoint portainer.Endpoint) bool {
+			return endpointMatchMetadataFilters(&endpoint, query.metadata)
+		})
+	}
+
+	if query.unhealthy {
+		f, err := filterUnhealthyEndpoints(filteredEndpoints, handler.DataStore)
+		if err != nil {
+			return nil, 0, err
+		}
+		filteredEndpoints = f
+	}
+
 	if query.edgeStackId != 0 {
 		f, err := filterEndpointsByEdgeStack(filteredEndpoints, query.edgeStackId, query.edgeStackStatus, handler.DataStore)
 		if err != nil {
@@ -281,6 +307,26 @@ func filterEndpointsByEdgeStack(endpoints []portainer.Endpoint, edgeStackId port
 	return filteredEndpoints, nil
 }
 
+// filterUnhealthyEndpoints keeps the environments whose latest snapshot reports unhealthy containers
+func filterUnhealthyEndpoints(endpoints []portainer.Endpoint, datastore dataservices.DataStore) ([]portainer.Endpoint, error) {
+	n := 0
+	for _, endpoint := range endpoints {
+		snapshot, err := datastore.Snapshot().Read(endpoint.ID)
+		if datastore.IsErrObjectNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, errors.WithMessage(err, "Unable to retrieve the snapshot of the environment from the database")
+		}
+
+		if snapshot.Docker != nil && snapshot.Docker.UnhealthyContainerCount > 0 {
+			endpoints[n] = endpoint
+			n++
+		}
+	}
+
+	return endpoints[:n], nil
+}
+
 func filterEndpointsByGroupIDs(endpoints []portainer.Endpoint, endpointGroupIDs []portainer.EndpointGroupID) []portainer.Endpoint {
 	n := 0
 	for _, endpoint := range endpoints {
@@ -331,25 +377,7 @@ func filterEndpointsBySearchCriteria(
 func filterEndpointsByStatuses(endpoints []portainer.Endpoint, statuses []portainer.EndpointStatus, settings *portainer.Settings) []portainer.Endpoint {
 	n := 0
 	for _, endpoint := range endpoints {
-		status := endpoint.Status
-		if endpointutils.IsEdgeEndpoint(&endpoint) {
-			isCheckValid := false
-			edgeCheckinInterval := endpoint.EdgeCheckinInterval
-			if endpoint.EdgeCheckinInterval == 0 {
-				edgeCheckinInterval = settings.EdgeAgentCheckinInterval
-			}
-
-			if edgeCheckinInterval != 0 && endpoint.LastCheckInDate != 0 {
-				isCheckValid = time.Now().Unix()-endpoint.LastCheckInDate <= int64(edgeCheckinInterval*EdgeDeviceIntervalMultiplier+EdgeDeviceIntervalAdd)
-			}
-
-			status = portainer.EndpointStatusDown // Offline
-			if isCheckValid {
-				status = portainer.EndpointStatusUp // Online
-			}
-		}
-
-		if slices.Contains(statuses, status) {
+		if slices.Contains(statuses, endpointStatus(&endpoint, settings)) {
 			endpoints[n] = endpoint
 			n++
 		}
@@ -358,6 +386,25 @@ func filterEndpointsByStatuses(endpoints []portainer.Endpoint, statuses []portai
 	return endpoints[:n]
 }
 
+// endpointStatus returns the status of an environment, the Edge environments are up when they checked in recently
+func endpointStatus(endpoint *portainer.Endpoint, settings *portainer.Settings) portainer.EndpointStatus {
+	if !endpointutils.IsEdgeEndpoint(endpoint) {
+		return endpoint.Status
+	}
+
+	edgeCheckinInterval := endpoint.EdgeCheckinInterval
+	if endpoint.EdgeCheckinInterval == 0 {
+		edgeCheckinInterval = settings.EdgeAgentCheckinInterval
+	}
+
+	if edgeCheckinInterval != 0 && endpoint.LastCheckInDate != 0 &&
+		time.Now().Unix()-endpoint.LastCheckInDate <= int64(edgeCheckinInterval*EdgeDeviceIntervalMultiplier+EdgeDeviceIntervalAdd) {
+		return portainer.EndpointStatusUp // Online
+	}
+
+	return portainer.EndpointStatusDown // Offline
+}
+
 func endpointMatchSearchCriteria(endpoint *portainer.Endpoint, tags []string, searchCriteria string) bool {
 	if strings.Contains(strings.ToLower(endpoint.Name), searchCriteria) {
 		return true
@@ -73,6 +73,48 @@ func Test_Filter_AgentVersion(t *testing.T) {
 	runTests(tests, t, handler, endpoints)
 }
 
+func Test_Filter_Metadata(t *testing.T) {
+	parisEndpoint := portainer.Endpoint{ID: 1, GroupID: 1, Type: portainer.DockerEnvironment,
+		Metadata: map[string]string{"location": "paris", "owner": "team-a"}}
+	londonEndpoint := portainer.Endpoint{ID: 2, GroupID: 1, Type: portainer.DockerEnvironment,
+		Metadata: map[string]string{"location": "london"}}
+	noMetadataEndpoint := portainer.Endpoint{ID: 3, GroupID: 1, Type: portainer.DockerEnvironment}
+
+	endpoints := []portainer.Endpoint{
+		parisEndpoint,
+		londonEndpoint,
+		noMetadataEndpoint,
+	}
+
+	handler := setupFilterTest(t, endpoints)
+
+	tests := []filterTest{
+		{
+			"should show endpoints with a metadata value",
+			[]portainer.EndpointID{parisEndpoint.ID},
+			EnvironmentsQuery{
+				metadata: []metadataFilter{{key: "location", value: "paris", hasValue: true}},
+			},
+		},
+		{
+			"should show endpoints having a metadata key",
+			[]portainer.EndpointID{parisEndpoint.ID, londonEndpoint.ID},
+			EnvironmentsQuery{
+				metadata: []metadataFilter{{key: "location"}},
+			},
+		},
+		{
+			"should show endpoints matching all the metadata filters",
+			[]portainer.EndpointID{},
+			EnvironmentsQuery{
+				metadata: []metadataFilter{{key: "location", value: "london", hasValue: true}, {key: "owner"}},
+			},
+		},
+	}
+
+	runTests(tests, t, handler, endpoints)
+}
+
 func Test_Filter_edgeFilter(t *testing.T) {
 
 	trustedEdgeAsync := portainer.Endpoint{ID: 1, UserTrusted: true, Edge: portainer.EnvironmentEdgeSettings{AsyncMode: true}, GroupID: 1, Type: portainer.EdgeAgentOnDockerEnvironment}
@@ -2,14 +2,28 @@ package endpoints
 
 import (
 	"net/http"
+	"sync"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/dataservices/edgegroup"
+	"github.com/portainer/portainer/api/dataservices/edgestack"
+	"github.com/portainer/portainer/api/dataservices/endpoint"
+	"github.com/portainer/portainer/api/dataservices/endpointgroup"
+	"github.com/portainer/portainer/api/dataservices/settings"
+	"github.com/portainer/portainer/api/dataservices/snapshot"
+	"github.com/portainer/portainer/api/dataservices/tag"
+	"github.com/portainer/portainer/api/dataservices/teammembership"
+	"github.com/portainer/portainer/api/dataservices/user"
 	"github.com/portainer/portainer/api/demo"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/http/middlewares"
 	"github.com/portainer/portainer/api/http/proxy"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/jobs"
 	"github.com/portainer/portainer/api/kubernetes/cli"
+	"github.com/portainer/portainer/api/lifecycle"
 	"github.com/portainer/portainer/api/pendingactions"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
@@ -34,11 +48,16 @@ type Handler struct {
 	ReverseTunnelService  portainer.ReverseTunnelService
 	SnapshotService       portainer.SnapshotService
 	K8sClientFactory      *cli.ClientFactory
+	DockerClientFactory   *dockerclient.ClientFactory
 	ComposeStackManager   portainer.ComposeStackManager
 	AuthorizationService  *authorization.Service
 	BindAddress           string
 	BindAddressHTTPS      string
 	PendingActionsService *pendingactions.PendingActionsService
+	SignatureService      portainer.DigitalSignatureService
+	JobQueue              *jobs.Queue
+	EventDispatcher       *lifecycle.Dispatcher
+	registrationMutex     sync.Mutex
 }
 
 // NewHandler creates a handler to manage environment(endpoint) operations.
@@ -48,17 +67,32 @@ func NewHandler(bouncer security.BouncerService, demoService *demo.Service) *Han
 		requestBouncer: bouncer,
 		demoService:    demoService,
 	}
+	listCache := middlewares.NewResponseCache(middlewares.ResponseCacheTTL)
 
 	h.Handle("/endpoints",
-		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointCreate))).Methods(http.MethodPost)
+		bouncer.AdminAccess(middlewares.WithIdempotency(httperror.LoggerHandler(h.endpointCreate)))).Methods(http.MethodPost)
 	h.Handle("/endpoints/{id}/settings",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointSettingsUpdate))).Methods(http.MethodPut)
 	h.Handle("/endpoints/{id}/association",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointAssociationDelete))).Methods(http.MethodDelete)
+	h.Handle("/endpoints/import/docker_contexts",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointImportDockerContexts))).Methods(http.MethodPost)
+	h.Handle("/dashboard",
+		bouncer.RestrictedAccess(httperror.LoggerHandler(h.endpointDashboard))).Methods(http.MethodGet)
 	h.Handle("/endpoints/snapshot",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointSnapshots))).Methods(http.MethodPost)
 	h.Handle("/endpoints",
-		bouncer.RestrictedAccess(httperror.LoggerHandler(h.endpointList))).Methods(http.MethodGet)
+		bouncer.RestrictedAccess(listCache.Handler(httperror.LoggerHandler(h.endpointList),
+			endpoint.BucketName, endpointgroup.BucketName, edgegroup.BucketName, edgestack.BucketName, settings.BucketName,
+			snapshot.BucketName, tag.BucketName, teammembership.BucketName, user.BucketName))).Methods(http.MethodGet)
+	h.Handle("/endpoints/registration_tokens",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.registrationTokenCreate))).Methods(http.MethodPost)
+	h.Handle("/endpoints/registration_tokens",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.registrationTokenList))).Methods(http.MethodGet)
+	h.Handle("/endpoints/registration_tokens/{id}",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.registrationTokenDelete))).Methods(http.MethodDelete)
+	h.Handle("/endpoints/register",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.endpointRegister))).Methods(http.MethodPost)
 	h.Handle("/endpoints/agent_versions",
 		bouncer.RestrictedAccess(httperror.LoggerHandler(h.agentVersions))).Methods(http.MethodGet)
 	h.Handle("/endpoints/relations", bouncer.RestrictedAccess(httperror.LoggerHandler(h.updateRelations))).Methods(http.MethodPut)
@@ -69,10 +103,20 @@ func NewHandler(bouncer security.BouncerService, demoService *demo.Service) *Han
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointUpdate))).Methods(http.MethodPut)
 	h.Handle("/endpoints/{id}",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointDelete))).Methods(http.MethodDelete)
+	h.Handle("/endpoints/{id}/effective_settings",
+		bouncer.RestrictedAccess(httperror.LoggerHandler(h.endpointEffectiveSettingsInspect))).Methods(http.MethodGet)
+	h.Handle("/endpoints/{id}/disk_usage",
+		bouncer.RestrictedAccess(httperror.LoggerHandler(h.endpointDiskUsageInspect))).Methods(http.MethodGet)
 	h.Handle("/endpoints/{id}/dockerhub/{registryId}",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.endpointDockerhubStatus))).Methods(http.MethodGet)
+	h.Handle("/endpoints/{id}/docker_operations",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointDockerOperationList))).Methods(http.MethodGet)
 	h.Handle("/endpoints/{id}/snapshot",
 		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointSnapshot))).Methods(http.MethodPost)
+	h.Handle("/endpoints/{id}/agent/trust",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointAgentTrust))).Methods(http.MethodPost)
+	h.Handle("/endpoints/{id}/networks/overview",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.endpointNetworksOverview))).Methods(http.MethodGet)
 	h.Handle("/endpoints/{id}/registries",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.endpointRegistriesList))).Methods(http.MethodGet)
 	h.Handle("/endpoints/{id}/registries/{registryId}",
@@ -0,0 +1,70 @@
+package endpoints
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	maxMetadataEntries     = 32
+	maxMetadataKeyLength   = 63
+	maxMetadataValueLength = 255
+)
+
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// validateMetadata checks the metadata set on an environment. The keys are limited to letters, digits, '_', '.' and '-',
+// so that they can be used in the list filters
+func validateMetadata(metadata map[string]string) error {
+	if len(metadata) > maxMetadataEntries {
+		return fmt.Errorf("an environment cannot have more than %d metadata entries", maxMetadataEntries)
+	}
+
+	for key, value := range metadata {
+		if len(key) > maxMetadataKeyLength || !metadataKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid metadata key %q. It must contain at most %d letters, digits, '_', '.' or '-' and start with a letter or a digit", key, maxMetadataKeyLength)
+		}
+
+		if len(value) > maxMetadataValueLength {
+			return fmt.Errorf("the value of the metadata key %q must not exceed %d characters", key, maxMetadataValueLength)
+		}
+	}
+
+	return nil
+}
+
+// metadataFilter matches the environments having a metadata key, with the given value when it is set
+type metadataFilter struct {
+	key      string
+	value    string
+	hasValue bool
+}
+
+// parseMetadataFilters parses the filters formatted as "key" or "key:value"
+func parseMetadataFilters(filters []string) ([]metadataFilter, error) {
+	result := make([]metadataFilter, 0, len(filters))
+	for _, filter := range filters {
+		key, value, hasValue := strings.Cut(filter, ":")
+		if !metadataKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid metadata filter %q", filter)
+		}
+
+		result = append(result, metadataFilter{key: key, value: value, hasValue: hasValue})
+	}
+
+	return result, nil
+}
+
+func endpointMatchMetadataFilters(endpoint *portainer.Endpoint, filters []metadataFilter) bool {
+	for _, filter := range filters {
+		value, ok := endpoint.Metadata[filter.key]
+		if !ok || (filter.hasValue && value != filter.value) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,42 @@
+package endpoints
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMetadata(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(validateMetadata(nil))
+	is.NoError(validateMetadata(map[string]string{"location": "paris", "cost-center": "R&D 42", "owner.team": ""}))
+
+	is.Error(validateMetadata(map[string]string{"": "paris"}))
+	is.Error(validateMetadata(map[string]string{"loca:tion": "paris"}))
+	is.Error(validateMetadata(map[string]string{"-location": "paris"}))
+	is.Error(validateMetadata(map[string]string{strings.Repeat("a", 64): "paris"}))
+	is.Error(validateMetadata(map[string]string{"location": strings.Repeat("a", 256)}))
+
+	tooMany := map[string]string{}
+	for i := 0; i <= maxMetadataEntries; i++ {
+		tooMany[strings.Repeat("a", i+1)] = "value"
+	}
+	is.Error(validateMetadata(tooMany))
+}
+
+func TestParseMetadataFilters(t *testing.T) {
+	is := assert.New(t)
+
+	filters, err := parseMetadataFilters([]string{"location:paris", "owner", "url:http://host:8080"})
+	is.NoError(err)
+	is.Equal([]metadataFilter{
+		{key: "location", value: "paris", hasValue: true},
+		{key: "owner"},
+		{key: "url", value: "http://host:8080", hasValue: true},
+	}, filters)
+
+	_, err = parseMetadataFilters([]string{":paris"})
+	is.Error(err)
+}
@@ -0,0 +1,83 @@
+package eventwebhooks
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/asaskevich/govalidator"
+)
+
+type eventWebhookCreatePayload struct {
+	// Name of the event webhook
+	Name string `validate:"required" example:"cmdb"`
+	// URL receiving the events
+	URL string `validate:"required" example:"https://cmdb.example.com/hooks/portainer"`
+	// Secret used to sign the payloads with HMAC-SHA256
+	Secret string `example:"s3cr3t"`
+	// Types of the events sent to the webhook, all the events are sent when empty
+	Events []string `example:"endpoint.created,endpoint.deleted"`
+	// Whether the events are sent to the webhook
+	Enabled bool `example:"true"`
+}
+
+func (payload *eventWebhookCreatePayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Name) {
+		return errors.New("invalid event webhook name")
+	}
+
+	if err := validateURL(payload.URL); err != nil {
+		return err
+	}
+
+	return validateEvents(payload.Events)
+}
+
+// @id EventWebhookCreate
+// @summary Create an event webhook
+// @description Register an outbound webhook notified of the lifecycle events of Portainer.
+// @description The events are posted as JSON with the X-Portainer-Event and X-Portainer-Delivery headers. When a secret is set,
+// @description the X-Portainer-Signature header contains the HMAC-SHA256 of the body keyed with the secret, as "sha256=<hex digest>".
+// @description **Access policy**: administrator
+// @tags event_webhooks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body eventWebhookCreatePayload true "Event webhook details"
+// @success 200 {object} portainer.EventWebhook "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /event_webhooks [post]
+func (handler *Handler) eventWebhookCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload eventWebhookCreatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	webhook := &portainer.EventWebhook{
+		Name:    payload.Name,
+		URL:     payload.URL,
+		Secret:  payload.Secret,
+		Events:  payload.Events,
+		Enabled: payload.Enabled,
+	}
+
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+
+	err = handler.DataStore.EventWebhook().Create(webhook)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the event webhook inside the database", err)
+	}
+
+	hideFields(webhook)
+
+	return response.JSON(w, webhook)
+}
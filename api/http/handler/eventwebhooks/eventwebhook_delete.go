@@ -0,0 +1,44 @@
+package eventwebhooks
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id EventWebhookDelete
+// @summary Remove an event webhook
+// @description Remove an event webhook and its delivery log.
+// @description **Access policy**: administrator
+// @tags event_webhooks
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Event webhook identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Event webhook not found"
+// @failure 500 "Server error"
+// @router /event_webhooks/{id} [delete]
+func (handler *Handler) eventWebhookDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	webhookID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid event webhook identifier route variable", err)
+	}
+
+	_, err = handler.DataStore.EventWebhook().Read(portainer.EventWebhookID(webhookID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an event webhook with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an event webhook with the specified identifier inside the database", err)
+	}
+
+	err = handler.DataStore.EventWebhook().Delete(portainer.EventWebhookID(webhookID))
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the event webhook from the database", err)
+	}
+
+	return response.Empty(w)
+}
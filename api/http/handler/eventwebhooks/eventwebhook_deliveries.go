@@ -0,0 +1,50 @@
+package eventwebhooks
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id EventWebhookDeliveries
+// @summary List the deliveries of an event webhook
+// @description List the most recent deliveries of the events to an event webhook, the most recent first. The pending
+// @description deliveries are waiting for their next attempt.
+// @description **Access policy**: administrator
+// @tags event_webhooks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Event webhook identifier"
+// @success 200 {array} portainer.EventWebhookDelivery "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Event webhook not found"
+// @failure 500 "Server error"
+// @router /event_webhooks/{id}/deliveries [get]
+func (handler *Handler) eventWebhookDeliveries(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	webhookID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid event webhook identifier route variable", err)
+	}
+
+	_, err = handler.DataStore.EventWebhook().Read(portainer.EventWebhookID(webhookID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an event webhook with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an event webhook with the specified identifier inside the database", err)
+	}
+
+	deliveries, err := handler.DataStore.EventWebhook().Deliveries(portainer.EventWebhookID(webhookID))
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the event webhook deliveries from the database", err)
+	}
+
+	for i := range deliveries {
+		deliveries[i].Payload = nil
+	}
+
+	return response.JSON(w, deliveries)
+}
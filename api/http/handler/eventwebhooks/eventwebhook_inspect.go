@@ -0,0 +1,41 @@
+package eventwebhooks
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id EventWebhookInspect
+// @summary Inspect an event webhook
+// @description **Access policy**: administrator
+// @tags event_webhooks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Event webhook identifier"
+// @success 200 {object} portainer.EventWebhook "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Event webhook not found"
+// @failure 500 "Server error"
+// @router /event_webhooks/{id} [get]
+func (handler *Handler) eventWebhookInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	webhookID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid event webhook identifier route variable", err)
+	}
+
+	webhook, err := handler.DataStore.EventWebhook().Read(portainer.EventWebhookID(webhookID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an event webhook with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an event webhook with the specified identifier inside the database", err)
+	}
+
+	hideFields(webhook)
+
+	return response.JSON(w, webhook)
+}
@@ -0,0 +1,31 @@
+package eventwebhooks
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id EventWebhookList
+// @summary List the event webhooks
+// @description **Access policy**: administrator
+// @tags event_webhooks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.EventWebhook "Success"
+// @failure 500 "Server error"
+// @router /event_webhooks [get]
+func (handler *Handler) eventWebhookList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	webhooks, err := handler.DataStore.EventWebhook().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the event webhooks from the database", err)
+	}
+
+	for i := range webhooks {
+		hideFields(&webhooks[i])
+	}
+
+	return response.JSON(w, webhooks)
+}
@@ -0,0 +1,102 @@
+package eventwebhooks
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type eventWebhookUpdatePayload struct {
+	// Name of the event webhook
+	Name *string `example:"cmdb"`
+	// URL receiving the events
+	URL *string `example:"https://cmdb.example.com/hooks/portainer"`
+	// Secret used to sign the payloads with HMAC-SHA256, an empty secret disables the signature
+	Secret *string `example:"s3cr3t"`
+	// Types of the events sent to the webhook, all the events are sent when empty
+	Events []string `example:"endpoint.created,endpoint.deleted"`
+	// Whether the events are sent to the webhook
+	Enabled *bool `example:"true"`
+}
+
+func (payload *eventWebhookUpdatePayload) Validate(r *http.Request) error {
+	if payload.Name != nil && *payload.Name == "" {
+		return errors.New("invalid event webhook name")
+	}
+
+	if payload.URL != nil {
+		if err := validateURL(*payload.URL); err != nil {
+			return err
+		}
+	}
+
+	return validateEvents(payload.Events)
+}
+
+// @id EventWebhookUpdate
+// @summary Update an event webhook
+// @description **Access policy**: administrator
+// @tags event_webhooks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Event webhook identifier"
+// @param body body eventWebhookUpdatePayload true "Event webhook details"
+// @success 200 {object} portainer.EventWebhook "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Event webhook not found"
+// @failure 500 "Server error"
+// @router /event_webhooks/{id} [put]
+func (handler *Handler) eventWebhookUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	webhookID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid event webhook identifier route variable", err)
+	}
+
+	var payload eventWebhookUpdatePayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	webhook, err := handler.DataStore.EventWebhook().Read(portainer.EventWebhookID(webhookID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an event webhook with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an event webhook with the specified identifier inside the database", err)
+	}
+
+	if payload.Name != nil {
+		webhook.Name = *payload.Name
+	}
+
+	if payload.URL != nil {
+		webhook.URL = *payload.URL
+	}
+
+	if payload.Secret != nil {
+		webhook.Secret = *payload.Secret
+	}
+
+	if payload.Events != nil {
+		webhook.Events = payload.Events
+	}
+
+	if payload.Enabled != nil {
+		webhook.Enabled = *payload.Enabled
+	}
+
+	err = handler.DataStore.EventWebhook().Update(webhook.ID, webhook)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the event webhook changes inside the database", err)
+	}
+
+	hideFields(webhook)
+
+	return response.JSON(w, webhook)
+}
@@ -0,0 +1,63 @@
+package eventwebhooks
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/lifecycle"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/gorilla/mux"
+)
+
+func hideFields(webhook *portainer.EventWebhook) {
+	webhook.Secret = ""
+}
+
+// Handler is the HTTP handler used to handle event webhook operations.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+}
+
+// NewHandler creates a handler to manage event webhook operations.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+
+	adminRouter := h.NewRoute().Subrouter()
+	adminRouter.Use(bouncer.AdminAccess)
+
+	adminRouter.Handle("/event_webhooks", httperror.LoggerHandler(h.eventWebhookCreate)).Methods(http.MethodPost)
+	adminRouter.Handle("/event_webhooks", httperror.LoggerHandler(h.eventWebhookList)).Methods(http.MethodGet)
+	adminRouter.Handle("/event_webhooks/{id}", httperror.LoggerHandler(h.eventWebhookInspect)).Methods(http.MethodGet)
+	adminRouter.Handle("/event_webhooks/{id}", httperror.LoggerHandler(h.eventWebhookUpdate)).Methods(http.MethodPut)
+	adminRouter.Handle("/event_webhooks/{id}", httperror.LoggerHandler(h.eventWebhookDelete)).Methods(http.MethodDelete)
+	adminRouter.Handle("/event_webhooks/{id}/deliveries", httperror.LoggerHandler(h.eventWebhookDeliveries)).Methods(http.MethodGet)
+
+	return h
+}
+
+func validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("invalid webhook URL, an absolute http or https URL is expected")
+	}
+
+	return nil
+}
+
+func validateEvents(events []string) error {
+	for _, event := range events {
+		if !lifecycle.IsEventType(event) {
+			return errors.New("invalid event type: " + event)
+		}
+	}
+
+	return nil
+}
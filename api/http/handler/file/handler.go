@@ -3,8 +3,6 @@ package file
 import (
 	"net/http"
 	"strings"
-
-	"github.com/gorilla/handlers"
 )
 
 // Handler represents an HTTP API handler for managing static files.
@@ -16,9 +14,7 @@ type Handler struct {
 // NewHandler creates a handler to serve static files.
 func NewHandler(assetPublicPath string, wasInstanceDisabled func() bool) *Handler {
 	h := &Handler{
-		Handler: handlers.CompressHandler(
-			http.FileServer(http.Dir(assetPublicPath)),
-		),
+		Handler:             http.FileServer(http.Dir(assetPublicPath)),
 		wasInstanceDisabled: wasInstanceDisabled,
 	}
 
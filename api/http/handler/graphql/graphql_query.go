@@ -0,0 +1,89 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	gql "github.com/graphql-go/graphql"
+)
+
+type graphqlQueryPayload struct {
+	// GraphQL query document
+	Query string `example:"{ endpoints { name status snapshot { docker { runningContainerCount } } } }" validate:"required"`
+	// Name of the operation to execute when the document contains several operations
+	OperationName string
+	// Values of the variables of the query
+	Variables map[string]any
+}
+
+func (payload *graphqlQueryPayload) Validate(r *http.Request) error {
+	if payload.Query == "" {
+		return errors.New("Invalid query")
+	}
+
+	return nil
+}
+
+// @id GraphQLQuery
+// @summary Query the inventory
+// @description Run a read-only GraphQL query over the environments, environment groups, tags, snapshots, stacks and users.
+// @description Only the resources the user has access to are returned, the users being restricted to the administrators.
+// @description The endpoint must be enabled in the settings. The queries are limited to 16KiB, a depth of 10 nested fields
+// @description and 1000 selected fields.
+// @description **Access policy**: authenticated
+// @tags graphql
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body graphqlQueryPayload true "GraphQL query"
+// @success 200 "Success, the errors of the query are listed in the errors field of the result"
+// @failure 400 "Invalid request"
+// @failure 404 "GraphQL endpoint disabled"
+// @failure 500 "Server error"
+// @router /graphql [post]
+func (handler *Handler) graphqlQuery(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	if !settings.EnableGraphQL {
+		return httperror.NotFound("The GraphQL endpoint is disabled", errors.New("graphql endpoint disabled"))
+	}
+
+	var payload graphqlQueryPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	if err := checkQueryLimits(payload.Query); err != nil {
+		return httperror.BadRequest("The query exceeds the limits of the GraphQL endpoint", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	schema, err := handler.schema()
+	if err != nil {
+		return httperror.InternalServerError("Unable to build the GraphQL schema", err)
+	}
+
+	result := gql.Do(gql.Params{
+		Schema:         schema,
+		RequestString:  payload.Query,
+		OperationName:  payload.OperationName,
+		VariableValues: payload.Variables,
+		Context:        context.WithValue(r.Context(), inventoryKey{}, newInventory(handler.DataStore, securityContext)),
+	})
+
+	return response.JSON(w, result)
+}
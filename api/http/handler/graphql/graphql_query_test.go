@@ -0,0 +1,165 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type queryResult struct {
+	Data   map[string]any
+	Errors []struct{ Message string }
+}
+
+func TestGraphQLQuery(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	user := &portainer.User{Username: "user", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	tag := &portainer.Tag{Name: "prod"}
+	is.NoError(store.Tag().Create(tag))
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "authorized", GroupID: 1, TagIDs: []portainer.TagID{tag.ID},
+		UserAccessPolicies: portainer.UserAccessPolicies{user.ID: {}}}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 2, Name: "unauthorized", GroupID: 1, TagIDs: []portainer.TagID{tag.ID}}))
+
+	is.NoError(store.Snapshot().Create(&portainer.Snapshot{EndpointID: 1, Docker: &portainer.DockerSnapshot{RunningContainerCount: 3, TotalMemory: 8 << 30}}))
+	is.NoError(store.Snapshot().Create(&portainer.Snapshot{EndpointID: 2, Docker: &portainer.DockerSnapshot{RunningContainerCount: 5}}))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer())
+	handler.DataStore = store
+
+	query := func(securityContext *security.RestrictedRequestContext, query string) (int, queryResult) {
+		body, err := json.Marshal(graphqlQueryPayload{Query: query})
+		is.NoError(err)
+
+		r := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+		r = r.WithContext(security.StoreRestrictedRequestContext(r, securityContext))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		var result queryResult
+		if w.Code == http.StatusOK {
+			is.NoError(json.NewDecoder(w.Body).Decode(&result))
+		}
+
+		return w.Code, result
+	}
+
+	admin := &security.RestrictedRequestContext{IsAdmin: true, UserID: 1}
+	standard := &security.RestrictedRequestContext{UserID: user.ID}
+
+	t.Run("the endpoint is disabled by default", func(t *testing.T) {
+		code, _ := query(admin, "{ tags { name } }")
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.EnableGraphQL = true
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	t.Run("the resources are filtered by the access of the user", func(t *testing.T) {
+		code, result := query(standard, `{
+			endpoints { name snapshot { docker { runningContainerCount totalMemory } } }
+			tags { name endpoints { name } }
+			snapshots { endpointId }
+		}`)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Empty(t, result.Errors)
+		assert.Equal(t, map[string]any{
+			"endpoints": []any{
+				map[string]any{"name": "authorized", "snapshot": map[string]any{
+					"docker": map[string]any{"runningContainerCount": float64(3), "totalMemory": float64(8 << 30)},
+				}},
+			},
+			"tags": []any{
+				map[string]any{"name": "prod", "endpoints": []any{map[string]any{"name": "authorized"}}},
+			},
+			"snapshots": []any{map[string]any{"endpointId": float64(1)}},
+		}, result.Data)
+	})
+
+	t.Run("the environments are filtered by the arguments", func(t *testing.T) {
+		_, result := query(admin, `{ endpoints(name: "unauthorized") { id group { name } tags { name } } }`)
+		assert.Empty(t, result.Errors)
+		assert.Equal(t, []any{
+			map[string]any{"id": float64(2), "group": map[string]any{"name": "Unassigned"}, "tags": []any{map[string]any{"name": "prod"}}},
+		}, result.Data["endpoints"])
+
+		_, result = query(admin, `{ endpoints(tagIds: [42]) { id } }`)
+		assert.Equal(t, []any{}, result.Data["endpoints"])
+	})
+
+	t.Run("the users are restricted to the administrators", func(t *testing.T) {
+		_, result := query(standard, "{ users { username } }")
+		assert.Len(t, result.Errors, 1)
+		assert.Nil(t, result.Data["users"])
+
+		_, result = query(admin, "{ users { username role } }")
+		assert.Empty(t, result.Errors)
+		assert.Equal(t, []any{map[string]any{"username": "user", "role": float64(portainer.StandardUserRole)}}, result.Data["users"])
+	})
+
+	t.Run("the mutations are rejected", func(t *testing.T) {
+		_, result := query(admin, "mutation { deleteEndpoint(id: 1) }")
+		assert.NotEmpty(t, result.Errors)
+	})
+
+	t.Run("the deeply nested queries are rejected", func(t *testing.T) {
+		nested := "{ name }"
+		for i := 0; i < maxQueryDepth; i++ {
+			nested = "{ endpoints { tags " + nested + " } }"
+		}
+
+		code, _ := query(standard, nested)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func TestCheckQueryLimits(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(checkQueryLimits("{ endpoints { name tags { name endpoints { name } } } }"))
+	is.NoError(checkQueryLimits("{ endpoints {"), "the syntax errors are reported by the execution of the query")
+
+	nested := "{ tags { name } }"
+	for i := 0; i < maxQueryDepth; i++ {
+		nested = "{ tags { endpoints " + nested + " } }"
+	}
+	is.ErrorContains(checkQueryLimits(nested), "maximum depth")
+
+	fragments := `
+		query { tags { ...deep } }
+		fragment deep on Tag { endpoints { tags { endpoints { tags { ...deeper } } } } }
+		fragment deeper on Tag { endpoints { tags { endpoints { tags { endpoints { tags { name } } } } } } }
+	`
+	is.ErrorContains(checkQueryLimits(fragments), "maximum depth", "the fragments should be expanded")
+
+	is.ErrorContains(checkQueryLimits("query { tags { ...a } } fragment a on Tag { endpoints { tags { ...a } } }"), "cycle")
+
+	// the fragments multiply the fields selected by small queries
+	aliases := "{"
+	for i := 0; i < 30; i++ {
+		aliases += fmt.Sprintf(" t%d: tags { ...names }", i)
+	}
+	aliases += " } fragment names on Tag {" + strings.Repeat(" name", 40) + " }"
+	is.ErrorContains(checkQueryLimits(aliases), "maximum complexity")
+
+	is.ErrorIs(checkQueryLimits(strings.Repeat(" ", maxQuerySize+1)), errQueryTooLarge)
+}
@@ -0,0 +1,33 @@
+package graphql
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/gorilla/mux"
+	gql "github.com/graphql-go/graphql"
+)
+
+// Handler is the HTTP handler used to serve the read-only GraphQL endpoint over the inventory.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+	schema    func() (gql.Schema, error)
+}
+
+// NewHandler creates a handler to serve the GraphQL endpoint.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+		schema: sync.OnceValues(newSchema),
+	}
+
+	h.Handle("/graphql",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.graphqlQuery))).Methods(http.MethodPost)
+
+	return h
+}
@@ -0,0 +1,144 @@
+package graphql
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+)
+
+type inventoryKey struct{}
+
+// inventory loads the resources a user has access to, each collection being read from the database at most once per
+// query however many fields reference it
+type inventory struct {
+	isAdmin bool
+
+	endpoints func() ([]portainer.Endpoint, error)
+	groups    func() ([]portainer.EndpointGroup, error)
+	tags      func() ([]portainer.Tag, error)
+	snapshots func() (map[portainer.EndpointID]portainer.Snapshot, error)
+	stacks    func() ([]portainer.Stack, error)
+	users     func() ([]portainer.User, error)
+}
+
+func newInventory(dataStore dataservices.DataStore, securityContext *security.RestrictedRequestContext) *inventory {
+	allGroups := sync.OnceValues(dataStore.EndpointGroup().ReadAll)
+
+	return &inventory{
+		isAdmin: securityContext.IsAdmin,
+
+		endpoints: sync.OnceValues(func() ([]portainer.Endpoint, error) {
+			endpoints, err := dataStore.Endpoint().Endpoints()
+			if err != nil {
+				return nil, err
+			}
+
+			groups, err := allGroups()
+			if err != nil {
+				return nil, err
+			}
+
+			return security.FilterEndpoints(endpoints, groups, securityContext), nil
+		}),
+
+		groups: sync.OnceValues(func() ([]portainer.EndpointGroup, error) {
+			groups, err := allGroups()
+			if err != nil {
+				return nil, err
+			}
+
+			// the filter reuses the backing array of the groups, which are shared with the environments filter
+			return security.FilterEndpointGroups(slices.Clone(groups), securityContext), nil
+		}),
+
+		tags: sync.OnceValues(dataStore.Tag().ReadAll),
+
+		snapshots: sync.OnceValues(func() (map[portainer.EndpointID]portainer.Snapshot, error) {
+			snapshots, err := dataStore.Snapshot().ReadAll()
+			if err != nil {
+				return nil, err
+			}
+
+			snapshotsByEndpoint := make(map[portainer.EndpointID]portainer.Snapshot, len(snapshots))
+			for _, snapshot := range snapshots {
+				snapshotsByEndpoint[snapshot.EndpointID] = snapshot
+			}
+
+			return snapshotsByEndpoint, nil
+		}),
+
+		stacks: sync.OnceValues(func() ([]portainer.Stack, error) {
+			stacks, err := dataStore.Stack().ReadAll()
+			if err != nil {
+				return nil, err
+			}
+
+			resourceControls, err := dataStore.ResourceControl().ReadAll()
+			if err != nil {
+				return nil, err
+			}
+
+			stacks = authorization.DecorateStacks(stacks, resourceControls)
+			if securityContext.IsAdmin {
+				return stacks, nil
+			}
+
+			user, err := dataStore.User().Read(securityContext.UserID)
+			if err != nil {
+				return nil, err
+			}
+
+			userTeamIDs := make([]portainer.TeamID, 0, len(securityContext.UserMemberships))
+			for _, membership := range securityContext.UserMemberships {
+				userTeamIDs = append(userTeamIDs, membership.TeamID)
+			}
+
+			return authorization.FilterAuthorizedStacks(stacks, user, userTeamIDs), nil
+		}),
+
+		users: sync.OnceValues(dataStore.User().ReadAll),
+	}
+}
+
+func inventoryFromContext(ctx context.Context) *inventory {
+	return ctx.Value(inventoryKey{}).(*inventory)
+}
+
+// endpoint returns an environment the user has access to, nil when it does not exist or is not accessible
+func (inv *inventory) endpoint(endpointID portainer.EndpointID) (*portainer.Endpoint, error) {
+	endpoints, err := inv.endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(endpoints, func(endpoint portainer.Endpoint) bool {
+		return endpoint.ID == endpointID
+	})
+	if i == -1 {
+		return nil, nil
+	}
+
+	return &endpoints[i], nil
+}
+
+// group returns an environment group the user has access to, nil when it does not exist or is not accessible
+func (inv *inventory) group(groupID portainer.EndpointGroupID) (*portainer.EndpointGroup, error) {
+	groups, err := inv.groups()
+	if err != nil {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(groups, func(group portainer.EndpointGroup) bool {
+		return group.ID == groupID
+	})
+	if i == -1 {
+		return nil, nil
+	}
+
+	return &groups[i], nil
+}
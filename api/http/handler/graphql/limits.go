@@ -0,0 +1,122 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const (
+	// maxQuerySize is the maximum size in bytes of a query document
+	maxQuerySize = 16 << 10
+	// maxQueryDepth is the maximum nesting of the selected fields, the fragments being expanded
+	maxQueryDepth = 10
+	// maxQueryComplexity is the maximum number of fields selected by an operation, the fragments being expanded
+	maxQueryComplexity = 1000
+)
+
+var errQueryTooLarge = fmt.Errorf("the query exceeds %d bytes", maxQuerySize)
+
+// queryLimits walks the operations of a query document to measure their depth and complexity
+type queryLimits struct {
+	fragments  map[string]*ast.FragmentDefinition
+	expanding  map[string]bool
+	complexity int
+}
+
+// checkQueryLimits rejects the queries which are too large, too deeply nested or selecting too many fields, as the
+// relations of the schema allow the queries to select the same resources again and again. The documents which cannot
+// be parsed are left to the GraphQL execution, which reports their syntax errors.
+func checkQueryLimits(query string) error {
+	if len(query) > maxQuerySize {
+		return errQueryTooLarge
+	}
+
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return nil
+	}
+
+	limits := &queryLimits{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		expanding: make(map[string]bool),
+	}
+
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok && fragment.Name != nil {
+			limits.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		limits.complexity = 0
+		if err := limits.walk(operation.SelectionSet, 1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (limits *queryLimits) walk(selectionSet *ast.SelectionSet, depth int) error {
+	if selectionSet == nil {
+		return nil
+	}
+
+	if depth > maxQueryDepth {
+		return fmt.Errorf("the query exceeds the maximum depth of %d", maxQueryDepth)
+	}
+
+	for _, selection := range selectionSet.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			limits.complexity++
+			if limits.complexity > maxQueryComplexity {
+				return fmt.Errorf("the query exceeds the maximum complexity of %d fields", maxQueryComplexity)
+			}
+
+			if err := limits.walk(selection.SelectionSet, depth+1); err != nil {
+				return err
+			}
+
+		case *ast.InlineFragment:
+			if err := limits.walk(selection.SelectionSet, depth); err != nil {
+				return err
+			}
+
+		case *ast.FragmentSpread:
+			if selection.Name == nil {
+				continue
+			}
+
+			name := selection.Name.Value
+
+			fragment, ok := limits.fragments[name]
+			if !ok {
+				continue
+			}
+
+			// a cycle of fragments would be expanded endlessly
+			if limits.expanding[name] {
+				return errors.New("the query contains a cycle of fragments")
+			}
+
+			limits.expanding[name] = true
+			err := limits.walk(fragment.SelectionSet, depth)
+			limits.expanding[name] = false
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
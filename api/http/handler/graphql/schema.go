@@ -0,0 +1,537 @@
+package graphql
+
+import (
+	"errors"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+
+	gql "github.com/graphql-go/graphql"
+)
+
+// The views expose the resources with plain types, which the default resolvers of the GraphQL fields can serialize
+
+type endpointView struct {
+	ID              int
+	Name            string
+	Type            int
+	Status          int
+	URL             string
+	GroupID         int
+	TagIDs          []int
+	LastCheckInDate int64
+}
+
+type groupView struct {
+	ID          int
+	Name        string
+	Description string
+	TagIDs      []int
+}
+
+type tagView struct {
+	ID   int
+	Name string
+}
+
+type snapshotView struct {
+	EndpointID int
+	Docker     *portainer.DockerSnapshot
+	Kubernetes *portainer.KubernetesSnapshot
+}
+
+type stackView struct {
+	ID           int
+	Name         string
+	Type         int
+	Status       int
+	EndpointID   int
+	CreationDate int64
+	CreatedBy    string
+	UpdateDate   int64
+	UpdatedBy    string
+}
+
+type userView struct {
+	ID       int
+	Username string
+	Role     int
+}
+
+func toEndpointView(endpoint *portainer.Endpoint) *endpointView {
+	return &endpointView{
+		ID:              int(endpoint.ID),
+		Name:            endpoint.Name,
+		Type:            int(endpoint.Type),
+		Status:          int(endpoint.Status),
+		URL:             endpoint.URL,
+		GroupID:         int(endpoint.GroupID),
+		TagIDs:          toInts(endpoint.TagIDs),
+		LastCheckInDate: endpoint.LastCheckInDate,
+	}
+}
+
+func toGroupView(group *portainer.EndpointGroup) *groupView {
+	return &groupView{
+		ID:          int(group.ID),
+		Name:        group.Name,
+		Description: group.Description,
+		TagIDs:      toInts(group.TagIDs),
+	}
+}
+
+func toSnapshotView(snapshot *portainer.Snapshot) *snapshotView {
+	return &snapshotView{
+		EndpointID: int(snapshot.EndpointID),
+		Docker:     snapshot.Docker,
+		Kubernetes: snapshot.Kubernetes,
+	}
+}
+
+func toStackView(stack *portainer.Stack) *stackView {
+	return &stackView{
+		ID:           int(stack.ID),
+		Name:         stack.Name,
+		Type:         int(stack.Type),
+		Status:       int(stack.Status),
+		EndpointID:   int(stack.EndpointID),
+		CreationDate: stack.CreationDate,
+		CreatedBy:    stack.CreatedBy,
+		UpdateDate:   stack.UpdateDate,
+		UpdatedBy:    stack.UpdatedBy,
+	}
+}
+
+func toInts[T ~int](ids []T) []int {
+	ints := make([]int, 0, len(ids))
+	for _, id := range ids {
+		ints = append(ints, int(id))
+	}
+
+	return ints
+}
+
+func endpointViews(endpoints []portainer.Endpoint, match func(endpoint *portainer.Endpoint) bool) []*endpointView {
+	views := make([]*endpointView, 0)
+	for i := range endpoints {
+		if match(&endpoints[i]) {
+			views = append(views, toEndpointView(&endpoints[i]))
+		}
+	}
+
+	return views
+}
+
+func stackViews(stacks []portainer.Stack, match func(stack *portainer.Stack) bool) []*stackView {
+	views := make([]*stackView, 0)
+	for i := range stacks {
+		if match(&stacks[i]) {
+			views = append(views, toStackView(&stacks[i]))
+		}
+	}
+
+	return views
+}
+
+func tagViews(tags []portainer.Tag, tagIDs []int) []*tagView {
+	views := make([]*tagView, 0, len(tagIDs))
+	for _, tag := range tags {
+		if slices.Contains(tagIDs, int(tag.ID)) {
+			views = append(views, &tagView{ID: int(tag.ID), Name: tag.Name})
+		}
+	}
+
+	return views
+}
+
+func nonNullList(t gql.Type) gql.Output {
+	return gql.NewNonNull(gql.NewList(gql.NewNonNull(t)))
+}
+
+// newSchema builds the read-only schema of the inventory, made of a query type only so that any mutation is rejected
+func newSchema() (gql.Schema, error) {
+	var endpointType, groupType, tagType, snapshotType, stackType *gql.Object
+
+	dockerSnapshotType := gql.NewObject(gql.ObjectConfig{
+		Name: "DockerSnapshot",
+		Fields: gql.Fields{
+			"time":                    &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"dockerVersion":           &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"swarm":                   &gql.Field{Type: gql.NewNonNull(gql.Boolean)},
+			"totalCpu":                &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"totalMemory":             &gql.Field{Type: gql.NewNonNull(gql.Float), Description: "Total memory in bytes"},
+			"runningContainerCount":   &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"stoppedContainerCount":   &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"healthyContainerCount":   &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"unhealthyContainerCount": &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"volumeCount":             &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"imageCount":              &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"serviceCount":            &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"stackCount":              &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"nodeCount":               &gql.Field{Type: gql.NewNonNull(gql.Int)},
+		},
+	})
+
+	kubernetesSnapshotType := gql.NewObject(gql.ObjectConfig{
+		Name: "KubernetesSnapshot",
+		Fields: gql.Fields{
+			"time":              &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"kubernetesVersion": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"nodeCount":         &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"totalCpu":          &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"totalMemory":       &gql.Field{Type: gql.NewNonNull(gql.Float), Description: "Total memory in bytes"},
+		},
+	})
+
+	endpointType = gql.NewObject(gql.ObjectConfig{
+		Name: "Endpoint",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"id":              &gql.Field{Type: gql.NewNonNull(gql.Int)},
+				"name":            &gql.Field{Type: gql.NewNonNull(gql.String)},
+				"type":            &gql.Field{Type: gql.NewNonNull(gql.Int)},
+				"status":          &gql.Field{Type: gql.NewNonNull(gql.Int), Description: "1 (up) or 2 (down)"},
+				"url":             &gql.Field{Type: gql.NewNonNull(gql.String)},
+				"groupId":         &gql.Field{Type: gql.NewNonNull(gql.Int)},
+				"tagIds":          &gql.Field{Type: nonNullList(gql.Int)},
+				"lastCheckInDate": &gql.Field{Type: gql.NewNonNull(gql.Int)},
+				"group": &gql.Field{
+					Type: groupType,
+					Resolve: func(p gql.ResolveParams) (any, error) {
+						group, err := inventoryFromContext(p.Context).group(portainer.EndpointGroupID(p.Source.(*endpointView).GroupID))
+						if err != nil || group == nil {
+							return nil, err
+						}
+
+						return toGroupView(group), nil
+					},
+				},
+				"tags": &gql.Field{
+					Type: nonNullList(tagType),
+					Resolve: func(p gql.ResolveParams) (any, error) {
+						tags, err := inventoryFromContext(p.Context).tags()
+						if err != nil {
+							return nil, err
+						}
+
+						return tagViews(tags, p.Source.(*endpointView).TagIDs), nil
+					},
+				},
+				"snapshot": &gql.Field{
+					Type: snapshotType,
+					Resolve: func(p gql.ResolveParams) (any, error) {
+						snapshots, err := inventoryFromContext(p.Context).snapshots()
+						if err != nil {
+							return nil, err
+						}
+
+						snapshot, ok := snapshots[portainer.EndpointID(p.Source.(*endpointView).ID)]
+						if !ok {
+							return nil, nil
+						}
+
+						return toSnapshotView(&snapshot), nil
+					},
+				},
+				"stacks": &gql.Field{
+					Type: nonNullList(stackType),
+					Resolve: func(p gql.ResolveParams) (any, error) {
+						stacks, err := inventoryFromContext(p.Context).stacks()
+						if err != nil {
+							return nil, err
+						}
+
+						endpointID := portainer.EndpointID(p.Source.(*endpointView).ID)
+
+						return stackViews(stacks, func(stack *portainer.Stack) bool {
+							return stack.EndpointID == endpointID
+						}), nil
+					},
+				},
+			}
+		}),
+	})
+
+	groupType = gql.NewObject(gql.ObjectConfig{
+		Name: "EndpointGroup",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"id":          &gql.Field{Type: gql.NewNonNull(gql.Int)},
+				"name":        &gql.Field{Type: gql.NewNonNull(gql.String)},
+				"description": &gql.Field{Type: gql.NewNonNull(gql.String)},
+				"tagIds":      &gql.Field{Type: nonNullList(gql.Int)},
+				"tags": &gql.Field{
+					Type: nonNullList(tagType),
+					Resolve: func(p gql.ResolveParams) (any, error) {
+						tags, err := inventoryFromContext(p.Context).tags()
+						if err != nil {
+							return nil, err
+						}
+
+						return tagViews(tags, p.Source.(*groupView).TagIDs), nil
+					},
+				},
+				"endpoints": &gql.Field{
+					Type:        nonNullList(endpointType),
+					Description: "The environments of the group the user has access to",
+					Resolve: func(p gql.ResolveParams) (any, error) {
+						endpoints, err := inventoryFromContext(p.Context).endpoints()
+						if err != nil {
+							return nil, err
+						}
+
+						groupID := portainer.EndpointGroupID(p.Source.(*groupView).ID)
+
+						return endpointViews(endpoints, func(endpoint *portainer.Endpoint) bool {
+							return endpoint.GroupID == groupID
+						}), nil
+					},
+				},
+			}
+		}),
+	})
+
+	tagType = gql.NewObject(gql.ObjectConfig{
+		Name: "Tag",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"id":   &gql.Field{Type: gql.NewNonNull(gql.Int)},
+				"name": &gql.Field{Type: gql.NewNonNull(gql.String)},
+				"endpoints": &gql.Field{
+					Type:        nonNullList(endpointType),
+					Description: "The environments having the tag the user has access to",
+					Resolve: func(p gql.ResolveParams) (any, error) {
+						endpoints, err := inventoryFromContext(p.Context).endpoints()
+						if err != nil {
+							return nil, err
+						}
+
+						tagID := portainer.TagID(p.Source.(*tagView).ID)
+
+						return endpointViews(endpoints, func(endpoint *portainer.Endpoint) bool {
+							return slices.Contains(endpoint.TagIDs, tagID)
+						}), nil
+					},
+				},
+			}
+		}),
+	})
+
+	snapshotType = gql.NewObject(gql.ObjectConfig{
+		Name: "Snapshot",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"endpointId": &gql.Field{Type: gql.NewNonNull(gql.Int)},
+				"docker":     &gql.Field{Type: dockerSnapshotType},
+				"kubernetes": &gql.Field{Type: kubernetesSnapshotType},
+				"endpoint": &gql.Field{
+					Type: endpointType,
+					Resolve: func(p gql.ResolveParams) (any, error) {
+						return resolveEndpoint(p, portainer.EndpointID(p.Source.(*snapshotView).EndpointID))
+					},
+				},
+			}
+		}),
+	})
+
+	stackType = gql.NewObject(gql.ObjectConfig{
+		Name: "Stack",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"id":           &gql.Field{Type: gql.NewNonNull(gql.Int)},
+				"name":         &gql.Field{Type: gql.NewNonNull(gql.String)},
+				"type":         &gql.Field{Type: gql.NewNonNull(gql.Int), Description: "1 (swarm), 2 (compose) or 3 (kubernetes)"},
+				"status":       &gql.Field{Type: gql.NewNonNull(gql.Int), Description: "1 (active) or 2 (inactive)"},
+				"endpointId":   &gql.Field{Type: gql.NewNonNull(gql.Int)},
+				"creationDate": &gql.Field{Type: gql.NewNonNull(gql.Int)},
+				"createdBy":    &gql.Field{Type: gql.NewNonNull(gql.String)},
+				"updateDate":   &gql.Field{Type: gql.NewNonNull(gql.Int)},
+				"updatedBy":    &gql.Field{Type: gql.NewNonNull(gql.String)},
+				"endpoint": &gql.Field{
+					Type:        endpointType,
+					Description: "The environment of the stack, null when the user has no access to it",
+					Resolve: func(p gql.ResolveParams) (any, error) {
+						return resolveEndpoint(p, portainer.EndpointID(p.Source.(*stackView).EndpointID))
+					},
+				},
+			}
+		}),
+	})
+
+	userType := gql.NewObject(gql.ObjectConfig{
+		Name: "User",
+		Fields: gql.Fields{
+			"id":       &gql.Field{Type: gql.NewNonNull(gql.Int)},
+			"username": &gql.Field{Type: gql.NewNonNull(gql.String)},
+			"role":     &gql.Field{Type: gql.NewNonNull(gql.Int), Description: "1 (administrator) or 2 (standard user)"},
+		},
+	})
+
+	queryType := gql.NewObject(gql.ObjectConfig{
+		Name: "Query",
+		Fields: gql.Fields{
+			"endpoints": &gql.Field{
+				Type:        nonNullList(endpointType),
+				Description: "The environments the user has access to",
+				Args: gql.FieldConfigArgument{
+					"name":    &gql.ArgumentConfig{Type: gql.String, Description: "Only list the environment with this exact name"},
+					"groupId": &gql.ArgumentConfig{Type: gql.Int, Description: "Only list the environments of this group"},
+					"tagIds":  &gql.ArgumentConfig{Type: gql.NewList(gql.NewNonNull(gql.Int)), Description: "Only list the environments having all these tags"},
+				},
+				Resolve: resolveEndpoints,
+			},
+			"endpoint": &gql.Field{
+				Type:        endpointType,
+				Description: "An environment the user has access to",
+				Args: gql.FieldConfigArgument{
+					"id": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.Int)},
+				},
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					return resolveEndpoint(p, portainer.EndpointID(p.Args["id"].(int)))
+				},
+			},
+			"endpointGroups": &gql.Field{
+				Type:        nonNullList(groupType),
+				Description: "The environment groups the user has access to",
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					groups, err := inventoryFromContext(p.Context).groups()
+					if err != nil {
+						return nil, err
+					}
+
+					views := make([]*groupView, 0, len(groups))
+					for i := range groups {
+						views = append(views, toGroupView(&groups[i]))
+					}
+
+					return views, nil
+				},
+			},
+			"tags": &gql.Field{
+				Type: nonNullList(tagType),
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					tags, err := inventoryFromContext(p.Context).tags()
+					if err != nil {
+						return nil, err
+					}
+
+					views := make([]*tagView, 0, len(tags))
+					for _, tag := range tags {
+						views = append(views, &tagView{ID: int(tag.ID), Name: tag.Name})
+					}
+
+					return views, nil
+				},
+			},
+			"snapshots": &gql.Field{
+				Type:        nonNullList(snapshotType),
+				Description: "The snapshots of the environments the user has access to",
+				Resolve:     resolveSnapshots,
+			},
+			"stacks": &gql.Field{
+				Type:        nonNullList(stackType),
+				Description: "The stacks the user has access to",
+				Args: gql.FieldConfigArgument{
+					"endpointId": &gql.ArgumentConfig{Type: gql.Int, Description: "Only list the stacks of this environment"},
+				},
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					stacks, err := inventoryFromContext(p.Context).stacks()
+					if err != nil {
+						return nil, err
+					}
+
+					endpointID, filtered := p.Args["endpointId"].(int)
+
+					return stackViews(stacks, func(stack *portainer.Stack) bool {
+						return !filtered || stack.EndpointID == portainer.EndpointID(endpointID)
+					}), nil
+				},
+			},
+			"users": &gql.Field{
+				Type:        gql.NewList(gql.NewNonNull(userType)),
+				Description: "The users, restricted to the administrators",
+				Resolve: func(p gql.ResolveParams) (any, error) {
+					inv := inventoryFromContext(p.Context)
+					if !inv.isAdmin {
+						return nil, errors.New("permission denied to access the users list")
+					}
+
+					users, err := inv.users()
+					if err != nil {
+						return nil, err
+					}
+
+					views := make([]*userView, 0, len(users))
+					for _, user := range users {
+						views = append(views, &userView{ID: int(user.ID), Username: user.Username, Role: int(user.Role)})
+					}
+
+					return views, nil
+				},
+			},
+		},
+	})
+
+	return gql.NewSchema(gql.SchemaConfig{Query: queryType})
+}
+
+func resolveEndpoint(p gql.ResolveParams, endpointID portainer.EndpointID) (any, error) {
+	endpoint, err := inventoryFromContext(p.Context).endpoint(endpointID)
+	if err != nil || endpoint == nil {
+		return nil, err
+	}
+
+	return toEndpointView(endpoint), nil
+}
+
+func resolveEndpoints(p gql.ResolveParams) (any, error) {
+	endpoints, err := inventoryFromContext(p.Context).endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := p.Args["name"].(string)
+	groupID, filterGroup := p.Args["groupId"].(int)
+	tagIDs, _ := p.Args["tagIds"].([]any)
+
+	return endpointViews(endpoints, func(endpoint *portainer.Endpoint) bool {
+		if name != "" && endpoint.Name != name {
+			return false
+		}
+
+		if filterGroup && endpoint.GroupID != portainer.EndpointGroupID(groupID) {
+			return false
+		}
+
+		for _, tagID := range tagIDs {
+			if !slices.Contains(endpoint.TagIDs, portainer.TagID(tagID.(int))) {
+				return false
+			}
+		}
+
+		return true
+	}), nil
+}
+
+func resolveSnapshots(p gql.ResolveParams) (any, error) {
+	inv := inventoryFromContext(p.Context)
+
+	endpoints, err := inv.endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := inv.snapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]*snapshotView, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if snapshot, ok := snapshots[endpoint.ID]; ok {
+			views = append(views, toSnapshotView(&snapshot))
+		}
+	}
+
+	return views, nil
+}
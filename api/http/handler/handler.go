@@ -5,7 +5,13 @@ import (
 	"strings"
 
 	"github.com/portainer/portainer/api/http/handler/auth"
+	"github.com/portainer/portainer/api/http/handler/automationwebhooks"
+	"github.com/portainer/portainer/api/http/handler/backgroundjobs"
 	"github.com/portainer/portainer/api/http/handler/backup"
+	"github.com/portainer/portainer/api/http/handler/changerequests"
+	"github.com/portainer/portainer/api/http/handler/chatops"
+	"github.com/portainer/portainer/api/http/handler/cmdbconnectors"
+	"github.com/portainer/portainer/api/http/handler/costs"
 	"github.com/portainer/portainer/api/http/handler/customtemplates"
 	"github.com/portainer/portainer/api/http/handler/docker"
 	"github.com/portainer/portainer/api/http/handler/edgegroups"
@@ -16,19 +22,28 @@ import (
 	"github.com/portainer/portainer/api/http/handler/endpointgroups"
 	"github.com/portainer/portainer/api/http/handler/endpointproxy"
 	"github.com/portainer/portainer/api/http/handler/endpoints"
+	"github.com/portainer/portainer/api/http/handler/eventwebhooks"
 	"github.com/portainer/portainer/api/http/handler/file"
 	"github.com/portainer/portainer/api/http/handler/gitops"
+	"github.com/portainer/portainer/api/http/handler/graphql"
 	"github.com/portainer/portainer/api/http/handler/helm"
 	"github.com/portainer/portainer/api/http/handler/hostmanagement/fdo"
 	"github.com/portainer/portainer/api/http/handler/hostmanagement/openamt"
+	"github.com/portainer/portainer/api/http/handler/imageupdates"
 	"github.com/portainer/portainer/api/http/handler/kubernetes"
 	"github.com/portainer/portainer/api/http/handler/ldap"
+	"github.com/portainer/portainer/api/http/handler/maintenancewindows"
 	"github.com/portainer/portainer/api/http/handler/motd"
+	"github.com/portainer/portainer/api/http/handler/notes"
+	"github.com/portainer/portainer/api/http/handler/ownershiprules"
 	"github.com/portainer/portainer/api/http/handler/registries"
 	"github.com/portainer/portainer/api/http/handler/resourcecontrols"
 	"github.com/portainer/portainer/api/http/handler/roles"
+	"github.com/portainer/portainer/api/http/handler/savedviews"
+	"github.com/portainer/portainer/api/http/handler/securityalerts"
 	"github.com/portainer/portainer/api/http/handler/settings"
 	"github.com/portainer/portainer/api/http/handler/ssl"
+	"github.com/portainer/portainer/api/http/handler/stackbundles"
 	"github.com/portainer/portainer/api/http/handler/stacks"
 	"github.com/portainer/portainer/api/http/handler/storybook"
 	"github.com/portainer/portainer/api/http/handler/system"
@@ -38,49 +53,66 @@ import (
 	"github.com/portainer/portainer/api/http/handler/templates"
 	"github.com/portainer/portainer/api/http/handler/upload"
 	"github.com/portainer/portainer/api/http/handler/users"
+	"github.com/portainer/portainer/api/http/handler/volumebackups"
 	"github.com/portainer/portainer/api/http/handler/webhooks"
 	"github.com/portainer/portainer/api/http/handler/websocket"
 )
 
 // Handler is a collection of all the service handlers.
 type Handler struct {
-	AuthHandler            *auth.Handler
-	BackupHandler          *backup.Handler
-	CustomTemplatesHandler *customtemplates.Handler
-	DockerHandler          *docker.Handler
-	EdgeGroupsHandler      *edgegroups.Handler
-	EdgeJobsHandler        *edgejobs.Handler
-	EdgeStacksHandler      *edgestacks.Handler
-	EdgeTemplatesHandler   *edgetemplates.Handler
-	EndpointEdgeHandler    *endpointedge.Handler
-	EndpointGroupHandler   *endpointgroups.Handler
-	EndpointHandler        *endpoints.Handler
-	EndpointHelmHandler    *helm.Handler
-	EndpointProxyHandler   *endpointproxy.Handler
-	GitOperationHandler    *gitops.Handler
-	HelmTemplatesHandler   *helm.Handler
-	KubernetesHandler      *kubernetes.Handler
-	FileHandler            *file.Handler
-	LDAPHandler            *ldap.Handler
-	MOTDHandler            *motd.Handler
-	RegistryHandler        *registries.Handler
-	ResourceControlHandler *resourcecontrols.Handler
-	RoleHandler            *roles.Handler
-	SettingsHandler        *settings.Handler
-	SSLHandler             *ssl.Handler
-	OpenAMTHandler         *openamt.Handler
-	FDOHandler             *fdo.Handler
-	StackHandler           *stacks.Handler
-	StorybookHandler       *storybook.Handler
-	SystemHandler          *system.Handler
-	TagHandler             *tags.Handler
-	TeamMembershipHandler  *teammemberships.Handler
-	TeamHandler            *teams.Handler
-	TemplatesHandler       *templates.Handler
-	UploadHandler          *upload.Handler
-	UserHandler            *users.Handler
-	WebSocketHandler       *websocket.Handler
-	WebhookHandler         *webhooks.Handler
+	AuthHandler              *auth.Handler
+	AutomationWebhookHandler *automationwebhooks.Handler
+	ChangeRequestHandler     *changerequests.Handler
+	BackgroundJobHandler     *backgroundjobs.Handler
+	BackupHandler            *backup.Handler
+	ChatOpsHandler           *chatops.Handler
+	CMDBConnectorHandler     *cmdbconnectors.Handler
+	CostHandler              *costs.Handler
+	CustomTemplatesHandler   *customtemplates.Handler
+	DockerHandler            *docker.Handler
+	EdgeGroupsHandler        *edgegroups.Handler
+	EdgeJobsHandler          *edgejobs.Handler
+	EdgeStacksHandler        *edgestacks.Handler
+	EdgeTemplatesHandler     *edgetemplates.Handler
+	EndpointEdgeHandler      *endpointedge.Handler
+	EndpointGroupHandler     *endpointgroups.Handler
+	EndpointHandler          *endpoints.Handler
+	EndpointHelmHandler      *helm.Handler
+	EndpointProxyHandler     *endpointproxy.Handler
+	EventWebhookHandler      *eventwebhooks.Handler
+	GitOperationHandler      *gitops.Handler
+	GraphQLHandler           *graphql.Handler
+	HelmTemplatesHandler     *helm.Handler
+	ImageUpdateHandler       *imageupdates.Handler
+	KubernetesHandler        *kubernetes.Handler
+	FileHandler              *file.Handler
+	LDAPHandler              *ldap.Handler
+	MOTDHandler              *motd.Handler
+	NoteHandler              *notes.Handler
+	OwnershipRuleHandler     *ownershiprules.Handler
+	MaintenanceWindowHandler *maintenancewindows.Handler
+	RegistryHandler          *registries.Handler
+	ResourceControlHandler   *resourcecontrols.Handler
+	RoleHandler              *roles.Handler
+	SavedViewHandler         *savedviews.Handler
+	SecurityAlertHandler     *securityalerts.Handler
+	SettingsHandler          *settings.Handler
+	SSLHandler               *ssl.Handler
+	OpenAMTHandler           *openamt.Handler
+	FDOHandler               *fdo.Handler
+	StackBundleHandler       *stackbundles.Handler
+	StackHandler             *stacks.Handler
+	StorybookHandler         *storybook.Handler
+	SystemHandler            *system.Handler
+	TagHandler               *tags.Handler
+	TeamMembershipHandler    *teammemberships.Handler
+	TeamHandler              *teams.Handler
+	TemplatesHandler         *templates.Handler
+	UploadHandler            *upload.Handler
+	UserHandler              *users.Handler
+	VolumeBackupHandler      *volumebackups.Handler
+	WebSocketHandler         *websocket.Handler
+	WebhookHandler           *webhooks.Handler
 }
 
 // @title PortainerCE API
@@ -107,8 +139,14 @@ type Handler struct {
 
 // @tag.name auth
 // @tag.description Authenticate against Portainer HTTP API
+// @tag.name background_jobs
+// @tag.description Manage the jobs of the background job queue
 // @tag.name backup
 // @tag.description Manage backups
+// @tag.name cmdb_connectors
+// @tag.description Manage the connectors synchronizing the inventory to a CMDB
+// @tag.name costs
+// @tag.description Estimate the costs of the stacks
 // @tag.name custom_templates
 // @tag.description Manage Custom Templates
 // @tag.name docker
@@ -127,6 +165,10 @@ type Handler struct {
 // @tag.description Manage environment(endpoint) groups
 // @tag.name endpoints
 // @tag.description Manage Docker environments(endpoints)
+// @tag.name event_webhooks
+// @tag.description Manage the webhooks notified of the lifecycle events
+// @tag.name image_update_policies
+// @tag.description Manage the automatic updates of the containers and stacks when a new digest of their images is available
 // @tag.name gitops
 // @tag.description Operate git repository
 // @tag.name helm
@@ -145,10 +187,14 @@ type Handler struct {
 // @tag.description Manage access control on Docker resources
 // @tag.name roles
 // @tag.description Manage roles
+// @tag.name security_alerts
+// @tag.description Review the security alerts raised by the suspicious authentication events
 // @tag.name settings
 // @tag.description Manage Portainer settings
 // @tag.name ssl
 // @tag.description Manage ssl settings
+// @tag.name stack_bundles
+// @tag.description Manage the bundles of stacks brought up and torn down as a unit
 // @tag.name stacks
 // @tag.description Manage stacks
 // @tag.name status
@@ -167,6 +213,8 @@ type Handler struct {
 // @tag.description Upload files
 // @tag.name users
 // @tag.description Manage users
+// @tag.name volume_backups
+// @tag.description Manage the backups of the Docker volumes
 // @tag.name webhooks
 // @tag.description Manage webhooks
 // @tag.name websocket
@@ -179,10 +227,22 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.EndpointEdgeHandler.ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/auth"):
 		http.StripPrefix("/api", h.AuthHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/automation_webhooks"):
+		http.StripPrefix("/api", h.AutomationWebhookHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/change_requests"):
+		http.StripPrefix("/api", h.ChangeRequestHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/chatops"):
+		http.StripPrefix("/api", h.ChatOpsHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/background_jobs"):
+		http.StripPrefix("/api", h.BackgroundJobHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/backup"):
 		http.StripPrefix("/api", h.BackupHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/restore"):
 		http.StripPrefix("/api", h.BackupHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/cmdb_connectors"):
+		http.StripPrefix("/api", h.CMDBConnectorHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/costs"):
+		http.StripPrefix("/api", h.CostHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/custom_templates"):
 		http.StripPrefix("/api", h.CustomTemplatesHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/edge_stacks"):
@@ -197,6 +257,8 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.StripPrefix("/api", h.EndpointGroupHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/kubernetes"):
 		http.StripPrefix("/api", h.KubernetesHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/dashboard"):
+		http.StripPrefix("/api", h.EndpointHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/docker"):
 		http.StripPrefix("/api/docker", h.DockerHandler).ServeHTTP(w, r)
 
@@ -204,6 +266,16 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case strings.HasPrefix(r.URL.Path, "/api/endpoints/") && strings.Contains(r.URL.Path, "/kubernetes/helm"):
 		http.StripPrefix("/api/endpoints", h.EndpointHelmHandler).ServeHTTP(w, r)
 
+	// Manifest apply under kubernetes -> /api/endpoints/{id}/kubernetes/apply
+	case strings.HasPrefix(r.URL.Path, "/api/endpoints/") && strings.HasSuffix(r.URL.Path, "/kubernetes/apply"):
+		http.StripPrefix("/api", h.StackHandler).ServeHTTP(w, r)
+
+	// Container migration under docker -> /api/endpoints/{id}/docker/containers/{containerId}/migrate
+	case strings.HasPrefix(r.URL.Path, "/api/endpoints/") && strings.Contains(r.URL.Path, "/docker/containers/") && strings.HasSuffix(r.URL.Path, "/migrate"):
+		r.URL.Path = strings.Replace(strings.TrimPrefix(r.URL.Path, "/api/endpoints"), "/docker/containers/", "/containers/", 1)
+		r.URL.RawPath = ""
+		h.DockerHandler.ServeHTTP(w, r)
+
 	case strings.HasPrefix(r.URL.Path, "/api/endpoints"):
 		switch {
 		case strings.Contains(r.URL.Path, "/docker/"):
@@ -217,20 +289,38 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		default:
 			http.StripPrefix("/api", h.EndpointHandler).ServeHTTP(w, r)
 		}
+	case strings.HasPrefix(r.URL.Path, "/api/event_webhooks"):
+		http.StripPrefix("/api", h.EventWebhookHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/gitops"):
 		http.StripPrefix("/api", h.GitOperationHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/graphql"):
+		http.StripPrefix("/api", h.GraphQLHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/image_update_policies"):
+		http.StripPrefix("/api", h.ImageUpdateHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/ldap"):
 		http.StripPrefix("/api", h.LDAPHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/motd"):
 		http.StripPrefix("/api", h.MOTDHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/notes"):
+		http.StripPrefix("/api", h.NoteHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/ownership_rules"):
+		http.StripPrefix("/api", h.OwnershipRuleHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/registries"):
 		http.StripPrefix("/api", h.RegistryHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/maintenance_windows"):
+		http.StripPrefix("/api", h.MaintenanceWindowHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/resource_controls"):
 		http.StripPrefix("/api", h.ResourceControlHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/saved_views"):
+		http.StripPrefix("/api", h.SavedViewHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/security_alerts"):
+		http.StripPrefix("/api", h.SecurityAlertHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/roles"):
 		http.StripPrefix("/api", h.RoleHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/settings"):
 		http.StripPrefix("/api", h.SettingsHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/stack_bundles"):
+		http.StripPrefix("/api", h.StackBundleHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/stacks"):
 		http.StripPrefix("/api", h.StackHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/status"):
@@ -257,6 +347,8 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.StripPrefix("/api", h.TeamHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/team_memberships"):
 		http.StripPrefix("/api", h.TeamMembershipHandler).ServeHTTP(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/volume_backups"):
+		http.StripPrefix("/api", h.VolumeBackupHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/websocket"):
 		http.StripPrefix("/api", h.WebSocketHandler).ServeHTTP(w, r)
 	case strings.HasPrefix(r.URL.Path, "/api/webhooks"):
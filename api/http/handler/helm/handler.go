@@ -48,6 +48,10 @@ func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStor
 	h.Handle("/{id}/kubernetes/helm/{release}",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.helmDelete))).Methods(http.MethodDelete)
 
+	// `helm upgrade RELEASE_NAME [CHART] --install flags`
+	h.Handle("/{id}/kubernetes/helm/{release}",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.helmUpgrade))).Methods(http.MethodPut)
+
 	// `helm install [NAME] [CHART] flags`
 	h.Handle("/{id}/kubernetes/helm",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.helmInstall))).Methods(http.MethodPost)
@@ -56,6 +60,8 @@ func NewHandler(bouncer security.BouncerService, dataStore dataservices.DataStor
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.userGetHelmRepos))).Methods(http.MethodGet)
 	h.Handle("/{id}/kubernetes/helm/repositories",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.userCreateHelmRepo))).Methods(http.MethodPost)
+	h.Handle("/{id}/kubernetes/helm/repositories/{repositoryID}",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.userDeleteHelmRepo))).Methods(http.MethodDelete)
 
 	return h
 }
@@ -91,6 +91,12 @@ func (p *installChartPayload) Validate(_ *http.Request) error {
 }
 
 func (handler *Handler) installChart(r *http.Request, p installChartPayload) (*release.Release, error) {
+	return handler.deployChart(r, p, handler.helmPackageManager.Install)
+}
+
+// deployChart runs the given helm deployment operation (install or upgrade) and
+// labels the resources of the resulting release manifest as managed by portainer.
+func (handler *Handler) deployChart(r *http.Request, p installChartPayload, deploy func(options.InstallOptions) (*release.Release, error)) (*release.Release, error) {
 	clusterAccess, httperr := handler.getHelmClusterAccess(r)
 	if httperr != nil {
 		return nil, httperr.Err
@@ -125,7 +131,7 @@ func (handler *Handler) installChart(r *http.Request, p installChartPayload) (*r
 		installOpts.ValuesFile = file.Name()
 	}
 
-	release, err := handler.helmPackageManager.Install(installOpts)
+	release, err := deploy(installOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,86 @@
+package helm
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/portainer/portainer/api/kubernetes/validation"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type upgradeChartPayload struct {
+	Namespace string `json:"namespace"`
+	Chart     string `json:"chart"`
+	Repo      string `json:"repo"`
+	Values    string `json:"values"`
+}
+
+func (p *upgradeChartPayload) Validate(_ *http.Request) error {
+	var required []string
+	if p.Repo == "" {
+		required = append(required, "repo")
+	}
+	if p.Namespace == "" {
+		required = append(required, "namespace")
+	}
+	if p.Chart == "" {
+		required = append(required, "chart")
+	}
+	if len(required) > 0 {
+		return fmt.Errorf("required field(s) missing: %s", strings.Join(required, ", "))
+	}
+
+	return nil
+}
+
+// @id HelmUpgrade
+// @summary Upgrade Helm Release
+// @description Upgrade a release to a new chart version and/or new values. The release is installed if it does not exist.
+// @description **Access policy**: authenticated
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param release path string true "The name of the release/application to upgrade"
+// @param payload body upgradeChartPayload true "Chart details"
+// @success 200 {object} release.Release "Success"
+// @failure 400 "Invalid request"
+// @failure 401 "Unauthorized"
+// @failure 404 "Environment(Endpoint) or ServiceAccount not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/kubernetes/helm/{release} [put]
+func (handler *Handler) helmUpgrade(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	releaseName, err := request.RetrieveRouteVariableValue(r, "release")
+	if err != nil {
+		return httperror.BadRequest("No release specified", err)
+	}
+
+	// the release name is passed as a positional argument of the helm binary, it must not be read as a flag
+	if errs := validation.IsDNS1123Subdomain(releaseName); len(errs) > 0 {
+		return httperror.BadRequest("Invalid release name", errChartNameInvalid)
+	}
+
+	var payload upgradeChartPayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid Helm upgrade payload", err)
+	}
+
+	release, err := handler.deployChart(r, installChartPayload{
+		Name:      releaseName,
+		Namespace: payload.Namespace,
+		Chart:     payload.Chart,
+		Repo:      payload.Repo,
+		Values:    payload.Values,
+	}, handler.helmPackageManager.Upgrade)
+	if err != nil {
+		return httperror.InternalServerError("Unable to upgrade the release", err)
+	}
+
+	return response.JSON(w, release)
+}
@@ -123,3 +123,48 @@ func (handler *Handler) userGetHelmRepos(w http.ResponseWriter, r *http.Request)
 
 	return response.JSON(w, resp)
 }
+
+// @id HelmUserRepositoryDelete
+// @summary Delete a user helm repository
+// @description Delete a helm repository of the current user.
+// @description **Access policy**: authenticated
+// @tags helm
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Environment(Endpoint) identifier"
+// @param repositoryID path int true "Helm repository identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Helm repository not found"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/kubernetes/helm/repositories/{repositoryID} [delete]
+func (handler *Handler) userDeleteHelmRepo(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	repositoryID, err := request.RetrieveNumericRouteVariableValue(r, "repositoryID")
+	if err != nil {
+		return httperror.BadRequest("Invalid Helm repository identifier route variable", err)
+	}
+
+	record, err := handler.dataStore.HelmUserRepository().Read(portainer.HelmUserRepositoryID(repositoryID))
+	if handler.dataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a Helm repository with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a Helm repository with the specified identifier inside the database", err)
+	}
+
+	if record.UserID != tokenData.ID {
+		return httperror.Forbidden("Permission denied to delete this Helm repository", errors.New("the Helm repository belongs to another user"))
+	}
+
+	err = handler.dataStore.HelmUserRepository().Delete(record.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the Helm repository from the database", err)
+	}
+
+	return response.Empty(w)
+}
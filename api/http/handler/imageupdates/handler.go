@@ -0,0 +1,57 @@
+package imageupdates
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/imageupdates"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to handle image update policy operations.
+type Handler struct {
+	*mux.Router
+	DataStore          dataservices.DataStore
+	ImageUpdateService *imageupdates.Service
+}
+
+// NewHandler creates a handler to manage image update policy operations.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+
+	adminRouter := h.NewRoute().Subrouter()
+	adminRouter.Use(bouncer.AdminAccess)
+
+	adminRouter.Handle("/image_update_policies", httperror.LoggerHandler(h.imageUpdatePolicyCreate)).Methods(http.MethodPost)
+	adminRouter.Handle("/image_update_policies", httperror.LoggerHandler(h.imageUpdatePolicyList)).Methods(http.MethodGet)
+	adminRouter.Handle("/image_update_policies/{id}", httperror.LoggerHandler(h.imageUpdatePolicyInspect)).Methods(http.MethodGet)
+	adminRouter.Handle("/image_update_policies/{id}", httperror.LoggerHandler(h.imageUpdatePolicyUpdate)).Methods(http.MethodPut)
+	adminRouter.Handle("/image_update_policies/{id}", httperror.LoggerHandler(h.imageUpdatePolicyDelete)).Methods(http.MethodDelete)
+	adminRouter.Handle("/image_update_policies/{id}/history", httperror.LoggerHandler(h.imageUpdatePolicyHistory)).Methods(http.MethodGet)
+	adminRouter.Handle("/image_update_policies/{id}/check", httperror.LoggerHandler(h.imageUpdatePolicyCheck)).Methods(http.MethodPost)
+
+	return h
+}
+
+func (handler *Handler) policyFromRequest(r *http.Request) (*portainer.ImageUpdatePolicy, *httperror.HandlerError) {
+	policyID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid image update policy identifier route variable", err)
+	}
+
+	policy, err := handler.DataStore.ImageUpdatePolicy().Read(portainer.ImageUpdatePolicyID(policyID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find an image update policy with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find an image update policy with the specified identifier inside the database", err)
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,42 @@
+package imageupdates
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ImageUpdatePolicyCheck
+// @summary Check the images of the resource of an image update policy
+// @description Check the registry now and update the resource when a new digest of its images is available, even when the policy is disabled.
+// @description The update is returned, no content is returned when the images are up to date.
+// @description **Access policy**: administrator
+// @tags image_update_policies
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Image update policy identifier"
+// @success 200 {object} portainer.ImageUpdateRecord "Updated"
+// @success 204 "Up to date"
+// @failure 400 "Invalid request"
+// @failure 404 "Image update policy not found"
+// @failure 500 "Server error"
+// @router /image_update_policies/{id}/check [post]
+func (handler *Handler) imageUpdatePolicyCheck(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	policy, httpErr := handler.policyFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	record, err := handler.ImageUpdateService.Check(r.Context(), policy.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to check the images of the resource", err)
+	}
+
+	if record == nil {
+		return response.Empty(w)
+	}
+
+	return response.JSON(w, record)
+}
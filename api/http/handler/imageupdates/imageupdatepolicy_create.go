@@ -0,0 +1,142 @@
+package imageupdates
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/imageupdates"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type imageUpdatePolicyCreatePayload struct {
+	// Environment identifier of the resource
+	EndpointID portainer.EndpointID `validate:"required" example:"1"`
+	// Type of the updated resource, container or stack
+	ResourceType portainer.ImageUpdateResourceType `validate:"required" example:"container"`
+	// Name of the container or identifier of the stack
+	ResourceID string `validate:"required" example:"web"`
+	// Interval between two checks of the registry, one minute at least
+	Interval string `validate:"required" example:"1h"`
+	// Whether the resource is checked and updated
+	Enabled bool `example:"true"`
+}
+
+func (payload *imageUpdatePolicyCreatePayload) Validate(r *http.Request) error {
+	if payload.EndpointID == 0 {
+		return errors.New("invalid environment identifier")
+	}
+
+	switch payload.ResourceType {
+	case portainer.ImageUpdateContainer:
+		if payload.ResourceID == "" {
+			return errors.New("invalid container name")
+		}
+	case portainer.ImageUpdateStack:
+		if _, err := strconv.Atoi(payload.ResourceID); err != nil {
+			return errors.New("invalid stack identifier")
+		}
+	default:
+		return fmt.Errorf("invalid resource type %q, container or stack is expected", payload.ResourceType)
+	}
+
+	_, err := imageupdates.ParseInterval(payload.Interval)
+
+	return err
+}
+
+// @id ImageUpdatePolicyCreate
+// @summary Create an image update policy
+// @description Opt a container or a Docker stack in to the automatic image updates. The registry is checked at the interval of the policy
+// @description and the container is recreated, or the stack redeployed, when a new digest of its images is available.
+// @description The containers are identified by their name, which is kept when they are recreated.
+// @description Every update is recorded in the history of the policy and sent to the event webhooks as an image.updated or image.update_failed event.
+// @description **Access policy**: administrator
+// @tags image_update_policies
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body imageUpdatePolicyCreatePayload true "Image update policy details"
+// @success 200 {object} portainer.ImageUpdatePolicy "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment or stack not found"
+// @failure 409 "The resource already has an image update policy"
+// @failure 500 "Server error"
+// @router /image_update_policies [post]
+func (handler *Handler) imageUpdatePolicyCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload imageUpdatePolicyCreatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(payload.EndpointID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if !endpointutils.IsDockerEndpoint(endpoint) || endpointutils.IsEdgeEndpoint(endpoint) {
+		return httperror.BadRequest("The images can only be updated on the Docker environments", errors.New("unsupported environment type"))
+	}
+
+	if payload.ResourceType == portainer.ImageUpdateStack {
+		if httpErr := handler.validateStack(endpoint, payload.ResourceID); httpErr != nil {
+			return httpErr
+		}
+	}
+
+	_, err = handler.DataStore.ImageUpdatePolicy().PolicyByResource(payload.EndpointID, payload.ResourceType, payload.ResourceID)
+	if err == nil {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "The resource already has an image update policy", Err: errors.New("duplicate image update policy")}
+	} else if !handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.InternalServerError("Unable to retrieve the image update policies from the database", err)
+	}
+
+	policy := &portainer.ImageUpdatePolicy{
+		EndpointID:   payload.EndpointID,
+		ResourceType: payload.ResourceType,
+		ResourceID:   payload.ResourceID,
+		Interval:     payload.Interval,
+		Enabled:      payload.Enabled,
+	}
+
+	err = handler.DataStore.ImageUpdatePolicy().Create(policy)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the image update policy inside the database", err)
+	}
+
+	if err := handler.ImageUpdateService.Schedule(policy); err != nil {
+		return httperror.InternalServerError("Unable to schedule the image update policy", err)
+	}
+
+	return response.JSON(w, policy)
+}
+
+func (handler *Handler) validateStack(endpoint *portainer.Endpoint, resourceID string) *httperror.HandlerError {
+	stackID, _ := strconv.Atoi(resourceID)
+
+	stack, err := handler.DataStore.Stack().Read(portainer.StackID(stackID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a stack with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a stack with the specified identifier inside the database", err)
+	}
+
+	if stack.EndpointID != endpoint.ID {
+		return httperror.BadRequest("The stack is not deployed on the environment", errors.New("stack environment mismatch"))
+	}
+
+	if stack.Type != portainer.DockerComposeStack && stack.Type != portainer.DockerSwarmStack {
+		return httperror.BadRequest("The images can only be updated for the Docker stacks", errors.New("unsupported stack type"))
+	}
+
+	return nil
+}
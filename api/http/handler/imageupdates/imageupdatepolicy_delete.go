@@ -0,0 +1,37 @@
+package imageupdates
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ImageUpdatePolicyDelete
+// @summary Remove an image update policy
+// @description Stop updating the resource of an image update policy and remove its history.
+// @description **Access policy**: administrator
+// @tags image_update_policies
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Image update policy identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Image update policy not found"
+// @failure 500 "Server error"
+// @router /image_update_policies/{id} [delete]
+func (handler *Handler) imageUpdatePolicyDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	policy, httpErr := handler.policyFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	handler.ImageUpdateService.Unschedule(policy.ID)
+
+	err := handler.DataStore.ImageUpdatePolicy().Delete(policy.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the image update policy from the database", err)
+	}
+
+	return response.Empty(w)
+}
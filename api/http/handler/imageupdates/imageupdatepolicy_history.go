@@ -0,0 +1,36 @@
+package imageupdates
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ImageUpdatePolicyHistory
+// @summary List the updates of an image update policy
+// @description List the most recent updates of the resource of an image update policy, the most recent first.
+// @description **Access policy**: administrator
+// @tags image_update_policies
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Image update policy identifier"
+// @success 200 {array} portainer.ImageUpdateRecord "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Image update policy not found"
+// @failure 500 "Server error"
+// @router /image_update_policies/{id}/history [get]
+func (handler *Handler) imageUpdatePolicyHistory(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	policy, httpErr := handler.policyFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	records, err := handler.DataStore.ImageUpdatePolicy().History(policy.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the image update history from the database", err)
+	}
+
+	return response.JSON(w, records)
+}
@@ -0,0 +1,30 @@
+package imageupdates
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ImageUpdatePolicyInspect
+// @summary Inspect an image update policy
+// @description **Access policy**: administrator
+// @tags image_update_policies
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Image update policy identifier"
+// @success 200 {object} portainer.ImageUpdatePolicy "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Image update policy not found"
+// @failure 500 "Server error"
+// @router /image_update_policies/{id} [get]
+func (handler *Handler) imageUpdatePolicyInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	policy, httpErr := handler.policyFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, policy)
+}
@@ -0,0 +1,47 @@
+package imageupdates
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id ImageUpdatePolicyList
+// @summary List the image update policies
+// @description **Access policy**: administrator
+// @tags image_update_policies
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param endpointId query int false "Only list the policies of the resources of this environment"
+// @success 200 {array} portainer.ImageUpdatePolicy "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /image_update_policies [get]
+func (handler *Handler) imageUpdatePolicyList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: endpointId", err)
+	}
+
+	policies, err := handler.DataStore.ImageUpdatePolicy().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the image update policies from the database", err)
+	}
+
+	if endpointID != 0 {
+		filtered := make([]portainer.ImageUpdatePolicy, 0, len(policies))
+		for _, policy := range policies {
+			if policy.EndpointID == portainer.EndpointID(endpointID) {
+				filtered = append(filtered, policy)
+			}
+		}
+
+		policies = filtered
+	}
+
+	return response.JSON(w, policies)
+}
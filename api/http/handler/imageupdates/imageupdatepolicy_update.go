@@ -0,0 +1,74 @@
+package imageupdates
+
+import (
+	"net/http"
+
+	"github.com/portainer/portainer/api/imageupdates"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type imageUpdatePolicyUpdatePayload struct {
+	// Interval between two checks of the registry, one minute at least
+	Interval *string `example:"1h"`
+	// Whether the resource is checked and updated
+	Enabled *bool `example:"true"`
+}
+
+func (payload *imageUpdatePolicyUpdatePayload) Validate(r *http.Request) error {
+	if payload.Interval != nil {
+		if _, err := imageupdates.ParseInterval(*payload.Interval); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// @id ImageUpdatePolicyUpdate
+// @summary Update an image update policy
+// @description **Access policy**: administrator
+// @tags image_update_policies
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Image update policy identifier"
+// @param body body imageUpdatePolicyUpdatePayload true "Image update policy details"
+// @success 200 {object} portainer.ImageUpdatePolicy "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Image update policy not found"
+// @failure 500 "Server error"
+// @router /image_update_policies/{id} [put]
+func (handler *Handler) imageUpdatePolicyUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload imageUpdatePolicyUpdatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	policy, httpErr := handler.policyFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if payload.Interval != nil {
+		policy.Interval = *payload.Interval
+	}
+
+	if payload.Enabled != nil {
+		policy.Enabled = *payload.Enabled
+	}
+
+	err = handler.DataStore.ImageUpdatePolicy().Update(policy.ID, policy)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the image update policy changes inside the database", err)
+	}
+
+	if err := handler.ImageUpdateService.Schedule(policy); err != nil {
+		return httperror.InternalServerError("Unable to schedule the image update policy", err)
+	}
+
+	return response.JSON(w, policy)
+}
@@ -0,0 +1,136 @@
+package kubernetes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	clientV1 "k8s.io/client-go/tools/clientcmd/api/v1"
+)
+
+// @id GetKubernetesEndpointKubeconfig
+// @summary Generate a kubeconfig file for a single environment
+// @description Generate a kubeconfig file enabling kubectl to reach the environment through the Portainer proxy.
+// @description For non-administrators, a context is generated for each of the namespaces the user is allowed to access.
+// @description **Access policy**: authenticated
+// @tags kubernetes
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @success 200 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment(Endpoint) not found"
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/kubeconfig [get]
+func (handler *Handler) getKubernetesEndpointKubeconfig(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	endpointGroups, err := handler.DataStore.EndpointGroup().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve environment groups from the database", err)
+	}
+
+	endpoints := security.FilterEndpoints([]portainer.Endpoint{*endpoint}, endpointGroups, securityContext)
+	if len(endpoints) == 0 {
+		return httperror.Forbidden("Permission denied to access environment", errors.New("the user is not authorized to access this environment"))
+	}
+
+	bearerToken, err := handler.JwtService.GenerateTokenForKubeconfig(tokenData)
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate JWT token", err)
+	}
+
+	config := handler.buildConfig(r, tokenData, bearerToken, endpoints, false)
+
+	if !securityContext.IsAdmin {
+		namespaces, err := handler.userAllowedNamespaces(endpoint, securityContext)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the namespaces accessible by the user", err)
+		}
+
+		if len(namespaces) > 0 {
+			config.Contexts = buildNamespaceContexts(config.Contexts[0], namespaces)
+			config.CurrentContext = config.Contexts[0].Name
+		}
+	}
+
+	return writeFileContent(w, r, endpoints, tokenData, config)
+}
+
+// userAllowedNamespaces returns the sorted list of the namespaces of the environment the user can access
+func (handler *Handler) userAllowedNamespaces(endpoint *portainer.Endpoint, securityContext *security.RestrictedRequestContext) ([]string, error) {
+	kubeClient, err := handler.KubernetesClientFactory.GetKubeClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := kubeClient.GetNamespaceAccessPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := []string{}
+	if !endpoint.Kubernetes.Configuration.RestrictDefaultNamespace {
+		namespaces = append(namespaces, "default")
+	}
+
+	for namespace, policy := range policies {
+		if namespace == "default" {
+			continue
+		}
+
+		if _, ok := policy.UserAccessPolicies[securityContext.UserID]; ok {
+			namespaces = append(namespaces, namespace)
+			continue
+		}
+
+		for _, membership := range securityContext.UserMemberships {
+			if _, ok := policy.TeamAccessPolicies[membership.TeamID]; ok {
+				namespaces = append(namespaces, namespace)
+				break
+			}
+		}
+	}
+
+	sort.Strings(namespaces)
+
+	return namespaces, nil
+}
+
+// buildNamespaceContexts derives a context scoped to each namespace from the environment context
+func buildNamespaceContexts(endpointContext clientV1.NamedContext, namespaces []string) []clientV1.NamedContext {
+	contexts := make([]clientV1.NamedContext, len(namespaces))
+	for idx, namespace := range namespaces {
+		contexts[idx] = clientV1.NamedContext{
+			Name: fmt.Sprintf("%s-%s", endpointContext.Name, namespace),
+			Context: clientV1.Context{
+				AuthInfo:  endpointContext.Context.AuthInfo,
+				Cluster:   endpointContext.Context.Cluster,
+				Namespace: namespace,
+			},
+		}
+	}
+
+	return contexts
+}
@@ -0,0 +1,28 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	clientV1 "k8s.io/client-go/tools/clientcmd/api/v1"
+)
+
+func Test_buildNamespaceContexts(t *testing.T) {
+	endpointContext := clientV1.NamedContext{
+		Name: "portainer-ctx-local",
+		Context: clientV1.Context{
+			AuthInfo: "portainer-sa-user-instance-2",
+			Cluster:  "portainer-cluster-local",
+		},
+	}
+
+	contexts := buildNamespaceContexts(endpointContext, []string{"default", "team-a"})
+
+	assert.Len(t, contexts, 2)
+	assert.Equal(t, "portainer-ctx-local-default", contexts[0].Name)
+	assert.Equal(t, "default", contexts[0].Context.Namespace)
+	assert.Equal(t, "portainer-ctx-local-team-a", contexts[1].Name)
+	assert.Equal(t, "team-a", contexts[1].Context.Namespace)
+	assert.Equal(t, endpointContext.Context.AuthInfo, contexts[1].Context.AuthInfo)
+	assert.Equal(t, endpointContext.Context.Cluster, contexts[1].Context.Cluster)
+}
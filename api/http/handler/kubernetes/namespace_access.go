@@ -0,0 +1,150 @@
+package kubernetes
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/middlewares"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type namespaceAccessUpdatePayload struct {
+	// Users allowed to access the namespace
+	UserAccessPolicies portainer.UserAccessPolicies
+	// Teams allowed to access the namespace
+	TeamAccessPolicies portainer.TeamAccessPolicies
+}
+
+func (payload *namespaceAccessUpdatePayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id KubernetesNamespaceAccessInspect
+// @summary Inspect the access policies of a namespace
+// @description Retrieve the users and teams allowed to access a namespace.
+// @description **Access policy**: administrator
+// @security ApiKeyAuth
+// @security jwt
+// @tags kubernetes
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param namespace path string true "Namespace name"
+// @success 200 {object} portainer.K8sNamespaceAccessPolicy "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment(Endpoint) not found"
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/namespaces/{namespace}/access [get]
+func (handler *Handler) namespaceAccessInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	namespaceName, err := request.RetrieveRouteVariableValue(r, "namespace")
+	if err != nil {
+		return httperror.BadRequest("Invalid namespace identifier route variable", err)
+	}
+
+	kubeClient, err := handler.KubernetesClientFactory.GetKubeClient(endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to create kubernetes client", err)
+	}
+
+	policies, err := kubeClient.GetNamespaceAccessPolicies()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve namespace access policies", err)
+	}
+
+	policy, ok := policies[namespaceName]
+	if !ok {
+		policy = portainer.K8sNamespaceAccessPolicy{
+			UserAccessPolicies: portainer.UserAccessPolicies{},
+			TeamAccessPolicies: portainer.TeamAccessPolicies{},
+		}
+	}
+
+	return response.JSON(w, policy)
+}
+
+// @id KubernetesNamespaceAccessUpdate
+// @summary Update the access policies of a namespace
+// @description Replace the users and teams allowed to access a namespace.
+// @description The new policies are enforced when the users' kubernetes tokens are renewed.
+// @description **Access policy**: administrator
+// @security ApiKeyAuth
+// @security jwt
+// @tags kubernetes
+// @accept json
+// @produce json
+// @param id path int true "Environment(Endpoint) identifier"
+// @param namespace path string true "Namespace name"
+// @param body body namespaceAccessUpdatePayload true "Access policies"
+// @success 200 {object} portainer.K8sNamespaceAccessPolicy "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment(Endpoint), user or team not found"
+// @failure 500 "Server error"
+// @router /kubernetes/{id}/namespaces/{namespace}/access [put]
+func (handler *Handler) namespaceAccessUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, err := middlewares.FetchEndpoint(r)
+	if err != nil {
+		return httperror.NotFound("Unable to find an environment on request context", err)
+	}
+
+	namespaceName, err := request.RetrieveRouteVariableValue(r, "namespace")
+	if err != nil {
+		return httperror.BadRequest("Invalid namespace identifier route variable", err)
+	}
+
+	var payload namespaceAccessUpdatePayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	for userID := range payload.UserAccessPolicies {
+		_, err := handler.DataStore.User().Read(userID)
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+		}
+	}
+
+	for teamID := range payload.TeamAccessPolicies {
+		_, err := handler.DataStore.Team().Read(teamID)
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.NotFound("Unable to find a team with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find a team with the specified identifier inside the database", err)
+		}
+	}
+
+	kubeClient, err := handler.KubernetesClientFactory.GetKubeClient(endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to create kubernetes client", err)
+	}
+
+	policies, err := kubeClient.GetNamespaceAccessPolicies()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve namespace access policies", err)
+	}
+
+	if policies == nil {
+		policies = map[string]portainer.K8sNamespaceAccessPolicy{}
+	}
+
+	policy := portainer.K8sNamespaceAccessPolicy{
+		UserAccessPolicies: payload.UserAccessPolicies,
+		TeamAccessPolicies: payload.TeamAccessPolicies,
+	}
+	policies[namespaceName] = policy
+
+	err = kubeClient.UpdateNamespaceAccessPolicies(policies)
+	if err != nil {
+		return httperror.InternalServerError("Unable to update namespace access policies", err)
+	}
+
+	return response.JSON(w, policy)
+}
@@ -1,9 +1,12 @@
 package ldap
 
 import (
+	"errors"
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -20,7 +23,7 @@ func (payload *checkPayload) Validate(r *http.Request) error {
 // @id LDAPCheck
 // @summary Test LDAP connectivity
 // @description Test LDAP connectivity using LDAP details
-// @description **Access policy**: administrator
+// @description **Access policy**: administrator, or user the authentication settings are delegated to
 // @tags ldap
 // @security ApiKeyAuth
 // @security jwt
@@ -28,11 +31,28 @@ func (payload *checkPayload) Validate(r *http.Request) error {
 // @param body body checkPayload true "details"
 // @success 204 "Success"
 // @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
 // @failure 500 "Server error"
 // @router /ldap/check [post]
 func (handler *Handler) ldapCheck(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	if tokenData.Role != portainer.AdministratorRole {
+		sections, err := authorization.DelegatedSettingsSections(handler.DataStore, tokenData.ID)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the settings sections delegated to the user", err)
+		}
+
+		if !sections[authorization.SettingsSectionAuthentication] {
+			return httperror.Forbidden("Access denied", errors.New("the authentication settings are not delegated to the user"))
+		}
+	}
+
 	var payload checkPayload
-	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
 	if err != nil {
 		return httperror.BadRequest("Invalid request payload", err)
 	}
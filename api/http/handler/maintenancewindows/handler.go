@@ -0,0 +1,81 @@
+package maintenancewindows
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/maintenance"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to handle maintenance window operations.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+}
+
+// NewHandler creates a handler to manage maintenance window operations.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+
+	adminRouter := h.NewRoute().Subrouter()
+	adminRouter.Use(bouncer.AdminAccess)
+
+	adminRouter.Handle("/maintenance_windows", httperror.LoggerHandler(h.maintenanceWindowCreate)).Methods(http.MethodPost)
+	adminRouter.Handle("/maintenance_windows", httperror.LoggerHandler(h.maintenanceWindowList)).Methods(http.MethodGet)
+	adminRouter.Handle("/maintenance_windows/status", httperror.LoggerHandler(h.maintenanceWindowStatus)).Methods(http.MethodGet)
+	adminRouter.Handle("/maintenance_windows/{id}", httperror.LoggerHandler(h.maintenanceWindowInspect)).Methods(http.MethodGet)
+	adminRouter.Handle("/maintenance_windows/{id}", httperror.LoggerHandler(h.maintenanceWindowUpdate)).Methods(http.MethodPut)
+	adminRouter.Handle("/maintenance_windows/{id}", httperror.LoggerHandler(h.maintenanceWindowDelete)).Methods(http.MethodDelete)
+
+	return h
+}
+
+func (handler *Handler) windowFromRequest(r *http.Request) (*portainer.MaintenanceWindow, *httperror.HandlerError) {
+	windowID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid maintenance window identifier route variable", err)
+	}
+
+	window, err := handler.DataStore.MaintenanceWindow().Read(portainer.MaintenanceWindowID(windowID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a maintenance window with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a maintenance window with the specified identifier inside the database", err)
+	}
+
+	return window, nil
+}
+
+// validateWindow checks the periods of the window and that the environments and the groups it targets exist
+func (handler *Handler) validateWindow(window *portainer.MaintenanceWindow) error {
+	if window.Name == "" {
+		return errors.New("invalid maintenance window name")
+	}
+
+	if err := maintenance.Validate(window); err != nil {
+		return err
+	}
+
+	for _, endpointID := range window.EndpointIDs {
+		if _, err := handler.DataStore.Endpoint().Endpoint(endpointID); err != nil {
+			return errors.New("invalid environment, it does not exist")
+		}
+	}
+
+	for _, groupID := range window.EndpointGroupIDs {
+		if _, err := handler.DataStore.EndpointGroup().Read(groupID); err != nil {
+			return errors.New("invalid environment group, it does not exist")
+		}
+	}
+
+	return nil
+}
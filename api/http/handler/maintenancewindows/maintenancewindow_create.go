@@ -0,0 +1,76 @@
+package maintenancewindows
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type maintenanceWindowCreatePayload struct {
+	// Name of the maintenance window
+	Name string `validate:"required" example:"weekend"`
+	// Environments of the window
+	EndpointIDs []portainer.EndpointID `example:"1"`
+	// Environment groups of the window, the window applying to all their environments
+	EndpointGroupIDs []portainer.EndpointGroupID `example:"2"`
+	// Cron expression of the starts of the recurring periods of the window
+	Schedule string `example:"0 2 * * 6"`
+	// Duration of the recurring periods of the window
+	Duration string `example:"4h"`
+	// Time zone of the schedule, UTC when empty
+	Timezone string `example:"Europe/Paris"`
+	// Calendar periods of the window, in addition to the recurring ones
+	Periods []portainer.MaintenancePeriod
+}
+
+func (payload *maintenanceWindowCreatePayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id MaintenanceWindowCreate
+// @summary Create a maintenance window
+// @description Create a maintenance window, made of recurring periods defined by a cron schedule and of calendar periods.
+// @description Once an environment is targeted by a maintenance window, directly or through its group, its stack and image
+// @description automatic updates and its scheduled volume backups only run inside the periods of its windows.
+// @description **Access policy**: administrator
+// @tags maintenance_windows
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body maintenanceWindowCreatePayload true "Maintenance window details"
+// @success 200 {object} portainer.MaintenanceWindow "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /maintenance_windows [post]
+func (handler *Handler) maintenanceWindowCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload maintenanceWindowCreatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	window := &portainer.MaintenanceWindow{
+		Name:             payload.Name,
+		EndpointIDs:      payload.EndpointIDs,
+		EndpointGroupIDs: payload.EndpointGroupIDs,
+		Schedule:         payload.Schedule,
+		Duration:         payload.Duration,
+		Timezone:         payload.Timezone,
+		Periods:          payload.Periods,
+	}
+
+	if err := handler.validateWindow(window); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	err = handler.DataStore.MaintenanceWindow().Create(window)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the maintenance window inside the database", err)
+	}
+
+	return response.JSON(w, window)
+}
@@ -0,0 +1,35 @@
+package maintenancewindows
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id MaintenanceWindowDelete
+// @summary Remove a maintenance window
+// @description The environments targeted by no other maintenance window are no longer restricted.
+// @description **Access policy**: administrator
+// @tags maintenance_windows
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Maintenance window identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Maintenance window not found"
+// @failure 500 "Server error"
+// @router /maintenance_windows/{id} [delete]
+func (handler *Handler) maintenanceWindowDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	window, httpErr := handler.windowFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err := handler.DataStore.MaintenanceWindow().Delete(window.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the maintenance window from the database", err)
+	}
+
+	return response.Empty(w)
+}
@@ -0,0 +1,30 @@
+package maintenancewindows
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id MaintenanceWindowInspect
+// @summary Inspect a maintenance window
+// @description **Access policy**: administrator
+// @tags maintenance_windows
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Maintenance window identifier"
+// @success 200 {object} portainer.MaintenanceWindow "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Maintenance window not found"
+// @failure 500 "Server error"
+// @router /maintenance_windows/{id} [get]
+func (handler *Handler) maintenanceWindowInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	window, httpErr := handler.windowFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, window)
+}
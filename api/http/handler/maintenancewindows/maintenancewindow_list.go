@@ -0,0 +1,27 @@
+package maintenancewindows
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id MaintenanceWindowList
+// @summary List the maintenance windows
+// @description **Access policy**: administrator
+// @tags maintenance_windows
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.MaintenanceWindow "Success"
+// @failure 500 "Server error"
+// @router /maintenance_windows [get]
+func (handler *Handler) maintenanceWindowList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	windows, err := handler.DataStore.MaintenanceWindow().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the maintenance windows from the database", err)
+	}
+
+	return response.JSON(w, windows)
+}
@@ -0,0 +1,53 @@
+package maintenancewindows
+
+import (
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/maintenance"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id MaintenanceWindowStatus
+// @summary Inspect the maintenance status of an environment
+// @description Retrieve whether the automated disruptive actions of an environment can run now, and the next period of
+// @description its maintenance windows.
+// @description **Access policy**: administrator
+// @tags maintenance_windows
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param endpointId query int true "Environment identifier"
+// @success 200 {object} maintenance.Status "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment not found"
+// @failure 500 "Server error"
+// @router /maintenance_windows/status [get]
+func (handler *Handler) maintenanceWindowStatus(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", false)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: endpointId", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	windows, err := handler.DataStore.MaintenanceWindow().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the maintenance windows from the database", err)
+	}
+
+	status, err := maintenance.EndpointStatus(windows, endpoint, time.Now())
+	if err != nil {
+		return httperror.InternalServerError("Unable to compute the maintenance status of the environment", err)
+	}
+
+	return response.JSON(w, status)
+}
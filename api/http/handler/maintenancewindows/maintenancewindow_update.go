@@ -0,0 +1,98 @@
+package maintenancewindows
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type maintenanceWindowUpdatePayload struct {
+	// Name of the maintenance window
+	Name *string `example:"weekend"`
+	// Environments of the window
+	EndpointIDs []portainer.EndpointID `example:"1"`
+	// Environment groups of the window, the window applying to all their environments
+	EndpointGroupIDs []portainer.EndpointGroupID `example:"2"`
+	// Cron expression of the starts of the recurring periods of the window, an empty value removes them
+	Schedule *string `example:"0 2 * * 6"`
+	// Duration of the recurring periods of the window
+	Duration *string `example:"4h"`
+	// Time zone of the schedule, UTC when empty
+	Timezone *string `example:"Europe/Paris"`
+	// Calendar periods of the window, replacing the existing ones
+	Periods []portainer.MaintenancePeriod
+}
+
+func (payload *maintenanceWindowUpdatePayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id MaintenanceWindowUpdate
+// @summary Update a maintenance window
+// @description **Access policy**: administrator
+// @tags maintenance_windows
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Maintenance window identifier"
+// @param body body maintenanceWindowUpdatePayload true "Maintenance window details"
+// @success 200 {object} portainer.MaintenanceWindow "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Maintenance window not found"
+// @failure 500 "Server error"
+// @router /maintenance_windows/{id} [put]
+func (handler *Handler) maintenanceWindowUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	window, httpErr := handler.windowFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var payload maintenanceWindowUpdatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	if payload.Name != nil {
+		window.Name = *payload.Name
+	}
+
+	if payload.EndpointIDs != nil {
+		window.EndpointIDs = payload.EndpointIDs
+	}
+
+	if payload.EndpointGroupIDs != nil {
+		window.EndpointGroupIDs = payload.EndpointGroupIDs
+	}
+
+	if payload.Schedule != nil {
+		window.Schedule = *payload.Schedule
+	}
+
+	if payload.Duration != nil {
+		window.Duration = *payload.Duration
+	}
+
+	if payload.Timezone != nil {
+		window.Timezone = *payload.Timezone
+	}
+
+	if payload.Periods != nil {
+		window.Periods = payload.Periods
+	}
+
+	if err := handler.validateWindow(window); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	err = handler.DataStore.MaintenanceWindow().Update(window.ID, window)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the maintenance window changes inside the database", err)
+	}
+
+	return response.JSON(w, window)
+}
@@ -0,0 +1,173 @@
+package notes
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+// maxNoteLength is the length of the longest note content
+const maxNoteLength = 16 << 10
+
+// Handler is the HTTP handler used to handle note operations.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+}
+
+// NewHandler creates a handler to manage note operations.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+
+	restrictedRouter := h.NewRoute().Subrouter()
+	restrictedRouter.Use(bouncer.RestrictedAccess)
+
+	restrictedRouter.Handle("/notes", httperror.LoggerHandler(h.noteCreate)).Methods(http.MethodPost)
+	restrictedRouter.Handle("/notes", httperror.LoggerHandler(h.noteList)).Methods(http.MethodGet)
+	restrictedRouter.Handle("/notes/{id}", httperror.LoggerHandler(h.noteInspect)).Methods(http.MethodGet)
+	restrictedRouter.Handle("/notes/{id}", httperror.LoggerHandler(h.noteUpdate)).Methods(http.MethodPut)
+	restrictedRouter.Handle("/notes/{id}", httperror.LoggerHandler(h.noteDelete)).Methods(http.MethodDelete)
+
+	return h
+}
+
+// noteFromRequest returns the note of the request, when the user can access the resource of the note. The note can
+// only be managed by its author and the administrators.
+func (handler *Handler) noteFromRequest(r *http.Request, manage bool) (*portainer.Note, *httperror.HandlerError) {
+	noteID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid note identifier route variable", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	note, err := handler.DataStore.Note().Read(portainer.NoteID(noteID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a note with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a note with the specified identifier inside the database", err)
+	}
+
+	checker, err := newAccessChecker(handler.DataStore, securityContext)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve the resource controls from the database", err)
+	}
+
+	canAccess, err := checker.canAccess(note)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to verify the access to the resource of the note", err)
+	}
+
+	if !canAccess {
+		return nil, httperror.Forbidden("Permission denied to access the note", httperrors.ErrResourceAccessDenied)
+	}
+
+	if manage && !securityContext.IsAdmin && note.AuthorID != securityContext.UserID {
+		return nil, httperror.Forbidden("Only the author of the note can manage it", httperrors.ErrResourceAccessDenied)
+	}
+
+	return note, nil
+}
+
+func validateContent(content string) error {
+	if content == "" {
+		return errors.New("invalid note content")
+	}
+
+	if len(content) > maxNoteLength {
+		return errors.New("invalid note content, it is too long")
+	}
+
+	return nil
+}
+
+// accessChecker verifies that the user can access the resources of the notes, caching the environments and the
+// resource controls shared by the notes
+type accessChecker struct {
+	dataStore        dataservices.DataStore
+	securityContext  *security.RestrictedRequestContext
+	teamIDs          []portainer.TeamID
+	resourceControls []portainer.ResourceControl
+	endpoints        map[portainer.EndpointID]bool
+}
+
+func newAccessChecker(dataStore dataservices.DataStore, securityContext *security.RestrictedRequestContext) (*accessChecker, error) {
+	checker := &accessChecker{
+		dataStore:       dataStore,
+		securityContext: securityContext,
+		endpoints:       make(map[portainer.EndpointID]bool),
+	}
+
+	if securityContext.IsAdmin {
+		return checker, nil
+	}
+
+	for _, membership := range securityContext.UserMemberships {
+		checker.teamIDs = append(checker.teamIDs, membership.TeamID)
+	}
+
+	resourceControls, err := dataStore.ResourceControl().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	checker.resourceControls = resourceControls
+
+	return checker, nil
+}
+
+// canAccess returns true when the user can access the environment of the note and, for the stack notes, the stack.
+// The notes of the resources which no longer exist are only available to the administrators.
+func (checker *accessChecker) canAccess(note *portainer.Note) (bool, error) {
+	if checker.securityContext.IsAdmin {
+		return true, nil
+	}
+
+	canAccessEndpoint, ok := checker.endpoints[note.EndpointID]
+	if !ok {
+		endpoint, err := checker.dataStore.Endpoint().Endpoint(note.EndpointID)
+		if checker.dataStore.IsErrObjectNotFound(err) {
+			checker.endpoints[note.EndpointID] = false
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+
+		endpointGroup, err := checker.dataStore.EndpointGroup().Read(endpoint.GroupID)
+		if err != nil {
+			return false, err
+		}
+
+		canAccessEndpoint = security.AuthorizedEndpointAccess(endpoint, endpointGroup, checker.securityContext.UserID, checker.securityContext.UserMemberships)
+		checker.endpoints[note.EndpointID] = canAccessEndpoint
+	}
+
+	if !canAccessEndpoint || note.ResourceType != portainer.NoteStack {
+		return canAccessEndpoint, nil
+	}
+
+	stack, err := checker.dataStore.Stack().Read(note.StackID)
+	if checker.dataStore.IsErrObjectNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	resourceID := stackutils.ResourceControlID(stack.EndpointID, stack.Name)
+
+	return authorization.UserCanAccessDockerResource(checker.securityContext.UserID, checker.teamIDs, stack.EndpointID, resourceID, portainer.StackResourceControl, nil, checker.resourceControls), nil
+}
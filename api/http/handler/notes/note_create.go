@@ -0,0 +1,131 @@
+package notes
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type noteCreatePayload struct {
+	// Type of the resource of the note, one of endpoint, stack or container
+	ResourceType portainer.NoteResourceType `validate:"required" example:"container"`
+	// Environment of the resource of the note
+	EndpointID portainer.EndpointID `validate:"required" example:"1"`
+	// Identifier of the stack, required for the stack notes
+	StackID portainer.StackID `example:"3"`
+	// Name of the container, required for the container notes
+	ContainerName string `example:"web-nginx-1"`
+	// Markdown content of the note
+	Content string `validate:"required" example:"Do not restart during business hours"`
+}
+
+func (payload *noteCreatePayload) Validate(r *http.Request) error {
+	if payload.EndpointID == 0 {
+		return errors.New("invalid environment identifier")
+	}
+
+	switch payload.ResourceType {
+	case portainer.NoteEndpoint:
+		if payload.StackID != 0 || payload.ContainerName != "" {
+			return errors.New("invalid environment note, it cannot reference a stack or a container")
+		}
+	case portainer.NoteStack:
+		if payload.StackID == 0 || payload.ContainerName != "" {
+			return errors.New("invalid stack note, it requires a stack identifier only")
+		}
+	case portainer.NoteContainer:
+		if payload.ContainerName == "" || payload.StackID != 0 {
+			return errors.New("invalid container note, it requires a container name only")
+		}
+	default:
+		return errors.New("invalid resource type, it must be one of endpoint, stack or container")
+	}
+
+	return validateContent(payload.Content)
+}
+
+// @id NoteCreate
+// @summary Attach a note to a resource
+// @description Attach a markdown note to an environment, a stack or a container. The containers are designated by
+// @description their name, so that the notes remain when they are recreated. The notes are returned along with the
+// @description environments, the stacks and the containers.
+// @description The user must be able to access the resource.
+// @description **Access policy**: restricted
+// @tags notes
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body noteCreatePayload true "Note details"
+// @success 200 {object} portainer.Note "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 500 "Server error"
+// @router /notes [post]
+func (handler *Handler) noteCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload noteCreatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	if _, err := handler.DataStore.Endpoint().Endpoint(payload.EndpointID); err != nil {
+		return httperror.BadRequest("Invalid environment, it does not exist", err)
+	}
+
+	if payload.ResourceType == portainer.NoteStack {
+		stack, err := handler.DataStore.Stack().Read(payload.StackID)
+		if err != nil || stack.EndpointID != payload.EndpointID {
+			return httperror.BadRequest("Invalid stack, it does not exist in the environment", err)
+		}
+	}
+
+	note := &portainer.Note{
+		ResourceType:  payload.ResourceType,
+		EndpointID:    payload.EndpointID,
+		StackID:       payload.StackID,
+		ContainerName: payload.ContainerName,
+		Content:       payload.Content,
+		AuthorID:      securityContext.UserID,
+		CreatedAt:     time.Now().Unix(),
+	}
+
+	checker, err := newAccessChecker(handler.DataStore, securityContext)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the resource controls from the database", err)
+	}
+
+	canAccess, err := checker.canAccess(note)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify the access to the resource of the note", err)
+	}
+
+	if !canAccess {
+		return httperror.Forbidden("Permission denied to access the resource of the note", httperrors.ErrResourceAccessDenied)
+	}
+
+	author, err := handler.DataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to find the user in the database", err)
+	}
+	note.Author = author.Username
+
+	err = handler.DataStore.Note().Create(note)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the note inside the database", err)
+	}
+
+	return response.JSON(w, note)
+}
@@ -0,0 +1,122 @@
+package notes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotes(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	alice := &portainer.User{Username: "alice", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(alice))
+	bob := &portainer.User{Username: "bob", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(bob))
+
+	author := &security.RestrictedRequestContext{UserID: alice.ID}
+	colleague := &security.RestrictedRequestContext{UserID: bob.ID}
+	outsider := &security.RestrictedRequestContext{UserID: 99}
+	admin := &security.RestrictedRequestContext{UserID: 1, IsAdmin: true}
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "production", Type: portainer.DockerEnvironment, GroupID: 1,
+		UserAccessPolicies: portainer.UserAccessPolicies{alice.ID: {}, bob.ID: {}}}))
+
+	is.NoError(store.Stack().Create(&portainer.Stack{ID: 1, Name: "web", EndpointID: 1}))
+	is.NoError(store.ResourceControl().Create(&portainer.ResourceControl{ResourceID: "1_web", Type: portainer.StackResourceControl,
+		UserAccesses: []portainer.UserResourceAccess{{UserID: alice.ID, AccessLevel: portainer.ReadWriteAccessLevel}}}))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer())
+	handler.DataStore = store
+
+	do := func(securityContext *security.RestrictedRequestContext, method, path string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			is.NoError(json.NewEncoder(&body).Encode(payload))
+		}
+
+		r := httptest.NewRequest(method, path, &body)
+		r = r.WithContext(security.StoreRestrictedRequestContext(r, securityContext))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	var containerNote portainer.Note
+
+	t.Run("a user attaches notes to the resources they can access", func(t *testing.T) {
+		w := do(author, http.MethodPost, "/notes", noteCreatePayload{ResourceType: portainer.NoteContainer, EndpointID: 1,
+			ContainerName: "web-nginx-1", Content: "**Do not restart** during business hours"})
+		is.Equal(http.StatusOK, w.Code)
+		is.NoError(json.NewDecoder(w.Body).Decode(&containerNote))
+		is.Equal("alice", containerNote.Author)
+
+		w = do(author, http.MethodPost, "/notes", noteCreatePayload{ResourceType: portainer.NoteStack, EndpointID: 1, StackID: 1, Content: "Owned by the web team"})
+		is.Equal(http.StatusOK, w.Code)
+
+		w = do(outsider, http.MethodPost, "/notes", noteCreatePayload{ResourceType: portainer.NoteEndpoint, EndpointID: 1, Content: "hello"})
+		is.Equal(http.StatusForbidden, w.Code)
+
+		w = do(colleague, http.MethodPost, "/notes", noteCreatePayload{ResourceType: portainer.NoteStack, EndpointID: 1, StackID: 1, Content: "hello"})
+		is.Equal(http.StatusForbidden, w.Code, "the stack is restricted to its owner")
+	})
+
+	t.Run("invalid notes are rejected", func(t *testing.T) {
+		for _, payload := range []noteCreatePayload{
+			{ResourceType: "volume", EndpointID: 1, Content: "hello"},
+			{ResourceType: portainer.NoteContainer, EndpointID: 1, Content: "hello"},
+			{ResourceType: portainer.NoteStack, EndpointID: 1, StackID: 2, Content: "hello"},
+			{ResourceType: portainer.NoteEndpoint, EndpointID: 2, Content: "hello"},
+			{ResourceType: portainer.NoteEndpoint, EndpointID: 1},
+		} {
+			w := do(author, http.MethodPost, "/notes", payload)
+			is.Equal(http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("the notes are listed for the users who can access their resources", func(t *testing.T) {
+		for securityContext, count := range map[*security.RestrictedRequestContext]int{author: 2, colleague: 1, outsider: 0, admin: 2} {
+			w := do(securityContext, http.MethodGet, "/notes?endpointId=1", nil)
+			is.Equal(http.StatusOK, w.Code)
+
+			var notes []portainer.Note
+			is.NoError(json.NewDecoder(w.Body).Decode(&notes))
+			is.Len(notes, count)
+		}
+
+		w := do(author, http.MethodGet, "/notes?resourceType=container&containerName=web-nginx-1", nil)
+		var notes []portainer.Note
+		is.NoError(json.NewDecoder(w.Body).Decode(&notes))
+		is.Len(notes, 1)
+	})
+
+	t.Run("only the author and the administrators manage a note", func(t *testing.T) {
+		w := do(colleague, http.MethodPut, fmt.Sprintf("/notes/%d", containerNote.ID), noteUpdatePayload{Content: "restart at will"})
+		is.Equal(http.StatusForbidden, w.Code)
+
+		w = do(author, http.MethodPut, fmt.Sprintf("/notes/%d", containerNote.ID), noteUpdatePayload{Content: "Restart after 18:00"})
+		is.Equal(http.StatusOK, w.Code)
+
+		note, err := store.Note().Read(containerNote.ID)
+		is.NoError(err)
+		is.Equal("Restart after 18:00", note.Content)
+		is.NotZero(note.UpdatedAt)
+
+		w = do(admin, http.MethodDelete, fmt.Sprintf("/notes/%d", containerNote.ID), nil)
+		is.Equal(http.StatusNoContent, w.Code)
+	})
+}
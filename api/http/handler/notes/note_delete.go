@@ -0,0 +1,36 @@
+package notes
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id NoteDelete
+// @summary Remove a note
+// @description Only the author of the note or an administrator can remove it.
+// @description **Access policy**: restricted
+// @tags notes
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Note identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Note not found"
+// @failure 500 "Server error"
+// @router /notes/{id} [delete]
+func (handler *Handler) noteDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	note, httpErr := handler.noteFromRequest(r, true)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err := handler.DataStore.Note().Delete(note.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the note from the database", err)
+	}
+
+	return response.Empty(w)
+}
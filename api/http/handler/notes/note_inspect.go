@@ -0,0 +1,32 @@
+package notes
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id NoteInspect
+// @summary Inspect a note
+// @description The user must be able to access the resource of the note.
+// @description **Access policy**: restricted
+// @tags notes
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Note identifier"
+// @success 200 {object} portainer.Note "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Note not found"
+// @failure 500 "Server error"
+// @router /notes/{id} [get]
+func (handler *Handler) noteInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	note, httpErr := handler.noteFromRequest(r, false)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, note)
+}
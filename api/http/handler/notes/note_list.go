@@ -0,0 +1,84 @@
+package notes
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id NoteList
+// @summary List the notes
+// @description List the notes about the resources the user can access.
+// @description **Access policy**: restricted
+// @tags notes
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param endpointId query int false "Only the notes about this environment and its resources"
+// @param resourceType query string false "Only the notes about this type of resource" Enums(endpoint, stack, container)
+// @param stackId query int false "Only the notes about this stack"
+// @param containerName query string false "Only the notes about the containers with this name"
+// @success 200 {array} portainer.Note "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /notes [get]
+func (handler *Handler) noteList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: endpointId", err)
+	}
+
+	stackID, err := request.RetrieveNumericQueryParameter(r, "stackId", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: stackId", err)
+	}
+
+	resourceType, _ := request.RetrieveQueryParameter(r, "resourceType", true)
+	containerName, _ := request.RetrieveQueryParameter(r, "containerName", true)
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	var notes []portainer.Note
+	if endpointID != 0 {
+		notes, err = handler.DataStore.Note().NotesByEndpoint(portainer.EndpointID(endpointID))
+	} else {
+		notes, err = handler.DataStore.Note().ReadAll()
+	}
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the notes from the database", err)
+	}
+
+	checker, err := newAccessChecker(handler.DataStore, securityContext)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the resource controls from the database", err)
+	}
+
+	filtered := []portainer.Note{}
+	for i := range notes {
+		note := &notes[i]
+
+		if (resourceType != "" && string(note.ResourceType) != resourceType) ||
+			(stackID != 0 && note.StackID != portainer.StackID(stackID)) ||
+			(containerName != "" && note.ContainerName != containerName) {
+			continue
+		}
+
+		canAccess, err := checker.canAccess(note)
+		if err != nil {
+			return httperror.InternalServerError("Unable to verify the access to the resource of the note", err)
+		}
+
+		if canAccess {
+			filtered = append(filtered, *note)
+		}
+	}
+
+	return response.JSON(w, filtered)
+}
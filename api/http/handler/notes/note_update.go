@@ -0,0 +1,59 @@
+package notes
+
+import (
+	"net/http"
+	"time"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type noteUpdatePayload struct {
+	// Markdown content of the note
+	Content string `validate:"required" example:"Do not restart during business hours"`
+}
+
+func (payload *noteUpdatePayload) Validate(r *http.Request) error {
+	return validateContent(payload.Content)
+}
+
+// @id NoteUpdate
+// @summary Update a note
+// @description Only the author of the note or an administrator can update it.
+// @description **Access policy**: restricted
+// @tags notes
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Note identifier"
+// @param body body noteUpdatePayload true "Note details"
+// @success 200 {object} portainer.Note "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Note not found"
+// @failure 500 "Server error"
+// @router /notes/{id} [put]
+func (handler *Handler) noteUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload noteUpdatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	note, httpErr := handler.noteFromRequest(r, true)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	note.Content = payload.Content
+	note.UpdatedAt = time.Now().Unix()
+
+	err = handler.DataStore.Note().Update(note.ID, note)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the note changes inside the database", err)
+	}
+
+	return response.JSON(w, note)
+}
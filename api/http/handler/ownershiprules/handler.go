@@ -0,0 +1,84 @@
+package ownershiprules
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/ownership"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to handle ownership rule operations.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+}
+
+// NewHandler creates a handler to manage ownership rule operations.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+
+	adminRouter := h.NewRoute().Subrouter()
+	adminRouter.Use(bouncer.AdminAccess)
+
+	adminRouter.Handle("/ownership_rules", httperror.LoggerHandler(h.ownershipRuleCreate)).Methods(http.MethodPost)
+	adminRouter.Handle("/ownership_rules", httperror.LoggerHandler(h.ownershipRuleList)).Methods(http.MethodGet)
+	adminRouter.Handle("/ownership_rules/{id}", httperror.LoggerHandler(h.ownershipRuleInspect)).Methods(http.MethodGet)
+	adminRouter.Handle("/ownership_rules/{id}", httperror.LoggerHandler(h.ownershipRuleUpdate)).Methods(http.MethodPut)
+	adminRouter.Handle("/ownership_rules/{id}", httperror.LoggerHandler(h.ownershipRuleDelete)).Methods(http.MethodDelete)
+
+	return h
+}
+
+func (handler *Handler) ruleFromRequest(r *http.Request) (*portainer.OwnershipRule, *httperror.HandlerError) {
+	ruleID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid ownership rule identifier route variable", err)
+	}
+
+	rule, err := handler.DataStore.OwnershipRule().Read(portainer.OwnershipRuleID(ruleID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find an ownership rule with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find an ownership rule with the specified identifier inside the database", err)
+	}
+
+	return rule, nil
+}
+
+// validateRule checks the matching criteria of the rule and that its team, its environments and its groups exist
+func (handler *Handler) validateRule(rule *portainer.OwnershipRule) error {
+	if rule.Name == "" {
+		return errors.New("invalid ownership rule name")
+	}
+
+	if err := ownership.Validate(rule); err != nil {
+		return err
+	}
+
+	if _, err := handler.DataStore.Team().Read(rule.TeamID); err != nil {
+		return errors.New("invalid team, it does not exist")
+	}
+
+	for _, endpointID := range rule.EndpointIDs {
+		if _, err := handler.DataStore.Endpoint().Endpoint(endpointID); err != nil {
+			return errors.New("invalid environment, it does not exist")
+		}
+	}
+
+	for _, groupID := range rule.EndpointGroupIDs {
+		if _, err := handler.DataStore.EndpointGroup().Read(groupID); err != nil {
+			return errors.New("invalid environment group, it does not exist")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package ownershiprules
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type ownershipRuleCreatePayload struct {
+	// Name of the ownership rule
+	Name string `validate:"required" example:"monitoring"`
+	// Environments of the rule, the rule applying to all the environments when neither environments nor groups are set
+	EndpointIDs []portainer.EndpointID `example:"1"`
+	// Environment groups of the rule, the rule applying to all their environments
+	EndpointGroupIDs []portainer.EndpointGroupID `example:"2"`
+	// Types of the matched resources among containers (1), volumes (3) and networks (4), all of them when empty
+	ResourceTypes []portainer.ResourceControlType `example:"1"`
+	// Label of the matched resources, formatted as name or name=value
+	Label string `example:"com.example.team=monitoring"`
+	// Regular expression matched against the name of the resources
+	NamePattern string `example:"^prometheus"`
+	// Team given the ownership of the matched resources
+	TeamID portainer.TeamID `validate:"required" example:"1"`
+}
+
+func (payload *ownershipRuleCreatePayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id OwnershipRuleCreate
+// @summary Create an ownership rule
+// @description Create a rule giving a team the ownership of the Docker resources created outside of Portainer, matched by a label
+// @description or a name pattern. When an environment is snapshotted, its containers, volumes and networks without resource control
+// @description are given to the team of the first matching rule instead of only being visible to the administrators.
+// @description **Access policy**: administrator
+// @tags ownership_rules
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body ownershipRuleCreatePayload true "Ownership rule details"
+// @success 200 {object} portainer.OwnershipRule "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /ownership_rules [post]
+func (handler *Handler) ownershipRuleCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload ownershipRuleCreatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	rule := &portainer.OwnershipRule{
+		Name:             payload.Name,
+		EndpointIDs:      payload.EndpointIDs,
+		EndpointGroupIDs: payload.EndpointGroupIDs,
+		ResourceTypes:    payload.ResourceTypes,
+		Label:            payload.Label,
+		NamePattern:      payload.NamePattern,
+		TeamID:           payload.TeamID,
+	}
+
+	if err := handler.validateRule(rule); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	err = handler.DataStore.OwnershipRule().Create(rule)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the ownership rule inside the database", err)
+	}
+
+	return response.JSON(w, rule)
+}
@@ -0,0 +1,35 @@
+package ownershiprules
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id OwnershipRuleDelete
+// @summary Remove an ownership rule
+// @description The resources already given to the team of the rule keep their resource control.
+// @description **Access policy**: administrator
+// @tags ownership_rules
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Ownership rule identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Ownership rule not found"
+// @failure 500 "Server error"
+// @router /ownership_rules/{id} [delete]
+func (handler *Handler) ownershipRuleDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	rule, httpErr := handler.ruleFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err := handler.DataStore.OwnershipRule().Delete(rule.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the ownership rule from the database", err)
+	}
+
+	return response.Empty(w)
+}
@@ -0,0 +1,30 @@
+package ownershiprules
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id OwnershipRuleInspect
+// @summary Inspect an ownership rule
+// @description **Access policy**: administrator
+// @tags ownership_rules
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Ownership rule identifier"
+// @success 200 {object} portainer.OwnershipRule "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Ownership rule not found"
+// @failure 500 "Server error"
+// @router /ownership_rules/{id} [get]
+func (handler *Handler) ownershipRuleInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	rule, httpErr := handler.ruleFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, rule)
+}
@@ -0,0 +1,28 @@
+package ownershiprules
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id OwnershipRuleList
+// @summary List the ownership rules
+// @description The rules are listed in the order they are evaluated, the first matching rule being used.
+// @description **Access policy**: administrator
+// @tags ownership_rules
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.OwnershipRule "Success"
+// @failure 500 "Server error"
+// @router /ownership_rules [get]
+func (handler *Handler) ownershipRuleList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	rules, err := handler.DataStore.OwnershipRule().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the ownership rules from the database", err)
+	}
+
+	return response.JSON(w, rules)
+}
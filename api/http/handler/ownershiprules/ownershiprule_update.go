@@ -0,0 +1,99 @@
+package ownershiprules
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type ownershipRuleUpdatePayload struct {
+	// Name of the ownership rule
+	Name *string `example:"monitoring"`
+	// Environments of the rule
+	EndpointIDs []portainer.EndpointID `example:"1"`
+	// Environment groups of the rule
+	EndpointGroupIDs []portainer.EndpointGroupID `example:"2"`
+	// Types of the matched resources, replacing the existing ones
+	ResourceTypes []portainer.ResourceControlType `example:"1"`
+	// Label of the matched resources, an empty value removes it
+	Label *string `example:"com.example.team=monitoring"`
+	// Regular expression matched against the name of the resources, an empty value removes it
+	NamePattern *string `example:"^prometheus"`
+	// Team given the ownership of the matched resources
+	TeamID *portainer.TeamID `example:"1"`
+}
+
+func (payload *ownershipRuleUpdatePayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id OwnershipRuleUpdate
+// @summary Update an ownership rule
+// @description The resources already given to a team keep their resource control.
+// @description **Access policy**: administrator
+// @tags ownership_rules
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Ownership rule identifier"
+// @param body body ownershipRuleUpdatePayload true "Ownership rule details"
+// @success 200 {object} portainer.OwnershipRule "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Ownership rule not found"
+// @failure 500 "Server error"
+// @router /ownership_rules/{id} [put]
+func (handler *Handler) ownershipRuleUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	rule, httpErr := handler.ruleFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var payload ownershipRuleUpdatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	if payload.Name != nil {
+		rule.Name = *payload.Name
+	}
+
+	if payload.EndpointIDs != nil {
+		rule.EndpointIDs = payload.EndpointIDs
+	}
+
+	if payload.EndpointGroupIDs != nil {
+		rule.EndpointGroupIDs = payload.EndpointGroupIDs
+	}
+
+	if payload.ResourceTypes != nil {
+		rule.ResourceTypes = payload.ResourceTypes
+	}
+
+	if payload.Label != nil {
+		rule.Label = *payload.Label
+	}
+
+	if payload.NamePattern != nil {
+		rule.NamePattern = *payload.NamePattern
+	}
+
+	if payload.TeamID != nil {
+		rule.TeamID = *payload.TeamID
+	}
+
+	if err := handler.validateRule(rule); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	err = handler.DataStore.OwnershipRule().Update(rule.ID, rule)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the ownership rule changes inside the database", err)
+	}
+
+	return response.JSON(w, rule)
+}
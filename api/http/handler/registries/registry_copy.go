@@ -0,0 +1,193 @@
+package registries
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/images"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// registryCopyMethodRegistry transfers the image directly from registry to registry
+	registryCopyMethodRegistry = "registry"
+	// registryCopyMethodEndpoint pulls and pushes the image with the Docker daemon of an environment
+	registryCopyMethodEndpoint = "endpoint"
+)
+
+type registryCopyPayload struct {
+	// Repository and tag or digest of the image in the source registry
+	SourceImage string `validate:"required" example:"app/api:1.4.2"`
+	// Identifier of the registry the image is copied to
+	TargetRegistryID portainer.RegistryID `validate:"required" example:"2"`
+	// Repository and tag of the image in the target registry, defaults to the source image
+	TargetImage string `example:"app/api:1.4.2"`
+	// Copy method, registry transfers the image from registry to registry, endpoint pulls and pushes the image with
+	// the Docker daemon of an environment. Defaults to registry
+	Method string `example:"registry" enums:"registry,endpoint"`
+	// Identifier of the Docker environment pulling and pushing the image, required by the endpoint method
+	EndpointID portainer.EndpointID `example:"1"`
+}
+
+func (payload *registryCopyPayload) Validate(r *http.Request) error {
+	if payload.TargetRegistryID == 0 {
+		return errors.New("invalid target registry identifier")
+	}
+
+	if err := images.ValidateImageName(payload.SourceImage, false); err != nil {
+		return err
+	}
+
+	if payload.TargetImage == "" {
+		payload.TargetImage = payload.SourceImage
+	}
+
+	if err := images.ValidateImageName(payload.TargetImage, true); err != nil {
+		return err
+	}
+
+	if payload.Method == "" {
+		payload.Method = registryCopyMethodRegistry
+	}
+
+	switch payload.Method {
+	case registryCopyMethodRegistry:
+	case registryCopyMethodEndpoint:
+		if payload.EndpointID == 0 {
+			return errors.New("invalid environment identifier, an environment is required by the endpoint method")
+		}
+	default:
+		return fmt.Errorf("invalid copy method %q, registry or endpoint is expected", payload.Method)
+	}
+
+	return nil
+}
+
+// @id RegistryCopy
+// @summary Copy an image to another registry
+// @description Retag and push an image of the registry to another registry, e.g. to promote an image from a staging registry to a production registry.
+// @description The image is either transferred directly from registry to registry, the blobs already present in the target registry being skipped,
+// @description or pulled and pushed by the Docker daemon of an environment.
+// @description The progress is streamed as newline-delimited Docker JSON messages, a message holding an error ends the stream when the copy fails.
+// @description **Access policy**: administrator
+// @tags registries
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce application/x-ndjson
+// @param id path int true "Source registry identifier"
+// @param body body registryCopyPayload true "Copy details"
+// @success 200 {object} jsonmessage.JSONMessage "Progress"
+// @failure 400 "Invalid request"
+// @failure 404 "Registry or environment not found"
+// @failure 500 "Server error"
+// @router /registries/{id}/copy [post]
+func (handler *Handler) registryCopy(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	registryID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid registry identifier route variable", err)
+	}
+
+	var payload registryCopyPayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	sourceRegistry, err := handler.DataStore.Registry().Read(portainer.RegistryID(registryID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a registry with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a registry with the specified identifier inside the database", err)
+	}
+
+	targetRegistry, err := handler.DataStore.Registry().Read(payload.TargetRegistryID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find the target registry inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find the target registry inside the database", err)
+	}
+
+	source := images.RegistryImage{Registry: sourceRegistry, Name: payload.SourceImage}
+	target := images.RegistryImage{Registry: targetRegistry, Name: payload.TargetImage}
+
+	if source.Reference() == target.Reference() {
+		return httperror.BadRequest("The source and target images are the same", errors.New("identical source and target images"))
+	}
+
+	copier := images.NewCopier(images.NewRegistryClient(handler.DataStore))
+
+	copy := func(progress images.ProgressFunc) error {
+		return copier.CopyBetweenRegistries(r.Context(), source, target, progress)
+	}
+
+	if payload.Method == registryCopyMethodEndpoint {
+		endpoint, err := handler.DataStore.Endpoint().Endpoint(payload.EndpointID)
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+		}
+
+		if !endpointutils.IsDockerEndpoint(endpoint) {
+			return httperror.BadRequest("The image can only be copied through a Docker environment", errors.New("environment is not a docker environment"))
+		}
+
+		if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+			return httperror.Forbidden("Permission denied to access environment", err)
+		}
+
+		cli, err := handler.DockerClientFactory.CreateClient(endpoint, "", nil)
+		if err != nil {
+			return httperror.InternalServerError("Unable to connect to the Docker environment", err)
+		}
+		defer cli.Close()
+
+		copy = func(progress images.ProgressFunc) error {
+			return copier.CopyThroughDaemon(r.Context(), cli, source, target, progress)
+		}
+	}
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	progress := func(message jsonmessage.JSONMessage) {
+		if err := encoder.Encode(message); err == nil {
+			rc.Flush()
+		}
+	}
+
+	progress(jsonmessage.JSONMessage{Status: fmt.Sprintf("Copying %s to %s", source.Reference(), target.Reference())})
+
+	if err := copy(progress); err != nil {
+		log.Warn().
+			Err(err).
+			Str("source", source.Reference()).
+			Str("target", target.Reference()).
+			Msg("unable to copy the image")
+
+		progress(jsonmessage.JSONMessage{
+			Error:        &jsonmessage.JSONError{Message: err.Error()},
+			ErrorMessage: err.Error(),
+		})
+
+		return nil
+	}
+
+	progress(jsonmessage.JSONMessage{Status: fmt.Sprintf("Copied %s to %s", source.Reference(), target.Reference())})
+
+	return nil
+}
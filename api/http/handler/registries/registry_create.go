@@ -140,6 +140,7 @@ func (handler *Handler) registryCreate(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unable to persist the registry inside the database", err)
 	}
 
-	hideFields(registry, true)
+	// the accesses are returned to the administrators, along with the other computed fields of the registry
+	hideFields(registry, false)
 	return response.JSON(w, registry)
 }
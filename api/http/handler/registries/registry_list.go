@@ -2,10 +2,13 @@ package registries
 
 import (
 	"net/http"
+	"slices"
 
+	portainer "github.com/portainer/portainer/api"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
 )
 
@@ -19,6 +22,7 @@ import (
 // @security ApiKeyAuth
 // @security jwt
 // @produce json
+// @param name query string false "Only list the registry with this exact name"
 // @success 200 {array} portainer.Registry "Success"
 // @failure 500 "Server error"
 // @router /registries [get]
@@ -36,5 +40,15 @@ func (handler *Handler) registryList(w http.ResponseWriter, r *http.Request) *ht
 		return httperror.InternalServerError("Unable to retrieve registries from the database", err)
 	}
 
+	if name, _ := request.RetrieveQueryParameter(r, "name", true); name != "" {
+		registries = slices.DeleteFunc(registries, func(registry portainer.Registry) bool {
+			return registry.Name != name
+		})
+	}
+
+	for idx := range registries {
+		hideFields(&registries[idx], false)
+	}
+
 	return response.JSON(w, registries)
 }
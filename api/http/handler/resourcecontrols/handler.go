@@ -2,9 +2,13 @@ package resourcecontrols
 
 import (
 	"net/http"
+	"strconv"
 
+	"github.com/portainer/portainer/api/database/changes"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/dataservices/resourcecontrol"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/lifecycle"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -13,7 +17,8 @@ import (
 // Handler is the HTTP handler used to handle resource control operations.
 type Handler struct {
 	*mux.Router
-	DataStore dataservices.DataStore
+	DataStore       dataservices.DataStore
+	EventDispatcher *lifecycle.Dispatcher
 }
 
 // NewHandler creates a handler to manage resource control operations.
@@ -29,3 +34,12 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 		bouncer.AdminAccess(httperror.LoggerHandler(h.resourceControlDelete))).Methods(http.MethodDelete)
 	return h
 }
+
+// publishAccessChanged notifies the event webhooks of a change of the users and teams accessing a resource
+func (handler *Handler) publishAccessChanged(id int, action changes.Action) {
+	if handler.EventDispatcher == nil {
+		return
+	}
+
+	handler.EventDispatcher.Publish(lifecycle.NewAccessChangedEvent(resourcecontrol.BucketName, strconv.Itoa(id), action))
+}
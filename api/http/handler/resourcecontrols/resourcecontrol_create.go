@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/database/changes"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -114,5 +115,7 @@ func (handler *Handler) resourceControlCreate(w http.ResponseWriter, r *http.Req
 		return httperror.InternalServerError("Unable to persist the resource control inside the database", err)
 	}
 
+	handler.publishAccessChanged(int(resourceControl.ID), changes.ActionCreate)
+
 	return response.JSON(w, resourceControl)
 }
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/database/changes"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -40,5 +41,7 @@ func (handler *Handler) resourceControlDelete(w http.ResponseWriter, r *http.Req
 		return httperror.InternalServerError("Unable to remove the resource control from the database", err)
 	}
 
+	handler.publishAccessChanged(resourceControlID, changes.ActionDelete)
+
 	return response.Empty(w)
 }
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/database/changes"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
@@ -111,5 +112,7 @@ func (handler *Handler) resourceControlUpdate(w http.ResponseWriter, r *http.Req
 		return httperror.InternalServerError("Unable to persist resource control changes inside the database", err)
 	}
 
+	handler.publishAccessChanged(int(resourceControl.ID), changes.ActionUpdate)
+
 	return response.JSON(w, resourceControl)
 }
@@ -0,0 +1,117 @@
+package roles
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/authorization"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/asaskevich/govalidator"
+)
+
+type customRolePayload struct {
+	Name        string `validate:"required" example:"Security team"`
+	Description string `example:"Manages the authentication settings"`
+	// Settings sections delegated by the role, among authentication, notifications, snapshots and edge
+	Sections []string `validate:"required" example:"authentication"`
+	// Users the role is assigned to
+	UserIDs []portainer.UserID `json:"UserIds" example:"3"`
+	// Teams whose members the role is assigned to
+	TeamIDs []portainer.TeamID `json:"TeamIds" example:"1"`
+}
+
+func (payload *customRolePayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Name) {
+		return errors.New("invalid role name")
+	}
+
+	if len(payload.Sections) == 0 {
+		return errors.New("at least one settings section is required")
+	}
+
+	for _, section := range payload.Sections {
+		if _, ok := authorization.SettingsSections[section]; !ok {
+			return fmt.Errorf("invalid settings section %q, authentication, notifications, snapshots or edge is expected", section)
+		}
+	}
+
+	return nil
+}
+
+// @id RoleCreate
+// @summary Create a custom role
+// @description Create a role delegating sections of the settings to users and teams which are not administrators.
+// @description **Access policy**: administrator
+// @tags roles
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body customRolePayload true "Role details"
+// @success 200 {object} portainer.Role "Success"
+// @failure 400 "Invalid request"
+// @failure 409 "Role name exists"
+// @failure 500 "Server error"
+// @router /roles [post]
+func (handler *Handler) roleCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload customRolePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	role := &portainer.Role{Custom: true}
+	if httpErr := handler.applyPayload(role, payload); httpErr != nil {
+		return httpErr
+	}
+
+	err = handler.DataStore.Role().Create(role)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the role inside the database", err)
+	}
+
+	return response.JSON(w, role)
+}
+
+// applyPayload validates the name, the users and the teams of the payload and applies them to the custom role
+func (handler *Handler) applyPayload(role *portainer.Role, payload customRolePayload) *httperror.HandlerError {
+	roles, err := handler.DataStore.Role().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the roles from the database", err)
+	}
+
+	for _, existing := range roles {
+		if existing.ID != role.ID && existing.Name == payload.Name {
+			return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "This name is already associated to a role", Err: errors.New("a role already exists with this name")}
+		}
+	}
+
+	for _, userID := range payload.UserIDs {
+		if _, err := handler.DataStore.User().Read(userID); err != nil {
+			return httperror.BadRequest("Unable to find a user with the specified identifier inside the database", err)
+		}
+	}
+
+	for _, teamID := range payload.TeamIDs {
+		if _, err := handler.DataStore.Team().Read(teamID); err != nil {
+			return httperror.BadRequest("Unable to find a team with the specified identifier inside the database", err)
+		}
+	}
+
+	role.Name = payload.Name
+	role.Description = payload.Description
+	role.UserIDs = payload.UserIDs
+	role.TeamIDs = payload.TeamIDs
+	role.Authorizations = portainer.Authorizations{}
+
+	for _, section := range payload.Sections {
+		role.Authorizations[authorization.SettingsSections[section]] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package roles
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id RoleDelete
+// @summary Remove a custom role
+// @description Remove a custom role, the settings sections it delegates are no longer accessible to its users and teams.
+// @description The predefined roles cannot be removed.
+// @description **Access policy**: administrator
+// @tags roles
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Role identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "The role is predefined"
+// @failure 404 "Role not found"
+// @failure 500 "Server error"
+// @router /roles/{id} [delete]
+func (handler *Handler) roleDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	roleID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid role identifier route variable", err)
+	}
+
+	role, httpErr := handler.customRole(portainer.RoleID(roleID))
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err = handler.DataStore.Role().Delete(role.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the role from the database", err)
+	}
+
+	return response.Empty(w)
+}
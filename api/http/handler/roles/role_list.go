@@ -9,7 +9,7 @@ import (
 
 // @id RoleList
 // @summary List roles
-// @description List all roles available for use
+// @description List all roles available for use, including the custom roles delegating sections of the settings
 // @description **Access policy**: administrator
 // @tags roles
 // @security ApiKeyAuth
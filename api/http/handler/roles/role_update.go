@@ -0,0 +1,74 @@
+package roles
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id RoleUpdate
+// @summary Update a custom role
+// @description Update the settings sections, the users and the teams of a custom role. The predefined roles cannot be updated.
+// @description **Access policy**: administrator
+// @tags roles
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Role identifier"
+// @param body body customRolePayload true "Role details"
+// @success 200 {object} portainer.Role "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "The role is predefined"
+// @failure 404 "Role not found"
+// @failure 409 "Role name exists"
+// @failure 500 "Server error"
+// @router /roles/{id} [put]
+func (handler *Handler) roleUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	roleID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid role identifier route variable", err)
+	}
+
+	var payload customRolePayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	role, httpErr := handler.customRole(portainer.RoleID(roleID))
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if httpErr := handler.applyPayload(role, payload); httpErr != nil {
+		return httpErr
+	}
+
+	err = handler.DataStore.Role().Update(role.ID, role)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the role changes inside the database", err)
+	}
+
+	return response.JSON(w, role)
+}
+
+// customRole reads a role which can be modified by the administrators
+func (handler *Handler) customRole(roleID portainer.RoleID) (*portainer.Role, *httperror.HandlerError) {
+	role, err := handler.DataStore.Role().Read(roleID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a role with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a role with the specified identifier inside the database", err)
+	}
+
+	if !role.Custom {
+		return nil, httperror.Forbidden("The predefined roles cannot be modified", errors.New("the role is not a custom role"))
+	}
+
+	return role, nil
+}
@@ -0,0 +1,207 @@
+package savedviews
+
+import (
+	"slices"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/internal/snapshot"
+)
+
+const (
+	healthHealthy   = "healthy"
+	healthUnhealthy = "unhealthy"
+	healthStarting  = "starting"
+	healthNone      = "none"
+)
+
+// systemNetworks are the networks created by the Docker engines, which any user of an environment can access
+var systemNetworks = []string{"bridge", "host", "ingress", "nat", "none"}
+
+type viewResult struct {
+	EndpointID   portainer.EndpointID `json:"EndpointId" example:"1"`
+	EndpointName string               `json:"EndpointName" example:"production"`
+	ID           string               `json:"Id" example:"3a8b3e1c5f2d"`
+	Name         string               `json:"Name" example:"web-nginx-1"`
+	// Image of the containers
+	Image string `json:"Image,omitempty" example:"nginx:1.25"`
+	// State, status and health of the containers
+	State  string            `json:"State,omitempty" example:"running"`
+	Status string            `json:"Status,omitempty" example:"Up 2 hours (unhealthy)"`
+	Health string            `json:"Health,omitempty" example:"unhealthy"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// evaluator matches the resources of the snapshots against the filter of a saved view, the non administrators only
+// getting the resources they can access through the Docker API
+type evaluator struct {
+	filter           portainer.SavedViewFilter
+	securityContext  *security.RestrictedRequestContext
+	teamIDs          []portainer.TeamID
+	resourceControls []portainer.ResourceControl
+}
+
+func newEvaluator(filter portainer.SavedViewFilter, securityContext *security.RestrictedRequestContext, resourceControls []portainer.ResourceControl) *evaluator {
+	e := &evaluator{
+		filter:           filter,
+		securityContext:  securityContext,
+		resourceControls: resourceControls,
+	}
+
+	for _, membership := range securityContext.UserMemberships {
+		e.teamIDs = append(e.teamIDs, membership.TeamID)
+	}
+
+	return e
+}
+
+func (e *evaluator) evaluate(endpoint *portainer.Endpoint, dockerSnapshot *portainer.DockerSnapshot) []viewResult {
+	results := []viewResult{}
+
+	newResult := func(id, name string, labels map[string]string) viewResult {
+		return viewResult{EndpointID: endpoint.ID, EndpointName: endpoint.Name, ID: id, Name: name, Labels: labels}
+	}
+
+	raw := dockerSnapshot.SnapshotRaw
+
+	switch e.filter.ResourceType {
+	case portainer.SavedViewContainer:
+		for _, container := range raw.Containers {
+			names := make([]string, 0, len(container.Names))
+			for _, name := range container.Names {
+				names = append(names, strings.TrimPrefix(name, "/"))
+			}
+
+			health := containerHealth(container.Status)
+
+			if !e.matchName(names...) || !e.matchLabels(container.Labels) || !e.matchContainer(container.State, health, container.Image) {
+				continue
+			}
+
+			if !e.canAccess(endpoint.ID, container.ID, portainer.ContainerResourceControl, container.Labels) {
+				continue
+			}
+
+			result := newResult(container.ID, firstOrDefault(names, container.ID), container.Labels)
+			result.Image = container.Image
+			result.State = container.State
+			result.Status = container.Status
+			result.Health = health
+			results = append(results, result)
+		}
+
+	case portainer.SavedViewImage:
+		// the images are not covered by the resource controls, any user of the environment can list them
+		for _, image := range raw.Images {
+			if e.matchName(image.RepoTags...) && e.matchLabels(image.Labels) {
+				results = append(results, newResult(image.ID, firstOrDefault(image.RepoTags, image.ID), image.Labels))
+			}
+		}
+
+	case portainer.SavedViewVolume:
+		dockerID, err := snapshot.FetchDockerID(*dockerSnapshot)
+
+		for _, volume := range raw.Volumes.Volumes {
+			if volume == nil || !e.matchName(volume.Name) || !e.matchLabels(volume.Labels) {
+				continue
+			}
+
+			// the resource controls of the volumes are scoped to the engine or the Swarm cluster
+			if !e.securityContext.IsAdmin && (err != nil || !e.canAccess(endpoint.ID, volume.Name+"_"+dockerID, portainer.VolumeResourceControl, volume.Labels)) {
+				continue
+			}
+
+			results = append(results, newResult(volume.Name, volume.Name, volume.Labels))
+		}
+
+	case portainer.SavedViewNetwork:
+		for _, network := range raw.Networks {
+			if !e.matchName(network.Name) || !e.matchLabels(network.Labels) {
+				continue
+			}
+
+			if !slices.Contains(systemNetworks, network.Name) && !e.canAccess(endpoint.ID, network.ID, portainer.NetworkResourceControl, network.Labels) {
+				continue
+			}
+
+			results = append(results, newResult(network.ID, network.Name, network.Labels))
+		}
+	}
+
+	return results
+}
+
+func (e *evaluator) matchName(names ...string) bool {
+	if e.filter.Name == "" {
+		return true
+	}
+
+	for _, name := range names {
+		if containsFold(name, e.filter.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e *evaluator) matchLabels(labels map[string]string) bool {
+	for _, label := range e.filter.Labels {
+		key, value, withValue := strings.Cut(label, "=")
+
+		actual, ok := labels[key]
+		if !ok || (withValue && actual != value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e *evaluator) matchContainer(state, health, image string) bool {
+	if len(e.filter.States) > 0 && !slices.Contains(e.filter.States, state) {
+		return false
+	}
+
+	if e.filter.Health != "" && e.filter.Health != health && !(e.filter.Health == healthNone && health == "") {
+		return false
+	}
+
+	return e.filter.Image == "" || containsFold(image, e.filter.Image)
+}
+
+func (e *evaluator) canAccess(endpointID portainer.EndpointID, resourceID string, resourceType portainer.ResourceControlType, labels map[string]string) bool {
+	if e.securityContext.IsAdmin {
+		return true
+	}
+
+	return authorization.UserCanAccessDockerResource(e.securityContext.UserID, e.teamIDs, endpointID, resourceID, resourceType, labels, e.resourceControls)
+}
+
+// containerHealth extracts the health of a container from its status, empty when it has no health check
+func containerHealth(status string) string {
+	switch {
+	case strings.Contains(status, "(healthy)"):
+		return healthHealthy
+	case strings.Contains(status, "(unhealthy)"):
+		return healthUnhealthy
+	case strings.Contains(status, "(health: starting)"):
+		return healthStarting
+	}
+
+	return ""
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func firstOrDefault(values []string, defaultValue string) string {
+	if len(values) > 0 {
+		return values[0]
+	}
+
+	return defaultValue
+}
@@ -0,0 +1,142 @@
+package savedviews
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to handle saved view operations.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+}
+
+// NewHandler creates a handler to manage saved view operations.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+
+	restrictedRouter := h.NewRoute().Subrouter()
+	restrictedRouter.Use(bouncer.RestrictedAccess)
+
+	restrictedRouter.Handle("/saved_views", httperror.LoggerHandler(h.savedViewCreate)).Methods(http.MethodPost)
+	restrictedRouter.Handle("/saved_views", httperror.LoggerHandler(h.savedViewList)).Methods(http.MethodGet)
+	restrictedRouter.Handle("/saved_views/{id}", httperror.LoggerHandler(h.savedViewInspect)).Methods(http.MethodGet)
+	restrictedRouter.Handle("/saved_views/{id}", httperror.LoggerHandler(h.savedViewUpdate)).Methods(http.MethodPut)
+	restrictedRouter.Handle("/saved_views/{id}", httperror.LoggerHandler(h.savedViewDelete)).Methods(http.MethodDelete)
+	restrictedRouter.Handle("/saved_views/{id}/results", httperror.LoggerHandler(h.savedViewResults)).Methods(http.MethodGet)
+
+	return h
+}
+
+// viewFromRequest returns the saved view of the request, when the user owns it or it is shared with one of their
+// teams. The saved view can only be managed by its owner and the administrators.
+func (handler *Handler) viewFromRequest(r *http.Request, manage bool) (*portainer.SavedView, *httperror.HandlerError) {
+	viewID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid saved view identifier route variable", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	view, err := handler.DataStore.SavedView().Read(portainer.SavedViewID(viewID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a saved view with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a saved view with the specified identifier inside the database", err)
+	}
+
+	if !canReadView(view, securityContext) {
+		return nil, httperror.Forbidden("Permission denied to access the saved view", httperrors.ErrResourceAccessDenied)
+	}
+
+	if manage && !canManageView(view, securityContext) {
+		return nil, httperror.Forbidden("Only the owner of the saved view can manage it", httperrors.ErrResourceAccessDenied)
+	}
+
+	return view, nil
+}
+
+func canReadView(view *portainer.SavedView, securityContext *security.RestrictedRequestContext) bool {
+	if canManageView(view, securityContext) {
+		return true
+	}
+
+	for _, membership := range securityContext.UserMemberships {
+		if slices.Contains(view.TeamIDs, membership.TeamID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func canManageView(view *portainer.SavedView, securityContext *security.RestrictedRequestContext) bool {
+	return securityContext.IsAdmin || view.OwnerID == securityContext.UserID
+}
+
+func validateFilter(filter portainer.SavedViewFilter) error {
+	switch filter.ResourceType {
+	case portainer.SavedViewContainer:
+	case portainer.SavedViewImage, portainer.SavedViewVolume, portainer.SavedViewNetwork:
+		if len(filter.States) > 0 || filter.Health != "" || filter.Image != "" {
+			return errors.New("the states, the health and the image can only filter the containers")
+		}
+	default:
+		return errors.New("invalid resource type, it must be one of container, image, volume or network")
+	}
+
+	switch filter.Health {
+	case "", healthHealthy, healthUnhealthy, healthStarting, healthNone:
+	default:
+		return errors.New("invalid health, it must be one of healthy, unhealthy, starting or none")
+	}
+
+	for _, label := range filter.Labels {
+		if key, _, _ := strings.Cut(label, "="); key == "" {
+			return errors.New("invalid label, it must be key=value or key")
+		}
+	}
+
+	return nil
+}
+
+// validateTeams ensures the teams exist and, for the non administrators, that the user is a member of each of them
+func (handler *Handler) validateTeams(teamIDs []portainer.TeamID, securityContext *security.RestrictedRequestContext) *httperror.HandlerError {
+	for _, teamID := range teamIDs {
+		_, err := handler.DataStore.Team().Read(teamID)
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.BadRequest("Unable to find a team the saved view is shared with", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find a team the saved view is shared with", err)
+		}
+
+		if securityContext.IsAdmin {
+			continue
+		}
+
+		isMember := slices.ContainsFunc(securityContext.UserMemberships, func(membership portainer.TeamMembership) bool {
+			return membership.TeamID == teamID
+		})
+		if !isMember {
+			return httperror.Forbidden("A saved view can only be shared with the teams of its owner", httperrors.ErrResourceAccessDenied)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package savedviews
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type savedViewCreatePayload struct {
+	// Name of the saved view
+	Name string `validate:"required" example:"unhealthy-prod"`
+	// Description of the saved view
+	Description string `example:"Unhealthy production containers"`
+	// Filter selecting the resources
+	Filter portainer.SavedViewFilter
+	// Teams the saved view is shared with, the non administrators can only share it with their teams
+	TeamIDs []portainer.TeamID `example:"1"`
+}
+
+func (payload *savedViewCreatePayload) Validate(r *http.Request) error {
+	if payload.Name == "" {
+		return errors.New("invalid saved view name")
+	}
+
+	return validateFilter(payload.Filter)
+}
+
+// @id SavedViewCreate
+// @summary Create a saved view
+// @description Save a filter on the containers, the images, the volumes or the networks of the environments, optionally
+// @description shared with teams. The saved view is owned by the user creating it.
+// @description **Access policy**: restricted
+// @tags saved_views
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body savedViewCreatePayload true "Saved view details"
+// @success 200 {object} portainer.SavedView "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 500 "Server error"
+// @router /saved_views [post]
+func (handler *Handler) savedViewCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload savedViewCreatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	if httpErr := handler.validateTeams(payload.TeamIDs, securityContext); httpErr != nil {
+		return httpErr
+	}
+
+	view := &portainer.SavedView{
+		Name:        payload.Name,
+		Description: payload.Description,
+		Filter:      payload.Filter,
+		OwnerID:     securityContext.UserID,
+		TeamIDs:     payload.TeamIDs,
+		CreatedAt:   time.Now().Unix(),
+	}
+
+	err = handler.DataStore.SavedView().Create(view)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the saved view inside the database", err)
+	}
+
+	return response.JSON(w, view)
+}
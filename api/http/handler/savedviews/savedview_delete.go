@@ -0,0 +1,36 @@
+package savedviews
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id SavedViewDelete
+// @summary Remove a saved view
+// @description Only the owner of the saved view and the administrators can remove it.
+// @description **Access policy**: restricted
+// @tags saved_views
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Saved view identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Saved view not found"
+// @failure 500 "Server error"
+// @router /saved_views/{id} [delete]
+func (handler *Handler) savedViewDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	view, httpErr := handler.viewFromRequest(r, true)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	err := handler.DataStore.SavedView().Delete(view.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the saved view from the database", err)
+	}
+
+	return response.Empty(w)
+}
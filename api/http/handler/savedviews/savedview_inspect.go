@@ -0,0 +1,32 @@
+package savedviews
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id SavedViewInspect
+// @summary Inspect a saved view
+// @description The saved view must be owned by the user or shared with one of their teams.
+// @description **Access policy**: restricted
+// @tags saved_views
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Saved view identifier"
+// @success 200 {object} portainer.SavedView "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Saved view not found"
+// @failure 500 "Server error"
+// @router /saved_views/{id} [get]
+func (handler *Handler) savedViewInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	view, httpErr := handler.viewFromRequest(r, false)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, view)
+}
@@ -0,0 +1,43 @@
+package savedviews
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id SavedViewList
+// @summary List the saved views
+// @description List the saved views owned by the user and the ones shared with their teams. The administrators see
+// @description all the saved views.
+// @description **Access policy**: restricted
+// @tags saved_views
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.SavedView "Success"
+// @failure 500 "Server error"
+// @router /saved_views [get]
+func (handler *Handler) savedViewList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	views, err := handler.DataStore.SavedView().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the saved views from the database", err)
+	}
+
+	filtered := []portainer.SavedView{}
+	for i := range views {
+		if canReadView(&views[i], securityContext) {
+			filtered = append(filtered, views[i])
+		}
+	}
+
+	return response.JSON(w, filtered)
+}
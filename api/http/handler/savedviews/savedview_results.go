@@ -0,0 +1,122 @@
+package savedviews
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type viewResults struct {
+	ViewID       portainer.SavedViewID           `json:"ViewId" example:"1"`
+	ResourceType portainer.SavedViewResourceType `json:"ResourceType" example:"container"`
+	// Unix timestamp of the evaluation
+	Time int64 `json:"Time" example:"1700000000"`
+	// Environments the saved view was evaluated against
+	Endpoints []viewEndpoint `json:"Endpoints"`
+	Results   []viewResult   `json:"Results"`
+}
+
+type viewEndpoint struct {
+	EndpointID   portainer.EndpointID `json:"EndpointId" example:"1"`
+	EndpointName string               `json:"EndpointName" example:"production"`
+	// Unix timestamp of the snapshot the results come from, 0 when the environment has no snapshot yet
+	SnapshotTime int64 `json:"SnapshotTime" example:"1700000000"`
+}
+
+// @id SavedViewResults
+// @summary Evaluate a saved view
+// @description Retrieve the resources matching the filter of a saved view in the Docker environments the user can
+// @description access. The saved view is evaluated against the latest snapshots of the environments, which are not
+// @description queried, and only the resources the user can access are returned.
+// @description **Access policy**: restricted
+// @tags saved_views
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Saved view identifier"
+// @success 200 {object} viewResults "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Saved view not found"
+// @failure 500 "Server error"
+// @router /saved_views/{id}/results [get]
+func (handler *Handler) savedViewResults(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	view, httpErr := handler.viewFromRequest(r, false)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	endpoints, err := handler.DataStore.Endpoint().Endpoints()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the environments from the database", err)
+	}
+
+	endpointGroups, err := handler.DataStore.EndpointGroup().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the environment groups from the database", err)
+	}
+
+	var resourceControls []portainer.ResourceControl
+	if !securityContext.IsAdmin {
+		resourceControls, err = handler.DataStore.ResourceControl().ReadAll()
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the resource controls from the database", err)
+		}
+	}
+
+	endpoints = slices.DeleteFunc(endpoints, func(endpoint portainer.Endpoint) bool {
+		return !endpointutils.IsDockerEndpoint(&endpoint) || (len(view.Filter.EndpointIDs) > 0 && !slices.Contains(view.Filter.EndpointIDs, endpoint.ID))
+	})
+	endpoints = security.FilterEndpoints(endpoints, endpointGroups, securityContext)
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].Name < endpoints[j].Name
+	})
+
+	results := viewResults{
+		ViewID:       view.ID,
+		ResourceType: view.Filter.ResourceType,
+		Time:         time.Now().Unix(),
+		Endpoints:    []viewEndpoint{},
+		Results:      []viewResult{},
+	}
+
+	evaluator := newEvaluator(view.Filter, securityContext, resourceControls)
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		evaluated := viewEndpoint{EndpointID: endpoint.ID, EndpointName: endpoint.Name}
+
+		snapshot, err := handler.DataStore.Snapshot().Read(endpoint.ID)
+		if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.InternalServerError("Unable to retrieve the environment snapshot from the database", err)
+		}
+
+		if snapshot != nil && snapshot.Docker != nil {
+			evaluated.SnapshotTime = snapshot.Docker.Time
+
+			matches := evaluator.evaluate(endpoint, snapshot.Docker)
+			sort.Slice(matches, func(i, j int) bool {
+				return matches[i].Name < matches[j].Name
+			})
+
+			results.Results = append(results.Results, matches...)
+		}
+
+		results.Endpoints = append(results.Endpoints, evaluated)
+	}
+
+	return response.JSON(w, results)
+}
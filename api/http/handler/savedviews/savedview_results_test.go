@@ -0,0 +1,213 @@
+package savedviews
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavedViews(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	team := &portainer.Team{Name: "oncall"}
+	is.NoError(store.Team().Create(team))
+
+	owner := &security.RestrictedRequestContext{UserID: 2, UserMemberships: []portainer.TeamMembership{{UserID: 2, TeamID: team.ID}}}
+	teammate := &security.RestrictedRequestContext{UserID: 3, UserMemberships: []portainer.TeamMembership{{UserID: 3, TeamID: team.ID}}}
+	outsider := &security.RestrictedRequestContext{UserID: 4}
+	admin := &security.RestrictedRequestContext{UserID: 1, IsAdmin: true}
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "production", Type: portainer.DockerEnvironment, GroupID: 1,
+		TeamAccessPolicies: portainer.TeamAccessPolicies{team.ID: {}}}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 2, Name: "staging", Type: portainer.DockerEnvironment, GroupID: 1}))
+
+	is.NoError(store.ResourceControl().Create(&portainer.ResourceControl{ResourceID: "1_web", Type: portainer.StackResourceControl,
+		TeamAccesses: []portainer.TeamResourceAccess{{TeamID: team.ID, AccessLevel: portainer.ReadWriteAccessLevel}}}))
+
+	containers := []portainer.DockerContainerSnapshot{
+		{Container: types.Container{ID: "c1", Names: []string{"/web-nginx-1"}, Image: "nginx:1.25", State: "running", Status: "Up 2 hours (unhealthy)",
+			Labels: map[string]string{"env": "prod", "com.docker.compose.project": "web"}}},
+		{Container: types.Container{ID: "c2", Names: []string{"/web-api-1"}, Image: "api:2", State: "running", Status: "Up 2 hours (healthy)",
+			Labels: map[string]string{"env": "prod", "com.docker.compose.project": "web"}}},
+		{Container: types.Container{ID: "c3", Names: []string{"/db"}, Image: "postgres:16", State: "running", Status: "Up 2 hours (unhealthy)",
+			Labels: map[string]string{"env": "prod"}}},
+	}
+
+	is.NoError(store.Snapshot().Create(&portainer.Snapshot{EndpointID: 1, Docker: &portainer.DockerSnapshot{Time: 1700000000, SnapshotRaw: portainer.DockerSnapshotRaw{
+		Containers: containers,
+		Volumes:    volume.ListResponse{Volumes: []*volume.Volume{{Name: "data", Labels: map[string]string{"env": "prod"}}}},
+		Networks:   []types.NetworkResource{{ID: "n1", Name: "bridge"}, {ID: "n2", Name: "web_default"}},
+	}}}))
+	is.NoError(store.Snapshot().Create(&portainer.Snapshot{EndpointID: 2, Docker: &portainer.DockerSnapshot{Time: 1700000000, SnapshotRaw: portainer.DockerSnapshotRaw{
+		Containers: containers,
+	}}}))
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer())
+	handler.DataStore = store
+
+	do := func(securityContext *security.RestrictedRequestContext, method, path string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			is.NoError(json.NewEncoder(&body).Encode(payload))
+		}
+
+		r := httptest.NewRequest(method, path, &body)
+		r = r.WithContext(security.StoreRestrictedRequestContext(r, securityContext))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	var view portainer.SavedView
+
+	t.Run("a user creates a saved view shared with their team", func(t *testing.T) {
+		w := do(owner, http.MethodPost, "/saved_views", savedViewCreatePayload{
+			Name:    "unhealthy-prod",
+			Filter:  portainer.SavedViewFilter{ResourceType: portainer.SavedViewContainer, Labels: []string{"env=prod"}, Health: healthUnhealthy},
+			TeamIDs: []portainer.TeamID{team.ID},
+		})
+		is.Equal(http.StatusOK, w.Code)
+		is.NoError(json.NewDecoder(w.Body).Decode(&view))
+		is.Equal(portainer.UserID(2), view.OwnerID)
+	})
+
+	t.Run("a saved view can only be shared with the teams of its owner", func(t *testing.T) {
+		w := do(outsider, http.MethodPost, "/saved_views", savedViewCreatePayload{
+			Name:    "shared",
+			Filter:  portainer.SavedViewFilter{ResourceType: portainer.SavedViewContainer},
+			TeamIDs: []portainer.TeamID{team.ID},
+		})
+		is.Equal(http.StatusForbidden, w.Code)
+	})
+
+	t.Run("invalid filters are rejected", func(t *testing.T) {
+		for _, filter := range []portainer.SavedViewFilter{
+			{ResourceType: "secret"},
+			{ResourceType: portainer.SavedViewVolume, Health: healthHealthy},
+			{ResourceType: portainer.SavedViewContainer, Health: "sick"},
+			{ResourceType: portainer.SavedViewContainer, Labels: []string{"=prod"}},
+		} {
+			w := do(owner, http.MethodPost, "/saved_views", savedViewCreatePayload{Name: "invalid", Filter: filter})
+			is.Equal(http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("the saved view is listed for its owner and their team only", func(t *testing.T) {
+		for securityContext, count := range map[*security.RestrictedRequestContext]int{owner: 1, teammate: 1, admin: 1, outsider: 0} {
+			w := do(securityContext, http.MethodGet, "/saved_views", nil)
+			is.Equal(http.StatusOK, w.Code)
+
+			var views []portainer.SavedView
+			is.NoError(json.NewDecoder(w.Body).Decode(&views))
+			is.Len(views, count)
+		}
+
+		w := do(outsider, http.MethodGet, fmt.Sprintf("/saved_views/%d", view.ID), nil)
+		is.Equal(http.StatusForbidden, w.Code)
+	})
+
+	t.Run("only the owner manages the saved view", func(t *testing.T) {
+		name := "renamed"
+
+		w := do(teammate, http.MethodPut, fmt.Sprintf("/saved_views/%d", view.ID), savedViewUpdatePayload{Name: &name})
+		is.Equal(http.StatusForbidden, w.Code)
+
+		w = do(teammate, http.MethodDelete, fmt.Sprintf("/saved_views/%d", view.ID), nil)
+		is.Equal(http.StatusForbidden, w.Code)
+
+		w = do(owner, http.MethodPut, fmt.Sprintf("/saved_views/%d", view.ID), savedViewUpdatePayload{Name: &name})
+		is.Equal(http.StatusOK, w.Code)
+	})
+
+	t.Run("the results only hold the resources the user can access", func(t *testing.T) {
+		w := do(teammate, http.MethodGet, fmt.Sprintf("/saved_views/%d/results", view.ID), nil)
+		is.Equal(http.StatusOK, w.Code)
+
+		var results viewResults
+		is.NoError(json.NewDecoder(w.Body).Decode(&results))
+
+		is.Len(results.Endpoints, 1, "the team has no access to the staging environment")
+		if is.Len(results.Results, 1, "the db container has no resource control") {
+			is.Equal("web-nginx-1", results.Results[0].Name)
+			is.Equal(healthUnhealthy, results.Results[0].Health)
+			is.Equal("production", results.Results[0].EndpointName)
+		}
+	})
+
+	t.Run("the administrators get the resources of all the environments", func(t *testing.T) {
+		w := do(admin, http.MethodGet, fmt.Sprintf("/saved_views/%d/results", view.ID), nil)
+		is.Equal(http.StatusOK, w.Code)
+
+		var results viewResults
+		is.NoError(json.NewDecoder(w.Body).Decode(&results))
+
+		is.Len(results.Endpoints, 2)
+		is.Len(results.Results, 4)
+	})
+
+	t.Run("the owner removes the saved view", func(t *testing.T) {
+		w := do(owner, http.MethodDelete, fmt.Sprintf("/saved_views/%d", view.ID), nil)
+		is.Equal(http.StatusNoContent, w.Code)
+
+		_, err := store.SavedView().Read(view.ID)
+		is.True(store.IsErrObjectNotFound(err))
+	})
+}
+
+func TestEvaluator(t *testing.T) {
+	is := assert.New(t)
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "production"}
+	snapshot := &portainer.DockerSnapshot{SnapshotRaw: portainer.DockerSnapshotRaw{
+		Containers: []portainer.DockerContainerSnapshot{
+			{Container: types.Container{ID: "c1", Names: []string{"/web-nginx-1"}, Image: "nginx:1.25", State: "running", Status: "Up 2 hours"}},
+			{Container: types.Container{ID: "c2", Names: []string{"/worker"}, Image: "worker:1", State: "exited", Status: "Exited (1) 2 hours ago",
+				Labels: map[string]string{"tier": "back"}}},
+		},
+		Images:   []types.ImageSummary{{ID: "sha256:1", RepoTags: []string{"nginx:1.25"}}, {ID: "sha256:2", RepoTags: []string{"postgres:16"}}},
+		Volumes:  volume.ListResponse{Volumes: []*volume.Volume{{Name: "pgdata"}, {Name: "cache", Labels: map[string]string{"tier": "front"}}}},
+		Networks: []types.NetworkResource{{ID: "n1", Name: "bridge"}, {ID: "n2", Name: "web_default"}},
+	}}
+
+	admin := &security.RestrictedRequestContext{UserID: 1, IsAdmin: true}
+
+	evaluate := func(filter portainer.SavedViewFilter) []string {
+		names := []string{}
+		for _, result := range newEvaluator(filter, admin, nil).evaluate(endpoint, snapshot) {
+			names = append(names, result.Name)
+		}
+
+		return names
+	}
+
+	is.Equal([]string{"web-nginx-1", "worker"}, evaluate(portainer.SavedViewFilter{ResourceType: portainer.SavedViewContainer}))
+	is.Equal([]string{"worker"}, evaluate(portainer.SavedViewFilter{ResourceType: portainer.SavedViewContainer, States: []string{"exited"}}))
+	is.Equal([]string{"worker"}, evaluate(portainer.SavedViewFilter{ResourceType: portainer.SavedViewContainer, Labels: []string{"tier"}}))
+	is.Equal([]string{}, evaluate(portainer.SavedViewFilter{ResourceType: portainer.SavedViewContainer, Labels: []string{"tier=front"}}))
+	is.Equal([]string{"web-nginx-1", "worker"}, evaluate(portainer.SavedViewFilter{ResourceType: portainer.SavedViewContainer, Health: healthNone}))
+	is.Equal([]string{"web-nginx-1"}, evaluate(portainer.SavedViewFilter{ResourceType: portainer.SavedViewContainer, Image: "NGINX"}))
+	is.Equal([]string{"postgres:16"}, evaluate(portainer.SavedViewFilter{ResourceType: portainer.SavedViewImage, Name: "postgres"}))
+	is.Equal([]string{"cache"}, evaluate(portainer.SavedViewFilter{ResourceType: portainer.SavedViewVolume, Labels: []string{"tier=front"}}))
+
+	user := &security.RestrictedRequestContext{UserID: 2}
+	networks := newEvaluator(portainer.SavedViewFilter{ResourceType: portainer.SavedViewNetwork}, user, nil).evaluate(endpoint, snapshot)
+	if is.Len(networks, 1, "only the system networks are available without resource control") {
+		is.Equal("bridge", networks[0].Name)
+	}
+}
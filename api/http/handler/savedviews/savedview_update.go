@@ -0,0 +1,92 @@
+package savedviews
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type savedViewUpdatePayload struct {
+	// Name of the saved view
+	Name *string `example:"unhealthy-prod"`
+	// Description of the saved view
+	Description *string `example:"Unhealthy production containers"`
+	// Filter selecting the resources
+	Filter *portainer.SavedViewFilter
+	// Teams the saved view is shared with, the non administrators can only share it with their teams
+	TeamIDs []portainer.TeamID `example:"1"`
+}
+
+func (payload *savedViewUpdatePayload) Validate(r *http.Request) error {
+	if payload.Filter != nil {
+		return validateFilter(*payload.Filter)
+	}
+
+	return nil
+}
+
+// @id SavedViewUpdate
+// @summary Update a saved view
+// @description Only the owner of the saved view and the administrators can update it.
+// @description **Access policy**: restricted
+// @tags saved_views
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Saved view identifier"
+// @param body body savedViewUpdatePayload true "Saved view details"
+// @success 200 {object} portainer.SavedView "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Saved view not found"
+// @failure 500 "Server error"
+// @router /saved_views/{id} [put]
+func (handler *Handler) savedViewUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload savedViewUpdatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	view, httpErr := handler.viewFromRequest(r, true)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	if payload.Name != nil && *payload.Name != "" {
+		view.Name = *payload.Name
+	}
+
+	if payload.Description != nil {
+		view.Description = *payload.Description
+	}
+
+	if payload.Filter != nil {
+		view.Filter = *payload.Filter
+	}
+
+	if payload.TeamIDs != nil {
+		if httpErr := handler.validateTeams(payload.TeamIDs, securityContext); httpErr != nil {
+			return httpErr
+		}
+
+		view.TeamIDs = payload.TeamIDs
+	}
+
+	err = handler.DataStore.SavedView().Update(view.ID, view)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the saved view changes inside the database", err)
+	}
+
+	return response.JSON(w, view)
+}
@@ -0,0 +1,58 @@
+package securityalerts
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+var errSecurityAlertNotOpen = errors.New("the security alert is not open")
+
+// Handler is the HTTP handler used to handle security alert operations.
+type Handler struct {
+	*mux.Router
+	DataStore dataservices.DataStore
+	// reviewMu serializes the reviews, so that a security alert is reviewed once
+	reviewMu sync.Mutex
+}
+
+// NewHandler creates a handler to review the security alerts raised by the suspicious authentication events.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router: mux.NewRouter(),
+	}
+
+	adminRouter := h.NewRoute().Subrouter()
+	adminRouter.Use(bouncer.AdminAccess)
+
+	adminRouter.Handle("/security_alerts", httperror.LoggerHandler(h.securityAlertList)).Methods(http.MethodGet)
+	adminRouter.Handle("/security_alerts/{id}", httperror.LoggerHandler(h.securityAlertInspect)).Methods(http.MethodGet)
+	adminRouter.Handle("/security_alerts/{id}/review", httperror.LoggerHandler(h.securityAlertReview)).Methods(http.MethodPost)
+
+	return h
+}
+
+// securityAlertFromRequest returns the security alert of the request
+func (handler *Handler) securityAlertFromRequest(r *http.Request) (*portainer.SecurityAlert, *httperror.HandlerError) {
+	alertID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid security alert identifier route variable", err)
+	}
+
+	alert, err := handler.DataStore.SecurityAlert().Read(portainer.SecurityAlertID(alertID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a security alert with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a security alert with the specified identifier inside the database", err)
+	}
+
+	return alert, nil
+}
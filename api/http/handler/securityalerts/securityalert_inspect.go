@@ -0,0 +1,31 @@
+package securityalerts
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id SecurityAlertInspect
+// @summary Inspect a security alert
+// @description Retrieve the details of a security alert.
+// @description **Access policy**: administrator
+// @tags security_alerts
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Security alert identifier"
+// @success 200 {object} portainer.SecurityAlert "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Security alert not found"
+// @failure 500 "Server error"
+// @router /security_alerts/{id} [get]
+func (handler *Handler) securityAlertInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	alert, httpErr := handler.securityAlertFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, alert)
+}
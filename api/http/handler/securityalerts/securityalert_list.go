@@ -0,0 +1,58 @@
+package securityalerts
+
+import (
+	"net/http"
+	"sort"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id SecurityAlertList
+// @summary List the security alerts
+// @description List the security alerts raised by the suspicious authentication events, the most recent first.
+// @description **Access policy**: administrator
+// @tags security_alerts
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param status query string false "Only the security alerts with this status" Enums(open, acknowledged, dismissed)
+// @param userId query int false "Only the security alerts of this user"
+// @success 200 {array} portainer.SecurityAlert "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /security_alerts [get]
+func (handler *Handler) securityAlertList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	status, _ := request.RetrieveQueryParameter(r, "status", true)
+
+	userID, err := request.RetrieveNumericQueryParameter(r, "userId", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: userId", err)
+	}
+
+	alerts, err := handler.DataStore.SecurityAlert().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the security alerts from the database", err)
+	}
+
+	filtered := []portainer.SecurityAlert{}
+	for _, alert := range alerts {
+		if status != "" && string(alert.Status) != status {
+			continue
+		}
+
+		if userID != 0 && alert.UserID != portainer.UserID(userID) {
+			continue
+		}
+
+		filtered = append(filtered, alert)
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].ID > filtered[j].ID
+	})
+
+	return response.JSON(w, filtered)
+}
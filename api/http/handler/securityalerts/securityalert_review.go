@@ -0,0 +1,119 @@
+package securityalerts
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type securityAlertReviewPayload struct {
+	// Outcome of the review: acknowledged when the event is suspicious, dismissed when it is legitimate
+	Status portainer.SecurityAlertStatus `validate:"required" enums:"acknowledged,dismissed" example:"acknowledged"`
+	// Comment of the reviewer
+	Comment string `example:"Confirmed with the user, the laptop was stolen"`
+	// Revoke the sessions of the user of the alert, who has to log in again
+	RevokeSessions bool `example:"true"`
+}
+
+func (payload *securityAlertReviewPayload) Validate(r *http.Request) error {
+	if payload.Status != portainer.SecurityAlertAcknowledged && payload.Status != portainer.SecurityAlertDismissed {
+		return errors.New("invalid status, the status must be acknowledged or dismissed")
+	}
+
+	if payload.RevokeSessions && payload.Status != portainer.SecurityAlertAcknowledged {
+		return errors.New("the sessions can only be revoked when acknowledging a security alert")
+	}
+
+	return nil
+}
+
+// @id SecurityAlertReview
+// @summary Review a security alert
+// @description Acknowledge an open security alert when the authentication event is suspicious, or dismiss it when it
+// @description is legitimate. When acknowledging the alert, the sessions of its user can be revoked, the tokens
+// @description issued before the review being rejected.
+// @description **Access policy**: administrator
+// @tags security_alerts
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Security alert identifier"
+// @param body body securityAlertReviewPayload true "Review details"
+// @success 200 {object} portainer.SecurityAlert "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Security alert not found"
+// @failure 409 "The security alert is not open"
+// @failure 500 "Server error"
+// @router /security_alerts/{id}/review [post]
+func (handler *Handler) securityAlertReview(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload securityAlertReviewPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	alert, httpErr := handler.securityAlertFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	handler.reviewMu.Lock()
+	defer handler.reviewMu.Unlock()
+
+	alert, err = handler.DataStore.SecurityAlert().Read(alert.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to find the security alert inside the database", err)
+	}
+
+	if alert.Status != portainer.SecurityAlertOpen {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "The security alert is not open", Err: errSecurityAlertNotOpen}
+	}
+
+	now := time.Now().Unix()
+
+	if payload.RevokeSessions {
+		if httpErr := handler.revokeSessions(alert.UserID, now); httpErr != nil {
+			return httpErr
+		}
+	}
+
+	alert.Status = payload.Status
+	alert.ReviewerID = tokenData.ID
+	alert.ReviewedAt = now
+	alert.ReviewComment = payload.Comment
+
+	if err := handler.DataStore.SecurityAlert().Update(alert.ID, alert); err != nil {
+		return httperror.InternalServerError("Unable to persist the security alert changes inside the database", err)
+	}
+
+	return response.JSON(w, alert)
+}
+
+// revokeSessions rejects the tokens issued to a user before now
+func (handler *Handler) revokeSessions(userID portainer.UserID, now int64) *httperror.HandlerError {
+	user, err := handler.DataStore.User().Read(userID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find the user of the security alert inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find the user of the security alert inside the database", err)
+	}
+
+	user.TokenIssueAt = now
+
+	if err := handler.DataStore.User().Update(user.ID, user); err != nil {
+		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+	}
+
+	return nil
+}
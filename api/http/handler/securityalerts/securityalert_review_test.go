@@ -0,0 +1,109 @@
+package securityalerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityAlertReview(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	admin := &portainer.User{Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(admin))
+	user := &portainer.User{Username: "alice", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	newAlert := func() *portainer.SecurityAlert {
+		alert := &portainer.SecurityAlert{Type: portainer.SecurityAlertTokenMultipleAddresses, UserID: user.ID, Username: user.Username,
+			Address: "198.51.100.7", CreatedAt: 1700000000, Status: portainer.SecurityAlertOpen}
+		is.NoError(store.SecurityAlert().Create(alert))
+
+		return alert
+	}
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer())
+	handler.DataStore = store
+
+	do := func(method, path string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			is.NoError(json.NewEncoder(&body).Encode(payload))
+		}
+
+		r := httptest.NewRequest(method, path, &body)
+		r = r.WithContext(security.StoreTokenData(r, &portainer.TokenData{ID: admin.ID, Username: admin.Username, Role: admin.Role}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	t.Run("the open alerts are listed, the most recent first", func(t *testing.T) {
+		first := newAlert()
+		second := newAlert()
+
+		w := do(http.MethodGet, fmt.Sprintf("/security_alerts?status=open&userId=%d", user.ID), nil)
+		is.Equal(http.StatusOK, w.Code)
+
+		var alerts []portainer.SecurityAlert
+		is.NoError(json.NewDecoder(w.Body).Decode(&alerts))
+		if is.Len(alerts, 2) {
+			is.Equal(second.ID, alerts[0].ID)
+			is.Equal(first.ID, alerts[1].ID)
+		}
+
+		w = do(http.MethodGet, fmt.Sprintf("/security_alerts?userId=%d", admin.ID), nil)
+		is.Equal("[]\n", w.Body.String())
+	})
+
+	t.Run("a dismissed alert is reviewed once", func(t *testing.T) {
+		alert := newAlert()
+
+		w := do(http.MethodPost, fmt.Sprintf("/security_alerts/%d/review", alert.ID), securityAlertReviewPayload{Status: portainer.SecurityAlertOpen})
+		is.Equal(http.StatusBadRequest, w.Code)
+
+		w = do(http.MethodPost, fmt.Sprintf("/security_alerts/%d/review", alert.ID), securityAlertReviewPayload{Status: portainer.SecurityAlertDismissed, RevokeSessions: true})
+		is.Equal(http.StatusBadRequest, w.Code, "the sessions should only be revoked when acknowledging the alert")
+
+		w = do(http.MethodPost, fmt.Sprintf("/security_alerts/%d/review", alert.ID), securityAlertReviewPayload{Status: portainer.SecurityAlertDismissed, Comment: "VPN"})
+		is.Equal(http.StatusOK, w.Code)
+
+		alert, err := store.SecurityAlert().Read(alert.ID)
+		is.NoError(err)
+		is.Equal(portainer.SecurityAlertDismissed, alert.Status)
+		is.Equal(admin.ID, alert.ReviewerID)
+		is.Equal("VPN", alert.ReviewComment)
+		is.NotZero(alert.ReviewedAt)
+
+		w = do(http.MethodPost, fmt.Sprintf("/security_alerts/%d/review", alert.ID), securityAlertReviewPayload{Status: portainer.SecurityAlertAcknowledged})
+		is.Equal(http.StatusConflict, w.Code)
+	})
+
+	t.Run("acknowledging an alert can revoke the sessions of its user", func(t *testing.T) {
+		alert := newAlert()
+
+		w := do(http.MethodPost, fmt.Sprintf("/security_alerts/%d/review", alert.ID), securityAlertReviewPayload{Status: portainer.SecurityAlertAcknowledged, RevokeSessions: true})
+		is.Equal(http.StatusOK, w.Code)
+
+		current, err := store.User().Read(user.ID)
+		is.NoError(err)
+		is.NotZero(current.TokenIssueAt, "the tokens issued before the review should be rejected")
+
+		w = do(http.MethodGet, "/security_alerts/999", nil)
+		is.Equal(http.StatusNotFound, w.Code)
+	})
+}
@@ -5,8 +5,23 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/dataservices/role"
+	"github.com/portainer/portainer/api/dataservices/settings"
+	"github.com/portainer/portainer/api/dataservices/teammembership"
 	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/discovery"
+	"github.com/portainer/portainer/api/http/apiusage"
+	"github.com/portainer/portainer/api/http/authzhook"
+	"github.com/portainer/portainer/api/http/cors"
+	"github.com/portainer/portainer/api/http/middlewares"
+	"github.com/portainer/portainer/api/http/ratelimit"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/http/securityheaders"
+	"github.com/portainer/portainer/api/intrusion"
+	"github.com/portainer/portainer/api/mail"
+	"github.com/portainer/portainer/api/release"
+	"github.com/portainer/portainer/api/syslog"
+	"github.com/portainer/portainer/api/usage"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -16,16 +31,43 @@ func hideFields(settings *portainer.Settings) {
 	settings.LDAPSettings.Password = ""
 	settings.OAuthSettings.ClientSecret = ""
 	settings.OAuthSettings.KubeSecretKey = nil
+	settings.Secrets.Vault.Token = ""
+	settings.ChatOps.SlackSigningSecret = ""
+	settings.ChatOps.MattermostToken = ""
+	settings.SMTP.Password = ""
 }
 
 // Handler is the HTTP handler used to handle settings operations.
 type Handler struct {
 	*mux.Router
-	DataStore       dataservices.DataStore
-	FileService     portainer.FileService
-	JWTService      dataservices.JWTService
-	LDAPService     portainer.LDAPService
-	SnapshotService portainer.SnapshotService
+	DataStore        dataservices.DataStore
+	DiscoveryService *discovery.Service
+	FileService      portainer.FileService
+	JWTService       dataservices.JWTService
+	LDAPService      portainer.LDAPService
+	SnapshotService  portainer.SnapshotService
+	UsageService     *usage.Service
+	ReleaseService   *release.Service
+	// CORSPolicy is updated when the CORS settings change
+	CORSPolicy *cors.Policy
+	// SecurityHeadersPolicy is updated when the security headers settings change
+	SecurityHeadersPolicy *securityheaders.Policy
+	// RateLimitPolicy is updated when the rate limit settings change
+	RateLimitPolicy *ratelimit.Policy
+	// ConcurrencyLimiter is updated when the proxy concurrency settings change
+	ConcurrencyLimiter *ratelimit.ConcurrencyLimiter
+	// APIUsageTracker is updated when the API usage settings change
+	APIUsageTracker *apiusage.Tracker
+	// IntrusionDetector is updated when the intrusion detection settings change
+	IntrusionDetector *intrusion.Detector
+	// AuthorizationHook is updated when the authorization hook settings change
+	AuthorizationHook *authzhook.Hook
+	// SyslogForwarder is updated when the syslog settings change
+	SyslogForwarder *syslog.Forwarder
+	// MailService is updated when the SMTP settings change
+	MailService *mail.Service
+	// OfflineModeFlag is set when the offline mode is enforced by the --offline-mode flag
+	OfflineModeFlag bool
 	demoService     *demo.Service
 }
 
@@ -35,12 +77,24 @@ func NewHandler(bouncer security.BouncerService, demoService *demo.Service) *Han
 		Router:      mux.NewRouter(),
 		demoService: demoService,
 	}
+	inspectCache := middlewares.NewResponseCache(middlewares.ResponseCacheTTL)
+
 	h.Handle("/settings",
-		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsInspect))).Methods(http.MethodGet)
+		bouncer.RestrictedAccess(inspectCache.Handler(httperror.LoggerHandler(h.settingsInspect), settings.BucketName, role.BucketName, teammembership.BucketName))).Methods(http.MethodGet)
 	h.Handle("/settings",
-		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsUpdate))).Methods(http.MethodPut)
+		bouncer.RestrictedAccess(httperror.LoggerHandler(h.settingsUpdate))).Methods(http.MethodPut)
+	h.Handle("/settings/history",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsHistory))).Methods(http.MethodGet)
+	h.Handle("/settings/history/{id}/rollback",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsRollback))).Methods(http.MethodPost)
 	h.Handle("/settings/public",
 		bouncer.PublicAccess(httperror.LoggerHandler(h.settingsPublic))).Methods(http.MethodGet)
+	h.Handle("/settings/logo",
+		bouncer.PublicAccess(httperror.LoggerHandler(h.settingsLogoInspect))).Methods(http.MethodGet)
+	h.Handle("/settings/logo",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsLogoUpload))).Methods(http.MethodPut)
+	h.Handle("/settings/logo",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.settingsLogoDelete))).Methods(http.MethodDelete)
 
 	return h
 }
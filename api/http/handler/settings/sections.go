@@ -0,0 +1,109 @@
+package settings
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+)
+
+// sections returns the settings sections modified by the payload, and whether it modifies fields restricted to
+// the administrators
+func (payload *settingsUpdatePayload) sections() (map[string]bool, bool) {
+	sections := map[string]bool{}
+	restricted := false
+
+	value := reflect.ValueOf(payload).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		if value.Field(i).IsNil() {
+			continue
+		}
+
+		section, ok := value.Type().Field(i).Tag.Lookup("section")
+		if !ok {
+			restricted = true
+			continue
+		}
+
+		sections[section] = true
+	}
+
+	return sections, restricted
+}
+
+// delegatedSections returns the settings sections delegated to the user of the request through the custom roles,
+// all the sections being accessible to the administrators
+func (handler *Handler) delegatedSections(r *http.Request) (map[string]bool, bool, *httperror.HandlerError) {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return nil, false, httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	if tokenData.Role == portainer.AdministratorRole {
+		return nil, true, nil
+	}
+
+	sections, err := authorization.DelegatedSettingsSections(handler.DataStore, tokenData.ID)
+	if err != nil {
+		return nil, false, httperror.InternalServerError("Unable to retrieve the settings sections delegated to the user", err)
+	}
+
+	return sections, false, nil
+}
+
+// delegatedSettings returns the settings of the sections delegated to a user keyed by their JSON name, so that the
+// other sections and the secrets of the instance are not disclosed to the user
+func delegatedSettings(settings *portainer.Settings, delegated map[string]bool) map[string]any {
+	result := map[string]any{}
+
+	settingsValue := reflect.ValueOf(settings).Elem()
+	payloadType := reflect.TypeOf(settingsUpdatePayload{})
+	for i := 0; i < payloadType.NumField(); i++ {
+		field := payloadType.Field(i)
+
+		section, ok := field.Tag.Lookup("section")
+		if !ok || !delegated[section] {
+			continue
+		}
+
+		settingsField, ok := settingsValue.Type().FieldByName(field.Name)
+		if !ok {
+			continue
+		}
+
+		name, _, _ := strings.Cut(settingsField.Tag.Get("json"), ",")
+		if name == "" {
+			name = settingsField.Name
+		}
+
+		result[name] = settingsValue.FieldByIndex(settingsField.Index).Interface()
+	}
+
+	return result
+}
+
+// authorizeSettingsUpdate ensures that the fields modified by the payload belong to the sections delegated to the user
+func (handler *Handler) authorizeSettingsUpdate(r *http.Request, payload *settingsUpdatePayload) *httperror.HandlerError {
+	delegated, isAdmin, httpErr := handler.delegatedSections(r)
+	if httpErr != nil || isAdmin {
+		return httpErr
+	}
+
+	sections, restricted := payload.sections()
+	if restricted {
+		return httperror.Forbidden("Access denied", errors.New("the settings can only be updated by an administrator"))
+	}
+
+	for section := range sections {
+		if !delegated[section] {
+			return httperror.Forbidden("Access denied", errors.New("the "+section+" settings are not delegated to the user"))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package settings
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingsUpdatePayload_Sections(t *testing.T) {
+	interval := "10m"
+	message := "maintenance"
+	logo := "https://example.com/logo.png"
+
+	payload := &settingsUpdatePayload{SnapshotInterval: &interval, LoginMessage: &message}
+	sections, restricted := payload.sections()
+	assert.False(t, restricted)
+	assert.Equal(t, map[string]bool{"snapshots": true, "notifications": true}, sections)
+
+	payload.LogoURL = &logo
+	_, restricted = payload.sections()
+	assert.True(t, restricted)
+}
+
+func TestAuthorizeSettingsUpdate(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	assert.NoError(t, store.User().Create(&portainer.User{ID: 2, Username: "security", Role: portainer.StandardUserRole}))
+	assert.NoError(t, store.User().Create(&portainer.User{ID: 3, Username: "standard", Role: portainer.StandardUserRole}))
+	assert.NoError(t, store.Role().Create(&portainer.Role{
+		Name:           "Security team",
+		Custom:         true,
+		Authorizations: portainer.Authorizations{portainer.OperationPortainerSettingsAuthentication: true},
+		UserIDs:        []portainer.UserID{2},
+	}))
+
+	handler := &Handler{DataStore: store}
+
+	request := func(userID portainer.UserID, role portainer.UserRole) *http.Request {
+		r := httptest.NewRequest(http.MethodPut, "/settings", nil)
+		return r.WithContext(security.StoreTokenData(r, &portainer.TokenData{ID: userID, Role: role}))
+	}
+
+	timeout := "1h"
+	interval := "10m"
+	authentication := &settingsUpdatePayload{UserSessionTimeout: &timeout}
+	snapshots := &settingsUpdatePayload{SnapshotInterval: &interval}
+
+	assert.Nil(t, handler.authorizeSettingsUpdate(request(1, portainer.AdministratorRole), snapshots))
+	assert.Nil(t, handler.authorizeSettingsUpdate(request(2, portainer.StandardUserRole), authentication))
+
+	httpErr := handler.authorizeSettingsUpdate(request(2, portainer.StandardUserRole), snapshots)
+	assert.Equal(t, http.StatusForbidden, httpErr.StatusCode)
+
+	httpErr = handler.authorizeSettingsUpdate(request(3, portainer.StandardUserRole), authentication)
+	assert.Equal(t, http.StatusForbidden, httpErr.StatusCode)
+}
+
+func TestSettingsInspect_DelegatedSections(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	settings, err := store.Settings().Settings()
+	assert.NoError(t, err)
+	settings.AgentSecret = "agent-secret"
+	settings.UserSessionTimeout = "8h"
+	settings.SnapshotInterval = "5m"
+	assert.NoError(t, store.Settings().UpdateSettings(settings))
+
+	assert.NoError(t, store.User().Create(&portainer.User{ID: 2, Username: "security", Role: portainer.StandardUserRole}))
+	assert.NoError(t, store.Role().Create(&portainer.Role{
+		Name:           "Security team",
+		Custom:         true,
+		Authorizations: portainer.Authorizations{portainer.OperationPortainerSettingsAuthentication: true},
+		UserIDs:        []portainer.UserID{2},
+	}))
+
+	handler := &Handler{DataStore: store}
+
+	inspect := func(userID portainer.UserID, role portainer.UserRole) map[string]any {
+		r := httptest.NewRequest(http.MethodGet, "/settings", nil)
+		r = r.WithContext(security.StoreTokenData(r, &portainer.TokenData{ID: userID, Role: role}))
+
+		w := httptest.NewRecorder()
+		assert.Nil(t, handler.settingsInspect(w, r))
+
+		var result map[string]any
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+
+		return result
+	}
+
+	delegated := inspect(2, portainer.StandardUserRole)
+	assert.Equal(t, "8h", delegated["UserSessionTimeout"])
+	assert.Contains(t, delegated, "LDAPSettings")
+	assert.NotContains(t, delegated, "AgentSecret", "the secrets of the instance should not be disclosed")
+	assert.NotContains(t, delegated, "SnapshotInterval", "the sections which are not delegated should not be disclosed")
+	assert.NotContains(t, delegated, "openAMTConfiguration")
+
+	admin := inspect(1, portainer.AdministratorRole)
+	assert.Equal(t, "agent-secret", admin["AgentSecret"])
+	assert.Equal(t, "5m", admin["SnapshotInterval"])
+}
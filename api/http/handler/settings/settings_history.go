@@ -0,0 +1,128 @@
+package settings
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id SettingsHistory
+// @summary List the previous versions of the settings
+// @description List the versions of the settings recorded on each change, the most recent first.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.SettingsVersion "Success"
+// @failure 500 "Server error"
+// @router /settings/history [get]
+func (handler *Handler) settingsHistory(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	versions, err := handler.DataStore.Settings().History()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the settings history from the database", err)
+	}
+
+	for i := range versions {
+		hideFields(&versions[i].Settings)
+	}
+
+	return response.JSON(w, versions)
+}
+
+// @id SettingsRollback
+// @summary Roll back the settings to a previous version
+// @description Restore a previous version of the settings, the restored settings are recorded as a new version.
+// @description The custom logo and the secrets generated by Portainer are not affected.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Settings version identifier"
+// @success 200 {object} portainer.Settings "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Settings version not found"
+// @failure 500 "Server error"
+// @router /settings/history/{id}/rollback [post]
+func (handler *Handler) settingsRollback(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	versionID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid settings version identifier route variable", err)
+	}
+
+	var settings *portainer.Settings
+	err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		settings, err = handler.rollbackSettings(tx, portainer.SettingsVersionID(versionID))
+		return err
+	})
+	if err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unexpected error", err)
+	}
+
+	userSessionDuration, _ := time.ParseDuration(settings.UserSessionTimeout)
+	handler.JWTService.SetUserSessionDuration(userSessionDuration)
+
+	if err := handler.SnapshotService.SetSnapshotInterval(settings.SnapshotInterval); err != nil {
+		return httperror.InternalServerError("Unable to update the snapshot interval", err)
+	}
+
+	if handler.DiscoveryService != nil {
+		if err := handler.DiscoveryService.Configure(settings.Discovery); err != nil {
+			return httperror.InternalServerError("Unable to update the environments discovery", err)
+		}
+	}
+
+	if handler.UsageService != nil {
+		if err := handler.UsageService.Configure(settings.UsageReport); err != nil {
+			return httperror.InternalServerError("Unable to update the usage report export", err)
+		}
+	}
+
+	handler.applyPolicies(settings)
+
+	hideFields(settings)
+	return response.JSON(w, settings)
+}
+
+func (handler *Handler) rollbackSettings(tx dataservices.DataStoreTx, versionID portainer.SettingsVersionID) (*portainer.Settings, error) {
+	version, err := tx.Settings().Version(versionID)
+	if tx.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a settings version with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a settings version with the specified identifier inside the database", err)
+	}
+
+	current, err := tx.Settings().Settings()
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	settings := version.Settings
+
+	// The logo file and the secrets generated by Portainer are not versioned
+	settings.CustomLogo = current.CustomLogo
+	settings.OAuthSettings.KubeSecretKey = current.OAuthSettings.KubeSecretKey
+	settings.IsDockerDesktopExtension = current.IsDockerDesktopExtension
+
+	if handler.OfflineModeFlag {
+		settings.OfflineMode = true
+	}
+
+	if err := saveSettings(tx, &settings); err != nil {
+		return nil, httperror.InternalServerError("Unable to persist settings changes inside the database", err)
+	}
+
+	return &settings, nil
+}
@@ -0,0 +1,113 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+type intervalSnapshotService struct {
+	portainer.SnapshotService
+	interval string
+}
+
+func (service *intervalSnapshotService) SetSnapshotInterval(snapshotInterval string) error {
+	service.interval = snapshotInterval
+	return nil
+}
+
+func TestSettingsHistoryAndRollback(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	jwtService, err := jwt.NewService("1h", store)
+	assert.NoError(t, err)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	assert.NoError(t, err)
+
+	snapshotService := &intervalSnapshotService{}
+
+	handler := &Handler{
+		DataStore:       store,
+		FileService:     fileService,
+		JWTService:      jwtService,
+		SnapshotService: snapshotService,
+		demoService:     demo.NewService(),
+	}
+
+	update := func(message string) {
+		body, _ := json.Marshal(settingsUpdatePayload{LoginMessage: &message})
+		r := httptest.NewRequest(http.MethodPut, "/settings", bytes.NewReader(body))
+		r = r.WithContext(security.StoreTokenData(r, &portainer.TokenData{ID: 1, Role: portainer.AdministratorRole}))
+
+		assert.Nil(t, handler.settingsUpdate(httptest.NewRecorder(), r))
+	}
+
+	history := func() []portainer.SettingsVersion {
+		rr := httptest.NewRecorder()
+		assert.Nil(t, handler.settingsHistory(rr, httptest.NewRequest(http.MethodGet, "/settings/history", nil)))
+
+		var versions []portainer.SettingsVersion
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&versions))
+
+		return versions
+	}
+
+	rollback := func(id portainer.SettingsVersionID) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/settings/history/%d/rollback", id), nil)
+		r = mux.SetURLVars(r, map[string]string{"id": fmt.Sprint(id)})
+
+		rr := httptest.NewRecorder()
+		if httpErr := handler.settingsRollback(rr, r); httpErr != nil {
+			rr.Code = httpErr.StatusCode
+		}
+
+		return rr
+	}
+
+	update("first")
+	update("second")
+
+	versions := history()
+	assert.Len(t, versions, 3, "the initial settings should be recorded before the first change")
+	assert.Equal(t, "second", versions[0].Settings.LoginMessage)
+	assert.Equal(t, "first", versions[1].Settings.LoginMessage)
+	assert.Empty(t, versions[2].Settings.LoginMessage)
+
+	update("second")
+	assert.Len(t, history(), 3, "an unchanged write should not create a version")
+
+	settings, err := store.Settings().Settings()
+	assert.NoError(t, err)
+	settings.CustomLogo = true
+	assert.NoError(t, store.Settings().UpdateSettings(settings))
+
+	rr := rollback(versions[1].ID)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	settings, err = store.Settings().Settings()
+	assert.NoError(t, err)
+	assert.Equal(t, "first", settings.LoginMessage)
+	assert.True(t, settings.CustomLogo, "the custom logo should not be rolled back")
+	assert.Equal(t, settings.SnapshotInterval, snapshotService.interval)
+
+	versions = history()
+	assert.Len(t, versions, 5)
+	assert.Equal(t, "first", versions[0].Settings.LoginMessage)
+	assert.True(t, versions[1].Settings.CustomLogo, "the settings changed outside of the API should be recorded")
+
+	assert.Equal(t, http.StatusNotFound, rollback(999).Code)
+}
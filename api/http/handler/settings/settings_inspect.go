@@ -1,6 +1,7 @@
 package settings
 
 import (
+	"errors"
 	"net/http"
 
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
@@ -10,20 +11,36 @@ import (
 // @id SettingsInspect
 // @summary Retrieve Portainer settings
 // @description Retrieve Portainer settings.
-// @description **Access policy**: administrator
+// @description The users a settings section is delegated to only retrieve the settings of their delegated sections.
+// @description **Access policy**: administrator, or user a settings section is delegated to
 // @tags settings
 // @security ApiKeyAuth
 // @security jwt
 // @produce json
 // @success 200 {object} portainer.Settings "Success"
+// @failure 403 "Permission denied"
 // @failure 500 "Server error"
 // @router /settings [get]
 func (handler *Handler) settingsInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	delegated, isAdmin, httpErr := handler.delegatedSections(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if !isAdmin && len(delegated) == 0 {
+		return httperror.Forbidden("Access denied", errors.New("no settings section is delegated to the user"))
+	}
+
 	settings, err := handler.DataStore.Settings().Settings()
 	if err != nil {
 		return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
 	}
 
 	hideFields(settings)
+
+	if !isAdmin {
+		return response.JSON(w, delegatedSettings(settings, delegated))
+	}
+
 	return response.JSON(w, settings)
 }
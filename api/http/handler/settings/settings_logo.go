@@ -0,0 +1,128 @@
+package settings
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+const (
+	// customLogoURL is the URL of the custom logo returned in the public settings
+	customLogoURL = "api/settings/logo"
+
+	maxLogoSize = 1024 * 1024
+)
+
+// logoContentTypes lists the accepted logo formats. SVG is not accepted as it can embed scripts.
+var logoContentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// @id SettingsLogoInspect
+// @summary Retrieve the custom logo
+// @description **Access policy**: public
+// @tags settings
+// @produce image/png,image/jpeg,image/gif,image/webp
+// @success 200 "Success"
+// @failure 404 "No custom logo"
+// @failure 500 "Server error"
+// @router /settings/logo [get]
+func (handler *Handler) settingsLogoInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	logo, err := os.ReadFile(handler.FileService.GetLogoFilePath())
+	if os.IsNotExist(err) {
+		return httperror.NotFound("No custom logo was uploaded", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to read the custom logo", err)
+	}
+
+	w.Header().Set("Content-Type", http.DetectContentType(logo))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(logo)
+
+	return nil
+}
+
+// @id SettingsLogoUpload
+// @summary Upload a custom logo
+// @description Upload the logo displayed on the login page and on top of the sidebar when no logo URL is set.
+// @description The logo must be a PNG, JPEG, GIF or WebP image of at most 1MB.
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @accept multipart/form-data
+// @param file formData file true "Logo"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /settings/logo [put]
+func (handler *Handler) settingsLogoUpload(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	if handler.demoService.IsDemo() {
+		return httperror.Forbidden(httperrors.ErrNotAvailableInDemo.Error(), httperrors.ErrNotAvailableInDemo)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxLogoSize+1024*1024)
+
+	logo, _, err := request.RetrieveMultiPartFormFile(r, "file")
+	if err != nil {
+		return httperror.BadRequest("Invalid logo file. Ensure that the file is uploaded correctly", err)
+	}
+
+	if len(logo) > maxLogoSize {
+		return httperror.BadRequest("Invalid logo file. The logo must not exceed 1MB", errors.New("logo too large"))
+	}
+
+	if !slices.Contains(logoContentTypes, http.DetectContentType(logo)) {
+		return httperror.BadRequest("Invalid logo file. The logo must be a PNG, JPEG, GIF or WebP image", errors.New("unsupported logo format"))
+	}
+
+	if _, err := handler.FileService.StoreLogoFile(logo); err != nil {
+		return httperror.InternalServerError("Unable to persist the custom logo on disk", err)
+	}
+
+	if err := handler.setCustomLogo(true); err != nil {
+		return httperror.InternalServerError("Unable to persist the settings changes inside the database", err)
+	}
+
+	return response.Empty(w)
+}
+
+// @id SettingsLogoDelete
+// @summary Remove the custom logo
+// @description **Access policy**: administrator
+// @tags settings
+// @security ApiKeyAuth
+// @security jwt
+// @success 204 "Success"
+// @failure 500 "Server error"
+// @router /settings/logo [delete]
+func (handler *Handler) settingsLogoDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	if err := handler.setCustomLogo(false); err != nil {
+		return httperror.InternalServerError("Unable to persist the settings changes inside the database", err)
+	}
+
+	if err := handler.FileService.RemoveLogoFile(); err != nil {
+		return httperror.InternalServerError("Unable to remove the custom logo from disk", err)
+	}
+
+	return response.Empty(w)
+}
+
+func (handler *Handler) setCustomLogo(customLogo bool) error {
+	return handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		settings, err := tx.Settings().Settings()
+		if err != nil {
+			return err
+		}
+
+		settings.CustomLogo = customLogo
+
+		return saveSettings(tx, settings)
+	})
+}
@@ -51,6 +51,15 @@ type publicSettingsResponse struct {
 	}
 
 	IsDockerDesktopExtension bool `json:"IsDockerDesktopExtension" example:"false"`
+
+	// Message of the day displayed on the login page
+	LoginMessage string `json:"LoginMessage" example:"Scheduled maintenance on Saturday"`
+	// Legal banner which must be acknowledged before logging in
+	LoginBanner portainer.LoginBannerSettings `json:"LoginBanner"`
+	// Whether users can request an account from the login page
+	SelfSignupEnabled bool `json:"SelfSignupEnabled" example:"false"`
+	// Whether the outbound internet access is disabled, the UI must not reach the internet either
+	OfflineMode bool `json:"OfflineMode" example:"false"`
 }
 
 // @id SettingsPublic
@@ -79,7 +88,7 @@ func generatePublicSettings(appSettings *portainer.Settings) *publicSettingsResp
 		RequiredPasswordLength:    appSettings.InternalAuthSettings.RequiredPasswordLength,
 		EnableEdgeComputeFeatures: appSettings.EnableEdgeComputeFeatures,
 		ShowKomposeBuildOption:    appSettings.ShowKomposeBuildOption,
-		EnableTelemetry:           appSettings.EnableTelemetry,
+		EnableTelemetry:           appSettings.EnableTelemetry && !appSettings.OfflineMode,
 		KubeconfigExpiry:          appSettings.KubeconfigExpiry,
 		Features:                  featureflags.FeatureFlags(),
 		IsFDOEnabled:              appSettings.EnableEdgeComputeFeatures && appSettings.FDOConfiguration.Enabled,
@@ -93,6 +102,15 @@ func generatePublicSettings(appSettings *portainer.Settings) *publicSettingsResp
 
 	publicSettings.IsDockerDesktopExtension = appSettings.IsDockerDesktopExtension
 
+	publicSettings.LoginMessage = appSettings.LoginMessage
+	publicSettings.LoginBanner = appSettings.LoginBanner
+	publicSettings.SelfSignupEnabled = appSettings.SelfSignup.Enabled && appSettings.AuthenticationMethod == portainer.AuthenticationInternal
+	publicSettings.OfflineMode = appSettings.OfflineMode
+
+	if publicSettings.LogoURL == "" && appSettings.CustomLogo {
+		publicSettings.LogoURL = customLogoURL
+	}
+
 	//if OAuth authentication is on, compose the related fields from application settings
 	if publicSettings.AuthenticationMethod == portainer.AuthenticationOAuth {
 		publicSettings.OAuthLogoutURI = appSettings.OAuthSettings.LogoutURI
@@ -68,3 +68,41 @@ func TestGeneratePublicSettingsWithoutSSO(t *testing.T) {
 		t.Errorf("wrong OAuthLogoutURI, want: %s, got: %s", dummyOAuthLogoutURI, publicSettings.OAuthLogoutURI)
 	}
 }
+
+func TestGeneratePublicSettingsWithBranding(t *testing.T) {
+	setup()
+	mockAppSettings.LoginMessage = "maintenance on Saturday"
+	mockAppSettings.LoginBanner = portainer.LoginBannerSettings{Enabled: true, Content: "authorized use only"}
+	mockAppSettings.CustomLogo = true
+
+	publicSettings := generatePublicSettings(mockAppSettings)
+	if publicSettings.LoginMessage != mockAppSettings.LoginMessage {
+		t.Errorf("wrong LoginMessage, want: %s, got: %s", mockAppSettings.LoginMessage, publicSettings.LoginMessage)
+	}
+	if publicSettings.LoginBanner != mockAppSettings.LoginBanner {
+		t.Errorf("wrong LoginBanner, want: %v, got: %v", mockAppSettings.LoginBanner, publicSettings.LoginBanner)
+	}
+	if publicSettings.LogoURL != customLogoURL {
+		t.Errorf("wrong LogoURL when a custom logo is uploaded, want: %s, got: %s", customLogoURL, publicSettings.LogoURL)
+	}
+
+	mockAppSettings.LogoURL = "https://example.com/logo.png"
+	publicSettings = generatePublicSettings(mockAppSettings)
+	if publicSettings.LogoURL != mockAppSettings.LogoURL {
+		t.Errorf("wrong LogoURL when a logo URL is set, want: %s, got: %s", mockAppSettings.LogoURL, publicSettings.LogoURL)
+	}
+}
+
+func TestGeneratePublicSettingsInOfflineMode(t *testing.T) {
+	setup()
+	mockAppSettings.EnableTelemetry = true
+	mockAppSettings.OfflineMode = true
+
+	publicSettings := generatePublicSettings(mockAppSettings)
+	if !publicSettings.OfflineMode {
+		t.Errorf("wrong OfflineMode, want: true, got: false")
+	}
+	if publicSettings.EnableTelemetry {
+		t.Errorf("telemetry must be disabled in offline mode")
+	}
+}
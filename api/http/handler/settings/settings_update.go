@@ -6,9 +6,26 @@ import (
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apptemplates"
+	"github.com/portainer/portainer/api/contenttrust"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/discovery"
 	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/http/apiusage"
+	"github.com/portainer/portainer/api/http/authzhook"
+	"github.com/portainer/portainer/api/http/client"
+	"github.com/portainer/portainer/api/http/cors"
+	"github.com/portainer/portainer/api/http/ratelimit"
+	"github.com/portainer/portainer/api/http/securityheaders"
+	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/api/internal/edge"
+	"github.com/portainer/portainer/api/intrusion"
+	"github.com/portainer/portainer/api/mail"
+	"github.com/portainer/portainer/api/secrets"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	"github.com/portainer/portainer/api/statuspage"
+	"github.com/portainer/portainer/api/syslog"
+	"github.com/portainer/portainer/api/usage"
 	"github.com/portainer/portainer/pkg/libhelm"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
@@ -18,28 +35,32 @@ import (
 	"github.com/pkg/errors"
 )
 
+// settingsUpdatePayload holds the settings to update, the fields tagged with a section can be updated by the users
+// the section is delegated to, the other fields by the administrators only
 type settingsUpdatePayload struct {
 	// URL to a logo that will be displayed on the login page as well as on top of the sidebar. Will use default Portainer logo when value is empty string
 	LogoURL *string `example:"https://mycompany.mydomain.tld/logo.png"`
 	// A list of label name & value that will be used to hide containers when querying containers
 	BlackListedLabels []portainer.Pair
 	// Active authentication method for the Portainer instance. Valid values are: 1 for internal, 2 for LDAP, or 3 for oauth
-	AuthenticationMethod *int `example:"1"`
-	InternalAuthSettings *portainer.InternalAuthSettings
-	LDAPSettings         *portainer.LDAPSettings
-	OAuthSettings        *portainer.OAuthSettings
+	AuthenticationMethod *int                            `example:"1" section:"authentication"`
+	InternalAuthSettings *portainer.InternalAuthSettings `section:"authentication"`
+	LDAPSettings         *portainer.LDAPSettings         `section:"authentication"`
+	OAuthSettings        *portainer.OAuthSettings        `section:"authentication"`
 	// The interval in which environment(endpoint) snapshots are created
-	SnapshotInterval *string `example:"5m"`
+	SnapshotInterval *string `example:"5m" section:"snapshots"`
+	// The sections skipped by the snapshots of the Docker environments(endpoints)
+	SnapshotContent *portainer.DockerSnapshotContent `section:"snapshots"`
 	// URL to the templates that will be displayed in the UI when navigating to App Templates
 	TemplatesURL *string `example:"https://raw.githubusercontent.com/portainer/templates/master/templates.json"`
 	// The default check in interval for edge agent (in seconds)
-	EdgeAgentCheckinInterval *int `example:"5"`
+	EdgeAgentCheckinInterval *int `example:"5" section:"edge"`
 	// Show the Kompose build option (discontinued in 2.18)
 	ShowKomposeBuildOption *bool `json:"ShowKomposeBuildOption" example:"false"`
 	// Whether edge compute features are enabled
-	EnableEdgeComputeFeatures *bool `example:"true"`
+	EnableEdgeComputeFeatures *bool `example:"true" section:"edge"`
 	// The duration of a user session
-	UserSessionTimeout *string `example:"5m"`
+	UserSessionTimeout *string `example:"5m" section:"authentication"`
 	// The expiry of a Kubeconfig
 	KubeconfigExpiry *string `example:"24h" default:"0"`
 	// Whether telemetry is enabled
@@ -49,11 +70,62 @@ type settingsUpdatePayload struct {
 	// Kubectl Shell Image
 	KubectlShellImage *string `example:"portainer/kubectl-shell:latest"`
 	// TrustOnFirstConnect makes Portainer accepting edge agent connection by default
-	TrustOnFirstConnect *bool `example:"false"`
+	TrustOnFirstConnect *bool `example:"false" section:"edge"`
 	// EnforceEdgeID makes Portainer store the Edge ID instead of accepting anyone
-	EnforceEdgeID *bool `example:"false"`
+	EnforceEdgeID *bool `example:"false" section:"edge"`
 	// EdgePortainerURL is the URL that is exposed to edge agents
-	EdgePortainerURL *string `json:"EdgePortainerURL"`
+	EdgePortainerURL *string `json:"EdgePortainerURL" section:"edge"`
+	// Discovery contains the settings of the automatic registration of environments
+	Discovery *portainer.DiscoverySettings
+	// Message of the day displayed on the login page
+	LoginMessage *string `example:"Scheduled maintenance on Saturday" section:"notifications"`
+	// Legal banner which must be acknowledged by the users before logging in
+	LoginBanner *portainer.LoginBannerSettings `section:"notifications"`
+	// UsageReport contains the settings of the scheduled export of the usage report
+	UsageReport *portainer.UsageReportSettings
+	// Whether the periodic check for new Portainer releases is disabled
+	DisableUpdateCheck *bool `example:"false"`
+	// Whether the outbound internet access is disabled, for air-gapped installations
+	OfflineMode *bool `example:"false"`
+	// CORS contains the cross-origin resource sharing policy of the API
+	CORS *portainer.CORSSettings
+	// SecurityHeaders contains the settings of the security headers of the responses
+	SecurityHeaders *portainer.SecurityHeadersSettings
+	// RateLimit contains the rate limiting of the API requests
+	RateLimit *portainer.RateLimitSettings
+	// ProxyConcurrency contains the limit of the requests proxied at the same time to each environment
+	ProxyConcurrency *portainer.ProxyConcurrencySettings
+	// APIUsage contains the alerts on the anomalous usage of the API
+	APIUsage *portainer.APIUsageSettings
+	// IntrusionDetection contains the alerts on the suspicious authentication events
+	IntrusionDetection *portainer.IntrusionDetectionSettings
+	// AuthorizationHook contains the external policy service authorizing the API operations
+	AuthorizationHook *portainer.AuthorizationHookSettings
+	// ChatOps contains the settings of the Slack and Mattermost slash commands.
+	// The signing secret and the token are kept when empty
+	ChatOps *portainer.ChatOpsSettings
+	// Whether the read-only GraphQL endpoint over the inventory is enabled
+	EnableGraphQL *bool `example:"false"`
+	// StatusPage contains the settings of the public status page
+	StatusPage *portainer.StatusPageSettings
+	// StackPolicy contains the policy checks of the compose files deployed as stacks
+	StackPolicy *portainer.StackPolicySettings
+	// Secrets contains the external secret stores which the stack environment variables can reference.
+	// The Vault token is kept when empty
+	Secrets *portainer.SecretsSettings
+	// Syslog contains the remote syslog server the audit events and the system logs are forwarded to
+	Syslog *portainer.SyslogSettings `section:"notifications"`
+	// SMTP contains the SMTP server and the message templates of the emails.
+	// The password is kept when empty
+	SMTP *portainer.SMTPSettings `section:"notifications"`
+	// SelfSignup contains the settings of the account requests submitted from the login page
+	SelfSignup *portainer.SelfSignupSettings `section:"authentication"`
+	// ShellAccessPolicy restricts the exec and attach sessions opened in the containers
+	ShellAccessPolicy *portainer.ShellAccessPolicy
+	// AppTemplates contains the additional sources of the app templates and the pinned templates
+	AppTemplates *portainer.AppTemplatesSettings
+	// ContentTrust contains the keys verifying the signatures of the templates feeds and of the git stacks
+	ContentTrust *portainer.ContentTrustSettings
 }
 
 func (payload *settingsUpdatePayload) Validate(r *http.Request) error {
@@ -94,13 +166,120 @@ func (payload *settingsUpdatePayload) Validate(r *http.Request) error {
 		}
 	}
 
+	if payload.Discovery != nil {
+		if err := discovery.ValidateSettings(*payload.Discovery); err != nil {
+			return err
+		}
+	}
+
+	if payload.LoginBanner != nil && payload.LoginBanner.Enabled && govalidator.IsNull(payload.LoginBanner.Content) {
+		return errors.New("Invalid login banner. The content is required when the banner is enabled")
+	}
+
+	if payload.UsageReport != nil {
+		if err := usage.ValidateSettings(*payload.UsageReport); err != nil {
+			return err
+		}
+	}
+
+	if payload.CORS != nil {
+		if err := cors.ValidateSettings(*payload.CORS); err != nil {
+			return err
+		}
+	}
+
+	if payload.SecurityHeaders != nil {
+		if err := securityheaders.ValidateSettings(*payload.SecurityHeaders); err != nil {
+			return err
+		}
+	}
+
+	if payload.RateLimit != nil {
+		if err := ratelimit.ValidateSettings(*payload.RateLimit); err != nil {
+			return err
+		}
+	}
+
+	if payload.ProxyConcurrency != nil {
+		if err := ratelimit.ValidateConcurrencySettings(*payload.ProxyConcurrency); err != nil {
+			return err
+		}
+	}
+
+	if payload.APIUsage != nil {
+		if err := apiusage.ValidateSettings(*payload.APIUsage); err != nil {
+			return err
+		}
+	}
+
+	if payload.IntrusionDetection != nil {
+		if err := intrusion.ValidateSettings(*payload.IntrusionDetection); err != nil {
+			return err
+		}
+	}
+
+	if payload.AuthorizationHook != nil {
+		if err := authzhook.ValidateSettings(*payload.AuthorizationHook); err != nil {
+			return err
+		}
+	}
+
+	if payload.StackPolicy != nil {
+		if err := stackutils.ValidateStackPolicySettings(*payload.StackPolicy); err != nil {
+			return err
+		}
+	}
+
+	if payload.Secrets != nil {
+		if err := secrets.ValidateVaultSettings(payload.Secrets.Vault); err != nil {
+			return err
+		}
+	}
+
+	if payload.Syslog != nil {
+		if err := syslog.ValidateSettings(*payload.Syslog); err != nil {
+			return err
+		}
+	}
+
+	if payload.SMTP != nil {
+		if err := mail.ValidateSettings(*payload.SMTP); err != nil {
+			return err
+		}
+	}
+
+	if payload.ShellAccessPolicy != nil {
+		if err := authorization.ValidateShellAccessPolicy(*payload.ShellAccessPolicy); err != nil {
+			return err
+		}
+	}
+
+	if payload.AppTemplates != nil {
+		if err := apptemplates.ValidateSettings(*payload.AppTemplates); err != nil {
+			return err
+		}
+	}
+
+	if payload.ContentTrust != nil {
+		if err := contenttrust.ValidateSettings(*payload.ContentTrust); err != nil {
+			return err
+		}
+	}
+
+	if payload.StatusPage != nil {
+		if err := statuspage.ValidateSettings(*payload.StatusPage); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // @id SettingsUpdate
 // @summary Update Portainer settings
 // @description Update Portainer settings.
-// @description **Access policy**: administrator
+// @description The users the authentication, notifications, snapshots or edge section is delegated to by a custom role can update the settings of the section.
+// @description **Access policy**: administrator, or user the updated settings sections are delegated to
 // @tags settings
 // @security ApiKeyAuth
 // @security jwt
@@ -109,6 +288,7 @@ func (payload *settingsUpdatePayload) Validate(r *http.Request) error {
 // @param body body settingsUpdatePayload true "New settings"
 // @success 200 {object} portainer.Settings "Success"
 // @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
 // @failure 500 "Server error"
 // @router /settings [put]
 func (handler *Handler) settingsUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
@@ -118,6 +298,10 @@ func (handler *Handler) settingsUpdate(w http.ResponseWriter, r *http.Request) *
 		return httperror.BadRequest("Invalid request payload", err)
 	}
 
+	if httpErr := handler.authorizeSettingsUpdate(r, &payload); httpErr != nil {
+		return httpErr
+	}
+
 	var settings *portainer.Settings
 	err = handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
 		settings, err = handler.updateSettings(tx, payload)
@@ -132,6 +316,26 @@ func (handler *Handler) settingsUpdate(w http.ResponseWriter, r *http.Request) *
 		return httperror.InternalServerError("Unexpected error", err)
 	}
 
+	// the periodic jobs are rescheduled once their settings are persisted, so that a failed update does not leave them
+	// running with the settings which were not saved
+	if payload.Discovery != nil {
+		if err := handler.DiscoveryService.Configure(settings.Discovery); err != nil {
+			return httperror.InternalServerError("Unable to update the environments discovery", err)
+		}
+	}
+
+	if payload.UsageReport != nil {
+		if err := handler.UsageService.Configure(settings.UsageReport); err != nil {
+			return httperror.InternalServerError("Unable to update the usage report export", err)
+		}
+	}
+
+	handler.applyPolicies(settings)
+
+	if payload.DisableUpdateCheck != nil && !*payload.DisableUpdateCheck && handler.ReleaseService != nil {
+		handler.ReleaseService.Refresh()
+	}
+
 	hideFields(settings)
 	return response.JSON(w, settings)
 }
@@ -155,6 +359,18 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 		settings.LogoURL = *payload.LogoURL
 	}
 
+	if payload.LoginMessage != nil {
+		settings.LoginMessage = *payload.LoginMessage
+	}
+
+	if payload.LoginBanner != nil {
+		settings.LoginBanner = *payload.LoginBanner
+	}
+
+	if payload.SelfSignup != nil {
+		settings.SelfSignup = *payload.SelfSignup
+	}
+
 	if payload.TemplatesURL != nil {
 		settings.TemplatesURL = *payload.TemplatesURL
 	}
@@ -237,6 +453,10 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 		settings.EdgePortainerURL = *payload.EdgePortainerURL
 	}
 
+	if payload.SnapshotContent != nil {
+		settings.SnapshotContent = *payload.SnapshotContent
+	}
+
 	if payload.SnapshotInterval != nil && *payload.SnapshotInterval != settings.SnapshotInterval {
 		err := handler.updateSnapshotInterval(settings, *payload.SnapshotInterval)
 		if err != nil {
@@ -264,6 +484,110 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 		settings.EnableTelemetry = *payload.EnableTelemetry
 	}
 
+	if payload.DisableUpdateCheck != nil {
+		settings.DisableUpdateCheck = *payload.DisableUpdateCheck
+	}
+
+	if payload.OfflineMode != nil {
+		if handler.OfflineModeFlag && !*payload.OfflineMode {
+			return nil, httperror.BadRequest("The offline mode is enforced by the --offline-mode flag", errors.New("offline mode cannot be disabled"))
+		}
+
+		settings.OfflineMode = *payload.OfflineMode
+	}
+
+	if payload.CORS != nil {
+		settings.CORS = *payload.CORS
+	}
+
+	if payload.SecurityHeaders != nil {
+		settings.SecurityHeaders = *payload.SecurityHeaders
+	}
+
+	if payload.RateLimit != nil {
+		settings.RateLimit = *payload.RateLimit
+	}
+
+	if payload.ProxyConcurrency != nil {
+		settings.ProxyConcurrency = *payload.ProxyConcurrency
+	}
+
+	if payload.APIUsage != nil {
+		settings.APIUsage = *payload.APIUsage
+	}
+
+	if payload.IntrusionDetection != nil {
+		settings.IntrusionDetection = *payload.IntrusionDetection
+	}
+
+	if payload.AuthorizationHook != nil {
+		settings.AuthorizationHook = *payload.AuthorizationHook
+	}
+
+	if payload.ChatOps != nil {
+		slackSigningSecret := payload.ChatOps.SlackSigningSecret
+		if slackSigningSecret == "" {
+			slackSigningSecret = settings.ChatOps.SlackSigningSecret
+		}
+
+		mattermostToken := payload.ChatOps.MattermostToken
+		if mattermostToken == "" {
+			mattermostToken = settings.ChatOps.MattermostToken
+		}
+
+		settings.ChatOps = *payload.ChatOps
+		settings.ChatOps.SlackSigningSecret = slackSigningSecret
+		settings.ChatOps.MattermostToken = mattermostToken
+	}
+
+	if payload.EnableGraphQL != nil {
+		settings.EnableGraphQL = *payload.EnableGraphQL
+	}
+
+	if payload.StatusPage != nil {
+		settings.StatusPage = *payload.StatusPage
+	}
+
+	if payload.StackPolicy != nil {
+		settings.StackPolicy = *payload.StackPolicy
+	}
+
+	if payload.Secrets != nil {
+		vaultToken := payload.Secrets.Vault.Token
+		if vaultToken == "" {
+			vaultToken = settings.Secrets.Vault.Token
+		}
+
+		settings.Secrets = *payload.Secrets
+		settings.Secrets.Vault.Token = vaultToken
+	}
+
+	if payload.Syslog != nil {
+		settings.Syslog = *payload.Syslog
+	}
+
+	if payload.SMTP != nil {
+		smtpPassword := payload.SMTP.Password
+		if smtpPassword == "" {
+			smtpPassword = settings.SMTP.Password
+		}
+
+		settings.SMTP = *payload.SMTP
+		settings.SMTP.Password = smtpPassword
+	}
+
+	if payload.ShellAccessPolicy != nil {
+		settings.ShellAccessPolicy = *payload.ShellAccessPolicy
+	}
+
+	if payload.AppTemplates != nil {
+		settings.AppTemplates = *payload.AppTemplates
+	}
+
+	if payload.ContentTrust != nil {
+		settings.ContentTrust = *payload.ContentTrust
+	}
+
 	err = handler.updateTLS(settings)
 	if err != nil {
 		return nil, err
@@ -273,7 +597,15 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 		settings.KubectlShellImage = *payload.KubectlShellImage
 	}
 
-	err = tx.Settings().UpdateSettings(settings)
+	if payload.Discovery != nil {
+		settings.Discovery = *payload.Discovery
+	}
+
+	if payload.UsageReport != nil {
+		settings.UsageReport = *payload.UsageReport
+	}
+
+	err = saveSettings(tx, settings)
 	if err != nil {
 		return nil, httperror.InternalServerError("Unable to persist settings changes inside the database", err)
 	}
@@ -281,6 +613,66 @@ func (handler *Handler) updateSettings(tx dataservices.DataStoreTx, payload sett
 	return settings, nil
 }
 
+// saveSettings persists the settings and records them in the history. The stored settings are recorded first so that
+// the changes made outside of the API, e.g. by the migrations, can be rolled back as well.
+func saveSettings(tx dataservices.DataStoreTx, settings *portainer.Settings) error {
+	previous, err := tx.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Settings().CreateVersion(previous); err != nil {
+		return err
+	}
+
+	if err := tx.Settings().UpdateSettings(settings); err != nil {
+		return err
+	}
+
+	return tx.Settings().CreateVersion(settings)
+}
+
+// applyPolicies applies the settings enforced by the HTTP server and the outgoing clients
+func (handler *Handler) applyPolicies(settings *portainer.Settings) {
+	client.SetOfflineMode(settings.OfflineMode)
+
+	if handler.CORSPolicy != nil {
+		handler.CORSPolicy.Update(settings.CORS)
+	}
+
+	if handler.SecurityHeadersPolicy != nil {
+		handler.SecurityHeadersPolicy.Update(settings.SecurityHeaders)
+	}
+
+	if handler.RateLimitPolicy != nil {
+		handler.RateLimitPolicy.Update(settings.RateLimit)
+	}
+
+	if handler.ConcurrencyLimiter != nil {
+		handler.ConcurrencyLimiter.Update(settings.ProxyConcurrency)
+	}
+
+	if handler.APIUsageTracker != nil {
+		handler.APIUsageTracker.Update(settings.APIUsage)
+	}
+
+	if handler.IntrusionDetector != nil {
+		handler.IntrusionDetector.Update(settings.IntrusionDetection)
+	}
+
+	if handler.AuthorizationHook != nil {
+		handler.AuthorizationHook.Update(settings.AuthorizationHook)
+	}
+
+	if handler.SyslogForwarder != nil {
+		handler.SyslogForwarder.Update(settings.Syslog)
+	}
+
+	if handler.MailService != nil {
+		handler.MailService.Update(settings.SMTP)
+	}
+}
+
 func (handler *Handler) updateSnapshotInterval(settings *portainer.Settings, snapshotInterval string) error {
 	settings.SnapshotInterval = snapshotInterval
 
@@ -0,0 +1,113 @@
+package stackbundles
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jobs"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+var errBundleBusy = errors.New("a bring-up or a teardown of the bundle is in progress")
+
+// Handler is the HTTP handler used to handle stack bundle operations.
+type Handler struct {
+	*mux.Router
+	DataStore      dataservices.DataStore
+	JobQueue       *jobs.Queue
+	requestBouncer security.BouncerService
+}
+
+// NewHandler creates a handler to manage stack bundle operations.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router:         mux.NewRouter(),
+		requestBouncer: bouncer,
+	}
+
+	adminRouter := h.NewRoute().Subrouter()
+	adminRouter.Use(bouncer.AdminAccess)
+
+	adminRouter.Handle("/stack_bundles", httperror.LoggerHandler(h.stackBundleCreate)).Methods(http.MethodPost)
+	adminRouter.Handle("/stack_bundles", httperror.LoggerHandler(h.stackBundleList)).Methods(http.MethodGet)
+	adminRouter.Handle("/stack_bundles/{id}", httperror.LoggerHandler(h.stackBundleInspect)).Methods(http.MethodGet)
+	adminRouter.Handle("/stack_bundles/{id}", httperror.LoggerHandler(h.stackBundleUpdate)).Methods(http.MethodPut)
+	adminRouter.Handle("/stack_bundles/{id}", httperror.LoggerHandler(h.stackBundleDelete)).Methods(http.MethodDelete)
+	adminRouter.Handle("/stack_bundles/{id}/up", httperror.LoggerHandler(h.stackBundleUp)).Methods(http.MethodPost)
+	adminRouter.Handle("/stack_bundles/{id}/down", httperror.LoggerHandler(h.stackBundleDown)).Methods(http.MethodPost)
+
+	return h
+}
+
+func (handler *Handler) bundleFromRequest(r *http.Request) (*portainer.StackBundle, *httperror.HandlerError) {
+	bundleID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid stack bundle identifier route variable", err)
+	}
+
+	bundle, err := handler.DataStore.StackBundle().Read(portainer.StackBundleID(bundleID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a stack bundle with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a stack bundle with the specified identifier inside the database", err)
+	}
+
+	return bundle, nil
+}
+
+// checkEndpointsAccess checks that the user can operate on the environments of the stacks of a bundle, which
+// includes the change approval of the protected environments
+func (handler *Handler) checkEndpointsAccess(r *http.Request, bundle *portainer.StackBundle) *httperror.HandlerError {
+	checked := make(map[portainer.EndpointID]bool)
+
+	for _, entry := range bundle.Stacks {
+		stack, err := handler.DataStore.Stack().Read(entry.StackID)
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return httperror.NotFound("Unable to find a stack of the bundle inside the database", err)
+		} else if err != nil {
+			return httperror.InternalServerError("Unable to find a stack of the bundle inside the database", err)
+		}
+
+		if checked[stack.EndpointID] {
+			continue
+		}
+		checked[stack.EndpointID] = true
+
+		endpoint, err := handler.DataStore.Endpoint().Endpoint(stack.EndpointID)
+		if err != nil {
+			return httperror.InternalServerError("Unable to find the environment of a stack of the bundle inside the database", err)
+		}
+
+		if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+			return httperror.Forbidden("Permission denied to access environment", err)
+		}
+	}
+
+	return nil
+}
+
+// checkNotBusy refuses the changes of a bundle while its last bring-up or teardown is queued or running
+func (handler *Handler) checkNotBusy(bundle *portainer.StackBundle) *httperror.HandlerError {
+	if bundle.JobID == 0 {
+		return nil
+	}
+
+	job, err := handler.DataStore.BackgroundJob().Read(bundle.JobID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find the background job of the stack bundle inside the database", err)
+	}
+
+	if !jobs.IsFinished(job.Status) {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "A bring-up or a teardown of the stack bundle is in progress", Err: errBundleBusy}
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package stackbundles
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/bundles"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type stackBundleCreatePayload struct {
+	// Name of the stack bundle
+	Name string `validate:"required" example:"shop"`
+	// Environment identifier of the stacks of the bundle
+	EndpointID portainer.EndpointID `validate:"required" example:"1"`
+	// Stacks of the bundle along with the stacks they depend on
+	Stacks []portainer.StackBundleEntry `validate:"required"`
+}
+
+func (payload *stackBundleCreatePayload) Validate(r *http.Request) error {
+	if payload.Name == "" {
+		return errors.New("invalid name")
+	}
+
+	if payload.EndpointID == 0 {
+		return errors.New("invalid environment identifier")
+	}
+
+	return nil
+}
+
+// @id StackBundleCreate
+// @summary Create a stack bundle
+// @description Group stacks of an environment into a bundle brought up and torn down as a unit. Each stack is brought up
+// @description after the stacks it depends on are running and healthy, and torn down before them.
+// @description **Access policy**: administrator
+// @tags stack_bundles
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body stackBundleCreatePayload true "Stack bundle details"
+// @success 200 {object} portainer.StackBundle "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /stack_bundles [post]
+func (handler *Handler) stackBundleCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload stackBundleCreatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	bundle := &portainer.StackBundle{
+		Name:         payload.Name,
+		EndpointID:   payload.EndpointID,
+		Stacks:       payload.Stacks,
+		Status:       portainer.StackBundleCreated,
+		CreationDate: time.Now().Unix(),
+		CreatedBy:    tokenData.Username,
+	}
+
+	if err := bundles.Validate(handler.DataStore, bundle); err != nil {
+		return httperror.BadRequest("Invalid stack bundle", err)
+	}
+
+	err = handler.DataStore.StackBundle().Create(bundle)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the stack bundle inside the database", err)
+	}
+
+	return response.JSON(w, bundle)
+}
@@ -0,0 +1,40 @@
+package stackbundles
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id StackBundleDelete
+// @summary Remove a stack bundle
+// @description Remove a stack bundle, its stacks being left as they are.
+// @description **Access policy**: administrator
+// @tags stack_bundles
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Stack bundle identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Stack bundle not found"
+// @failure 409 "A bring-up or a teardown of the bundle is in progress"
+// @failure 500 "Server error"
+// @router /stack_bundles/{id} [delete]
+func (handler *Handler) stackBundleDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	bundle, httpErr := handler.bundleFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if httpErr := handler.checkNotBusy(bundle); httpErr != nil {
+		return httpErr
+	}
+
+	err := handler.DataStore.StackBundle().Delete(bundle.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the stack bundle from the database", err)
+	}
+
+	return response.Empty(w)
+}
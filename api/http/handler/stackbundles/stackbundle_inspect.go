@@ -0,0 +1,30 @@
+package stackbundles
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id StackBundleInspect
+// @summary Inspect a stack bundle
+// @description **Access policy**: administrator
+// @tags stack_bundles
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack bundle identifier"
+// @success 200 {object} portainer.StackBundle "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Stack bundle not found"
+// @failure 500 "Server error"
+// @router /stack_bundles/{id} [get]
+func (handler *Handler) stackBundleInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	bundle, httpErr := handler.bundleFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, bundle)
+}
@@ -0,0 +1,27 @@
+package stackbundles
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id StackBundleList
+// @summary List the stack bundles
+// @description **Access policy**: administrator
+// @tags stack_bundles
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @success 200 {array} portainer.StackBundle "Success"
+// @failure 500 "Server error"
+// @router /stack_bundles [get]
+func (handler *Handler) stackBundleList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	bundles, err := handler.DataStore.StackBundle().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the stack bundles from the database", err)
+	}
+
+	return response.JSON(w, bundles)
+}
@@ -0,0 +1,97 @@
+package stackbundles
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/bundles"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id StackBundleUp
+// @summary Bring up a stack bundle
+// @description Queue a background job starting the stacks of the bundle in the order of their dependencies. Each stack is
+// @description started once the containers of the stacks it depends on are running and healthy, the bring-up stopping at the
+// @description first stack failing to start or to be healthy within its health timeout. The images are pulled with the
+// @description registries of the user. The progress is reported by the background job.
+// @description **Access policy**: administrator
+// @tags stack_bundles
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack bundle identifier"
+// @success 200 {object} portainer.BackgroundJob "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Stack bundle not found"
+// @failure 409 "A bring-up or a teardown of the bundle is in progress"
+// @failure 500 "Server error"
+// @router /stack_bundles/{id}/up [post]
+func (handler *Handler) stackBundleUp(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return handler.enqueue(w, r, bundles.UpJobType, "Bring-up of the stack bundle %s")
+}
+
+// @id StackBundleDown
+// @summary Tear down a stack bundle
+// @description Queue a background job stopping the stacks of the bundle in the reverse order of their dependencies. The
+// @description teardown goes on when a stack cannot be stopped, the bundle being reported as failed at the end.
+// @description **Access policy**: administrator
+// @tags stack_bundles
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack bundle identifier"
+// @success 200 {object} portainer.BackgroundJob "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Stack bundle not found"
+// @failure 409 "A bring-up or a teardown of the bundle is in progress"
+// @failure 500 "Server error"
+// @router /stack_bundles/{id}/down [post]
+func (handler *Handler) stackBundleDown(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return handler.enqueue(w, r, bundles.DownJobType, "Teardown of the stack bundle %s")
+}
+
+func (handler *Handler) enqueue(w http.ResponseWriter, r *http.Request, jobType, description string) *httperror.HandlerError {
+	bundle, httpErr := handler.bundleFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if httpErr := handler.checkNotBusy(bundle); httpErr != nil {
+		return httpErr
+	}
+
+	if _, err := bundles.Order(bundle.Stacks); err != nil {
+		return httperror.BadRequest("Invalid stack bundle", err)
+	}
+
+	if httpErr := handler.checkEndpointsAccess(r, bundle); httpErr != nil {
+		return httpErr
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user details from authentication token", err)
+	}
+
+	job, err := handler.JobQueue.Enqueue(jobType, bundles.JobPayload{BundleID: bundle.ID}, tokenData.ID, fmt.Sprintf(description, bundle.Name))
+	if err != nil {
+		return httperror.InternalServerError("Unable to queue the background job of the stack bundle", err)
+	}
+
+	// the job may already have updated the status of the bundle
+	bundle, err = handler.DataStore.StackBundle().Read(bundle.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to find a stack bundle with the specified identifier inside the database", err)
+	}
+
+	bundle.JobID = job.ID
+
+	err = handler.DataStore.StackBundle().Update(bundle.ID, bundle)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the stack bundle changes inside the database", err)
+	}
+
+	return response.JSON(w, job)
+}
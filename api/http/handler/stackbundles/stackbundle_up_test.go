@@ -0,0 +1,91 @@
+package stackbundles
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/portainer/portainer/api/jobs"
+	"github.com/portainer/portainer/api/stacks/bundles"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_stackBundleUp(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Type: portainer.DockerEnvironment}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 2, Type: portainer.DockerEnvironment}))
+	is.NoError(store.Stack().Create(&portainer.Stack{ID: 1, Name: "database", Type: portainer.DockerComposeStack, EndpointID: 1}))
+	is.NoError(store.Stack().Create(&portainer.Stack{ID: 2, Name: "backend", Type: portainer.DockerComposeStack, EndpointID: 1}))
+	is.NoError(store.Stack().Create(&portainer.Stack{ID: 3, Name: "other", Type: portainer.DockerComposeStack, EndpointID: 2}))
+
+	queue := jobs.NewQueue(store)
+	queue.Register(bundles.UpJobType, jobs.Options{MaxAttempts: 1}, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		return nil
+	})
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer())
+	h.DataStore = store
+	h.JobQueue = queue
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 1, Username: "admin", Role: portainer.AdministratorRole}))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	rr := send(http.MethodPost, "/stack_bundles", `{"Name":"shop","EndpointID":1,"Stacks":[{"StackId":3}]}`)
+	is.Equal(http.StatusBadRequest, rr.Code, "the stacks of another environment should be refused")
+
+	rr = send(http.MethodPost, "/stack_bundles", `{"Name":"shop","EndpointID":1,"Stacks":[{"StackId":1,"DependsOn":[2]},{"StackId":2,"DependsOn":[1]}]}`)
+	is.Equal(http.StatusBadRequest, rr.Code, "the dependency cycles should be refused")
+
+	rr = send(http.MethodPost, "/stack_bundles", `{"Name":"shop","EndpointID":1,"Stacks":[{"StackId":2,"DependsOn":[1]},{"StackId":1}]}`)
+	is.Equal(http.StatusOK, rr.Code)
+
+	var bundle portainer.StackBundle
+	is.NoError(json.NewDecoder(rr.Body).Decode(&bundle))
+	is.Equal(portainer.StackBundleCreated, bundle.Status)
+	is.Equal("admin", bundle.CreatedBy)
+
+	rr = send(http.MethodPost, "/stack_bundles/1/up", "")
+	is.Equal(http.StatusOK, rr.Code)
+
+	var job portainer.BackgroundJob
+	is.NoError(json.NewDecoder(rr.Body).Decode(&job))
+	is.Equal(bundles.UpJobType, job.Type)
+
+	stored, err := store.StackBundle().Read(bundle.ID)
+	is.NoError(err)
+	is.Equal(job.ID, stored.JobID)
+
+	rr = send(http.MethodPost, "/stack_bundles/1/up", "")
+	is.Equal(http.StatusConflict, rr.Code, "a bundle should not be brought up twice at the same time")
+
+	rr = send(http.MethodPut, "/stack_bundles/1", `{"Name":"store"}`)
+	is.Equal(http.StatusConflict, rr.Code, "a bundle should not be updated while it is brought up")
+
+	job.Status = portainer.BackgroundJobSucceeded
+	is.NoError(store.BackgroundJob().Update(job.ID, &job))
+
+	rr = send(http.MethodPut, "/stack_bundles/1", `{"Name":"store"}`)
+	is.Equal(http.StatusOK, rr.Code)
+
+	rr = send(http.MethodDelete, "/stack_bundles/1", "")
+	is.Equal(http.StatusNoContent, rr.Code)
+
+	_, err = store.Stack().Read(1)
+	is.NoError(err, "the stacks should be left in place")
+}
@@ -0,0 +1,76 @@
+package stackbundles
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/stacks/bundles"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type stackBundleUpdatePayload struct {
+	// Name of the stack bundle
+	Name *string `example:"shop"`
+	// Stacks of the bundle along with the stacks they depend on
+	Stacks []portainer.StackBundleEntry
+}
+
+func (payload *stackBundleUpdatePayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id StackBundleUpdate
+// @summary Update a stack bundle
+// @description The stacks of the bundle are left as they are, the changes applying to the next bring-up or teardown.
+// @description A bundle cannot be updated while it is brought up or torn down.
+// @description **Access policy**: administrator
+// @tags stack_bundles
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Stack bundle identifier"
+// @param body body stackBundleUpdatePayload true "Stack bundle details"
+// @success 200 {object} portainer.StackBundle "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Stack bundle not found"
+// @failure 409 "A bring-up or a teardown of the bundle is in progress"
+// @failure 500 "Server error"
+// @router /stack_bundles/{id} [put]
+func (handler *Handler) stackBundleUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	bundle, httpErr := handler.bundleFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	var payload stackBundleUpdatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	if httpErr := handler.checkNotBusy(bundle); httpErr != nil {
+		return httpErr
+	}
+
+	if payload.Name != nil {
+		bundle.Name = *payload.Name
+	}
+
+	if payload.Stacks != nil {
+		bundle.Stacks = payload.Stacks
+	}
+
+	if err := bundles.Validate(handler.DataStore, bundle); err != nil {
+		return httperror.BadRequest("Invalid stack bundle", err)
+	}
+
+	err = handler.DataStore.StackBundle().Update(bundle.ID, bundle)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the stack bundle changes inside the database", err)
+	}
+
+	return response.JSON(w, bundle)
+}
@@ -22,12 +22,16 @@ import (
 type composeStackFromFileContentPayload struct {
 	// Name of the stack
 	Name string `example:"myStack" validate:"required"`
-	// Content of the Stack file
-	StackFileContent string `example:"version: 3\n services:\n web:\n image:nginx" validate:"required"`
+	// Content of the Stack file, required unless a custom template is deployed
+	StackFileContent string `example:"version: 3\n services:\n web:\n image:nginx"`
 	// A list of environment variables used during stack deployment
 	Env []portainer.Pair
 	// Whether the stack is from a app template
 	FromAppTemplate bool `example:"false"`
+	// Identifier of the custom template to deploy instead of the Stack file content
+	CustomTemplateID portainer.CustomTemplateID `example:"1"`
+	// Values of the variables declared by the custom template
+	Variables map[string]string
 }
 
 func (payload *composeStackFromFileContentPayload) Validate(r *http.Request) error {
@@ -35,12 +39,24 @@ func (payload *composeStackFromFileContentPayload) Validate(r *http.Request) err
 		return errors.New("Invalid stack name")
 	}
 
-	if govalidator.IsNull(payload.StackFileContent) {
+	if payload.CustomTemplateID != 0 {
+		if !govalidator.IsNull(payload.StackFileContent) {
+			return errors.New("The stack file content cannot be provided along with a custom template")
+		}
+	} else if govalidator.IsNull(payload.StackFileContent) {
 		return errors.New("Invalid stack file content")
 	}
 	return nil
 }
 
+// ValidateComposeStackFromFileContentRequest runs the validation of the compose stack creation on a request, so that
+// the clients of the API can report an invalid request before sending it
+func ValidateComposeStackFromFileContentRequest(r *http.Request) error {
+	var payload composeStackFromFileContentPayload
+
+	return request.DecodeAndValidateJSONPayload(r, &payload)
+}
+
 func createStackPayloadFromComposeFileContentPayload(name string, fileContent string, env []portainer.Pair, fromAppTemplate bool) stackbuilders.StackPayload {
 	return stackbuilders.StackPayload{
 		Name:             name,
@@ -98,8 +114,13 @@ func (handler *Handler) checkAndCleanStackDupFromSwarm(w http.ResponseWriter, r
 // @produce json
 // @param body body composeStackFromFileContentPayload true "stack config"
 // @param endpointId query int true "Identifier of the environment that will be used to deploy the stack"
+// @param Idempotency-Key header string false "Key identifying the retries of the request, whose response is replayed for 24 hours"
 // @success 200 {object} portainer.Stack
 // @failure 400 "Invalid request"
+// @failure 403 "Access denied to the custom template"
+// @failure 404 "Custom template not found"
+// @failure 409 "A request with the same idempotency key is in progress"
+// @failure 422 "The idempotency key was used for a different request"
 // @failure 500 "Server error"
 // @router /stacks/create/standalone/string [post]
 func (handler *Handler) createComposeStackFromFileContent(w http.ResponseWriter, r *http.Request, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
@@ -109,6 +130,15 @@ func (handler *Handler) createComposeStackFromFileContent(w http.ResponseWriter,
 		return httperror.BadRequest("Invalid request payload", err)
 	}
 
+	if payload.CustomTemplateID != 0 {
+		fileContent, httpErr := handler.renderCustomTemplate(r, payload.CustomTemplateID, payload.Variables)
+		if httpErr != nil {
+			return httpErr
+		}
+
+		payload.StackFileContent = fileContent
+	}
+
 	payload.Name = handler.ComposeStackManager.NormalizeStackName(payload.Name)
 
 	isUnique, err := handler.checkUniqueStackNameInDocker(endpoint, payload.Name, 0, false)
@@ -151,6 +181,8 @@ func (handler *Handler) createComposeStackFromFileContent(w http.ResponseWriter,
 		return httpErr
 	}
 
+	handler.publishStackDeployed(stack)
+
 	return handler.decorateStackResponse(w, stack, userID)
 }
 
@@ -227,8 +259,11 @@ func (payload *composeStackFromGitRepositoryPayload) Validate(r *http.Request) e
 // @accept json
 // @param endpointId query int true "Identifier of the environment that will be used to deploy the stack"
 // @param body body composeStackFromGitRepositoryPayload true "stack config"
+// @param Idempotency-Key header string false "Key identifying the retries of the request, whose response is replayed for 24 hours"
 // @success 200 {object} portainer.Stack
 // @failure 400 "Invalid request"
+// @failure 409 "A request with the same idempotency key is in progress"
+// @failure 422 "The idempotency key was used for a different request"
 // @failure 500 "Server error"
 // @router /stacks/create/standalone/repository [post]
 func (handler *Handler) createComposeStackFromGitRepository(w http.ResponseWriter, r *http.Request, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
@@ -300,6 +335,7 @@ func (handler *Handler) createComposeStackFromGitRepository(w http.ResponseWrite
 		handler.FileService,
 		handler.GitService,
 		handler.Scheduler,
+		handler.JobQueue,
 		handler.StackDeployer)
 
 	stackBuilderDirector := stackbuilders.NewStackBuilderDirector(composeStackBuilder)
@@ -308,6 +344,8 @@ func (handler *Handler) createComposeStackFromGitRepository(w http.ResponseWrite
 		return httpErr
 	}
 
+	handler.publishStackDeployed(stack)
+
 	return handler.decorateStackResponse(w, stack, userID)
 }
 
@@ -361,8 +399,11 @@ func decodeRequestForm(r *http.Request) (*composeStackFromFileUploadPayload, err
 // @param Env formData string false "Environment variables passed during deployment, represented as a JSON array [{'name': 'name', 'value': 'value'}]."
 // @param file formData file false "Stack file"
 // @param endpointId query int true "Identifier of the environment that will be used to deploy the stack"
+// @param Idempotency-Key header string false "Key identifying the retries of the request, whose response is replayed for 24 hours"
 // @success 200 {object} portainer.Stack
 // @failure 400 "Invalid request"
+// @failure 409 "A request with the same idempotency key is in progress"
+// @failure 422 "The idempotency key was used for a different request"
 // @failure 500 "Server error"
 // @router /stacks/create/standalone/file [post]
 func (handler *Handler) createComposeStackFromFileUpload(w http.ResponseWriter, r *http.Request, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
@@ -413,5 +454,7 @@ func (handler *Handler) createComposeStackFromFileUpload(w http.ResponseWriter,
 		return httpErr
 	}
 
+	handler.publishStackDeployed(stack)
+
 	return handler.decorateStackResponse(w, stack, userID)
 }
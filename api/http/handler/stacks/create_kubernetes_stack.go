@@ -9,6 +9,7 @@ import (
 	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/internal/registryutils"
 	k "github.com/portainer/portainer/api/kubernetes"
+	"github.com/portainer/portainer/api/kubernetes/application"
 	"github.com/portainer/portainer/api/stacks/deployments"
 	"github.com/portainer/portainer/api/stacks/stackbuilders"
 	"github.com/portainer/portainer/api/stacks/stackutils"
@@ -129,6 +130,14 @@ func (payload *kubernetesManifestURLDeploymentPayload) Validate(r *http.Request)
 	return nil
 }
 
+type kubernetesApplicationDeploymentPayload struct {
+	application.Application
+}
+
+func (payload *kubernetesApplicationDeploymentPayload) Validate(r *http.Request) error {
+	return payload.Application.Validate()
+}
+
 type createKubernetesStackResponse struct {
 	Output string `json:"Output"`
 }
@@ -143,8 +152,11 @@ type createKubernetesStackResponse struct {
 // @produce json
 // @param body body kubernetesStringDeploymentPayload true "stack config"
 // @param endpointId query int true "Identifier of the environment that will be used to deploy the stack"
+// @param Idempotency-Key header string false "Key identifying the retries of the request, whose response is replayed for 24 hours"
 // @success 200 {object} portainer.Stack
 // @failure 400 "Invalid request"
+// @failure 409 "A request with the same idempotency key is in progress"
+// @failure 422 "The idempotency key was used for a different request"
 // @failure 500 "Server error"
 // @router /stacks/create/kubernetes/string [post]
 func (handler *Handler) createKubernetesStackFromFileContent(w http.ResponseWriter, r *http.Request, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
@@ -157,16 +169,76 @@ func (handler *Handler) createKubernetesStackFromFileContent(w http.ResponseWrit
 		return httperror.BadRequest("Invalid request payload", err)
 	}
 
+	stack, output, httpErr := handler.buildKubernetesStackFromFileContent(endpoint, userID, payload)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	handler.publishStackDeployed(stack)
+
+	return response.JSON(w, &createKubernetesStackResponse{Output: output})
+}
+
+// @id StackCreateKubernetesApplication
+// @summary Deploy a new kubernetes application from a form
+// @description Translate an application description into Kubernetes manifests (Deployment, Service, Ingress, PersistentVolumeClaims and HorizontalPodAutoscaler)
+// @description and deploy them as a new stack into the Kubernetes environment specified via the environment identifier.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param body body kubernetesApplicationDeploymentPayload true "application description"
+// @param endpointId query int true "Identifier of the environment that will be used to deploy the application"
+// @param Idempotency-Key header string false "Key identifying the retries of the request, whose response is replayed for 24 hours"
+// @success 200 {object} createKubernetesStackResponse
+// @failure 400 "Invalid request"
+// @failure 409 "A stack with the same name already exists"
+// @failure 422 "The idempotency key was used for a different request"
+// @failure 500 "Server error"
+// @router /stacks/create/kubernetes/application [post]
+func (handler *Handler) createKubernetesStackFromApplication(w http.ResponseWriter, r *http.Request, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
+	if !endpointutils.IsKubernetesEndpoint(endpoint) {
+		return httperror.BadRequest("Environment type does not match", errors.New("Environment type does not match"))
+	}
+
+	var payload kubernetesApplicationDeploymentPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	manifest, err := application.GenerateManifest(payload.Application)
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate the application manifest", err)
+	}
+
+	stack, output, httpErr := handler.buildKubernetesStackFromFileContent(endpoint, userID, kubernetesStringDeploymentPayload{
+		StackName:        payload.Name,
+		Namespace:        payload.Namespace,
+		StackFileContent: string(manifest),
+	})
+	if httpErr != nil {
+		return httpErr
+	}
+
+	handler.publishStackDeployed(stack)
+
+	return response.JSON(w, &createKubernetesStackResponse{Output: output})
+}
+
+// buildKubernetesStackFromFileContent deploys the manifest of the payload as a new Kubernetes stack
+// and returns the created stack along with the output of the deployment
+func (handler *Handler) buildKubernetesStackFromFileContent(endpoint *portainer.Endpoint, userID portainer.UserID, payload kubernetesStringDeploymentPayload) (*portainer.Stack, string, *httperror.HandlerError) {
 	user, err := handler.DataStore.User().Read(userID)
 	if err != nil {
-		return httperror.InternalServerError("Unable to load user information from the database", err)
+		return nil, "", httperror.InternalServerError("Unable to load user information from the database", err)
 	}
 	isUnique, err := handler.checkUniqueStackNameInKubernetes(endpoint, payload.StackName, 0, payload.Namespace)
 	if err != nil {
-		return httperror.InternalServerError("Unable to check for name collision", err)
+		return nil, "", httperror.InternalServerError("Unable to check for name collision", err)
 	}
 	if !isUnique {
-		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("A stack with the name '%s' already exists", payload.StackName), Err: stackutils.ErrStackAlreadyExists}
+		return nil, "", &httperror.HandlerError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("A stack with the name '%s' already exists", payload.StackName), Err: stackutils.ErrStackAlreadyExists}
 	}
 
 	stackPayload := createStackPayloadFromK8sFileContentPayload(payload.StackName, payload.Namespace, payload.StackFileContent, payload.ComposeFormat, payload.FromAppTemplate)
@@ -186,16 +258,12 @@ func (handler *Handler) createKubernetesStackFromFileContent(w http.ResponseWrit
 	}
 
 	stackBuilderDirector := stackbuilders.NewStackBuilderDirector(k8sStackBuilder)
-	_, httpErr := stackBuilderDirector.Build(&stackPayload, endpoint)
+	stack, httpErr := stackBuilderDirector.Build(&stackPayload, endpoint)
 	if httpErr != nil {
-		return httpErr
-	}
-
-	resp := &createKubernetesStackResponse{
-		Output: k8sStackBuilder.GetResponse(),
+		return nil, "", httpErr
 	}
 
-	return response.JSON(w, resp)
+	return stack, k8sStackBuilder.GetResponse(), nil
 }
 
 // @id StackCreateKubernetesGit
@@ -208,8 +276,11 @@ func (handler *Handler) createKubernetesStackFromFileContent(w http.ResponseWrit
 // @produce json
 // @param body body kubernetesGitDeploymentPayload true "stack config"
 // @param endpointId query int true "Identifier of the environment that will be used to deploy the stack"
+// @param Idempotency-Key header string false "Key identifying the retries of the request, whose response is replayed for 24 hours"
 // @success 200 {object} portainer.Stack
 // @failure 400 "Invalid request"
+// @failure 409 "A request with the same idempotency key is in progress"
+// @failure 422 "The idempotency key was used for a different request"
 // @failure 500 "Server error"
 // @router /stacks/create/kubernetes/repository [post]
 func (handler *Handler) createKubernetesStackFromGitRepository(w http.ResponseWriter, r *http.Request, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
@@ -222,26 +293,39 @@ func (handler *Handler) createKubernetesStackFromGitRepository(w http.ResponseWr
 		return httperror.BadRequest("Invalid request payload", err)
 	}
 
+	stack, output, httpErr := handler.buildKubernetesStackFromGitRepository(endpoint, userID, payload)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	handler.publishStackDeployed(stack)
+
+	return response.JSON(w, &createKubernetesStackResponse{Output: output})
+}
+
+// buildKubernetesStackFromGitRepository deploys the manifest stored in the Git repository of the payload
+// as a new Kubernetes stack and returns the created stack along with the output of the deployment
+func (handler *Handler) buildKubernetesStackFromGitRepository(endpoint *portainer.Endpoint, userID portainer.UserID, payload kubernetesGitDeploymentPayload) (*portainer.Stack, string, *httperror.HandlerError) {
 	user, err := handler.DataStore.User().Read(userID)
 	if err != nil {
-		return httperror.InternalServerError("Unable to load user information from the database", err)
+		return nil, "", httperror.InternalServerError("Unable to load user information from the database", err)
 	}
 	isUnique, err := handler.checkUniqueStackNameInKubernetes(endpoint, payload.StackName, 0, payload.Namespace)
 	if err != nil {
-		return httperror.InternalServerError("Unable to check for name collision", err)
+		return nil, "", httperror.InternalServerError("Unable to check for name collision", err)
 	}
 	if !isUnique {
-		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("A stack with the name '%s' already exists", payload.StackName), Err: stackutils.ErrStackAlreadyExists}
+		return nil, "", &httperror.HandlerError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("A stack with the name '%s' already exists", payload.StackName), Err: stackutils.ErrStackAlreadyExists}
 	}
 
 	//make sure the webhook ID is unique
 	if payload.AutoUpdate != nil && payload.AutoUpdate.Webhook != "" {
 		isUnique, err := handler.checkUniqueWebhookID(payload.AutoUpdate.Webhook)
 		if err != nil {
-			return httperror.InternalServerError("Unable to check for webhook ID collision", err)
+			return nil, "", httperror.InternalServerError("Unable to check for webhook ID collision", err)
 		}
 		if !isUnique {
-			return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("Webhook ID: %s already exists", payload.AutoUpdate.Webhook), Err: stackutils.ErrWebhookIDAlreadyExists}
+			return nil, "", &httperror.HandlerError{StatusCode: http.StatusConflict, Message: fmt.Sprintf("Webhook ID: %s already exists", payload.AutoUpdate.Webhook), Err: stackutils.ErrWebhookIDAlreadyExists}
 		}
 	}
 
@@ -263,21 +347,18 @@ func (handler *Handler) createKubernetesStackFromGitRepository(w http.ResponseWr
 		handler.FileService,
 		handler.GitService,
 		handler.Scheduler,
+		handler.JobQueue,
 		handler.StackDeployer,
 		handler.KubernetesDeployer,
 		user)
 
 	stackBuilderDirector := stackbuilders.NewStackBuilderDirector(k8sStackBuilder)
-	_, httpErr := stackBuilderDirector.Build(&stackPayload, endpoint)
+	stack, httpErr := stackBuilderDirector.Build(&stackPayload, endpoint)
 	if httpErr != nil {
-		return httpErr
+		return nil, "", httpErr
 	}
 
-	resp := &createKubernetesStackResponse{
-		Output: k8sStackBuilder.GetResponse(),
-	}
-
-	return response.JSON(w, resp)
+	return stack, k8sStackBuilder.GetResponse(), nil
 }
 
 // @id StackCreateKubernetesUrl
@@ -290,8 +371,11 @@ func (handler *Handler) createKubernetesStackFromGitRepository(w http.ResponseWr
 // @produce json
 // @param body body kubernetesManifestURLDeploymentPayload true "stack config"
 // @param endpointId query int true "Identifier of the environment that will be used to deploy the stack"
+// @param Idempotency-Key header string false "Key identifying the retries of the request, whose response is replayed for 24 hours"
 // @success 200 {object} portainer.Stack
 // @failure 400 "Invalid request"
+// @failure 409 "A request with the same idempotency key is in progress"
+// @failure 422 "The idempotency key was used for a different request"
 // @failure 500 "Server error"
 // @router /stacks/create/kubernetes/url [post]
 func (handler *Handler) createKubernetesStackFromManifestURL(w http.ResponseWriter, r *http.Request, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
@@ -324,11 +408,13 @@ func (handler *Handler) createKubernetesStackFromManifestURL(w http.ResponseWrit
 		user)
 
 	stackBuilderDirector := stackbuilders.NewStackBuilderDirector(k8sStackBuilder)
-	_, httpErr := stackBuilderDirector.Build(&stackPayload, endpoint)
+	stack, httpErr := stackBuilderDirector.Build(&stackPayload, endpoint)
 	if httpErr != nil {
 		return httpErr
 	}
 
+	handler.publishStackDeployed(stack)
+
 	resp := &createKubernetesStackResponse{
 		Output: k8sStackBuilder.GetResponse(),
 	}
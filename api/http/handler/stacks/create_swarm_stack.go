@@ -21,12 +21,16 @@ type swarmStackFromFileContentPayload struct {
 	Name string `example:"myStack" validate:"required"`
 	// Swarm cluster identifier
 	SwarmID string `example:"jpofkc0i9uo9wtx1zesuk649w" validate:"required"`
-	// Content of the Stack file
-	StackFileContent string `example:"version: 3\n services:\n web:\n image:nginx" validate:"required"`
+	// Content of the Stack file, required unless a custom template is deployed
+	StackFileContent string `example:"version: 3\n services:\n web:\n image:nginx"`
 	// A list of environment variables used during stack deployment
 	Env []portainer.Pair
 	// Whether the stack is from a app template
 	FromAppTemplate bool `example:"false"`
+	// Identifier of the custom template to deploy instead of the Stack file content
+	CustomTemplateID portainer.CustomTemplateID `example:"1"`
+	// Values of the variables declared by the custom template
+	Variables map[string]string
 }
 
 func (payload *swarmStackFromFileContentPayload) Validate(r *http.Request) error {
@@ -36,7 +40,11 @@ func (payload *swarmStackFromFileContentPayload) Validate(r *http.Request) error
 	if govalidator.IsNull(payload.SwarmID) {
 		return errors.New("Invalid Swarm ID")
 	}
-	if govalidator.IsNull(payload.StackFileContent) {
+	if payload.CustomTemplateID != 0 {
+		if !govalidator.IsNull(payload.StackFileContent) {
+			return errors.New("The stack file content cannot be provided along with a custom template")
+		}
+	} else if govalidator.IsNull(payload.StackFileContent) {
 		return errors.New("Invalid stack file content")
 	}
 	return nil
@@ -63,8 +71,13 @@ func createStackPayloadFromSwarmFileContentPayload(name string, swarmID string,
 // @produce json
 // @param body body swarmStackFromFileContentPayload true "stack config"
 // @param endpointId query int true "Identifier of the environment that will be used to deploy the stack"
+// @param Idempotency-Key header string false "Key identifying the retries of the request, whose response is replayed for 24 hours"
 // @success 200 {object} portainer.Stack
 // @failure 400 "Invalid request"
+// @failure 403 "Access denied to the custom template"
+// @failure 404 "Custom template not found"
+// @failure 409 "A request with the same idempotency key is in progress"
+// @failure 422 "The idempotency key was used for a different request"
 // @failure 500 "Server error"
 // @router /stacks/create/swarm/string [post]
 func (handler *Handler) createSwarmStackFromFileContent(w http.ResponseWriter, r *http.Request, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
@@ -74,6 +87,15 @@ func (handler *Handler) createSwarmStackFromFileContent(w http.ResponseWriter, r
 		return httperror.BadRequest("Invalid request payload", err)
 	}
 
+	if payload.CustomTemplateID != 0 {
+		fileContent, httpErr := handler.renderCustomTemplate(r, payload.CustomTemplateID, payload.Variables)
+		if httpErr != nil {
+			return httpErr
+		}
+
+		payload.StackFileContent = fileContent
+	}
+
 	payload.Name = handler.SwarmStackManager.NormalizeStackName(payload.Name)
 
 	isUnique, err := handler.checkUniqueStackNameInDocker(endpoint, payload.Name, 0, true)
@@ -103,6 +125,8 @@ func (handler *Handler) createSwarmStackFromFileContent(w http.ResponseWriter, r
 		return httpErr
 	}
 
+	handler.publishStackDeployed(stack)
+
 	return handler.decorateStackResponse(w, stack, userID)
 }
 
@@ -186,8 +210,11 @@ func createStackPayloadFromSwarmGitPayload(name, swarmID, repoUrl, repoReference
 // @accept json
 // @param endpointId query int true "Identifier of the environment that will be used to deploy the stack"
 // @param body body swarmStackFromGitRepositoryPayload true "stack config"
+// @param Idempotency-Key header string false "Key identifying the retries of the request, whose response is replayed for 24 hours"
 // @success 200 {object} portainer.Stack
 // @failure 400 "Invalid request"
+// @failure 409 "A request with the same idempotency key is in progress"
+// @failure 422 "The idempotency key was used for a different request"
 // @failure 500 "Server error"
 // @router /stacks/create/swarm/repository [post]
 func (handler *Handler) createSwarmStackFromGitRepository(w http.ResponseWriter, r *http.Request, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
@@ -243,6 +270,7 @@ func (handler *Handler) createSwarmStackFromGitRepository(w http.ResponseWriter,
 		handler.FileService,
 		handler.GitService,
 		handler.Scheduler,
+		handler.JobQueue,
 		handler.StackDeployer)
 
 	stackBuilderDirector := stackbuilders.NewStackBuilderDirector(swarmStackBuilder)
@@ -251,6 +279,8 @@ func (handler *Handler) createSwarmStackFromGitRepository(w http.ResponseWriter,
 		return httpErr
 	}
 
+	handler.publishStackDeployed(stack)
+
 	return handler.decorateStackResponse(w, stack, userID)
 }
 
@@ -312,8 +342,11 @@ func (payload *swarmStackFromFileUploadPayload) Validate(r *http.Request) error
 // @param Env formData string false "Environment variables passed during deployment, represented as a JSON array [{'name': 'name', 'value': 'value'}]. Optional"
 // @param file formData file false "Stack file"
 // @param endpointId query int true "Identifier of the environment that will be used to deploy the stack"
+// @param Idempotency-Key header string false "Key identifying the retries of the request, whose response is replayed for 24 hours"
 // @success 200 {object} portainer.Stack
 // @failure 400 "Invalid request"
+// @failure 409 "A request with the same idempotency key is in progress"
+// @failure 422 "The idempotency key was used for a different request"
 // @failure 500 "Server error"
 // @router /stacks/create/swarm/file [post]
 func (handler *Handler) createSwarmStackFromFileUpload(w http.ResponseWriter, r *http.Request, endpoint *portainer.Endpoint, userID portainer.UserID) *httperror.HandlerError {
@@ -352,5 +385,7 @@ func (handler *Handler) createSwarmStackFromFileUpload(w http.ResponseWriter, r
 		return httpErr
 	}
 
+	handler.publishStackDeployed(stack)
+
 	return handler.decorateStackResponse(w, stack, userID)
 }
@@ -0,0 +1,67 @@
+package stacks
+
+import (
+	"net/http"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/internal/customtemplateutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/pkg/errors"
+)
+
+// renderCustomTemplate returns the content of a custom template once its variables are replaced by their values
+func (handler *Handler) renderCustomTemplate(r *http.Request, customTemplateID portainer.CustomTemplateID, variables map[string]string) (string, *httperror.HandlerError) {
+	customTemplate, err := handler.DataStore.CustomTemplate().Read(customTemplateID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return "", httperror.NotFound("Unable to find a custom template with the specified identifier inside the database", err)
+	} else if err != nil {
+		return "", httperror.InternalServerError("Unable to find a custom template with the specified identifier inside the database", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return "", httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	if !securityContext.IsAdmin && customTemplate.CreatedByUserID != securityContext.UserID {
+		resourceControl, err := handler.DataStore.ResourceControl().ResourceControlByResourceIDAndType(strconv.Itoa(int(customTemplate.ID)), portainer.CustomTemplateResourceControl)
+		if err != nil {
+			return "", httperror.InternalServerError("Unable to retrieve a resource control associated to the custom template", err)
+		}
+
+		userTeamIDs := make([]portainer.TeamID, 0, len(securityContext.UserMemberships))
+		for _, membership := range securityContext.UserMemberships {
+			userTeamIDs = append(userTeamIDs, membership.TeamID)
+		}
+
+		if !authorization.UserCanAccessResource(securityContext.UserID, userTeamIDs, resourceControl) {
+			return "", httperror.Forbidden("Access denied to resource", httperrors.ErrResourceAccessDenied)
+		}
+	}
+
+	if customTemplate.Type == portainer.KubernetesStack {
+		return "", httperror.BadRequest("Invalid custom template", errors.New("a Kubernetes template cannot be deployed as a Docker stack"))
+	}
+
+	entryPath := customTemplate.EntryPoint
+	if customTemplate.GitConfig != nil {
+		entryPath = customTemplate.GitConfig.ConfigFilePath
+	}
+
+	fileContent, err := handler.FileService.GetFileContent(customTemplate.ProjectPath, entryPath)
+	if err != nil {
+		return "", httperror.InternalServerError("Unable to retrieve custom template file from disk", err)
+	}
+
+	rendered, err := customtemplateutils.Render(string(fileContent), customTemplate.Variables, variables)
+	if err != nil {
+		return "", httperror.BadRequest("Invalid custom template variables", err)
+	}
+
+	return rendered, nil
+}
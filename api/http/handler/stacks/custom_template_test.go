@@ -0,0 +1,64 @@
+package stacks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/http/security"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderCustomTemplate(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	fileService, err := filesystem.NewService(t.TempDir(), "")
+	assert.NoError(t, err)
+
+	projectPath, err := fileService.StoreCustomTemplateFileFromBytes("1", "docker-compose.yml", []byte("services:\n  web:\n    image: {{ .IMAGE }}\n"))
+	assert.NoError(t, err)
+
+	err = store.CustomTemplate().Create(&portainer.CustomTemplate{
+		ID:              1,
+		Title:           "web",
+		ProjectPath:     projectPath,
+		EntryPoint:      "docker-compose.yml",
+		CreatedByUserID: 2,
+		Type:            portainer.DockerComposeStack,
+		Variables: []portainer.CustomTemplateVariableDefinition{
+			{Name: "IMAGE", Label: "Image", Options: []string{"nginx:1.25", "nginx:1.24"}, Required: true},
+		},
+	})
+	assert.NoError(t, err)
+
+	handler := &Handler{DataStore: store, FileService: fileService}
+
+	render := func(userID portainer.UserID, variables map[string]string) (string, int) {
+		r := httptest.NewRequest(http.MethodPost, "/stacks/create/standalone/string", nil)
+		r = r.WithContext(security.StoreRestrictedRequestContext(r, &security.RestrictedRequestContext{UserID: userID}))
+
+		content, httpErr := handler.renderCustomTemplate(r, 1, variables)
+		if httpErr != nil {
+			return "", httpErr.StatusCode
+		}
+
+		return content, http.StatusOK
+	}
+
+	content, status := render(2, map[string]string{"IMAGE": "nginx:1.24"})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "services:\n  web:\n    image: nginx:1.24\n", content)
+
+	_, status = render(2, map[string]string{"IMAGE": "httpd"})
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	_, status = render(2, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	_, status = render(3, map[string]string{"IMAGE": "nginx:1.24"})
+	assert.Equal(t, http.StatusForbidden, status, "the template is only accessible to its author")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -14,9 +15,12 @@ import (
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
 	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/jobs"
 	"github.com/portainer/portainer/api/kubernetes/cli"
+	"github.com/portainer/portainer/api/lifecycle"
 	"github.com/portainer/portainer/api/scheduler"
 	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/drift"
 	"github.com/portainer/portainer/api/stacks/stackutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
@@ -40,7 +44,10 @@ type Handler struct {
 	KubernetesDeployer      portainer.KubernetesDeployer
 	KubernetesClientFactory *cli.ClientFactory
 	Scheduler               *scheduler.Scheduler
+	JobQueue                *jobs.Queue
 	StackDeployer           deployments.StackDeployer
+	DriftService            *drift.Service
+	EventDispatcher         *lifecycle.Dispatcher
 }
 
 func stackExistsError(name string) *httperror.HandlerError {
@@ -59,11 +66,17 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 	}
 
 	h.Handle("/stacks/create/{type}/{method}",
-		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackCreate))).Methods(http.MethodPost)
+		bouncer.AuthenticatedAccess(middlewares.WithIdempotency(httperror.LoggerHandler(h.stackCreate)))).Methods(http.MethodPost)
 	h.Handle("/stacks",
 		bouncer.AuthenticatedAccess(middlewares.Deprecated(h, deprecatedStackCreateUrlParser))).Methods(http.MethodPost) // Deprecated
 	h.Handle("/stacks",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackList))).Methods(http.MethodGet)
+	h.Handle("/stacks/lint",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackLint))).Methods(http.MethodPost)
+	h.Handle("/stacks/adoptable",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.stackAdoptableList))).Methods(http.MethodGet)
+	h.Handle("/stacks/adopt",
+		bouncer.AdminAccess(httperror.LoggerHandler(h.stackAdopt))).Methods(http.MethodPost)
 	h.Handle("/stacks/{id}",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackInspect))).Methods(http.MethodGet)
 	h.Handle("/stacks/{id}",
@@ -78,12 +91,22 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackGitRedeploy))).Methods(http.MethodPut)
 	h.Handle("/stacks/{id}/file",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackFile))).Methods(http.MethodGet)
+	h.Handle("/stacks/{id}/drift",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackDriftInspect))).Methods(http.MethodGet)
+	h.Handle("/stacks/{id}/drift/reconcile",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackDriftReconcile))).Methods(http.MethodPost)
+	h.Handle("/stacks/{id}/usage",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackUsage))).Methods(http.MethodGet)
+	h.Handle("/stacks/{id}/duplicate",
+		bouncer.AuthenticatedAccess(middlewares.WithIdempotency(httperror.LoggerHandler(h.stackDuplicate)))).Methods(http.MethodPost)
 	h.Handle("/stacks/{id}/migrate",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackMigrate))).Methods(http.MethodPost)
 	h.Handle("/stacks/{id}/start",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackStart))).Methods(http.MethodPost)
 	h.Handle("/stacks/{id}/stop",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.stackStop))).Methods(http.MethodPost)
+	h.Handle("/endpoints/{id}/kubernetes/apply",
+		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.kubernetesApply))).Methods(http.MethodPost)
 	h.Handle("/stacks/webhooks/{webhookID}",
 		bouncer.PublicAccess(httperror.LoggerHandler(h.webhookInvoke))).Methods(http.MethodPost)
 
@@ -136,7 +159,16 @@ func (handler *Handler) userCanManageStacks(securityContext *security.Restricted
 		return true, nil
 	}
 
-	if endpointutils.IsDockerEndpoint(endpoint) && !endpoint.SecuritySettings.AllowStackManagementForRegularUsers {
+	if !endpointutils.IsDockerEndpoint(endpoint) {
+		return true, nil
+	}
+
+	securitySettings, err := endpointutils.EffectiveSecuritySettings(handler.DataStore, endpoint)
+	if err != nil {
+		return false, err
+	}
+
+	if !securitySettings.AllowStackManagementForRegularUsers {
 		canCreate, err := handler.userCanCreateStack(securityContext, portainer.EndpointID(endpoint.ID))
 
 		if err != nil {
@@ -236,3 +268,15 @@ func (handler *Handler) checkUniqueWebhookID(webhookID string) (bool, error) {
 	}
 	return false, err
 }
+
+// publishStackDeployed notifies the event webhooks of a stack deployed to its environment
+func (handler *Handler) publishStackDeployed(stack *portainer.Stack) {
+	if handler.EventDispatcher == nil {
+		return
+	}
+
+	handler.EventDispatcher.Publish(lifecycle.NewEvent(lifecycle.StackDeployed, strconv.Itoa(int(stack.ID)), map[string]string{
+		"name":       stack.Name,
+		"endpointId": strconv.Itoa(int(stack.EndpointID)),
+	}))
+}
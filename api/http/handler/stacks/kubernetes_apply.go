@@ -0,0 +1,260 @@
+package stacks
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	k "github.com/portainer/portainer/api/kubernetes"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/pkg/errors"
+)
+
+const kubernetesApplyManifestFile = "manifest.yml"
+
+type kubernetesApplyPayload struct {
+	// Name of the stack used to store the manifest
+	StackName string `example:"my-app"`
+	// Namespace the resources are deployed to when they do not specify one
+	Namespace string `example:"default"`
+	// Content of the manifest, required unless RepositoryURL is specified
+	StackFileContent string
+	// URL of a Git repository hosting the manifest
+	RepositoryURL            string `example:"https://github.com/openfaas/faas"`
+	RepositoryReferenceName  string `example:"refs/heads/master"`
+	RepositoryAuthentication bool   `example:"false"`
+	RepositoryUsername       string
+	RepositoryPassword       string
+	// Path to the manifest inside the Git repository
+	ManifestFile string `example:"deployment.yml"`
+	// TLSSkipVerify skips SSL verification when cloning the Git repository
+	TLSSkipVerify bool `example:"false"`
+	// Only validate the manifest against the API server, nothing is applied nor stored
+	DryRun bool `example:"false"`
+}
+
+func (payload *kubernetesApplyPayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.StackName) {
+		return errors.New("Invalid stack name")
+	}
+
+	if govalidator.IsNull(payload.RepositoryURL) {
+		if govalidator.IsNull(payload.StackFileContent) {
+			return errors.New("Invalid stack file content. Either a manifest or a repository URL must be specified")
+		}
+
+		return nil
+	}
+
+	if !govalidator.IsURL(payload.RepositoryURL) {
+		return errors.New("Invalid repository URL. Must correspond to a valid URL format")
+	}
+	if payload.RepositoryAuthentication && govalidator.IsNull(payload.RepositoryPassword) {
+		return errors.New("Invalid repository credentials. Password must be specified when authentication is enabled")
+	}
+	if govalidator.IsNull(payload.ManifestFile) {
+		return errors.New("Invalid manifest file in repository")
+	}
+
+	return nil
+}
+
+type kubernetesApplyResult struct {
+	// Resource the result applies to, e.g. deployment.apps/my-app
+	Resource string `example:"deployment.apps/my-app"`
+	// Outcome of the operation as reported by the API server, e.g. created, configured or unchanged
+	Result string `example:"created"`
+}
+
+type kubernetesApplyResponse struct {
+	// Identifier of the stack storing the manifest, unset for a dry-run
+	StackID portainer.StackID `example:"1"`
+	DryRun  bool              `example:"false"`
+	Results []kubernetesApplyResult
+	Output  string
+}
+
+// @id KubernetesApply
+// @summary Validate and apply a Kubernetes manifest
+// @description Validate a manifest, specified as raw YAML or hosted in a Git repository, against the schema and
+// @description the admission chain of the Kubernetes API server (dry-run), then apply it.
+// @description The manifest is stored as a Kubernetes stack so that it can be inspected and redeployed later.
+// @description **Access policy**: authenticated
+// @tags kubernetes
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Environment identifier"
+// @param body body kubernetesApplyPayload true "Manifest to apply"
+// @success 200 {object} kubernetesApplyResponse "Success"
+// @failure 400 "Invalid request or manifest rejected by the API server"
+// @failure 403 "Permission denied"
+// @failure 404 "Environment not found"
+// @failure 409 "A stack with the same name already exists"
+// @failure 500 "Server error"
+// @router /endpoints/{id}/kubernetes/apply [post]
+func (handler *Handler) kubernetesApply(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid environment identifier route variable", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if !endpointutils.IsKubernetesEndpoint(endpoint) {
+		return httperror.BadRequest("Environment type does not match", errors.New("Environment type does not match"))
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user info from request context", err)
+	}
+
+	canManage, err := handler.userCanManageStacks(securityContext, endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify user authorizations to validate stack creation", err)
+	}
+	if !canManage {
+		errMsg := "Stack creation is disabled for non-admin users"
+		return httperror.Forbidden(errMsg, errors.New(errMsg))
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	var payload kubernetesApplyPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	manifest, err := handler.kubernetesApplyManifest(payload)
+	if err != nil {
+		return httperror.BadRequest("Unable to retrieve the manifest", err)
+	}
+
+	if err := k.ValidateManifest(manifest); err != nil {
+		return httperror.BadRequest("Invalid manifest", err)
+	}
+
+	output, err := handler.dryRunKubernetesManifest(securityContext.UserID, endpoint, manifest, payload.Namespace)
+	if err != nil {
+		return httperror.BadRequest("The manifest was rejected by the Kubernetes API server", err)
+	}
+
+	if payload.DryRun {
+		return response.JSON(w, &kubernetesApplyResponse{
+			DryRun:  true,
+			Results: parseKubectlApplyOutput(output),
+			Output:  output,
+		})
+	}
+
+	var stack *portainer.Stack
+	var httpErr *httperror.HandlerError
+	if payload.RepositoryURL != "" {
+		stack, output, httpErr = handler.buildKubernetesStackFromGitRepository(endpoint, securityContext.UserID, kubernetesGitDeploymentPayload{
+			StackName:                payload.StackName,
+			Namespace:                payload.Namespace,
+			RepositoryURL:            payload.RepositoryURL,
+			RepositoryReferenceName:  payload.RepositoryReferenceName,
+			RepositoryAuthentication: payload.RepositoryAuthentication,
+			RepositoryUsername:       payload.RepositoryUsername,
+			RepositoryPassword:       payload.RepositoryPassword,
+			ManifestFile:             payload.ManifestFile,
+			TLSSkipVerify:            payload.TLSSkipVerify,
+		})
+	} else {
+		stack, output, httpErr = handler.buildKubernetesStackFromFileContent(endpoint, securityContext.UserID, kubernetesStringDeploymentPayload{
+			StackName:        payload.StackName,
+			Namespace:        payload.Namespace,
+			StackFileContent: payload.StackFileContent,
+		})
+	}
+
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, &kubernetesApplyResponse{
+		StackID: stack.ID,
+		Results: parseKubectlApplyOutput(output),
+		Output:  output,
+	})
+}
+
+// kubernetesApplyManifest returns the content of the manifest, cloning the Git repository when required
+func (handler *Handler) kubernetesApplyManifest(payload kubernetesApplyPayload) ([]byte, error) {
+	if payload.RepositoryURL == "" {
+		return []byte(payload.StackFileContent), nil
+	}
+
+	repositoryUsername := ""
+	repositoryPassword := ""
+	if payload.RepositoryAuthentication {
+		repositoryUsername = payload.RepositoryUsername
+		repositoryPassword = payload.RepositoryPassword
+	}
+
+	tmpDir, err := os.MkdirTemp("", "kube_apply_repository")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a temporary directory")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := handler.GitService.CloneRepository(tmpDir, payload.RepositoryURL, payload.RepositoryReferenceName, repositoryUsername, repositoryPassword, payload.TLSSkipVerify); err != nil {
+		return nil, errors.Wrap(err, "failed to clone the Git repository")
+	}
+
+	return os.ReadFile(filesystem.JoinPaths(tmpDir, payload.ManifestFile))
+}
+
+// dryRunKubernetesManifest submits the manifest to the API server without persisting the resources
+func (handler *Handler) dryRunKubernetesManifest(userID portainer.UserID, endpoint *portainer.Endpoint, manifest []byte, namespace string) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "kube_apply")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create a temporary directory")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	manifestPath := filesystem.JoinPaths(tmpDir, kubernetesApplyManifestFile)
+	if err := filesystem.WriteToFile(manifestPath, manifest); err != nil {
+		return "", errors.Wrap(err, "failed to create a temporary manifest file")
+	}
+
+	return handler.KubernetesDeployer.Validate(userID, endpoint, []string{manifestPath}, namespace)
+}
+
+// parseKubectlApplyOutput extracts the result of each resource from the output of kubectl apply,
+// which reports one resource per line, e.g. "deployment.apps/my-app created (server dry run)"
+func parseKubectlApplyOutput(output string) []kubernetesApplyResult {
+	results := []kubernetesApplyResult{}
+
+	for _, line := range strings.Split(output, "\n") {
+		resource, result, found := strings.Cut(strings.TrimSpace(line), " ")
+		if !found || !strings.Contains(resource, "/") {
+			continue
+		}
+
+		results = append(results, kubernetesApplyResult{
+			Resource: resource,
+			Result:   strings.TrimSpace(strings.TrimSuffix(result, "(server dry run)")),
+		})
+	}
+
+	return results
+}
@@ -0,0 +1,50 @@
+package stacks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKubectlApplyOutput(t *testing.T) {
+	output := `namespace/demo unchanged
+deployment.apps/web created (server dry run)
+service/web configured
+
+Warning: resource is deprecated
+`
+
+	results := parseKubectlApplyOutput(output)
+
+	assert.Equal(t, []kubernetesApplyResult{
+		{Resource: "namespace/demo", Result: "unchanged"},
+		{Resource: "deployment.apps/web", Result: "created"},
+		{Resource: "service/web", Result: "configured"},
+	}, results)
+}
+
+func TestKubernetesApplyPayloadValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload kubernetesApplyPayload
+		wantErr bool
+	}{
+		{name: "manifest content", payload: kubernetesApplyPayload{StackName: "web", StackFileContent: "kind: Pod"}},
+		{name: "git repository", payload: kubernetesApplyPayload{StackName: "web", RepositoryURL: "https://github.com/portainer/portainer", ManifestFile: "web.yml"}},
+		{name: "missing name", payload: kubernetesApplyPayload{StackFileContent: "kind: Pod"}, wantErr: true},
+		{name: "missing manifest", payload: kubernetesApplyPayload{StackName: "web"}, wantErr: true},
+		{name: "missing manifest file", payload: kubernetesApplyPayload{StackName: "web", RepositoryURL: "https://github.com/portainer/portainer"}, wantErr: true},
+		{name: "missing password", payload: kubernetesApplyPayload{StackName: "web", RepositoryURL: "https://github.com/portainer/portainer", ManifestFile: "web.yml", RepositoryAuthentication: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.payload.Validate(nil)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
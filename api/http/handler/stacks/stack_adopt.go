@@ -0,0 +1,252 @@
+package stacks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+type stackAdoptPayload struct {
+	// Name of the compose project to adopt, which is the name of the stack
+	ProjectName string `example:"wordpress" validate:"required"`
+	// Content of the Stack file of the project, generated from the configuration of its containers when empty
+	StackFileContent string `example:"services:\n  web:\n    image: nginx"`
+	// A list of environment variables used by the Stack file
+	Env []portainer.Pair
+}
+
+func (payload *stackAdoptPayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.ProjectName) {
+		return errors.New("Invalid project name")
+	}
+
+	return nil
+}
+
+// @id StackAdoptableList
+// @summary List the compose projects which can be adopted as stacks
+// @description List the compose projects deployed on an environment outside of Portainer, found from the labels of their containers.
+// @description **Access policy**: administrator
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param endpointId query int true "Environment identifier"
+// @success 200 {array} stackutils.ComposeProject "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment not found"
+// @failure 500 "Server error"
+// @router /stacks/adoptable [get]
+func (handler *Handler) stackAdoptableList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpoint, httpErr := handler.adoptionEndpoint(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	cli, err := handler.DockerClientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return httperror.InternalServerError("Unable to connect to the Docker environment", err)
+	}
+	defer cli.Close()
+
+	containers, err := cli.ContainerList(r.Context(), types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", stackutils.ComposeProjectLabel)),
+	})
+	if err != nil {
+		return httperror.InternalServerError("Unable to list the containers of the environment", err)
+	}
+
+	projects := []stackutils.ComposeProject{}
+	for _, project := range stackutils.ComposeProjects(containers) {
+		isUnique, err := handler.checkUniqueStackName(endpoint, project.Name, 0)
+		if err != nil {
+			return httperror.InternalServerError("Unable to retrieve the stacks from the database", err)
+		}
+
+		if isUnique {
+			projects = append(projects, project)
+		}
+	}
+
+	return response.JSON(w, projects)
+}
+
+// @id StackAdopt
+// @summary Adopt a compose project as a stack
+// @description Create a stack from a compose project deployed on an environment outside of Portainer, without redeploying its containers.
+// @description The Stack file is generated from the configuration of the containers unless it is provided, and should be reviewed before the stack is updated.
+// @description **Access policy**: administrator
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param endpointId query int true "Environment identifier"
+// @param body body stackAdoptPayload true "Project to adopt"
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment or compose project not found"
+// @failure 409 "A stack with the same name already exists"
+// @failure 500 "Server error"
+// @router /stacks/adopt [post]
+func (handler *Handler) stackAdopt(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload stackAdoptPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	endpoint, httpErr := handler.adoptionEndpoint(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	user, err := handler.DataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to load user information from the database", err)
+	}
+
+	handler.stackCreationMutex.Lock()
+	defer handler.stackCreationMutex.Unlock()
+
+	isUnique, err := handler.checkUniqueStackName(endpoint, payload.ProjectName, 0)
+	if err != nil {
+		return httperror.InternalServerError("Unable to check for name collision", err)
+	}
+
+	if !isUnique {
+		return stackExistsError(payload.ProjectName)
+	}
+
+	cli, err := handler.DockerClientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return httperror.InternalServerError("Unable to connect to the Docker environment", err)
+	}
+	defer cli.Close()
+
+	containers, err := cli.ContainerList(r.Context(), types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", stackutils.ComposeProjectLabel+"="+payload.ProjectName)),
+	})
+	if err != nil {
+		return httperror.InternalServerError("Unable to list the containers of the environment", err)
+	}
+
+	projects := stackutils.ComposeProjects(containers)
+	if len(projects) == 0 {
+		return httperror.NotFound("Unable to find the compose project on the environment", fmt.Errorf("no container found for the compose project %s", payload.ProjectName))
+	}
+
+	fileContent := []byte(payload.StackFileContent)
+	if govalidator.IsNull(payload.StackFileContent) {
+		fileContent, err = composeFileOf(r.Context(), cli, payload.ProjectName, containers)
+		if err != nil {
+			return httperror.InternalServerError("Unable to generate the Stack file of the compose project", err)
+		}
+	}
+
+	status := portainer.StackStatusInactive
+	if projects[0].Running > 0 {
+		status = portainer.StackStatusActive
+	}
+
+	stack := &portainer.Stack{
+		ID:           portainer.StackID(handler.DataStore.Stack().GetNextIdentifier()),
+		Name:         payload.ProjectName,
+		Type:         portainer.DockerComposeStack,
+		EndpointID:   endpoint.ID,
+		EntryPoint:   filesystem.ComposeFileDefaultName,
+		Env:          payload.Env,
+		Status:       status,
+		CreationDate: time.Now().Unix(),
+		CreatedBy:    user.Username,
+	}
+
+	stack.ProjectPath, err = handler.FileService.StoreStackFileFromBytes(strconv.Itoa(int(stack.ID)), stack.EntryPoint, fileContent)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist Compose file on disk", err)
+	}
+
+	if err := handler.DataStore.Stack().Create(stack); err != nil {
+		if err := handler.FileService.RemoveDirectory(stack.ProjectPath); err != nil {
+			log.Warn().Err(err).Int("stack_id", int(stack.ID)).Msg("unable to remove the files of the adopted stack")
+		}
+
+		return httperror.InternalServerError("Unable to persist the stack inside the database", err)
+	}
+
+	return handler.decorateStackResponse(w, stack, securityContext.UserID)
+}
+
+// adoptionEndpoint returns the Docker environment whose compose projects are adopted
+func (handler *Handler) adoptionEndpoint(r *http.Request) (*portainer.Endpoint, *httperror.HandlerError) {
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", false)
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid query parameter: endpointId", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if !endpointutils.IsDockerEndpoint(endpoint) {
+		return nil, httperror.BadRequest("The compose projects can only be adopted on Docker environments", errors.New("not a Docker environment"))
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return nil, httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	return endpoint, nil
+}
+
+// composeFileOf generates the Stack file of a compose project from the configuration of its containers and images
+func composeFileOf(ctx context.Context, cli *client.Client, project string, containers []types.Container) ([]byte, error) {
+	composeContainers := make([]stackutils.ComposeContainer, 0, len(containers))
+
+	for _, c := range containers {
+		container, err := cli.ContainerInspect(ctx, c.ID)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "unable to inspect the container %s", c.ID)
+		}
+
+		composeContainer := stackutils.ComposeContainer{Container: container}
+
+		// the image may have been removed since the container was created, its defaults are then kept in the file
+		if image, _, err := cli.ImageInspectWithRaw(ctx, container.Image); err == nil {
+			composeContainer.ImageConfig = image.Config
+		} else if !client.IsErrNotFound(err) {
+			return nil, errors.WithMessagef(err, "unable to inspect the image of the container %s", c.ID)
+		}
+
+		composeContainers = append(composeContainers, composeContainer)
+	}
+
+	return stackutils.ComposeFile(project, composeContainers)
+}
@@ -108,9 +108,11 @@ func (handler *Handler) createKubernetesStack(w http.ResponseWriter, r *http.Req
 		return handler.createKubernetesStackFromGitRepository(w, r, endpoint, userID)
 	case "url":
 		return handler.createKubernetesStackFromManifestURL(w, r, endpoint, userID)
+	case "application":
+		return handler.createKubernetesStackFromApplication(w, r, endpoint, userID)
 	}
 
-	return httperror.BadRequest("Invalid value for query parameter: method. Value must be one of: string or repository", errors.New(request.ErrInvalidQueryParameter))
+	return httperror.BadRequest("Invalid value for query parameter: method. Value must be one of: string, repository, url or application", errors.New(request.ErrInvalidQueryParameter))
 }
 
 func (handler *Handler) decorateStackResponse(w http.ResponseWriter, stack *portainer.Stack, userID portainer.UserID) *httperror.HandlerError {
@@ -0,0 +1,166 @@
+package stacks
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/git"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/pkg/errors"
+)
+
+// @id StackDriftInspect
+// @summary Inspect the drift of a git stack
+// @description Retrieve the differences of a git stack with the head of its repository and with the services deployed on its environment.
+// @description The result of the last periodic check is returned unless a new check is requested.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @param refresh query boolean false "Check the stack again instead of returning the result of the last check"
+// @success 200 {object} portainer.StackDrift "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/drift [get]
+func (handler *Handler) stackDriftInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	refresh, _ := request.RetrieveBooleanQueryParameter(r, "refresh", true)
+
+	stack, _, _, httpErr := handler.authorizedGitStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	drift, ok := handler.DriftService.Drift(stack.ID)
+	if !ok || refresh {
+		drift = handler.DriftService.Check(r.Context(), stack)
+	}
+
+	return response.JSON(w, drift)
+}
+
+// @id StackDriftReconcile
+// @summary Reconcile a git stack with its repository
+// @description Pull the stack files from the head of the repository and redeploy the stack with its current settings, whether the repository changed or the deployment drifted.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/drift/reconcile [post]
+func (handler *Handler) stackDriftReconcile(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stack, endpoint, securityContext, httpErr := handler.authorizedGitStack(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if stack.FromAppTemplate {
+		return httperror.BadRequest("The stacks deployed from an app template cannot be updated from their repository", errors.New("stack deployed from an app template"))
+	}
+
+	username, password, err := git.GetCredentials(stack.GitConfig.Authentication)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the credentials of the repository", err)
+	}
+
+	if httpErr := handler.redeployFromGit(r, stack, endpoint, username, password, false, securityContext.UserID); httpErr != nil {
+		return httpErr
+	}
+
+	drift := handler.DriftService.Check(r.Context(), stack)
+	stack.Drift = &drift
+
+	if stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
+		// sanitize password in the http response to minimise possible security leaks
+		stack.GitConfig.Authentication.Password = ""
+	}
+
+	return response.JSON(w, stack)
+}
+
+// authorizedGitStack returns the git stack of the request along with its environment, when the user can manage it
+func (handler *Handler) authorizedGitStack(r *http.Request) (*portainer.Stack, *portainer.Endpoint, *security.RestrictedRequestContext, *httperror.HandlerError) {
+	stackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, nil, nil, httperror.BadRequest("Invalid stack identifier route variable", err)
+	}
+
+	stack, err := handler.DataStore.Stack().Read(portainer.StackID(stackID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, nil, nil, httperror.NotFound("Unable to find a stack with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, nil, nil, httperror.InternalServerError("Unable to find a stack with the specified identifier inside the database", err)
+	}
+
+	if stack.GitConfig == nil {
+		return nil, nil, nil, httperror.BadRequest("Stack is not created from git", errors.New("stack is not created from git"))
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(stack.EndpointID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, nil, nil, httperror.NotFound("Unable to find the environment associated to the stack inside the database", err)
+	} else if err != nil {
+		return nil, nil, nil, httperror.InternalServerError("Unable to find the environment associated to the stack inside the database", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return nil, nil, nil, httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return nil, nil, nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	if stack.Type == portainer.DockerSwarmStack || stack.Type == portainer.DockerComposeStack {
+		resourceControl, err := handler.DataStore.ResourceControl().ResourceControlByResourceIDAndType(stackutils.ResourceControlID(stack.EndpointID, stack.Name), portainer.StackResourceControl)
+		if err != nil {
+			return nil, nil, nil, httperror.InternalServerError("Unable to retrieve a resource control associated to the stack", err)
+		}
+
+		access, err := handler.userCanAccessStack(securityContext, endpoint.ID, resourceControl)
+		if err != nil {
+			return nil, nil, nil, httperror.InternalServerError("Unable to verify user authorizations to validate stack access", err)
+		}
+		if !access {
+			return nil, nil, nil, httperror.Forbidden("Access denied to resource", httperrors.ErrResourceAccessDenied)
+		}
+	}
+
+	canManage, err := handler.userCanManageStacks(securityContext, endpoint)
+	if err != nil {
+		return nil, nil, nil, httperror.InternalServerError("Unable to verify user authorizations to validate stack management", err)
+	}
+	if !canManage {
+		errMsg := "Stack management is disabled for non-admin users"
+		return nil, nil, nil, httperror.Forbidden(errMsg, errors.New(errMsg))
+	}
+
+	return stack, endpoint, securityContext, nil
+}
+
+// decorateStackDrift sets the result of the last drift check of a git stack
+func (handler *Handler) decorateStackDrift(stack *portainer.Stack) {
+	if stack.GitConfig == nil || handler.DriftService == nil {
+		return
+	}
+
+	if drift, ok := handler.DriftService.Drift(stack.ID); ok {
+		stack.Drift = &drift
+	}
+}
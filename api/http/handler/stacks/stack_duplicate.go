@@ -0,0 +1,257 @@
+package stacks
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/filesystem"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type stackDuplicatePayload struct {
+	// Environment(Endpoint) identifier of the environment(endpoint) where the copy will be deployed
+	EndpointID int `example:"2" validate:"required"`
+	// Swarm cluster identifier, must match the identifier of the cluster where the copy will be deployed
+	SwarmID string `example:"jpofkc0i9uo9wtx1zesuk649w"`
+	// Name of the copy, defaults to the name of the stack
+	Name string `example:"new-stack"`
+	// Environment variables overriding the ones of the stack, by name
+	Env []portainer.Pair
+}
+
+func (payload *stackDuplicatePayload) Validate(r *http.Request) error {
+	if payload.EndpointID == 0 {
+		return errors.New("Invalid environment identifier. Must be a positive number")
+	}
+
+	return nil
+}
+
+// @id StackDuplicate
+// @summary Duplicate a stack to an environment(endpoint)
+// @description Deploy a copy of a stack to an environment(endpoint), which can be the environment(endpoint) of the stack
+// @description when the copy is renamed. The files of the stack are copied, and its environment variables can be overridden.
+// @description The copy is not updated automatically from its git repository, and the source stack is recorded in its lineage.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Stack identifier"
+// @param body body stackDuplicatePayload true "Stack duplication details"
+// @param Idempotency-Key header string false "Key identifying the retries of the request, whose response is replayed for 24 hours"
+// @success 200 {object} portainer.Stack "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 409 "A stack with the same name already exists on the environment, or a request with the same idempotency key is in progress"
+// @failure 422 "The idempotency key was used for a different request"
+// @failure 500 "Server error"
+// @router /stacks/{id}/duplicate [post]
+func (handler *Handler) stackDuplicate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid stack identifier route variable", err)
+	}
+
+	var payload stackDuplicatePayload
+	err = request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	stack, err := handler.DataStore.Stack().Read(portainer.StackID(stackID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a stack with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a stack with the specified identifier inside the database", err)
+	}
+
+	if stack.Type == portainer.KubernetesStack {
+		return httperror.BadRequest("Duplicating a kubernetes stack is not supported", errors.New("unsupported stack type"))
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(stack.EndpointID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an endpoint with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an endpoint with the specified identifier inside the database", err)
+	}
+
+	err = handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint)
+	if err != nil {
+		return httperror.Forbidden("Permission denied to access endpoint", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	resourceControl, err := handler.DataStore.ResourceControl().ResourceControlByResourceIDAndType(stackutils.ResourceControlID(stack.EndpointID, stack.Name), portainer.StackResourceControl)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve a resource control associated to the stack", err)
+	}
+
+	access, err := handler.userCanAccessStack(securityContext, endpoint.ID, resourceControl)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify user authorizations to validate stack access", err)
+	}
+	if !access {
+		return httperror.Forbidden("Access denied to resource", httperrors.ErrResourceAccessDenied)
+	}
+
+	targetEndpoint, err := handler.DataStore.Endpoint().Endpoint(portainer.EndpointID(payload.EndpointID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an endpoint with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an endpoint with the specified identifier inside the database", err)
+	}
+
+	httpErr := handler.authorizeStackTarget(r, securityContext, targetEndpoint)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	user, err := handler.DataStore.User().Read(securityContext.UserID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to load user information from the database", err)
+	}
+
+	duplicate := *stack
+	duplicate.ID = portainer.StackID(handler.DataStore.Stack().GetNextIdentifier())
+	duplicate.EndpointID = targetEndpoint.ID
+	duplicate.Env = mergeStackEnv(stack.Env, payload.Env)
+	duplicate.ResourceControl = nil
+	duplicate.AutoUpdate = nil
+	duplicate.Status = portainer.StackStatusActive
+	duplicate.CreationDate = time.Now().Unix()
+	duplicate.CreatedBy = user.Username
+	duplicate.UpdateDate = 0
+	duplicate.UpdatedBy = ""
+	duplicate.Lineage = append(append([]portainer.StackLineageEntry{}, stack.Lineage...), portainer.StackLineageEntry{
+		StackID:    stack.ID,
+		EndpointID: stack.EndpointID,
+		Operation:  portainer.StackLineageDuplicate,
+		Date:       duplicate.CreationDate,
+	})
+
+	if payload.SwarmID != "" {
+		duplicate.SwarmID = payload.SwarmID
+	}
+
+	if payload.Name != "" {
+		duplicate.Name = payload.Name
+	}
+
+	if stack.GitConfig != nil {
+		gitConfig := *stack.GitConfig
+		duplicate.GitConfig = &gitConfig
+	}
+
+	isUnique, err := handler.checkUniqueStackNameInDocker(targetEndpoint, duplicate.Name, 0, duplicate.Type == portainer.DockerSwarmStack)
+	if err != nil {
+		return httperror.InternalServerError("Unable to check for name collision", err)
+	}
+
+	if !isUnique {
+		errorMessage := fmt.Sprintf("A stack with the name '%s' is already running on endpoint '%s'", duplicate.Name, targetEndpoint.Name)
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: errorMessage, Err: errors.New(errorMessage)}
+	}
+
+	duplicate.ProjectPath = handler.FileService.GetStackProjectPath(strconv.Itoa(int(duplicate.ID)))
+
+	err = filesystem.CopyDir(stack.ProjectPath, duplicate.ProjectPath, false)
+	if err != nil {
+		return httperror.InternalServerError("Unable to copy the stack files", err)
+	}
+
+	httpErr = handler.migrateStack(r, &duplicate, targetEndpoint)
+	if httpErr != nil {
+		handler.FileService.RemoveDirectory(duplicate.ProjectPath)
+		return httpErr
+	}
+
+	err = handler.DataStore.Stack().Create(&duplicate)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the stack inside the database", err)
+	}
+
+	handler.publishStackDeployed(&duplicate)
+
+	if resourceControl == nil {
+		return handler.decorateStackResponse(w, &duplicate, securityContext.UserID)
+	}
+
+	// the copy is shared with the same users and teams as the source stack
+	duplicateResourceControl := *resourceControl
+	duplicateResourceControl.ID = 0
+	duplicateResourceControl.ResourceID = stackutils.ResourceControlID(duplicate.EndpointID, duplicate.Name)
+
+	err = handler.DataStore.ResourceControl().Create(&duplicateResourceControl)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist resource control inside the database", err)
+	}
+
+	duplicate.ResourceControl = &duplicateResourceControl
+
+	if duplicate.GitConfig != nil && duplicate.GitConfig.Authentication != nil && duplicate.GitConfig.Authentication.Password != "" {
+		// sanitize password in the http response to minimise possible security leaks
+		authentication := *duplicate.GitConfig.Authentication
+		authentication.Password = ""
+		duplicate.GitConfig.Authentication = &authentication
+	}
+
+	return response.JSON(w, duplicate)
+}
+
+// authorizeStackTarget checks that the user can deploy stacks to the environment a stack is duplicated or migrated to
+func (handler *Handler) authorizeStackTarget(r *http.Request, securityContext *security.RestrictedRequestContext, targetEndpoint *portainer.Endpoint) *httperror.HandlerError {
+	err := handler.requestBouncer.AuthorizedEndpointOperation(r, targetEndpoint)
+	if err != nil {
+		return httperror.Forbidden("Permission denied to access the target environment", err)
+	}
+
+	canManage, err := handler.userCanManageStacks(securityContext, targetEndpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify user authorizations to validate stack management", err)
+	}
+	if !canManage {
+		errMsg := "Stack management is disabled for non-admin users on the target environment"
+		return httperror.Forbidden(errMsg, errors.New(errMsg))
+	}
+
+	return nil
+}
+
+// mergeStackEnv returns the environment variables of a stack with the overridden ones replaced, and the new ones added
+func mergeStackEnv(env []portainer.Pair, overrides []portainer.Pair) []portainer.Pair {
+	merged := make([]portainer.Pair, 0, len(env)+len(overrides))
+	merged = append(merged, env...)
+
+	for _, override := range overrides {
+		found := false
+		for i := range merged {
+			if merged[i].Name == override.Name {
+				merged[i].Value = override.Value
+				found = true
+			}
+		}
+
+		if !found {
+			merged = append(merged, override)
+		}
+	}
+
+	return merged
+}
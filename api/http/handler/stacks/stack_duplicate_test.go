@@ -0,0 +1,44 @@
+package stacks
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeStackEnv(t *testing.T) {
+	env := []portainer.Pair{{Name: "HOST", Value: "db"}, {Name: "PORT", Value: "5432"}}
+
+	merged := mergeStackEnv(env, []portainer.Pair{{Name: "PORT", Value: "5433"}, {Name: "USER", Value: "admin"}})
+
+	assert.Equal(t, []portainer.Pair{
+		{Name: "HOST", Value: "db"},
+		{Name: "PORT", Value: "5433"},
+		{Name: "USER", Value: "admin"},
+	}, merged)
+	assert.Equal(t, "5432", env[1].Value, "the environment variables of the source stack must not be modified")
+
+	assert.Empty(t, mergeStackEnv(nil, nil))
+}
+
+func TestFilterStacksBySource(t *testing.T) {
+	stacks := []portainer.Stack{
+		{ID: 1},
+		{ID: 2, Lineage: []portainer.StackLineageEntry{{StackID: 1, Operation: portainer.StackLineageDuplicate}}},
+		{ID: 3, Lineage: []portainer.StackLineageEntry{
+			{StackID: 1, Operation: portainer.StackLineageDuplicate},
+			{StackID: 2, Operation: portainer.StackLineageDuplicate},
+		}},
+	}
+
+	assert.Len(t, filterStacksBySource(stacks, 0), 3)
+
+	filtered := filterStacksBySource(stacks, 2)
+	assert.Len(t, filtered, 1)
+	assert.Equal(t, portainer.StackID(3), filtered[0].ID)
+
+	assert.Len(t, filterStacksBySource(stacks, 1), 2)
+	assert.Empty(t, filterStacksBySource(stacks, 3))
+}
@@ -6,6 +6,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/notes"
 	"github.com/portainer/portainer/api/stacks/stackutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
@@ -96,5 +97,12 @@ func (handler *Handler) stackInspect(w http.ResponseWriter, r *http.Request) *ht
 		stack.GitConfig.Authentication.Password = ""
 	}
 
+	stackNotes, err := handler.DataStore.Note().NotesByEndpoint(stack.EndpointID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the notes of the stack from the database", err)
+	}
+	stack.Notes = notes.NewIndex(stackNotes).Stack(stack.ID)
+	handler.decorateStackDrift(stack)
+
 	return response.JSON(w, stack)
 }
@@ -0,0 +1,80 @@
+package stacks
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/pkg/errors"
+)
+
+type stackLintPayload struct {
+	// Content of the compose file
+	StackFileContent string `example:"version: 3\n services:\n web:\n image:nginx" validate:"required"`
+	// Type of the stack the compose file is deployed as (1 - 'Swarm stack', 2 - 'Compose stack')
+	Type portainer.StackType `example:"2" enums:"1,2" validate:"required"`
+	// Environment variables used to interpolate the compose file
+	Env []portainer.Pair
+}
+
+func (payload *stackLintPayload) Validate(r *http.Request) error {
+	if len(payload.StackFileContent) == 0 {
+		return errors.New("Invalid stack file content")
+	}
+
+	if payload.Type != portainer.DockerSwarmStack && payload.Type != portainer.DockerComposeStack {
+		return errors.New("Invalid stack type. Must be 1 (Swarm stack) or 2 (Compose stack)")
+	}
+
+	return nil
+}
+
+type stackLintResponse struct {
+	// Whether the compose file can be deployed, which is the case when no issue is an error
+	Valid bool `json:"Valid" example:"true"`
+	// Issues found in the compose file
+	Issues []stackutils.LintIssue `json:"Issues"`
+}
+
+// @id StackLint
+// @summary Lint a compose file
+// @description Check the syntax and the schema of a compose file, and the policy checks configured in the settings,
+// @description before deploying it as a stack. The issues are reported with their line in the compose file.
+// @description **Access policy**: authenticated
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body stackLintPayload true "Compose file to lint"
+// @success 200 {object} stackLintResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /stacks/lint [post]
+func (handler *Handler) stackLint(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload stackLintPayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	issues := stackutils.LintComposeFile([]byte(payload.StackFileContent), payload.Env, payload.Type, settings.StackPolicy)
+
+	valid := true
+	for _, issue := range issues {
+		if issue.Severity == stackutils.LintSeverityError {
+			valid = false
+		}
+	}
+
+	return response.JSON(w, stackLintResponse{Valid: valid, Issues: issues})
+}
@@ -2,11 +2,13 @@ package stacks
 
 import (
 	"net/http"
+	"slices"
 
 	portainer "github.com/portainer/portainer/api"
 	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/internal/notes"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -16,6 +18,7 @@ type stackListOperationFilters struct {
 	SwarmID               string `json:"SwarmID"`
 	EndpointID            int    `json:"EndpointID"`
 	IncludeOrphanedStacks bool   `json:"IncludeOrphanedStacks"`
+	SourceStackID         int    `json:"SourceStackID"`
 }
 
 // @id StackList
@@ -27,7 +30,7 @@ type stackListOperationFilters struct {
 // @tags stacks
 // @security ApiKeyAuth
 // @security jwt
-// @param filters query string false "Filters to process on the stack list. Encoded as JSON (a map[string]string). For example, {'SwarmID': 'jpofkc0i9uo9wtx1zesuk649w'} will only return stacks that are part of the specified Swarm cluster. Available filters: EndpointID, SwarmID."
+// @param filters query string false "Filters to process on the stack list. Encoded as JSON (a map[string]string). For example, {'SwarmID': 'jpofkc0i9uo9wtx1zesuk649w'} will only return stacks that are part of the specified Swarm cluster. Available filters: EndpointID, SwarmID, SourceStackID (the stacks duplicated or migrated from the stack)."
 // @success 200 {array} portainer.Stack "Success"
 // @success 204 "Success"
 // @failure 400 "Invalid request"
@@ -50,6 +53,7 @@ func (handler *Handler) stackList(w http.ResponseWriter, r *http.Request) *httpe
 		return httperror.InternalServerError("Unable to retrieve stacks from the database", err)
 	}
 	stacks = filterStacks(stacks, &filters, endpoints)
+	stacks = filterStacksBySource(stacks, portainer.StackID(filters.SourceStackID))
 
 	resourceControls, err := handler.DataStore.ResourceControl().ReadAll()
 	if err != nil {
@@ -81,6 +85,17 @@ func (handler *Handler) stackList(w http.ResponseWriter, r *http.Request) *httpe
 		stacks = authorization.FilterAuthorizedStacks(stacks, user, userTeamIDs)
 	}
 
+	allNotes, err := handler.DataStore.Note().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the notes from the database", err)
+	}
+	noteIndex := notes.NewIndex(allNotes)
+
+	for i := range stacks {
+		stacks[i].Notes = noteIndex.Stack(stacks[i].ID)
+		handler.decorateStackDrift(&stacks[i])
+	}
+
 	for _, stack := range stacks {
 		if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
 			// sanitize password in the http response to minimise possible security leaks
@@ -116,6 +131,24 @@ func filterStacks(stacks []portainer.Stack, filters *stackListOperationFilters,
 	return filteredStacks
 }
 
+// filterStacksBySource returns the stacks duplicated or migrated from the source stack, or all the stacks when no source is set
+func filterStacksBySource(stacks []portainer.Stack, sourceStackID portainer.StackID) []portainer.Stack {
+	if sourceStackID == 0 {
+		return stacks
+	}
+
+	filteredStacks := make([]portainer.Stack, 0, len(stacks))
+	for _, stack := range stacks {
+		if slices.ContainsFunc(stack.Lineage, func(entry portainer.StackLineageEntry) bool {
+			return entry.StackID == sourceStackID
+		}) {
+			filteredStacks = append(filteredStacks, stack)
+		}
+	}
+
+	return filteredStacks
+}
+
 func isOrphanedStack(stack portainer.Stack, endpoints []portainer.Endpoint) bool {
 	for _, endpoint := range endpoints {
 		if stack.EndpointID == endpoint.ID {
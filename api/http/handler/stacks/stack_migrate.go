@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	httperrors "github.com/portainer/portainer/api/http/errors"
@@ -22,6 +23,8 @@ type stackMigratePayload struct {
 	SwarmID string `example:"jpofkc0i9uo9wtx1zesuk649w"`
 	// If provided will rename the migrated stack
 	Name string `example:"new-stack"`
+	// Environment variables overriding the ones of the stack, by name
+	Env []portainer.Pair
 }
 
 func (payload *stackMigratePayload) Validate(r *http.Request) error {
@@ -34,6 +37,7 @@ func (payload *stackMigratePayload) Validate(r *http.Request) error {
 // @id StackMigrate
 // @summary Migrate a stack to another environment(endpoint)
 // @description  Migrate a stack from an environment(endpoint) to another environment(endpoint). It will re-create the stack inside the target environment(endpoint) before removing the original stack.
+// @description The source environment(endpoint) is recorded in the lineage of the stack.
 // @description **Access policy**: authenticated
 // @tags stacks
 // @security ApiKeyAuth
@@ -128,7 +132,14 @@ func (handler *Handler) stackMigrate(w http.ResponseWriter, r *http.Request) *ht
 		return httperror.InternalServerError("Unable to find an endpoint with the specified identifier inside the database", err)
 	}
 
+	httpErr := handler.authorizeStackTarget(r, securityContext, targetEndpoint)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	sourceEndpointID := stack.EndpointID
 	stack.EndpointID = portainer.EndpointID(payload.EndpointID)
+	stack.Env = mergeStackEnv(stack.Env, payload.Env)
 	if payload.SwarmID != "" {
 		stack.SwarmID = payload.SwarmID
 	}
@@ -161,11 +172,20 @@ func (handler *Handler) stackMigrate(w http.ResponseWriter, r *http.Request) *ht
 	}
 
 	stack.Name = newName
+	stack.Lineage = append(stack.Lineage, portainer.StackLineageEntry{
+		StackID:    stack.ID,
+		EndpointID: sourceEndpointID,
+		Operation:  portainer.StackLineageMigrate,
+		Date:       time.Now().Unix(),
+	})
+
 	err = handler.DataStore.Stack().Update(stack.ID, stack)
 	if err != nil {
 		return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
 	}
 
+	handler.publishStackDeployed(stack)
+
 	if resourceControl != nil {
 		resourceControl.ResourceID = stackutils.ResourceControlID(stack.EndpointID, stack.Name)
 		err := handler.DataStore.ResourceControl().Update(resourceControl.ID, resourceControl)
@@ -211,7 +231,7 @@ func (handler *Handler) migrateComposeStack(r *http.Request, stack *portainer.St
 	// Deploy the stack
 	err = composeDeploymentConfig.Deploy()
 	if err != nil {
-		return httperror.InternalServerError(err.Error(), err)
+		return stackutils.DeploymentError(err)
 	}
 	return nil
 }
@@ -238,7 +258,7 @@ func (handler *Handler) migrateSwarmStack(r *http.Request, stack *portainer.Stac
 	// Deploy the stack
 	err = swarmDeploymentConfig.Deploy()
 	if err != nil {
-		return httperror.InternalServerError(err.Error(), err)
+		return stackutils.DeploymentError(err)
 	}
 
 	return nil
@@ -0,0 +1,61 @@
+package stacks
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackStop_ChangeApproval(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	user := &portainer.User{Username: "standard", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	endpoint := &portainer.Endpoint{
+		ID:                 1,
+		Name:               "production",
+		GroupID:            1,
+		Type:               portainer.DockerEnvironment,
+		UserAccessPolicies: portainer.UserAccessPolicies{user.ID: {}},
+		ChangeApproval:     &portainer.EndpointChangeApproval{Enabled: true},
+	}
+	is.NoError(store.Endpoint().Create(endpoint))
+
+	stack := &portainer.Stack{ID: 1, Name: "web", Type: portainer.DockerComposeStack, EndpointID: endpoint.ID, Status: portainer.StackStatusActive}
+	is.NoError(store.Stack().Create(stack))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+
+	h := NewHandler(requestBouncer)
+	h.DataStore = store
+
+	token, err := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+	is.NoError(err)
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/stacks/%d/stop?endpointId=%d", stack.ID, endpoint.ID), nil)
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	is.Equal(http.StatusForbidden, rr.Code, "the stacks of a protected environment cannot be stopped without an approval")
+
+	stack, err = store.Stack().Read(stack.ID)
+	is.NoError(err)
+	is.Equal(portainer.StackStatusActive, stack.Status)
+}
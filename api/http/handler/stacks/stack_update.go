@@ -156,6 +156,8 @@ func (handler *Handler) stackUpdate(w http.ResponseWriter, r *http.Request) *htt
 		return httperror.InternalServerError("Unable to persist the stack changes inside the database", err)
 	}
 
+	handler.publishStackDeployed(stack)
+
 	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
 		// sanitize password in the http response to minimise possible security leaks
 		stack.GitConfig.Authentication.Password = ""
@@ -242,7 +244,7 @@ func (handler *Handler) updateComposeStack(r *http.Request, stack *portainer.Sta
 			log.Warn().Err(rollbackErr).Msg("rollback stack file error")
 		}
 
-		return httperror.InternalServerError(err.Error(), err)
+		return stackutils.DeploymentError(err)
 	}
 
 	handler.FileService.RemoveStackFileBackup(stackFolder, stack.EntryPoint)
@@ -312,7 +314,7 @@ func (handler *Handler) updateSwarmStack(r *http.Request, stack *portainer.Stack
 			log.Warn().Err(rollbackErr).Msg("rollback stack file error")
 		}
 
-		return httperror.InternalServerError(err.Error(), err)
+		return stackutils.DeploymentError(err)
 	}
 
 	handler.FileService.RemoveStackFileBackup(stackFolder, stack.EntryPoint)
@@ -149,6 +149,21 @@ func (handler *Handler) stackGitRedeploy(w http.ResponseWriter, r *http.Request)
 		repositoryUsername = payload.RepositoryUsername
 	}
 
+	if httpErr := handler.redeployFromGit(r, stack, endpoint, repositoryUsername, repositoryPassword, payload.PullImage, securityContext.UserID); httpErr != nil {
+		return httpErr
+	}
+
+	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
+		// sanitize password in the http response to minimise possible security leaks
+		stack.GitConfig.Authentication.Password = ""
+	}
+
+	return response.JSON(w, stack)
+}
+
+// redeployFromGit pulls the stack files from the repository and deploys them, the stack being updated with the
+// deployed commit
+func (handler *Handler) redeployFromGit(r *http.Request, stack *portainer.Stack, endpoint *portainer.Endpoint, repositoryUsername, repositoryPassword string, pullImage bool, userID portainer.UserID) *httperror.HandlerError {
 	cloneOptions := git.CloneOptions{
 		ProjectPath:   stack.ProjectPath,
 		URL:           stack.GitConfig.URL,
@@ -165,7 +180,7 @@ func (handler *Handler) stackGitRedeploy(w http.ResponseWriter, r *http.Request)
 
 	defer clean()
 
-	httpErr := handler.deployStack(r, stack, payload.PullImage, endpoint)
+	httpErr := handler.deployStack(r, stack, pullImage, endpoint)
 	if httpErr != nil {
 		return httpErr
 	}
@@ -176,7 +191,7 @@ func (handler *Handler) stackGitRedeploy(w http.ResponseWriter, r *http.Request)
 	}
 	stack.GitConfig.ConfigHash = newHash
 
-	user, err := handler.DataStore.User().Read(securityContext.UserID)
+	user, err := handler.DataStore.User().Read(userID)
 	if err != nil {
 		return httperror.BadRequest("Cannot find context user", errors.Wrap(err, "failed to fetch the user"))
 	}
@@ -189,12 +204,9 @@ func (handler *Handler) stackGitRedeploy(w http.ResponseWriter, r *http.Request)
 		return httperror.InternalServerError("Unable to persist the stack changes inside the database", errors.Wrap(err, "failed to update the stack"))
 	}
 
-	if stack.GitConfig != nil && stack.GitConfig.Authentication != nil && stack.GitConfig.Authentication.Password != "" {
-		// sanitize password in the http response to minimise possible security leaks
-		stack.GitConfig.Authentication.Password = ""
-	}
+	handler.publishStackDeployed(stack)
 
-	return response.JSON(w, stack)
+	return nil
 }
 
 func (handler *Handler) deployStack(r *http.Request, stack *portainer.Stack, pullImage bool, endpoint *portainer.Endpoint) *httperror.HandlerError {
@@ -263,7 +275,7 @@ func (handler *Handler) deployStack(r *http.Request, stack *portainer.Stack, pul
 
 	err = deploymentConfiger.Deploy()
 	if err != nil {
-		return httperror.InternalServerError(err.Error(), err)
+		return stackutils.DeploymentError(err)
 	}
 	return nil
 }
@@ -0,0 +1,134 @@
+package stacks
+
+import (
+	"context"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	"github.com/portainer/portainer/api/timeouts"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// @id StackUsage
+// @summary Inspect the resources used by a stack
+// @description Aggregate the CPU, memory and disk usage of the containers of a Docker stack, identified by their labels.
+// @description The usage is computed from the statistics of the running containers, or from the latest snapshot of the
+// @description environment when the source is snapshot or when the environment cannot be reached. The snapshots do not
+// @description hold the CPU and memory usage.
+// @description **Access policy**: restricted
+// @tags stacks
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Stack identifier"
+// @param source query string false "Source of the usage, live by default" Enums(live,snapshot)
+// @success 200 {object} docker.StackUsage "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "Stack not found"
+// @failure 500 "Server error"
+// @router /stacks/{id}/usage [get]
+func (handler *Handler) stackUsage(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	stackID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid stack identifier route variable", err)
+	}
+
+	source, _ := request.RetrieveQueryParameter(r, "source", true)
+	if source == "" {
+		source = docker.StackUsageSourceLive
+	}
+
+	if source != docker.StackUsageSourceLive && source != docker.StackUsageSourceSnapshot {
+		return httperror.BadRequest("Invalid source query parameter, it must be live or snapshot", errors.New("invalid source"))
+	}
+
+	stack, err := handler.DataStore.Stack().Read(portainer.StackID(stackID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a stack with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a stack with the specified identifier inside the database", err)
+	}
+
+	if stack.Type != portainer.DockerSwarmStack && stack.Type != portainer.DockerComposeStack {
+		return httperror.BadRequest("The resource usage is only available for the Docker stacks", errors.New("unsupported stack type"))
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(stack.EndpointID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	canManage, err := handler.userCanManageStacks(securityContext, endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify user authorizations to validate stack access", err)
+	}
+	if !canManage {
+		errMsg := "Stack management is disabled for non-admin users"
+		return httperror.Forbidden(errMsg, errors.New(errMsg))
+	}
+
+	err = handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint)
+	if err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	resourceControl, err := handler.DataStore.ResourceControl().ResourceControlByResourceIDAndType(stackutils.ResourceControlID(stack.EndpointID, stack.Name), portainer.StackResourceControl)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve a resource control associated to the stack", err)
+	}
+
+	access, err := handler.userCanAccessStack(securityContext, endpoint.ID, resourceControl)
+	if err != nil {
+		return httperror.InternalServerError("Unable to verify user authorizations to validate stack access", err)
+	}
+	if !access {
+		return httperror.Forbidden("Access denied to resource", httperrors.ErrResourceAccessDenied)
+	}
+
+	if source == docker.StackUsageSourceLive {
+		usage, err := handler.liveStackUsage(r.Context(), endpoint, stack)
+		if err == nil {
+			return response.JSON(w, usage)
+		}
+
+		log.Warn().Err(err).Int("stack_id", int(stack.ID)).Msg("unable to compute the live usage of the stack, falling back to the snapshot")
+	}
+
+	snapshot, err := handler.DataStore.Snapshot().Read(endpoint.ID)
+	if handler.DataStore.IsErrObjectNotFound(err) || (err == nil && snapshot.Docker == nil) {
+		return httperror.NotFound("Unable to find a snapshot of the environment", errors.New("snapshot not found"))
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the environment snapshot from the database", err)
+	}
+
+	return response.JSON(w, docker.SnapshotStackUsage(stack, snapshot))
+}
+
+func (handler *Handler) liveStackUsage(ctx context.Context, endpoint *portainer.Endpoint, stack *portainer.Stack) (*docker.StackUsage, error) {
+	timeout := timeouts.Current().Snapshot
+
+	cli, err := handler.DockerClientFactory.CreateClient(endpoint, "", &timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Close()
+
+	return docker.InspectStackUsage(ctx, cli, stack)
+}
@@ -0,0 +1,31 @@
+package system
+
+import (
+	"errors"
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id systemAPIUsage
+// @summary Retrieve the usage of the API
+// @description Retrieve the number of API requests since Portainer started and over each minute of the last hour, for the
+// @description whole instance and for each user, API key and unauthenticated IP address, to find the clients overloading
+// @description the server. The counts are kept in memory and reset when Portainer restarts. An api.usage_anomaly event is
+// @description published when a client sends many more requests than usual, if enabled in the API usage settings.
+// @description **Access policy**: administrator
+// @security ApiKeyAuth
+// @security jwt
+// @tags system
+// @produce json
+// @success 200 {object} apiusage.Report "Success"
+// @failure 500 "Server error"
+// @router /system/api-usage [get]
+func (handler *Handler) systemAPIUsage(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	if handler.APIUsageTracker == nil {
+		return httperror.InternalServerError("Unable to retrieve the API usage", errors.New("the API usage is not tracked"))
+	}
+
+	return response.JSON(w, handler.APIUsageTracker.Report())
+}
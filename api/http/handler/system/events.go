@@ -0,0 +1,203 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/database/changes"
+	"github.com/portainer/portainer/api/dataservices/edgestack"
+	"github.com/portainer/portainer/api/dataservices/endpoint"
+	"github.com/portainer/portainer/api/dataservices/endpointgroup"
+	"github.com/portainer/portainer/api/dataservices/resourcecontrol"
+	"github.com/portainer/portainer/api/dataservices/role"
+	"github.com/portainer/portainer/api/dataservices/stack"
+	"github.com/portainer/portainer/api/dataservices/team"
+	"github.com/portainer/portainer/api/dataservices/teammembership"
+	"github.com/portainer/portainer/api/dataservices/user"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/rs/zerolog/log"
+)
+
+// eventsKeepAliveInterval is the interval between the comments sent to keep the stream open through the proxies
+const eventsKeepAliveInterval = 30 * time.Second
+
+// eventTypes maps the database buckets to the type of the events published when they are modified
+var eventTypes = map[string]string{
+	endpoint.BucketName:        "endpoint",
+	endpointgroup.BucketName:   "endpoint_group",
+	stack.BucketName:           "stack",
+	edgestack.BucketName:       "edge_stack",
+	user.BucketName:            "user",
+	team.BucketName:            "team",
+	teammembership.BucketName:  "team_membership",
+	resourcecontrol.BucketName: "resource_control",
+	role.BucketName:            "role",
+}
+
+type systemEvent struct {
+	// Type of the modified resource
+	Type string `json:"type" example:"endpoint"`
+	// Modification applied to the resource, one of create, update or delete
+	Action string `json:"action" example:"update"`
+	// Identifier of the modified resource
+	ID string `json:"id" example:"1"`
+	// Unix timestamp of the modification
+	Time int64 `json:"time" example:"1700000000"`
+}
+
+// @id systemEvents
+// @summary Stream the resource changes
+// @description Open a server-sent events stream notifying the changes of the environments, environment groups, stacks, users, teams and access policies.
+// @description Each event is named after the type of the modified resource and carries its identifier, so that the clients can refresh it instead of polling the lists.
+// @description Non-administrators are only notified of the changes of the environments they can access and of their own user.
+// @description **Access policy**: authenticated
+// @security ApiKeyAuth
+// @security jwt
+// @tags system
+// @produce text/event-stream
+// @param types query []string false "Only stream the events of these types" collectionFormat(multi) Enums(endpoint, endpoint_group, stack, edge_stack, user, team, team_membership, resource_control, role)
+// @success 200 {object} systemEvent "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /system/events [get]
+func (handler *Handler) systemEvents(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	types := r.URL.Query()["types[]"]
+	for _, t := range types {
+		if !isEventType(t) {
+			return httperror.BadRequest("Invalid query parameter: types", fmt.Errorf("unknown event type: %s", t))
+		}
+	}
+
+	subscription, unsubscribe := changes.Subscribe()
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := rc.Flush(); err != nil {
+		return httperror.InternalServerError("Streaming is not supported", err)
+	}
+
+	shutdownCtx := handler.ShutdownCtx
+	if shutdownCtx == nil {
+		shutdownCtx = context.Background()
+	}
+
+	keepAlive := time.NewTicker(eventsKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+
+		case <-shutdownCtx.Done():
+			return nil
+
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+
+		case change := <-subscription:
+			eventType, ok := eventTypes[change.Bucket]
+			if !ok || (len(types) > 0 && !slices.Contains(types, eventType)) {
+				continue
+			}
+
+			if !securityContext.IsAdmin && !handler.authorizedEvent(securityContext.UserID, eventType, change) {
+				continue
+			}
+
+			if err := writeEvent(w, systemEvent{
+				Type:   eventType,
+				Action: string(change.Action),
+				ID:     change.Key,
+				Time:   change.Time.Unix(),
+			}); err != nil {
+				return nil
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return nil
+		}
+	}
+}
+
+// authorizedEvent returns true when a non-administrator user is allowed to be notified of the change, i.e. when it
+// modifies the user itself or an environment the user can access
+func (handler *Handler) authorizedEvent(userID portainer.UserID, eventType string, change changes.Change) bool {
+	switch eventType {
+	case "user":
+		return change.Key == strconv.Itoa(int(userID))
+
+	case "endpoint":
+		if change.Action == changes.ActionDelete {
+			return false
+		}
+
+		endpointID, err := strconv.Atoi(change.Key)
+		if err != nil {
+			return false
+		}
+
+		environment, err := handler.dataStore.Endpoint().Endpoint(portainer.EndpointID(endpointID))
+		if err != nil {
+			return false
+		}
+
+		group, err := handler.dataStore.EndpointGroup().Read(environment.GroupID)
+		if err != nil {
+			return false
+		}
+
+		memberships, err := handler.dataStore.TeamMembership().TeamMembershipsByUserID(userID)
+		if err != nil {
+			log.Warn().Err(err).Msg("unable to retrieve the user team memberships")
+			return false
+		}
+
+		return security.AuthorizedEndpointAccess(environment, group, userID, memberships)
+	}
+
+	return false
+}
+
+func writeEvent(w http.ResponseWriter, event systemEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+
+	return err
+}
+
+func isEventType(eventType string) bool {
+	for _, t := range eventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,145 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_systemEvents(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	adminUser := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(adminUser))
+
+	standardUser := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(standardUser))
+
+	is.NoError(store.EndpointGroup().Create(&portainer.EndpointGroup{ID: 1, Name: "Unassigned"}))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+
+	h := NewHandler(requestBouncer, &portainer.Status{}, &demo.Service{}, store, nil)
+
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	// subscribe opens the stream and returns the events received on it
+	subscribe := func(user *portainer.User, query string) (<-chan systemEvent, context.CancelFunc) {
+		token, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/system/events"+query, nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		resp, err := http.DefaultClient.Do(req)
+		if !is.NoError(err) {
+			cancel()
+			return nil, cancel
+		}
+
+		is.Equal(http.StatusOK, resp.StatusCode)
+		is.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+		events := make(chan systemEvent, 16)
+		go func() {
+			defer resp.Body.Close()
+
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+				if !ok {
+					continue
+				}
+
+				var event systemEvent
+				if json.Unmarshal([]byte(data), &event) == nil {
+					events <- event
+				}
+			}
+		}()
+
+		return events, cancel
+	}
+
+	adminEvents, cancelAdmin := subscribe(adminUser, "?types[]=endpoint")
+	defer cancelAdmin()
+
+	standardEvents, cancelStandard := subscribe(standardUser, "")
+	defer cancelStandard()
+
+	// only visible to the administrator
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "restricted", GroupID: 1}))
+
+	// only streamed to the standard user, the administrator stream is filtered on the environments
+	standardUser.Username = "renamed"
+	is.NoError(store.User().Update(standardUser.ID, standardUser))
+
+	select {
+	case event := <-adminEvents:
+		is.Equal(systemEvent{Type: "endpoint", Action: "create", ID: "1", Time: event.Time}, event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the administrator did not receive the environment event")
+	}
+
+	select {
+	case event := <-standardEvents:
+		is.Equal(systemEvent{Type: "user", Action: "update", ID: "2", Time: event.Time}, event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the standard user did not receive the user event")
+	}
+
+	select {
+	case event := <-adminEvents:
+		t.Fatalf("unexpected event streamed to the administrator: %+v", event)
+	case event := <-standardEvents:
+		t.Fatalf("unexpected event streamed to the standard user: %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func Test_systemEvents_invalidType(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	adminUser := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(adminUser))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+
+	h := NewHandler(requestBouncer, &portainer.Status{}, &demo.Service{}, store, nil)
+
+	token, _ := jwtService.GenerateToken(&portainer.TokenData{ID: adminUser.ID, Username: adminUser.Username, Role: adminUser.Role})
+
+	req := httptest.NewRequest(http.MethodGet, "/system/events?types[]=registry", nil)
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	is.Equal(http.StatusBadRequest, rr.Code)
+}
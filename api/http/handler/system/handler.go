@@ -1,13 +1,19 @@
 package system
 
 import (
+	"context"
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/apiusage"
+	"github.com/portainer/portainer/api/http/offlinegate"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/upgrade"
+	"github.com/portainer/portainer/api/release"
+	"github.com/portainer/portainer/api/scheduler"
+	"github.com/portainer/portainer/api/usage"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -20,6 +26,18 @@ type Handler struct {
 	dataStore      dataservices.DataStore
 	demoService    *demo.Service
 	upgradeService upgrade.Service
+	// ShutdownCtx ends the event streams when the server shuts down
+	ShutdownCtx  context.Context
+	UsageService *usage.Service
+	// APIUsageTracker counts the API requests by client
+	APIUsageTracker *apiusage.Tracker
+	// Scheduler and DataPath are used by the health probes, the related checks are skipped when they are not set
+	Scheduler *scheduler.Scheduler
+	DataPath  string
+	// ReleaseService provides the latest Portainer release, no update is reported when it is not set
+	ReleaseService *release.Service
+	// OfflineGate blocks the modifications while the database is backed up before an in-place upgrade
+	OfflineGate *offlinegate.OfflineGate
 }
 
 // NewHandler creates a handler to manage status operations.
@@ -43,18 +61,31 @@ func NewHandler(bouncer security.BouncerService,
 	adminRouter.Use(bouncer.AdminAccess)
 
 	adminRouter.Handle("/upgrade", httperror.LoggerHandler(h.systemUpgrade)).Methods(http.MethodPost)
+	adminRouter.Handle("/usage", httperror.LoggerHandler(h.systemUsage)).Methods(http.MethodGet)
+	adminRouter.Handle("/api-usage", httperror.LoggerHandler(h.systemAPIUsage)).Methods(http.MethodGet)
+	adminRouter.Handle("/prometheus/metrics", httperror.LoggerHandler(h.systemPrometheusMetrics)).Methods(http.MethodGet)
+	adminRouter.Handle("/prometheus/rules", httperror.LoggerHandler(h.systemPrometheusRules)).Methods(http.MethodGet)
 
 	authenticatedRouter := router.PathPrefix("/").Subrouter()
 	authenticatedRouter.Use(bouncer.AuthenticatedAccess)
 
 	authenticatedRouter.Handle("/version", http.HandlerFunc(h.version)).Methods(http.MethodGet)
+	authenticatedRouter.Handle("/version/latest", httperror.LoggerHandler(h.latestRelease)).Methods(http.MethodGet)
 	authenticatedRouter.Handle("/nodes", httperror.LoggerHandler(h.systemNodesCount)).Methods(http.MethodGet)
 	authenticatedRouter.Handle("/info", httperror.LoggerHandler(h.systemInfo)).Methods(http.MethodGet)
 
+	restrictedRouter := router.PathPrefix("/").Subrouter()
+	restrictedRouter.Use(bouncer.RestrictedAccess)
+
+	restrictedRouter.Handle("/events", httperror.LoggerHandler(h.systemEvents)).Methods(http.MethodGet)
+
 	publicRouter := router.PathPrefix("/").Subrouter()
 	publicRouter.Use(bouncer.PublicAccess)
 
 	publicRouter.Handle("/status", httperror.LoggerHandler(h.systemStatus)).Methods(http.MethodGet)
+	publicRouter.Handle("/status/healthz", httperror.LoggerHandler(h.systemHealthz)).Methods(http.MethodGet)
+	publicRouter.Handle("/status/readyz", httperror.LoggerHandler(h.systemReadyz)).Methods(http.MethodGet)
+	publicRouter.Handle("/status/page", httperror.LoggerHandler(h.systemStatusPage)).Methods(http.MethodGet)
 
 	// Deprecated /status endpoint, will be removed in the future.
 	h.Handle("/status",
@@ -0,0 +1,142 @@
+package system
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/portainer/portainer/api/scheduler"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	healthStatusOK   = "ok"
+	healthStatusFail = "fail"
+
+	// maxHeartbeatAge is the age after which the background workers are considered stalled
+	maxHeartbeatAge = 6 * scheduler.HeartbeatInterval
+)
+
+type healthCheck struct {
+	Status string `json:"status" example:"ok"`
+	Error  string `json:"error,omitempty"`
+}
+
+type healthResponse struct {
+	// ok when all the checks pass, fail otherwise
+	Status string                 `json:"status" example:"ok"`
+	Checks map[string]healthCheck `json:"checks"`
+}
+
+// @id systemHealthz
+// @summary Check that Portainer is alive
+// @description Liveness probe, checking that the database is accessible and that the background workers are running.
+// @description Responds with a 503 status code when a check fails, a restart of Portainer being expected to fix it.
+// @description **Access policy**: public
+// @tags system
+// @produce json
+// @success 200 {object} healthResponse "Success"
+// @failure 503 {object} healthResponse "Portainer is unhealthy"
+// @router /system/status/healthz [get]
+func (handler *Handler) systemHealthz(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return writeHealth(w, map[string]error{
+		"database": handler.checkDatabase(),
+		"workers":  handler.checkWorkers(),
+	})
+}
+
+// @id systemReadyz
+// @summary Check that Portainer is ready to serve requests
+// @description Readiness probe, checking that the database is accessible, that the data folder is writable
+// @description and that the background workers are running. Responds with a 503 status code when a check fails.
+// @description **Access policy**: public
+// @tags system
+// @produce json
+// @success 200 {object} healthResponse "Success"
+// @failure 503 {object} healthResponse "Portainer is not ready"
+// @router /system/status/readyz [get]
+func (handler *Handler) systemReadyz(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	return writeHealth(w, map[string]error{
+		"database":   handler.checkDatabase(),
+		"filesystem": handler.checkFilesystem(),
+		"workers":    handler.checkWorkers(),
+	})
+}
+
+func (handler *Handler) checkDatabase() error {
+	_, err := handler.dataStore.Version().Version()
+
+	return err
+}
+
+// checkFilesystem creates and removes a file in the data folder
+func (handler *Handler) checkFilesystem() error {
+	if handler.DataPath == "" {
+		return nil
+	}
+
+	file, err := os.CreateTemp(handler.DataPath, ".healthcheck-*")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.WriteString(healthStatusOK)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if removeErr := os.Remove(file.Name()); err == nil {
+		err = removeErr
+	}
+
+	return err
+}
+
+// checkWorkers verifies that the scheduler running the background jobs is still alive
+func (handler *Handler) checkWorkers() error {
+	if handler.Scheduler == nil {
+		return nil
+	}
+
+	if time.Since(handler.Scheduler.LastHeartbeat()) > maxHeartbeatAge {
+		return errors.New("the background workers are not running")
+	}
+
+	return nil
+}
+
+func writeHealth(w http.ResponseWriter, results map[string]error) *httperror.HandlerError {
+	health := healthResponse{
+		Status: healthStatusOK,
+		Checks: make(map[string]healthCheck, len(results)),
+	}
+
+	for name, err := range results {
+		if err == nil {
+			health.Checks[name] = healthCheck{Status: healthStatusOK}
+			continue
+		}
+
+		log.Warn().Err(err).Str("check", name).Msg("health check failed")
+
+		health.Status = healthStatusFail
+		health.Checks[name] = healthCheck{Status: healthStatusFail, Error: err.Error()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if health.Status != healthStatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	if err := json.NewEncoder(w).Encode(health); err != nil {
+		return httperror.InternalServerError("Unable to write JSON response", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/portainer/portainer/api/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_systemHealthProbes(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHandler(requestBouncer, &portainer.Status{}, &demo.Service{}, store, nil)
+	h.Scheduler = scheduler.NewScheduler(ctx)
+	h.DataPath = t.TempDir()
+
+	probe := func(path string) (int, healthResponse) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		var health healthResponse
+		is.NoError(json.NewDecoder(rr.Body).Decode(&health))
+
+		return rr.Code, health
+	}
+
+	t.Run("probes pass on a healthy instance", func(t *testing.T) {
+		code, health := probe("/system/status/healthz")
+		is.Equal(http.StatusOK, code)
+		is.Equal(healthStatusOK, health.Status)
+		is.Len(health.Checks, 2)
+
+		code, health = probe("/system/status/readyz")
+		is.Equal(http.StatusOK, code)
+		is.Equal(healthStatusOK, health.Status)
+		is.Equal(healthStatusOK, health.Checks["filesystem"].Status)
+
+		entries, err := os.ReadDir(h.DataPath)
+		is.NoError(err)
+		is.Empty(entries, "the probe file should be removed")
+	})
+
+	t.Run("readiness fails when the data folder is not writable", func(t *testing.T) {
+		h.DataPath = filepath.Join(t.TempDir(), "missing")
+
+		code, health := probe("/system/status/readyz")
+		is.Equal(http.StatusServiceUnavailable, code)
+		is.Equal(healthStatusFail, health.Status)
+		is.Equal(healthStatusFail, health.Checks["filesystem"].Status)
+		is.NotEmpty(health.Checks["filesystem"].Error)
+
+		code, _ = probe("/system/status/healthz")
+		is.Equal(http.StatusOK, code)
+	})
+}
@@ -0,0 +1,96 @@
+package system
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/portainer/portainer/api/monitoring"
+	"github.com/portainer/portainer/api/retries"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+)
+
+// @id systemPrometheusMetrics
+// @summary Retrieve the Prometheus metrics of the environments
+// @description Retrieve the health of the environments in the Prometheus text exposition format, to be scraped by Prometheus
+// @description with an access token: whether each environment is up, the time of its last snapshot or of the last check-in of
+// @description its Edge agent, and the expiry of its TLS certificates. The retries of the requests to the environments failing
+// @description with a transient connection error are also counted, by subsystem.
+// @description **Access policy**: administrator
+// @security ApiKeyAuth
+// @security jwt
+// @tags system
+// @produce plain
+// @success 200 {string} string "Success"
+// @failure 500 "Server error"
+// @router /system/prometheus/metrics [get]
+func (handler *Handler) systemPrometheusMetrics(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	healths, err := monitoring.Collect(handler.dataStore)
+	if err != nil {
+		return httperror.InternalServerError("Unable to compute the health of the environments", err)
+	}
+
+	w.Header().Set("Content-Type", monitoring.ContentType)
+
+	if err := monitoring.WriteMetrics(w, healths); err != nil {
+		return httperror.InternalServerError("Unable to write the metrics", err)
+	}
+
+	if err := retries.WriteMetrics(w); err != nil {
+		return httperror.InternalServerError("Unable to write the metrics", err)
+	}
+
+	return nil
+}
+
+// @id systemPrometheusRules
+// @summary Retrieve the Prometheus alerting rules of the environments
+// @description Generate a Prometheus rule file alerting on the metrics of the environments: the environments which are down,
+// @description the Edge agents which stopped checking in for longer than the heartbeat tolerance of their environment and the
+// @description TLS certificates about to expire. The rules follow the environments registered in Portainer, the file can be
+// @description downloaded periodically to keep the monitoring in sync with the inventory.
+// @description **Access policy**: administrator
+// @security ApiKeyAuth
+// @security jwt
+// @tags system
+// @produce plain
+// @param for query string false "Duration during which a condition must hold before the alert fires, defaults to 5m"
+// @param certificateExpiryDays query int false "Remaining validity of the certificates, in days, below which the expiry alert fires, defaults to 14"
+// @success 200 {string} string "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /system/prometheus/rules [get]
+func (handler *Handler) systemPrometheusRules(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var options monitoring.RuleOptions
+
+	if value, _ := request.RetrieveQueryParameter(r, "for", true); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			return httperror.BadRequest("Invalid for query parameter", errors.New("a positive duration is expected"))
+		}
+
+		options.For = duration
+	}
+
+	days, err := request.RetrieveNumericQueryParameter(r, "certificateExpiryDays", true)
+	if err != nil || days < 0 {
+		return httperror.BadRequest("Invalid certificateExpiryDays query parameter", errors.New("a positive number of days is expected"))
+	}
+	options.CertificateWarning = time.Duration(days) * 24 * time.Hour
+
+	healths, err := monitoring.Collect(handler.dataStore)
+	if err != nil {
+		return httperror.InternalServerError("Unable to compute the health of the environments", err)
+	}
+
+	content, err := monitoring.Rules(healths, options).Marshal()
+	if err != nil {
+		return httperror.InternalServerError("Unable to encode the alerting rules", err)
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(content)
+
+	return nil
+}
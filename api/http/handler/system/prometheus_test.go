@@ -0,0 +1,45 @@
+package system
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_systemPrometheusRules(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	err := store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "production", Type: portainer.DockerEnvironment, GroupID: 1})
+	is.NoError(err, "error creating environment")
+
+	h := NewHandler(testhelpers.NewTestRequestBouncer(), &portainer.Status{}, &demo.Service{}, store, nil)
+
+	rules := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/system/prometheus/rules"+query, nil)
+		rr := httptest.NewRecorder()
+
+		if httpErr := h.systemPrometheusRules(rr, req); httpErr != nil {
+			rr.Code = httpErr.StatusCode
+		}
+
+		return rr
+	}
+
+	rr := rules("?for=10m&certificateExpiryDays=30")
+	is.Equal(http.StatusOK, rr.Code)
+	is.Equal("application/yaml", rr.Header().Get("Content-Type"))
+	is.Contains(rr.Body.String(), "- alert: PortainerEnvironmentDown\n")
+	is.Contains(rr.Body.String(), `expr: portainer_endpoint_up{endpoint_id="1"} == 0`)
+	is.Contains(rr.Body.String(), "for: 10m\n")
+
+	is.Equal(http.StatusBadRequest, rules("?for=soon").Code)
+	is.Equal(http.StatusBadRequest, rules("?certificateExpiryDays=-1").Code)
+}
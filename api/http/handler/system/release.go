@@ -0,0 +1,34 @@
+package system
+
+import (
+	"errors"
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id systemLatestRelease
+// @summary Retrieve the latest Portainer release
+// @description Retrieve the version and the release notes of the latest Portainer release, as retrieved by the last
+// @description background check.
+// @description **Access policy**: authenticated
+// @security ApiKeyAuth
+// @security jwt
+// @tags system
+// @produce json
+// @success 200 {object} release.Release "Success"
+// @failure 404 "The latest release is unknown, Portainer runs offline or the update check is disabled"
+// @router /system/version/latest [get]
+func (handler *Handler) latestRelease(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	if handler.ReleaseService == nil {
+		return httperror.NotFound("The latest release is unknown", errors.New("release check unavailable"))
+	}
+
+	release := handler.ReleaseService.Latest()
+	if release == nil {
+		return httperror.NotFound("The latest release is unknown", errors.New("release check disabled or not completed"))
+	}
+
+	return response.JSON(w, release)
+}
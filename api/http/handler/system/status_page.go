@@ -0,0 +1,40 @@
+package system
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/portainer/portainer/api/statuspage"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id systemStatusPage
+// @summary Retrieve the public status page
+// @description Retrieve the status of the environments selected in the status page settings, with the counts of their containers.
+// @description The names of the environments are replaced by numbers when the settings hide them. Responds with a 404 status code
+// @description when the status page is disabled.
+// @description **Access policy**: public
+// @tags system
+// @produce json
+// @success 200 {object} statuspage.Page "Success"
+// @failure 404 "Status page disabled"
+// @failure 500 "Server error"
+// @router /system/status/page [get]
+func (handler *Handler) systemStatusPage(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	settings, err := handler.dataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the settings from the database", err)
+	}
+
+	if !settings.StatusPage.Enabled {
+		return httperror.NotFound("The status page is disabled", errors.New("status page disabled"))
+	}
+
+	page, err := statuspage.Build(handler.dataStore, settings)
+	if err != nil {
+		return httperror.InternalServerError("Unable to compute the status page", err)
+	}
+
+	return response.JSON(w, page)
+}
@@ -5,27 +5,49 @@ import (
 	"regexp"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/backup"
+	httperrors "github.com/portainer/portainer/api/http/errors"
 	"github.com/portainer/portainer/api/platform"
+	"github.com/portainer/portainer/api/release"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
 
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 )
 
 type systemUpgradePayload struct {
+	// License used to upgrade to Business Edition
 	License string
+	// Version to upgrade to in place, keeping the edition. Ignored when a license is provided
+	Version string `example:"2.21.0"`
 }
 
-var re = regexp.MustCompile(`^\d-.+`)
+var (
+	re        = regexp.MustCompile(`^\d-.+`)
+	versionRe = regexp.MustCompile(`^\d+\.\d+\.\d+(-[0-9A-Za-z.-]+)?$`)
+)
 
 func (payload *systemUpgradePayload) Validate(r *http.Request) error {
-	if payload.License == "" {
+	if payload.License == "" && payload.Version == "" {
 		return errors.New("license is missing")
 	}
 
-	if !re.MatchString(payload.License) {
-		return errors.New("license is invalid")
+	if payload.License != "" {
+		if !re.MatchString(payload.License) {
+			return errors.New("license is invalid")
+		}
+
+		return nil
+	}
+
+	if !versionRe.MatchString(payload.Version) {
+		return errors.New("version is invalid")
+	}
+
+	if !release.HasNewerVersion(portainer.APIVersion, payload.Version) {
+		return errors.New("version must be newer than the running version")
 	}
 
 	return nil
@@ -38,12 +60,18 @@ var platformToEndpointType = map[platform.ContainerPlatform]portainer.EndpointTy
 }
 
 // @id systemUpgrade
-// @summary Upgrade Portainer to BE
-// @description Upgrade Portainer to BE
+// @summary Upgrade Portainer
+// @description Upgrade Portainer to BE when a license is provided. Otherwise, upgrade Portainer in place to the version:
+// @description the image of the version is pulled, the database is backed up in the backup folder of the data volume,
+// @description then the Portainer container is recreated with the new image, keeping its volumes and flags.
+// @description The in-place upgrade is only available when Portainer runs as a Docker container with access to its engine.
 // @description **Access policy**: administrator
 // @tags system
-// @produce json
+// @accept json
+// @param body body systemUpgradePayload true "Upgrade details"
 // @success 204 {object} status "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
 // @router /system/upgrade [post]
 func (handler *Handler) systemUpgrade(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
 	payload, err := request.GetPayload[systemUpgradePayload](r)
@@ -51,6 +79,10 @@ func (handler *Handler) systemUpgrade(w http.ResponseWriter, r *http.Request) *h
 		return httperror.BadRequest("Invalid request payload", err)
 	}
 
+	if payload.License == "" {
+		return handler.upgradeVersion(w, payload.Version)
+	}
+
 	environment, err := handler.guessLocalEndpoint()
 	if err != nil {
 		return httperror.InternalServerError("Failed to guess local endpoint", err)
@@ -64,6 +96,25 @@ func (handler *Handler) systemUpgrade(w http.ResponseWriter, r *http.Request) *h
 	return response.Empty(w)
 }
 
+func (handler *Handler) upgradeVersion(w http.ResponseWriter, version string) *httperror.HandlerError {
+	if handler.demoService.IsDemo() {
+		return httperror.Forbidden(httperrors.ErrNotAvailableInDemo.Error(), httperrors.ErrNotAvailableInDemo)
+	}
+
+	archivePath, err := backup.CreateBackupArchive("", handler.OfflineGate, handler.dataStore, handler.DataPath)
+	if err != nil {
+		return httperror.InternalServerError("Failed to back up Portainer before the upgrade", err)
+	}
+
+	log.Info().Str("version", version).Str("backup", archivePath).Msg("upgrading Portainer")
+
+	if err := handler.upgradeService.UpgradeVersion(version); err != nil {
+		return httperror.InternalServerError("Failed to upgrade Portainer", err)
+	}
+
+	return response.Empty(w)
+}
+
 func (handler *Handler) guessLocalEndpoint() (*portainer.Endpoint, error) {
 	platform, err := platform.DetermineContainerPlatform()
 	if err != nil {
@@ -0,0 +1,36 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_systemUpgradePayload_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload systemUpgradePayload
+		valid   bool
+	}{
+		{name: "empty payload", payload: systemUpgradePayload{}},
+		{name: "license", payload: systemUpgradePayload{License: "2-abc"}, valid: true},
+		{name: "invalid license", payload: systemUpgradePayload{License: "abc"}},
+		{name: "license takes precedence over the version", payload: systemUpgradePayload{License: "2-abc", Version: "1.0.0"}, valid: true},
+		{name: "newer version", payload: systemUpgradePayload{Version: "99.0.0"}, valid: true},
+		{name: "newer pre-release version", payload: systemUpgradePayload{Version: "99.0.0-rc1"}, valid: true},
+		{name: "older version", payload: systemUpgradePayload{Version: "1.0.0"}},
+		{name: "invalid version", payload: systemUpgradePayload{Version: "latest"}},
+		{name: "image reference", payload: systemUpgradePayload{Version: "99.0.0@sha256:abc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.payload.Validate(nil)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package system
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id systemUsage
+// @summary Retrieve the usage of the instance
+// @description Retrieve the number of environments by type, Edge devices, users, teams and stacks, and the size of the database,
+// @description for chargeback and internal reporting. The same report can be exported on a schedule to a URL from the settings.
+// @description **Access policy**: administrator
+// @security ApiKeyAuth
+// @security jwt
+// @tags system
+// @produce json
+// @success 200 {object} usage.Report "Success"
+// @failure 500 "Server error"
+// @router /system/usage [get]
+func (handler *Handler) systemUsage(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	report, err := handler.UsageService.Report()
+	if err != nil {
+		return httperror.InternalServerError("Unable to compute the usage report", err)
+	}
+
+	return response.JSON(w, report)
+}
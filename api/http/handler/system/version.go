@@ -1,15 +1,12 @@
 package system
 
 import (
-	"encoding/json"
 	"net/http"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/build"
-	"github.com/portainer/portainer/api/http/client"
 	"github.com/portainer/portainer/pkg/libhttp/response"
 
-	"github.com/coreos/go-semver/semver"
 	"github.com/rs/zerolog/log"
 )
 
@@ -36,7 +33,8 @@ type BuildInfo struct {
 
 // @id systemVersion
 // @summary Check for portainer updates
-// @description Check if portainer has an update available
+// @description Check if portainer has an update available. The latest version is retrieved periodically in the background,
+// @description it is not returned when Portainer runs offline or when the update check is disabled in the settings.
 // @description **Access policy**: authenticated
 // @security ApiKeyAuth
 // @security jwt
@@ -60,55 +58,16 @@ func (handler *Handler) version(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	latestVersion := GetLatestVersion()
-	if HasNewerVersion(portainer.APIVersion, latestVersion) {
-		result.UpdateAvailable = true
-		result.LatestVersion = latestVersion
+	if handler.ReleaseService != nil {
+		if release, ok := handler.ReleaseService.UpdateAvailable(); ok {
+			result.UpdateAvailable = true
+			result.LatestVersion = release.Version
+		}
 	}
 
 	response.JSON(w, &result)
 }
 
-func GetLatestVersion() string {
-	motd, err := client.Get(portainer.VersionCheckURL, 5)
-	if err != nil {
-		log.Debug().Err(err).Msg("couldn't fetch latest Portainer release version")
-
-		return ""
-	}
-
-	var data struct {
-		TagName string `json:"tag_name"`
-	}
-
-	err = json.Unmarshal(motd, &data)
-	if err != nil {
-		log.Debug().Err(err).Msg("couldn't parse latest Portainer version")
-
-		return ""
-	}
-
-	return data.TagName
-}
-
-func HasNewerVersion(currentVersion, latestVersion string) bool {
-	currentVersionSemver, err := semver.NewVersion(currentVersion)
-	if err != nil {
-		log.Debug().Str("version", currentVersion).Msg("current Portainer version isn't a semver")
-
-		return false
-	}
-
-	latestVersionSemver, err := semver.NewVersion(latestVersion)
-	if err != nil {
-		log.Debug().Str("version", latestVersion).Msg("latest Portainer version isn't a semver")
-
-		return false
-	}
-
-	return currentVersionSemver.LessThan(*latestVersionSemver)
-}
-
 // @id Version
 // @summary Check for portainer updates
 // @deprecated
@@ -0,0 +1,40 @@
+package tags
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id TagInspect
+// @summary Inspect a tag
+// @description Retrieve details about a tag.
+// @description **Access policy**: authenticated
+// @tags tags
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Tag identifier"
+// @success 200 {object} portainer.Tag "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Tag not found"
+// @failure 500 "Server error"
+// @router /tags/{id} [get]
+func (handler *Handler) tagInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	id, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid tag identifier route variable", err)
+	}
+
+	tag, err := handler.DataStore.Tag().Read(portainer.TagID(id))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a tag with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a tag with the specified identifier inside the database", err)
+	}
+
+	return response.JSON(w, tag)
+}
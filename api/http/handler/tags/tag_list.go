@@ -2,8 +2,11 @@ package tags
 
 import (
 	"net/http"
+	"slices"
 
+	portainer "github.com/portainer/portainer/api"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
 )
 
@@ -15,6 +18,7 @@ import (
 // @security ApiKeyAuth
 // @security jwt
 // @produce json
+// @param name query string false "Only list the tag with this exact name"
 // @success 200 {array} portainer.Tag "Success"
 // @failure 500 "Server error"
 // @router /tags [get]
@@ -24,5 +28,11 @@ func (handler *Handler) tagList(w http.ResponseWriter, r *http.Request) *httperr
 		return httperror.InternalServerError("Unable to retrieve tags from the database", err)
 	}
 
+	if name, _ := request.RetrieveQueryParameter(r, "name", true); name != "" {
+		tags = slices.DeleteFunc(tags, func(tag portainer.Tag) bool {
+			return tag.Name != name
+		})
+	}
+
 	return response.JSON(w, tags)
 }
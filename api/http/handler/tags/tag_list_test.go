@@ -0,0 +1,57 @@
+package tags
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagLookup(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	for _, name := range []string{"prod", "production"} {
+		is.NoError(store.Tag().Create(&portainer.Tag{Name: name}))
+	}
+
+	handler := NewHandler(testhelpers.NewTestRequestBouncer())
+	handler.DataStore = store
+
+	get := func(url string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, url, nil)
+		r = r.WithContext(security.StoreTokenData(r, &portainer.TokenData{ID: 1, Role: portainer.AdministratorRole}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		return w
+	}
+
+	w := get("/tags?name=prod")
+	is.Equal(http.StatusOK, w.Code)
+
+	var tags []portainer.Tag
+	is.NoError(json.NewDecoder(w.Body).Decode(&tags))
+	if is.Len(tags, 1, "only the tag with the exact name should be returned") {
+		is.Equal("prod", tags[0].Name)
+
+		w = get("/tags/" + strconv.Itoa(int(tags[0].ID)))
+		is.Equal(http.StatusOK, w.Code)
+
+		var tag portainer.Tag
+		is.NoError(json.NewDecoder(w.Body).Decode(&tag))
+		is.Equal(tags[0], tag)
+	}
+
+	is.Equal(http.StatusNotFound, get("/tags/9").Code)
+}
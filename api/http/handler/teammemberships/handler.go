@@ -2,9 +2,13 @@ package teammemberships
 
 import (
 	"net/http"
+	"strconv"
 
+	"github.com/portainer/portainer/api/database/changes"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/dataservices/teammembership"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/lifecycle"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -13,7 +17,8 @@ import (
 // Handler is the HTTP handler used to handle team membership operations.
 type Handler struct {
 	*mux.Router
-	DataStore dataservices.DataStore
+	DataStore       dataservices.DataStore
+	EventDispatcher *lifecycle.Dispatcher
 }
 
 // NewHandler creates a handler to manage team membership operations.
@@ -26,8 +31,18 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 
 	h.Handle("/team_memberships", httperror.LoggerHandler(h.teamMembershipCreate)).Methods(http.MethodPost)
 	h.Handle("/team_memberships", httperror.LoggerHandler(h.teamMembershipList)).Methods(http.MethodGet)
+	h.Handle("/team_memberships/{id}", httperror.LoggerHandler(h.teamMembershipInspect)).Methods(http.MethodGet)
 	h.Handle("/team_memberships/{id}", httperror.LoggerHandler(h.teamMembershipUpdate)).Methods(http.MethodPut)
 	h.Handle("/team_memberships/{id}", httperror.LoggerHandler(h.teamMembershipDelete)).Methods(http.MethodDelete)
 
 	return h
 }
+
+// publishAccessChanged notifies the event webhooks of a change of the members of a team
+func (handler *Handler) publishAccessChanged(id int, action changes.Action) {
+	if handler.EventDispatcher == nil {
+		return
+	}
+
+	handler.EventDispatcher.Publish(lifecycle.NewAccessChangedEvent(teammembership.BucketName, strconv.Itoa(id), action))
+}
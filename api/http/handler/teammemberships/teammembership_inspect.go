@@ -0,0 +1,52 @@
+package teammemberships
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id TeamMembershipInspect
+// @summary Inspect a team membership
+// @description Retrieve details about a team membership. Access is only available to administrators or leaders of the associated team.
+// @description **Access policy**: administrator or leaders of the associated team
+// @tags team_memberships
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Team membership identifier"
+// @success 200 {object} portainer.TeamMembership "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "TeamMembership not found"
+// @failure 500 "Server error"
+// @router /team_memberships/{id} [get]
+func (handler *Handler) teamMembershipInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	membershipID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid membership identifier route variable", err)
+	}
+
+	membership, err := handler.DataStore.TeamMembership().Read(portainer.TeamMembershipID(membershipID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a team membership with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a team membership with the specified identifier inside the database", err)
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	if !security.AuthorizedTeamManagement(membership.TeamID, securityContext) {
+		return httperror.Forbidden("Permission denied to inspect the membership", httperrors.ErrResourceAccessDenied)
+	}
+
+	return response.JSON(w, membership)
+}
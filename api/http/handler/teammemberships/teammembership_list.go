@@ -2,8 +2,11 @@ package teammemberships
 
 import (
 	"net/http"
+	"slices"
 
+	portainer "github.com/portainer/portainer/api"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
 )
 
@@ -15,6 +18,8 @@ import (
 // @security ApiKeyAuth
 // @security jwt
 // @produce json
+// @param userId query int false "Only list the memberships of this user"
+// @param teamId query int false "Only list the memberships of this team"
 // @success 200 {array} portainer.TeamMembership "Success"
 // @failure 400 "Invalid request"
 // @failure 403 "Permission denied"
@@ -26,5 +31,13 @@ func (handler *Handler) teamMembershipList(w http.ResponseWriter, r *http.Reques
 		return httperror.InternalServerError("Unable to retrieve team memberships from the database", err)
 	}
 
+	userID, _ := request.RetrieveNumericQueryParameter(r, "userId", true)
+	teamID, _ := request.RetrieveNumericQueryParameter(r, "teamId", true)
+
+	memberships = slices.DeleteFunc(memberships, func(membership portainer.TeamMembership) bool {
+		return (userID != 0 && membership.UserID != portainer.UserID(userID)) ||
+			(teamID != 0 && membership.TeamID != portainer.TeamID(teamID))
+	})
+
 	return response.JSON(w, memberships)
 }
@@ -48,6 +48,11 @@ func (handler *Handler) teamDelete(w http.ResponseWriter, r *http.Request) *http
 		return httperror.InternalServerError("Unable to delete associated team memberships from the database", err)
 	}
 
+	err = handler.deleteTeamOwnershipRules(portainer.TeamID(teamID))
+	if err != nil {
+		return httperror.InternalServerError("Unable to delete the ownership rules of the team from the database", err)
+	}
+
 	// update default team if deleted team was default
 	err = handler.updateDefaultTeamIfDeleted(portainer.TeamID(teamID))
 	if err != nil {
@@ -72,3 +77,23 @@ func (handler *Handler) updateDefaultTeamIfDeleted(teamID portainer.TeamID) erro
 	err = handler.DataStore.Settings().UpdateSettings(settings)
 	return errors.Wrap(err, "failed to update settings")
 }
+
+// deleteTeamOwnershipRules removes the ownership rules giving resources to the deleted team
+func (handler *Handler) deleteTeamOwnershipRules(teamID portainer.TeamID) error {
+	rules, err := handler.DataStore.OwnershipRule().ReadAll()
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch ownership rules")
+	}
+
+	for _, rule := range rules {
+		if rule.TeamID != teamID {
+			continue
+		}
+
+		if err := handler.DataStore.OwnershipRule().Delete(rule.ID); err != nil {
+			return errors.Wrap(err, "failed to delete ownership rule")
+		}
+	}
+
+	return nil
+}
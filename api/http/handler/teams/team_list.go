@@ -2,6 +2,7 @@ package teams
 
 import (
 	"net/http"
+	"slices"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/security"
@@ -17,6 +18,7 @@ import (
 // @tags teams
 // @param onlyLedTeams query boolean false "Only list teams that the user is leader of"
 // @param environmentId query int false "Identifier of the environment(endpoint) that will be used to filter the authorized teams"
+// @param name query string false "Only list the team with this exact name"
 // @security ApiKeyAuth
 // @security jwt
 // @produce json
@@ -43,6 +45,12 @@ func (handler *Handler) teamList(w http.ResponseWriter, r *http.Request) *httper
 		userTeams = security.FilterUserTeams(teams, securityContext)
 	}
 
+	if name, _ := request.RetrieveQueryParameter(r, "name", true); name != "" {
+		userTeams = slices.DeleteFunc(userTeams, func(team portainer.Team) bool {
+			return team.Name != name
+		})
+	}
+
 	endpointID, _ := request.RetrieveNumericQueryParameter(r, "environmentId", true)
 	if endpointID == 0 {
 		return response.JSON(w, userTeams)
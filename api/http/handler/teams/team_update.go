@@ -12,6 +12,10 @@ import (
 type teamUpdatePayload struct {
 	// Name
 	Name string `example:"developers"`
+	// Environment the members of the team land on, 0 removes it
+	DefaultEndpointID *portainer.EndpointID `example:"1"`
+	// Environment group whose environments make the home of the members of the team, 0 removes it
+	DefaultEndpointGroupID *portainer.EndpointGroupID `example:"2"`
 }
 
 func (payload *teamUpdatePayload) Validate(r *http.Request) error {
@@ -20,7 +24,7 @@ func (payload *teamUpdatePayload) Validate(r *http.Request) error {
 
 // @id TeamUpdate
 // @summary Update a team
-// @description Update a team.
+// @description Update a team, its name and the default environment and environment group making the home of its members.
 // @description **Access policy**: administrator
 // @tags teams
 // @security ApiKeyAuth
@@ -59,6 +63,26 @@ func (handler *Handler) teamUpdate(w http.ResponseWriter, r *http.Request) *http
 		team.Name = payload.Name
 	}
 
+	if payload.DefaultEndpointID != nil {
+		if *payload.DefaultEndpointID != 0 {
+			if _, err := handler.DataStore.Endpoint().Endpoint(*payload.DefaultEndpointID); err != nil {
+				return httperror.BadRequest("Invalid default environment, it does not exist", err)
+			}
+		}
+
+		team.DefaultEndpointID = *payload.DefaultEndpointID
+	}
+
+	if payload.DefaultEndpointGroupID != nil {
+		if *payload.DefaultEndpointGroupID != 0 {
+			if _, err := handler.DataStore.EndpointGroup().Read(*payload.DefaultEndpointGroupID); err != nil {
+				return httperror.BadRequest("Invalid default environment group, it does not exist", err)
+			}
+		}
+
+		team.DefaultEndpointGroupID = *payload.DefaultEndpointGroupID
+	}
+
 	err = handler.DataStore.Team().Update(team.ID, team)
 	if err != nil {
 		return httperror.NotFound("Unable to persist team changes inside the database", err)
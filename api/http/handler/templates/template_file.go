@@ -1,11 +1,9 @@
 package templates
 
 import (
-	"encoding/json"
 	"errors"
 	"net/http"
 
-	portainer "github.com/portainer/portainer/api"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 	"github.com/portainer/portainer/pkg/libhttp/response"
@@ -38,27 +36,13 @@ func (payload *filePayload) Validate(r *http.Request) error {
 	return nil
 }
 
-func (handler *Handler) ifRequestedTemplateExists(payload *filePayload) *httperror.HandlerError {
-	settings, err := handler.DataStore.Settings().Settings()
-	if err != nil {
-		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
-	}
-
-	resp, err := http.Get(settings.TemplatesURL)
-	if err != nil {
-		return httperror.InternalServerError("Unable to retrieve templates via the network", err)
-	}
-	defer resp.Body.Close()
-
-	var templates struct {
-		Templates []portainer.Template
-	}
-	err = json.NewDecoder(resp.Body).Decode(&templates)
-	if err != nil {
-		return httperror.InternalServerError("Unable to parse template file", err)
+func (handler *Handler) ifRequestedTemplateExists(r *http.Request, payload *filePayload) *httperror.HandlerError {
+	list, httpErr := handler.listTemplates(r)
+	if httpErr != nil {
+		return httpErr
 	}
 
-	for _, t := range templates.Templates {
+	for _, t := range list.Templates {
 		if t.Repository.URL == payload.RepositoryURL && t.Repository.StackFile == payload.ComposeFilePathInRepository {
 			return nil
 		}
@@ -89,7 +73,7 @@ func (handler *Handler) templateFile(w http.ResponseWriter, r *http.Request) *ht
 		return httperror.BadRequest("Invalid request payload", err)
 	}
 
-	if err := handler.ifRequestedTemplateExists(&payload); err != nil {
+	if err := handler.ifRequestedTemplateExists(r, &payload); err != nil {
 		return err
 	}
 
@@ -1,47 +1,51 @@
 package templates
 
 import (
-	"io"
 	"net/http"
 
-	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apptemplates"
+	"github.com/portainer/portainer/api/http/security"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
 )
 
-// introduced for swagger
-type listResponse struct {
-	Version   string
-	Templates []portainer.Template
-}
-
 // @id TemplateList
 // @summary List available templates
-// @description List available templates.
+// @description List the templates of the templates URL merged with the ones of the enabled template sources.
+// @description The templates of the sources requiring an approval are only listed to the non-administrator users once pinned.
 // @description **Access policy**: authenticated
 // @tags templates
 // @security ApiKeyAuth
 // @security jwt
 // @produce json
-// @success 200 {object} listResponse "Success"
+// @success 200 {object} apptemplates.List "Success"
 // @failure 500 "Server error"
 // @router /templates [get]
 func (handler *Handler) templateList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	list, httpErr := handler.listTemplates(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	return response.JSON(w, list)
+}
+
+// listTemplates returns the templates visible to the user of the request
+func (handler *Handler) listTemplates(r *http.Request) (*apptemplates.List, *httperror.HandlerError) {
 	settings, err := handler.DataStore.Settings().Settings()
 	if err != nil {
-		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+		return nil, httperror.InternalServerError("Unable to retrieve settings from the database", err)
 	}
 
-	resp, err := http.Get(settings.TemplatesURL)
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
 	if err != nil {
-		return httperror.InternalServerError("Unable to retrieve templates via the network", err)
+		return nil, httperror.InternalServerError("Unable to retrieve info from request context", err)
 	}
-	defer resp.Body.Close()
 
-	w.Header().Set("Content-Type", "application/json")
-	_, err = io.Copy(w, resp.Body)
+	list, err := apptemplates.NewFetcher(handler.GitService, handler.FileService).List(settings, securityContext.IsAdmin)
 	if err != nil {
-		return httperror.InternalServerError("Unable to write templates from templates URL", err)
+		return nil, httperror.InternalServerError("Unable to retrieve templates via the network", err)
 	}
 
-	return nil
+	return list, nil
 }
@@ -9,6 +9,8 @@ import (
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/demo"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/lifecycle"
+	"github.com/portainer/portainer/api/mail"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/gorilla/mux"
@@ -21,10 +23,15 @@ var (
 	errCannotRemoveLastLocalAdmin = errors.New("Cannot remove the last local administrator account")
 	errCryptoHashFailure          = errors.New("Unable to hash data")
 	errWrongPassword              = errors.New("Wrong password")
+	errPasswordNotManaged         = errors.New("The password of the user is not managed by Portainer")
+	errInvalidPasswordResetToken  = errors.New("Invalid or expired password reset token")
+	errImpersonatedSession        = errors.New("Not available while impersonating a user")
 )
 
 func hideFields(user *portainer.User) {
 	user.Password = ""
+	user.PasswordResetTokenDigest = nil
+	user.KnownLoginSources = nil
 }
 
 // Handler is the HTTP handler used to handle user operations.
@@ -35,8 +42,11 @@ type Handler struct {
 	demoService             *demo.Service
 	DataStore               dataservices.DataStore
 	CryptoService           portainer.CryptoService
+	JWTService              dataservices.JWTService
 	passwordStrengthChecker security.PasswordStrengthChecker
 	AdminCreationDone       chan<- struct{}
+	EventDispatcher         *lifecycle.Dispatcher
+	MailService             *mail.Service
 }
 
 // NewHandler creates a handler to manage user operations.
@@ -73,9 +83,19 @@ func NewHandler(bouncer security.BouncerService, rateLimiter *security.RateLimit
 	restrictedRouter.Handle("/users/{id}/tokens", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userCreateAccessToken))).Methods(http.MethodPost)
 	restrictedRouter.Handle("/users/{id}/tokens/{keyID}", httperror.LoggerHandler(h.userRemoveAccessToken)).Methods(http.MethodDelete)
 	restrictedRouter.Handle("/users/{id}/memberships", httperror.LoggerHandler(h.userMemberships)).Methods(http.MethodGet)
+	restrictedRouter.Handle("/users/{id}/favorites", httperror.LoggerHandler(h.userFavoritesList)).Methods(http.MethodGet)
+	restrictedRouter.Handle("/users/{id}/favorites", httperror.LoggerHandler(h.userFavoritesUpdate)).Methods(http.MethodPut)
+	restrictedRouter.Handle("/users/{id}/favorites/status", httperror.LoggerHandler(h.userFavoritesStatus)).Methods(http.MethodGet)
+	restrictedRouter.Handle("/users/{id}/home", httperror.LoggerHandler(h.userHome)).Methods(http.MethodGet)
 	authenticatedRouter.Handle("/users/{id}/passwd", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userUpdatePassword))).Methods(http.MethodPut)
 	publicRouter.Handle("/users/admin/check", httperror.LoggerHandler(h.adminCheck)).Methods(http.MethodGet)
 	publicRouter.Handle("/users/admin/init", httperror.LoggerHandler(h.adminInit)).Methods(http.MethodPost)
+	adminRouter.Handle("/users/{id}/password_reset", httperror.LoggerHandler(h.userCreatePasswordReset)).Methods(http.MethodPost)
+	adminRouter.Handle("/users/{id}/impersonate", httperror.LoggerHandler(h.userImpersonate)).Methods(http.MethodPost)
+	adminRouter.Handle("/users/{id}/approve", httperror.LoggerHandler(h.userSignupApprove)).Methods(http.MethodPost)
+	adminRouter.Handle("/users/{id}/reject", httperror.LoggerHandler(h.userSignupReject)).Methods(http.MethodPost)
+	publicRouter.Handle("/users/signup", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userSignup))).Methods(http.MethodPost)
+	publicRouter.Handle("/users/password_reset", rateLimiter.LimitAccess(httperror.LoggerHandler(h.userRedeemPasswordReset))).Methods(http.MethodPost)
 
 	return h
 }
@@ -17,6 +17,10 @@ type userCreatePayload struct {
 	Password string `validate:"required" example:"cg9Wgky3"`
 	// User role (1 for administrator account and 2 for regular account)
 	Role int `validate:"required" enums:"1,2" example:"2"`
+	// Whether the user must change the password at the next login, only available with internal authentication
+	ForcePasswordChange bool `example:"true"`
+	// Email address of the user, used to send the password resets
+	Email string `example:"bob@example.com"`
 }
 
 func (payload *userCreatePayload) Validate(r *http.Request) error {
@@ -27,6 +31,11 @@ func (payload *userCreatePayload) Validate(r *http.Request) error {
 	if payload.Role != 1 && payload.Role != 2 {
 		return errors.New("Invalid role value. Value must be one of: 1 (administrator) or 2 (regular user)")
 	}
+
+	if payload.Email != "" && !govalidator.IsEmail(payload.Email) {
+		return errors.New("Invalid email address")
+	}
+
 	return nil
 }
 
@@ -65,6 +74,7 @@ func (handler *Handler) userCreate(w http.ResponseWriter, r *http.Request) *http
 	user = &portainer.User{
 		Username: payload.Username,
 		Role:     portainer.UserRole(payload.Role),
+		Email:    payload.Email,
 	}
 
 	settings, err := handler.DataStore.Settings().Settings()
@@ -87,6 +97,8 @@ func (handler *Handler) userCreate(w http.ResponseWriter, r *http.Request) *http
 		if err != nil {
 			return httperror.InternalServerError("Unable to hash user password", errCryptoHashFailure)
 		}
+
+		user.ForcePasswordChange = payload.ForcePasswordChange
 	}
 
 	err = handler.DataStore.User().Create(user)
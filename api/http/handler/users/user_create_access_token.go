@@ -80,6 +80,10 @@ func (handler *Handler) userCreateAccessToken(w http.ResponseWriter, r *http.Req
 		return httperror.Forbidden("Permission denied to create user access token", httperrors.ErrUnauthorized)
 	}
 
+	if tokenData.ImpersonatorID != 0 {
+		return httperror.Forbidden("Access tokens cannot be created while impersonating a user", errImpersonatedSession)
+	}
+
 	user, err := handler.DataStore.User().Read(portainer.UserID(userID))
 	if err != nil {
 		return httperror.BadRequest("Unable to find a user", err)
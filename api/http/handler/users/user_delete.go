@@ -94,6 +94,11 @@ func (handler *Handler) deleteUser(w http.ResponseWriter, user *portainer.User)
 		return httperror.InternalServerError("Unable to remove user memberships from the database", err)
 	}
 
+	err = handler.DataStore.SavedView().DeleteByOwnerID(user.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the saved views of the user from the database", err)
+	}
+
 	// Remove all of the users persisted API keys
 	apiKeys, err := handler.apiKeyService.GetAPIKeys(user.ID)
 	if err != nil {
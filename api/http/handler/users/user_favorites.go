@@ -0,0 +1,208 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// maxUserFavorites bounds the number of favorites of a user, the status of all of them being computed in one request
+const maxUserFavorites = 500
+
+type userFavoritesUpdatePayload struct {
+	// Containers, stacks and environments watched by the user, replacing the existing ones
+	Favorites []portainer.UserFavorite
+}
+
+func (payload *userFavoritesUpdatePayload) Validate(r *http.Request) error {
+	if len(payload.Favorites) > maxUserFavorites {
+		return fmt.Errorf("a user cannot have more than %d favorites", maxUserFavorites)
+	}
+
+	for _, favorite := range payload.Favorites {
+		if favorite.EndpointID == 0 {
+			return errors.New("invalid favorite, the environment identifier is required")
+		}
+
+		switch favorite.Type {
+		case portainer.UserFavoriteEndpoint:
+			if favorite.StackID != 0 || favorite.ContainerName != "" {
+				return errors.New("invalid environment favorite, it cannot reference a stack or a container")
+			}
+		case portainer.UserFavoriteStack:
+			if favorite.StackID == 0 || favorite.ContainerName != "" {
+				return errors.New("invalid stack favorite, the stack identifier is required")
+			}
+		case portainer.UserFavoriteContainer:
+			if favorite.ContainerName == "" || favorite.StackID != 0 {
+				return errors.New("invalid container favorite, the container name is required")
+			}
+		default:
+			return errors.New("invalid favorite type, it must be one of endpoint, stack or container")
+		}
+	}
+
+	return nil
+}
+
+// @id UserFavoritesList
+// @summary List the favorites of a user
+// @description List the containers, the stacks and the environments watched by a user.
+// @description Only the user or an administrator can list the favorites.
+// @description **Access policy**: restricted
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "User identifier"
+// @success 200 {array} portainer.UserFavorite "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/favorites [get]
+func (handler *Handler) userFavoritesList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	user, httpErr := handler.favoritesOwner(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	favorites := user.Favorites
+	if favorites == nil {
+		favorites = []portainer.UserFavorite{}
+	}
+
+	return response.JSON(w, favorites)
+}
+
+// @id UserFavoritesUpdate
+// @summary Update the favorites of a user
+// @description Replace the containers, the stacks and the environments watched by a user. The containers are
+// @description referenced by their name, which is kept when they are recreated.
+// @description Only the user or an administrator can update the favorites.
+// @description **Access policy**: restricted
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "User identifier"
+// @param body body userFavoritesUpdatePayload true "Favorites"
+// @success 200 {array} portainer.UserFavorite "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/favorites [put]
+func (handler *Handler) userFavoritesUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload userFavoritesUpdatePayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	owner, httpErr := handler.favoritesOwner(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	favorites := []portainer.UserFavorite{}
+	for _, favorite := range payload.Favorites {
+		if !slices.Contains(favorites, favorite) {
+			favorites = append(favorites, favorite)
+		}
+	}
+
+	if err := validateFavoriteResources(handler.DataStore, favorites); err != nil {
+		var httpErr *httperror.HandlerError
+		if errors.As(err, &httpErr) {
+			return httpErr
+		}
+
+		return httperror.InternalServerError("Unable to validate the resources of the favorites", err)
+	}
+
+	err := handler.DataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		user, err := tx.User().Read(owner.ID)
+		if err != nil {
+			return err
+		}
+
+		user.Favorites = favorites
+
+		return tx.User().Update(user.ID, user)
+	})
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the favorites of the user inside the database", err)
+	}
+
+	return response.JSON(w, favorites)
+}
+
+// favoritesOwner returns the user whose favorites are requested, the users only managing their own favorites
+func (handler *Handler) favoritesOwner(r *http.Request) (*portainer.User, *httperror.HandlerError) {
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid user identifier route variable", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	if tokenData.Role != portainer.AdministratorRole && tokenData.ID != portainer.UserID(userID) {
+		return nil, httperror.Forbidden("Permission denied to access the favorites of the user", httperrors.ErrUnauthorized)
+	}
+
+	user, err := handler.DataStore.User().Read(portainer.UserID(userID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+	}
+
+	return user, nil
+}
+
+// validateFavoriteResources ensures the environments and the stacks of the favorites exist, the containers being
+// only known through the snapshots they can be watched before being created
+func validateFavoriteResources(dataStore dataservices.DataStore, favorites []portainer.UserFavorite) error {
+	for _, favorite := range favorites {
+		endpoint, err := dataStore.Endpoint().Endpoint(favorite.EndpointID)
+		if dataStore.IsErrObjectNotFound(err) {
+			return httperror.BadRequest(fmt.Sprintf("Unable to find the environment %d of a favorite", favorite.EndpointID), err)
+		} else if err != nil {
+			return err
+		}
+
+		switch favorite.Type {
+		case portainer.UserFavoriteStack:
+			stack, err := dataStore.Stack().Read(favorite.StackID)
+			if dataStore.IsErrObjectNotFound(err) {
+				return httperror.BadRequest(fmt.Sprintf("Unable to find the stack %d of a favorite", favorite.StackID), err)
+			} else if err != nil {
+				return err
+			}
+
+			if stack.EndpointID != endpoint.ID {
+				return httperror.BadRequest(fmt.Sprintf("The stack %d is not deployed on the environment %d", stack.ID, endpoint.ID), errors.New("stack environment mismatch"))
+			}
+		case portainer.UserFavoriteContainer:
+			if !endpointutils.IsDockerEndpoint(endpoint) {
+				return httperror.BadRequest(fmt.Sprintf("The environment %d is not a Docker environment", endpoint.ID), errors.New("unsupported environment type"))
+			}
+		}
+	}
+
+	return nil
+}
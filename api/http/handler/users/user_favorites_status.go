@@ -0,0 +1,294 @@
+package users
+
+import (
+	"net/http"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+const (
+	// favoriteStatusNotFound is the status of the favorites whose resource no longer exists
+	favoriteStatusNotFound = "not_found"
+	// favoriteStatusDenied is the status of the favorites whose resource the user cannot access anymore
+	favoriteStatusDenied = "denied"
+	// favoriteStatusUnknown is the status of the container favorites of the environments without snapshot
+	favoriteStatusUnknown = "unknown"
+)
+
+type favoriteStatus struct {
+	Favorite portainer.UserFavorite `json:"Favorite"`
+	// Name of the environment, of the stack or of the container
+	Name string `json:"Name" example:"web"`
+	// Name of the environment of the favorite
+	EndpointName string `json:"EndpointName" example:"production"`
+	// Status of the environment of the favorite, up or down
+	EndpointStatus string `json:"EndpointStatus" example:"up"`
+	// Status of the favorite: up or down for the environments, active or inactive for the stacks, the state of the
+	// container for the containers, or one of not_found, denied and unknown
+	Status string `json:"Status" example:"running"`
+	// Health of the container, when it defines a health check
+	Health string `json:"Health,omitempty" example:"healthy"`
+	// Unix timestamp of the snapshot the status of the container comes from
+	SnapshotTime int64 `json:"SnapshotTime,omitempty" example:"1700000000"`
+}
+
+// @id UserFavoritesStatus
+// @summary Inspect the status of the favorites of a user
+// @description Retrieve the status of the containers, the stacks and the environments watched by a user in a single
+// @description call. The status is read from the database and from the latest snapshots of the environments, the
+// @description environments themselves are not queried.
+// @description Only the user or an administrator can inspect the favorites.
+// @description **Access policy**: restricted
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "User identifier"
+// @success 200 {array} favoriteStatus "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/favorites/status [get]
+func (handler *Handler) userFavoritesStatus(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	user, httpErr := handler.favoritesOwner(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	securityContext, err := security.RetrieveRestrictedRequestContext(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve info from request context", err)
+	}
+
+	statuses, err := favoritesStatus(handler.DataStore, securityContext, user.Favorites)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the status of the favorites", err)
+	}
+
+	return response.JSON(w, statuses)
+}
+
+// favoriteResolver caches the environments, the snapshots and the resource controls shared by the favorites
+type favoriteResolver struct {
+	dataStore        dataservices.DataStore
+	securityContext  *security.RestrictedRequestContext
+	teamIDs          []portainer.TeamID
+	endpoints        map[portainer.EndpointID]*portainer.Endpoint
+	snapshots        map[portainer.EndpointID]*portainer.Snapshot
+	resourceControls []portainer.ResourceControl
+}
+
+func favoritesStatus(dataStore dataservices.DataStore, securityContext *security.RestrictedRequestContext, favorites []portainer.UserFavorite) ([]favoriteStatus, error) {
+	resolver := &favoriteResolver{
+		dataStore:       dataStore,
+		securityContext: securityContext,
+		endpoints:       make(map[portainer.EndpointID]*portainer.Endpoint),
+		snapshots:       make(map[portainer.EndpointID]*portainer.Snapshot),
+	}
+
+	for _, membership := range securityContext.UserMemberships {
+		resolver.teamIDs = append(resolver.teamIDs, membership.TeamID)
+	}
+
+	if !securityContext.IsAdmin {
+		resourceControls, err := dataStore.ResourceControl().ReadAll()
+		if err != nil {
+			return nil, err
+		}
+
+		resolver.resourceControls = resourceControls
+	}
+
+	statuses := make([]favoriteStatus, 0, len(favorites))
+	for _, favorite := range favorites {
+		status, err := resolver.status(favorite)
+		if err != nil {
+			return nil, err
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (resolver *favoriteResolver) status(favorite portainer.UserFavorite) (favoriteStatus, error) {
+	status := favoriteStatus{Favorite: favorite}
+
+	endpoint, err := resolver.endpoint(favorite.EndpointID)
+	if err != nil || endpoint == nil {
+		status.Status = favoriteStatusNotFound
+		return status, err
+	}
+
+	canAccess, err := resolver.canAccessEndpoint(endpoint)
+	if err != nil || !canAccess {
+		status.Status = favoriteStatusDenied
+		return status, err
+	}
+
+	status.EndpointName = endpoint.Name
+	status.EndpointStatus = endpointStatus(endpoint)
+
+	switch favorite.Type {
+	case portainer.UserFavoriteEndpoint:
+		status.Name = endpoint.Name
+		status.Status = status.EndpointStatus
+
+	case portainer.UserFavoriteStack:
+		return resolver.stackStatus(status)
+
+	case portainer.UserFavoriteContainer:
+		return resolver.containerStatus(status)
+	}
+
+	return status, nil
+}
+
+func (resolver *favoriteResolver) stackStatus(status favoriteStatus) (favoriteStatus, error) {
+	stack, err := resolver.dataStore.Stack().Read(status.Favorite.StackID)
+	if resolver.dataStore.IsErrObjectNotFound(err) || (err == nil && stack.EndpointID != status.Favorite.EndpointID) {
+		status.Status = favoriteStatusNotFound
+		return status, nil
+	} else if err != nil {
+		return status, err
+	}
+
+	if !resolver.canAccessResource(stack.EndpointID, stackutils.ResourceControlID(stack.EndpointID, stack.Name), portainer.StackResourceControl, nil) {
+		status.Status = favoriteStatusDenied
+		return status, nil
+	}
+
+	status.Name = stack.Name
+	status.Status = "inactive"
+	if stack.Status == portainer.StackStatusActive {
+		status.Status = "active"
+	}
+
+	return status, nil
+}
+
+func (resolver *favoriteResolver) containerStatus(status favoriteStatus) (favoriteStatus, error) {
+	snapshot, err := resolver.snapshot(status.Favorite.EndpointID)
+	if err != nil {
+		return status, err
+	}
+
+	if snapshot == nil || snapshot.Docker == nil {
+		status.Name = status.Favorite.ContainerName
+		status.Status = favoriteStatusUnknown
+		return status, nil
+	}
+
+	status.SnapshotTime = snapshot.Docker.Time
+
+	for _, container := range snapshot.Docker.SnapshotRaw.Containers {
+		if !containerHasName(container, status.Favorite.ContainerName) {
+			continue
+		}
+
+		if !resolver.canAccessResource(status.Favorite.EndpointID, container.ID, portainer.ContainerResourceControl, container.Labels) {
+			status.Status = favoriteStatusDenied
+			return status, nil
+		}
+
+		status.Name = status.Favorite.ContainerName
+		status.Status = container.State
+
+		switch {
+		case strings.Contains(container.Status, "(healthy)"):
+			status.Health = "healthy"
+		case strings.Contains(container.Status, "(unhealthy)"):
+			status.Health = "unhealthy"
+		case strings.Contains(container.Status, "(health: starting)"):
+			status.Health = "starting"
+		}
+
+		return status, nil
+	}
+
+	status.Status = favoriteStatusNotFound
+
+	return status, nil
+}
+
+func (resolver *favoriteResolver) endpoint(endpointID portainer.EndpointID) (*portainer.Endpoint, error) {
+	if endpoint, ok := resolver.endpoints[endpointID]; ok {
+		return endpoint, nil
+	}
+
+	endpoint, err := resolver.dataStore.Endpoint().Endpoint(endpointID)
+	if resolver.dataStore.IsErrObjectNotFound(err) {
+		endpoint = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	resolver.endpoints[endpointID] = endpoint
+
+	return endpoint, nil
+}
+
+func (resolver *favoriteResolver) snapshot(endpointID portainer.EndpointID) (*portainer.Snapshot, error) {
+	if snapshot, ok := resolver.snapshots[endpointID]; ok {
+		return snapshot, nil
+	}
+
+	snapshot, err := resolver.dataStore.Snapshot().Read(endpointID)
+	if resolver.dataStore.IsErrObjectNotFound(err) {
+		snapshot = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	resolver.snapshots[endpointID] = snapshot
+
+	return snapshot, nil
+}
+
+func (resolver *favoriteResolver) canAccessEndpoint(endpoint *portainer.Endpoint) (bool, error) {
+	if resolver.securityContext.IsAdmin {
+		return true, nil
+	}
+
+	endpointGroup, err := resolver.dataStore.EndpointGroup().Read(endpoint.GroupID)
+	if err != nil {
+		return false, err
+	}
+
+	return security.AuthorizedEndpointAccess(endpoint, endpointGroup, resolver.securityContext.UserID, resolver.securityContext.UserMemberships), nil
+}
+
+func (resolver *favoriteResolver) canAccessResource(endpointID portainer.EndpointID, resourceID string, resourceType portainer.ResourceControlType, labels map[string]string) bool {
+	if resolver.securityContext.IsAdmin {
+		return true
+	}
+
+	return authorization.UserCanAccessDockerResource(resolver.securityContext.UserID, resolver.teamIDs, endpointID, resourceID, resourceType, labels, resolver.resourceControls)
+}
+
+func containerHasName(container portainer.DockerContainerSnapshot, name string) bool {
+	for _, containerName := range container.Names {
+		if strings.TrimPrefix(containerName, "/") == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func endpointStatus(endpoint *portainer.Endpoint) string {
+	if endpoint.Status == portainer.EndpointStatusUp {
+		return "up"
+	}
+
+	return "down"
+}
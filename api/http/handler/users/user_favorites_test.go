@@ -0,0 +1,192 @@
+package users
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userFavorites(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	adminUser := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	err := store.User().Create(adminUser)
+	is.NoError(err, "error creating admin user")
+
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	err = store.User().Create(user)
+	is.NoError(err, "error creating user")
+
+	err = store.Endpoint().Create(&portainer.Endpoint{
+		ID:                 1,
+		Name:               "production",
+		Type:               portainer.AgentOnDockerEnvironment,
+		GroupID:            1,
+		Status:             portainer.EndpointStatusUp,
+		UserAccessPolicies: portainer.UserAccessPolicies{user.ID: {}},
+	})
+	is.NoError(err, "error creating environment")
+
+	err = store.Endpoint().Create(&portainer.Endpoint{ID: 2, Name: "staging", Type: portainer.KubernetesLocalEnvironment, GroupID: 1, Status: portainer.EndpointStatusDown})
+	is.NoError(err, "error creating environment")
+
+	err = store.Stack().Create(&portainer.Stack{ID: 1, Name: "web", EndpointID: 1, Type: portainer.DockerComposeStack, Status: portainer.StackStatusActive})
+	is.NoError(err, "error creating stack")
+
+	err = store.ResourceControl().Create(&portainer.ResourceControl{
+		ID:           1,
+		ResourceID:   "1_web",
+		Type:         portainer.StackResourceControl,
+		UserAccesses: []portainer.UserResourceAccess{{UserID: user.ID, AccessLevel: portainer.ReadWriteAccessLevel}},
+	})
+	is.NoError(err, "error creating resource control")
+
+	err = store.Snapshot().Create(&portainer.Snapshot{EndpointID: 1, Docker: &portainer.DockerSnapshot{
+		Time: 1700000000,
+		SnapshotRaw: portainer.DockerSnapshotRaw{Containers: []portainer.DockerContainerSnapshot{
+			{Container: types.Container{ID: "c1", Names: []string{"/web-nginx-1"}, State: "running", Status: "Up 2 hours (healthy)", Labels: map[string]string{"com.docker.compose.project": "web"}}},
+			{Container: types.Container{ID: "c2", Names: []string{"/db"}, State: "exited", Status: "Exited (0) 1 hour ago"}},
+		}},
+	}})
+	is.NoError(err, "error creating snapshot")
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, nil, passwordChecker)
+	h.DataStore = store
+
+	adminJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: adminUser.ID, Username: adminUser.Username, Role: adminUser.Role})
+	userJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	do := func(method, path, token string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			err := json.NewEncoder(&body).Encode(payload)
+			is.NoError(err, "error encoding payload")
+		}
+
+		req := httptest.NewRequest(method, path, &body)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	favorites := []portainer.UserFavorite{
+		{Type: portainer.UserFavoriteEndpoint, EndpointID: 1},
+		{Type: portainer.UserFavoriteStack, EndpointID: 1, StackID: 1},
+		{Type: portainer.UserFavoriteContainer, EndpointID: 1, ContainerName: "web-nginx-1"},
+		{Type: portainer.UserFavoriteContainer, EndpointID: 1, ContainerName: "db"},
+		{Type: portainer.UserFavoriteContainer, EndpointID: 1, ContainerName: "web-nginx-1"},
+		{Type: portainer.UserFavoriteEndpoint, EndpointID: 2},
+	}
+
+	t.Run("user can update their favorites", func(t *testing.T) {
+		rr := do(http.MethodPut, "/users/2/favorites", userJWT, userFavoritesUpdatePayload{Favorites: favorites})
+		is.Equal(http.StatusOK, rr.Code)
+
+		user, err := store.User().Read(user.ID)
+		is.NoError(err)
+		is.Len(user.Favorites, 5, "duplicate favorites should be dropped")
+	})
+
+	t.Run("user can list their favorites", func(t *testing.T) {
+		rr := do(http.MethodGet, "/users/2/favorites", userJWT, nil)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var resp []portainer.UserFavorite
+		is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+		is.Equal(favorites[:4], resp[:4])
+	})
+
+	t.Run("user cannot access the favorites of another user", func(t *testing.T) {
+		rr := do(http.MethodGet, "/users/1/favorites", userJWT, nil)
+		is.Equal(http.StatusForbidden, rr.Code)
+
+		rr = do(http.MethodPut, "/users/1/favorites", userJWT, userFavoritesUpdatePayload{})
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("favorites referencing missing or mismatching resources are rejected", func(t *testing.T) {
+		invalid := [][]portainer.UserFavorite{
+			{{Type: "volume", EndpointID: 1}},
+			{{Type: portainer.UserFavoriteStack, EndpointID: 1}},
+			{{Type: portainer.UserFavoriteEndpoint, EndpointID: 3}},
+			{{Type: portainer.UserFavoriteStack, EndpointID: 2, StackID: 1}},
+			{{Type: portainer.UserFavoriteContainer, EndpointID: 2, ContainerName: "web"}},
+		}
+
+		for _, favorites := range invalid {
+			rr := do(http.MethodPut, "/users/2/favorites", userJWT, userFavoritesUpdatePayload{Favorites: favorites})
+			is.Equal(http.StatusBadRequest, rr.Code)
+		}
+
+		user, err := store.User().Read(user.ID)
+		is.NoError(err)
+		is.Len(user.Favorites, 5, "the favorites should be left untouched")
+	})
+
+	t.Run("user retrieves the status of their favorites", func(t *testing.T) {
+		rr := do(http.MethodGet, "/users/2/favorites/status", userJWT, nil)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var resp []favoriteStatus
+		is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+		is.Len(resp, 5)
+		if len(resp) != 5 {
+			return
+		}
+
+		is.Equal("up", resp[0].Status)
+		is.Equal("production", resp[0].Name)
+
+		is.Equal("active", resp[1].Status)
+		is.Equal("web", resp[1].Name)
+
+		is.Equal("running", resp[2].Status)
+		is.Equal("healthy", resp[2].Health)
+		is.Equal(int64(1700000000), resp[2].SnapshotTime)
+
+		is.Equal(favoriteStatusDenied, resp[3].Status, "the container without resource control is restricted to the administrators")
+		is.Equal(favoriteStatusDenied, resp[4].Status, "the user has no access to the environment")
+		is.Empty(resp[4].EndpointName)
+	})
+
+	t.Run("admin retrieves the status of the favorites of a user", func(t *testing.T) {
+		rr := do(http.MethodGet, "/users/2/favorites/status", adminJWT, nil)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var resp []favoriteStatus
+		is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+		is.Len(resp, 5)
+		if len(resp) != 5 {
+			return
+		}
+
+		is.Equal("exited", resp[3].Status)
+		is.Equal("down", resp[4].Status)
+		is.Equal("staging", resp[4].EndpointName)
+	})
+}
@@ -0,0 +1,187 @@
+package users
+
+import (
+	"net/http"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type homeTeam struct {
+	ID   portainer.TeamID `json:"Id" example:"1"`
+	Name string           `json:"Name" example:"developers"`
+	// Environment the members of the team land on
+	DefaultEndpointID portainer.EndpointID `json:"DefaultEndpointId,omitempty" example:"1"`
+	// Environment group whose environments make the home of the members of the team
+	DefaultEndpointGroupID portainer.EndpointGroupID `json:"DefaultEndpointGroupId,omitempty" example:"2"`
+}
+
+type homeGroup struct {
+	ID   portainer.EndpointGroupID `json:"Id" example:"2"`
+	Name string                    `json:"Name" example:"team-a"`
+}
+
+type homeEndpoint struct {
+	ID      portainer.EndpointID      `json:"Id" example:"1"`
+	Name    string                    `json:"Name" example:"production"`
+	Type    portainer.EndpointType    `json:"Type" example:"1"`
+	Status  portainer.EndpointStatus  `json:"Status" example:"1"`
+	GroupID portainer.EndpointGroupID `json:"GroupId" example:"2"`
+}
+
+type userHome struct {
+	// Environment the user lands on, the default environment of the first of their teams defining an accessible one
+	DefaultEndpointID portainer.EndpointID `json:"DefaultEndpointId,omitempty" example:"1"`
+	// Teams of the user
+	Teams []homeTeam `json:"Teams"`
+	// Default environment groups of the teams of the user
+	Groups []homeGroup `json:"Groups"`
+	// Environments of the default groups and default environments of the teams which the user can access
+	Endpoints []homeEndpoint `json:"Endpoints"`
+}
+
+// @id UserHome
+// @summary Inspect the home of a user
+// @description Retrieve the environment a user lands on and the environments of the home of their teams, made of the default
+// @description environments and of the environments of the default environment groups of the teams. Only the environments the
+// @description user can access are returned, so that the navigation of the large installations is restricted to the relevant
+// @description environments. The home is empty when the teams of the user have no default environment or group.
+// @description Only the user or an administrator can inspect the home.
+// @description **Access policy**: restricted
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "User identifier"
+// @success 200 {object} userHome "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/home [get]
+func (handler *Handler) userHome(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	user, httpErr := handler.favoritesOwner(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	home, err := buildUserHome(handler.DataStore, user)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the home of the user", err)
+	}
+
+	return response.JSON(w, home)
+}
+
+// buildUserHome collects the default environments and environment groups of the teams of the user, ordered by team
+func buildUserHome(dataStore dataservices.DataStore, user *portainer.User) (*userHome, error) {
+	home := &userHome{Teams: []homeTeam{}, Groups: []homeGroup{}, Endpoints: []homeEndpoint{}}
+
+	memberships, err := dataStore.TeamMembership().TeamMembershipsByUserID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(memberships, func(a, b portainer.TeamMembership) int {
+		return int(a.TeamID) - int(b.TeamID)
+	})
+
+	endpointIDs := []portainer.EndpointID{}
+	groupIDs := []portainer.EndpointGroupID{}
+
+	for _, membership := range memberships {
+		team, err := dataStore.Team().Read(membership.TeamID)
+		if dataStore.IsErrObjectNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		home.Teams = append(home.Teams, homeTeam{
+			ID:                     team.ID,
+			Name:                   team.Name,
+			DefaultEndpointID:      team.DefaultEndpointID,
+			DefaultEndpointGroupID: team.DefaultEndpointGroupID,
+		})
+
+		if team.DefaultEndpointID != 0 {
+			endpointIDs = append(endpointIDs, team.DefaultEndpointID)
+		}
+
+		if team.DefaultEndpointGroupID != 0 && !slices.Contains(groupIDs, team.DefaultEndpointGroupID) {
+			groupIDs = append(groupIDs, team.DefaultEndpointGroupID)
+		}
+	}
+
+	if len(endpointIDs) == 0 && len(groupIDs) == 0 {
+		return home, nil
+	}
+
+	groups, err := dataStore.EndpointGroup().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	groupsByID := make(map[portainer.EndpointGroupID]*portainer.EndpointGroup, len(groups))
+	for i := range groups {
+		groupsByID[groups[i].ID] = &groups[i]
+	}
+
+	for _, groupID := range groupIDs {
+		if group, ok := groupsByID[groupID]; ok {
+			home.Groups = append(home.Groups, homeGroup{ID: group.ID, Name: group.Name})
+		}
+	}
+
+	endpoints, err := dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(endpoints, func(a, b portainer.Endpoint) int {
+		return int(a.ID) - int(b.ID)
+	})
+
+	canAccess := func(endpoint *portainer.Endpoint) bool {
+		if user.Role == portainer.AdministratorRole {
+			return true
+		}
+
+		group, ok := groupsByID[endpoint.GroupID]
+		if !ok {
+			group = &portainer.EndpointGroup{}
+		}
+
+		return security.AuthorizedEndpointAccess(endpoint, group, user.ID, memberships)
+	}
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+
+		if !slices.Contains(endpointIDs, endpoint.ID) && !slices.Contains(groupIDs, endpoint.GroupID) || !canAccess(endpoint) {
+			continue
+		}
+
+		home.Endpoints = append(home.Endpoints, homeEndpoint{
+			ID:      endpoint.ID,
+			Name:    endpoint.Name,
+			Type:    endpoint.Type,
+			Status:  endpoint.Status,
+			GroupID: endpoint.GroupID,
+		})
+	}
+
+	// the teams are ordered, the first accessible default environment is the landing one
+	for _, endpointID := range endpointIDs {
+		if slices.ContainsFunc(home.Endpoints, func(endpoint homeEndpoint) bool { return endpoint.ID == endpointID }) {
+			home.DefaultEndpointID = endpointID
+			break
+		}
+	}
+
+	return home, nil
+}
@@ -0,0 +1,136 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userHome(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	adminUser := &portainer.User{Username: "admin", Role: portainer.AdministratorRole}
+	err := store.User().Create(adminUser)
+	is.NoError(err, "error creating admin user")
+
+	user := &portainer.User{Username: "standard", Role: portainer.StandardUserRole}
+	err = store.User().Create(user)
+	is.NoError(err, "error creating user")
+
+	loneUser := &portainer.User{Username: "lone", Role: portainer.StandardUserRole}
+	err = store.User().Create(loneUser)
+	is.NoError(err, "error creating user")
+
+	developers := &portainer.Team{Name: "developers"}
+	err = store.Team().Create(developers)
+	is.NoError(err, "error creating team")
+
+	group := &portainer.EndpointGroup{Name: "team-a", TeamAccessPolicies: portainer.TeamAccessPolicies{developers.ID: {}}}
+	err = store.EndpointGroup().Create(group)
+	is.NoError(err, "error creating environment group")
+
+	endpoints := []*portainer.Endpoint{
+		{ID: 1, Name: "production", GroupID: 1, Status: portainer.EndpointStatusUp, UserAccessPolicies: portainer.UserAccessPolicies{user.ID: {}}},
+		{ID: 2, Name: "team-a-staging", GroupID: group.ID, Status: portainer.EndpointStatusDown},
+		{ID: 3, Name: "restricted", GroupID: 1, Status: portainer.EndpointStatusUp},
+		{ID: 4, Name: "unrelated", GroupID: 1, Status: portainer.EndpointStatusUp, UserAccessPolicies: portainer.UserAccessPolicies{user.ID: {}}},
+	}
+	for _, endpoint := range endpoints {
+		err = store.Endpoint().Create(endpoint)
+		is.NoError(err, "error creating environment")
+	}
+
+	// the default environment of the first team is not accessible to the user
+	developers.DefaultEndpointID = 3
+	developers.DefaultEndpointGroupID = group.ID
+	err = store.Team().Update(developers.ID, developers)
+	is.NoError(err, "error updating team")
+
+	operators := &portainer.Team{Name: "operators", DefaultEndpointID: 1}
+	err = store.Team().Create(operators)
+	is.NoError(err, "error creating team")
+
+	for _, teamID := range []portainer.TeamID{operators.ID, developers.ID} {
+		err = store.TeamMembership().Create(&portainer.TeamMembership{UserID: user.ID, TeamID: teamID, Role: portainer.TeamMember})
+		is.NoError(err, "error creating team membership")
+	}
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, nil, passwordChecker)
+	h.DataStore = store
+
+	adminJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: adminUser.ID, Username: adminUser.Username, Role: adminUser.Role})
+	userJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+	loneJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: loneUser.ID, Username: loneUser.Username, Role: loneUser.Role})
+
+	get := func(userID portainer.UserID, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/users/%d/home", userID), nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	expectedHome := func(rr *httptest.ResponseRecorder) {
+		is.Equal(http.StatusOK, rr.Code)
+
+		var home userHome
+		is.NoError(json.NewDecoder(rr.Body).Decode(&home))
+
+		is.Equal(portainer.EndpointID(1), home.DefaultEndpointID, "the first accessible default environment should be the landing one")
+		is.Equal([]homeTeam{
+			{ID: developers.ID, Name: "developers", DefaultEndpointID: 3, DefaultEndpointGroupID: group.ID},
+			{ID: operators.ID, Name: "operators", DefaultEndpointID: 1},
+		}, home.Teams)
+		is.Equal([]homeGroup{{ID: group.ID, Name: "team-a"}}, home.Groups)
+		is.Equal([]homeEndpoint{
+			{ID: 1, Name: "production", GroupID: 1, Status: portainer.EndpointStatusUp},
+			{ID: 2, Name: "team-a-staging", GroupID: group.ID, Status: portainer.EndpointStatusDown},
+		}, home.Endpoints, "only the accessible environments of the home should be returned")
+	}
+
+	t.Run("user retrieves their home", func(t *testing.T) {
+		expectedHome(get(user.ID, userJWT))
+	})
+
+	t.Run("admin retrieves the home of a user", func(t *testing.T) {
+		expectedHome(get(user.ID, adminJWT))
+	})
+
+	t.Run("user without teams has an empty home", func(t *testing.T) {
+		rr := get(loneUser.ID, loneJWT)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var home userHome
+		is.NoError(json.NewDecoder(rr.Body).Decode(&home))
+		is.Zero(home.DefaultEndpointID)
+		is.Empty(home.Teams)
+		is.Empty(home.Endpoints)
+	})
+
+	t.Run("user cannot retrieve the home of another user", func(t *testing.T) {
+		rr := get(user.ID, loneJWT)
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+}
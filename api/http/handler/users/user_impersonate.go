@@ -0,0 +1,98 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/lifecycle"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/rs/zerolog/log"
+)
+
+var errCannotImpersonateAdmin = errors.New("Administrators cannot be impersonated")
+
+type impersonateResponse struct {
+	// JWT token used to authenticate against the API as the impersonated user
+	JWT string `json:"jwt" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"`
+}
+
+// @id UserImpersonate
+// @summary Impersonate a user
+// @description Issue a token allowing an administrator to use Portainer as the specified user, e.g. to troubleshoot
+// @description the environments and the resources the user can access. The token is valid for one hour at most and
+// @description every request made with it is logged along with the administrator. Administrators cannot be impersonated.
+// @description **Access policy**: administrator
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "User identifier"
+// @success 200 {object} impersonateResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/impersonate [post]
+func (handler *Handler) userImpersonate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid user identifier route variable", err)
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	if tokenData.ImpersonatorID != 0 {
+		return httperror.Forbidden("Permission denied to impersonate a user", errImpersonatedSession)
+	}
+
+	user, err := handler.DataStore.User().Read(portainer.UserID(userID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+	}
+
+	if user.Role == portainer.AdministratorRole {
+		return httperror.Forbidden("Permission denied to impersonate an administrator", errCannotImpersonateAdmin)
+	}
+
+	if user.Pending {
+		return httperror.Forbidden("Permission denied to impersonate a user pending approval", httperrors.ErrAccountPendingApproval)
+	}
+
+	token, err := handler.JWTService.GenerateToken(&portainer.TokenData{
+		ID:             user.ID,
+		Username:       user.Username,
+		Role:           user.Role,
+		ImpersonatorID: tokenData.ID,
+	})
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate JWT token", err)
+	}
+
+	log.Info().
+		Int("impersonator_id", int(tokenData.ID)).
+		Str("impersonator", tokenData.Username).
+		Int("user_id", int(user.ID)).
+		Str("user", user.Username).
+		Msg("user impersonation started")
+
+	if handler.EventDispatcher != nil {
+		handler.EventDispatcher.Publish(lifecycle.NewEvent(lifecycle.UserImpersonated, strconv.Itoa(int(user.ID)), map[string]string{
+			"username":     user.Username,
+			"impersonator": tokenData.Username,
+		}))
+	}
+
+	return response.JSON(w, impersonateResponse{JWT: token})
+}
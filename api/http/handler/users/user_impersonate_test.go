@@ -0,0 +1,96 @@
+package users
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userImpersonate(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	adminUser := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(adminUser))
+
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+	h.JWTService = jwtService
+
+	adminJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: adminUser.ID, Username: adminUser.Username, Role: adminUser.Role})
+
+	impersonate := func(userID portainer.UserID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/users/%d/impersonate", userID), nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", adminJWT))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("administrators cannot be impersonated", func(t *testing.T) {
+		rr := impersonate(adminUser.ID)
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("admin impersonates a standard user", func(t *testing.T) {
+		rr := impersonate(user.ID)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var resp impersonateResponse
+		is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+
+		tokenData, err := jwtService.ParseAndVerifyToken(resp.JWT)
+		is.NoError(err)
+		is.Equal(user.ID, tokenData.ID)
+		is.Equal(portainer.StandardUserRole, tokenData.Role)
+		is.Equal(adminUser.ID, tokenData.ImpersonatorID)
+
+		body, _ := json.Marshal(userAccessTokenCreatePayload{Description: "test-token"})
+		req := httptest.NewRequest(http.MethodPost, "/users/2/tokens", bytes.NewReader(body))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", resp.JWT))
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("impersonation token is revoked with the sessions of the admin", func(t *testing.T) {
+		rr := impersonate(user.ID)
+		is.Equal(http.StatusOK, rr.Code)
+
+		var resp impersonateResponse
+		is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+
+		admin, err := store.User().Read(adminUser.ID)
+		is.NoError(err)
+		admin.TokenIssueAt = time.Now().Add(time.Minute).Unix()
+		is.NoError(store.User().Update(admin.ID, admin))
+
+		_, err = jwtService.ParseAndVerifyToken(resp.JWT)
+		is.Error(err)
+	})
+}
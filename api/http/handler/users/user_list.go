@@ -2,6 +2,7 @@ package users
 
 import (
 	"net/http"
+	"slices"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/security"
@@ -21,6 +22,7 @@ import (
 // @security jwt
 // @produce json
 // @param environmentId query int false "Identifier of the environment(endpoint) that will be used to filter the authorized users"
+// @param name query string false "Only list the user with this exact username"
 // @success 200 {array} portainer.User "Success"
 // @failure 400 "Invalid request"
 // @failure 500 "Server error"
@@ -40,6 +42,12 @@ func (handler *Handler) userList(w http.ResponseWriter, r *http.Request) *httper
 		return httperror.InternalServerError("Unable to retrieve users from the database", err)
 	}
 
+	if name, _ := request.RetrieveQueryParameter(r, "name", true); name != "" {
+		users = slices.DeleteFunc(users, func(user portainer.User) bool {
+			return user.Username != name
+		})
+	}
+
 	endpointID, _ := request.RetrieveNumericQueryParameter(r, "environmentId", true)
 	if endpointID == 0 {
 		return response.JSON(w, users)
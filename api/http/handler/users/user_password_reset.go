@@ -0,0 +1,267 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/mail"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// passwordResetTokenPrefix identifies the password reset tokens, e.g. in the secret scanners
+	passwordResetTokenPrefix = "ptp_"
+
+	defaultPasswordResetExpiry = time.Hour
+	minPasswordResetExpiry     = 5 * time.Minute
+	maxPasswordResetExpiry     = 72 * time.Hour
+)
+
+type passwordResetCreatePayload struct {
+	// Validity of the token, defaults to 1h and must be between 5m and 72h
+	ExpiresIn string `example:"1h"`
+}
+
+func (payload *passwordResetCreatePayload) Validate(r *http.Request) error {
+	if payload.ExpiresIn == "" {
+		return nil
+	}
+
+	expiry, err := time.ParseDuration(payload.ExpiresIn)
+	if err != nil || expiry < minPasswordResetExpiry || expiry > maxPasswordResetExpiry {
+		return errors.New("invalid expiry, a duration between 5m and 72h is expected")
+	}
+
+	return nil
+}
+
+type passwordResetCreateResponse struct {
+	// Token to hand over to the user, it cannot be retrieved afterwards
+	Token string `json:"Token" example:"ptp_Yk5uQ0Z2eVQ0d0pHa2hUbU1Ya1N5Z2FqU0VQd3J2Tnk"`
+	// Unix timestamp of the expiry of the token
+	ExpiresAt int64 `json:"ExpiresAt" example:"1700003600"`
+	// Whether the token was emailed to the user
+	Emailed bool `json:"Emailed" example:"true"`
+}
+
+// @id UserPasswordResetCreate
+// @summary Generate a password reset token
+// @description Generate a time-limited token allowing a user using internal authentication to choose a new password.
+// @description Any token previously generated for the user is replaced. The token is only returned in this response,
+// @description and emailed to the user when the user has an email address and the emails are enabled.
+// @description **Access policy**: administrator
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "User identifier"
+// @param body body passwordResetCreatePayload false "Password reset details"
+// @success 200 {object} passwordResetCreateResponse "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 500 "Server error"
+// @router /users/{id}/password_reset [post]
+func (handler *Handler) userCreatePasswordReset(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return httperror.BadRequest("Invalid user identifier route variable", err)
+	}
+
+	if handler.demoService.IsDemoUser(portainer.UserID(userID)) {
+		return httperror.Forbidden(httperrors.ErrNotAvailableInDemo.Error(), httperrors.ErrNotAvailableInDemo)
+	}
+
+	var payload passwordResetCreatePayload
+	if r.ContentLength != 0 {
+		if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+			return httperror.BadRequest("Invalid request payload", err)
+		}
+	}
+
+	user, err := handler.DataStore.User().Read(portainer.UserID(userID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+	}
+
+	internal, err := handler.usesInternalAuthentication(user)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	if !internal {
+		return httperror.BadRequest("A password reset can only be generated for users using internal authentication", errPasswordNotManaged)
+	}
+
+	expiry := defaultPasswordResetExpiry
+	if payload.ExpiresIn != "" {
+		expiry, _ = time.ParseDuration(payload.ExpiresIn)
+	}
+
+	token, digest, err := generatePasswordResetToken()
+	if err != nil {
+		return httperror.InternalServerError("Unable to generate the password reset token", err)
+	}
+
+	user.PasswordResetTokenDigest = digest
+	user.PasswordResetExpiresAt = time.Now().Add(expiry).Unix()
+
+	if err := handler.DataStore.User().Update(user.ID, user); err != nil {
+		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+	}
+
+	return response.JSON(w, passwordResetCreateResponse{
+		Token:     token,
+		ExpiresAt: user.PasswordResetExpiresAt,
+		Emailed:   handler.emailPasswordReset(user, token),
+	})
+}
+
+// emailPasswordReset sends the password reset token to the user, returning whether the email was sent
+func (handler *Handler) emailPasswordReset(user *portainer.User, token string) bool {
+	if user.Email == "" || handler.MailService == nil || !handler.MailService.Enabled() {
+		return false
+	}
+
+	err := handler.MailService.Send([]string{user.Email}, mail.TemplatePasswordReset, mail.PasswordResetData{
+		Username:  user.Username,
+		Token:     token,
+		ExpiresAt: time.Unix(user.PasswordResetExpiresAt, 0),
+	})
+	if err != nil {
+		log.Warn().Err(err).Int("user_id", int(user.ID)).Msg("unable to email the password reset token")
+		return false
+	}
+
+	return true
+}
+
+type passwordResetRedeemPayload struct {
+	// Password reset token generated by an administrator
+	Token string `validate:"required" example:"ptp_Yk5uQ0Z2eVQ0d0pHa2hUbU1Ya1N5Z2FqU0VQd3J2Tnk"`
+	// New password
+	NewPassword string `validate:"required" example:"asfj2emv"`
+}
+
+func (payload *passwordResetRedeemPayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Token) {
+		return errors.New("invalid token")
+	}
+
+	if govalidator.IsNull(payload.NewPassword) {
+		return errors.New("invalid new password")
+	}
+
+	return nil
+}
+
+// @id UserPasswordResetRedeem
+// @summary Reset a password
+// @description Set a new password using a password reset token generated by an administrator.
+// @description The token can only be used once and the existing sessions of the user are revoked.
+// @description **Access policy**: public
+// @tags users
+// @accept json
+// @param body body passwordResetRedeemPayload true "Password reset details"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 401 "Invalid or expired password reset token"
+// @failure 500 "Server error"
+// @router /users/password_reset [post]
+func (handler *Handler) userRedeemPasswordReset(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload passwordResetRedeemPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	user, err := handler.userByPasswordResetToken(payload.Token)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve users from the database", err)
+	}
+
+	if user == nil || time.Now().Unix() > user.PasswordResetExpiresAt {
+		return httperror.Unauthorized("Invalid password reset token", errInvalidPasswordResetToken)
+	}
+
+	if !handler.passwordStrengthChecker.Check(payload.NewPassword) {
+		return httperror.BadRequest("Password does not meet the minimum strength requirements", nil)
+	}
+
+	user.Password, err = handler.CryptoService.Hash(payload.NewPassword)
+	if err != nil {
+		return httperror.InternalServerError("Unable to hash user password", errCryptoHashFailure)
+	}
+
+	user.PasswordResetTokenDigest = nil
+	user.PasswordResetExpiresAt = 0
+	user.ForcePasswordChange = false
+	user.TokenIssueAt = time.Now().Unix()
+
+	if err := handler.DataStore.User().Update(user.ID, user); err != nil {
+		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+	}
+
+	handler.apiKeyService.InvalidateUserKeyCache(user.ID)
+
+	return response.Empty(w)
+}
+
+// userByPasswordResetToken returns the user the password reset token was generated for, or nil when no user matches
+func (handler *Handler) userByPasswordResetToken(token string) (*portainer.User, error) {
+	digest := sha256.Sum256([]byte(token))
+
+	users, err := handler.DataStore.User().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range users {
+		if len(users[i].PasswordResetTokenDigest) > 0 && subtle.ConstantTimeCompare(users[i].PasswordResetTokenDigest, digest[:]) == 1 {
+			return &users[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// usesInternalAuthentication returns whether the password of the user is managed by Portainer
+func (handler *Handler) usesInternalAuthentication(user *portainer.User) (bool, error) {
+	if user.ID == 1 {
+		return true, nil
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return false, err
+	}
+
+	return settings.AuthenticationMethod == portainer.AuthenticationInternal, nil
+}
+
+// generatePasswordResetToken returns a random token and its SHA256 digest
+func generatePasswordResetToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+
+	token := passwordResetTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	digest := sha256.Sum256([]byte(token))
+
+	return token, digest[:], nil
+}
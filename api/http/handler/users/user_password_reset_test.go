@@ -0,0 +1,122 @@
+package users
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userPasswordReset(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	adminUser := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(adminUser))
+
+	user := &portainer.User{ID: 2, Username: "standard", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+	h.CryptoService = &crypto.Service{}
+
+	adminJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: adminUser.ID, Username: adminUser.Username, Role: adminUser.Role})
+	userJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role})
+
+	createReset := func(jwt string, payload passwordResetCreatePayload) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+
+		req := httptest.NewRequest(http.MethodPost, "/users/2/password_reset", bytes.NewReader(body))
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", jwt))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	redeemReset := func(token, newPassword string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(passwordResetRedeemPayload{Token: token, NewPassword: newPassword})
+
+		req := httptest.NewRequest(http.MethodPost, "/users/password_reset", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("standard user cannot generate a reset token", func(t *testing.T) {
+		rr := createReset(userJWT, passwordResetCreatePayload{})
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("expiry out of bounds is rejected", func(t *testing.T) {
+		rr := createReset(adminJWT, passwordResetCreatePayload{ExpiresIn: "1000h"})
+		is.Equal(http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("unknown token is rejected", func(t *testing.T) {
+		rr := redeemReset("ptp_unknown", "the-new-password")
+		is.Equal(http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("user resets the password with the generated token", func(t *testing.T) {
+		rr := createReset(adminJWT, passwordResetCreatePayload{ExpiresIn: "30m"})
+		is.Equal(http.StatusOK, rr.Code)
+
+		var resp passwordResetCreateResponse
+		is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+		is.NotEmpty(resp.Token)
+		is.InDelta(time.Now().Add(30*time.Minute).Unix(), resp.ExpiresAt, 5)
+
+		rr = redeemReset(resp.Token, "short")
+		is.Equal(http.StatusBadRequest, rr.Code)
+
+		rr = redeemReset(resp.Token, "the-new-password")
+		is.Equal(http.StatusNoContent, rr.Code)
+
+		user, err := store.User().Read(2)
+		is.NoError(err)
+		is.Empty(user.PasswordResetTokenDigest)
+		is.NoError(h.CryptoService.CompareHashAndData(user.Password, "the-new-password"))
+
+		rr = redeemReset(resp.Token, "another-new-password")
+		is.Equal(http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("expired token is rejected", func(t *testing.T) {
+		rr := createReset(adminJWT, passwordResetCreatePayload{})
+		is.Equal(http.StatusOK, rr.Code)
+
+		var resp passwordResetCreateResponse
+		is.NoError(json.NewDecoder(rr.Body).Decode(&resp))
+
+		user, err := store.User().Read(2)
+		is.NoError(err)
+		user.PasswordResetExpiresAt = time.Now().Add(-time.Minute).Unix()
+		is.NoError(store.User().Update(user.ID, user))
+
+		rr = redeemReset(resp.Token, "the-new-password")
+		is.Equal(http.StatusUnauthorized, rr.Code)
+	})
+}
@@ -0,0 +1,202 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/lifecycle"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+
+	"github.com/asaskevich/govalidator"
+)
+
+var (
+	errSignupDisabled = errors.New("Account requests are disabled")
+	errUserNotPending = errors.New("The user is not pending approval")
+)
+
+type userSignupPayload struct {
+	Username string `validate:"required" example:"bob"`
+	Password string `validate:"required" example:"cg9Wgky3"`
+	// Email address used to notify the user of the approval
+	Email string `example:"bob@example.com"`
+}
+
+func (payload *userSignupPayload) Validate(r *http.Request) error {
+	if govalidator.IsNull(payload.Username) || govalidator.Contains(payload.Username, " ") {
+		return errors.New("Invalid username. Must not contain any whitespace")
+	}
+
+	if govalidator.IsNull(payload.Password) {
+		return errors.New("Invalid password")
+	}
+
+	if payload.Email != "" && !govalidator.IsEmail(payload.Email) {
+		return errors.New("Invalid email address")
+	}
+
+	return nil
+}
+
+// @id UserSignup
+// @summary Request an account
+// @description Request a regular user account. The account cannot be used before an administrator approves it.
+// @description Only available when the account requests are enabled and the internal authentication is used.
+// @description **Access policy**: public
+// @tags users
+// @accept json
+// @param body body userSignupPayload true "Account details"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Account requests are disabled"
+// @failure 409 "User already exists"
+// @failure 500 "Server error"
+// @router /users/signup [post]
+func (handler *Handler) userSignup(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload userSignupPayload
+	if err := request.DecodeAndValidateJSONPayload(r, &payload); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	settings, err := handler.DataStore.Settings().Settings()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+	}
+
+	if !settings.SelfSignup.Enabled || settings.AuthenticationMethod != portainer.AuthenticationInternal {
+		return httperror.Forbidden("Account requests are disabled", errSignupDisabled)
+	}
+
+	user, err := handler.DataStore.User().UserByUsername(payload.Username)
+	if err != nil && !handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.InternalServerError("Unable to retrieve users from the database", err)
+	}
+	if user != nil {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "Another user with the same username already exists", Err: errUserAlreadyExists}
+	}
+
+	if !handler.passwordStrengthChecker.Check(payload.Password) {
+		return httperror.BadRequest("Password does not meet the requirements", nil)
+	}
+
+	user = &portainer.User{
+		Username: payload.Username,
+		Role:     portainer.StandardUserRole,
+		Email:    payload.Email,
+		Pending:  true,
+	}
+
+	user.Password, err = handler.CryptoService.Hash(payload.Password)
+	if err != nil {
+		return httperror.InternalServerError("Unable to hash user password", errCryptoHashFailure)
+	}
+
+	if err := handler.DataStore.User().Create(user); err != nil {
+		return httperror.InternalServerError("Unable to persist user inside the database", err)
+	}
+
+	handler.publishSignupEvent(lifecycle.UserSignupRequested, user)
+
+	return response.Empty(w)
+}
+
+// @id UserSignupApprove
+// @summary Approve an account request
+// @description Approve an account requested through the signup, the user can log in afterwards.
+// @description **Access policy**: administrator
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "User identifier"
+// @success 200 {object} portainer.User "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 409 "User is not pending approval"
+// @failure 500 "Server error"
+// @router /users/{id}/approve [post]
+func (handler *Handler) userSignupApprove(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	user, httpErr := handler.pendingUser(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	user.Pending = false
+
+	if err := handler.DataStore.User().Update(user.ID, user); err != nil {
+		return httperror.InternalServerError("Unable to persist user changes inside the database", err)
+	}
+
+	handler.publishSignupEvent(lifecycle.UserSignupApproved, user)
+
+	hideFields(user)
+	return response.JSON(w, user)
+}
+
+// @id UserSignupReject
+// @summary Reject an account request
+// @description Reject an account requested through the signup, the user is removed.
+// @description **Access policy**: administrator
+// @tags users
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "User identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 403 "Permission denied"
+// @failure 404 "User not found"
+// @failure 409 "User is not pending approval"
+// @failure 500 "Server error"
+// @router /users/{id}/reject [post]
+func (handler *Handler) userSignupReject(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	user, httpErr := handler.pendingUser(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if httpErr := handler.deleteUser(w, user); httpErr != nil {
+		return httpErr
+	}
+
+	handler.publishSignupEvent(lifecycle.UserSignupRejected, user)
+
+	return nil
+}
+
+// pendingUser returns the user of the route which must be pending approval
+func (handler *Handler) pendingUser(r *http.Request) (*portainer.User, *httperror.HandlerError) {
+	userID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid user identifier route variable", err)
+	}
+
+	user, err := handler.DataStore.User().Read(portainer.UserID(userID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a user with the specified identifier inside the database", err)
+	}
+
+	if !user.Pending {
+		return nil, &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "The user is not pending approval", Err: errUserNotPending}
+	}
+
+	return user, nil
+}
+
+// publishSignupEvent notifies the event webhooks of an account request, e.g. so that an email is sent
+func (handler *Handler) publishSignupEvent(eventType string, user *portainer.User) {
+	if handler.EventDispatcher == nil {
+		return
+	}
+
+	handler.EventDispatcher.Publish(lifecycle.NewEvent(eventType, strconv.Itoa(int(user.ID)), map[string]string{
+		"username": user.Username,
+		"email":    user.Email,
+	}))
+}
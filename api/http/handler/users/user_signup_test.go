@@ -0,0 +1,109 @@
+package users
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userSignup(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	adminUser := &portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	is.NoError(store.User().Create(adminUser))
+
+	jwtService, err := jwt.NewService("1h", store)
+	is.NoError(err, "Error initiating jwt service")
+	apiKeyService := apikey.NewAPIKeyService(store.APIKeyRepository(), store.User())
+	requestBouncer := security.NewRequestBouncer(store, jwtService, apiKeyService)
+	rateLimiter := security.NewRateLimiter(10, 1*time.Second, 1*time.Hour)
+	passwordChecker := security.NewPasswordStrengthChecker(store.SettingsService)
+
+	h := NewHandler(requestBouncer, rateLimiter, apiKeyService, demo.NewService(), passwordChecker)
+	h.DataStore = store
+	h.CryptoService = &crypto.Service{}
+
+	adminJWT, _ := jwtService.GenerateToken(&portainer.TokenData{ID: adminUser.ID, Username: adminUser.Username, Role: adminUser.Role})
+
+	signup := func(username string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(userSignupPayload{Username: username, Password: "a-strong-password", Email: username + "@example.com"})
+
+		req := httptest.NewRequest(http.MethodPost, "/users/signup", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	decide := func(userID portainer.UserID, decision string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/users/%d/%s", userID, decision), nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", adminJWT))
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("signup is rejected when disabled", func(t *testing.T) {
+		rr := signup("bob")
+		is.Equal(http.StatusForbidden, rr.Code)
+	})
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.SelfSignup.Enabled = true
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	t.Run("signup creates a pending user which can be approved", func(t *testing.T) {
+		rr := signup("bob")
+		is.Equal(http.StatusNoContent, rr.Code)
+
+		user, err := store.User().UserByUsername("bob")
+		is.NoError(err)
+		is.True(user.Pending)
+		is.Equal(portainer.StandardUserRole, user.Role)
+		is.Equal("bob@example.com", user.Email)
+
+		rr = signup("bob")
+		is.Equal(http.StatusConflict, rr.Code)
+
+		rr = decide(user.ID, "approve")
+		is.Equal(http.StatusOK, rr.Code)
+
+		user, err = store.User().Read(user.ID)
+		is.NoError(err)
+		is.False(user.Pending)
+
+		rr = decide(user.ID, "approve")
+		is.Equal(http.StatusConflict, rr.Code)
+	})
+
+	t.Run("rejected user is removed", func(t *testing.T) {
+		rr := signup("alice")
+		is.Equal(http.StatusNoContent, rr.Code)
+
+		user, err := store.User().UserByUsername("alice")
+		is.NoError(err)
+
+		rr = decide(user.ID, "reject")
+		is.Equal(http.StatusNoContent, rr.Code)
+
+		_, err = store.User().Read(user.ID)
+		is.True(store.IsErrObjectNotFound(err))
+	})
+}
@@ -28,6 +28,10 @@ type userUpdatePayload struct {
 
 	// User role (1 for administrator account and 2 for regular account)
 	Role int `validate:"required" enums:"1,2" example:"2"`
+	// Whether the user must change the password at the next login, administrators only
+	ForcePasswordChange *bool `example:"true"`
+	// Email address of the user, used to send the password resets. An empty address removes it
+	Email *string `example:"bob@example.com"`
 }
 
 func (payload *userUpdatePayload) Validate(r *http.Request) error {
@@ -39,6 +43,10 @@ func (payload *userUpdatePayload) Validate(r *http.Request) error {
 		return errors.New("invalid role value. Value must be one of: 1 (administrator) or 2 (regular user)")
 	}
 
+	if payload.Email != nil && *payload.Email != "" && !govalidator.IsEmail(*payload.Email) {
+		return errors.New("invalid email address")
+	}
+
 	return nil
 }
 
@@ -90,6 +98,10 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 		return httperror.Forbidden("Permission denied to update user to administrator role", httperrors.ErrResourceAccessDenied)
 	}
 
+	if tokenData.Role != portainer.AdministratorRole && payload.ForcePasswordChange != nil {
+		return httperror.Forbidden("Permission denied to force a password change", httperrors.ErrResourceAccessDenied)
+	}
+
 	user, err := handler.DataStore.User().Read(portainer.UserID(userID))
 	if handler.DataStore.IsErrObjectNotFound(err) {
 		return httperror.NotFound("Unable to find a user with the specified identifier inside the database", err)
@@ -139,6 +151,28 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 			return httperror.InternalServerError("Unable to hash user password", errCryptoHashFailure)
 		}
 		user.TokenIssueAt = time.Now().Unix()
+
+		if tokenData.ID == user.ID {
+			user.ForcePasswordChange = false
+		}
+	}
+
+	if payload.ForcePasswordChange != nil && *payload.ForcePasswordChange != user.ForcePasswordChange {
+		if *payload.ForcePasswordChange {
+			internal, err := handler.usesInternalAuthentication(user)
+			if err != nil {
+				return httperror.InternalServerError("Unable to retrieve settings from the database", err)
+			}
+
+			if !internal {
+				return httperror.BadRequest("A password change can only be forced for users using internal authentication", errPasswordNotManaged)
+			}
+
+			// log the user out so that the password change is enforced on the next login
+			user.TokenIssueAt = time.Now().Unix()
+		}
+
+		user.ForcePasswordChange = *payload.ForcePasswordChange
 	}
 
 	if payload.Theme != nil {
@@ -147,6 +181,10 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 		}
 	}
 
+	if payload.Email != nil {
+		user.Email = *payload.Email
+	}
+
 	if payload.Role != 0 {
 		user.Role = portainer.UserRole(payload.Role)
 		user.TokenIssueAt = time.Now().Unix()
@@ -160,8 +198,7 @@ func (handler *Handler) userUpdate(w http.ResponseWriter, r *http.Request) *http
 	// remove all of the users persisted API keys
 	handler.apiKeyService.InvalidateUserKeyCache(user.ID)
 
-	// hide the password field in the response payload
-	user.Password = ""
+	hideFields(user)
 
 	return response.JSON(w, user)
 }
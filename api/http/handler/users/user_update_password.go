@@ -96,6 +96,7 @@ func (handler *Handler) userUpdatePassword(w http.ResponseWriter, r *http.Reques
 	}
 
 	user.TokenIssueAt = time.Now().Unix()
+	user.ForcePasswordChange = false
 
 	err = handler.DataStore.User().Update(user.ID, user)
 	if err != nil {
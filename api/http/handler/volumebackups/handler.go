@@ -0,0 +1,69 @@
+package volumebackups
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/volumebackups"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler is the HTTP handler used to handle volume backup job operations.
+type Handler struct {
+	*mux.Router
+	DataStore           dataservices.DataStore
+	VolumeBackupService *volumebackups.Service
+	requestBouncer      security.BouncerService
+}
+
+// NewHandler creates a handler to manage volume backup job operations.
+func NewHandler(bouncer security.BouncerService) *Handler {
+	h := &Handler{
+		Router:         mux.NewRouter(),
+		requestBouncer: bouncer,
+	}
+
+	adminRouter := h.NewRoute().Subrouter()
+	adminRouter.Use(bouncer.AdminAccess)
+
+	adminRouter.Handle("/volume_backups", httperror.LoggerHandler(h.volumeBackupJobCreate)).Methods(http.MethodPost)
+	adminRouter.Handle("/volume_backups", httperror.LoggerHandler(h.volumeBackupJobList)).Methods(http.MethodGet)
+	adminRouter.Handle("/volume_backups/{id}", httperror.LoggerHandler(h.volumeBackupJobInspect)).Methods(http.MethodGet)
+	adminRouter.Handle("/volume_backups/{id}", httperror.LoggerHandler(h.volumeBackupJobUpdate)).Methods(http.MethodPut)
+	adminRouter.Handle("/volume_backups/{id}", httperror.LoggerHandler(h.volumeBackupJobDelete)).Methods(http.MethodDelete)
+	adminRouter.Handle("/volume_backups/{id}/history", httperror.LoggerHandler(h.volumeBackupJobHistory)).Methods(http.MethodGet)
+	adminRouter.Handle("/volume_backups/{id}/backup", httperror.LoggerHandler(h.volumeBackupJobBackup)).Methods(http.MethodPost)
+	adminRouter.Handle("/volume_backups/{id}/restore", httperror.LoggerHandler(h.volumeBackupJobRestore)).Methods(http.MethodPost)
+
+	return h
+}
+
+func (handler *Handler) jobFromRequest(r *http.Request) (*portainer.VolumeBackupJob, *httperror.HandlerError) {
+	jobID, err := request.RetrieveNumericRouteVariableValue(r, "id")
+	if err != nil {
+		return nil, httperror.BadRequest("Invalid volume backup job identifier route variable", err)
+	}
+
+	job, err := handler.DataStore.VolumeBackupJob().Read(portainer.VolumeBackupJobID(jobID))
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return nil, httperror.NotFound("Unable to find a volume backup job with the specified identifier inside the database", err)
+	} else if err != nil {
+		return nil, httperror.InternalServerError("Unable to find a volume backup job with the specified identifier inside the database", err)
+	}
+
+	return job, nil
+}
+
+// hideFields removes the S3 secret access key from a job returned by the API
+func hideFields(job *portainer.VolumeBackupJob) {
+	if job.Target.S3 != nil {
+		s3 := *job.Target.S3
+		s3.SecretAccessKey = ""
+		job.Target.S3 = &s3
+	}
+}
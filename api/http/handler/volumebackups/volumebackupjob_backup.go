@@ -0,0 +1,41 @@
+package volumebackups
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/portainer/portainer/api/volumebackups"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id VolumeBackupJobBackup
+// @summary Back up the volume of a volume backup job
+// @description Back up the volume now, the backup is returned whether it succeeded or not.
+// @description **Access policy**: administrator
+// @tags volume_backups
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Volume backup job identifier"
+// @success 200 {object} portainer.VolumeBackupRecord "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Volume backup job not found"
+// @failure 409 "A backup of the volume is already in progress"
+// @failure 500 "Server error"
+// @router /volume_backups/{id}/backup [post]
+func (handler *Handler) volumeBackupJobBackup(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	job, httpErr := handler.jobFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	record, err := handler.VolumeBackupService.Backup(r.Context(), job.ID)
+	if errors.Is(err, volumebackups.ErrBackupInProgress) {
+		return &httperror.HandlerError{StatusCode: http.StatusConflict, Message: "A backup of the volume is already in progress", Err: err}
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to back up the volume", err)
+	}
+
+	return response.JSON(w, record)
+}
@@ -0,0 +1,139 @@
+package volumebackups
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/volumebackups"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// volumeNamePattern matches the names of the Docker volumes
+var volumeNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+type volumeBackupJobCreatePayload struct {
+	// Volume backup job name
+	Name string `validate:"required" example:"database"`
+	// Environment identifier of the volume
+	EndpointID portainer.EndpointID `validate:"required" example:"1"`
+	// Name of the backed up volume
+	VolumeName string `validate:"required" example:"postgres-data"`
+	// Name of the Swarm node of the volume, for the agent environments
+	NodeName string `example:"node-1"`
+	// Storage of the archives
+	Target portainer.VolumeBackupTarget
+	// Interval between two backups, five minutes at least. The volume is only backed up on demand when empty
+	Interval string `example:"24h"`
+	// Number of archives kept, all the archives are kept when zero
+	Retention int `example:"7"`
+	// Image of the helper container mounting the volume, defaults to alpine:latest
+	HelperImage string `example:"alpine:latest"`
+}
+
+func (payload *volumeBackupJobCreatePayload) Validate(r *http.Request) error {
+	if payload.Name == "" {
+		return errors.New("invalid name")
+	}
+
+	if payload.EndpointID == 0 {
+		return errors.New("invalid environment identifier")
+	}
+
+	if !volumeNamePattern.MatchString(payload.VolumeName) {
+		return errors.New("invalid volume name")
+	}
+
+	return validateJob(payload.Target, payload.Interval, payload.Retention)
+}
+
+func validateJob(target portainer.VolumeBackupTarget, interval string, retention int) error {
+	if err := volumebackups.ValidateTarget(target); err != nil {
+		return err
+	}
+
+	if interval != "" {
+		if _, err := volumebackups.ParseInterval(interval); err != nil {
+			return err
+		}
+	}
+
+	if retention < 0 {
+		return errors.New("invalid retention, a positive number of archives is expected")
+	}
+
+	return nil
+}
+
+// @id VolumeBackupJobCreate
+// @summary Create a volume backup job
+// @description Back up the contents of a named volume to a directory of the Portainer server or to an S3 bucket.
+// @description The volume is archived by a helper container which mounts it, the container is never started and is removed once the archive is created.
+// @description The volume is backed up at the interval of the job, or only on demand when the job has no interval,
+// @description and the oldest archives exceeding the retention of the job are removed.
+// @description **Access policy**: administrator
+// @tags volume_backups
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param body body volumeBackupJobCreatePayload true "Volume backup job details"
+// @success 200 {object} portainer.VolumeBackupJob "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Environment not found"
+// @failure 500 "Server error"
+// @router /volume_backups [post]
+func (handler *Handler) volumeBackupJobCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload volumeBackupJobCreatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	if httpErr := handler.validateEndpoint(payload.EndpointID); httpErr != nil {
+		return httpErr
+	}
+
+	job := &portainer.VolumeBackupJob{
+		Name:        payload.Name,
+		EndpointID:  payload.EndpointID,
+		VolumeName:  payload.VolumeName,
+		NodeName:    payload.NodeName,
+		Target:      payload.Target,
+		Interval:    payload.Interval,
+		Retention:   payload.Retention,
+		HelperImage: payload.HelperImage,
+	}
+
+	err = handler.DataStore.VolumeBackupJob().Create(job)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the volume backup job inside the database", err)
+	}
+
+	if err := handler.VolumeBackupService.Schedule(job); err != nil {
+		return httperror.InternalServerError("Unable to schedule the volume backup job", err)
+	}
+
+	hideFields(job)
+
+	return response.JSON(w, job)
+}
+
+func (handler *Handler) validateEndpoint(endpointID portainer.EndpointID) *httperror.HandlerError {
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(endpointID)
+	if handler.DataStore.IsErrObjectNotFound(err) {
+		return httperror.NotFound("Unable to find an environment with the specified identifier inside the database", err)
+	} else if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if !endpointutils.IsDockerEndpoint(endpoint) || endpointutils.IsEdgeEndpoint(endpoint) {
+		return httperror.BadRequest("The volumes can only be backed up and restored on the Docker environments", errors.New("unsupported environment type"))
+	}
+
+	return nil
+}
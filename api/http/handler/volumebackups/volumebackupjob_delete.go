@@ -0,0 +1,37 @@
+package volumebackups
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id VolumeBackupJobDelete
+// @summary Remove a volume backup job
+// @description Stop backing up the volume of a volume backup job and remove its history. The archives are kept in the storage of the job.
+// @description **Access policy**: administrator
+// @tags volume_backups
+// @security ApiKeyAuth
+// @security jwt
+// @param id path int true "Volume backup job identifier"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Volume backup job not found"
+// @failure 500 "Server error"
+// @router /volume_backups/{id} [delete]
+func (handler *Handler) volumeBackupJobDelete(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	job, httpErr := handler.jobFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	handler.VolumeBackupService.Unschedule(job.ID)
+
+	err := handler.DataStore.VolumeBackupJob().Delete(job.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to remove the volume backup job from the database", err)
+	}
+
+	return response.Empty(w)
+}
@@ -0,0 +1,36 @@
+package volumebackups
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id VolumeBackupJobHistory
+// @summary List the backups of a volume backup job
+// @description List the backups of the volume of a volume backup job, the most recent first. The successful backups are listed as long as their archive is kept.
+// @description **Access policy**: administrator
+// @tags volume_backups
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Volume backup job identifier"
+// @success 200 {array} portainer.VolumeBackupRecord "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Volume backup job not found"
+// @failure 500 "Server error"
+// @router /volume_backups/{id}/history [get]
+func (handler *Handler) volumeBackupJobHistory(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	job, httpErr := handler.jobFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	records, err := handler.DataStore.VolumeBackupJob().History(job.ID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the volume backup history from the database", err)
+	}
+
+	return response.JSON(w, records)
+}
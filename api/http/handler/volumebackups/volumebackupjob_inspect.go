@@ -0,0 +1,32 @@
+package volumebackups
+
+import (
+	"net/http"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id VolumeBackupJobInspect
+// @summary Inspect a volume backup job
+// @description **Access policy**: administrator
+// @tags volume_backups
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param id path int true "Volume backup job identifier"
+// @success 200 {object} portainer.VolumeBackupJob "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Volume backup job not found"
+// @failure 500 "Server error"
+// @router /volume_backups/{id} [get]
+func (handler *Handler) volumeBackupJobInspect(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	job, httpErr := handler.jobFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	hideFields(job)
+
+	return response.JSON(w, job)
+}
@@ -0,0 +1,46 @@
+package volumebackups
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+// @id VolumeBackupJobList
+// @summary List the volume backup jobs
+// @description **Access policy**: administrator
+// @tags volume_backups
+// @security ApiKeyAuth
+// @security jwt
+// @produce json
+// @param endpointId query int false "Only list the jobs of the volumes of this environment"
+// @success 200 {array} portainer.VolumeBackupJob "Success"
+// @failure 400 "Invalid request"
+// @failure 500 "Server error"
+// @router /volume_backups [get]
+func (handler *Handler) volumeBackupJobList(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	endpointID, err := request.RetrieveNumericQueryParameter(r, "endpointId", true)
+	if err != nil {
+		return httperror.BadRequest("Invalid query parameter: endpointId", err)
+	}
+
+	jobs, err := handler.DataStore.VolumeBackupJob().ReadAll()
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the volume backup jobs from the database", err)
+	}
+
+	filtered := make([]portainer.VolumeBackupJob, 0, len(jobs))
+	for _, job := range jobs {
+		if endpointID != 0 && job.EndpointID != portainer.EndpointID(endpointID) {
+			continue
+		}
+
+		hideFields(&job)
+		filtered = append(filtered, job)
+	}
+
+	return response.JSON(w, filtered)
+}
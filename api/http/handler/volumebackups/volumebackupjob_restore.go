@@ -0,0 +1,130 @@
+package volumebackups
+
+import (
+	"errors"
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type volumeBackupJobRestorePayload struct {
+	// Identifier of the restored backup, defaults to the most recent successful backup
+	RecordID portainer.VolumeBackupRecordID `example:"12"`
+	// Identifier of the environment of the restored volume, defaults to the environment of the job
+	EndpointID portainer.EndpointID `example:"2"`
+	// Name of the Swarm node of the restored volume, for the agent environments
+	NodeName string `example:"node-1"`
+	// Name of the restored volume, defaults to the volume of the job. The volume is created when it does not exist
+	VolumeName string `example:"postgres-data"`
+}
+
+func (payload *volumeBackupJobRestorePayload) Validate(r *http.Request) error {
+	if payload.VolumeName != "" && !volumeNamePattern.MatchString(payload.VolumeName) {
+		return errors.New("invalid volume name")
+	}
+
+	return nil
+}
+
+// @id VolumeBackupJobRestore
+// @summary Restore a backup of a volume backup job
+// @description Extract the archive of a backup into a volume of the same or another environment, the volume is created when it does not exist.
+// @description The files of the archive overwrite the existing files of the volume, the containers using the volume should be stopped beforehand.
+// @description **Access policy**: administrator
+// @tags volume_backups
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @param id path int true "Volume backup job identifier"
+// @param body body volumeBackupJobRestorePayload true "Restoration details"
+// @success 204 "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Volume backup job, backup or environment not found"
+// @failure 500 "Server error"
+// @router /volume_backups/{id}/restore [post]
+func (handler *Handler) volumeBackupJobRestore(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload volumeBackupJobRestorePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	job, httpErr := handler.jobFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	record, httpErr := handler.restoredRecord(job, payload.RecordID)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	endpointID := job.EndpointID
+	nodeName := job.NodeName
+	if payload.EndpointID != 0 {
+		endpointID = payload.EndpointID
+		nodeName = payload.NodeName
+	}
+
+	if httpErr := handler.validateEndpoint(endpointID); httpErr != nil {
+		return httpErr
+	}
+
+	endpoint, err := handler.DataStore.Endpoint().Endpoint(endpointID)
+	if err != nil {
+		return httperror.InternalServerError("Unable to find an environment with the specified identifier inside the database", err)
+	}
+
+	if err := handler.requestBouncer.AuthorizedEndpointOperation(r, endpoint); err != nil {
+		return httperror.Forbidden("Permission denied to access environment", err)
+	}
+
+	volumeName := job.VolumeName
+	if payload.VolumeName != "" {
+		volumeName = payload.VolumeName
+	}
+
+	err = handler.VolumeBackupService.Restore(r.Context(), job, record, endpoint, nodeName, volumeName)
+	if err != nil {
+		return httperror.InternalServerError("Unable to restore the volume", err)
+	}
+
+	return response.Empty(w)
+}
+
+func (handler *Handler) restoredRecord(job *portainer.VolumeBackupJob, recordID portainer.VolumeBackupRecordID) (*portainer.VolumeBackupRecord, *httperror.HandlerError) {
+	if recordID != 0 {
+		record, err := handler.DataStore.VolumeBackupJob().Record(recordID)
+		if handler.DataStore.IsErrObjectNotFound(err) {
+			return nil, httperror.NotFound("Unable to find a backup with the specified identifier inside the database", err)
+		} else if err != nil {
+			return nil, httperror.InternalServerError("Unable to find a backup with the specified identifier inside the database", err)
+		}
+
+		if record.JobID != job.ID {
+			return nil, httperror.NotFound("Unable to find a backup with the specified identifier inside the database", errors.New("backup of another job"))
+		}
+
+		if !record.Success {
+			return nil, httperror.BadRequest("The backup failed and has no archive", errors.New("failed backup"))
+		}
+
+		return record, nil
+	}
+
+	records, err := handler.DataStore.VolumeBackupJob().History(job.ID)
+	if err != nil {
+		return nil, httperror.InternalServerError("Unable to retrieve the volume backup history from the database", err)
+	}
+
+	for i := range records {
+		if records[i].Success {
+			return &records[i], nil
+		}
+	}
+
+	return nil, httperror.NotFound("The volume backup job has no successful backup", errors.New("no archive"))
+}
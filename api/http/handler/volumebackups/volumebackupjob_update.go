@@ -0,0 +1,100 @@
+package volumebackups
+
+import (
+	"net/http"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+	"github.com/portainer/portainer/pkg/libhttp/response"
+)
+
+type volumeBackupJobUpdatePayload struct {
+	// Volume backup job name
+	Name *string `example:"database"`
+	// Storage of the archives, the S3 secret access key is kept when empty
+	Target *portainer.VolumeBackupTarget
+	// Interval between two backups, five minutes at least. The volume is only backed up on demand when empty
+	Interval *string `example:"24h"`
+	// Number of archives kept, all the archives are kept when zero
+	Retention *int `example:"7"`
+	// Image of the helper container mounting the volume
+	HelperImage *string `example:"alpine:latest"`
+}
+
+func (payload *volumeBackupJobUpdatePayload) Validate(r *http.Request) error {
+	return nil
+}
+
+// @id VolumeBackupJobUpdate
+// @summary Update a volume backup job
+// @description The archives stored before a change of the target are not moved, they can no longer be restored nor pruned.
+// @description **Access policy**: administrator
+// @tags volume_backups
+// @security ApiKeyAuth
+// @security jwt
+// @accept json
+// @produce json
+// @param id path int true "Volume backup job identifier"
+// @param body body volumeBackupJobUpdatePayload true "Volume backup job details"
+// @success 200 {object} portainer.VolumeBackupJob "Success"
+// @failure 400 "Invalid request"
+// @failure 404 "Volume backup job not found"
+// @failure 500 "Server error"
+// @router /volume_backups/{id} [put]
+func (handler *Handler) volumeBackupJobUpdate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+	var payload volumeBackupJobUpdatePayload
+	err := request.DecodeAndValidateJSONPayload(r, &payload)
+	if err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	job, httpErr := handler.jobFromRequest(r)
+	if httpErr != nil {
+		return httpErr
+	}
+
+	if payload.Name != nil && *payload.Name != "" {
+		job.Name = *payload.Name
+	}
+
+	if payload.Target != nil {
+		target := *payload.Target
+		if target.S3 != nil && target.S3.SecretAccessKey == "" && job.Target.S3 != nil {
+			s3 := *target.S3
+			s3.SecretAccessKey = job.Target.S3.SecretAccessKey
+			target.S3 = &s3
+		}
+
+		job.Target = target
+	}
+
+	if payload.Interval != nil {
+		job.Interval = *payload.Interval
+	}
+
+	if payload.Retention != nil {
+		job.Retention = *payload.Retention
+	}
+
+	if payload.HelperImage != nil {
+		job.HelperImage = *payload.HelperImage
+	}
+
+	if err := validateJob(job.Target, job.Interval, job.Retention); err != nil {
+		return httperror.BadRequest("Invalid request payload", err)
+	}
+
+	err = handler.DataStore.VolumeBackupJob().Update(job.ID, job)
+	if err != nil {
+		return httperror.InternalServerError("Unable to persist the volume backup job changes inside the database", err)
+	}
+
+	if err := handler.VolumeBackupService.Schedule(job); err != nil {
+		return httperror.InternalServerError("Unable to schedule the volume backup job", err)
+	}
+
+	hideFields(job)
+
+	return response.JSON(w, job)
+}
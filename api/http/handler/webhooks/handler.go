@@ -5,6 +5,7 @@ import (
 
 	"github.com/portainer/portainer/api/dataservices"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/http/middlewares"
 	"github.com/portainer/portainer/api/http/security"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
@@ -26,7 +27,7 @@ func NewHandler(bouncer security.BouncerService) *Handler {
 		requestBouncer: bouncer,
 	}
 	h.Handle("/webhooks",
-		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.webhookCreate))).Methods(http.MethodPost)
+		bouncer.AuthenticatedAccess(middlewares.WithIdempotency(httperror.LoggerHandler(h.webhookCreate)))).Methods(http.MethodPost)
 	h.Handle("/webhooks/{id}",
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.webhookUpdate))).Methods(http.MethodPut)
 	h.Handle("/webhooks",
@@ -44,9 +44,11 @@ func (payload *webhookCreatePayload) Validate(r *http.Request) error {
 // @accept json
 // @produce json
 // @param body body webhookCreatePayload true "Webhook data"
+// @param Idempotency-Key header string false "Key identifying the retries of the request, whose response is replayed for 24 hours"
 // @success 200 {object} portainer.Webhook
 // @failure 400
 // @failure 409
+// @failure 422 "The idempotency key was used for a different request"
 // @failure 500
 // @router /webhooks [post]
 func (handler *Handler) webhookCreate(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
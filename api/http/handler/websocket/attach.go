@@ -7,6 +7,8 @@ import (
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 
@@ -18,6 +20,7 @@ import (
 // @description If the nodeName query parameter is present, the request will be proxied to the underlying agent environment(endpoint).
 // @description If the nodeName query parameter is not specified, the request will be upgraded to the websocket protocol and
 // @description an AttachStart operation HTTP request will be created and hijacked.
+// @description The attachments are recorded in the audit of the environment, along with the reason required by the shell access policy.
 // @description **Access policy**: authenticated
 // @security ApiKeyAuth
 // @security jwt
@@ -27,6 +30,7 @@ import (
 // @param endpointId query int true "environment(endpoint) ID of the environment(endpoint) where the resource is located"
 // @param nodeName query string false "node name"
 // @param token query string true "JWT token used for authentication against this environment(endpoint)"
+// @param reason query string false "Reason of the access, required by the shell access policy"
 // @success 200
 // @failure 400
 // @failure 403
@@ -59,6 +63,18 @@ func (handler *Handler) websocketAttach(w http.ResponseWriter, r *http.Request)
 		return httperror.Forbidden("Permission denied to access environment", err)
 	}
 
+	if httpErr := handler.checkReadOnly(endpoint); httpErr != nil {
+		return httpErr
+	}
+
+	if httpErr := handler.checkChangeApproval(r, endpoint); httpErr != nil {
+		return httpErr
+	}
+
+	if httpErr := handler.checkAttachShellAccess(r, endpoint, attachID); httpErr != nil {
+		return httpErr
+	}
+
 	params := &webSocketRequestParams{
 		endpoint: endpoint,
 		ID:       attachID,
@@ -89,11 +105,11 @@ func (handler *Handler) handleAttachRequest(w http.ResponseWriter, r *http.Reque
 	}
 	defer websocketConn.Close()
 
-	return hijackAttachStartOperation(websocketConn, params.endpoint, params.ID)
+	return handler.hijackAttachStartOperation(websocketConn, params.endpoint, params.ID)
 }
 
-func hijackAttachStartOperation(websocketConn *websocket.Conn, endpoint *portainer.Endpoint, attachID string) error {
-	dial, err := initDial(endpoint)
+func (handler *Handler) hijackAttachStartOperation(websocketConn *websocket.Conn, endpoint *portainer.Endpoint, attachID string) error {
+	dial, err := handler.initDial(endpoint)
 	if err != nil {
 		return err
 	}
@@ -132,3 +148,80 @@ func createAttachStartRequest(attachID string) (*http.Request, error) {
 
 	return request, nil
 }
+
+// checkAttachShellAccess applies the shell access policy to an attachment and records it in the audit of the
+// environment
+func (handler *Handler) checkAttachShellAccess(r *http.Request, endpoint *portainer.Endpoint, containerID string) *httperror.HandlerError {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	rules, err := authorization.ShellAccessRules(handler.DataStore, tokenData, endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve the shell access policy", err)
+	}
+
+	// the attachment reaches the main process of the container, which runs as the user of the container
+	root := false
+	if authorization.ShellAccessRequiresRoot(rules) {
+		root, err = handler.containerRunsAsRoot(r, endpoint, containerID)
+		if err != nil {
+			return httperror.InternalServerError("Unable to inspect the container", err)
+		}
+	}
+
+	reason, _ := request.RetrieveQueryParameter(r, "reason", true)
+
+	audit := &portainer.DockerOperationAudit{
+		EndpointID:     endpoint.ID,
+		Timestamp:      time.Now().Unix(),
+		UserID:         tokenData.ID,
+		Username:       tokenData.Username,
+		ImpersonatorID: tokenData.ImpersonatorID,
+		Operation:      "container.attach",
+		ResourceID:     containerID,
+		Method:         http.MethodPost,
+		Path:           "/containers/" + containerID + "/attach",
+		StatusCode:     http.StatusSwitchingProtocols,
+		RequestID:      r.Header.Get(request.RequestIDHeader),
+	}
+	if reason != "" {
+		audit.Summary = map[string]string{"reason": reason}
+	}
+
+	accessErr := authorization.CheckShellAccess(rules, nil, root, reason)
+	if accessErr != nil {
+		audit.StatusCode = http.StatusForbidden
+	}
+
+	if err := handler.DataStore.DockerOperationAudit().Create(audit); err != nil {
+		return httperror.InternalServerError("Unable to record the attachment in the audit of the environment", err)
+	}
+
+	if accessErr != nil {
+		return httperror.Forbidden(accessErr.Error(), accessErr)
+	}
+
+	return nil
+}
+
+// containerRunsAsRoot returns whether the main process of a container runs as root
+func (handler *Handler) containerRunsAsRoot(r *http.Request, endpoint *portainer.Endpoint, containerID string) (bool, error) {
+	cli, err := handler.DockerClientFactory.CreateClient(endpoint, r.FormValue("nodeName"), nil)
+	if err != nil {
+		return false, err
+	}
+	defer cli.Close()
+
+	container, err := cli.ContainerInspect(r.Context(), containerID)
+	if err != nil {
+		return false, err
+	}
+
+	if container.Config == nil {
+		return true, nil
+	}
+
+	return authorization.IsRootUser(container.Config.User), nil
+}
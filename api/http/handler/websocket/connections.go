@@ -0,0 +1,141 @@
+package websocket
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+const closeFrameWriteTimeout = time.Second
+
+// connectionTracker keeps track of the connections hijacked by the websocket operations, which are not managed by
+// the HTTP server anymore, so that they can be closed gracefully when the server shuts down
+type connectionTracker struct {
+	mu          sync.Mutex
+	connections map[*trackedConn]struct{}
+}
+
+func newConnectionTracker() *connectionTracker {
+	return &connectionTracker{
+		connections: make(map[*trackedConn]struct{}),
+	}
+}
+
+// middleware tracks the connections hijacked by the next handler
+func (tracker *connectionTracker) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(&trackedResponseWriter{ResponseWriter: w, hijacker: hijacker, tracker: tracker}, r)
+	})
+}
+
+// closeAll sends a close frame with the specified status to every tracked connection, then closes it
+func (tracker *connectionTracker) closeAll(code int, reason string) {
+	tracker.mu.Lock()
+	connections := make([]*trackedConn, 0, len(tracker.connections))
+	for conn := range tracker.connections {
+		connections = append(connections, conn)
+	}
+	tracker.mu.Unlock()
+
+	if len(connections) > 0 {
+		log.Info().Int("connections", len(connections)).Msg("closing websocket connections")
+	}
+
+	for _, conn := range connections {
+		if err := conn.writeCloseFrame(code, reason); err != nil {
+			log.Debug().Err(err).Msg("unable to send the close frame to the websocket client")
+		}
+
+		conn.Close()
+	}
+}
+
+func (tracker *connectionTracker) add(conn *trackedConn) {
+	tracker.mu.Lock()
+	tracker.connections[conn] = struct{}{}
+	tracker.mu.Unlock()
+}
+
+func (tracker *connectionTracker) remove(conn *trackedConn) {
+	tracker.mu.Lock()
+	delete(tracker.connections, conn)
+	tracker.mu.Unlock()
+}
+
+type trackedResponseWriter struct {
+	http.ResponseWriter
+	hijacker http.Hijacker
+	tracker  *connectionTracker
+}
+
+func (w *trackedResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tracked := &trackedConn{Conn: conn, tracker: w.tracker}
+	w.tracker.add(tracked)
+
+	return tracked, rw, nil
+}
+
+func (w *trackedResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *trackedResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// trackedConn serializes the writes so that the close frame is never interleaved with another write
+type trackedConn struct {
+	net.Conn
+	tracker   *connectionTracker
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+}
+
+func (conn *trackedConn) Write(b []byte) (int, error) {
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+
+	return conn.Conn.Write(b)
+}
+
+func (conn *trackedConn) Close() error {
+	var err error
+	conn.closeOnce.Do(func() {
+		conn.tracker.remove(conn)
+		err = conn.Conn.Close()
+	})
+
+	return err
+}
+
+// writeCloseFrame writes an unmasked close frame, as sent by a server
+func (conn *trackedConn) writeCloseFrame(code int, reason string) error {
+	payload := websocket.FormatCloseMessage(code, reason)
+	frame := append([]byte{0x80 | websocket.CloseMessage, byte(len(payload))}, payload...)
+
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+
+	conn.Conn.SetWriteDeadline(time.Now().Add(closeFrameWriteTimeout))
+	_, err := conn.Conn.Write(frame)
+
+	return err
+}
@@ -0,0 +1,50 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionTracker_CloseAll(t *testing.T) {
+	tracker := newConnectionTracker()
+	upgrader := websocket.Upgrader{}
+
+	upgraded := make(chan struct{})
+	server := httptest.NewServer(tracker.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		close(upgraded)
+
+		// keep the connection open until it is closed by the tracker
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	})))
+	defer server.Close()
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer client.Close()
+
+	<-upgraded
+	tracker.closeAll(websocket.CloseGoingAway, "server shutting down")
+
+	_, _, err = client.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "unexpected error: %v", err)
+
+	tracker.mu.Lock()
+	assert.Empty(t, tracker.connections)
+	tracker.mu.Unlock()
+}
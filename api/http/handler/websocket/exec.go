@@ -3,17 +3,25 @@ package websocket
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 	"github.com/portainer/portainer/pkg/libhttp/request"
 
 	"github.com/asaskevich/govalidator"
-	"github.com/gorilla/websocket"
+)
+
+var (
+	errControlNotSupported = errors.New("the control protocol is not supported by the agent")
+	errExecSessionNotFound = errors.New("invalid or expired reconnection token")
 )
 
 type execStartOperationPayload struct {
@@ -25,6 +33,10 @@ type execStartOperationPayload struct {
 // @description If the nodeName query parameter is present, the request will be proxied to the underlying agent environment(endpoint).
 // @description If the nodeName query parameter is not specified, the request will be upgraded to the websocket protocol and
 // @description an ExecStart operation HTTP request will be created and hijacked.
+// @description When the control query parameter is set, the messages are JSON objects: "input" and "output" messages carry
+// @description the data of the process, "resize" messages resize its TTY and a "session" message provides the token
+// @description allowing to resume the session with the reconnectToken query parameter when the connection drops.
+// @description The control protocol is only available on the environments Portainer connects to directly.
 // @**Access policy**: authenticated
 // @security ApiKeyAuth
 // @security jwt
@@ -34,8 +46,11 @@ type execStartOperationPayload struct {
 // @param endpointId query int true "environment(endpoint) ID of the environment(endpoint) where the resource is located"
 // @param nodeName query string false "node name"
 // @param token query string true "JWT token used for authentication against this environment(endpoint)"
+// @param control query bool false "Use the control protocol"
+// @param reconnectToken query string false "Token resuming a session whose connection dropped"
 // @success 200
 // @failure 400
+// @failure 404
 // @failure 409
 // @failure 500
 // @router /websocket/exec [get]
@@ -65,13 +80,49 @@ func (handler *Handler) websocketExec(w http.ResponseWriter, r *http.Request) *h
 		return httperror.Forbidden("Permission denied to access environment", err)
 	}
 
+	if httpErr := handler.checkReadOnly(endpoint); httpErr != nil {
+		return httpErr
+	}
+
+	if httpErr := handler.checkChangeApproval(r, endpoint); httpErr != nil {
+		return httpErr
+	}
+
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	control, _ := request.RetrieveBooleanQueryParameter(r, "control", true)
+	reconnectToken, _ := request.RetrieveQueryParameter(r, "reconnectToken", true)
+
+	proxied := endpoint.Type == portainer.AgentOnDockerEnvironment || endpoint.Type == portainer.EdgeAgentOnDockerEnvironment
+	if proxied && (control || reconnectToken != "") {
+		return httperror.BadRequest("The control protocol is not available on agent environments", errControlNotSupported)
+	}
+
+	var session *execSession
+	if reconnectToken != "" {
+		session = handler.execSessions.lookup(reconnectToken)
+		if session == nil || session.execID != execID || session.endpointID != endpoint.ID || session.userID != tokenData.ID {
+			return httperror.NotFound("Unable to find the exec session to resume", errExecSessionNotFound)
+		}
+	}
+
 	params := &webSocketRequestParams{
 		endpoint: endpoint,
 		ID:       execID,
 		nodeName: r.FormValue("nodeName"),
 	}
 
-	err = handler.handleExecRequest(w, r, params)
+	err = handler.handleExecRequest(w, r, params, session, execSessionOptions{
+		control:         control,
+		idleTimeout:     handler.ExecIdleTimeout,
+		reconnectWindow: handler.ExecReconnectWindow,
+		resize: func(rows, cols uint) error {
+			return handler.resizeExec(endpoint, execID, rows, cols)
+		},
+	}, tokenData.ID)
 	if err != nil {
 		return httperror.InternalServerError("An error occurred during websocket exec operation", err)
 	}
@@ -79,7 +130,7 @@ func (handler *Handler) websocketExec(w http.ResponseWriter, r *http.Request) *h
 	return nil
 }
 
-func (handler *Handler) handleExecRequest(w http.ResponseWriter, r *http.Request, params *webSocketRequestParams) error {
+func (handler *Handler) handleExecRequest(w http.ResponseWriter, r *http.Request, params *webSocketRequestParams, session *execSession, options execSessionOptions, userID portainer.UserID) error {
 	r.Header.Del("Origin")
 
 	if params.endpoint.Type == portainer.AgentOnDockerEnvironment {
@@ -94,13 +145,25 @@ func (handler *Handler) handleExecRequest(w http.ResponseWriter, r *http.Request
 	}
 	defer websocketConn.Close()
 
-	return hijackExecStartOperation(websocketConn, params.endpoint, params.ID)
+	if session == nil {
+		conn, err := handler.hijackExecStartOperation(params.endpoint, params.ID)
+		if err != nil {
+			return err
+		}
+
+		session = handler.execSessions.start(conn, params.endpoint.ID, params.ID, userID, options)
+	}
+
+	session.serve(websocketConn)
+
+	return nil
 }
 
-func hijackExecStartOperation(websocketConn *websocket.Conn, endpoint *portainer.Endpoint, execID string) error {
-	dial, err := initDial(endpoint)
+// hijackExecStartOperation starts the exec instance and returns the connection streaming its process
+func (handler *Handler) hijackExecStartOperation(endpoint *portainer.Endpoint, execID string) (io.ReadWriteCloser, error) {
+	dial, err := handler.initDial(endpoint)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// When we set up a TCP connection for hijack, there could be long periods
@@ -114,14 +177,58 @@ func hijackExecStartOperation(websocketConn *websocket.Conn, endpoint *portainer
 	}
 
 	httpConn := httputil.NewClientConn(dial, nil)
-	defer httpConn.Close()
 
 	execStartRequest, err := createExecStartRequest(execID)
+	if err != nil {
+		httpConn.Close()
+		return nil, err
+	}
+
+	tcpConn, brw, err := hijackConnection(httpConn, execStartRequest)
+	if err != nil {
+		httpConn.Close()
+		return nil, err
+	}
+
+	return &hijackedConn{Conn: tcpConn, reader: brw}, nil
+}
+
+// hijackedConn reads from the buffer of the hijacked connection, which may already hold some output
+type hijackedConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (conn *hijackedConn) Read(b []byte) (int, error) {
+	return conn.reader.Read(b)
+}
+
+// resizeExec resizes the TTY of an exec instance
+func (handler *Handler) resizeExec(endpoint *portainer.Endpoint, execID string, rows, cols uint) error {
+	dial, err := handler.initDial(endpoint)
 	if err != nil {
 		return err
 	}
 
-	return hijackRequest(websocketConn, httpConn, execStartRequest)
+	httpConn := httputil.NewClientConn(dial, nil)
+	defer httpConn.Close()
+
+	resizeRequest, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/exec/%s/resize?h=%d&w=%d", execID, rows, cols), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpConn.Do(resizeRequest)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unable to resize the exec instance, received %d", resp.StatusCode)
+	}
+
+	return nil
 }
 
 func createExecStartRequest(execID string) (*http.Request, error) {
@@ -0,0 +1,385 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxDetachedOutput is the size of the output kept while an exec session has no connection
+	maxDetachedOutput = 64 * 1024
+
+	execMessageInput   = "input"
+	execMessageOutput  = "output"
+	execMessageResize  = "resize"
+	execMessageSession = "session"
+
+	// interruptSequence is written to the TTY of an abandoned process since Docker cannot kill an exec instance: the
+	// interrupt character stops the running command and the end of transmission character then exits the shell
+	interruptSequence = "\x03\x04"
+	interruptTimeout  = time.Second
+)
+
+// execMessage is a message of the control protocol of the exec sessions, which wraps the input and the output of
+// the process and carries the resize and reconnection information
+type execMessage struct {
+	Type string `json:"type"`
+	Data string `json:"data,omitempty"`
+	Rows uint   `json:"rows,omitempty"`
+	Cols uint   `json:"cols,omitempty"`
+	// Token allowing the client to resume the session when its connection drops
+	Token string `json:"token,omitempty"`
+	// Seconds during which the session can be resumed after the connection dropped
+	ReconnectWindow int `json:"reconnectWindow,omitempty"`
+}
+
+// execSessionOptions describe how an exec session is served
+type execSessionOptions struct {
+	// control is true when the client uses the control protocol, otherwise the input and output are sent as is
+	control         bool
+	idleTimeout     time.Duration
+	reconnectWindow time.Duration
+	resize          func(rows, cols uint) error
+}
+
+// execSessionManager keeps track of the exec sessions which can be resumed
+type execSessionManager struct {
+	mu       sync.Mutex
+	sessions map[string]*execSession
+}
+
+func newExecSessionManager() *execSessionManager {
+	return &execSessionManager{
+		sessions: make(map[string]*execSession),
+	}
+}
+
+// start creates a session streaming the process of an exec instance
+func (manager *execSessionManager) start(conn io.ReadWriteCloser, endpointID portainer.EndpointID, execID string, userID portainer.UserID, options execSessionOptions) *execSession {
+	session := &execSession{
+		manager:    manager,
+		conn:       conn,
+		endpointID: endpointID,
+		execID:     execID,
+		userID:     userID,
+		options:    options,
+	}
+
+	if options.idleTimeout > 0 {
+		session.idle = time.AfterFunc(options.idleTimeout, func() {
+			session.terminate(websocket.CloseNormalClosure, "idle timeout")
+		})
+	}
+
+	go session.pump()
+
+	return session
+}
+
+// lookup returns the session the reconnection token was issued for
+func (manager *execSessionManager) lookup(token string) *execSession {
+	manager.mu.Lock()
+	defer manager.mu.Unlock()
+
+	return manager.sessions[token]
+}
+
+func (manager *execSessionManager) register(token string, session *execSession) {
+	manager.mu.Lock()
+	manager.sessions[token] = session
+	manager.mu.Unlock()
+}
+
+func (manager *execSessionManager) unregister(token string) {
+	manager.mu.Lock()
+	delete(manager.sessions, token)
+	manager.mu.Unlock()
+}
+
+// execSession is the process of an exec instance streamed to a websocket connection. When the control protocol is
+// used, the process outlives the connection during the reconnection window so that the client can resume it.
+type execSession struct {
+	manager    *execSessionManager
+	conn       io.ReadWriteCloser
+	endpointID portainer.EndpointID
+	execID     string
+	userID     portainer.UserID
+	options    execSessionOptions
+
+	mu        sync.Mutex
+	websocket *websocket.Conn
+	token     string
+	detached  []byte
+	reconnect *time.Timer
+	idle      *time.Timer
+	closed    bool
+	// exited is true once the process stream ended, the process no longer needing to be interrupted
+	exited bool
+}
+
+// serve streams the session to the websocket connection until the connection or the process ends, a connection
+// already attached to the session is replaced
+func (session *execSession) serve(websocketConn *websocket.Conn) {
+	if !session.attach(websocketConn) {
+		return
+	}
+
+	for {
+		_, data, err := websocketConn.ReadMessage()
+		if err != nil {
+			session.detach(websocketConn, err)
+			return
+		}
+
+		session.touch()
+
+		if !session.options.control {
+			if _, err := session.conn.Write(data); err != nil {
+				session.terminate(websocket.CloseInternalServerErr, "unable to write to the process")
+				return
+			}
+
+			continue
+		}
+
+		var message execMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			log.Debug().Err(err).Str("exec_id", session.execID).Msg("ignoring an invalid exec session message")
+			continue
+		}
+
+		switch message.Type {
+		case execMessageInput:
+			if _, err := session.conn.Write([]byte(message.Data)); err != nil {
+				session.terminate(websocket.CloseInternalServerErr, "unable to write to the process")
+				return
+			}
+		case execMessageResize:
+			if message.Rows == 0 || message.Cols == 0 || session.options.resize == nil {
+				continue
+			}
+
+			if err := session.options.resize(message.Rows, message.Cols); err != nil {
+				log.Debug().Err(err).Str("exec_id", session.execID).Msg("unable to resize the exec session")
+			}
+		}
+	}
+}
+
+func (session *execSession) attach(websocketConn *websocket.Conn) bool {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if session.closed {
+		writeClose(websocketConn, websocket.CloseNormalClosure, "exec session ended")
+		return false
+	}
+
+	if session.reconnect != nil {
+		session.reconnect.Stop()
+		session.reconnect = nil
+	}
+
+	if session.websocket != nil {
+		writeClose(session.websocket, websocket.CloseNormalClosure, "exec session resumed by another connection")
+		session.websocket.Close()
+	}
+
+	session.websocket = websocketConn
+
+	if session.options.control && session.options.reconnectWindow > 0 {
+		// The token is renewed on each connection so that it can only be used once
+		if session.token != "" {
+			session.manager.unregister(session.token)
+		}
+
+		token, err := generateReconnectToken()
+		if err != nil {
+			log.Warn().Err(err).Msg("unable to generate the reconnection token of the exec session")
+		} else {
+			session.token = token
+			session.manager.register(token, session)
+
+			session.write(execMessage{
+				Type:            execMessageSession,
+				Token:           token,
+				ReconnectWindow: int(session.options.reconnectWindow.Seconds()),
+			})
+		}
+	}
+
+	if len(session.detached) > 0 {
+		session.writeOutput(session.detached)
+		session.detached = nil
+	}
+
+	session.touchLocked()
+
+	return true
+}
+
+// detach removes the connection from the session, the session ends unless it can be resumed
+func (session *execSession) detach(websocketConn *websocket.Conn, err error) {
+	session.mu.Lock()
+
+	if session.websocket != websocketConn {
+		session.mu.Unlock()
+		return
+	}
+
+	session.websocket = nil
+
+	resumable := session.token != "" && !session.closed &&
+		!websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway)
+	if resumable {
+		session.reconnect = time.AfterFunc(session.options.reconnectWindow, func() {
+			session.terminate(websocket.CloseNormalClosure, "reconnection window expired")
+		})
+	}
+
+	session.mu.Unlock()
+
+	if !resumable {
+		session.terminate(websocket.CloseNormalClosure, "exec session ended")
+	}
+}
+
+// pump streams the output of the process to the connection, or keeps it while the session has no connection
+func (session *execSession) pump() {
+	out := make([]byte, readerBufferSize)
+
+	for {
+		n, err := session.conn.Read(out)
+		if n > 0 {
+			session.output(out[:n])
+		}
+
+		if err != nil {
+			session.mu.Lock()
+			session.exited = true
+			session.mu.Unlock()
+
+			session.terminate(websocket.CloseNormalClosure, "exec session ended")
+			return
+		}
+	}
+}
+
+func (session *execSession) output(data []byte) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	session.touchLocked()
+
+	if session.websocket != nil {
+		session.writeOutput(data)
+		return
+	}
+
+	session.detached = append(session.detached, data...)
+	if len(session.detached) > maxDetachedOutput {
+		session.detached = session.detached[len(session.detached)-maxDetachedOutput:]
+	}
+}
+
+// terminate ends the process stream and closes the connection with the specified status, the process being
+// interrupted when it is still running so that it does not keep running on the daemon once abandoned
+func (session *execSession) terminate(code int, reason string) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+
+	if session.closed {
+		return
+	}
+	session.closed = true
+
+	if session.token != "" {
+		session.manager.unregister(session.token)
+	}
+
+	if session.reconnect != nil {
+		session.reconnect.Stop()
+	}
+
+	if session.idle != nil {
+		session.idle.Stop()
+	}
+
+	if !session.exited {
+		session.interrupt()
+	}
+
+	session.conn.Close()
+
+	if session.websocket != nil {
+		writeClose(session.websocket, code, reason)
+		session.websocket.Close()
+		session.websocket = nil
+	}
+}
+
+// interrupt writes the interrupt sequence to the TTY of the process, without waiting for a stuck stream
+func (session *execSession) interrupt() {
+	if conn, ok := session.conn.(interface{ SetWriteDeadline(time.Time) error }); ok {
+		conn.SetWriteDeadline(time.Now().Add(interruptTimeout))
+	}
+
+	if _, err := session.conn.Write([]byte(interruptSequence)); err != nil {
+		log.Debug().Err(err).Str("exec_id", session.execID).Msg("unable to interrupt the exec process")
+	}
+}
+
+func (session *execSession) touch() {
+	session.mu.Lock()
+	session.touchLocked()
+	session.mu.Unlock()
+}
+
+func (session *execSession) touchLocked() {
+	if session.idle != nil && !session.closed {
+		session.idle.Reset(session.options.idleTimeout)
+	}
+}
+
+// writeOutput sends the output of the process, a write failure is handled by the reader of the connection
+func (session *execSession) writeOutput(data []byte) {
+	output := validString(string(data))
+
+	if !session.options.control {
+		session.websocket.WriteMessage(websocket.TextMessage, []byte(output))
+		return
+	}
+
+	session.write(execMessage{Type: execMessageOutput, Data: output})
+}
+
+func (session *execSession) write(message execMessage) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+
+	session.websocket.WriteMessage(websocket.TextMessage, data)
+}
+
+func writeClose(websocketConn *websocket.Conn, code int, reason string) {
+	websocketConn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(closeFrameWriteTimeout))
+}
+
+func generateReconnectToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,196 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+type execSessionTest struct {
+	t       *testing.T
+	manager *execSessionManager
+	server  *httptest.Server
+	// process is the side of the hijacked connection of the Docker daemon
+	process net.Conn
+	resized chan [2]uint
+}
+
+func newExecSessionTest(t *testing.T, options execSessionOptions) *execSessionTest {
+	test := &execSessionTest{
+		t:       t,
+		manager: newExecSessionManager(),
+		resized: make(chan [2]uint, 1),
+	}
+
+	options.resize = func(rows, cols uint) error {
+		test.resized <- [2]uint{rows, cols}
+		return nil
+	}
+
+	conn, process := net.Pipe()
+	test.process = process
+	session := test.manager.start(conn, 1, "abcdef", 1, options)
+
+	upgrader := websocket.Upgrader{}
+	test.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served := session
+		if token := r.URL.Query().Get("reconnectToken"); token != "" {
+			served = test.manager.lookup(token)
+			if served == nil {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+		}
+
+		websocketConn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer websocketConn.Close()
+
+		served.serve(websocketConn)
+	}))
+	t.Cleanup(test.server.Close)
+	t.Cleanup(func() { process.Close() })
+
+	return test
+}
+
+func (test *execSessionTest) dial(query string) *websocket.Conn {
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(test.server.URL, "http")+query, nil)
+	if !assert.NoError(test.t, err) {
+		test.t.FailNow()
+	}
+	resp.Body.Close()
+	test.t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func readExecMessage(t *testing.T, conn *websocket.Conn) execMessage {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	_, data, err := conn.ReadMessage()
+	assert.NoError(t, err)
+
+	var message execMessage
+	assert.NoError(t, json.Unmarshal(data, &message))
+
+	return message
+}
+
+func readProcessInput(t *testing.T, process net.Conn) string {
+	process.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	buf := make([]byte, 64)
+	n, err := process.Read(buf)
+	assert.NoError(t, err)
+
+	return string(buf[:n])
+}
+
+func TestExecSession_ControlProtocol(t *testing.T) {
+	test := newExecSessionTest(t, execSessionOptions{control: true, reconnectWindow: time.Minute})
+	client := test.dial("")
+
+	session := readExecMessage(t, client)
+	assert.Equal(t, execMessageSession, session.Type)
+	assert.NotEmpty(t, session.Token)
+	assert.Equal(t, 60, session.ReconnectWindow)
+
+	assert.NoError(t, client.WriteJSON(execMessage{Type: execMessageInput, Data: "ls\n"}))
+	assert.Equal(t, "ls\n", readProcessInput(t, test.process))
+
+	_, err := test.process.Write([]byte("file.txt\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, execMessage{Type: execMessageOutput, Data: "file.txt\n"}, readExecMessage(t, client))
+
+	assert.NoError(t, client.WriteJSON(execMessage{Type: execMessageResize, Rows: 40, Cols: 120}))
+	select {
+	case size := <-test.resized:
+		assert.Equal(t, [2]uint{40, 120}, size)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the session should have been resized")
+	}
+}
+
+func TestExecSession_Reconnection(t *testing.T) {
+	test := newExecSessionTest(t, execSessionOptions{control: true, reconnectWindow: time.Minute})
+	client := test.dial("")
+
+	token := readExecMessage(t, client).Token
+
+	// The connection drops without a close frame
+	client.UnderlyingConn().Close()
+	assert.Eventually(t, func() bool {
+		session := test.manager.lookup(token)
+		session.mu.Lock()
+		defer session.mu.Unlock()
+
+		return session.websocket == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err := test.process.Write([]byte("output while detached"))
+	assert.NoError(t, err)
+
+	resumed := test.dial("?reconnectToken=" + token)
+
+	session := readExecMessage(t, resumed)
+	assert.Equal(t, execMessageSession, session.Type)
+	assert.NotEqual(t, token, session.Token, "the reconnection token should be renewed")
+	assert.Nil(t, test.manager.lookup(token), "the reconnection token should only be usable once")
+
+	assert.Equal(t, execMessage{Type: execMessageOutput, Data: "output while detached"}, readExecMessage(t, resumed))
+
+	assert.NoError(t, resumed.WriteJSON(execMessage{Type: execMessageInput, Data: "exit\n"}))
+	assert.Equal(t, "exit\n", readProcessInput(t, test.process))
+}
+
+func TestExecSession_ClosedByClient(t *testing.T) {
+	test := newExecSessionTest(t, execSessionOptions{control: true, reconnectWindow: time.Minute})
+	client := test.dial("")
+
+	token := readExecMessage(t, client).Token
+
+	assert.NoError(t, client.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")))
+
+	assert.Equal(t, interruptSequence, readProcessInput(t, test.process), "the abandoned process should be interrupted")
+
+	test.process.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err := test.process.Read(make([]byte, 1))
+	assert.Error(t, err, "the process stream should be closed")
+	assert.Nil(t, test.manager.lookup(token))
+}
+
+func TestExecSession_IdleTimeout(t *testing.T) {
+	test := newExecSessionTest(t, execSessionOptions{idleTimeout: 100 * time.Millisecond})
+	client := test.dial("")
+
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := client.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+	assert.Contains(t, err.Error(), "idle timeout")
+}
+
+func TestExecSession_RawProtocol(t *testing.T) {
+	test := newExecSessionTest(t, execSessionOptions{reconnectWindow: time.Minute})
+	client := test.dial("")
+
+	assert.NoError(t, client.WriteMessage(websocket.TextMessage, []byte("ls\n")))
+	assert.Equal(t, "ls\n", readProcessInput(t, test.process))
+
+	_, err := test.process.Write([]byte("file.txt\n"))
+	assert.NoError(t, err)
+
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, data, err := client.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, "file.txt\n", string(data))
+}
@@ -1,10 +1,15 @@
 package websocket
 
 import (
+	"net/http"
+	"time"
+
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
 	"github.com/portainer/portainer/api/http/proxy/factory/kubernetes"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/kubernetes/cli"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
@@ -15,12 +20,20 @@ import (
 // Handler is the HTTP handler used to handle websocket operations.
 type Handler struct {
 	*mux.Router
-	DataStore                   dataservices.DataStore
-	SignatureService            portainer.DigitalSignatureService
-	ReverseTunnelService        portainer.ReverseTunnelService
-	KubernetesClientFactory     *cli.ClientFactory
+	DataStore               dataservices.DataStore
+	SignatureService        portainer.DigitalSignatureService
+	ReverseTunnelService    portainer.ReverseTunnelService
+	KubernetesClientFactory *cli.ClientFactory
+	DockerClientFactory     *dockerclient.ClientFactory
+	// ExecIdleTimeout closes the exec sessions without input or output for this duration when not zero
+	ExecIdleTimeout time.Duration
+	// ExecReconnectWindow is the duration an exec session using the control protocol can be resumed after its
+	// connection dropped, zero disabling the reconnection
+	ExecReconnectWindow         time.Duration
 	requestBouncer              security.BouncerService
 	connectionUpgrader          websocket.Upgrader
+	connections                 *connectionTracker
+	execSessions                *execSessionManager
 	kubernetesTokenCacheManager *kubernetes.TokenCacheManager
 }
 
@@ -29,9 +42,13 @@ func NewHandler(kubernetesTokenCacheManager *kubernetes.TokenCacheManager, bounc
 	h := &Handler{
 		Router:                      mux.NewRouter(),
 		connectionUpgrader:          websocket.Upgrader{},
+		connections:                 newConnectionTracker(),
+		execSessions:                newExecSessionManager(),
 		requestBouncer:              bouncer,
 		kubernetesTokenCacheManager: kubernetesTokenCacheManager,
 	}
+	h.Use(h.connections.middleware)
+
 	h.PathPrefix("/websocket/exec").Handler(
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.websocketExec)))
 	h.PathPrefix("/websocket/attach").Handler(
@@ -40,5 +57,42 @@ func NewHandler(kubernetesTokenCacheManager *kubernetes.TokenCacheManager, bounc
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.websocketPodExec)))
 	h.PathPrefix("/websocket/kubernetes-shell").Handler(
 		bouncer.AuthenticatedAccess(httperror.LoggerHandler(h.websocketShellPodExec)))
+	h.PathPrefix("/websocket/tunnel").Handler(
+		bouncer.PublicAccess(http.HandlerFunc(h.websocketTunnel)))
 	return h
 }
+
+// CloseConnections closes the websocket connections with a going away status, so that the clients are notified
+// that the server is shutting down instead of seeing the connection being dropped
+func (handler *Handler) CloseConnections() {
+	handler.connections.closeAll(websocket.CloseGoingAway, "server shutting down")
+}
+
+// checkReadOnly rejects the interactive sessions on a read-only environment
+func (handler *Handler) checkReadOnly(endpoint *portainer.Endpoint) *httperror.HandlerError {
+	readOnly, err := endpointutils.IsReadOnly(handler.DataStore, endpoint)
+	if err != nil {
+		return httperror.InternalServerError("Unable to resolve the settings of the environment", err)
+	}
+
+	if readOnly {
+		return httperror.Forbidden("The environment is read-only", endpointutils.ErrReadOnlyEndpoint)
+	}
+
+	return nil
+}
+
+// checkChangeApproval rejects the interactive sessions of the non-administrator users on an environment requiring the
+// approval of its changes, as they cannot be submitted for approval
+func (handler *Handler) checkChangeApproval(r *http.Request, endpoint *portainer.Endpoint) *httperror.HandlerError {
+	tokenData, err := security.RetrieveTokenData(r)
+	if err != nil {
+		return httperror.InternalServerError("Unable to retrieve user authentication token", err)
+	}
+
+	if endpointutils.RequiresChangeApproval(endpoint, tokenData) {
+		return httperror.Forbidden("The interactive sessions of the environment are restricted to the administrators", endpointutils.ErrChangeApprovalRequired)
+	}
+
+	return nil
+}
@@ -1,8 +1,10 @@
 package websocket
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httputil"
 
@@ -10,19 +12,10 @@ import (
 )
 
 func hijackRequest(websocketConn *websocket.Conn, httpConn *httputil.ClientConn, request *http.Request) error {
-	// Server hijacks the connection, error 'connection closed' expected
-	resp, err := httpConn.Do(request)
-	if !errors.Is(err, httputil.ErrPersistEOF) {
-		if err != nil {
-			return err
-		}
-		if resp.StatusCode != http.StatusSwitchingProtocols {
-			resp.Body.Close()
-			return fmt.Errorf("unable to upgrade to tcp, received %d", resp.StatusCode)
-		}
+	tcpConn, brw, err := hijackConnection(httpConn, request)
+	if err != nil {
+		return err
 	}
-
-	tcpConn, brw := httpConn.Hijack()
 	defer tcpConn.Close()
 
 	errorChan := make(chan error, 1)
@@ -36,3 +29,22 @@ func hijackRequest(websocketConn *websocket.Conn, httpConn *httputil.ClientConn,
 
 	return nil
 }
+
+// hijackConnection sends the request upgrading the connection and takes the connection over
+func hijackConnection(httpConn *httputil.ClientConn, request *http.Request) (net.Conn, *bufio.Reader, error) {
+	// Server hijacks the connection, error 'connection closed' expected
+	resp, err := httpConn.Do(request)
+	if !errors.Is(err, httputil.ErrPersistEOF) {
+		if err != nil {
+			return nil, nil, err
+		}
+		if resp.StatusCode != http.StatusSwitchingProtocols {
+			resp.Body.Close()
+			return nil, nil, fmt.Errorf("unable to upgrade to tcp, received %d", resp.StatusCode)
+		}
+	}
+
+	tcpConn, brw := httpConn.Hijack()
+
+	return tcpConn, brw, nil
+}
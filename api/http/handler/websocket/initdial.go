@@ -1,15 +1,26 @@
 package websocket
 
 import (
+	"context"
 	"crypto/tls"
 	"net"
 	"net/url"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/internal/endpointutils"
 )
 
-func initDial(endpoint *portainer.Endpoint) (net.Conn, error) {
+func (handler *Handler) initDial(endpoint *portainer.Endpoint) (net.Conn, error) {
+	if endpointutils.IsDockerSSHEndpoint(endpoint) {
+		dialContext, err := handler.DockerClientFactory.SSHDialContext(endpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		return dialContext(context.Background(), "", "")
+	}
+
 	url, err := url.Parse(endpoint.URL)
 	if err != nil {
 		return nil, err
@@ -26,6 +37,7 @@ func initDial(endpoint *portainer.Endpoint) (net.Conn, error) {
 		if err != nil {
 			return nil, err
 		}
+		crypto.PinPublicKeys(tlsConfig, endpoint.AgentPublicKeyPins)
 
 		return tls.Dial(url.Scheme, host, tlsConfig)
 	}
@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -74,6 +75,10 @@ func (handler *Handler) websocketPodExec(w http.ResponseWriter, r *http.Request)
 		return httperror.Forbidden("Permission denied to access environment", err)
 	}
 
+	if httpErr := handler.checkReadOnly(endpoint); httpErr != nil {
+		return httpErr
+	}
+
 	serviceAccountToken, isAdminToken, err := handler.getToken(r, endpoint, false)
 	if err != nil {
 		return httperror.InternalServerError("Unable to get user service account token", err)
@@ -142,10 +147,15 @@ func (handler *Handler) hijackPodExecStartOperation(
 	go streamFromWebsocketToWriter(websocketConn, stdinWriter, errorChan)
 	go streamFromReaderToWebsocket(websocketConn, stdoutReader, errorChan)
 
+	// The stream is closed once the websocket client is gone, so that the process does not keep running in the pod.
+	// The context of the request is not canceled when the client disconnects as the connection was hijacked.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
 	// StartExecProcess is a blocking operation which streams IO to/from pod;
 	// this must execute in asynchronously, since the websocketConn could return errors (e.g. client disconnects) before
 	// the blocking operation is completed.
-	go cli.StartExecProcess(serviceAccountToken, isAdminToken, namespace, podName, containerName, commandArray, stdinReader, stdoutWriter, errorChan)
+	go cli.StartExecProcess(ctx, serviceAccountToken, isAdminToken, namespace, podName, containerName, commandArray, stdinReader, stdoutWriter, errorChan)
 
 	err = <-errorChan
 
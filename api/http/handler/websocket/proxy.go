@@ -7,6 +7,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/http/proxy/factory/agent"
 
 	"github.com/gorilla/websocket"
 	"github.com/koding/websocketproxy"
@@ -66,12 +67,23 @@ func (handler *Handler) proxyAgentWebsocketRequest(w http.ResponseWriter, r *htt
 
 		tlsConfig := crypto.CreateTLSConfiguration()
 		tlsConfig.InsecureSkipVerify = params.endpoint.TLSConfig.TLSSkipVerify
+		crypto.PinPublicKeys(tlsConfig, params.endpoint.AgentPublicKeyPins)
 
 		proxy.Dialer = &websocket.Dialer{
 			TLSClientConfig: tlsConfig,
 		}
 	}
 
+	if params.endpoint.Type == portainer.AgentOnDockerEnvironment {
+		if proxy.Dialer == nil {
+			dialer := *websocket.DefaultDialer
+			proxy.Dialer = &dialer
+		}
+
+		// the configured agent may be down while the other members of its cluster are reachable
+		proxy.Dialer.NetDialContext = agent.ClusterDialContext(params.endpoint)
+	}
+
 	signature, err := handler.SignatureService.CreateSignature(portainer.PortainerAgentSignatureMessage)
 	if err != nil {
 		return err
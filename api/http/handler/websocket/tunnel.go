@@ -0,0 +1,19 @@
+package websocket
+
+import (
+	"net/http"
+)
+
+// @summary Open a reverse tunnel over a websocket
+// @description Used by Edge agents that cannot reach the tunnel server port to establish their reverse tunnel
+// @description over the HTTPS listener instead. The connection is forwarded to the tunnel server which
+// @description authenticates the agent using the tunnel credentials.
+// @description **Access policy**: public
+// @tags websocket
+// @success 101
+// @failure 400
+// @failure 503
+// @router /websocket/tunnel [get]
+func (handler *Handler) websocketTunnel(w http.ResponseWriter, r *http.Request) {
+	handler.ReverseTunnelService.TunnelHandler().ServeHTTP(w, r)
+}
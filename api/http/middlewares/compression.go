@@ -0,0 +1,189 @@
+package middlewares
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	// compressionMinSize is the size under which a response with a known length is not worth compressing
+	compressionMinSize = 1024
+	// brotliLevel trades some compression ratio for the speed required by dynamic responses
+	brotliLevel = 4
+)
+
+// compressibleContentTypes are the prefixes of the content types which are compressed, the images, archives
+// and Docker streams being either already compressed or streamed
+var compressibleContentTypes = []string{
+	"application/javascript",
+	"application/json",
+	"application/xml",
+	"application/x-yaml",
+	"application/yaml",
+	"image/svg+xml",
+	"text/",
+}
+
+// WithCompression compresses the responses with brotli or gzip, as negotiated with the Accept-Encoding header.
+// The websockets, the hijacked connections, the server-sent events and the responses which are already encoded
+// are not compressed.
+func WithCompression(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" ||
+			r.Method == http.MethodHead ||
+			r.Header.Get("Upgrade") != "" ||
+			r.Header.Get("Range") != "" ||
+			strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		cw := &compressResponseWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding returns the preferred encoding accepted by the client, brotli being preferred to gzip
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := map[string]bool{}
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		accepted[strings.ToLower(strings.TrimSpace(name))] = q > 0
+	}
+
+	switch {
+	case accepted["br"]:
+		return "br"
+	case accepted["gzip"]:
+		return "gzip"
+	}
+
+	return ""
+}
+
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding    string
+	writer      io.WriteCloser
+	wroteHeader bool
+}
+
+func (cw *compressResponseWriter) WriteHeader(statusCode int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+
+	if cw.shouldCompress(statusCode) {
+		h := cw.Header()
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+
+		if cw.encoding == "br" {
+			cw.writer = brotli.NewWriterLevel(cw.ResponseWriter, brotliLevel)
+		} else {
+			cw.writer = gzip.NewWriter(cw.ResponseWriter)
+		}
+	}
+
+	cw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (cw *compressResponseWriter) shouldCompress(statusCode int) bool {
+	h := cw.Header()
+
+	if statusCode < http.StatusOK || statusCode == http.StatusNoContent || statusCode == http.StatusNotModified {
+		return false
+	}
+
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	if length, err := strconv.Atoi(h.Get("Content-Length")); err == nil && length < compressionMinSize {
+		return false
+	}
+
+	contentType := strings.ToLower(h.Get("Content-Type"))
+	for _, prefix := range compressibleContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cw *compressResponseWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		if cw.Header().Get("Content-Type") == "" {
+			cw.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if cw.writer == nil {
+		return cw.ResponseWriter.Write(b)
+	}
+
+	return cw.writer.Write(b)
+}
+
+// Flush sends the data compressed so far, so that the streamed responses keep working
+func (cw *compressResponseWriter) Flush() {
+	if cw.writer != nil {
+		if flusher, ok := cw.writer.(interface{ Flush() error }); ok {
+			flusher.Flush()
+		}
+	}
+
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (cw *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if cw.writer != nil {
+		return nil, nil, errors.New("cannot hijack a compressed response")
+	}
+
+	hijacker, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the response writer does not support hijacking")
+	}
+
+	return hijacker.Hijack()
+}
+
+func (cw *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressResponseWriter) close() {
+	if cw.writer != nil {
+		cw.writer.Close()
+	}
+}
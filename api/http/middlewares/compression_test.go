@@ -0,0 +1,101 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                       "",
+		"gzip":                   "gzip",
+		"gzip, deflate, br":      "br",
+		"br;q=0, gzip":           "gzip",
+		"identity":               "",
+		"GZIP;q=0.5, deflate":    "gzip",
+		"gzip;q=0, br;q=0, zstd": "",
+	}
+
+	for acceptEncoding, expected := range tests {
+		assert.Equal(t, expected, negotiateEncoding(acceptEncoding), acceptEncoding)
+	}
+}
+
+func TestWithCompression(t *testing.T) {
+	is := assert.New(t)
+
+	payload := `{"containers": "` + strings.Repeat("a", 4096) + `"}`
+
+	handler := WithCompression(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Length", "2")
+			w.Write([]byte("{}"))
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(payload))
+		case "/encoded":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write([]byte(payload))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(payload))
+		}
+	}))
+
+	do := func(path, acceptEncoding string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("gzip", func(t *testing.T) {
+		rr := do("/api/endpoints", "gzip")
+		is.Equal("gzip", rr.Header().Get("Content-Encoding"))
+		is.Contains(rr.Header().Values("Vary"), "Accept-Encoding")
+
+		reader, err := gzip.NewReader(rr.Body)
+		is.NoError(err)
+		body, err := io.ReadAll(reader)
+		is.NoError(err)
+		is.Equal(payload, string(body))
+	})
+
+	t.Run("brotli", func(t *testing.T) {
+		rr := do("/api/endpoints", "gzip, br")
+		is.Equal("br", rr.Header().Get("Content-Encoding"))
+		is.Less(rr.Body.Len(), len(payload))
+
+		body, err := io.ReadAll(brotli.NewReader(rr.Body))
+		is.NoError(err)
+		is.Equal(payload, string(body))
+	})
+
+	t.Run("not compressed", func(t *testing.T) {
+		is.Empty(do("/api/endpoints", "").Header().Get("Content-Encoding"))
+		is.Empty(do("/small", "gzip").Header().Get("Content-Encoding"))
+		is.Empty(do("/image", "gzip").Header().Get("Content-Encoding"))
+		is.Empty(do("/api/websocket/exec", "gzip", "Upgrade", "websocket").Header().Get("Content-Encoding"))
+		is.Empty(do("/api/system/events", "gzip", "Accept", "text/event-stream").Header().Get("Content-Encoding"))
+
+		rr := do("/encoded", "br")
+		is.Equal("gzip", rr.Header().Get("Content-Encoding"))
+		is.Equal(payload, rr.Body.String())
+	})
+}
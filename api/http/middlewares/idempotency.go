@@ -0,0 +1,265 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/portainer/portainer/api/http/security"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+)
+
+const (
+	// IdempotencyKeyHeader is the header carrying the key identifying the retries of a request
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on the responses replayed from the cache
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// idempotencyWindow is the duration the responses are kept for the retries
+	idempotencyWindow         = 24 * time.Hour
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentResponseSize = 1024 * 1024
+)
+
+var (
+	errIdempotencyKeyInvalid  = errors.New("invalid idempotency key")
+	errIdempotencyKeyInUse    = errors.New("a request with the same idempotency key is in progress")
+	errIdempotencyKeyMismatch = errors.New("the idempotency key was used for a different request")
+)
+
+// idempotentResponse is a response kept for the retries of a request, done being closed once it is complete
+type idempotentResponse struct {
+	done       chan struct{}
+	bodyHash   []byte
+	statusCode int
+	header     http.Header
+	body       []byte
+	expiresAt  time.Time
+}
+
+// idempotencyStore keeps the responses of the requests by user and idempotency key
+type idempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]*idempotentResponse
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+var defaultIdempotencyStore = newIdempotencyStore()
+
+func newIdempotencyStore() *idempotencyStore {
+	return &idempotencyStore{
+		responses: make(map[string]*idempotentResponse),
+		now:       time.Now,
+	}
+}
+
+// WithIdempotency replays the response of an authenticated request retried with the same Idempotency-Key header
+// for 24 hours, instead of executing it again. A retry sent while the request is in progress is rejected with a 409,
+// and the reuse of a key for a different request with a 422. The server errors are not kept, so that the requests
+// failing with them can be retried.
+func WithIdempotency(next http.Handler) http.Handler {
+	return defaultIdempotencyStore.middleware(next)
+}
+
+func (store *idempotencyStore) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			httperror.WriteError(w, http.StatusBadRequest, "The idempotency key must not exceed 255 characters", errIdempotencyKeyInvalid)
+			return
+		}
+
+		tokenData, err := security.RetrieveTokenData(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		storeKey := strconv.Itoa(int(tokenData.ID)) + " " + r.Method + " " + r.URL.Path + " " + key
+
+		response, found := store.reserve(storeKey)
+		if found {
+			store.replay(w, r, response)
+			return
+		}
+
+		hasher := sha256.New()
+		r.Body = &hashingReader{ReadCloser: r.Body, hash: hasher}
+
+		recorder := &responseRecorder{ResponseWriter: w, header: make(http.Header), statusCode: http.StatusOK}
+		defer func() {
+			if err := recover(); err != nil {
+				store.release(storeKey, response)
+				panic(err)
+			}
+
+			recorder.WriteHeader(http.StatusOK)
+
+			// hash the part of the body that was not read by the handler
+			io.Copy(io.Discard, r.Body)
+
+			store.complete(storeKey, response, hasher.Sum(nil), recorder)
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// reserve returns the response of the key, or creates a pending one when the key is unknown
+func (store *idempotencyStore) reserve(key string) (*idempotentResponse, bool) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := store.now()
+	if now.Sub(store.lastSweep) > time.Minute {
+		store.lastSweep = now
+
+		for k, response := range store.responses {
+			if !response.expiresAt.IsZero() && now.After(response.expiresAt) {
+				delete(store.responses, k)
+			}
+		}
+	}
+
+	if response, ok := store.responses[key]; ok && (response.expiresAt.IsZero() || now.Before(response.expiresAt)) {
+		return response, true
+	}
+
+	response := &idempotentResponse{done: make(chan struct{})}
+	store.responses[key] = response
+
+	return response, false
+}
+
+// complete keeps the response for the retries, or forgets the key when it cannot be replayed
+func (store *idempotencyStore) complete(key string, response *idempotentResponse, bodyHash []byte, recorder *responseRecorder) {
+	if recorder.statusCode >= http.StatusInternalServerError || recorder.overflow {
+		store.release(key, response)
+		return
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	response.bodyHash = bodyHash
+	response.statusCode = recorder.statusCode
+	response.header = recorder.header.Clone()
+	response.body = recorder.body.Bytes()
+	response.expiresAt = store.now().Add(idempotencyWindow)
+
+	close(response.done)
+}
+
+// release forgets the key of a pending response, so that the request can be retried
+func (store *idempotencyStore) release(key string, response *idempotentResponse) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.responses[key] == response {
+		delete(store.responses, key)
+	}
+
+	close(response.done)
+}
+
+func (store *idempotencyStore) replay(w http.ResponseWriter, r *http.Request, response *idempotentResponse) {
+	select {
+	case <-response.done:
+	default:
+		httperror.WriteError(w, http.StatusConflict, "A request with the same idempotency key is in progress, retry later", errIdempotencyKeyInUse)
+		return
+	}
+
+	if response.expiresAt.IsZero() {
+		// the request failed and its key was forgotten after this retry found it
+		httperror.WriteError(w, http.StatusConflict, "The request with the same idempotency key failed, retry it", errIdempotencyKeyInUse)
+		return
+	}
+
+	hasher := sha256.New()
+	io.Copy(hasher, r.Body)
+	if !bytes.Equal(hasher.Sum(nil), response.bodyHash) {
+		httperror.WriteError(w, http.StatusUnprocessableEntity, "The idempotency key was already used for a different request", errIdempotencyKeyMismatch)
+		return
+	}
+
+	for k, v := range response.header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(response.statusCode)
+	w.Write(response.body)
+}
+
+type hashingReader struct {
+	io.ReadCloser
+	hash hash.Hash
+}
+
+func (reader *hashingReader) Read(p []byte) (int, error) {
+	n, err := reader.ReadCloser.Read(p)
+	reader.hash.Write(p[:n])
+
+	return n, err
+}
+
+// responseRecorder writes the response through while keeping a copy of it. The handler headers are kept apart
+// from the ones set by the outer middlewares, so that only them are replayed.
+type responseRecorder struct {
+	http.ResponseWriter
+	header      http.Header
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+	overflow    bool
+}
+
+func (recorder *responseRecorder) Header() http.Header {
+	return recorder.header
+}
+
+func (recorder *responseRecorder) WriteHeader(statusCode int) {
+	if recorder.wroteHeader {
+		return
+	}
+
+	recorder.wroteHeader = true
+	recorder.statusCode = statusCode
+
+	for k, v := range recorder.header {
+		recorder.ResponseWriter.Header()[k] = v
+	}
+
+	recorder.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (recorder *responseRecorder) Write(b []byte) (int, error) {
+	recorder.WriteHeader(http.StatusOK)
+
+	if !recorder.overflow {
+		if recorder.body.Len()+len(b) > maxIdempotentResponseSize {
+			recorder.overflow = true
+			recorder.body.Reset()
+		} else {
+			recorder.body.Write(b)
+		}
+	}
+
+	return recorder.ResponseWriter.Write(b)
+}
+
+func (recorder *responseRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
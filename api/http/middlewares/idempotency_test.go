@@ -0,0 +1,127 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithIdempotency(t *testing.T) {
+	is := assert.New(t)
+
+	store := newIdempotencyStore()
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+
+	var created atomic.Int32
+	status := http.StatusOK
+	handler := store.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := created.Add(1)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"Id":` + strconv.Itoa(int(id)) + `}`))
+	}))
+
+	do := func(userID portainer.UserID, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/endpoints", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: userID}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("requests without key are executed", func(t *testing.T) {
+		do(1, "", "a")
+		do(1, "", "a")
+		is.Equal(int32(2), created.Load())
+	})
+
+	t.Run("retries are replayed", func(t *testing.T) {
+		rr := do(1, "create-env", "a")
+		is.Equal(`{"Id":3}`, rr.Body.String())
+		is.Empty(rr.Header().Get(IdempotentReplayedHeader))
+
+		rr = do(1, "create-env", "a")
+		is.Equal(http.StatusOK, rr.Code)
+		is.Equal(`{"Id":3}`, rr.Body.String())
+		is.Equal("application/json", rr.Header().Get("Content-Type"))
+		is.Equal("true", rr.Header().Get(IdempotentReplayedHeader))
+		is.Equal(int32(3), created.Load())
+	})
+
+	t.Run("the keys are scoped by user", func(t *testing.T) {
+		is.Equal(`{"Id":4}`, do(2, "create-env", "a").Body.String())
+	})
+
+	t.Run("a key cannot be reused for a different request", func(t *testing.T) {
+		is.Equal(http.StatusUnprocessableEntity, do(1, "create-env", "b").Code)
+	})
+
+	t.Run("the keys expire", func(t *testing.T) {
+		now = now.Add(idempotencyWindow + time.Minute)
+		is.Equal(`{"Id":5}`, do(1, "create-env", "a").Body.String())
+	})
+
+	t.Run("server errors are not kept", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		do(1, "failing", "a")
+
+		status = http.StatusOK
+		rr := do(1, "failing", "a")
+		is.Equal(`{"Id":7}`, rr.Body.String())
+		is.Empty(rr.Header().Get(IdempotentReplayedHeader))
+	})
+}
+
+func TestWithIdempotency_InProgress(t *testing.T) {
+	store := newIdempotencyStore()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := store.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", nil)
+		req.Header.Set(IdempotencyKeyHeader, "key")
+
+		return req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 1}))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+		close(done)
+	}()
+
+	<-started
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newRequest())
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	close(release)
+	<-done
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, newRequest())
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "true", rr.Header().Get(IdempotentReplayedHeader))
+}
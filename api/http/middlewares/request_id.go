@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// validRequestID restricts the request IDs sent by the clients to the characters safe to log and to forward
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// WithRequestID honors the X-Request-ID header of the request or generates one when it is missing or invalid. The
+// identifier is set on the request so that the proxies forward it to the environments, returned in the response and
+// added to the logger of the request context, retrieved with log.Ctx.
+func WithRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(request.RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.Must(uuid.NewV4()).String()
+		}
+
+		r.Header.Set(request.RequestIDHeader, requestID)
+		w.Header().Set(request.RequestIDHeader, requestID)
+
+		logger := log.With().Str("request_id", requestID).Logger()
+
+		next.ServeHTTP(w, r.WithContext(logger.WithContext(r.Context())))
+	})
+}
@@ -0,0 +1,55 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRequestID(t *testing.T) {
+	is := assert.New(t)
+
+	var forwarded string
+	handler := WithRequestID(httperror.LoggerHandler(func(w http.ResponseWriter, r *http.Request) *httperror.HandlerError {
+		forwarded = r.Header.Get(request.RequestIDHeader)
+
+		return httperror.NotFound("Unable to find the environment", errors.New("not found"))
+	}))
+
+	serve := func(requestID string) (*httptest.ResponseRecorder, string) {
+		r := httptest.NewRequest(http.MethodGet, "/endpoints/1", nil)
+		if requestID != "" {
+			r.Header.Set(request.RequestIDHeader, requestID)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		var body struct {
+			RequestID string `json:"requestId"`
+		}
+		is.NoError(json.NewDecoder(w.Body).Decode(&body))
+
+		return w, body.RequestID
+	}
+
+	w, bodyRequestID := serve("ui-4f2a")
+	is.Equal("ui-4f2a", w.Header().Get(request.RequestIDHeader), "the request ID of the client should be honored")
+	is.Equal("ui-4f2a", forwarded, "the request ID should be set on the request for the proxies")
+	is.Equal("ui-4f2a", bodyRequestID, "the request ID should be included in the error response")
+
+	w, bodyRequestID = serve("")
+	is.Len(w.Header().Get(request.RequestIDHeader), 36, "a request ID should be generated")
+	is.Equal(w.Header().Get(request.RequestIDHeader), bodyRequestID)
+
+	w, _ = serve("invalid id\n")
+	is.NotEqual("invalid id\n", w.Header().Get(request.RequestIDHeader), "an invalid request ID should be replaced")
+	is.Len(w.Header().Get(request.RequestIDHeader), 36)
+}
@@ -0,0 +1,174 @@
+package middlewares
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/portainer/portainer/api/database/changes"
+	"github.com/portainer/portainer/api/http/security"
+)
+
+const (
+	// ResponseCacheTTL bounds the age of the cached responses, some of them containing data computed from the
+	// current time, e.g. the heartbeat of the Edge environments
+	ResponseCacheTTL = 5 * time.Second
+
+	maxCachedResponses = 1000
+)
+
+// ResponseCache caches the JSON responses of the list handlers, per user and per URL, until one of the database
+// buckets they are built from is modified. The responses are tagged with an ETag so that clients polling the
+// same list receive a 304 Not Modified response while it does not change.
+type ResponseCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+}
+
+type cachedResponse struct {
+	versions  []uint64
+	createdAt time.Time
+	etag      string
+	header    http.Header
+	body      []byte
+}
+
+// NewResponseCache creates a cache keeping the responses up to the specified duration
+func NewResponseCache(ttl time.Duration) *ResponseCache {
+	return &ResponseCache{
+		ttl:     ttl,
+		entries: make(map[string]*cachedResponse),
+	}
+}
+
+// Handler serves the responses of next from the cache, the responses depending on the content of the buckets
+func (cache *ResponseCache) Handler(next http.Handler, buckets ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tokenData, err := security.RetrieveTokenData(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := fmt.Sprintf("%d:%s", tokenData.ID, r.URL.RequestURI())
+
+		// the versions are read before building the response so that a modification committed meanwhile
+		// invalidates it
+		versions := make([]uint64, len(buckets))
+		for i, bucket := range buckets {
+			versions[i] = changes.Version(bucket)
+		}
+
+		if cached := cache.get(key, versions); cached != nil {
+			writeCachedResponse(w, r, cached)
+			return
+		}
+
+		rr := httptest.NewRecorder()
+		next.ServeHTTP(rr, r)
+
+		if rr.Code != http.StatusOK {
+			copyHeader(w.Header(), rr.Header())
+			w.WriteHeader(rr.Code)
+			w.Write(rr.Body.Bytes())
+
+			return
+		}
+
+		h := fnv.New64a()
+		h.Write(rr.Body.Bytes())
+
+		cached := &cachedResponse{
+			versions:  versions,
+			createdAt: time.Now(),
+			etag:      strconv.Quote(strconv.FormatUint(h.Sum64(), 16)),
+			header:    rr.Header().Clone(),
+			body:      rr.Body.Bytes(),
+		}
+		cache.set(key, cached)
+
+		writeCachedResponse(w, r, cached)
+	})
+}
+
+func (cache *ResponseCache) get(key string, versions []uint64) *cachedResponse {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cached, ok := cache.entries[key]
+	if !ok {
+		return nil
+	}
+
+	if time.Since(cached.createdAt) > cache.ttl || !slices.Equal(cached.versions, versions) {
+		delete(cache.entries, key)
+		return nil
+	}
+
+	return cached
+}
+
+func (cache *ResponseCache) set(key string, cached *cachedResponse) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if len(cache.entries) >= maxCachedResponses {
+		for k, entry := range cache.entries {
+			if time.Since(entry.createdAt) > cache.ttl {
+				delete(cache.entries, k)
+			}
+		}
+
+		if len(cache.entries) >= maxCachedResponses {
+			cache.entries = make(map[string]*cachedResponse)
+		}
+	}
+
+	cache.entries[key] = cached
+}
+
+func writeCachedResponse(w http.ResponseWriter, r *http.Request, cached *cachedResponse) {
+	copyHeader(w.Header(), cached.header)
+	w.Header().Set("ETag", cached.etag)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if etagMatches(r.Header.Get("If-None-Match"), cached.etag) {
+		w.Header().Del("Content-Type")
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(cached.body)
+}
+
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func copyHeader(dst, src http.Header) {
+	for k, vs := range src {
+		dst[k] = append([]string(nil), vs...)
+	}
+}
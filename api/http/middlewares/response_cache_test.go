@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/database/changes"
+	"github.com/portainer/portainer/api/http/security"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseCache(t *testing.T) {
+	const bucket = "response_cache_test"
+
+	calls := 0
+	handler := NewResponseCache(ResponseCacheTTL).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", "1")
+		w.Write([]byte(`[{"Id":1}]`))
+	}), bucket)
+
+	get := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tags", nil)
+		req = req.WithContext(security.StoreTokenData(req, &portainer.TokenData{ID: 1}))
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	rr := get("")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `[{"Id":1}]`, rr.Body.String())
+	assert.Equal(t, "1", rr.Header().Get("X-Total-Count"))
+	etag := rr.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	rr = get("")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `[{"Id":1}]`, rr.Body.String())
+	assert.Equal(t, 1, calls, "the response should be served from the cache")
+
+	rr = get(etag)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Empty(t, rr.Body.String())
+	assert.Equal(t, 1, calls)
+
+	changes.Notify(bucket, "1", changes.ActionUpdate)
+
+	rr = get(etag)
+	assert.Equal(t, http.StatusNotModified, rr.Code, "the content did not change")
+	assert.Equal(t, 2, calls, "the cache should be invalidated by the modification of the bucket")
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -30,6 +32,7 @@ func WithSlowRequestsLogger(next http.Handler) http.Handler {
 				Dur("elapsed_ms", d).
 				Str("method", req.Method).
 				Str("url", req.URL.String()).
+				Str("request_id", req.Header.Get(request.RequestIDHeader)).
 				Msg("slow request")
 		}
 	})
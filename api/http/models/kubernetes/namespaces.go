@@ -1,12 +1,64 @@
 package kubernetes
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
 
 type K8sNamespaceDetails struct {
-	Name        string            `json:"Name"`
-	Annotations map[string]string `json:"Annotations"`
+	Name          string            `json:"Name"`
+	Annotations   map[string]string `json:"Annotations"`
+	ResourceQuota *K8sResourceQuota `json:"ResourceQuota"`
+	LimitRange    *K8sLimitRange    `json:"LimitRange"`
+}
+
+// K8sResourceQuota represents the CPU and memory limits applied to all the workloads of a namespace
+type K8sResourceQuota struct {
+	Enabled bool   `json:"enabled"`
+	CPU     string `json:"cpu" example:"2"`
+	Memory  string `json:"memory" example:"2Gi"`
+}
+
+// K8sLimitRange represents the default CPU and memory limits applied to the containers of a namespace
+// that do not specify their own
+type K8sLimitRange struct {
+	Enabled bool   `json:"enabled"`
+	CPU     string `json:"cpu" example:"500m"`
+	Memory  string `json:"memory" example:"256Mi"`
 }
 
 func (r *K8sNamespaceDetails) Validate(request *http.Request) error {
+	if r.ResourceQuota != nil && r.ResourceQuota.Enabled {
+		if err := validateQuantities(r.ResourceQuota.CPU, r.ResourceQuota.Memory); err != nil {
+			return fmt.Errorf("invalid resource quota: %w", err)
+		}
+	}
+
+	if r.LimitRange != nil && r.LimitRange.Enabled {
+		if err := validateQuantities(r.LimitRange.CPU, r.LimitRange.Memory); err != nil {
+			return fmt.Errorf("invalid limit range: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func validateQuantities(cpu, memory string) error {
+	if cpu == "" && memory == "" {
+		return fmt.Errorf("at least one of cpu or memory is required")
+	}
+
+	for _, quantity := range []string{cpu, memory} {
+		if quantity == "" {
+			continue
+		}
+
+		if _, err := resource.ParseQuantity(quantity); err != nil {
+			return fmt.Errorf("%q: %w", quantity, err)
+		}
+	}
+
 	return nil
 }
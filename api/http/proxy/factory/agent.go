@@ -1,9 +1,11 @@
 package factory
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
+	neturl "net/url"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/crypto"
@@ -23,6 +25,10 @@ type ProxyServer struct {
 
 // NewAgentProxy creates a new instance of ProxyServer that wrap http requests with agent headers
 func (factory *ProxyFactory) NewAgentProxy(endpoint *portainer.Endpoint) (*ProxyServer, error) {
+	if endpointutils.IsDockerSSHEndpoint(endpoint) {
+		return factory.newSSHProxyServer(endpoint)
+	}
+
 	urlString := endpoint.URL
 
 	if endpointutils.IsEdgeEndpoint(endpoint) {
@@ -40,18 +46,31 @@ func (factory *ProxyFactory) NewAgentProxy(endpoint *portainer.Endpoint) (*Proxy
 	}
 
 	endpointURL.Scheme = "http"
-	httpTransport := &http.Transport{}
+	var tlsConfig *tls.Config
 
 	if endpoint.TLSConfig.TLS || endpoint.TLSConfig.TLSSkipVerify {
 		config, err := crypto.CreateTLSConfigurationFromDisk(endpoint.TLSConfig.TLSCACertPath, endpoint.TLSConfig.TLSCertPath, endpoint.TLSConfig.TLSKeyPath, endpoint.TLSConfig.TLSSkipVerify)
 		if err != nil {
 			return nil, errors.WithMessage(err, "failed generating tls configuration")
 		}
+		crypto.PinPublicKeys(config, endpoint.AgentPublicKeyPins)
 
-		httpTransport.TLSClientConfig = config
+		tlsConfig = config
 		endpointURL.Scheme = "https"
 	}
 
+	httpTransport := agent.NewTunnelHTTPTransport()
+	if !endpointutils.IsEdgeEndpoint(endpoint) {
+		httpTransport = agent.NewHTTPTransport(tlsConfig)
+	}
+
+	if endpoint.Type == portainer.AgentOnDockerEnvironment {
+		httpTransport, err = agent.NewClusterTransport(httpTransport, endpoint, nil, nil)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed creating the agent cluster transport")
+		}
+	}
+
 	proxy := newSingleHostReverseProxyWithHostHeader(endpointURL)
 
 	proxy.Transport = agent.NewTransport(factory.signatureService, httpTransport)
@@ -71,6 +90,31 @@ func (factory *ProxyFactory) NewAgentProxy(endpoint *portainer.Endpoint) (*Proxy
 	return proxyServer, nil
 }
 
+// newSSHProxyServer creates a ProxyServer forwarding the requests to the Docker socket of a host reached via SSH
+func (factory *ProxyFactory) newSSHProxyServer(endpoint *portainer.Endpoint) (*ProxyServer, error) {
+	dialContext, err := factory.dockerClientFactory.SSHDialContext(endpoint)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed creating the SSH dialer")
+	}
+
+	proxy := newSingleHostReverseProxyWithHostHeader(&neturl.URL{Scheme: "http", Host: sshProxyHost})
+	proxy.Transport = &http.Transport{DialContext: dialContext}
+
+	proxyServer := &ProxyServer{
+		server: &http.Server{
+			Handler: proxy,
+		},
+		Port: 0,
+	}
+
+	err = proxyServer.start()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed starting proxy server")
+	}
+
+	return proxyServer, nil
+}
+
 func (proxy *ProxyServer) start() error {
 	listener, err := net.Listen("tcp", ":0")
 	if err != nil {
@@ -0,0 +1,428 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/url"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// memberDownDuration is the duration an unreachable member of an agent cluster is tried last
+	memberDownDuration = 30 * time.Second
+	// discoveryInterval is the duration between two discoveries of the members of an agent cluster
+	discoveryInterval = 5 * time.Minute
+	// discoveryTimeout bounds the request listing the members of an agent cluster
+	discoveryTimeout = 10 * time.Second
+	// defaultAgentPort is the port of the agents when the URL of the environment(endpoint) does not specify one
+	defaultAgentPort = "9001"
+)
+
+// ClusterMember is a member of an agent cluster, as listed by the agents
+type ClusterMember struct {
+	IPAddress string
+	NodeName  string
+	NodeRole  int
+}
+
+// unreachableMembers are the members of the agent clusters whose last connection attempt failed, shared by the
+// transports and dialers reaching the same clusters
+var unreachableMembers = &memberHealth{downUntil: make(map[string]time.Time)}
+
+type memberHealth struct {
+	mu        sync.Mutex
+	downUntil map[string]time.Time
+}
+
+func (health *memberHealth) markDown(address string) {
+	health.mu.Lock()
+	health.downUntil[address] = time.Now().Add(memberDownDuration)
+	health.mu.Unlock()
+}
+
+func (health *memberHealth) markUp(address string) {
+	health.mu.Lock()
+	delete(health.downUntil, address)
+	health.mu.Unlock()
+}
+
+func (health *memberHealth) isDown(address string) bool {
+	health.mu.Lock()
+	defer health.mu.Unlock()
+
+	return time.Now().Before(health.downUntil[address])
+}
+
+// ClusterURLs returns the URL of an environment(endpoint) followed by the URLs of the other members of its agent
+// cluster
+func ClusterURLs(endpoint *portainer.Endpoint) []string {
+	urls := []string{endpoint.URL}
+
+	primary, err := primaryAddress(endpoint)
+	if err != nil {
+		return urls
+	}
+
+	for _, member := range endpoint.AgentClusterMembers {
+		if member != primary {
+			urls = append(urls, "tcp://"+member)
+		}
+	}
+
+	return urls
+}
+
+// ClusterDialContext returns a function opening a connection to the first reachable member of the agent cluster of
+// an environment(endpoint), starting with the configured one. The connections to other addresses are opened as is.
+func ClusterDialContext(endpoint *portainer.Endpoint) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := newDialer(currentHTTPSettings())
+
+	primary, err := primaryAddress(endpoint)
+	if err != nil {
+		return dialer.DialContext
+	}
+
+	addresses := clusterAddresses(primary, endpoint.AgentClusterMembers)
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr != primary {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		var lastErr error
+		for _, address := range orderAddresses(addresses, -1) {
+			conn, err := dialer.DialContext(ctx, network, address)
+			if err == nil {
+				unreachableMembers.markUp(address)
+				return conn, nil
+			}
+
+			unreachableMembers.markDown(address)
+			lastErr = err
+		}
+
+		return nil, lastErr
+	}
+}
+
+// ClusterTransport sends the requests of an environment(endpoint) to the members of its agent cluster, any member
+// being able to forward a request to the node it targets. The requests targeting a node are load-balanced across the
+// reachable members while the other ones are sent to the configured member. A request fails over to the next member
+// when its member cannot be reached.
+type ClusterTransport struct {
+	httpTransport    http.RoundTripper
+	primary          string
+	signatureService portainer.DigitalSignatureService
+	onDiscovered     func(members []string)
+	next             atomic.Uint32
+
+	mu            sync.Mutex
+	members       []string
+	lastDiscovery time.Time
+	discovering   bool
+}
+
+// NewClusterTransport returns a transport sending the requests to the members of the agent cluster of an
+// environment(endpoint). When signatureService is not nil, the members are periodically discovered through the
+// agents and onDiscovered is called when they change.
+func NewClusterTransport(httpTransport http.RoundTripper, endpoint *portainer.Endpoint, signatureService portainer.DigitalSignatureService, onDiscovered func(members []string)) (*ClusterTransport, error) {
+	primary, err := primaryAddress(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClusterTransport{
+		httpTransport:    httpTransport,
+		primary:          primary,
+		signatureService: signatureService,
+		onDiscovered:     onDiscovered,
+		members:          endpoint.AgentClusterMembers,
+	}, nil
+}
+
+// RoundTrip is the implementation of the http.RoundTripper interface
+func (transport *ClusterTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport.discover(request.URL.Scheme)
+
+	transport.mu.Lock()
+	addresses := clusterAddresses(transport.primary, transport.members)
+	transport.mu.Unlock()
+
+	start := -1
+	if request.Header.Get(portainer.PortainerAgentTargetHeader) != "" {
+		start = int(transport.next.Add(1))
+	}
+	addresses = orderAddresses(addresses, start)
+
+	var body *replayableBody
+	if request.Body != nil && request.Body != http.NoBody {
+		body = &replayableBody{ReadCloser: request.Body}
+	}
+
+	for i, address := range addresses {
+		outRequest := request.Clone(request.Context())
+		outRequest.URL.Host = address
+		if body != nil {
+			body.reset()
+			outRequest.Body = body
+		}
+
+		response, err := transport.httpTransport.RoundTrip(outRequest)
+		if err == nil {
+			unreachableMembers.markUp(address)
+			body.commit()
+
+			return response, nil
+		}
+
+		if !isDialError(err) {
+			body.commit()
+			return nil, err
+		}
+
+		unreachableMembers.markDown(address)
+
+		if i == len(addresses)-1 || body.consumed() {
+			body.commit()
+			return nil, err
+		}
+
+		log.Debug().Err(err).Str("member", address).Msg("agent cluster member unreachable, failing over to the next member")
+	}
+
+	return nil, errors.New("no member of the agent cluster to send the request to")
+}
+
+// discover lists the members of the agent cluster in the background when the last discovery is too old
+func (transport *ClusterTransport) discover(scheme string) {
+	if transport.signatureService == nil {
+		return
+	}
+
+	transport.mu.Lock()
+	if transport.discovering || time.Since(transport.lastDiscovery) < discoveryInterval {
+		transport.mu.Unlock()
+		return
+	}
+	transport.discovering = true
+	transport.mu.Unlock()
+
+	go func() {
+		members, err := transport.listMembers(scheme)
+
+		transport.mu.Lock()
+		transport.discovering = false
+		transport.lastDiscovery = time.Now()
+
+		changed := err == nil && !slices.Equal(members, transport.members)
+		if changed {
+			transport.members = members
+		}
+		transport.mu.Unlock()
+
+		if err != nil {
+			log.Debug().Err(err).Str("agent", transport.primary).Msg("unable to discover the members of the agent cluster")
+			return
+		}
+
+		if changed && transport.onDiscovered != nil {
+			transport.onDiscovered(members)
+		}
+	}()
+}
+
+// listMembers returns the addresses of the members of the agent cluster, sorted
+func (transport *ClusterTransport) listMembers(scheme string) ([]string, error) {
+	if scheme == "" {
+		scheme = "https"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s/agents", scheme, transport.primary), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := transport.signatureService.CreateSignature(portainer.PortainerAgentSignatureMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set(portainer.PortainerAgentPublicKeyHeader, transport.signatureService.EncodedPublicKey())
+	request.Header.Set(portainer.PortainerAgentSignatureHeader, signature)
+
+	response, err := transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d when listing the agents", response.StatusCode)
+	}
+
+	var clusterMembers []ClusterMember
+	if err := json.NewDecoder(response.Body).Decode(&clusterMembers); err != nil {
+		return nil, err
+	}
+
+	_, port, err := net.SplitHostPort(transport.primary)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]string, 0, len(clusterMembers))
+	for _, member := range clusterMembers {
+		if member.IPAddress == "" {
+			continue
+		}
+
+		address := net.JoinHostPort(member.IPAddress, port)
+		if !slices.Contains(members, address) {
+			members = append(members, address)
+		}
+	}
+	slices.Sort(members)
+
+	return members, nil
+}
+
+// primaryAddress returns the address of the agent configured for an environment(endpoint)
+func primaryAddress(endpoint *portainer.Endpoint) (string, error) {
+	endpointURL, err := url.ParseURL(endpoint.URL)
+	if err != nil {
+		return "", err
+	}
+
+	if endpointURL.Hostname() == "" {
+		return "", fmt.Errorf("invalid agent URL %q", endpoint.URL)
+	}
+
+	port := endpointURL.Port()
+	if port == "" {
+		port = defaultAgentPort
+	}
+
+	return net.JoinHostPort(endpointURL.Hostname(), port), nil
+}
+
+// clusterAddresses returns the address of the configured agent followed by the ones of the other members
+func clusterAddresses(primary string, members []string) []string {
+	addresses := []string{primary}
+	for _, member := range members {
+		if !slices.Contains(addresses, member) {
+			addresses = append(addresses, member)
+		}
+	}
+
+	return addresses
+}
+
+// orderAddresses returns the reachable addresses followed by the unreachable ones. The reachable addresses are
+// rotated from start when it is not negative, so that the requests are balanced across them.
+func orderAddresses(addresses []string, start int) []string {
+	var reachable, unreachable []string
+	for _, address := range addresses {
+		if unreachableMembers.isDown(address) {
+			unreachable = append(unreachable, address)
+		} else {
+			reachable = append(reachable, address)
+		}
+	}
+
+	if start >= 0 && len(reachable) > 1 {
+		offset := start % len(reachable)
+		reachable = append(reachable[offset:], reachable[:offset]...)
+	}
+
+	return append(reachable, unreachable...)
+}
+
+func isDialError(err error) bool {
+	var opErr *net.OpError
+
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// replayableBody is the body of a request sent to several members in turn. It is only closed once the request is
+// answered or failed for good, so that it can be sent again when the connection to a member could not be opened.
+type replayableBody struct {
+	io.ReadCloser
+
+	mu             sync.Mutex
+	read           bool
+	closeRequested bool
+	committed      bool
+}
+
+func (body *replayableBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+
+	body.mu.Lock()
+	if n > 0 || err != nil {
+		body.read = true
+	}
+	body.mu.Unlock()
+
+	return n, err
+}
+
+func (body *replayableBody) Close() error {
+	body.mu.Lock()
+	defer body.mu.Unlock()
+
+	if !body.committed {
+		body.closeRequested = true
+		return nil
+	}
+
+	return body.ReadCloser.Close()
+}
+
+// consumed returns whether the body was read, in which case the request cannot be sent again
+func (body *replayableBody) consumed() bool {
+	if body == nil {
+		return false
+	}
+
+	body.mu.Lock()
+	defer body.mu.Unlock()
+
+	return body.read
+}
+
+// reset forgets the closing of the body by the transport of a failed attempt
+func (body *replayableBody) reset() {
+	body.mu.Lock()
+	body.closeRequested = false
+	body.mu.Unlock()
+}
+
+// commit ends the attempts, the body is closed when the transport of the last attempt closes it
+func (body *replayableBody) commit() {
+	if body == nil {
+		return
+	}
+
+	body.mu.Lock()
+	defer body.mu.Unlock()
+
+	body.committed = true
+	if body.closeRequested {
+		body.ReadCloser.Close()
+	}
+}
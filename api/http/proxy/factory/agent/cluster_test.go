@@ -0,0 +1,188 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unreachableAddress returns an address on which no agent listens
+func unreachableAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	address := listener.Addr().String()
+	listener.Close()
+
+	return address
+}
+
+// newMemberServer returns an agent answering with its name and the body of the request
+func newMemberServer(t *testing.T, name string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("X-Member", name)
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func sendClusterRequest(t *testing.T, transport http.RoundTripper, address, body string, target bool) (string, string) {
+	request, err := http.NewRequest(http.MethodPost, "http://"+address+"/containers/create", strings.NewReader(body))
+	assert.NoError(t, err)
+
+	if target {
+		request.Header.Set(portainer.PortainerAgentTargetHeader, "node1")
+	}
+
+	response, err := transport.RoundTrip(request)
+	if !assert.NoError(t, err) {
+		return "", ""
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	assert.NoError(t, err)
+
+	return response.Header.Get("X-Member"), string(responseBody)
+}
+
+func TestClusterTransport_Failover(t *testing.T) {
+	primary := unreachableAddress(t)
+	member := newMemberServer(t, "member")
+	memberAddress := strings.TrimPrefix(member.URL, "http://")
+
+	endpoint := &portainer.Endpoint{URL: "tcp://" + primary, AgentClusterMembers: []string{memberAddress}}
+
+	transport, err := NewClusterTransport(&http.Transport{}, endpoint, nil, nil)
+	assert.NoError(t, err)
+
+	name, body := sendClusterRequest(t, transport, primary, `{"Image":"nginx"}`, false)
+	assert.Equal(t, "member", name)
+	assert.Equal(t, `{"Image":"nginx"}`, body, "the body should be sent again to the next member")
+	assert.True(t, unreachableMembers.isDown(primary))
+	assert.Equal(t, []string{memberAddress, primary}, orderAddresses(clusterAddresses(primary, endpoint.AgentClusterMembers), -1))
+}
+
+func TestClusterTransport_NoReachableMember(t *testing.T) {
+	primary := unreachableAddress(t)
+	endpoint := &portainer.Endpoint{URL: "tcp://" + primary, AgentClusterMembers: []string{unreachableAddress(t)}}
+
+	transport, err := NewClusterTransport(&http.Transport{}, endpoint, nil, nil)
+	assert.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodGet, "http://"+primary+"/containers/json", nil)
+	assert.NoError(t, err)
+
+	_, err = transport.RoundTrip(request)
+	assert.Error(t, err)
+}
+
+func TestClusterTransport_LoadBalancing(t *testing.T) {
+	primary := newMemberServer(t, "primary")
+	member := newMemberServer(t, "member")
+	primaryAddress := strings.TrimPrefix(primary.URL, "http://")
+
+	endpoint := &portainer.Endpoint{URL: "tcp://" + primaryAddress, AgentClusterMembers: []string{strings.TrimPrefix(member.URL, "http://")}}
+
+	transport, err := NewClusterTransport(&http.Transport{}, endpoint, nil, nil)
+	assert.NoError(t, err)
+
+	targeted := map[string]int{}
+	for i := 0; i < 4; i++ {
+		name, _ := sendClusterRequest(t, transport, primaryAddress, "", true)
+		targeted[name]++
+
+		name, _ = sendClusterRequest(t, transport, primaryAddress, "", false)
+		assert.Equal(t, "primary", name, "the requests without target should be sent to the configured member")
+	}
+
+	assert.Equal(t, map[string]int{"primary": 2, "member": 2}, targeted)
+}
+
+func TestClusterTransport_Discovery(t *testing.T) {
+	signatureService := crypto.NewECDSAService("secret")
+	_, _, err := signatureService.GenerateKeyPair()
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/agents" || r.Header.Get(portainer.PortainerAgentSignatureHeader) == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		json.NewEncoder(w).Encode([]ClusterMember{
+			{IPAddress: "127.0.0.1", NodeName: "node1"},
+			{IPAddress: "10.0.0.3", NodeName: "node2"},
+		})
+	}))
+	defer server.Close()
+
+	address := strings.TrimPrefix(server.URL, "http://")
+	_, port, _ := net.SplitHostPort(address)
+
+	var mu sync.Mutex
+	var discovered []string
+
+	endpoint := &portainer.Endpoint{URL: "tcp://" + address}
+	transport, err := NewClusterTransport(&http.Transport{}, endpoint, signatureService, func(members []string) {
+		mu.Lock()
+		discovered = members
+		mu.Unlock()
+	})
+	assert.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/_ping", nil)
+	assert.NoError(t, err)
+
+	response, err := transport.RoundTrip(request)
+	assert.NoError(t, err)
+	response.Body.Close()
+
+	expected := []string{"10.0.0.3:" + port, "127.0.0.1:" + port}
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return assert.ObjectsAreEqual(expected, discovered)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	transport.mu.Lock()
+	assert.Equal(t, expected, transport.members)
+	transport.mu.Unlock()
+}
+
+func TestClusterDialContext(t *testing.T) {
+	primary := unreachableAddress(t)
+	member := newMemberServer(t, "member")
+	memberAddress := strings.TrimPrefix(member.URL, "http://")
+
+	dial := ClusterDialContext(&portainer.Endpoint{URL: "tcp://" + primary, AgentClusterMembers: []string{memberAddress}})
+
+	conn, err := dial(context.Background(), "tcp", primary)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	assert.Equal(t, memberAddress, conn.RemoteAddr().String())
+}
+
+func TestClusterURLs(t *testing.T) {
+	endpoint := &portainer.Endpoint{URL: "tcp://agent.local:9001", AgentClusterMembers: []string{"agent.local:9001", "10.0.0.3:9001"}}
+
+	assert.Equal(t, []string{"tcp://agent.local:9001", "tcp://10.0.0.3:9001"}, ClusterURLs(endpoint))
+}
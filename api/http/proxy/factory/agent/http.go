@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/portainer/portainer/api/timeouts"
+
+	"golang.org/x/net/http2"
+)
+
+// HTTPSettings tunes the connections opened to the agents
+type HTTPSettings struct {
+	// HTTP2 negotiates HTTP/2 with the agents served over TLS, the agents not supporting it being reached over HTTP/1.1
+	HTTP2 bool
+	// H2C uses HTTP/2 over cleartext with the agents reached without TLS, which must all support it. It does not apply
+	// to the Edge agents, whose reverse tunnel already multiplexes the requests on a single connection
+	H2C bool
+	// IdleConnTimeout is the duration an idle connection is kept open, 0 keeping it open until the agent closes it
+	IdleConnTimeout time.Duration
+	// KeepAlive is the interval of the TCP keep-alive probes, and of the pings checking the health of the HTTP/2
+	// connections. 0 uses the system defaults and disables the pings
+	KeepAlive time.Duration
+	// MaxIdleConnsPerHost is the number of idle HTTP/1.1 connections kept open for each agent
+	MaxIdleConnsPerHost int
+}
+
+const (
+	dialTimeout         = 30 * time.Second
+	tlsHandshakeTimeout = 10 * time.Second
+	// pingTimeout bounds the wait for the answer to a health ping before the HTTP/2 connection is closed
+	pingTimeout = 15 * time.Second
+)
+
+// DefaultHTTPSettings are the settings used until Configure is called
+var DefaultHTTPSettings = HTTPSettings{
+	HTTP2:               true,
+	IdleConnTimeout:     90 * time.Second,
+	KeepAlive:           30 * time.Second,
+	MaxIdleConnsPerHost: 10,
+}
+
+var httpSettings atomic.Pointer[HTTPSettings]
+
+// Configure sets the settings of the transports created afterwards
+func Configure(settings HTTPSettings) {
+	httpSettings.Store(&settings)
+}
+
+func currentHTTPSettings() HTTPSettings {
+	if settings := httpSettings.Load(); settings != nil {
+		return *settings
+	}
+
+	return DefaultHTTPSettings
+}
+
+// NewHTTPTransport returns the transport used to reach an agent directly, over TLS when tlsConfig is not nil
+func NewHTTPTransport(tlsConfig *tls.Config) http.RoundTripper {
+	settings := currentHTTPSettings()
+
+	http1 := newHTTP1Transport(settings, tlsConfig)
+
+	if tlsConfig != nil && settings.HTTP2 {
+		// the TLS configuration is cloned as h2 is added to the protocols it negotiates
+		h2 := newHTTP1Transport(settings, tlsConfig.Clone())
+		h2.TLSNextProto = nil
+
+		if t, err := http2.ConfigureTransports(h2); err == nil {
+			configurePings(t, settings)
+
+			return &upgradeTransport{http1: http1, http2: h2}
+		}
+	}
+
+	if tlsConfig == nil && settings.H2C {
+		dialer := newDialer(settings)
+
+		h2c := &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+		}
+		configurePings(h2c, settings)
+
+		return &upgradeTransport{http1: http1, http2: h2c}
+	}
+
+	return http1
+}
+
+// NewTunnelHTTPTransport returns the transport used to reach an Edge agent through its reverse tunnel
+func NewTunnelHTTPTransport() http.RoundTripper {
+	return newHTTP1Transport(currentHTTPSettings(), nil)
+}
+
+func newDialer(settings HTTPSettings) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   dialTimeout,
+		KeepAlive: settings.KeepAlive,
+	}
+}
+
+// newHTTP1Transport returns a transport that never negotiates HTTP/2
+func newHTTP1Transport(settings HTTPSettings, tlsConfig *tls.Config) *http.Transport {
+	return &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         newDialer(settings).DialContext,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: tlsHandshakeTimeout,
+		TLSNextProto:        map[string]func(string, *tls.Conn) http.RoundTripper{},
+		IdleConnTimeout:     settings.IdleConnTimeout,
+		MaxIdleConnsPerHost: settings.MaxIdleConnsPerHost,
+		// the requests upgrading the connection, such as attach and exec, are sent over HTTP/1.1 and their response
+		// headers are received as soon as the connection is upgraded
+		ResponseHeaderTimeout: timeouts.Current().ProxyRequest,
+	}
+}
+
+func configurePings(t *http2.Transport, settings HTTPSettings) {
+	if settings.KeepAlive > 0 {
+		t.ReadIdleTimeout = settings.KeepAlive
+		t.PingTimeout = pingTimeout
+	}
+}
+
+// upgradeTransport sends the requests upgrading the connection, such as the attach and exec ones, over HTTP/1.1 as
+// HTTP/2 does not support the Upgrade header, and the other requests over HTTP/2
+type upgradeTransport struct {
+	http1 http.RoundTripper
+	http2 http.RoundTripper
+}
+
+func (transport *upgradeTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if isUpgradeRequest(request) {
+		return transport.http1.RoundTrip(request)
+	}
+
+	return transport.http2.RoundTrip(request)
+}
+
+func isUpgradeRequest(request *http.Request) bool {
+	for _, value := range request.Header.Values("Connection") {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
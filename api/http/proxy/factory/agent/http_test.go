@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func protoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Proto", r.Proto)
+	})
+}
+
+func roundTrip(t *testing.T, transport http.RoundTripper, url string, upgrade bool) string {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	assert.NoError(t, err)
+
+	if upgrade {
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "tcp")
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	return resp.Header.Get("X-Proto")
+}
+
+func TestNewHTTPTransport_TLS(t *testing.T) {
+	defer Configure(DefaultHTTPSettings)
+
+	server := httptest.NewUnstartedServer(protoHandler())
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+
+	transport := NewHTTPTransport(tlsConfig)
+	assert.Equal(t, "HTTP/2.0", roundTrip(t, transport, server.URL, false))
+	assert.Equal(t, "HTTP/1.1", roundTrip(t, transport, server.URL, true), "upgrade requests must use HTTP/1.1")
+	assert.Empty(t, tlsConfig.NextProtos, "the TLS configuration of the caller must not be modified")
+
+	settings := DefaultHTTPSettings
+	settings.HTTP2 = false
+	Configure(settings)
+
+	assert.Equal(t, "HTTP/1.1", roundTrip(t, NewHTTPTransport(tlsConfig), server.URL, false))
+}
+
+func TestNewHTTPTransport_TLSWithoutHTTP2Support(t *testing.T) {
+	server := httptest.NewTLSServer(protoHandler())
+	defer server.Close()
+
+	transport := NewHTTPTransport(&tls.Config{InsecureSkipVerify: true})
+	assert.Equal(t, "HTTP/1.1", roundTrip(t, transport, server.URL, false))
+}
+
+func TestNewHTTPTransport_H2C(t *testing.T) {
+	defer Configure(DefaultHTTPSettings)
+
+	server := httptest.NewServer(h2c.NewHandler(protoHandler(), &http2.Server{}))
+	defer server.Close()
+
+	assert.Equal(t, "HTTP/1.1", roundTrip(t, NewHTTPTransport(nil), server.URL, false), "h2c must be opt-in")
+
+	settings := DefaultHTTPSettings
+	settings.H2C = true
+	Configure(settings)
+
+	transport := NewHTTPTransport(nil)
+	assert.Equal(t, "HTTP/2.0", roundTrip(t, transport, server.URL, false))
+	assert.Equal(t, "HTTP/1.1", roundTrip(t, transport, server.URL, true))
+
+	assert.Equal(t, "HTTP/1.1", roundTrip(t, NewTunnelHTTPTransport(), server.URL, false))
+}
@@ -6,14 +6,14 @@ import (
 	portainer "github.com/portainer/portainer/api"
 )
 
-// Transport is an http.Transport wrapper that adds custom http headers to communicate to an Agent
+// Transport is an http.RoundTripper wrapper that adds custom http headers to communicate to an Agent
 type Transport struct {
-	httpTransport    *http.Transport
+	httpTransport    http.RoundTripper
 	signatureService portainer.DigitalSignatureService
 }
 
 // NewTransport returns a new transport that can be used to send signed requests to a Portainer agent
-func NewTransport(signatureService portainer.DigitalSignatureService, httpTransport *http.Transport) *Transport {
+func NewTransport(signatureService portainer.DigitalSignatureService, httpTransport http.RoundTripper) *Transport {
 	transport := &Transport{
 		httpTransport:    httpTransport,
 		signatureService: signatureService,
@@ -10,6 +10,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/client"
+	"github.com/portainer/portainer/api/timeouts"
 )
 
 type (
@@ -40,7 +41,7 @@ type (
 func NewTransport(credentials *portainer.AzureCredentials, dataStore dataservices.DataStore, endpoint *portainer.Endpoint) *Transport {
 	return &Transport{
 		credentials: credentials,
-		client:      client.NewHTTPClient(),
+		client:      &client.HTTPClient{Client: &http.Client{Timeout: timeouts.Current().AzureToken}},
 		dataStore:   dataStore,
 		endpoint:    endpoint,
 	}
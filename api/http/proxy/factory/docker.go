@@ -1,21 +1,36 @@
 package factory
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
+	"slices"
 	"strings"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/proxy/factory/agent"
 	"github.com/portainer/portainer/api/http/proxy/factory/docker"
+	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/internal/url"
+	"github.com/portainer/portainer/api/retries"
+	"github.com/portainer/portainer/api/timeouts"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/rs/zerolog/log"
 )
 
+// sshProxyHost is the host of the requests proxied via SSH, the connections being opened to the Docker socket
+const sshProxyHost = "docker.ssh"
+
 func (factory *ProxyFactory) newDockerProxy(endpoint *portainer.Endpoint) (http.Handler, error) {
+	if endpointutils.IsDockerSSHEndpoint(endpoint) {
+		return factory.newDockerSSHProxy(endpoint)
+	}
+
 	if strings.HasPrefix(endpoint.URL, "unix://") || strings.HasPrefix(endpoint.URL, "npipe://") {
 		return factory.newDockerLocalProxy(endpoint)
 	}
@@ -45,7 +60,7 @@ func (factory *ProxyFactory) newDockerHTTPProxy(endpoint *portainer.Endpoint) (h
 	}
 
 	endpointURL.Scheme = "http"
-	httpTransport := &http.Transport{}
+	var tlsConfig *tls.Config
 
 	if endpoint.TLSConfig.TLS || endpoint.TLSConfig.TLSSkipVerify {
 		config, err := crypto.CreateTLSConfigurationFromDisk(endpoint.TLSConfig.TLSCACertPath, endpoint.TLSConfig.TLSCertPath, endpoint.TLSConfig.TLSKeyPath, endpoint.TLSConfig.TLSSkipVerify)
@@ -53,10 +68,29 @@ func (factory *ProxyFactory) newDockerHTTPProxy(endpoint *portainer.Endpoint) (h
 			return nil, err
 		}
 
-		httpTransport.TLSClientConfig = config
+		// the pins are read on each handshake so that the keys of the cluster members discovered afterwards and
+		// the keys trusted again by an administrator are taken into account
+		config.VerifyConnection = crypto.VerifyPublicKeyPins(factory.agentPublicKeyPins(endpoint))
+
+		tlsConfig = config
 		endpointURL.Scheme = "https"
 	}
 
+	var httpTransport http.RoundTripper
+	switch endpoint.Type {
+	case portainer.AgentOnDockerEnvironment:
+		httpTransport, err = agent.NewClusterTransport(agent.NewHTTPTransport(tlsConfig), endpoint, factory.signatureService, factory.agentClusterMembersUpdater(endpoint.ID, tlsConfig))
+		if err != nil {
+			return nil, err
+		}
+	case portainer.EdgeAgentOnDockerEnvironment:
+		httpTransport = agent.NewTunnelHTTPTransport()
+	default:
+		httpTransport = &http.Transport{TLSClientConfig: tlsConfig, ResponseHeaderTimeout: timeouts.Current().ProxyRequest}
+	}
+
+	httpTransport = retries.NewTransport(httpTransport, retries.SubsystemProxy)
+
 	transportParameters := &docker.TransportParameters{
 		Endpoint:             endpoint,
 		DataStore:            factory.dataStore,
@@ -75,6 +109,103 @@ func (factory *ProxyFactory) newDockerHTTPProxy(endpoint *portainer.Endpoint) (h
 	return proxy, nil
 }
 
+// newDockerSSHProxy creates a proxy to the Docker socket of a host, reached through an SSH connection
+// established on demand
+func (factory *ProxyFactory) newDockerSSHProxy(endpoint *portainer.Endpoint) (http.Handler, error) {
+	dialContext, err := factory.dockerClientFactory.SSHDialContext(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	transportParameters := &docker.TransportParameters{
+		Endpoint:             endpoint,
+		DataStore:            factory.dataStore,
+		ReverseTunnelService: factory.reverseTunnelService,
+		SignatureService:     factory.signatureService,
+		DockerClientFactory:  factory.dockerClientFactory,
+	}
+
+	dockerTransport, err := docker.NewTransport(transportParameters, &http.Transport{DialContext: dialContext, ResponseHeaderTimeout: timeouts.Current().ProxyRequest}, factory.gitService)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := newSingleHostReverseProxyWithHostHeader(&neturl.URL{Scheme: "http", Host: sshProxyHost})
+	proxy.Transport = dockerTransport
+	return proxy, nil
+}
+
+// agentClusterMembersUpdater returns the function persisting the members discovered in the agent cluster of an
+// environment(endpoint)
+func (factory *ProxyFactory) agentClusterMembersUpdater(endpointID portainer.EndpointID, tlsConfig *tls.Config) func(members []string) {
+	return func(members []string) {
+		// the members are listed by a trusted agent, the keys they present are trusted on first use when the keys
+		// of the environment are pinned
+		memberPins := factory.agentClusterMemberPins(endpointID, members, tlsConfig)
+
+		err := factory.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+			endpoint, err := tx.Endpoint().Endpoint(endpointID)
+			if err != nil {
+				return err
+			}
+
+			endpoint.AgentClusterMembers = members
+			for _, pin := range memberPins {
+				if !slices.Contains(endpoint.AgentPublicKeyPins, pin) {
+					endpoint.AgentPublicKeyPins = append(endpoint.AgentPublicKeyPins, pin)
+				}
+			}
+
+			return tx.Endpoint().UpdateEndpoint(endpointID, endpoint)
+		})
+		if err != nil {
+			log.Warn().Err(err).Int("endpoint_id", int(endpointID)).Msg("unable to update the members of the agent cluster")
+			return
+		}
+
+		log.Info().Int("endpoint_id", int(endpointID)).Strs("members", members).Msg("agent cluster members discovered")
+	}
+}
+
+// agentClusterMemberPins returns the pins of the public keys presented by the members of an agent cluster that were
+// not members yet, when the keys of the environment are pinned
+func (factory *ProxyFactory) agentClusterMemberPins(endpointID portainer.EndpointID, members []string, tlsConfig *tls.Config) []string {
+	endpoint, err := factory.dataStore.Endpoint().Endpoint(endpointID)
+	if err != nil || len(endpoint.AgentPublicKeyPins) == 0 || tlsConfig == nil {
+		return nil
+	}
+
+	var pins []string
+	for _, member := range members {
+		if slices.Contains(endpoint.AgentClusterMembers, member) {
+			continue
+		}
+
+		pin, err := crypto.FetchPublicKeyPin(member, tlsConfig)
+		if err != nil {
+			log.Warn().Err(err).Int("endpoint_id", int(endpointID)).Str("member", member).Msg("unable to retrieve the public key of the agent cluster member")
+			continue
+		}
+
+		pins = append(pins, pin)
+	}
+
+	return pins
+}
+
+// agentPublicKeyPins returns a function reading the pins of the public keys of the agents of an environment, the
+// pins known when the proxy was created being used when the environment cannot be read
+func (factory *ProxyFactory) agentPublicKeyPins(endpoint *portainer.Endpoint) func() []string {
+	return func() []string {
+		current, err := factory.dataStore.Endpoint().Endpoint(endpoint.ID)
+		if err != nil {
+			return endpoint.AgentPublicKeyPins
+		}
+
+		return current.AgentPublicKeyPins
+	}
+}
+
 type dockerLocalProxy struct {
 	transport *docker.Transport
 }
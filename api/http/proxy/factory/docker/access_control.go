@@ -2,7 +2,6 @@ package docker
 
 import (
 	"net/http"
-	"strings"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/proxy/factory/utils"
@@ -13,12 +12,9 @@ import (
 )
 
 const (
-	resourceLabelForPortainerTeamResourceControl   = "io.portainer.accesscontrol.teams"
-	resourceLabelForPortainerUserResourceControl   = "io.portainer.accesscontrol.users"
-	resourceLabelForPortainerPublicResourceControl = "io.portainer.accesscontrol.public"
-	resourceLabelForDockerSwarmStackName           = "com.docker.stack.namespace"
-	resourceLabelForDockerServiceID                = "com.docker.swarm.service.id"
-	resourceLabelForDockerComposeStackName         = "com.docker.compose.project"
+	resourceLabelForDockerSwarmStackName   = "com.docker.stack.namespace"
+	resourceLabelForDockerServiceID        = "com.docker.swarm.service.id"
+	resourceLabelForDockerComposeStackName = "com.docker.compose.project"
 )
 
 type (
@@ -30,90 +26,25 @@ type (
 	}
 )
 
-func getUniqueElements(items string) []string {
-	result := []string{}
-	seen := make(map[string]struct{})
-	for _, item := range strings.Split(items, ",") {
-		v := strings.TrimSpace(item)
-		if v == "" {
-			continue
-		}
-		if _, ok := seen[v]; !ok {
-			result = append(result, v)
-			seen[v] = struct{}{}
-		}
-	}
-
-	return result
-}
-
 func (transport *Transport) newResourceControlFromPortainerLabels(labelsObject map[string]interface{}, resourceID string, resourceType portainer.ResourceControlType) (*portainer.ResourceControl, error) {
-	if labelsObject[resourceLabelForPortainerPublicResourceControl] != nil {
-		resourceControl := authorization.NewPublicResourceControl(resourceID, resourceType)
-
-		err := transport.dataStore.ResourceControl().Create(resourceControl)
-		if err != nil {
-			return nil, err
+	labels := make(map[string]string)
+	for name, value := range labelsObject {
+		if value, ok := value.(string); ok {
+			labels[name] = value
 		}
-
-		return resourceControl, nil
 	}
 
-	teamNames := make([]string, 0)
-	userNames := make([]string, 0)
-	if labelsObject[resourceLabelForPortainerTeamResourceControl] != nil {
-		concatenatedTeamNames := labelsObject[resourceLabelForPortainerTeamResourceControl].(string)
-		teamNames = getUniqueElements(concatenatedTeamNames)
+	resourceControl := authorization.ResourceControlFromLabels(transport.dataStore, labels, resourceID, resourceType)
+	if resourceControl == nil {
+		return nil, nil
 	}
 
-	if labelsObject[resourceLabelForPortainerUserResourceControl] != nil {
-		concatenatedUserNames := labelsObject[resourceLabelForPortainerUserResourceControl].(string)
-		userNames = getUniqueElements(concatenatedUserNames)
-	}
-
-	if len(teamNames) > 0 || len(userNames) > 0 {
-		teamIDs := make([]portainer.TeamID, 0)
-		userIDs := make([]portainer.UserID, 0)
-
-		for _, name := range teamNames {
-			team, err := transport.dataStore.Team().TeamByName(name)
-			if err != nil {
-				log.Warn().
-					Str("name", name).
-					Str("resource_id", resourceID).
-					Msg("unknown team name in access control label, ignoring access control rule for this team")
-
-				continue
-			}
-
-			teamIDs = append(teamIDs, team.ID)
-		}
-
-		for _, name := range userNames {
-			user, err := transport.dataStore.User().UserByUsername(name)
-			if err != nil {
-				log.Warn().
-					Str("name", name).
-					Str("resource_id", resourceID).
-					Msg("unknown user name in access control label, ignoring access control rule for this user")
-
-				continue
-			}
-
-			userIDs = append(userIDs, user.ID)
-		}
-
-		resourceControl := authorization.NewRestrictedResourceControl(resourceID, resourceType, userIDs, teamIDs)
-
-		err := transport.dataStore.ResourceControl().Create(resourceControl)
-		if err != nil {
-			return nil, err
-		}
-
-		return resourceControl, nil
+	err := transport.dataStore.ResourceControl().Create(resourceControl)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, nil
+	return resourceControl, nil
 }
 
 func (transport *Transport) createPrivateResourceControl(resourceIdentifier string, resourceType portainer.ResourceControlType, userID portainer.UserID) (*portainer.ResourceControl, error) {
@@ -0,0 +1,365 @@
+package docker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+	requesthelpers "github.com/portainer/portainer/pkg/libhttp/request"
+
+	"github.com/rs/zerolog/log"
+)
+
+const redactedValue = "<redacted>"
+
+// auditedOperation describes a mutating Docker operation recorded in the audit of the environment
+type auditedOperation struct {
+	method string
+	path   *regexp.Regexp
+	// operation is the normalized name of the operation, %s is replaced by the action matched by the path if any
+	operation string
+	// previousPath is the path used to retrieve the object modified by an update, %s is replaced by the resource identifier
+	previousPath string
+	// previousField is the field of the previous object which the payload of an update is compared to
+	previousField string
+	// partial is true when the payload of an update only contains the modified fields
+	partial bool
+}
+
+var auditedOperations = []auditedOperation{
+	{method: http.MethodPost, path: regexp.MustCompile(`^/containers/create$`), operation: "container.create"},
+	{method: http.MethodPost, path: regexp.MustCompile(`^/containers/([^/]+)/update$`), operation: "container.update", previousPath: "/containers/%s/json", previousField: "HostConfig", partial: true},
+	{method: http.MethodPost, path: regexp.MustCompile(`^/containers/([^/]+)/(start|stop|restart|kill|pause|unpause|rename|exec)$`), operation: "container.%s"},
+	{method: http.MethodDelete, path: regexp.MustCompile(`^/containers/([^/]+)$`), operation: "container.delete"},
+	{method: http.MethodPost, path: regexp.MustCompile(`^/services/create$`), operation: "service.create"},
+	{method: http.MethodPost, path: regexp.MustCompile(`^/services/([^/]+)/update$`), operation: "service.update", previousPath: "/services/%s", previousField: "Spec"},
+	{method: http.MethodDelete, path: regexp.MustCompile(`^/services/([^/]+)$`), operation: "service.delete"},
+	{method: http.MethodPost, path: regexp.MustCompile(`^/networks/create$`), operation: "network.create"},
+	{method: http.MethodPost, path: regexp.MustCompile(`^/networks/([^/]+)/(connect|disconnect)$`), operation: "network.%s"},
+	{method: http.MethodDelete, path: regexp.MustCompile(`^/networks/([^/]+)$`), operation: "network.delete"},
+	{method: http.MethodPost, path: regexp.MustCompile(`^/volumes/create$`), operation: "volume.create"},
+	{method: http.MethodDelete, path: regexp.MustCompile(`^/volumes/([^/]+)$`), operation: "volume.delete"},
+	{method: http.MethodPost, path: regexp.MustCompile(`^/secrets/create$`), operation: "secret.create"},
+	{method: http.MethodDelete, path: regexp.MustCompile(`^/secrets/([^/]+)$`), operation: "secret.delete"},
+	{method: http.MethodPost, path: regexp.MustCompile(`^/configs/create$`), operation: "config.create"},
+	{method: http.MethodDelete, path: regexp.MustCompile(`^/configs/([^/]+)$`), operation: "config.delete"},
+	{method: http.MethodPost, path: regexp.MustCompile(`^/nodes/([^/]+)/update$`), operation: "node.update", previousPath: "/nodes/%s", previousField: "Spec"},
+}
+
+// sensitiveFields are the fields whose values are never recorded
+var sensitiveFields = []string{"data", "password", "secret", "token", "auth", "identitytoken", "registrytoken"}
+
+// matchAuditedOperation returns the audited operation matching the request and the identifier of its resource
+func matchAuditedOperation(method, requestPath string) (*auditedOperation, string, string) {
+	for i := range auditedOperations {
+		op := &auditedOperations[i]
+		if op.method != method {
+			continue
+		}
+
+		matches := op.path.FindStringSubmatch(requestPath)
+		if matches == nil {
+			continue
+		}
+
+		name := op.operation
+		resourceID := ""
+		if len(matches) > 1 {
+			resourceID = matches[1]
+		}
+		if len(matches) > 2 {
+			name = fmt.Sprintf(op.operation, matches[2])
+		}
+
+		return op, name, resourceID
+	}
+
+	return nil, "", ""
+}
+
+// prepareOperationAudit starts the audit of a mutating operation, the payload is summarized and the object modified
+// by an update is retrieved before the request is executed. It returns nil when the request is not audited.
+func (transport *Transport) prepareOperationAudit(request *http.Request, requestPath string) *portainer.DockerOperationAudit {
+	op, name, resourceID := matchAuditedOperation(request.Method, requestPath)
+	if op == nil {
+		return nil
+	}
+
+	audit := &portainer.DockerOperationAudit{
+		EndpointID: transport.endpoint.ID,
+		Timestamp:  time.Now().Unix(),
+		Operation:  name,
+		ResourceID: resourceID,
+		Method:     request.Method,
+		Path:       requestPath,
+		Summary:    map[string]string{},
+		RequestID:  request.Header.Get(requesthelpers.RequestIDHeader),
+	}
+
+	if tokenData, err := security.RetrieveTokenData(request); err == nil {
+		audit.UserID = tokenData.ID
+		audit.Username = tokenData.Username
+		audit.ImpersonatorID = tokenData.ImpersonatorID
+	}
+
+	for key, values := range request.URL.Query() {
+		audit.Summary["query."+key] = strings.Join(values, ",")
+	}
+
+	if reason := request.Header.Get(authorization.ShellAccessReasonHeader); reason != "" {
+		audit.Summary["reason"] = reason
+	}
+
+	var payload interface{}
+	if request.Body != nil && request.Body != http.NoBody {
+		body, err := io.ReadAll(request.Body)
+		request.Body.Close()
+		request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			log.Warn().Err(err).Str("operation", name).Msg("unable to read the payload of the audited operation")
+			return audit
+		}
+
+		payload, _ = decodeJSON(body)
+	}
+
+	current := flattenPayload(payload)
+	for field, value := range current {
+		audit.Summary[field] = value
+	}
+
+	if op.previousPath != "" && resourceID != "" {
+		previous, err := transport.inspectObject(request, fmt.Sprintf(op.previousPath, resourceID))
+		if err != nil {
+			log.Warn().Err(err).Str("operation", name).Msg("unable to retrieve the object modified by the audited operation")
+		} else {
+			previousObject, _ := previous.(map[string]interface{})
+			var fields map[string]interface{}
+			if op.partial {
+				fields, _ = payload.(map[string]interface{})
+			}
+
+			audit.Changes = diffPayloads(flattenPayload(previousObject[op.previousField]), current, fields)
+		}
+	}
+
+	if len(audit.Summary) == 0 {
+		audit.Summary = nil
+	}
+
+	return audit
+}
+
+// recordOperationAudit completes the audit of an operation with its result and saves it
+func (transport *Transport) recordOperationAudit(audit *portainer.DockerOperationAudit, response *http.Response) {
+	if response != nil {
+		audit.StatusCode = response.StatusCode
+
+		if audit.ResourceID == "" && response.StatusCode < http.StatusMultipleChoices && response.Body != nil {
+			body, err := io.ReadAll(response.Body)
+			response.Body.Close()
+			response.Body = io.NopCloser(bytes.NewReader(body))
+
+			if err == nil {
+				audit.ResourceID = createdResourceID(body)
+			}
+		}
+	}
+
+	if err := transport.dataStore.DockerOperationAudit().Create(audit); err != nil {
+		log.Warn().Err(err).Str("operation", audit.Operation).Msg("unable to persist the audit of the operation")
+	}
+}
+
+// inspectObject retrieves an object through the proxied Docker API, e.g. the object modified by an update
+func (transport *Transport) inspectObject(request *http.Request, objectPath string) (interface{}, error) {
+	inspectRequest := request.Clone(request.Context())
+	inspectRequest.Method = http.MethodGet
+	inspectRequest.URL.Path = objectPath
+	inspectRequest.URL.RawPath = ""
+	inspectRequest.URL.RawQuery = ""
+	inspectRequest.Body = http.NoBody
+	inspectRequest.ContentLength = 0
+	inspectRequest.Header.Del("Content-Type")
+
+	response, err := transport.HTTPTransport.RoundTrip(inspectRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeJSON(body)
+}
+
+func decodeJSON(body []byte) (interface{}, error) {
+	var value interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	err := decoder.Decode(&value)
+
+	return value, err
+}
+
+// createdResourceID returns the identifier of the resource created by an operation from its response
+func createdResourceID(body []byte) string {
+	var created struct {
+		ID   string `json:"Id"`
+		Name string `json:"Name"`
+	}
+
+	if json.Unmarshal(body, &created) != nil {
+		return ""
+	}
+
+	if created.ID != "" {
+		return created.ID
+	}
+
+	return created.Name
+}
+
+// flattenPayload normalizes a JSON payload into dotted field paths, e.g. TaskTemplate.ContainerSpec.Image.
+// The empty values are left out and the sensitive values are redacted, only the names of the environment
+// variables are kept.
+func flattenPayload(payload interface{}) map[string]string {
+	fields := map[string]string{}
+	flattenValue(fields, "", payload, false)
+
+	return fields
+}
+
+func flattenValue(fields map[string]string, field string, value interface{}, env bool) {
+	switch v := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		for key, child := range v {
+			childField := key
+			if field != "" {
+				childField = field + "." + key
+			}
+
+			if isSensitiveField(key) {
+				if !isEmptyValue(child) {
+					fields[childField] = redactedValue
+				}
+
+				continue
+			}
+
+			flattenValue(fields, childField, child, key == "Env")
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenValue(fields, fmt.Sprintf("%s[%d]", field, i), child, env)
+		}
+	case string:
+		if v == "" {
+			return
+		}
+
+		if env {
+			name, _, _ := strings.Cut(v, "=")
+			v = name + "=" + redactedValue
+		}
+
+		fields[field] = v
+	case bool:
+		if v {
+			fields[field] = "true"
+		}
+	case json.Number:
+		if v.String() != "0" {
+			fields[field] = v.String()
+		}
+	default:
+		fields[field] = fmt.Sprint(v)
+	}
+}
+
+func isSensitiveField(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range sensitiveFields {
+		if key == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+
+	return false
+}
+
+// diffPayloads returns the fields changed between the previous and the current flattened objects. When only some
+// fields of the previous object are updated, the comparison is limited to these top level fields.
+func diffPayloads(previous, current map[string]string, updatedFields map[string]interface{}) []portainer.DockerOperationChange {
+	compared := func(field string) bool {
+		if updatedFields == nil {
+			return true
+		}
+
+		top, _, _ := strings.Cut(field, ".")
+		top, _, _ = strings.Cut(top, "[")
+		_, ok := updatedFields[top]
+
+		return ok
+	}
+
+	fields := map[string]struct{}{}
+	for field := range previous {
+		if compared(field) {
+			fields[field] = struct{}{}
+		}
+	}
+	for field := range current {
+		fields[field] = struct{}{}
+	}
+
+	changes := []portainer.DockerOperationChange{}
+	for field := range fields {
+		if previous[field] != current[field] {
+			changes = append(changes, portainer.DockerOperationChange{
+				Field:    field,
+				Previous: previous[field],
+				Current:  current[field],
+			})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+
+	return changes
+}
@@ -0,0 +1,127 @@
+package docker
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestMatchAuditedOperation(t *testing.T) {
+	tests := []struct {
+		method     string
+		path       string
+		operation  string
+		resourceID string
+	}{
+		{http.MethodPost, "/containers/create", "container.create", ""},
+		{http.MethodPost, "/containers/abc/restart", "container.restart", "abc"},
+		{http.MethodDelete, "/containers/abc", "container.delete", "abc"},
+		{http.MethodPost, "/services/svc/update", "service.update", "svc"},
+		{http.MethodPost, "/networks/net/disconnect", "network.disconnect", "net"},
+		{http.MethodGet, "/containers/abc/json", "", ""},
+		{http.MethodPost, "/containers/abc/exec", "container.exec", "abc"},
+		{http.MethodPost, "/containers/abc/attach", "", ""},
+	}
+
+	for _, tt := range tests {
+		_, operation, resourceID := matchAuditedOperation(tt.method, tt.path)
+		assert.Equal(t, tt.operation, operation, tt.path)
+		assert.Equal(t, tt.resourceID, resourceID, tt.path)
+	}
+}
+
+func TestFlattenPayload(t *testing.T) {
+	payload, err := decodeJSON([]byte(`{
+		"Image": "nginx:1.25",
+		"Env": ["DB_PASSWORD=hunter2", "DEBUG"],
+		"Data": "c2VjcmV0",
+		"HostConfig": {"Privileged": false, "Memory": 0, "Binds": ["/data:/data"], "NanoCpus": 1500000000},
+		"Labels": {}
+	}`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"Image":               "nginx:1.25",
+		"Env[0]":              "DB_PASSWORD=<redacted>",
+		"Env[1]":              "DEBUG=<redacted>",
+		"Data":                "<redacted>",
+		"HostConfig.Binds[0]": "/data:/data",
+		"HostConfig.NanoCpus": "1500000000",
+	}, flattenPayload(payload))
+}
+
+func TestDiffPayloads(t *testing.T) {
+	previous := map[string]string{"Memory": "536870912", "CpuShares": "512", "RestartPolicy.Name": "always"}
+	current := map[string]string{"CpuShares": "1024"}
+
+	changes := diffPayloads(previous, current, map[string]interface{}{"Memory": 0, "CpuShares": 1024})
+
+	assert.Equal(t, []portainer.DockerOperationChange{
+		{Field: "CpuShares", Previous: "512", Current: "1024"},
+		{Field: "Memory", Previous: "536870912", Current: ""},
+	}, changes)
+}
+
+func TestTransport_OperationAudit(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	endpoint := &portainer.Endpoint{ID: 1, Type: portainer.DockerEnvironment}
+
+	var forwarded string
+	transport := &Transport{
+		endpoint:  endpoint,
+		dataStore: store,
+		HTTPTransport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Method == http.MethodGet {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"ID": "svc", "Spec": {"Name": "web", "TaskTemplate": {"ContainerSpec": {"Image": "nginx:1.24"}}}}`)),
+				}, nil
+			}
+
+			body, _ := io.ReadAll(r.Body)
+			forwarded = string(body)
+
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{}`))}, nil
+		}),
+	}
+
+	payload := `{"Name": "web", "TaskTemplate": {"ContainerSpec": {"Image": "nginx:1.25"}}}`
+	request := httptest.NewRequest(http.MethodPost, "http://docker/services/svc/update?version=12", strings.NewReader(payload))
+	request = request.WithContext(security.StoreTokenData(request, &portainer.TokenData{ID: 1, Username: "admin", Role: portainer.AdministratorRole}))
+
+	audit := transport.prepareOperationAudit(request, request.URL.Path)
+	assert.NotNil(t, audit)
+
+	response, err := transport.HTTPTransport.RoundTrip(request)
+	assert.NoError(t, err)
+	transport.recordOperationAudit(audit, response)
+
+	assert.Equal(t, payload, forwarded, "the payload must be forwarded unchanged")
+
+	audits, err := store.DockerOperationAudit().AuditsByEndpoint(endpoint.ID)
+	assert.NoError(t, err)
+	assert.Len(t, audits, 1)
+
+	assert.Equal(t, "service.update", audits[0].Operation)
+	assert.Equal(t, "svc", audits[0].ResourceID)
+	assert.Equal(t, "admin", audits[0].Username)
+	assert.Equal(t, http.StatusOK, audits[0].StatusCode)
+	assert.Equal(t, "12", audits[0].Summary["query.version"])
+	assert.Equal(t, []portainer.DockerOperationChange{
+		{Field: "TaskTemplate.ContainerSpec.Image", Previous: "nginx:1.24", Current: "nginx:1.25"},
+	}, audits[0].Changes)
+}
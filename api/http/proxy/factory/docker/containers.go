@@ -14,6 +14,8 @@ import (
 	"github.com/portainer/portainer/api/http/proxy/factory/utils"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
+
+	"github.com/rs/zerolog/log"
 )
 
 const (
@@ -75,6 +77,17 @@ func (transport *Transport) containerListOperation(response *http.Response, exec
 		return err
 	}
 
+	noteIndex, err := transport.containerNoteIndex()
+	if err != nil {
+		return err
+	}
+
+	for _, container := range responseArray {
+		if containerObject, ok := container.(map[string]interface{}); ok {
+			transport.decorateContainerWithNotes(containerObject, noteIndex)
+		}
+	}
+
 	return utils.RewriteResponse(response, responseArray, http.StatusOK)
 }
 
@@ -96,6 +109,12 @@ func (transport *Transport) containerInspectOperation(response *http.Response, e
 
 	responseObject, _ = transport.applyPortainerContainer(responseObject)
 
+	noteIndex, err := transport.containerNoteIndex()
+	if err != nil {
+		return err
+	}
+	transport.decorateContainerWithNotes(responseObject, noteIndex)
+
 	return transport.applyAccessControlOnResource(resourceOperationParameters, responseObject, response, executor)
 }
 
@@ -243,3 +262,70 @@ func (transport *Transport) decorateContainerCreationOperation(request *http.Req
 
 	return response, err
 }
+
+type execCreatePayload struct {
+	Cmd  []string
+	User string
+}
+
+// checkShellAccess applies the shell access policy to the creation of an exec instance or to an attachment to the
+// main process of a container, it returns the response rejecting the request when the shell is denied
+func (transport *Transport) checkShellAccess(request *http.Request, containerID, action string) (*http.Response, error) {
+	tokenData, err := security.RetrieveTokenData(request)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := authorization.ShellAccessRules(transport.dataStore, tokenData, transport.endpoint)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	var payload execCreatePayload
+	if action == "exec" {
+		body, err := io.ReadAll(request.Body)
+		if err != nil {
+			return nil, err
+		}
+		request.Body.Close()
+		request.Body = io.NopCloser(bytes.NewReader(body))
+
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return utils.WriteErrorResponse("invalid exec payload", http.StatusBadRequest)
+		}
+	}
+
+	root := false
+	if authorization.ShellAccessRequiresRoot(rules) {
+		user := payload.User
+		if user == "" {
+			container, err := transport.inspectObject(request, "/containers/"+containerID+"/json")
+			if err != nil {
+				return nil, err
+			}
+
+			if container, ok := container.(map[string]interface{}); ok {
+				if config, ok := container["Config"].(map[string]interface{}); ok {
+					user, _ = config["User"].(string)
+				}
+			}
+		}
+
+		root = authorization.IsRootUser(user)
+	}
+
+	reason := request.Header.Get(authorization.ShellAccessReasonHeader)
+	if err := authorization.CheckShellAccess(rules, payload.Cmd, root, reason); err != nil {
+		log.Warn().
+			Str("username", tokenData.Username).
+			Int("endpoint_id", int(transport.endpoint.ID)).
+			Str("container_id", containerID).
+			Strs("command", payload.Cmd).
+			Err(err).
+			Msg("shell access denied by the policy")
+
+		return utils.WriteErrorResponse(err.Error(), http.StatusForbidden)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,117 @@
+package docker
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/authorization"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransport_CheckShellAccess(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	settings, err := store.Settings().Settings()
+	assert.NoError(t, err)
+	settings.ShellAccessPolicy.Rules = []portainer.ShellAccessRule{
+		{RoleIDs: []portainer.RoleID{4}, DenyRoot: true, RequireReason: true, AllowedCommands: []string{"sh", "bash"}},
+	}
+	assert.NoError(t, store.Settings().UpdateSettings(settings))
+
+	endpoint := &portainer.Endpoint{
+		ID:                 1,
+		GroupID:            1,
+		Type:               portainer.DockerEnvironment,
+		UserAccessPolicies: portainer.UserAccessPolicies{2: {RoleID: 4}},
+	}
+
+	transport := &Transport{
+		endpoint:  endpoint,
+		dataStore: store,
+		HTTPTransport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			// the user of the container is inspected when the exec does not specify it
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"Id": "abc", "Config": {"User": ""}}`)),
+			}, nil
+		}),
+	}
+
+	checkAction := func(action string, tokenData *portainer.TokenData, payload, reason string) (int, string) {
+		request := httptest.NewRequest(http.MethodPost, "http://docker/containers/abc/"+action, strings.NewReader(payload))
+		request = request.WithContext(security.StoreTokenData(request, tokenData))
+		if reason != "" {
+			request.Header.Set(authorization.ShellAccessReasonHeader, reason)
+		}
+
+		response, err := transport.checkShellAccess(request, "abc", action)
+		assert.NoError(t, err)
+		if response == nil {
+			body, _ := io.ReadAll(request.Body)
+			assert.Equal(t, payload, string(body), "the payload must be forwarded unchanged")
+
+			return 0, ""
+		}
+
+		var message struct {
+			Message string `json:"message"`
+		}
+		assert.NoError(t, json.NewDecoder(response.Body).Decode(&message))
+
+		return response.StatusCode, message.Message
+	}
+
+	check := func(tokenData *portainer.TokenData, payload, reason string) (int, string) {
+		return checkAction("exec", tokenData, payload, reason)
+	}
+
+	operator := &portainer.TokenData{ID: 2, Username: "operator", Role: portainer.StandardUserRole}
+
+	status, message := check(operator, `{"Cmd": ["sh"], "User": "app"}`, "")
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, authorization.ErrShellAccessReasonRequired.Error(), message)
+
+	status, _ = check(operator, `{"Cmd": ["sh"]}`, "INC-42")
+	assert.Equal(t, http.StatusForbidden, status, "the container runs as root by default")
+
+	status, message = check(operator, `{"Cmd": ["/usr/bin/python3"], "User": "app"}`, "INC-42")
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Contains(t, message, "not allowed")
+
+	status, _ = check(operator, `{"Cmd": ["/bin/bash"], "User": "app"}`, "INC-42")
+	assert.Zero(t, status)
+
+	status, _ = check(&portainer.TokenData{ID: 1, Username: "admin", Role: portainer.AdministratorRole}, `{"Cmd": ["sh"]}`, "")
+	assert.Zero(t, status, "the rule only applies to the operators")
+
+	status, message = checkAction("attach", operator, "", "")
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, authorization.ErrShellAccessReasonRequired.Error(), message)
+
+	status, message = checkAction("attach", operator, "", "INC-42")
+	assert.Equal(t, http.StatusForbidden, status, "the attachments reach the main process of the container running as root")
+	assert.Equal(t, authorization.ErrShellAccessRootDenied.Error(), message)
+}
+
+func TestTransport_ShellAccessReasonAudit(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	transport := &Transport{endpoint: &portainer.Endpoint{ID: 1, Type: portainer.DockerEnvironment}, dataStore: store}
+
+	request := httptest.NewRequest(http.MethodPost, "http://docker/containers/abc/exec", strings.NewReader(`{"Cmd": ["sh"]}`))
+	request.Header.Set(authorization.ShellAccessReasonHeader, "INC-42")
+
+	audit := transport.prepareOperationAudit(request, request.URL.Path)
+	assert.NotNil(t, audit)
+	assert.Equal(t, "container.exec", audit.Operation)
+	assert.Equal(t, "INC-42", audit.Summary["reason"])
+	assert.Equal(t, "sh", audit.Summary["Cmd[0]"])
+}
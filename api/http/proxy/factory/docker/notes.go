@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"strings"
+
+	"github.com/portainer/portainer/api/internal/notes"
+)
+
+// containerNoteIndex returns the notes about the containers of the environment
+func (transport *Transport) containerNoteIndex() (*notes.Index, error) {
+	endpointNotes, err := transport.dataStore.Note().NotesByEndpoint(transport.endpoint.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return notes.NewIndex(endpointNotes), nil
+}
+
+// decorateContainerWithNotes adds the notes about the container to its Portainer metadata. The notes are attached to
+// the names of the containers, the containers listed by the Docker API having several names when they are linked.
+func (transport *Transport) decorateContainerWithNotes(containerObject map[string]interface{}, noteIndex *notes.Index) {
+	var names []string
+
+	if name, ok := containerObject["Name"].(string); ok {
+		names = append(names, name)
+	}
+
+	if listedNames, ok := containerObject["Names"].([]interface{}); ok {
+		for _, name := range listedNames {
+			if name, ok := name.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		containerNotes := noteIndex.Container(transport.endpoint.ID, strings.TrimPrefix(name, "/"))
+		if len(containerNotes) == 0 {
+			continue
+		}
+
+		if containerObject["Portainer"] == nil {
+			containerObject["Portainer"] = make(map[string]interface{})
+		}
+
+		portainerMetadata := containerObject["Portainer"].(map[string]interface{})
+		portainerMetadata["Notes"] = containerNotes
+
+		return
+	}
+}
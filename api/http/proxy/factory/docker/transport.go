@@ -19,6 +19,7 @@ import (
 	"github.com/portainer/portainer/api/http/proxy/factory/utils"
 	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/internal/endpointutils"
 
 	"github.com/rs/zerolog/log"
 )
@@ -29,7 +30,7 @@ type (
 	// Transport is a custom transport for Docker API reverse proxy. It allows
 	// interception of requests and rewriting of responses.
 	Transport struct {
-		HTTPTransport        *http.Transport
+		HTTPTransport        http.RoundTripper
 		endpoint             *portainer.Endpoint
 		dataStore            dataservices.DataStore
 		signatureService     portainer.DigitalSignatureService
@@ -63,7 +64,7 @@ type (
 )
 
 // NewTransport returns a pointer to a new Transport instance.
-func NewTransport(parameters *TransportParameters, httpTransport *http.Transport, gitService portainer.GitService) (*Transport, error) {
+func NewTransport(parameters *TransportParameters, httpTransport http.RoundTripper, gitService portainer.GitService) (*Transport, error) {
 	transport := &Transport{
 		endpoint:             parameters.Endpoint,
 		dataStore:            parameters.DataStore,
@@ -98,6 +99,28 @@ func (transport *Transport) ProxyDockerRequest(request *http.Request) (*http.Res
 		request.Header.Set(portainer.PortainerAgentSignatureHeader, signature)
 	}
 
+	audit := transport.prepareOperationAudit(request, requestPath)
+	if audit == nil {
+		return transport.dispatchDockerRequest(request, requestPath)
+	}
+
+	response, err := transport.dispatchDockerRequest(request, requestPath)
+	transport.recordOperationAudit(audit, response)
+
+	return response, err
+}
+
+// dispatchDockerRequest applies the logic of the requested operation
+func (transport *Transport) dispatchDockerRequest(request *http.Request, requestPath string) (*http.Response, error) {
+	allowed, err := transport.isFeatureAllowed(request, requestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !allowed {
+		return utils.WriteErrorResponse("access denied to a feature disabled on this environment", http.StatusForbidden)
+	}
+
 	switch {
 	case strings.HasPrefix(requestPath, "/configs"):
 		return transport.proxyConfigRequest(request)
@@ -128,6 +151,54 @@ func (transport *Transport) ProxyDockerRequest(request *http.Request) (*http.Res
 	}
 }
 
+// isFeatureAllowed checks the request against the features disabled by the security settings of the environment.
+// The host management features are disabled for all the users while the Swarm management is only disabled for the
+// non-administrator users.
+func (transport *Transport) isFeatureAllowed(request *http.Request, requestPath string) (bool, error) {
+	hostManagement := isHostManagementRequest(request, requestPath)
+	swarmManagement := isSwarmManagementRequest(request, requestPath)
+	if !hostManagement && !swarmManagement {
+		return true, nil
+	}
+
+	securitySettings, err := transport.fetchEndpointSecuritySettings()
+	if err != nil {
+		return false, err
+	}
+
+	if hostManagement {
+		return securitySettings.EnableHostManagementFeatures, nil
+	}
+
+	if securitySettings.AllowSwarmManagementForRegularUsers {
+		return true, nil
+	}
+
+	return transport.isAdminOrEndpointAdmin(request)
+}
+
+// isHostManagementRequest returns whether the request uses the host management features of the agent, which are
+// the host information and the host file browser
+func isHostManagementRequest(request *http.Request, requestPath string) bool {
+	agentPath := strings.TrimPrefix(requestPath, "/v2")
+	if strings.HasPrefix(agentPath, "/host") {
+		return true
+	}
+
+	return strings.HasPrefix(agentPath, "/browse") && request.URL.Query().Get("volumeID") == ""
+}
+
+// isSwarmManagementRequest returns whether the request creates, updates or removes a service, a secret or a config
+func isSwarmManagementRequest(request *http.Request, requestPath string) bool {
+	if request.Method == http.MethodGet || request.Method == http.MethodHead {
+		return false
+	}
+
+	return strings.HasPrefix(requestPath, "/services") ||
+		strings.HasPrefix(requestPath, "/secrets") ||
+		strings.HasPrefix(requestPath, "/configs")
+}
+
 func (transport *Transport) executeDockerRequest(request *http.Request) (*http.Response, error) {
 	response, err := transport.HTTPTransport.RoundTrip(request)
 
@@ -246,6 +317,12 @@ func (transport *Transport) proxyContainerRequest(request *http.Request) (*http.
 			if action == "json" {
 				return transport.rewriteOperation(request, transport.containerInspectOperation)
 			}
+
+			if (action == "exec" || action == "attach") && request.Method == http.MethodPost {
+				if response, err := transport.checkShellAccess(request, containerID, action); response != nil || err != nil {
+					return response, err
+				}
+			}
 			return transport.restrictedResourceOperation(request, containerID, containerID, portainer.ContainerResourceControl, false)
 		} else if match, _ := path.Match("/containers/*", requestPath); match {
 			// Handle /containers/{id} requests
@@ -780,5 +857,5 @@ func (transport *Transport) fetchEndpointSecuritySettings() (*portainer.Endpoint
 		return nil, err
 	}
 
-	return &endpoint.SecuritySettings, nil
+	return endpointutils.EffectiveSecuritySettings(transport.dataStore, endpoint)
 }
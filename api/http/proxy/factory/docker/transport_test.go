@@ -2,11 +2,15 @@ package docker
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/security"
 	"github.com/portainer/portainer/api/internal/testhelpers"
 	"github.com/stretchr/testify/assert"
 )
@@ -67,3 +71,72 @@ func TestTransport_updateDefaultGitBranch(t *testing.T) {
 		})
 	}
 }
+
+func TestTransport_FeatureToggles(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	err := store.EndpointGroup().Create(&portainer.EndpointGroup{
+		ID:   2,
+		Name: "production",
+		Defaults: &portainer.EndpointGroupDefaults{
+			SecuritySettings: &portainer.EndpointSecuritySettings{},
+		},
+	})
+	assert.NoError(t, err)
+
+	lab := &portainer.Endpoint{
+		ID:      1,
+		GroupID: 1,
+		Type:    portainer.AgentOnDockerEnvironment,
+		SecuritySettings: portainer.EndpointSecuritySettings{
+			AllowSwarmManagementForRegularUsers: true,
+			EnableHostManagementFeatures:        true,
+		},
+	}
+	assert.NoError(t, store.Endpoint().Create(lab))
+
+	// the security settings of the environment are overridden by the defaults of its group
+	production := &portainer.Endpoint{
+		ID:               2,
+		GroupID:          2,
+		Type:             portainer.AgentOnDockerEnvironment,
+		SecuritySettings: lab.SecuritySettings,
+	}
+	assert.NoError(t, store.Endpoint().Create(production))
+
+	send := func(endpoint *portainer.Endpoint, tokenData *portainer.TokenData, method, url string) int {
+		transport := &Transport{
+			endpoint:  endpoint,
+			dataStore: store,
+			HTTPTransport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+			}),
+		}
+
+		request := httptest.NewRequest(method, url, nil)
+		request = request.WithContext(security.StoreTokenData(request, tokenData))
+
+		response, err := transport.dispatchDockerRequest(request, request.URL.Path)
+		assert.NoError(t, err)
+
+		return response.StatusCode
+	}
+
+	admin := &portainer.TokenData{ID: 1, Username: "admin", Role: portainer.AdministratorRole}
+	user := &portainer.TokenData{ID: 2, Username: "user", Role: portainer.StandardUserRole}
+
+	assert.Equal(t, http.StatusOK, send(lab, admin, http.MethodGet, "http://agent/host/info"))
+	assert.Equal(t, http.StatusOK, send(lab, admin, http.MethodGet, "http://agent/v2/browse/ls?path=/"))
+	assert.Equal(t, http.StatusForbidden, send(production, admin, http.MethodGet, "http://agent/host/info"))
+	assert.Equal(t, http.StatusForbidden, send(production, admin, http.MethodGet, "http://agent/v2/browse/ls?path=/"))
+
+	assert.Equal(t, http.StatusOK, send(production, admin, http.MethodPost, "http://agent/secrets/abc/update"))
+	assert.Equal(t, http.StatusForbidden, send(production, user, http.MethodPost, "http://agent/secrets/abc/update"))
+	assert.Equal(t, http.StatusForbidden, send(production, user, http.MethodDelete, "http://agent/configs/abc"))
+
+	request := httptest.NewRequest(http.MethodPost, "http://agent/secrets/abc/update", nil)
+	request = request.WithContext(security.StoreTokenData(request, user))
+	allowed, err := (&Transport{endpoint: lab, dataStore: store}).isFeatureAllowed(request, request.URL.Path)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+}
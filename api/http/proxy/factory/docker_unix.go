@@ -9,6 +9,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/proxy/factory/docker"
+	"github.com/portainer/portainer/api/timeouts"
 )
 
 func (factory ProxyFactory) newOSBasedLocalProxy(path string, endpoint *portainer.Endpoint) (http.Handler, error) {
@@ -36,5 +37,6 @@ func newSocketTransport(socketPath string) *http.Transport {
 		Dial: func(proto, addr string) (conn net.Conn, err error) {
 			return net.Dial("unix", socketPath)
 		},
+		ResponseHeaderTimeout: timeouts.Current().ProxyRequest,
 	}
 }
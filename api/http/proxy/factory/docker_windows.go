@@ -10,6 +10,7 @@ import (
 	"github.com/Microsoft/go-winio"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/http/proxy/factory/docker"
+	"github.com/portainer/portainer/api/timeouts"
 )
 
 func (factory ProxyFactory) newOSBasedLocalProxy(path string, endpoint *portainer.Endpoint) (http.Handler, error) {
@@ -37,5 +38,6 @@ func newNamedPipeTransport(namedPipePath string) *http.Transport {
 		Dial: func(proto, addr string) (conn net.Conn, err error) {
 			return winio.DialPipe(namedPipePath, nil)
 		},
+		ResponseHeaderTimeout: timeouts.Current().ProxyRequest,
 	}
 }
@@ -95,6 +95,7 @@ func (factory *ProxyFactory) newKubernetesAgentHTTPSProxy(endpoint *portainer.En
 	if err != nil {
 		return nil, err
 	}
+	crypto.PinPublicKeys(tlsConfig, endpoint.AgentPublicKeyPins)
 
 	tokenCache := factory.kubernetesTokenCacheManager.GetOrCreateTokenCache(endpoint.ID)
 	tokenManager, err := kubernetes.NewTokenManager(kubecli, factory.dataStore, tokenCache, false)
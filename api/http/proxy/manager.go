@@ -18,18 +18,20 @@ import (
 type (
 	// Manager represents a service used to manage proxies to environments (endpoints) and extensions.
 	Manager struct {
-		proxyFactory     *factory.ProxyFactory
-		endpointProxies  cmap.ConcurrentMap
-		k8sClientFactory *cli.ClientFactory
+		proxyFactory        *factory.ProxyFactory
+		endpointProxies     cmap.ConcurrentMap
+		k8sClientFactory    *cli.ClientFactory
+		dockerClientFactory *dockerclient.ClientFactory
 	}
 )
 
 // NewManager initializes a new proxy Service
 func NewManager(dataStore dataservices.DataStore, signatureService portainer.DigitalSignatureService, tunnelService portainer.ReverseTunnelService, clientFactory *dockerclient.ClientFactory, kubernetesClientFactory *cli.ClientFactory, kubernetesTokenCacheManager *kubernetes.TokenCacheManager, gitService portainer.GitService) *Manager {
 	return &Manager{
-		endpointProxies:  cmap.New(),
-		k8sClientFactory: kubernetesClientFactory,
-		proxyFactory:     factory.NewProxyFactory(dataStore, signatureService, tunnelService, clientFactory, kubernetesClientFactory, kubernetesTokenCacheManager, gitService),
+		endpointProxies:     cmap.New(),
+		k8sClientFactory:    kubernetesClientFactory,
+		dockerClientFactory: clientFactory,
+		proxyFactory:        factory.NewProxyFactory(dataStore, signatureService, tunnelService, clientFactory, kubernetesClientFactory, kubernetesTokenCacheManager, gitService),
 	}
 }
 
@@ -62,7 +64,7 @@ func (manager *Manager) GetEndpointProxy(endpoint *portainer.Endpoint) http.Hand
 }
 
 // DeleteEndpointProxy deletes the proxy associated to a key
-// and cleans the k8s environment(endpoint) client cache. DeleteEndpointProxy
+// and cleans the k8s environment(endpoint) client cache and the SSH connection. DeleteEndpointProxy
 // is currently only called for edge connection clean up and when endpoint is updated
 func (manager *Manager) DeleteEndpointProxy(endpointID portainer.EndpointID) {
 	manager.endpointProxies.Remove(fmt.Sprint(endpointID))
@@ -70,6 +72,10 @@ func (manager *Manager) DeleteEndpointProxy(endpointID portainer.EndpointID) {
 	if manager.k8sClientFactory != nil {
 		manager.k8sClientFactory.RemoveKubeClient(endpointID)
 	}
+
+	if manager.dockerClientFactory != nil {
+		manager.dockerClientFactory.CloseSSHConnection(endpointID)
+	}
 }
 
 // CreateGitlabProxy creates a new HTTP reverse proxy that can be used to send requests to the Gitlab API
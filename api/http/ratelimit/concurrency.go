@@ -0,0 +1,213 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// ErrConcurrencyLimitExceeded is returned to the requests exceeding the concurrency limit of an environment
+var ErrConcurrencyLimitExceeded = errors.New("environment concurrency limit exceeded")
+
+// concurrencyPolicy is the normalized form of the proxy concurrency settings
+type concurrencyPolicy struct {
+	enabled         bool
+	maxRequests     int
+	edgeMaxRequests int
+	maxQueued       int
+	queueTimeout    time.Duration
+}
+
+func (policy concurrencyPolicy) limit(edge bool) int {
+	if edge && policy.edgeMaxRequests > 0 {
+		return policy.edgeMaxRequests
+	}
+
+	return policy.maxRequests
+}
+
+// endpointSlots are the requests proxied to an environment and the requests waiting for one of them to end
+type endpointSlots struct {
+	active  int
+	waiters []chan struct{}
+}
+
+// ConcurrencyLimiter limits the requests proxied at the same time to each environment so that a burst of requests
+// cannot overload its Docker daemon or Kubernetes API, the excess requests waiting in line or being rejected
+type ConcurrencyLimiter struct {
+	mu        sync.Mutex
+	policy    concurrencyPolicy
+	endpoints map[portainer.EndpointID]*endpointSlots
+}
+
+// NewConcurrencyLimiter creates a limiter from the settings, nothing being limited by default
+func NewConcurrencyLimiter(settings portainer.ProxyConcurrencySettings) *ConcurrencyLimiter {
+	limiter := &ConcurrencyLimiter{
+		endpoints: make(map[portainer.EndpointID]*endpointSlots),
+	}
+	limiter.Update(settings)
+
+	return limiter
+}
+
+// Update replaces the policy with the settings, which must have been validated. The requests in progress keep their
+// slot.
+func (limiter *ConcurrencyLimiter) Update(settings portainer.ProxyConcurrencySettings) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	limiter.policy = concurrencyPolicy{
+		enabled:         settings.Enabled,
+		maxRequests:     settings.MaxRequests,
+		edgeMaxRequests: settings.EdgeMaxRequests,
+		maxQueued:       settings.MaxQueued,
+		queueTimeout:    time.Duration(settings.QueueTimeout) * time.Second,
+	}
+}
+
+// Acquire waits for a slot of the environment, in the order of arrival, and returns the function releasing it. The
+// requests are rejected with ErrConcurrencyLimitExceeded when the queue of the environment is full or when the
+// queue timeout expires.
+func (limiter *ConcurrencyLimiter) Acquire(ctx context.Context, endpointID portainer.EndpointID, edge bool) (func(), error) {
+	limiter.mu.Lock()
+
+	policy := limiter.policy
+	limit := policy.limit(edge)
+	if !policy.enabled || limit <= 0 {
+		limiter.mu.Unlock()
+		return func() {}, nil
+	}
+
+	slots := limiter.endpoints[endpointID]
+	if slots == nil {
+		slots = &endpointSlots{}
+		limiter.endpoints[endpointID] = slots
+	}
+
+	release := limiter.releaser(endpointID, slots)
+
+	if slots.active < limit && len(slots.waiters) == 0 {
+		slots.active++
+		limiter.mu.Unlock()
+
+		return release, nil
+	}
+
+	if len(slots.waiters) >= policy.maxQueued || policy.queueTimeout <= 0 {
+		limiter.mu.Unlock()
+		return nil, ErrConcurrencyLimitExceeded
+	}
+
+	ready := make(chan struct{}, 1)
+	slots.waiters = append(slots.waiters, ready)
+	limiter.mu.Unlock()
+
+	timer := time.NewTimer(policy.queueTimeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-ready:
+		return release, nil
+	case <-timer.C:
+		err = ErrConcurrencyLimitExceeded
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	if !slots.removeWaiter(ready) {
+		// the slot was handed over while giving up, it is passed on
+		limiter.releaseLocked(endpointID, slots)
+	}
+
+	return nil, err
+}
+
+// releaser returns the function releasing a slot, which can be called several times
+func (limiter *ConcurrencyLimiter) releaser(endpointID portainer.EndpointID, slots *endpointSlots) func() {
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			limiter.mu.Lock()
+			defer limiter.mu.Unlock()
+
+			limiter.releaseLocked(endpointID, slots)
+		})
+	}
+}
+
+// releaseLocked hands the slot over to the first waiting request, or frees it. The lock must be held.
+func (limiter *ConcurrencyLimiter) releaseLocked(endpointID portainer.EndpointID, slots *endpointSlots) {
+	if len(slots.waiters) > 0 {
+		ready := slots.waiters[0]
+		slots.waiters = slots.waiters[1:]
+		ready <- struct{}{}
+
+		return
+	}
+
+	slots.active--
+	if slots.active <= 0 && limiter.endpoints[endpointID] == slots {
+		delete(limiter.endpoints, endpointID)
+	}
+}
+
+func (slots *endpointSlots) removeWaiter(ready chan struct{}) bool {
+	for i, waiter := range slots.waiters {
+		if waiter == ready {
+			slots.waiters = append(slots.waiters[:i], slots.waiters[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsLongLived returns whether a proxied request keeps its connection open until the client goes away, such as the
+// event streams, the followed logs, the attach and exec sessions or the Kubernetes watches, which are not limited
+// as they would hold a slot indefinitely
+func IsLongLived(r *http.Request) bool {
+	if r.Header.Get("Upgrade") != "" {
+		return true
+	}
+
+	query := r.URL.Query()
+	if isTrue(query.Get("follow")) || isTrue(query.Get("watch")) {
+		return true
+	}
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if strings.HasSuffix(path, "/events") || strings.HasSuffix(path, "/attach") {
+		return true
+	}
+
+	// the container stats are streamed unless stream=false
+	stream := query.Get("stream")
+	return strings.HasSuffix(path, "/stats") && stream != "0" && stream != "false"
+}
+
+func isTrue(value string) bool {
+	return value == "1" || value == "true"
+}
+
+// ValidateConcurrencySettings checks that a limit is set when the proxy concurrency limit is enabled
+func ValidateConcurrencySettings(settings portainer.ProxyConcurrencySettings) error {
+	if settings.MaxRequests < 0 || settings.EdgeMaxRequests < 0 || settings.MaxQueued < 0 || settings.QueueTimeout < 0 {
+		return errors.New("invalid proxy concurrency limit, the limits must not be negative")
+	}
+
+	if settings.Enabled && settings.MaxRequests == 0 && settings.EdgeMaxRequests == 0 {
+		return errors.New("invalid proxy concurrency limit, a maximum number of requests is required when the limit is enabled")
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	is := assert.New(t)
+
+	limiter := NewConcurrencyLimiter(portainer.ProxyConcurrencySettings{})
+
+	release, err := limiter.Acquire(context.Background(), 1, false)
+	is.NoError(err, "nothing should be limited by default")
+	release()
+
+	settings := portainer.ProxyConcurrencySettings{Enabled: true, MaxRequests: 2, EdgeMaxRequests: 1, QueueTimeout: 5}
+	limiter.Update(settings)
+
+	first, err := limiter.Acquire(context.Background(), 1, false)
+	is.NoError(err)
+	second, err := limiter.Acquire(context.Background(), 1, false)
+	is.NoError(err)
+
+	edge, err := limiter.Acquire(context.Background(), 2, true)
+	is.NoError(err, "the environments should be limited separately")
+	_, err = limiter.Acquire(context.Background(), 2, true)
+	is.ErrorIs(err, ErrConcurrencyLimitExceeded, "the Edge environments should use their own limit")
+	edge()
+
+	settings.MaxQueued = 1
+	limiter.Update(settings)
+
+	acquired := make(chan error, 1)
+	go func() {
+		release, err := limiter.Acquire(context.Background(), 1, false)
+		if err == nil {
+			release()
+		}
+
+		acquired <- err
+	}()
+
+	is.Eventually(func() bool {
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+
+		return len(limiter.endpoints[1].waiters) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = limiter.Acquire(context.Background(), 1, false)
+	is.ErrorIs(err, ErrConcurrencyLimitExceeded, "the requests exceeding the queue should be rejected")
+
+	first()
+	first()
+	is.NoError(<-acquired, "the slot should be handed over to the waiting request")
+
+	second()
+
+	limiter.mu.Lock()
+	is.Empty(limiter.endpoints, "the idle environments should be removed")
+	limiter.mu.Unlock()
+}
+
+func TestConcurrencyLimiter_QueueTimeout(t *testing.T) {
+	is := assert.New(t)
+
+	limiter := NewConcurrencyLimiter(portainer.ProxyConcurrencySettings{Enabled: true, MaxRequests: 1, MaxQueued: 5, QueueTimeout: 5})
+
+	release, err := limiter.Acquire(context.Background(), 1, false)
+	is.NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = limiter.Acquire(ctx, 1, false)
+	is.ErrorIs(err, context.DeadlineExceeded, "the requests of the clients gone away should leave the queue")
+
+	release()
+
+	release, err = limiter.Acquire(context.Background(), 1, false)
+	is.NoError(err)
+	release()
+}
+
+func TestIsLongLived(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"/1/docker/containers/json":                   false,
+		"/1/docker/images/create?fromImage=nginx":     false,
+		"/1/docker/events":                            true,
+		"/1/docker/containers/abc/logs?follow=1":      true,
+		"/1/docker/containers/abc/stats":              true,
+		"/1/docker/containers/abc/stats?stream=false": false,
+		"/1/kubernetes/api/v1/pods?watch=true":        true,
+	} {
+		assert.Equal(t, expected, IsLongLived(httptest.NewRequest("GET", path, nil)), path)
+	}
+
+	r := httptest.NewRequest("POST", "/1/docker/exec/abc/start", nil)
+	r.Header.Set("Upgrade", "tcp")
+	assert.True(t, IsLongLived(r))
+}
@@ -0,0 +1,311 @@
+// Package ratelimit limits the rate of the API requests, globally and for each client, with token buckets
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/internal/requestutils"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+)
+
+// sweepInterval is the interval at which the buckets of the idle clients are removed
+const sweepInterval = time.Minute
+
+// ErrRateLimitExceeded is returned to the clients exceeding the rate limits
+var ErrRateLimitExceeded = errors.New("API rate limit exceeded")
+
+// alwaysExemptPaths are the paths never rate limited: the health probes and the polling of the Edge agents,
+// which can share a single IP address
+var alwaysExemptPaths = []string{"/api/system/status/**", "/api/endpoints/*/edge/**"}
+
+// UserLookup returns the user authenticated by the request, if any
+type UserLookup func(r *http.Request) (portainer.UserID, bool)
+
+// limit is a token bucket configuration, rate being the number of tokens added per second
+type limit struct {
+	rate  float64
+	burst float64
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// policy is the normalized form of the rate limit settings
+type policy struct {
+	enabled         bool
+	global          *limit
+	client          *limit
+	exemptPaths     [][]string
+	exemptAddresses []*net.IPNet
+}
+
+// Policy applies the rate limit settings to the API requests, nothing being limited by default
+type Policy struct {
+	lookupUser UserLookup
+	current    atomic.Pointer[policy]
+	now        func() time.Time
+
+	mu        sync.Mutex
+	global    *bucket
+	clients   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewPolicy creates a policy from the settings, the requests of the users found by lookupUser being limited by user
+// instead of by IP address
+func NewPolicy(settings portainer.RateLimitSettings, lookupUser UserLookup) *Policy {
+	p := &Policy{
+		lookupUser: lookupUser,
+		now:        time.Now,
+	}
+	p.Update(settings)
+
+	return p
+}
+
+// Update replaces the policy with the settings, which must have been validated, and resets the buckets
+func (p *Policy) Update(settings portainer.RateLimitSettings) {
+	current := &policy{
+		enabled: settings.Enabled,
+		global:  newLimit(settings.GlobalRate, settings.GlobalBurst),
+		client:  newLimit(settings.ClientRate, settings.ClientBurst),
+	}
+
+	for _, pattern := range append(alwaysExemptPaths, settings.ExemptPaths...) {
+		current.exemptPaths = append(current.exemptPaths, requestutils.PathSegments(pattern))
+	}
+
+	for _, address := range settings.ExemptAddresses {
+		if network, err := parseAddress(address); err == nil {
+			current.exemptAddresses = append(current.exemptAddresses, network)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.current.Store(current)
+	p.global = nil
+	p.clients = make(map[string]*bucket)
+}
+
+func newLimit(rate float64, burst int) *limit {
+	if rate <= 0 {
+		return nil
+	}
+
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+
+	return &limit{rate: rate, burst: float64(burst)}
+}
+
+// Middleware rejects the API requests exceeding the rate limits with a 429 response, and adds the RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers to the responses of the limited requests
+func (p *Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := p.current.Load()
+		if !current.enabled || !strings.HasPrefix(r.URL.Path, "/api/") || r.Method == http.MethodOptions || current.exempts(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		clientKey := ""
+		if current.client != nil {
+			clientKey = p.clientKey(r)
+		}
+
+		allowed, state := p.take(current, clientKey)
+
+		if state.limit != nil {
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(int(state.limit.burst)))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(int(math.Floor(state.tokens))))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds((state.limit.burst-state.tokens)/state.limit.rate)))
+		}
+
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(max(1, seconds((1-state.tokens)/state.limit.rate))))
+			httperror.WriteError(w, http.StatusTooManyRequests, "Too many requests, retry later", ErrRateLimitExceeded)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey identifies the user of the request, or its IP address when it is not authenticated
+func (p *Policy) clientKey(r *http.Request) string {
+	if p.lookupUser != nil {
+		if userID, ok := p.lookupUser(r); ok {
+			return "user:" + strconv.Itoa(int(userID))
+		}
+	}
+
+	return "ip:" + requestutils.RemoteIP(r)
+}
+
+// bucketState is the state of the most restrictive bucket of a request
+type bucketState struct {
+	limit  *limit
+	tokens float64
+}
+
+// take consumes a token of the global bucket and of the bucket of the client, the request being allowed only when
+// both have one available
+func (p *Policy) take(current *policy, clientKey string) (bool, bucketState) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	p.sweep(current, now)
+
+	var global, client *bucket
+	if current.global != nil {
+		if p.global == nil {
+			p.global = &bucket{tokens: current.global.burst, last: now}
+		}
+
+		global = p.global
+		global.refill(current.global, now)
+	}
+
+	if current.client != nil {
+		client = p.clients[clientKey]
+		if client == nil {
+			client = &bucket{tokens: current.client.burst, last: now}
+			p.clients[clientKey] = client
+		}
+
+		client.refill(current.client, now)
+	}
+
+	if global != nil && global.tokens < 1 {
+		return false, bucketState{limit: current.global, tokens: global.tokens}
+	}
+
+	if client != nil && client.tokens < 1 {
+		return false, bucketState{limit: current.client, tokens: client.tokens}
+	}
+
+	if global != nil {
+		global.tokens--
+	}
+
+	if client != nil {
+		client.tokens--
+		return true, bucketState{limit: current.client, tokens: client.tokens}
+	}
+
+	return true, bucketState{limit: current.global, tokens: global.tokens}
+}
+
+// sweep removes the buckets of the clients that would be full, which are the same as new ones
+func (p *Policy) sweep(current *policy, now time.Time) {
+	if current.client == nil || now.Sub(p.lastSweep) < sweepInterval {
+		return
+	}
+
+	p.lastSweep = now
+
+	for key, b := range p.clients {
+		if b.tokens+now.Sub(b.last).Seconds()*current.client.rate >= current.client.burst {
+			delete(p.clients, key)
+		}
+	}
+}
+
+func (b *bucket) refill(l *limit, now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(l.burst, b.tokens+elapsed*l.rate)
+	}
+
+	b.last = now
+}
+
+func (current *policy) exempts(r *http.Request) bool {
+	segments := requestutils.PathSegments(r.URL.Path)
+	for _, pattern := range current.exemptPaths {
+		if requestutils.MatchPath(pattern, segments) {
+			return true
+		}
+	}
+
+	if len(current.exemptAddresses) == 0 {
+		return false
+	}
+
+	ip := net.ParseIP(requestutils.RemoteIP(r))
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range current.exemptAddresses {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseAddress(address string) (*net.IPNet, error) {
+	if strings.Contains(address, "/") {
+		_, network, err := net.ParseCIDR(address)
+		return network, err
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", address)
+	}
+
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 8 * net.IPv4len
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+func seconds(duration float64) int {
+	return int(math.Ceil(math.Max(0, duration)))
+}
+
+// ValidateSettings checks that a rate is set when the rate limiting is enabled, and that the exemptions are valid
+func ValidateSettings(settings portainer.RateLimitSettings) error {
+	if settings.GlobalRate < 0 || settings.ClientRate < 0 || settings.GlobalBurst < 0 || settings.ClientBurst < 0 {
+		return errors.New("invalid rate limit, the rates and bursts must not be negative")
+	}
+
+	if settings.Enabled && settings.GlobalRate == 0 && settings.ClientRate == 0 {
+		return errors.New("invalid rate limit, a global or client rate is required when the rate limiting is enabled")
+	}
+
+	for _, pattern := range settings.ExemptPaths {
+		if !strings.HasPrefix(pattern, "/") {
+			return fmt.Errorf("invalid rate limit exempt path %q, an absolute path such as /api/endpoints/*/docker/** is expected", pattern)
+		}
+	}
+
+	for _, address := range settings.ExemptAddresses {
+		if _, err := parseAddress(address); err != nil {
+			return fmt.Errorf("invalid rate limit exempt address %q, an IP address or a CIDR range is expected", address)
+		}
+	}
+
+	return nil
+}
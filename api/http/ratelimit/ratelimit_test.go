@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(t *testing.T) {
+	is := assert.New(t)
+
+	policy := NewPolicy(portainer.RateLimitSettings{}, func(r *http.Request) (portainer.UserID, bool) {
+		if r.Header.Get("X-User") == "" {
+			return 0, false
+		}
+
+		return 1, true
+	})
+
+	now := time.Unix(1700000000, 0)
+	policy.now = func() time.Time { return now }
+
+	handler := policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(path, remoteAddr string, user bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		if user {
+			req.Header.Set("X-User", "admin")
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("nothing is limited by default", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			rr := do("/api/endpoints", "10.0.0.1:1234", false)
+			is.Equal(http.StatusOK, rr.Code)
+			is.Empty(rr.Header().Get("RateLimit-Limit"))
+		}
+	})
+
+	policy.Update(portainer.RateLimitSettings{
+		Enabled:         true,
+		ClientRate:      1,
+		ClientBurst:     2,
+		ExemptPaths:     []string{"/api/endpoints/*/docker/**"},
+		ExemptAddresses: []string{"192.168.1.0/24"},
+	})
+
+	t.Run("the clients are limited separately", func(t *testing.T) {
+		rr := do("/api/endpoints", "10.0.0.1:1234", true)
+		is.Equal(http.StatusOK, rr.Code)
+		is.Equal("2", rr.Header().Get("RateLimit-Limit"))
+		is.Equal("1", rr.Header().Get("RateLimit-Remaining"))
+		is.Equal("1", rr.Header().Get("RateLimit-Reset"))
+
+		// the user is identified whatever its address
+		is.Equal(http.StatusOK, do("/api/endpoints", "10.0.0.2:1234", true).Code)
+
+		rr = do("/api/endpoints", "10.0.0.1:1234", true)
+		is.Equal(http.StatusTooManyRequests, rr.Code)
+		is.Equal("0", rr.Header().Get("RateLimit-Remaining"))
+		is.Equal("1", rr.Header().Get("Retry-After"))
+
+		is.Equal(http.StatusOK, do("/api/endpoints", "10.0.0.1:1234", false).Code)
+
+		now = now.Add(time.Second)
+		is.Equal(http.StatusOK, do("/api/endpoints", "10.0.0.1:1234", true).Code)
+		is.Equal(http.StatusTooManyRequests, do("/api/endpoints", "10.0.0.1:1234", true).Code)
+	})
+
+	t.Run("exemptions", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			is.Equal(http.StatusOK, do("/api/endpoints/1/docker/containers/json", "10.0.0.3:1234", false).Code)
+			is.Equal(http.StatusOK, do("/api/endpoints/1/edge/status", "10.0.0.3:1234", false).Code)
+			is.Equal(http.StatusOK, do("/api/system/status/healthz", "10.0.0.3:1234", false).Code)
+			is.Equal(http.StatusOK, do("/api/users", "192.168.1.20:1234", false).Code)
+			is.Equal(http.StatusOK, do("/index.html", "10.0.0.3:1234", false).Code)
+		}
+
+		is.Equal(http.StatusOK, do("/api/endpoints/1/kubernetes/api", "10.0.0.3:1234", false).Code)
+	})
+
+	policy.Update(portainer.RateLimitSettings{Enabled: true, GlobalRate: 2})
+
+	t.Run("the global limit applies to all the clients", func(t *testing.T) {
+		is.Equal(http.StatusOK, do("/api/endpoints", "10.0.0.1:1234", false).Code)
+		is.Equal(http.StatusOK, do("/api/endpoints", "10.0.0.2:1234", false).Code)
+
+		rr := do("/api/endpoints", "10.0.0.3:1234", true)
+		is.Equal(http.StatusTooManyRequests, rr.Code)
+		is.Equal("2", rr.Header().Get("RateLimit-Limit"))
+	})
+}
+
+func TestSweep(t *testing.T) {
+	policy := NewPolicy(portainer.RateLimitSettings{Enabled: true, ClientRate: 1}, nil)
+
+	now := time.Unix(1700000000, 0)
+	policy.now = func() time.Time { return now }
+
+	handler := policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, addr := range []string{"10.0.0.1:1234", "10.0.0.2:1234"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/endpoints", nil)
+		req.RemoteAddr = addr
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Len(t, policy.clients, 2)
+
+	now = now.Add(2 * sweepInterval)
+	policy.take(policy.current.Load(), "ip:10.0.0.3")
+	assert.Len(t, policy.clients, 1)
+}
+
+func TestValidateSettings(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateSettings(portainer.RateLimitSettings{}))
+	is.NoError(ValidateSettings(portainer.RateLimitSettings{
+		Enabled:         true,
+		ClientRate:      10,
+		ExemptPaths:     []string{"/api/endpoints/*/docker/**"},
+		ExemptAddresses: []string{"10.0.0.1", "10.0.0.0/8", "fd00::/8"},
+	}))
+
+	is.Error(ValidateSettings(portainer.RateLimitSettings{Enabled: true}))
+	is.Error(ValidateSettings(portainer.RateLimitSettings{GlobalRate: -1}))
+	is.Error(ValidateSettings(portainer.RateLimitSettings{ExemptPaths: []string{"api/users"}}))
+	is.Error(ValidateSettings(portainer.RateLimitSettings{ExemptAddresses: []string{"10.0.0.0/33"}}))
+}
@@ -1,6 +1,8 @@
 package security
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
 	"strings"
 	"time"
@@ -9,9 +11,11 @@ import (
 	"github.com/portainer/portainer/api/apikey"
 	"github.com/portainer/portainer/api/dataservices"
 	httperrors "github.com/portainer/portainer/api/http/errors"
+	"github.com/portainer/portainer/api/internal/endpointutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
 )
 
 type (
@@ -24,6 +28,7 @@ type (
 		EdgeComputeOperation(http.Handler) http.Handler
 
 		AuthorizedEndpointOperation(*http.Request, *portainer.Endpoint) error
+		AuthorizedEndpointProxyOperation(*http.Request, *portainer.Endpoint) error
 		AuthorizedEdgeEndpointOperation(*http.Request, *portainer.Endpoint) error
 		TrustedEdgeEnvironmentAccess(dataservices.DataStoreTx, *portainer.Endpoint) error
 		JWTAuthLookup(*http.Request) *portainer.TokenData
@@ -34,6 +39,7 @@ type (
 		dataStore     dataservices.DataStore
 		jwtService    dataservices.JWTService
 		apiKeyService apikey.APIKeyService
+		tokenUses     TokenUseListener
 	}
 
 	// RestrictedRequestContext is a data structure containing information
@@ -47,6 +53,10 @@ type (
 
 	// tokenLookup looks up a token in the request
 	tokenLookup func(*http.Request) *portainer.TokenData
+
+	// TokenUseListener is called with the requests authenticated by a session token, identified by the hex encoded
+	// SHA-256 digest of the token
+	TokenUseListener func(r *http.Request, tokenData *portainer.TokenData, tokenDigest string)
 )
 
 const apiKeyHeader = "X-API-KEY"
@@ -60,6 +70,12 @@ func NewRequestBouncer(dataStore dataservices.DataStore, jwtService dataservices
 	}
 }
 
+// ListenTokenUses sets the listener of the requests authenticated by a session token. It must be set before the
+// requests are served.
+func (bouncer *RequestBouncer) ListenTokenUses(listener TokenUseListener) {
+	bouncer.tokenUses = listener
+}
+
 // PublicAccess defines a security check for public API environments(endpoints).
 // No authentication is required to access these environments(endpoints).
 func (bouncer *RequestBouncer) PublicAccess(h http.Handler) http.Handler {
@@ -118,8 +134,34 @@ func (bouncer *RequestBouncer) AuthenticatedAccess(h http.Handler) http.Handler
 // AuthorizedEndpointOperation retrieves the JWT token from the request context and verifies
 // that the user can access the specified environment(endpoint).
 // An error is returned when access to the environments(endpoints) is denied or if the user do not have the required
-// authorization to execute the operation.
+// authorization to execute the operation. The changes of the non-administrator users on an environment requiring the
+// approval of its changes are rejected, as they are not submitted for approval.
 func (bouncer *RequestBouncer) AuthorizedEndpointOperation(r *http.Request, endpoint *portainer.Endpoint) error {
+	if err := bouncer.AuthorizedEndpointProxyOperation(r, endpoint); err != nil {
+		return err
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	tokenData, err := RetrieveTokenData(r)
+	if err != nil {
+		return err
+	}
+
+	if endpointutils.RequiresChangeApproval(endpoint, tokenData) {
+		return endpointutils.ErrChangeApprovalRequired
+	}
+
+	return nil
+}
+
+// AuthorizedEndpointProxyOperation verifies that the user can access the specified environment like
+// AuthorizedEndpointOperation, without rejecting the changes requiring an approval. It is used by the Docker proxy,
+// which submits these changes for approval.
+func (bouncer *RequestBouncer) AuthorizedEndpointProxyOperation(r *http.Request, endpoint *portainer.Endpoint) error {
 	tokenData, err := RetrieveTokenData(r)
 	if err != nil {
 		return err
@@ -297,11 +339,48 @@ func (bouncer *RequestBouncer) mwAuthenticateFirst(tokenLookups []tokenLookup, n
 			return
 		}
 
+		if token.ImpersonatorID != 0 {
+			logImpersonatedRequest(r, token)
+		}
+
+		bouncer.notifyTokenUse(r, token)
+
 		ctx := StoreTokenData(r, token)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// notifyTokenUse calls the token use listener when the request is authenticated by a session token rather than an
+// API key
+func (bouncer *RequestBouncer) notifyTokenUse(r *http.Request, token *portainer.TokenData) {
+	if bouncer.tokenUses == nil {
+		return
+	}
+
+	if _, ok := extractAPIKey(r); ok {
+		return
+	}
+
+	bearer, err := extractBearerToken(r)
+	if err != nil {
+		return
+	}
+
+	digest := sha256.Sum256([]byte(bearer))
+	bouncer.tokenUses(r, token, hex.EncodeToString(digest[:]))
+}
+
+// logImpersonatedRequest records the requests made by an administrator impersonating a user in the audit trail
+func logImpersonatedRequest(r *http.Request, token *portainer.TokenData) {
+	log.Ctx(r.Context()).Info().
+		Int("impersonator_id", int(token.ImpersonatorID)).
+		Int("user_id", int(token.ID)).
+		Str("user", token.Username).
+		Str("method", r.Method).
+		Str("path", r.URL.Path).
+		Msg("impersonated request")
+}
+
 // JWTAuthLookup looks up a valid bearer in the request.
 func (bouncer *RequestBouncer) JWTAuthLookup(r *http.Request) *portainer.TokenData {
 	// get token from the Authorization header or query parameter
@@ -318,6 +397,46 @@ func (bouncer *RequestBouncer) JWTAuthLookup(r *http.Request) *portainer.TokenDa
 	return tokenData
 }
 
+// LookupUser returns the ID of the user authenticated by the bearer token or the API key of the request. Unlike the
+// API key lookup of the authentication, it does not update the last used time of the key.
+func (bouncer *RequestBouncer) LookupUser(r *http.Request) (portainer.UserID, bool) {
+	if tokenData := bouncer.JWTAuthLookup(r); tokenData != nil {
+		return tokenData.ID, true
+	}
+
+	rawAPIKey, ok := extractAPIKey(r)
+	if !ok {
+		return 0, false
+	}
+
+	user, _, err := bouncer.apiKeyService.GetDigestUserAndKey(bouncer.apiKeyService.HashRaw(rawAPIKey))
+	if err != nil {
+		return 0, false
+	}
+
+	return user.ID, true
+}
+
+// LookupToken returns the user authenticated by the bearer token or the API key of the request, along with the API
+// key when it is used. Like LookupUser, it does not update the last used time of the key.
+func (bouncer *RequestBouncer) LookupToken(r *http.Request) (*portainer.TokenData, *portainer.APIKey) {
+	if tokenData := bouncer.JWTAuthLookup(r); tokenData != nil {
+		return tokenData, nil
+	}
+
+	rawAPIKey, ok := extractAPIKey(r)
+	if !ok {
+		return nil, nil
+	}
+
+	user, apiKey, err := bouncer.apiKeyService.GetDigestUserAndKey(bouncer.apiKeyService.HashRaw(rawAPIKey))
+	if err != nil {
+		return nil, nil
+	}
+
+	return &portainer.TokenData{ID: user.ID, Username: user.Username, Role: user.Role}, &apiKey
+}
+
 // apiKeyLookup looks up an verifies an api-key by:
 // - computing the digest of the raw api-key
 // - verifying it exists in cache/database
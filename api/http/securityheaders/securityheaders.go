@@ -0,0 +1,135 @@
+// Package securityheaders adds the security headers, such as HSTS and the Content-Security-Policy, to the responses
+package securityheaders
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	hstsHeader = "max-age=31536000"
+
+	// DefaultContentSecurityPolicy is the policy of the UI when none is configured. It restricts the plugins, the base
+	// URL and the form targets while allowing the external resources the UI can use, such as a logo URL.
+	DefaultContentSecurityPolicy = "default-src 'self'; " +
+		"script-src 'self' 'unsafe-inline' 'unsafe-eval' https:; " +
+		"style-src 'self' 'unsafe-inline' https:; " +
+		"img-src 'self' data: blob: https:; " +
+		"font-src 'self' data: https:; " +
+		"connect-src 'self' ws: wss: https:; " +
+		"object-src 'none'; " +
+		"base-uri 'self'; " +
+		"form-action 'self'"
+
+	// apiContentSecurityPolicy is the policy of the API responses, which are never rendered as documents
+	apiContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+)
+
+type headers struct {
+	frameOptions string
+	csp          string
+}
+
+// Policy adds the security headers to the responses according to the settings
+type Policy struct {
+	current atomic.Pointer[headers]
+}
+
+// NewPolicy creates a policy from the settings
+func NewPolicy(settings portainer.SecurityHeadersSettings) *Policy {
+	p := &Policy{}
+	p.Update(settings)
+
+	return p
+}
+
+// Update replaces the policy with the settings, which must have been validated
+func (p *Policy) Update(settings portainer.SecurityHeadersSettings) {
+	current := &headers{
+		frameOptions: "DENY",
+		csp:          strings.TrimSuffix(strings.TrimSpace(settings.ContentSecurityPolicy), ";"),
+	}
+
+	if current.csp == "" {
+		current.csp = DefaultContentSecurityPolicy
+	}
+
+	if !hasDirective(current.csp, "frame-ancestors") {
+		frameAncestors := "'none'"
+		if settings.AllowFrameEmbedding {
+			frameAncestors = "'self' " + strings.Join(settings.FrameAncestors, " ")
+		}
+
+		current.csp += "; frame-ancestors " + strings.TrimSpace(frameAncestors)
+	}
+
+	// X-Frame-Options cannot list several origins, frame-ancestors takes over when the embedding is allowed
+	if settings.AllowFrameEmbedding {
+		current.frameOptions = ""
+	}
+
+	p.current.Store(current)
+}
+
+// Middleware adds the security headers to the responses of the UI and of the API
+func (p *Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := p.current.Load()
+
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+
+		if r.TLS != nil {
+			h.Set("Strict-Transport-Security", hstsHeader)
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Content-Security-Policy", apiContentSecurityPolicy)
+		} else {
+			if current.frameOptions != "" {
+				h.Set("X-Frame-Options", current.frameOptions)
+			}
+			h.Set("Content-Security-Policy", current.csp)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func hasDirective(csp, directive string) bool {
+	for _, d := range strings.Split(csp, ";") {
+		if fields := strings.Fields(d); len(fields) > 0 && strings.EqualFold(fields[0], directive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateSettings checks that the frame ancestors are origins, required when the frame embedding is allowed,
+// and that the Content-Security-Policy fits in a header
+func ValidateSettings(settings portainer.SecurityHeadersSettings) error {
+	if settings.AllowFrameEmbedding && len(settings.FrameAncestors) == 0 {
+		return fmt.Errorf("at least one frame ancestor is required to allow the frame embedding")
+	}
+
+	for _, origin := range settings.FrameAncestors {
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.User != nil {
+			return fmt.Errorf("invalid frame ancestor %q, an origin such as https://portal.example.com is expected", origin)
+		}
+	}
+
+	if strings.ContainsAny(settings.ContentSecurityPolicy, "\r\n") {
+		return fmt.Errorf("invalid Content-Security-Policy, it must fit on a single line")
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package securityheaders
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy(t *testing.T) {
+	is := assert.New(t)
+
+	policy := NewPolicy(portainer.SecurityHeadersSettings{})
+	handler := policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(path string, secure bool) http.Header {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if secure {
+			req.TLS = &tls.ConnectionState{}
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Header()
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		h := do("/", true)
+		is.Equal(hstsHeader, h.Get("Strict-Transport-Security"))
+		is.Equal("DENY", h.Get("X-Frame-Options"))
+		is.Equal("nosniff", h.Get("X-Content-Type-Options"))
+		is.Equal(DefaultContentSecurityPolicy+"; frame-ancestors 'none'", h.Get("Content-Security-Policy"))
+
+		h = do("/api/status", false)
+		is.Empty(h.Get("Strict-Transport-Security"), "HSTS must only be sent over HTTPS")
+		is.Equal(apiContentSecurityPolicy, h.Get("Content-Security-Policy"))
+	})
+
+	t.Run("frame embedding", func(t *testing.T) {
+		policy.Update(portainer.SecurityHeadersSettings{AllowFrameEmbedding: true, FrameAncestors: []string{"https://portal.example.com"}})
+
+		h := do("/", true)
+		is.Empty(h.Get("X-Frame-Options"))
+		is.Contains(h.Get("Content-Security-Policy"), "frame-ancestors 'self' https://portal.example.com")
+
+		h = do("/api/status", true)
+		is.Equal("DENY", h.Get("X-Frame-Options"))
+	})
+
+	t.Run("custom policy", func(t *testing.T) {
+		policy.Update(portainer.SecurityHeadersSettings{ContentSecurityPolicy: "default-src 'self'; frame-ancestors 'self';"})
+		is.Equal("default-src 'self'; frame-ancestors 'self'", do("/", false).Get("Content-Security-Policy"))
+
+		policy.Update(portainer.SecurityHeadersSettings{ContentSecurityPolicy: "default-src 'self'"})
+		is.Equal("default-src 'self'; frame-ancestors 'none'", do("/", false).Get("Content-Security-Policy"))
+	})
+}
+
+func TestValidateSettings(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateSettings(portainer.SecurityHeadersSettings{}))
+	is.NoError(ValidateSettings(portainer.SecurityHeadersSettings{AllowFrameEmbedding: true, FrameAncestors: []string{"https://portal.example.com"}}))
+
+	is.Error(ValidateSettings(portainer.SecurityHeadersSettings{AllowFrameEmbedding: true}))
+	is.Error(ValidateSettings(portainer.SecurityHeadersSettings{FrameAncestors: []string{"portal.example.com"}}))
+	is.Error(ValidateSettings(portainer.SecurityHeadersSettings{ContentSecurityPolicy: "default-src 'self'\r\nX-Injected: 1"}))
+}
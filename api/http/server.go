@@ -3,21 +3,35 @@ package http
 import (
 	"context"
 	"crypto/tls"
+	"net"
 	"net/http"
 	"path/filepath"
+	"sync"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/adminmonitor"
 	"github.com/portainer/portainer/api/apikey"
+	"github.com/portainer/portainer/api/cmdb"
 	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/demo"
+	"github.com/portainer/portainer/api/discovery"
 	"github.com/portainer/portainer/api/docker"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/grpcapi"
+	"github.com/portainer/portainer/api/http/apiusage"
+	"github.com/portainer/portainer/api/http/authzhook"
+	"github.com/portainer/portainer/api/http/cors"
 	"github.com/portainer/portainer/api/http/handler"
 	"github.com/portainer/portainer/api/http/handler/auth"
+	"github.com/portainer/portainer/api/http/handler/automationwebhooks"
+	"github.com/portainer/portainer/api/http/handler/backgroundjobs"
 	"github.com/portainer/portainer/api/http/handler/backup"
+	"github.com/portainer/portainer/api/http/handler/changerequests"
+	"github.com/portainer/portainer/api/http/handler/chatops"
+	"github.com/portainer/portainer/api/http/handler/cmdbconnectors"
+	"github.com/portainer/portainer/api/http/handler/costs"
 	"github.com/portainer/portainer/api/http/handler/customtemplates"
 	dockerhandler "github.com/portainer/portainer/api/http/handler/docker"
 	"github.com/portainer/portainer/api/http/handler/edgegroups"
@@ -28,19 +42,28 @@ import (
 	"github.com/portainer/portainer/api/http/handler/endpointgroups"
 	"github.com/portainer/portainer/api/http/handler/endpointproxy"
 	"github.com/portainer/portainer/api/http/handler/endpoints"
+	"github.com/portainer/portainer/api/http/handler/eventwebhooks"
 	"github.com/portainer/portainer/api/http/handler/file"
 	"github.com/portainer/portainer/api/http/handler/gitops"
+	"github.com/portainer/portainer/api/http/handler/graphql"
 	"github.com/portainer/portainer/api/http/handler/helm"
 	"github.com/portainer/portainer/api/http/handler/hostmanagement/fdo"
 	"github.com/portainer/portainer/api/http/handler/hostmanagement/openamt"
+	imageupdatehandler "github.com/portainer/portainer/api/http/handler/imageupdates"
 	kubehandler "github.com/portainer/portainer/api/http/handler/kubernetes"
 	"github.com/portainer/portainer/api/http/handler/ldap"
+	"github.com/portainer/portainer/api/http/handler/maintenancewindows"
 	"github.com/portainer/portainer/api/http/handler/motd"
+	"github.com/portainer/portainer/api/http/handler/notes"
+	"github.com/portainer/portainer/api/http/handler/ownershiprules"
 	"github.com/portainer/portainer/api/http/handler/registries"
 	"github.com/portainer/portainer/api/http/handler/resourcecontrols"
 	"github.com/portainer/portainer/api/http/handler/roles"
+	"github.com/portainer/portainer/api/http/handler/savedviews"
+	"github.com/portainer/portainer/api/http/handler/securityalerts"
 	"github.com/portainer/portainer/api/http/handler/settings"
 	sslhandler "github.com/portainer/portainer/api/http/handler/ssl"
+	"github.com/portainer/portainer/api/http/handler/stackbundles"
 	"github.com/portainer/portainer/api/http/handler/stacks"
 	"github.com/portainer/portainer/api/http/handler/storybook"
 	"github.com/portainer/portainer/api/http/handler/system"
@@ -50,33 +73,55 @@ import (
 	"github.com/portainer/portainer/api/http/handler/templates"
 	"github.com/portainer/portainer/api/http/handler/upload"
 	"github.com/portainer/portainer/api/http/handler/users"
+	volumebackuphandler "github.com/portainer/portainer/api/http/handler/volumebackups"
 	"github.com/portainer/portainer/api/http/handler/webhooks"
 	"github.com/portainer/portainer/api/http/handler/websocket"
 	"github.com/portainer/portainer/api/http/middlewares"
 	"github.com/portainer/portainer/api/http/offlinegate"
 	"github.com/portainer/portainer/api/http/proxy"
 	"github.com/portainer/portainer/api/http/proxy/factory/kubernetes"
+	"github.com/portainer/portainer/api/http/ratelimit"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/http/securityheaders"
+	"github.com/portainer/portainer/api/imageupdates"
 	"github.com/portainer/portainer/api/internal/authorization"
 	edgestackservice "github.com/portainer/portainer/api/internal/edge/edgestacks"
+	"github.com/portainer/portainer/api/internal/handoff"
 	"github.com/portainer/portainer/api/internal/snapshot"
+	"github.com/portainer/portainer/api/internal/sockets"
 	"github.com/portainer/portainer/api/internal/ssl"
 	"github.com/portainer/portainer/api/internal/upgrade"
+	"github.com/portainer/portainer/api/intrusion"
+	"github.com/portainer/portainer/api/jobs"
 	k8s "github.com/portainer/portainer/api/kubernetes"
 	"github.com/portainer/portainer/api/kubernetes/cli"
+	"github.com/portainer/portainer/api/lifecycle"
+	"github.com/portainer/portainer/api/mail"
 	"github.com/portainer/portainer/api/pendingactions"
+	"github.com/portainer/portainer/api/release"
 	"github.com/portainer/portainer/api/scheduler"
 	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/drift"
+	"github.com/portainer/portainer/api/syslog"
+	"github.com/portainer/portainer/api/usage"
+	"github.com/portainer/portainer/api/volumebackups"
 	"github.com/portainer/portainer/pkg/libhelm"
 
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
+const defaultShutdownTimeout = 30 * time.Second
+
 // Server implements the portainer.Server interface
 type Server struct {
 	AuthorizationService        *authorization.Service
 	BindAddress                 string
 	BindAddressHTTPS            string
+	BindAddressGRPC             string
+	ListenNetwork               string
+	UnixSocketOptions           sockets.UnixOptions
 	HTTPEnabled                 bool
 	AssetsPath                  string
 	Status                      *portainer.Status
@@ -107,8 +152,22 @@ type Server struct {
 	Scheduler                   *scheduler.Scheduler
 	ShutdownCtx                 context.Context
 	ShutdownTrigger             context.CancelFunc
+	ShutdownTimeout             time.Duration
+	Handoff                     *handoff.Handoff
 	StackDeployer               deployments.StackDeployer
 	DemoService                 *demo.Service
+	DiscoveryService            *discovery.Service
+	UsageService                *usage.Service
+	CMDBService                 *cmdb.Service
+	DriftService                *drift.Service
+	JobQueue                    *jobs.Queue
+	SyslogForwarder             *syslog.Forwarder
+	MailService                 *mail.Service
+	ReleaseService              *release.Service
+	OfflineModeFlag             bool
+	GeoIPDatabase               *intrusion.GeoIPDatabase
+	WebSocketIdleTimeout        time.Duration
+	WebSocketReconnectWindow    time.Duration
 	UpgradeService              upgrade.Service
 	AdminCreationDone           chan struct{}
 	PendingActionsService       *pendingactions.PendingActionsService
@@ -125,6 +184,16 @@ func (server *Server) Start() error {
 
 	passwordStrengthChecker := security.NewPasswordStrengthChecker(server.DataStore.Settings())
 
+	appSettings, err := server.DataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+	corsPolicy := cors.NewPolicy(appSettings.CORS)
+	securityHeadersPolicy := securityheaders.NewPolicy(appSettings.SecurityHeaders)
+	rateLimitPolicy := ratelimit.NewPolicy(appSettings.RateLimit, requestBouncer.LookupUser)
+	concurrencyLimiter := ratelimit.NewConcurrencyLimiter(appSettings.ProxyConcurrency)
+	authorizationHook := authzhook.NewHook(appSettings.AuthorizationHook, server.DataStore, requestBouncer.LookupUser)
+
 	var authHandler = auth.NewHandler(requestBouncer, rateLimiter, passwordStrengthChecker)
 	authHandler.DataStore = server.DataStore
 	authHandler.CryptoService = server.CryptoService
@@ -134,6 +203,26 @@ func (server *Server) Start() error {
 	authHandler.KubernetesTokenCacheManager = kubernetesTokenCacheManager
 	authHandler.OAuthService = server.OAuthService
 
+	eventDispatcher := lifecycle.NewDispatcher(server.DataStore)
+	if server.SyslogForwarder != nil {
+		eventDispatcher.Listen(server.SyslogForwarder.ForwardEvent)
+	}
+	if server.MailService != nil {
+		eventDispatcher.Listen(server.MailService.NotifyEvent)
+		eventDispatcher.ListenFailures(server.MailService.NotifyWebhookFailure)
+	}
+	eventDispatcher.Start(server.ShutdownCtx)
+	if snapshotService, ok := server.SnapshotService.(*snapshot.Service); ok {
+		snapshotService.SetUnhealthyNotifier(lifecycle.NewContainerNotifier(eventDispatcher))
+	}
+	authHandler.EventDispatcher = eventDispatcher
+
+	apiUsageTracker := apiusage.NewTracker(appSettings.APIUsage, requestBouncer.LookupToken, eventDispatcher)
+
+	intrusionDetector := intrusion.NewDetector(appSettings.IntrusionDetection, server.DataStore, server.GeoIPDatabase, eventDispatcher)
+	requestBouncer.ListenTokenUses(intrusionDetector.TokenUsed)
+	authHandler.IntrusionDetector = intrusionDetector
+
 	adminMonitor := adminmonitor.New(5*time.Minute, server.DataStore, server.ShutdownCtx)
 	adminMonitor.Start()
 
@@ -171,34 +260,47 @@ func (server *Server) Start() error {
 
 	var endpointHandler = endpoints.NewHandler(requestBouncer, server.DemoService)
 	endpointHandler.DataStore = server.DataStore
+	endpointHandler.EventDispatcher = eventDispatcher
 	endpointHandler.FileService = server.FileService
 	endpointHandler.ProxyManager = server.ProxyManager
 	endpointHandler.SnapshotService = server.SnapshotService
 	endpointHandler.K8sClientFactory = server.KubernetesClientFactory
+	endpointHandler.DockerClientFactory = server.DockerClientFactory
 	endpointHandler.ReverseTunnelService = server.ReverseTunnelService
 	endpointHandler.ComposeStackManager = server.ComposeStackManager
 	endpointHandler.AuthorizationService = server.AuthorizationService
 	endpointHandler.BindAddress = server.BindAddress
 	endpointHandler.BindAddressHTTPS = server.BindAddressHTTPS
 	endpointHandler.PendingActionsService = server.PendingActionsService
+	endpointHandler.JobQueue = server.JobQueue
+	endpointHandler.SignatureService = server.SignatureService
 
 	var endpointEdgeHandler = endpointedge.NewHandler(requestBouncer, server.DataStore, server.FileService, server.ReverseTunnelService)
 
 	var endpointGroupHandler = endpointgroups.NewHandler(requestBouncer)
 	endpointGroupHandler.AuthorizationService = server.AuthorizationService
 	endpointGroupHandler.DataStore = server.DataStore
+	endpointGroupHandler.EventDispatcher = eventDispatcher
 	endpointGroupHandler.PendingActionsService = server.PendingActionsService
 
 	var endpointProxyHandler = endpointproxy.NewHandler(requestBouncer)
 	endpointProxyHandler.DataStore = server.DataStore
 	endpointProxyHandler.ProxyManager = server.ProxyManager
 	endpointProxyHandler.ReverseTunnelService = server.ReverseTunnelService
+	endpointProxyHandler.EventDispatcher = eventDispatcher
+	endpointProxyHandler.ConcurrencyLimiter = concurrencyLimiter
+
+	var changeRequestHandler = changerequests.NewHandler(requestBouncer)
+	changeRequestHandler.DataStore = server.DataStore
+	changeRequestHandler.ProxyManager = server.ProxyManager
+	changeRequestHandler.ReverseTunnelService = server.ReverseTunnelService
+	changeRequestHandler.EventDispatcher = eventDispatcher
 
 	var kubernetesHandler = kubehandler.NewHandler(requestBouncer, server.AuthorizationService, server.DataStore, server.JWTService, server.KubeClusterAccessService, server.KubernetesClientFactory, nil)
 
 	containerService := docker.NewContainerService(server.DockerClientFactory, server.DataStore)
 
-	var dockerHandler = dockerhandler.NewHandler(requestBouncer, server.AuthorizationService, server.DataStore, server.DockerClientFactory, containerService)
+	var dockerHandler = dockerhandler.NewHandler(requestBouncer, server.AuthorizationService, server.DataStore, server.DockerClientFactory, containerService, server.JobQueue)
 
 	var fileHandler = file.NewHandler(filepath.Join(server.AssetsPath, "public"), adminMonitor.WasInstanceDisabled)
 
@@ -215,21 +317,51 @@ func (server *Server) Start() error {
 
 	var motdHandler = motd.NewHandler(requestBouncer)
 
+	var noteHandler = notes.NewHandler(requestBouncer)
+	noteHandler.DataStore = server.DataStore
+
+	var ownershipRuleHandler = ownershiprules.NewHandler(requestBouncer)
+	ownershipRuleHandler.DataStore = server.DataStore
+
 	var registryHandler = registries.NewHandler(requestBouncer)
 	registryHandler.DataStore = server.DataStore
 	registryHandler.FileService = server.FileService
 	registryHandler.ProxyManager = server.ProxyManager
 	registryHandler.K8sClientFactory = server.KubernetesClientFactory
+	registryHandler.DockerClientFactory = server.DockerClientFactory
 
 	var resourceControlHandler = resourcecontrols.NewHandler(requestBouncer)
 	resourceControlHandler.DataStore = server.DataStore
+	resourceControlHandler.EventDispatcher = eventDispatcher
+
+	var maintenanceWindowHandler = maintenancewindows.NewHandler(requestBouncer)
+	maintenanceWindowHandler.DataStore = server.DataStore
+
+	var savedViewHandler = savedviews.NewHandler(requestBouncer)
+	savedViewHandler.DataStore = server.DataStore
+
+	var securityAlertHandler = securityalerts.NewHandler(requestBouncer)
+	securityAlertHandler.DataStore = server.DataStore
 
 	var settingsHandler = settings.NewHandler(requestBouncer, server.DemoService)
 	settingsHandler.DataStore = server.DataStore
+	settingsHandler.DiscoveryService = server.DiscoveryService
 	settingsHandler.FileService = server.FileService
 	settingsHandler.JWTService = server.JWTService
 	settingsHandler.LDAPService = server.LDAPService
 	settingsHandler.SnapshotService = server.SnapshotService
+	settingsHandler.UsageService = server.UsageService
+	settingsHandler.SyslogForwarder = server.SyslogForwarder
+	settingsHandler.MailService = server.MailService
+	settingsHandler.ReleaseService = server.ReleaseService
+	settingsHandler.OfflineModeFlag = server.OfflineModeFlag
+	settingsHandler.CORSPolicy = corsPolicy
+	settingsHandler.SecurityHeadersPolicy = securityHeadersPolicy
+	settingsHandler.RateLimitPolicy = rateLimitPolicy
+	settingsHandler.ConcurrencyLimiter = concurrencyLimiter
+	settingsHandler.AuthorizationHook = authorizationHook
+	settingsHandler.APIUsageTracker = apiUsageTracker
+	settingsHandler.IntrusionDetector = intrusionDetector
 
 	var sslHandler = sslhandler.NewHandler(requestBouncer)
 	sslHandler.SSLService = server.SSLService
@@ -243,15 +375,22 @@ func (server *Server) Start() error {
 
 	var stackHandler = stacks.NewHandler(requestBouncer)
 	stackHandler.DataStore = server.DataStore
+	stackHandler.EventDispatcher = eventDispatcher
 	stackHandler.DockerClientFactory = server.DockerClientFactory
 	stackHandler.FileService = server.FileService
 	stackHandler.KubernetesClientFactory = server.KubernetesClientFactory
 	stackHandler.KubernetesDeployer = server.KubernetesDeployer
 	stackHandler.GitService = server.GitService
 	stackHandler.Scheduler = server.Scheduler
+	stackHandler.JobQueue = server.JobQueue
 	stackHandler.SwarmStackManager = server.SwarmStackManager
 	stackHandler.ComposeStackManager = server.ComposeStackManager
 	stackHandler.StackDeployer = server.StackDeployer
+	stackHandler.DriftService = server.DriftService
+
+	var stackBundleHandler = stackbundles.NewHandler(requestBouncer)
+	stackBundleHandler.DataStore = server.DataStore
+	stackBundleHandler.JobQueue = server.JobQueue
 
 	var storybookHandler = storybook.NewHandler(server.AssetsPath)
 
@@ -263,12 +402,20 @@ func (server *Server) Start() error {
 
 	var teamMembershipHandler = teammemberships.NewHandler(requestBouncer)
 	teamMembershipHandler.DataStore = server.DataStore
+	teamMembershipHandler.EventDispatcher = eventDispatcher
 
 	var systemHandler = system.NewHandler(requestBouncer,
 		server.Status,
 		server.DemoService,
 		server.DataStore,
 		server.UpgradeService)
+	systemHandler.ShutdownCtx = server.ShutdownCtx
+	systemHandler.UsageService = server.UsageService
+	systemHandler.APIUsageTracker = apiUsageTracker
+	systemHandler.ReleaseService = server.ReleaseService
+	systemHandler.OfflineGate = offlineGate
+	systemHandler.Scheduler = server.Scheduler
+	systemHandler.DataPath = server.FileService.GetDatastorePath()
 
 	var templatesHandler = templates.NewHandler(requestBouncer)
 	templatesHandler.DataStore = server.DataStore
@@ -281,80 +428,176 @@ func (server *Server) Start() error {
 	var userHandler = users.NewHandler(requestBouncer, rateLimiter, server.APIKeyService, server.DemoService, passwordStrengthChecker)
 	userHandler.DataStore = server.DataStore
 	userHandler.CryptoService = server.CryptoService
+	userHandler.JWTService = server.JWTService
 	userHandler.AdminCreationDone = server.AdminCreationDone
+	userHandler.EventDispatcher = eventDispatcher
+	userHandler.MailService = server.MailService
 
 	var websocketHandler = websocket.NewHandler(server.KubernetesTokenCacheManager, requestBouncer)
 	websocketHandler.DataStore = server.DataStore
 	websocketHandler.SignatureService = server.SignatureService
 	websocketHandler.ReverseTunnelService = server.ReverseTunnelService
 	websocketHandler.KubernetesClientFactory = server.KubernetesClientFactory
+	websocketHandler.DockerClientFactory = server.DockerClientFactory
+	websocketHandler.ExecIdleTimeout = server.WebSocketIdleTimeout
+	websocketHandler.ExecReconnectWindow = server.WebSocketReconnectWindow
+
+	var eventWebhookHandler = eventwebhooks.NewHandler(requestBouncer)
+	eventWebhookHandler.DataStore = server.DataStore
+
+	var cmdbConnectorHandler = cmdbconnectors.NewHandler(requestBouncer)
+	cmdbConnectorHandler.DataStore = server.DataStore
+	cmdbConnectorHandler.CMDBService = server.CMDBService
+
+	var costHandler = costs.NewHandler(requestBouncer)
+	costHandler.DataStore = server.DataStore
+
+	var backgroundJobHandler = backgroundjobs.NewHandler(requestBouncer)
+	backgroundJobHandler.DataStore = server.DataStore
+	backgroundJobHandler.JobQueue = server.JobQueue
+	backgroundJobHandler.ShutdownCtx = server.ShutdownCtx
+
+	imageUpdateService := imageupdates.NewService(server.DataStore, server.Scheduler, eventDispatcher)
+	imageUpdateService.RegisterUpdater(portainer.ImageUpdateContainer, imageupdates.NewContainerUpdater(server.DataStore, server.DockerClientFactory, containerService))
+	imageUpdateService.RegisterUpdater(portainer.ImageUpdateStack, imageupdates.NewStackUpdater(server.DataStore, server.DockerClientFactory, server.StackDeployer))
+	if err := imageUpdateService.Start(); err != nil {
+		log.Error().Err(err).Msg("failed starting the image updates")
+	}
+
+	var imageUpdateHandler = imageupdatehandler.NewHandler(requestBouncer)
+	imageUpdateHandler.DataStore = server.DataStore
+	imageUpdateHandler.ImageUpdateService = imageUpdateService
+
+	volumeBackupService := volumebackups.NewService(server.DataStore, server.Scheduler, server.DockerClientFactory, server.FileService.GetDatastorePath())
+	volumeBackupService.RegisterJobs(server.JobQueue)
+	if err := volumeBackupService.Start(); err != nil {
+		log.Error().Err(err).Msg("failed starting the volume backups")
+	}
+
+	var volumeBackupHandler = volumebackuphandler.NewHandler(requestBouncer)
+	volumeBackupHandler.DataStore = server.DataStore
+	volumeBackupHandler.VolumeBackupService = volumeBackupService
+
+	var automationWebhookHandler = automationwebhooks.NewHandler(requestBouncer)
+	automationWebhookHandler.DataStore = server.DataStore
+	automationWebhookHandler.FileService = server.FileService
+	automationWebhookHandler.StackDeployer = server.StackDeployer
+	automationWebhookHandler.ComposeStackManager = server.ComposeStackManager
+	automationWebhookHandler.DockerClientFactory = server.DockerClientFactory
+	automationWebhookHandler.VolumeBackupService = volumeBackupService
+	automationWebhookHandler.ImageUpdateService = imageUpdateService
+
+	var chatOpsHandler = chatops.NewHandler(requestBouncer)
+	chatOpsHandler.DataStore = server.DataStore
+	chatOpsHandler.DockerClientFactory = server.DockerClientFactory
+
+	var graphQLHandler = graphql.NewHandler(requestBouncer)
+	graphQLHandler.DataStore = server.DataStore
 
 	var webhookHandler = webhooks.NewHandler(requestBouncer)
 	webhookHandler.DataStore = server.DataStore
 	webhookHandler.DockerClientFactory = server.DockerClientFactory
 
 	server.Handler = &handler.Handler{
-		RoleHandler:            roleHandler,
-		AuthHandler:            authHandler,
-		BackupHandler:          backupHandler,
-		CustomTemplatesHandler: customTemplatesHandler,
-		DockerHandler:          dockerHandler,
-		EdgeGroupsHandler:      edgeGroupsHandler,
-		EdgeJobsHandler:        edgeJobsHandler,
-		EdgeStacksHandler:      edgeStacksHandler,
-		EdgeTemplatesHandler:   edgeTemplatesHandler,
-		EndpointGroupHandler:   endpointGroupHandler,
-		EndpointHandler:        endpointHandler,
-		EndpointHelmHandler:    endpointHelmHandler,
-		EndpointEdgeHandler:    endpointEdgeHandler,
-		EndpointProxyHandler:   endpointProxyHandler,
-		GitOperationHandler:    gitOperationHandler,
-		FileHandler:            fileHandler,
-		LDAPHandler:            ldapHandler,
-		HelmTemplatesHandler:   helmTemplatesHandler,
-		KubernetesHandler:      kubernetesHandler,
-		MOTDHandler:            motdHandler,
-		OpenAMTHandler:         openAMTHandler,
-		FDOHandler:             fdoHandler,
-		RegistryHandler:        registryHandler,
-		ResourceControlHandler: resourceControlHandler,
-		SettingsHandler:        settingsHandler,
-		SSLHandler:             sslHandler,
-		StackHandler:           stackHandler,
-		StorybookHandler:       storybookHandler,
-		SystemHandler:          systemHandler,
-		TagHandler:             tagHandler,
-		TeamHandler:            teamHandler,
-		TeamMembershipHandler:  teamMembershipHandler,
-		TemplatesHandler:       templatesHandler,
-		UploadHandler:          uploadHandler,
-		UserHandler:            userHandler,
-		WebSocketHandler:       websocketHandler,
-		WebhookHandler:         webhookHandler,
+		RoleHandler:              roleHandler,
+		AuthHandler:              authHandler,
+		AutomationWebhookHandler: automationWebhookHandler,
+		ChangeRequestHandler:     changeRequestHandler,
+		BackgroundJobHandler:     backgroundJobHandler,
+		BackupHandler:            backupHandler,
+		ChatOpsHandler:           chatOpsHandler,
+		CMDBConnectorHandler:     cmdbConnectorHandler,
+		CostHandler:              costHandler,
+		CustomTemplatesHandler:   customTemplatesHandler,
+		DockerHandler:            dockerHandler,
+		EdgeGroupsHandler:        edgeGroupsHandler,
+		EdgeJobsHandler:          edgeJobsHandler,
+		EdgeStacksHandler:        edgeStacksHandler,
+		EdgeTemplatesHandler:     edgeTemplatesHandler,
+		EndpointGroupHandler:     endpointGroupHandler,
+		EndpointHandler:          endpointHandler,
+		EndpointHelmHandler:      endpointHelmHandler,
+		EndpointEdgeHandler:      endpointEdgeHandler,
+		EventWebhookHandler:      eventWebhookHandler,
+		ImageUpdateHandler:       imageUpdateHandler,
+		EndpointProxyHandler:     endpointProxyHandler,
+		GitOperationHandler:      gitOperationHandler,
+		GraphQLHandler:           graphQLHandler,
+		FileHandler:              fileHandler,
+		LDAPHandler:              ldapHandler,
+		HelmTemplatesHandler:     helmTemplatesHandler,
+		KubernetesHandler:        kubernetesHandler,
+		MOTDHandler:              motdHandler,
+		NoteHandler:              noteHandler,
+		OwnershipRuleHandler:     ownershipRuleHandler,
+		OpenAMTHandler:           openAMTHandler,
+		FDOHandler:               fdoHandler,
+		RegistryHandler:          registryHandler,
+		MaintenanceWindowHandler: maintenanceWindowHandler,
+		ResourceControlHandler:   resourceControlHandler,
+		SavedViewHandler:         savedViewHandler,
+		SecurityAlertHandler:     securityAlertHandler,
+		SettingsHandler:          settingsHandler,
+		SSLHandler:               sslHandler,
+		StackBundleHandler:       stackBundleHandler,
+		StackHandler:             stackHandler,
+		StorybookHandler:         storybookHandler,
+		SystemHandler:            systemHandler,
+		TagHandler:               tagHandler,
+		TeamHandler:              teamHandler,
+		TeamMembershipHandler:    teamMembershipHandler,
+		TemplatesHandler:         templatesHandler,
+		UploadHandler:            uploadHandler,
+		UserHandler:              userHandler,
+		VolumeBackupHandler:      volumeBackupHandler,
+		WebSocketHandler:         websocketHandler,
+		WebhookHandler:           webhookHandler,
 	}
 
 	errorLogger := NewHTTPLogger()
 
 	handler := adminMonitor.WithRedirect(offlineGate.WaitingMiddleware(time.Minute, server.Handler))
 
-	handler = middlewares.WithSlowRequestsLogger(handler)
+	// the policies of the API requests, which also apply to the calls of the gRPC API
+	apiPolicies := func(next http.Handler) http.Handler {
+		return apiUsageTracker.Middleware(rateLimitPolicy.Middleware(authorizationHook.Middleware(next)))
+	}
 
-	if server.HTTPEnabled {
-		go func() {
-			log.Info().Str("bind_address", server.BindAddress).Msg("starting HTTP server")
-			httpServer := &http.Server{
-				Addr:     server.BindAddress,
-				Handler:  handler,
-				ErrorLog: errorLogger,
-			}
+	handler = apiPolicies(handler)
+	handler = corsPolicy.Middleware(handler)
+	handler = securityHeadersPolicy.Middleware(handler)
 
-			go shutdown(server.ShutdownCtx, httpServer)
+	handler = middlewares.WithSlowRequestsLogger(handler)
+	handler = middlewares.WithCompression(handler)
+	handler = middlewares.WithRequestID(handler)
 
-			err := httpServer.ListenAndServe()
-			if err != nil && err != http.ErrServerClosed {
-				log.Error().Err(err).Msg("HTTP server failed to start")
-			}
-		}()
+	var shutdowns sync.WaitGroup
+
+	if server.HTTPEnabled {
+		log.Info().Str("bind_address", server.BindAddress).Msg("starting HTTP server")
+		httpServer := &http.Server{
+			Addr:     server.BindAddress,
+			Handler:  handler,
+			ErrorLog: errorLogger,
+		}
+
+		httpListener, err := server.listen(server.BindAddress)
+		if err != nil {
+			log.Error().Err(err).Msg("HTTP server failed to start")
+		} else {
+			shutdowns.Add(1)
+			go func() {
+				defer shutdowns.Done()
+				shutdown(server.ShutdownCtx, httpServer, server.ShutdownTimeout)
+			}()
+
+			go func() {
+				err := httpServer.Serve(httpListener)
+				if err != nil && err != http.ErrServerClosed {
+					log.Error().Err(err).Msg("HTTP server failed to start")
+				}
+			}()
+		}
 	}
 
 	log.Info().Str("bind_address", server.BindAddressHTTPS).Msg("starting HTTPS server")
@@ -370,18 +613,91 @@ func (server *Server) Start() error {
 		return server.SSLService.GetRawCertificate(), nil
 	}
 
-	go shutdown(server.ShutdownCtx, httpsServer)
+	httpsListener, err := server.listen(server.BindAddressHTTPS)
+	if err != nil {
+		return err
+	}
+
+	shutdowns.Add(1)
+	go func() {
+		defer shutdowns.Done()
+		shutdown(server.ShutdownCtx, httpsServer, server.ShutdownTimeout)
+	}()
+
+	if server.BindAddressGRPC != "" {
+		grpcListener, err := server.listen(server.BindAddressGRPC)
+		if err != nil {
+			return err
+		}
+
+		log.Info().Str("bind_address", server.BindAddressGRPC).Msg("starting gRPC server")
+		grpcServer := grpcapi.NewServer(requestBouncer, apiPolicies, server.DataStore, server.JobQueue).GRPCServer(grpc.Creds(credentials.NewTLS(httpsServer.TLSConfig)))
+
+		shutdowns.Add(1)
+		go func() {
+			defer shutdowns.Done()
+			<-server.ShutdownCtx.Done()
+
+			// the watch streams only end when their clients close them
+			timer := time.AfterFunc(server.ShutdownTimeout, grpcServer.Stop)
+			grpcServer.GracefulStop()
+			timer.Stop()
+		}()
+
+		go func() {
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				log.Error().Err(err).Msg("gRPC server failed to start")
+			}
+		}()
+	}
 
 	go snapshot.NewBackgroundSnapshotter(server.DataStore, server.ReverseTunnelService)
 
-	return httpsServer.ListenAndServeTLS("", "")
+	err = httpsServer.ServeTLS(httpsListener, "", "")
+	if err != http.ErrServerClosed {
+		return err
+	}
+
+	// Serve returns as soon as the shutdown starts, the long-lived websocket connections are not tracked by the
+	// HTTP servers so they are closed here while the in-flight requests are drained
+	websocketHandler.CloseConnections()
+	shutdowns.Wait()
+
+	if err := server.ReverseTunnelService.StopTunnelServer(); err != nil {
+		log.Error().Err(err).Msg("failed to stop the tunnel server")
+	}
+
+	if err := server.DataStore.Close(); err != nil {
+		log.Error().Err(err).Msg("failed to close the database")
+	}
+
+	return err
+}
+
+// listen returns the listener for the address, inherited from the previous process when it was handed over
+func (server *Server) listen(addr string) (net.Listener, error) {
+	listen := func() (net.Listener, error) {
+		return sockets.Listen(server.ListenNetwork, addr, server.UnixSocketOptions)
+	}
+
+	if server.Handoff == nil {
+		return listen()
+	}
+
+	return server.Handoff.Listen(addr, listen)
 }
 
-func shutdown(shutdownCtx context.Context, httpServer *http.Server) {
+// shutdown gracefully shuts the HTTP server down once the shutdown is triggered, waiting up to the timeout for
+// the in-flight requests to complete before closing the remaining connections
+func shutdown(shutdownCtx context.Context, httpServer *http.Server, timeout time.Duration) {
 	<-shutdownCtx.Done()
 
-	log.Debug().Msg("shutting down the HTTP server")
-	shutdownTimeout, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	if timeout == 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	log.Debug().Float64("timeout_seconds", timeout.Seconds()).Msg("shutting down the HTTP server")
+	shutdownTimeout, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	err := httpServer.Shutdown(shutdownTimeout)
@@ -389,5 +705,7 @@ func shutdown(shutdownCtx context.Context, httpServer *http.Server) {
 		log.Error().
 			Err(err).
 			Msg("failed to shut down the HTTP server")
+
+		httpServer.Close()
 	}
 }
@@ -0,0 +1,171 @@
+package imageupdates
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/docker/images"
+	"github.com/portainer/portainer/api/stacks/deployments"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/pkg/errors"
+)
+
+// ContainerUpdater recreates the containers, identified by their name, with the latest digest of their image
+type ContainerUpdater struct {
+	clientFactory    *dockerclient.ClientFactory
+	digestClient     *images.DigestClient
+	containerService *docker.ContainerService
+}
+
+// NewContainerUpdater creates an updater of the containers
+func NewContainerUpdater(dataStore dataservices.DataStore, clientFactory *dockerclient.ClientFactory, containerService *docker.ContainerService) *ContainerUpdater {
+	return &ContainerUpdater{
+		clientFactory:    clientFactory,
+		digestClient:     images.NewClientWithRegistry(images.NewRegistryClient(dataStore), clientFactory),
+		containerService: containerService,
+	}
+}
+
+// Outdated returns whether the registry holds a new digest of the image of the container
+func (updater *ContainerUpdater) Outdated(ctx context.Context, endpoint *portainer.Endpoint, containerName string) (bool, error) {
+	containerID, err := updater.containerID(ctx, endpoint, containerName)
+	if err != nil {
+		return false, err
+	}
+
+	status, err := updater.digestClient.ContainerImageStatus(ctx, containerID, endpoint, "")
+	if err != nil {
+		return false, err
+	}
+
+	return status == images.Outdated, nil
+}
+
+// Update pulls the image of the container and recreates it
+func (updater *ContainerUpdater) Update(ctx context.Context, endpoint *portainer.Endpoint, containerName string, record *portainer.ImageUpdateRecord) error {
+	containerID, err := updater.containerID(ctx, endpoint, containerName)
+	if err != nil {
+		return err
+	}
+
+	record.PreviousContainerID = containerID
+
+	newContainer, err := updater.containerService.Recreate(ctx, endpoint, containerID, true, docker.ContainerPatch{}, "")
+	if err != nil {
+		return err
+	}
+
+	record.ContainerID = newContainer.ID
+
+	updater.containerService.UpdateContainerReferences(containerID, newContainer.ID)
+	images.EvictImageStatus(containerID)
+
+	return nil
+}
+
+func (updater *ContainerUpdater) containerID(ctx context.Context, endpoint *portainer.Endpoint, containerName string) (string, error) {
+	cli, err := updater.clientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to create the Docker client")
+	}
+	defer cli.Close()
+
+	container, err := cli.ContainerInspect(ctx, containerName)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to find the container %s", containerName)
+	}
+
+	return container.ID, nil
+}
+
+// StackUpdater redeploys the Docker stacks, identified by their identifier, with the latest digest of their images
+type StackUpdater struct {
+	dataStore     dataservices.DataStore
+	clientFactory *dockerclient.ClientFactory
+	digestClient  *images.DigestClient
+	stackDeployer deployments.StackDeployer
+}
+
+// NewStackUpdater creates an updater of the Docker stacks
+func NewStackUpdater(dataStore dataservices.DataStore, clientFactory *dockerclient.ClientFactory, stackDeployer deployments.StackDeployer) *StackUpdater {
+	return &StackUpdater{
+		dataStore:     dataStore,
+		clientFactory: clientFactory,
+		digestClient:  images.NewClientWithRegistry(images.NewRegistryClient(dataStore), clientFactory),
+		stackDeployer: stackDeployer,
+	}
+}
+
+// Outdated returns whether the registry holds a new digest of the image of a running container of the stack
+func (updater *StackUpdater) Outdated(ctx context.Context, endpoint *portainer.Endpoint, resourceID string) (bool, error) {
+	stack, err := updater.stack(resourceID)
+	if err != nil {
+		return false, err
+	}
+
+	label := consts.ComposeStackNameLabel
+	if stack.Type == portainer.DockerSwarmStack {
+		label = consts.SwarmStackNameLabel
+	}
+
+	cli, err := updater.clientFactory.CreateClient(endpoint, "", nil)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to create the Docker client")
+	}
+	defer cli.Close()
+
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", label+"="+stack.Name)),
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "unable to list the containers of the stack %s", stack.Name)
+	}
+
+	if len(containers) == 0 {
+		return false, nil
+	}
+
+	return updater.digestClient.ContainersImageStatus(ctx, containers, endpoint) == images.Outdated, nil
+}
+
+// Update pulls the images of the stack and redeploys it
+func (updater *StackUpdater) Update(ctx context.Context, endpoint *portainer.Endpoint, resourceID string, record *portainer.ImageUpdateRecord) error {
+	stack, err := updater.stack(resourceID)
+	if err != nil {
+		return err
+	}
+
+	if err := deployments.RedeployWithLatestImages(stack.ID, updater.stackDeployer, updater.dataStore); err != nil {
+		return err
+	}
+
+	images.EvictImageStatus(stack.Name)
+
+	return nil
+}
+
+func (updater *StackUpdater) stack(resourceID string) (*portainer.Stack, error) {
+	stackID, err := strconv.Atoi(resourceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stack identifier %q", resourceID)
+	}
+
+	stack, err := updater.dataStore.Stack().Read(portainer.StackID(stackID))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to find the stack %d", stackID)
+	}
+
+	if stack.Type != portainer.DockerComposeStack && stack.Type != portainer.DockerSwarmStack {
+		return nil, fmt.Errorf("the images of the stack %d cannot be updated, only the Docker stacks are supported", stackID)
+	}
+
+	return stack, nil
+}
@@ -0,0 +1,236 @@
+// Package imageupdates recreates the containers and redeploys the stacks opted in to the automatic image updates
+// when a new digest of their images is pushed to the registry
+package imageupdates
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/lifecycle"
+	"github.com/portainer/portainer/api/maintenance"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// minInterval is the shortest interval between two checks of the registry, to stay below the registries rate limits
+const minInterval = time.Minute
+
+// EventPublisher publishes the lifecycle events notifying the image updates
+type EventPublisher interface {
+	Publish(event lifecycle.Event)
+}
+
+// Updater checks the images of the resources of a type and updates them
+type Updater interface {
+	// Outdated returns whether a new digest of an image of the resource is available in its registry
+	Outdated(ctx context.Context, endpoint *portainer.Endpoint, resourceID string) (bool, error)
+	// Update recreates or redeploys the resource with the latest images, the identifiers of the container before
+	// and after the update are recorded for the containers
+	Update(ctx context.Context, endpoint *portainer.Endpoint, resourceID string, record *portainer.ImageUpdateRecord) error
+}
+
+// Service schedules a job per enabled image update policy, checking the registry at the interval of the policy
+type Service struct {
+	dataStore dataservices.DataStore
+	scheduler *scheduler.Scheduler
+	publisher EventPublisher
+	updaters  map[portainer.ImageUpdateResourceType]Updater
+
+	mu   sync.Mutex
+	jobs map[portainer.ImageUpdatePolicyID]string
+}
+
+// NewService creates the image update service, the updaters of the containers and the stacks are registered with
+// RegisterUpdater
+func NewService(dataStore dataservices.DataStore, scheduler *scheduler.Scheduler, publisher EventPublisher) *Service {
+	return &Service{
+		dataStore: dataStore,
+		scheduler: scheduler,
+		publisher: publisher,
+		updaters:  map[portainer.ImageUpdateResourceType]Updater{},
+		jobs:      map[portainer.ImageUpdatePolicyID]string{},
+	}
+}
+
+// RegisterUpdater sets the updater of the resources of a type
+func (service *Service) RegisterUpdater(resourceType portainer.ImageUpdateResourceType, updater Updater) {
+	service.updaters[resourceType] = updater
+}
+
+// Start schedules the enabled image update policies stored in the database
+func (service *Service) Start() error {
+	policies, err := service.dataStore.ImageUpdatePolicy().ReadAll()
+	if err != nil {
+		return errors.WithMessage(err, "unable to retrieve the image update policies")
+	}
+
+	for i := range policies {
+		if err := service.Schedule(&policies[i]); err != nil {
+			log.Warn().Err(err).Int("policy_id", int(policies[i].ID)).Msg("unable to schedule the image update policy")
+		}
+	}
+
+	return nil
+}
+
+// Schedule replaces the job of the policy with one matching its interval, the disabled policies are not scheduled
+func (service *Service) Schedule(policy *portainer.ImageUpdatePolicy) error {
+	service.Unschedule(policy.ID)
+
+	if !policy.Enabled {
+		return nil
+	}
+
+	interval, err := ParseInterval(policy.Interval)
+	if err != nil {
+		return err
+	}
+
+	policyID := policy.ID
+	endpointID := policy.EndpointID
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	service.jobs[policyID] = service.scheduler.StartJobEvery(interval, func() error {
+		allowed, err := maintenance.Allowed(service.dataStore, endpointID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if !allowed {
+			log.Debug().Int("policy_id", int(policyID)).Msg("outside of the maintenance windows of the environment, skipping the image update check")
+			return nil
+		}
+
+		_, err = service.Check(context.Background(), policyID)
+		return err
+	})
+
+	return nil
+}
+
+// Unschedule stops the job of the policy
+func (service *Service) Unschedule(policyID portainer.ImageUpdatePolicyID) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	jobID, ok := service.jobs[policyID]
+	if !ok {
+		return
+	}
+
+	delete(service.jobs, policyID)
+
+	if err := service.scheduler.StopJob(jobID); err != nil {
+		log.Warn().Err(err).Int("policy_id", int(policyID)).Msg("unable to stop the image update job")
+	}
+}
+
+// Check updates the resource of the policy when its images are outdated. The update is recorded in the history of
+// the policy and notified with a lifecycle event, no record is returned when the images are up to date.
+func (service *Service) Check(ctx context.Context, policyID portainer.ImageUpdatePolicyID) (*portainer.ImageUpdateRecord, error) {
+	policy, err := service.dataStore.ImageUpdatePolicy().Read(policyID)
+	if dataservices.IsErrObjectNotFound(err) {
+		return nil, scheduler.NewPermanentError(errors.WithMessagef(err, "failed to get the image update policy %d", policyID))
+	} else if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get the image update policy %d", policyID)
+	}
+
+	updater, ok := service.updaters[policy.ResourceType]
+	if !ok {
+		return nil, scheduler.NewPermanentError(fmt.Errorf("unsupported resource type %q for the image update policy %d", policy.ResourceType, policyID))
+	}
+
+	endpoint, err := service.dataStore.Endpoint().Endpoint(policy.EndpointID)
+	if dataservices.IsErrObjectNotFound(err) {
+		return nil, scheduler.NewPermanentError(errors.WithMessagef(err, "failed to find the environment %d of the image update policy %d", policy.EndpointID, policyID))
+	} else if err != nil {
+		return nil, errors.WithMessagef(err, "failed to find the environment %d of the image update policy %d", policy.EndpointID, policyID)
+	}
+
+	outdated, err := updater.Outdated(ctx, endpoint, policy.ResourceID)
+
+	policy.LastCheck = time.Now().Unix()
+	if err := service.dataStore.ImageUpdatePolicy().Update(policy.ID, policy); err != nil {
+		log.Warn().Err(err).Int("policy_id", int(policy.ID)).Msg("unable to record the last check of the image update policy")
+	}
+
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to check the images of the %s %s", policy.ResourceType, policy.ResourceID)
+	}
+
+	if !outdated {
+		return nil, nil
+	}
+
+	log.Info().
+		Int("policy_id", int(policy.ID)).
+		Str("resource_type", string(policy.ResourceType)).
+		Str("resource_id", policy.ResourceID).
+		Msg("new image digest available, updating")
+
+	record := &portainer.ImageUpdateRecord{
+		PolicyID:  policy.ID,
+		Timestamp: time.Now().Unix(),
+	}
+
+	if err := updater.Update(ctx, endpoint, policy.ResourceID, record); err != nil {
+		record.Error = err.Error()
+	} else {
+		record.Success = true
+	}
+
+	if err := service.dataStore.ImageUpdatePolicy().CreateRecord(record); err != nil {
+		log.Error().Err(err).Int("policy_id", int(policy.ID)).Msg("unable to record the image update")
+	}
+
+	service.notify(policy, record)
+
+	return record, nil
+}
+
+func (service *Service) notify(policy *portainer.ImageUpdatePolicy, record *portainer.ImageUpdateRecord) {
+	if service.publisher == nil {
+		return
+	}
+
+	eventType := lifecycle.ImageUpdated
+	data := map[string]string{
+		"policyId":     strconv.Itoa(int(policy.ID)),
+		"endpointId":   strconv.Itoa(int(policy.EndpointID)),
+		"resourceType": string(policy.ResourceType),
+	}
+
+	if record.ContainerID != "" {
+		data["containerId"] = record.ContainerID
+	}
+
+	if !record.Success {
+		eventType = lifecycle.ImageUpdateFail
+		data["error"] = record.Error
+	}
+
+	service.publisher.Publish(lifecycle.NewEvent(eventType, policy.ResourceID, data))
+}
+
+// ParseInterval parses the interval of an image update policy
+func ParseInterval(interval string) (time.Duration, error) {
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid image update interval %q", interval)
+	}
+
+	if d < minInterval {
+		return 0, fmt.Errorf("invalid image update interval %q, the minimum is %s", interval, minInterval)
+	}
+
+	return d, nil
+}
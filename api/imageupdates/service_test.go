@@ -0,0 +1,135 @@
+package imageupdates
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/lifecycle"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testUpdater struct {
+	outdated  bool
+	updateErr error
+	updated   []string
+}
+
+func (updater *testUpdater) Outdated(ctx context.Context, endpoint *portainer.Endpoint, resourceID string) (bool, error) {
+	return updater.outdated, nil
+}
+
+func (updater *testUpdater) Update(ctx context.Context, endpoint *portainer.Endpoint, resourceID string, record *portainer.ImageUpdateRecord) error {
+	updater.updated = append(updater.updated, resourceID)
+	record.PreviousContainerID = "old"
+	record.ContainerID = "new"
+
+	return updater.updateErr
+}
+
+type testPublisher struct {
+	events []lifecycle.Event
+}
+
+func (publisher *testPublisher) Publish(event lifecycle.Event) {
+	publisher.events = append(publisher.events, event)
+}
+
+func Test_Check(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Type: portainer.DockerEnvironment}))
+
+	policy := &portainer.ImageUpdatePolicy{EndpointID: 1, ResourceType: portainer.ImageUpdateContainer, ResourceID: "web", Interval: "1h", Enabled: true}
+	is.NoError(store.ImageUpdatePolicy().Create(policy))
+
+	updater := &testUpdater{}
+	publisher := &testPublisher{}
+	service := NewService(store, nil, publisher)
+	service.RegisterUpdater(portainer.ImageUpdateContainer, updater)
+
+	record, err := service.Check(context.Background(), policy.ID)
+	is.NoError(err)
+	is.Nil(record, "the resource is not updated when its images are up to date")
+	is.Empty(updater.updated)
+
+	policy, err = store.ImageUpdatePolicy().Read(policy.ID)
+	is.NoError(err)
+	is.NotZero(policy.LastCheck)
+
+	updater.outdated = true
+	record, err = service.Check(context.Background(), policy.ID)
+	is.NoError(err)
+	is.True(record.Success)
+	is.Equal([]string{"web"}, updater.updated)
+
+	updater.updateErr = errors.New("pull access denied")
+	record, err = service.Check(context.Background(), policy.ID)
+	is.NoError(err)
+	is.False(record.Success)
+	is.Equal("pull access denied", record.Error)
+
+	history, err := store.ImageUpdatePolicy().History(policy.ID)
+	is.NoError(err)
+	is.Len(history, 2)
+	is.False(history[0].Success, "the most recent update is listed first")
+	is.Equal("new", history[1].ContainerID)
+
+	is.Len(publisher.events, 2)
+	is.Equal(lifecycle.ImageUpdated, publisher.events[0].Type)
+	is.Equal("web", publisher.events[0].ResourceID)
+	is.Equal("new", publisher.events[0].Data["containerId"])
+	is.Equal(lifecycle.ImageUpdateFail, publisher.events[1].Type)
+	is.Equal("pull access denied", publisher.events[1].Data["error"])
+
+	is.NoError(store.ImageUpdatePolicy().Delete(policy.ID))
+	history, err = store.ImageUpdatePolicy().History(policy.ID)
+	is.NoError(err)
+	is.Empty(history, "the history is removed with the policy")
+
+	_, err = service.Check(context.Background(), policy.ID)
+	var permErr *scheduler.PermanentError
+	is.ErrorAs(err, &permErr, "the job of a removed policy is stopped")
+}
+
+func Test_Schedule(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	service := NewService(store, scheduler.NewScheduler(ctx), nil)
+
+	policy := &portainer.ImageUpdatePolicy{ID: 1, Interval: "1h", Enabled: true}
+	is.NoError(service.Schedule(policy))
+	is.Len(service.jobs, 1)
+
+	policy.Enabled = false
+	is.NoError(service.Schedule(policy))
+	is.Empty(service.jobs, "the disabled policies are not scheduled")
+
+	policy.Enabled = true
+	policy.Interval = "10s"
+	is.Error(service.Schedule(policy))
+	is.Empty(service.jobs)
+}
+
+func Test_ParseInterval(t *testing.T) {
+	is := assert.New(t)
+
+	_, err := ParseInterval("1h30m")
+	is.NoError(err)
+
+	_, err = ParseInterval("30s")
+	is.Error(err)
+
+	_, err = ParseInterval("daily")
+	is.Error(err)
+}
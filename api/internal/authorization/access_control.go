@@ -2,9 +2,14 @@ package authorization
 
 import (
 	"strconv"
+	"strings"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker/consts"
 	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/rs/zerolog/log"
 )
 
 // NewAdministratorsOnlyResourceControl will create a new administrators only resource control associated to the resource specified by the
@@ -201,3 +206,106 @@ func GetResourceControlByResourceIDAndType(resourceID string, resourceType porta
 
 	return nil
 }
+
+// UserCanAccessDockerResource verifies that a user can access a Docker resource of an environment. It follows the
+// resource control of the resource, then the ones of its Swarm service and of its stack found in its labels, as the
+// Docker proxy does.
+func UserCanAccessDockerResource(userID portainer.UserID, userTeamIDs []portainer.TeamID, endpointID portainer.EndpointID, resourceID string, resourceType portainer.ResourceControlType, labels map[string]string, resourceControls []portainer.ResourceControl) bool {
+	return UserCanAccessResource(userID, userTeamIDs, DockerResourceControl(endpointID, resourceID, resourceType, labels, resourceControls))
+}
+
+// DockerResourceControl returns the resource control applying to a Docker resource of an environment: its own
+// resource control, or the one of its Swarm service or of its stack found in its labels. It returns nil when no
+// resource control applies to the resource.
+func DockerResourceControl(endpointID portainer.EndpointID, resourceID string, resourceType portainer.ResourceControlType, labels map[string]string, resourceControls []portainer.ResourceControl) *portainer.ResourceControl {
+	resourceControl := GetResourceControlByResourceIDAndType(resourceID, resourceType, resourceControls)
+
+	if resourceControl == nil && labels[consts.SwarmServiceIdLabel] != "" {
+		resourceControl = GetResourceControlByResourceIDAndType(labels[consts.SwarmServiceIdLabel], portainer.ServiceResourceControl, resourceControls)
+	}
+
+	for _, stackLabel := range []string{consts.SwarmStackNameLabel, consts.ComposeStackNameLabel} {
+		if resourceControl == nil && labels[stackLabel] != "" {
+			resourceControl = GetResourceControlByResourceIDAndType(stackutils.ResourceControlID(endpointID, labels[stackLabel]), portainer.StackResourceControl, resourceControls)
+		}
+	}
+
+	return resourceControl
+}
+
+// IsAccessControlLabel returns true when the label sets the ownership of a Docker resource
+func IsAccessControlLabel(name string) bool {
+	switch name {
+	case consts.AccessControlPublicLabel, consts.AccessControlTeamsLabel, consts.AccessControlUsersLabel:
+		return true
+	}
+
+	return false
+}
+
+// ResourceControlFromLabels builds the resource control described by the ownership labels of a Docker resource, the
+// unknown user and team names being ignored. It returns nil when the labels do not set the ownership of the resource.
+// The resource control is not persisted.
+func ResourceControlFromLabels(tx dataservices.DataStoreTx, labels map[string]string, resourceID string, resourceType portainer.ResourceControlType) *portainer.ResourceControl {
+	if _, ok := labels[consts.AccessControlPublicLabel]; ok {
+		return NewPublicResourceControl(resourceID, resourceType)
+	}
+
+	teamNames := getUniqueElements(labels[consts.AccessControlTeamsLabel])
+	userNames := getUniqueElements(labels[consts.AccessControlUsersLabel])
+
+	if len(teamNames) == 0 && len(userNames) == 0 {
+		return nil
+	}
+
+	teamIDs := make([]portainer.TeamID, 0)
+	userIDs := make([]portainer.UserID, 0)
+
+	for _, name := range teamNames {
+		team, err := tx.Team().TeamByName(name)
+		if err != nil {
+			log.Warn().
+				Str("name", name).
+				Str("resource_id", resourceID).
+				Msg("unknown team name in access control label, ignoring access control rule for this team")
+
+			continue
+		}
+
+		teamIDs = append(teamIDs, team.ID)
+	}
+
+	for _, name := range userNames {
+		user, err := tx.User().UserByUsername(name)
+		if err != nil {
+			log.Warn().
+				Str("name", name).
+				Str("resource_id", resourceID).
+				Msg("unknown user name in access control label, ignoring access control rule for this user")
+
+			continue
+		}
+
+		userIDs = append(userIDs, user.ID)
+	}
+
+	return NewRestrictedResourceControl(resourceID, resourceType, userIDs, teamIDs)
+}
+
+// getUniqueElements splits the comma separated values of a label, dropping the empty and the duplicated values
+func getUniqueElements(value string) []string {
+	result := []string{}
+	seen := make(map[string]struct{})
+	for _, item := range strings.Split(value, ",") {
+		v := strings.TrimSpace(item)
+		if v == "" {
+			continue
+		}
+		if _, ok := seen[v]; !ok {
+			result = append(result, v)
+			seen[v] = struct{}{}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,70 @@
+package authorization
+
+import (
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+const (
+	// SettingsSectionAuthentication covers the authentication method, the LDAP and OAuth settings and the sessions
+	SettingsSectionAuthentication = "authentication"
+	// SettingsSectionNotifications covers the messages displayed to the users and the forwarding of the logs
+	SettingsSectionNotifications = "notifications"
+	// SettingsSectionSnapshots covers the snapshots of the environments
+	SettingsSectionSnapshots = "snapshots"
+	// SettingsSectionEdge covers the Edge compute features and the Edge agents
+	SettingsSectionEdge = "edge"
+)
+
+// SettingsSections maps the settings sections which can be delegated to the authorization of the custom roles
+var SettingsSections = map[string]portainer.Authorization{
+	SettingsSectionAuthentication: portainer.OperationPortainerSettingsAuthentication,
+	SettingsSectionNotifications:  portainer.OperationPortainerSettingsNotifications,
+	SettingsSectionSnapshots:      portainer.OperationPortainerSettingsSnapshots,
+	SettingsSectionEdge:           portainer.OperationPortainerSettingsEdge,
+}
+
+// DelegatedSettingsSections returns the settings sections delegated to a user through the custom roles assigned to
+// the user or to the teams of the user
+func DelegatedSettingsSections(tx dataservices.DataStoreTx, userID portainer.UserID) (map[string]bool, error) {
+	roles, err := tx.Role().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := tx.TeamMembership().TeamMembershipsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := map[string]bool{}
+	for _, role := range roles {
+		if !role.Custom || !isAssigned(role, userID, memberships) {
+			continue
+		}
+
+		for section, authorization := range SettingsSections {
+			if role.Authorizations[authorization] {
+				sections[section] = true
+			}
+		}
+	}
+
+	return sections, nil
+}
+
+func isAssigned(role portainer.Role, userID portainer.UserID, memberships []portainer.TeamMembership) bool {
+	if slices.Contains(role.UserIDs, userID) {
+		return true
+	}
+
+	for _, membership := range memberships {
+		if slices.Contains(role.TeamIDs, membership.TeamID) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,50 @@
+package authorization_test
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/internal/authorization"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelegatedSettingsSections(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	assert.NoError(t, store.TeamMembership().Create(&portainer.TeamMembership{UserID: 2, TeamID: 1}))
+
+	roles := []portainer.Role{
+		{
+			Name:           "Security team",
+			Custom:         true,
+			Authorizations: portainer.Authorizations{portainer.OperationPortainerSettingsAuthentication: true},
+			TeamIDs:        []portainer.TeamID{1},
+		},
+		{
+			Name:           "Edge operators",
+			Custom:         true,
+			Authorizations: portainer.Authorizations{portainer.OperationPortainerSettingsEdge: true},
+			UserIDs:        []portainer.UserID{3},
+		},
+		{
+			// only the custom roles delegate the settings sections
+			Name:           "Predefined",
+			Authorizations: portainer.Authorizations{portainer.OperationPortainerSettingsSnapshots: true},
+			UserIDs:        []portainer.UserID{2},
+		},
+	}
+
+	for i := range roles {
+		assert.NoError(t, store.Role().Create(&roles[i]))
+	}
+
+	sections, err := authorization.DelegatedSettingsSections(store, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{authorization.SettingsSectionAuthentication: true}, sections)
+
+	sections, err = authorization.DelegatedSettingsSections(store, 4)
+	assert.NoError(t, err)
+	assert.Empty(t, sections)
+}
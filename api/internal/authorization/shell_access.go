@@ -0,0 +1,245 @@
+package authorization
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// ShellAccessReasonHeader is the header carrying the reason of the access when creating an exec instance
+const ShellAccessReasonHeader = "X-Portainer-Shell-Access-Reason"
+
+var (
+	// ErrShellAccessReasonRequired is returned when a shell is opened without the reason required by the policy
+	ErrShellAccessReasonRequired = errors.New("a reason is required to open a shell in the containers of this environment")
+	// ErrShellAccessRootDenied is returned when a shell is opened as root while denied by the policy
+	ErrShellAccessRootDenied = errors.New("opening a shell as root is denied in the containers of this environment")
+)
+
+// ValidateShellAccessPolicy validates the rules of a shell access policy
+func ValidateShellAccessPolicy(policy portainer.ShellAccessPolicy) error {
+	for _, rule := range policy.Rules {
+		for _, pattern := range append(append([]string{}, rule.DeniedCommands...), rule.AllowedCommands...) {
+			if strings.TrimSpace(pattern) == "" {
+				return errors.New("invalid shell access policy, the command patterns must not be empty")
+			}
+		}
+
+		if !rule.DenyRoot && !rule.RequireReason && len(rule.DeniedCommands) == 0 && len(rule.AllowedCommands) == 0 {
+			return errors.New("invalid shell access policy, each rule must restrict the commands, the root user or require a reason")
+		}
+	}
+
+	return nil
+}
+
+// ShellAccessRules returns the rules of the shell access policy applying to a user on an environment
+func ShellAccessRules(tx dataservices.DataStoreTx, tokenData *portainer.TokenData, endpoint *portainer.Endpoint) ([]portainer.ShellAccessRule, error) {
+	settings, err := tx.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(settings.ShellAccessPolicy.Rules) == 0 {
+		return nil, nil
+	}
+
+	roleID, err := endpointRoleID(tx, tokenData, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []portainer.ShellAccessRule
+	for _, rule := range settings.ShellAccessPolicy.Rules {
+		if len(rule.EndpointGroupIDs) > 0 && !slices.Contains(rule.EndpointGroupIDs, endpoint.GroupID) {
+			continue
+		}
+
+		if len(rule.RoleIDs) > 0 && (roleID == 0 || !slices.Contains(rule.RoleIDs, roleID)) {
+			continue
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// ShellAccessRequiresRoot returns whether one of the rules denies the shells opened as root
+func ShellAccessRequiresRoot(rules []portainer.ShellAccessRule) bool {
+	return slices.ContainsFunc(rules, func(rule portainer.ShellAccessRule) bool {
+		return rule.DenyRoot
+	})
+}
+
+// CheckShellAccess verifies a shell can be opened with the command, as root or not, and the reason provided
+func CheckShellAccess(rules []portainer.ShellAccessRule, command []string, root bool, reason string) error {
+	for _, rule := range rules {
+		if rule.RequireReason && strings.TrimSpace(reason) == "" {
+			return ErrShellAccessReasonRequired
+		}
+
+		if rule.DenyRoot && root {
+			return ErrShellAccessRootDenied
+		}
+
+		if len(command) == 0 {
+			continue
+		}
+
+		for _, pattern := range rule.DeniedCommands {
+			if slices.ContainsFunc(append([][]string{command}, shellScriptCommands(command)...), func(command []string) bool {
+				return MatchShellCommand(pattern, command)
+			}) {
+				return fmt.Errorf("the command %q is denied in the containers of this environment", strings.Join(command, " "))
+			}
+		}
+
+		if len(rule.AllowedCommands) > 0 && !slices.ContainsFunc(rule.AllowedCommands, func(pattern string) bool {
+			return MatchShellCommand(pattern, command)
+		}) {
+			return fmt.Errorf("the command %q is not allowed in the containers of this environment", strings.Join(command, " "))
+		}
+	}
+
+	return nil
+}
+
+// MatchShellCommand returns whether a command matches a pattern of the shell access policy. A pattern without
+// space is matched against the executable, otherwise against the whole command line, * matching any sequence of
+// characters
+func MatchShellCommand(pattern string, command []string) bool {
+	subject := path.Base(command[0])
+	if strings.Contains(pattern, " ") {
+		subject = strings.Join(append([]string{subject}, command[1:]...), " ")
+	}
+
+	expression := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+	matched, _ := regexp.MatchString(expression, subject)
+
+	return matched
+}
+
+// shellScriptCommands returns the commands of the script run by a shell with the -c option, such as sh -c "rm -rf
+// /data; ls", so that the denied commands cannot be run through a shell. The script is split on the separators of
+// the shell commands and on the quotes, a quoted string being matched as a command. It is a best effort: the
+// commands built from variables or run by eval, by another interpreter or from an interactive shell are not matched.
+func shellScriptCommands(command []string) [][]string {
+	if len(command) < 2 || !slices.Contains(shells, path.Base(command[0])) {
+		return nil
+	}
+
+	var script string
+	scriptOption := false
+
+	for _, arg := range command[1:] {
+		if strings.HasPrefix(arg, "-") && arg != "-" && arg != "--" {
+			scriptOption = scriptOption || strings.Contains(arg[1:], "c")
+			continue
+		}
+
+		if scriptOption {
+			script = arg
+		}
+
+		break
+	}
+
+	var commands [][]string
+	for _, line := range strings.FieldsFunc(script, isShellSeparator) {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			commands = append(commands, fields)
+			commands = append(commands, shellScriptCommands(fields)...)
+		}
+	}
+
+	return commands
+}
+
+// shells are the executables whose -c option runs a script
+var shells = []string{"sh", "bash", "ash", "dash", "zsh", "ksh"}
+
+func isShellSeparator(r rune) bool {
+	return strings.ContainsRune(";&|\n()`{}$\"'", r)
+}
+
+// IsRootUser returns whether the user of a process, as specified in the Docker API, is root
+func IsRootUser(user string) bool {
+	name, _, _ := strings.Cut(user, ":")
+
+	return name == "" || name == "root" || name == "0"
+}
+
+// endpointRoleID returns the role of the user on the environment, following the precedence of the access policies,
+// or 0 when the user has no role, e.g. the administrators
+func endpointRoleID(tx dataservices.DataStoreTx, tokenData *portainer.TokenData, endpoint *portainer.Endpoint) (portainer.RoleID, error) {
+	if tokenData.Role == portainer.AdministratorRole {
+		return 0, nil
+	}
+
+	if policy, ok := endpoint.UserAccessPolicies[tokenData.ID]; ok {
+		return policy.RoleID, nil
+	}
+
+	group, err := tx.EndpointGroup().Read(endpoint.GroupID)
+	if err != nil && !tx.IsErrObjectNotFound(err) {
+		return 0, err
+	}
+
+	if group != nil {
+		if policy, ok := group.UserAccessPolicies[tokenData.ID]; ok {
+			return policy.RoleID, nil
+		}
+	}
+
+	memberships, err := tx.TeamMembership().TeamMembershipsByUserID(tokenData.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	roles, err := tx.Role().ReadAll()
+	if err != nil {
+		return 0, err
+	}
+
+	teamRole := func(policies portainer.TeamAccessPolicies) portainer.RoleID {
+		var roleID portainer.RoleID
+		priority := 0
+
+		for _, membership := range memberships {
+			policy, ok := policies[membership.TeamID]
+			if !ok {
+				continue
+			}
+
+			if roleID == 0 {
+				roleID = policy.RoleID
+			}
+
+			for _, role := range roles {
+				if role.ID == policy.RoleID && role.Priority > priority {
+					roleID = role.ID
+					priority = role.Priority
+				}
+			}
+		}
+
+		return roleID
+	}
+
+	if roleID := teamRole(endpoint.TeamAccessPolicies); roleID != 0 {
+		return roleID, nil
+	}
+
+	if group != nil {
+		return teamRole(group.TeamAccessPolicies), nil
+	}
+
+	return 0, nil
+}
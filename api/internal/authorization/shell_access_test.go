@@ -0,0 +1,106 @@
+package authorization_test
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/internal/authorization"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchShellCommand(t *testing.T) {
+	tests := []struct {
+		pattern string
+		command []string
+		match   bool
+	}{
+		{"bash", []string{"/bin/bash"}, true},
+		{"bash", []string{"bash", "-l"}, true},
+		{"ba*", []string{"bash"}, true},
+		{"sh", []string{"bash"}, false},
+		{"rm *", []string{"rm", "-rf", "/data"}, true},
+		{"rm *", []string{"/bin/rm", "/tmp/file"}, true},
+		{"rm *", []string{"ls", "rm"}, false},
+		{"psql -U postgres", []string{"psql", "-U", "app"}, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, authorization.MatchShellCommand(tt.pattern, tt.command), "%s %v", tt.pattern, tt.command)
+	}
+}
+
+func TestCheckShellAccess(t *testing.T) {
+	rules := []portainer.ShellAccessRule{
+		{DeniedCommands: []string{"rm *"}},
+		{RequireReason: true, DenyRoot: true},
+	}
+
+	assert.NoError(t, authorization.CheckShellAccess(rules, []string{"sh"}, false, "INC-42"))
+	assert.ErrorIs(t, authorization.CheckShellAccess(rules, []string{"sh"}, false, " "), authorization.ErrShellAccessReasonRequired)
+	assert.ErrorIs(t, authorization.CheckShellAccess(rules, []string{"sh"}, true, "INC-42"), authorization.ErrShellAccessRootDenied)
+	assert.Error(t, authorization.CheckShellAccess(rules, []string{"rm", "-rf", "/"}, false, "INC-42"))
+
+	// the denied commands are matched in the scripts run by a shell
+	for _, command := range [][]string{
+		{"sh", "-c", "rm -rf /data"},
+		{"/bin/bash", "-ec", "ls; rm -rf /data"},
+		{"sh", "-c", "echo $(rm -rf /data)"},
+		{"sh", "-c", "bash -c 'rm -rf /data'"},
+		{"sh", "-c", "sh -c true && rm -rf /data"},
+	} {
+		assert.Error(t, authorization.CheckShellAccess(rules, command, false, "INC-42"), "%v", command)
+	}
+
+	assert.NoError(t, authorization.CheckShellAccess(rules, []string{"sh", "-c", "ls -l /data"}, false, "INC-42"))
+	assert.NoError(t, authorization.CheckShellAccess(rules, []string{"sh", "script.sh", "-c", "rm -rf /data"}, false, "INC-42"), "the arguments of a script are not a script")
+}
+
+func TestIsRootUser(t *testing.T) {
+	assert.True(t, authorization.IsRootUser(""))
+	assert.True(t, authorization.IsRootUser("root"))
+	assert.True(t, authorization.IsRootUser("0:0"))
+	assert.False(t, authorization.IsRootUser("1000"))
+	assert.False(t, authorization.IsRootUser("app:root"))
+}
+
+func TestShellAccessRules(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, true)
+
+	settings, err := store.Settings().Settings()
+	assert.NoError(t, err)
+	settings.ShellAccessPolicy.Rules = []portainer.ShellAccessRule{
+		{RequireReason: true},
+		{RoleIDs: []portainer.RoleID{3}, DenyRoot: true},
+		{EndpointGroupIDs: []portainer.EndpointGroupID{2}, DeniedCommands: []string{"bash"}},
+	}
+	assert.NoError(t, store.Settings().UpdateSettings(settings))
+
+	assert.NoError(t, store.TeamMembership().Create(&portainer.TeamMembership{UserID: 3, TeamID: 1}))
+
+	endpoint := &portainer.Endpoint{
+		ID:                 1,
+		GroupID:            1,
+		UserAccessPolicies: portainer.UserAccessPolicies{2: {RoleID: 4}},
+		TeamAccessPolicies: portainer.TeamAccessPolicies{1: {RoleID: 3}},
+	}
+
+	rules, err := authorization.ShellAccessRules(store, &portainer.TokenData{ID: 1, Role: portainer.AdministratorRole}, endpoint)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 1, "only the rules without roles apply to the administrators")
+
+	rules, err = authorization.ShellAccessRules(store, &portainer.TokenData{ID: 2, Role: portainer.StandardUserRole}, endpoint)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 1)
+
+	rules, err = authorization.ShellAccessRules(store, &portainer.TokenData{ID: 3, Role: portainer.StandardUserRole}, endpoint)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2, "the role of the team of the user applies")
+	assert.True(t, authorization.ShellAccessRequiresRoot(rules))
+
+	endpoint.GroupID = 2
+	rules, err = authorization.ShellAccessRules(store, &portainer.TokenData{ID: 2, Role: portainer.StandardUserRole}, endpoint)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2, "the rules of the group of the environment apply")
+}
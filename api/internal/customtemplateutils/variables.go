@@ -0,0 +1,100 @@
+package customtemplateutils
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	variableNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// placeholderRe matches the {{ .name }} placeholders. The placeholders using a path, such as the {{.Node.ID}}
+	// templates of the Swarm services, are not matched.
+	placeholderRe = regexp.MustCompile(`\{\{\s*\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+)
+
+// ValidateVariableDefinitions checks the variables declared by a custom template
+func ValidateVariableDefinitions(variables []portainer.CustomTemplateVariableDefinition) error {
+	names := make(map[string]bool, len(variables))
+
+	for _, variable := range variables {
+		if variable.Name == "" {
+			return errors.New("variable name is required")
+		}
+
+		if !variableNameRe.MatchString(variable.Name) {
+			return fmt.Errorf("invalid variable name %q, it must only contain letters, digits and underscores and not start with a digit", variable.Name)
+		}
+
+		if names[variable.Name] {
+			return fmt.Errorf("variable %q is declared more than once", variable.Name)
+		}
+		names[variable.Name] = true
+
+		if variable.Label == "" {
+			return errors.New("variable label is required")
+		}
+
+		if variable.DefaultValue != "" && len(variable.Options) > 0 && !slices.Contains(variable.Options, variable.DefaultValue) {
+			return fmt.Errorf("the default value of the variable %q is not one of its options", variable.Name)
+		}
+	}
+
+	return nil
+}
+
+// ResolveVariables returns the value of each variable declared by a custom template, taken from the provided
+// values or from the default values of the variables. The values are validated against the declarations.
+func ResolveVariables(variables []portainer.CustomTemplateVariableDefinition, values map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(variables))
+
+	for name := range values {
+		if !slices.ContainsFunc(variables, func(variable portainer.CustomTemplateVariableDefinition) bool {
+			return variable.Name == name
+		}) {
+			return nil, fmt.Errorf("the variable %q is not declared by the template", name)
+		}
+	}
+
+	for _, variable := range variables {
+		value := values[variable.Name]
+		if value == "" {
+			value = variable.DefaultValue
+		}
+
+		if value == "" {
+			if variable.Required {
+				return nil, fmt.Errorf("a value is required for the variable %q", variable.Name)
+			}
+		} else if len(variable.Options) > 0 && !slices.Contains(variable.Options, value) {
+			return nil, fmt.Errorf("the value of the variable %q must be one of %v", variable.Name, variable.Options)
+		}
+
+		resolved[variable.Name] = value
+	}
+
+	return resolved, nil
+}
+
+// Render replaces the {{ .name }} placeholders of the content of a custom template by the values of the variables.
+// The placeholders of the variables not declared by the template are left untouched.
+func Render(content string, variables []portainer.CustomTemplateVariableDefinition, values map[string]string) (string, error) {
+	resolved, err := ResolveVariables(variables, values)
+	if err != nil {
+		return "", err
+	}
+
+	return placeholderRe.ReplaceAllStringFunc(content, func(placeholder string) string {
+		name := placeholderRe.FindStringSubmatch(placeholder)[1]
+
+		if value, ok := resolved[name]; ok {
+			return value
+		}
+
+		return placeholder
+	}), nil
+}
@@ -0,0 +1,69 @@
+package customtemplateutils
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateVariableDefinitions(t *testing.T) {
+	valid := []portainer.CustomTemplateVariableDefinition{
+		{Name: "IMAGE", Label: "Image", DefaultValue: "nginx:1.25", Options: []string{"nginx:1.25", "nginx:1.24"}},
+		{Name: "replicas_2", Label: "Replicas", Required: true},
+	}
+	assert.NoError(t, ValidateVariableDefinitions(valid))
+
+	for _, variables := range [][]portainer.CustomTemplateVariableDefinition{
+		{{Label: "Image"}},
+		{{Name: "IMAGE"}},
+		{{Name: "2IMAGE", Label: "Image"}},
+		{{Name: "MY-IMAGE", Label: "Image"}},
+		{{Name: "IMAGE", Label: "Image"}, {Name: "IMAGE", Label: "Other image"}},
+		{{Name: "IMAGE", Label: "Image", DefaultValue: "httpd", Options: []string{"nginx"}}},
+	} {
+		assert.Error(t, ValidateVariableDefinitions(variables), "%+v", variables)
+	}
+}
+
+func TestRender(t *testing.T) {
+	variables := []portainer.CustomTemplateVariableDefinition{
+		{Name: "IMAGE", Label: "Image", DefaultValue: "nginx:1.25", Options: []string{"nginx:1.25", "nginx:1.24"}},
+		{Name: "PORT", Label: "Port", Required: true},
+		{Name: "NETWORK", Label: "Network"},
+	}
+
+	content := `services:
+  web:
+    image: {{ .IMAGE }}
+    hostname: "{{.Node.Hostname}}-{{ .UNDECLARED }}"
+    ports:
+      - "{{.PORT}}:80"
+    networks: [{{ .NETWORK }}]
+`
+
+	rendered, err := Render(content, variables, map[string]string{"PORT": "8080"})
+	assert.NoError(t, err)
+	assert.Equal(t, `services:
+  web:
+    image: nginx:1.25
+    hostname: "{{.Node.Hostname}}-{{ .UNDECLARED }}"
+    ports:
+      - "8080:80"
+    networks: []
+`, rendered)
+
+	rendered, err = Render("image: {{ .IMAGE }}", variables, map[string]string{"PORT": "8080", "IMAGE": "nginx:1.24"})
+	assert.NoError(t, err)
+	assert.Equal(t, "image: nginx:1.24", rendered)
+
+	for _, values := range []map[string]string{
+		nil,
+		{"PORT": "8080", "IMAGE": "httpd"},
+		{"PORT": "8080", "OTHER": "value"},
+	} {
+		_, err := Render(content, variables, values)
+		assert.Error(t, err, "%v", values)
+	}
+}
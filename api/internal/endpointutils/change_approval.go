@@ -0,0 +1,42 @@
+package endpointutils
+
+import (
+	"errors"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// ErrChangeApprovalRequired is returned when a change that cannot be submitted for approval is sent by a
+// non-administrator user to an environment requiring the approval of its changes
+var ErrChangeApprovalRequired = errors.New("the changes of the environment require an approval")
+
+// RequiresChangeApproval returns true when the mutating operations of the user on the environment must be approved
+// before they are run, which is the case of the non-administrator users of the protected environments
+func RequiresChangeApproval(endpoint *portainer.Endpoint, tokenData *portainer.TokenData) bool {
+	if endpoint.ChangeApproval == nil || !endpoint.ChangeApproval.Enabled {
+		return false
+	}
+
+	return tokenData == nil || tokenData.Role != portainer.AdministratorRole
+}
+
+// IsChangeApprover returns true when the user is one of the approvers of the changes of the environment, directly or
+// through one of their teams. The administrators can approve the changes of all the environments.
+func IsChangeApprover(endpoint *portainer.Endpoint, userID portainer.UserID, memberships []portainer.TeamMembership) bool {
+	if endpoint.ChangeApproval == nil {
+		return false
+	}
+
+	if slices.Contains(endpoint.ChangeApproval.ApproverUserIDs, userID) {
+		return true
+	}
+
+	for _, membership := range memberships {
+		if slices.Contains(endpoint.ChangeApproval.ApproverTeamIDs, membership.TeamID) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,168 @@
+package endpointutils
+
+import (
+	"slices"
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/rs/zerolog/log"
+)
+
+// EndpointProxyRemover removes the cached proxy of an environment
+type EndpointProxyRemover interface {
+	DeleteEndpointProxy(endpointID portainer.EndpointID)
+}
+
+// UsersAuthorizationsUpdater recomputes the authorizations of the users
+type UsersAuthorizationsUpdater interface {
+	UpdateUsersAuthorizationsTx(tx dataservices.DataStoreTx) error
+}
+
+// EndpointDeleter removes the environments along with their files, their cached proxy and their relations to the
+// other resources
+type EndpointDeleter struct {
+	DataStore            dataservices.DataStore
+	FileService          portainer.FileService
+	ProxyManager         EndpointProxyRemover
+	AuthorizationService UsersAuthorizationsUpdater
+}
+
+// DeleteTx removes an environment and its relations to the snapshots, tags, edge groups, edge stacks, edge jobs and
+// registries. The notes about the environment are removed by DeleteNotes once the transaction is committed.
+func (deleter *EndpointDeleter) DeleteTx(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint) error {
+	if endpoint.TLSConfig.TLS {
+		folder := strconv.Itoa(int(endpoint.ID))
+		err := deleter.FileService.DeleteTLSFiles(folder)
+		if err != nil {
+			log.Error().Err(err).Msgf("Unable to remove TLS files from disk when deleting endpoint %d", endpoint.ID)
+		}
+	}
+
+	if endpoint.SSHConfig != nil {
+		folder := strconv.Itoa(int(endpoint.ID))
+		err := deleter.FileService.DeleteSSHFiles(folder)
+		if err != nil {
+			log.Error().Err(err).Msgf("Unable to remove SSH files from disk when deleting endpoint %d", endpoint.ID)
+		}
+	}
+
+	err := tx.Snapshot().Delete(endpoint.ID)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Unable to remove the snapshot from the database")
+	}
+
+	deleter.ProxyManager.DeleteEndpointProxy(endpoint.ID)
+
+	if len(endpoint.UserAccessPolicies) > 0 || len(endpoint.TeamAccessPolicies) > 0 {
+		err = deleter.AuthorizationService.UpdateUsersAuthorizationsTx(tx)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Unable to update user authorizations")
+		}
+	}
+
+	err = tx.EndpointRelation().DeleteEndpointRelation(endpoint.ID)
+	if err != nil {
+		log.Warn().Err(err).Msgf("Unable to remove environment relation from the database")
+	}
+
+	for _, tagID := range endpoint.TagIDs {
+		var tag *portainer.Tag
+		tag, err = tx.Tag().Read(tagID)
+		if err == nil {
+			delete(tag.Endpoints, endpoint.ID)
+			err = tx.Tag().Update(tagID, tag)
+		}
+
+		if tx.IsErrObjectNotFound(err) {
+			log.Warn().Err(err).Msgf("Unable to find tag inside the database")
+		} else if err != nil {
+			log.Warn().Err(err).Msgf("Unable to delete tag relation from the database")
+		}
+	}
+
+	edgeGroups, err := tx.EdgeGroup().ReadAll()
+	if err != nil {
+		log.Warn().Err(err).Msgf("Unable to retrieve edge groups from the database")
+	}
+
+	for _, edgeGroup := range edgeGroups {
+		edgeGroup.Endpoints = slices.DeleteFunc(edgeGroup.Endpoints, func(e portainer.EndpointID) bool {
+			return e == endpoint.ID
+		})
+
+		err = tx.EdgeGroup().Update(edgeGroup.ID, &edgeGroup)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Unable to update edge group")
+		}
+	}
+
+	edgeStacks, err := tx.EdgeStack().EdgeStacks()
+	if err != nil {
+		log.Warn().Err(err).Msgf("Unable to retrieve edge stacks from the database")
+	}
+
+	for idx := range edgeStacks {
+		edgeStack := &edgeStacks[idx]
+		if _, ok := edgeStack.Status[endpoint.ID]; ok {
+			delete(edgeStack.Status, endpoint.ID)
+			err = tx.EdgeStack().UpdateEdgeStack(edgeStack.ID, edgeStack)
+			if err != nil {
+				log.Warn().Err(err).Msgf("Unable to update edge stack")
+			}
+		}
+	}
+
+	registries, err := tx.Registry().ReadAll()
+	if err != nil {
+		log.Warn().Err(err).Msgf("Unable to retrieve registries from the database")
+	}
+
+	for idx := range registries {
+		registry := &registries[idx]
+		if _, ok := registry.RegistryAccesses[endpoint.ID]; ok {
+			delete(registry.RegistryAccesses, endpoint.ID)
+			err = tx.Registry().Update(registry.ID, registry)
+			if err != nil {
+				log.Warn().Err(err).Msgf("Unable to update registry accesses")
+			}
+		}
+	}
+
+	if IsEdgeEndpoint(endpoint) {
+		edgeJobs, err := tx.EdgeJob().ReadAll()
+		if err != nil {
+			log.Warn().Err(err).Msgf("Unable to retrieve edge jobs from the database")
+		}
+
+		for idx := range edgeJobs {
+			edgeJob := &edgeJobs[idx]
+			if _, ok := edgeJob.Endpoints[endpoint.ID]; ok {
+				delete(edgeJob.Endpoints, endpoint.ID)
+
+				err = tx.EdgeJob().Update(edgeJob.ID, edgeJob)
+				if err != nil {
+					log.Warn().Err(err).Msgf("Unable to update edge job")
+				}
+			}
+		}
+	}
+
+	return tx.Endpoint().DeleteEndpoint(endpoint.ID)
+}
+
+// DeleteNotes removes the notes about a removed environment and about its stacks and containers
+func (deleter *EndpointDeleter) DeleteNotes(endpointID portainer.EndpointID) {
+	notes, err := deleter.DataStore.Note().NotesByEndpoint(endpointID)
+	if err != nil {
+		log.Warn().Err(err).Msg("unable to retrieve the notes of the environment from the database")
+		return
+	}
+
+	for _, note := range notes {
+		if err := deleter.DataStore.Note().Delete(note.ID); err != nil {
+			log.Warn().Err(err).Int("note", int(note.ID)).Msg("unable to remove the note from the database")
+		}
+	}
+}
@@ -104,6 +104,7 @@ func createTLSSecuredEndpoint(flags *portainer.CLIFlags, dataStore dataservices.
 			AllowContainerCapabilitiesForRegularUsers: true,
 			AllowDeviceMappingForRegularUsers:         true,
 			AllowStackManagementForRegularUsers:       true,
+			AllowSwarmManagementForRegularUsers:       true,
 		},
 	}
 
@@ -169,6 +170,7 @@ func createUnsecuredEndpoint(endpointURL string, dataStore dataservices.DataStor
 			AllowContainerCapabilitiesForRegularUsers: true,
 			AllowDeviceMappingForRegularUsers:         true,
 			AllowStackManagementForRegularUsers:       true,
+			AllowSwarmManagementForRegularUsers:       true,
 		},
 	}
 
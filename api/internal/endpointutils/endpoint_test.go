@@ -46,6 +46,23 @@ func Test_IsKubernetesEndpoint(t *testing.T) {
 	}
 }
 
+func Test_IsDockerSSHEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint portainer.Endpoint
+		expected bool
+	}{
+		{endpoint: portainer.Endpoint{Type: portainer.DockerSSHEnvironment, URL: "ssh://user@10.0.0.10"}, expected: true},
+		{endpoint: portainer.Endpoint{Type: portainer.DockerEnvironment, URL: "ssh://user@10.0.0.10:2222"}, expected: true},
+		{endpoint: portainer.Endpoint{Type: portainer.DockerEnvironment, URL: "tcp://10.0.0.10:2375"}, expected: false},
+		{endpoint: portainer.Endpoint{Type: portainer.AgentOnDockerEnvironment, URL: "ssh://user@10.0.0.10"}, expected: false},
+	}
+
+	for _, test := range tests {
+		ans := IsDockerSSHEndpoint(&test.endpoint)
+		assert.Equal(t, test.expected, ans, test.endpoint.URL)
+	}
+}
+
 func Test_IsAgentEndpoint(t *testing.T) {
 	tests := []isEndpointTypeTest{
 		{endpointType: portainer.DockerEnvironment, expected: false},
@@ -27,7 +27,15 @@ func IsKubernetesEndpoint(endpoint *portainer.Endpoint) bool {
 func IsDockerEndpoint(endpoint *portainer.Endpoint) bool {
 	return endpoint.Type == portainer.DockerEnvironment ||
 		endpoint.Type == portainer.AgentOnDockerEnvironment ||
-		endpoint.Type == portainer.EdgeAgentOnDockerEnvironment
+		endpoint.Type == portainer.EdgeAgentOnDockerEnvironment ||
+		endpoint.Type == portainer.DockerSSHEnvironment
+}
+
+// IsDockerSSHEndpoint returns true if this is a docker environment(endpoint) reached via SSH, either created as such
+// or whose URL uses the ssh:// scheme
+func IsDockerSSHEndpoint(endpoint *portainer.Endpoint) bool {
+	return endpoint.Type == portainer.DockerSSHEnvironment ||
+		(endpoint.Type == portainer.DockerEnvironment && strings.HasPrefix(endpoint.URL, "ssh://"))
 }
 
 // IsEdgeEndpoint returns true if this is an Edge endpoint
@@ -201,11 +209,16 @@ func InitialStorageDetection(endpoint *portainer.Endpoint, endpointService datas
 func UpdateEdgeEndpointHeartbeat(endpoint *portainer.Endpoint, settings *portainer.Settings) {
 	if IsEdgeEndpoint(endpoint) {
 		endpoint.QueryDate = time.Now().Unix()
-		checkInInterval := getEndpointCheckinInterval(endpoint, settings)
-		endpoint.Heartbeat = endpoint.QueryDate-endpoint.LastCheckInDate <= int64(checkInInterval*2+20)
+		endpoint.Heartbeat = endpoint.QueryDate-endpoint.LastCheckInDate <= int64(EdgeHeartbeatTolerance(endpoint, settings))
 	}
 }
 
+// EdgeHeartbeatTolerance returns the number of seconds after the last check-in of the agent of the Edge environment
+// during which the environment is considered alive
+func EdgeHeartbeatTolerance(endpoint *portainer.Endpoint, settings *portainer.Settings) int {
+	return getEndpointCheckinInterval(endpoint, settings)*2 + 20
+}
+
 func getEndpointCheckinInterval(endpoint *portainer.Endpoint, settings *portainer.Settings) int {
 	if endpoint.Edge.AsyncMode {
 		defaultInterval := 60
@@ -0,0 +1,265 @@
+package endpointutils
+
+import (
+	"slices"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SettingSourceEnvironment is the source of a setting set on the environment itself
+	SettingSourceEnvironment = "environment"
+	// SettingSourceGroup is the source of a setting inherited from the environment group
+	SettingSourceGroup = "group"
+	// SettingSourceGlobal is the source of a setting taken from the global settings
+	SettingSourceGlobal = "global"
+)
+
+// EffectiveSettings represents the settings applied to an environment once the defaults of its group are resolved
+type EffectiveSettings struct {
+	// Tags of the environment and of its group
+	TagIDs []portainer.TagID `json:"TagIds"`
+	// Interval between the snapshots of the environment
+	SnapshotInterval string `json:"SnapshotInterval" example:"5m"`
+	// Security settings of the environment
+	SecuritySettings portainer.EndpointSecuritySettings `json:"SecuritySettings"`
+	// Registries the environment has access to
+	RegistryIDs []portainer.RegistryID `json:"RegistryIds"`
+	// Whether the environment only accepts read requests
+	ReadOnly bool `json:"ReadOnly" example:"false"`
+	// Sections skipped by the snapshots of the environment
+	SnapshotContent portainer.DockerSnapshotContent `json:"SnapshotContent"`
+	// Source of each setting, one of environment, group or global
+	Sources map[string]string `json:"Sources"`
+}
+
+func groupDefaults(group *portainer.EndpointGroup) portainer.EndpointGroupDefaults {
+	if group == nil || group.Defaults == nil {
+		return portainer.EndpointGroupDefaults{}
+	}
+
+	return *group.Defaults
+}
+
+// ResolveSecuritySettings returns the security settings of an environment, inherited from its group unless the
+// environment overrides them
+func ResolveSecuritySettings(endpoint *portainer.Endpoint, group *portainer.EndpointGroup) portainer.EndpointSecuritySettings {
+	defaults := groupDefaults(group)
+	if endpoint.SecuritySettingsOverridden || defaults.SecuritySettings == nil {
+		return endpoint.SecuritySettings
+	}
+
+	return *defaults.SecuritySettings
+}
+
+// ResolveReadOnly returns whether an environment only accepts read requests
+func ResolveReadOnly(endpoint *portainer.Endpoint, group *portainer.EndpointGroup) bool {
+	if endpoint.ReadOnly != nil {
+		return *endpoint.ReadOnly
+	}
+
+	defaults := groupDefaults(group)
+
+	return defaults.ReadOnly != nil && *defaults.ReadOnly
+}
+
+// ResolveCost returns the costs of an environment, inherited from its group unless the environment overrides them, or
+// nil when no cost is set
+func ResolveCost(endpoint *portainer.Endpoint, group *portainer.EndpointGroup) *portainer.CostSettings {
+	if endpoint.Cost != nil {
+		return endpoint.Cost
+	}
+
+	return groupDefaults(group).Cost
+}
+
+// ResolveSnapshotInterval returns the interval between the snapshots of an environment, falling back to the global
+// snapshot interval
+func ResolveSnapshotInterval(endpoint *portainer.Endpoint, group *portainer.EndpointGroup, globalInterval string) string {
+	if endpoint.SnapshotInterval != "" {
+		return endpoint.SnapshotInterval
+	}
+
+	if defaults := groupDefaults(group); defaults.SnapshotInterval != "" {
+		return defaults.SnapshotInterval
+	}
+
+	return globalInterval
+}
+
+// ResolveSnapshotContent returns the sections skipped by the snapshots of an environment, its own ones overriding the
+// global ones
+func ResolveSnapshotContent(endpoint *portainer.Endpoint, globalContent portainer.DockerSnapshotContent) portainer.DockerSnapshotContent {
+	if endpoint.SnapshotContent != nil {
+		return *endpoint.SnapshotContent
+	}
+
+	return globalContent
+}
+
+// ResolveSettings returns the effective settings of an environment along with their source. The registries are
+// not resolved, as they are granted to the environments when they join their group.
+func ResolveSettings(endpoint *portainer.Endpoint, group *portainer.EndpointGroup, globalSnapshotInterval string) EffectiveSettings {
+	defaults := groupDefaults(group)
+
+	settings := EffectiveSettings{
+		TagIDs:           slices.Clone(endpoint.TagIDs),
+		SnapshotInterval: ResolveSnapshotInterval(endpoint, group, globalSnapshotInterval),
+		SecuritySettings: ResolveSecuritySettings(endpoint, group),
+		RegistryIDs:      []portainer.RegistryID{},
+		ReadOnly:         ResolveReadOnly(endpoint, group),
+		Sources:          map[string]string{"TagIds": SettingSourceEnvironment},
+	}
+
+	if group != nil {
+		for _, tagID := range group.TagIDs {
+			if !slices.Contains(settings.TagIDs, tagID) {
+				settings.TagIDs = append(settings.TagIDs, tagID)
+			}
+		}
+	}
+
+	switch {
+	case endpoint.SnapshotInterval != "":
+		settings.Sources["SnapshotInterval"] = SettingSourceEnvironment
+	case defaults.SnapshotInterval != "":
+		settings.Sources["SnapshotInterval"] = SettingSourceGroup
+	default:
+		settings.Sources["SnapshotInterval"] = SettingSourceGlobal
+	}
+
+	settings.Sources["SecuritySettings"] = SettingSourceEnvironment
+	if !endpoint.SecuritySettingsOverridden && defaults.SecuritySettings != nil {
+		settings.Sources["SecuritySettings"] = SettingSourceGroup
+	}
+
+	switch {
+	case endpoint.ReadOnly != nil:
+		settings.Sources["ReadOnly"] = SettingSourceEnvironment
+	case defaults.ReadOnly != nil:
+		settings.Sources["ReadOnly"] = SettingSourceGroup
+	default:
+		settings.Sources["ReadOnly"] = SettingSourceGlobal
+	}
+
+	return settings
+}
+
+// EndpointGroup returns the group of an environment, or nil when it does not exist anymore
+func EndpointGroup(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint) (*portainer.EndpointGroup, error) {
+	group, err := tx.EndpointGroup().Read(endpoint.GroupID)
+	if tx.IsErrObjectNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.WithMessage(err, "Unable to retrieve the environment group from the database")
+	}
+
+	return group, nil
+}
+
+// EffectiveSecuritySettings returns the security settings of an environment once the defaults of its group are resolved
+func EffectiveSecuritySettings(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint) (*portainer.EndpointSecuritySettings, error) {
+	group, err := EndpointGroup(tx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := ResolveSecuritySettings(endpoint, group)
+
+	return &settings, nil
+}
+
+// IsReadOnly returns whether an environment only accepts read requests once the defaults of its group are resolved
+func IsReadOnly(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint) (bool, error) {
+	group, err := EndpointGroup(tx, endpoint)
+	if err != nil {
+		return false, err
+	}
+
+	return ResolveReadOnly(endpoint, group), nil
+}
+
+// GrantGroupRegistryAccess gives the environments access to the default registries of their group. The access
+// stays when the environments leave the group, and can be removed from each environment.
+func GrantGroupRegistryAccess(tx dataservices.DataStoreTx, group *portainer.EndpointGroup, endpointIDs ...portainer.EndpointID) error {
+	defaults := groupDefaults(group)
+	if len(defaults.RegistryIDs) == 0 || len(endpointIDs) == 0 {
+		return nil
+	}
+
+	for _, registryID := range defaults.RegistryIDs {
+		registry, err := tx.Registry().Read(registryID)
+		if tx.IsErrObjectNotFound(err) {
+			continue
+		} else if err != nil {
+			return errors.WithMessage(err, "Unable to retrieve the registry from the database")
+		}
+
+		if registry.RegistryAccesses == nil {
+			registry.RegistryAccesses = portainer.RegistryAccesses{}
+		}
+
+		updated := false
+		for _, endpointID := range endpointIDs {
+			if _, ok := registry.RegistryAccesses[endpointID]; !ok {
+				registry.RegistryAccesses[endpointID] = portainer.RegistryAccessPolicies{
+					UserAccessPolicies: portainer.UserAccessPolicies{},
+					TeamAccessPolicies: portainer.TeamAccessPolicies{},
+				}
+				updated = true
+			}
+		}
+
+		if !updated {
+			continue
+		}
+
+		if err := tx.Registry().Update(registry.ID, registry); err != nil {
+			return errors.WithMessage(err, "Unable to persist the registry changes inside the database")
+		}
+	}
+
+	return nil
+}
+
+// ValidateGroupDefaults checks the defaults of an environment group
+func ValidateGroupDefaults(tx dataservices.DataStoreTx, defaults *portainer.EndpointGroupDefaults) error {
+	if defaults == nil {
+		return nil
+	}
+
+	if defaults.SnapshotInterval != "" {
+		if err := ValidateSnapshotInterval(defaults.SnapshotInterval); err != nil {
+			return err
+		}
+	}
+
+	for _, registryID := range defaults.RegistryIDs {
+		if _, err := tx.Registry().Read(registryID); err != nil {
+			return errors.WithMessagef(err, "Unable to find the registry %d", registryID)
+		}
+	}
+
+	return nil
+}
+
+// ValidateSnapshotInterval checks the snapshot interval of an environment or an environment group
+func ValidateSnapshotInterval(interval string) error {
+	duration, err := time.ParseDuration(interval)
+	if err != nil {
+		return errors.Wrap(err, "invalid snapshot interval")
+	}
+
+	if duration < time.Minute {
+		return errors.New("the snapshot interval must be at least one minute")
+	}
+
+	return nil
+}
+
+// ErrReadOnlyEndpoint is returned when a write operation is sent to a read-only environment
+var ErrReadOnlyEndpoint = errors.New("the environment is read-only")
@@ -0,0 +1,94 @@
+package endpointutils
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSettings(t *testing.T) {
+	is := assert.New(t)
+
+	readOnly := true
+	group := &portainer.EndpointGroup{
+		ID:     2,
+		TagIDs: []portainer.TagID{1, 3},
+		Defaults: &portainer.EndpointGroupDefaults{
+			SnapshotInterval: "10m",
+			SecuritySettings: &portainer.EndpointSecuritySettings{AllowStackManagementForRegularUsers: false},
+			ReadOnly:         &readOnly,
+		},
+	}
+
+	endpoint := &portainer.Endpoint{
+		ID:               1,
+		GroupID:          2,
+		TagIDs:           []portainer.TagID{1, 2},
+		SecuritySettings: portainer.EndpointSecuritySettings{AllowStackManagementForRegularUsers: true},
+	}
+
+	t.Run("the defaults of the group are inherited", func(t *testing.T) {
+		settings := ResolveSettings(endpoint, group, "5m")
+
+		is.Equal([]portainer.TagID{1, 2, 3}, settings.TagIDs)
+		is.Equal("10m", settings.SnapshotInterval)
+		is.False(settings.SecuritySettings.AllowStackManagementForRegularUsers)
+		is.True(settings.ReadOnly)
+		is.Equal(map[string]string{
+			"TagIds":           SettingSourceEnvironment,
+			"SnapshotInterval": SettingSourceGroup,
+			"SecuritySettings": SettingSourceGroup,
+			"ReadOnly":         SettingSourceGroup,
+		}, settings.Sources)
+	})
+
+	t.Run("the environment overrides the defaults", func(t *testing.T) {
+		notReadOnly := false
+		overridden := *endpoint
+		overridden.SnapshotInterval = "1h"
+		overridden.SecuritySettingsOverridden = true
+		overridden.ReadOnly = &notReadOnly
+
+		settings := ResolveSettings(&overridden, group, "5m")
+
+		is.Equal("1h", settings.SnapshotInterval)
+		is.True(settings.SecuritySettings.AllowStackManagementForRegularUsers)
+		is.False(settings.ReadOnly)
+		is.Equal(SettingSourceEnvironment, settings.Sources["SnapshotInterval"])
+		is.Equal(SettingSourceEnvironment, settings.Sources["SecuritySettings"])
+		is.Equal(SettingSourceEnvironment, settings.Sources["ReadOnly"])
+	})
+
+	t.Run("the global settings apply without defaults", func(t *testing.T) {
+		settings := ResolveSettings(endpoint, nil, "5m")
+
+		is.Equal([]portainer.TagID{1, 2}, settings.TagIDs)
+		is.Equal("5m", settings.SnapshotInterval)
+		is.True(settings.SecuritySettings.AllowStackManagementForRegularUsers)
+		is.False(settings.ReadOnly)
+		is.Equal(SettingSourceGlobal, settings.Sources["SnapshotInterval"])
+		is.Equal(SettingSourceGlobal, settings.Sources["ReadOnly"])
+	})
+}
+
+func TestValidateSnapshotInterval(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateSnapshotInterval("1m"))
+	is.NoError(ValidateSnapshotInterval("2h"))
+	is.Error(ValidateSnapshotInterval("30s"))
+	is.Error(ValidateSnapshotInterval("ten minutes"))
+}
+
+func TestResolveSnapshotContent(t *testing.T) {
+	is := assert.New(t)
+
+	global := portainer.DockerSnapshotContent{ExcludeImages: true}
+
+	is.Equal(global, ResolveSnapshotContent(&portainer.Endpoint{}, global))
+
+	override := &portainer.DockerSnapshotContent{ExcludeVolumes: true}
+	is.Equal(*override, ResolveSnapshotContent(&portainer.Endpoint{SnapshotContent: override}, global))
+}
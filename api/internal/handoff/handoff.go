@@ -0,0 +1,198 @@
+// Package handoff allows Portainer to be restarted without refusing connections, by passing its listening
+// sockets over to a new process which starts accepting connections once the previous process has exited.
+package handoff
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// listenersEnvVar lists the addresses of the inherited listeners, in the order of their file descriptors
+	listenersEnvVar = "PORTAINER_HANDOFF_LISTENERS"
+	// parentEnvVar holds the identifier of the process that handed the listeners over
+	parentEnvVar = "PORTAINER_HANDOFF_PARENT"
+	// firstListenerFd is the file descriptor of the first inherited listener, following stdin, stdout and stderr
+	firstListenerFd = 3
+
+	parentPollInterval = 100 * time.Millisecond
+)
+
+// fileListener is a listener whose socket can be passed to another process, such as the TCP and the unix listeners
+type fileListener interface {
+	net.Listener
+	File() (*os.File, error)
+}
+
+// Handoff keeps track of the listening sockets of the server so that they can be handed over to a new process
+type Handoff struct {
+	mu        sync.Mutex
+	inherited map[string]net.Listener
+	active    map[string]fileListener
+	parentPID int
+}
+
+// New creates a Handoff, retrieving the listeners inherited from the parent process if any
+func New() (*Handoff, error) {
+	handoff := &Handoff{
+		inherited: make(map[string]net.Listener),
+		active:    make(map[string]fileListener),
+	}
+
+	addresses := os.Getenv(listenersEnvVar)
+	if addresses == "" {
+		return handoff, nil
+	}
+
+	parentPID, err := strconv.Atoi(os.Getenv(parentEnvVar))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid parent process identifier")
+	}
+	handoff.parentPID = parentPID
+
+	for i, addr := range strings.Split(addresses, ",") {
+		file := os.NewFile(uintptr(firstListenerFd+i), addr)
+
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to inherit the listener for %s", addr)
+		}
+
+		handoff.inherited[addr] = listener
+	}
+
+	os.Unsetenv(listenersEnvVar)
+	os.Unsetenv(parentEnvVar)
+
+	return handoff, nil
+}
+
+// Inherited returns true when the listeners were handed over by a parent process
+func (handoff *Handoff) Inherited() bool {
+	return handoff.parentPID != 0
+}
+
+// WaitForParent waits for the parent process to exit, up to the specified timeout. The parent process keeps
+// holding resources such as the database until it is done draining its in-flight requests
+func (handoff *Handoff) WaitForParent(timeout time.Duration) {
+	if !handoff.Inherited() {
+		return
+	}
+
+	log.Info().Int("parent_pid", handoff.parentPID).Msg("waiting for the previous Portainer process to shut down")
+
+	deadline := time.Now().Add(timeout)
+	for os.Getppid() == handoff.parentPID {
+		if time.Now().After(deadline) {
+			log.Warn().Int("parent_pid", handoff.parentPID).Msg("the previous Portainer process is still running")
+			return
+		}
+
+		time.Sleep(parentPollInterval)
+	}
+}
+
+// Listen returns the listener inherited for the address, or the one created by listen when there is none
+func (handoff *Handoff) Listen(addr string, listen func() (net.Listener, error)) (net.Listener, error) {
+	handoff.mu.Lock()
+	defer handoff.mu.Unlock()
+
+	listener, ok := handoff.inherited[addr]
+	if ok {
+		delete(handoff.inherited, addr)
+	} else {
+		var err error
+		listener, err = listen()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fileListener, ok := listener.(fileListener); ok {
+		handoff.active[addr] = fileListener
+	}
+
+	return listener, nil
+}
+
+// Supported returns an error when the listeners cannot be handed over to a new process: on Windows, and when
+// Portainer is the init process of its container, i.e. PID 1, as the container is stopped along with the new process
+// as soon as its init process exits. Portainer must then be run by an init process, e.g. with docker run --init.
+func Supported() error {
+	if runtime.GOOS == "windows" {
+		return errors.New("the socket handoff is not supported on Windows")
+	}
+
+	if os.Getpid() == 1 {
+		return errors.New("the socket handoff is not supported when Portainer is the init process of its container, run it with an init process such as docker run --init")
+	}
+
+	return nil
+}
+
+// Restart starts a new Portainer process, with the same arguments, which inherits the active listeners.
+// The caller is expected to shut down once it returns, the new process waiting for it to exit before serving.
+func (handoff *Handoff) Restart() error {
+	if err := Supported(); err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "unable to locate the Portainer executable")
+	}
+
+	handoff.mu.Lock()
+	defer handoff.mu.Unlock()
+
+	addresses := make([]string, 0, len(handoff.active))
+	files := make([]*os.File, 0, len(handoff.active))
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+
+	for addr, listener := range handoff.active {
+		// the unix socket must outlive this process, which would otherwise remove it when closing its listener
+		if unixListener, ok := listener.(*net.UnixListener); ok {
+			unixListener.SetUnlinkOnClose(false)
+		}
+
+		file, err := listener.File()
+		if err != nil {
+			return errors.Wrapf(err, "unable to retrieve the listener for %s", addr)
+		}
+
+		addresses = append(addresses, addr)
+		files = append(files, file)
+	}
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%s", listenersEnvVar, strings.Join(addresses, ",")),
+		fmt.Sprintf("%s=%d", parentEnvVar, os.Getpid()),
+	)
+
+	if err := cmd.Start(); err != nil {
+		return errors.Wrap(err, "unable to start the new Portainer process")
+	}
+
+	log.Info().Int("pid", cmd.Process.Pid).Strs("listeners", addresses).Msg("listening sockets handed over to a new Portainer process")
+
+	return cmd.Process.Release()
+}
@@ -0,0 +1,57 @@
+// Package notes attaches the notes written by the users to the environments, the stacks and the containers returned
+// by the API
+package notes
+
+import (
+	portainer "github.com/portainer/portainer/api"
+)
+
+type containerKey struct {
+	endpointID portainer.EndpointID
+	name       string
+}
+
+// Index groups the notes by the resource they are attached to, in their order of creation
+type Index struct {
+	endpoints  map[portainer.EndpointID][]portainer.Note
+	stacks     map[portainer.StackID][]portainer.Note
+	containers map[containerKey][]portainer.Note
+}
+
+// NewIndex indexes the notes by resource
+func NewIndex(notes []portainer.Note) *Index {
+	index := &Index{
+		endpoints:  make(map[portainer.EndpointID][]portainer.Note),
+		stacks:     make(map[portainer.StackID][]portainer.Note),
+		containers: make(map[containerKey][]portainer.Note),
+	}
+
+	for _, note := range notes {
+		switch note.ResourceType {
+		case portainer.NoteEndpoint:
+			index.endpoints[note.EndpointID] = append(index.endpoints[note.EndpointID], note)
+		case portainer.NoteStack:
+			index.stacks[note.StackID] = append(index.stacks[note.StackID], note)
+		case portainer.NoteContainer:
+			key := containerKey{endpointID: note.EndpointID, name: note.ContainerName}
+			index.containers[key] = append(index.containers[key], note)
+		}
+	}
+
+	return index
+}
+
+// Endpoint returns the notes about the environment
+func (index *Index) Endpoint(endpointID portainer.EndpointID) []portainer.Note {
+	return index.endpoints[endpointID]
+}
+
+// Stack returns the notes about the stack
+func (index *Index) Stack(stackID portainer.StackID) []portainer.Note {
+	return index.stacks[stackID]
+}
+
+// Container returns the notes about the container of the environment with the name
+func (index *Index) Container(endpointID portainer.EndpointID, name string) []portainer.Note {
+	return index.containers[containerKey{endpointID: endpointID, name: name}]
+}
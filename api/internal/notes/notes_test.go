@@ -0,0 +1,36 @@
+package notes
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndex(t *testing.T) {
+	is := assert.New(t)
+
+	index := NewIndex([]portainer.Note{
+		{ID: 1, ResourceType: portainer.NoteEndpoint, EndpointID: 1},
+		{ID: 2, ResourceType: portainer.NoteStack, EndpointID: 1, StackID: 3},
+		{ID: 3, ResourceType: portainer.NoteContainer, EndpointID: 1, ContainerName: "web"},
+		{ID: 4, ResourceType: portainer.NoteContainer, EndpointID: 2, ContainerName: "web"},
+		{ID: 5, ResourceType: portainer.NoteContainer, EndpointID: 1, ContainerName: "web"},
+	})
+
+	noteIDs := func(notes []portainer.Note) []portainer.NoteID {
+		ids := []portainer.NoteID{}
+		for _, note := range notes {
+			ids = append(ids, note.ID)
+		}
+
+		return ids
+	}
+
+	is.Equal([]portainer.NoteID{1}, noteIDs(index.Endpoint(1)))
+	is.Equal([]portainer.NoteID{2}, noteIDs(index.Stack(3)))
+	is.Equal([]portainer.NoteID{3, 5}, noteIDs(index.Container(1, "web")), "the containers are identified by environment")
+	is.Equal([]portainer.NoteID{4}, noteIDs(index.Container(2, "web")))
+	is.Empty(index.Endpoint(2))
+}
@@ -0,0 +1,40 @@
+// Package requestutils provides the helpers shared by the policies applied to the API requests, such as the rate
+// limiting, the authorization hook, the API usage tracking and the intrusion detection.
+package requestutils
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RemoteIP returns the IP address of the client of a request, without its port
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// PathSegments splits a path, or a path pattern, into its segments
+func PathSegments(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// MatchPath matches the segments of a path against a pattern, "*" matching a segment and a trailing "**" matching
+// the remaining segments
+func MatchPath(pattern, segments []string) bool {
+	for i, part := range pattern {
+		if part == "**" && i == len(pattern)-1 {
+			return true
+		}
+
+		if i >= len(segments) || (part != "*" && part != segments[i]) {
+			return false
+		}
+	}
+
+	return len(pattern) == len(segments)
+}
@@ -0,0 +1,40 @@
+package requestutils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/status", nil)
+
+	r.RemoteAddr = "10.0.0.1:51234"
+	assert.Equal(t, "10.0.0.1", RemoteIP(r))
+
+	r.RemoteAddr = "[::1]:51234"
+	assert.Equal(t, "::1", RemoteIP(r))
+
+	r.RemoteAddr = "10.0.0.1"
+	assert.Equal(t, "10.0.0.1", RemoteIP(r))
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{pattern: "/api/status", path: "/api/status", match: true},
+		{pattern: "/api/status", path: "/api/status/version", match: false},
+		{pattern: "/api/endpoints/*", path: "/api/endpoints/1", match: true},
+		{pattern: "/api/endpoints/*", path: "/api/endpoints/1/docker", match: false},
+		{pattern: "/api/endpoints/**", path: "/api/endpoints/1/docker/containers/json", match: true},
+		{pattern: "/api/endpoints/**", path: "/api/stacks", match: false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, MatchPath(PathSegments(tt.pattern), PathSegments(tt.path)), "%s %s", tt.pattern, tt.path)
+	}
+}
@@ -0,0 +1,98 @@
+package snapshot
+
+import (
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// diskUsageInterval is the minimum delay between two collections of the disk usage of an environment, as the
+	// engine has to walk the volumes and the layers of the containers to compute it
+	diskUsageInterval = time.Hour
+	// diskUsageConcurrency bounds the number of environments whose disk usage is collected at the same time
+	diskUsageConcurrency = 2
+)
+
+// diskUsageCollector throttles the collections of the disk usage of the environments, which run in the background
+// so that they do not delay the snapshots
+type diskUsageCollector struct {
+	mu       sync.Mutex
+	last     map[portainer.EndpointID]time.Time
+	interval time.Duration
+	slots    chan struct{}
+}
+
+func newDiskUsageCollector(interval time.Duration, concurrency int) *diskUsageCollector {
+	return &diskUsageCollector{
+		last:     make(map[portainer.EndpointID]time.Time),
+		interval: interval,
+		slots:    make(chan struct{}, concurrency),
+	}
+}
+
+// due returns true and records the collection when the disk usage of the environment was not collected during the
+// interval
+func (collector *diskUsageCollector) due(endpointID portainer.EndpointID, now time.Time) bool {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	if last, ok := collector.last[endpointID]; ok && now.Sub(last) < collector.interval {
+		return false
+	}
+
+	collector.last[endpointID] = now
+
+	return true
+}
+
+// supportDiskUsage returns true when the disk usage of the environment is collected, which is only the case of the
+// agents as the engines exposed directly are often shared with other tools
+func supportDiskUsage(endpoint *portainer.Endpoint, content portainer.DockerSnapshotContent) bool {
+	return endpoint.Type == portainer.AgentOnDockerEnvironment && !content.ExcludeDiskUsage
+}
+
+// collectDiskUsage collects the disk usage of the environment in the background when it is due, and stores it along
+// with its snapshot
+func (service *Service) collectDiskUsage(endpoint *portainer.Endpoint, content portainer.DockerSnapshotContent) {
+	if !supportDiskUsage(endpoint, content) || !service.diskUsage.due(endpoint.ID, time.Now()) {
+		return
+	}
+
+	endpointCopy := *endpoint
+	endpoint = &endpointCopy
+
+	go func() {
+		select {
+		case service.diskUsage.slots <- struct{}{}:
+		case <-service.shutdownCtx.Done():
+			return
+		}
+		defer func() { <-service.diskUsage.slots }()
+
+		usage, err := service.dockerSnapshotter.CreateDiskUsage(endpoint)
+		if err != nil {
+			log.Warn().Err(err).Str("environment", endpoint.Name).Msg("unable to collect the disk usage")
+
+			return
+		}
+
+		err = service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+			snapshot, err := tx.Snapshot().Read(endpoint.ID)
+			if err != nil {
+				return err
+			}
+
+			snapshot.DockerDiskUsage = usage
+
+			return tx.Snapshot().Update(endpoint.ID, snapshot)
+		})
+		if err != nil {
+			log.Warn().Err(err).Str("environment", endpoint.Name).Msg("unable to store the disk usage")
+		}
+	}()
+}
@@ -0,0 +1,32 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskUsageCollector_Due(t *testing.T) {
+	is := assert.New(t)
+
+	collector := newDiskUsageCollector(time.Hour, 1)
+	now := time.Now()
+
+	is.True(collector.due(1, now))
+	is.False(collector.due(1, now.Add(30*time.Minute)))
+	is.True(collector.due(2, now.Add(30*time.Minute)))
+	is.True(collector.due(1, now.Add(time.Hour)))
+}
+
+func TestSupportDiskUsage(t *testing.T) {
+	is := assert.New(t)
+
+	agent := &portainer.Endpoint{Type: portainer.AgentOnDockerEnvironment}
+
+	is.True(supportDiskUsage(agent, portainer.DockerSnapshotContent{}))
+	is.False(supportDiskUsage(agent, portainer.DockerSnapshotContent{ExcludeDiskUsage: true}))
+	is.False(supportDiskUsage(&portainer.Endpoint{Type: portainer.DockerEnvironment}, portainer.DockerSnapshotContent{}))
+}
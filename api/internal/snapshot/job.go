@@ -0,0 +1,70 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/jobs"
+	"github.com/portainer/portainer/api/scheduler"
+)
+
+// JobType is the type of the background jobs snapshotting an environment on demand
+const JobType = "endpoint.snapshot"
+
+// jobConcurrency is the number of environments snapshotted at the same time by the background jobs
+const jobConcurrency = 5
+
+// JobPayload represents the environment snapshotted by a background job
+type JobPayload struct {
+	EndpointID portainer.EndpointID
+}
+
+// RegisterJobs registers the handler of the snapshot jobs in the job queue
+func RegisterJobs(queue *jobs.Queue, dataStore dataservices.DataStore, snapshotService portainer.SnapshotService) {
+	queue.Register(JobType, jobs.Options{Concurrency: jobConcurrency, MaxAttempts: 2, Backoff: 10 * time.Second}, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		var payload JobPayload
+		if err := jobs.DecodePayload(job, &payload); err != nil {
+			return scheduler.NewPermanentError(err)
+		}
+
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "snapshot", Message: "Snapshotting the environment"})
+
+		return SnapshotAndUpdateStatus(dataStore, snapshotService, payload.EndpointID)
+	})
+}
+
+// SnapshotAndUpdateStatus snapshots an environment and updates its status, the environments that cannot be
+// snapshotted returning a permanent error
+func SnapshotAndUpdateStatus(dataStore dataservices.DataStore, snapshotService portainer.SnapshotService, endpointID portainer.EndpointID) error {
+	endpoint, err := dataStore.Endpoint().Endpoint(endpointID)
+	if err != nil {
+		return scheduler.NewPermanentError(errors.New("unable to find an environment with the specified identifier"))
+	}
+
+	if !SupportDirectSnapshot(endpoint) {
+		return scheduler.NewPermanentError(errors.New("snapshots are not supported for this environment"))
+	}
+
+	snapshotErr := snapshotService.SnapshotEndpoint(endpoint)
+
+	latestEndpoint, err := dataStore.Endpoint().Endpoint(endpoint.ID)
+	if err != nil {
+		return scheduler.NewPermanentError(errors.New("unable to find an environment with the specified identifier"))
+	}
+
+	latestEndpoint.Status = portainer.EndpointStatusUp
+	if snapshotErr != nil {
+		latestEndpoint.Status = portainer.EndpointStatusDown
+	}
+
+	latestEndpoint.Agent.Version = endpoint.Agent.Version
+
+	if err := dataStore.Endpoint().UpdateEndpoint(latestEndpoint.ID, latestEndpoint); err != nil {
+		return errors.New("unable to persist the environment changes inside the database")
+	}
+
+	return snapshotErr
+}
@@ -0,0 +1,129 @@
+package snapshot
+
+import (
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/authorization"
+	"github.com/portainer/portainer/api/ownership"
+
+	"github.com/rs/zerolog/log"
+)
+
+// systemNetworks are the networks created by the Docker engines, which are not owned by any team
+var systemNetworks = []string{"bridge", "host", "none", "ingress", "docker_gwbridge", "nat"}
+
+// externalResource is a Docker resource of a snapshot which may have been created outside of Portainer
+type externalResource struct {
+	id           string
+	name         string
+	resourceType portainer.ResourceControlType
+	labels       map[string]string
+}
+
+// AssignOwnership gives the resources of the snapshot that have no resource control, neither their own nor inherited
+// from their service or their stack, to the team of the first ownership rule of the environment matching them. The
+// resources carrying ownership labels are left to the Docker proxy, which creates their resource control from them.
+func AssignOwnership(dataStore dataservices.DataStore, endpoint *portainer.Endpoint, snapshot *portainer.DockerSnapshot) error {
+	rules, err := dataStore.OwnershipRule().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	rules = slices.DeleteFunc(rules, func(rule portainer.OwnershipRule) bool {
+		return !ownership.Applies(&rule, endpoint)
+	})
+
+	if len(rules) == 0 {
+		return nil
+	}
+
+	resourceControls, err := dataStore.ResourceControl().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range externalResources(snapshot) {
+		if hasAccessControlLabel(resource.labels) || authorization.DockerResourceControl(endpoint.ID, resource.id, resource.resourceType, resource.labels, resourceControls) != nil {
+			continue
+		}
+
+		rule := ownership.Match(rules, resource.resourceType, resource.name, resource.labels)
+		if rule == nil {
+			continue
+		}
+
+		resourceControl := authorization.NewRestrictedResourceControl(resource.id, resource.resourceType, []portainer.UserID{}, []portainer.TeamID{rule.TeamID})
+		if err := dataStore.ResourceControl().Create(resourceControl); err != nil {
+			return err
+		}
+		resourceControls = append(resourceControls, *resourceControl)
+
+		log.Debug().
+			Str("environment", endpoint.Name).
+			Str("resource", resource.name).
+			Str("rule", rule.Name).
+			Msg("ownership of the external resource assigned by an ownership rule")
+	}
+
+	return nil
+}
+
+// externalResources lists the containers, the volumes and the user defined networks of the snapshot
+func externalResources(snapshot *portainer.DockerSnapshot) []externalResource {
+	resources := []externalResource{}
+
+	for _, container := range snapshot.SnapshotRaw.Containers {
+		resources = append(resources, externalResource{
+			id:           container.ID,
+			name:         containerName(container),
+			resourceType: portainer.ContainerResourceControl,
+			labels:       container.Labels,
+		})
+	}
+
+	// the volumes are identified by their name and by the Docker engine or the Swarm cluster holding them
+	if dockerID, err := FetchDockerID(*snapshot); err == nil {
+		for _, volume := range snapshot.SnapshotRaw.Volumes.Volumes {
+			resources = append(resources, externalResource{
+				id:           volume.Name + "_" + dockerID,
+				name:         volume.Name,
+				resourceType: portainer.VolumeResourceControl,
+				labels:       volume.Labels,
+			})
+		}
+	}
+
+	for _, network := range snapshot.SnapshotRaw.Networks {
+		if slices.Contains(systemNetworks, network.Name) {
+			continue
+		}
+
+		resources = append(resources, externalResource{
+			id:           network.ID,
+			name:         network.Name,
+			resourceType: portainer.NetworkResourceControl,
+			labels:       network.Labels,
+		})
+	}
+
+	return resources
+}
+
+func hasAccessControlLabel(labels map[string]string) bool {
+	for name := range labels {
+		if authorization.IsAccessControlLabel(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// assignOwnership applies the ownership rules to the resources of a new snapshot of the environment
+func (service *Service) assignOwnership(endpoint *portainer.Endpoint, snapshot *portainer.DockerSnapshot) {
+	if err := AssignOwnership(service.dataStore, endpoint, snapshot); err != nil {
+		log.Warn().Err(err).Str("environment", endpoint.Name).Msg("unable to apply the ownership rules to the resources of the environment")
+	}
+}
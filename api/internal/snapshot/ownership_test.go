@@ -0,0 +1,66 @@
+package snapshot_test
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/internal/snapshot"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignOwnership(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	team := &portainer.Team{Name: "monitoring"}
+	is.NoError(store.Team().Create(team))
+
+	endpoint := &portainer.Endpoint{ID: 1, Name: "production", GroupID: 1}
+
+	is.NoError(store.OwnershipRule().Create(&portainer.OwnershipRule{Name: "monitoring", Label: "team=monitoring", TeamID: team.ID}))
+	is.NoError(store.OwnershipRule().Create(&portainer.OwnershipRule{Name: "staging", EndpointIDs: []portainer.EndpointID{2}, NamePattern: ".", TeamID: team.ID}))
+
+	is.NoError(store.ResourceControl().Create(&portainer.ResourceControl{ResourceID: "c2", Type: portainer.ContainerResourceControl, AdministratorsOnly: true}))
+	is.NoError(store.ResourceControl().Create(&portainer.ResourceControl{ResourceID: "1_web", Type: portainer.StackResourceControl, Public: true}))
+
+	labels := map[string]string{"team": "monitoring"}
+
+	dockerSnapshot := &portainer.DockerSnapshot{SnapshotRaw: portainer.DockerSnapshotRaw{
+		Info: types.Info{ID: "engine"},
+		Containers: []portainer.DockerContainerSnapshot{
+			{Container: types.Container{ID: "c1", Names: []string{"/prometheus"}, Labels: labels}},
+			{Container: types.Container{ID: "c2", Names: []string{"/node-exporter"}, Labels: labels}},
+			{Container: types.Container{ID: "c3", Names: []string{"/web-nginx-1"}, Labels: map[string]string{"team": "monitoring", "com.docker.compose.project": "web"}}},
+			{Container: types.Container{ID: "c4", Names: []string{"/grafana"}, Labels: map[string]string{"team": "monitoring", "io.portainer.accesscontrol.public": ""}}},
+			{Container: types.Container{ID: "c5", Names: []string{"/db"}}},
+		},
+		Volumes:  volume.ListResponse{Volumes: []*volume.Volume{{Name: "metrics", Labels: labels}}},
+		Networks: []types.NetworkResource{{ID: "n1", Name: "bridge", Labels: labels}, {ID: "n2", Name: "monitoring", Labels: labels}},
+	}}
+
+	is.NoError(snapshot.AssignOwnership(store, endpoint, dockerSnapshot))
+
+	resourceControls, err := store.ResourceControl().ReadAll()
+	is.NoError(err)
+
+	owned := []string{}
+	for _, resourceControl := range resourceControls {
+		if len(resourceControl.TeamAccesses) == 1 && resourceControl.TeamAccesses[0].TeamID == team.ID {
+			owned = append(owned, resourceControl.ResourceID)
+		}
+	}
+
+	is.ElementsMatch([]string{"c1", "metrics_engine", "n2"}, owned,
+		"the resources with a resource control, inherited from their stack, or with ownership labels are left unchanged")
+
+	is.NoError(snapshot.AssignOwnership(store, endpoint, dockerSnapshot))
+
+	all, err := store.ResourceControl().ReadAll()
+	is.NoError(err)
+	is.Len(all, len(resourceControls), "the resources are assigned once")
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/portainer/portainer/api/agent"
 	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/dataservices"
+	agentproxy "github.com/portainer/portainer/api/http/proxy/factory/agent"
 	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/pendingactions"
 
@@ -27,8 +28,16 @@ type Service struct {
 	kubernetesSnapshotter     portainer.KubernetesSnapshotter
 	shutdownCtx               context.Context
 	pendingActionsService     *pendingactions.PendingActionsService
+	lastSnapshots             map[portainer.EndpointID]time.Time
+	diskUsage                 *diskUsageCollector
+	unhealthy                 *unhealthyTracker
+	notifier                  UnhealthyNotifier
 }
 
+// snapshotTick is the maximum delay between two checks of the environments to snapshot, so that the snapshot
+// intervals of the environments and of their groups are honored with a one minute resolution
+const snapshotTick = time.Minute
+
 // NewService creates a new instance of a service
 func NewService(
 	snapshotIntervalFromFlag string,
@@ -51,6 +60,9 @@ func NewService(
 		kubernetesSnapshotter:     kubernetesSnapshotter,
 		shutdownCtx:               shutdownCtx,
 		pendingActionsService:     pendingActionsService,
+		lastSnapshots:             make(map[portainer.EndpointID]time.Time),
+		diskUsage:                 newDiskUsageCollector(diskUsageInterval, diskUsageConcurrency),
+		unhealthy:                 newUnhealthyTracker(),
 	}, nil
 }
 
@@ -150,9 +162,18 @@ func (service *Service) SnapshotEndpoint(endpoint *portainer.Endpoint) error {
 			if err != nil {
 				return err
 			}
+			crypto.PinPublicKeys(tlsConfig, endpoint.AgentPublicKeyPins)
+		}
+
+		// the configured agent may be down while the other members of its cluster are reachable
+		var version string
+		for _, agentURL := range agentproxy.ClusterURLs(endpoint) {
+			_, version, err = agent.GetAgentVersionAndPlatform(agentURL, tlsConfig)
+			if err == nil {
+				break
+			}
 		}
 
-		_, version, err := agent.GetAgentVersionAndPlatform(endpoint.URL, tlsConfig)
 		if err != nil {
 			return err
 		}
@@ -218,24 +239,48 @@ func (service *Service) snapshotKubernetesEndpoint(endpoint *portainer.Endpoint)
 }
 
 func (service *Service) snapshotDockerEndpoint(endpoint *portainer.Endpoint) error {
-	dockerSnapshot, err := service.dockerSnapshotter.CreateSnapshot(endpoint)
+	settings, err := service.dataStore.Settings().Settings()
 	if err != nil {
 		return err
 	}
 
-	if dockerSnapshot != nil {
+	content := endpointutils.ResolveSnapshotContent(endpoint, settings.SnapshotContent)
+
+	dockerSnapshot, err := service.dockerSnapshotter.CreateSnapshot(endpoint, content)
+	if err != nil {
+		return err
+	}
+
+	if dockerSnapshot == nil {
+		return nil
+	}
+
+	err = service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
 		snapshot := &portainer.Snapshot{EndpointID: endpoint.ID, Docker: dockerSnapshot}
 
-		return service.dataStore.Snapshot().Create(snapshot)
+		// the disk usage is collected less often than the snapshot
+		if previous, err := tx.Snapshot().Read(endpoint.ID); err == nil && !content.ExcludeDiskUsage {
+			snapshot.DockerDiskUsage = previous.DockerDiskUsage
+		}
+
+		return tx.Snapshot().Create(snapshot)
+	})
+	if err != nil {
+		return err
 	}
 
+	service.collectDiskUsage(endpoint, content)
+	service.checkUnhealthyContainers(endpoint, dockerSnapshot)
+	service.assignOwnership(endpoint, dockerSnapshot)
+
 	return nil
 }
 
 func (service *Service) startSnapshotLoop() {
-	ticker := time.NewTicker(time.Duration(service.snapshotIntervalInSeconds) * time.Second)
+	interval := time.Duration(service.snapshotIntervalInSeconds) * time.Second
+	ticker := time.NewTicker(min(interval, snapshotTick))
 
-	err := service.snapshotEndpoints()
+	err := service.snapshotEndpoints(interval)
 	if err != nil {
 		log.Error().Err(err).Msg("background schedule error (environment snapshot)")
 	}
@@ -243,7 +288,7 @@ func (service *Service) startSnapshotLoop() {
 	for {
 		select {
 		case <-ticker.C:
-			err := service.snapshotEndpoints()
+			err := service.snapshotEndpoints(interval)
 			if err != nil {
 				log.Error().Err(err).Msg("background schedule error (environment snapshot)")
 			}
@@ -251,23 +296,53 @@ func (service *Service) startSnapshotLoop() {
 			log.Debug().Msg("shutting down snapshotting")
 			ticker.Stop()
 			return
-		case interval := <-service.snapshotIntervalCh:
-			ticker.Reset(interval)
+		case interval = <-service.snapshotIntervalCh:
+			ticker.Reset(min(interval, snapshotTick))
 		}
 	}
 }
 
-func (service *Service) snapshotEndpoints() error {
+func (service *Service) snapshotEndpoints(globalInterval time.Duration) error {
 	endpoints, err := service.dataStore.Endpoint().Endpoints()
 	if err != nil {
 		return err
 	}
 
+	groups, err := service.dataStore.EndpointGroup().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	groupsByID := make(map[portainer.EndpointGroupID]*portainer.EndpointGroup, len(groups))
+	for i := range groups {
+		groupsByID[groups[i].ID] = &groups[i]
+	}
+
+	now := time.Now()
+
+	// forget the environments that were removed
+	existing := make(map[portainer.EndpointID]bool, len(endpoints))
+	for _, endpoint := range endpoints {
+		existing[endpoint.ID] = true
+	}
+
+	for endpointID := range service.lastSnapshots {
+		if !existing[endpointID] {
+			delete(service.lastSnapshots, endpointID)
+		}
+	}
+
 	for _, endpoint := range endpoints {
 		if !SupportDirectSnapshot(&endpoint) || endpoint.URL == "" {
 			continue
 		}
 
+		interval := snapshotInterval(&endpoint, groupsByID[endpoint.GroupID], globalInterval)
+		if last, ok := service.lastSnapshots[endpoint.ID]; ok && now.Sub(last) < interval-time.Second {
+			continue
+		}
+		service.lastSnapshots[endpoint.ID] = now
+
 		snapshotError := service.SnapshotEndpoint(&endpoint)
 
 		service.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
@@ -279,6 +354,21 @@ func (service *Service) snapshotEndpoints() error {
 	return nil
 }
 
+// snapshotInterval returns the interval between the snapshots of an environment, inherited from its group
+func snapshotInterval(endpoint *portainer.Endpoint, group *portainer.EndpointGroup, globalInterval time.Duration) time.Duration {
+	value := endpointutils.ResolveSnapshotInterval(endpoint, group, "")
+	if value == "" {
+		return globalInterval
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return globalInterval
+	}
+
+	return interval
+}
+
 func updateEndpointStatus(tx dataservices.DataStoreTx, endpoint *portainer.Endpoint, snapshotError error, pendingActionsService *pendingactions.PendingActionsService) {
 	latestEndpointReference, err := tx.Endpoint().Endpoint(endpoint.ID)
 	if latestEndpointReference == nil {
@@ -0,0 +1,177 @@
+package snapshot
+
+import (
+	"strings"
+	"sync"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// defaultMaxUnhealthyRestarts is the number of restarts of an unhealthy container when the policy does not set it
+	defaultMaxUnhealthyRestarts = 3
+	// swarmTaskIDLabel is set on the containers of the Swarm services, which are rescheduled by Swarm itself
+	swarmTaskIDLabel = "com.docker.swarm.task.id"
+)
+
+// UnhealthyNotifier notifies the containers which became unhealthy, and the containers still unhealthy after all the
+// restarts allowed by the unhealthy restart policy of their environment
+type UnhealthyNotifier interface {
+	ContainerUnhealthy(endpoint *portainer.Endpoint, containerID, containerName string)
+	ContainerRecoveryFailed(endpoint *portainer.Endpoint, containerID, containerName string, restarts int)
+}
+
+// unhealthyContainer is the state of a container whose health check failed
+type unhealthyContainer struct {
+	restarts int
+	alerted  bool
+}
+
+// unhealthyActions are the actions deduced from a snapshot
+type unhealthyActions struct {
+	// containers which became unhealthy since the previous snapshot
+	detected []portainer.DockerContainerSnapshot
+	// containers to restart
+	restart []portainer.DockerContainerSnapshot
+	// containers still unhealthy after all the restarts allowed by the policy
+	failed []portainer.DockerContainerSnapshot
+}
+
+// unhealthyTracker counts the restarts of the unhealthy containers between the snapshots of the environments. The
+// counts are kept in memory, a restart of Portainer granting the containers new restarts.
+type unhealthyTracker struct {
+	mu         sync.Mutex
+	containers map[portainer.EndpointID]map[string]*unhealthyContainer
+}
+
+func newUnhealthyTracker() *unhealthyTracker {
+	return &unhealthyTracker{
+		containers: make(map[portainer.EndpointID]map[string]*unhealthyContainer),
+	}
+}
+
+// track updates the state of the unhealthy containers of the environment from its latest snapshot. A container is
+// tracked until it is reported healthy, removed or stopped, so that its restarts are counted across the snapshots.
+func (tracker *unhealthyTracker) track(endpointID portainer.EndpointID, containers []portainer.DockerContainerSnapshot, policy *portainer.UnhealthyRestartPolicy) unhealthyActions {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	var actions unhealthyActions
+
+	previous := tracker.containers[endpointID]
+	current := make(map[string]*unhealthyContainer)
+
+	for _, container := range containers {
+		state, tracked := previous[container.ID]
+
+		switch {
+		case strings.Contains(container.Status, "(unhealthy)"):
+			if !tracked {
+				state = &unhealthyContainer{}
+				actions.detected = append(actions.detected, container)
+			}
+
+			if !canRestart(container, policy) {
+				break
+			}
+
+			if state.restarts < maxUnhealthyRestarts(policy) {
+				state.restarts++
+				actions.restart = append(actions.restart, container)
+			} else if !state.alerted {
+				state.alerted = true
+				actions.failed = append(actions.failed, container)
+			}
+
+		case tracked && strings.Contains(container.Status, "(health: starting)"):
+			// the container is being restarted, its health is not known yet
+
+		default:
+			continue
+		}
+
+		current[container.ID] = state
+	}
+
+	if len(current) == 0 {
+		delete(tracker.containers, endpointID)
+	} else {
+		tracker.containers[endpointID] = current
+	}
+
+	return actions
+}
+
+// canRestart returns true when the policy restarts the container, the tasks of the Swarm services being left to Swarm
+func canRestart(container portainer.DockerContainerSnapshot, policy *portainer.UnhealthyRestartPolicy) bool {
+	return policy != nil && policy.Enabled && container.State == "running" && container.Labels[swarmTaskIDLabel] == ""
+}
+
+func maxUnhealthyRestarts(policy *portainer.UnhealthyRestartPolicy) int {
+	if policy.MaxRestarts == 0 {
+		return defaultMaxUnhealthyRestarts
+	}
+
+	return policy.MaxRestarts
+}
+
+// SetUnhealthyNotifier sets the notifier of the unhealthy containers
+func (service *Service) SetUnhealthyNotifier(notifier UnhealthyNotifier) {
+	service.notifier = notifier
+}
+
+// checkUnhealthyContainers notifies the containers whose health check fails and restarts them in the background
+// according to the unhealthy restart policy of the environment
+func (service *Service) checkUnhealthyContainers(endpoint *portainer.Endpoint, snapshot *portainer.DockerSnapshot) {
+	actions := service.unhealthy.track(endpoint.ID, snapshot.SnapshotRaw.Containers, endpoint.UnhealthyRestartPolicy)
+
+	for _, container := range actions.detected {
+		if service.notifier != nil {
+			service.notifier.ContainerUnhealthy(endpoint, container.ID, containerName(container))
+		}
+	}
+
+	for _, container := range actions.failed {
+		log.Warn().
+			Str("environment", endpoint.Name).
+			Str("container", containerName(container)).
+			Msg("the container is still unhealthy after all the restarts of the unhealthy restart policy")
+
+		if service.notifier != nil {
+			service.notifier.ContainerRecoveryFailed(endpoint, container.ID, containerName(container), maxUnhealthyRestarts(endpoint.UnhealthyRestartPolicy))
+		}
+	}
+
+	if len(actions.restart) == 0 {
+		return
+	}
+
+	endpointCopy := *endpoint
+	endpoint = &endpointCopy
+
+	go func() {
+		for _, container := range actions.restart {
+			if service.shutdownCtx.Err() != nil {
+				return
+			}
+
+			err := service.dockerSnapshotter.RestartContainer(endpoint, container.ID)
+			if err != nil {
+				log.Warn().Err(err).Str("environment", endpoint.Name).Str("container", containerName(container)).Msg("unable to restart the unhealthy container")
+				continue
+			}
+
+			log.Info().Str("environment", endpoint.Name).Str("container", containerName(container)).Msg("unhealthy container restarted")
+		}
+	}()
+}
+
+func containerName(container portainer.DockerContainerSnapshot) string {
+	if len(container.Names) == 0 {
+		return container.ID
+	}
+
+	return strings.TrimPrefix(container.Names[0], "/")
+}
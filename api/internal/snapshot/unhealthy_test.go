@@ -0,0 +1,89 @@
+package snapshot
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func containerIDs(containers []portainer.DockerContainerSnapshot) []string {
+	ids := []string{}
+	for _, container := range containers {
+		ids = append(ids, container.ID)
+	}
+
+	return ids
+}
+
+func TestUnhealthyTracker_Track(t *testing.T) {
+	is := assert.New(t)
+
+	tracker := newUnhealthyTracker()
+	policy := &portainer.UnhealthyRestartPolicy{Enabled: true, MaxRestarts: 2}
+
+	snapshot := func(status string) []portainer.DockerContainerSnapshot {
+		return []portainer.DockerContainerSnapshot{
+			{Container: types.Container{ID: "web", State: "running", Status: "Up 1 hour " + status}},
+			{Container: types.Container{ID: "task", State: "running", Status: "Up 1 hour (unhealthy)",
+				Labels: map[string]string{swarmTaskIDLabel: "t1"}}},
+			{Container: types.Container{ID: "db", State: "running", Status: "Up 1 hour (healthy)"}},
+		}
+	}
+
+	actions := tracker.track(1, snapshot("(unhealthy)"), policy)
+	is.Equal([]string{"web", "task"}, containerIDs(actions.detected))
+	is.Equal([]string{"web"}, containerIDs(actions.restart), "the Swarm tasks are left to Swarm")
+	is.Empty(actions.failed)
+
+	actions = tracker.track(1, snapshot("(health: starting)"), policy)
+	is.Empty(actions.detected)
+	is.Empty(actions.restart)
+
+	actions = tracker.track(1, snapshot("(unhealthy)"), policy)
+	is.Empty(actions.detected, "the container is still tracked while it is starting")
+	is.Equal([]string{"web"}, containerIDs(actions.restart))
+
+	actions = tracker.track(1, snapshot("(unhealthy)"), policy)
+	is.Empty(actions.restart)
+	is.Equal([]string{"web"}, containerIDs(actions.failed))
+
+	actions = tracker.track(1, snapshot("(unhealthy)"), policy)
+	is.Empty(actions.restart)
+	is.Empty(actions.failed, "the alert is raised once")
+
+	tracker.track(1, snapshot("(healthy)"), policy)
+	actions = tracker.track(1, snapshot("(unhealthy)"), policy)
+	is.Equal([]string{"web"}, containerIDs(actions.detected), "a healthy container is granted new restarts")
+	is.Equal([]string{"web"}, containerIDs(actions.restart))
+}
+
+func TestUnhealthyTracker_WithoutPolicy(t *testing.T) {
+	is := assert.New(t)
+
+	tracker := newUnhealthyTracker()
+	containers := []portainer.DockerContainerSnapshot{
+		{Container: types.Container{ID: "web", State: "running", Status: "Up 1 hour (unhealthy)"}},
+	}
+
+	for _, policy := range []*portainer.UnhealthyRestartPolicy{nil, {Enabled: false, MaxRestarts: 5}} {
+		actions := tracker.track(1, containers, policy)
+		is.Empty(actions.restart)
+		is.Empty(actions.failed)
+	}
+
+	actions := tracker.track(2, containers, &portainer.UnhealthyRestartPolicy{Enabled: true})
+	is.Equal([]string{"web"}, containerIDs(actions.detected), "the environments are tracked separately")
+
+	for i := 1; i < defaultMaxUnhealthyRestarts; i++ {
+		tracker.track(2, containers, &portainer.UnhealthyRestartPolicy{Enabled: true})
+	}
+
+	actions = tracker.track(2, containers, &portainer.UnhealthyRestartPolicy{Enabled: true})
+	is.Equal([]string{"web"}, containerIDs(actions.failed))
+
+	tracker.track(2, nil, nil)
+	is.NotContains(tracker.containers, portainer.EndpointID(2), "the removed containers are forgotten")
+}
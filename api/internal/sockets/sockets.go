@@ -0,0 +1,248 @@
+// Package sockets creates the listeners of the server, which can be bound to TCP addresses, to unix sockets so that
+// the local tooling can reach Portainer without exposing a TCP port, or be activated by systemd.
+package sockets
+
+import (
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// UnixPrefix prefixes the addresses of the unix sockets, e.g. unix:///run/portainer/portainer.sock
+	UnixPrefix = "unix://"
+	// SystemdPrefix prefixes the addresses of the sockets activated by systemd, fd:// selecting the first one and
+	// fd://<name> the one named by the FileDescriptorName option of the socket unit
+	SystemdPrefix = "fd://"
+
+	// systemdFirstFd is the file descriptor of the first socket passed by systemd
+	systemdFirstFd = 3
+)
+
+// UnixOptions are the ownership and the permissions of the unix sockets
+type UnixOptions struct {
+	// Owner is the name or the identifier of the user owning the socket, the current user when empty
+	Owner string
+	// Group is the name or the identifier of the group owning the socket, the group of the owner when empty
+	Group string
+	// Mode is the permissions of the socket
+	Mode fs.FileMode
+}
+
+// DefaultUnixMode restricts the unix sockets to their owner and their group
+const DefaultUnixMode fs.FileMode = 0o660
+
+// IsSocketAddress returns true when the address designates a unix socket or a socket activated by systemd rather
+// than a TCP address
+func IsSocketAddress(addr string) bool {
+	return strings.HasPrefix(addr, UnixPrefix) || strings.HasPrefix(addr, SystemdPrefix)
+}
+
+// ParseMode parses the octal permissions of the unix sockets, such as 0660
+func ParseMode(mode string) (fs.FileMode, error) {
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || value > uint64(fs.ModePerm) {
+		return 0, fmt.Errorf("invalid socket mode %q, octal permissions such as 0660 are expected", mode)
+	}
+
+	return fs.FileMode(value), nil
+}
+
+// Listen announces on the address, which is either a unix socket, a socket activated by systemd or a TCP address
+// of the network
+func Listen(network, addr string, options UnixOptions) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(addr, UnixPrefix):
+		return listenUnix(strings.TrimPrefix(addr, UnixPrefix), options)
+	case strings.HasPrefix(addr, SystemdPrefix):
+		return systemdListener(strings.TrimPrefix(addr, SystemdPrefix))
+	}
+
+	return net.Listen(network, addr)
+}
+
+func listenUnix(path string, options UnixOptions) (net.Listener, error) {
+	if path == "" {
+		return nil, errors.New("the path of the unix socket cannot be empty")
+	}
+
+	// the socket left over by a previous process that did not exit cleanly prevents the bind
+	if info, err := os.Lstat(path); err == nil && info.Mode()&fs.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, errors.Wrapf(err, "unable to remove the stale unix socket %s", path)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyOptions(path, options); err != nil {
+		listener.Close()
+
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+func applyOptions(path string, options UnixOptions) error {
+	mode := options.Mode
+	if mode == 0 {
+		mode = DefaultUnixMode
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		return errors.Wrapf(err, "unable to set the mode of the unix socket %s", path)
+	}
+
+	if options.Owner == "" && options.Group == "" {
+		return nil
+	}
+
+	uid, gid := -1, -1
+
+	if options.Owner != "" {
+		owner, err := lookupUser(options.Owner)
+		if err != nil {
+			return err
+		}
+
+		uid, _ = strconv.Atoi(owner.Uid)
+		if options.Group == "" {
+			gid, _ = strconv.Atoi(owner.Gid)
+		}
+	}
+
+	if options.Group != "" {
+		group, err := lookupGroup(options.Group)
+		if err != nil {
+			return err
+		}
+
+		gid, _ = strconv.Atoi(group.Gid)
+	}
+
+	if err := os.Chown(path, uid, gid); err != nil {
+		return errors.Wrapf(err, "unable to set the owner of the unix socket %s", path)
+	}
+
+	return nil
+}
+
+func lookupUser(owner string) (*user.User, error) {
+	if _, err := strconv.Atoi(owner); err == nil {
+		if u, err := user.LookupId(owner); err == nil {
+			return u, nil
+		}
+
+		// the identifiers unknown to the system are still valid owners, e.g. the ones of the users of the host
+		return &user.User{Uid: owner, Gid: "-1"}, nil
+	}
+
+	u, err := user.Lookup(owner)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find the socket owner %s", owner)
+	}
+
+	return u, nil
+}
+
+func lookupGroup(group string) (*user.Group, error) {
+	if _, err := strconv.Atoi(group); err == nil {
+		return &user.Group{Gid: group}, nil
+	}
+
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find the socket group %s", group)
+	}
+
+	return g, nil
+}
+
+type activatedListeners struct {
+	mu        sync.Mutex
+	listeners []net.Listener
+	names     []string
+}
+
+// activated holds the sockets passed by systemd, they are read once as the environment variables describing them
+// are removed so that they are not inherited by the child processes
+var activated = sync.OnceValues(func() (*activatedListeners, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	sockets := &activatedListeners{}
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return sockets, nil
+	}
+
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid number of sockets passed by systemd")
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	for i := 0; i < count; i++ {
+		file := os.NewFile(uintptr(systemdFirstFd+i), fmt.Sprintf("systemd-socket-%d", i))
+
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to use the socket %d passed by systemd", i)
+		}
+
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+
+		sockets.listeners = append(sockets.listeners, listener)
+		sockets.names = append(sockets.names, name)
+	}
+
+	return sockets, nil
+})
+
+// systemdListener returns the socket activated by systemd with the name, the first one not yet used when the name
+// is empty
+func systemdListener(name string) (net.Listener, error) {
+	sockets, err := activated()
+	if err != nil {
+		return nil, err
+	}
+
+	sockets.mu.Lock()
+	defer sockets.mu.Unlock()
+
+	for i, listener := range sockets.listeners {
+		if listener == nil || (name != "" && sockets.names[i] != name) {
+			continue
+		}
+
+		sockets.listeners[i] = nil
+
+		return listener, nil
+	}
+
+	if name == "" {
+		return nil, errors.New("no socket passed by systemd is available, the socket activation is not configured")
+	}
+
+	return nil, fmt.Errorf("no socket named %s was passed by systemd", name)
+}
@@ -0,0 +1,75 @@
+package sockets
+
+import (
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenUnix(t *testing.T) {
+	is := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "portainer.sock")
+
+	// the socket of a previous process is replaced
+	stale, err := net.Listen("unix", path)
+	is.NoError(err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := Listen("tcp", UnixPrefix+path, UnixOptions{Mode: 0o600, Group: strconv.Itoa(os.Getgid())})
+	if !is.NoError(err) {
+		return
+	}
+	defer listener.Close()
+
+	info, err := os.Stat(path)
+	is.NoError(err)
+	is.Equal(fs.FileMode(0o600), info.Mode().Perm())
+
+	go http.Serve(listener, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	}))
+
+	client := &http.Client{Transport: &http.Transport{
+		Dial: func(_, _ string) (net.Conn, error) { return net.Dial("unix", path) },
+	}}
+
+	resp, err := client.Get("http://portainer/ping")
+	if is.NoError(err) {
+		resp.Body.Close()
+		is.Equal(http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestListenUnix_KeepsRegularFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "portainer.sock")
+	assert.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+
+	_, err := Listen("tcp", UnixPrefix+path, UnixOptions{})
+	assert.Error(t, err)
+}
+
+func TestListen_WithoutSystemdActivation(t *testing.T) {
+	_, err := Listen("tcp", SystemdPrefix, UnixOptions{})
+	assert.Error(t, err)
+}
+
+func TestParseMode(t *testing.T) {
+	is := assert.New(t)
+
+	mode, err := ParseMode("0660")
+	is.NoError(err)
+	is.Equal(fs.FileMode(0o660), mode)
+
+	for _, invalid := range []string{"", "rw", "0999", "17777"} {
+		_, err := ParseMode(invalid)
+		is.Error(err, "mode %q", invalid)
+	}
+}
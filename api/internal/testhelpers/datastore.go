@@ -10,31 +10,47 @@ import (
 )
 
 type testDatastore struct {
-	customTemplate          dataservices.CustomTemplateService
-	edgeGroup               dataservices.EdgeGroupService
-	edgeJob                 dataservices.EdgeJobService
-	edgeStack               dataservices.EdgeStackService
-	endpoint                dataservices.EndpointService
-	endpointGroup           dataservices.EndpointGroupService
-	endpointRelation        dataservices.EndpointRelationService
-	fdoProfile              dataservices.FDOProfileService
-	helmUserRepository      dataservices.HelmUserRepositoryService
-	registry                dataservices.RegistryService
-	resourceControl         dataservices.ResourceControlService
-	apiKeyRepositoryService dataservices.APIKeyRepository
-	role                    dataservices.RoleService
-	sslSettings             dataservices.SSLSettingsService
-	settings                dataservices.SettingsService
-	snapshot                dataservices.SnapshotService
-	stack                   dataservices.StackService
-	tag                     dataservices.TagService
-	teamMembership          dataservices.TeamMembershipService
-	team                    dataservices.TeamService
-	tunnelServer            dataservices.TunnelServerService
-	user                    dataservices.UserService
-	version                 dataservices.VersionService
-	webhook                 dataservices.WebhookService
-	pendingActionsService   dataservices.PendingActionsService
+	automationWebhook         dataservices.AutomationWebhookService
+	backgroundJob             dataservices.BackgroundJobService
+	changeRequest             dataservices.ChangeRequestService
+	cmdbConnector             dataservices.CMDBConnectorService
+	costReport                dataservices.CostReportService
+	customTemplate            dataservices.CustomTemplateService
+	edgeGroup                 dataservices.EdgeGroupService
+	edgeJob                   dataservices.EdgeJobService
+	edgeStack                 dataservices.EdgeStackService
+	endpoint                  dataservices.EndpointService
+	endpointGroup             dataservices.EndpointGroupService
+	endpointRelation          dataservices.EndpointRelationService
+	endpointRegistrationToken dataservices.EndpointRegistrationTokenService
+	eventWebhook              dataservices.EventWebhookService
+	dockerOperationAudit      dataservices.DockerOperationAuditService
+	fdoProfile                dataservices.FDOProfileService
+	helmUserRepository        dataservices.HelmUserRepositoryService
+	imageUpdatePolicy         dataservices.ImageUpdatePolicyService
+	maintenanceWindow         dataservices.MaintenanceWindowService
+	note                      dataservices.NoteService
+	ownershipRule             dataservices.OwnershipRuleService
+	registry                  dataservices.RegistryService
+	resourceControl           dataservices.ResourceControlService
+	apiKeyRepositoryService   dataservices.APIKeyRepository
+	role                      dataservices.RoleService
+	savedView                 dataservices.SavedViewService
+	sslSettings               dataservices.SSLSettingsService
+	settings                  dataservices.SettingsService
+	snapshot                  dataservices.SnapshotService
+	stack                     dataservices.StackService
+	securityAlert             dataservices.SecurityAlertService
+	stackBundle               dataservices.StackBundleService
+	tag                       dataservices.TagService
+	teamMembership            dataservices.TeamMembershipService
+	team                      dataservices.TeamService
+	tunnelServer              dataservices.TunnelServerService
+	user                      dataservices.UserService
+	version                   dataservices.VersionService
+	volumeBackupJob           dataservices.VolumeBackupJobService
+	webhook                   dataservices.WebhookService
+	pendingActionsService     dataservices.PendingActionsService
 }
 
 func (d *testDatastore) BackupTo(io.Writer) error                            { return nil }
@@ -44,9 +60,16 @@ func (d *testDatastore) Close() error                                        { r
 func (d *testDatastore) UpdateTx(func(dataservices.DataStoreTx) error) error { return nil }
 func (d *testDatastore) ViewTx(func(dataservices.DataStoreTx) error) error   { return nil }
 
-func (d *testDatastore) CheckCurrentEdition() error                         { return nil }
-func (d *testDatastore) MigrateData() error                                 { return nil }
-func (d *testDatastore) Rollback(force bool) error                          { return nil }
+func (d *testDatastore) CheckCurrentEdition() error { return nil }
+func (d *testDatastore) MigrateData() error         { return nil }
+func (d *testDatastore) Rollback(force bool) error  { return nil }
+func (d *testDatastore) AutomationWebhook() dataservices.AutomationWebhookService {
+	return d.automationWebhook
+}
+func (d *testDatastore) BackgroundJob() dataservices.BackgroundJobService   { return d.backgroundJob }
+func (d *testDatastore) ChangeRequest() dataservices.ChangeRequestService   { return d.changeRequest }
+func (d *testDatastore) CMDBConnector() dataservices.CMDBConnectorService   { return d.cmdbConnector }
+func (d *testDatastore) CostReport() dataservices.CostReportService         { return d.costReport }
 func (d *testDatastore) CustomTemplate() dataservices.CustomTemplateService { return d.customTemplate }
 func (d *testDatastore) EdgeGroup() dataservices.EdgeGroupService           { return d.edgeGroup }
 func (d *testDatastore) EdgeJob() dataservices.EdgeJobService               { return d.edgeJob }
@@ -54,6 +77,18 @@ func (d *testDatastore) EdgeStack() dataservices.EdgeStackService           { re
 func (d *testDatastore) Endpoint() dataservices.EndpointService             { return d.endpoint }
 func (d *testDatastore) EndpointGroup() dataservices.EndpointGroupService   { return d.endpointGroup }
 
+func (d *testDatastore) EndpointRegistrationToken() dataservices.EndpointRegistrationTokenService {
+	return d.endpointRegistrationToken
+}
+
+func (d *testDatastore) DockerOperationAudit() dataservices.DockerOperationAuditService {
+	return d.dockerOperationAudit
+}
+
+func (d *testDatastore) EventWebhook() dataservices.EventWebhookService {
+	return d.eventWebhook
+}
+
 func (d *testDatastore) FDOProfile() dataservices.FDOProfileService {
 	return d.fdoProfile
 }
@@ -63,11 +98,24 @@ func (d *testDatastore) EndpointRelation() dataservices.EndpointRelationService
 func (d *testDatastore) HelmUserRepository() dataservices.HelmUserRepositoryService {
 	return d.helmUserRepository
 }
+func (d *testDatastore) ImageUpdatePolicy() dataservices.ImageUpdatePolicyService {
+	return d.imageUpdatePolicy
+}
+func (d *testDatastore) MaintenanceWindow() dataservices.MaintenanceWindowService {
+	return d.maintenanceWindow
+}
+func (d *testDatastore) Note() dataservices.NoteService { return d.note }
+func (d *testDatastore) OwnershipRule() dataservices.OwnershipRuleService {
+	return d.ownershipRule
+}
 func (d *testDatastore) Registry() dataservices.RegistryService { return d.registry }
 func (d *testDatastore) ResourceControl() dataservices.ResourceControlService {
 	return d.resourceControl
 }
 func (d *testDatastore) Role() dataservices.RoleService { return d.role }
+func (d *testDatastore) SavedView() dataservices.SavedViewService {
+	return d.savedView
+}
 func (d *testDatastore) APIKeyRepository() dataservices.APIKeyRepository {
 	return d.apiKeyRepositoryService
 }
@@ -75,6 +123,8 @@ func (d *testDatastore) Settings() dataservices.SettingsService             { re
 func (d *testDatastore) Snapshot() dataservices.SnapshotService             { return d.snapshot }
 func (d *testDatastore) SSLSettings() dataservices.SSLSettingsService       { return d.sslSettings }
 func (d *testDatastore) Stack() dataservices.StackService                   { return d.stack }
+func (d *testDatastore) SecurityAlert() dataservices.SecurityAlertService   { return d.securityAlert }
+func (d *testDatastore) StackBundle() dataservices.StackBundleService       { return d.stackBundle }
 func (d *testDatastore) Tag() dataservices.TagService                       { return d.tag }
 func (d *testDatastore) TeamMembership() dataservices.TeamMembershipService { return d.teamMembership }
 func (d *testDatastore) Team() dataservices.TeamService                     { return d.team }
@@ -82,6 +132,9 @@ func (d *testDatastore) TunnelServer() dataservices.TunnelServerService     { re
 func (d *testDatastore) User() dataservices.UserService                     { return d.user }
 func (d *testDatastore) Version() dataservices.VersionService               { return d.version }
 func (d *testDatastore) Webhook() dataservices.WebhookService               { return d.webhook }
+func (d *testDatastore) VolumeBackupJob() dataservices.VolumeBackupJobService {
+	return d.volumeBackupJob
+}
 
 func (d *testDatastore) PendingActions() dataservices.PendingActionsService {
 	return d.pendingActionsService
@@ -123,6 +176,15 @@ func (s *stubSettingsService) UpdateSettings(settings *portainer.Settings) error
 	s.settings = settings
 	return nil
 }
+func (s *stubSettingsService) CreateVersion(settings *portainer.Settings) error {
+	return nil
+}
+func (s *stubSettingsService) History() ([]portainer.SettingsVersion, error) {
+	return []portainer.SettingsVersion{}, nil
+}
+func (s *stubSettingsService) Version(ID portainer.SettingsVersionID) (*portainer.SettingsVersion, error) {
+	return nil, errors.ErrObjectNotFound
+}
 func WithSettingsService(settings *portainer.Settings) datastoreOption {
 	return func(d *testDatastore) {
 		d.settings = &stubSettingsService{
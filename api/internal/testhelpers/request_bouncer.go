@@ -42,6 +42,10 @@ func (testRequestBouncer) AuthorizedEndpointOperation(r *http.Request, endpoint
 	return nil
 }
 
+func (testRequestBouncer) AuthorizedEndpointProxyOperation(r *http.Request, endpoint *portainer.Endpoint) error {
+	return nil
+}
+
 func (testRequestBouncer) AuthorizedEdgeEndpointOperation(r *http.Request, endpoint *portainer.Endpoint) error {
 	return nil
 }
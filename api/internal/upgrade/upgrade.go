@@ -2,6 +2,7 @@ package upgrade
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/pkg/errors"
 	portainer "github.com/portainer/portainer/api"
@@ -27,6 +28,8 @@ const (
 
 type Service interface {
 	Upgrade(environment *portainer.Endpoint, licenseKey string) error
+	// UpgradeVersion recreates the Portainer container with the image of the version, keeping the edition
+	UpgradeVersion(version string) error
 }
 
 type service struct {
@@ -60,14 +63,45 @@ func NewService(
 func (service *service) Upgrade(environment *portainer.Endpoint, licenseKey string) error {
 	service.isUpdating = true
 
+	image := fmt.Sprintf("%s:%s", imagePrefix("portainer/portainer-ee"), portainer.APIVersion)
+
 	switch service.platform {
 	case platform.PlatformDockerStandalone:
-		return service.upgradeDocker(licenseKey, portainer.APIVersion, "standalone")
+		return service.upgradeDocker(image, licenseKey, portainer.APIVersion, "standalone", false)
 	case platform.PlatformDockerSwarm:
-		return service.upgradeDocker(licenseKey, portainer.APIVersion, "swarm")
+		return service.upgradeDocker(image, licenseKey, portainer.APIVersion, "swarm", false)
 	case platform.PlatformKubernetes:
 		return service.upgradeKubernetes(environment, licenseKey, portainer.APIVersion)
 	}
 
 	return fmt.Errorf("unsupported platform %s", service.platform)
 }
+
+func (service *service) UpgradeVersion(version string) error {
+	service.isUpdating = true
+
+	defaultImagePrefix := "portainer/portainer-ce"
+	if portainer.Edition != portainer.PortainerCE {
+		defaultImagePrefix = "portainer/portainer-ee"
+	}
+
+	image := fmt.Sprintf("%s:%s", imagePrefix(defaultImagePrefix), version)
+
+	switch service.platform {
+	case platform.PlatformDockerStandalone:
+		return service.upgradeDocker(image, "", version, "standalone", true)
+	case platform.PlatformDockerSwarm:
+		return service.upgradeDocker(image, "", version, "swarm", true)
+	}
+
+	return fmt.Errorf("in-place upgrade is only supported when Portainer runs as a Docker container, not on platform %q", service.platform)
+}
+
+// imagePrefix returns the repository of the Portainer image, which can be overridden to test PR images
+func imagePrefix(defaultPrefix string) string {
+	if prefix := os.Getenv(portainerImagePrefixEnvVar); prefix != "" {
+		return prefix
+	}
+
+	return defaultPrefix
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/portainer/portainer/api/filesystem"
 	"github.com/portainer/portainer/pkg/libstack"
 
@@ -19,20 +21,19 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func (service *service) upgradeDocker(licenseKey, version, envType string) error {
+// upgradeDocker deploys the updater which recreates the Portainer container with the image, keeping its volumes
+// and flags. When pull is set, the image is pulled beforehand so that the downtime does not include the pull.
+func (service *service) upgradeDocker(image, licenseKey, version, envType string, pull bool) error {
 	ctx := context.TODO()
 	templateName := filesystem.JoinPaths(service.assetsPath, "mustache-templates", mustacheUpgradeDockerTemplateFile)
 
-	portainerImagePrefix := os.Getenv(portainerImagePrefixEnvVar)
-	if portainerImagePrefix == "" {
-		portainerImagePrefix = "portainer/portainer-ee"
-	}
-
-	image := fmt.Sprintf("%s:%s", portainerImagePrefix, version)
-
 	skipPullImage := os.Getenv(skipPullImageEnvVar)
 
-	if err := service.checkImageForDocker(ctx, image, skipPullImage != ""); err != nil {
+	if pull && skipPullImage == "" {
+		if err := service.pullImageForDocker(ctx, image); err != nil {
+			return err
+		}
+	} else if err := service.checkImageForDocker(ctx, image, skipPullImage != ""); err != nil {
 		return err
 	}
 
@@ -123,3 +124,27 @@ func (service *service) checkImageForDocker(ctx context.Context, image string, s
 		return nil
 	}
 }
+
+func (service *service) pullImageForDocker(ctx context.Context, image string) error {
+	cli, err := client.NewClientWithOpts(
+		client.FromEnv,
+		client.WithAPIVersionNegotiation(),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create docker client")
+	}
+	defer cli.Close()
+
+	reader, err := cli.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to pull image %s", image)
+	}
+	defer reader.Close()
+
+	// the pull errors are reported in the stream
+	if err := jsonmessage.DisplayJSONMessagesStream(reader, io.Discard, 0, false, nil); err != nil {
+		return errors.Wrapf(err, "failed to pull image %s", image)
+	}
+
+	return nil
+}
@@ -0,0 +1,389 @@
+// Package intrusion detects the suspicious authentication events: the logins from a new IP address or country, the
+// successful logins following many failed ones and the session tokens used from several IP addresses at the same time.
+// The events are recorded as security alerts reviewed by the administrators, and published as lifecycle events.
+package intrusion
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/requestutils"
+	"github.com/portainer/portainer/api/lifecycle"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultFailedLoginThreshold = 5
+	defaultFailedLoginWindow    = 15 * time.Minute
+	// concurrentUseWindow is the interval within which the uses of a session token from two IP addresses are
+	// considered simultaneous
+	concurrentUseWindow = 2 * time.Minute
+	// maxLoginSources is the number of IP addresses remembered for each user, the least recently used being
+	// forgotten first
+	maxLoginSources = 50
+	// sweepInterval is the interval at which the failed logins and the token uses which expired are removed
+	sweepInterval = time.Minute
+)
+
+// EventPublisher publishes the lifecycle events of the security alerts
+type EventPublisher interface {
+	Publish(event lifecycle.Event)
+}
+
+// tokenUses are the IP addresses a session token was recently used from
+type tokenUses struct {
+	addresses map[string]time.Time
+	alerted   bool
+}
+
+// Detector detects the suspicious authentication events
+type Detector struct {
+	dataStore dataservices.DataStore
+	geoIP     *GeoIPDatabase
+	publisher EventPublisher
+	settings  atomic.Pointer[portainer.IntrusionDetectionSettings]
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  map[string][]time.Time
+	tokens    map[string]*tokenUses
+	lastSweep time.Time
+}
+
+// NewDetector creates a detector recording the security alerts in the data store and publishing them with publisher.
+// The logins from a new country are only detected when a GeoIP database is set.
+func NewDetector(settings portainer.IntrusionDetectionSettings, dataStore dataservices.DataStore, geoIP *GeoIPDatabase, publisher EventPublisher) *Detector {
+	detector := &Detector{
+		dataStore: dataStore,
+		geoIP:     geoIP,
+		publisher: publisher,
+		now:       time.Now,
+		failures:  make(map[string][]time.Time),
+		tokens:    make(map[string]*tokenUses),
+	}
+	detector.Update(settings)
+
+	return detector
+}
+
+// Update replaces the intrusion detection settings, which must have been validated
+func (detector *Detector) Update(settings portainer.IntrusionDetectionSettings) {
+	detector.settings.Store(&settings)
+}
+
+// LoginFailed records a failed login of a user
+func (detector *Detector) LoginFailed(r *http.Request, username string) {
+	settings := detector.settings.Load()
+	if !settings.Enabled {
+		return
+	}
+
+	detector.mu.Lock()
+	defer detector.mu.Unlock()
+
+	now := detector.now()
+	detector.sweep(settings, now)
+
+	key := strings.ToLower(username)
+	detector.failures[key] = append(recentFailures(detector.failures[key], failedLoginWindow(settings), now), now)
+}
+
+// LoginSucceeded checks a successful login of a user against their failed logins and the IP addresses they logged in
+// from, then remembers its IP address
+func (detector *Detector) LoginSucceeded(r *http.Request, user *portainer.User) {
+	settings := detector.settings.Load()
+	if !settings.Enabled {
+		return
+	}
+
+	address := requestutils.RemoteIP(r)
+	now := detector.now()
+
+	detector.mu.Lock()
+	failures := recentFailures(detector.failures[strings.ToLower(user.Username)], failedLoginWindow(settings), now)
+	delete(detector.failures, strings.ToLower(user.Username))
+	detector.mu.Unlock()
+
+	if len(failures) >= failedLoginThreshold(settings) {
+		detector.raise(&portainer.SecurityAlert{
+			Type:     portainer.SecurityAlertLoginAfterFailures,
+			UserID:   user.ID,
+			Username: user.Username,
+			Address:  address,
+			Country:  detector.geoIP.Country(address),
+			Details:  map[string]string{"failedLogins": strconv.Itoa(len(failures))},
+		})
+	}
+
+	country := detector.geoIP.Country(address)
+
+	var alert *portainer.SecurityAlert
+	err := detector.dataStore.UpdateTx(func(tx dataservices.DataStoreTx) error {
+		alert = nil
+
+		current, err := tx.User().Read(user.ID)
+		if err != nil {
+			return err
+		}
+
+		if alertType, ok := NewSourceAlert(current.KnownLoginSources, address, country, settings.AlertNewAddress); ok {
+			alert = &portainer.SecurityAlert{
+				Type:     alertType,
+				UserID:   current.ID,
+				Username: current.Username,
+				Address:  address,
+				Country:  country,
+				Details:  sourcesDetails(current.KnownLoginSources),
+			}
+		}
+
+		current.KnownLoginSources = RecordSource(current.KnownLoginSources, address, country, now)
+
+		return tx.User().Update(current.ID, current)
+	})
+	if err != nil {
+		log.Warn().Err(err).Int("user_id", int(user.ID)).Msg("unable to record the IP address of the login")
+		return
+	}
+
+	if alert != nil {
+		detector.raise(alert)
+	}
+}
+
+// TokenUsed records a request authenticated by a session token, identified by its digest, and raises an alert the
+// first time the token is used from several IP addresses at the same time
+func (detector *Detector) TokenUsed(r *http.Request, tokenData *portainer.TokenData, tokenDigest string) {
+	settings := detector.settings.Load()
+	if !settings.Enabled {
+		return
+	}
+
+	address := requestutils.RemoteIP(r)
+	now := detector.now()
+
+	detector.mu.Lock()
+	detector.sweep(settings, now)
+
+	uses := detector.tokens[tokenDigest]
+	if uses == nil {
+		uses = &tokenUses{addresses: make(map[string]time.Time)}
+		detector.tokens[tokenDigest] = uses
+	}
+
+	uses.addresses[address] = now
+
+	var addresses []string
+	for usedFrom, lastUse := range uses.addresses {
+		if now.Sub(lastUse) <= concurrentUseWindow {
+			addresses = append(addresses, usedFrom)
+		}
+	}
+
+	alert := len(addresses) > 1 && !uses.alerted
+	if alert {
+		uses.alerted = true
+	}
+	detector.mu.Unlock()
+
+	if !alert {
+		return
+	}
+
+	sort.Strings(addresses)
+
+	detector.raise(&portainer.SecurityAlert{
+		Type:     portainer.SecurityAlertTokenMultipleAddresses,
+		UserID:   tokenData.ID,
+		Username: tokenData.Username,
+		Address:  address,
+		Country:  detector.geoIP.Country(address),
+		Details:  map[string]string{"addresses": strings.Join(addresses, ", ")},
+	})
+}
+
+// raise records a security alert and publishes it
+func (detector *Detector) raise(alert *portainer.SecurityAlert) {
+	alert.CreatedAt = detector.now().Unix()
+	alert.Status = portainer.SecurityAlertOpen
+
+	if err := detector.dataStore.SecurityAlert().Create(alert); err != nil {
+		log.Error().Err(err).Str("type", string(alert.Type)).Msg("unable to record the security alert")
+		return
+	}
+
+	log.Warn().
+		Int("alert_id", int(alert.ID)).
+		Str("type", string(alert.Type)).
+		Str("user", alert.Username).
+		Str("address", alert.Address).
+		Msg("suspicious authentication event")
+
+	if detector.publisher == nil {
+		return
+	}
+
+	data := map[string]string{
+		"type":     string(alert.Type),
+		"userId":   strconv.Itoa(int(alert.UserID)),
+		"username": alert.Username,
+		"address":  alert.Address,
+	}
+
+	if alert.Country != "" {
+		data["country"] = alert.Country
+	}
+
+	for key, value := range alert.Details {
+		data[key] = value
+	}
+
+	detector.publisher.Publish(lifecycle.NewEvent(lifecycle.SecurityAlert, strconv.Itoa(int(alert.ID)), data))
+}
+
+// sweep removes the failed logins and the token uses which expired. The lock must be held.
+func (detector *Detector) sweep(settings *portainer.IntrusionDetectionSettings, now time.Time) {
+	if now.Sub(detector.lastSweep) < sweepInterval {
+		return
+	}
+
+	detector.lastSweep = now
+
+	for key, failures := range detector.failures {
+		if len(recentFailures(failures, failedLoginWindow(settings), now)) == 0 {
+			delete(detector.failures, key)
+		}
+	}
+
+	for digest, uses := range detector.tokens {
+		for address, lastUse := range uses.addresses {
+			if now.Sub(lastUse) > concurrentUseWindow {
+				delete(uses.addresses, address)
+			}
+		}
+
+		// the tokens alerted on are kept while they are used, so that they are alerted on once
+		if len(uses.addresses) == 0 {
+			delete(detector.tokens, digest)
+		}
+	}
+}
+
+// NewSourceAlert returns the alert raised by a login from an IP address and a country, if any. The first login of a
+// user raises no alert, and the logins from a new country are only detected once the countries of the previous
+// logins are known.
+func NewSourceAlert(sources []portainer.LoginSource, address, country string, alertNewAddress bool) (portainer.SecurityAlertType, bool) {
+	if len(sources) == 0 {
+		return "", false
+	}
+
+	if country != "" {
+		knownCountry, locatedSources := false, false
+		for _, source := range sources {
+			locatedSources = locatedSources || source.Country != ""
+			knownCountry = knownCountry || source.Country == country
+		}
+
+		if locatedSources && !knownCountry {
+			return portainer.SecurityAlertNewCountry, true
+		}
+	}
+
+	knownAddress := slices.ContainsFunc(sources, func(source portainer.LoginSource) bool {
+		return source.Address == address
+	})
+
+	if alertNewAddress && !knownAddress {
+		return portainer.SecurityAlertNewAddress, true
+	}
+
+	return "", false
+}
+
+// RecordSource remembers a login from an IP address, forgetting the least recently used addresses beyond the
+// maximum number of addresses
+func RecordSource(sources []portainer.LoginSource, address, country string, now time.Time) []portainer.LoginSource {
+	i := slices.IndexFunc(sources, func(source portainer.LoginSource) bool {
+		return source.Address == address
+	})
+
+	if i >= 0 {
+		sources[i].LastSeen = now.Unix()
+		if country != "" {
+			sources[i].Country = country
+		}
+	} else {
+		sources = append(sources, portainer.LoginSource{Address: address, Country: country, FirstSeen: now.Unix(), LastSeen: now.Unix()})
+	}
+
+	if len(sources) <= maxLoginSources {
+		return sources
+	}
+
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].LastSeen > sources[j].LastSeen
+	})
+
+	return sources[:maxLoginSources]
+}
+
+// sourcesDetails lists the countries the user logged in from in the details of an alert
+func sourcesDetails(sources []portainer.LoginSource) map[string]string {
+	var countries []string
+	for _, source := range sources {
+		if source.Country != "" && !slices.Contains(countries, source.Country) {
+			countries = append(countries, source.Country)
+		}
+	}
+
+	if len(countries) == 0 {
+		return nil
+	}
+
+	sort.Strings(countries)
+
+	return map[string]string{"knownCountries": strings.Join(countries, ", ")}
+}
+
+func recentFailures(failures []time.Time, window time.Duration, now time.Time) []time.Time {
+	i := sort.Search(len(failures), func(i int) bool {
+		return now.Sub(failures[i]) <= window
+	})
+
+	return failures[i:]
+}
+
+func failedLoginThreshold(settings *portainer.IntrusionDetectionSettings) int {
+	if settings.FailedLoginThreshold <= 0 {
+		return defaultFailedLoginThreshold
+	}
+
+	return settings.FailedLoginThreshold
+}
+
+func failedLoginWindow(settings *portainer.IntrusionDetectionSettings) time.Duration {
+	if settings.FailedLoginWindow <= 0 {
+		return defaultFailedLoginWindow
+	}
+
+	return time.Duration(settings.FailedLoginWindow) * time.Minute
+}
+
+// ValidateSettings checks that the thresholds of the intrusion detection are not negative
+func ValidateSettings(settings portainer.IntrusionDetectionSettings) error {
+	if settings.FailedLoginThreshold < 0 || settings.FailedLoginWindow < 0 {
+		return errors.New("invalid intrusion detection, the failed login threshold and window must not be negative")
+	}
+
+	return nil
+}
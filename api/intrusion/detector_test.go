@@ -0,0 +1,201 @@
+package intrusion
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/lifecycle"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type eventRecorder struct {
+	events []lifecycle.Event
+}
+
+func (recorder *eventRecorder) Publish(event lifecycle.Event) {
+	recorder.events = append(recorder.events, event)
+}
+
+func request(address string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/auth", nil)
+	r.RemoteAddr = address + ":51234"
+
+	return r
+}
+
+func TestDetector(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	user := &portainer.User{Username: "alice", Role: portainer.StandardUserRole}
+	is.NoError(store.User().Create(user))
+
+	geoIP, err := ParseGeoIPDatabase(strings.NewReader("81.2.69.0,81.2.69.255,GB\n1.0.0.0,1.0.0.255,AU\n"))
+	is.NoError(err)
+
+	recorder := &eventRecorder{}
+	detector := NewDetector(portainer.IntrusionDetectionSettings{}, store, geoIP, recorder)
+
+	now := time.Unix(1700000000, 0)
+	detector.now = func() time.Time { return now }
+
+	alerts := func() []portainer.SecurityAlert {
+		alerts, err := store.SecurityAlert().ReadAll()
+		is.NoError(err)
+
+		return alerts
+	}
+
+	detector.LoginSucceeded(request("81.2.69.10"), user)
+	is.Empty(alerts(), "nothing should be detected by default")
+
+	detector.Update(portainer.IntrusionDetectionSettings{Enabled: true, FailedLoginThreshold: 3})
+
+	t.Run("the first login only records its IP address", func(t *testing.T) {
+		detector.LoginSucceeded(request("81.2.69.10"), user)
+		is.Empty(alerts())
+
+		current, err := store.User().Read(user.ID)
+		is.NoError(err)
+		is.Equal([]portainer.LoginSource{{Address: "81.2.69.10", Country: "GB", FirstSeen: now.Unix(), LastSeen: now.Unix()}}, current.KnownLoginSources)
+	})
+
+	t.Run("a new address is only alerted on when enabled", func(t *testing.T) {
+		detector.LoginSucceeded(request("81.2.69.20"), user)
+		is.Empty(alerts())
+	})
+
+	t.Run("a login from a new country is alerted on", func(t *testing.T) {
+		detector.LoginSucceeded(request("1.0.0.1"), user)
+
+		if is.Len(alerts(), 1) {
+			alert := alerts()[0]
+			is.Equal(portainer.SecurityAlertNewCountry, alert.Type)
+			is.Equal(user.ID, alert.UserID)
+			is.Equal("1.0.0.1", alert.Address)
+			is.Equal("AU", alert.Country)
+			is.Equal(portainer.SecurityAlertOpen, alert.Status)
+			is.Equal(map[string]string{"knownCountries": "GB"}, alert.Details)
+		}
+
+		if is.Len(recorder.events, 1) {
+			is.Equal(lifecycle.SecurityAlert, recorder.events[0].Type)
+			is.Equal("login.new_country", recorder.events[0].Data["type"])
+			is.Equal("alice", recorder.events[0].Data["username"])
+		}
+
+		detector.LoginSucceeded(request("1.0.0.1"), user)
+		is.Len(alerts(), 1, "a known country should not be alerted on again")
+	})
+
+	t.Run("a login after many failures is alerted on", func(t *testing.T) {
+		detector.LoginFailed(request("203.0.113.5"), "Alice")
+		detector.LoginFailed(request("203.0.113.5"), "alice")
+		detector.LoginSucceeded(request("81.2.69.10"), user)
+		is.Len(alerts(), 1, "the failures below the threshold should not be alerted on")
+
+		for i := 0; i < 3; i++ {
+			detector.LoginFailed(request("203.0.113.5"), "alice")
+		}
+
+		now = now.Add(20 * time.Minute)
+		detector.LoginSucceeded(request("81.2.69.10"), user)
+		is.Len(alerts(), 1, "the failures out of the window should not be alerted on")
+
+		for i := 0; i < 4; i++ {
+			detector.LoginFailed(request("203.0.113.5"), "alice")
+		}
+
+		detector.LoginSucceeded(request("81.2.69.10"), user)
+		if is.Len(alerts(), 2) {
+			alert := alerts()[1]
+			is.Equal(portainer.SecurityAlertLoginAfterFailures, alert.Type)
+			is.Equal("4", alert.Details["failedLogins"])
+		}
+	})
+
+	t.Run("a session token used from two addresses is alerted on once", func(t *testing.T) {
+		tokenData := &portainer.TokenData{ID: user.ID, Username: user.Username}
+
+		detector.TokenUsed(request("81.2.69.10"), tokenData, "digest-1")
+		detector.TokenUsed(request("81.2.69.10"), tokenData, "digest-1")
+		detector.TokenUsed(request("1.0.0.1"), tokenData, "digest-2")
+		is.Len(alerts(), 2)
+
+		now = now.Add(time.Minute)
+		detector.TokenUsed(request("198.51.100.7"), tokenData, "digest-1")
+		if is.Len(alerts(), 3) {
+			alert := alerts()[2]
+			is.Equal(portainer.SecurityAlertTokenMultipleAddresses, alert.Type)
+			is.Equal("198.51.100.7", alert.Address)
+			is.Equal("198.51.100.7, 81.2.69.10", alert.Details["addresses"])
+		}
+
+		detector.TokenUsed(request("81.2.69.10"), tokenData, "digest-1")
+		is.Len(alerts(), 3)
+
+		now = now.Add(5 * time.Minute)
+		detector.TokenUsed(request("1.0.0.2"), tokenData, "digest-2")
+		is.Len(alerts(), 3, "the uses out of the window should not be simultaneous")
+	})
+}
+
+func TestNewSourceAlert(t *testing.T) {
+	is := assert.New(t)
+
+	sources := []portainer.LoginSource{{Address: "81.2.69.10", Country: "GB"}}
+
+	_, ok := NewSourceAlert(nil, "1.0.0.1", "AU", true)
+	is.False(ok, "the first login should not be alerted on")
+
+	alertType, ok := NewSourceAlert(sources, "81.2.69.20", "GB", true)
+	is.True(ok)
+	is.Equal(portainer.SecurityAlertNewAddress, alertType)
+
+	_, ok = NewSourceAlert(sources, "81.2.69.10", "GB", true)
+	is.False(ok)
+
+	_, ok = NewSourceAlert([]portainer.LoginSource{{Address: "81.2.69.10"}}, "1.0.0.1", "AU", false)
+	is.False(ok, "a new country should not be alerted on before the countries of the logins are known")
+}
+
+func TestRecordSource(t *testing.T) {
+	is := assert.New(t)
+
+	start := time.Unix(1700000000, 0)
+
+	var sources []portainer.LoginSource
+	for i := 0; i < maxLoginSources+5; i++ {
+		sources = RecordSource(sources, fmt.Sprintf("10.0.0.%d", i), "", start.Add(time.Duration(i)*time.Minute))
+	}
+
+	is.Len(sources, maxLoginSources)
+	is.Equal("10.0.0.54", sources[0].Address, "the least recently used addresses should be forgotten")
+
+	sources = RecordSource(sources, "10.0.0.10", "GB", start.Add(time.Hour))
+	is.Len(sources, maxLoginSources)
+
+	i := slices.IndexFunc(sources, func(source portainer.LoginSource) bool { return source.Address == "10.0.0.10" })
+	if is.GreaterOrEqual(i, 0) {
+		is.Equal("GB", sources[i].Country)
+		is.Equal(start.Add(time.Hour).Unix(), sources[i].LastSeen)
+		is.Equal(start.Add(10*time.Minute).Unix(), sources[i].FirstSeen)
+	}
+}
+
+func TestValidateSettings(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateSettings(portainer.IntrusionDetectionSettings{Enabled: true}))
+	is.Error(ValidateSettings(portainer.IntrusionDetectionSettings{FailedLoginThreshold: -1}))
+	is.Error(ValidateSettings(portainer.IntrusionDetectionSettings{FailedLoginWindow: -5}))
+}
@@ -0,0 +1,115 @@
+package intrusion
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ipRange is a range of IP addresses located in a country
+type ipRange struct {
+	start   netip.Addr
+	end     netip.Addr
+	country string
+}
+
+// GeoIPDatabase locates the IP addresses in their country
+type GeoIPDatabase struct {
+	ranges []ipRange
+}
+
+// LoadGeoIPDatabase loads a CSV database of the countries of the IP address ranges, with start_ip,end_ip,country_code
+// rows such as the DB-IP IP to Country Lite database. The rows which are not IP address ranges, such as a header, are
+// skipped.
+func LoadGeoIPDatabase(path string) (*GeoIPDatabase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseGeoIPDatabase(file)
+}
+
+// ParseGeoIPDatabase parses a CSV database of the countries of the IP address ranges
+func ParseGeoIPDatabase(r io.Reader) (*GeoIPDatabase, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	db := &GeoIPDatabase{}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("unable to read the GeoIP database: %w", err)
+		}
+
+		if len(record) < 3 {
+			continue
+		}
+
+		start, err := netip.ParseAddr(strings.TrimSpace(record[0]))
+		if err != nil {
+			continue
+		}
+
+		end, err := netip.ParseAddr(strings.TrimSpace(record[1]))
+		if err != nil || start.Is4() != end.Is4() || end.Less(start) {
+			continue
+		}
+
+		country := strings.ToUpper(strings.TrimSpace(record[2]))
+		if country == "" || country == "ZZ" {
+			continue
+		}
+
+		db.ranges = append(db.ranges, ipRange{start: start.Unmap(), end: end.Unmap(), country: country})
+	}
+
+	if len(db.ranges) == 0 {
+		return nil, errors.New("the GeoIP database does not contain any IP address range")
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return db.ranges[i].start.Less(db.ranges[j].start)
+	})
+
+	return db, nil
+}
+
+// Country returns the ISO code of the country of an IP address, or an empty string when it is not located
+func (db *GeoIPDatabase) Country(address string) string {
+	if db == nil {
+		return ""
+	}
+
+	ip, err := netip.ParseAddr(address)
+	if err != nil {
+		return ""
+	}
+
+	ip = ip.Unmap()
+
+	// the range of the address is the last one starting before it
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return ip.Less(db.ranges[i].start)
+	})
+	if i == 0 {
+		return ""
+	}
+
+	r := db.ranges[i-1]
+	if r.end.Less(ip) || r.start.Is4() != ip.Is4() {
+		return ""
+	}
+
+	return r.country
+}
@@ -0,0 +1,37 @@
+package intrusion
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeoIPDatabase(t *testing.T) {
+	is := assert.New(t)
+
+	db, err := ParseGeoIPDatabase(strings.NewReader(`start_ip,end_ip,country_code
+81.2.69.0,81.2.69.255,GB
+1.0.0.0,1.0.0.255,au
+"2001:200::","2001:200:ffff:ffff:ffff:ffff:ffff:ffff",JP
+10.0.0.0,10.255.255.255,ZZ
+`))
+	if !is.NoError(err) {
+		return
+	}
+
+	is.Equal("GB", db.Country("81.2.69.160"))
+	is.Equal("AU", db.Country("1.0.0.1"), "the country codes should be upper case")
+	is.Equal("GB", db.Country("::ffff:81.2.69.1"), "the IPv4-mapped addresses should be located")
+	is.Equal("JP", db.Country("2001:200:1::1"))
+	is.Empty(db.Country("81.2.70.1"))
+	is.Empty(db.Country("0.0.0.1"))
+	is.Empty(db.Country("10.1.2.3"), "the unknown country should be skipped")
+	is.Empty(db.Country("not-an-address"))
+
+	var noDB *GeoIPDatabase
+	is.Empty(noDB.Country("81.2.69.160"))
+
+	_, err = ParseGeoIPDatabase(strings.NewReader("start_ip,end_ip,country_code\n"))
+	is.Error(err, "an empty database should be rejected")
+}
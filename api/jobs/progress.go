@@ -0,0 +1,208 @@
+package jobs
+
+import (
+	"context"
+	"io"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	// EventStatus is the type of the events published when the status of a job changes
+	EventStatus = "status"
+	// EventProgress is the type of the events reporting the progress of a running job
+	EventProgress = "progress"
+
+	// subscriptionBuffer is the number of events buffered for a subscriber, the progress events being dropped for the
+	// subscribers not reading them fast enough
+	subscriptionBuffer = 64
+	// minProgressInterval is the minimum interval between two progress events of a job within the same stage
+	minProgressInterval = 250 * time.Millisecond
+)
+
+// Progress represents the progress of a running job
+type Progress struct {
+	// Step of the job, such as pull, archive, upload or deploy
+	Stage string
+	// Human readable description of the current step
+	Message string
+	// Units processed so far, such as bytes, and their total when known
+	Current int64
+	Total   int64
+}
+
+// Event represents a change of the status of a job or a progress report of a running job
+type Event struct {
+	// Type of the event, either status or progress
+	Type  string                    `json:"type" example:"progress"`
+	JobID portainer.BackgroundJobID `json:"jobId" example:"1"`
+	// Status of the job, set on the status events
+	Status portainer.BackgroundJobStatus `json:"status,omitempty" example:"running"`
+	// Attempt of the job, starting at 1
+	Attempt int `json:"attempt,omitempty" example:"1"`
+	// Error of the last attempt, set on the status events
+	Error   string `json:"error,omitempty" example:"unable to reach the environment"`
+	Stage   string `json:"stage,omitempty" example:"pull"`
+	Message string `json:"message,omitempty" example:"Downloading 3 of 5 layers"`
+	Current int64  `json:"current,omitempty" example:"1048576"`
+	Total   int64  `json:"total,omitempty" example:"4194304"`
+	// Unix timestamp of the event, in milliseconds
+	Time int64 `json:"time" example:"1700000000000"`
+}
+
+// StatusEvent returns the status event describing the current state of a job
+func StatusEvent(job *portainer.BackgroundJob) Event {
+	return Event{
+		Type:    EventStatus,
+		JobID:   job.ID,
+		Status:  job.Status,
+		Attempt: job.Attempts,
+		Error:   job.Error,
+		Time:    time.Now().UnixMilli(),
+	}
+}
+
+type progressReporterKey struct{}
+
+// ReportProgress publishes the progress of the job running with the context, it does nothing when the context is
+// not the one of a job
+func ReportProgress(ctx context.Context, progress Progress) {
+	if report, ok := ctx.Value(progressReporterKey{}).(func(Progress)); ok {
+		report(progress)
+	}
+}
+
+// ProgressReader reports the bytes read from the reader as the progress of the job running with the context
+func ProgressReader(ctx context.Context, r io.Reader, stage, message string, total int64) io.Reader {
+	return &progressReader{ctx: ctx, r: r, stage: stage, message: message, total: total}
+}
+
+type progressReader struct {
+	ctx     context.Context
+	r       io.Reader
+	stage   string
+	message string
+	current int64
+	total   int64
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.current += int64(n)
+
+	ReportProgress(pr.ctx, Progress{Stage: pr.stage, Message: pr.message, Current: pr.current, Total: pr.total})
+
+	return n, err
+}
+
+// Subscribe returns the events of a job until it is finished, the channel being then closed. The last progress of a
+// running job is sent first.
+func (queue *Queue) Subscribe(id portainer.BackgroundJobID) (<-chan Event, func()) {
+	events := make(chan Event, subscriptionBuffer)
+
+	queue.mu.Lock()
+	queue.subscribers[id] = append(queue.subscribers[id], events)
+	if last, ok := queue.progress[id]; ok {
+		events <- last
+	}
+	queue.mu.Unlock()
+
+	unsubscribe := func() {
+		queue.mu.Lock()
+		defer queue.mu.Unlock()
+
+		subscribers := queue.subscribers[id]
+		for i, subscriber := range subscribers {
+			if subscriber == events {
+				queue.subscribers[id] = append(subscribers[:i], subscribers[i+1:]...)
+				close(events)
+				break
+			}
+		}
+
+		if len(queue.subscribers[id]) == 0 {
+			delete(queue.subscribers, id)
+		}
+	}
+
+	return events, unsubscribe
+}
+
+// progressReporter returns the function publishing the progress of a job, the reports of a stage being throttled
+func (queue *Queue) progressReporter(id portainer.BackgroundJobID) func(Progress) {
+	var lastStage string
+	var lastReport time.Time
+
+	return func(progress Progress) {
+		queue.mu.Lock()
+		defer queue.mu.Unlock()
+
+		if _, ok := queue.running[id]; !ok {
+			return
+		}
+
+		now := time.Now()
+		completed := progress.Total > 0 && progress.Current >= progress.Total
+		if progress.Stage == lastStage && !completed && now.Sub(lastReport) < minProgressInterval {
+			return
+		}
+
+		lastStage = progress.Stage
+		lastReport = now
+
+		event := Event{
+			Type:    EventProgress,
+			JobID:   id,
+			Stage:   progress.Stage,
+			Message: progress.Message,
+			Current: progress.Current,
+			Total:   progress.Total,
+			Time:    now.UnixMilli(),
+		}
+
+		queue.progress[id] = event
+		queue.publish(event, false)
+	}
+}
+
+// publish sends the event to the subscribers of the job, the subscribers being released once the job is finished.
+// The lock must be held.
+func (queue *Queue) publish(event Event, finished bool) {
+	for _, subscriber := range queue.subscribers[event.JobID] {
+		select {
+		case subscriber <- event:
+		default:
+			// the status events are more important than the progress reports of the slow subscribers
+			if event.Type == EventStatus {
+				select {
+				case <-subscriber:
+				default:
+				}
+
+				select {
+				case subscriber <- event:
+				default:
+				}
+			}
+		}
+
+		if finished {
+			close(subscriber)
+		}
+	}
+
+	if finished {
+		delete(queue.subscribers, event.JobID)
+		delete(queue.progress, event.JobID)
+	}
+}
+
+// publishStatus publishes the status of a job to its subscribers. The lock must be held.
+func (queue *Queue) publishStatus(job *portainer.BackgroundJob) {
+	if job.Status != portainer.BackgroundJobRunning {
+		delete(queue.progress, job.ID)
+	}
+
+	queue.publish(StatusEvent(job), IsFinished(job.Status))
+}
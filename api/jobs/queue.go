@@ -0,0 +1,526 @@
+// Package jobs runs the background work of Portainer, such as the snapshots, the stack deployments, the backups and
+// the image pulls, through a queue persisted in the database. The jobs survive the restarts of Portainer, are retried
+// with an exponential backoff when they fail, can be canceled by the users and are run with a limited concurrency per
+// type of job.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultConcurrency = 1
+	defaultMaxAttempts = 3
+	defaultBackoff     = 30 * time.Second
+	maxBackoff         = 30 * time.Minute
+
+	// pollInterval is the interval between two checks of the queue, catching the retries becoming due
+	pollInterval     = 5 * time.Second
+	defaultRetention = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrUnknownType is returned when enqueuing a job of a type without a registered handler
+	ErrUnknownType = errors.New("unknown job type")
+	// ErrJobFinished is returned when canceling a job that is already finished
+	ErrJobFinished = errors.New("the job is already finished")
+	// ErrJobNotRetryable is returned when retrying a job that did not fail and was not canceled
+	ErrJobNotRetryable = errors.New("only the failed and canceled jobs can be retried")
+)
+
+// Handler runs a job. The context is canceled when the job is canceled or when Portainer is stopped. The errors
+// wrapped with scheduler.NewPermanentError are not retried.
+type Handler func(ctx context.Context, job *portainer.BackgroundJob) error
+
+// Options configures how the jobs of a type are run
+type Options struct {
+	// Maximum number of jobs of the type run at the same time, 1 by default
+	Concurrency int
+	// Maximum number of attempts of a job before it is marked as failed, 3 by default
+	MaxAttempts int
+	// Delay before the first retry of a failed job, doubled on each retry, 30 seconds by default
+	Backoff time.Duration
+	// Duration during which the finished jobs are kept, 7 days by default
+	Retention time.Duration
+}
+
+type registration struct {
+	handler Handler
+	options Options
+}
+
+// Queue stores the jobs in the database and runs them with the handlers registered for their type.
+type Queue struct {
+	dataStore dataservices.DataStore
+	now       func() time.Time
+
+	mu      sync.Mutex
+	types   map[string]registration
+	running map[portainer.BackgroundJobID]context.CancelFunc
+	active  map[string]int
+	waiters map[portainer.BackgroundJobID][]chan struct{}
+	// subscribers receive the events of the jobs, along with the last progress of the running jobs
+	subscribers map[portainer.BackgroundJobID][]chan Event
+	progress    map[portainer.BackgroundJobID]Event
+	// recovered is set once the jobs interrupted by a restart were queued again
+	recovered bool
+	stopping  bool
+	wg        sync.WaitGroup
+
+	wake chan struct{}
+}
+
+// NewQueue creates a job queue storing its jobs in the database
+func NewQueue(dataStore dataservices.DataStore) *Queue {
+	return &Queue{
+		dataStore:   dataStore,
+		now:         time.Now,
+		types:       map[string]registration{},
+		running:     map[portainer.BackgroundJobID]context.CancelFunc{},
+		active:      map[string]int{},
+		waiters:     map[portainer.BackgroundJobID][]chan struct{}{},
+		subscribers: map[portainer.BackgroundJobID][]chan Event{},
+		progress:    map[portainer.BackgroundJobID]Event{},
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Register sets the handler running the jobs of a type
+func (queue *Queue) Register(jobType string, options Options, handler Handler) {
+	if options.Concurrency <= 0 {
+		options.Concurrency = defaultConcurrency
+	}
+
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = defaultMaxAttempts
+	}
+
+	if options.Backoff <= 0 {
+		options.Backoff = defaultBackoff
+	}
+
+	if options.Retention <= 0 {
+		options.Retention = defaultRetention
+	}
+
+	queue.mu.Lock()
+	queue.types[jobType] = registration{handler: handler, options: options}
+	queue.mu.Unlock()
+
+	queue.signal()
+}
+
+// Enqueue adds a job to the queue, its payload being stored as JSON
+func (queue *Queue) Enqueue(jobType string, payload any, createdBy portainer.UserID, description string) (*portainer.BackgroundJob, error) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	return queue.enqueue(jobType, payload, createdBy, description)
+}
+
+// EnqueueOnce adds a job to the queue unless a job of the same type with the same payload is already queued or
+// running, in which case the existing job is returned. It is used by the periodic work, which must not pile up when a
+// run lasts longer than its interval.
+func (queue *Queue) EnqueueOnce(jobType string, payload any, createdBy portainer.UserID, description string) (*portainer.BackgroundJob, error) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := queue.dataStore.BackgroundJob().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Type == jobType && !IsFinished(job.Status) && samePayload(job.Payload, data) {
+			return job, nil
+		}
+	}
+
+	return queue.enqueue(jobType, payload, createdBy, description)
+}
+
+func (queue *Queue) enqueue(jobType string, payload any, createdBy portainer.UserID, description string) (*portainer.BackgroundJob, error) {
+	registration, ok := queue.types[jobType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, jobType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &portainer.BackgroundJob{
+		Type:        jobType,
+		Status:      portainer.BackgroundJobQueued,
+		Description: description,
+		Payload:     data,
+		CreatedBy:   createdBy,
+		MaxAttempts: registration.options.MaxAttempts,
+		Created:     queue.now().Unix(),
+	}
+
+	if err := queue.dataStore.BackgroundJob().Create(job); err != nil {
+		return nil, err
+	}
+
+	queue.signal()
+
+	return job, nil
+}
+
+// Cancel cancels a queued or running job, the context of a running job being canceled
+func (queue *Queue) Cancel(id portainer.BackgroundJobID) (*portainer.BackgroundJob, error) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	job, err := queue.dataStore.BackgroundJob().Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsFinished(job.Status) {
+		return nil, ErrJobFinished
+	}
+
+	job.Status = portainer.BackgroundJobCanceled
+	job.Finished = queue.now().Unix()
+	job.NextAttempt = 0
+
+	if err := queue.dataStore.BackgroundJob().Update(job.ID, job); err != nil {
+		return nil, err
+	}
+
+	if cancel, ok := queue.running[job.ID]; ok {
+		cancel()
+	}
+
+	queue.notify(job.ID)
+	queue.publishStatus(job)
+
+	return job, nil
+}
+
+// Retry queues again a failed or canceled job, its attempts being reset
+func (queue *Queue) Retry(id portainer.BackgroundJobID) (*portainer.BackgroundJob, error) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	job, err := queue.dataStore.BackgroundJob().Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status != portainer.BackgroundJobFailed && job.Status != portainer.BackgroundJobCanceled {
+		return nil, ErrJobNotRetryable
+	}
+
+	job.Status = portainer.BackgroundJobQueued
+	job.Attempts = 0
+	job.Error = ""
+	job.Started = 0
+	job.Finished = 0
+	job.NextAttempt = 0
+
+	if err := queue.dataStore.BackgroundJob().Update(job.ID, job); err != nil {
+		return nil, err
+	}
+
+	queue.signal()
+
+	return job, nil
+}
+
+// Wait blocks until the job is finished and returns it, or until the context is canceled
+func (queue *Queue) Wait(ctx context.Context, id portainer.BackgroundJobID) (*portainer.BackgroundJob, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		queue.mu.Lock()
+		job, err := queue.dataStore.BackgroundJob().Read(id)
+		if err != nil {
+			queue.mu.Unlock()
+			return nil, err
+		}
+
+		if IsFinished(job.Status) {
+			queue.mu.Unlock()
+			return job, nil
+		}
+
+		done := make(chan struct{})
+		queue.waiters[id] = append(queue.waiters[id], done)
+		queue.mu.Unlock()
+
+		select {
+		case <-done:
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Start recovers the jobs interrupted by the last stop of Portainer and runs the queued jobs until the context is
+// canceled, the running jobs being then interrupted and queued again
+func (queue *Queue) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			queue.dispatch(ctx)
+
+			select {
+			case <-ctx.Done():
+				queue.stop()
+				return
+			case <-ticker.C:
+			case <-queue.wake:
+			}
+		}
+	}()
+}
+
+func (queue *Queue) stop() {
+	queue.mu.Lock()
+	queue.stopping = true
+	for _, cancel := range queue.running {
+		cancel()
+	}
+	queue.mu.Unlock()
+
+	queue.wg.Wait()
+}
+
+// dispatch starts the queued jobs that are due, within the concurrency limits of their type
+func (queue *Queue) dispatch(ctx context.Context) {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	if queue.stopping || ctx.Err() != nil {
+		return
+	}
+
+	jobs, err := queue.dataStore.BackgroundJob().ReadAll()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to retrieve the background jobs")
+		return
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID < jobs[j].ID
+	})
+
+	now := queue.now()
+	recovering := !queue.recovered
+	queue.recovered = true
+
+	for i := range jobs {
+		job := &jobs[i]
+
+		switch job.Status {
+		case portainer.BackgroundJobRunning:
+			// the jobs left running before a restart were interrupted
+			if _, ok := queue.running[job.ID]; ok || !recovering {
+				continue
+			}
+
+			log.Info().Int("job_id", int(job.ID)).Str("type", job.Type).Msg("queuing again the interrupted background job")
+
+			job.Status = portainer.BackgroundJobQueued
+			if err := queue.dataStore.BackgroundJob().Update(job.ID, job); err != nil {
+				log.Error().Err(err).Int("job_id", int(job.ID)).Msg("unable to queue again the background job")
+				continue
+			}
+
+		case portainer.BackgroundJobSucceeded, portainer.BackgroundJobFailed, portainer.BackgroundJobCanceled:
+			if cancel, ok := queue.running[job.ID]; ok {
+				// finished while it was still running, such as a job canceled from the database
+				cancel()
+			}
+
+			retention := defaultRetention
+			if registration, ok := queue.types[job.Type]; ok {
+				retention = registration.options.Retention
+			}
+
+			if job.Finished > 0 && now.Sub(time.Unix(job.Finished, 0)) > retention {
+				if err := queue.dataStore.BackgroundJob().Delete(job.ID); err != nil {
+					log.Warn().Err(err).Int("job_id", int(job.ID)).Msg("unable to remove the background job")
+				}
+			}
+
+			continue
+		}
+
+		registration, ok := queue.types[job.Type]
+		if !ok || job.NextAttempt > now.Unix() || queue.active[job.Type] >= registration.options.Concurrency {
+			continue
+		}
+
+		job.Status = portainer.BackgroundJobRunning
+		job.Attempts++
+		job.Started = now.Unix()
+		job.NextAttempt = 0
+
+		if err := queue.dataStore.BackgroundJob().Update(job.ID, job); err != nil {
+			log.Error().Err(err).Int("job_id", int(job.ID)).Msg("unable to start the background job")
+			continue
+		}
+
+		queue.publishStatus(job)
+
+		jobCtx, cancel := context.WithCancel(ctx)
+		jobCtx = context.WithValue(jobCtx, progressReporterKey{}, queue.progressReporter(job.ID))
+		queue.running[job.ID] = cancel
+		queue.active[job.Type]++
+		queue.wg.Add(1)
+
+		go queue.run(jobCtx, cancel, *job, registration)
+	}
+}
+
+// run runs a job and records its outcome, the failed jobs being retried after a delay until their last attempt
+func (queue *Queue) run(ctx context.Context, cancel context.CancelFunc, job portainer.BackgroundJob, registration registration) {
+	defer queue.wg.Done()
+	defer cancel()
+
+	err := runHandler(ctx, registration.handler, &job)
+
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	delete(queue.running, job.ID)
+	delete(queue.progress, job.ID)
+	queue.active[job.Type]--
+
+	defer queue.signal()
+
+	latest, readErr := queue.dataStore.BackgroundJob().Read(job.ID)
+	if readErr != nil {
+		// removed while it was running
+		return
+	}
+
+	if latest.Status != portainer.BackgroundJobRunning {
+		queue.notify(job.ID)
+		return
+	}
+
+	now := queue.now()
+
+	var permErr *scheduler.PermanentError
+
+	switch {
+	case err == nil:
+		latest.Status = portainer.BackgroundJobSucceeded
+		latest.Error = ""
+		latest.Finished = now.Unix()
+
+	case queue.stopping:
+		// interrupted by the stop of Portainer, the attempt is not counted
+		latest.Status = portainer.BackgroundJobQueued
+		latest.Attempts--
+		latest.Error = err.Error()
+
+	case errors.As(err, &permErr) || latest.Attempts >= latest.MaxAttempts:
+		latest.Status = portainer.BackgroundJobFailed
+		latest.Error = err.Error()
+		latest.Finished = now.Unix()
+
+	default:
+		latest.Status = portainer.BackgroundJobQueued
+		latest.Error = err.Error()
+		latest.NextAttempt = now.Add(backoff(registration.options.Backoff, latest.Attempts)).Unix()
+	}
+
+	if err != nil {
+		log.Warn().Err(err).Int("job_id", int(job.ID)).Str("type", job.Type).Int("attempt", latest.Attempts).Msg("background job failed")
+	}
+
+	if err := queue.dataStore.BackgroundJob().Update(latest.ID, latest); err != nil {
+		log.Error().Err(err).Int("job_id", int(job.ID)).Msg("unable to persist the outcome of the background job")
+	}
+
+	queue.publishStatus(latest)
+
+	if IsFinished(latest.Status) {
+		queue.notify(job.ID)
+	}
+}
+
+func runHandler(ctx context.Context, handler Handler, job *portainer.BackgroundJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("the job panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+// backoff returns the delay before the next attempt, doubled after each failed attempt
+func backoff(initial time.Duration, attempts int) time.Duration {
+	delay := initial
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxBackoff)
+}
+
+// notify wakes up the callers waiting for the job, the lock must be held
+func (queue *Queue) notify(id portainer.BackgroundJobID) {
+	for _, done := range queue.waiters[id] {
+		close(done)
+	}
+
+	delete(queue.waiters, id)
+}
+
+func (queue *Queue) signal() {
+	select {
+	case queue.wake <- struct{}{}:
+	default:
+	}
+}
+
+// IsFinished returns whether a job with the status will no longer be run
+func IsFinished(status portainer.BackgroundJobStatus) bool {
+	return status == portainer.BackgroundJobSucceeded || status == portainer.BackgroundJobFailed || status == portainer.BackgroundJobCanceled
+}
+
+// DecodePayload decodes the payload of a job
+func DecodePayload(job *portainer.BackgroundJob, payload any) error {
+	return json.Unmarshal(job.Payload, payload)
+}
+
+func samePayload(a, b json.RawMessage) bool {
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(x, y)
+}
@@ -0,0 +1,232 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/jobs"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type payload struct {
+	EndpointID portainer.EndpointID
+}
+
+func newTestQueue(t *testing.T) (*jobs.Queue, dataservices.DataStore, context.Context) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return jobs.NewQueue(store), store, ctx
+}
+
+func TestQueue_Run(t *testing.T) {
+	queue, _, ctx := newTestQueue(t)
+
+	var received payload
+	queue.Register("test", jobs.Options{}, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		return jobs.DecodePayload(job, &received)
+	})
+
+	_, err := queue.Enqueue("unknown", nil, 0, "")
+	assert.ErrorIs(t, err, jobs.ErrUnknownType)
+
+	job, err := queue.Enqueue("test", payload{EndpointID: 3}, 1, "Test")
+	assert.NoError(t, err)
+	assert.Equal(t, portainer.BackgroundJobQueued, job.Status)
+
+	queue.Start(ctx)
+
+	job, err = queue.Wait(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, portainer.BackgroundJobSucceeded, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, portainer.EndpointID(3), received.EndpointID)
+}
+
+func TestQueue_Retry(t *testing.T) {
+	queue, _, ctx := newTestQueue(t)
+
+	var attempts atomic.Int32
+	queue.Register("flaky", jobs.Options{MaxAttempts: 3, Backoff: time.Millisecond}, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		if attempts.Add(1) < 3 {
+			return errors.New("unavailable")
+		}
+
+		return nil
+	})
+
+	queue.Register("broken", jobs.Options{MaxAttempts: 3}, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		return scheduler.NewPermanentError(errors.New("invalid"))
+	})
+
+	queue.Start(ctx)
+
+	flaky, err := queue.Enqueue("flaky", nil, 0, "")
+	assert.NoError(t, err)
+
+	broken, err := queue.Enqueue("broken", nil, 0, "")
+	assert.NoError(t, err)
+
+	// the retries become due on the next poll of the queue
+	waitCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	flaky, err = queue.Wait(waitCtx, flaky.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, portainer.BackgroundJobSucceeded, flaky.Status)
+	assert.Equal(t, 3, flaky.Attempts)
+
+	broken, err = queue.Wait(waitCtx, broken.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, portainer.BackgroundJobFailed, broken.Status)
+	assert.Equal(t, 1, broken.Attempts, "the permanent errors should not be retried")
+	assert.Equal(t, "invalid", broken.Error)
+
+	_, err = queue.Retry(flaky.ID)
+	assert.ErrorIs(t, err, jobs.ErrJobNotRetryable)
+
+	broken, err = queue.Retry(broken.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, portainer.BackgroundJobQueued, broken.Status)
+	assert.Equal(t, 0, broken.Attempts)
+}
+
+func TestQueue_Cancel(t *testing.T) {
+	queue, _, ctx := newTestQueue(t)
+
+	started := make(chan struct{})
+	queue.Register("slow", jobs.Options{}, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		close(started)
+		<-ctx.Done()
+
+		return ctx.Err()
+	})
+
+	queue.Start(ctx)
+
+	job, err := queue.Enqueue("slow", nil, 0, "")
+	assert.NoError(t, err)
+
+	<-started
+
+	job, err = queue.Cancel(job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, portainer.BackgroundJobCanceled, job.Status)
+
+	job, err = queue.Wait(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, portainer.BackgroundJobCanceled, job.Status)
+
+	_, err = queue.Cancel(job.ID)
+	assert.ErrorIs(t, err, jobs.ErrJobFinished)
+}
+
+func TestQueue_Concurrency(t *testing.T) {
+	queue, _, ctx := newTestQueue(t)
+
+	var current, peak atomic.Int32
+	queue.Register("limited", jobs.Options{Concurrency: 2}, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		n := current.Add(1)
+		defer current.Add(-1)
+
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+
+		return nil
+	})
+
+	var ids []portainer.BackgroundJobID
+	for i := 0; i < 5; i++ {
+		job, err := queue.EnqueueOnce("limited", payload{EndpointID: portainer.EndpointID(i)}, 0, "")
+		assert.NoError(t, err)
+		ids = append(ids, job.ID)
+	}
+
+	duplicate, err := queue.EnqueueOnce("limited", payload{EndpointID: 0}, 0, "")
+	assert.NoError(t, err)
+	assert.Equal(t, ids[0], duplicate.ID, "a pending job with the same payload should be reused")
+
+	queue.Start(ctx)
+
+	for _, id := range ids {
+		job, err := queue.Wait(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, portainer.BackgroundJobSucceeded, job.Status)
+	}
+
+	assert.Equal(t, int32(2), peak.Load())
+}
+
+func TestQueue_RecoverInterruptedJobs(t *testing.T) {
+	queue, store, ctx := newTestQueue(t)
+
+	interrupted := &portainer.BackgroundJob{Type: "test", Status: portainer.BackgroundJobRunning, Attempts: 1, MaxAttempts: 3}
+	assert.NoError(t, store.BackgroundJob().Create(interrupted))
+
+	old := &portainer.BackgroundJob{Type: "test", Status: portainer.BackgroundJobSucceeded, Finished: time.Now().Add(-30 * 24 * time.Hour).Unix()}
+	assert.NoError(t, store.BackgroundJob().Create(old))
+
+	queue.Register("test", jobs.Options{}, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		return nil
+	})
+
+	queue.Start(ctx)
+
+	job, err := queue.Wait(ctx, interrupted.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, portainer.BackgroundJobSucceeded, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+
+	_, err = store.BackgroundJob().Read(old.ID)
+	assert.True(t, store.IsErrObjectNotFound(err), "the old finished jobs should be removed")
+}
+
+func TestQueue_Subscribe(t *testing.T) {
+	queue, _, ctx := newTestQueue(t)
+
+	queue.Register("pull", jobs.Options{}, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "pull", Message: "Downloading", Current: 1, Total: 2})
+		// throttled, as reported right after the previous progress of the same stage
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "pull", Message: "Downloading", Current: 1, Total: 2})
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "pull", Message: "Downloaded", Current: 2, Total: 2})
+
+		return nil
+	})
+
+	job, err := queue.Enqueue("pull", nil, 0, "")
+	assert.NoError(t, err)
+
+	events, unsubscribe := queue.Subscribe(job.ID)
+	defer unsubscribe()
+
+	queue.Start(ctx)
+
+	var received []jobs.Event
+	for event := range events {
+		event.Time = 0
+		received = append(received, event)
+	}
+
+	assert.Equal(t, []jobs.Event{
+		{Type: jobs.EventStatus, JobID: job.ID, Status: portainer.BackgroundJobRunning, Attempt: 1},
+		{Type: jobs.EventProgress, JobID: job.ID, Stage: "pull", Message: "Downloading", Current: 1, Total: 2},
+		{Type: jobs.EventProgress, JobID: job.ID, Stage: "pull", Message: "Downloaded", Current: 2, Total: 2},
+		{Type: jobs.EventStatus, JobID: job.ID, Status: portainer.BackgroundJobSucceeded, Attempt: 1},
+	}, received)
+}
@@ -29,6 +29,7 @@ type claims struct {
 	Role                int    `json:"role"`
 	Scope               scope  `json:"scope"`
 	ForceChangePassword bool   `json:"forceChangePassword"`
+	ImpersonatorID      int    `json:"impersonatorId,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -42,6 +43,9 @@ const (
 	kubeConfigScope = scope("kubeconfig")
 )
 
+// impersonationTimeout is the maximum validity of the tokens issued when an administrator impersonates a user
+const impersonationTimeout = time.Hour
+
 // NewService initializes a new service. It will generate a random key that will be used to sign JWT tokens.
 func NewService(userSessionDuration string, dataStore dataservices.DataStore) (*Service, error) {
 	userSessionTimeout, err := time.ParseDuration(userSessionDuration)
@@ -133,16 +137,32 @@ func (service *Service) ParseAndVerifyToken(token string) (*portainer.TokenData,
 				return nil, errInvalidJWTToken
 			}
 
+			if cl.ImpersonatorID != 0 && !service.validImpersonator(portainer.UserID(cl.ImpersonatorID), cl.StandardClaims.IssuedAt) {
+				return nil, errInvalidJWTToken
+			}
+
 			return &portainer.TokenData{
-				ID:       portainer.UserID(cl.UserID),
-				Username: cl.Username,
-				Role:     portainer.UserRole(cl.Role),
+				ID:             portainer.UserID(cl.UserID),
+				Username:       cl.Username,
+				Role:           portainer.UserRole(cl.Role),
+				ImpersonatorID: portainer.UserID(cl.ImpersonatorID),
 			}, nil
 		}
 	}
 	return nil, errInvalidJWTToken
 }
 
+// validImpersonator returns true when the impersonator is still an administrator whose sessions were not revoked
+// since the token was issued
+func (service *Service) validImpersonator(impersonatorID portainer.UserID, issuedAt int64) bool {
+	impersonator, err := service.dataStore.User().Read(impersonatorID)
+	if err != nil {
+		return false
+	}
+
+	return impersonator.Role == portainer.AdministratorRole && impersonator.TokenIssueAt <= issuedAt
+}
+
 // parse a JWT token, fallback to defaultScope if no scope is present in the JWT
 func parseScope(token string) scope {
 	unverifiedToken, _, _ := new(jwt.Parser).ParseUnverified(token, &claims{})
@@ -179,12 +199,20 @@ func (service *Service) generateSignedToken(data *portainer.TokenData, expiresAt
 		expiresAt = time.Now().Add(time.Hour * 8760 * 99).Unix()
 	}
 
+	if data.ImpersonatorID != 0 {
+		maxExpiresAt := time.Now().Add(impersonationTimeout).Unix()
+		if expiresAt == 0 || expiresAt > maxExpiresAt {
+			expiresAt = maxExpiresAt
+		}
+	}
+
 	cl := claims{
 		UserID:              int(data.ID),
 		Username:            data.Username,
 		Role:                int(data.Role),
 		Scope:               scope,
 		ForceChangePassword: data.ForceChangePassword,
+		ImpersonatorID:      int(data.ImpersonatorID),
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: expiresAt,
 			IssuedAt:  time.Now().Unix(),
@@ -55,3 +55,31 @@ func TestGenerateSignedToken_InvalidScope(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "invalid scope: testing", err.Error())
 }
+
+func TestGenerateSignedToken_Impersonation(t *testing.T) {
+	dataStore := i.NewDatastore(i.WithSettingsService(&portainer.Settings{}))
+	svc, err := NewService("24h", dataStore)
+	assert.NoError(t, err, "failed to create a copy of service")
+
+	token := &portainer.TokenData{
+		Username:       "Joe",
+		ID:             2,
+		Role:           2,
+		ImpersonatorID: 1,
+	}
+
+	// tokens without expiry, such as the kubeconfig ones, are limited to the impersonation timeout too
+	generatedToken, err := svc.generateSignedToken(token, 0, kubeConfigScope)
+	assert.NoError(t, err, "failed to generate a signed token")
+
+	parsedToken, err := jwt.ParseWithClaims(generatedToken, &claims{}, func(token *jwt.Token) (interface{}, error) {
+		return svc.secrets[kubeConfigScope], nil
+	})
+	assert.NoError(t, err, "failed to parse generated token")
+
+	tokenClaims, ok := parsedToken.Claims.(*claims)
+	assert.Equal(t, true, ok, "failed to claims out of generated ticket")
+
+	assert.Equal(t, 1, tokenClaims.ImpersonatorID)
+	assert.InDelta(t, time.Now().Add(impersonationTimeout).Unix(), tokenClaims.ExpiresAt, 5)
+}
@@ -0,0 +1,185 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/portainer/portainer/api/kubernetes/validation"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	labelApplicationName = "app.kubernetes.io/name"
+
+	defaultReplicas = 1
+)
+
+// Application is a simplified description of a containerized workload which is translated
+// into the Kubernetes objects required to run it
+type Application struct {
+	// Name of the application, used as the name of all the generated objects
+	Name string `json:"Name" example:"my-app"`
+	// Namespace the application is deployed to
+	Namespace string `json:"Namespace" example:"default"`
+	// Container image
+	Image string `json:"Image" example:"nginx:latest"`
+	// Number of replicas, ignored when autoscaling is enabled
+	Replicas int32 `json:"Replicas" example:"1"`
+	// Environment variables of the container
+	Env []EnvVar `json:"Env"`
+	// Ports published by the container
+	Ports []Port `json:"Ports"`
+	// Type of the service exposing the ports: ClusterIP, NodePort or LoadBalancer
+	ServiceType string `json:"ServiceType" example:"ClusterIP"`
+	// Resource requirements of the container
+	Resources *Resources `json:"Resources"`
+	// Persistent volumes mounted in the container
+	Persistence []Volume `json:"Persistence"`
+	// Ingress routing external HTTP traffic to the application
+	Ingress *Ingress `json:"Ingress"`
+	// Horizontal autoscaling of the application
+	Autoscaling *Autoscaling `json:"Autoscaling"`
+}
+
+// EnvVar is an environment variable of the application container
+type EnvVar struct {
+	Name  string `json:"Name" example:"LOG_LEVEL"`
+	Value string `json:"Value" example:"debug"`
+}
+
+// Port is a port published by the application container
+type Port struct {
+	// Port exposed by the container
+	ContainerPort int32 `json:"ContainerPort" example:"80"`
+	// Port exposed by the service, defaults to the container port
+	ServicePort int32 `json:"ServicePort" example:"8080"`
+	// Port exposed on the nodes when the service type is NodePort or LoadBalancer
+	NodePort int32 `json:"NodePort" example:"30080"`
+	// TCP or UDP, defaults to TCP
+	Protocol string `json:"Protocol" example:"TCP"`
+}
+
+// Resources are the CPU and memory reservations and limits of the application container
+type Resources struct {
+	RequestsCPU    string `json:"RequestsCPU" example:"100m"`
+	RequestsMemory string `json:"RequestsMemory" example:"128Mi"`
+	LimitsCPU      string `json:"LimitsCPU" example:"500m"`
+	LimitsMemory   string `json:"LimitsMemory" example:"512Mi"`
+}
+
+// Volume is a persistent volume claim mounted in the application container
+type Volume struct {
+	Name         string `json:"Name" example:"data"`
+	MountPath    string `json:"MountPath" example:"/data"`
+	Size         string `json:"Size" example:"1Gi"`
+	StorageClass string `json:"StorageClass" example:"local-path"`
+}
+
+// Ingress routes external HTTP traffic to one of the application ports
+type Ingress struct {
+	ClassName string `json:"ClassName" example:"nginx"`
+	Host      string `json:"Host" example:"my-app.example.com"`
+	Path      string `json:"Path" example:"/"`
+	// Service port targeted by the ingress, defaults to the first port of the application
+	Port int32 `json:"Port" example:"8080"`
+}
+
+// Autoscaling scales the application based on its CPU usage
+type Autoscaling struct {
+	MinReplicas                    int32 `json:"MinReplicas" example:"1"`
+	MaxReplicas                    int32 `json:"MaxReplicas" example:"5"`
+	TargetCPUUtilizationPercentage int32 `json:"TargetCPUUtilizationPercentage" example:"80"`
+}
+
+// Validate checks that the application can be translated into valid Kubernetes objects
+func (app *Application) Validate() error {
+	if errs := validation.IsDNS1123Subdomain(app.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid application name: %s", strings.Join(errs, ", "))
+	}
+
+	if app.Image == "" {
+		return errors.New("application image is required")
+	}
+
+	if app.Replicas < 0 {
+		return errors.New("application replicas must be positive")
+	}
+
+	switch app.ServiceType {
+	case "", "ClusterIP", "NodePort", "LoadBalancer":
+	default:
+		return fmt.Errorf("invalid service type %q", app.ServiceType)
+	}
+
+	for _, env := range app.Env {
+		if env.Name == "" {
+			return errors.New("environment variable name is required")
+		}
+	}
+
+	for _, port := range app.Ports {
+		if !validPort(port.ContainerPort) || (port.ServicePort != 0 && !validPort(port.ServicePort)) {
+			return fmt.Errorf("invalid port %d", port.ContainerPort)
+		}
+
+		switch strings.ToUpper(port.Protocol) {
+		case "", "TCP", "UDP":
+		default:
+			return fmt.Errorf("invalid protocol %q", port.Protocol)
+		}
+	}
+
+	if app.Resources != nil {
+		for _, quantity := range []string{app.Resources.RequestsCPU, app.Resources.RequestsMemory, app.Resources.LimitsCPU, app.Resources.LimitsMemory} {
+			if quantity == "" {
+				continue
+			}
+
+			if _, err := resource.ParseQuantity(quantity); err != nil {
+				return fmt.Errorf("invalid resource quantity %q: %w", quantity, err)
+			}
+		}
+	}
+
+	for _, volume := range app.Persistence {
+		if errs := validation.IsDNS1123Subdomain(volume.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid volume name %q", volume.Name)
+		}
+
+		if !strings.HasPrefix(volume.MountPath, "/") {
+			return fmt.Errorf("invalid mount path %q for volume %s", volume.MountPath, volume.Name)
+		}
+
+		if _, err := resource.ParseQuantity(volume.Size); err != nil {
+			return fmt.Errorf("invalid size %q for volume %s: %w", volume.Size, volume.Name, err)
+		}
+	}
+
+	if app.Ingress != nil {
+		if len(app.Ports) == 0 {
+			return errors.New("an ingress requires the application to publish at least one port")
+		}
+
+		if app.Ingress.Path != "" && !strings.HasPrefix(app.Ingress.Path, "/") {
+			return fmt.Errorf("invalid ingress path %q", app.Ingress.Path)
+		}
+	}
+
+	if app.Autoscaling != nil {
+		if app.Autoscaling.MinReplicas < 1 || app.Autoscaling.MaxReplicas < app.Autoscaling.MinReplicas {
+			return errors.New("invalid autoscaling replicas range")
+		}
+
+		if app.Autoscaling.TargetCPUUtilizationPercentage < 1 || app.Autoscaling.TargetCPUUtilizationPercentage > 100 {
+			return errors.New("autoscaling target CPU utilization must be between 1 and 100")
+		}
+	}
+
+	return nil
+}
+
+func validPort(port int32) bool {
+	return port > 0 && port <= 65535
+}
@@ -0,0 +1,319 @@
+package application
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
+)
+
+const manifestSeparator = "---\n"
+
+// GenerateManifest translates the application into a multi-document YAML manifest containing
+// a Deployment and, depending on the application settings, a Service, an Ingress,
+// PersistentVolumeClaims and a HorizontalPodAutoscaler
+func GenerateManifest(app Application) ([]byte, error) {
+	if err := app.Validate(); err != nil {
+		return nil, err
+	}
+
+	objects := []any{}
+
+	for _, volume := range app.Persistence {
+		objects = append(objects, persistentVolumeClaim(app, volume))
+	}
+
+	objects = append(objects, deployment(app))
+
+	if len(app.Ports) > 0 {
+		objects = append(objects, service(app))
+	}
+
+	if app.Ingress != nil {
+		objects = append(objects, ingress(app))
+	}
+
+	if app.Autoscaling != nil {
+		objects = append(objects, horizontalPodAutoscaler(app))
+	}
+
+	var manifest bytes.Buffer
+	for i, object := range objects {
+		data, err := yaml.Marshal(object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal the %s manifest: %w", app.Name, err)
+		}
+
+		if i > 0 {
+			manifest.WriteString(manifestSeparator)
+		}
+
+		manifest.Write(data)
+	}
+
+	return manifest.Bytes(), nil
+}
+
+func objectMeta(app Application, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: app.Namespace,
+		Labels:    selectorLabels(app),
+	}
+}
+
+func selectorLabels(app Application) map[string]string {
+	return map[string]string{labelApplicationName: app.Name}
+}
+
+func volumeClaimName(app Application, volume Volume) string {
+	return app.Name + "-" + volume.Name
+}
+
+func deployment(app Application) *appsv1.Deployment {
+	replicas := app.Replicas
+	if replicas == 0 {
+		replicas = defaultReplicas
+	}
+
+	if app.Autoscaling != nil {
+		replicas = app.Autoscaling.MinReplicas
+	}
+
+	container := corev1.Container{
+		Name:  app.Name,
+		Image: app.Image,
+	}
+
+	for _, env := range app.Env {
+		container.Env = append(container.Env, corev1.EnvVar{Name: env.Name, Value: env.Value})
+	}
+
+	for _, port := range app.Ports {
+		container.Ports = append(container.Ports, corev1.ContainerPort{
+			ContainerPort: port.ContainerPort,
+			Protocol:      protocol(port),
+		})
+	}
+
+	if app.Resources != nil {
+		container.Resources = corev1.ResourceRequirements{
+			Requests: resourceList(app.Resources.RequestsCPU, app.Resources.RequestsMemory),
+			Limits:   resourceList(app.Resources.LimitsCPU, app.Resources.LimitsMemory),
+		}
+	}
+
+	volumes := []corev1.Volume{}
+	for _, volume := range app.Persistence {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      volume.Name,
+			MountPath: volume.MountPath,
+		})
+
+		volumes = append(volumes, corev1.Volume{
+			Name: volume.Name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: volumeClaimName(app, volume),
+				},
+			},
+		})
+	}
+
+	if len(volumes) == 0 {
+		volumes = nil
+	}
+
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: objectMeta(app, app.Name),
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: selectorLabels(app)},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: selectorLabels(app)},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{container},
+					Volumes:    volumes,
+				},
+			},
+		},
+	}
+}
+
+func resourceList(cpu, memory string) corev1.ResourceList {
+	list := corev1.ResourceList{}
+
+	if cpu != "" {
+		list[corev1.ResourceCPU] = resource.MustParse(cpu)
+	}
+
+	if memory != "" {
+		list[corev1.ResourceMemory] = resource.MustParse(memory)
+	}
+
+	if len(list) == 0 {
+		return nil
+	}
+
+	return list
+}
+
+func protocol(port Port) corev1.Protocol {
+	if port.Protocol == "" {
+		return corev1.ProtocolTCP
+	}
+
+	return corev1.Protocol(strings.ToUpper(port.Protocol))
+}
+
+func servicePort(port Port) int32 {
+	if port.ServicePort == 0 {
+		return port.ContainerPort
+	}
+
+	return port.ServicePort
+}
+
+func service(app Application) *corev1.Service {
+	serviceType := corev1.ServiceTypeClusterIP
+	if app.ServiceType != "" {
+		serviceType = corev1.ServiceType(app.ServiceType)
+	}
+
+	ports := []corev1.ServicePort{}
+	for _, port := range app.Ports {
+		servicePort := corev1.ServicePort{
+			Name:       fmt.Sprintf("%s-%d", strings.ToLower(string(protocol(port))), servicePort(port)),
+			Protocol:   protocol(port),
+			Port:       servicePort(port),
+			TargetPort: intstr.FromInt(int(port.ContainerPort)),
+		}
+
+		if serviceType != corev1.ServiceTypeClusterIP {
+			servicePort.NodePort = port.NodePort
+		}
+
+		ports = append(ports, servicePort)
+	}
+
+	return &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: objectMeta(app, app.Name),
+		Spec: corev1.ServiceSpec{
+			Type:     serviceType,
+			Selector: selectorLabels(app),
+			Ports:    ports,
+		},
+	}
+}
+
+func ingress(app Application) *networkingv1.Ingress {
+	path := app.Ingress.Path
+	if path == "" {
+		path = "/"
+	}
+
+	port := app.Ingress.Port
+	if port == 0 {
+		port = servicePort(app.Ports[0])
+	}
+
+	pathType := networkingv1.PathTypePrefix
+
+	spec := networkingv1.IngressSpec{
+		Rules: []networkingv1.IngressRule{
+			{
+				Host: app.Ingress.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{
+							{
+								Path:     path,
+								PathType: &pathType,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: app.Name,
+										Port: networkingv1.ServiceBackendPort{Number: port},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if app.Ingress.ClassName != "" {
+		className := app.Ingress.ClassName
+		spec.IngressClassName = &className
+	}
+
+	return &networkingv1.Ingress{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"},
+		ObjectMeta: objectMeta(app, app.Name),
+		Spec:       spec,
+	}
+}
+
+func persistentVolumeClaim(app Application, volume Volume) *corev1.PersistentVolumeClaim {
+	claim := &corev1.PersistentVolumeClaim{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+		ObjectMeta: objectMeta(app, volumeClaimName(app, volume)),
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(volume.Size),
+				},
+			},
+		},
+	}
+
+	if volume.StorageClass != "" {
+		storageClass := volume.StorageClass
+		claim.Spec.StorageClassName = &storageClass
+	}
+
+	return claim
+}
+
+func horizontalPodAutoscaler(app Application) *autoscalingv2.HorizontalPodAutoscaler {
+	minReplicas := app.Autoscaling.MinReplicas
+	targetCPU := app.Autoscaling.TargetCPUUtilizationPercentage
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "autoscaling/v2", Kind: "HorizontalPodAutoscaler"},
+		ObjectMeta: objectMeta(app, app.Name),
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       app.Name,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: app.Autoscaling.MaxReplicas,
+			Metrics: []autoscalingv2.MetricSpec{
+				{
+					Type: autoscalingv2.ResourceMetricSourceType,
+					Resource: &autoscalingv2.ResourceMetricSource{
+						Name: corev1.ResourceCPU,
+						Target: autoscalingv2.MetricTarget{
+							Type:               autoscalingv2.UtilizationMetricType,
+							AverageUtilization: &targetCPU,
+						},
+					},
+				},
+			},
+		},
+	}
+}
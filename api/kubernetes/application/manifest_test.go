@@ -0,0 +1,84 @@
+package application
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateManifest(t *testing.T) {
+	app := Application{
+		Name:        "web",
+		Namespace:   "apps",
+		Image:       "nginx:latest",
+		Replicas:    2,
+		Env:         []EnvVar{{Name: "LOG_LEVEL", Value: "debug"}},
+		Ports:       []Port{{ContainerPort: 80, ServicePort: 8080}},
+		ServiceType: "NodePort",
+		Resources:   &Resources{RequestsCPU: "100m", LimitsMemory: "256Mi"},
+		Persistence: []Volume{{Name: "data", MountPath: "/data", Size: "1Gi"}},
+		Ingress:     &Ingress{ClassName: "nginx", Host: "web.example.com"},
+		Autoscaling: &Autoscaling{MinReplicas: 2, MaxReplicas: 4, TargetCPUUtilizationPercentage: 75},
+	}
+
+	manifest, err := GenerateManifest(app)
+	assert.NoError(t, err)
+
+	documents := strings.Split(string(manifest), manifestSeparator)
+	if !assert.Len(t, documents, 5) {
+		return
+	}
+
+	assert.Contains(t, documents[0], "kind: PersistentVolumeClaim")
+	assert.Contains(t, documents[0], "name: web-data")
+	assert.Contains(t, documents[0], "storage: 1Gi")
+
+	assert.Contains(t, documents[1], "kind: Deployment")
+	assert.Contains(t, documents[1], "namespace: apps")
+	assert.Contains(t, documents[1], "image: nginx:latest")
+	assert.Contains(t, documents[1], "claimName: web-data")
+	assert.Contains(t, documents[1], "replicas: 2")
+
+	assert.Contains(t, documents[2], "kind: Service")
+	assert.Contains(t, documents[2], "type: NodePort")
+	assert.Contains(t, documents[2], "port: 8080")
+	assert.Contains(t, documents[2], "targetPort: 80")
+
+	assert.Contains(t, documents[3], "kind: Ingress")
+	assert.Contains(t, documents[3], "ingressClassName: nginx")
+	assert.Contains(t, documents[3], "number: 8080")
+
+	assert.Contains(t, documents[4], "kind: HorizontalPodAutoscaler")
+	assert.Contains(t, documents[4], "averageUtilization: 75")
+	assert.Contains(t, documents[4], "maxReplicas: 4")
+}
+
+func TestGenerateManifest_DeploymentOnly(t *testing.T) {
+	manifest, err := GenerateManifest(Application{Name: "worker", Namespace: "default", Image: "busybox"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, strings.Count(string(manifest), "kind:"))
+	assert.Contains(t, string(manifest), "replicas: 1")
+}
+
+func TestApplicationValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		app  Application
+	}{
+		{name: "invalid name", app: Application{Name: "Web_App", Image: "nginx"}},
+		{name: "missing image", app: Application{Name: "web"}},
+		{name: "invalid service type", app: Application{Name: "web", Image: "nginx", ServiceType: "External"}},
+		{name: "invalid port", app: Application{Name: "web", Image: "nginx", Ports: []Port{{ContainerPort: 70000}}}},
+		{name: "invalid volume size", app: Application{Name: "web", Image: "nginx", Persistence: []Volume{{Name: "data", MountPath: "/data", Size: "big"}}}},
+		{name: "ingress without port", app: Application{Name: "web", Image: "nginx", Ingress: &Ingress{Host: "web.example.com"}}},
+		{name: "invalid autoscaling range", app: Application{Name: "web", Image: "nginx", Autoscaling: &Autoscaling{MinReplicas: 3, MaxReplicas: 2, TargetCPUUtilizationPercentage: 50}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, tt.app.Validate())
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
 	"github.com/portainer/portainer/api/dataservices"
 
 	"github.com/patrickmn/go-cache"
@@ -219,6 +220,19 @@ func (factory *ClientFactory) buildAgentConfig(endpoint *portainer.Endpoint) (*r
 	config.QPS = DefaultKubeClientQPS
 	config.Burst = DefaultKubeClientBurst
 
+	// a custom transport is required to pin the public key of the agent, rest.Config cannot express it
+	if len(endpoint.AgentPublicKeyPins) > 0 {
+		tlsConfig := crypto.CreateTLSConfiguration()
+		tlsConfig.InsecureSkipVerify = true
+		crypto.PinPublicKeys(tlsConfig, endpoint.AgentPublicKeyPins)
+
+		config.Insecure = false
+		config.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		}
+	}
+
 	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
 		return &agentHeaderRoundTripper{
 			signatureHeader: signature,
@@ -16,8 +16,8 @@ import (
 // using the specified command. The stdin parameter will be bound to the stdin process and the stdout process will write
 // to the stdout parameter.
 // This function only works against a local environment(endpoint) using an in-cluster config with the user's SA token.
-// This is a blocking operation.
-func (kcl *KubeClient) StartExecProcess(token string, useAdminToken bool, namespace, podName, containerName string, command []string, stdin io.Reader, stdout io.Writer, errChan chan error) {
+// This is a blocking operation, the process being terminated when the context is canceled.
+func (kcl *KubeClient) StartExecProcess(ctx context.Context, token string, useAdminToken bool, namespace, podName, containerName string, command []string, stdin io.Reader, stdout io.Writer, errChan chan error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		errChan <- err
@@ -51,7 +51,7 @@ func (kcl *KubeClient) StartExecProcess(token string, useAdminToken bool, namesp
 		return
 	}
 
-	err = exec.StreamWithContext(context.TODO(), remotecommand.StreamOptions{
+	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
 		Stdin:  stdin,
 		Stdout: stdout,
 		Tty:    true,
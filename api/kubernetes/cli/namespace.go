@@ -68,7 +68,26 @@ func (kcl *KubeClient) CreateNamespace(info models.K8sNamespaceDetails) error {
 	ns.Annotations = info.Annotations
 
 	_, err := client.Create(context.Background(), &ns, metav1.CreateOptions{})
-	return err
+	if err != nil {
+		return err
+	}
+
+	return kcl.applyNamespaceResources(info)
+}
+
+// applyNamespaceResources applies the resource quota and limit range of a namespace
+func (kcl *KubeClient) applyNamespaceResources(info models.K8sNamespaceDetails) error {
+	err := kcl.applyNamespaceResourceQuota(info.Name, info.ResourceQuota)
+	if err != nil {
+		return errors.Wrap(err, "failed applying namespace resource quota")
+	}
+
+	err = kcl.applyNamespaceLimitRange(info.Name, info.LimitRange)
+	if err != nil {
+		return errors.Wrap(err, "failed applying namespace limit range")
+	}
+
+	return nil
 }
 
 func isSystemNamespace(namespace v1.Namespace) bool {
@@ -131,7 +150,11 @@ func (kcl *KubeClient) UpdateNamespace(info models.K8sNamespaceDetails) error {
 	ns.Annotations = info.Annotations
 
 	_, err := client.Update(context.Background(), &ns, metav1.UpdateOptions{})
-	return err
+	if err != nil {
+		return err
+	}
+
+	return kcl.applyNamespaceResources(info)
 }
 
 func (kcl *KubeClient) DeleteNamespace(namespace string) error {
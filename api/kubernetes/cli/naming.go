@@ -15,6 +15,8 @@ const (
 	portainerConfigMapName                  = "portainer-config"
 	portainerConfigMapAccessPoliciesKey     = "NamespaceAccessPolicies"
 	portainerShellPodPrefix                 = "portainer-pod-kubectl-shell"
+	portainerResourceQuotaPrefix            = "portainer-rq"
+	portainerLimitRangePrefix               = "portainer-lr"
 )
 
 func UserServiceAccountName(userID int, instanceID string) string {
@@ -32,3 +34,11 @@ func namespaceClusterRoleBindingName(namespace string, instanceID string) string
 func userShellPodPrefix(serviceAccountName string) string {
 	return fmt.Sprintf("%s-%s-", portainerShellPodPrefix, serviceAccountName)
 }
+
+func namespaceResourceQuotaName(namespace string) string {
+	return fmt.Sprintf("%s-%s", portainerResourceQuotaPrefix, namespace)
+}
+
+func namespaceLimitRangeName(namespace string) string {
+	return fmt.Sprintf("%s-%s", portainerLimitRangePrefix, namespace)
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// applyNamespaceResourceQuota creates, updates or removes the portainer managed resource quota of a namespace.
+// A nil quota leaves the current state of the namespace untouched.
+func (kcl *KubeClient) applyNamespaceResourceQuota(namespace string, quota *models.K8sResourceQuota) error {
+	if quota == nil {
+		return nil
+	}
+
+	client := kcl.cli.CoreV1().ResourceQuotas(namespace)
+	name := namespaceResourceQuotaName(namespace)
+
+	if !quota.Enabled {
+		err := client.Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	hard := v1.ResourceList{}
+	if quota.CPU != "" {
+		cpu := resource.MustParse(quota.CPU)
+		hard[v1.ResourceLimitsCPU] = cpu
+		hard[v1.ResourceRequestsCPU] = cpu
+	}
+	if quota.Memory != "" {
+		memory := resource.MustParse(quota.Memory)
+		hard[v1.ResourceLimitsMemory] = memory
+		hard[v1.ResourceRequestsMemory] = memory
+	}
+
+	resourceQuota := &v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1.ResourceQuotaSpec{Hard: hard},
+	}
+
+	_, err := client.Create(context.TODO(), resourceQuota, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = client.Update(context.TODO(), resourceQuota, metav1.UpdateOptions{})
+	}
+
+	return err
+}
+
+// applyNamespaceLimitRange creates, updates or removes the portainer managed limit range of a namespace.
+// The limit range sets the default requests and limits of the containers created in the namespace, which is
+// required for them to be scheduled once a resource quota is enforced.
+// A nil limit range leaves the current state of the namespace untouched.
+func (kcl *KubeClient) applyNamespaceLimitRange(namespace string, limitRange *models.K8sLimitRange) error {
+	if limitRange == nil {
+		return nil
+	}
+
+	client := kcl.cli.CoreV1().LimitRanges(namespace)
+	name := namespaceLimitRangeName(namespace)
+
+	if !limitRange.Enabled {
+		err := client.Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	defaults := v1.ResourceList{}
+	if limitRange.CPU != "" {
+		defaults[v1.ResourceCPU] = resource.MustParse(limitRange.CPU)
+	}
+	if limitRange.Memory != "" {
+		defaults[v1.ResourceMemory] = resource.MustParse(limitRange.Memory)
+	}
+
+	lr := &v1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1.LimitRangeSpec{
+			Limits: []v1.LimitRangeItem{
+				{
+					Type:           v1.LimitTypeContainer,
+					Default:        defaults,
+					DefaultRequest: defaults,
+				},
+			},
+		},
+	}
+
+	_, err := client.Create(context.TODO(), lr, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = client.Update(context.TODO(), lr, metav1.UpdateOptions{})
+	}
+
+	return err
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"context"
+	"testing"
+
+	models "github.com/portainer/portainer/api/http/models/kubernetes"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_CreateNamespace_WithResourceQuota(t *testing.T) {
+	kcl := &KubeClient{
+		cli:        kfake.NewSimpleClientset(),
+		instanceID: "instance",
+	}
+
+	err := kcl.CreateNamespace(models.K8sNamespaceDetails{
+		Name:          "team-a",
+		ResourceQuota: &models.K8sResourceQuota{Enabled: true, CPU: "2", Memory: "1Gi"},
+		LimitRange:    &models.K8sLimitRange{Enabled: true, CPU: "250m", Memory: "128Mi"},
+	})
+	assert.NoError(t, err)
+
+	quota, err := kcl.cli.CoreV1().ResourceQuotas("team-a").Get(context.Background(), namespaceResourceQuotaName("team-a"), metav1.GetOptions{})
+	assert.NoError(t, err)
+	cpu := quota.Spec.Hard[v1.ResourceLimitsCPU]
+	assert.Equal(t, "2", cpu.String())
+	memory := quota.Spec.Hard[v1.ResourceLimitsMemory]
+	assert.Equal(t, "1Gi", memory.String())
+
+	limitRange, err := kcl.cli.CoreV1().LimitRanges("team-a").Get(context.Background(), namespaceLimitRangeName("team-a"), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, limitRange.Spec.Limits, 1)
+	defaultCPU := limitRange.Spec.Limits[0].Default[v1.ResourceCPU]
+	assert.Equal(t, "250m", defaultCPU.String())
+
+	t.Run("disabling the quota removes it", func(t *testing.T) {
+		err := kcl.UpdateNamespace(models.K8sNamespaceDetails{
+			Name:          "team-a",
+			ResourceQuota: &models.K8sResourceQuota{Enabled: false},
+		})
+		assert.NoError(t, err)
+
+		quotas, err := kcl.cli.CoreV1().ResourceQuotas("team-a").List(context.Background(), metav1.ListOptions{})
+		assert.NoError(t, err)
+		assert.Empty(t, quotas.Items)
+
+		_, err = kcl.cli.CoreV1().LimitRanges("team-a").Get(context.Background(), namespaceLimitRangeName("team-a"), metav1.GetOptions{})
+		assert.NoError(t, err, "limit range should be left untouched")
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
+	"net"
 	"net/url"
 	"os"
 	"strconv"
@@ -96,7 +97,9 @@ func (service *kubeClusterAccessService) IsSecure() bool {
 // - isInternal is used to determine whether the kubeclient is accessed internally (for example using the kube client for backend calls) or externally (for example downloading the kubeconfig file)
 func (service *kubeClusterAccessService) GetClusterDetails(hostURL string, endpointID portainer.EndpointID, isInternal bool) kubernetesClusterAccessData {
 	if hostURL == "localhost" {
-		hostURL += service.httpsBindAddr
+		if _, port, err := net.SplitHostPort(service.httpsBindAddr); err == nil {
+			hostURL = net.JoinHostPort(hostURL, port)
+		}
 	}
 
 	baseURL := service.baseURL
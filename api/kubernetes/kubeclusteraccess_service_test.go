@@ -130,4 +130,12 @@ func TestKubeClusterAccessService_GetKubeConfigInternal(t *testing.T) {
 
 		is.Equal(wantClusterAccessDetails, clusterAccessDetails)
 	})
+
+	t.Run("GetClusterDetails adds the port of the HTTPS bind address to localhost", func(t *testing.T) {
+		for _, bindAddr := range []string{":9443", "0.0.0.0:9443", "[::1]:9443"} {
+			kcs := NewKubeClusterAccessService("/", bindAddr, "")
+			clusterAccessDetails := kcs.GetClusterDetails("localhost", 1, true)
+			is.Equal("https://localhost:9443/api/endpoints/1/kubernetes", clusterAccessDetails.ClusterServerURL, "bind address %q", bindAddr)
+		}
+	})
 }
@@ -134,6 +134,82 @@ func GetNamespace(manifestYaml []byte) (string, error) {
 	return "", nil
 }
 
+// ValidateManifest checks that every document of the manifest describes Kubernetes resources
+// with the fields required by the API server (apiVersion, kind and metadata.name)
+func ValidateManifest(manifestYaml []byte) error {
+	yamlDecoder := yaml.NewDecoder(bytes.NewReader(manifestYaml))
+
+	resources := 0
+	for index := 1; ; index++ {
+		var m map[string]interface{}
+		err := yamlDecoder.Decode(&m)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "invalid yaml document #%d", index)
+		}
+
+		// empty document
+		if m == nil {
+			continue
+		}
+
+		if err := validateResource(m); err != nil {
+			return errors.Wrapf(err, "invalid yaml document #%d", index)
+		}
+
+		resources++
+	}
+
+	if resources == 0 {
+		return errors.New("manifest does not define any resource")
+	}
+
+	return nil
+}
+
+func validateResource(resource map[string]interface{}) error {
+	if apiVersion, _ := resource["apiVersion"].(string); apiVersion == "" {
+		return errors.New("missing apiVersion")
+	}
+
+	kind, _ := resource["kind"].(string)
+	if kind == "" {
+		return errors.New("missing kind")
+	}
+
+	if strings.HasSuffix(kind, "List") {
+		items, ok := resource["items"].([]interface{})
+		if !ok {
+			return errors.Errorf("missing items in %s", kind)
+		}
+
+		for _, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return errors.Errorf("invalid item in %s", kind)
+			}
+
+			if err := validateResource(m); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	metadata, _ := resource["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	generateName, _ := metadata["generateName"].(string)
+	if name == "" && generateName == "" {
+		return errors.Errorf("missing metadata.name in %s", kind)
+	}
+
+	return nil
+}
+
 func addResourceLabels(yamlDoc interface{}, appLabels map[string]string) {
 	m, ok := yamlDoc.(map[string]interface{})
 	if !ok {
@@ -711,3 +711,81 @@ kind: Namespace
 		})
 	}
 }
+
+func Test_ValidateManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name: "valid multiple documents",
+			input: `apiVersion: v1
+kind: Namespace
+metadata:
+  name: test
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  generateName: test-
+`,
+		},
+		{
+			name: "valid list",
+			input: `apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: test
+`,
+		},
+		{
+			name:    "empty manifest",
+			input:   "---\n",
+			wantErr: true,
+		},
+		{
+			name: "missing kind",
+			input: `apiVersion: v1
+metadata:
+  name: test
+`,
+			wantErr: true,
+		},
+		{
+			name: "missing name",
+			input: `apiVersion: v1
+kind: Service
+`,
+			wantErr: true,
+		},
+		{
+			name: "invalid list item",
+			input: `apiVersion: v1
+kind: List
+items:
+  - kind: ConfigMap
+`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid yaml",
+			input:   "apiVersion: [v1",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateManifest([]byte(tt.input))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
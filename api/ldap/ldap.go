@@ -2,7 +2,7 @@ package ldap
 
 import (
 	"fmt"
-	"strings"
+	"net"
 
 	ldap "github.com/go-ldap/ldap/v3"
 	"github.com/pkg/errors"
@@ -35,7 +35,10 @@ func createConnectionForURL(url string, settings *portainer.LDAPSettings) (*ldap
 		if err != nil {
 			return nil, err
 		}
-		config.ServerName = strings.Split(url, ":")[0]
+		config.ServerName = url
+		if host, _, err := net.SplitHostPort(url); err == nil {
+			config.ServerName = host
+		}
 
 		if settings.TLSConfig.TLS {
 			return ldap.DialTLS("tcp", url, config)
@@ -0,0 +1,24 @@
+package lifecycle
+
+import (
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// NewChangeRequestEvent creates an event about a change request of a protected environment
+func NewChangeRequestEvent(eventType string, changeRequest *portainer.ChangeRequest) Event {
+	data := map[string]string{
+		"endpointId":  strconv.Itoa(int(changeRequest.EndpointID)),
+		"requesterId": strconv.Itoa(int(changeRequest.RequesterID)),
+		"method":      changeRequest.Method,
+		"path":        changeRequest.Path,
+		"status":      string(changeRequest.Status),
+	}
+
+	if changeRequest.ReviewerID != 0 {
+		data["reviewerId"] = strconv.Itoa(int(changeRequest.ReviewerID))
+	}
+
+	return NewEvent(eventType, strconv.Itoa(int(changeRequest.ID)), data)
+}
@@ -0,0 +1,39 @@
+package lifecycle
+
+import (
+	"strconv"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// ContainerNotifier publishes the events of the containers whose health check fails, as detected by the snapshots
+// of the environments
+type ContainerNotifier struct {
+	dispatcher *Dispatcher
+}
+
+// NewContainerNotifier creates a notifier publishing the events of the containers through the dispatcher
+func NewContainerNotifier(dispatcher *Dispatcher) *ContainerNotifier {
+	return &ContainerNotifier{dispatcher: dispatcher}
+}
+
+// ContainerUnhealthy publishes a container.unhealthy event
+func (notifier *ContainerNotifier) ContainerUnhealthy(endpoint *portainer.Endpoint, containerID, containerName string) {
+	notifier.dispatcher.Publish(NewEvent(ContainerUnhealthy, containerID, containerData(endpoint, containerName)))
+}
+
+// ContainerRecoveryFailed publishes a container.recovery_failed event
+func (notifier *ContainerNotifier) ContainerRecoveryFailed(endpoint *portainer.Endpoint, containerID, containerName string, restarts int) {
+	data := containerData(endpoint, containerName)
+	data["restarts"] = strconv.Itoa(restarts)
+
+	notifier.dispatcher.Publish(NewEvent(ContainerRecoveryFail, containerID, data))
+}
+
+func containerData(endpoint *portainer.Endpoint, containerName string) map[string]string {
+	return map[string]string{
+		"endpointId":    strconv.Itoa(int(endpoint.ID)),
+		"endpointName":  endpoint.Name,
+		"containerName": containerName,
+	}
+}
@@ -0,0 +1,303 @@
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/database/changes"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/client"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxAttempts is the number of attempts made to deliver an event before giving up
+	maxAttempts = 5
+	// defaultRetryDelay is the delay before the first retry, doubled after each failed attempt
+	defaultRetryDelay = 2 * time.Second
+	// deliveryTimeout bounds the duration of a delivery attempt
+	deliveryTimeout = 10 * time.Second
+	// queueSize is the number of events waiting to be dispatched, the events published while it is full are dropped
+	queueSize = 256
+)
+
+// Dispatcher sends the lifecycle events to the enabled event webhooks subscribed to them. The events are signed
+// with the secret of the webhook, retried with an exponential backoff and recorded in the delivery log. The pending
+// deliveries are persisted in the delivery log, so that they are resumed when Portainer restarts.
+type Dispatcher struct {
+	dataStore  dataservices.DataStore
+	client     *http.Client
+	queue      chan Event
+	retryDelay time.Duration
+	listeners  []func(Event)
+	// failureListeners are called with the deliveries given up after the last attempt
+	failureListeners []func(portainer.EventWebhook, portainer.EventWebhookDelivery)
+}
+
+// NewDispatcher creates a dispatcher of the lifecycle events
+func NewDispatcher(dataStore dataservices.DataStore) *Dispatcher {
+	return &Dispatcher{
+		dataStore:  dataStore,
+		client:     client.NewExternalClient(deliveryTimeout),
+		queue:      make(chan Event, queueSize),
+		retryDelay: defaultRetryDelay,
+	}
+}
+
+// Start dispatches the events published and the events derived from the database modifications until the
+// context is done
+func (dispatcher *Dispatcher) Start(ctx context.Context) {
+	subscription, unsubscribe := changes.Subscribe()
+
+	dispatcher.resume(ctx)
+
+	go func() {
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case change := <-subscription:
+				if event, ok := eventFromChange(change); ok {
+					dispatcher.dispatch(ctx, event)
+				}
+
+			case event := <-dispatcher.queue:
+				dispatcher.dispatch(ctx, event)
+			}
+		}
+	}()
+}
+
+// Listen registers a function called with every event, whether webhooks are subscribed to it or not. The listeners
+// must be registered before the dispatcher is started.
+func (dispatcher *Dispatcher) Listen(listener func(Event)) {
+	dispatcher.listeners = append(dispatcher.listeners, listener)
+}
+
+// ListenFailures registers a function called with the deliveries given up after the last attempt, e.g. to notify the
+// administrators. The listeners must be registered before the dispatcher is started.
+func (dispatcher *Dispatcher) ListenFailures(listener func(portainer.EventWebhook, portainer.EventWebhookDelivery)) {
+	dispatcher.failureListeners = append(dispatcher.failureListeners, listener)
+}
+
+// Publish queues an event to be sent to the event webhooks
+func (dispatcher *Dispatcher) Publish(event Event) {
+	select {
+	case dispatcher.queue <- event:
+	default:
+		log.Warn().Str("event", event.Type).Msg("the lifecycle event queue is full, dropping the event")
+	}
+}
+
+// resume restarts the deliveries left pending by the previous run
+func (dispatcher *Dispatcher) resume(ctx context.Context) {
+	deliveries, err := dispatcher.dataStore.EventWebhook().PendingDeliveries()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to retrieve the pending event webhook deliveries")
+		return
+	}
+
+	for i := range deliveries {
+		go dispatcher.deliver(ctx, &deliveries[i])
+	}
+
+	if len(deliveries) > 0 {
+		log.Info().Int("count", len(deliveries)).Msg("resuming the pending event webhook deliveries")
+	}
+}
+
+// dispatch notifies the listeners and queues the event for each enabled webhook subscribed to it, the deliveries
+// being made in the background
+func (dispatcher *Dispatcher) dispatch(ctx context.Context, event Event) {
+	for _, listener := range dispatcher.listeners {
+		listener(event)
+	}
+
+	webhooks, err := dispatcher.dataStore.EventWebhook().ReadAll()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to retrieve the event webhooks")
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Err(err).Msg("unable to encode the lifecycle event")
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Enabled || (len(webhook.Events) > 0 && !slices.Contains(webhook.Events, event.Type)) {
+			continue
+		}
+
+		delivery := &portainer.EventWebhookDelivery{
+			WebhookID:   webhook.ID,
+			EventID:     event.ID,
+			Event:       event.Type,
+			Pending:     true,
+			NextAttempt: time.Now().Unix(),
+			Payload:     body,
+		}
+
+		if err := dispatcher.dataStore.EventWebhook().CreateDelivery(delivery); err != nil {
+			log.Error().Err(err).Int("webhook_id", int(webhook.ID)).Msg("unable to queue the event webhook delivery")
+			continue
+		}
+
+		go dispatcher.deliver(ctx, delivery)
+	}
+}
+
+// deliver sends a pending delivery to its webhook, retrying on failure. The outcome of each attempt is saved in the
+// delivery log, a delivery interrupted by the shutdown of the server staying pending.
+func (dispatcher *Dispatcher) deliver(ctx context.Context, delivery *portainer.EventWebhookDelivery) {
+	var event Event
+	if err := json.Unmarshal(delivery.Payload, &event); err != nil {
+		dispatcher.complete(delivery, "invalid payload of the pending delivery")
+		return
+	}
+
+	next := time.Unix(delivery.NextAttempt, 0)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		webhook, err := dispatcher.dataStore.EventWebhook().Read(delivery.WebhookID)
+		if dispatcher.dataStore.IsErrObjectNotFound(err) {
+			return
+		} else if err != nil {
+			log.Error().Err(err).Int("webhook_id", int(delivery.WebhookID)).Msg("unable to retrieve the event webhook")
+			return
+		}
+
+		if !webhook.Enabled {
+			dispatcher.complete(delivery, "the event webhook was disabled before the event was delivered")
+			return
+		}
+
+		statusCode, err := dispatcher.send(ctx, *webhook, event, delivery.Payload)
+		if ctx.Err() != nil {
+			return
+		}
+
+		delivery.Attempts++
+		delivery.Timestamp = time.Now().Unix()
+		delivery.StatusCode = statusCode
+
+		if err == nil {
+			delivery.Success = true
+			dispatcher.complete(delivery, "")
+
+			return
+		}
+
+		log.Debug().
+			Err(err).
+			Int("webhook_id", int(webhook.ID)).
+			Str("event", event.Type).
+			Int("attempt", delivery.Attempts).
+			Msg("unable to deliver the lifecycle event")
+
+		if delivery.Attempts >= maxAttempts {
+			log.Warn().
+				Err(err).
+				Int("webhook_id", int(webhook.ID)).
+				Str("event", event.Type).
+				Msg("giving up delivering the lifecycle event")
+
+			dispatcher.complete(delivery, err.Error())
+
+			for _, listener := range dispatcher.failureListeners {
+				listener(*webhook, *delivery)
+			}
+
+			return
+		}
+
+		next = time.Now().Add(dispatcher.retryDelay << (delivery.Attempts - 1))
+
+		delivery.Error = err.Error()
+		delivery.NextAttempt = next.Unix()
+		if !dispatcher.updateDelivery(delivery) {
+			return
+		}
+	}
+}
+
+// complete saves the final outcome of a delivery, its payload being no longer needed
+func (dispatcher *Dispatcher) complete(delivery *portainer.EventWebhookDelivery, deliveryError string) {
+	delivery.Error = deliveryError
+	delivery.Pending = false
+	delivery.NextAttempt = 0
+	delivery.Payload = nil
+
+	dispatcher.updateDelivery(delivery)
+}
+
+// updateDelivery saves a delivery and returns whether it still exists, the deliveries being removed with their webhook
+func (dispatcher *Dispatcher) updateDelivery(delivery *portainer.EventWebhookDelivery) bool {
+	err := dispatcher.dataStore.EventWebhook().UpdateDelivery(delivery)
+	if dispatcher.dataStore.IsErrObjectNotFound(err) {
+		return false
+	} else if err != nil {
+		log.Error().Err(err).Msg("unable to save the event webhook delivery")
+	}
+
+	return true
+}
+
+// send posts the event to the webhook, any response but a 2xx being a failure
+func (dispatcher *Dispatcher) send(ctx context.Context, webhook portainer.EventWebhook, event Event, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Portainer-Webhook")
+	req.Header.Set("X-Portainer-Event", event.Type)
+	req.Header.Set("X-Portainer-Delivery", event.ID)
+	req.Header.Set("X-Portainer-Timestamp", strconv.FormatInt(event.Time, 10))
+
+	if webhook.Secret != "" {
+		req.Header.Set("X-Portainer-Signature", Sign(webhook.Secret, body))
+	}
+
+	resp, err := dispatcher.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the value of the X-Portainer-Signature header of the payload, the hex encoded HMAC-SHA256 of the
+// payload keyed with the secret of the webhook, prefixed with "sha256="
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
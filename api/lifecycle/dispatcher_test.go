@@ -0,0 +1,235 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/database/changes"
+	"github.com/portainer/portainer/api/dataservices/endpoint"
+	"github.com/portainer/portainer/api/dataservices/stack"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatcher_DeliversSignedEventsWithRetry(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	var attempts atomic.Int32
+	received := make(chan Event, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		assert.Equal(t, Sign("secret", body), r.Header.Get("X-Portainer-Signature"))
+		assert.Equal(t, EndpointCreated, r.Header.Get("X-Portainer-Event"))
+
+		var event Event
+		assert.NoError(t, json.Unmarshal(body, &event))
+		received <- event
+	}))
+	defer server.Close()
+
+	webhook := &portainer.EventWebhook{Name: "cmdb", URL: server.URL, Secret: "secret", Enabled: true}
+	assert.NoError(t, store.EventWebhook().Create(webhook))
+
+	// not subscribed to the environment events
+	assert.NoError(t, store.EventWebhook().Create(&portainer.EventWebhook{
+		Name:    "logins",
+		URL:     server.URL,
+		Events:  []string{UserLoggedIn},
+		Enabled: true,
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dispatcher := NewDispatcher(store)
+	dispatcher.retryDelay = 10 * time.Millisecond
+	dispatcher.Start(ctx)
+
+	assert.NoError(t, store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "env"}))
+
+	select {
+	case event := <-received:
+		assert.Equal(t, EndpointCreated, event.Type)
+		assert.Equal(t, "1", event.ResourceID)
+		assert.NotEmpty(t, event.ID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the event was not delivered")
+	}
+
+	var deliveries []portainer.EventWebhookDelivery
+	assert.Eventually(t, func() bool {
+		deliveries, _ = store.EventWebhook().Deliveries(webhook.ID)
+		return len(deliveries) == 1 && !deliveries[0].Pending
+	}, 5*time.Second, 10*time.Millisecond)
+
+	if assert.Len(t, deliveries, 1) {
+		assert.True(t, deliveries[0].Success)
+		assert.Empty(t, deliveries[0].Payload)
+		assert.Equal(t, 2, deliveries[0].Attempts)
+		assert.Equal(t, http.StatusOK, deliveries[0].StatusCode)
+		assert.Equal(t, EndpointCreated, deliveries[0].Event)
+	}
+
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestDispatcher_ResumesPendingDeliveries(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	received := make(chan *http.Request, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+	}))
+	defer server.Close()
+
+	webhook := &portainer.EventWebhook{Name: "cmdb", URL: server.URL, Secret: "secret", Enabled: true}
+	assert.NoError(t, store.EventWebhook().Create(webhook))
+
+	payload, err := json.Marshal(Event{ID: "event-1", Type: StackDeployed, Time: 1700000000})
+	assert.NoError(t, err)
+
+	// delivery left pending by a previous run after a failed attempt
+	delivery := &portainer.EventWebhookDelivery{
+		WebhookID:   webhook.ID,
+		EventID:     "event-1",
+		Event:       StackDeployed,
+		Attempts:    1,
+		Error:       "unexpected response status: 503 Service Unavailable",
+		Pending:     true,
+		NextAttempt: time.Now().Unix(),
+		Payload:     payload,
+	}
+	assert.NoError(t, store.EventWebhook().CreateDelivery(delivery))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	NewDispatcher(store).Start(ctx)
+
+	select {
+	case r := <-received:
+		assert.Equal(t, "event-1", r.Header.Get("X-Portainer-Delivery"))
+		assert.Equal(t, "1700000000", r.Header.Get("X-Portainer-Timestamp"))
+		assert.Equal(t, Sign("secret", payload), r.Header.Get("X-Portainer-Signature"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("the pending delivery was not resumed")
+	}
+
+	assert.Eventually(t, func() bool {
+		pending, _ := store.EventWebhook().PendingDeliveries()
+		return len(pending) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	deliveries, err := store.EventWebhook().Deliveries(webhook.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 1) {
+		assert.True(t, deliveries[0].Success)
+		assert.Equal(t, 2, deliveries[0].Attempts)
+		assert.Empty(t, deliveries[0].Error)
+	}
+}
+
+func TestDispatcher_NotifiesGivenUpDeliveries(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	webhook := &portainer.EventWebhook{Name: "cmdb", URL: server.URL, Enabled: true}
+	assert.NoError(t, store.EventWebhook().Create(webhook))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	failures := make(chan portainer.EventWebhookDelivery, 1)
+
+	dispatcher := NewDispatcher(store)
+	dispatcher.retryDelay = time.Millisecond
+	dispatcher.ListenFailures(func(failedWebhook portainer.EventWebhook, delivery portainer.EventWebhookDelivery) {
+		assert.Equal(t, webhook.ID, failedWebhook.ID)
+		failures <- delivery
+	})
+	dispatcher.Start(ctx)
+
+	dispatcher.Publish(NewEvent(StackDeleted, "1", nil))
+
+	select {
+	case delivery := <-failures:
+		assert.Equal(t, StackDeleted, delivery.Event)
+		assert.Equal(t, maxAttempts, delivery.Attempts)
+		assert.False(t, delivery.Success)
+		assert.Equal(t, "unexpected response status: 500 Internal Server Error", delivery.Error)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the given up delivery was not notified")
+	}
+}
+
+func TestDeliveryLogIsPruned(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	webhook := &portainer.EventWebhook{Name: "cmdb", URL: "http://localhost"}
+	assert.NoError(t, store.EventWebhook().Create(webhook))
+
+	for i := 0; i < 105; i++ {
+		assert.NoError(t, store.EventWebhook().CreateDelivery(&portainer.EventWebhookDelivery{WebhookID: webhook.ID}))
+	}
+
+	deliveries, err := store.EventWebhook().Deliveries(webhook.ID)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 100)
+	assert.Equal(t, portainer.EventWebhookDeliveryID(105), deliveries[0].ID)
+
+	assert.NoError(t, store.EventWebhook().Delete(webhook.ID))
+
+	deliveries, err = store.EventWebhook().Deliveries(webhook.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
+
+func TestEventFromChange(t *testing.T) {
+	is := assert.New(t)
+
+	event, ok := eventFromChange(changes.Change{Bucket: endpoint.BucketName, Key: "1", Action: changes.ActionCreate, Time: time.Unix(1700000000, 0)})
+	is.True(ok)
+	is.Equal(EndpointCreated, event.Type)
+	is.Equal("1", event.ResourceID)
+	is.Equal(int64(1700000000), event.Time)
+
+	event, ok = eventFromChange(changes.Change{Bucket: stack.BucketName, Key: "2", Action: changes.ActionDelete})
+	is.True(ok)
+	is.Equal(StackDeleted, event.Type)
+
+	// the stacks stopped or renamed are not deployed, the handlers publish the deployments
+	_, ok = eventFromChange(changes.Change{Bucket: stack.BucketName, Key: "2", Action: changes.ActionUpdate})
+	is.False(ok)
+
+	// the access changes are published by the handlers
+	_, ok = eventFromChange(changes.Change{Bucket: "endpoint_groups", Key: "1", Action: changes.ActionUpdate})
+	is.False(ok)
+}
+
+func TestNewAccessChangedEvent(t *testing.T) {
+	event := NewAccessChangedEvent("team_membership", "3", changes.ActionDelete)
+
+	assert.Equal(t, AccessChanged, event.Type)
+	assert.Equal(t, "3", event.ResourceID)
+	assert.Equal(t, map[string]string{"resource": "team_membership", "action": "delete"}, event.Data)
+}
@@ -0,0 +1,135 @@
+// Package lifecycle publishes the lifecycle events of Portainer (environments created or deleted, stacks deployed,
+// users logging in, access policies changed, images updated, containers unhealthy, changes of the protected
+// environments requested and reviewed, anomalous usage of the API, suspicious authentication events) to the event webhooks registered by the administrators.
+package lifecycle
+
+import (
+	"slices"
+	"time"
+
+	"github.com/portainer/portainer/api/database/changes"
+	"github.com/portainer/portainer/api/dataservices/endpoint"
+	"github.com/portainer/portainer/api/dataservices/stack"
+
+	"github.com/gofrs/uuid"
+)
+
+const (
+	EndpointCreated  = "endpoint.created"
+	EndpointDeleted  = "endpoint.deleted"
+	StackDeployed    = "stack.deployed"
+	StackDeleted     = "stack.deleted"
+	UserLoggedIn     = "user.login"
+	UserImpersonated = "user.impersonated"
+	AccessChanged    = "access.changed"
+	ImageUpdated     = "image.updated"
+	ImageUpdateFail  = "image.update_failed"
+
+	ContainerUnhealthy    = "container.unhealthy"
+	ContainerRecoveryFail = "container.recovery_failed"
+
+	UserSignupRequested = "user.signup_requested"
+	UserSignupApproved  = "user.signup_approved"
+	UserSignupRejected  = "user.signup_rejected"
+
+	ChangeRequested = "change.requested"
+	ChangeApproved  = "change.approved"
+	ChangeRejected  = "change.rejected"
+	ChangeFailed    = "change.failed"
+
+	APIUsageAnomaly = "api.usage_anomaly"
+	SecurityAlert   = "security.alert"
+)
+
+// EventTypes lists the types of the events that can be sent to the event webhooks
+var EventTypes = []string{
+	EndpointCreated,
+	EndpointDeleted,
+	StackDeployed,
+	StackDeleted,
+	UserLoggedIn,
+	UserImpersonated,
+	AccessChanged,
+	ImageUpdated,
+	ImageUpdateFail,
+	ContainerUnhealthy,
+	ContainerRecoveryFail,
+	UserSignupRequested,
+	UserSignupApproved,
+	UserSignupRejected,
+	ChangeRequested,
+	ChangeApproved,
+	ChangeRejected,
+	ChangeFailed,
+	APIUsageAnomaly,
+	SecurityAlert,
+}
+
+// IsEventType returns true when the type is one of the event types
+func IsEventType(eventType string) bool {
+	return slices.Contains(EventTypes, eventType)
+}
+
+// Event is the payload sent to the event webhooks
+type Event struct {
+	// Unique identifier of the event, also sent in the X-Portainer-Delivery header
+	ID string `json:"id"`
+	// Type of the event, also sent in the X-Portainer-Event header
+	Type string `json:"type"`
+	// Unix timestamp of the event
+	Time int64 `json:"time"`
+	// Identifier of the resource the event relates to
+	ResourceID string `json:"resourceId"`
+	// Details of the event, depending on its type
+	Data map[string]string `json:"data,omitempty"`
+}
+
+// NewEvent creates an event of the specified type occurring now
+func NewEvent(eventType, resourceID string, data map[string]string) Event {
+	return Event{
+		ID:         uuid.Must(uuid.NewV4()).String(),
+		Type:       eventType,
+		Time:       time.Now().Unix(),
+		ResourceID: resourceID,
+		Data:       data,
+	}
+}
+
+// NewAccessChangedEvent creates the event of a change of the access to a resource, such as the access policies of an
+// environment or the members of a team. The resource is the name of the bucket of the resource.
+func NewAccessChangedEvent(resource, resourceID string, action changes.Action) Event {
+	return NewEvent(AccessChanged, resourceID, map[string]string{
+		"resource": resource,
+		"action":   string(action),
+	})
+}
+
+// eventFromChange translates a database modification into the lifecycle event it represents, if any. The stacks
+// deployed and the access changes are published by the handlers, as most of the updates of their buckets are neither.
+func eventFromChange(change changes.Change) (Event, bool) {
+	var eventType string
+
+	switch change.Bucket {
+	case endpoint.BucketName:
+		switch change.Action {
+		case changes.ActionCreate:
+			eventType = EndpointCreated
+		case changes.ActionDelete:
+			eventType = EndpointDeleted
+		}
+
+	case stack.BucketName:
+		if change.Action == changes.ActionDelete {
+			eventType = StackDeleted
+		}
+	}
+
+	if eventType == "" {
+		return Event{}, false
+	}
+
+	event := NewEvent(eventType, change.Key, nil)
+	event.Time = change.Time.Unix()
+
+	return event, true
+}
@@ -0,0 +1,250 @@
+// Package mail sends the emails of Portainer (password resets, account requests and approvals, alert notifications
+// and event webhook failures) through the SMTP server of the settings, using message templates which the
+// administrators can override
+package mail
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
+
+	"github.com/asaskevich/govalidator"
+)
+
+const (
+	SecurityNone     = "none"
+	SecurityStartTLS = "starttls"
+	SecurityTLS      = "tls"
+
+	timeout = 10 * time.Second
+)
+
+// ErrDisabled is returned when an email is sent while the SMTP server is not configured
+var ErrDisabled = errors.New("the emails are disabled")
+
+type config struct {
+	settings  portainer.SMTPSettings
+	templates map[string]*messageTemplate
+}
+
+// Service sends the emails through the SMTP server of the settings
+type Service struct {
+	current atomic.Pointer[config]
+}
+
+// NewService creates a service sending the emails through the SMTP server of the settings, which must have been validated
+func NewService(settings portainer.SMTPSettings) *Service {
+	s := &Service{}
+	s.Update(settings)
+
+	return s
+}
+
+// Update replaces the SMTP server and the message templates with the ones of the settings, which must have been validated
+func (s *Service) Update(settings portainer.SMTPSettings) {
+	if !settings.Enabled {
+		s.current.Store(nil)
+		return
+	}
+
+	templates, err := parseTemplates(settings.Templates)
+	if err != nil {
+		s.current.Store(nil)
+		return
+	}
+
+	s.current.Store(&config{settings: settings, templates: templates})
+}
+
+// Enabled returns whether the emails are sent
+func (s *Service) Enabled() bool {
+	return s.current.Load() != nil
+}
+
+// Send renders the template with the data and sends the message to the recipients
+func (s *Service) Send(to []string, templateName string, data any) error {
+	c := s.current.Load()
+	if c == nil {
+		return ErrDisabled
+	}
+
+	t, ok := c.templates[templateName]
+	if !ok {
+		return fmt.Errorf("unknown email template %q", templateName)
+	}
+
+	subject, body, err := t.render(data)
+	if err != nil {
+		return fmt.Errorf("unable to render the %s email template: %w", templateName, err)
+	}
+
+	return send(c.settings, to, buildMessage(c.settings.From, to, subject, body))
+}
+
+// alertRecipients returns the recipients of the alert notifications, nil when the emails are disabled
+func (s *Service) alertRecipients() []string {
+	c := s.current.Load()
+	if c == nil {
+		return nil
+	}
+
+	return c.settings.AlertRecipients
+}
+
+// ValidateSettings checks the SMTP server, the recipients and the message templates of the settings
+func ValidateSettings(settings portainer.SMTPSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
+
+	if settings.Host == "" {
+		return errors.New("invalid SMTP server, a host is required")
+	}
+
+	if settings.Port < 0 || settings.Port > 65535 {
+		return fmt.Errorf("invalid SMTP port %d", settings.Port)
+	}
+
+	switch settings.Security {
+	case "", SecurityNone, SecurityStartTLS, SecurityTLS:
+	default:
+		return fmt.Errorf("invalid SMTP security %q, none, starttls or tls is expected", settings.Security)
+	}
+
+	if settings.Security == SecurityNone && settings.Username != "" {
+		return errors.New("invalid SMTP security, the credentials can only be sent over starttls or tls")
+	}
+
+	if !govalidator.IsEmail(settings.From) {
+		return fmt.Errorf("invalid sender address %q", settings.From)
+	}
+
+	for _, recipient := range settings.AlertRecipients {
+		if !govalidator.IsEmail(recipient) {
+			return fmt.Errorf("invalid alert recipient %q", recipient)
+		}
+	}
+
+	for name := range settings.Templates {
+		if _, ok := DefaultTemplates[name]; !ok {
+			return fmt.Errorf("unknown email template %q", name)
+		}
+	}
+
+	_, err := parseTemplates(settings.Templates)
+
+	return err
+}
+
+// port returns the port of the SMTP server, defaulting to the standard port of the security
+func port(settings portainer.SMTPSettings) int {
+	if settings.Port != 0 {
+		return settings.Port
+	}
+
+	switch settings.Security {
+	case SecurityNone:
+		return 25
+	case SecurityTLS:
+		return 465
+	default:
+		return 587
+	}
+}
+
+// send delivers the message to the recipients through the SMTP server
+func send(settings portainer.SMTPSettings, to []string, message []byte) error {
+	addr := net.JoinHostPort(settings.Host, strconv.Itoa(port(settings)))
+
+	tlsConfig := crypto.CreateTLSConfiguration()
+	tlsConfig.ServerName = settings.Host
+	tlsConfig.InsecureSkipVerify = settings.TLSSkipVerify
+
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	var err error
+	if settings.Security == SecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	client, err := smtp.NewClient(conn, settings.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if settings.Security == "" || settings.Security == SecurityStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if settings.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(settings.From); err != nil {
+		return err
+	}
+
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// buildMessage formats a plain text message, the subject being encoded when it is not ASCII
+func buildMessage(from string, to []string, subject, body string) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(slices.Compact(slices.Clone(to)), ", ") + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+
+	return b.Bytes()
+}
@@ -0,0 +1,237 @@
+package mail
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/lifecycle"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type received struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer accepts the messages sent without TLS nor authentication, returning its settings
+func fakeSMTPServer(t *testing.T) (portainer.SMTPSettings, <-chan received) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan received, 10)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go serveSMTP(conn, messages)
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+
+	return portainer.SMTPSettings{
+		Enabled:  true,
+		Host:     host,
+		Port:     portNumber,
+		Security: SecurityNone,
+		From:     "portainer@example.com",
+	}, messages
+}
+
+func serveSMTP(conn net.Conn, messages chan<- received) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP")
+
+	var msg received
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch command := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); command {
+		case "EHLO", "HELO", "RSET", "NOOP":
+			reply("250 localhost")
+		case "MAIL":
+			msg.from = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<> ")
+			reply("250 OK")
+		case "RCPT":
+			msg.to = append(msg.to, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<> "))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+
+			msg.data = data.String()
+			messages <- msg
+			msg = received{}
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func waitMessage(t *testing.T, messages <-chan received) received {
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email was received")
+	}
+
+	return received{}
+}
+
+func TestService_Send(t *testing.T) {
+	settings, messages := fakeSMTPServer(t)
+	settings.Templates = map[string]portainer.EmailTemplate{
+		TemplateSignupApproved: {Subject: "Bienvenue {{ .Username }} ✓"},
+	}
+
+	service := NewService(settings)
+
+	err := service.Send([]string{"bob@example.com"}, TemplateSignupApproved, UserData{Username: "bob"})
+	assert.NoError(t, err)
+
+	msg := waitMessage(t, messages)
+	assert.Equal(t, "portainer@example.com", msg.from)
+	assert.Equal(t, []string{"bob@example.com"}, msg.to)
+	assert.Contains(t, msg.data, "To: bob@example.com\r\n")
+	assert.Contains(t, msg.data, "Subject: =?utf-8?q?Bienvenue_bob_=E2=9C=93?=\r\n", "the subject should be overridden and encoded")
+	assert.Contains(t, msg.data, "\r\n\r\nHello bob,\r\n\r\nYour Portainer account request was approved", "the default body should be kept")
+
+	err = service.Send([]string{"bob@example.com"}, "unknown", nil)
+	assert.Error(t, err)
+}
+
+func TestService_Disabled(t *testing.T) {
+	service := NewService(portainer.SMTPSettings{})
+	assert.False(t, service.Enabled())
+
+	err := service.Send([]string{"bob@example.com"}, TemplateSignupApproved, UserData{Username: "bob"})
+	assert.ErrorIs(t, err, ErrDisabled)
+}
+
+func TestService_NotifyEvent(t *testing.T) {
+	settings, messages := fakeSMTPServer(t)
+	settings.AlertRecipients = []string{"ops@example.com"}
+
+	service := NewService(settings)
+
+	// not an alert
+	service.NotifyEvent(lifecycle.NewEvent(lifecycle.StackDeployed, "1", nil))
+
+	service.NotifyEvent(lifecycle.NewEvent(lifecycle.ContainerUnhealthy, "c1", map[string]string{
+		"endpointName":  "production",
+		"containerName": "web",
+	}))
+
+	msg := waitMessage(t, messages)
+	assert.Equal(t, []string{"ops@example.com"}, msg.to)
+	assert.Contains(t, msg.data, "Subject: [Portainer] container.unhealthy on production\r\n")
+	assert.Contains(t, msg.data, "containerName: web\r\n")
+
+	service.NotifyEvent(lifecycle.NewEvent(lifecycle.UserSignupApproved, "3", map[string]string{"username": "bob", "email": "bob@example.com"}))
+
+	msg = waitMessage(t, messages)
+	assert.Equal(t, []string{"bob@example.com"}, msg.to)
+	assert.Contains(t, msg.data, "Subject: Your Portainer account was approved\r\n")
+
+	service.NotifyWebhookFailure(
+		portainer.EventWebhook{ID: 2, URL: "https://cmdb.example.com/hook"},
+		portainer.EventWebhookDelivery{Event: lifecycle.StackDeployed, EventID: "6a1e", Attempts: 5, Error: "connection refused"},
+	)
+
+	msg = waitMessage(t, messages)
+	assert.Equal(t, []string{"ops@example.com"}, msg.to)
+	assert.Contains(t, msg.data, "Subject: [Portainer] Event webhook 2 failed\r\n")
+	assert.Contains(t, msg.data, "Last error: connection refused")
+
+	assert.Empty(t, messages)
+}
+
+func TestValidateSettings(t *testing.T) {
+	valid := []portainer.SMTPSettings{
+		{},
+		{Enabled: true, Host: "smtp.example.com", From: "portainer@example.com"},
+		{Enabled: true, Host: "smtp.example.com", Port: 465, Security: SecurityTLS, Username: "portainer", From: "portainer@example.com", AlertRecipients: []string{"ops@example.com"}},
+		{Enabled: true, Host: "smtp.example.com", From: "portainer@example.com", Templates: map[string]portainer.EmailTemplate{TemplateAlert: {Body: "{{ .Type }}"}}},
+	}
+
+	for _, settings := range valid {
+		assert.NoError(t, ValidateSettings(settings), settings)
+	}
+
+	invalid := []portainer.SMTPSettings{
+		{Enabled: true, From: "portainer@example.com"},
+		{Enabled: true, Host: "smtp.example.com", Port: 70000, From: "portainer@example.com"},
+		{Enabled: true, Host: "smtp.example.com", Security: "ssl", From: "portainer@example.com"},
+		{Enabled: true, Host: "smtp.example.com", Security: SecurityNone, Username: "portainer", From: "portainer@example.com"},
+		{Enabled: true, Host: "smtp.example.com", From: "portainer"},
+		{Enabled: true, Host: "smtp.example.com", From: "portainer@example.com", AlertRecipients: []string{"ops"}},
+		{Enabled: true, Host: "smtp.example.com", From: "portainer@example.com", Templates: map[string]portainer.EmailTemplate{"unknown": {}}},
+		{Enabled: true, Host: "smtp.example.com", From: "portainer@example.com", Templates: map[string]portainer.EmailTemplate{TemplateAlert: {Subject: "{{ .Type "}}},
+	}
+
+	for _, settings := range invalid {
+		assert.Error(t, ValidateSettings(settings), settings)
+	}
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	templates, err := parseTemplates(nil)
+	assert.NoError(t, err)
+
+	data := map[string]any{
+		TemplatePasswordReset:   PasswordResetData{Username: "bob", Token: "ptp_token", ExpiresAt: time.Unix(1700003600, 0)},
+		TemplateSignupRequested: UserData{Username: "bob", Email: "bob@example.com"},
+		TemplateSignupApproved:  UserData{Username: "bob"},
+		TemplateSignupRejected:  UserData{Username: "bob"},
+		TemplateAlert:           AlertData{Type: lifecycle.ImageUpdateFail, Time: time.Unix(1700000000, 0)},
+		TemplateWebhookFailure:  WebhookFailureData{WebhookID: 1},
+	}
+
+	assert.Len(t, templates, len(data))
+
+	for name, template := range templates {
+		subject, body, err := template.render(data[name])
+		assert.NoError(t, err, name)
+		assert.NotEmpty(t, subject, name)
+		assert.NotContains(t, body, "<no value>", name)
+	}
+
+	subject, body, err := templates[TemplatePasswordReset].render(data[TemplatePasswordReset])
+	assert.NoError(t, err)
+	assert.Equal(t, "Reset your Portainer password", subject)
+	assert.Contains(t, body, "\nptp_token\n")
+}
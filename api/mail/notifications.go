@@ -0,0 +1,80 @@
+package mail
+
+import (
+	"slices"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/lifecycle"
+
+	"github.com/rs/zerolog/log"
+)
+
+// AlertEvents are the lifecycle events sent to the alert recipients
+var AlertEvents = []string{
+	lifecycle.ContainerUnhealthy,
+	lifecycle.ContainerRecoveryFail,
+	lifecycle.ImageUpdateFail,
+	lifecycle.ChangeFailed,
+	lifecycle.APIUsageAnomaly,
+	lifecycle.SecurityAlert,
+}
+
+// NotifyEvent emails the alert events to the alert recipients, the account requests to the alert recipients and
+// the outcome of the account requests to the requesters. The emails are sent in the background.
+func (s *Service) NotifyEvent(event lifecycle.Event) {
+	if !s.Enabled() {
+		return
+	}
+
+	switch {
+	case slices.Contains(AlertEvents, event.Type):
+		s.sendInBackground(s.alertRecipients(), TemplateAlert, AlertData{
+			Type:       event.Type,
+			ResourceID: event.ResourceID,
+			Time:       time.Unix(event.Time, 0),
+			Data:       event.Data,
+		})
+
+	case event.Type == lifecycle.UserSignupRequested:
+		s.sendInBackground(s.alertRecipients(), TemplateSignupRequested, userData(event))
+
+	case event.Type == lifecycle.UserSignupApproved && event.Data["email"] != "":
+		s.sendInBackground([]string{event.Data["email"]}, TemplateSignupApproved, userData(event))
+
+	case event.Type == lifecycle.UserSignupRejected && event.Data["email"] != "":
+		s.sendInBackground([]string{event.Data["email"]}, TemplateSignupRejected, userData(event))
+	}
+}
+
+// NotifyWebhookFailure emails the event webhook deliveries given up to the alert recipients, in the background
+func (s *Service) NotifyWebhookFailure(webhook portainer.EventWebhook, delivery portainer.EventWebhookDelivery) {
+	if !s.Enabled() {
+		return
+	}
+
+	s.sendInBackground(s.alertRecipients(), TemplateWebhookFailure, WebhookFailureData{
+		WebhookID:  webhook.ID,
+		WebhookURL: webhook.URL,
+		Event:      delivery.Event,
+		EventID:    delivery.EventID,
+		Attempts:   delivery.Attempts,
+		Error:      delivery.Error,
+	})
+}
+
+func (s *Service) sendInBackground(to []string, templateName string, data any) {
+	if len(to) == 0 {
+		return
+	}
+
+	go func() {
+		if err := s.Send(to, templateName, data); err != nil {
+			log.Warn().Err(err).Str("template", templateName).Msg("unable to send the email")
+		}
+	}()
+}
+
+func userData(event lifecycle.Event) UserData {
+	return UserData{Username: event.Data["username"], Email: event.Data["email"]}
+}
@@ -0,0 +1,169 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+const (
+	// TemplatePasswordReset is sent to a user when an administrator issues a password reset token, with PasswordResetData
+	TemplatePasswordReset = "password_reset"
+	// TemplateSignupRequested is sent to the alert recipients when an account is requested, with UserData
+	TemplateSignupRequested = "signup_requested"
+	// TemplateSignupApproved is sent to a user when their account request is approved, with UserData
+	TemplateSignupApproved = "signup_approved"
+	// TemplateSignupRejected is sent to a user when their account request is rejected, with UserData
+	TemplateSignupRejected = "signup_rejected"
+	// TemplateAlert is sent to the alert recipients when an alert event occurs, with AlertData
+	TemplateAlert = "alert"
+	// TemplateWebhookFailure is sent to the alert recipients when an event webhook delivery is given up, with WebhookFailureData
+	TemplateWebhookFailure = "webhook_failure"
+)
+
+// PasswordResetData is the data of the password_reset template
+type PasswordResetData struct {
+	Username  string
+	Token     string
+	ExpiresAt time.Time
+}
+
+// UserData is the data of the signup templates
+type UserData struct {
+	Username string
+	Email    string
+}
+
+// AlertData is the data of the alert template
+type AlertData struct {
+	// Type of the lifecycle event, e.g. container.unhealthy
+	Type       string
+	ResourceID string
+	Time       time.Time
+	// Details of the event, e.g. endpointName and containerName
+	Data map[string]string
+}
+
+// WebhookFailureData is the data of the webhook_failure template
+type WebhookFailureData struct {
+	WebhookID  portainer.EventWebhookID
+	WebhookURL string
+	// Type of the lifecycle event which could not be delivered
+	Event    string
+	EventID  string
+	Attempts int
+	Error    string
+}
+
+// DefaultTemplates are the message templates used when the settings do not override them
+var DefaultTemplates = map[string]portainer.EmailTemplate{
+	TemplatePasswordReset: {
+		Subject: "Reset your Portainer password",
+		Body: `Hello {{ .Username }},
+
+A password reset was requested for your Portainer account. Use the following token to choose a new password
+before {{ .ExpiresAt.Format "2006-01-02 15:04 MST" }}:
+
+{{ .Token }}
+
+If you did not expect this email, contact your Portainer administrator.
+`,
+	},
+	TemplateSignupRequested: {
+		Subject: "Portainer account requested by {{ .Username }}",
+		Body: `Hello,
+
+{{ .Username }}{{ with .Email }} ({{ . }}){{ end }} requested a Portainer account, which is waiting for the approval of an administrator.
+`,
+	},
+	TemplateSignupApproved: {
+		Subject: "Your Portainer account was approved",
+		Body: `Hello {{ .Username }},
+
+Your Portainer account request was approved, you can now log in.
+`,
+	},
+	TemplateSignupRejected: {
+		Subject: "Your Portainer account request was rejected",
+		Body: `Hello {{ .Username }},
+
+Your Portainer account request was rejected by an administrator.
+`,
+	},
+	TemplateAlert: {
+		Subject: "[Portainer] {{ .Type }}{{ with index .Data \"endpointName\" }} on {{ . }}{{ end }}",
+		Body: `Event: {{ .Type }}
+Resource: {{ .ResourceID }}
+Time: {{ .Time.Format "2006-01-02 15:04:05 MST" }}
+{{ range $name, $value := .Data }}
+{{ $name }}: {{ $value }}{{ end }}
+`,
+	},
+	TemplateWebhookFailure: {
+		Subject: "[Portainer] Event webhook {{ .WebhookID }} failed",
+		Body: `The {{ .Event }} event {{ .EventID }} could not be delivered to the event webhook {{ .WebhookID }} ({{ .WebhookURL }})
+after {{ .Attempts }} attempts.
+
+Last error: {{ .Error }}
+`,
+	},
+}
+
+// messageTemplate is a parsed message template
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// parseTemplates parses the default templates, replacing their subject or their body with the ones of the overrides
+func parseTemplates(overrides map[string]portainer.EmailTemplate) (map[string]*messageTemplate, error) {
+	templates := make(map[string]*messageTemplate, len(DefaultTemplates))
+
+	for name, defaultTemplate := range DefaultTemplates {
+		subject, body := defaultTemplate.Subject, defaultTemplate.Body
+
+		if override, ok := overrides[name]; ok {
+			if override.Subject != "" {
+				subject = override.Subject
+			}
+
+			if override.Body != "" {
+				body = override.Body
+			}
+		}
+
+		t := &messageTemplate{}
+
+		var err error
+		if t.subject, err = template.New(name + ".subject").Option("missingkey=zero").Parse(subject); err != nil {
+			return nil, fmt.Errorf("invalid subject of the %s template: %w", name, err)
+		}
+
+		if t.body, err = template.New(name + ".body").Option("missingkey=zero").Parse(body); err != nil {
+			return nil, fmt.Errorf("invalid body of the %s template: %w", name, err)
+		}
+
+		templates[name] = t
+	}
+
+	return templates, nil
+}
+
+// render executes the template, the subject being kept on a single line
+func (t *messageTemplate) render(data any) (string, string, error) {
+	var subject, body bytes.Buffer
+
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return "", "", err
+	}
+
+	if err := t.body.Execute(&body, data); err != nil {
+		return "", "", err
+	}
+
+	return strings.Join(strings.Fields(subject.String()), " "), body.String(), nil
+}
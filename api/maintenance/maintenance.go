@@ -0,0 +1,198 @@
+// Package maintenance restricts the automated disruptive actions of the environments, such as the automatic updates
+// of the stacks and of the images or the scheduled volume backups, to the maintenance windows approved by the
+// administrators
+package maintenance
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Status is the maintenance status of an environment
+type Status struct {
+	// Whether maintenance windows apply to the environment, the automated actions of the other environments running
+	// at any time
+	Restricted bool `json:"Restricted" example:"true"`
+	// Whether the automated actions can run now
+	Open bool `json:"Open" example:"false"`
+	// Maintenance windows of the environment
+	WindowIDs []portainer.MaintenanceWindowID `json:"WindowIds"`
+	// Unix timestamp of the start of the next period of the windows of the environment, if any
+	NextStart int64 `json:"NextStart,omitempty" example:"1700000000"`
+	// Unix timestamp of the end of the next period of the windows of the environment, if any
+	NextEnd int64 `json:"NextEnd,omitempty" example:"1700014400"`
+}
+
+// Validate checks the schedule, the duration, the time zone and the calendar periods of a maintenance window
+func Validate(window *portainer.MaintenanceWindow) error {
+	if len(window.EndpointIDs) == 0 && len(window.EndpointGroupIDs) == 0 {
+		return errors.New("a maintenance window requires at least one environment or environment group")
+	}
+
+	if window.Schedule == "" && len(window.Periods) == 0 {
+		return errors.New("a maintenance window requires a schedule or calendar periods")
+	}
+
+	if window.Schedule == "" && (window.Duration != "" || window.Timezone != "") {
+		return errors.New("the duration and the time zone of a maintenance window require a schedule")
+	}
+
+	if window.Schedule != "" {
+		if _, _, _, err := recurrence(window); err != nil {
+			return err
+		}
+	}
+
+	for _, period := range window.Periods {
+		if period.End <= period.Start {
+			return fmt.Errorf("invalid maintenance period, its end %d must be after its start %d", period.End, period.Start)
+		}
+	}
+
+	return nil
+}
+
+// recurrence parses the schedule, the duration and the time zone of the recurring periods of a window
+func recurrence(window *portainer.MaintenanceWindow) (cron.Schedule, time.Duration, *time.Location, error) {
+	schedule, err := cron.ParseStandard(window.Schedule)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("invalid maintenance window schedule %q: %w", window.Schedule, err)
+	}
+
+	// the periods of the @every schedules start when they are parsed, they cannot be computed from the schedule
+	if _, ok := schedule.(*cron.SpecSchedule); !ok {
+		return nil, 0, nil, fmt.Errorf("invalid maintenance window schedule %q, it must be a cron expression", window.Schedule)
+	}
+
+	duration, err := time.ParseDuration(window.Duration)
+	if err != nil || duration <= 0 {
+		return nil, 0, nil, fmt.Errorf("invalid maintenance window duration %q, it must be a positive duration such as 4h", window.Duration)
+	}
+
+	location, err := time.LoadLocation(window.Timezone)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("invalid maintenance window time zone %q: %w", window.Timezone, err)
+	}
+
+	return schedule, duration, location, nil
+}
+
+// Open returns true when the time falls inside one of the periods of the window
+func Open(window *portainer.MaintenanceWindow, now time.Time) (bool, error) {
+	for _, period := range window.Periods {
+		if now.Unix() >= period.Start && now.Unix() < period.End {
+			return true, nil
+		}
+	}
+
+	if window.Schedule == "" {
+		return false, nil
+	}
+
+	schedule, duration, location, err := recurrence(window)
+	if err != nil {
+		return false, err
+	}
+
+	// the first start following the time minus the duration is the start of the period covering the time, if any
+	start := schedule.Next(now.In(location).Add(-duration))
+
+	return !start.IsZero() && !start.After(now), nil
+}
+
+// Next returns the next period of the window starting after the time, false when the window has no future period
+func Next(window *portainer.MaintenanceWindow, now time.Time) (time.Time, time.Time, bool, error) {
+	var start, end time.Time
+
+	for _, period := range window.Periods {
+		if period.Start > now.Unix() && (start.IsZero() || period.Start < start.Unix()) {
+			start, end = time.Unix(period.Start, 0), time.Unix(period.End, 0)
+		}
+	}
+
+	if window.Schedule != "" {
+		schedule, duration, location, err := recurrence(window)
+		if err != nil {
+			return start, end, false, err
+		}
+
+		next := schedule.Next(now.In(location))
+		if !next.IsZero() && (start.IsZero() || next.Before(start)) {
+			start, end = next, next.Add(duration)
+		}
+	}
+
+	return start, end, !start.IsZero(), nil
+}
+
+// Applies returns true when the window targets the environment or its group
+func Applies(window *portainer.MaintenanceWindow, endpoint *portainer.Endpoint) bool {
+	return slices.Contains(window.EndpointIDs, endpoint.ID) || slices.Contains(window.EndpointGroupIDs, endpoint.GroupID)
+}
+
+// EndpointStatus computes the maintenance status of the environment from its maintenance windows
+func EndpointStatus(windows []portainer.MaintenanceWindow, endpoint *portainer.Endpoint, now time.Time) (*Status, error) {
+	status := &Status{WindowIDs: []portainer.MaintenanceWindowID{}}
+
+	for i := range windows {
+		window := &windows[i]
+		if !Applies(window, endpoint) {
+			continue
+		}
+
+		status.Restricted = true
+		status.WindowIDs = append(status.WindowIDs, window.ID)
+
+		open, err := Open(window, now)
+		if err != nil {
+			return nil, err
+		}
+		status.Open = status.Open || open
+
+		start, end, ok, err := Next(window, now)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok && (status.NextStart == 0 || start.Unix() < status.NextStart) {
+			status.NextStart, status.NextEnd = start.Unix(), end.Unix()
+		}
+	}
+
+	if !status.Restricted {
+		status.Open = true
+	}
+
+	return status, nil
+}
+
+// Allowed returns true when the automated disruptive actions can run on the environment at the time, which is the
+// case of the environments without maintenance window and of the environments inside one of their windows. The
+// missing environments are allowed, leaving the action to report them.
+func Allowed(dataStore dataservices.DataStore, endpointID portainer.EndpointID, now time.Time) (bool, error) {
+	endpoint, err := dataStore.Endpoint().Endpoint(endpointID)
+	if dataStore.IsErrObjectNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	windows, err := dataStore.MaintenanceWindow().ReadAll()
+	if err != nil {
+		return false, err
+	}
+
+	status, err := EndpointStatus(windows, endpoint, now)
+	if err != nil {
+		return false, err
+	}
+
+	return status.Open, nil
+}
@@ -0,0 +1,115 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	is := assert.New(t)
+
+	valid := []portainer.MaintenanceWindow{
+		{EndpointIDs: []portainer.EndpointID{1}, Schedule: "0 2 * * 6", Duration: "4h"},
+		{EndpointGroupIDs: []portainer.EndpointGroupID{1}, Schedule: "30 22 * * 1-5", Duration: "90m", Timezone: "Europe/Paris"},
+		{EndpointIDs: []portainer.EndpointID{1}, Periods: []portainer.MaintenancePeriod{{Start: 1700000000, End: 1700003600}}},
+	}
+	for _, window := range valid {
+		is.NoError(Validate(&window))
+	}
+
+	invalid := []portainer.MaintenanceWindow{
+		{Schedule: "0 2 * * 6", Duration: "4h"},
+		{EndpointIDs: []portainer.EndpointID{1}},
+		{EndpointIDs: []portainer.EndpointID{1}, Schedule: "0 2 * * 6"},
+		{EndpointIDs: []portainer.EndpointID{1}, Schedule: "@every 1h", Duration: "10m"},
+		{EndpointIDs: []portainer.EndpointID{1}, Schedule: "0 2 * *", Duration: "4h"},
+		{EndpointIDs: []portainer.EndpointID{1}, Schedule: "0 2 * * 6", Duration: "4h", Timezone: "Mars/Olympus"},
+		{EndpointIDs: []portainer.EndpointID{1}, Duration: "4h", Periods: []portainer.MaintenancePeriod{{Start: 1, End: 2}}},
+		{EndpointIDs: []portainer.EndpointID{1}, Periods: []portainer.MaintenancePeriod{{Start: 2, End: 1}}},
+	}
+	for _, window := range invalid {
+		is.Error(Validate(&window), window)
+	}
+}
+
+func TestOpenAndNext(t *testing.T) {
+	is := assert.New(t)
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	is.NoError(err)
+
+	// every Saturday from 02:00 to 06:00 in Paris
+	window := &portainer.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: "4h", Timezone: "Europe/Paris"}
+
+	saturday := time.Date(2024, time.June, 15, 0, 0, 0, 0, paris)
+
+	for hour, expected := range map[int]bool{1: false, 2: true, 5: true, 6: false} {
+		open, err := Open(window, saturday.Add(time.Duration(hour)*time.Hour).UTC())
+		is.NoError(err)
+		is.Equal(expected, open, "at %d:00", hour)
+	}
+
+	start, end, ok, err := Next(window, saturday.Add(3*time.Hour))
+	is.NoError(err)
+	is.True(ok)
+	is.Equal(saturday.AddDate(0, 0, 7).Add(2*time.Hour).Unix(), start.Unix(), "the next period starts the following Saturday")
+	is.Equal(saturday.AddDate(0, 0, 7).Add(6*time.Hour).Unix(), end.Unix())
+
+	// a calendar period on the Wednesday before
+	window.Periods = []portainer.MaintenancePeriod{{Start: saturday.AddDate(0, 0, 4).Unix(), End: saturday.AddDate(0, 0, 4).Add(time.Hour).Unix()}}
+
+	open, err := Open(window, saturday.AddDate(0, 0, 4).Add(30*time.Minute))
+	is.NoError(err)
+	is.True(open)
+
+	start, _, ok, err = Next(window, saturday.Add(3*time.Hour))
+	is.NoError(err)
+	is.True(ok)
+	is.Equal(window.Periods[0].Start, start.Unix())
+}
+
+func TestAllowed(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.EndpointGroup().Create(&portainer.EndpointGroup{ID: 2, Name: "production"}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "dev", GroupID: 1}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 2, Name: "prod", GroupID: 2}))
+
+	now := time.Now()
+	is.NoError(store.MaintenanceWindow().Create(&portainer.MaintenanceWindow{
+		Name:             "next-week",
+		EndpointGroupIDs: []portainer.EndpointGroupID{2},
+		Periods:          []portainer.MaintenancePeriod{{Start: now.AddDate(0, 0, 7).Unix(), End: now.AddDate(0, 0, 8).Unix()}},
+	}))
+
+	allowed, err := Allowed(store, 1, now)
+	is.NoError(err)
+	is.True(allowed, "the environments without maintenance window are not restricted")
+
+	allowed, err = Allowed(store, 2, now)
+	is.NoError(err)
+	is.False(allowed, "the environments of the group only run automated actions inside the window")
+
+	allowed, err = Allowed(store, 2, now.AddDate(0, 0, 7).Add(time.Hour))
+	is.NoError(err)
+	is.True(allowed)
+
+	endpoint, err := store.Endpoint().Endpoint(2)
+	is.NoError(err)
+
+	windows, err := store.MaintenanceWindow().ReadAll()
+	is.NoError(err)
+
+	status, err := EndpointStatus(windows, endpoint, now)
+	is.NoError(err)
+	is.True(status.Restricted)
+	is.False(status.Open)
+	is.Equal(now.AddDate(0, 0, 7).Unix(), status.NextStart)
+}
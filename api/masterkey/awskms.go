@@ -0,0 +1,61 @@
+package masterkey
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// awsKMSClient is the part of the AWS KMS API used to wrap the data keys
+type awsKMSClient interface {
+	Encrypt(ctx context.Context, params *kms.EncryptInput, optFns ...func(*kms.Options)) (*kms.EncryptOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+type awsKMSWrapper struct {
+	client awsKMSClient
+	keyID  string
+}
+
+// newAWSKMSWrapper creates the wrapper of an AWS KMS key, its region being read from its ARN when the default
+// configuration does not specify one
+func newAWSKMSWrapper(keyID string) (Wrapper, error) {
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	// arn:aws:kms:<region>:<account>:key/<id>
+	if parts := strings.Split(keyID, ":"); cfg.Region == "" && len(parts) > 3 && parts[0] == "arn" {
+		cfg.Region = parts[3]
+	}
+
+	return &awsKMSWrapper{client: kms.NewFromConfig(cfg), keyID: keyID}, nil
+}
+
+func (wrapper *awsKMSWrapper) Wrap(ctx context.Context, plaintext []byte) ([]byte, error) {
+	output, err := wrapper.client.Encrypt(ctx, &kms.EncryptInput{
+		KeyId:     aws.String(wrapper.keyID),
+		Plaintext: plaintext,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.CiphertextBlob, nil
+}
+
+func (wrapper *awsKMSWrapper) Unwrap(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	output, err := wrapper.client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:          aws.String(wrapper.keyID),
+		CiphertextBlob: ciphertext,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Plaintext, nil
+}
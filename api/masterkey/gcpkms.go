@@ -0,0 +1,85 @@
+package masterkey
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	gcpKMSEndpoint = "https://cloudkms.googleapis.com/v1/"
+	gcpKMSScope    = "https://www.googleapis.com/auth/cloudkms"
+)
+
+// gcpKMSWrapper wraps the data keys with a Cloud KMS key through the REST API
+type gcpKMSWrapper struct {
+	client   *http.Client
+	endpoint string
+	keyName  string
+}
+
+// newGCPKMSWrapper creates the wrapper of a Cloud KMS key, authenticated with the application default credentials
+func newGCPKMSWrapper(keyName string) (Wrapper, error) {
+	if !strings.HasPrefix(keyName, "projects/") || !strings.Contains(keyName, "/cryptoKeys/") {
+		return nil, fmt.Errorf("invalid Cloud KMS key %q, projects/<project>/locations/<location>/keyRings/<key ring>/cryptoKeys/<key> is expected", keyName)
+	}
+
+	client, err := google.DefaultClient(context.Background(), gcpKMSScope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcpKMSWrapper{client: client, endpoint: gcpKMSEndpoint, keyName: keyName}, nil
+}
+
+func (wrapper *gcpKMSWrapper) Wrap(ctx context.Context, plaintext []byte) ([]byte, error) {
+	var response struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}
+
+	err := wrapper.call(ctx, "encrypt", map[string][]byte{"plaintext": plaintext}, &response)
+
+	return response.Ciphertext, err
+}
+
+func (wrapper *gcpKMSWrapper) Unwrap(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	var response struct {
+		Plaintext []byte `json:"plaintext"`
+	}
+
+	err := wrapper.call(ctx, "decrypt", map[string][]byte{"ciphertext": ciphertext}, &response)
+
+	return response.Plaintext, err
+}
+
+// call posts a request to a method of the key, the bytes being base64 encoded in the JSON documents
+func (wrapper *gcpKMSWrapper) call(ctx context.Context, method string, request map[string][]byte, response any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wrapper.endpoint+wrapper.keyName+":"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wrapper.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to %s with the Cloud KMS key, status %d", method, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(response)
+}
@@ -0,0 +1,161 @@
+// Package masterkey wraps the data keys encrypting the database and the secret store with a master key held by a KMS
+// or an HSM (envelope encryption). The wrapped data keys are stored in place of the data keys, in an envelope naming
+// the master key, so that the master key can be rotated by re-wrapping the data keys without re-encrypting the data.
+//
+// The master keys are identified by URIs:
+//   - awskms://<key ID or ARN> for an AWS KMS key, authenticated with the default AWS credentials
+//   - gcpkms://projects/<project>/locations/<location>/keyRings/<key ring>/cryptoKeys/<key> for a GCP Cloud KMS key,
+//     authenticated with the application default credentials
+//   - pkcs11://<token label>/<key label>?module=<library path> for an AES key of a PKCS#11 token, the PIN being read
+//     from the PKCS11_PIN environment variable
+//   - vault://<address>/<mount path>/<key name> for a key of the transit secrets engine of HashiCorp Vault, reached
+//     over HTTPS with the token read from the VAULT_TOKEN environment variable
+package masterkey
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Wrapper encrypts and decrypts the data keys with a master key
+type Wrapper interface {
+	Wrap(ctx context.Context, plaintext []byte) ([]byte, error)
+	Unwrap(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// Envelope is a data key wrapped by a master key
+type Envelope struct {
+	// URI of the master key wrapping the data key
+	MasterKey string `json:"masterKey"`
+	// Data key encrypted by the master key
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// factories create the wrappers of the master keys from the URIs without their scheme
+var factories = map[string]func(key string) (Wrapper, error){
+	"awskms": newAWSKMSWrapper,
+	"gcpkms": newGCPKMSWrapper,
+	"pkcs11": newPKCS11Wrapper,
+	"vault":  newVaultWrapper,
+}
+
+// NewWrapper returns the wrapper of the master key identified by the URI
+func NewWrapper(uri string) (Wrapper, error) {
+	scheme, key, ok := strings.Cut(uri, "://")
+	if !ok || key == "" {
+		return nil, fmt.Errorf("invalid master key URI %q, a URI such as awskms://<key ARN> is expected", uri)
+	}
+
+	factory, ok := factories[scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported master key %q, the awskms, gcpkms, pkcs11 and vault schemes are supported", scheme)
+	}
+
+	return factory(key)
+}
+
+// ValidateURI checks that a master key URI is supported, without reaching the master key
+func ValidateURI(uri string) error {
+	scheme, key, ok := strings.Cut(uri, "://")
+	if !ok || key == "" {
+		return fmt.Errorf("invalid master key URI %q, a URI such as awskms://<key ARN> is expected", uri)
+	}
+
+	if _, ok := factories[scheme]; !ok {
+		return fmt.Errorf("unsupported master key %q, the awskms, gcpkms, pkcs11 and vault schemes are supported", scheme)
+	}
+
+	return nil
+}
+
+// IsEnvelope returns whether the content of a key file is a wrapped data key
+func IsEnvelope(content []byte) bool {
+	content = bytes.TrimSpace(content)
+	if !bytes.HasPrefix(content, []byte("{")) {
+		return false
+	}
+
+	var envelope Envelope
+	return json.Unmarshal(content, &envelope) == nil && envelope.MasterKey != ""
+}
+
+// Seal wraps a data key with the master key identified by the URI and returns the envelope to store
+func Seal(ctx context.Context, uri string, plaintext []byte) ([]byte, error) {
+	wrapper, err := NewWrapper(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := wrapper.Wrap(ctx, plaintext)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to wrap the data key with the master key %s", uri)
+	}
+
+	return json.Marshal(Envelope{MasterKey: uri, Ciphertext: ciphertext})
+}
+
+// Open unwraps the data key of an envelope with the master key named by the envelope
+func Open(ctx context.Context, content []byte) ([]byte, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(bytes.TrimSpace(content), &envelope); err != nil || envelope.MasterKey == "" {
+		return nil, errors.New("invalid wrapped data key")
+	}
+
+	wrapper, err := NewWrapper(envelope.MasterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := wrapper.Unwrap(ctx, envelope.Ciphertext)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "unable to unwrap the data key with the master key %s", envelope.MasterKey)
+	}
+
+	return plaintext, nil
+}
+
+// Rewrap wraps the data key stored in the content of a key file with the master key identified by the URI. The data
+// key is first unwrapped when the content is an envelope, otherwise the content is the data key itself.
+func Rewrap(ctx context.Context, content []byte, uri string) ([]byte, error) {
+	plaintext := content
+	if IsEnvelope(content) {
+		var err error
+		if plaintext, err = Open(ctx, content); err != nil {
+			return nil, err
+		}
+	}
+
+	return Seal(ctx, uri, plaintext)
+}
+
+// RewrapFile wraps the data key stored in a key file with the master key identified by the URI, replacing the file
+func RewrapFile(ctx context.Context, path, uri string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	content, err = Rewrap(ctx, content, uri)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	// write the new envelope next to the key file before replacing it, so that the data key cannot be lost
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
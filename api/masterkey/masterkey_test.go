@@ -0,0 +1,241 @@
+package masterkey
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeWrapper wraps the data keys by prefixing them with the name of the key
+type fakeWrapper struct {
+	key string
+}
+
+func (wrapper fakeWrapper) Wrap(ctx context.Context, plaintext []byte) ([]byte, error) {
+	return append([]byte(wrapper.key+":"), plaintext...), nil
+}
+
+func (wrapper fakeWrapper) Unwrap(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	if !bytes.HasPrefix(ciphertext, []byte(wrapper.key+":")) {
+		return nil, errors.New("wrapped by another key")
+	}
+
+	return ciphertext[len(wrapper.key)+1:], nil
+}
+
+func registerFakeWrapper(t *testing.T) {
+	factories["fake"] = func(key string) (Wrapper, error) {
+		return fakeWrapper{key: key}, nil
+	}
+
+	t.Cleanup(func() {
+		delete(factories, "fake")
+	})
+}
+
+func TestSealAndOpen(t *testing.T) {
+	is := assert.New(t)
+	registerFakeWrapper(t)
+
+	dataKey := []byte("c2VjcmV0IGtleQ==")
+
+	content, err := Seal(context.Background(), "fake://first", dataKey)
+	is.NoError(err)
+	is.True(IsEnvelope(content))
+	is.False(IsEnvelope(dataKey))
+	is.NotContains(string(content), string(dataKey))
+
+	plaintext, err := Open(context.Background(), content)
+	is.NoError(err)
+	is.Equal(dataKey, plaintext)
+
+	_, err = Open(context.Background(), dataKey)
+	is.Error(err, "the content is not an envelope")
+
+	_, err = Seal(context.Background(), "unknown://key", dataKey)
+	is.Error(err)
+}
+
+func TestRewrap(t *testing.T) {
+	is := assert.New(t)
+	registerFakeWrapper(t)
+
+	dataKey := []byte("c2VjcmV0IGtleQ==")
+
+	content, err := Rewrap(context.Background(), dataKey, "fake://first")
+	is.NoError(err, "a plain data key should be wrapped")
+
+	content, err = Rewrap(context.Background(), content, "fake://second")
+	is.NoError(err)
+
+	var envelope Envelope
+	is.NoError(json.Unmarshal(content, &envelope))
+	is.Equal("fake://second", envelope.MasterKey)
+
+	plaintext, err := Open(context.Background(), content)
+	is.NoError(err)
+	is.Equal(dataKey, plaintext, "the data key should be unchanged")
+}
+
+func TestRewrapFile(t *testing.T) {
+	is := assert.New(t)
+	registerFakeWrapper(t)
+
+	path := filepath.Join(t.TempDir(), "secrets.key")
+	is.NoError(os.WriteFile(path, []byte("c2VjcmV0IGtleQ=="), 0o600))
+
+	is.NoError(RewrapFile(context.Background(), path, "fake://first"))
+
+	content, err := os.ReadFile(path)
+	is.NoError(err)
+	is.True(IsEnvelope(content))
+
+	info, err := os.Stat(path)
+	is.NoError(err)
+	is.Equal(os.FileMode(0o600), info.Mode().Perm(), "the permissions of the key file should be kept")
+}
+
+func TestValidateURI(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateURI("awskms://arn:aws:kms:eu-west-1:111122223333:key/1234abcd"))
+	is.NoError(ValidateURI("gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/k"))
+	is.NoError(ValidateURI("pkcs11://token/key?module=/usr/lib/softhsm/libsofthsm2.so"))
+	is.NoError(ValidateURI("vault://vault:8200/transit/portainer"))
+	is.Error(ValidateURI("/path/to/key"))
+	is.Error(ValidateURI("awskms://"))
+	is.Error(ValidateURI("file:///path/to/key"))
+}
+
+type fakeAWSKMSClient struct {
+	keyID string
+}
+
+func (client fakeAWSKMSClient) Encrypt(ctx context.Context, params *kms.EncryptInput, optFns ...func(*kms.Options)) (*kms.EncryptOutput, error) {
+	if aws.ToString(params.KeyId) != client.keyID {
+		return nil, errors.New("not found")
+	}
+
+	return &kms.EncryptOutput{CiphertextBlob: append([]byte("aws:"), params.Plaintext...)}, nil
+}
+
+func (client fakeAWSKMSClient) Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	if aws.ToString(params.KeyId) != client.keyID {
+		return nil, errors.New("not found")
+	}
+
+	return &kms.DecryptOutput{Plaintext: bytes.TrimPrefix(params.CiphertextBlob, []byte("aws:"))}, nil
+}
+
+func TestAWSKMSWrapper(t *testing.T) {
+	is := assert.New(t)
+
+	wrapper := &awsKMSWrapper{client: fakeAWSKMSClient{keyID: "alias/portainer"}, keyID: "alias/portainer"}
+
+	ciphertext, err := wrapper.Wrap(context.Background(), []byte("data key"))
+	is.NoError(err)
+	is.Equal([]byte("aws:data key"), ciphertext)
+
+	plaintext, err := wrapper.Unwrap(context.Background(), ciphertext)
+	is.NoError(err)
+	is.Equal([]byte("data key"), plaintext)
+
+	wrapper.keyID = "alias/other"
+	_, err = wrapper.Wrap(context.Background(), []byte("data key"))
+	is.Error(err)
+}
+
+func TestGCPKMSWrapper(t *testing.T) {
+	is := assert.New(t)
+
+	keyName := "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string][]byte
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/" + keyName + ":encrypt":
+			json.NewEncoder(w).Encode(map[string][]byte{"ciphertext": append([]byte("gcp:"), request["plaintext"]...)})
+		case "/" + keyName + ":decrypt":
+			json.NewEncoder(w).Encode(map[string][]byte{"plaintext": bytes.TrimPrefix(request["ciphertext"], []byte("gcp:"))})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	wrapper := &gcpKMSWrapper{client: srv.Client(), endpoint: srv.URL + "/", keyName: keyName}
+
+	ciphertext, err := wrapper.Wrap(context.Background(), []byte("data key"))
+	is.NoError(err)
+	is.Equal([]byte("gcp:data key"), ciphertext)
+
+	plaintext, err := wrapper.Unwrap(context.Background(), ciphertext)
+	is.NoError(err)
+	is.Equal([]byte("data key"), plaintext)
+
+	wrapper.keyName = "projects/p/locations/global/keyRings/r/cryptoKeys/other"
+	_, err = wrapper.Wrap(context.Background(), []byte("data key"))
+	is.Error(err)
+}
+
+func TestVaultWrapper(t *testing.T) {
+	is := assert.New(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]string
+		if r.Header.Get("X-Vault-Token") != "token" || json.NewDecoder(r.Body).Decode(&request) != nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/portainer":
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"ciphertext": "vault:v1:" + request["plaintext"]}})
+		case "/v1/transit/decrypt/portainer":
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"plaintext": strings.TrimPrefix(request["ciphertext"], "vault:v1:")}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	wrapper := &vaultWrapper{client: srv.Client(), mount: srv.URL + "/v1/transit", keyName: "portainer", token: "token"}
+
+	ciphertext, err := wrapper.Wrap(context.Background(), []byte("data key"))
+	is.NoError(err)
+	is.True(strings.HasPrefix(string(ciphertext), "vault:v1:"))
+
+	plaintext, err := wrapper.Unwrap(context.Background(), ciphertext)
+	is.NoError(err)
+	is.Equal([]byte("data key"), plaintext)
+
+	wrapper.token = "invalid"
+	_, err = wrapper.Unwrap(context.Background(), ciphertext)
+	is.Error(err)
+
+	t.Setenv(vaultTokenEnv, "token")
+
+	created, err := newVaultWrapper("vault:8200/transit/portainer")
+	is.NoError(err)
+	is.Equal("https://vault:8200/v1/transit", created.(*vaultWrapper).mount)
+	is.Equal("portainer", created.(*vaultWrapper).keyName)
+
+	_, err = newVaultWrapper("vault:8200/portainer")
+	is.Error(err, "the mount path is required")
+}
@@ -0,0 +1,169 @@
+//go:build pkcs11 && cgo
+
+package masterkey
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+)
+
+const (
+	pkcs11PINEnv   = "PKCS11_PIN"
+	pkcs11IVSize   = 12
+	pkcs11TagBits  = 128
+	pkcs11KeyClass = pkcs11.CKO_SECRET_KEY
+)
+
+// pkcs11Wrapper wraps the data keys with an AES key of a PKCS#11 token using AES-GCM, the ciphertext being prefixed by
+// the IV
+type pkcs11Wrapper struct {
+	module     string
+	tokenLabel string
+	keyLabel   string
+	pin        string
+}
+
+// newPKCS11Wrapper creates the wrapper of the key identified by <token label>/<key label>?module=<library path>
+func newPKCS11Wrapper(key string) (Wrapper, error) {
+	u, err := url.Parse("pkcs11://" + key)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapper := &pkcs11Wrapper{
+		module:     u.Query().Get("module"),
+		tokenLabel: u.Host,
+		keyLabel:   strings.TrimPrefix(u.Path, "/"),
+		pin:        os.Getenv(pkcs11PINEnv),
+	}
+
+	if wrapper.module == "" || wrapper.tokenLabel == "" || wrapper.keyLabel == "" {
+		return nil, errors.New("invalid PKCS#11 key, pkcs11://<token label>/<key label>?module=<library path> is expected")
+	}
+
+	return wrapper, nil
+}
+
+func (wrapper *pkcs11Wrapper) Wrap(ctx context.Context, plaintext []byte) ([]byte, error) {
+	iv := make([]byte, pkcs11IVSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	var ciphertext []byte
+
+	err := wrapper.withKey(func(p *pkcs11.Ctx, session pkcs11.SessionHandle, key pkcs11.ObjectHandle) error {
+		params := pkcs11.NewGCMParams(iv, nil, pkcs11TagBits)
+		defer params.Free()
+
+		if err := p.EncryptInit(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_GCM, params)}, key); err != nil {
+			return err
+		}
+
+		encrypted, err := p.Encrypt(session, plaintext)
+		if err != nil {
+			return err
+		}
+
+		// some HSMs generate their own IV
+		if actualIV := params.IV(); len(actualIV) > 0 {
+			iv = actualIV
+		}
+
+		ciphertext = append(append([]byte{}, iv...), encrypted...)
+
+		return nil
+	})
+
+	return ciphertext, err
+}
+
+func (wrapper *pkcs11Wrapper) Unwrap(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) <= pkcs11IVSize {
+		return nil, errors.New("invalid wrapped data key")
+	}
+
+	var plaintext []byte
+
+	err := wrapper.withKey(func(p *pkcs11.Ctx, session pkcs11.SessionHandle, key pkcs11.ObjectHandle) error {
+		params := pkcs11.NewGCMParams(ciphertext[:pkcs11IVSize], nil, pkcs11TagBits)
+		defer params.Free()
+
+		if err := p.DecryptInit(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_AES_GCM, params)}, key); err != nil {
+			return err
+		}
+
+		var err error
+		plaintext, err = p.Decrypt(session, ciphertext[pkcs11IVSize:])
+
+		return err
+	})
+
+	return plaintext, err
+}
+
+// withKey opens a session on the token, finds the key and runs fn with it
+func (wrapper *pkcs11Wrapper) withKey(fn func(p *pkcs11.Ctx, session pkcs11.SessionHandle, key pkcs11.ObjectHandle) error) error {
+	p := pkcs11.New(wrapper.module)
+	if p == nil {
+		return fmt.Errorf("unable to load the PKCS#11 module %s", wrapper.module)
+	}
+	defer p.Destroy()
+
+	if err := p.Initialize(); err != nil {
+		return err
+	}
+	defer p.Finalize()
+
+	slots, err := p.GetSlotList(true)
+	if err != nil {
+		return err
+	}
+
+	for _, slot := range slots {
+		info, err := p.GetTokenInfo(slot)
+		if err != nil || info.Label != wrapper.tokenLabel {
+			continue
+		}
+
+		session, err := p.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+		if err != nil {
+			return err
+		}
+		defer p.CloseSession(session)
+
+		if err := p.Login(session, pkcs11.CKU_USER, wrapper.pin); err != nil {
+			return errors.WithMessage(err, "unable to log in to the PKCS#11 token")
+		}
+		defer p.Logout(session)
+
+		if err := p.FindObjectsInit(session, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11KeyClass),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, wrapper.keyLabel),
+		}); err != nil {
+			return err
+		}
+
+		keys, _, err := p.FindObjects(session, 1)
+		p.FindObjectsFinal(session)
+		if err != nil {
+			return err
+		}
+
+		if len(keys) == 0 {
+			return fmt.Errorf("the key %s does not exist on the PKCS#11 token %s", wrapper.keyLabel, wrapper.tokenLabel)
+		}
+
+		return fn(p, session, keys[0])
+	}
+
+	return fmt.Errorf("the PKCS#11 token %s does not exist", wrapper.tokenLabel)
+}
@@ -0,0 +1,10 @@
+//go:build !pkcs11 || !cgo
+
+package masterkey
+
+import "errors"
+
+// newPKCS11Wrapper is not available in the static builds, the PKCS#11 modules being loaded through cgo
+func newPKCS11Wrapper(key string) (Wrapper, error) {
+	return nil, errors.New("PKCS#11 master keys require a build with cgo and the pkcs11 tag")
+}
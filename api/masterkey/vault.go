@@ -0,0 +1,101 @@
+package masterkey
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	vaultTokenEnv       = "VAULT_TOKEN"
+	vaultRequestTimeout = 10 * time.Second
+)
+
+// vaultWrapper wraps the data keys with a key of the transit secrets engine of HashiCorp Vault
+type vaultWrapper struct {
+	client *http.Client
+	// address of the transit secrets engine, such as https://vault:8200/v1/transit
+	mount   string
+	keyName string
+	token   string
+}
+
+// newVaultWrapper creates the wrapper of the transit key identified by <address>/<mount path>/<key name>, the Vault
+// token being read from the VAULT_TOKEN environment variable
+func newVaultWrapper(key string) (Wrapper, error) {
+	mount, keyName := path.Split(key)
+	address, mountPath, _ := strings.Cut(strings.TrimSuffix(mount, "/"), "/")
+	if address == "" || mountPath == "" || keyName == "" {
+		return nil, fmt.Errorf("invalid Vault transit key %q, <address>/<mount path>/<key name> is expected", key)
+	}
+
+	token := os.Getenv(vaultTokenEnv)
+	if token == "" {
+		return nil, errors.Errorf("the Vault token must be specified in the %s environment variable", vaultTokenEnv)
+	}
+
+	return &vaultWrapper{
+		client:  &http.Client{Timeout: vaultRequestTimeout},
+		mount:   "https://" + address + "/v1/" + mountPath,
+		keyName: keyName,
+		token:   token,
+	}, nil
+}
+
+func (wrapper *vaultWrapper) Wrap(ctx context.Context, plaintext []byte) ([]byte, error) {
+	var response struct {
+		Ciphertext string `json:"ciphertext"`
+	}
+
+	err := wrapper.call(ctx, "encrypt", map[string]any{"plaintext": plaintext}, &response)
+
+	return []byte(response.Ciphertext), err
+}
+
+func (wrapper *vaultWrapper) Unwrap(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	var response struct {
+		Plaintext []byte `json:"plaintext"`
+	}
+
+	err := wrapper.call(ctx, "decrypt", map[string]any{"ciphertext": string(ciphertext)}, &response)
+
+	return response.Plaintext, err
+}
+
+// call posts a request to an endpoint of the transit key, the plaintexts being base64 encoded in the JSON documents
+func (wrapper *vaultWrapper) call(ctx context.Context, endpoint string, request map[string]any, data any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wrapper.mount+"/"+endpoint+"/"+wrapper.keyName, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", wrapper.token)
+
+	resp, err := wrapper.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to %s with the Vault transit key, status %d", endpoint, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(&struct {
+		Data any `json:"data"`
+	}{Data: data})
+}
@@ -0,0 +1,82 @@
+package monitoring
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	MetricUp                = "portainer_endpoint_up"
+	MetricLastCheck         = "portainer_endpoint_last_check_timestamp_seconds"
+	MetricCertificateExpiry = "portainer_endpoint_certificate_expiry_timestamp_seconds"
+
+	// ContentType is the content type of the Prometheus text exposition format
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WriteMetrics writes the metrics of the environments in the Prometheus text exposition format
+func WriteMetrics(w io.Writer, healths []EndpointHealth) error {
+	metrics := []struct {
+		name  string
+		help  string
+		value func(health EndpointHealth) (float64, bool)
+	}{
+		{
+			name: MetricUp,
+			help: "Whether the environment is reachable, the Edge environments being up while their agent checks in",
+			value: func(health EndpointHealth) (float64, bool) {
+				if health.Up {
+					return 1, true
+				}
+
+				return 0, true
+			},
+		},
+		{
+			name: MetricLastCheck,
+			help: "Unix timestamp of the last check-in of the Edge agent, or of the last snapshot of the environment",
+			value: func(health EndpointHealth) (float64, bool) {
+				return float64(health.LastCheck), health.LastCheck != 0
+			},
+		},
+		{
+			name: MetricCertificateExpiry,
+			help: "Unix timestamp of the expiry of the earliest expiring TLS certificate of the environment",
+			value: func(health EndpointHealth) (float64, bool) {
+				return float64(health.CertificateExpiry.Unix()), !health.CertificateExpiry.IsZero()
+			},
+		},
+	}
+
+	var b strings.Builder
+
+	for _, metric := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n", metric.name, metric.help)
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", metric.name)
+
+		for _, health := range healths {
+			value, ok := metric.value(health)
+			if !ok {
+				continue
+			}
+
+			fmt.Fprintf(&b, "%s{%s} %s\n", metric.name, labels(health), strconv.FormatFloat(value, 'f', -1, 64))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func labels(health EndpointHealth) string {
+	return fmt.Sprintf(`endpoint_id="%d",endpoint_name="%s",endpoint_group="%s"`,
+		health.ID,
+		labelEscaper.Replace(health.Name),
+		labelEscaper.Replace(health.GroupName),
+	)
+}
@@ -0,0 +1,137 @@
+// Package monitoring exposes the health of the environments to Prometheus: the metrics of the environments, and the
+// alerting rules evaluating them, generated from the inventory so that the monitoring follows the environments
+// added to and removed from Portainer
+package monitoring
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"slices"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+
+	"github.com/rs/zerolog/log"
+)
+
+// EndpointHealth is the health of an environment
+type EndpointHealth struct {
+	ID        portainer.EndpointID
+	Name      string
+	GroupName string
+	Edge      bool
+	Up        bool
+	// Unix timestamp of the last check-in of the Edge agent, or of the last snapshot of the other environments
+	LastCheck int64
+	// Number of seconds after the last check-in during which an Edge environment is considered alive
+	HeartbeatTolerance int
+	// Expiry of the earliest expiring TLS certificate used to connect to the environment, zero without certificate
+	CertificateExpiry time.Time
+}
+
+// Collect computes the health of the environments, ordered by identifier
+func Collect(dataStore dataservices.DataStore) ([]EndpointHealth, error) {
+	settings, err := dataStore.Settings().Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := dataStore.EndpointGroup().ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	groupNames := make(map[portainer.EndpointGroupID]string, len(groups))
+	for _, group := range groups {
+		groupNames[group.ID] = group.Name
+	}
+
+	endpoints, err := dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(endpoints, func(a, b portainer.Endpoint) int {
+		return int(a.ID) - int(b.ID)
+	})
+
+	healths := make([]EndpointHealth, 0, len(endpoints))
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+
+		health := EndpointHealth{
+			ID:                endpoint.ID,
+			Name:              endpoint.Name,
+			GroupName:         groupNames[endpoint.GroupID],
+			Edge:              endpointutils.IsEdgeEndpoint(endpoint),
+			Up:                endpoint.Status == portainer.EndpointStatusUp,
+			CertificateExpiry: certificateExpiry(endpoint),
+		}
+
+		if health.Edge {
+			endpointutils.UpdateEdgeEndpointHeartbeat(endpoint, settings)
+
+			health.Up = endpoint.Heartbeat
+			health.LastCheck = endpoint.LastCheckInDate
+			health.HeartbeatTolerance = endpointutils.EdgeHeartbeatTolerance(endpoint, settings)
+		} else {
+			snapshot, err := dataStore.Snapshot().Read(endpoint.ID)
+			if err != nil && !dataStore.IsErrObjectNotFound(err) {
+				return nil, err
+			}
+
+			if snapshot != nil && snapshot.Docker != nil {
+				health.LastCheck = snapshot.Docker.Time
+			} else if snapshot != nil && snapshot.Kubernetes != nil {
+				health.LastCheck = snapshot.Kubernetes.Time
+			}
+		}
+
+		healths = append(healths, health)
+	}
+
+	return healths, nil
+}
+
+// certificateExpiry returns the expiry of the earliest expiring certificate of the TLS configuration of the
+// environment, the CA certificate and the client certificate
+func certificateExpiry(endpoint *portainer.Endpoint) time.Time {
+	var expiry time.Time
+
+	if !endpoint.TLSConfig.TLS {
+		return expiry
+	}
+
+	for _, path := range []string{endpoint.TLSConfig.TLSCACertPath, endpoint.TLSConfig.TLSCertPath} {
+		if path == "" {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Debug().Err(err).Str("path", path).Msg("unable to read the TLS certificate of the environment")
+			continue
+		}
+
+		for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				continue
+			}
+
+			if expiry.IsZero() || certificate.NotAfter.Before(expiry) {
+				expiry = certificate.NotAfter
+			}
+		}
+	}
+
+	return expiry
+}
@@ -0,0 +1,148 @@
+package monitoring
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCertificate writes a self-signed certificate expiring at the specified time, returning its path
+func writeCertificate(t *testing.T, notAfter time.Time) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "docker"},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	assert.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+
+	return path
+}
+
+func TestCollect(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	expiry := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+	caExpiry := time.Now().Add(3 * 365 * 24 * time.Hour).Truncate(time.Second)
+
+	endpoints := []*portainer.Endpoint{
+		{
+			ID:      2,
+			Name:    "production",
+			Type:    portainer.DockerEnvironment,
+			GroupID: 1,
+			Status:  portainer.EndpointStatusDown,
+			TLSConfig: portainer.TLSConfiguration{
+				TLS:           true,
+				TLSCACertPath: writeCertificate(t, caExpiry),
+				TLSCertPath:   writeCertificate(t, expiry),
+			},
+		},
+		{
+			ID:                  1,
+			Name:                "edge",
+			Type:                portainer.EdgeAgentOnDockerEnvironment,
+			GroupID:             1,
+			EdgeCheckinInterval: 10,
+			LastCheckInDate:     time.Now().Unix(),
+		},
+	}
+	for _, endpoint := range endpoints {
+		assert.NoError(t, store.Endpoint().Create(endpoint))
+	}
+
+	assert.NoError(t, store.Snapshot().Create(&portainer.Snapshot{EndpointID: 2, Docker: &portainer.DockerSnapshot{Time: 1700000000}}))
+
+	healths, err := Collect(store)
+	assert.NoError(t, err)
+	if !assert.Len(t, healths, 2) {
+		return
+	}
+
+	assert.Equal(t, portainer.EndpointID(1), healths[0].ID)
+	assert.True(t, healths[0].Edge)
+	assert.True(t, healths[0].Up, "the Edge agent checked in")
+	assert.Equal(t, 40, healths[0].HeartbeatTolerance)
+	assert.True(t, healths[0].CertificateExpiry.IsZero())
+
+	assert.Equal(t, "production", healths[1].Name)
+	assert.Equal(t, "Unassigned", healths[1].GroupName)
+	assert.False(t, healths[1].Up)
+	assert.Equal(t, int64(1700000000), healths[1].LastCheck)
+	assert.True(t, expiry.Equal(healths[1].CertificateExpiry), "the earliest expiring certificate should be reported")
+}
+
+func TestWriteMetrics(t *testing.T) {
+	var b strings.Builder
+
+	err := WriteMetrics(&b, []EndpointHealth{
+		{ID: 1, Name: `edge "1"`, GroupName: "Unassigned", Edge: true, Up: true, LastCheck: 1700000000},
+		{ID: 2, Name: "production", GroupName: "Unassigned", CertificateExpiry: time.Unix(1800000000, 0)},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, `# HELP portainer_endpoint_up Whether the environment is reachable, the Edge environments being up while their agent checks in
+# TYPE portainer_endpoint_up gauge
+portainer_endpoint_up{endpoint_id="1",endpoint_name="edge \"1\"",endpoint_group="Unassigned"} 1
+portainer_endpoint_up{endpoint_id="2",endpoint_name="production",endpoint_group="Unassigned"} 0
+# HELP portainer_endpoint_last_check_timestamp_seconds Unix timestamp of the last check-in of the Edge agent, or of the last snapshot of the environment
+# TYPE portainer_endpoint_last_check_timestamp_seconds gauge
+portainer_endpoint_last_check_timestamp_seconds{endpoint_id="1",endpoint_name="edge \"1\"",endpoint_group="Unassigned"} 1700000000
+# HELP portainer_endpoint_certificate_expiry_timestamp_seconds Unix timestamp of the expiry of the earliest expiring TLS certificate of the environment
+# TYPE portainer_endpoint_certificate_expiry_timestamp_seconds gauge
+portainer_endpoint_certificate_expiry_timestamp_seconds{endpoint_id="2",endpoint_name="production",endpoint_group="Unassigned"} 1800000000
+`, b.String())
+}
+
+func TestRules(t *testing.T) {
+	file := Rules([]EndpointHealth{
+		{ID: 1, Name: "edge", Edge: true, HeartbeatTolerance: 40},
+		{ID: 2, Name: "production", CertificateExpiry: time.Unix(1800000000, 0)},
+	}, RuleOptions{For: 90 * time.Second, CertificateWarning: 24 * time.Hour})
+
+	if !assert.Len(t, file.Groups, 1) || !assert.Len(t, file.Groups[0].Rules, 3) {
+		return
+	}
+
+	rules := file.Groups[0].Rules
+
+	assert.Equal(t, AlertAgentStale, rules[0].Alert)
+	assert.Equal(t, `time() - portainer_endpoint_last_check_timestamp_seconds{endpoint_id="1"} > 40`, rules[0].Expr)
+	assert.Equal(t, "1m30s", rules[0].For)
+
+	assert.Equal(t, AlertEndpointDown, rules[1].Alert)
+	assert.Equal(t, `portainer_endpoint_up{endpoint_id="2"} == 0`, rules[1].Expr)
+
+	assert.Equal(t, AlertCertificateExpiring, rules[2].Alert)
+	assert.Equal(t, `portainer_endpoint_certificate_expiry_timestamp_seconds{endpoint_id="2"} - time() < 86400`, rules[2].Expr)
+	assert.Empty(t, rules[2].For)
+
+	content, err := file.Marshal()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(content), "groups:\n  - name: portainer-environments\n    rules:\n      - alert: PortainerEdgeAgentStale\n"), string(content))
+
+	defaults := Rules([]EndpointHealth{{ID: 2, Name: "production"}}, RuleOptions{})
+	assert.Equal(t, "5m", defaults.Groups[0].Rules[0].For)
+}
@@ -0,0 +1,149 @@
+package monitoring
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	AlertEndpointDown        = "PortainerEnvironmentDown"
+	AlertAgentStale          = "PortainerEdgeAgentStale"
+	AlertCertificateExpiring = "PortainerEnvironmentCertificateExpiring"
+)
+
+const (
+	defaultFor                = 5 * time.Minute
+	defaultCertificateWarning = 14 * 24 * time.Hour
+)
+
+// RuleOptions tunes the generated alerting rules
+type RuleOptions struct {
+	// Duration during which a condition must hold before the alert fires, defaults to 5m
+	For time.Duration
+	// Remaining validity of the certificates below which the expiry alert fires, defaults to 14 days
+	CertificateWarning time.Duration
+}
+
+// RuleFile is a Prometheus rule file
+type RuleFile struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+// RuleGroup is a group of Prometheus rules
+type RuleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule is a Prometheus alerting rule
+type Rule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Rules generates the alerting rules of the environments: the environments which are down, the Edge agents which
+// stopped checking in for longer than the heartbeat tolerance of their environment, and the TLS certificates about
+// to expire
+func Rules(healths []EndpointHealth, options RuleOptions) RuleFile {
+	if options.For <= 0 {
+		options.For = defaultFor
+	}
+
+	if options.CertificateWarning <= 0 {
+		options.CertificateWarning = defaultCertificateWarning
+	}
+
+	group := RuleGroup{Name: "portainer-environments", Rules: []Rule{}}
+
+	for _, health := range healths {
+		selector := fmt.Sprintf(`{endpoint_id="%d"}`, health.ID)
+
+		if health.Edge {
+			group.Rules = append(group.Rules, Rule{
+				Alert:  AlertAgentStale,
+				Expr:   fmt.Sprintf("time() - %s%s > %d", MetricLastCheck, selector, health.HeartbeatTolerance),
+				For:    formatDuration(options.For),
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     fmt.Sprintf("The Edge agent of the environment %s stopped checking in", health.Name),
+					"description": fmt.Sprintf("The Edge agent of the environment %s has not checked in for more than %ds.", health.Name, health.HeartbeatTolerance),
+				},
+			})
+		} else {
+			group.Rules = append(group.Rules, Rule{
+				Alert:  AlertEndpointDown,
+				Expr:   fmt.Sprintf("%s%s == 0", MetricUp, selector),
+				For:    formatDuration(options.For),
+				Labels: map[string]string{"severity": "critical"},
+				Annotations: map[string]string{
+					"summary":     fmt.Sprintf("The environment %s is down", health.Name),
+					"description": fmt.Sprintf("Portainer is unable to reach the environment %s.", health.Name),
+				},
+			})
+		}
+
+		if !health.CertificateExpiry.IsZero() {
+			group.Rules = append(group.Rules, Rule{
+				Alert:  AlertCertificateExpiring,
+				Expr:   fmt.Sprintf("%s%s - time() < %d", MetricCertificateExpiry, selector, int64(options.CertificateWarning.Seconds())),
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     fmt.Sprintf("A TLS certificate of the environment %s is about to expire", health.Name),
+					"description": fmt.Sprintf("A TLS certificate used to connect to the environment %s expires on %s.", health.Name, health.CertificateExpiry.UTC().Format(time.RFC3339)),
+				},
+			})
+		}
+	}
+
+	return RuleFile{Groups: []RuleGroup{group}}
+}
+
+// Marshal encodes the rule file in YAML, indented with two spaces as the rule files usually are
+func (file RuleFile) Marshal() ([]byte, error) {
+	var b bytes.Buffer
+
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(file); err != nil {
+		return nil, err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// formatDuration formats the duration as a Prometheus duration, e.g. 5m or 1h30m
+func formatDuration(d time.Duration) string {
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+
+	result := ""
+	for _, unit := range units {
+		if n := d / unit.size; n > 0 {
+			result += fmt.Sprintf("%d%s", n, unit.suffix)
+			d -= n * unit.size
+		}
+	}
+
+	if result == "" {
+		return "0s"
+	}
+
+	return result
+}
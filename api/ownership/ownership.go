@@ -0,0 +1,89 @@
+// Package ownership assigns to teams the Docker resources created outside of Portainer, which have no resource
+// control and are otherwise only visible to the administrators, according to the ownership rules of the environments
+package ownership
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+)
+
+// ResourceTypes are the types of the resources the ownership rules can match, the resources found in the snapshots
+var ResourceTypes = []portainer.ResourceControlType{
+	portainer.ContainerResourceControl,
+	portainer.VolumeResourceControl,
+	portainer.NetworkResourceControl,
+}
+
+// Validate checks the matching criteria and the resource types of an ownership rule
+func Validate(rule *portainer.OwnershipRule) error {
+	if rule.Label == "" && rule.NamePattern == "" {
+		return errors.New("an ownership rule requires a label or a name pattern")
+	}
+
+	if name, _, _ := strings.Cut(rule.Label, "="); rule.Label != "" && name == "" {
+		return fmt.Errorf("invalid label %q, it must be formatted as name or name=value", rule.Label)
+	}
+
+	if _, err := regexp.Compile(rule.NamePattern); err != nil {
+		return fmt.Errorf("invalid name pattern %q: %w", rule.NamePattern, err)
+	}
+
+	for _, resourceType := range rule.ResourceTypes {
+		if !slices.Contains(ResourceTypes, resourceType) {
+			return fmt.Errorf("invalid resource type %d, only the containers (1), the volumes (3) and the networks (4) can be matched", resourceType)
+		}
+	}
+
+	return nil
+}
+
+// Applies returns true when the rule targets the environment or its group, the rules targeting neither environments
+// nor groups applying to all the environments
+func Applies(rule *portainer.OwnershipRule, endpoint *portainer.Endpoint) bool {
+	if len(rule.EndpointIDs) == 0 && len(rule.EndpointGroupIDs) == 0 {
+		return true
+	}
+
+	return slices.Contains(rule.EndpointIDs, endpoint.ID) || slices.Contains(rule.EndpointGroupIDs, endpoint.GroupID)
+}
+
+// Matches returns true when the resource matches the resource types, the label and the name pattern of the rule
+func Matches(rule *portainer.OwnershipRule, resourceType portainer.ResourceControlType, name string, labels map[string]string) bool {
+	if len(rule.ResourceTypes) > 0 && !slices.Contains(rule.ResourceTypes, resourceType) {
+		return false
+	}
+
+	if rule.Label != "" {
+		labelName, labelValue, hasValue := strings.Cut(rule.Label, "=")
+
+		value, ok := labels[labelName]
+		if !ok || (hasValue && value != labelValue) {
+			return false
+		}
+	}
+
+	if rule.NamePattern != "" {
+		matched, err := regexp.MatchString(rule.NamePattern, name)
+		if err != nil || !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Match returns the first rule matching the resource, nil when no rule matches it
+func Match(rules []portainer.OwnershipRule, resourceType portainer.ResourceControlType, name string, labels map[string]string) *portainer.OwnershipRule {
+	for i := range rules {
+		if Matches(&rules[i], resourceType, name, labels) {
+			return &rules[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package ownership
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	is := assert.New(t)
+
+	for _, rule := range []portainer.OwnershipRule{
+		{},
+		{Label: "=monitoring"},
+		{NamePattern: "(prometheus"},
+		{Label: "team", ResourceTypes: []portainer.ResourceControlType{portainer.StackResourceControl}},
+	} {
+		is.Error(Validate(&rule))
+	}
+
+	is.NoError(Validate(&portainer.OwnershipRule{Label: "team=monitoring"}))
+	is.NoError(Validate(&portainer.OwnershipRule{NamePattern: "^prometheus", ResourceTypes: []portainer.ResourceControlType{portainer.ContainerResourceControl}}))
+}
+
+func TestApplies(t *testing.T) {
+	is := assert.New(t)
+
+	endpoint := &portainer.Endpoint{ID: 1, GroupID: 2}
+
+	is.True(Applies(&portainer.OwnershipRule{}, endpoint), "the rules without scope apply to all the environments")
+	is.True(Applies(&portainer.OwnershipRule{EndpointIDs: []portainer.EndpointID{1}}, endpoint))
+	is.True(Applies(&portainer.OwnershipRule{EndpointGroupIDs: []portainer.EndpointGroupID{2}}, endpoint))
+	is.False(Applies(&portainer.OwnershipRule{EndpointIDs: []portainer.EndpointID{3}, EndpointGroupIDs: []portainer.EndpointGroupID{4}}, endpoint))
+}
+
+func TestMatch(t *testing.T) {
+	is := assert.New(t)
+
+	rules := []portainer.OwnershipRule{
+		{ID: 1, Label: "team=monitoring", ResourceTypes: []portainer.ResourceControlType{portainer.ContainerResourceControl}},
+		{ID: 2, NamePattern: "^prometheus"},
+		{ID: 3, Label: "team"},
+	}
+
+	match := func(resourceType portainer.ResourceControlType, name string, labels map[string]string) portainer.OwnershipRuleID {
+		if rule := Match(rules, resourceType, name, labels); rule != nil {
+			return rule.ID
+		}
+
+		return 0
+	}
+
+	is.Equal(portainer.OwnershipRuleID(1), match(portainer.ContainerResourceControl, "prometheus", map[string]string{"team": "monitoring"}), "the first matching rule is used")
+	is.Equal(portainer.OwnershipRuleID(2), match(portainer.VolumeResourceControl, "prometheus-data", map[string]string{"team": "monitoring"}))
+	is.Equal(portainer.OwnershipRuleID(3), match(portainer.NetworkResourceControl, "backend", map[string]string{"team": "web"}))
+	is.Equal(portainer.OwnershipRuleID(0), match(portainer.ContainerResourceControl, "grafana", nil))
+}
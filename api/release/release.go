@@ -0,0 +1,200 @@
+// Package release checks for new Portainer releases in the background
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/client"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	checkInterval  = 12 * time.Hour
+	requestTimeout = 10 * time.Second
+)
+
+// Release represents a published Portainer release
+type Release struct {
+	// Version of the release
+	Version string `json:"Version" example:"2.21.0"`
+	// Name of the release
+	Name string `json:"Name" example:"Release 2.21.0"`
+	// Release notes, in markdown
+	Notes string `json:"Notes,omitempty"`
+	// URL of the release page
+	URL string `json:"URL" example:"https://github.com/portainer/portainer/releases/tag/2.21.0"`
+	// Unix timestamp of the publication of the release
+	PublishedAt int64 `json:"PublishedAt" example:"1700000000"`
+}
+
+// Service periodically retrieves the latest Portainer release. No call is made in offline mode or when the
+// update check is disabled in the settings.
+type Service struct {
+	dataStore dataservices.DataStore
+	url       string
+	client    *http.Client
+	refresh   chan struct{}
+
+	mu     sync.RWMutex
+	latest *Release
+}
+
+// NewService creates a release check service
+func NewService(dataStore dataservices.DataStore) *Service {
+	return &Service{
+		dataStore: dataStore,
+		url:       portainer.VersionCheckURL,
+		client:    client.NewExternalClient(requestTimeout),
+		refresh:   make(chan struct{}, 1),
+	}
+}
+
+// Start checks for a new release right away, then periodically until the context is done
+func (service *Service) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		for {
+			if service.Enabled() {
+				if err := service.Check(ctx); err != nil {
+					log.Debug().Err(err).Msg("couldn't fetch the latest Portainer release")
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-service.refresh:
+			}
+		}
+	}()
+}
+
+// Refresh triggers a check outside of the schedule, typically after the update check was enabled
+func (service *Service) Refresh() {
+	select {
+	case service.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Enabled returns whether Portainer is allowed to check for new releases
+func (service *Service) Enabled() bool {
+	if client.OfflineMode() {
+		return false
+	}
+
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		log.Warn().Err(err).Msg("unable to retrieve the settings")
+		return false
+	}
+
+	return !settings.DisableUpdateCheck
+}
+
+// Check retrieves the latest release and caches it
+func (service *Service) Check(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, service.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := service.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	var data struct {
+		TagName     string    `json:"tag_name"`
+		Name        string    `json:"name"`
+		Body        string    `json:"body"`
+		HTMLURL     string    `json:"html_url"`
+		PublishedAt time.Time `json:"published_at"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return err
+	}
+
+	release := &Release{
+		Version: data.TagName,
+		Name:    data.Name,
+		Notes:   data.Body,
+		URL:     data.HTMLURL,
+	}
+
+	if !data.PublishedAt.IsZero() {
+		release.PublishedAt = data.PublishedAt.Unix()
+	}
+
+	service.mu.Lock()
+	service.latest = release
+	service.mu.Unlock()
+
+	return nil
+}
+
+// Latest returns the latest release retrieved, nil when it is unknown or when the update check is disabled
+func (service *Service) Latest() *Release {
+	if !service.Enabled() {
+		return nil
+	}
+
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	if service.latest == nil {
+		return nil
+	}
+
+	release := *service.latest
+
+	return &release
+}
+
+// UpdateAvailable returns the latest release when it is newer than the running version
+func (service *Service) UpdateAvailable() (*Release, bool) {
+	release := service.Latest()
+	if release == nil || !HasNewerVersion(portainer.APIVersion, release.Version) {
+		return nil, false
+	}
+
+	return release, true
+}
+
+// HasNewerVersion returns whether latestVersion is a newer semantic version than currentVersion
+func HasNewerVersion(currentVersion, latestVersion string) bool {
+	currentVersionSemver, err := semver.NewVersion(currentVersion)
+	if err != nil {
+		log.Debug().Str("version", currentVersion).Msg("current Portainer version isn't a semver")
+
+		return false
+	}
+
+	latestVersionSemver, err := semver.NewVersion(latestVersion)
+	if err != nil {
+		log.Debug().Str("version", latestVersion).Msg("latest Portainer version isn't a semver")
+
+		return false
+	}
+
+	return currentVersionSemver.LessThan(*latestVersionSemver)
+}
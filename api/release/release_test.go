@@ -0,0 +1,63 @@
+package release
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/portainer/portainer/api/datastore"
+	"github.com/portainer/portainer/api/http/client"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tag_name": "99.0.0", "name": "Release 99.0.0", "body": "## Changes", "html_url": "https://example.com/99.0.0", "published_at": "2024-01-02T03:04:05Z"}`))
+	}))
+	defer server.Close()
+
+	service := NewService(store)
+	service.url = server.URL
+
+	is.Nil(service.Latest())
+
+	is.NoError(service.Check(context.Background()))
+
+	release, ok := service.UpdateAvailable()
+	is.True(ok)
+	is.Equal("99.0.0", release.Version)
+	is.Equal("## Changes", release.Notes)
+	is.Equal(int64(1704164645), release.PublishedAt)
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.DisableUpdateCheck = true
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	is.Nil(service.Latest(), "no release should be reported when the update check is disabled")
+}
+
+func TestOfflineMode(t *testing.T) {
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	client.SetOfflineMode(true)
+	defer client.SetOfflineMode(false)
+
+	service := NewService(store)
+	assert.False(t, service.Enabled())
+	assert.Nil(t, service.Latest())
+	assert.ErrorIs(t, service.Check(context.Background()), client.ErrOfflineMode)
+}
+
+func TestHasNewerVersion(t *testing.T) {
+	assert.True(t, HasNewerVersion("2.20.0", "2.21.0"))
+	assert.False(t, HasNewerVersion("2.21.0", "2.21.0"))
+	assert.False(t, HasNewerVersion("2.21.0", "2.20.3"))
+	assert.False(t, HasNewerVersion("2.21.0", "latest"))
+}
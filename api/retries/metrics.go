@@ -0,0 +1,89 @@
+package retries
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	MetricRetries    = "portainer_transient_retries_total"
+	MetricRecoveries = "portainer_transient_retry_recoveries_total"
+	MetricFailures   = "portainer_transient_retry_failures_total"
+)
+
+type counters struct {
+	// retries are the requests sent again after a transient failure
+	retries atomic.Int64
+	// recoveries are the requests which succeeded after being retried
+	recoveries atomic.Int64
+	// failures are the requests which still failed after being retried
+	failures atomic.Int64
+}
+
+var (
+	countersMu sync.Mutex
+	// the known subsystems are exported before their first retry
+	subsystems = map[string]*counters{
+		SubsystemProxy:    {},
+		SubsystemSnapshot: {},
+	}
+	metricsHelp = map[string]string{
+		MetricRetries:    "Requests to the environments sent again after a transient connection error",
+		MetricRecoveries: "Requests to the environments which succeeded after being retried",
+		MetricFailures:   "Requests to the environments which still failed after being retried",
+	}
+)
+
+func countersOf(subsystem string) *counters {
+	countersMu.Lock()
+	defer countersMu.Unlock()
+
+	c, ok := subsystems[subsystem]
+	if !ok {
+		c = &counters{}
+		subsystems[subsystem] = c
+	}
+
+	return c
+}
+
+// WriteMetrics writes the retry counters of the subsystems in the Prometheus text exposition format
+func WriteMetrics(w io.Writer) error {
+	countersMu.Lock()
+	names := make([]string, 0, len(subsystems))
+	for name := range subsystems {
+		names = append(names, name)
+	}
+	countersMu.Unlock()
+
+	sort.Strings(names)
+
+	var b strings.Builder
+
+	for _, metric := range []string{MetricRetries, MetricRecoveries, MetricFailures} {
+		fmt.Fprintf(&b, "# HELP %s %s\n", metric, metricsHelp[metric])
+		fmt.Fprintf(&b, "# TYPE %s counter\n", metric)
+
+		for _, name := range names {
+			c := countersOf(name)
+
+			value := c.retries.Load()
+			switch metric {
+			case MetricRecoveries:
+				value = c.recoveries.Load()
+			case MetricFailures:
+				value = c.failures.Load()
+			}
+
+			fmt.Fprintf(&b, "%s{subsystem=\"%s\"} %d\n", metric, name, value)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
@@ -0,0 +1,145 @@
+// Package retries retries the idempotent requests to the agents and the Docker daemons failing with a transient
+// connection error, such as the connection resets of the flaky WAN links reaching the remote environments.
+package retries
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	// SubsystemProxy is the subsystem of the requests proxied to the environments
+	SubsystemProxy = "proxy"
+	// SubsystemSnapshot is the subsystem of the requests of the snapshots of the environments
+	SubsystemSnapshot = "snapshot"
+)
+
+// Policy describes how the failed requests are retried
+type Policy struct {
+	// MaxRetries is the number of retries of a failed request, 0 disabling the retries
+	MaxRetries int
+	// Backoff is the delay before the first retry, doubled on each retry up to MaxBackoff. A random jitter of up to
+	// half of the delay is subtracted so that the requests failing together are not retried together.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// Default is the policy used until Configure is called
+var Default = Policy{
+	MaxRetries: 2,
+	Backoff:    200 * time.Millisecond,
+	MaxBackoff: 2 * time.Second,
+}
+
+var current atomic.Pointer[Policy]
+
+// Configure sets the retry policy
+func Configure(policy Policy) {
+	current.Store(&policy)
+}
+
+// Current returns the retry policy
+func Current() Policy {
+	if policy := current.Load(); policy != nil {
+		return *policy
+	}
+
+	return Default
+}
+
+// delay returns the jittered delay before a retry, attempt starting at 0
+func (policy Policy) delay(attempt int) time.Duration {
+	delay := policy.Backoff
+	for i := 0; i < attempt && (policy.MaxBackoff <= 0 || delay < policy.MaxBackoff); i++ {
+		delay *= 2
+	}
+
+	if policy.MaxBackoff > 0 && delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay - time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+// Transport retries the idempotent requests failing with a transient connection error
+type Transport struct {
+	next      http.RoundTripper
+	subsystem string
+}
+
+// NewTransport wraps a transport so that its idempotent requests are retried, the retries being counted in the
+// metrics of the subsystem
+func NewTransport(next http.RoundTripper, subsystem string) *Transport {
+	return &Transport{next: next, subsystem: subsystem}
+}
+
+// RoundTrip is the implementation of the http.RoundTripper interface
+func (transport *Transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if !IsRetryable(request) {
+		return transport.next.RoundTrip(request)
+	}
+
+	policy := Current()
+	counters := countersOf(transport.subsystem)
+
+	for attempt := 0; ; attempt++ {
+		response, err := transport.next.RoundTrip(request)
+		if err == nil || attempt >= policy.MaxRetries || !IsTransient(err) {
+			if attempt > 0 {
+				if err == nil {
+					counters.recoveries.Add(1)
+				} else {
+					counters.failures.Add(1)
+				}
+			}
+
+			return response, err
+		}
+
+		timer := time.NewTimer(policy.delay(attempt))
+		select {
+		case <-timer.C:
+		case <-request.Context().Done():
+			timer.Stop()
+			counters.failures.Add(1)
+
+			return nil, err
+		}
+
+		counters.retries.Add(1)
+	}
+}
+
+// IsRetryable returns whether a request can be sent again: the GET and HEAD requests without a body, which are not
+// upgraded to a stream such as the attach and exec sessions
+func IsRetryable(request *http.Request) bool {
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		return false
+	}
+
+	if request.Body != nil && request.Body != http.NoBody {
+		return false
+	}
+
+	return request.Header.Get("Upgrade") == ""
+}
+
+// IsTransient returns whether an error is a connection error which may not happen again, the timeouts not being
+// transient as retrying them would multiply the time the clients wait
+func IsTransient(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
@@ -0,0 +1,91 @@
+package retries
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyTransport fails the first requests with a connection reset
+type flakyTransport struct {
+	failures int
+	calls    int
+}
+
+func (transport *flakyTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport.calls++
+	if transport.calls <= transport.failures {
+		return nil, &connError{syscall.ECONNRESET}
+	}
+
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+}
+
+// connError wraps an error the way the network errors of the http.Transport do
+type connError struct {
+	err error
+}
+
+func (e *connError) Error() string { return "read: " + e.err.Error() }
+func (e *connError) Unwrap() error { return e.err }
+
+func TestTransport(t *testing.T) {
+	is := assert.New(t)
+
+	Configure(Policy{MaxRetries: 2, Backoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond})
+	defer Configure(Default)
+
+	// a dedicated subsystem keeps the counters of the test apart
+	subsystem := "test"
+	counters := countersOf(subsystem)
+
+	flaky := &flakyTransport{failures: 2}
+	response, err := NewTransport(flaky, subsystem).RoundTrip(httptest.NewRequest(http.MethodGet, "/containers/json", nil))
+	is.NoError(err)
+	is.Equal(http.StatusOK, response.StatusCode)
+	is.Equal(3, flaky.calls)
+	is.Equal(int64(2), counters.retries.Load())
+	is.Equal(int64(1), counters.recoveries.Load())
+
+	flaky = &flakyTransport{failures: 5}
+	_, err = NewTransport(flaky, subsystem).RoundTrip(httptest.NewRequest(http.MethodGet, "/containers/json", nil))
+	is.ErrorIs(err, syscall.ECONNRESET)
+	is.Equal(3, flaky.calls, "the request should be retried at most MaxRetries times")
+	is.Equal(int64(1), counters.failures.Load())
+
+	flaky = &flakyTransport{failures: 1}
+	_, err = NewTransport(flaky, subsystem).RoundTrip(httptest.NewRequest(http.MethodPost, "/containers/create", strings.NewReader("{}")))
+	is.Error(err)
+	is.Equal(1, flaky.calls, "the requests which are not idempotent should not be retried")
+
+	var b strings.Builder
+	is.NoError(WriteMetrics(&b))
+	is.Contains(b.String(), `portainer_transient_retries_total{subsystem="test"} 4`)
+	is.Contains(b.String(), `portainer_transient_retry_recoveries_total{subsystem="proxy"} 0`)
+}
+
+func TestIsTransient(t *testing.T) {
+	is := assert.New(t)
+
+	is.True(IsTransient(&connError{syscall.ECONNRESET}))
+	is.True(IsTransient(io.ErrUnexpectedEOF))
+	is.False(IsTransient(errors.New("no such container")))
+	is.False(IsTransient(&connError{syscall.ETIMEDOUT}), "the timeouts should not be retried")
+}
+
+func TestPolicy_Delay(t *testing.T) {
+	policy := Policy{Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+
+	for attempt, expected := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond} {
+		delay := policy.delay(attempt)
+		assert.LessOrEqual(t, delay, expected)
+		assert.GreaterOrEqual(t, delay, expected/2, "the jitter should not exceed half of the delay")
+	}
+}
@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PeriodicJob is a job run at an interval set by the settings, which is replaced whenever the settings are updated
+type PeriodicJob struct {
+	scheduler *Scheduler
+
+	mu    sync.Mutex
+	jobID string
+}
+
+// NewPeriodicJob creates a periodic job, which is not run until it is scheduled
+func (s *Scheduler) NewPeriodicJob() *PeriodicJob {
+	return &PeriodicJob{scheduler: s}
+}
+
+// Schedule replaces the scheduled job with run, which is run at the interval
+func (job *PeriodicJob) Schedule(interval time.Duration, run func() error) error {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	if err := job.stop(); err != nil {
+		return err
+	}
+
+	job.jobID = job.scheduler.StartJobEvery(interval, run)
+
+	return nil
+}
+
+// Stop stops the scheduled job, if any
+func (job *PeriodicJob) Stop() error {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	return job.stop()
+}
+
+func (job *PeriodicJob) stop() error {
+	if job.jobID == "" {
+		return nil
+	}
+
+	if err := job.scheduler.StopJob(job.jobID); err != nil {
+		return err
+	}
+
+	job.jobID = ""
+
+	return nil
+}
+
+// ParseInterval parses the interval of a periodic job, defaultInterval being used when it is empty. The name of the
+// job is used in the errors.
+func ParseInterval(interval, defaultInterval, name string) (time.Duration, error) {
+	if interval == "" {
+		interval = defaultInterval
+	}
+
+	duration, err := time.ParseDuration(interval)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s interval", name)
+	}
+
+	if duration <= 0 {
+		return 0, errors.Errorf("%s interval must be positive", name)
+	}
+
+	return duration, nil
+}
@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PeriodicJob_IsReplacedWhenScheduled(t *testing.T) {
+	s := NewScheduler(context.Background())
+	defer s.Shutdown()
+
+	job := s.NewPeriodicJob()
+
+	var replaced, current atomic.Int32
+	assert.NoError(t, job.Schedule(jobInterval, func() error {
+		replaced.Add(1)
+		return nil
+	}))
+
+	assert.NoError(t, job.Schedule(jobInterval, func() error {
+		current.Add(1)
+		return nil
+	}))
+
+	assert.Eventually(t, func() bool { return current.Load() > 0 }, 3*jobInterval, 10*time.Millisecond)
+	assert.Equal(t, int32(0), replaced.Load(), "the replaced job should not be run")
+
+	assert.NoError(t, job.Stop())
+
+	runs := current.Load()
+	time.Sleep(2 * jobInterval)
+	assert.Equal(t, runs, current.Load(), "the stopped job should not be run")
+}
+
+func Test_ParseInterval(t *testing.T) {
+	interval, err := ParseInterval("", "1h", "export")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, interval)
+
+	interval, err = ParseInterval("5m", "1h", "export")
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, interval)
+
+	_, err = ParseInterval("often", "1h", "export")
+	assert.ErrorContains(t, err, "invalid export interval")
+
+	_, err = ParseInterval("-1m", "1h", "export")
+	assert.ErrorContains(t, err, "export interval must be positive")
+}
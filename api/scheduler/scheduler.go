@@ -4,6 +4,7 @@ import (
 	"context"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -11,10 +12,14 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+// HeartbeatInterval is the interval at which the scheduler records that it is running jobs
+const HeartbeatInterval = 10 * time.Second
+
 type Scheduler struct {
 	crontab    *cron.Cron
 	activeJobs map[cron.EntryID]context.CancelFunc
 	mu         sync.Mutex
+	heartbeat  atomic.Int64
 }
 
 type PermanentError struct {
@@ -38,6 +43,11 @@ func NewScheduler(ctx context.Context) *Scheduler {
 		activeJobs: make(map[cron.EntryID]context.CancelFunc),
 	}
 
+	s.heartbeat.Store(time.Now().Unix())
+	crontab.Schedule(cron.Every(HeartbeatInterval), cron.FuncJob(func() {
+		s.heartbeat.Store(time.Now().Unix())
+	}))
+
 	if ctx != nil {
 		go func() {
 			<-ctx.Done()
@@ -48,6 +58,12 @@ func NewScheduler(ctx context.Context) *Scheduler {
 	return s
 }
 
+// LastHeartbeat returns the last time the scheduler ran its heartbeat job. It stops being updated once the
+// scheduler is shut down or when its jobs are no longer run.
+func (s *Scheduler) LastHeartbeat() time.Time {
+	return time.Unix(s.heartbeat.Load(), 0)
+}
+
 // Shutdown stops the scheduler and waits for it to stop if it is running; otherwise does nothing.
 func (s *Scheduler) Shutdown() error {
 	if s.crontab == nil {
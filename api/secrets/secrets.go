@@ -0,0 +1,172 @@
+// Package secrets resolves the stack environment variables referencing the secrets of an external secret store
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+
+	"github.com/pkg/errors"
+)
+
+// Provider retrieves the secrets of an external secret store
+type Provider interface {
+	// Secret returns the value of the key of the secret stored at the path
+	Secret(ctx context.Context, path, key string) (string, error)
+}
+
+// ProviderFactory creates the provider of a secret store from the settings, it returns a nil provider when the
+// secret store is not configured
+type ProviderFactory func(settings portainer.SecretsSettings) (Provider, error)
+
+// Reference represents a secret referenced by an environment variable, formatted as scheme:path#key
+type Reference struct {
+	// Scheme of the secret store, such as vault
+	Scheme string
+	// Path of the secret in the secret store
+	Path string
+	// Key of the value in the secret
+	Key string
+}
+
+func (reference Reference) String() string {
+	return fmt.Sprintf("%s:%s#%s", reference.Scheme, reference.Path, reference.Key)
+}
+
+// Service resolves the secret references of the stack environment variables on deployment, so that the values of
+// the secrets are neither stored in the database nor in the stack files
+type Service struct {
+	dataStore dataservices.DataStore
+	factories map[string]ProviderFactory
+}
+
+// NewService creates a secrets service supporting the HashiCorp Vault references
+func NewService(dataStore dataservices.DataStore) *Service {
+	service := &Service{
+		dataStore: dataStore,
+		factories: map[string]ProviderFactory{},
+	}
+
+	service.Register(VaultScheme, NewVaultProvider)
+
+	return service
+}
+
+// Register adds the secret store of a reference scheme
+func (service *Service) Register(scheme string, factory ProviderFactory) {
+	service.factories[scheme] = factory
+}
+
+// ParseReference returns the secret referenced by the value of an environment variable. The values which do not use
+// the scheme of a registered secret store are not references.
+func (service *Service) ParseReference(value string) (Reference, bool) {
+	scheme, rest, ok := strings.Cut(value, ":")
+	if !ok {
+		return Reference{}, false
+	}
+
+	if _, ok := service.factories[scheme]; !ok {
+		return Reference{}, false
+	}
+
+	path, key, ok := strings.Cut(rest, "#")
+	if !ok || path == "" || key == "" {
+		return Reference{}, false
+	}
+
+	return Reference{Scheme: scheme, Path: strings.Trim(path, "/"), Key: key}, true
+}
+
+// HasReferences returns whether some environment variables reference secrets
+func (service *Service) HasReferences(env []portainer.Pair) bool {
+	for _, pair := range env {
+		if _, ok := service.ParseReference(pair.Value); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResolveEnv splits the environment variables between the plain ones and the secret ones, whose values are
+// retrieved from their secret store
+func (service *Service) ResolveEnv(ctx context.Context, env []portainer.Pair) (plain []portainer.Pair, resolved []portainer.Pair, err error) {
+	providers := map[string]Provider{}
+
+	for _, pair := range env {
+		reference, ok := service.ParseReference(pair.Value)
+		if !ok {
+			plain = append(plain, pair)
+			continue
+		}
+
+		value, err := service.secret(ctx, providers, reference)
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "unable to resolve the secret of the environment variable %s", pair.Name)
+		}
+
+		resolved = append(resolved, portainer.Pair{Name: pair.Name, Value: value})
+	}
+
+	return plain, resolved, nil
+}
+
+// ResolveEnvValues returns the environment variables with the values of the secrets they reference
+func (service *Service) ResolveEnvValues(ctx context.Context, env []portainer.Pair) ([]portainer.Pair, error) {
+	providers := map[string]Provider{}
+	values := make([]portainer.Pair, 0, len(env))
+
+	for _, pair := range env {
+		reference, ok := service.ParseReference(pair.Value)
+		if !ok {
+			values = append(values, pair)
+			continue
+		}
+
+		value, err := service.secret(ctx, providers, reference)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "unable to resolve the secret of the environment variable %s", pair.Name)
+		}
+
+		values = append(values, portainer.Pair{Name: pair.Name, Value: value})
+	}
+
+	return values, nil
+}
+
+// secret retrieves a referenced secret, the providers are created once per resolution
+func (service *Service) secret(ctx context.Context, providers map[string]Provider, reference Reference) (string, error) {
+	provider, ok := providers[reference.Scheme]
+	if !ok {
+		var err error
+		provider, err = service.provider(reference.Scheme)
+		if err != nil {
+			return "", err
+		}
+
+		providers[reference.Scheme] = provider
+	}
+
+	return provider.Secret(ctx, reference.Path, reference.Key)
+}
+
+func (service *Service) provider(scheme string) (Provider, error) {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return nil, errors.WithMessage(err, "unable to retrieve the settings from the database")
+	}
+
+	provider, err := service.factories[scheme](settings.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider == nil {
+		return nil, fmt.Errorf("the %s secret store is not configured", scheme)
+	}
+
+	return provider, nil
+}
@@ -0,0 +1,140 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newVaultServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/app":
+			json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]any{
+					"data":     map[string]any{"password": "s3cr3t", "port": 5432},
+					"metadata": map[string]any{"version": 1},
+				},
+			})
+		case "/v1/kv/app":
+			json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]any{"password": "v1-s3cr3t"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func Test_ParseReference(t *testing.T) {
+	is := assert.New(t)
+
+	service := &Service{factories: map[string]ProviderFactory{VaultScheme: NewVaultProvider}}
+
+	reference, ok := service.ParseReference("vault:secret/app#password")
+	is.True(ok)
+	is.Equal(Reference{Scheme: "vault", Path: "secret/app", Key: "password"}, reference)
+	is.Equal("vault:secret/app#password", reference.String())
+
+	for _, value := range []string{"plain", "vault:secret/app", "vault:#password", "vault:secret/app#", "aws:secret/app#password", "http://example.com#anchor"} {
+		_, ok := service.ParseReference(value)
+		is.False(ok, value)
+	}
+}
+
+func Test_VaultProvider(t *testing.T) {
+	is := assert.New(t)
+	server := newVaultServer(t)
+
+	provider, err := NewVaultProvider(portainer.SecretsSettings{Vault: portainer.VaultSettings{Enabled: true, Address: server.URL, Token: "token"}})
+	is.NoError(err)
+
+	value, err := provider.Secret(context.Background(), "secret/app", "password")
+	is.NoError(err)
+	is.Equal("s3cr3t", value)
+
+	value, err = provider.Secret(context.Background(), "secret/app", "port")
+	is.NoError(err)
+	is.Equal("5432", value)
+
+	_, err = provider.Secret(context.Background(), "secret/app", "user")
+	is.ErrorContains(err, "has no key user")
+
+	_, err = provider.Secret(context.Background(), "secret/missing", "password")
+	is.ErrorContains(err, "does not exist")
+
+	provider, err = NewVaultProvider(portainer.SecretsSettings{Vault: portainer.VaultSettings{Enabled: true, Address: server.URL, Token: "token", KVVersion: 1}})
+	is.NoError(err)
+
+	value, err = provider.Secret(context.Background(), "kv/app", "password")
+	is.NoError(err)
+	is.Equal("v1-s3cr3t", value)
+
+	provider, err = NewVaultProvider(portainer.SecretsSettings{Vault: portainer.VaultSettings{Enabled: true, Address: server.URL, Token: "invalid"}})
+	is.NoError(err)
+
+	_, err = provider.Secret(context.Background(), "secret/app", "password")
+	is.ErrorContains(err, "permission denied")
+
+	provider, err = NewVaultProvider(portainer.SecretsSettings{})
+	is.NoError(err)
+	is.Nil(provider, "no provider is created when Vault is disabled")
+}
+
+func Test_ResolveEnv(t *testing.T) {
+	is := assert.New(t)
+	server := newVaultServer(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+	service := NewService(store)
+
+	env := []portainer.Pair{
+		{Name: "DB_HOST", Value: "db"},
+		{Name: "DB_PASSWORD", Value: "vault:secret/app#password"},
+	}
+
+	is.True(service.HasReferences(env))
+	is.False(service.HasReferences(env[:1]))
+
+	_, _, err := service.ResolveEnv(context.Background(), env)
+	is.ErrorContains(err, "the vault secret store is not configured")
+
+	settings, err := store.Settings().Settings()
+	is.NoError(err)
+	settings.Secrets.Vault = portainer.VaultSettings{Enabled: true, Address: server.URL, Token: "token"}
+	is.NoError(store.Settings().UpdateSettings(settings))
+
+	plain, resolved, err := service.ResolveEnv(context.Background(), env)
+	is.NoError(err)
+	is.Equal([]portainer.Pair{{Name: "DB_HOST", Value: "db"}}, plain)
+	is.Equal([]portainer.Pair{{Name: "DB_PASSWORD", Value: "s3cr3t"}}, resolved)
+
+	values, err := service.ResolveEnvValues(context.Background(), env)
+	is.NoError(err)
+	is.Equal([]portainer.Pair{{Name: "DB_HOST", Value: "db"}, {Name: "DB_PASSWORD", Value: "s3cr3t"}}, values)
+	is.Equal("vault:secret/app#password", env[1].Value, "the references are not replaced")
+}
+
+func Test_ValidateVaultSettings(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateVaultSettings(portainer.VaultSettings{}))
+	is.NoError(ValidateVaultSettings(portainer.VaultSettings{Enabled: true, Address: "https://vault.example.com:8200", KVVersion: 1}))
+	is.Error(ValidateVaultSettings(portainer.VaultSettings{Enabled: true, Address: "vault.example.com"}))
+	is.Error(ValidateVaultSettings(portainer.VaultSettings{Enabled: true, Address: "https://vault.example.com", KVVersion: 3}))
+}
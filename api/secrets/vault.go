@@ -0,0 +1,136 @@
+package secrets
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/http/client"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// VaultScheme is the scheme of the references to the HashiCorp Vault secrets, such as vault:secret/app#password
+	VaultScheme = "vault"
+
+	vaultRequestTimeout = 10 * time.Second
+)
+
+// VaultProvider retrieves the secrets of a KV secrets engine of a HashiCorp Vault server
+type VaultProvider struct {
+	settings portainer.VaultSettings
+	client   *http.Client
+}
+
+// NewVaultProvider creates a Vault provider, it returns nil when Vault is not enabled in the settings
+func NewVaultProvider(settings portainer.SecretsSettings) (Provider, error) {
+	if !settings.Vault.Enabled {
+		return nil, nil
+	}
+
+	if err := ValidateVaultSettings(settings.Vault); err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if settings.Vault.TLSSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &VaultProvider{
+		settings: settings.Vault,
+		client:   &http.Client{Timeout: vaultRequestTimeout, Transport: client.NewExternalTransport(transport)},
+	}, nil
+}
+
+// Secret reads the secret at the path, whose first segment is the mount of the KV secrets engine
+func (provider *VaultProvider) Secret(ctx context.Context, path, key string) (string, error) {
+	apiPath := path
+	if provider.settings.KVVersion != 1 {
+		mount, secretPath, ok := strings.Cut(path, "/")
+		if !ok {
+			return "", fmt.Errorf("invalid Vault secret path %q, the mount of the secrets engine is expected first", path)
+		}
+
+		apiPath = mount + "/data/" + secretPath
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(provider.settings.Address, "/")+"/v1/"+apiPath, nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("X-Vault-Token", provider.settings.Token)
+	if provider.settings.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", provider.settings.Namespace)
+	}
+
+	resp, err := provider.client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to reach the Vault server")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", fmt.Errorf("the Vault secret %s does not exist", path)
+	case http.StatusForbidden:
+		return "", fmt.Errorf("permission denied to read the Vault secret %s", path)
+	default:
+		return "", fmt.Errorf("unable to read the Vault secret %s, status %d", path, resp.StatusCode)
+	}
+
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", errors.Wrap(err, "unable to decode the Vault response")
+	}
+
+	data := body.Data
+	if provider.settings.KVVersion != 1 {
+		data, _ = body.Data["data"].(map[string]any)
+	}
+
+	value, ok := data[key]
+	if !ok || value == nil {
+		return "", fmt.Errorf("the Vault secret %s has no key %s", path, key)
+	}
+
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), nil
+}
+
+// ValidateVaultSettings checks the connection settings of an enabled Vault server
+func ValidateVaultSettings(settings portainer.VaultSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
+
+	address, err := url.Parse(settings.Address)
+	if err != nil || (address.Scheme != "http" && address.Scheme != "https") || address.Host == "" {
+		return fmt.Errorf("invalid Vault address %q, an http or https URL is expected", settings.Address)
+	}
+
+	if settings.KVVersion != 0 && settings.KVVersion != 1 && settings.KVVersion != 2 {
+		return fmt.Errorf("invalid Vault KV version %d, 1 or 2 is expected", settings.KVVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package secretstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/portainer/portainer/api/masterkey"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultKeyFileName is the name of the key file of the secret store in the data directory, used when no key file is
+// specified
+const DefaultKeyFileName = "secrets.key"
+
+// LoadKey reads the key of the secret store from a file holding the base64 encoded key, or an envelope of the
+// masterkey package which is unwrapped by the master key it names. A key is generated when the file does not exist,
+// wrapped by the master key identified by masterKeyURI when it is specified.
+func LoadKey(path, masterKeyURI string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return generateKey(path, masterKeyURI)
+	} else if err != nil {
+		return nil, errors.Wrap(err, "unable to read the key file of the secret store")
+	}
+
+	if masterkey.IsEnvelope(content) {
+		content, err = masterkey.Open(context.Background(), content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key of the secret store, a base64 encoded %d bytes key is expected", KeySize)
+	}
+
+	return key, nil
+}
+
+func generateKey(path, masterKeyURI string) ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	content := []byte(base64.StdEncoding.EncodeToString(key))
+
+	if masterKeyURI != "" {
+		var err error
+		if content, err = masterkey.Seal(context.Background(), masterKeyURI, content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return nil, errors.Wrap(err, "unable to write the key file of the secret store")
+	}
+
+	log.Info().Str("path", path).Msg("generated the key of the secret store")
+
+	return key, nil
+}
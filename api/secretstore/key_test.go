@@ -0,0 +1,35 @@
+package secretstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadKey(t *testing.T) {
+	is := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), DefaultKeyFileName)
+
+	generated, err := LoadKey(path, "")
+	is.NoError(err)
+	is.Len(generated, KeySize)
+
+	info, err := os.Stat(path)
+	is.NoError(err)
+	is.Equal(os.FileMode(0o600), info.Mode().Perm())
+
+	loaded, err := LoadKey(path, "")
+	is.NoError(err)
+	is.Equal(generated, loaded)
+
+	is.NoError(os.WriteFile(path, []byte("not a key"), 0o600))
+	_, err = LoadKey(path, "")
+	is.Error(err)
+
+	// the master key is validated before a key is generated
+	_, err = LoadKey(filepath.Join(t.TempDir(), DefaultKeyFileName), "file:///path/to/key")
+	is.Error(err)
+}
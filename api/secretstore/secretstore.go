@@ -0,0 +1,214 @@
+// Package secretstore encrypts the integration credentials stored in the database, such as the registry passwords,
+// the LDAP bind password, the Azure credentials, the signing keys of the event webhooks, the tokens of the
+// automation webhooks, the secrets of the slash commands and the S3 secret access keys of the volume backups. The credentials are encrypted field by field with AES-GCM
+// when the objects are written and decrypted when they are read, so that the rest of Portainer keeps handling them in
+// plain text.
+package secretstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/pkg/errors"
+)
+
+// encryptedPrefix identifies the encrypted values, the values without it are stored in plain text and are encrypted
+// the next time their object is written
+const encryptedPrefix = "enc:v1:"
+
+// KeySize is the size of the AES-256 keys of the secret store
+const KeySize = 32
+
+// ErrDecryption is returned when a value cannot be decrypted, usually because the key has changed
+var ErrDecryption = errors.New("unable to decrypt a secret, check the key of the secret store")
+
+// Service encrypts and decrypts the secrets of the objects stored in the database
+type Service struct {
+	aead cipher.AEAD
+}
+
+// NewService creates a secret store encrypting the secrets with the 32 bytes key
+func NewService(key []byte) (*Service, error) {
+	if len(key) != KeySize {
+		return nil, errors.Errorf("invalid secret store key, %d bytes are expected", KeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{aead: aead}, nil
+}
+
+// IsEncrypted returns whether a value has been encrypted by a secret store
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// Encrypt encrypts a value, the empty and the already encrypted values are returned as is
+func (service *Service) Encrypt(value string) (string, error) {
+	if value == "" || IsEncrypted(value) {
+		return value, nil
+	}
+
+	nonce := make([]byte, service.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := service.aead.Seal(nonce, nonce, []byte(value), nil)
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value, the values stored in plain text are returned as is
+func (service *Service) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil || len(sealed) < service.aead.NonceSize() {
+		return "", ErrDecryption
+	}
+
+	nonceSize := service.aead.NonceSize()
+	plaintext, err := service.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", ErrDecryption
+	}
+
+	return string(plaintext), nil
+}
+
+// EncryptFields returns the object to store in place of an object holding secrets, which is a copy of the object
+// whose secrets are encrypted. The object itself is left untouched as it can be shared, e.g. by a cache.
+func (service *Service) EncryptFields(object any) (any, error) {
+	object = clone(object)
+
+	for _, field := range secretFields(object) {
+		encrypted, err := service.Encrypt(*field)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to encrypt a secret")
+		}
+
+		*field = encrypted
+	}
+
+	return object, nil
+}
+
+// DecryptFields decrypts in place the secrets of an object read from the database
+func (service *Service) DecryptFields(object any) error {
+	for _, field := range secretFields(object) {
+		decrypted, err := service.Decrypt(*field)
+		if err != nil {
+			return err
+		}
+
+		*field = decrypted
+	}
+
+	return nil
+}
+
+// clone returns a copy of the objects holding secrets, deep enough for their secrets to be replaced
+func clone(object any) any {
+	switch o := object.(type) {
+	case *portainer.Registry:
+		c := *o
+		if o.ManagementConfiguration != nil {
+			configuration := *o.ManagementConfiguration
+			c.ManagementConfiguration = &configuration
+		}
+
+		return &c
+	case *portainer.DockerHub:
+		c := *o
+		return &c
+	case *portainer.Settings:
+		c := *o
+		return &c
+	case *portainer.SettingsVersion:
+		c := *o
+		return &c
+	case *portainer.Endpoint:
+		c := *o
+		return &c
+	case *portainer.EventWebhook:
+		c := *o
+		return &c
+	case *portainer.CMDBConnector:
+		c := *o
+		return &c
+	case *portainer.AutomationWebhook:
+		c := *o
+		return &c
+	case *portainer.VolumeBackupJob:
+		c := *o
+		if o.Target.S3 != nil {
+			s3 := *o.Target.S3
+			c.Target.S3 = &s3
+		}
+
+		return &c
+	}
+
+	return object
+}
+
+// secretFields returns the secrets of an object, none for the objects not holding secrets
+func secretFields(object any) []*string {
+	switch o := object.(type) {
+	case *portainer.Registry:
+		fields := []*string{&o.Password, &o.AccessToken}
+		if o.ManagementConfiguration != nil {
+			fields = append(fields, &o.ManagementConfiguration.Password)
+		}
+
+		return fields
+	case *portainer.DockerHub:
+		return []*string{&o.Password}
+	case *portainer.Settings:
+		return settingsFields(o)
+	case *portainer.SettingsVersion:
+		return settingsFields(&o.Settings)
+	case *portainer.Endpoint:
+		return []*string{&o.AzureCredentials.AuthenticationKey}
+	case *portainer.EventWebhook:
+		return []*string{&o.Secret}
+	case *portainer.CMDBConnector:
+		return []*string{&o.Password, &o.Token}
+	case *portainer.AutomationWebhook:
+		return []*string{&o.Token}
+	case *portainer.VolumeBackupJob:
+		if o.Target.S3 != nil {
+			return []*string{&o.Target.S3.SecretAccessKey}
+		}
+	}
+
+	return nil
+}
+
+func settingsFields(settings *portainer.Settings) []*string {
+	return []*string{
+		&settings.LDAPSettings.Password,
+		&settings.OAuthSettings.ClientSecret,
+		&settings.Secrets.Vault.Token,
+		&settings.ChatOps.SlackSigningSecret,
+		&settings.ChatOps.MattermostToken,
+		&settings.SMTP.Password,
+	}
+}
@@ -0,0 +1,129 @@
+package secretstore
+
+import (
+	"bytes"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/database/boltdb"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestService(t *testing.T, seed byte) *Service {
+	service, err := NewService(bytes.Repeat([]byte{seed}, KeySize))
+	assert.NoError(t, err)
+
+	return service
+}
+
+func TestService_EncryptDecrypt(t *testing.T) {
+	is := assert.New(t)
+
+	service := newTestService(t, 1)
+
+	encrypted, err := service.Encrypt("registry_password")
+	is.NoError(err)
+	is.True(IsEncrypted(encrypted))
+	is.NotContains(encrypted, "registry_password")
+
+	// the values are encrypted once
+	again, err := service.Encrypt(encrypted)
+	is.NoError(err)
+	is.Equal(encrypted, again)
+
+	decrypted, err := service.Decrypt(encrypted)
+	is.NoError(err)
+	is.Equal("registry_password", decrypted)
+
+	empty, err := service.Encrypt("")
+	is.NoError(err)
+	is.Empty(empty)
+
+	plain, err := service.Decrypt("stored before the secret store")
+	is.NoError(err)
+	is.Equal("stored before the secret store", plain)
+
+	_, err = newTestService(t, 2).Decrypt(encrypted)
+	is.ErrorIs(err, ErrDecryption)
+
+	_, err = NewService([]byte("short"))
+	is.Error(err)
+}
+
+func TestService_Fields(t *testing.T) {
+	is := assert.New(t)
+
+	service := newTestService(t, 1)
+
+	registry := &portainer.Registry{
+		ID:                      1,
+		Password:                "registry_password",
+		ManagementConfiguration: &portainer.RegistryManagementConfiguration{Password: "registry_password"},
+	}
+
+	stored, err := service.EncryptFields(registry)
+	is.NoError(err)
+
+	storedRegistry := stored.(*portainer.Registry)
+	is.True(IsEncrypted(storedRegistry.Password))
+	is.True(IsEncrypted(storedRegistry.ManagementConfiguration.Password))
+	is.Empty(storedRegistry.AccessToken)
+
+	// the original object is left untouched
+	is.Equal("registry_password", registry.Password)
+	is.Equal("registry_password", registry.ManagementConfiguration.Password)
+
+	is.NoError(service.DecryptFields(storedRegistry))
+	is.Equal(registry, storedRegistry)
+
+	settings := &portainer.Settings{}
+	settings.LDAPSettings.Password = "bind_password"
+	settings.OAuthSettings.ClientSecret = "client_secret"
+
+	stored, err = service.EncryptFields(&portainer.SettingsVersion{ID: 1, Settings: *settings})
+	is.NoError(err)
+	is.True(IsEncrypted(stored.(*portainer.SettingsVersion).Settings.LDAPSettings.Password))
+	is.True(IsEncrypted(stored.(*portainer.SettingsVersion).Settings.OAuthSettings.ClientSecret))
+
+	job := &portainer.VolumeBackupJob{ID: 1, Target: portainer.VolumeBackupTarget{
+		Type: portainer.VolumeBackupS3,
+		S3:   &portainer.VolumeBackupS3Target{Bucket: "backups", AccessKeyID: "AKIA", SecretAccessKey: "secret_access_key"},
+	}}
+
+	stored, err = service.EncryptFields(job)
+	is.NoError(err)
+	is.True(IsEncrypted(stored.(*portainer.VolumeBackupJob).Target.S3.SecretAccessKey))
+	is.Equal("secret_access_key", job.Target.S3.SecretAccessKey, "the original object is left untouched")
+
+	is.NoError(service.DecryptFields(stored))
+	is.Equal(job, stored)
+
+	// the objects without secrets are stored as they are
+	user := &portainer.User{ID: 1, Username: "admin"}
+	stored, err = service.EncryptFields(user)
+	is.NoError(err)
+	is.Same(user, stored)
+}
+
+func TestService_Connection(t *testing.T) {
+	is := assert.New(t)
+
+	connection := &boltdb.DbConnection{Path: t.TempDir(), FieldCipher: newTestService(t, 1)}
+	is.NoError(connection.Open())
+	defer connection.Close()
+
+	is.NoError(connection.SetServiceName("event_webhooks"))
+
+	webhook := &portainer.EventWebhook{ID: 1, Name: "cmdb", Secret: "signing_key"}
+	is.NoError(connection.CreateObjectWithId("event_webhooks", 1, webhook))
+	is.Equal("signing_key", webhook.Secret)
+
+	var raw map[string]any
+	is.NoError((&boltdb.DbConnection{Path: connection.Path, DB: connection.DB}).GetObject("event_webhooks", connection.ConvertToKey(1), &raw))
+	is.True(IsEncrypted(raw["Secret"].(string)))
+
+	var read portainer.EventWebhook
+	is.NoError(connection.GetObject("event_webhooks", connection.ConvertToKey(1), &read))
+	is.Equal(*webhook, read)
+}
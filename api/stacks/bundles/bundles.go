@@ -0,0 +1,129 @@
+// Package bundles brings up the stacks of a bundle in the order of their dependencies, waiting for the containers of
+// each stack to be healthy before bringing up its dependents, and tears them down in the reverse order
+package bundles
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+)
+
+// defaultHealthTimeout is the time waited for the containers of a stack to be healthy when the bundle does not set it
+const defaultHealthTimeout = 2 * time.Minute
+
+// Order returns the stacks of a bundle in the order they are brought up, each stack after its dependencies and
+// otherwise in the order of the bundle
+func Order(entries []portainer.StackBundleEntry) ([]portainer.StackBundleEntry, error) {
+	known := make(map[portainer.StackID]bool, len(entries))
+	for _, entry := range entries {
+		if known[entry.StackID] {
+			return nil, fmt.Errorf("the stack %d is listed more than once", entry.StackID)
+		}
+
+		known[entry.StackID] = true
+	}
+
+	for _, entry := range entries {
+		for _, dependency := range entry.DependsOn {
+			if dependency == entry.StackID {
+				return nil, fmt.Errorf("the stack %d depends on itself", entry.StackID)
+			}
+
+			if !known[dependency] {
+				return nil, fmt.Errorf("the stack %d depends on the stack %d which is not in the bundle", entry.StackID, dependency)
+			}
+		}
+	}
+
+	ordered := make([]portainer.StackBundleEntry, 0, len(entries))
+	placed := make(map[portainer.StackID]bool, len(entries))
+
+	for len(ordered) < len(entries) {
+		progressed := false
+
+		for _, entry := range entries {
+			if placed[entry.StackID] || !dependenciesPlaced(entry, placed) {
+				continue
+			}
+
+			ordered = append(ordered, entry)
+			placed[entry.StackID] = true
+			progressed = true
+		}
+
+		if !progressed {
+			cycle := []string{}
+			for _, entry := range entries {
+				if !placed[entry.StackID] {
+					cycle = append(cycle, fmt.Sprint(entry.StackID))
+				}
+			}
+
+			return nil, fmt.Errorf("the dependencies of the stacks %s form a cycle", strings.Join(cycle, ", "))
+		}
+	}
+
+	return ordered, nil
+}
+
+func dependenciesPlaced(entry portainer.StackBundleEntry, placed map[portainer.StackID]bool) bool {
+	for _, dependency := range entry.DependsOn {
+		if !placed[dependency] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Validate checks a bundle before it is saved: its stacks are Docker stacks of its environment, and their
+// dependencies can be ordered
+func Validate(dataStore dataservices.DataStore, bundle *portainer.StackBundle) error {
+	if strings.TrimSpace(bundle.Name) == "" {
+		return errors.New("the name of the bundle is required")
+	}
+
+	if len(bundle.Stacks) == 0 {
+		return errors.New("a bundle requires at least one stack")
+	}
+
+	if _, err := dataStore.Endpoint().Endpoint(bundle.EndpointID); err != nil {
+		return fmt.Errorf("unable to find the environment %d: %w", bundle.EndpointID, err)
+	}
+
+	for _, entry := range bundle.Stacks {
+		stack, err := dataStore.Stack().Read(entry.StackID)
+		if err != nil {
+			return fmt.Errorf("unable to find the stack %d: %w", entry.StackID, err)
+		}
+
+		if stack.EndpointID != bundle.EndpointID {
+			return fmt.Errorf("the stack %s is not deployed on the environment of the bundle", stack.Name)
+		}
+
+		if stack.Type != portainer.DockerComposeStack && stack.Type != portainer.DockerSwarmStack {
+			return fmt.Errorf("the stack %s is not a Docker stack", stack.Name)
+		}
+
+		if entry.HealthTimeout < 0 {
+			return fmt.Errorf("the health timeout of the stack %s cannot be negative", stack.Name)
+		}
+	}
+
+	_, err := Order(bundle.Stacks)
+
+	return err
+}
+
+// healthTimeout returns the time waited for the containers of a stack to be healthy
+func healthTimeout(entry portainer.StackBundleEntry) time.Duration {
+	if entry.HealthTimeout <= 0 {
+		return defaultHealthTimeout
+	}
+
+	return time.Duration(entry.HealthTimeout) * time.Second
+}
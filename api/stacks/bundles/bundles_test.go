@@ -0,0 +1,148 @@
+package bundles
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func stackIDs(entries []portainer.StackBundleEntry) []portainer.StackID {
+	ids := make([]portainer.StackID, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.StackID)
+	}
+
+	return ids
+}
+
+func TestOrder(t *testing.T) {
+	is := assert.New(t)
+
+	ordered, err := Order([]portainer.StackBundleEntry{
+		{StackID: 1, DependsOn: []portainer.StackID{3}},
+		{StackID: 2},
+		{StackID: 3, DependsOn: []portainer.StackID{2}},
+		{StackID: 4},
+	})
+	is.NoError(err)
+	is.Equal([]portainer.StackID{2, 3, 4, 1}, stackIDs(ordered))
+
+	_, err = Order([]portainer.StackBundleEntry{
+		{StackID: 1, DependsOn: []portainer.StackID{2}},
+		{StackID: 2, DependsOn: []portainer.StackID{1}},
+		{StackID: 3},
+	})
+	is.ErrorContains(err, "the dependencies of the stacks 1, 2 form a cycle")
+
+	_, err = Order([]portainer.StackBundleEntry{{StackID: 1, DependsOn: []portainer.StackID{5}}})
+	is.ErrorContains(err, "not in the bundle")
+
+	_, err = Order([]portainer.StackBundleEntry{{StackID: 1, DependsOn: []portainer.StackID{1}}})
+	is.ErrorContains(err, "depends on itself")
+
+	_, err = Order([]portainer.StackBundleEntry{{StackID: 1}, {StackID: 1}})
+	is.ErrorContains(err, "more than once")
+}
+
+func TestContainersHealthy(t *testing.T) {
+	is := assert.New(t)
+
+	is.False(containersHealthy(nil))
+	is.True(containersHealthy([]dockertypes.Container{
+		{State: "running", Status: "Up 2 minutes (healthy)"},
+		{State: "running", Status: "Up 2 minutes"},
+		{State: "exited", Labels: map[string]string{"com.docker.compose.oneoff": "True"}},
+	}))
+	is.False(containersHealthy([]dockertypes.Container{{State: "running", Status: "Up 5 seconds (health: starting)"}}))
+	is.False(containersHealthy([]dockertypes.Container{{State: "running", Status: "Up 2 minutes (unhealthy)"}}))
+	is.False(containersHealthy([]dockertypes.Container{{State: "restarting"}}))
+}
+
+func TestService_UpAndDown(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Type: portainer.DockerEnvironment}))
+	is.NoError(store.User().Create(&portainer.User{ID: 1, Username: "admin", Role: portainer.AdministratorRole}))
+
+	for i, name := range []string{"database", "backend", "frontend"} {
+		is.NoError(store.Stack().Create(&portainer.Stack{ID: portainer.StackID(i + 1), Name: name, Type: portainer.DockerComposeStack, EndpointID: 1, Status: portainer.StackStatusInactive}))
+	}
+
+	bundle := &portainer.StackBundle{
+		Name:       "shop",
+		EndpointID: 1,
+		Stacks: []portainer.StackBundleEntry{
+			{StackID: 3, DependsOn: []portainer.StackID{2}},
+			{StackID: 2, DependsOn: []portainer.StackID{1}, HealthTimeout: 1},
+			{StackID: 1},
+		},
+	}
+	is.NoError(store.StackBundle().Create(bundle))
+
+	var calls []string
+	unhealthy := ""
+
+	service := &Service{
+		dataStore: store,
+		start: func(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry) error {
+			calls = append(calls, "start "+stack.Name)
+			return nil
+		},
+		stop: func(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint) error {
+			calls = append(calls, "stop "+stack.Name)
+			if stack.Name == "backend" {
+				return errors.New("environment unreachable")
+			}
+
+			return nil
+		},
+		healthy: func(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint) (bool, error) {
+			calls = append(calls, "health "+stack.Name)
+			return stack.Name != unhealthy, nil
+		},
+		pollInterval: 10 * time.Millisecond,
+	}
+
+	is.NoError(service.Up(context.Background(), bundle.ID, 1))
+	is.Equal([]string{"start database", "health database", "start backend", "health backend", "start frontend", "health frontend"}, calls)
+
+	saved, err := store.StackBundle().Read(bundle.ID)
+	is.NoError(err)
+	is.Equal(portainer.StackBundleUp, saved.Status)
+
+	stack, err := store.Stack().Read(3)
+	is.NoError(err)
+	is.Equal(portainer.StackStatusActive, stack.Status)
+
+	calls = nil
+	err = service.Down(context.Background(), bundle.ID)
+	is.ErrorContains(err, "failed to tear down the stack backend")
+	is.Equal([]string{"stop frontend", "stop backend", "stop database"}, calls, "the teardown should go on after a failure")
+
+	saved, err = store.StackBundle().Read(bundle.ID)
+	is.NoError(err)
+	is.Equal(portainer.StackBundleFailed, saved.Status)
+	is.Contains(saved.Error, "environment unreachable")
+
+	stack, err = store.Stack().Read(1)
+	is.NoError(err)
+	is.Equal(portainer.StackStatusInactive, stack.Status)
+
+	calls = nil
+	unhealthy = "backend"
+	err = service.Up(context.Background(), bundle.ID, 1)
+	is.ErrorContains(err, "the stack backend is not healthy after 1s")
+	is.NotContains(calls, "start frontend", "the dependents of an unhealthy stack should not be brought up")
+
+	saved, err = store.StackBundle().Read(bundle.ID)
+	is.NoError(err)
+	is.Equal(portainer.StackBundleFailed, saved.Status)
+}
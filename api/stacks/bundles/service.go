@@ -0,0 +1,349 @@
+package bundles
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/docker"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/jobs"
+	"github.com/portainer/portainer/api/scheduler"
+	"github.com/portainer/portainer/api/stacks/deployments"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// UpJobType is the type of the background jobs bringing up the stacks of a bundle
+	UpJobType = "stack.bundle.up"
+	// DownJobType is the type of the background jobs tearing down the stacks of a bundle
+	DownJobType = "stack.bundle.down"
+
+	healthPollInterval = 2 * time.Second
+)
+
+// JobPayload represents the bundle brought up or torn down by a background job
+type JobPayload struct {
+	BundleID portainer.StackBundleID
+}
+
+// Service brings up and tears down the stacks of the bundles
+type Service struct {
+	dataStore dataservices.DataStore
+	start     func(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry) error
+	stop      func(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint) error
+	healthy   func(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint) (bool, error)
+
+	pollInterval time.Duration
+}
+
+// NewService creates a new instance of the bundle service
+func NewService(dataStore dataservices.DataStore, deployer deployments.StackDeployer, composeStackManager portainer.ComposeStackManager, swarmStackManager portainer.SwarmStackManager, clientFactory *dockerclient.ClientFactory) *Service {
+	return &Service{
+		dataStore: dataStore,
+		start: func(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry) error {
+			switch stack.Type {
+			case portainer.DockerComposeStack:
+				stack.Name = composeStackManager.NormalizeStackName(stack.Name)
+
+				if stackutils.IsRelativePathStack(stack) {
+					return deployer.StartRemoteComposeStack(stack, endpoint, registries)
+				}
+
+				return composeStackManager.Up(ctx, stack, endpoint, false)
+			case portainer.DockerSwarmStack:
+				stack.Name = swarmStackManager.NormalizeStackName(stack.Name)
+
+				if stackutils.IsRelativePathStack(stack) {
+					return deployer.StartRemoteSwarmStack(stack, endpoint, registries)
+				}
+
+				return deployer.DeploySwarmStack(stack, endpoint, registries, true, true)
+			}
+
+			return fmt.Errorf("unsupported stack type: %v", stack.Type)
+		},
+		stop: func(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint) error {
+			switch stack.Type {
+			case portainer.DockerComposeStack:
+				stack.Name = composeStackManager.NormalizeStackName(stack.Name)
+
+				if stackutils.IsRelativePathStack(stack) {
+					return deployer.StopRemoteComposeStack(stack, endpoint)
+				}
+
+				return composeStackManager.Down(ctx, stack, endpoint)
+			case portainer.DockerSwarmStack:
+				stack.Name = swarmStackManager.NormalizeStackName(stack.Name)
+
+				if stackutils.IsRelativePathStack(stack) {
+					return deployer.StopRemoteSwarmStack(stack, endpoint)
+				}
+
+				return swarmStackManager.Remove(stack, endpoint)
+			}
+
+			return fmt.Errorf("unsupported stack type: %v", stack.Type)
+		},
+		healthy: func(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint) (bool, error) {
+			cli, err := clientFactory.CreateClient(endpoint, "", nil)
+			if err != nil {
+				return false, err
+			}
+			defer cli.Close()
+
+			filter := filters.NewArgs(filters.Arg("label", docker.StackLabel(stack)+"="+stack.Name))
+
+			if stack.Type == portainer.DockerSwarmStack {
+				services, err := cli.ServiceList(ctx, dockertypes.ServiceListOptions{Filters: filter, Status: true})
+				if err != nil {
+					return false, err
+				}
+
+				return servicesHealthy(services), nil
+			}
+
+			containers, err := cli.ContainerList(ctx, dockertypes.ContainerListOptions{All: true, Filters: filter})
+			if err != nil {
+				return false, err
+			}
+
+			return containersHealthy(containers), nil
+		},
+		pollInterval: healthPollInterval,
+	}
+}
+
+// RegisterJobs registers the handlers of the bundle jobs in the job queue. A failed bring-up or teardown is not
+// retried, as its stacks are left as they were for the users to fix them.
+func RegisterJobs(queue *jobs.Queue, service *Service) {
+	options := jobs.Options{MaxAttempts: 1}
+
+	queue.Register(UpJobType, options, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		var payload JobPayload
+		if err := jobs.DecodePayload(job, &payload); err != nil {
+			return scheduler.NewPermanentError(err)
+		}
+
+		return service.Up(ctx, payload.BundleID, job.CreatedBy)
+	})
+
+	queue.Register(DownJobType, options, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		var payload JobPayload
+		if err := jobs.DecodePayload(job, &payload); err != nil {
+			return scheduler.NewPermanentError(err)
+		}
+
+		return service.Down(ctx, payload.BundleID)
+	})
+}
+
+// Up brings up the stacks of a bundle in the order of their dependencies with the registries of the user, and waits
+// for the containers of each stack to be healthy before bringing up its dependents
+func (service *Service) Up(ctx context.Context, bundleID portainer.StackBundleID, userID portainer.UserID) error {
+	bundle, endpoint, ordered, err := service.load(bundleID)
+	if err != nil {
+		return err
+	}
+
+	user, err := service.dataStore.User().Read(userID)
+	if err != nil {
+		return service.fail(bundle.ID, scheduler.NewPermanentError(pkgerrors.WithMessagef(err, "failed to find the user %d", userID)))
+	}
+
+	registries, err := deployments.UserRegistries(service.dataStore, user, endpoint.ID)
+	if err != nil {
+		return service.fail(bundle.ID, err)
+	}
+
+	service.setStatus(bundle.ID, portainer.StackBundleStarting, "")
+
+	for i, entry := range ordered {
+		stack, err := service.dataStore.Stack().Read(entry.StackID)
+		if err != nil {
+			return service.fail(bundle.ID, pkgerrors.WithMessagef(err, "failed to find the stack %d", entry.StackID))
+		}
+
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "start", Message: "Bringing up the stack " + stack.Name, Current: int64(i), Total: int64(len(ordered))})
+
+		if err := service.start(ctx, stack, endpoint, registries); err != nil {
+			return service.fail(bundle.ID, pkgerrors.WithMessagef(err, "failed to bring up the stack %s", stack.Name))
+		}
+
+		stack.Status = portainer.StackStatusActive
+		if err := service.dataStore.Stack().Update(stack.ID, stack); err != nil {
+			return service.fail(bundle.ID, pkgerrors.WithMessagef(err, "failed to update the stack %s", stack.Name))
+		}
+
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "health", Message: "Waiting for the stack " + stack.Name + " to be healthy", Current: int64(i), Total: int64(len(ordered))})
+
+		if err := service.waitHealthy(ctx, stack, endpoint, healthTimeout(entry)); err != nil {
+			return service.fail(bundle.ID, err)
+		}
+	}
+
+	service.setStatus(bundle.ID, portainer.StackBundleUp, "")
+
+	return nil
+}
+
+// Down tears down the stacks of a bundle in the reverse order of their dependencies. The teardown goes on when a stack
+// cannot be torn down, the bundle being marked as failed at the end.
+func (service *Service) Down(ctx context.Context, bundleID portainer.StackBundleID) error {
+	bundle, endpoint, ordered, err := service.load(bundleID)
+	if err != nil {
+		return err
+	}
+
+	service.setStatus(bundle.ID, portainer.StackBundleStopping, "")
+
+	var failures []string
+
+	for i := len(ordered) - 1; i >= 0; i-- {
+		stack, err := service.dataStore.Stack().Read(ordered[i].StackID)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("failed to find the stack %d: %s", ordered[i].StackID, err))
+			continue
+		}
+
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "stop", Message: "Tearing down the stack " + stack.Name, Current: int64(len(ordered) - 1 - i), Total: int64(len(ordered))})
+
+		if err := service.stop(ctx, stack, endpoint); err != nil {
+			failures = append(failures, fmt.Sprintf("failed to tear down the stack %s: %s", stack.Name, err))
+			continue
+		}
+
+		stack.Status = portainer.StackStatusInactive
+		if err := service.dataStore.Stack().Update(stack.ID, stack); err != nil {
+			failures = append(failures, fmt.Sprintf("failed to update the stack %s: %s", stack.Name, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return service.fail(bundle.ID, scheduler.NewPermanentError(pkgerrors.New(strings.Join(failures, "; "))))
+	}
+
+	service.setStatus(bundle.ID, portainer.StackBundleDown, "")
+
+	return nil
+}
+
+// load returns a bundle along with its environment and its stacks in the order they are brought up
+func (service *Service) load(bundleID portainer.StackBundleID) (*portainer.StackBundle, *portainer.Endpoint, []portainer.StackBundleEntry, error) {
+	bundle, err := service.dataStore.StackBundle().Read(bundleID)
+	if err != nil {
+		return nil, nil, nil, scheduler.NewPermanentError(pkgerrors.WithMessagef(err, "failed to find the stack bundle %d", bundleID))
+	}
+
+	endpoint, err := service.dataStore.Endpoint().Endpoint(bundle.EndpointID)
+	if err != nil {
+		return nil, nil, nil, service.fail(bundle.ID, scheduler.NewPermanentError(pkgerrors.WithMessagef(err, "failed to find the environment %d", bundle.EndpointID)))
+	}
+
+	ordered, err := Order(bundle.Stacks)
+	if err != nil {
+		return nil, nil, nil, service.fail(bundle.ID, scheduler.NewPermanentError(err))
+	}
+
+	return bundle, endpoint, ordered, nil
+}
+
+// waitHealthy waits for the containers of a stack to be running and healthy
+func (service *Service) waitHealthy(ctx context.Context, stack *portainer.Stack, endpoint *portainer.Endpoint, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(service.pollInterval)
+	defer ticker.Stop()
+
+	var lastErr error
+
+	for {
+		healthy, err := service.healthy(ctx, stack, endpoint)
+		if err == nil && healthy {
+			return nil
+		}
+
+		if err != nil {
+			lastErr = err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if lastErr != nil {
+				return pkgerrors.WithMessagef(lastErr, "the stack %s is not healthy after %s", stack.Name, timeout)
+			}
+
+			return fmt.Errorf("the stack %s is not healthy after %s", stack.Name, timeout)
+		}
+	}
+}
+
+// fail marks the bundle as failed with the error, which is returned
+func (service *Service) fail(bundleID portainer.StackBundleID, err error) error {
+	service.setStatus(bundleID, portainer.StackBundleFailed, err.Error())
+
+	return err
+}
+
+func (service *Service) setStatus(bundleID portainer.StackBundleID, status portainer.StackBundleStatus, message string) {
+	bundle, err := service.dataStore.StackBundle().Read(bundleID)
+	if err != nil {
+		log.Warn().Err(err).Int("bundle_id", int(bundleID)).Msg("unable to update the status of the stack bundle")
+		return
+	}
+
+	bundle.Status = status
+	bundle.Error = message
+
+	if err := service.dataStore.StackBundle().Update(bundle.ID, bundle); err != nil {
+		log.Warn().Err(err).Int("bundle_id", int(bundleID)).Msg("unable to update the status of the stack bundle")
+	}
+}
+
+// containersHealthy returns whether the containers of a compose stack are all running, and healthy when they have a
+// health check. The health is read from the status of the containers, such as "Up 2 minutes (healthy)".
+func containersHealthy(containers []dockertypes.Container) bool {
+	running := 0
+
+	for _, container := range containers {
+		// the one-off containers of docker compose run are not services of the stack
+		if strings.EqualFold(container.Labels["com.docker.compose.oneoff"], "true") {
+			continue
+		}
+
+		if container.State != "running" || strings.Contains(container.Status, "(health: starting)") || strings.Contains(container.Status, "(unhealthy)") {
+			return false
+		}
+
+		running++
+	}
+
+	return running > 0
+}
+
+// servicesHealthy returns whether the services of a swarm stack run all their tasks, the health checks of the
+// containers being taken into account by swarm to report the tasks as running
+func servicesHealthy(services []swarm.Service) bool {
+	if len(services) == 0 {
+		return false
+	}
+
+	for _, service := range services {
+		if service.ServiceStatus == nil || service.ServiceStatus.RunningTasks < service.ServiceStatus.DesiredTasks {
+			return false
+		}
+	}
+
+	return true
+}
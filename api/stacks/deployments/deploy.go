@@ -2,12 +2,15 @@ package deployments
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/filesystem"
 	"github.com/portainer/portainer/api/git/update"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/maintenance"
 	"github.com/portainer/portainer/api/scheduler"
 	"github.com/portainer/portainer/api/stacks/stackutils"
 
@@ -24,6 +27,13 @@ func (e *StackAuthorMissingErr) Error() string {
 	return fmt.Sprintf("stack's %v author %s is missing", e.stackID, e.authorName)
 }
 
+// localStackFiles reads the stack files from the local file system
+type localStackFiles struct{}
+
+func (localStackFiles) GetFileContent(trustedRoot, filePath string) ([]byte, error) {
+	return os.ReadFile(filesystem.JoinPaths(trustedRoot, filePath))
+}
+
 // RedeployWhenChanged pull and redeploy the stack when git repo changed
 // Stack will always be redeployed if force deployment is set to true
 func RedeployWhenChanged(stackID portainer.StackID, deployer StackDeployer, datastore dataservices.DataStore, gitService portainer.GitService) error {
@@ -40,9 +50,79 @@ func RedeployWhenChanged(stackID portainer.StackID, deployer StackDeployer, data
 		return nil // do nothing if it isn't a git-based stack
 	}
 
+	endpoint, user, err := stackEndpointAndAuthor(stack, datastore)
+	if err != nil {
+		return err
+	}
+
+	var gitCommitChangedOrForceUpdate bool
+	if !stack.FromAppTemplate {
+		updated, newHash, err := update.UpdateGitObject(gitService, fmt.Sprintf("stack:%d", stackID), stack.GitConfig, false, false, stack.ProjectPath)
+		if err != nil {
+			return err
+		}
+
+		if updated {
+			stack.GitConfig.ConfigHash = newHash
+			stack.UpdateDate = time.Now().Unix()
+			gitCommitChangedOrForceUpdate = updated
+		}
+	}
+
+	if !gitCommitChangedOrForceUpdate {
+		return nil
+	}
+
+	return redeploy(stack, endpoint, user, deployer, datastore)
+}
+
+// autoRedeploy redeploys the stack when its git repository changed, only inside the maintenance windows of its
+// environment as the automatic updates are not requested by a user
+func autoRedeploy(stackID portainer.StackID, deployer StackDeployer, datastore dataservices.DataStore, gitService portainer.GitService) error {
+	stack, err := datastore.Stack().Read(stackID)
+	if err == nil {
+		allowed, err := maintenance.Allowed(datastore, stack.EndpointID, time.Now())
+		if err != nil {
+			return errors.WithMessagef(err, "failed to check the maintenance windows of the stack %v", stackID)
+		}
+
+		if !allowed {
+			log.Debug().Int("stack_id", int(stackID)).Msg("outside of the maintenance windows of the environment, skipping the stack auto update")
+			return nil
+		}
+	}
+
+	return RedeployWhenChanged(stackID, deployer, datastore, gitService)
+}
+
+// RedeployWithLatestImages pulls the latest images of a Docker stack and redeploys it
+func RedeployWithLatestImages(stackID portainer.StackID, deployer StackDeployer, datastore dataservices.DataStore) error {
+	log.Debug().Int("stack_id", int(stackID)).Msg("redeploying stack with the latest images")
+
+	stack, err := datastore.Stack().Read(stackID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed to get the stack %v", stackID)
+	}
+
+	if stack.Type != portainer.DockerComposeStack && stack.Type != portainer.DockerSwarmStack {
+		return errors.Errorf("cannot update the images of the stack %v, type %v is unsupported", stackID, stack.Type)
+	}
+
+	endpoint, user, err := stackEndpointAndAuthor(stack, datastore)
+	if err != nil {
+		return err
+	}
+
+	stack.UpdateDate = time.Now().Unix()
+
+	return redeploy(stack, endpoint, user, deployer, datastore)
+}
+
+// stackEndpointAndAuthor returns the environment of the stack and the user who last deployed it
+func stackEndpointAndAuthor(stack *portainer.Stack, datastore dataservices.DataStore) (*portainer.Endpoint, *portainer.User, error) {
 	endpoint, err := datastore.Endpoint().Endpoint(stack.EndpointID)
 	if dataservices.IsErrObjectNotFound(err) {
-		return scheduler.NewPermanentError(
+		return nil, nil, scheduler.NewPermanentError(
 			errors.WithMessagef(err,
 				"failed to find the environment %v associated to the stack %v",
 				stack.EndpointID,
@@ -50,7 +130,7 @@ func RedeployWhenChanged(stackID portainer.StackID, deployer StackDeployer, data
 			),
 		)
 	} else if err != nil {
-		return errors.WithMessagef(err, "failed to find the environment %v associated to the stack %v", stack.EndpointID, stack.ID)
+		return nil, nil, errors.WithMessagef(err, "failed to find the environment %v associated to the stack %v", stack.EndpointID, stack.ID)
 	}
 
 	author := stack.UpdatedBy
@@ -61,40 +141,41 @@ func RedeployWhenChanged(stackID portainer.StackID, deployer StackDeployer, data
 	user, err := datastore.User().UserByUsername(author)
 	if err != nil {
 		log.Warn().
-			Int("stack_id", int(stackID)).
+			Int("stack_id", int(stack.ID)).
 			Str("author", author).
 			Str("stack", stack.Name).
 			Int("endpoint_id", int(stack.EndpointID)).
 			Msg("cannot auto update a stack, stack author user is missing")
 
-		return &StackAuthorMissingErr{int(stack.ID), author}
+		return nil, nil, &StackAuthorMissingErr{int(stack.ID), author}
 	}
 
-	var gitCommitChangedOrForceUpdate bool
-	if !stack.FromAppTemplate {
-		updated, newHash, err := update.UpdateGitObject(gitService, fmt.Sprintf("stack:%d", stackID), stack.GitConfig, false, false, stack.ProjectPath)
-		if err != nil {
-			return err
-		}
-
-		if updated {
-			stack.GitConfig.ConfigHash = newHash
-			stack.UpdateDate = time.Now().Unix()
-			gitCommitChangedOrForceUpdate = updated
-		}
-	}
+	return endpoint, user, nil
+}
 
-	if !gitCommitChangedOrForceUpdate {
-		return nil
-	}
+// redeploy pulls the images of the stack and deploys it again
+func redeploy(stack *portainer.Stack, endpoint *portainer.Endpoint, user *portainer.User, deployer StackDeployer, datastore dataservices.DataStore) error {
+	stackID := stack.ID
 
-	registries, err := getUserRegistries(datastore, user, endpoint.ID)
+	registries, err := UserRegistries(datastore, user, endpoint.ID)
 	if dataservices.IsErrObjectNotFound(err) {
 		return scheduler.NewPermanentError(err)
 	} else if err != nil {
 		return err
 	}
 
+	if stack.Type == portainer.DockerComposeStack || stack.Type == portainer.DockerSwarmStack {
+		settings, err := datastore.Settings().Settings()
+		if err != nil {
+			return errors.WithMessage(err, "failed to retrieve the settings")
+		}
+
+		err = stackutils.ValidateStackPolicy(stack, settings.StackPolicy, localStackFiles{})
+		if err != nil {
+			return errors.WithMessagef(err, "failed to validate the stack %v", stackID)
+		}
+	}
+
 	switch stack.Type {
 	case portainer.DockerComposeStack:
 
@@ -136,7 +217,8 @@ func RedeployWhenChanged(stackID portainer.StackID, deployer StackDeployer, data
 	return nil
 }
 
-func getUserRegistries(datastore dataservices.DataStore, user *portainer.User, endpointID portainer.EndpointID) ([]portainer.Registry, error) {
+// UserRegistries returns the registries of an environment(endpoint) the user can pull from
+func UserRegistries(datastore dataservices.DataStore, user *portainer.User, endpointID portainer.EndpointID) ([]portainer.Registry, error) {
 	registries, err := datastore.Registry().ReadAll()
 	if err != nil {
 		return nil, errors.WithMessage(err, "unable to retrieve registries from the database")
@@ -2,6 +2,8 @@ package deployments
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -9,6 +11,7 @@ import (
 	"github.com/portainer/portainer/api/datastore"
 	gittypes "github.com/portainer/portainer/api/git/types"
 	"github.com/portainer/portainer/api/internal/testhelpers"
+	"github.com/portainer/portainer/api/stacks/stackutils"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -145,10 +148,14 @@ func Test_redeployWhenChanged(t *testing.T) {
 	err = store.User().Create(&portainer.User{Username: username, Role: portainer.AdministratorRole})
 	assert.NoError(t, err, "error creating a user")
 
+	err = os.WriteFile(filepath.Join(tmpDir, "docker-compose.yml"), []byte("services:\n  web:\n    image: nginx:1.25\n"), 0644)
+	assert.NoError(t, err, "error writing the stack file")
+
 	stack := portainer.Stack{
 		ID:          1,
 		EndpointID:  1,
 		ProjectPath: tmpDir,
+		EntryPoint:  "docker-compose.yml",
 		UpdatedBy:   username,
 		GitConfig: &gittypes.RepoConfig{
 			URL:           "url",
@@ -183,9 +190,27 @@ func Test_redeployWhenChanged(t *testing.T) {
 		err = RedeployWhenChanged(1, &noopDeployer{}, store, testhelpers.NewGitService(nil, "newHash"))
 		assert.NoError(t, err)
 	})
+
+	t.Run("does not deploy a stack breaking the stack policy", func(t *testing.T) {
+		stack.Type = portainer.DockerComposeStack
+		store.Stack().Update(stack.ID, &stack)
+
+		settings, err := store.Settings().Settings()
+		assert.NoError(t, err)
+		settings.StackPolicy.LatestImageTag = portainer.StackPolicyBlock
+		err = store.Settings().UpdateSettings(settings)
+		assert.NoError(t, err)
+
+		err = os.WriteFile(filepath.Join(tmpDir, "docker-compose.yml"), []byte("services:\n  web:\n    image: nginx\n"), 0644)
+		assert.NoError(t, err)
+
+		err = RedeployWhenChanged(1, &noopDeployer{}, store, testhelpers.NewGitService(nil, "newHash"))
+		var lintErr *stackutils.StackLintError
+		assert.ErrorAs(t, err, &lintErr)
+	})
 }
 
-func Test_getUserRegistries(t *testing.T) {
+func TestUserRegistries(t *testing.T) {
 	_, store := datastore.MustNewTestStore(t, true, true)
 
 	endpointID := 123
@@ -250,13 +275,13 @@ func Test_getUserRegistries(t *testing.T) {
 	assert.NoError(t, err, "couldn't create a registry")
 
 	t.Run("admin should has access to all registries", func(t *testing.T) {
-		registries, err := getUserRegistries(store, admin, portainer.EndpointID(endpointID))
+		registries, err := UserRegistries(store, admin, portainer.EndpointID(endpointID))
 		assert.NoError(t, err)
 		assert.ElementsMatch(t, []portainer.Registry{registryReachableByUser, registryReachableByTeam, registryRestricted}, registries)
 	})
 
 	t.Run("regular user has access to registries allowed to him and/or his team", func(t *testing.T) {
-		registries, err := getUserRegistries(store, user, portainer.EndpointID(endpointID))
+		registries, err := UserRegistries(store, user, portainer.EndpointID(endpointID))
 		assert.NoError(t, err)
 		assert.ElementsMatch(t, []portainer.Registry{registryReachableByUser, registryReachableByTeam}, registries)
 	})
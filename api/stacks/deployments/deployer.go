@@ -7,9 +7,12 @@ import (
 	"github.com/pkg/errors"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/contenttrust"
 	"github.com/portainer/portainer/api/dataservices"
 	dockerclient "github.com/portainer/portainer/api/docker/client"
 	k "github.com/portainer/portainer/api/kubernetes"
+	"github.com/portainer/portainer/api/secrets"
+	"github.com/portainer/portainer/api/stacks/stackutils"
 )
 
 type BaseStackDeployer interface {
@@ -30,11 +33,12 @@ type stackDeployer struct {
 	kubernetesDeployer  portainer.KubernetesDeployer
 	ClientFactory       *dockerclient.ClientFactory
 	dataStore           dataservices.DataStore
+	secretsService      *secrets.Service
 }
 
 // NewStackDeployer inits a stackDeployer struct with a SwarmStackManager, a ComposeStackManager and a KubernetesDeployer
 func NewStackDeployer(swarmStackManager portainer.SwarmStackManager, composeStackManager portainer.ComposeStackManager,
-	kubernetesDeployer portainer.KubernetesDeployer, clientFactory *dockerclient.ClientFactory, dataStore dataservices.DataStore) *stackDeployer {
+	kubernetesDeployer portainer.KubernetesDeployer, clientFactory *dockerclient.ClientFactory, dataStore dataservices.DataStore, secretsService *secrets.Service) *stackDeployer {
 	return &stackDeployer{
 		lock:                &sync.Mutex{},
 		swarmStackManager:   swarmStackManager,
@@ -42,9 +46,15 @@ func NewStackDeployer(swarmStackManager portainer.SwarmStackManager, composeStac
 		kubernetesDeployer:  kubernetesDeployer,
 		ClientFactory:       clientFactory,
 		dataStore:           dataStore,
+		secretsService:      secretsService,
 	}
 }
+
 func (d *stackDeployer) DeploySwarmStack(stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry, prune bool, pullImage bool) error {
+	if err := d.verifyStackFiles(stack); err != nil {
+		return err
+	}
+
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
@@ -55,6 +65,10 @@ func (d *stackDeployer) DeploySwarmStack(stack *portainer.Stack, endpoint *porta
 }
 
 func (d *stackDeployer) DeployComposeStack(stack *portainer.Stack, endpoint *portainer.Endpoint, registries []portainer.Registry, forcePullImage bool, forceRecreate bool) error {
+	if err := d.verifyStackFiles(stack); err != nil {
+		return err
+	}
+
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
@@ -77,6 +91,10 @@ func (d *stackDeployer) DeployComposeStack(stack *portainer.Stack, endpoint *por
 }
 
 func (d *stackDeployer) DeployKubernetesStack(stack *portainer.Stack, endpoint *portainer.Endpoint, user *portainer.User) error {
+	if err := d.verifyStackFiles(stack); err != nil {
+		return err
+	}
+
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
@@ -104,3 +122,24 @@ func (d *stackDeployer) DeployKubernetesStack(stack *portainer.Stack, endpoint *
 
 	return nil
 }
+
+// verifyStackFiles checks the signatures of the files of a stack deployed from a git repository, according to the
+// content trust settings
+func (d *stackDeployer) verifyStackFiles(stack *portainer.Stack) error {
+	if stack.GitConfig == nil || d.dataStore == nil {
+		return nil
+	}
+
+	settings, err := d.dataStore.Settings().Settings()
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve the settings")
+	}
+
+	for _, file := range stackutils.GetStackFilePaths(stack, false) {
+		if err := contenttrust.VerifyFile(settings.ContentTrust, stack.ProjectPath, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
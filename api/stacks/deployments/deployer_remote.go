@@ -50,6 +50,10 @@ func (d *stackDeployer) DeployRemoteComposeStack(
 	forcePullImage bool,
 	forceRecreate bool,
 ) error {
+	if err := d.verifyStackFiles(stack); err != nil {
+		return err
+	}
+
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
@@ -111,6 +115,10 @@ func (d *stackDeployer) DeployRemoteSwarmStack(
 	prune bool,
 	pullImage bool,
 ) error {
+	if err := d.verifyStackFiles(stack); err != nil {
+		return err
+	}
+
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
@@ -184,6 +192,17 @@ func (d *stackDeployer) remoteStack(stack *portainer.Stack, endpoint *portainer.
 	}
 	targetSocketBind := getTargetSocketBind(info.OSType)
 
+	if d.secretsService != nil {
+		env, err := d.secretsService.ResolveEnvValues(ctx, stack.Env)
+		if err != nil {
+			return errors.WithMessage(err, "unable to resolve the stack secrets")
+		}
+
+		resolvedStack := *stack
+		resolvedStack.Env = env
+		stack = &resolvedStack
+	}
+
 	composeDestination := filesystem.JoinPaths(stack.ProjectPath, composePathPrefix)
 
 	opts.composeDestination = composeDestination
@@ -8,19 +8,22 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/stacks/stackutils"
 )
 
 type ComposeStackDeploymentConfig struct {
-	stack          *portainer.Stack
-	endpoint       *portainer.Endpoint
-	registries     []portainer.Registry
-	isAdmin        bool
-	user           *portainer.User
-	forcePullImage bool
-	ForceCreate    bool
-	FileService    portainer.FileService
-	StackDeployer  StackDeployer
+	stack            *portainer.Stack
+	endpoint         *portainer.Endpoint
+	securitySettings *portainer.EndpointSecuritySettings
+	stackPolicy      portainer.StackPolicySettings
+	registries       []portainer.Registry
+	isAdmin          bool
+	user             *portainer.User
+	forcePullImage   bool
+	ForceCreate      bool
+	FileService      portainer.FileService
+	StackDeployer    StackDeployer
 }
 
 func CreateComposeStackDeploymentConfig(securityContext *security.RestrictedRequestContext, stack *portainer.Stack, endpoint *portainer.Endpoint, dataStore dataservices.DataStore, fileService portainer.FileService, deployer StackDeployer, forcePullImage, forceCreate bool) (*ComposeStackDeploymentConfig, error) {
@@ -36,16 +39,28 @@ func CreateComposeStackDeploymentConfig(securityContext *security.RestrictedRequ
 
 	filteredRegistries := security.FilterRegistries(registries, user, securityContext.UserMemberships, endpoint.ID)
 
+	securitySettings, err := endpointutils.EffectiveSecuritySettings(dataStore, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := dataStore.Settings().Settings()
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve the settings from the database: %w", err)
+	}
+
 	config := &ComposeStackDeploymentConfig{
-		stack:          stack,
-		endpoint:       endpoint,
-		registries:     filteredRegistries,
-		isAdmin:        securityContext.IsAdmin,
-		user:           user,
-		forcePullImage: forcePullImage,
-		ForceCreate:    forceCreate,
-		FileService:    fileService,
-		StackDeployer:  deployer,
+		stack:            stack,
+		endpoint:         endpoint,
+		securitySettings: securitySettings,
+		stackPolicy:      settings.StackPolicy,
+		registries:       filteredRegistries,
+		isAdmin:          securityContext.IsAdmin,
+		user:             user,
+		forcePullImage:   forcePullImage,
+		ForceCreate:      forceCreate,
+		FileService:      fileService,
+		StackDeployer:    deployer,
 	}
 
 	return config, nil
@@ -69,7 +84,7 @@ func (config *ComposeStackDeploymentConfig) Deploy() error {
 		return errors.Wrap(err, "failed to validate user admin privileges")
 	}
 
-	securitySettings := &config.endpoint.SecuritySettings
+	securitySettings := config.securitySettings
 
 	if (!securitySettings.AllowBindMountsForRegularUsers ||
 		!securitySettings.AllowPrivilegedModeForRegularUsers ||
@@ -84,6 +99,12 @@ func (config *ComposeStackDeploymentConfig) Deploy() error {
 			return err
 		}
 	}
+
+	err = stackutils.ValidateStackPolicy(config.stack, config.stackPolicy, config.FileService)
+	if err != nil {
+		return err
+	}
+
 	if stackutils.IsRelativePathStack(config.stack) {
 		return config.StackDeployer.DeployRemoteComposeStack(config.stack, config.endpoint, config.registries, config.forcePullImage, config.ForceCreate)
 	}
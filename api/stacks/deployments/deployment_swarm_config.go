@@ -8,19 +8,22 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/internal/endpointutils"
 	"github.com/portainer/portainer/api/stacks/stackutils"
 )
 
 type SwarmStackDeploymentConfig struct {
-	stack         *portainer.Stack
-	endpoint      *portainer.Endpoint
-	registries    []portainer.Registry
-	prune         bool
-	isAdmin       bool
-	user          *portainer.User
-	pullImage     bool
-	FileService   portainer.FileService
-	StackDeployer StackDeployer
+	stack            *portainer.Stack
+	endpoint         *portainer.Endpoint
+	securitySettings *portainer.EndpointSecuritySettings
+	stackPolicy      portainer.StackPolicySettings
+	registries       []portainer.Registry
+	prune            bool
+	isAdmin          bool
+	user             *portainer.User
+	pullImage        bool
+	FileService      portainer.FileService
+	StackDeployer    StackDeployer
 }
 
 func CreateSwarmStackDeploymentConfig(securityContext *security.RestrictedRequestContext, stack *portainer.Stack, endpoint *portainer.Endpoint, dataStore dataservices.DataStore, fileService portainer.FileService, deployer StackDeployer, prune bool, pullImage bool) (*SwarmStackDeploymentConfig, error) {
@@ -36,16 +39,28 @@ func CreateSwarmStackDeploymentConfig(securityContext *security.RestrictedReques
 
 	filteredRegistries := security.FilterRegistries(registries, user, securityContext.UserMemberships, endpoint.ID)
 
+	securitySettings, err := endpointutils.EffectiveSecuritySettings(dataStore, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := dataStore.Settings().Settings()
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve the settings from the database: %w", err)
+	}
+
 	config := &SwarmStackDeploymentConfig{
-		stack:         stack,
-		endpoint:      endpoint,
-		registries:    filteredRegistries,
-		prune:         prune,
-		isAdmin:       securityContext.IsAdmin,
-		user:          user,
-		pullImage:     pullImage,
-		FileService:   fileService,
-		StackDeployer: deployer,
+		stack:            stack,
+		endpoint:         endpoint,
+		securitySettings: securitySettings,
+		stackPolicy:      settings.StackPolicy,
+		registries:       filteredRegistries,
+		prune:            prune,
+		isAdmin:          securityContext.IsAdmin,
+		user:             user,
+		pullImage:        pullImage,
+		FileService:      fileService,
+		StackDeployer:    deployer,
 	}
 
 	return config, nil
@@ -69,7 +84,7 @@ func (config *SwarmStackDeploymentConfig) Deploy() error {
 		return errors.Wrap(err, "failed to validate user admin privileges")
 	}
 
-	settings := &config.endpoint.SecuritySettings
+	settings := config.securitySettings
 
 	if !settings.AllowBindMountsForRegularUsers && !isAdminOrEndpointAdmin {
 		err = stackutils.ValidateStackFiles(config.stack, settings, config.FileService)
@@ -78,6 +93,11 @@ func (config *SwarmStackDeploymentConfig) Deploy() error {
 		}
 	}
 
+	err = stackutils.ValidateStackPolicy(config.stack, config.stackPolicy, config.FileService)
+	if err != nil {
+		return err
+	}
+
 	if stackutils.IsRelativePathStack(config.stack) {
 		return config.StackDeployer.DeployRemoteSwarmStack(config.stack, config.endpoint, config.registries, config.prune, config.pullImage)
 	}
@@ -0,0 +1,59 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/jobs"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/pkg/errors"
+)
+
+// AutoUpdateJobType is the type of the background jobs redeploying a stack when its git repository changed
+const AutoUpdateJobType = "stack.autoupdate"
+
+// AutoUpdateJobPayload represents the stack redeployed by a background job
+type AutoUpdateJobPayload struct {
+	StackID portainer.StackID
+}
+
+// RegisterJobs registers the handler of the stack auto update jobs in the job queue. The jobs are queued on each
+// interval of the auto updates, most of them finding no change, so they are only kept for a short time.
+func RegisterJobs(queue *jobs.Queue, deployer StackDeployer, datastore dataservices.DataStore, gitService portainer.GitService) {
+	options := jobs.Options{Concurrency: 2, Retention: time.Hour}
+
+	queue.Register(AutoUpdateJobType, options, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		var payload AutoUpdateJobPayload
+		if err := jobs.DecodePayload(job, &payload); err != nil {
+			return scheduler.NewPermanentError(err)
+		}
+
+		jobs.ReportProgress(ctx, jobs.Progress{Stage: "deploy", Message: "Redeploying the stack when its git repository changed"})
+
+		return autoRedeploy(payload.StackID, deployer, datastore, gitService)
+	})
+}
+
+// scheduledRedeploy returns the function run on each interval of the auto update of a stack. The redeployment is
+// queued as a background job, or run directly when there is no job queue.
+func scheduledRedeploy(stackID portainer.StackID, queue *jobs.Queue, deployer StackDeployer, datastore dataservices.DataStore, gitService portainer.GitService) func() error {
+	return func() error {
+		if queue == nil {
+			return autoRedeploy(stackID, deployer, datastore, gitService)
+		}
+
+		// stop the schedule of the removed stacks, as the redeployment would fail
+		_, err := datastore.Stack().Read(stackID)
+		if dataservices.IsErrObjectNotFound(err) {
+			return scheduler.NewPermanentError(errors.WithMessagef(err, "failed to get the stack %v", stackID))
+		}
+
+		_, err = queue.EnqueueOnce(AutoUpdateJobType, AutoUpdateJobPayload{StackID: stackID}, 0, fmt.Sprintf("Automatic update of the stack %d", stackID))
+
+		return err
+	}
+}
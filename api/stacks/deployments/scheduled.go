@@ -6,10 +6,11 @@ import (
 	"github.com/pkg/errors"
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/jobs"
 	"github.com/portainer/portainer/api/scheduler"
 )
 
-func StartStackSchedules(scheduler *scheduler.Scheduler, stackdeployer StackDeployer, datastore dataservices.DataStore, gitService portainer.GitService) error {
+func StartStackSchedules(scheduler *scheduler.Scheduler, jobQueue *jobs.Queue, stackdeployer StackDeployer, datastore dataservices.DataStore, gitService portainer.GitService) error {
 	stacks, err := datastore.Stack().RefreshableStacks()
 	if err != nil {
 		return errors.Wrap(err, "failed to fetch refreshable stacks")
@@ -21,9 +22,7 @@ func StartStackSchedules(scheduler *scheduler.Scheduler, stackdeployer StackDepl
 			return errors.Wrap(err, "Unable to parse auto update interval")
 		}
 		stackID := stack.ID // to be captured by the scheduled function
-		jobID := scheduler.StartJobEvery(d, func() error {
-			return RedeployWhenChanged(stackID, stackdeployer, datastore, gitService)
-		})
+		jobID := scheduler.StartJobEvery(d, scheduledRedeploy(stackID, jobQueue, stackdeployer, datastore, gitService))
 
 		stack.AutoUpdate.JobID = jobID
 		if err := datastore.Stack().Update(stack.ID, &stack); err != nil {
@@ -0,0 +1,194 @@
+// Package drift detects the git stacks whose repository moved past the deployed commit, or whose deployment does not
+// match their stack files anymore, such as the services removed or stopped outside of Portainer
+package drift
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/docker/consts"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// DeployedService is a service of a stack deployed on an environment
+type DeployedService struct {
+	Name    string
+	Image   string
+	Running bool
+}
+
+// DeclaredServices returns the images of the services declared by the stack files, indexed by service. The image is
+// empty when it is unknown, such as the images built by compose or the interpolated references.
+func DeclaredServices(stack *portainer.Stack, fileService stackutils.StackFileReader) (map[string]string, error) {
+	services := make(map[string]string)
+
+	for _, file := range stackutils.GetStackFilePaths(stack, false) {
+		content, err := fileService.GetFileContent(stack.ProjectPath, file)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get stack file content")
+		}
+
+		var config struct {
+			Services map[string]struct {
+				Image string `yaml:"image"`
+			} `yaml:"services"`
+		}
+
+		if err := yaml.Unmarshal(content, &config); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse the stack file %s", file)
+		}
+
+		// the additional files override the services of the previous ones
+		for name, service := range config.Services {
+			image := service.Image
+			if strings.Contains(image, "$") {
+				image = ""
+			}
+
+			if _, ok := services[name]; !ok || image != "" {
+				services[name] = image
+			}
+		}
+	}
+
+	return services, nil
+}
+
+// Compare fills the drift with the differences between the declared services and the deployed ones
+func Compare(drift *portainer.StackDrift, declared map[string]string, deployed []DeployedService) {
+	deployedServices := make(map[string]DeployedService)
+	for _, service := range deployed {
+		if previous, ok := deployedServices[service.Name]; ok {
+			// a service is running as long as one of its replicas is
+			service.Running = service.Running || previous.Running
+			if previous.Image != service.Image {
+				service.Image = ""
+			}
+		}
+
+		deployedServices[service.Name] = service
+	}
+
+	for name, image := range declared {
+		service, ok := deployedServices[name]
+		if !ok {
+			drift.MissingServices = append(drift.MissingServices, name)
+			continue
+		}
+
+		if !service.Running {
+			drift.StoppedServices = append(drift.StoppedServices, name)
+		}
+
+		if image != "" && service.Image != "" && !sameImage(image, service.Image) {
+			drift.ChangedImageServices = append(drift.ChangedImageServices, name)
+		}
+	}
+
+	for name := range deployedServices {
+		if _, ok := declared[name]; !ok {
+			drift.OrphanedServices = append(drift.OrphanedServices, name)
+		}
+	}
+
+	sort.Strings(drift.MissingServices)
+	sort.Strings(drift.StoppedServices)
+	sort.Strings(drift.OrphanedServices)
+	sort.Strings(drift.ChangedImageServices)
+}
+
+// sameImage returns whether two image references designate the same image, the image IDs of the containers whose tag
+// moved to another image being considered unknown
+func sameImage(declared, deployed string) bool {
+	if strings.HasPrefix(deployed, "sha256:") {
+		return true
+	}
+
+	return normalizeImage(declared) == normalizeImage(deployed)
+}
+
+// normalizeImage returns the familiar form of an image reference, with its tag and without its digest when it has one
+func normalizeImage(image string) string {
+	name, digest, _ := strings.Cut(image, "@")
+
+	name = strings.TrimPrefix(name, "docker.io/")
+	name = strings.TrimPrefix(name, "index.docker.io/")
+	name = strings.TrimPrefix(name, "library/")
+
+	if !strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+		// a reference pinned by digest only
+		if digest != "" {
+			return name + "@" + digest
+		}
+
+		name += ":latest"
+	}
+
+	return name
+}
+
+// InspectDeployedServices returns the services deployed for a stack, the containers of a compose stack and the
+// services of a swarm stack
+func InspectDeployedServices(ctx context.Context, cli *client.Client, stack *portainer.Stack) ([]DeployedService, error) {
+	if stack.Type == portainer.DockerSwarmStack {
+		services, err := cli.ServiceList(ctx, types.ServiceListOptions{
+			Filters: filters.NewArgs(filters.Arg("label", consts.SwarmStackNameLabel+"="+stack.Name)),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to list the services")
+		}
+
+		deployed := make([]DeployedService, 0, len(services))
+		for _, service := range services {
+			running := true
+			if replicated := service.Spec.Mode.Replicated; replicated != nil && replicated.Replicas != nil {
+				running = *replicated.Replicas > 0
+			}
+
+			image := ""
+			if spec := service.Spec.TaskTemplate.ContainerSpec; spec != nil {
+				image = spec.Image
+			}
+
+			deployed = append(deployed, DeployedService{
+				Name:    strings.TrimPrefix(service.Spec.Name, stack.Name+"_"),
+				Image:   image,
+				Running: running,
+			})
+		}
+
+		return deployed, nil
+	}
+
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", consts.ComposeStackNameLabel+"="+stack.Name)),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list the containers")
+	}
+
+	deployed := make([]DeployedService, 0, len(containers))
+	for _, container := range containers {
+		// the one-off containers of docker compose run are not services of the stack
+		if strings.EqualFold(container.Labels["com.docker.compose.oneoff"], "true") {
+			continue
+		}
+
+		deployed = append(deployed, DeployedService{
+			Name:    container.Labels["com.docker.compose.service"],
+			Image:   container.Image,
+			Running: container.State == "running",
+		})
+	}
+
+	return deployed, nil
+}
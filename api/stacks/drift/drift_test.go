@@ -0,0 +1,129 @@
+package drift
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/portainer/portainer/api/internal/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stackFiles serves the content of the stack files from memory
+type stackFiles map[string]string
+
+func (files stackFiles) GetFileContent(trustedRoot, filePath string) ([]byte, error) {
+	content, ok := files[filePath]
+	if !ok {
+		return nil, errors.New("file not found")
+	}
+
+	return []byte(content), nil
+}
+
+func TestDeclaredServices(t *testing.T) {
+	stack := &portainer.Stack{EntryPoint: "docker-compose.yml", AdditionalFiles: []string{"override.yml"}}
+
+	services, err := DeclaredServices(stack, stackFiles{
+		"docker-compose.yml": "services:\n  web:\n    image: nginx:1.25\n  app:\n    build: .\n  db:\n    image: postgres:${PG_VERSION}\n",
+		"override.yml":       "services:\n  web:\n    ports:\n      - 8080:80\n  worker:\n    image: worker:2\n",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"web": "nginx:1.25", "app": "", "db": "", "worker": "worker:2"}, services)
+}
+
+func TestCompare(t *testing.T) {
+	var drift portainer.StackDrift
+
+	Compare(&drift, map[string]string{
+		"web":    "nginx:1.25",
+		"api":    "registry.example.com/api",
+		"db":     "postgres:16",
+		"worker": "worker:2",
+		"cache":  "",
+	}, []DeployedService{
+		{Name: "web", Image: "docker.io/library/nginx:1.25@sha256:abc", Running: false},
+		{Name: "web", Image: "nginx:1.25", Running: true},
+		{Name: "api", Image: "registry.example.com/api:latest", Running: true},
+		{Name: "db", Image: "postgres:15", Running: true},
+		{Name: "cache", Image: "sha256:0123", Running: false},
+		{Name: "legacy", Image: "legacy:1", Running: true},
+	})
+
+	assert.Equal(t, []string{"worker"}, drift.MissingServices)
+	assert.Equal(t, []string{"cache"}, drift.StoppedServices)
+	assert.Equal(t, []string{"legacy"}, drift.OrphanedServices)
+	assert.Equal(t, []string{"db"}, drift.ChangedImageServices)
+}
+
+func TestService_Check(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Type: portainer.DockerEnvironment}))
+
+	stack := &portainer.Stack{
+		ID:         1,
+		Name:       "web",
+		Type:       portainer.DockerComposeStack,
+		EndpointID: 1,
+		EntryPoint: "docker-compose.yml",
+		Status:     portainer.StackStatusActive,
+		GitConfig:  &gittypes.RepoConfig{URL: "https://example.com/web.git", ConfigHash: "deployed"},
+	}
+	is.NoError(store.Stack().Create(stack))
+	is.NoError(store.Stack().Create(&portainer.Stack{ID: 2, Name: "local", EndpointID: 1}))
+
+	service := &Service{
+		dataStore:   store,
+		gitService:  testhelpers.NewGitService(nil, "deployed"),
+		fileService: stackFiles{"docker-compose.yml": "services:\n  web:\n    image: nginx:1.25\n"},
+		drifts:      make(map[portainer.StackID]portainer.StackDrift),
+	}
+
+	deployed := []DeployedService{{Name: "web", Image: "nginx:1.25", Running: true}}
+	var inspected []portainer.StackID
+	service.inspect = func(ctx context.Context, endpoint *portainer.Endpoint, stack *portainer.Stack) ([]DeployedService, error) {
+		inspected = append(inspected, stack.ID)
+		return deployed, nil
+	}
+
+	is.NoError(service.CheckAll(context.Background()))
+	is.Equal([]portainer.StackID{1}, inspected, "only the git stacks should be checked")
+
+	drift, ok := service.Drift(1)
+	is.True(ok)
+	is.Equal(portainer.StackDriftInSync, drift.Status)
+	is.False(drift.RepositoryChanged)
+
+	_, ok = service.Drift(2)
+	is.False(ok)
+
+	service.gitService = testhelpers.NewGitService(nil, "head")
+	deployed = nil
+
+	drift = service.Check(context.Background(), stack)
+	is.Equal(portainer.StackDriftDrifted, drift.Status)
+	is.True(drift.RepositoryChanged)
+	is.Equal("head", drift.RepositoryCommit)
+	is.Equal([]string{"web"}, drift.MissingServices)
+
+	service.inspect = func(ctx context.Context, endpoint *portainer.Endpoint, stack *portainer.Stack) ([]DeployedService, error) {
+		return nil, errors.New("environment unreachable")
+	}
+	service.gitService = testhelpers.NewGitService(nil, "deployed")
+
+	drift = service.Check(context.Background(), stack)
+	is.Equal(portainer.StackDriftUnknown, drift.Status)
+	is.Len(drift.Errors, 1)
+
+	is.NoError(store.Stack().Delete(1))
+	is.NoError(service.CheckAll(context.Background()))
+
+	_, ok = service.Drift(1)
+	is.False(ok, "the removed stacks should be forgotten")
+}
@@ -0,0 +1,204 @@
+package drift
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/git"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/scheduler"
+	"github.com/portainer/portainer/api/stacks/stackutils"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// checkInterval is the interval between the checks of the git stacks
+	checkInterval = 15 * time.Minute
+
+	inspectTimeout = time.Minute
+)
+
+// Service checks periodically the drift of the git stacks and keeps the result of the last check of each stack
+type Service struct {
+	dataStore   dataservices.DataStore
+	gitService  portainer.GitService
+	fileService stackutils.StackFileReader
+	scheduler   *scheduler.Scheduler
+	inspect     func(ctx context.Context, endpoint *portainer.Endpoint, stack *portainer.Stack) ([]DeployedService, error)
+
+	mu     sync.Mutex
+	drifts map[portainer.StackID]portainer.StackDrift
+}
+
+// NewService creates a new instance of the drift service
+func NewService(dataStore dataservices.DataStore, gitService portainer.GitService, fileService portainer.FileService, clientFactory *dockerclient.ClientFactory, scheduler *scheduler.Scheduler) *Service {
+	return &Service{
+		dataStore:   dataStore,
+		gitService:  gitService,
+		fileService: fileService,
+		scheduler:   scheduler,
+		inspect: func(ctx context.Context, endpoint *portainer.Endpoint, stack *portainer.Stack) ([]DeployedService, error) {
+			timeout := inspectTimeout
+
+			cli, err := clientFactory.CreateClient(endpoint, "", &timeout)
+			if err != nil {
+				return nil, err
+			}
+			defer cli.Close()
+
+			return InspectDeployedServices(ctx, cli, stack)
+		},
+		drifts: make(map[portainer.StackID]portainer.StackDrift),
+	}
+}
+
+// Start schedules the checks of the git stacks
+func (service *Service) Start() {
+	service.scheduler.StartJobEvery(checkInterval, func() error {
+		return service.CheckAll(context.Background())
+	})
+}
+
+// CheckAll checks the drift of all the git stacks
+func (service *Service) CheckAll(ctx context.Context) error {
+	stacks, err := service.dataStore.Stack().ReadAll()
+	if err != nil {
+		return err
+	}
+
+	checked := make(map[portainer.StackID]bool)
+
+	for i := range stacks {
+		stack := &stacks[i]
+		if stack.GitConfig == nil {
+			continue
+		}
+
+		drift := service.Check(ctx, stack)
+		checked[stack.ID] = true
+
+		if drift.Status == portainer.StackDriftDrifted {
+			log.Debug().
+				Int("stack_id", int(stack.ID)).
+				Str("stack", stack.Name).
+				Bool("repository_changed", drift.RepositoryChanged).
+				Msg("the stack drifted from its repository or from its deployment")
+		}
+	}
+
+	// forget the stacks removed or detached from their repository
+	service.mu.Lock()
+	for stackID := range service.drifts {
+		if !checked[stackID] {
+			delete(service.drifts, stackID)
+		}
+	}
+	service.mu.Unlock()
+
+	return nil
+}
+
+// Check compares a git stack with the head of its repository and with the services deployed on its environment, and
+// keeps the result as the drift of the stack
+func (service *Service) Check(ctx context.Context, stack *portainer.Stack) portainer.StackDrift {
+	drift := portainer.StackDrift{CheckedAt: time.Now().Unix()}
+
+	if stack.GitConfig != nil && !stack.FromAppTemplate {
+		if err := service.checkRepository(stack, &drift); err != nil {
+			drift.Errors = append(drift.Errors, "unable to check the repository: "+err.Error())
+		}
+	}
+
+	if err := service.checkDeployment(ctx, stack, &drift); err != nil {
+		drift.Errors = append(drift.Errors, "unable to check the deployment: "+err.Error())
+	}
+
+	drift.Status = status(drift)
+
+	service.mu.Lock()
+	service.drifts[stack.ID] = drift
+	service.mu.Unlock()
+
+	return drift
+}
+
+// Drift returns the result of the last check of a stack
+func (service *Service) Drift(stackID portainer.StackID) (portainer.StackDrift, bool) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	drift, ok := service.drifts[stackID]
+
+	return drift, ok
+}
+
+func (service *Service) checkRepository(stack *portainer.Stack, drift *portainer.StackDrift) error {
+	username, password, err := git.GetCredentials(stack.GitConfig.Authentication)
+	if err != nil {
+		return err
+	}
+
+	commit, err := service.gitService.LatestCommitID(stack.GitConfig.URL, stack.GitConfig.ReferenceName, username, password, stack.GitConfig.TLSSkipVerify)
+	if err != nil {
+		return err
+	}
+
+	drift.RepositoryCommit = commit
+	drift.RepositoryChanged = !strings.EqualFold(commit, stack.GitConfig.ConfigHash)
+
+	return nil
+}
+
+// checkDeployment compares the stack files with the services deployed on the environment. The stopped stacks, the
+// Kubernetes stacks and the stacks of the Edge environments, only reachable through their tunnel, are not inspected.
+func (service *Service) checkDeployment(ctx context.Context, stack *portainer.Stack, drift *portainer.StackDrift) error {
+	if stack.Status != portainer.StackStatusActive || (stack.Type != portainer.DockerComposeStack && stack.Type != portainer.DockerSwarmStack) {
+		return nil
+	}
+
+	endpoint, err := service.dataStore.Endpoint().Endpoint(stack.EndpointID)
+	if err != nil {
+		return err
+	}
+
+	if endpointutils.IsEdgeEndpoint(endpoint) || endpoint.Status == portainer.EndpointStatusDown {
+		return nil
+	}
+
+	declared, err := DeclaredServices(stack, service.fileService)
+	if err != nil {
+		return err
+	}
+
+	deployed, err := service.inspect(ctx, endpoint, stack)
+	if err != nil {
+		return err
+	}
+
+	Compare(drift, declared, deployed)
+
+	return nil
+}
+
+// status returns the drift status, which is unknown when a part of the check failed without finding a difference
+func status(drift portainer.StackDrift) portainer.StackDriftStatus {
+	if drift.RepositoryChanged ||
+		len(drift.MissingServices) > 0 ||
+		len(drift.StoppedServices) > 0 ||
+		len(drift.OrphanedServices) > 0 ||
+		len(drift.ChangedImageServices) > 0 {
+		return portainer.StackDriftDrifted
+	}
+
+	if len(drift.Errors) > 0 {
+		return portainer.StackDriftUnknown
+	}
+
+	return portainer.StackDriftInSync
+}
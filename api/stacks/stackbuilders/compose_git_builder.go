@@ -4,6 +4,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
 	"github.com/portainer/portainer/api/http/security"
+	"github.com/portainer/portainer/api/jobs"
 	"github.com/portainer/portainer/api/scheduler"
 	"github.com/portainer/portainer/api/stacks/deployments"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
@@ -20,6 +21,7 @@ func CreateComposeStackGitBuilder(securityContext *security.RestrictedRequestCon
 	fileService portainer.FileService,
 	gitService portainer.GitService,
 	scheduler *scheduler.Scheduler,
+	jobQueue *jobs.Queue,
 	stackDeployer deployments.StackDeployer) *ComposeStackGitBuilder {
 
 	return &ComposeStackGitBuilder{
@@ -27,6 +29,7 @@ func CreateComposeStackGitBuilder(securityContext *security.RestrictedRequestCon
 			StackBuilder: CreateStackBuilder(dataStore, fileService, stackDeployer),
 			gitService:   gitService,
 			scheduler:    scheduler,
+			jobQueue:     jobQueue,
 		},
 		SecurityContext: securityContext,
 	}
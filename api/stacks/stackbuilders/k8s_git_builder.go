@@ -5,6 +5,7 @@ import (
 
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/jobs"
 	k "github.com/portainer/portainer/api/kubernetes"
 	"github.com/portainer/portainer/api/scheduler"
 	"github.com/portainer/portainer/api/stacks/deployments"
@@ -24,6 +25,7 @@ func CreateKubernetesStackGitBuilder(dataStore dataservices.DataStore,
 	fileService portainer.FileService,
 	gitService portainer.GitService,
 	scheduler *scheduler.Scheduler,
+	jobQueue *jobs.Queue,
 	stackDeployer deployments.StackDeployer,
 	kuberneteDeployer portainer.KubernetesDeployer,
 	user *portainer.User) *KubernetesStackGitBuilder {
@@ -33,6 +35,7 @@ func CreateKubernetesStackGitBuilder(dataStore dataservices.DataStore,
 			StackBuilder: CreateStackBuilder(dataStore, fileService, stackDeployer),
 			gitService:   gitService,
 			scheduler:    scheduler,
+			jobQueue:     jobQueue,
 		},
 		stackCreateMut:    &sync.Mutex{},
 		KuberneteDeployer: kuberneteDeployer,
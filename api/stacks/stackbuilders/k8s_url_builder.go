@@ -11,6 +11,7 @@ import (
 	k "github.com/portainer/portainer/api/kubernetes"
 	"github.com/portainer/portainer/api/stacks/deployments"
 	"github.com/portainer/portainer/api/stacks/stackutils"
+	"github.com/portainer/portainer/api/timeouts"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 )
 
@@ -63,7 +64,7 @@ func (b *KubernetesStackUrlBuilder) SetURL(payload *StackPayload) UrlMethodStack
 	}
 
 	var manifestContent []byte
-	manifestContent, err := client.Get(payload.ManifestURL, 30)
+	manifestContent, err := client.Get(payload.ManifestURL, timeouts.Current().TemplateFetch)
 	if err != nil {
 		b.err = httperror.InternalServerError("Unable to retrieve manifest from URL", err)
 		return b
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/stacks/stackutils"
 	httperror "github.com/portainer/portainer/pkg/libhttp/error"
 )
 
@@ -56,7 +57,7 @@ func (b *FileContentMethodStackBuilder) Deploy(payload *StackPayload, endpoint *
 	// Deploy the stack
 	err := b.deploymentConfiger.Deploy()
 	if err != nil {
-		b.err = httperror.InternalServerError(err.Error(), err)
+		b.err = stackutils.DeploymentError(err)
 		return b
 	}
 
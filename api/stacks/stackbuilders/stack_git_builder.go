@@ -8,6 +8,7 @@ import (
 	portainer "github.com/portainer/portainer/api"
 	"github.com/portainer/portainer/api/filesystem"
 	gittypes "github.com/portainer/portainer/api/git/types"
+	"github.com/portainer/portainer/api/jobs"
 	"github.com/portainer/portainer/api/scheduler"
 	"github.com/portainer/portainer/api/stacks/deployments"
 	"github.com/portainer/portainer/api/stacks/stackutils"
@@ -35,6 +36,7 @@ type GitMethodStackBuilder struct {
 	StackBuilder
 	gitService portainer.GitService
 	scheduler  *scheduler.Scheduler
+	jobQueue   *jobs.Queue
 }
 
 func (b *GitMethodStackBuilder) SetGeneralInfo(payload *StackPayload, endpoint *portainer.Endpoint) GitMethodStackBuildProcess {
@@ -108,7 +110,7 @@ func (b *GitMethodStackBuilder) Deploy(payload *StackPayload, endpoint *portaine
 	// Deploy the stack
 	err := b.deploymentConfiger.Deploy()
 	if err != nil {
-		b.err = httperror.InternalServerError(err.Error(), err)
+		b.err = stackutils.DeploymentError(err)
 		return b
 	}
 
@@ -124,6 +126,7 @@ func (b *GitMethodStackBuilder) SetAutoUpdate(payload *StackPayload) GitMethodSt
 		jobID, err := deployments.StartAutoupdate(b.stack.ID,
 			b.stack.AutoUpdate.Interval,
 			b.scheduler,
+			b.jobQueue,
 			b.stackDeployer,
 			b.dataStore,
 			b.gitService)
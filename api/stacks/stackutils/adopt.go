@@ -0,0 +1,331 @@
+package stackutils
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"gopkg.in/yaml.v3"
+)
+
+// Labels set by Docker Compose on the containers of a project
+const (
+	ComposeProjectLabel     = "com.docker.compose.project"
+	composeServiceLabel     = "com.docker.compose.service"
+	composeWorkingDirLabel  = "com.docker.compose.project.working_dir"
+	composeConfigFilesLabel = "com.docker.compose.project.config_files"
+	composeOneoffLabel      = "com.docker.compose.oneoff"
+	composeLabelPrefix      = "com.docker.compose."
+)
+
+// ComposeProject represents a compose project found on an environment from the labels of its containers
+type ComposeProject struct {
+	// Name of the project, which is the name of the stack adopting it
+	Name string `json:"Name" example:"wordpress"`
+	// Directory the project was deployed from, on the host of the client which deployed it
+	WorkingDir string `json:"WorkingDir,omitempty" example:"/home/user/wordpress"`
+	// Compose files the project was deployed from, on the host of the client which deployed it
+	ConfigFiles []string `json:"ConfigFiles,omitempty" example:"/home/user/wordpress/docker-compose.yml"`
+	// Services of the project
+	Services []string `json:"Services" example:"db,wordpress"`
+	// Number of containers of the project, and of those running
+	Containers int `json:"Containers" example:"2"`
+	Running    int `json:"Running" example:"2"`
+}
+
+// ComposeProjects groups the containers by compose project, the one-off containers such as the ones of
+// `docker compose run` being ignored
+func ComposeProjects(containers []types.Container) []ComposeProject {
+	projects := make(map[string]*ComposeProject)
+
+	for _, c := range containers {
+		name := c.Labels[ComposeProjectLabel]
+		if name == "" || isOneoff(c.Labels) {
+			continue
+		}
+
+		project, ok := projects[name]
+		if !ok {
+			project = &ComposeProject{Name: name, WorkingDir: c.Labels[composeWorkingDirLabel]}
+			if configFiles := c.Labels[composeConfigFilesLabel]; configFiles != "" {
+				project.ConfigFiles = strings.Split(configFiles, ",")
+			}
+
+			projects[name] = project
+		}
+
+		project.Containers++
+		if c.State == "running" {
+			project.Running++
+		}
+
+		if service := c.Labels[composeServiceLabel]; service != "" && !slices.Contains(project.Services, service) {
+			project.Services = append(project.Services, service)
+		}
+	}
+
+	result := make([]ComposeProject, 0, len(projects))
+	for _, project := range projects {
+		sort.Strings(project.Services)
+		result = append(result, *project)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// ComposeContainer is a container of a compose project along with the configuration of its image, which is nil when
+// the image is not available anymore
+type ComposeContainer struct {
+	Container   types.ContainerJSON
+	ImageConfig *container.Config
+}
+
+type composeFile struct {
+	Services map[string]composeService  `yaml:"services"`
+	Networks map[string]composeResource `yaml:"networks,omitempty"`
+	Volumes  map[string]composeResource `yaml:"volumes,omitempty"`
+}
+
+type composeService struct {
+	Image         string            `yaml:"image"`
+	ContainerName string            `yaml:"container_name,omitempty"`
+	Entrypoint    []string          `yaml:"entrypoint,omitempty"`
+	Command       []string          `yaml:"command,omitempty"`
+	Environment   []string          `yaml:"environment,omitempty"`
+	Ports         []string          `yaml:"ports,omitempty"`
+	Volumes       []string          `yaml:"volumes,omitempty"`
+	NetworkMode   string            `yaml:"network_mode,omitempty"`
+	Networks      []string          `yaml:"networks,omitempty"`
+	Restart       string            `yaml:"restart,omitempty"`
+	Labels        map[string]string `yaml:"labels,omitempty"`
+}
+
+type composeResource struct {
+	External bool   `yaml:"external,omitempty"`
+	Name     string `yaml:"name,omitempty"`
+}
+
+// ComposeFile generates a compose file describing the containers of a compose project from their configuration,
+// the settings inherited from their image being left out. The file is a best effort which should be reviewed
+// before the stack is redeployed, the build sections and the settings without an equivalent being lost.
+func ComposeFile(project string, containers []ComposeContainer) ([]byte, error) {
+	file := composeFile{
+		Services: make(map[string]composeService),
+		Networks: make(map[string]composeResource),
+		Volumes:  make(map[string]composeResource),
+	}
+
+	sorted := slices.Clone(containers)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Container.Name < sorted[j].Container.Name
+	})
+
+	for _, c := range sorted {
+		if c.Container.ContainerJSONBase == nil || c.Container.Config == nil || isOneoff(c.Container.Config.Labels) {
+			continue
+		}
+
+		name := c.Container.Config.Labels[composeServiceLabel]
+		if name == "" {
+			return nil, fmt.Errorf("the container %s is not a service of a compose project", strings.TrimPrefix(c.Container.Name, "/"))
+		}
+
+		// the replicas of a service share its configuration
+		if _, ok := file.Services[name]; ok {
+			continue
+		}
+
+		file.Services[name] = composeServiceOf(project, name, c, &file)
+	}
+
+	if len(file.Services) == 0 {
+		return nil, fmt.Errorf("no container found for the compose project %s", project)
+	}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(file); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), encoder.Close()
+}
+
+func composeServiceOf(project, name string, c ComposeContainer, file *composeFile) composeService {
+	config := c.Container.Config
+	hostConfig := c.Container.HostConfig
+
+	imageConfig := c.ImageConfig
+	if imageConfig == nil {
+		imageConfig = &container.Config{}
+	}
+
+	service := composeService{Image: config.Image}
+
+	if !isDefaultContainerName(project, name, c.Container.Name) {
+		service.ContainerName = strings.TrimPrefix(c.Container.Name, "/")
+	}
+
+	if !slices.Equal(config.Entrypoint, imageConfig.Entrypoint) {
+		service.Entrypoint = config.Entrypoint
+	}
+
+	if !slices.Equal(config.Cmd, imageConfig.Cmd) {
+		service.Command = config.Cmd
+	}
+
+	for _, env := range config.Env {
+		if !slices.Contains(imageConfig.Env, env) {
+			service.Environment = append(service.Environment, env)
+		}
+	}
+
+	for key, value := range config.Labels {
+		if strings.HasPrefix(key, composeLabelPrefix) || imageConfig.Labels[key] == value {
+			continue
+		}
+
+		if service.Labels == nil {
+			service.Labels = make(map[string]string)
+		}
+		service.Labels[key] = value
+	}
+
+	if hostConfig != nil {
+		service.Ports = composePorts(hostConfig)
+
+		if policy := hostConfig.RestartPolicy.Name; policy != "" && policy != "no" {
+			service.Restart = policy
+			if policy == "on-failure" && hostConfig.RestartPolicy.MaximumRetryCount > 0 {
+				service.Restart = fmt.Sprintf("on-failure:%d", hostConfig.RestartPolicy.MaximumRetryCount)
+			}
+		}
+
+		if mode := hostConfig.NetworkMode; mode.IsHost() || mode.IsNone() || mode.IsContainer() {
+			service.NetworkMode = string(mode)
+		}
+	}
+
+	for _, m := range c.Container.Mounts {
+		switch m.Type {
+		case mount.TypeBind:
+			service.Volumes = append(service.Volumes, withMode(m.Source+":"+m.Destination, m.RW))
+
+		case mount.TypeVolume:
+			// the anonymous volumes declared by the image are created again with the container
+			if _, ok := imageConfig.Volumes[m.Destination]; ok && isAnonymousVolume(m.Name) {
+				continue
+			}
+
+			volume := projectResource(project, m.Name, file.Volumes)
+			service.Volumes = append(service.Volumes, withMode(volume+":"+m.Destination, m.RW))
+		}
+	}
+
+	if service.NetworkMode == "" && c.Container.NetworkSettings != nil {
+		for network := range c.Container.NetworkSettings.Networks {
+			if network == project+"_default" {
+				service.Networks = append(service.Networks, "default")
+				continue
+			}
+
+			service.Networks = append(service.Networks, projectResource(project, network, file.Networks))
+		}
+
+		sort.Strings(service.Networks)
+
+		// the services are attached to the default network of the project when no network is specified
+		if slices.Equal(service.Networks, []string{"default"}) {
+			service.Networks = nil
+		}
+	}
+
+	return service
+}
+
+// projectResource returns the name of a network or a volume in the compose file, the resources of the project being
+// declared with their short name and the other ones as external
+func projectResource(project, name string, resources map[string]composeResource) string {
+	if short, ok := strings.CutPrefix(name, project+"_"); ok && short != "" {
+		resources[short] = composeResource{}
+		return short
+	}
+
+	resources[name] = composeResource{External: true}
+
+	return name
+}
+
+func composePorts(hostConfig *container.HostConfig) []string {
+	var ports []string
+
+	for port, bindings := range hostConfig.PortBindings {
+		target := port.Port()
+		if port.Proto() != "tcp" {
+			target += "/" + port.Proto()
+		}
+
+		for _, binding := range bindings {
+			published := target
+			if binding.HostPort != "" {
+				published = binding.HostPort + ":" + target
+			}
+
+			if binding.HostIP != "" && binding.HostIP != "0.0.0.0" && binding.HostIP != "::" {
+				published = binding.HostIP + ":" + published
+			}
+
+			ports = append(ports, published)
+		}
+	}
+
+	sort.Strings(ports)
+
+	return ports
+}
+
+var anonymousVolumeRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+func isAnonymousVolume(name string) bool {
+	return anonymousVolumeRegex.MatchString(name)
+}
+
+// isDefaultContainerName returns whether the container name is the one generated by Docker Compose, which uses
+// dashes since Compose v2 and underscores before
+func isDefaultContainerName(project, service, containerName string) bool {
+	containerName = strings.TrimPrefix(containerName, "/")
+
+	for _, separator := range []string{"-", "_"} {
+		prefix := project + separator + service + separator
+		if rest, ok := strings.CutPrefix(containerName, prefix); ok && rest != "" && strings.Trim(rest, "0123456789") == "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isOneoff returns whether a container was created by `docker compose run` rather than for a service of the project
+func isOneoff(labels map[string]string) bool {
+	return strings.EqualFold(labels[composeOneoffLabel], "true")
+}
+
+func withMode(volume string, rw bool) string {
+	if !rw {
+		return volume + ":ro"
+	}
+
+	return volume
+}
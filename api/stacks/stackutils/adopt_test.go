@@ -0,0 +1,122 @@
+package stackutils
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposeProjects(t *testing.T) {
+	projects := ComposeProjects([]types.Container{
+		{State: "running", Labels: map[string]string{ComposeProjectLabel: "wordpress", composeServiceLabel: "wordpress", composeWorkingDirLabel: "/srv/wordpress"}},
+		{State: "exited", Labels: map[string]string{ComposeProjectLabel: "wordpress", composeServiceLabel: "db"}},
+		{State: "running", Labels: map[string]string{ComposeProjectLabel: "wordpress", composeServiceLabel: "db", composeOneoffLabel: "True"}},
+		{State: "running", Labels: map[string]string{ComposeProjectLabel: "cache", composeServiceLabel: "redis"}},
+		{State: "running"},
+	})
+
+	assert.Equal(t, []ComposeProject{
+		{Name: "cache", Services: []string{"redis"}, Containers: 1, Running: 1},
+		{Name: "wordpress", WorkingDir: "/srv/wordpress", Services: []string{"db", "wordpress"}, Containers: 2, Running: 1},
+	}, projects)
+}
+
+func TestComposeFile(t *testing.T) {
+	is := assert.New(t)
+
+	web := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			Name: "/wordpress-web-1",
+			HostConfig: &container.HostConfig{
+				PortBindings:  nat.PortMap{"80/tcp": {{HostPort: "8080"}}, "53/udp": {{HostIP: "127.0.0.1", HostPort: "53"}}},
+				RestartPolicy: container.RestartPolicy{Name: "unless-stopped"},
+			},
+		},
+		Mounts: []types.MountPoint{
+			{Type: mount.TypeVolume, Name: "wordpress_data", Destination: "/var/www/html", RW: true},
+			{Type: mount.TypeVolume, Name: "shared", Destination: "/shared", RW: false},
+			{Type: mount.TypeVolume, Name: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", Destination: "/cache", RW: true},
+			{Type: mount.TypeBind, Source: "/etc/localtime", Destination: "/etc/localtime", RW: false},
+		},
+		Config: &container.Config{
+			Image: "wordpress:6",
+			Cmd:   []string{"apache2-foreground"},
+			Env:   []string{"PATH=/usr/bin", "WORDPRESS_DB_HOST=db"},
+			Labels: map[string]string{
+				ComposeProjectLabel: "wordpress",
+				composeServiceLabel: "web",
+				"traefik.enable":    "true",
+				"maintainer":        "wordpress",
+			},
+		},
+		NetworkSettings: &types.NetworkSettings{Networks: map[string]*network.EndpointSettings{
+			"wordpress_default": {},
+			"wordpress_backend": {},
+			"proxy":             {},
+		}},
+	}
+
+	db := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{Name: "/mariadb", HostConfig: &container.HostConfig{}},
+		Config: &container.Config{
+			Image:  "mariadb:11",
+			Labels: map[string]string{ComposeProjectLabel: "wordpress", composeServiceLabel: "db"},
+		},
+		NetworkSettings: &types.NetworkSettings{Networks: map[string]*network.EndpointSettings{"wordpress_default": {}}},
+	}
+
+	replica := web
+	replica.ContainerJSONBase = &types.ContainerJSONBase{Name: "/wordpress-web-2", HostConfig: &container.HostConfig{}}
+
+	content, err := ComposeFile("wordpress", []ComposeContainer{
+		{Container: replica},
+		{Container: db},
+		{Container: web, ImageConfig: &container.Config{
+			Cmd:     []string{"apache2-foreground"},
+			Env:     []string{"PATH=/usr/bin"},
+			Labels:  map[string]string{"maintainer": "wordpress"},
+			Volumes: map[string]struct{}{"/cache": {}},
+		}},
+	})
+	is.NoError(err)
+
+	is.Equal(`services:
+  db:
+    image: mariadb:11
+    container_name: mariadb
+  web:
+    image: wordpress:6
+    environment:
+      - WORDPRESS_DB_HOST=db
+    ports:
+      - 127.0.0.1:53:53/udp
+      - 8080:80
+    volumes:
+      - data:/var/www/html
+      - shared:/shared:ro
+      - /etc/localtime:/etc/localtime:ro
+    networks:
+      - backend
+      - default
+      - proxy
+    restart: unless-stopped
+    labels:
+      traefik.enable: "true"
+networks:
+  backend: {}
+  proxy:
+    external: true
+volumes:
+  data: {}
+  shared:
+    external: true
+`, string(content))
+
+	_, err = ComposeFile("wordpress", nil)
+	is.Error(err, "a project without containers should not generate a file")
+}
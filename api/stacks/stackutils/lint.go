@@ -0,0 +1,435 @@
+package stackutils
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	httperror "github.com/portainer/portainer/pkg/libhttp/error"
+
+	"github.com/docker/cli/cli/compose/loader"
+	"github.com/docker/cli/cli/compose/types"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// LintSeverity represents the severity of an issue found in a compose file
+type LintSeverity string
+
+const (
+	// LintSeverityError is the severity of the issues preventing the deployment of a stack
+	LintSeverityError LintSeverity = "error"
+	// LintSeverityWarning is the severity of the issues reported without preventing the deployment of a stack
+	LintSeverityWarning LintSeverity = "warning"
+)
+
+// Rules reporting the issues of the compose files
+const (
+	LintRuleYAML           = "yaml"
+	LintRuleSchema         = "schema"
+	LintRuleLatestImageTag = "latest-image-tag"
+	LintRulePrivilegedMode = "privileged-mode"
+	LintRuleMissingLabels  = "missing-labels"
+)
+
+// LintIssue represents an issue found in a compose file
+type LintIssue struct {
+	// Path of the compose file, relative to the stack project path
+	File string `json:"File,omitempty" example:"docker-compose.yml"`
+	// Line of the issue in the compose file, 0 when unknown
+	Line int `json:"Line" example:"4"`
+	// Service of the issue, empty when the issue is not specific to a service
+	Service string `json:"Service,omitempty" example:"web"`
+	// Rule which found the issue
+	Rule string `json:"Rule" example:"latest-image-tag"`
+	// Severity of the issue, the stack is not deployed when an issue is an error
+	Severity LintSeverity `json:"Severity" example:"warning" enums:"error,warning"`
+	// Description of the issue
+	Message string `json:"Message" example:"the image nginx:latest of the service web uses the latest tag"`
+}
+
+func (issue LintIssue) String() string {
+	location := issue.File
+	if issue.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, issue.Line)
+	}
+
+	if location == "" {
+		return issue.Message
+	}
+
+	return location + ": " + issue.Message
+}
+
+// StackLintError is returned when the compose files of a stack have blocking issues
+type StackLintError struct {
+	Issues []LintIssue
+}
+
+func (e *StackLintError) Error() string {
+	messages := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		if issue.Severity == LintSeverityError {
+			messages = append(messages, issue.String())
+		}
+	}
+
+	return "stack config file is invalid: " + strings.Join(messages, "; ")
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// LintComposeFile checks the syntax of a compose file, its schema when it declares a version 3 format, and the stack
+// policy. Schema issues are blocking for swarm stacks only, as docker compose also accepts the compose specification.
+func LintComposeFile(content []byte, env []portainer.Pair, stackType portainer.StackType, policy portainer.StackPolicySettings) []LintIssue {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return []LintIssue{{Rule: LintRuleYAML, Severity: LintSeverityError, Message: "the compose file is empty"}}
+	}
+
+	// the syntax errors are reported by the parser of docker, the nodes are only used to locate the issues
+	config, err := loader.ParseYAML(content)
+	if err != nil {
+		return []LintIssue{yamlIssue(err)}
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil || len(document.Content) == 0 {
+		return []LintIssue{{Rule: LintRuleYAML, Severity: LintSeverityError, Message: "the compose file cannot be parsed"}}
+	}
+	root := document.Content[0]
+
+	issues := []LintIssue{}
+
+	if version := mappingValue(root, "version"); version != nil && strings.HasPrefix(version.Value, "3") {
+		schemaSeverity := LintSeverityWarning
+		if stackType == portainer.DockerSwarmStack {
+			schemaSeverity = LintSeverityError
+		}
+
+		if issue := schemaIssue(config, env, root); issue != nil {
+			issue.Severity = schemaSeverity
+			issues = append(issues, *issue)
+		}
+	}
+
+	services := mappingValue(root, "services")
+	if services == nil || services.Kind != yaml.MappingNode {
+		return issues
+	}
+
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		name, service := services.Content[i], services.Content[i+1]
+		if service.Kind != yaml.MappingNode {
+			continue
+		}
+
+		issues = append(issues, lintService(name, service, policy)...)
+	}
+
+	return issues
+}
+
+func lintService(name, service *yaml.Node, policy portainer.StackPolicySettings) []LintIssue {
+	issues := []LintIssue{}
+
+	if severity, ok := policySeverity(policy.LatestImageTag); ok {
+		if image := mappingValue(service, "image"); image != nil && usesLatestTag(image.Value) {
+			issues = append(issues, LintIssue{
+				Line:     image.Line,
+				Service:  name.Value,
+				Rule:     LintRuleLatestImageTag,
+				Severity: severity,
+				Message:  fmt.Sprintf("the image %s of the service %s uses the latest tag, a version tag or a digest is expected", image.Value, name.Value),
+			})
+		}
+	}
+
+	if severity, ok := policySeverity(policy.PrivilegedMode); ok {
+		if privileged := mappingValue(service, "privileged"); privileged != nil && isTrue(privileged.Value) {
+			issues = append(issues, LintIssue{
+				Line:     privileged.Line,
+				Service:  name.Value,
+				Rule:     LintRulePrivilegedMode,
+				Severity: severity,
+				Message:  fmt.Sprintf("the service %s runs in privileged mode", name.Value),
+			})
+		}
+	}
+
+	if severity, ok := policySeverity(policy.MissingLabels); ok && len(policy.RequiredLabels) > 0 {
+		labels := serviceLabels(service)
+
+		missing := []string{}
+		for _, label := range policy.RequiredLabels {
+			if !labels[label] {
+				missing = append(missing, label)
+			}
+		}
+
+		if len(missing) > 0 {
+			issues = append(issues, LintIssue{
+				Line:     name.Line,
+				Service:  name.Value,
+				Rule:     LintRuleMissingLabels,
+				Severity: severity,
+				Message:  fmt.Sprintf("the service %s misses the required labels %s", name.Value, strings.Join(missing, ", ")),
+			})
+		}
+	}
+
+	return issues
+}
+
+// isTrue returns whether a YAML value is a true boolean, including the YAML 1.1 forms read by docker
+func isTrue(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "y":
+		return true
+	}
+
+	return false
+}
+
+func policySeverity(action portainer.StackPolicyAction) (LintSeverity, bool) {
+	switch action {
+	case portainer.StackPolicyWarn:
+		return LintSeverityWarning, true
+	case portainer.StackPolicyBlock:
+		return LintSeverityError, true
+	}
+
+	return "", false
+}
+
+// usesLatestTag returns whether an image reference has the latest tag or no tag. The interpolated references are
+// not checked, as their tag is only known on deployment.
+func usesLatestTag(image string) bool {
+	if image == "" || strings.Contains(image, "$") || strings.Contains(image, "@") {
+		return false
+	}
+
+	name := image[strings.LastIndex(image, "/")+1:]
+	separator := strings.LastIndex(name, ":")
+	if separator == -1 {
+		return true
+	}
+
+	return name[separator+1:] == "latest"
+}
+
+// serviceLabels returns the names of the labels of a service and of its swarm deployment
+func serviceLabels(service *yaml.Node) map[string]bool {
+	labels := map[string]bool{}
+
+	nodes := []*yaml.Node{mappingValue(service, "labels")}
+	if deploy := mappingValue(service, "deploy"); deploy != nil {
+		nodes = append(nodes, mappingValue(deploy, "labels"))
+	}
+
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i < len(node.Content); i += 2 {
+				labels[node.Content[i].Value] = true
+			}
+		case yaml.SequenceNode:
+			for _, item := range node.Content {
+				key, _, _ := strings.Cut(item.Value, "=")
+				labels[key] = true
+			}
+		}
+	}
+
+	return labels
+}
+
+func yamlIssue(err error) LintIssue {
+	issue := LintIssue{Rule: LintRuleYAML, Severity: LintSeverityError, Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+
+	if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+		issue.Line, _ = strconv.Atoi(match[1])
+	}
+
+	return issue
+}
+
+// schemaIssue validates a compose file against the schema of its version, and locates the first invalid field
+func schemaIssue(config map[string]interface{}, env []portainer.Pair, root *yaml.Node) *LintIssue {
+	environment := make(map[string]string, len(env))
+	for _, pair := range env {
+		environment[pair.Name] = pair.Value
+	}
+
+	_, err := loader.Load(types.ConfigDetails{
+		ConfigFiles: []types.ConfigFile{{Config: config}},
+		Environment: environment,
+	})
+	if err != nil {
+		return &LintIssue{Line: fieldLine(root, err.Error()), Rule: LintRuleSchema, Message: err.Error()}
+	}
+
+	return nil
+}
+
+// fieldLine returns the line of the field a schema error starts with, such as services.web.ports.0, or of its closest
+// parent found in the compose file
+func fieldLine(root *yaml.Node, message string) int {
+	field, description, _ := strings.Cut(message, " ")
+
+	node := root
+	if field != "(root)" {
+		for _, key := range strings.Split(field, ".") {
+			child := childNode(node, key)
+			if child == nil {
+				break
+			}
+			node = child
+		}
+	}
+
+	// point to the property itself when it is not allowed
+	if property, ok := strings.CutPrefix(description, "Additional property "); ok {
+		property, _, _ = strings.Cut(property, " ")
+		if key := mappingKey(node, property); key != nil {
+			return key.Line
+		}
+	}
+
+	return node.Line
+}
+
+func childNode(node *yaml.Node, key string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return mappingValue(node, key)
+	case yaml.SequenceNode:
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(node.Content) {
+			return nil
+		}
+		return node.Content[index]
+	}
+
+	return nil
+}
+
+func mappingKey(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+
+	return nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// StackFileReader reads the content of the stack files, it is implemented by the file service
+type StackFileReader interface {
+	GetFileContent(trustedRoot, filePath string) ([]byte, error)
+}
+
+// LintStackFiles lints the compose files of a stack
+func LintStackFiles(stack *portainer.Stack, policy portainer.StackPolicySettings, fileService StackFileReader) ([]LintIssue, error) {
+	issues := []LintIssue{}
+
+	for _, file := range GetStackFilePaths(stack, false) {
+		content, err := fileService.GetFileContent(stack.ProjectPath, file)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get stack file content")
+		}
+
+		for _, issue := range LintComposeFile(content, stack.Env, stack.Type, policy) {
+			issue.File = file
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
+
+// ValidateStackPolicy lints the compose files of a stack before its deployment. The warnings are logged, and a
+// StackLintError is returned when an issue is blocking.
+func ValidateStackPolicy(stack *portainer.Stack, policy portainer.StackPolicySettings, fileService StackFileReader) error {
+	issues, err := LintStackFiles(stack, policy, fileService)
+	if err != nil {
+		return err
+	}
+
+	blocking := false
+	for _, issue := range issues {
+		if issue.Severity == LintSeverityError {
+			blocking = true
+			continue
+		}
+
+		log.Warn().
+			Str("stack", stack.Name).
+			Int("endpoint_id", int(stack.EndpointID)).
+			Str("rule", issue.Rule).
+			Msg(issue.String())
+	}
+
+	if blocking {
+		return &StackLintError{Issues: issues}
+	}
+
+	return nil
+}
+
+// DeploymentError returns the HTTP error of a failed stack deployment, which is a bad request when the compose files
+// have blocking issues
+func DeploymentError(err error) *httperror.HandlerError {
+	var lintErr *StackLintError
+	if errors.As(err, &lintErr) {
+		return httperror.BadRequest(err.Error(), err)
+	}
+
+	return httperror.InternalServerError(err.Error(), err)
+}
+
+// ValidateStackPolicySettings checks the actions of the stack policy checks
+func ValidateStackPolicySettings(policy portainer.StackPolicySettings) error {
+	for name, action := range map[string]portainer.StackPolicyAction{
+		"LatestImageTag": policy.LatestImageTag,
+		"PrivilegedMode": policy.PrivilegedMode,
+		"MissingLabels":  policy.MissingLabels,
+	} {
+		if action != "" && action != portainer.StackPolicyWarn && action != portainer.StackPolicyBlock {
+			return fmt.Errorf("invalid stack policy action %q for %s, warn or block is expected", action, name)
+		}
+	}
+
+	for _, label := range policy.RequiredLabels {
+		if strings.TrimSpace(label) == "" {
+			return errors.New("invalid stack policy, the required labels must not be empty")
+		}
+	}
+
+	return nil
+}
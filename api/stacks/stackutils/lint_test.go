@@ -0,0 +1,134 @@
+package stackutils
+
+import (
+	"errors"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LintComposeFile_Syntax(t *testing.T) {
+	is := assert.New(t)
+
+	issues := LintComposeFile([]byte("services:\n  web:\n    image: nginx:1.25\n    ports: [80\n"), nil, portainer.DockerComposeStack, portainer.StackPolicySettings{})
+	is.Len(issues, 1)
+	is.Equal(LintRuleYAML, issues[0].Rule)
+	is.Equal(LintSeverityError, issues[0].Severity)
+	is.Equal(4, issues[0].Line)
+
+	issues = LintComposeFile([]byte(""), nil, portainer.DockerComposeStack, portainer.StackPolicySettings{})
+	is.Len(issues, 1)
+	is.Equal(LintRuleYAML, issues[0].Rule)
+
+	issues = LintComposeFile([]byte("- web\n"), nil, portainer.DockerComposeStack, portainer.StackPolicySettings{})
+	is.Len(issues, 1)
+	is.Equal("Top-level object must be a mapping", issues[0].Message)
+}
+
+func Test_LintComposeFile_Schema(t *testing.T) {
+	is := assert.New(t)
+
+	content := []byte(`version: "3.8"
+services:
+  web:
+    image: nginx:1.25
+    imagee: nginx
+`)
+
+	issues := LintComposeFile(content, nil, portainer.DockerSwarmStack, portainer.StackPolicySettings{})
+	is.Len(issues, 1)
+	is.Equal(LintRuleSchema, issues[0].Rule)
+	is.Equal(LintSeverityError, issues[0].Severity)
+	is.Equal(5, issues[0].Line)
+
+	issues = LintComposeFile(content, nil, portainer.DockerComposeStack, portainer.StackPolicySettings{})
+	is.Len(issues, 1)
+	is.Equal(LintSeverityWarning, issues[0].Severity, "the schema issues only warn for compose stacks")
+
+	content = []byte(`version: "3.8"
+services:
+  web:
+    image: nginx:1.25
+    deploy:
+      replicas: ${REPLICAS}
+`)
+	is.Empty(LintComposeFile(content, []portainer.Pair{{Name: "REPLICAS", Value: "2"}}, portainer.DockerSwarmStack, portainer.StackPolicySettings{}))
+
+	is.Empty(LintComposeFile([]byte("services:\n  web:\n    image: nginx:1.25\n    profiles: [debug]\n"), nil, portainer.DockerSwarmStack, portainer.StackPolicySettings{}),
+		"the compose files without version are not validated against the version 3 schema")
+}
+
+func Test_LintComposeFile_Policy(t *testing.T) {
+	is := assert.New(t)
+
+	content := []byte(`services:
+  web:
+    image: nginx
+    privileged: true
+    labels:
+      com.example.team: web
+  db:
+    image: registry.example.com:5000/postgres:16
+    deploy:
+      labels:
+        - com.example.team=data
+  cache:
+    image: redis:latest
+  worker:
+    image: worker@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+    labels: [com.example.team=jobs]
+`)
+
+	is.Empty(LintComposeFile(content, nil, portainer.DockerComposeStack, portainer.StackPolicySettings{RequiredLabels: []string{"com.example.team"}}),
+		"the checks are disabled without action")
+
+	issues := LintComposeFile(content, nil, portainer.DockerComposeStack, portainer.StackPolicySettings{
+		LatestImageTag: portainer.StackPolicyWarn,
+		PrivilegedMode: portainer.StackPolicyBlock,
+		RequiredLabels: []string{"com.example.team"},
+		MissingLabels:  portainer.StackPolicyWarn,
+	})
+
+	is.Equal([]LintIssue{
+		{Line: 3, Service: "web", Rule: LintRuleLatestImageTag, Severity: LintSeverityWarning, Message: issues[0].Message},
+		{Line: 4, Service: "web", Rule: LintRulePrivilegedMode, Severity: LintSeverityError, Message: issues[1].Message},
+		{Line: 13, Service: "cache", Rule: LintRuleLatestImageTag, Severity: LintSeverityWarning, Message: issues[2].Message},
+		{Line: 12, Service: "cache", Rule: LintRuleMissingLabels, Severity: LintSeverityWarning, Message: issues[3].Message},
+	}, issues)
+	is.Contains(issues[3].Message, "com.example.team")
+}
+
+func Test_usesLatestTag(t *testing.T) {
+	is := assert.New(t)
+
+	is.True(usesLatestTag("nginx"))
+	is.True(usesLatestTag("nginx:latest"))
+	is.True(usesLatestTag("registry.example.com:5000/nginx"))
+	is.False(usesLatestTag("registry.example.com:5000/nginx:1.25"))
+	is.False(usesLatestTag("nginx@sha256:0123"))
+	is.False(usesLatestTag("nginx:${TAG}"))
+}
+
+func Test_DeploymentError(t *testing.T) {
+	is := assert.New(t)
+
+	lintErr := &StackLintError{Issues: []LintIssue{
+		{File: "docker-compose.yml", Line: 4, Severity: LintSeverityError, Message: "the service web runs in privileged mode"},
+		{File: "docker-compose.yml", Line: 3, Severity: LintSeverityWarning, Message: "the image nginx of the service web uses the latest tag"},
+	}}
+	is.Equal("stack config file is invalid: docker-compose.yml:4: the service web runs in privileged mode", lintErr.Error())
+
+	is.Equal(400, DeploymentError(lintErr).StatusCode)
+	is.Equal(500, DeploymentError(errors.New("deployment failed")).StatusCode)
+}
+
+func Test_ValidateStackPolicySettings(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateStackPolicySettings(portainer.StackPolicySettings{}))
+	is.NoError(ValidateStackPolicySettings(portainer.StackPolicySettings{LatestImageTag: portainer.StackPolicyBlock, RequiredLabels: []string{"team"}, MissingLabels: portainer.StackPolicyWarn}))
+	is.Error(ValidateStackPolicySettings(portainer.StackPolicySettings{PrivilegedMode: "deny"}))
+	is.Error(ValidateStackPolicySettings(portainer.StackPolicySettings{RequiredLabels: []string{" "}}))
+}
@@ -0,0 +1,127 @@
+// Package statuspage builds the public status page, a curated and anonymous view of the health of a selection of
+// environments, for the wall displays of the operation centers
+package statuspage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+)
+
+const (
+	// StatusUp is the status of the environments which are reachable
+	StatusUp = "up"
+	// StatusDown is the status of the environments which are unreachable
+	StatusDown = "down"
+)
+
+// Page is the content of the status page
+type Page struct {
+	// Title of the status page
+	Title string `json:"Title,omitempty" example:"Production"`
+	// Number of the environments which are reachable
+	Up int `json:"Up" example:"4"`
+	// Number of the environments which are unreachable
+	Down int `json:"Down" example:"1"`
+	// Environments of the status page
+	Environments []Environment `json:"Environments"`
+}
+
+// Environment is the status of an environment of the status page
+type Environment struct {
+	// Name of the environment, replaced by its number when the names are hidden
+	Name string `json:"Name" example:"production"`
+	// Status of the environment, up or down
+	Status string `json:"Status" example:"up"`
+	// Unix timestamp of the last snapshot of the environment
+	LastCheck int64 `json:"LastCheck,omitempty" example:"1700000000"`
+	// Number of the running containers, for the Docker environments
+	RunningContainers int `json:"RunningContainers,omitempty" example:"12"`
+	// Number of the stopped containers, for the Docker environments
+	StoppedContainers int `json:"StoppedContainers,omitempty" example:"1"`
+	// Number of the containers whose health check fails, for the Docker environments
+	UnhealthyContainers int `json:"UnhealthyContainers,omitempty" example:"0"`
+}
+
+// ValidateSettings checks that an enabled status page displays at least one environment or environment group
+func ValidateSettings(settings portainer.StatusPageSettings) error {
+	if settings.Enabled && len(settings.EndpointIDs) == 0 && len(settings.EndpointGroupIDs) == 0 {
+		return errors.New("invalid status page, at least one environment or environment group is required")
+	}
+
+	return nil
+}
+
+// Build computes the status page of the environments selected by the settings, ordered by identifier
+func Build(dataStore dataservices.DataStore, settings *portainer.Settings) (*Page, error) {
+	endpoints, err := dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints = slices.DeleteFunc(endpoints, func(endpoint portainer.Endpoint) bool {
+		return !slices.Contains(settings.StatusPage.EndpointIDs, endpoint.ID) && !slices.Contains(settings.StatusPage.EndpointGroupIDs, endpoint.GroupID)
+	})
+
+	slices.SortFunc(endpoints, func(a, b portainer.Endpoint) int {
+		return int(a.ID) - int(b.ID)
+	})
+
+	page := &Page{Title: settings.StatusPage.Title, Environments: make([]Environment, 0, len(endpoints))}
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+
+		environment := Environment{Name: endpoint.Name, Status: status(endpoint, settings)}
+		if settings.StatusPage.HideNames {
+			environment.Name = fmt.Sprintf("Environment %d", i+1)
+		}
+
+		snapshot, err := dataStore.Snapshot().Read(endpoint.ID)
+		if err != nil && !dataStore.IsErrObjectNotFound(err) {
+			return nil, err
+		}
+
+		if snapshot != nil && snapshot.Docker != nil {
+			environment.LastCheck = snapshot.Docker.Time
+			environment.RunningContainers = snapshot.Docker.RunningContainerCount
+			environment.StoppedContainers = snapshot.Docker.StoppedContainerCount
+			environment.UnhealthyContainers = snapshot.Docker.UnhealthyContainerCount
+		} else if snapshot != nil && snapshot.Kubernetes != nil {
+			environment.LastCheck = snapshot.Kubernetes.Time
+		}
+
+		if environment.Status == StatusUp {
+			page.Up++
+		} else {
+			page.Down++
+		}
+
+		page.Environments = append(page.Environments, environment)
+	}
+
+	return page, nil
+}
+
+// status returns the status of the environment, the Edge environments being up while their agent checks in
+func status(endpoint *portainer.Endpoint, settings *portainer.Settings) string {
+	if endpointutils.IsEdgeEndpoint(endpoint) {
+		endpointutils.UpdateEdgeEndpointHeartbeat(endpoint, settings)
+
+		if endpoint.Heartbeat {
+			return StatusUp
+		}
+
+		return StatusDown
+	}
+
+	if endpoint.Status == portainer.EndpointStatusUp {
+		return StatusUp
+	}
+
+	return StatusDown
+}
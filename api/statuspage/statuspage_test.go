@@ -0,0 +1,64 @@
+package statuspage
+
+import (
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSettings(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateSettings(portainer.StatusPageSettings{}))
+	is.Error(ValidateSettings(portainer.StatusPageSettings{Enabled: true}))
+	is.NoError(ValidateSettings(portainer.StatusPageSettings{Enabled: true, EndpointGroupIDs: []portainer.EndpointGroupID{1}}))
+}
+
+func TestBuild(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Name: "production", GroupID: 2, Type: portainer.DockerEnvironment, Status: portainer.EndpointStatusUp}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 2, Name: "staging", GroupID: 1, Type: portainer.DockerEnvironment, Status: portainer.EndpointStatusDown}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 3, Name: "internal", GroupID: 1, Type: portainer.DockerEnvironment, Status: portainer.EndpointStatusUp}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 4, Name: "store-42", GroupID: 2, Type: portainer.EdgeAgentOnDockerEnvironment, Status: portainer.EndpointStatusUp}))
+
+	is.NoError(store.Snapshot().Create(&portainer.Snapshot{EndpointID: 1, Docker: &portainer.DockerSnapshot{
+		Time: 1700000000, RunningContainerCount: 12, StoppedContainerCount: 1, UnhealthyContainerCount: 2,
+	}}))
+
+	settings := &portainer.Settings{StatusPage: portainer.StatusPageSettings{
+		Enabled:          true,
+		Title:            "Production",
+		EndpointIDs:      []portainer.EndpointID{2},
+		EndpointGroupIDs: []portainer.EndpointGroupID{2},
+	}}
+
+	page, err := Build(store, settings)
+	is.NoError(err)
+
+	is.Equal("Production", page.Title)
+	is.Equal(1, page.Up)
+	is.Equal(2, page.Down, "the edge environment which never checked in is down")
+
+	if is.Len(page.Environments, 3, "the other environments of the groups are not displayed") {
+		is.Equal(Environment{Name: "production", Status: StatusUp, LastCheck: 1700000000, RunningContainers: 12, StoppedContainers: 1, UnhealthyContainers: 2}, page.Environments[0])
+		is.Equal(Environment{Name: "staging", Status: StatusDown}, page.Environments[1])
+		is.Equal("store-42", page.Environments[2].Name)
+	}
+
+	settings.StatusPage.HideNames = true
+
+	page, err = Build(store, settings)
+	is.NoError(err)
+
+	names := []string{}
+	for _, environment := range page.Environments {
+		names = append(names, environment.Name)
+	}
+	is.Equal([]string{"Environment 1", "Environment 2", "Environment 3"}, names)
+}
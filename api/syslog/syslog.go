@@ -0,0 +1,373 @@
+// Package syslog forwards the audit events and the system logs to a remote syslog server in the RFC 5424 format,
+// over UDP, TCP or TLS
+package syslog
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/crypto"
+	"github.com/portainer/portainer/api/lifecycle"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	ProtocolUDP = "udp"
+	ProtocolTCP = "tcp"
+	ProtocolTLS = "tls"
+
+	appName = "portainer"
+	// structuredDataID identifies the parameters of the audit events, 32473 is the private enterprise number
+	// reserved for the documentation by RFC 5612
+	structuredDataID = "event@32473"
+
+	facilityAudit  = 13 // log audit
+	facilityLocal0 = 16 // local use 0, for the system logs
+
+	severityCritical = 2
+	severityError    = 3
+	severityWarning  = 4
+	severityNotice   = 5
+	severityInfo     = 6
+	severityDebug    = 7
+
+	// queueSize is the number of messages waiting to be sent, the messages written while it is full are dropped
+	queueSize = 1024
+	// maxDatagramSize truncates the messages sent over UDP
+	maxDatagramSize = 8192
+	timeout         = 5 * time.Second
+)
+
+type config struct {
+	settings  portainer.SyslogSettings
+	level     zerolog.Level
+	tlsConfig *tls.Config
+}
+
+type message struct {
+	config *config
+	data   []byte
+}
+
+// Forwarder sends the audit events and the system logs to the syslog server of the settings. The messages are
+// queued and sent in the background, so that a slow or unreachable server never blocks the callers.
+type Forwarder struct {
+	hostname string
+	current  atomic.Pointer[config]
+	queue    chan message
+}
+
+// NewForwarder creates a forwarder to the syslog server of the settings, which must have been validated
+func NewForwarder(settings portainer.SyslogSettings) *Forwarder {
+	hostname, _ := os.Hostname()
+
+	f := &Forwarder{
+		hostname: hostname,
+		queue:    make(chan message, queueSize),
+	}
+	f.Update(settings)
+
+	return f
+}
+
+// Update replaces the syslog server with the one of the settings, which must have been validated
+func (f *Forwarder) Update(settings portainer.SyslogSettings) {
+	if !settings.Enabled {
+		f.current.Store(nil)
+		return
+	}
+
+	c := &config{settings: settings, level: parseLevel(settings.Level)}
+
+	if settings.Protocol == ProtocolTLS {
+		c.tlsConfig = crypto.CreateTLSConfiguration()
+		c.tlsConfig.InsecureSkipVerify = settings.TLSSkipVerify
+
+		if settings.TLSCACert != "" {
+			pool := x509.NewCertPool()
+			pool.AppendCertsFromPEM([]byte(settings.TLSCACert))
+			c.tlsConfig.RootCAs = pool
+		}
+	}
+
+	f.current.Store(c)
+}
+
+// Start sends the queued messages until the context is done
+func (f *Forwarder) Start(ctx context.Context) {
+	go func() {
+		s := &sender{}
+		defer s.close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-f.queue:
+				s.send(msg)
+			}
+		}
+	}()
+}
+
+// WriteLevel forwards the system logs of the level of the settings or above, it implements zerolog.LevelWriter
+func (f *Forwarder) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	c := f.current.Load()
+	if c == nil || level < c.level || level == zerolog.NoLevel {
+		return len(p), nil
+	}
+
+	f.enqueue(c, f.format(facilityLocal0, severityOf(level), "log", "-", string(bytes.TrimSpace(p))))
+
+	return len(p), nil
+}
+
+// Write drops the logs without a level, it implements io.Writer
+func (f *Forwarder) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// ForwardEvent forwards a lifecycle event as an audit event, its details being sent as structured data
+func (f *Forwarder) ForwardEvent(event lifecycle.Event) {
+	c := f.current.Load()
+	if c == nil {
+		return
+	}
+
+	params := map[string]string{
+		"id":         event.ID,
+		"resourceId": event.ResourceID,
+	}
+	for key, value := range event.Data {
+		params[key] = value
+	}
+
+	text := fmt.Sprintf("%s on resource %s", event.Type, event.ResourceID)
+
+	f.enqueue(c, f.format(facilityAudit, severityNotice, event.Type, structuredData(params), text))
+}
+
+func (f *Forwarder) enqueue(c *config, data []byte) {
+	select {
+	case f.queue <- message{config: c, data: data}:
+	default:
+		// logging the drop would be forwarded again
+	}
+}
+
+// format builds a RFC 5424 message
+func (f *Forwarder) format(facility, severity int, msgID, structuredData, text string) []byte {
+	return []byte(fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		facility*8+severity,
+		time.Now().UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		headerField(f.hostname, 255),
+		appName,
+		os.Getpid(),
+		headerField(msgID, 32),
+		structuredData,
+		text,
+	))
+}
+
+// sender holds the connection to the syslog server, which is dialed again when the settings change or after a failure
+type sender struct {
+	config  *config
+	conn    net.Conn
+	failing bool
+}
+
+func (s *sender) send(msg message) {
+	err := s.write(msg)
+	if err != nil {
+		s.close()
+
+		// the failures are only logged once, as the log is forwarded as well
+		if !s.failing {
+			log.Warn().Err(err).Str("address", msg.config.settings.Address).Msg("unable to forward the logs to the syslog server")
+		}
+		s.failing = true
+
+		return
+	}
+
+	if s.failing {
+		s.failing = false
+		log.Info().Str("address", msg.config.settings.Address).Msg("forwarding the logs to the syslog server again")
+	}
+}
+
+func (s *sender) write(msg message) error {
+	if s.conn == nil || s.config != msg.config {
+		s.close()
+
+		conn, err := dial(msg.config)
+		if err != nil {
+			return err
+		}
+
+		s.conn = conn
+		s.config = msg.config
+	}
+
+	data := msg.data
+	if s.config.settings.Protocol == ProtocolUDP {
+		if len(data) > maxDatagramSize {
+			data = data[:maxDatagramSize]
+		}
+	} else {
+		// octet counting framing of RFC 6587 and RFC 5425
+		data = append([]byte(strconv.Itoa(len(data))+" "), data...)
+	}
+
+	if err := s.conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	_, err := s.conn.Write(data)
+
+	return err
+}
+
+func (s *sender) close() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+func dial(c *config) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+
+	switch c.settings.Protocol {
+	case ProtocolUDP:
+		return dialer.Dial("udp", c.settings.Address)
+	case ProtocolTLS:
+		return tls.DialWithDialer(dialer, "tcp", c.settings.Address, c.tlsConfig)
+	default:
+		return dialer.Dial("tcp", c.settings.Address)
+	}
+}
+
+// ValidateSettings checks the syslog settings
+func ValidateSettings(settings portainer.SyslogSettings) error {
+	if !settings.Enabled {
+		return nil
+	}
+
+	switch settings.Protocol {
+	case ProtocolUDP, ProtocolTCP, ProtocolTLS:
+	default:
+		return fmt.Errorf("invalid syslog protocol %q, udp, tcp or tls is expected", settings.Protocol)
+	}
+
+	host, port, err := net.SplitHostPort(settings.Address)
+	if err != nil || host == "" || port == "" {
+		return fmt.Errorf("invalid syslog address %q, host:port is expected", settings.Address)
+	}
+
+	switch strings.ToUpper(settings.Level) {
+	case "", "ERROR", "WARN", "INFO":
+	default:
+		return fmt.Errorf("invalid syslog level %q, ERROR, WARN or INFO is expected", settings.Level)
+	}
+
+	if settings.TLSCACert != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(settings.TLSCACert)) {
+		return errors.New("invalid syslog CA certificate, a PEM encoded certificate is expected")
+	}
+
+	return nil
+}
+
+func parseLevel(level string) zerolog.Level {
+	switch strings.ToUpper(level) {
+	case "ERROR":
+		return zerolog.ErrorLevel
+	case "INFO":
+		return zerolog.InfoLevel
+	default:
+		return zerolog.WarnLevel
+	}
+}
+
+func severityOf(level zerolog.Level) int {
+	switch level {
+	case zerolog.PanicLevel, zerolog.FatalLevel:
+		return severityCritical
+	case zerolog.ErrorLevel:
+		return severityError
+	case zerolog.WarnLevel:
+		return severityWarning
+	case zerolog.InfoLevel:
+		return severityInfo
+	default:
+		return severityDebug
+	}
+}
+
+// headerField replaces the characters not allowed in the header fields, which are printable US-ASCII characters
+func headerField(value string, maxLength int) string {
+	field := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+
+		return r
+	}, value)
+
+	if field == "" {
+		return "-"
+	}
+
+	if len(field) > maxLength {
+		field = field[:maxLength]
+	}
+
+	return field
+}
+
+// structuredData formats the parameters as a structured data element, sorted by name
+func structuredData(params map[string]string) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("[" + structuredDataID)
+
+	for _, name := range names {
+		paramName := strings.Map(func(r rune) rune {
+			if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+				return '_'
+			}
+
+			return r
+		}, name)
+		if len(paramName) > 32 {
+			paramName = paramName[:32]
+		}
+
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(params[name])
+
+		b.WriteString(" " + paramName + `="` + value + `"`)
+	}
+
+	b.WriteString("]")
+
+	return b.String()
+}
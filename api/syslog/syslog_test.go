@@ -0,0 +1,132 @@
+package syslog
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/lifecycle"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForwarder_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	forwarder := NewForwarder(portainer.SyslogSettings{
+		Enabled:  true,
+		Protocol: ProtocolUDP,
+		Address:  conn.LocalAddr().String(),
+		Level:    "ERROR",
+	})
+	forwarder.Start(ctx)
+
+	// below the level of the settings
+	forwarder.WriteLevel(zerolog.WarnLevel, []byte(`{"level":"warn","message":"ignored"}`))
+	forwarder.WriteLevel(zerolog.ErrorLevel, []byte(`{"level":"error","message":"failed"}`+"\n"))
+
+	buf := make([]byte, maxDatagramSize)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+
+	msg := string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<131>1 "), msg) // local0.err
+	assert.True(t, strings.HasSuffix(msg, ` portainer `+strconv.Itoa(os.Getpid())+` log - {"level":"error","message":"failed"}`), msg)
+}
+
+func TestForwarder_TCPAuditEvents(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	forwarder := NewForwarder(portainer.SyslogSettings{
+		Enabled:  true,
+		Protocol: ProtocolTCP,
+		Address:  listener.Addr().String(),
+	})
+	forwarder.Start(ctx)
+
+	forwarder.ForwardEvent(lifecycle.Event{
+		ID:         "6a1e",
+		Type:       lifecycle.UserLoggedIn,
+		ResourceID: "3",
+		Data:       map[string]string{"username": `bob "the" admin`},
+	})
+
+	conn, err := listener.Accept()
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	length, err := reader.ReadString(' ')
+	assert.NoError(t, err)
+
+	size, err := strconv.Atoi(strings.TrimSpace(length))
+	assert.NoError(t, err)
+
+	buf := make([]byte, size)
+	_, err = reader.Read(buf)
+	assert.NoError(t, err)
+
+	msg := string(buf)
+	assert.True(t, strings.HasPrefix(msg, "<109>1 "), msg) // log audit.notice
+	assert.Contains(t, msg, ` user.login [event@32473 id="6a1e" resourceId="3" username="bob \"the\" admin"] user.login on resource 3`)
+}
+
+func TestForwarder_Disabled(t *testing.T) {
+	forwarder := NewForwarder(portainer.SyslogSettings{})
+
+	forwarder.WriteLevel(zerolog.ErrorLevel, []byte(`{"level":"error"}`))
+	forwarder.ForwardEvent(lifecycle.NewEvent(lifecycle.EndpointCreated, "1", nil))
+
+	assert.Empty(t, forwarder.queue)
+}
+
+func TestValidateSettings(t *testing.T) {
+	valid := []portainer.SyslogSettings{
+		{},
+		{Enabled: true, Protocol: ProtocolUDP, Address: "siem:514"},
+		{Enabled: true, Protocol: ProtocolTLS, Address: "10.0.0.1:6514", Level: "INFO"},
+	}
+
+	for _, settings := range valid {
+		assert.NoError(t, ValidateSettings(settings), settings)
+	}
+
+	invalid := []portainer.SyslogSettings{
+		{Enabled: true, Address: "siem:514"},
+		{Enabled: true, Protocol: ProtocolTCP, Address: "siem"},
+		{Enabled: true, Protocol: ProtocolTCP, Address: ":514"},
+		{Enabled: true, Protocol: ProtocolTCP, Address: "siem:514", Level: "DEBUG"},
+		{Enabled: true, Protocol: ProtocolTLS, Address: "siem:6514", TLSCACert: "not a certificate"},
+	}
+
+	for _, settings := range invalid {
+		assert.Error(t, ValidateSettings(settings), settings)
+	}
+}
+
+func TestHeaderField(t *testing.T) {
+	assert.Equal(t, "-", headerField("", 32))
+	assert.Equal(t, "my_host", headerField("my host", 32))
+	assert.Equal(t, "abc", headerField("abcdef", 3))
+}
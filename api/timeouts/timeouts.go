@@ -0,0 +1,49 @@
+// Package timeouts holds the timeouts of the subsystems reaching the environments and the external services, so
+// that they can be tuned separately, e.g. long snapshots over slow satellite links along with quick pings for the UI.
+package timeouts
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Timeouts are the timeouts of the subsystems, 0 meaning no timeout unless stated otherwise
+type Timeouts struct {
+	// EndpointPing bounds the pings checking that a Docker environment or an agent is reachable
+	EndpointPing time.Duration
+	// Snapshot bounds each request of the snapshots of the Docker environments and the whole snapshot of the
+	// Kubernetes environments
+	Snapshot time.Duration
+	// ProxyRequest bounds the wait for the response headers of the requests proxied to the Docker environments, the
+	// streamed responses such as the logs being unaffected once their headers are received
+	ProxyRequest time.Duration
+	// AzureToken bounds the requests retrieving the access tokens of the Azure environments
+	AzureToken time.Duration
+	// TemplateFetch bounds the retrieval of the app templates, of the edge templates and of the manifests
+	// deployed from a URL
+	TemplateFetch time.Duration
+}
+
+// Default are the timeouts used until Configure is called
+var Default = Timeouts{
+	EndpointPing:  3 * time.Second,
+	Snapshot:      60 * time.Second,
+	AzureToken:    5 * time.Second,
+	TemplateFetch: 30 * time.Second,
+}
+
+var current atomic.Pointer[Timeouts]
+
+// Configure sets the timeouts of the subsystems
+func Configure(timeouts Timeouts) {
+	current.Store(&timeouts)
+}
+
+// Current returns the timeouts of the subsystems
+func Current() Timeouts {
+	if timeouts := current.Load(); timeouts != nil {
+		return *timeouts
+	}
+
+	return Default
+}
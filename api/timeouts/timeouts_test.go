@@ -0,0 +1,19 @@
+package timeouts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigure(t *testing.T) {
+	is := assert.New(t)
+
+	is.Equal(Default, Current())
+
+	Configure(Timeouts{EndpointPing: time.Second, Snapshot: 5 * time.Minute})
+	defer current.Store(nil)
+
+	is.Equal(Timeouts{EndpointPing: time.Second, Snapshot: 5 * time.Minute}, Current())
+}
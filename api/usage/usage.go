@@ -0,0 +1,249 @@
+// Package usage reports the number of resources managed by Portainer, for chargeback and internal reporting
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/database/boltdb"
+	"github.com/portainer/portainer/api/dataservices"
+	"github.com/portainer/portainer/api/http/client"
+	"github.com/portainer/portainer/api/internal/endpointutils"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const exportTimeout = 30 * time.Second
+
+// endpointTypeNames are the keys of the environment counts of the report
+var endpointTypeNames = map[portainer.EndpointType]string{
+	portainer.DockerEnvironment:                "docker",
+	portainer.AgentOnDockerEnvironment:         "agent-docker",
+	portainer.AzureEnvironment:                 "azure",
+	portainer.EdgeAgentOnDockerEnvironment:     "edge-agent-docker",
+	portainer.KubernetesLocalEnvironment:       "kubernetes-local",
+	portainer.AgentOnKubernetesEnvironment:     "agent-kubernetes",
+	portainer.EdgeAgentOnKubernetesEnvironment: "edge-agent-kubernetes",
+	portainer.DockerSSHEnvironment:             "docker-ssh",
+}
+
+// Report represents the usage of the Portainer instance
+type Report struct {
+	// Unix timestamp of the report
+	Timestamp int64 `json:"timestamp" example:"1700000000"`
+	// Identifier of the Portainer instance
+	InstanceID string `json:"instanceId" example:"299ab403-70a8-4c05-92f7-bf7a994d50df"`
+	// Total number of environments
+	Endpoints int `json:"endpoints" example:"12"`
+	// Number of environments by type
+	EndpointsByType map[string]int `json:"endpointsByType"`
+	// Number of Edge devices, i.e. environments managed by an Edge agent, including the ones in the waiting room
+	EdgeDevices int `json:"edgeDevices" example:"8"`
+	// Number of Edge devices waiting to be trusted
+	EdgeDevicesUntrusted int `json:"edgeDevicesUntrusted" example:"1"`
+	Users                int `json:"users" example:"25"`
+	Teams                int `json:"teams" example:"4"`
+	Stacks               int `json:"stacks" example:"30"`
+	EdgeStacks           int `json:"edgeStacks" example:"3"`
+	// Size of the database file in bytes
+	DatabaseSize int64 `json:"databaseSize" example:"1048576"`
+}
+
+// Service computes the usage report and exports it on a schedule
+type Service struct {
+	dataStore dataservices.DataStore
+	dataPath  string
+	job       *scheduler.PeriodicJob
+	client    *http.Client
+}
+
+// NewService creates a usage service, dataPath being the folder of the database
+func NewService(dataStore dataservices.DataStore, dataPath string, scheduler *scheduler.Scheduler) *Service {
+	return &Service{
+		dataStore: dataStore,
+		dataPath:  dataPath,
+		job:       scheduler.NewPeriodicJob(),
+		client:    client.NewExternalClient(exportTimeout),
+	}
+}
+
+// Start schedules the export according to the settings stored in the database
+func (service *Service) Start() error {
+	settings, err := service.dataStore.Settings().Settings()
+	if err != nil {
+		return err
+	}
+
+	return service.Configure(settings.UsageReport)
+}
+
+// Configure replaces the scheduled export with one matching the settings
+func (service *Service) Configure(settings portainer.UsageReportSettings) error {
+	if err := ValidateSettings(settings); err != nil {
+		return err
+	}
+
+	if !settings.Enabled {
+		return service.job.Stop()
+	}
+
+	interval, err := parseInterval(settings.Interval)
+	if err != nil {
+		return err
+	}
+
+	exportURL := settings.URL
+
+	return service.job.Schedule(interval, func() error {
+		if err := service.Export(context.Background(), exportURL); err != nil {
+			log.Warn().Err(err).Msg("unable to export the usage report")
+		}
+
+		return nil
+	})
+}
+
+// ValidateSettings checks that the usage report export can be scheduled
+func ValidateSettings(settings portainer.UsageReportSettings) error {
+	if _, err := parseInterval(settings.Interval); err != nil {
+		return err
+	}
+
+	if !settings.Enabled {
+		return nil
+	}
+
+	u, err := url.Parse(settings.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("invalid usage report URL, an absolute http or https URL is expected")
+	}
+
+	return nil
+}
+
+func parseInterval(interval string) (time.Duration, error) {
+	return scheduler.ParseInterval(interval, portainer.DefaultUsageReportInterval, "usage report")
+}
+
+// Report computes the current usage of the instance
+func (service *Service) Report() (*Report, error) {
+	report := &Report{
+		Timestamp:       time.Now().Unix(),
+		EndpointsByType: make(map[string]int),
+	}
+
+	version, err := service.dataStore.Version().Version()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve the instance version")
+	}
+	report.InstanceID = version.InstanceID
+
+	endpoints, err := service.dataStore.Endpoint().Endpoints()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve the environments")
+	}
+
+	report.Endpoints = len(endpoints)
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+
+		report.EndpointsByType[endpointTypeName(endpoint.Type)]++
+
+		if endpointutils.IsEdgeEndpoint(endpoint) {
+			report.EdgeDevices++
+
+			if !endpoint.UserTrusted {
+				report.EdgeDevicesUntrusted++
+			}
+		}
+	}
+
+	users, err := service.dataStore.User().ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve the users")
+	}
+	report.Users = len(users)
+
+	teams, err := service.dataStore.Team().ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve the teams")
+	}
+	report.Teams = len(teams)
+
+	stacks, err := service.dataStore.Stack().ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve the stacks")
+	}
+	report.Stacks = len(stacks)
+
+	edgeStacks, err := service.dataStore.EdgeStack().EdgeStacks()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to retrieve the Edge stacks")
+	}
+	report.EdgeStacks = len(edgeStacks)
+
+	report.DatabaseSize = service.databaseSize()
+
+	return report, nil
+}
+
+// databaseSize returns the size of the database file, encrypted or not
+func (service *Service) databaseSize() int64 {
+	for _, name := range []string{boltdb.EncryptedDatabaseFileName, boltdb.DatabaseFileName} {
+		info, err := os.Stat(filepath.Join(service.dataPath, name))
+		if err == nil {
+			return info.Size()
+		}
+	}
+
+	return 0
+}
+
+// Export posts the usage report to the URL
+func (service *Service) Export(ctx context.Context, exportURL string) error {
+	report, err := service.Report()
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, exportURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := service.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	return nil
+}
+
+func endpointTypeName(endpointType portainer.EndpointType) string {
+	if name, ok := endpointTypeNames[endpointType]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("type-%d", endpointType)
+}
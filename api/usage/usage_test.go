@@ -0,0 +1,72 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Type: portainer.DockerEnvironment}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 2, Type: portainer.EdgeAgentOnDockerEnvironment, UserTrusted: true}))
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 3, Type: portainer.EdgeAgentOnKubernetesEnvironment}))
+	is.NoError(store.User().Create(&portainer.User{Username: "admin", Role: portainer.AdministratorRole}))
+	is.NoError(store.Team().Create(&portainer.Team{Name: "devs"}))
+	is.NoError(store.Stack().Create(&portainer.Stack{ID: 1, Name: "web"}))
+
+	service := NewService(store, t.TempDir(), nil)
+
+	report, err := service.Report()
+	is.NoError(err)
+
+	is.Equal(3, report.Endpoints)
+	is.Equal(map[string]int{"docker": 1, "edge-agent-docker": 1, "edge-agent-kubernetes": 1}, report.EndpointsByType)
+	is.Equal(2, report.EdgeDevices)
+	is.Equal(1, report.EdgeDevicesUntrusted)
+	is.Equal(1, report.Users)
+	is.Equal(1, report.Teams)
+	is.Equal(1, report.Stacks)
+	is.Zero(report.EdgeStacks)
+}
+
+func TestExport(t *testing.T) {
+	is := assert.New(t)
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+	is.NoError(store.Endpoint().Create(&portainer.Endpoint{ID: 1, Type: portainer.AgentOnDockerEnvironment}))
+
+	received := make(chan Report, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report Report
+		is.NoError(json.NewDecoder(r.Body).Decode(&report))
+		received <- report
+	}))
+	defer server.Close()
+
+	service := NewService(store, t.TempDir(), nil)
+	is.NoError(service.Export(context.Background(), server.URL))
+
+	report := <-received
+	is.Equal(1, report.EndpointsByType["agent-docker"])
+}
+
+func TestValidateSettings(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateSettings(portainer.UsageReportSettings{}))
+	is.NoError(ValidateSettings(portainer.UsageReportSettings{Enabled: true, URL: "https://reports.example.com", Interval: "1h"}))
+	is.Error(ValidateSettings(portainer.UsageReportSettings{Enabled: true, URL: "reports.example.com"}))
+	is.Error(ValidateSettings(portainer.UsageReportSettings{Enabled: true, URL: "https://reports.example.com", Interval: "-1h"}))
+	is.Error(ValidateSettings(portainer.UsageReportSettings{Interval: "daily"}))
+}
@@ -0,0 +1,46 @@
+package volumebackups
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/jobs"
+	"github.com/portainer/portainer/api/scheduler"
+)
+
+// BackupJobType is the type of the background jobs running the scheduled volume backups
+const BackupJobType = "volume.backup"
+
+// BackupJobPayload represents the volume backup job run by a background job
+type BackupJobPayload struct {
+	JobID portainer.VolumeBackupJobID
+}
+
+// RegisterJobs runs the scheduled backups through the job queue, the failed backups being retried
+func (service *Service) RegisterJobs(queue *jobs.Queue) {
+	queue.Register(BackupJobType, jobs.Options{Concurrency: 2, Backoff: time.Minute}, func(ctx context.Context, job *portainer.BackgroundJob) error {
+		var payload BackupJobPayload
+		if err := jobs.DecodePayload(job, &payload); err != nil {
+			return scheduler.NewPermanentError(err)
+		}
+
+		record, err := service.Backup(ctx, payload.JobID)
+		if errors.Is(err, ErrBackupInProgress) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if !record.Success {
+			return errors.New(record.Error)
+		}
+
+		return nil
+	})
+
+	service.mu.Lock()
+	service.jobQueue = queue
+	service.mu.Unlock()
+}
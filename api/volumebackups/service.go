@@ -0,0 +1,354 @@
+// Package volumebackups archives the contents of the named volumes to a local directory or an S3 bucket, and
+// restores the archives onto the same or another environment
+package volumebackups
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/dataservices"
+	dockerclient "github.com/portainer/portainer/api/docker/client"
+	"github.com/portainer/portainer/api/jobs"
+	"github.com/portainer/portainer/api/maintenance"
+	"github.com/portainer/portainer/api/scheduler"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// minInterval is the shortest interval between two scheduled backups of a volume
+	minInterval = 5 * time.Minute
+	// archivesDirectory is the directory of the data path storing the local archives of the jobs without a path
+	archivesDirectory = "volume_backups"
+)
+
+// ErrBackupInProgress is returned when a backup of the job is already running
+var ErrBackupInProgress = errors.New("a backup of the volume is already in progress")
+
+// Service schedules a job per volume backup job with an interval, and runs the backups and restorations
+type Service struct {
+	dataStore     dataservices.DataStore
+	scheduler     *scheduler.Scheduler
+	clientFactory *dockerclient.ClientFactory
+	dataPath      string
+
+	mu       sync.Mutex
+	jobQueue *jobs.Queue
+	jobs     map[portainer.VolumeBackupJobID]string
+	running  map[portainer.VolumeBackupJobID]bool
+}
+
+// NewService creates the volume backup service, the local archives are stored under the data path by default
+func NewService(dataStore dataservices.DataStore, scheduler *scheduler.Scheduler, clientFactory *dockerclient.ClientFactory, dataPath string) *Service {
+	return &Service{
+		dataStore:     dataStore,
+		scheduler:     scheduler,
+		clientFactory: clientFactory,
+		dataPath:      dataPath,
+		jobs:          map[portainer.VolumeBackupJobID]string{},
+		running:       map[portainer.VolumeBackupJobID]bool{},
+	}
+}
+
+// Start schedules the volume backup jobs stored in the database
+func (service *Service) Start() error {
+	jobs, err := service.dataStore.VolumeBackupJob().ReadAll()
+	if err != nil {
+		return errors.WithMessage(err, "unable to retrieve the volume backup jobs")
+	}
+
+	for i := range jobs {
+		if err := service.Schedule(&jobs[i]); err != nil {
+			log.Warn().Err(err).Int("job_id", int(jobs[i].ID)).Msg("unable to schedule the volume backup job")
+		}
+	}
+
+	return nil
+}
+
+// Schedule replaces the scheduled backups of the job with ones matching its interval, the jobs without an interval
+// are only run on demand
+func (service *Service) Schedule(job *portainer.VolumeBackupJob) error {
+	service.Unschedule(job.ID)
+
+	if job.Interval == "" {
+		return nil
+	}
+
+	interval, err := ParseInterval(job.Interval)
+	if err != nil {
+		return err
+	}
+
+	jobID := job.ID
+	endpointID := job.EndpointID
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	service.jobs[jobID] = service.scheduler.StartJobEvery(interval, func() error {
+		allowed, err := maintenance.Allowed(service.dataStore, endpointID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if !allowed {
+			log.Debug().Int("job_id", int(jobID)).Msg("outside of the maintenance windows of the environment, skipping the volume backup")
+			return nil
+		}
+
+		if queue := service.queue(); queue != nil {
+			_, err := queue.EnqueueOnce(BackupJobType, BackupJobPayload{JobID: jobID}, 0, fmt.Sprintf("Scheduled backup of the volume backup job %d", jobID))
+			return err
+		}
+
+		_, err = service.Backup(context.Background(), jobID)
+		if errors.Is(err, ErrBackupInProgress) {
+			return nil
+		}
+
+		return err
+	})
+
+	return nil
+}
+
+func (service *Service) queue() *jobs.Queue {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	return service.jobQueue
+}
+
+// Unschedule stops the scheduled backups of the job
+func (service *Service) Unschedule(jobID portainer.VolumeBackupJobID) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	scheduledID, ok := service.jobs[jobID]
+	if !ok {
+		return
+	}
+
+	delete(service.jobs, jobID)
+
+	if err := service.scheduler.StopJob(scheduledID); err != nil {
+		log.Warn().Err(err).Int("job_id", int(jobID)).Msg("unable to stop the volume backup job")
+	}
+}
+
+// Backup archives the volume of the job to its storage and prunes the archives exceeding its retention. The backup
+// is recorded in the history of the job, the error is returned when the record could not be created.
+func (service *Service) Backup(ctx context.Context, jobID portainer.VolumeBackupJobID) (*portainer.VolumeBackupRecord, error) {
+	job, err := service.dataStore.VolumeBackupJob().Read(jobID)
+	if dataservices.IsErrObjectNotFound(err) {
+		return nil, scheduler.NewPermanentError(errors.WithMessagef(err, "failed to get the volume backup job %d", jobID))
+	} else if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get the volume backup job %d", jobID)
+	}
+
+	if !service.acquire(jobID) {
+		return nil, ErrBackupInProgress
+	}
+	defer service.release(jobID)
+
+	now := time.Now()
+	record := &portainer.VolumeBackupRecord{
+		JobID:     job.ID,
+		Timestamp: now.Unix(),
+		Archive:   fmt.Sprintf("%s-%d-%s.tar.gz", job.VolumeName, job.ID, now.UTC().Format("20060102T150405Z")),
+	}
+
+	size, err := service.backup(ctx, job, record.Archive)
+	if err != nil {
+		log.Warn().Err(err).Int("job_id", int(job.ID)).Str("volume", job.VolumeName).Msg("unable to back up the volume")
+
+		record.Archive = ""
+		record.Error = err.Error()
+	} else {
+		record.Size = size
+		record.Success = true
+	}
+
+	if err := service.dataStore.VolumeBackupJob().CreateRecord(record); err != nil {
+		return nil, errors.WithMessage(err, "unable to record the volume backup")
+	}
+
+	job.LastBackup = record.Timestamp
+	if err := service.dataStore.VolumeBackupJob().Update(job.ID, job); err != nil {
+		log.Warn().Err(err).Int("job_id", int(job.ID)).Msg("unable to record the last backup of the volume backup job")
+	}
+
+	if record.Success {
+		service.prune(ctx, job)
+	}
+
+	return record, nil
+}
+
+func (service *Service) backup(ctx context.Context, job *portainer.VolumeBackupJob, archive string) (int64, error) {
+	storage, err := NewStorage(job.Target, service.defaultPath(job.ID))
+	if err != nil {
+		return 0, err
+	}
+
+	endpoint, err := service.dataStore.Endpoint().Endpoint(job.EndpointID)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "failed to find the environment %d", job.EndpointID)
+	}
+
+	cli, err := service.clientFactory.CreateClient(endpoint, job.NodeName, nil)
+	if err != nil {
+		return 0, errors.WithMessage(err, "unable to connect to the Docker environment")
+	}
+	defer cli.Close()
+
+	// the archive is buffered in a temporary file since the storages require its size
+	tmp, err := os.CreateTemp("", "volume-backup-*.tar.gz")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	jobs.ReportProgress(ctx, jobs.Progress{Stage: "archive", Message: fmt.Sprintf("Archiving the volume %s", job.VolumeName)})
+
+	if err := archiveVolume(ctx, cli, job.VolumeName, job.HelperImage, tmp); err != nil {
+		return 0, err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	upload := jobs.ProgressReader(ctx, tmp, "upload", fmt.Sprintf("Uploading the archive %s", archive), size)
+	if err := storage.Put(ctx, archive, upload, size); err != nil {
+		return 0, errors.WithMessage(err, "unable to store the volume archive")
+	}
+
+	return size, nil
+}
+
+// prune removes the archives of the successful backups exceeding the retention of the job, along with their record
+func (service *Service) prune(ctx context.Context, job *portainer.VolumeBackupJob) {
+	if job.Retention <= 0 {
+		return
+	}
+
+	records, err := service.dataStore.VolumeBackupJob().History(job.ID)
+	if err != nil {
+		log.Warn().Err(err).Int("job_id", int(job.ID)).Msg("unable to retrieve the volume backup history")
+		return
+	}
+
+	storage, err := NewStorage(job.Target, service.defaultPath(job.ID))
+	if err != nil {
+		return
+	}
+
+	kept := 0
+	for _, record := range records {
+		if !record.Success {
+			continue
+		}
+
+		kept++
+		if kept <= job.Retention {
+			continue
+		}
+
+		if err := storage.Delete(ctx, record.Archive); err != nil {
+			log.Warn().Err(err).Int("job_id", int(job.ID)).Str("archive", record.Archive).Msg("unable to remove the volume archive")
+			continue
+		}
+
+		if err := service.dataStore.VolumeBackupJob().DeleteRecord(record.ID); err != nil {
+			log.Warn().Err(err).Int("job_id", int(job.ID)).Int("record_id", int(record.ID)).Msg("unable to remove the volume backup record")
+		}
+	}
+}
+
+// Restore extracts the archive of a backup of the job into a volume of an environment, which can differ from the
+// backed up volume and environment. The volume is created when it does not exist.
+func (service *Service) Restore(ctx context.Context, job *portainer.VolumeBackupJob, record *portainer.VolumeBackupRecord, endpoint *portainer.Endpoint, nodeName, volumeName string) error {
+	if !record.Success || record.Archive == "" {
+		return errors.New("the backup has no archive")
+	}
+
+	storage, err := NewStorage(job.Target, service.defaultPath(job.ID))
+	if err != nil {
+		return err
+	}
+
+	archive, err := storage.Get(ctx, record.Archive)
+	if err != nil {
+		return errors.WithMessagef(err, "unable to open the volume archive %s", record.Archive)
+	}
+	defer archive.Close()
+
+	cli, err := service.clientFactory.CreateClient(endpoint, nodeName, nil)
+	if err != nil {
+		return errors.WithMessage(err, "unable to connect to the Docker environment")
+	}
+	defer cli.Close()
+
+	log.Info().
+		Int("job_id", int(job.ID)).
+		Str("archive", record.Archive).
+		Int("endpoint_id", int(endpoint.ID)).
+		Str("volume", volumeName).
+		Msg("restoring the volume")
+
+	return restoreVolume(ctx, cli, volumeName, job.HelperImage, archive)
+}
+
+func (service *Service) defaultPath(jobID portainer.VolumeBackupJobID) string {
+	return filepath.Join(service.dataPath, archivesDirectory, strconv.Itoa(int(jobID)))
+}
+
+func (service *Service) acquire(jobID portainer.VolumeBackupJobID) bool {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	if service.running[jobID] {
+		return false
+	}
+
+	service.running[jobID] = true
+
+	return true
+}
+
+func (service *Service) release(jobID portainer.VolumeBackupJobID) {
+	service.mu.Lock()
+	defer service.mu.Unlock()
+
+	delete(service.running, jobID)
+}
+
+// ParseInterval parses the interval of a volume backup job
+func ParseInterval(interval string) (time.Duration, error) {
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid backup interval %q", interval)
+	}
+
+	if d < minInterval {
+		return 0, fmt.Errorf("invalid backup interval %q, the minimum is %s", interval, minInterval)
+	}
+
+	return d, nil
+}
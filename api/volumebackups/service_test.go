@@ -0,0 +1,96 @@
+package volumebackups
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/datastore"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTarget(t *testing.T) {
+	is := assert.New(t)
+
+	is.NoError(ValidateTarget(portainer.VolumeBackupTarget{Type: portainer.VolumeBackupLocal}))
+	is.NoError(ValidateTarget(portainer.VolumeBackupTarget{Type: portainer.VolumeBackupLocal, Path: "/backups"}))
+	is.Error(ValidateTarget(portainer.VolumeBackupTarget{Type: portainer.VolumeBackupLocal, Path: "backups"}))
+	is.Error(ValidateTarget(portainer.VolumeBackupTarget{Type: portainer.VolumeBackupS3}))
+	is.Error(ValidateTarget(portainer.VolumeBackupTarget{Type: portainer.VolumeBackupS3, S3: &portainer.VolumeBackupS3Target{Bucket: "backups", Region: "eu-west-1"}}))
+	is.NoError(ValidateTarget(portainer.VolumeBackupTarget{Type: portainer.VolumeBackupS3, S3: &portainer.VolumeBackupS3Target{
+		Bucket: "backups", Region: "eu-west-1", AccessKeyID: "key", SecretAccessKey: "secret",
+	}}))
+	is.Error(ValidateTarget(portainer.VolumeBackupTarget{Type: "ftp"}))
+}
+
+func TestLocalStorage(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	dir := filepath.Join(t.TempDir(), "archives")
+	storage, err := NewStorage(portainer.VolumeBackupTarget{Type: portainer.VolumeBackupLocal}, dir)
+	is.NoError(err)
+
+	is.NoError(storage.Put(ctx, "data.tar.gz", strings.NewReader("archive"), 7))
+
+	archive, err := storage.Get(ctx, "data.tar.gz")
+	is.NoError(err)
+	content, _ := io.ReadAll(archive)
+	archive.Close()
+	is.Equal("archive", string(content))
+
+	entries, _ := os.ReadDir(dir)
+	is.Len(entries, 1, "the temporary file is renamed")
+
+	is.NoError(storage.Delete(ctx, "data.tar.gz"))
+	is.NoError(storage.Delete(ctx, "data.tar.gz"), "removing a missing archive succeeds")
+}
+
+func Test_prune(t *testing.T) {
+	is := assert.New(t)
+	ctx := context.Background()
+
+	_, store := datastore.MustNewTestStore(t, true, false)
+
+	job := &portainer.VolumeBackupJob{EndpointID: 1, VolumeName: "data", Target: portainer.VolumeBackupTarget{Type: portainer.VolumeBackupLocal}, Retention: 2}
+	is.NoError(store.VolumeBackupJob().Create(job))
+
+	service := NewService(store, nil, nil, t.TempDir())
+	storage, err := NewStorage(job.Target, service.defaultPath(job.ID))
+	is.NoError(err)
+
+	for _, name := range []string{"a", "b", "", "c"} {
+		record := &portainer.VolumeBackupRecord{JobID: job.ID, Archive: name, Success: name != ""}
+		if name != "" {
+			is.NoError(storage.Put(ctx, name, strings.NewReader(name), 1))
+		}
+		is.NoError(store.VolumeBackupJob().CreateRecord(record))
+	}
+
+	service.prune(ctx, job)
+
+	records, err := store.VolumeBackupJob().History(job.ID)
+	is.NoError(err)
+	is.Len(records, 3, "the failed backups are not pruned")
+	is.Equal("c", records[0].Archive)
+	is.Equal("b", records[2].Archive)
+
+	_, err = storage.Get(ctx, "a")
+	is.Error(err, "the oldest archive is removed")
+}
+
+func TestParseInterval(t *testing.T) {
+	is := assert.New(t)
+
+	d, err := ParseInterval("24h")
+	is.NoError(err)
+	is.Equal(24*60*60, int(d.Seconds()))
+
+	_, err = ParseInterval("1m")
+	is.Error(err)
+}
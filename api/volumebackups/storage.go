@@ -0,0 +1,140 @@
+package volumebackups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	portainer "github.com/portainer/portainer/api"
+	"github.com/portainer/portainer/api/aws/s3"
+)
+
+// Storage stores the volume archives of a volume backup job
+type Storage interface {
+	Put(ctx context.Context, name string, archive io.Reader, size int64) error
+	// Get opens an archive, it must be closed by the caller
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// ValidateTarget checks the storage of a volume backup job
+func ValidateTarget(target portainer.VolumeBackupTarget) error {
+	switch target.Type {
+	case portainer.VolumeBackupLocal:
+		if target.Path != "" && !filepath.IsAbs(target.Path) {
+			return fmt.Errorf("invalid archive directory %q, an absolute path is expected", target.Path)
+		}
+	case portainer.VolumeBackupS3:
+		if target.S3 == nil || target.S3.Bucket == "" || target.S3.Region == "" {
+			return errors.New("the S3 bucket and region are required")
+		}
+
+		if target.S3.AccessKeyID == "" || target.S3.SecretAccessKey == "" {
+			return errors.New("the S3 access key is required")
+		}
+	default:
+		return fmt.Errorf("invalid target type %q, local or s3 is expected", target.Type)
+	}
+
+	return nil
+}
+
+// NewStorage returns the storage of a volume backup job, the local archives are stored in the default directory
+// unless the target specifies one
+func NewStorage(target portainer.VolumeBackupTarget, defaultPath string) (Storage, error) {
+	if err := ValidateTarget(target); err != nil {
+		return nil, err
+	}
+
+	if target.Type == portainer.VolumeBackupS3 {
+		return &s3Storage{
+			prefix: target.S3.Prefix,
+			client: s3.NewClient(s3.Options{
+				Endpoint:        target.S3.Endpoint,
+				Region:          target.S3.Region,
+				Bucket:          target.S3.Bucket,
+				AccessKeyID:     target.S3.AccessKeyID,
+				SecretAccessKey: target.S3.SecretAccessKey,
+				ForcePathStyle:  target.S3.ForcePathStyle,
+			}),
+		}, nil
+	}
+
+	path := target.Path
+	if path == "" {
+		path = defaultPath
+	}
+
+	return &localStorage{path: path}, nil
+}
+
+type localStorage struct {
+	path string
+}
+
+func (storage *localStorage) Put(ctx context.Context, name string, archive io.Reader, size int64) error {
+	if err := os.MkdirAll(storage.path, 0700); err != nil {
+		return err
+	}
+
+	// the archive is renamed once complete, so that an interrupted backup does not leave a truncated archive
+	tmp, err := os.CreateTemp(storage.path, ".tmp-"+name)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, archive); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(storage.path, name))
+}
+
+func (storage *localStorage) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(storage.path, name))
+}
+
+func (storage *localStorage) Delete(ctx context.Context, name string) error {
+	err := os.Remove(filepath.Join(storage.path, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+type s3Storage struct {
+	client *s3.Client
+	prefix string
+}
+
+func (storage *s3Storage) key(name string) string {
+	if storage.prefix == "" {
+		return name
+	}
+
+	return strings.TrimSuffix(storage.prefix, "/") + "/" + name
+}
+
+func (storage *s3Storage) Put(ctx context.Context, name string, archive io.Reader, size int64) error {
+	return storage.client.PutObject(ctx, storage.key(name), archive, size)
+}
+
+func (storage *s3Storage) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return storage.client.GetObject(ctx, storage.key(name))
+}
+
+func (storage *s3Storage) Delete(ctx context.Context, name string) error {
+	return storage.client.DeleteObject(ctx, storage.key(name))
+}
@@ -0,0 +1,35 @@
+package volumebackups
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+
+	"github.com/portainer/portainer/api/docker"
+
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// archiveVolume writes a gzipped tar archive of the contents of a volume
+func archiveVolume(ctx context.Context, cli *client.Client, volumeName, helperImage string, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	if err := docker.ExportVolume(ctx, cli, volumeName, helperImage, gz); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+// restoreVolume extracts a gzipped tar archive created by archiveVolume into a volume, which is created when it
+// does not exist. The existing files of the volume are overwritten.
+func restoreVolume(ctx context.Context, cli *client.Client, volumeName, helperImage string, archive io.Reader) error {
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return errors.Wrap(err, "invalid volume archive")
+	}
+	defer gz.Close()
+
+	return docker.ImportVolume(ctx, cli, volume.CreateOptions{Name: volumeName}, helperImage, gz)
+}
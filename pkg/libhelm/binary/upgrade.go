@@ -0,0 +1,52 @@
+package binary
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/portainer/portainer/pkg/libhelm/options"
+	"github.com/portainer/portainer/pkg/libhelm/release"
+)
+
+var errRequiredUpgradeOptions = errors.New("release name and chart are required")
+
+// Upgrade runs `helm upgrade --install` with specified install options.
+// The install options translate to CLI arguments which are passed in to the helm binary when executing upgrade.
+func (hbpm *helmBinaryPackageManager) Upgrade(upgradeOpts options.InstallOptions) (*release.Release, error) {
+	if upgradeOpts.Name == "" || upgradeOpts.Chart == "" {
+		return nil, errRequiredUpgradeOptions
+	}
+
+	args := []string{
+		upgradeOpts.Name,
+		upgradeOpts.Chart,
+		"--install",
+		"--repo", upgradeOpts.Repo,
+		"--output", "json",
+	}
+	if upgradeOpts.Namespace != "" {
+		args = append(args, "--namespace", upgradeOpts.Namespace)
+	}
+	if upgradeOpts.ValuesFile != "" {
+		args = append(args, "--values", upgradeOpts.ValuesFile)
+	}
+	if upgradeOpts.Wait {
+		args = append(args, "--wait")
+	}
+	if upgradeOpts.PostRenderer != "" {
+		args = append(args, "--post-renderer", upgradeOpts.PostRenderer)
+	}
+
+	result, err := hbpm.runWithKubeConfig("upgrade", args, upgradeOpts.KubernetesClusterAccess, upgradeOpts.Env)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to run helm upgrade on specified args")
+	}
+
+	response := &release.Release{}
+	err = json.Unmarshal(result, &response)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal helm upgrade response to Release struct")
+	}
+
+	return response, nil
+}
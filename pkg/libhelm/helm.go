@@ -12,5 +12,6 @@ type HelmPackageManager interface {
 	Get(getOpts options.GetOptions) ([]byte, error)
 	List(listOpts options.ListOptions) ([]release.ReleaseElement, error)
 	Install(installOpts options.InstallOptions) (*release.Release, error)
+	Upgrade(upgradeOpts options.InstallOptions) (*release.Release, error)
 	Uninstall(uninstallOpts options.UninstallOptions) error
 }
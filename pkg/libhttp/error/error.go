@@ -6,6 +6,8 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/portainer/portainer/pkg/libhttp/request"
+
 	"github.com/rs/zerolog/log"
 )
 
@@ -16,6 +18,8 @@ type (
 	errorResponse struct {
 		Message string `json:"message,omitempty"`
 		Details string `json:"details,omitempty"`
+		// Identifier of the request, to correlate the error with the server logs
+		RequestID string `json:"requestId,omitempty"`
 	}
 )
 
@@ -31,12 +35,19 @@ func writeErrorResponse(rw http.ResponseWriter, err *HandlerError) {
 		err.Err = errors.New(err.Message)
 	}
 
-	log.Debug().CallerSkipFrame(2).Err(err.Err).Int("status_code", err.StatusCode).Str("msg", err.Message).Msg("HTTP error")
+	// the request ID is set on the response before the handlers are called
+	requestID := rw.Header().Get(request.RequestIDHeader)
+
+	event := log.Debug().CallerSkipFrame(2).Err(err.Err).Int("status_code", err.StatusCode).Str("msg", err.Message)
+	if requestID != "" {
+		event = event.Str("request_id", requestID)
+	}
+	event.Msg("HTTP error")
 
 	rw.Header().Set("Content-Type", "application/json")
 	rw.WriteHeader(err.StatusCode)
 
-	json.NewEncoder(rw).Encode(&errorResponse{Message: err.Message, Details: err.Err.Error()})
+	json.NewEncoder(rw).Encode(&errorResponse{Message: err.Message, Details: err.Err.Error(), RequestID: requestID})
 }
 
 // WriteError is a convenience function that creates a new HandlerError before calling writeErrorResponse.
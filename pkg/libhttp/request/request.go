@@ -24,6 +24,10 @@ const (
 	ErrMissingFormDataValue = "Missing form data value"
 )
 
+// RequestIDHeader is the header correlating an API call with the server logs, its error response and the requests
+// it causes to the environments
+const RequestIDHeader = "X-Request-ID"
+
 // RetrieveMultiPartFormFile returns the content of an uploaded file (form data) as bytes as well
 // as the name of the uploaded file.
 func RetrieveMultiPartFormFile(request *http.Request, requestParameter string) ([]byte, string, error) {
@@ -147,7 +147,7 @@ func (wrapper *PluginWrapper) command(command composeCommand, options libstack.O
 	log.Debug().
 		Str("command", program).
 		Strs("args", args).
-		Interface("env", cmd.Env).
+		Strs("env", envNames(cmd.Env)).
 		Msg("run command")
 
 	cmd.Stderr = &stderr
@@ -240,3 +240,14 @@ func (command *composeCommand) WithEnvFilePath(envFilePath string) {
 func (command *composeCommand) ToArgs() []string {
 	return append(command.globalArgs, command.subCommandAndArgs...)
 }
+
+// envNames returns the names of the environment variables, so that their values, which can be secrets, are not logged
+func envNames(env []string) []string {
+	names := make([]string, 0, len(env))
+	for _, variable := range env {
+		name, _, _ := strings.Cut(variable, "=")
+		names = append(names, name)
+	}
+
+	return names
+}